@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/migrate"
+	"starterkit/internal/platform/tlspolicy"
+
+	// Modules bring their own migrations
+	_ "starterkit/internal/modules"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// auditTailBatch is the number of audit entries fetched per query
+const auditTailBatch = 100
+
+// externalIDBatch is the most external ID mappings the server imports per
+// request
+const externalIDBatch = 1000
+
+// connect opens the database with the same configuration as the server
+func connect() (*pgxpool.Pool, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	policy, err := tlspolicy.New(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize TLS policy: %w", err)
+	}
+	return database.Connect(cfg.Database, policy)
+}
+
+func (a *app) migrate(ctx context.Context) error {
+	pool, err := connect()
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	if err := migrate.Up(ctx, pool, logger); err != nil {
+		return err
+	}
+	return a.out.message("database is up to date")
+}
+
+func (a *app) auditTail(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("audit tail", flag.ContinueOnError)
+	since := fs.Duration("since", 15*time.Minute, "start this far in the past")
+	follow := fs.Bool("follow", false, "keep polling for new entries")
+	interval := fs.Duration("interval", 2*time.Second, "poll interval with -follow")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	pool, err := connect()
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	queries := db.New(pool)
+
+	start := time.Now().Add(-*since)
+	cursor, err := audit.CursorSince(ctx, queries, start)
+	if err != nil {
+		return fmt.Errorf("failed to find the start of the audit log: %w", err)
+	}
+
+	columns := []string{"created_at", "actor", "action", "resource_type", "resource_id"}
+	for {
+		logs, err := queries.ListAuditLogsAfter(ctx, cursor.AfterParams(auditTailBatch))
+		if err != nil {
+			return fmt.Errorf("failed to list audit logs: %w", err)
+		}
+
+		for _, l := range logs {
+			cursor = audit.CursorOf(l)
+			if l.CreatedAt.Time.Before(start) {
+				continue
+			}
+			row := map[string]any{
+				"id":            uuid.UUID(l.ID.Bytes).String(),
+				"created_at":    l.CreatedAt.Time.Format(time.RFC3339),
+				"actor":         l.Actor,
+				"action":        l.Action,
+				"resource_type": l.ResourceType,
+				"resource_id":   l.ResourceID,
+				"metadata":      json.RawMessage(l.Metadata),
+			}
+			if err := a.out.stream(row, columns...); err != nil {
+				return err
+			}
+		}
+
+		if len(logs) == auditTailBatch {
+			continue
+		}
+		if !*follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(*interval):
+		}
+	}
+}
+
+func (a *app) retentionList(ctx context.Context) error {
+	var resp struct {
+		Policies []map[string]any `json:"policies"`
+	}
+	if err := a.client.do(ctx, http.MethodGet, "/admin/retention/policies", nil, nil, &resp); err != nil {
+		return err
+	}
+	return a.out.list(resp.Policies, "id", "name", "target", "action", "retention_days", "enabled", "last_run_at")
+}
+
+func (a *app) retentionRun(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("retention run", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report affected rows without changing data")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+
+	query := url.Values{"dry_run": {strconv.FormatBool(*dryRun)}}
+	var report map[string]any
+	if err := a.client.do(ctx, http.MethodPost, "/admin/retention/policies/"+url.PathEscape(fs.Arg(0))+"/run", query, nil, &report); err != nil {
+		return err
+	}
+	return a.out.object(report, "id", "policy_id", "dry_run", "cutoff", "affected_rows", "error", "started_at", "finished_at")
+}
+
+func (a *app) moderationQueue(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("moderation queue", flag.ContinueOnError)
+	status := fs.String("status", "pending", "flag status to list")
+	limit := fs.Int("limit", 50, "maximum flags to list")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	query := url.Values{"status": {*status}, "limit": {strconv.Itoa(*limit)}}
+	var resp struct {
+		Flags []map[string]any `json:"flags"`
+	}
+	if err := a.client.do(ctx, http.MethodGet, "/admin/moderation/queue", query, nil, &resp); err != nil {
+		return err
+	}
+	return a.out.list(resp.Flags, "id", "resource_type", "resource_id", "field", "reasons", "status", "created_at")
+}
+
+func (a *app) moderationResolve(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("moderation resolve", flag.ContinueOnError)
+	note := fs.String("note", "", "review note")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 2 {
+		return errUsage
+	}
+
+	body := map[string]string{"decision": fs.Arg(1), "note": *note}
+	var flagged map[string]any
+	if err := a.client.do(ctx, http.MethodPost, "/admin/moderation/queue/"+url.PathEscape(fs.Arg(0))+"/resolve", nil, body, &flagged); err != nil {
+		return err
+	}
+	return a.out.object(flagged, "id", "resource_type", "resource_id", "field", "status", "reviewed_by", "reviewed_at")
+}
+
+func (a *app) shadowBanList(ctx context.Context) error {
+	var resp struct {
+		ShadowBans []map[string]any `json:"shadow_bans"`
+	}
+	if err := a.client.do(ctx, http.MethodGet, "/admin/shadow-bans", nil, nil, &resp); err != nil {
+		return err
+	}
+	return a.out.list(resp.ShadowBans, "user_id", "email", "name", "reason", "banned_at")
+}
+
+func (a *app) shadowBanSet(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("shadow-bans set", flag.ContinueOnError)
+	reason := fs.String("reason", "", "reason recorded with the ban")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+
+	var ban map[string]any
+	if err := a.client.do(ctx, http.MethodPut, "/admin/users/"+url.PathEscape(fs.Arg(0))+"/shadow-ban", nil, map[string]string{"reason": *reason}, &ban); err != nil {
+		return err
+	}
+	return a.out.object(ban, "user_id", "email", "name", "reason", "banned_at")
+}
+
+func (a *app) shadowBanLift(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	if err := a.client.do(ctx, http.MethodDelete, "/admin/users/"+url.PathEscape(args[0])+"/shadow-ban", nil, nil, nil); err != nil {
+		return err
+	}
+	return a.out.message("lifted shadow ban for %s", args[0])
+}
+
+func (a *app) killSwitchList(ctx context.Context) error {
+	var resp struct {
+		KillSwitches []map[string]any `json:"kill_switches"`
+	}
+	if err := a.client.do(ctx, http.MethodGet, "/admin/kill-switches", nil, nil, &resp); err != nil {
+		return err
+	}
+	return a.out.list(resp.KillSwitches, "route", "reason", "source", "created_by", "created_at")
+}
+
+func (a *app) killSwitchSet(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("kill-switches set", flag.ContinueOnError)
+	reason := fs.String("reason", "", "reason the route answers 503 with")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 || *reason == "" {
+		return errUsage
+	}
+
+	var sw map[string]any
+	if err := a.client.do(ctx, http.MethodPut, "/admin/kill-switches", nil, map[string]string{"route": fs.Arg(0), "reason": *reason}, &sw); err != nil {
+		return err
+	}
+	return a.out.object(sw, "route", "reason", "source", "created_by", "created_at")
+}
+
+func (a *app) killSwitchClear(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	if err := a.client.do(ctx, http.MethodDelete, "/admin/kill-switches", url.Values{"route": {args[0]}}, nil, nil); err != nil {
+		return err
+	}
+	return a.out.message("switched %s back on", args[0])
+}
+
+func (a *app) externalIDsImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("external-ids import", flag.ContinueOnError)
+	system := fs.String("system", "", "system the IDs come from, such as legacy")
+	batch := fs.Int("batch", externalIDBatch, "mappings sent per request")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 || *system == "" || *batch < 1 || *batch > externalIDBatch {
+		return errUsage
+	}
+
+	mappings, err := readExternalIDs(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var imported, unchanged int
+	var rejected []map[string]any
+	for start := 0; start < len(mappings); start += *batch {
+		end := min(start+*batch, len(mappings))
+		var resp struct {
+			Imported  int              `json:"imported"`
+			Unchanged int              `json:"unchanged"`
+			Rejected  []map[string]any `json:"rejected"`
+		}
+		body := map[string]any{"system": *system, "mappings": mappings[start:end]}
+		if err := a.client.do(ctx, http.MethodPost, "/admin/external-ids", nil, body, &resp); err != nil {
+			return fmt.Errorf("mappings %d-%d: %w", start+1, end, err)
+		}
+		imported += resp.Imported
+		unchanged += resp.Unchanged
+		rejected = append(rejected, resp.Rejected...)
+	}
+
+	if len(rejected) > 0 {
+		if err := a.out.list(rejected, "external_id", "user_id", "reason", "mapped_to"); err != nil {
+			return err
+		}
+	}
+	totals := map[string]any{"system": *system, "imported": imported, "unchanged": unchanged, "rejected": len(rejected)}
+	return a.out.object(totals, "system", "imported", "unchanged", "rejected")
+}
+
+func (a *app) externalIDsRemove(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	path := "/admin/external-ids/" + url.PathEscape(args[0]) + "/" + url.PathEscape(args[1])
+	if err := a.client.do(ctx, http.MethodDelete, path, nil, nil, nil); err != nil {
+		return err
+	}
+	return a.out.message("removed %s ID %s", args[0], args[1])
+}
+
+// readExternalIDs reads external_id,user_id rows from a CSV file, skipping
+// a header row naming those columns
+func readExternalIDs(path string) ([]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+	var mappings []map[string]string
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if line == 1 && record[0] == "external_id" && record[1] == "user_id" {
+			continue
+		}
+		if _, err := uuid.Parse(record[1]); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid user ID %q", path, line, record[1])
+		}
+		mappings = append(mappings, map[string]string{"external_id": record[0], "user_id": record[1]})
+	}
+	if len(mappings) == 0 {
+		return nil, fmt.Errorf("%s has no mappings", path)
+	}
+	return mappings, nil
+}
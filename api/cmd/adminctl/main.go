@@ -0,0 +1,135 @@
+// Command adminctl runs common operations against the admin API, or
+// directly against the database for schema and audit tasks.
+//
+//	adminctl [-api URL] [-actor EMAIL] [-o table|json] <command> [flags] [args]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const usage = `Usage: adminctl [-api URL] [-actor EMAIL] [-o table|json] <command> [flags] [args]
+
+Database commands (use the server's configuration):
+  migrate                                   apply pending migrations
+  audit tail [-since 15m] [-follow]         print recent audit log entries
+
+Admin API commands:
+  retention list                            list retention policies
+  retention run [-dry-run] <policy-id>      execute a retention policy
+  moderation queue [-status pending]        list flagged content
+  moderation resolve [-note N] <flag-id> approve|remove
+  shadow-bans list                          list shadow-banned users
+  shadow-bans set [-reason R] <user-id>     shadow-ban a user
+  shadow-bans lift <user-id>                lift a shadow ban
+  kill-switches list                        list routes switched off
+  kill-switches set -reason R <route>       switch off a route, such as "POST /api/v1/links"
+  kill-switches clear <route>               switch a route back on
+  external-ids import -system S [-batch 1000] <file.csv>
+                                            map external_id,user_id rows to users
+  external-ids remove <system> <external-id>
+                                            remove an external ID mapping
+
+Flags:
+`
+
+// errUsage reports a malformed command line
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	apiURL := flag.String("api", envOr("ADMINCTL_API_URL", "http://localhost:8080"), "server base URL")
+	actor := flag.String("actor", envOr("ADMINCTL_ACTOR", os.Getenv("USER")+"@adminctl"), "actor recorded in the audit log")
+	output := flag.String("o", "table", "output format: table or json")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "adminctl: unsupported output format: %s\n", *output)
+		os.Exit(2)
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	app := &app{
+		client: newClient(*apiURL, *actor),
+		out:    newPrinter(os.Stdout, *output),
+	}
+	if err := app.run(ctx, flag.Args()); err != nil {
+		if errors.Is(err, errUsage) {
+			flag.Usage()
+			os.Exit(2)
+		}
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, "adminctl:", err)
+		os.Exit(1)
+	}
+}
+
+type app struct {
+	client *client
+	out    *printer
+}
+
+// run dispatches to the command named by the first one or two arguments
+func (a *app) run(ctx context.Context, args []string) error {
+	command, sub, rest := args[0], "", args[1:]
+	if len(rest) > 0 {
+		sub = rest[0]
+	}
+
+	switch {
+	case command == "migrate":
+		return a.migrate(ctx)
+	case command == "audit" && sub == "tail":
+		return a.auditTail(ctx, rest[1:])
+	case command == "retention" && sub == "list":
+		return a.retentionList(ctx)
+	case command == "retention" && sub == "run":
+		return a.retentionRun(ctx, rest[1:])
+	case command == "moderation" && sub == "queue":
+		return a.moderationQueue(ctx, rest[1:])
+	case command == "moderation" && sub == "resolve":
+		return a.moderationResolve(ctx, rest[1:])
+	case command == "shadow-bans" && sub == "list":
+		return a.shadowBanList(ctx)
+	case command == "shadow-bans" && sub == "set":
+		return a.shadowBanSet(ctx, rest[1:])
+	case command == "shadow-bans" && sub == "lift":
+		return a.shadowBanLift(ctx, rest[1:])
+	case command == "kill-switches" && sub == "list":
+		return a.killSwitchList(ctx)
+	case command == "kill-switches" && sub == "set":
+		return a.killSwitchSet(ctx, rest[1:])
+	case command == "kill-switches" && sub == "clear":
+		return a.killSwitchClear(ctx, rest[1:])
+	case command == "external-ids" && sub == "import":
+		return a.externalIDsImport(ctx, rest[1:])
+	case command == "external-ids" && sub == "remove":
+		return a.externalIDsRemove(ctx, rest[1:])
+	default:
+		return errUsage
+	}
+}
+
+func envOr(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
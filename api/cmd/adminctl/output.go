@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// printer renders command results as aligned tables or JSON
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func newPrinter(w io.Writer, format string) *printer {
+	return &printer{w: w, json: format == "json"}
+}
+
+// list prints rows with the given columns; JSON output keeps every field
+func (p *printer) list(rows []map[string]any, columns ...string) error {
+	if p.json {
+		return p.encode(rows)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = cell(row[column])
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// object prints a single result as field/value pairs
+func (p *printer) object(obj map[string]any, fields ...string) error {
+	if p.json {
+		return p.encode(obj)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	for _, field := range fields {
+		fmt.Fprintf(tw, "%s\t%s\n", field, cell(obj[field]))
+	}
+	return tw.Flush()
+}
+
+// stream prints one row of a follow-mode listing; JSON output is one object per line
+func (p *printer) stream(row map[string]any, columns ...string) error {
+	if p.json {
+		return json.NewEncoder(p.w).Encode(row)
+	}
+
+	cells := make([]string, len(columns))
+	for i, column := range columns {
+		cells[i] = cell(row[column])
+	}
+	_, err := fmt.Fprintln(p.w, strings.Join(cells, "  "))
+	return err
+}
+
+// message prints a confirmation for commands without a result body
+func (p *printer) message(format string, args ...any) error {
+	if p.json {
+		return p.encode(map[string]string{"message": fmt.Sprintf(format, args...)})
+	}
+	_, err := fmt.Fprintf(p.w, format+"\n", args...)
+	return err
+}
+
+func (p *printer) encode(v any) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// cell formats a decoded JSON value for a table column
+func cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		return v
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = cell(item)
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
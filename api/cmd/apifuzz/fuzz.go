@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"starterkit/internal/openapi"
+	"starterkit/internal/platform/apierror"
+)
+
+// maxResponseBody bounds how much of a response is read and checked
+const maxResponseBody = 8 << 20
+
+// fuzzer sends cases to the server under test and checks its responses
+type fuzzer struct {
+	baseURL  string
+	headers  []string
+	timeout  time.Duration
+	interval time.Duration
+	verbose  bool
+}
+
+// run sends every case and reports the failures, returning the exit code
+func (f *fuzzer) run(cases []openapi.FuzzCase) int {
+	// Test servers use self-signed certificates, and redirects are
+	// responses to check rather than follow
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	operations := make(map[string]bool)
+	failed := make(map[string]bool)
+	failures, limited := 0, 0
+	for i, c := range cases {
+		if i > 0 && f.interval > 0 {
+			time.Sleep(f.interval)
+		}
+		operations[c.Operation] = true
+		status, problem := f.send(client, c)
+		if status == http.StatusTooManyRequests {
+			limited++
+		}
+		if problem == "" {
+			if f.verbose {
+				fmt.Printf("ok   %d %s %s (%s): %s\n", status, c.Method, truncate(c.Path), c.Operation, c.Description)
+			}
+			continue
+		}
+		failures++
+		failed[c.Operation] = true
+		fmt.Printf("FAIL %s %s (%s): %s: %s\n", c.Method, truncate(c.Path), c.Operation, c.Description, problem)
+	}
+
+	fmt.Printf("%d requests to %d operations, %d failures in %d operations\n", len(cases), len(operations), failures, len(failed))
+	if limited > 0 {
+		fmt.Printf("%d requests were rate limited and tested only the rate limiter; lower -rate to reach the handlers\n", limited)
+	}
+	if failures > 0 {
+		return 1
+	}
+	return 0
+}
+
+// send makes the request of c and returns the status it was answered with,
+// and what is wrong with the response, if anything
+func (f *fuzzer) send(client *http.Client, c openapi.FuzzCase) (int, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, c.Method, f.baseURL+c.Path, bytes.NewReader(c.Body))
+	if err != nil {
+		return 0, "request could not be built: " + err.Error()
+	}
+	if c.ContentType != "" {
+		req.Header.Set("Content-Type", c.ContentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	for _, h := range f.headers {
+		name, value, _ := strings.Cut(h, ":")
+		req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return 0, fmt.Sprintf("no response within %s", f.timeout)
+		}
+		return 0, "request failed: " + err.Error()
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return resp.StatusCode, "failed to read response: " + err.Error()
+	}
+	return resp.StatusCode, check(c, resp, body)
+}
+
+// check returns what is wrong with a response, or "" when nothing is
+func check(c openapi.FuzzCase, resp *http.Response, body []byte) string {
+	status := resp.StatusCode
+	// Unavailable is how kill switches, read-only mode, and readiness
+	// answer on purpose
+	if status >= 500 && status != http.StatusServiceUnavailable {
+		return fmt.Sprintf("server error %d: %s", status, excerpt(body))
+	}
+	if status == http.StatusNoContent || status == http.StatusNotModified || (status >= 300 && status < 400) || c.Method == http.MethodHead {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if status >= 400 {
+		if mediaType != apierror.ContentType {
+			return fmt.Sprintf("%d answered with %q instead of problem details: %s", status, mediaType, excerpt(body))
+		}
+		var p apierror.Problem
+		if err := json.Unmarshal(body, &p); err != nil {
+			return fmt.Sprintf("%d answered with invalid problem details: %v: %s", status, err, excerpt(body))
+		}
+		if p.Status != status || p.Code == "" {
+			return fmt.Sprintf("%d answered with problem details of status %d and code %q", status, p.Status, p.Code)
+		}
+		return ""
+	}
+
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		if !json.Valid(body) {
+			return fmt.Sprintf("%d answered with invalid JSON: %s", status, excerpt(body))
+		}
+		return ""
+	}
+	// Operations may describe other bodies, such as CSV exports, or none
+	types, described := c.Responses[status]
+	if slices.Contains(types, mediaType) || (described && len(types) == 0 && len(body) == 0) {
+		return ""
+	}
+	return fmt.Sprintf("%d answered with %q, which the operation does not describe: %s", status, mediaType, excerpt(body))
+}
+
+// excerpt shortens a response body for reports
+func excerpt(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) > 200 {
+		s = s[:200] + "…"
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// truncate shortens paths with long parameters for reports
+func truncate(path string) string {
+	if len(path) > 120 {
+		return path[:120] + "…"
+	}
+	return path
+}
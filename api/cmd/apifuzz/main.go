@@ -0,0 +1,104 @@
+// Command apifuzz fuzzes every operation of the OpenAPI document against a
+// running test server, such as one started with server -dev. It sends
+// malformed bodies, values of the wrong type, and boundary values, and
+// fails when a response is a 5xx other than 503, or is not JSON: problem
+// details for failures, or a content type the operation describes. The
+// document is built like cmd/openapi builds it, or read from -spec. Write
+// operations change the server's data, so never point it at one that
+// matters.
+//
+//	apifuzz [-url URL] [-spec FILE] [-run REGEXP] [-H 'Name: value'] [-rate N] [-v]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/openapi"
+	"starterkit/internal/server"
+)
+
+// headerFlags collects repeated -H flags
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(v string) error {
+	name, _, ok := strings.Cut(v, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("header %q is not Name: value", v)
+	}
+	*h = append(*h, v)
+	return nil
+}
+
+func main() {
+	var headers headerFlags
+	baseURL := flag.String("url", "", "base URL of the server under test (default this environment's local server URL)")
+	spec := flag.String("spec", "", "OpenAPI document to read instead of building it")
+	run := flag.String("run", "", "only fuzz operations whose ID matches this regular expression")
+	rate := flag.Float64("rate", 0, "requests per second, to stay under rate limits (0 for no limit)")
+	timeout := flag.Duration("timeout", 10*time.Second, "how long to wait for each response")
+	verbose := flag.Bool("v", false, "print every case, not only failures")
+	flag.Var(&headers, "H", "header sent with every request, such as 'Authorization: Bearer TOKEN'; repeatable")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "apifuzz: failed to load configuration:", err)
+		os.Exit(1)
+	}
+	if *baseURL == "" {
+		*baseURL = cfg.Server.LocalURL()
+	}
+	var filter *regexp.Regexp
+	if *run != "" {
+		if filter, err = regexp.Compile(*run); err != nil {
+			fmt.Fprintln(os.Stderr, "apifuzz: invalid -run:", err)
+			os.Exit(2)
+		}
+	}
+
+	doc, err := document(cfg, *spec)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "apifuzz:", err)
+		os.Exit(1)
+	}
+
+	f := &fuzzer{
+		baseURL: strings.TrimSuffix(*baseURL, "/"),
+		headers: headers,
+		timeout: *timeout,
+		verbose: *verbose,
+	}
+	if *rate > 0 {
+		f.interval = time.Duration(float64(time.Second) / *rate)
+	}
+	var cases []openapi.FuzzCase
+	for _, c := range doc.FuzzCases() {
+		if filter == nil || filter.MatchString(c.Operation) {
+			cases = append(cases, c)
+		}
+	}
+	os.Exit(f.run(cases))
+}
+
+// document builds the OpenAPI document with this environment's
+// configuration, or reads it from spec
+func document(cfg *config.Config, spec string) (*openapi.Document, error) {
+	if spec == "" {
+		return server.APIDocument(cfg)
+	}
+	data, err := os.ReadFile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", spec, err)
+	}
+	return openapi.Parse(data)
+}
@@ -0,0 +1,174 @@
+// Command backup creates, lists, prunes, and restores logical database
+// backups in the configured object storage.
+//
+//	backup run                  create a backup and prune expired ones
+//	backup list                 show the backup catalog
+//	backup prune                delete backups past retention
+//	backup restore <key|latest> load a backup with PII anonymized
+//	backup refresh              clone the staging refresh source with PII anonymized
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/backup"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/storage"
+	"starterkit/internal/platform/telemetry"
+	"starterkit/internal/platform/tlspolicy"
+)
+
+const usage = `Usage:
+  backup [-actor EMAIL] run
+  backup [-actor EMAIL] list
+  backup [-actor EMAIL] prune
+  backup [-actor EMAIL] restore <object-key|latest>
+  backup [-actor EMAIL] refresh
+
+Restore and refresh replace all table contents in the configured database
+and anonymize PII; both are refused when APP_ENV=prod. Restore loads a
+stored backup: run "backup list" against production to pick a key, then
+restore with staging's database settings and the same storage backend.
+Refresh clones STAGING_REFRESH_SOURCE_DSN directly.
+`
+
+func main() {
+	actor := flag.String("actor", os.Getenv("USER")+"@backup", "actor recorded in the audit log")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	command := flag.Arg(0)
+	if command != "run" && command != "list" && command != "prune" && command != "refresh" && !(command == "restore" && flag.NArg() == 2) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(command, flag.Arg(1), *actor); err != nil {
+		fmt.Fprintln(os.Stderr, "backup:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command, target, actor string) (err error) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if (command == "restore" || command == "refresh") && cfg.Service.Environment == config.EnvProd {
+		return fmt.Errorf("%s is disabled when APP_ENV=prod", command)
+	}
+	if command == "refresh" && cfg.Refresh.SourceDSN == "" {
+		return errors.New("STAGING_REFRESH_SOURCE_DSN is required for refresh")
+	}
+
+	tlsPolicy, err := tlspolicy.New(cfg.TLS)
+	if err != nil {
+		return fmt.Errorf("failed to initialize TLS policy: %w", err)
+	}
+	tlsPolicy.InstallDefaultTransport()
+
+	// The run exits before any scrape, so its outcome is pushed as it ends
+	var otlpTLS *tls.Config
+	if !cfg.Telemetry.OTLPInsecure {
+		otlpTLS = tlsPolicy.Config()
+	}
+	ctx, batch, err := telemetry.StartBatch(context.Background(), "backup "+command, telemetry.BatchConfig(cfg, "backup", otlpTLS))
+	if err != nil {
+		return fmt.Errorf("failed to initialize telemetry: %w", err)
+	}
+	defer func() { batch.End(err) }()
+
+	pool, err := database.Connect(cfg.Database, tlsPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	queries := db.New(pool)
+	service := backup.NewService(pool, queries, store, audit.NewService(queries), logger, cfg.Backup.RetainCount, cfg.Backup.RetainFor)
+
+	switch command {
+	case "run":
+		b, err := service.Create(ctx, actor)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created %s (%d tables, %d rows, %d bytes)\n", b.ObjectKey, b.TableCount, b.RowCount, b.SizeBytes)
+		return prune(ctx, service, actor)
+
+	case "list":
+		backups, err := service.List(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "CREATED\tKEY\tSCHEMA\tTABLES\tROWS\tBYTES")
+		for _, b := range backups {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\n", b.CreatedAt.Format(time.RFC3339), b.ObjectKey, b.SchemaVersion, b.TableCount, b.RowCount, b.SizeBytes)
+		}
+		return tw.Flush()
+
+	case "prune":
+		return prune(ctx, service, actor)
+
+	case "restore":
+		key := target
+		if key == "latest" {
+			latest, err := service.Latest(ctx)
+			if err != nil {
+				return err
+			}
+			key = latest.ObjectKey
+		}
+		logger.Info("restoring backup", "key", key, "database", cfg.Database.Host+"/"+cfg.Database.Database)
+		result, err := service.Restore(ctx, key, actor)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("restored %s (%d tables, %d rows) with PII anonymized\n", result.ObjectKey, result.Tables, result.Rows)
+
+	case "refresh":
+		result, err := service.RefreshFrom(ctx, cfg.Refresh.SourceDSN, actor)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("refreshed from source (%d tables, %d rows) with PII anonymized\n", result.Tables, result.Rows)
+	}
+	return nil
+}
+
+func prune(ctx context.Context, service *backup.Service, actor string) error {
+	deleted, err := service.Prune(ctx, actor)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("pruned %d expired backups\n", deleted)
+	return nil
+}
@@ -0,0 +1,110 @@
+// Command embedder inspects and runs the embedding of user profiles for
+// semantic search.
+//
+//	embedder status
+//	embedder run
+//	embedder reembed
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"starterkit/internal/ai"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/tlspolicy"
+	"starterkit/internal/search"
+)
+
+const usage = `Usage:
+  embedder status
+  embedder run
+  embedder reembed
+
+Run embeds every profile that is new, changed, or embedded by a model other
+than the configured one; the server's embeddings job does the same on each
+tick. Reembed first clears every embedding, for when a provider changes its
+vectors without changing the model name. Profiles drop out of semantic
+search until they are embedded again.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	command := flag.Arg(0)
+	if command != "status" && command != "run" && command != "reembed" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(command); err != nil {
+		fmt.Fprintln(os.Stderr, "embedder:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command string) error {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	tlsPolicy, err := tlspolicy.New(cfg.TLS)
+	if err != nil {
+		return fmt.Errorf("failed to initialize TLS policy: %w", err)
+	}
+	tlsPolicy.InstallDefaultTransport()
+
+	embedder, err := ai.NewEmbedder(cfg.Embeddings, cfg.Connectors)
+	if err != nil {
+		return fmt.Errorf("failed to initialize embeddings provider: %w", err)
+	}
+
+	pool, err := database.Connect(cfg.Database, tlsPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	indexer := search.NewIndexer(embedder, db.New(pool), cfg.Embeddings.BatchSize, cfg.Embeddings.MaxChars, logger)
+
+	switch command {
+	case "reembed":
+		count, err := indexer.Reset(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println("cleared", count, "embeddings")
+		if err := indexer.Run(ctx); err != nil {
+			return err
+		}
+
+	case "run":
+		if err := indexer.Run(ctx); err != nil {
+			return err
+		}
+	}
+
+	status, err := indexer.Status(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tPROFILES\tEMBEDDED\tPENDING")
+	fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", status.Model, status.Total, status.Total-status.Pending, status.Pending)
+	return tw.Flush()
+}
@@ -0,0 +1,139 @@
+// Command migrate applies and inspects the database migrations embedded in
+// the binary, and creates new migration files.
+//
+//	migrate up            apply pending migrations
+//	migrate down          roll back the last applied migration
+//	migrate status        list migrations and when they were applied
+//	migrate create <name> add an empty migration to db/migrations, or one
+//	                      creating the table given with -table
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/migrate"
+	"starterkit/internal/platform/telemetry"
+	"starterkit/internal/platform/tlspolicy"
+
+	// Modules bring their own migrations
+	_ "starterkit/internal/modules"
+)
+
+const usage = `Usage:
+  migrate up
+  migrate down
+  migrate status
+  migrate [-dir DIR] [-table TABLE] create <name>
+
+Up, down, and status connect with the server's database configuration and
+use the migrations built into the binary. Create writes a new file to the
+source tree; rebuild before applying it. With -table it creates that table,
+keyed by a time-ordered UUID.
+`
+
+func main() {
+	dir := flag.String("dir", "db/migrations", "migrations directory for create")
+	table := flag.String("table", "", "table for create to add")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	command := flag.Arg(0)
+	if command != "up" && command != "down" && command != "status" && !(command == "create" && flag.NArg() == 2) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(command, flag.Arg(1), *dir, *table); err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command, name, dir, table string) (err error) {
+	if command == "create" {
+		path, err := migrate.Create(dir, name, table)
+		if err != nil {
+			return err
+		}
+		fmt.Println("created", path)
+		return nil
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	tlsPolicy, err := tlspolicy.New(cfg.TLS)
+	if err != nil {
+		return fmt.Errorf("failed to initialize TLS policy: %w", err)
+	}
+
+	// The run exits before any scrape, so its outcome is pushed as it ends
+	var otlpTLS *tls.Config
+	if !cfg.Telemetry.OTLPInsecure {
+		otlpTLS = tlsPolicy.Config()
+	}
+	ctx, batch, err := telemetry.StartBatch(context.Background(), "migrate "+command, telemetry.BatchConfig(cfg, "migrate", otlpTLS))
+	if err != nil {
+		return fmt.Errorf("failed to initialize telemetry: %w", err)
+	}
+	defer func() { batch.End(err) }()
+
+	pool, err := database.Connect(cfg.Database, tlsPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch command {
+	case "up":
+		if err := migrate.Up(ctx, pool, logger); err != nil {
+			return err
+		}
+		fmt.Println("database is up to date")
+		return nil
+
+	case "down":
+		return migrate.Down(ctx, pool, logger)
+
+	default:
+		migrations, err := migrate.Status(ctx, pool)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "MODULE\tVERSION\tAPPLIED\tNAME")
+		for _, m := range migrations {
+			applied := "pending"
+			if m.Applied {
+				applied = m.AppliedAt.Local().Format(time.DateTime)
+			}
+			module := m.Module
+			if module == "" {
+				module = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", module, m.Version, applied, m.Name)
+		}
+		return tw.Flush()
+	}
+}
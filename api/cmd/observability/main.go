@@ -0,0 +1,66 @@
+// Command observability exports Grafana dashboards and Prometheus alert
+// rules generated from the metrics registry, so they always match the
+// metric names and labels the server emits. Business metrics get a
+// dashboard of their own for product teams.
+//
+//	observability export [-dir DIR] [-title TITLE]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"starterkit/internal/platform/metrics"
+)
+
+const usage = `Usage:
+  observability export [-dir DIR] [-title TITLE]
+
+Writes DIR/dashboard.json and DIR/business-dashboard.json (Grafana) and
+DIR/alerts.yml (Prometheus rules).
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.Arg(0) != "export" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := export(flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "observability:", err)
+		os.Exit(1)
+	}
+}
+
+func export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dir := fs.String("dir", "observability", "output directory")
+	title := fs.String("title", "Starterkit API", "dashboard title")
+	fs.Parse(args)
+
+	dashboard, err := metrics.Dashboard(*title, metrics.All)
+	if err != nil {
+		return fmt.Errorf("failed to render dashboard: %w", err)
+	}
+	business, err := metrics.Dashboard(*title+" Business", metrics.Business)
+	if err != nil {
+		return fmt.Errorf("failed to render business dashboard: %w", err)
+	}
+	rules := metrics.AlertRules(*title, metrics.All)
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	for name, data := range map[string][]byte{"dashboard.json": dashboard, "business-dashboard.json": business, "alerts.yml": rules} {
+		path := filepath.Join(*dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Println("wrote", path)
+	}
+	return nil
+}
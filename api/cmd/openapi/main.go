@@ -0,0 +1,53 @@
+// Command openapi writes the OpenAPI document the server serves at
+// /api/v1/openapi.json, for generating clients without running the server.
+// Operations need the scopes their routes declare with the configuration
+// of this environment.
+//
+//	openapi [-o FILE]
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"starterkit/internal/config"
+	"starterkit/internal/server"
+)
+
+func main() {
+	out := flag.String("o", "", "output file (default stdout)")
+	flag.Parse()
+
+	if err := run(*out); err != nil {
+		fmt.Fprintln(os.Stderr, "openapi:", err)
+		os.Exit(1)
+	}
+}
+
+func run(out string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	doc, err := server.APIDocument(cfg)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, doc.JSON(), "", "  "); err != nil {
+		return fmt.Errorf("failed to format document: %w", err)
+	}
+	buf.WriteByte('\n')
+
+	if out == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(out, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	return nil
+}
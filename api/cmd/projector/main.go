@@ -0,0 +1,115 @@
+// Command projector inspects, catches up, and rebuilds the read-model
+// projections the server maintains from the user change log.
+//
+//	projector status
+//	projector run
+//	projector rebuild <name|all>
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/tlspolicy"
+	"starterkit/internal/projections"
+)
+
+const usage = `Usage:
+  projector status
+  projector run
+  projector rebuild <name|all>
+
+Rebuild repopulates a projection's read table from the source tables and
+moves its checkpoint to the end of the change log. It holds the
+projection's checkpoint lock, so the server's projection job waits for it.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	command := flag.Arg(0)
+	if command != "status" && command != "run" && !(command == "rebuild" && flag.NArg() == 2) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(command, flag.Arg(1)); err != nil {
+		fmt.Fprintln(os.Stderr, "projector:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command, target string) error {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	tlsPolicy, err := tlspolicy.New(cfg.TLS)
+	if err != nil {
+		return fmt.Errorf("failed to initialize TLS policy: %w", err)
+	}
+	tlsPolicy.InstallDefaultTransport()
+
+	pool, err := database.Connect(cfg.Database, tlsPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	projector := projections.NewProjector(pool, db.New(pool), cfg.Projection.BatchSize, logger, projections.UserSearch{}, projections.UserSummaries{}, projections.UserEmbeddings{})
+
+	switch command {
+	case "run":
+		if err := projector.Run(ctx); err != nil {
+			return err
+		}
+
+	case "rebuild":
+		names := []string{target}
+		if target == "all" {
+			names = projector.Names()
+		}
+		for _, name := range names {
+			if err := projector.Rebuild(ctx, name); err != nil {
+				if errors.Is(err, projections.ErrProjectionNotFound) {
+					return fmt.Errorf("unknown projection %q; run 'projector status' to see projections", name)
+				}
+				return err
+			}
+			fmt.Println("rebuilt", name)
+		}
+	}
+
+	statuses, err := projector.Status(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tPOSITION\tLATEST\tLAG\tUPDATED")
+	for _, s := range statuses {
+		updated := "never"
+		if !s.UpdatedAt.IsZero() {
+			updated = s.UpdatedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", s.Name, s.Position, s.Latest, s.Lag, updated)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"starterkit/internal/config"
+	"starterkit/internal/server"
+)
+
+// printAccessMatrix writes the roles and scopes of this environment with
+// the routes each may reach as JSON, for access reviews
+func printAccessMatrix() int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "access-matrix: failed to load configuration:", err)
+		return 1
+	}
+	matrix, err := server.BuildAccessMatrix(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "access-matrix:", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(matrix); err != nil {
+		fmt.Fprintln(os.Stderr, "access-matrix:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"starterkit/internal/config"
+)
+
+// printConfig writes the effective configuration as JSON, with secrets
+// masked and the source of every variable that was read
+func printConfig() int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "config: failed to load configuration:", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(cfg.Dump()); err != nil {
+		fmt.Fprintln(os.Stderr, "config:", err)
+		return 1
+	}
+	return 0
+}
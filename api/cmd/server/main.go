@@ -2,28 +2,59 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"flag"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"starterkit/internal/config"
-	"starterkit/internal/db"
-	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/lifecycle"
+	applog "starterkit/internal/platform/logger"
 	"starterkit/internal/platform/telemetry"
+	"starterkit/internal/platform/tlspolicy"
+	"starterkit/internal/platform/wiring"
 	"starterkit/internal/server"
 )
 
 func main() {
-	// Initialize structured logger
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	devMode := flag.Bool("dev", false, "run with dev defaults, a throwaway Postgres container if none is reachable, migrations, and demo data")
+	mockMode := flag.Bool("mock", false, "serve the examples in the OpenAPI document with dev defaults and no database, instead of running the API")
+	flag.Parse()
+
+	// server healthcheck probes a running server, server preflight checks
+	// the environment before a deploy, server config prints the effective
+	// configuration, server routes the route table, and server
+	// access-matrix the access matrix, instead of starting a server
+	switch flag.Arg(0) {
+	case "healthcheck":
+		os.Exit(healthcheck(flag.Args()[1:]))
+	case "preflight":
+		os.Exit(preflight(flag.Args()[1:]))
+	case "config":
+		os.Exit(printConfig())
+	case "routes":
+		os.Exit(printRoutes(flag.Args()[1:]))
+	case "access-matrix":
+		os.Exit(printAccessMatrix())
+	}
+
+	// Initialize structured logger, correlating records with the request
+	// and trace they are logged in
+	logger := slog.New(applog.NewHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
-	}))
+	})))
 	slog.SetDefault(logger)
 
+	// Dev and mock mode imply the dev environment defaults (text logs,
+	// permissive CORS)
+	if *devMode || *mockMode {
+		os.Setenv("APP_ENV", config.EnvDev)
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -31,27 +62,87 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Switch to the environment's log format
+	if cfg.Service.LogFormat == "text" {
+		logger = slog.New(applog.NewHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+			Level: slog.LevelInfo,
+		})))
+		slog.SetDefault(logger)
+	}
+	logger.Info("configuration loaded", "environment", cfg.Service.Environment)
+
+	if *mockMode {
+		os.Exit(serveMock(cfg, logger))
+	}
+
+	// Initialize TLS policy before any connection is made
+	tlsPolicy, err := tlspolicy.New(cfg.TLS)
+	if err != nil {
+		logger.Error("failed to initialize TLS policy", "error", err)
+		os.Exit(1)
+	}
+	tlsPolicy.InstallDefaultTransport()
+	if tlsPolicy.Profile() == "fips" && !tlsPolicy.FIPSMode() {
+		logger.Warn("TLS profile is fips but Go FIPS 140-3 mode is off; set GODEBUG=fips140=on")
+	}
+
 	// Initialize telemetry
-	shutdown, err := telemetry.Init(context.Background(), cfg.Service.Name, cfg.Service.Version)
+	var otlpTLS *tls.Config
+	if !cfg.Telemetry.OTLPInsecure {
+		otlpTLS = tlsPolicy.Config()
+	}
+	res, err := telemetry.NewResource(context.Background(), cfg.Service.Name, cfg.Service.Version, cfg.Service.Environment, cfg.Telemetry.ResourceDetectors)
+	if err != nil {
+		logger.Error("failed to detect telemetry resource", "error", err)
+		os.Exit(1)
+	}
+	// Startup and shutdown hooks. Telemetry and the components stop after
+	// the hooks modules register, which may use them.
+	hooks := lifecycle.New(logger)
+	shutdown, err := telemetry.Init(context.Background(), res, cfg.Telemetry.OTLPEndpoint, otlpTLS, cfg.Telemetry.SampleRatio)
 	if err != nil {
 		logger.Error("failed to initialize telemetry", "error", err)
 		os.Exit(1)
 	}
-	defer shutdown()
-
-	// Initialize database connection
-	dbPool, err := database.Connect(cfg.Database)
+	hooks.Append(lifecycle.Hook{Name: "traces", Order: lifecycle.OrderTelemetry, OnStop: func(context.Context) error {
+		shutdown()
+		return nil
+	}})
+	metricsHandler, shutdownMetrics, err := telemetry.InitMetrics(context.Background(), res, cfg.Telemetry.MetricsExporters, cfg.Telemetry.OTLPEndpoint, otlpTLS, cfg.Telemetry.MetricsInterval)
 	if err != nil {
-		logger.Error("failed to connect to database", "error", err)
+		logger.Error("failed to initialize metrics", "error", err)
 		os.Exit(1)
 	}
-	defer dbPool.Close()
+	hooks.Append(lifecycle.Hook{Name: "metrics", Order: lifecycle.OrderTelemetry, OnStop: func(context.Context) error {
+		shutdownMetrics()
+		return nil
+	}})
+
+	// Assemble the server. Components are built in dependency order as
+	// they are first needed and stopped in reverse once the server is down.
+	c := wiring.New()
+	wiring.Supply(c, cfg)
+	wiring.Supply(c, logger)
+	wiring.Supply(c, tlsPolicy)
+	wiring.Supply(c, hooks)
+	wiring.Supply(c, server.MetricsHandler(metricsHandler))
+	provideInfrastructure(c, *devMode)
+	hooks.Append(lifecycle.Hook{Name: "components", Order: lifecycle.OrderComponents, OnStop: c.Close})
 
-	// Initialize sqlc queries
-	queries := db.New(dbPool)
+	srv, err := server.New(c)
+	if err != nil {
+		logger.Error("failed to initialize server", "error", err)
+		c.Close(context.Background())
+		os.Exit(1)
+	}
 
-	// Initialize server
-	srv := server.New(cfg, logger, queries)
+	startCtx, cancelStart := context.WithTimeout(context.Background(), cfg.Server.StartupTimeout)
+	err = hooks.Start(startCtx)
+	cancelStart()
+	if err != nil {
+		logger.Error("failed to start", "error", err)
+		os.Exit(1)
+	}
 
 	// Start server in a goroutine
 	go func() {
@@ -69,11 +160,19 @@ func main() {
 	logger.Info("shutting down server...")
 
 	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Error("server forced to shutdown", "error", err)
+	shutdownErr := srv.Shutdown(ctx)
+	if shutdownErr != nil {
+		logger.Error("server forced to shutdown", "error", shutdownErr)
+	}
+	// Hooks stop once requests and background work have, each within its
+	// own timeout
+	if err := hooks.Stop(context.Background()); err != nil {
+		logger.Error("failed to stop", "error", err)
+	}
+	if shutdownErr != nil {
 		os.Exit(1)
 	}
 
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"syscall"
+
+	"starterkit/internal/config"
+	"starterkit/internal/server"
+)
+
+// serveMock answers API requests from the examples in the OpenAPI document
+// until interrupted, connecting to nothing
+func serveMock(cfg *config.Config, logger *slog.Logger) int {
+	handler, err := server.Mock(cfg, logger)
+	if err != nil {
+		logger.Error("failed to initialize mock server", "error", err)
+		return 1
+	}
+	srv := &http.Server{
+		Addr:              cfg.Server.Address,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.Server.ReadTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("serving API examples", "address", cfg.Server.Address)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("mock server error", "error", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/mailer"
+	"starterkit/internal/platform/migrate"
+	"starterkit/internal/platform/storage"
+	"starterkit/internal/platform/tlspolicy"
+
+	// Modules bring their own migrations
+	_ "starterkit/internal/modules"
+)
+
+// Statuses of a preflight check
+const (
+	preflightPass = "pass"
+	preflightWarn = "warn"
+	preflightFail = "fail"
+	preflightSkip = "skip"
+)
+
+// preflightReport is what preflight prints, for deploy pipelines to gate on
+type preflightReport struct {
+	OK        bool             `json:"ok"`
+	CheckedAt time.Time        `json:"checked_at"`
+	Checks    []preflightCheck `json:"checks"`
+}
+
+type preflightCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// errSkipped marks a check that does not apply to the configuration; its
+// message is the reason
+type errSkipped string
+
+func (e errSkipped) Error() string { return string(e) }
+
+// errWarning marks a check that passed with something to look at
+type errWarning string
+
+func (e errWarning) Error() string { return string(e) }
+
+// preflight checks that the environment can run the server before it is
+// deployed: the configuration loads, the database is reachable and
+// migrated, the secrets enabled features need are set, the OTLP collector
+// accepts connections, storage is writable, and email providers accept the
+// credentials. It prints a JSON report and exits 0 when no check failed
+// and 1 otherwise.
+func preflight(args []string) int {
+	fs := flag.NewFlagSet("preflight", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 10*time.Second, "how long each check may take")
+	skip := fs.String("skip", "", "comma-separated checks to skip: database, migrations, secrets, otlp, storage, email")
+	fs.Parse(args)
+
+	// Only the report goes to stdout
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	slog.SetDefault(logger)
+
+	report := preflightReport{OK: true, CheckedAt: time.Now().UTC()}
+	run := func(name string, fn func(ctx context.Context) error) {
+		check := preflightCheck{Name: name}
+		start := time.Now()
+		if slices.Contains(strings.Split(*skip, ","), name) {
+			check.Status, check.Detail = preflightSkip, "skipped with -skip"
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+			err := fn(ctx)
+			cancel()
+			var skipped errSkipped
+			var warning errWarning
+			switch {
+			case err == nil:
+				check.Status = preflightPass
+			case errors.As(err, &skipped):
+				check.Status, check.Detail = preflightSkip, err.Error()
+			case errors.As(err, &warning):
+				check.Status, check.Detail = preflightWarn, err.Error()
+			default:
+				check.Status, check.Detail = preflightFail, err.Error()
+				report.OK = false
+			}
+		}
+		check.DurationMS = time.Since(start).Milliseconds()
+		report.Checks = append(report.Checks, check)
+	}
+
+	var cfg *config.Config
+	var policy *tlspolicy.Policy
+	run("config", func(context.Context) error {
+		var err error
+		if cfg, err = config.Load(); err != nil {
+			return err
+		}
+		policy, err = tlspolicy.New(cfg.TLS)
+		return err
+	})
+	if !report.OK {
+		return printPreflight(report)
+	}
+	policy.InstallDefaultTransport()
+
+	var pool *pgxpool.Pool
+	run("database", func(ctx context.Context) error {
+		p, err := database.Open(cfg.Database, policy)
+		if err != nil {
+			return err
+		}
+		if err := p.Ping(ctx); err != nil {
+			p.Close()
+			return fmt.Errorf("failed to ping database: %w", err)
+		}
+		pool = p
+		return nil
+	})
+	if pool != nil {
+		defer pool.Close()
+	}
+	run("migrations", func(ctx context.Context) error {
+		return checkMigrations(ctx, pool, cfg.Database.AutoMigrate)
+	})
+	run("secrets", func(context.Context) error {
+		if missing := cfg.MissingSecrets(); len(missing) > 0 {
+			return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+		}
+		return nil
+	})
+	run("otlp", func(ctx context.Context) error {
+		var tlsConfig *tls.Config
+		if !cfg.Telemetry.OTLPInsecure {
+			tlsConfig = policy.Config()
+		}
+		return checkOTLP(ctx, cfg.Telemetry.OTLPEndpoint, tlsConfig)
+	})
+	run("storage", func(ctx context.Context) error {
+		return checkStorage(ctx, cfg.Storage)
+	})
+	run("email", func(ctx context.Context) error {
+		return checkEmail(ctx, cfg, logger)
+	})
+	return printPreflight(report)
+}
+
+func printPreflight(report preflightReport) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, "preflight:", err)
+		return 1
+	}
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
+
+// checkMigrations fails when migrations are pending and the server will
+// not apply them at startup
+func checkMigrations(ctx context.Context, pool *pgxpool.Pool, autoMigrate bool) error {
+	if pool == nil {
+		return errSkipped("no database connection")
+	}
+	migrations, err := migrate.Status(ctx, pool)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, m := range migrations {
+		if !m.Applied {
+			name := m.Name
+			if m.Module != "" {
+				name = m.Module + "/" + name
+			}
+			pending = append(pending, name)
+		}
+	}
+	switch {
+	case len(pending) == 0:
+		return nil
+	case autoMigrate:
+		return errWarning(fmt.Sprintf("%d pending, applied at startup: %s", len(pending), strings.Join(pending, ", ")))
+	default:
+		return fmt.Errorf("%d pending; run migrate up or set DB_AUTO_MIGRATE: %s", len(pending), strings.Join(pending, ", "))
+	}
+}
+
+// checkOTLP connects to the collector, completing a TLS handshake unless
+// tlsConfig is nil
+func checkOTLP(ctx context.Context, endpoint string, tlsConfig *tls.Config) error {
+	if endpoint == "" {
+		return errSkipped("OTEL_EXPORTER_OTLP_ENDPOINT is empty")
+	}
+	var dialer interface {
+		DialContext(ctx context.Context, network, address string) (net.Conn, error)
+	} = &net.Dialer{}
+	if tlsConfig != nil {
+		tlsConfig = tlsConfig.Clone()
+		if host, _, err := net.SplitHostPort(endpoint); err == nil {
+			tlsConfig.ServerName = host
+		}
+		dialer = &tls.Dialer{Config: tlsConfig}
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", endpoint, err)
+	}
+	return conn.Close()
+}
+
+// checkStorage writes, reads back, and deletes an object
+func checkStorage(ctx context.Context, cfg config.StorageConfig) error {
+	store, err := storage.New(cfg)
+	if err != nil {
+		return err
+	}
+	key := ".preflight/" + uuid.NewString()
+	want := []byte("preflight")
+	if err := store.Put(ctx, key, bytes.NewReader(want)); err != nil {
+		return fmt.Errorf("failed to write: %w", err)
+	}
+	got, err := readBack(ctx, store, key)
+	if delErr := store.Delete(context.WithoutCancel(ctx), key); delErr != nil && err == nil {
+		err = fmt.Errorf("failed to delete: %w", delErr)
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return errors.New("read back different content than was written")
+	}
+	return nil
+}
+
+func readBack(ctx context.Context, store storage.Storage, key string) ([]byte, error) {
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read back: %w", err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read back: %w", err)
+	}
+	return got, nil
+}
+
+// checkEmail signs in to every email provider that can check its
+// credentials, which is all but log
+func checkEmail(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
+	failover, err := mailer.New(ctx, cfg.Email, cfg.Connectors, logger)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	checked := 0
+	for _, provider := range failover.Providers() {
+		checker, ok := provider.Sender.(interface {
+			Check(ctx context.Context) error
+		})
+		if !ok {
+			continue
+		}
+		checked++
+		if err := checker.Check(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", provider.Name, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if checked == 0 {
+		return errSkipped("no provider sends email")
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"starterkit/internal/ai"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/devenv"
+	"starterkit/internal/platform/locale"
+	"starterkit/internal/platform/lock"
+	"starterkit/internal/platform/mailer"
+	"starterkit/internal/platform/migrate"
+	"starterkit/internal/platform/moderator"
+	"starterkit/internal/platform/scanner"
+	"starterkit/internal/platform/siem"
+	"starterkit/internal/platform/sms"
+	"starterkit/internal/platform/storage"
+	"starterkit/internal/platform/temporal"
+	"starterkit/internal/platform/tlspolicy"
+	"starterkit/internal/platform/useragent"
+	"starterkit/internal/platform/wiring"
+	"starterkit/internal/risk"
+
+	"go.temporal.io/sdk/client"
+)
+
+// provideInfrastructure registers the providers of the connections and
+// external providers the server's services build on. In dev mode the
+// database falls back to a throwaway Postgres container and is migrated and
+// seeded.
+func provideInfrastructure(c *wiring.Container, devMode bool) {
+	// Database pools, one per workload. Lazy mode opens them without
+	// waiting and leaves reconnecting to the server's database monitor.
+	wiring.Provide(c, func(c *wiring.Container) (*database.Pools, error) {
+		cfg, logger, tlsPolicy := wiring.Use[*config.Config](c), wiring.Use[*slog.Logger](c), wiring.Use[*tlspolicy.Policy](c)
+		var dbPools *database.Pools
+		var err error
+		if cfg.Database.LazyConnect && !devMode {
+			dbPools, err = database.OpenPools(cfg.Database, tlsPolicy)
+		} else {
+			dbPools, err = database.ConnectPools(cfg.Database, tlsPolicy)
+		}
+		if err != nil && devMode {
+			logger.Warn("configured database unreachable, starting postgres container", "error", err)
+			var pg *devenv.Postgres
+			pg, err = devenv.StartPostgres(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			c.OnStop("dev database", func(context.Context) error {
+				return pg.Stop()
+			})
+			logger.Info("started postgres container", "host", pg.Host, "port", pg.Port)
+
+			cfg.Database.Host, cfg.Database.Port = pg.Host, pg.Port
+			cfg.Database.User, cfg.Database.Password, cfg.Database.Database = pg.User, pg.Password, pg.Database
+			cfg.Database.Auth, cfg.Database.SSLMode = "password", "disable"
+			dbPools, err = database.ConnectPools(cfg.Database, tlsPolicy)
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.OnStop("database pools", func(context.Context) error {
+			dbPools.Close()
+			return nil
+		})
+		if err := dbPools.ObserveMetrics(); err != nil {
+			logger.Warn("database pool metrics unavailable", "error", err)
+		}
+
+		// Dev mode and DB_AUTO_MIGRATE bring the schema up to date; dev mode
+		// also fills an empty database
+		if devMode || cfg.Database.AutoMigrate {
+			if err := migrate.Up(context.Background(), dbPools.Pool(database.WorkloadInteractive), logger); err != nil {
+				return nil, err
+			}
+		}
+		if devMode {
+			seeded, err := devenv.Seed(context.Background(), dbPools.Pool(database.WorkloadInteractive))
+			if err != nil {
+				return nil, err
+			}
+			if seeded > 0 {
+				logger.Info("seeded demo data", "users", seeded)
+			}
+		}
+		return dbPools, nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*database.Monitor, error) {
+		cfg := wiring.Use[*config.Config](c)
+		if !cfg.Database.LazyConnect || devMode {
+			return nil, nil
+		}
+		return database.NewMonitor(wiring.Use[*database.Pools](c), wiring.Use[*slog.Logger](c)), nil
+	})
+
+	// Read-only mode, shared by the write guard on queries, transactions,
+	// and the service turning it on and off
+	wiring.Provide(c, func(c *wiring.Container) (*database.ReadOnlyMode, error) {
+		return &database.ReadOnlyMode{}, nil
+	})
+
+	// sqlc queries, routed to a pool by the context's workload hint, or to
+	// read replicas for replica reads, retrying reads through transient
+	// connection failures, and refusing writes in read-only mode
+	wiring.Provide(c, func(c *wiring.Container) (*db.Queries, error) {
+		cfg, dbPools := wiring.Use[*config.Config](c), wiring.Use[*database.Pools](c)
+		var dbtx database.DBTX = dbPools
+		if replicas := dbPools.Replicas(); len(replicas) > 0 {
+			dbtx = database.NewHedging(dbtx, replicas, cfg.Database.HedgePercentile, cfg.Database.HedgeMinDelay)
+		}
+		dbtx = database.NewRetrying(dbtx, cfg.Database.RetryAttempts, cfg.Database.RetryMaxBackoff)
+		if cfg.Database.ExplainThreshold > 0 {
+			dbtx = database.NewExplaining(dbtx, cfg.Database.ExplainThreshold)
+		}
+		dbtx = database.NewWriteGuard(dbtx, wiring.Use[*database.ReadOnlyMode](c))
+		return db.New(dbtx), nil
+	})
+
+	// Locks shared with other replicas
+	wiring.Provide(c, func(c *wiring.Container) (lock.Locker, error) {
+		return lock.New(wiring.Use[*config.Config](c).Lock, wiring.Use[*database.Pools](c).Pool(database.WorkloadBackground))
+	})
+
+	wiring.Provide(c, func(c *wiring.Container) (storage.Storage, error) {
+		return storage.New(wiring.Use[*config.Config](c).Storage)
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*locale.Resolver, error) {
+		cfg := wiring.Use[*config.Config](c)
+		return locale.NewResolver(cfg.Locale.SupportedLocales, cfg.Locale.DefaultCurrency, cfg.Locale.DefaultUnits, nil)
+	})
+	wiring.Provide(c, func(c *wiring.Container) (sms.Sender, error) {
+		return sms.New(wiring.Use[*config.Config](c).SMS, wiring.Use[*slog.Logger](c))
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*mailer.Failover, error) {
+		cfg := wiring.Use[*config.Config](c)
+		return mailer.New(context.Background(), cfg.Email, cfg.Connectors, wiring.Use[*slog.Logger](c))
+	})
+
+	// Upload malware scanner and user content moderator
+	wiring.Provide(c, func(c *wiring.Container) (scanner.Scanner, error) {
+		return scanner.New(wiring.Use[*config.Config](c).Scan)
+	})
+	wiring.Provide(c, func(c *wiring.Container) (moderator.Moderator, error) {
+		return moderator.New(wiring.Use[*config.Config](c).Moderation)
+	})
+
+	// The User-Agent parser behind session device tracking
+	wiring.Provide(c, func(c *wiring.Container) (useragent.Parser, error) {
+		return useragent.New(wiring.Use[*config.Config](c).Sessions.UserAgentParser)
+	})
+
+	// Abuse risk policy
+	wiring.Provide(c, func(c *wiring.Container) (risk.Policy, error) {
+		cfg := wiring.Use[*config.Config](c)
+		ipDenylist, err := risk.ParseIPDenylist(cfg.Risk.IPDenylist)
+		if err != nil {
+			return risk.Policy{}, err
+		}
+		return risk.Policy{
+			IPDenylist:         ipDenylist,
+			VelocityWindow:     cfg.Risk.VelocityWindow,
+			VelocityLimit:      cfg.Risk.VelocityLimit,
+			NewAccountAge:      cfg.Risk.NewAccountAge,
+			BlockThreshold:     cfg.Risk.BlockThreshold,
+			ShadowBanThreshold: cfg.Risk.ShadowBanThreshold,
+		}, nil
+	})
+
+	// SIEM sink for audit forwarding
+	wiring.Provide(c, func(c *wiring.Container) (siem.Sink, error) {
+		cfg := wiring.Use[*config.Config](c)
+		if !cfg.SIEM.Enabled {
+			return nil, nil
+		}
+		sink, err := siem.New(cfg.SIEM)
+		if err != nil {
+			return nil, err
+		}
+		c.OnStop("siem sink", func(context.Context) error {
+			return sink.Close()
+		})
+		return sink, nil
+	})
+
+	// The assistant's model provider and the embeddings behind semantic
+	// search
+	wiring.Provide(c, func(c *wiring.Container) (ai.Provider, error) {
+		cfg := wiring.Use[*config.Config](c)
+		return ai.New(cfg.AI, cfg.Connectors)
+	})
+	wiring.Provide(c, func(c *wiring.Container) (ai.Embedder, error) {
+		cfg := wiring.Use[*config.Config](c)
+		return ai.NewEmbedder(cfg.Embeddings, cfg.Connectors)
+	})
+
+	// Temporal client for workflows run on a Temporal cluster
+	wiring.Provide(c, func(c *wiring.Container) (client.Client, error) {
+		cfg := wiring.Use[*config.Config](c)
+		if !cfg.Temporal.Enabled {
+			return nil, nil
+		}
+		temporalClient, err := temporal.NewClient(context.Background(), cfg.Temporal, wiring.Use[*tlspolicy.Policy](c), wiring.Use[*slog.Logger](c))
+		if err != nil {
+			return nil, err
+		}
+		c.OnStop("temporal client", func(context.Context) error {
+			temporalClient.Close()
+			return nil
+		})
+		return temporalClient, nil
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"starterkit/internal/config"
+	"starterkit/internal/server"
+)
+
+// printRoutes lists the routes the server registers with the configuration
+// of this environment: their methods, auth requirements, middleware, and
+// handlers, as a table or with -json as JSON
+func printRoutes(args []string) int {
+	fs := flag.NewFlagSet("routes", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "routes: failed to load configuration:", err)
+		return 1
+	}
+	table := server.ListRoutes(cfg)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(table); err != nil {
+			fmt.Fprintln(os.Stderr, "routes:", err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Printf("Global middleware: %s\n\n", strings.Join(table.Middleware, ", "))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tAUTH\tMIDDLEWARE\tHANDLER")
+	for _, r := range table.Routes {
+		method := r.Method
+		if method == "" {
+			method = "*"
+		}
+		middleware := strings.Join(r.Middleware, ", ")
+		if middleware == "" {
+			middleware = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", method, r.Path, r.Auth, middleware, r.Handler)
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, "routes:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,180 @@
+// Command task runs registered maintenance tasks with the server's
+// configuration, database, and telemetry.
+//
+//	task list
+//	task run [-dry-run] [-actor EMAIL] <name> [key=value...]
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/connectors/statushooks"
+	"starterkit/internal/db"
+	"starterkit/internal/maintenance"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/lock"
+	"starterkit/internal/platform/telemetry"
+	"starterkit/internal/platform/tlspolicy"
+)
+
+const usage = `Usage:
+  task list
+  task run [-dry-run] [-actor EMAIL] <name> [key=value...]
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	registry := maintenance.Builtin()
+	switch flag.Arg(0) {
+	case "list":
+		list(registry)
+	case "run":
+		if err := run(registry, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "task:", err)
+			os.Exit(1)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func list(registry *maintenance.Registry) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tPARAMS\tDESCRIPTION")
+	for _, task := range registry.List() {
+		params := strings.Join(task.Params, ",")
+		if params == "" {
+			params = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", task.Name, params, task.Description)
+	}
+	tw.Flush()
+}
+
+func run(registry *maintenance.Registry, args []string) (err error) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "run the task and roll back its changes")
+	actor := fs.String("actor", os.Getenv("USER")+"@task", "actor recorded in the audit log")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	name := fs.Arg(0)
+	taskArgs := make(map[string]string)
+	for _, arg := range fs.Args()[1:] {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("%w: expected key=value, got %q", maintenance.ErrInvalidArgs, arg)
+		}
+		taskArgs[key] = value
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	tlsPolicy, err := tlspolicy.New(cfg.TLS)
+	if err != nil {
+		return fmt.Errorf("failed to initialize TLS policy: %w", err)
+	}
+	tlsPolicy.InstallDefaultTransport()
+
+	var otlpTLS *tls.Config
+	if !cfg.Telemetry.OTLPInsecure {
+		otlpTLS = tlsPolicy.Config()
+	}
+	// Every run is traced, and its outcome is pushed as it ends since the
+	// command exits before any scrape
+	ctx, batch, err := telemetry.StartBatch(context.Background(), "task "+name, telemetry.BatchConfig(cfg, "task", otlpTLS))
+	if err != nil {
+		return fmt.Errorf("failed to initialize telemetry: %w", err)
+	}
+	defer func() { batch.End(err) }()
+
+	pool, err := database.Connect(cfg.Database, tlsPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	locker, err := lock.New(cfg.Lock, pool)
+	if err != nil {
+		return fmt.Errorf("failed to initialize locker: %w", err)
+	}
+
+	// Uptime monitors are told of runs that change data, so they are not
+	// taken for outages
+	hooks, err := statushooks.New(cfg, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize status hooks: %w", err)
+	}
+	ping := func(ctx context.Context, event string, detail map[string]any) {
+		if hooks == nil || *dryRun {
+			return
+		}
+		if err := hooks.Send(ctx, event, detail); err != nil {
+			logger.Warn("failed to send status ping", "event", event, "error", err)
+		}
+	}
+
+	// A task runs on one host at a time
+	runner := maintenance.NewRunner(registry, pool, audit.NewService(db.New(pool)), logger)
+	var result maintenance.Result
+	err = lock.Do(ctx, locker, "task:"+name, cfg.Lock.JobTTL, func(ctx context.Context) error {
+		ping(ctx, statushooks.EventMaintenanceStarted, map[string]any{"task": name, "actor": *actor})
+		var err error
+		result, err = runner.Run(ctx, name, maintenance.RunOptions{
+			DryRun: *dryRun,
+			Actor:  *actor,
+			Args:   taskArgs,
+		})
+		detail := map[string]any{"task": name, "actor": *actor, "affected": result.Affected}
+		if err != nil {
+			detail["error"] = err.Error()
+		}
+		ping(context.WithoutCancel(ctx), statushooks.EventMaintenanceFinished, detail)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, maintenance.ErrTaskNotFound) {
+			return fmt.Errorf("unknown task %q; run 'task list' to see available tasks", name)
+		}
+		if errors.Is(err, lock.ErrNotAcquired) {
+			return fmt.Errorf("task %q is already running elsewhere", name)
+		}
+		return err
+	}
+
+	verb := "affected"
+	if *dryRun {
+		verb = "would affect (dry run, rolled back)"
+	}
+	fmt.Printf("%s: %s %d rows\n", name, verb, result.Affected)
+	if result.Message != "" {
+		fmt.Println(result.Message)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+-- +goose Up
+-- Audit trail and data retention policies
+
+CREATE TABLE audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(100) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at DESC);
+CREATE INDEX idx_audit_logs_resource ON audit_logs(resource_type, resource_id);
+
+CREATE TABLE retention_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) UNIQUE NOT NULL,
+    target VARCHAR(50) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    retention_days INTEGER NOT NULL CHECK (retention_days > 0),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_run_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE retention_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    policy_id UUID NOT NULL REFERENCES retention_policies(id) ON DELETE CASCADE,
+    dry_run BOOLEAN NOT NULL,
+    cutoff TIMESTAMPTZ NOT NULL,
+    affected_rows BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_retention_runs_policy_id ON retention_runs(policy_id, started_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_retention_runs_policy_id;
+DROP TABLE IF EXISTS retention_runs;
+DROP TABLE IF EXISTS retention_policies;
+DROP INDEX IF EXISTS idx_audit_logs_resource;
+DROP INDEX IF EXISTS idx_audit_logs_created_at;
+DROP TABLE IF EXISTS audit_logs;
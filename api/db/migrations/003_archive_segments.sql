@@ -0,0 +1,18 @@
+-- +goose Up
+-- Manifest of rows moved from Postgres into object storage
+
+CREATE TABLE archive_segments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    table_name VARCHAR(100) NOT NULL,
+    object_key TEXT UNIQUE NOT NULL,
+    range_start TIMESTAMPTZ NOT NULL,
+    range_end TIMESTAMPTZ NOT NULL,
+    row_count BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_archive_segments_range ON archive_segments(table_name, range_start, range_end);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_archive_segments_range;
+DROP TABLE IF EXISTS archive_segments;
@@ -0,0 +1,43 @@
+-- +goose Up
+-- Change data capture for the users table
+
+CREATE TABLE user_changes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    operation VARCHAR(10) NOT NULL,
+    old_data JSONB,
+    new_data JSONB,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_changes_user_id ON user_changes(user_id);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_user_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO user_changes (user_id, operation, new_data)
+        VALUES (NEW.id, TG_OP, to_jsonb(NEW));
+        RETURN NEW;
+    ELSIF TG_OP = 'UPDATE' THEN
+        INSERT INTO user_changes (user_id, operation, old_data, new_data)
+        VALUES (NEW.id, TG_OP, to_jsonb(OLD), to_jsonb(NEW));
+        RETURN NEW;
+    ELSE
+        INSERT INTO user_changes (user_id, operation, old_data)
+        VALUES (OLD.id, TG_OP, to_jsonb(OLD));
+        RETURN OLD;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER users_record_change
+AFTER INSERT OR UPDATE OR DELETE ON users
+FOR EACH ROW EXECUTE FUNCTION record_user_change();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS users_record_change ON users;
+DROP FUNCTION IF EXISTS record_user_change();
+DROP INDEX IF EXISTS idx_user_changes_user_id;
+DROP TABLE IF EXISTS user_changes;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Receipts for asynchronous mutations
+
+CREATE TABLE operations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(100) NOT NULL,
+    owner VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    result JSONB,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_operations_owner ON operations(owner, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_operations_owner;
+DROP TABLE IF EXISTS operations;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- E.164 phone numbers on user profiles with SMS verification
+
+ALTER TABLE users ADD COLUMN phone VARCHAR(16);
+ALTER TABLE users ADD COLUMN phone_verified_at TIMESTAMPTZ;
+
+CREATE TABLE phone_verifications (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    phone VARCHAR(16) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS phone_verifications;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_verified_at;
+ALTER TABLE users DROP COLUMN IF EXISTS phone;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Versioned, localized templates for transactional messages
+
+CREATE TABLE message_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    key VARCHAR(100) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    locale VARCHAR(35) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    active_version INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (key, channel, locale)
+);
+
+CREATE TABLE message_template_versions (
+    template_id UUID NOT NULL REFERENCES message_templates(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    subject TEXT,
+    body TEXT NOT NULL,
+    variables TEXT[] NOT NULL DEFAULT '{}',
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (template_id, version)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS message_template_versions;
+DROP TABLE IF EXISTS message_templates;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- User uploads, quarantined until a malware scan has completed
+
+CREATE TABLE uploads (
+    id UUID PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    object_key VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    scan_engine VARCHAR(50),
+    scan_signature TEXT,
+    scan_error TEXT,
+    scanned_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_uploads_status ON uploads(status, created_at);
+CREATE INDEX idx_uploads_owner ON uploads(owner, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_uploads_owner;
+DROP INDEX IF EXISTS idx_uploads_status;
+DROP TABLE IF EXISTS uploads;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Free-text profile bio and a review queue for moderated user content
+
+ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE moderation_flags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    resource_type VARCHAR(50) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    content TEXT NOT NULL,
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    actor VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reviewed_by VARCHAR(255),
+    review_note TEXT,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_moderation_flags_status ON moderation_flags(status, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_moderation_flags_status;
+DROP TABLE IF EXISTS moderation_flags;
+ALTER TABLE users DROP COLUMN IF EXISTS bio;
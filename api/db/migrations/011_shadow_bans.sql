@@ -0,0 +1,12 @@
+-- +goose Up
+-- Shadow bans hide an account's content from everyone but the account itself
+
+ALTER TABLE users ADD COLUMN shadow_banned_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN shadow_ban_reason TEXT;
+
+CREATE INDEX idx_users_shadow_banned ON users(shadow_banned_at) WHERE shadow_banned_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_shadow_banned;
+ALTER TABLE users DROP COLUMN IF EXISTS shadow_ban_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS shadow_banned_at;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Position of each audit log forwarder so delivery resumes after restarts
+
+CREATE TABLE audit_forwarder_checkpoints (
+    name VARCHAR(100) PRIMARY KEY,
+    last_created_at TIMESTAMPTZ NOT NULL,
+    last_id UUID NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_logs_created_at_id ON audit_logs(created_at, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_logs_created_at_id;
+DROP TABLE IF EXISTS audit_forwarder_checkpoints;
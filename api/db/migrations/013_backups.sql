@@ -0,0 +1,18 @@
+-- +goose Up
+-- Catalog of logical database backups stored in object storage
+
+CREATE TABLE backups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    object_key TEXT UNIQUE NOT NULL,
+    schema_version BIGINT NOT NULL,
+    table_count INTEGER NOT NULL,
+    row_count BIGINT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_backups_created_at ON backups(created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_backups_created_at;
+DROP TABLE IF EXISTS backups;
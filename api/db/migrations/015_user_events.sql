@@ -0,0 +1,23 @@
+-- +goose Up
+-- Append-only event streams for the user aggregate, used when
+-- USERS_PERSISTENCE=events. The users table stays the read model.
+
+CREATE TABLE user_events (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    version BIGINT NOT NULL,
+    type TEXT NOT NULL,
+    data JSONB NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, version)
+);
+
+CREATE TABLE user_snapshots (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    version BIGINT NOT NULL,
+    state JSONB NOT NULL,
+    taken_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_snapshots;
+DROP TABLE IF EXISTS user_events;
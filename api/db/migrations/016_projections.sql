@@ -0,0 +1,33 @@
+-- +goose Up
+-- Denormalized read models built from the user change log, with the last
+-- change each projection has applied
+
+CREATE TABLE projection_checkpoints (
+    name TEXT PRIMARY KEY,
+    position BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE user_search (
+    user_id UUID PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    bio TEXT NOT NULL DEFAULT '',
+    phone TEXT,
+    phone_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    shadow_banned BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    document TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('simple', name || ' ' || replace(email, '@', ' '))
+    ) STORED
+);
+
+CREATE INDEX idx_user_search_document ON user_search USING GIN (document);
+CREATE INDEX idx_user_search_created_at ON user_search(created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_search_created_at;
+DROP INDEX IF EXISTS idx_user_search_document;
+DROP TABLE IF EXISTS user_search;
+DROP TABLE IF EXISTS projection_checkpoints;
@@ -0,0 +1,38 @@
+-- +goose Up
+-- Durable multi-step workflows (sagas) and the history of their steps
+
+CREATE TABLE workflows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running',
+    step INTEGER NOT NULL DEFAULT 0,
+    compensating BOOLEAN NOT NULL DEFAULT FALSE,
+    data JSONB NOT NULL DEFAULT '{}',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_workflows_due ON workflows(next_run_at) WHERE status IN ('running', 'compensating');
+CREATE INDEX idx_workflows_status ON workflows(status, updated_at DESC);
+
+CREATE TABLE workflow_steps (
+    id BIGSERIAL PRIMARY KEY,
+    workflow_id UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    step INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    action TEXT NOT NULL,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_workflow_steps_workflow_id ON workflow_steps(workflow_id, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_workflow_steps_workflow_id;
+DROP TABLE IF EXISTS workflow_steps;
+DROP INDEX IF EXISTS idx_workflows_status;
+DROP INDEX IF EXISTS idx_workflows_due;
+DROP TABLE IF EXISTS workflows;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Daily per-user token usage of the assistant, used to enforce budgets
+
+CREATE TABLE ai_usage (
+    user_email TEXT NOT NULL,
+    day DATE NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    input_tokens BIGINT NOT NULL DEFAULT 0,
+    output_tokens BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_email, day)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS ai_usage;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Vector embeddings of user profile text for semantic search. The
+-- user_embeddings projection records each profile's content hash and clears
+-- the embedding when the text changes; the embedding job fills in rows
+-- whose embedding is missing or was made by another model.
+
+CREATE EXTENSION IF NOT EXISTS vector;
+
+CREATE TABLE user_embeddings (
+    user_id UUID PRIMARY KEY,
+    content_hash TEXT NOT NULL,
+    model TEXT,
+    embedding vector,
+    embedded_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_embeddings_model ON user_embeddings(model);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_embeddings_model;
+DROP TABLE IF EXISTS user_embeddings;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- Devices each user has been seen on, keyed by the client's session ID or,
+-- without one, a hash of its User-Agent header
+
+CREATE TABLE user_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_email TEXT NOT NULL,
+    session_key TEXT NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    browser TEXT NOT NULL DEFAULT '',
+    browser_version TEXT NOT NULL DEFAULT '',
+    os TEXT NOT NULL DEFAULT '',
+    device_class VARCHAR(20) NOT NULL DEFAULT 'unknown',
+    ip_address TEXT,
+    country VARCHAR(2),
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_email, session_key)
+);
+
+CREATE INDEX idx_user_sessions_last_seen_at ON user_sessions(last_seen_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_sessions_last_seen_at;
+DROP TABLE IF EXISTS user_sessions;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Background job queue. Each job keeps the trace context and request ID of
+-- the code that enqueued it so its execution joins the originating trace.
+
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    last_error TEXT,
+    trace_context JSONB NOT NULL DEFAULT '{}',
+    request_id TEXT,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_jobs_due ON jobs(run_at) WHERE status IN ('queued', 'running');
+CREATE INDEX idx_jobs_finished_at ON jobs(finished_at) WHERE finished_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_jobs_finished_at;
+DROP INDEX IF EXISTS idx_jobs_due;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Priority lanes for the job queue and the schedule of recurring jobs
+
+ALTER TABLE jobs ADD COLUMN priority SMALLINT NOT NULL DEFAULT 1;
+
+DROP INDEX IF EXISTS idx_jobs_due;
+CREATE INDEX idx_jobs_due ON jobs(priority, run_at) WHERE status IN ('queued', 'running');
+CREATE INDEX idx_jobs_created_at ON jobs(created_at DESC);
+
+CREATE TABLE recurring_jobs (
+    kind TEXT PRIMARY KEY,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_enqueued_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS recurring_jobs;
+DROP INDEX IF EXISTS idx_jobs_created_at;
+DROP INDEX IF EXISTS idx_jobs_due;
+CREATE INDEX idx_jobs_due ON jobs(run_at) WHERE status IN ('queued', 'running');
+ALTER TABLE jobs DROP COLUMN IF EXISTS priority;
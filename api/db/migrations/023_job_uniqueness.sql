@@ -0,0 +1,23 @@
+-- +goose Up
+-- Unique job keys, which dedupe enqueues within a window, and the side
+-- effects each job has completed, which retries skip
+
+CREATE TABLE job_unique_keys (
+    unique_key TEXT PRIMARY KEY,
+    job_id UUID NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_job_unique_keys_expires_at ON job_unique_keys(expires_at);
+
+CREATE TABLE job_effects (
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    effect TEXT NOT NULL,
+    completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (job_id, effect)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS job_effects;
+DROP INDEX IF EXISTS idx_job_unique_keys_expires_at;
+DROP TABLE IF EXISTS job_unique_keys;
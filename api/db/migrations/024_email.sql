@@ -0,0 +1,40 @@
+-- +goose Up
+-- Outbound email with its delivery status, and the addresses that must not
+-- be mailed again after a permanent bounce or spam complaint
+
+CREATE TABLE email_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID,
+    notification_id UUID,
+    to_address TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    provider_message_id TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_email_messages_provider_message_id ON email_messages(provider_message_id)
+WHERE provider_message_id IS NOT NULL;
+CREATE INDEX idx_email_messages_user_id ON email_messages(user_id);
+CREATE INDEX idx_email_messages_notification_id ON email_messages(notification_id);
+CREATE INDEX idx_email_messages_created_at ON email_messages(created_at);
+
+CREATE TABLE email_suppressions (
+    email TEXT PRIMARY KEY,
+    reason VARCHAR(20) NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS email_suppressions;
+DROP INDEX IF EXISTS idx_email_messages_created_at;
+DROP INDEX IF EXISTS idx_email_messages_notification_id;
+DROP INDEX IF EXISTS idx_email_messages_user_id;
+DROP INDEX IF EXISTS idx_email_messages_provider_message_id;
+DROP TABLE IF EXISTS email_messages;
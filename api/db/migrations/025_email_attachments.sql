@@ -0,0 +1,10 @@
+-- +goose Up
+-- HTML bodies and attachments for outbound email. Attachments are storage
+-- references, read when the message is sent.
+
+ALTER TABLE email_messages ADD COLUMN html_body TEXT NOT NULL DEFAULT '';
+ALTER TABLE email_messages ADD COLUMN attachments JSONB NOT NULL DEFAULT '[]';
+
+-- +goose Down
+ALTER TABLE email_messages DROP COLUMN IF EXISTS attachments;
+ALTER TABLE email_messages DROP COLUMN IF EXISTS html_body;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Calendar invitations emailed to attendees as iCalendar attachments. The
+-- sequence is raised on every update or cancellation so calendar clients
+-- replace their copy.
+
+CREATE TABLE calendar_invites (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    summary TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    location TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    timezone TEXT NOT NULL,
+    recurrence JSONB,
+    attendees TEXT[] NOT NULL,
+    sequence INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'confirmed',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_calendar_invites_starts_at ON calendar_invites(starts_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_calendar_invites_starts_at;
+DROP TABLE IF EXISTS calendar_invites;
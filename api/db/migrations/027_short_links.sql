@@ -0,0 +1,30 @@
+-- +goose Up
+-- Short links redirect /l/{code} to a target URL. Each redirect is
+-- recorded in short_link_clicks; the running total is kept on the link.
+
+CREATE TABLE short_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(16) NOT NULL UNIQUE,
+    target_url TEXT NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL,
+    expires_at TIMESTAMPTZ,
+    last_clicked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE short_link_clicks (
+    id BIGSERIAL PRIMARY KEY,
+    link_id UUID NOT NULL REFERENCES short_links(id) ON DELETE CASCADE,
+    referer_host TEXT NOT NULL DEFAULT '',
+    device_class TEXT NOT NULL DEFAULT '',
+    country TEXT NOT NULL DEFAULT '',
+    clicked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_short_link_clicks_link_id ON short_link_clicks(link_id, clicked_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_short_link_clicks_link_id;
+DROP TABLE IF EXISTS short_link_clicks;
+DROP TABLE IF EXISTS short_links;
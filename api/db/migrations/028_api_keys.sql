@@ -0,0 +1,41 @@
+-- +goose Up
+-- Rate plans set the request rate and daily quota of API keys. The tiers
+-- are rows, so they can be tuned or added without a deploy.
+
+CREATE TABLE rate_plans (
+    name VARCHAR(50) PRIMARY KEY,
+    requests_per_minute INTEGER NOT NULL,
+    burst INTEGER NOT NULL,
+    -- Requests per UTC day; 0 means unlimited
+    daily_quota BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO rate_plans (name, requests_per_minute, burst, daily_quota)
+VALUES ('free', 60, 10, 1000),
+    ('pro', 600, 100, 100000),
+    ('enterprise', 6000, 1000, 0);
+
+-- Only a hash of each key is stored; the prefix identifies it in listings
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    plan VARCHAR(50) NOT NULL REFERENCES rate_plans(name),
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE TABLE api_key_usage (
+    key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_key_usage;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS rate_plans;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Developers manage the keys they created, so keys are looked up by creator
+CREATE INDEX idx_api_keys_created_by ON api_keys(created_by, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_api_keys_created_by;
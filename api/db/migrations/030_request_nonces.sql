@@ -0,0 +1,14 @@
+-- +goose Up
+-- Nonces of signed requests, kept until their timestamp falls outside the
+-- accepted clock skew so a captured request cannot be replayed
+CREATE TABLE request_nonces (
+    client_id VARCHAR(100) NOT NULL,
+    nonce VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (client_id, nonce)
+);
+
+CREATE INDEX idx_request_nonces_expires_at ON request_nonces(expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS request_nonces;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Public announcements, published in the Atom feed at
+-- /feeds/announcements.atom once published_at has passed
+CREATE TABLE announcements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    link TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL,
+    published_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_announcements_published_at ON announcements(published_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_announcements_published_at;
+DROP TABLE IF EXISTS announcements;
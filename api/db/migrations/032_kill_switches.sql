@@ -0,0 +1,13 @@
+-- +goose Up
+-- Routes switched off by an operator, answered with 503 and the reason
+-- until the switch is removed. route is the registered pattern as listed by
+-- server routes, such as "POST /api/v1/links".
+CREATE TABLE kill_switches (
+    route VARCHAR(255) PRIMARY KEY,
+    reason TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS kill_switches;
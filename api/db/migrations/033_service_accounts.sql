@@ -0,0 +1,42 @@
+-- +goose Up
+-- Service accounts are non-human callers, such as batch jobs and partner
+-- integrations, holding a fixed set of scopes. They never hold roles, so
+-- they cannot reach the admin API. owner_id is the user warned before
+-- their credentials expire.
+CREATE TABLE service_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    owner_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    disabled_at TIMESTAMPTZ
+);
+
+-- Credentials are bearer secrets of which only a hash is stored. An
+-- account has several while a rotation overlaps the old credential with
+-- the new one.
+CREATE TABLE service_account_credentials (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES service_accounts(id) ON DELETE CASCADE,
+    prefix VARCHAR(16) NOT NULL,
+    secret_hash TEXT NOT NULL UNIQUE,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    expiry_warned_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_service_account_credentials_account ON service_account_credentials(account_id, created_at);
+
+-- The expiry warning job scans the active credentials not yet warned about
+CREATE INDEX idx_service_account_credentials_expiry ON service_account_credentials(expires_at)
+WHERE revoked_at IS NULL
+    AND expiry_warned_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS service_account_credentials;
+DROP TABLE IF EXISTS service_accounts;
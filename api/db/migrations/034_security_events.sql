@@ -0,0 +1,44 @@
+-- +goose Up
+-- A session revoked through a login alert is refused until it is pruned
+ALTER TABLE user_sessions
+ADD COLUMN revoked_at TIMESTAMPTZ;
+
+-- The networks, devices, and countries each user has signed in from, so a
+-- sign-in from an unfamiliar one can be flagged. Unknown values are stored
+-- as empty strings to keep them in the key.
+CREATE TABLE login_fingerprints (
+    user_email TEXT NOT NULL,
+    -- The client address masked to /24 for IPv4 and /48 for IPv6
+    ip_range TEXT NOT NULL,
+    device TEXT NOT NULL,
+    country VARCHAR(2) NOT NULL,
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_email, ip_range, device, country)
+);
+
+CREATE INDEX idx_login_fingerprints_last_seen_at ON login_fingerprints(last_seen_at);
+
+-- Sign-ins and revocations shown to users. Alerted sign-ins carry the hash
+-- of the token in their "this wasn't me" link.
+CREATE TABLE security_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_email TEXT NOT NULL,
+    kind VARCHAR(30) NOT NULL,
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    session_key TEXT NOT NULL,
+    device TEXT NOT NULL,
+    ip_address TEXT,
+    country VARCHAR(2),
+    revoke_token_hash TEXT UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_security_events_user ON security_events(user_email, created_at);
+CREATE INDEX idx_security_events_created_at ON security_events(created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS security_events;
+DROP TABLE IF EXISTS login_fingerprints;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS revoked_at;
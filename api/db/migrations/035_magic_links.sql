@@ -0,0 +1,44 @@
+-- +goose Up
+-- Magic links are single-use sign-in links emailed to users. Only a hash of
+-- the signed token in the link is stored. The requesting device is kept so
+-- a link opened on another device asks for confirmation first.
+CREATE TABLE magic_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    device_key TEXT NOT NULL,
+    device TEXT NOT NULL,
+    ip_address TEXT,
+    country TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+-- Rate limits count the recent links of an email and of a client IP
+CREATE INDEX idx_magic_links_email ON magic_links(email, created_at);
+CREATE INDEX idx_magic_links_ip_address ON magic_links(ip_address, created_at);
+
+-- Auth sessions are the bearer tokens issued for used magic links, of which
+-- only a hash is stored
+CREATE TABLE auth_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    magic_link_id UUID REFERENCES magic_links(id) ON DELETE SET NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    device TEXT NOT NULL,
+    ip_address TEXT,
+    country TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_auth_sessions_user ON auth_sessions(user_id);
+CREATE INDEX idx_auth_sessions_expires_at ON auth_sessions(expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS auth_sessions;
+DROP TABLE IF EXISTS magic_links;
@@ -0,0 +1,42 @@
+-- +goose Up
+-- WebAuthn credentials are the passkeys users register to sign in. The
+-- public key is kept as the COSE key the authenticator returned.
+CREATE TABLE webauthn_credentials (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credential_id BYTEA NOT NULL UNIQUE,
+    public_key BYTEA NOT NULL,
+    algorithm INTEGER NOT NULL,
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    aaguid BYTEA,
+    transports TEXT[] NOT NULL DEFAULT '{}',
+    backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+    backed_up BOOLEAN NOT NULL DEFAULT FALSE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webauthn_credentials_user ON webauthn_credentials(user_id);
+
+-- WebAuthn challenges are issued for each ceremony and deleted when the
+-- response is verified, so a challenge is answered at most once.
+-- Registration challenges belong to the user adding a passkey.
+CREATE TABLE webauthn_challenges (
+    challenge BYTEA PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('registration', 'authentication')),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_webauthn_challenges_expires_at ON webauthn_challenges(expires_at);
+
+-- Auth sessions record how they were signed in to
+ALTER TABLE auth_sessions
+    ADD COLUMN method TEXT NOT NULL DEFAULT 'magic_link';
+
+-- +goose Down
+ALTER TABLE auth_sessions DROP COLUMN IF EXISTS method;
+DROP TABLE IF EXISTS webauthn_challenges;
+DROP TABLE IF EXISTS webauthn_credentials;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- User identities link the identities a user signs in with at the identity
+-- provider to one account, so a token for any of them acts as that account.
+-- provider is the upstream provider the issuer names, such as google.
+CREATE TABLE user_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user ON user_identities(user_id);
+
+-- Account merges record users merged into another. Tokens carrying a
+-- merged user's email act as the account it was merged into.
+CREATE TABLE account_merges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source_user_id UUID NOT NULL,
+    source_email TEXT NOT NULL UNIQUE,
+    target_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    merged_by TEXT NOT NULL,
+    report JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_account_merges_target ON account_merges(target_user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS account_merges;
+DROP TABLE IF EXISTS user_identities;
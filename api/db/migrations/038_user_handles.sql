@@ -0,0 +1,23 @@
+-- +goose Up
+-- Unique public handles on users, and the handles they had before
+
+ALTER TABLE users ADD COLUMN handle VARCHAR(30);
+ALTER TABLE users ADD COLUMN handle_changed_at TIMESTAMPTZ;
+CREATE UNIQUE INDEX idx_users_handle ON users(handle);
+
+-- Previous handles redirect to their user, and are held for them for a
+-- while before anyone else may take them
+CREATE TABLE user_handle_history (
+    handle VARCHAR(30) PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    released_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_handle_history_user_id ON user_handle_history(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_handle_history_user_id;
+DROP TABLE IF EXISTS user_handle_history;
+DROP INDEX IF EXISTS idx_users_handle;
+ALTER TABLE users DROP COLUMN IF EXISTS handle_changed_at;
+ALTER TABLE users DROP COLUMN IF EXISTS handle;
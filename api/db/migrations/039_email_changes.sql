@@ -0,0 +1,24 @@
+-- +goose Up
+-- Email changes confirmed from both the old and the new address
+
+ALTER TABLE users ADD COLUMN pending_email VARCHAR(255);
+
+-- A user has at most one change pending; a new request replaces it
+CREATE TABLE email_changes (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    old_email VARCHAR(255) NOT NULL,
+    new_email VARCHAR(255) NOT NULL,
+    old_token_hash VARCHAR(64) NOT NULL UNIQUE,
+    new_token_hash VARCHAR(64) NOT NULL UNIQUE,
+    old_confirmed_at TIMESTAMPTZ,
+    new_confirmed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_changes_expires_at ON email_changes(expires_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_email_changes_expires_at;
+DROP TABLE IF EXISTS email_changes;
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Denormalized user listing maintained by the projector from the user
+-- change log, so list endpoints need not join what profiles grow into
+
+CREATE TABLE user_summaries (
+    user_id UUID PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    handle VARCHAR(30),
+    bio TEXT NOT NULL DEFAULT '',
+    phone TEXT,
+    phone_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    pending_email VARCHAR(255),
+    shadow_banned BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_user_summaries_created_at ON user_summaries(created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_summaries_created_at;
+DROP TABLE IF EXISTS user_summaries;
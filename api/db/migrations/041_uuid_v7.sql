@@ -0,0 +1,31 @@
+-- +goose Up
+-- Time-ordered UUIDs (RFC 9562 version 7) for primary keys. New rows sort
+-- after existing ones, so inserts touch the right edge of the index
+-- instead of random pages. Postgres 18 has uuidv7(); this matches it for
+-- earlier versions. The append-only tables switch to it; existing IDs stay.
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION uuid_generate_v7() RETURNS UUID AS $$
+    -- A random UUID with its first 48 bits replaced by the Unix time in
+    -- milliseconds and its version bits set from 4 to 7
+    SELECT encode(
+        set_bit(
+            set_bit(
+                overlay(uuid_send(gen_random_uuid())
+                    PLACING substring(int8send(floor(extract(epoch FROM clock_timestamp()) * 1000)::BIGINT) FROM 3)
+                    FROM 1 FOR 6),
+                52, 1),
+            53, 1),
+        'hex')::UUID;
+$$ LANGUAGE sql VOLATILE;
+-- +goose StatementEnd
+
+ALTER TABLE audit_logs ALTER COLUMN id SET DEFAULT uuid_generate_v7();
+ALTER TABLE jobs ALTER COLUMN id SET DEFAULT uuid_generate_v7();
+ALTER TABLE email_messages ALTER COLUMN id SET DEFAULT uuid_generate_v7();
+
+-- +goose Down
+ALTER TABLE email_messages ALTER COLUMN id SET DEFAULT gen_random_uuid();
+ALTER TABLE jobs ALTER COLUMN id SET DEFAULT gen_random_uuid();
+ALTER TABLE audit_logs ALTER COLUMN id SET DEFAULT gen_random_uuid();
+DROP FUNCTION IF EXISTS uuid_generate_v7();
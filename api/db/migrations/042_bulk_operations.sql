@@ -0,0 +1,50 @@
+-- +goose Up
+-- Roles granted to users in the app, in addition to those their identity
+-- provider's tokens carry. Session tokens from magic links and passkeys
+-- carry these.
+CREATE TABLE user_roles (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(100) NOT NULL,
+    granted_by TEXT NOT NULL,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role)
+);
+
+-- An admin's action on many users at once. The targets are resolved when
+-- it is submitted and worked through in batches by the job queue; each
+-- item records the outcome for one user.
+CREATE TABLE bulk_operations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    action VARCHAR(20) NOT NULL,
+    role VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    total INTEGER NOT NULL,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_bulk_operations_created_at ON bulk_operations(created_at DESC);
+
+CREATE TABLE bulk_operation_items (
+    operation_id UUID NOT NULL REFERENCES bulk_operations(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    user_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    error TEXT,
+    finished_at TIMESTAMPTZ,
+    PRIMARY KEY (operation_id, position)
+);
+
+-- Workers take the next batch of pending items in order
+CREATE INDEX idx_bulk_operation_items_pending ON bulk_operation_items(operation_id, position)
+WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE bulk_operation_items;
+DROP TABLE bulk_operations;
+DROP TABLE user_roles;
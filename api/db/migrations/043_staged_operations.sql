@@ -0,0 +1,14 @@
+-- +goose Up
+-- Destructive actions staged behind an undo window. A staged operation
+-- keeps what to act on in payload and can be undone until undo_until, after
+-- which the scheduler carries it out.
+
+ALTER TABLE operations ADD COLUMN payload JSONB;
+ALTER TABLE operations ADD COLUMN undo_until TIMESTAMPTZ;
+
+CREATE INDEX idx_operations_staged ON operations(undo_until) WHERE status = 'staged';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_operations_staged;
+ALTER TABLE operations DROP COLUMN IF EXISTS undo_until;
+ALTER TABLE operations DROP COLUMN IF EXISTS payload;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Deleted templates and retention policies move to the trash instead of
+-- going away: deleted_at marks them, and they are purged for good once they
+-- have been there longer than the trash retention period. Uniqueness only
+-- holds among live rows, so a name can be reused while the old row waits.
+
+ALTER TABLE message_templates ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE message_templates ADD COLUMN deleted_by VARCHAR(255);
+ALTER TABLE message_templates DROP CONSTRAINT message_templates_key_channel_locale_key;
+CREATE UNIQUE INDEX idx_message_templates_key_channel_locale ON message_templates(key, channel, locale) WHERE deleted_at IS NULL;
+CREATE INDEX idx_message_templates_deleted_at ON message_templates(deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE retention_policies ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE retention_policies ADD COLUMN deleted_by VARCHAR(255);
+ALTER TABLE retention_policies DROP CONSTRAINT retention_policies_name_key;
+CREATE UNIQUE INDEX idx_retention_policies_name ON retention_policies(name) WHERE deleted_at IS NULL;
+CREATE INDEX idx_retention_policies_deleted_at ON retention_policies(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DELETE FROM retention_policies WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_retention_policies_deleted_at;
+DROP INDEX IF EXISTS idx_retention_policies_name;
+ALTER TABLE retention_policies ADD CONSTRAINT retention_policies_name_key UNIQUE (name);
+ALTER TABLE retention_policies DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE retention_policies DROP COLUMN IF EXISTS deleted_at;
+
+DELETE FROM message_templates WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_message_templates_deleted_at;
+DROP INDEX IF EXISTS idx_message_templates_key_channel_locale;
+ALTER TABLE message_templates ADD CONSTRAINT message_templates_key_channel_locale_key UNIQUE (key, channel, locale);
+ALTER TABLE message_templates DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE message_templates DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Heartbeats the running replicas renew so each knows its peers. A row
+-- whose expiry has passed is a replica that stopped or lost the database;
+-- it is kept for a while so the cluster view can show it.
+
+CREATE TABLE replica_heartbeats (
+    id TEXT PRIMARY KEY,
+    version TEXT NOT NULL,
+    ready BOOLEAN NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    heartbeat_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS replica_heartbeats;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- IDs users had in systems being migrated from, so clients that still hold
+-- them can find the users while they move over
+
+CREATE TABLE user_external_ids (
+    system VARCHAR(50) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (system, external_id)
+);
+
+CREATE INDEX idx_user_external_ids_user_id ON user_external_ids(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_external_ids_user_id;
+DROP TABLE IF EXISTS user_external_ids;
@@ -0,0 +1,58 @@
+-- +goose Up
+-- Email campaigns sent by admins to an audience of users. The audience is
+-- resolved into recipients when a campaign starts, and the job queue
+-- renders and queues their messages a batch at a time. Campaign messages
+-- link back to their campaign so delivery and opens can be reported on it.
+
+CREATE TABLE email_campaigns (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    name TEXT NOT NULL,
+    template_key VARCHAR(100) NOT NULL,
+    locale VARCHAR(35) NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    audience JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    -- Raised on every start and resume, so batches queued before a pause
+    -- do not run alongside those queued after it
+    run INTEGER NOT NULL DEFAULT 0,
+    total INTEGER NOT NULL DEFAULT 0,
+    queued INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_email_campaigns_created_at ON email_campaigns(created_at DESC);
+
+CREATE TABLE email_campaign_recipients (
+    campaign_id UUID NOT NULL REFERENCES email_campaigns(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    user_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    error TEXT,
+    finished_at TIMESTAMPTZ,
+    PRIMARY KEY (campaign_id, position)
+);
+
+-- Workers take the next batch of pending recipients in order
+CREATE INDEX idx_email_campaign_recipients_pending ON email_campaign_recipients(campaign_id, position)
+WHERE status = 'pending';
+
+ALTER TABLE email_messages
+    ADD COLUMN campaign_id UUID REFERENCES email_campaigns(id) ON DELETE SET NULL,
+    ADD COLUMN opened_at TIMESTAMPTZ;
+
+CREATE INDEX idx_email_messages_campaign_id ON email_messages(campaign_id)
+WHERE campaign_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_email_messages_campaign_id;
+ALTER TABLE email_messages
+    DROP COLUMN IF EXISTS opened_at,
+    DROP COLUMN IF EXISTS campaign_id;
+DROP TABLE IF EXISTS email_campaign_recipients;
+DROP TABLE IF EXISTS email_campaigns;
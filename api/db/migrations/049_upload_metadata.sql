@@ -0,0 +1,24 @@
+-- +goose Up
+-- Metadata extracted from clean uploads, and a lookup of clean uploads by
+-- checksum so identical files share one stored object
+
+ALTER TABLE uploads
+    ADD COLUMN sniffed_type VARCHAR(255),
+    ADD COLUMN width INTEGER,
+    ADD COLUMN height INTEGER,
+    ADD COLUMN duration_ms BIGINT,
+    ADD COLUMN metadata_extracted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_uploads_sha256 ON uploads(sha256, created_at) WHERE status = 'clean';
+CREATE INDEX idx_uploads_metadata_pending ON uploads(created_at)
+    WHERE status = 'clean' AND metadata_extracted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_uploads_metadata_pending;
+DROP INDEX IF EXISTS idx_uploads_sha256;
+ALTER TABLE uploads
+    DROP COLUMN IF EXISTS metadata_extracted_at,
+    DROP COLUMN IF EXISTS duration_ms,
+    DROP COLUMN IF EXISTS height,
+    DROP COLUMN IF EXISTS width,
+    DROP COLUMN IF EXISTS sniffed_type;
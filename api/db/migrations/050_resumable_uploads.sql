@@ -0,0 +1,23 @@
+-- +goose Up
+-- Uploads sent in chunks over the tus protocol. Each chunk is stored as
+-- its own object until the last one arrives, when the file is assembled
+-- into the upload with the same ID and the row is removed.
+
+CREATE TABLE resumable_uploads (
+    id UUID PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    upload_length BIGINT NOT NULL,
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    chunk_keys TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_resumable_uploads_expires_at ON resumable_uploads(expires_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_resumable_uploads_expires_at;
+DROP TABLE IF EXISTS resumable_uploads;
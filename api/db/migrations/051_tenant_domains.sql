@@ -0,0 +1,27 @@
+-- +goose Up
+-- Custom domains tenants serve the app on. A domain is only routed to its
+-- tenant, and given a certificate, once a DNS TXT record proves the tenant
+-- controls it.
+
+CREATE TABLE tenant_domains (
+    domain VARCHAR(253) PRIMARY KEY,
+    tenant_id VARCHAR(100) NOT NULL,
+    verification_token VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMPTZ,
+    last_checked_at TIMESTAMPTZ,
+    check_error TEXT,
+    cors_origins TEXT[] NOT NULL DEFAULT '{}',
+    cookie_domain VARCHAR(253),
+    cookie_same_site VARCHAR(10),
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_tenant_domains_tenant_id ON tenant_domains(tenant_id);
+CREATE INDEX idx_tenant_domains_unverified ON tenant_domains(created_at) WHERE verified_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tenant_domains_unverified;
+DROP INDEX IF EXISTS idx_tenant_domains_tenant_id;
+DROP TABLE IF EXISTS tenant_domains;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Read-only mode set by an operator, under which mutating requests are
+-- answered with 503 and the reason. The table holds at most one row, present
+-- while the mode is on.
+CREATE TABLE read_only_mode (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    reason TEXT NOT NULL,
+    enabled_by TEXT NOT NULL,
+    enabled_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS read_only_mode;
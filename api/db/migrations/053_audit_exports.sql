@@ -0,0 +1,57 @@
+-- +goose Up
+-- Recurring exports of a tenant's audit events, scheduled by the tenant's
+-- admins. Entries are tagged with the tenant of the caller that caused them;
+-- entries from before the column existed, or from callers without a tenant,
+-- belong to no tenant and are never exported.
+
+ALTER TABLE audit_logs ADD COLUMN tenant_id VARCHAR(100);
+
+CREATE INDEX idx_audit_logs_tenant ON audit_logs(tenant_id, created_at, id) WHERE tenant_id IS NOT NULL;
+
+CREATE TABLE audit_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    tenant_id VARCHAR(100) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    frequency VARCHAR(10) NOT NULL,
+    destination VARCHAR(10) NOT NULL,
+    -- target is the email address or webhook URL exports are delivered to;
+    -- webhook deliveries are signed with signing_secret
+    target TEXT NOT NULL,
+    signing_secret VARCHAR(64),
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    -- The last entry delivered; the next export starts after it
+    exported_through TIMESTAMPTZ NOT NULL,
+    exported_through_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_exports_tenant_id ON audit_exports(tenant_id);
+CREATE INDEX idx_audit_exports_due ON audit_exports(next_run_at) WHERE NOT paused;
+
+CREATE TABLE audit_export_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    export_id UUID NOT NULL REFERENCES audit_exports(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    entry_count INTEGER NOT NULL DEFAULT 0,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    email_message_id UUID,
+    response_status INTEGER,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_export_deliveries_export ON audit_export_deliveries(export_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_export_deliveries_export;
+DROP TABLE IF EXISTS audit_export_deliveries;
+DROP INDEX IF EXISTS idx_audit_exports_due;
+DROP INDEX IF EXISTS idx_audit_exports_tenant_id;
+DROP TABLE IF EXISTS audit_exports;
+DROP INDEX IF EXISTS idx_audit_logs_tenant;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- The transaction that recorded each user change. Change IDs are taken when
+-- a change is inserted, not when it commits, so a transaction that commits
+-- late can add an ID below one a reader has already passed. Readers order
+-- the log by transaction and ID instead, and only read changes of
+-- transactions older than every one still running. Changes and checkpoints
+-- from before the column existed are given this migration's transaction, so
+-- positions taken before it keep their place.
+
+ALTER TABLE user_changes ADD COLUMN txid BIGINT NOT NULL DEFAULT pg_current_xact_id()::text::bigint;
+
+CREATE INDEX idx_user_changes_position ON user_changes(txid, id);
+
+ALTER TABLE projection_checkpoints ADD COLUMN txid BIGINT NOT NULL DEFAULT 0;
+
+UPDATE projection_checkpoints SET txid = pg_current_xact_id()::text::bigint;
+
+-- +goose Down
+ALTER TABLE projection_checkpoints DROP COLUMN IF EXISTS txid;
+DROP INDEX IF EXISTS idx_user_changes_position;
+ALTER TABLE user_changes DROP COLUMN IF EXISTS txid;
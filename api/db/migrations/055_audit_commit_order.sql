@@ -0,0 +1,20 @@
+-- +goose Up
+-- The transaction that recorded each audit entry. created_at is when the
+-- transaction started, so an entry of a long transaction can commit behind
+-- a reader paging by it. Readers order the log by transaction, then
+-- created_at and ID, and only read entries of transactions older than every
+-- one still running. Entries from before the column existed get 0, so they
+-- keep their created_at order ahead of every later entry and the
+-- checkpoints taken among them stay valid.
+
+ALTER TABLE audit_logs ADD COLUMN txid BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE audit_logs ALTER COLUMN txid SET DEFAULT pg_current_xact_id()::text::bigint;
+
+CREATE INDEX idx_audit_logs_position ON audit_logs(txid, created_at, id);
+
+ALTER TABLE audit_forwarder_checkpoints ADD COLUMN last_txid BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE audit_forwarder_checkpoints DROP COLUMN IF EXISTS last_txid;
+DROP INDEX IF EXISTS idx_audit_logs_position;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS txid;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Audit exports read a tenant's entries in commit order, as the forwarder
+-- does since 055. The checkpoint of each export takes the transaction of the
+-- entry it points at.
+
+ALTER TABLE audit_exports ADD COLUMN exported_through_txid BIGINT NOT NULL DEFAULT 0;
+
+UPDATE audit_exports e
+SET exported_through_txid = l.txid
+FROM audit_logs l
+WHERE l.id = e.exported_through_id;
+
+DROP INDEX IF EXISTS idx_audit_logs_tenant;
+CREATE INDEX idx_audit_logs_tenant ON audit_logs(tenant_id, txid, created_at, id) WHERE tenant_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_logs_tenant;
+CREATE INDEX idx_audit_logs_tenant ON audit_logs(tenant_id, created_at, id) WHERE tenant_id IS NOT NULL;
+ALTER TABLE audit_exports DROP COLUMN IF EXISTS exported_through_txid;
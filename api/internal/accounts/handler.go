@@ -0,0 +1,165 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	Identities(ctx context.Context, actor string) ([]Identity, error)
+	Link(ctx context.Context, actor string, identity *auth.Principal) (*Identity, error)
+	Unlink(ctx context.Context, actor string, current *auth.Principal, id uuid.UUID) error
+	Merge(ctx context.Context, targetID uuid.UUID, req MergeRequest, actor string) (*MergeReport, error)
+}
+
+// VerifyFunc verifies an identity provider token and returns its identity
+type VerifyFunc func(ctx context.Context, token string) (*auth.Principal, error)
+
+type Handler struct {
+	service ServiceInterface
+	verify  VerifyFunc
+	logger  *slog.Logger
+}
+
+// NewHandler creates the account handlers; verify checks the tokens of
+// identities being linked, and is nil when linking is off
+func NewHandler(service ServiceInterface, verify VerifyFunc, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		verify:  verify,
+		logger:  logger,
+	}
+}
+
+// HandleListIdentities lists the identities linked to the caller's account
+func (h *Handler) HandleListIdentities() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identities, err := h.service.Identities(r.Context(), actorFromRequest(r))
+		if err != nil {
+			h.respondWithAccountError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, map[string]any{"identities": identities})
+	}
+}
+
+// HandleLink links the identity of a token in the body to the caller's
+// account
+func (h *Handler) HandleLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req LinkRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		identity, err := h.verify(r.Context(), req.Token)
+		if err != nil {
+			h.logger.Info("rejected identity token", "error", err)
+			apierror.Write(w, r, apierror.BadRequest("invalid_identity_token", "invalid identity token"))
+			return
+		}
+
+		linked, err := h.service.Link(r.Context(), actorFromRequest(r), identity)
+		if err != nil {
+			h.respondWithAccountError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusCreated, linked)
+	}
+}
+
+// HandleUnlink removes an identity from the caller's account
+func (h *Handler) HandleUnlink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseID(w, r)
+		if !ok {
+			return
+		}
+
+		current, _ := auth.FromContext(r.Context())
+		if err := h.service.Unlink(r.Context(), actorFromRequest(r), current, id); err != nil {
+			h.respondWithAccountError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleMerge merges a source user into the user of the route
+func (h *Handler) HandleMerge() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseID(w, r)
+		if !ok {
+			return
+		}
+		var req MergeRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		report, err := h.service.Merge(r.Context(), id, req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithAccountError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, report)
+	}
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_id", "invalid ID format"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) respondWithAccountError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "unauthenticated", err.Error()))
+	case errors.Is(err, ErrUserNotFound):
+		apierror.Write(w, r, apierror.NotFound("user_not_found", err.Error()))
+	case errors.Is(err, ErrIdentityNotFound):
+		apierror.Write(w, r, apierror.NotFound("identity_not_found", err.Error()))
+	case errors.Is(err, ErrSourceNotFound):
+		apierror.Write(w, r, apierror.Unprocessable("source_not_found", err.Error()))
+	case errors.Is(err, ErrSameUser):
+		apierror.Write(w, r, apierror.Unprocessable("same_user", err.Error()))
+	case errors.Is(err, ErrAlreadyLinked):
+		apierror.Write(w, r, apierror.Conflict("already_linked", err.Error()))
+	case errors.Is(err, ErrIdentityLinked):
+		apierror.Write(w, r, apierror.Conflict("identity_linked", err.Error()))
+	case errors.Is(err, ErrIdentityInUse):
+		apierror.Write(w, r, apierror.Conflict("identity_in_use", err.Error()))
+	case errors.Is(err, ErrCurrentIdentity):
+		apierror.Write(w, r, apierror.Conflict("current_identity", err.Error()))
+	default:
+		h.logger.Error("account request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
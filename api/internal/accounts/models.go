@@ -0,0 +1,84 @@
+package accounts
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnauthenticated  = errors.New("X-User-Email header is required")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrSourceNotFound   = errors.New("source user not found")
+	ErrIdentityNotFound = errors.New("identity not found")
+	ErrAlreadyLinked    = errors.New("identity is already linked to your account")
+	ErrIdentityLinked   = errors.New("identity is linked to another account")
+	// ErrIdentityInUse is returned for identities whose email belongs to
+	// another user; combining the two takes a merge
+	ErrIdentityInUse   = errors.New("identity signs in to another account; ask an administrator to merge the accounts")
+	ErrCurrentIdentity = errors.New("cannot unlink the identity you are signed in with")
+	ErrSameUser        = errors.New("cannot merge a user into itself")
+)
+
+// Identity is an identity provider identity linked to an account
+type Identity struct {
+	ID        uuid.UUID `json:"id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LinkRequest links the identity a token was issued for
+type LinkRequest struct {
+	// Token is an identity provider token for the identity to link, which
+	// proves the caller controls it
+	Token string `json:"token"`
+}
+
+func (r *LinkRequest) Validate() error {
+	r.Token = strings.TrimSpace(r.Token)
+
+	var v request.Validation
+	v.Check(r.Token != "", "token", "is required")
+	return v.Err()
+}
+
+// MergeRequest merges the source user into the user of the route
+type MergeRequest struct {
+	SourceID uuid.UUID `json:"source_id"`
+	// DryRun reports what a merge would do without making it
+	DryRun bool `json:"dry_run"`
+}
+
+func (r *MergeRequest) Validate() error {
+	var v request.Validation
+	v.Check(r.SourceID != uuid.Nil, "source_id", "is required")
+	return v.Err()
+}
+
+// MergeReport describes a merge: the resources reassigned to the target,
+// keyed by resource, and the conflicts resolved along the way
+type MergeReport struct {
+	TargetID        uuid.UUID        `json:"target_id"`
+	TargetEmail     string           `json:"target_email"`
+	SourceID        uuid.UUID        `json:"source_id"`
+	SourceEmail     string           `json:"source_email"`
+	DryRun          bool             `json:"dry_run"`
+	Moved           map[string]int64 `json:"moved"`
+	RevokedSessions int64            `json:"revoked_sessions"`
+	Conflicts       []Conflict       `json:"conflicts"`
+}
+
+// Conflict is a part of the source user the target already had. Resources
+// count the source's rows that were dropped; profile fields name the field.
+type Conflict struct {
+	Resource   string `json:"resource"`
+	Field      string `json:"field,omitempty"`
+	Count      int64  `json:"count,omitempty"`
+	Resolution string `json:"resolution"`
+}
@@ -0,0 +1,483 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/memcache"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxCached bounds the resolved accounts each replica caches
+const maxCached = 10000
+
+// keptTarget is how conflicts are resolved: the target's data wins
+const keptTarget = "kept the target's"
+
+// errDryRun rolls back the transaction of a dry-run merge
+var errDryRun = errors.New("dry run")
+
+type Querier interface {
+	GetActiveUserIDByEmail(ctx context.Context, email string) (pgtype.UUID, error)
+	GetAccountUser(ctx context.Context, id pgtype.UUID) (db.GetAccountUserRow, error)
+	ResolveIdentity(ctx context.Context, arg db.ResolveIdentityParams) (string, error)
+	ListUserIdentities(ctx context.Context, userID pgtype.UUID) ([]db.UserIdentity, error)
+	GetUserIdentity(ctx context.Context, arg db.GetUserIdentityParams) (db.UserIdentity, error)
+	CreateUserIdentity(ctx context.Context, arg db.CreateUserIdentityParams) (db.UserIdentity, error)
+	DeleteUserIdentity(ctx context.Context, arg db.DeleteUserIdentityParams) (db.UserIdentity, error)
+}
+
+// Transactor runs a unit of work in a database transaction
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(q *db.Queries) error, opts ...database.TxOption) error
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service links identities to accounts, merges accounts, and resolves the
+// account an identity provider token acts as
+type Service struct {
+	queries Querier
+	tx      Transactor
+	auditor Auditor
+	cfg     config.AccountsConfig
+	logger  *slog.Logger
+
+	// resolved caches Resolve; it is nil when ACCOUNT_RESOLVE_CACHE_TTL is 0
+	resolved *memcache.Cache[string, string]
+}
+
+func NewService(queries Querier, tx Transactor, auditor Auditor, cfg config.AccountsConfig, logger *slog.Logger) *Service {
+	s := &Service{
+		queries: queries,
+		tx:      tx,
+		auditor: auditor,
+		cfg:     cfg,
+		logger:  logger,
+	}
+	if cfg.ResolveCacheTTL > 0 {
+		s.resolved = memcache.New[string, string]("accounts.resolved", maxCached, cfg.ResolveCacheTTL)
+	}
+	return s
+}
+
+// Resolve returns the email of the account a token's principal acts as: the
+// account its identity is linked to, or the account its email's user was
+// merged into. It returns "" when the principal acts as itself.
+func (s *Service) Resolve(ctx context.Context, principal *auth.Principal) (string, error) {
+	resolve := func(ctx context.Context) (string, error) {
+		email, err := s.queries.ResolveIdentity(ctx, db.ResolveIdentityParams{
+			Provider: principal.Provider,
+			Subject:  principal.Subject,
+			Email:    principal.Email,
+		})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("failed to resolve account: %w", err)
+		}
+		return email, nil
+	}
+	if s.resolved == nil {
+		return resolve(ctx)
+	}
+	key := principal.Provider + "\x00" + principal.Subject + "\x00" + principal.Email
+	return s.resolved.GetOrLoad(ctx, key, resolve)
+}
+
+// Identities lists the identities linked to the caller's account
+func (s *Service) Identities(ctx context.Context, actor string) ([]Identity, error) {
+	userID, err := s.user(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.queries.ListUserIdentities(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+
+	identities := make([]Identity, len(rows))
+	for i, row := range rows {
+		identities[i] = toIdentity(row)
+	}
+	return identities, nil
+}
+
+// Link links the identity of a verified token to the caller's account.
+// Identities linked elsewhere, or whose email is another user's, are not
+// moved; combining two users takes a merge.
+func (s *Service) Link(ctx context.Context, actor string, identity *auth.Principal) (*Identity, error) {
+	userID, err := s.user(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.queries.GetUserIdentity(ctx, db.GetUserIdentityParams{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+	})
+	switch {
+	case err == nil && existing.UserID == userID:
+		return nil, ErrAlreadyLinked
+	case err == nil:
+		return nil, ErrIdentityLinked
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+	if identity.Email != "" && identity.Email != actor {
+		other, err := s.queries.GetActiveUserIDByEmail(ctx, identity.Email)
+		if err == nil && other != userID {
+			return nil, ErrIdentityInUse
+		}
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+	}
+
+	row, err := s.queries.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		UserID:   userID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if isUniqueViolation(err) {
+		return nil, ErrIdentityLinked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+	s.forget()
+
+	linked := toIdentity(row)
+	s.record(ctx, actor, "user.identity_linked", userID, map[string]any{
+		"identity_id": linked.ID.String(),
+		"provider":    linked.Provider,
+		"email":       linked.Email,
+	})
+	return &linked, nil
+}
+
+// Unlink removes an identity from the caller's account, except the one
+// current signed the request with
+func (s *Service) Unlink(ctx context.Context, actor string, current *auth.Principal, id uuid.UUID) error {
+	userID, err := s.user(ctx, actor)
+	if err != nil {
+		return err
+	}
+	rows, err := s.queries.ListUserIdentities(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list identities: %w", err)
+	}
+	var identity *db.UserIdentity
+	for i := range rows {
+		if uuid.UUID(rows[i].ID.Bytes) == id {
+			identity = &rows[i]
+		}
+	}
+	if identity == nil {
+		return ErrIdentityNotFound
+	}
+	if current != nil && current.Provider == identity.Provider && current.Subject == identity.Subject {
+		return ErrCurrentIdentity
+	}
+
+	_, err = s.queries.DeleteUserIdentity(ctx, db.DeleteUserIdentityParams{ID: identity.ID, UserID: userID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrIdentityNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unlink identity: %w", err)
+	}
+	s.forget()
+
+	s.record(ctx, actor, "user.identity_unlinked", userID, map[string]any{
+		"identity_id": id.String(),
+		"provider":    identity.Provider,
+	})
+	return nil
+}
+
+// Merge moves everything the source user owns to the target user in one
+// transaction, then deactivates the source. Rows the target already has an
+// equivalent of are dropped and reported as conflicts, as are profile
+// fields that differ; the target's always win. Identities linked to the
+// source, and tokens carrying its email, act as the target from then on.
+// A dry run reports the same without committing.
+func (s *Service) Merge(ctx context.Context, targetID uuid.UUID, req MergeRequest, actor string) (*MergeReport, error) {
+	if req.SourceID == targetID {
+		return nil, ErrSameUser
+	}
+	target, err := s.queries.GetAccountUser(ctx, pgtype.UUID{Bytes: targetID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	source, err := s.queries.GetAccountUser(ctx, pgtype.UUID{Bytes: req.SourceID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSourceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source user: %w", err)
+	}
+
+	var report *MergeReport
+	err = s.tx.WithTx(ctx, func(q *db.Queries) error {
+		// A retried transaction starts its report over
+		report = &MergeReport{
+			TargetID:    targetID,
+			TargetEmail: target.Email,
+			SourceID:    req.SourceID,
+			SourceEmail: source.Email,
+			DryRun:      req.DryRun,
+			Moved:       map[string]int64{},
+			Conflicts:   profileConflicts(target, source),
+		}
+		if err := merge(ctx, q, target, source, report); err != nil {
+			return err
+		}
+
+		encoded, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("failed to encode merge report: %w", err)
+		}
+		if err := q.CreateAccountMerge(ctx, db.CreateAccountMergeParams{
+			SourceUserID: source.ID,
+			SourceEmail:  source.Email,
+			TargetUserID: target.ID,
+			MergedBy:     actor,
+			Report:       encoded,
+		}); err != nil {
+			return fmt.Errorf("failed to record merge: %w", err)
+		}
+		if _, err := q.DeactivateUser(ctx, source.ID); err != nil {
+			return fmt.Errorf("failed to deactivate source user: %w", err)
+		}
+
+		// The merge and its audit entries are written together or not at all
+		auditor := audit.NewService(q)
+		if err := auditor.Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "user.merged",
+			ResourceType: "user",
+			ResourceID:   targetID.String(),
+			Metadata:     map[string]any{"source_id": req.SourceID.String(), "source_email": source.Email, "moved": report.Moved, "conflicts": len(report.Conflicts)},
+		}); err != nil {
+			return err
+		}
+		if err := auditor.Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "user.merged_away",
+			ResourceType: "user",
+			ResourceID:   req.SourceID.String(),
+			Metadata:     map[string]any{"target_id": targetID.String(), "target_email": target.Email},
+		}); err != nil {
+			return err
+		}
+
+		if req.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, fmt.Errorf("failed to merge users: %w", err)
+	}
+	if !req.DryRun {
+		s.forget()
+	}
+	return report, nil
+}
+
+// merge reassigns the source's rows to the target, first dropping the
+// source's rows that would collide with the target's
+func merge(ctx context.Context, q *db.Queries, target, source db.GetAccountUserRow, report *MergeReport) error {
+	type step struct {
+		resource string
+		run      func() (int64, error)
+	}
+
+	conflicts := []step{
+		{"notification_preferences", func() (int64, error) {
+			return q.DeleteConflictingNotificationPreferences(ctx, db.DeleteConflictingNotificationPreferencesParams{SourceUserID: source.ID, TargetUserID: target.ID})
+		}},
+		{"notification_read_cursors", func() (int64, error) {
+			return q.DeleteConflictingNotificationReadCursors(ctx, db.DeleteConflictingNotificationReadCursorsParams{SourceUserID: source.ID, TargetUserID: target.ID})
+		}},
+		{"sessions", func() (int64, error) {
+			return q.DeleteConflictingUserSessions(ctx, db.DeleteConflictingUserSessionsParams{SourceEmail: source.Email, TargetEmail: target.Email})
+		}},
+		{"login_fingerprints", func() (int64, error) {
+			return q.DeleteConflictingLoginFingerprints(ctx, db.DeleteConflictingLoginFingerprintsParams{SourceEmail: source.Email, TargetEmail: target.Email})
+		}},
+	}
+	for _, c := range conflicts {
+		dropped, err := c.run()
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s conflicts: %w", c.resource, err)
+		}
+		if dropped > 0 {
+			report.Conflicts = append(report.Conflicts, Conflict{Resource: c.resource, Count: dropped, Resolution: keptTarget})
+		}
+	}
+
+	moves := []step{
+		{"identities", func() (int64, error) {
+			return q.MoveUserIdentities(ctx, db.MoveUserIdentitiesParams{TargetUserID: target.ID, SourceUserID: source.ID})
+		}},
+		{"account_merges", func() (int64, error) {
+			return q.MoveAccountMerges(ctx, db.MoveAccountMergesParams{TargetUserID: target.ID, SourceUserID: source.ID})
+		}},
+		{"external_ids", func() (int64, error) {
+			return q.MoveUserExternalIDs(ctx, db.MoveUserExternalIDsParams{TargetUserID: target.ID, SourceUserID: source.ID})
+		}},
+		{"push_subscriptions", func() (int64, error) {
+			return q.MovePushSubscriptions(ctx, db.MovePushSubscriptionsParams{TargetUserID: target.ID, SourceUserID: source.ID})
+		}},
+		{"notification_preferences", func() (int64, error) {
+			return q.MoveNotificationPreferences(ctx, db.MoveNotificationPreferencesParams{TargetUserID: target.ID, SourceUserID: source.ID})
+		}},
+		{"notifications", func() (int64, error) {
+			return q.MoveNotifications(ctx, db.MoveNotificationsParams{TargetUserID: target.ID, SourceUserID: source.ID})
+		}},
+		{"notification_read_cursors", func() (int64, error) {
+			return q.MoveNotificationReadCursors(ctx, db.MoveNotificationReadCursorsParams{TargetUserID: target.ID, SourceUserID: source.ID})
+		}},
+		{"passkeys", func() (int64, error) {
+			return q.MoveWebauthnCredentials(ctx, db.MoveWebauthnCredentialsParams{TargetUserID: target.ID, SourceUserID: source.ID})
+		}},
+		{"email_messages", func() (int64, error) {
+			return q.MoveEmailMessages(ctx, db.MoveEmailMessagesParams{TargetUserID: target.ID, SourceUserID: source.ID})
+		}},
+		{"service_accounts", func() (int64, error) {
+			return q.MoveServiceAccountOwners(ctx, db.MoveServiceAccountOwnersParams{TargetUserID: target.ID, SourceUserID: source.ID})
+		}},
+		{"uploads", func() (int64, error) {
+			return q.MoveUploads(ctx, db.MoveUploadsParams{TargetEmail: target.Email, SourceEmail: source.Email})
+		}},
+		{"operations", func() (int64, error) {
+			return q.MoveOperations(ctx, db.MoveOperationsParams{TargetEmail: target.Email, SourceEmail: source.Email})
+		}},
+		{"short_links", func() (int64, error) {
+			return q.MoveShortLinks(ctx, db.MoveShortLinksParams{TargetEmail: target.Email, SourceEmail: source.Email})
+		}},
+		{"api_keys", func() (int64, error) {
+			return q.MoveAPIKeys(ctx, db.MoveAPIKeysParams{TargetEmail: target.Email, SourceEmail: source.Email})
+		}},
+		{"calendar_invites", func() (int64, error) {
+			return q.MoveCalendarInvites(ctx, db.MoveCalendarInvitesParams{TargetEmail: target.Email, SourceEmail: source.Email})
+		}},
+		{"sessions", func() (int64, error) {
+			return q.MoveUserSessions(ctx, db.MoveUserSessionsParams{TargetEmail: target.Email, SourceEmail: source.Email})
+		}},
+		{"login_fingerprints", func() (int64, error) {
+			return q.MoveLoginFingerprints(ctx, db.MoveLoginFingerprintsParams{TargetEmail: target.Email, SourceEmail: source.Email})
+		}},
+		{"security_events", func() (int64, error) {
+			return q.MoveSecurityEvents(ctx, db.MoveSecurityEventsParams{TargetEmail: target.Email, SourceEmail: source.Email})
+		}},
+		// Usage on days both users had any is added together
+		{"ai_usage", func() (int64, error) {
+			return q.MergeAIUsage(ctx, db.MergeAIUsageParams{SourceEmail: source.Email, TargetEmail: target.Email})
+		}},
+	}
+	for _, m := range moves {
+		moved, err := m.run()
+		if err != nil {
+			return fmt.Errorf("failed to move %s: %w", m.resource, err)
+		}
+		if moved > 0 {
+			report.Moved[m.resource] = moved
+		}
+	}
+
+	// The source's sessions would sign in to a deactivated account
+	revoked, err := q.RevokeUserAuthSessions(ctx, source.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke source sessions: %w", err)
+	}
+	report.RevokedSessions = revoked
+	return nil
+}
+
+// profileConflicts reports the profile fields the source has set to
+// something other than the target's
+func profileConflicts(target, source db.GetAccountUserRow) []Conflict {
+	conflicts := []Conflict{}
+	fields := []struct {
+		name           string
+		target, source string
+	}{
+		{"name", target.Name, source.Name},
+		{"phone", target.Phone.String, source.Phone.String},
+		{"bio", target.Bio, source.Bio},
+	}
+	for _, f := range fields {
+		if f.source != "" && f.source != f.target {
+			conflicts = append(conflicts, Conflict{Resource: "user", Field: f.name, Resolution: keptTarget})
+		}
+	}
+	return conflicts
+}
+
+func (s *Service) user(ctx context.Context, actor string) (pgtype.UUID, error) {
+	if actor == "" {
+		return pgtype.UUID{}, ErrUnauthenticated
+	}
+	id, err := s.queries.GetActiveUserIDByEmail(ctx, actor)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return pgtype.UUID{}, ErrUserNotFound
+	}
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return id, nil
+}
+
+// forget drops this replica's cached accounts after links change; other
+// replicas catch up within the cache TTL
+func (s *Service) forget() {
+	if s.resolved != nil {
+		s.resolved.Clear()
+	}
+}
+
+func (s *Service) record(ctx context.Context, actor, action string, userID pgtype.UUID, metadata map[string]any) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "user",
+		ResourceID:   uuid.UUID(userID.Bytes).String(),
+		Metadata:     metadata,
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+func toIdentity(row db.UserIdentity) Identity {
+	return Identity{
+		ID:        uuid.UUID(row.ID.Bytes),
+		Provider:  row.Provider,
+		Subject:   row.Subject,
+		Email:     row.Email,
+		CreatedAt: row.CreatedAt.Time,
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+)
+
+const (
+	anthropicBaseURL = "https://api.anthropic.com"
+	anthropicVersion = "2023-06-01"
+)
+
+// Anthropic streams replies from the Anthropic Messages API
+type Anthropic struct {
+	client *connectors.Client
+}
+
+// NewAnthropic creates a provider for the anthropic connector
+func NewAnthropic(conn config.ConnectorConfig) *Anthropic {
+	if conn.BaseURL == "" {
+		conn.BaseURL = anthropicBaseURL
+	}
+	auth := func(req *http.Request) {
+		req.Header.Set("x-api-key", conn.Token)
+		req.Header.Set("anthropic-version", anthropicVersion)
+	}
+	return &Anthropic{client: connectors.NewClient("anthropic", conn, auth)}
+}
+
+func (a *Anthropic) Name() string {
+	return a.client.Name()
+}
+
+// Check lists the models, which needs a valid API key
+func (a *Anthropic) Check(ctx context.Context) error {
+	return a.client.Do(ctx, http.MethodGet, "/v1/models", nil, nil)
+}
+
+func (a *Anthropic) DefaultModel() string {
+	return "claude-3-5-haiku-latest"
+}
+
+type anthropicEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Usage struct {
+			InputTokens int64 `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int64 `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (a *Anthropic) Stream(ctx context.Context, req Completion, emit func(text string) error) (Usage, error) {
+	resp, err := a.client.Open(ctx, http.MethodPost, "/v1/messages", map[string]any{
+		"model":      req.Model,
+		"system":     req.System,
+		"messages":   req.Messages,
+		"max_tokens": req.MaxTokens,
+		"stream":     true,
+	})
+	if err != nil {
+		return Usage{}, err
+	}
+	defer resp.Body.Close()
+
+	var usage Usage
+	err = readEvents(resp.Body, func(_, data string) (bool, error) {
+		var event anthropicEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return false, fmt.Errorf("failed to decode anthropic event: %w", err)
+		}
+		switch event.Type {
+		case "message_start":
+			usage.InputTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				if err := emit(event.Delta.Text); err != nil {
+					return false, err
+				}
+			}
+		case "message_delta":
+			usage.OutputTokens = event.Usage.OutputTokens
+		case "message_stop":
+			return false, nil
+		case "error":
+			kind := connectors.ErrUnavailable
+			if event.Error.Type == "rate_limit_error" {
+				kind = connectors.ErrRateLimited
+			}
+			return false, &connectors.Error{Connector: a.Name(), Message: event.Error.Message, Kind: kind}
+		}
+		return true, nil
+	})
+	return usage, err
+}
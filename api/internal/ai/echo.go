@@ -0,0 +1,47 @@
+package ai
+
+import (
+	"context"
+	"strings"
+)
+
+// Echo answers without a model so the assistant can be developed offline
+type Echo struct{}
+
+func (Echo) Name() string {
+	return "echo"
+}
+
+func (Echo) Check(ctx context.Context) error {
+	return nil
+}
+
+func (Echo) DefaultModel() string {
+	return "echo"
+}
+
+// Stream replies with the prompt, a word at a time
+func (Echo) Stream(ctx context.Context, req Completion, emit func(text string) error) (Usage, error) {
+	prompt := req.Messages[len(req.Messages)-1].Content
+	reply := "You asked: " + prompt
+
+	var output strings.Builder
+	for i, word := range strings.Fields(reply) {
+		if i > 0 {
+			word = " " + word
+		}
+		if estimateTokens(output.String()+word) > int64(req.MaxTokens) {
+			break
+		}
+		if err := emit(word); err != nil {
+			return Usage{}, err
+		}
+		output.WriteString(word)
+	}
+
+	input := estimateTokens(req.System)
+	for _, m := range req.Messages {
+		input += estimateTokens(m.Content)
+	}
+	return Usage{InputTokens: input, OutputTokens: estimateTokens(output.String())}, nil
+}
@@ -0,0 +1,186 @@
+package ai
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+)
+
+// hashDimensions is the vector size of the hash embedder by default
+const hashDimensions = 256
+
+// Embedder turns text into vectors for semantic search
+type Embedder interface {
+	connectors.Connector
+	// Model identifies the vector space; vectors of different models are
+	// never compared
+	Model() string
+	// Embed returns one vector per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedder creates the embedder selected in configuration
+func NewEmbedder(cfg config.EmbeddingsConfig, conns config.ConnectorsConfig) (Embedder, error) {
+	if cfg.Provider == "hash" || cfg.Provider == "" {
+		return NewHashEmbedder(cfg.Dimensions), nil
+	}
+
+	conn, ok := conns.Provider(cfg.Provider)
+	if !ok {
+		return nil, fmt.Errorf("embeddings provider %s requires CONNECTORS to include %s", cfg.Provider, cfg.Provider)
+	}
+	switch cfg.Provider {
+	case "openai":
+		return NewOpenAIEmbedder("openai", conn, openAIBaseURL, cmp.Or(cfg.Model, "text-embedding-3-small"), cfg.Dimensions), nil
+	case "local":
+		return NewOpenAIEmbedder("local", conn, localBaseURL, cmp.Or(cfg.Model, "nomic-embed-text"), cfg.Dimensions), nil
+	default:
+		return nil, fmt.Errorf("unsupported embeddings provider: %s", cfg.Provider)
+	}
+}
+
+// HashEmbedder hashes words into a fixed-size vector. It needs no model,
+// so semantic search can be developed offline, but only matches shared
+// words.
+type HashEmbedder struct {
+	dimensions int
+}
+
+func NewHashEmbedder(dimensions int) HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = hashDimensions
+	}
+	return HashEmbedder{dimensions: dimensions}
+}
+
+func (HashEmbedder) Name() string {
+	return "hash"
+}
+
+func (HashEmbedder) Check(ctx context.Context) error {
+	return nil
+}
+
+func (e HashEmbedder) Model() string {
+	return fmt.Sprintf("hash/%d", e.dimensions)
+}
+
+func (e HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, e.dimensions)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, word := range words {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			sum := h.Sum32()
+			// The top bit picks a sign so collisions tend to cancel out
+			if sum&(1<<31) != 0 {
+				vector[sum%uint32(e.dimensions)]--
+			} else {
+				vector[sum%uint32(e.dimensions)]++
+			}
+		}
+		vectors[i] = normalize(vector)
+	}
+	return vectors, nil
+}
+
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
+
+// OpenAIEmbedder embeds text with the OpenAI API or a server compatible
+// with it
+type OpenAIEmbedder struct {
+	client     *connectors.Client
+	model      string
+	dimensions int
+}
+
+// NewOpenAIEmbedder creates an embedder for the named connector, using
+// baseURL when the connector sets none
+func NewOpenAIEmbedder(name string, conn config.ConnectorConfig, baseURL, model string, dimensions int) *OpenAIEmbedder {
+	if conn.BaseURL == "" {
+		conn.BaseURL = baseURL
+	}
+	auth := connectors.NoAuth()
+	if conn.Token != "" {
+		auth = connectors.BearerToken(conn.Token)
+	}
+	return &OpenAIEmbedder{
+		client:     connectors.NewClient(name, conn, auth),
+		model:      model,
+		dimensions: dimensions,
+	}
+}
+
+func (o *OpenAIEmbedder) Name() string {
+	return o.client.Name()
+}
+
+// Check lists the models, which needs a valid API key
+func (o *OpenAIEmbedder) Check(ctx context.Context) error {
+	return o.client.Do(ctx, http.MethodGet, "/models", nil, nil)
+}
+
+func (o *OpenAIEmbedder) Model() string {
+	if o.dimensions > 0 {
+		return fmt.Sprintf("%s/%d", o.model, o.dimensions)
+	}
+	return o.model
+}
+
+func (o *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body := map[string]any{
+		"model": o.model,
+		"input": texts,
+	}
+	if o.dimensions > 0 {
+		body["dimensions"] = o.dimensions
+	}
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := o.client.Do(ctx, http.MethodPost, "/embeddings", body, &resp); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("%s returned an embedding for unknown input %d", o.Name(), d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("%s returned no embedding for input %d", o.Name(), i)
+		}
+	}
+	return vectors, nil
+}
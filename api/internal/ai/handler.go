@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"starterkit/internal/connectors"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/sse"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleAssist streams the assistant's reply as server-sent events: "delta"
+// events carry text, then "done" reports usage or "error" ends a stream
+// that failed part way. Requests rejected before the reply starts get a
+// plain JSON error.
+func (h *Handler) HandleAssist() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AssistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		stream := sse.NewWriter(w)
+		done, err := h.service.Assist(r.Context(), actorFromRequest(r), req, func(text string) error {
+			return stream.Send(sse.Message{Event: "delta", Data: Delta{Text: text}})
+		})
+		if err != nil {
+			if r.Context().Err() != nil {
+				return
+			}
+			if stream.Started() {
+				h.logger.Warn("assistant stream failed", "error", err)
+				_ = stream.Send(sse.Message{Event: "error", Data: map[string]string{"error": "the assistant stopped responding"}})
+				return
+			}
+			h.handleServiceError(w, r, err)
+			return
+		}
+		if err := stream.Send(sse.Message{Event: "done", Data: done}); err != nil {
+			h.logger.Debug("assistant stream closed", "error", err)
+		}
+	}
+}
+
+func (h *Handler) handleServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	var connErr *connectors.Error
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "unauthenticated", err.Error()))
+	case errors.Is(err, ErrInvalidPrompt):
+		apierror.Write(w, r, apierror.BadRequest("invalid_prompt", err.Error()))
+	case errors.Is(err, ErrRateLimited):
+		w.Header().Set("Retry-After", strconv.Itoa(int(max(h.service.cfg.RateWindow.Seconds(), 1))))
+		apierror.Write(w, r, apierror.TooManyRequests("rate_limited", err.Error()))
+	case errors.Is(err, ErrBudgetExceeded):
+		apierror.Write(w, r, apierror.TooManyRequests("budget_exceeded", err.Error()))
+	case errors.As(err, &connErr):
+		h.logger.Error("assistant provider failed", "error", err)
+		apierror.Write(w, r, apierror.New(http.StatusBadGateway, "assistant_unavailable", "the assistant is unavailable"))
+	default:
+		h.logger.Error("failed to assist", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
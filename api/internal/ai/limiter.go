@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter counts requests per user in fixed windows. Counts are kept in
+// memory, so each replica enforces the limit on its own traffic.
+type limiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	counts  map[string]int
+	started time.Time
+}
+
+func newLimiter(limit int, window time.Duration) *limiter {
+	return &limiter{
+		limit:   limit,
+		window:  window,
+		counts:  make(map[string]int),
+		started: time.Now(),
+	}
+}
+
+// allow records a request by user and reports whether it is within the limit
+func (l *limiter) allow(user string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.started) >= l.window {
+		clear(l.counts)
+		l.started = time.Now()
+	}
+	l.counts[user]++
+	return l.limit <= 0 || l.counts[user] <= l.limit
+}
@@ -0,0 +1,56 @@
+package ai
+
+import "errors"
+
+var (
+	ErrUnauthenticated = errors.New("X-User-Email header is required")
+	ErrInvalidPrompt   = errors.New("invalid prompt")
+	ErrRateLimited     = errors.New("too many assistant requests, try again shortly")
+	ErrBudgetExceeded  = errors.New("daily assistant token budget used up")
+)
+
+// Message roles
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// maxMessages bounds the conversation history sent with a prompt
+const maxMessages = 20
+
+// Message is one turn of the conversation
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// AssistRequest is the body of POST /api/v1/assist. The last message is the
+// user's prompt; earlier ones are the conversation so far.
+type AssistRequest struct {
+	Messages []Message `json:"messages"`
+}
+
+// Completion is a provider-agnostic generation request
+type Completion struct {
+	Model     string
+	System    string
+	Messages  []Message
+	MaxTokens int
+}
+
+// Usage is the tokens a completion consumed
+type Usage struct {
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+}
+
+// Delta is streamed to the client for each piece of the reply
+type Delta struct {
+	Text string `json:"text"`
+}
+
+// Done ends a successful stream
+type Done struct {
+	Usage           Usage `json:"usage"`
+	TokensRemaining int64 `json:"tokens_remaining"`
+}
@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+)
+
+const (
+	openAIBaseURL = "https://api.openai.com/v1"
+	localBaseURL  = "http://localhost:11434/v1"
+)
+
+// OpenAI streams chat completions from the OpenAI API or a server
+// compatible with it
+type OpenAI struct {
+	client       *connectors.Client
+	defaultModel string
+}
+
+// NewOpenAI creates a provider for the named connector, using baseURL when
+// the connector sets none
+func NewOpenAI(name string, conn config.ConnectorConfig, baseURL, defaultModel string) *OpenAI {
+	if conn.BaseURL == "" {
+		conn.BaseURL = baseURL
+	}
+	auth := connectors.NoAuth()
+	if conn.Token != "" {
+		auth = connectors.BearerToken(conn.Token)
+	}
+	return &OpenAI{
+		client:       connectors.NewClient(name, conn, auth),
+		defaultModel: defaultModel,
+	}
+}
+
+func (o *OpenAI) Name() string {
+	return o.client.Name()
+}
+
+// Check lists the models, which needs a valid API key
+func (o *OpenAI) Check(ctx context.Context) error {
+	return o.client.Do(ctx, http.MethodGet, "/models", nil, nil)
+}
+
+func (o *OpenAI) DefaultModel() string {
+	return o.defaultModel
+}
+
+type openAIChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (o *OpenAI) Stream(ctx context.Context, req Completion, emit func(text string) error) (Usage, error) {
+	messages := make([]Message, 0, len(req.Messages)+1)
+	messages = append(messages, Message{Role: "system", Content: req.System})
+	messages = append(messages, req.Messages...)
+
+	resp, err := o.client.Open(ctx, http.MethodPost, "/chat/completions", map[string]any{
+		"model":          req.Model,
+		"messages":       messages,
+		"max_tokens":     req.MaxTokens,
+		"stream":         true,
+		"stream_options": map[string]bool{"include_usage": true},
+	})
+	if err != nil {
+		return Usage{}, err
+	}
+	defer resp.Body.Close()
+
+	var usage Usage
+	err = readEvents(resp.Body, func(_, data string) (bool, error) {
+		if data == "[DONE]" {
+			return false, nil
+		}
+		var chunk openAIChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, fmt.Errorf("failed to decode %s chunk: %w", o.Name(), err)
+		}
+		if chunk.Usage != nil {
+			usage = Usage{InputTokens: chunk.Usage.PromptTokens, OutputTokens: chunk.Usage.CompletionTokens}
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if err := emit(choice.Delta.Content); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+	return usage, err
+}
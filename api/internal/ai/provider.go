@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+)
+
+// Provider generates replies with a language model
+type Provider interface {
+	connectors.Connector
+	// Stream generates a reply, passing text to emit as it arrives
+	Stream(ctx context.Context, req Completion, emit func(text string) error) (Usage, error)
+	// DefaultModel is used when AI_MODEL is not set
+	DefaultModel() string
+}
+
+// New creates the provider selected in configuration
+func New(cfg config.AIConfig, conns config.ConnectorsConfig) (Provider, error) {
+	if cfg.Provider == "echo" || cfg.Provider == "" {
+		return Echo{}, nil
+	}
+
+	conn, ok := conns.Provider(cfg.Provider)
+	if !ok {
+		return nil, fmt.Errorf("AI provider %s requires CONNECTORS to include %s", cfg.Provider, cfg.Provider)
+	}
+	switch cfg.Provider {
+	case "openai":
+		return NewOpenAI("openai", conn, openAIBaseURL, "gpt-4o-mini"), nil
+	case "local":
+		// Ollama, vLLM, and llama.cpp serve the OpenAI API
+		return NewOpenAI("local", conn, localBaseURL, "llama3.2"), nil
+	case "anthropic":
+		return NewAnthropic(conn), nil
+	default:
+		return nil, fmt.Errorf("unsupported AI provider: %s", cfg.Provider)
+	}
+}
+
+// readEvents calls fn with the event name and data of each server-sent
+// event in r until fn returns false or r ends
+func readEvents(r io.Reader, fn func(event, data string) (bool, error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event string
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				more, err := fn(event, data.String())
+				if err != nil || !more {
+					return err
+				}
+			}
+			event = ""
+			data.Reset()
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read model stream: %w", err)
+	}
+	return nil
+}
+
+// estimateTokens approximates a token count when a provider reports none
+func estimateTokens(text string) int64 {
+	return int64(len(text)+3) / 4
+}
@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/logger"
+)
+
+type Querier interface {
+	GetAITokensToday(ctx context.Context, userEmail string) (int64, error)
+	AddAIUsage(ctx context.Context, arg db.AddAIUsageParams) error
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+type Service struct {
+	provider Provider
+	queries  Querier
+	auditor  Auditor
+	limiter  *limiter
+	cfg      config.AIConfig
+	model    string
+	logger   *slog.Logger
+}
+
+func NewService(provider Provider, queries Querier, auditor Auditor, cfg config.AIConfig, logger *slog.Logger) *Service {
+	model := cfg.Model
+	if model == "" {
+		model = provider.DefaultModel()
+	}
+	return &Service{
+		provider: provider,
+		queries:  queries,
+		auditor:  auditor,
+		limiter:  newLimiter(cfg.RateLimit, cfg.RateWindow),
+		cfg:      cfg,
+		model:    model,
+		logger:   logger,
+	}
+}
+
+// Assist streams a reply to the conversation through emit. Usage is charged
+// to the user's daily budget and the exchange is audit-logged even if the
+// stream fails part way, estimating tokens the provider did not report.
+func (s *Service) Assist(ctx context.Context, user string, req AssistRequest, emit func(text string) error) (*Done, error) {
+	if user == "" {
+		return nil, ErrUnauthenticated
+	}
+	if err := s.validate(req.Messages); err != nil {
+		return nil, err
+	}
+	if !s.limiter.allow(user) {
+		return nil, ErrRateLimited
+	}
+
+	used, err := s.queries.GetAITokensToday(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assistant usage: %w", err)
+	}
+	remaining := s.cfg.DailyTokenBudget - used
+	if remaining <= 0 {
+		return nil, ErrBudgetExceeded
+	}
+
+	var reply strings.Builder
+	usage, streamErr := s.provider.Stream(ctx, Completion{
+		Model:     s.model,
+		System:    s.cfg.SystemPrompt,
+		Messages:  req.Messages,
+		MaxTokens: int(min(int64(s.cfg.MaxTokens), remaining)),
+	}, func(text string) error {
+		reply.WriteString(text)
+		return emit(text)
+	})
+
+	if usage.InputTokens == 0 {
+		usage.InputTokens = estimateTokens(s.cfg.SystemPrompt)
+		for _, m := range req.Messages {
+			usage.InputTokens += estimateTokens(m.Content)
+		}
+	}
+	if usage.OutputTokens == 0 {
+		usage.OutputTokens = estimateTokens(reply.String())
+	}
+
+	// The client may have gone away; the usage still counts
+	recordCtx := context.WithoutCancel(ctx)
+	if err := s.queries.AddAIUsage(recordCtx, db.AddAIUsageParams{
+		UserEmail:    user,
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+	}); err != nil {
+		logger.FromContext(ctx).Warn("failed to record assistant usage", "error", err)
+	}
+	s.record(recordCtx, user, req.Messages[len(req.Messages)-1].Content, reply.String(), usage, streamErr)
+
+	if streamErr != nil {
+		return nil, streamErr
+	}
+	return &Done{
+		Usage:           usage,
+		TokensRemaining: max(remaining-usage.InputTokens-usage.OutputTokens, 0),
+	}, nil
+}
+
+func (s *Service) validate(messages []Message) error {
+	if len(messages) == 0 {
+		return fmt.Errorf("%w: messages are required", ErrInvalidPrompt)
+	}
+	if len(messages) > maxMessages {
+		return fmt.Errorf("%w: at most %d messages are allowed", ErrInvalidPrompt, maxMessages)
+	}
+	total := 0
+	for _, m := range messages {
+		if m.Role != RoleUser && m.Role != RoleAssistant {
+			return fmt.Errorf("%w: role must be user or assistant", ErrInvalidPrompt)
+		}
+		total += len(m.Content)
+	}
+	last := messages[len(messages)-1]
+	if last.Role != RoleUser || strings.TrimSpace(last.Content) == "" {
+		return fmt.Errorf("%w: the last message must be a non-empty user message", ErrInvalidPrompt)
+	}
+	if total > s.cfg.MaxPromptChars {
+		return fmt.Errorf("%w: conversation exceeds %d characters", ErrInvalidPrompt, s.cfg.MaxPromptChars)
+	}
+	return nil
+}
+
+// record writes the exchange to the audit log; failures are logged since
+// the reply has already been sent
+func (s *Service) record(ctx context.Context, user, prompt, response string, usage Usage, streamErr error) {
+	metadata := map[string]any{
+		"provider":      s.provider.Name(),
+		"model":         s.model,
+		"prompt":        prompt,
+		"response":      response,
+		"input_tokens":  usage.InputTokens,
+		"output_tokens": usage.OutputTokens,
+	}
+	if streamErr != nil && !errors.Is(streamErr, context.Canceled) {
+		metadata["error"] = streamErr.Error()
+	}
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        user,
+		Action:       "ai.assist",
+		ResourceType: "assistant",
+		ResourceID:   s.provider.Name(),
+		Metadata:     metadata,
+	}); err != nil {
+		logger.FromContext(ctx).Warn("failed to record audit entry", "error", err, "action", "ai.assist")
+	}
+}
@@ -0,0 +1,131 @@
+package announcements
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/request"
+)
+
+const (
+	// FeedPath is where the Atom feed is served
+	FeedPath = "/feeds/announcements.atom"
+	// ContentType is the media type of the feed
+	ContentType = "application/atom+xml"
+)
+
+type ServiceInterface interface {
+	Create(ctx context.Context, req AnnouncementRequest, actor string) (*Announcement, error)
+	Feed(ctx context.Context) (*Feed, error)
+}
+
+type Handler struct {
+	service  ServiceInterface
+	cacheTTL time.Duration
+	logger   *slog.Logger
+}
+
+// NewHandler creates the announcement handler. Feed responses may be
+// cached by clients and proxies for cacheTTL.
+func NewHandler(service ServiceInterface, cacheTTL time.Duration, logger *slog.Logger) *Handler {
+	return &Handler{
+		service:  service,
+		cacheTTL: cacheTTL,
+		logger:   logger,
+	}
+}
+
+// HandleCreate publishes an announcement
+func (h *Handler) HandleCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AnnouncementRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		announcement, err := h.service.Create(r.Context(), req, r.Header.Get("X-User-Email"))
+		if err != nil {
+			h.logger.Error("failed to create announcement", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(announcement); err != nil {
+			h.logger.Error("failed to encode response", "error", err)
+		}
+	}
+}
+
+// HandleFeed serves the Atom feed. Clients revalidating with the ETag or
+// Last-Modified date of the feed they hold get 304 Not Modified.
+func (h *Handler) HandleFeed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feed, err := h.service.Feed(r.Context())
+		if err != nil {
+			h.logger.Error("failed to render announcements feed", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(h.cacheTTL.Seconds())))
+		w.Header().Set("ETag", feed.ETag)
+		w.Header().Set("Last-Modified", feed.LastModified.UTC().Format(http.TimeFormat))
+
+		if notModified(r, feed) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", ContentType+"; charset=utf-8")
+		w.Header().Set("Content-Length", strconv.Itoa(len(feed.Body)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodHead {
+			return
+		}
+		if _, err := w.Write(feed.Body); err != nil {
+			h.logger.Error("failed to write announcements feed", "error", err)
+		}
+	}
+}
+
+// notModified evaluates the request's preconditions as RFC 9110 orders
+// them: If-None-Match wins over If-Modified-Since when both are sent
+func notModified(r *http.Request, feed *Feed) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range splitETags(match) {
+			if tag == "*" || weakMatch(tag, feed.ETag) {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !feed.LastModified.After(since)
+	}
+	return false
+}
+
+func splitETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// weakMatch compares entity tags ignoring the weak prefix, as revalidation
+// does
+func weakMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
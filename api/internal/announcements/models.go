@@ -0,0 +1,57 @@
+package announcements
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxTitleLength = 200
+	maxBodyLength  = 10000
+	maxLinkLength  = 2048
+)
+
+// Announcement is a public notice published in the announcements feed
+type Announcement struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body"`
+	Link        string    `json:"link,omitempty"`
+	CreatedBy   string    `json:"created_by"`
+	PublishedAt time.Time `json:"published_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// AnnouncementRequest creates an announcement, published at once or at
+// PublishedAt
+type AnnouncementRequest struct {
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	Link        string     `json:"link"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+// Validate trims the title and body and checks the link is an absolute
+// http or https URL
+func (r *AnnouncementRequest) Validate() error {
+	r.Title = strings.TrimSpace(r.Title)
+	r.Body = strings.TrimSpace(r.Body)
+	r.Link = strings.TrimSpace(r.Link)
+
+	var v request.Validation
+	v.Check(r.Title != "" && utf8.RuneCountInString(r.Title) <= maxTitleLength, "title", fmt.Sprintf("must be 1-%d characters", maxTitleLength))
+	v.Check(r.Body != "" && utf8.RuneCountInString(r.Body) <= maxBodyLength, "body", fmt.Sprintf("must be 1-%d characters", maxBodyLength))
+	if r.Link != "" {
+		u, err := url.Parse(r.Link)
+		v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && len(r.Link) <= maxLinkLength,
+			"link", fmt.Sprintf("must be an http or https URL of at most %d characters", maxLinkLength))
+	}
+	return v.Err()
+}
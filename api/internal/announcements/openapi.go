@@ -0,0 +1,28 @@
+package announcements
+
+import (
+	"net/http"
+
+	"starterkit/internal/openapi"
+)
+
+// Describe registers the public announcements feed in the OpenAPI document
+func Describe(api *openapi.Registry) {
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        FeedPath,
+		ID:          "getAnnouncementsFeed",
+		Summary:     "Announcements feed",
+		Description: "Returns the latest published announcements as an Atom feed. Responses carry an ETag and Last-Modified date; revalidating with If-None-Match or If-Modified-Since returns 304 when the feed is unchanged.",
+		Tags:        []string{"Announcements"},
+		Parameters: []openapi.Parameter{
+			{Name: "If-None-Match", In: "header", Description: "ETag of a cached copy of the feed", Schema: openapi.String("")},
+			{Name: "If-Modified-Since", In: "header", Description: "Last-Modified date of a cached copy of the feed", Schema: openapi.String("")},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Atom feed", Body: openapi.String(""), ContentType: ContentType},
+			{Status: http.StatusNotModified, Description: "The cached copy is current"},
+			openapi.Problem(http.StatusInternalServerError, "Internal server error"),
+		},
+	})
+}
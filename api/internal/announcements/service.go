@@ -0,0 +1,278 @@
+package announcements
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Querier interface {
+	CreateAnnouncement(ctx context.Context, arg db.CreateAnnouncementParams) (db.Announcement, error)
+	ListPublishedAnnouncements(ctx context.Context, rowLimit int32) ([]db.Announcement, error)
+	ListAnnouncementSitemapPages(ctx context.Context, arg db.ListAnnouncementSitemapPagesParams) ([]db.ListAnnouncementSitemapPagesRow, error)
+	ListAnnouncementSitemapEntries(ctx context.Context, arg db.ListAnnouncementSitemapEntriesParams) ([]db.ListAnnouncementSitemapEntriesRow, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Options describe the feed
+type Options struct {
+	Title string
+	// BaseURL is the public origin the feed is served from; it names the
+	// feed and its entries
+	BaseURL string
+	// Size is how many of the latest announcements the feed holds
+	Size int
+	// CacheTTL is how long a rendered feed is served before the
+	// announcements are read again
+	CacheTTL time.Duration
+}
+
+// Feed is a rendered Atom document with its validators
+type Feed struct {
+	Body         []byte
+	ETag         string
+	LastModified time.Time
+}
+
+// Service publishes announcements and renders them as an Atom feed
+type Service struct {
+	queries Querier
+	auditor Auditor
+	opts    Options
+	logger  *slog.Logger
+
+	mu       sync.Mutex
+	feed     *Feed
+	rendered time.Time
+}
+
+func NewService(queries Querier, auditor Auditor, opts Options, logger *slog.Logger) *Service {
+	return &Service{
+		queries: queries,
+		auditor: auditor,
+		opts:    opts,
+		logger:  logger,
+	}
+}
+
+// Create stores an announcement, published at once unless it is scheduled
+func (s *Service) Create(ctx context.Context, req AnnouncementRequest, actor string) (*Announcement, error) {
+	publishedAt := pgtype.Timestamptz{}
+	if req.PublishedAt != nil {
+		publishedAt = pgtype.Timestamptz{Time: *req.PublishedAt, Valid: true}
+	}
+
+	row, err := s.queries.CreateAnnouncement(ctx, db.CreateAnnouncementParams{
+		Title:       req.Title,
+		Body:        req.Body,
+		Link:        req.Link,
+		CreatedBy:   actor,
+		PublishedAt: publishedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create announcement: %w", err)
+	}
+
+	announcement := toAnnouncement(row)
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "announcement.create",
+		ResourceType: "announcement",
+		ResourceID:   announcement.ID.String(),
+		Metadata:     map[string]any{"title": announcement.Title},
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", "announcement.create")
+	}
+
+	// Drop the cached feed so a new announcement shows up at once on this
+	// replica; others pick it up when their cache expires
+	s.mu.Lock()
+	s.feed = nil
+	s.mu.Unlock()
+	return announcement, nil
+}
+
+// Feed returns the Atom feed of the latest published announcements,
+// rendering it at most once per cache TTL
+func (s *Service) Feed(ctx context.Context) (*Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.feed != nil && time.Since(s.rendered) < s.opts.CacheTTL {
+		return s.feed, nil
+	}
+
+	rows, err := s.queries.ListPublishedAnnouncements(ctx, int32(s.opts.Size))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list announcements: %w", err)
+	}
+	feed, err := s.render(rows)
+	if err != nil {
+		return nil, err
+	}
+	s.feed, s.rendered = feed, time.Now()
+	return feed, nil
+}
+
+// LinkedPage is a page an announcement links to
+type LinkedPage struct {
+	URL       string
+	UpdatedAt time.Time
+}
+
+// LinkPages splits the links of published announcements under prefix
+// into pages of size and returns when each page last changed, so sitemaps
+// can list the site's pages that announcements point to
+func (s *Service) LinkPages(ctx context.Context, prefix string, size int) ([]time.Time, error) {
+	rows, err := s.queries.ListAnnouncementSitemapPages(ctx, db.ListAnnouncementSitemapPagesParams{
+		PageSize:   int32(size),
+		LinkPrefix: prefix,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list announcement sitemap pages: %w", err)
+	}
+	pages := make([]time.Time, len(rows))
+	for _, row := range rows {
+		if int(row.Page) < len(pages) {
+			pages[row.Page] = row.LastModified.Time
+		}
+	}
+	return pages, nil
+}
+
+// Links returns one page, counted from zero, of the links of published
+// announcements under prefix
+func (s *Service) Links(ctx context.Context, prefix string, page, size int) ([]LinkedPage, error) {
+	rows, err := s.queries.ListAnnouncementSitemapEntries(ctx, db.ListAnnouncementSitemapEntriesParams{
+		LinkPrefix: prefix,
+		RowLimit:   int32(size),
+		RowOffset:  int32(page * size),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list announcement links: %w", err)
+	}
+	linked := make([]LinkedPage, len(rows))
+	for i, row := range rows {
+		linked[i] = LinkedPage{URL: row.Link, UpdatedAt: row.UpdatedAt.Time}
+	}
+	return linked, nil
+}
+
+// atomFeed and atomEntry are the parts of RFC 4287 the feed uses
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Links     []atomLink `xml:"link,omitempty"`
+	Content   atomText   `xml:"content"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func (s *Service) render(rows []db.Announcement) (*Feed, error) {
+	feedURL := s.opts.BaseURL + FeedPath
+
+	// A feed with no entries still needs an updated date; the epoch keeps
+	// it stable so its ETag does not change between renders
+	updated := time.Unix(0, 0).UTC()
+	entries := make([]atomEntry, 0, len(rows))
+	for _, row := range rows {
+		a := toAnnouncement(row)
+		entryUpdated := a.PublishedAt
+		if a.UpdatedAt.After(entryUpdated) {
+			entryUpdated = a.UpdatedAt
+		}
+		if entryUpdated.After(updated) {
+			updated = entryUpdated
+		}
+
+		entry := atomEntry{
+			ID:        "urn:uuid:" + a.ID.String(),
+			Title:     a.Title,
+			Published: a.PublishedAt.UTC().Format(time.RFC3339),
+			Updated:   entryUpdated.UTC().Format(time.RFC3339),
+			Content:   atomText{Type: "text", Body: a.Body},
+		}
+		if a.Link != "" {
+			entry.Links = []atomLink{{Rel: "alternate", Href: a.Link}}
+		}
+		entries = append(entries, entry)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(atomFeed{
+		ID:      feedURL,
+		Title:   s.opts.Title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: ContentType, Href: feedURL},
+			{Rel: "alternate", Href: s.opts.BaseURL + "/"},
+		},
+		Author:  atomAuthor{Name: s.opts.Title},
+		Entries: entries,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to render feed: %w", err)
+	}
+	buf.WriteByte('\n')
+
+	sum := sha256.Sum256(buf.Bytes())
+	return &Feed{
+		Body:         buf.Bytes(),
+		ETag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+		LastModified: updated.Truncate(time.Second),
+	}, nil
+}
+
+func toAnnouncement(row db.Announcement) *Announcement {
+	return &Announcement{
+		ID:          uuid.UUID(row.ID.Bytes),
+		Title:       row.Title,
+		Body:        row.Body,
+		Link:        row.Link,
+		CreatedBy:   row.CreatedBy,
+		PublishedAt: row.PublishedAt.Time,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,284 @@
+package apikeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	ListPlans(ctx context.Context) ([]Plan, error)
+	PutPlan(ctx context.Context, name string, req PlanRequest, actor string) (*Plan, error)
+	ListKeys(ctx context.Context) ([]Key, error)
+	CreateKey(ctx context.Context, req KeyRequest, actor string) (*CreatedKey, error)
+	SetKeyPlan(ctx context.Context, id uuid.UUID, plan, actor string) (*Key, error)
+	RevokeKey(ctx context.Context, id uuid.UUID, actor string) (*Key, error)
+	ListOwnKeys(ctx context.Context, actor string) ([]Key, error)
+	CreateOwnKey(ctx context.Context, req OwnKeyRequest, actor string) (*CreatedKey, error)
+	RotateOwnKey(ctx context.Context, id uuid.UUID, actor string) (*CreatedKey, error)
+	RevokeOwnKey(ctx context.Context, id uuid.UUID, actor string) (*Key, error)
+	KeyUsage(ctx context.Context, id uuid.UUID, days int, actor string) (*KeyUsage, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) HandleListPlans() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		plans, err := h.service.ListPlans(r.Context())
+		if err != nil {
+			h.respondWithKeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, plans)
+	}
+}
+
+// HandlePutPlan creates a rate plan or changes its limits. Keys on the plan
+// get the new limits within the refresh interval.
+func (h *Handler) HandlePutPlan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req PlanRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		plan, err := h.service.PutPlan(r.Context(), r.PathValue("name"), req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithKeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, plan)
+	}
+}
+
+func (h *Handler) HandleListKeys() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := h.service.ListKeys(r.Context())
+		if err != nil {
+			h.respondWithKeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, keys)
+	}
+}
+
+// HandleCreateKey issues a key; the response is the only time its secret
+// is shown
+func (h *Handler) HandleCreateKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req KeyRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		key, err := h.service.CreateKey(r.Context(), req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithKeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusCreated, key)
+	}
+}
+
+func (h *Handler) HandleSetKeyPlan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keyID, ok := h.parseKeyID(w, r)
+		if !ok {
+			return
+		}
+
+		var req PlanChange
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		key, err := h.service.SetKeyPlan(r.Context(), keyID, req.Plan, actorFromRequest(r))
+		if err != nil {
+			h.respondWithKeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, key)
+	}
+}
+
+func (h *Handler) HandleRevokeKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keyID, ok := h.parseKeyID(w, r)
+		if !ok {
+			return
+		}
+
+		key, err := h.service.RevokeKey(r.Context(), keyID, actorFromRequest(r))
+		if err != nil {
+			h.respondWithKeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, key)
+	}
+}
+
+// HandleListOwnKeys lists the caller's keys for the developer portal
+func (h *Handler) HandleListOwnKeys() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := h.service.ListOwnKeys(r.Context(), actorFromRequest(r))
+		if err != nil {
+			h.respondWithKeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, keys)
+	}
+}
+
+// HandleCreateOwnKey issues a key to the caller; the response is the only
+// time its secret is shown
+func (h *Handler) HandleCreateOwnKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req OwnKeyRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		key, err := h.service.CreateOwnKey(r.Context(), req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithKeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusCreated, key)
+	}
+}
+
+// HandleRotateOwnKey replaces one of the caller's keys with a new secret
+func (h *Handler) HandleRotateOwnKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keyID, ok := h.parseKeyID(w, r)
+		if !ok {
+			return
+		}
+
+		key, err := h.service.RotateOwnKey(r.Context(), keyID, actorFromRequest(r))
+		if err != nil {
+			h.respondWithKeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusCreated, key)
+	}
+}
+
+func (h *Handler) HandleRevokeOwnKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keyID, ok := h.parseKeyID(w, r)
+		if !ok {
+			return
+		}
+
+		key, err := h.service.RevokeOwnKey(r.Context(), keyID, actorFromRequest(r))
+		if err != nil {
+			h.respondWithKeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, key)
+	}
+}
+
+// HandleKeyUsage returns the daily requests of one of the caller's keys;
+// days defaults to 30
+func (h *Handler) HandleKeyUsage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keyID, ok := h.parseKeyID(w, r)
+		if !ok {
+			return
+		}
+		days := 30
+		if raw := r.URL.Query().Get("days"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid days parameter"))
+				return
+			}
+			days = parsed
+		}
+
+		usage, err := h.service.KeyUsage(r.Context(), keyID, days, actorFromRequest(r))
+		if err != nil {
+			h.respondWithKeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, usage)
+	}
+}
+
+func (h *Handler) parseKeyID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	keyID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_api_key_id", "invalid API key ID format"))
+		return uuid.Nil, false
+	}
+	return keyID, true
+}
+
+func (h *Handler) respondWithKeyError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		apierror.Write(w, r, apierror.NotFound("key_not_found", err.Error()))
+	case errors.Is(err, ErrPlanNotFound):
+		apierror.Write(w, r, apierror.Unprocessable("plan_not_found", err.Error()))
+	case errors.Is(err, ErrInvalidPlan):
+		apierror.Write(w, r, apierror.BadRequest("invalid_plan", err.Error()))
+	case errors.Is(err, ErrInvalidDays):
+		apierror.Write(w, r, apierror.BadRequest("invalid_days", err.Error()))
+	case errors.Is(err, ErrKeyRevoked):
+		apierror.Write(w, r, apierror.Conflict("key_revoked", err.Error()))
+	case errors.Is(err, ErrTooManyKeys):
+		apierror.Write(w, r, apierror.Conflict("too_many_keys", err.Error()))
+	case errors.Is(err, ErrUnauthenticated):
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "unauthenticated", err.Error()))
+	default:
+		h.logger.Error("API key request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
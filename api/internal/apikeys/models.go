@@ -0,0 +1,147 @@
+package apikeys
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrKeyNotFound     = errors.New("API key not found")
+	ErrPlanNotFound    = errors.New("rate plan not found")
+	ErrInvalidPlan     = errors.New("rate plan names are 1-50 lowercase letters, digits, or dashes")
+	ErrInvalidKey      = errors.New("invalid API key")
+	ErrRateLimited     = errors.New("API key rate limit exceeded")
+	ErrQuotaExceeded   = errors.New("API key daily quota exceeded")
+	ErrKeyRevoked      = errors.New("API key is revoked")
+	ErrTooManyKeys     = fmt.Errorf("at most %d active API keys per developer", MaxOwnKeys)
+	ErrInvalidDays     = fmt.Errorf("days must be 1-%d", MaxUsageDays)
+	ErrUnauthenticated = errors.New("caller is not signed in")
+)
+
+// DefaultPlan is given to keys created without a plan
+const DefaultPlan = "free"
+
+const maxNameLength = 100
+
+const (
+	// MaxOwnKeys is how many active keys a developer may create
+	MaxOwnKeys = 10
+	// MaxUsageDays is the longest usage series a developer may request
+	MaxUsageDays = 90
+)
+
+// planName matches the names of rate plans
+var planName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// Plan is a rate tier. Keys on it may make RequestsPerMinute requests, in
+// bursts of up to Burst, and DailyQuota requests per UTC day, 0 for no
+// quota.
+type Plan struct {
+	Name              string    `json:"name"`
+	RequestsPerMinute int       `json:"requests_per_minute"`
+	Burst             int       `json:"burst"`
+	DailyQuota        int64     `json:"daily_quota"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Key is an API key without its secret
+type Key struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Plan      string     `json:"plan"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreatedKey is a new key with its secret, which is shown only once
+type CreatedKey struct {
+	Key
+	Secret string `json:"secret"`
+}
+
+// Usage is a metered request's key, plan, and requests so far today
+type Usage struct {
+	Key      *Key
+	Plan     Plan
+	Requests int64
+}
+
+// KeyUsage is a key's requests per UTC day. DailyQuota is the quota of the
+// key's plan, 0 for none.
+type KeyUsage struct {
+	Key
+	DailyQuota int64           `json:"daily_quota"`
+	Daily      []DailyRequests `json:"daily"`
+}
+
+type DailyRequests struct {
+	Day      string `json:"day"`
+	Requests int64  `json:"requests"`
+}
+
+// PlanRequest creates or changes a rate plan
+type PlanRequest struct {
+	RequestsPerMinute int   `json:"requests_per_minute"`
+	Burst             int   `json:"burst"`
+	DailyQuota        int64 `json:"daily_quota"`
+}
+
+func (r *PlanRequest) Validate() error {
+	var v request.Validation
+	v.Check(r.RequestsPerMinute > 0, "requests_per_minute", "must be positive")
+	v.Check(r.Burst > 0, "burst", "must be positive")
+	v.Check(r.DailyQuota >= 0, "daily_quota", "must not be negative")
+	return v.Err()
+}
+
+// KeyRequest creates an API key
+type KeyRequest struct {
+	Name string `json:"name"`
+	Plan string `json:"plan"`
+}
+
+func (r *KeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Plan == "" {
+		r.Plan = DefaultPlan
+	}
+
+	var v request.Validation
+	v.Check(r.Name != "" && utf8.RuneCountInString(r.Name) <= maxNameLength, "name", fmt.Sprintf("must be 1-%d characters", maxNameLength))
+	v.Check(planName.MatchString(r.Plan), "plan", "must be a rate plan name")
+	return v.Err()
+}
+
+// OwnKeyRequest creates a key for the caller on the default plan
+type OwnKeyRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *OwnKeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+
+	var v request.Validation
+	v.Check(r.Name != "" && utf8.RuneCountInString(r.Name) <= maxNameLength, "name", fmt.Sprintf("must be 1-%d characters", maxNameLength))
+	return v.Err()
+}
+
+// PlanChange moves an API key to another rate plan
+type PlanChange struct {
+	Plan string `json:"plan"`
+}
+
+func (r *PlanChange) Validate() error {
+	var v request.Validation
+	v.Check(planName.MatchString(r.Plan), "plan", "must be a rate plan name")
+	return v.Err()
+}
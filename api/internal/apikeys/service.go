@@ -0,0 +1,498 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/time/rate"
+)
+
+// secretPrefix marks API keys so they are recognizable in leaked logs or
+// source code
+const secretPrefix = "sk_"
+
+// prefixLength is the leading part of a secret shown in listings
+const prefixLength = len(secretPrefix) + 8
+
+type Querier interface {
+	ListRatePlans(ctx context.Context) ([]db.RatePlan, error)
+	UpsertRatePlan(ctx context.Context, arg db.UpsertRatePlanParams) (db.RatePlan, error)
+	CreateAPIKey(ctx context.Context, arg db.CreateAPIKeyParams) (db.ApiKey, error)
+	ListAPIKeys(ctx context.Context) ([]db.ApiKey, error)
+	GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (db.ApiKey, error)
+	SetAPIKeyPlan(ctx context.Context, arg db.SetAPIKeyPlanParams) (db.ApiKey, error)
+	RevokeAPIKey(ctx context.Context, id pgtype.UUID) (db.ApiKey, error)
+	IncrementAPIKeyUsage(ctx context.Context, keyID pgtype.UUID) (int64, error)
+	ListAPIKeysByCreator(ctx context.Context, createdBy string) ([]db.ApiKey, error)
+	GetAPIKeyByCreator(ctx context.Context, arg db.GetAPIKeyByCreatorParams) (db.ApiKey, error)
+	CountActiveAPIKeysByCreator(ctx context.Context, createdBy string) (int64, error)
+	RotateAPIKey(ctx context.Context, arg db.RotateAPIKeyParams) (db.ApiKey, error)
+	ListAPIKeyUsage(ctx context.Context, arg db.ListAPIKeyUsageParams) ([]db.ListAPIKeyUsageRow, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service issues API keys and meters their requests against their rate
+// plans. Plans and key lookups are cached for the refresh interval and
+// dropped on changes made through this replica, so the limiter picks up
+// new plan parameters without a restart. Rates are enforced per replica;
+// daily quotas are counted in the database across replicas.
+type Service struct {
+	queries Querier
+	auditor Auditor
+	refresh time.Duration
+	logger  *slog.Logger
+
+	mu       sync.Mutex
+	plans    map[string]Plan
+	plansAt  time.Time
+	keys     map[string]cachedKey
+	limiters map[uuid.UUID]*rate.Limiter
+}
+
+type cachedKey struct {
+	key       *Key
+	fetchedAt time.Time
+}
+
+func NewService(queries Querier, auditor Auditor, refresh time.Duration, logger *slog.Logger) *Service {
+	return &Service{
+		queries:  queries,
+		auditor:  auditor,
+		refresh:  refresh,
+		logger:   logger,
+		keys:     make(map[string]cachedKey),
+		limiters: make(map[uuid.UUID]*rate.Limiter),
+	}
+}
+
+// Admit meters a request made with secret. It returns the usage alongside
+// ErrRateLimited and ErrQuotaExceeded so callers can report the limits.
+func (s *Service) Admit(ctx context.Context, secret string) (*Usage, error) {
+	key, err := s.lookup(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := s.plan(ctx, key.Plan)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{Key: key, Plan: plan}
+	if !s.limiter(key.ID, plan).Allow() {
+		return usage, ErrRateLimited
+	}
+	usage.Requests, err = s.queries.IncrementAPIKeyUsage(ctx, pgtype.UUID{Bytes: key.ID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count API key usage: %w", err)
+	}
+	if plan.DailyQuota > 0 && usage.Requests > plan.DailyQuota {
+		return usage, ErrQuotaExceeded
+	}
+	return usage, nil
+}
+
+// ListPlans returns the rate plans from the database
+func (s *Service) ListPlans(ctx context.Context) ([]Plan, error) {
+	rows, err := s.queries.ListRatePlans(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate plans: %w", err)
+	}
+	plans := make([]Plan, 0, len(rows))
+	for _, row := range rows {
+		plans = append(plans, toPlan(row))
+	}
+	return plans, nil
+}
+
+// PutPlan creates a rate plan or changes its parameters
+func (s *Service) PutPlan(ctx context.Context, name string, req PlanRequest, actor string) (*Plan, error) {
+	if !planName.MatchString(name) {
+		return nil, ErrInvalidPlan
+	}
+	row, err := s.queries.UpsertRatePlan(ctx, db.UpsertRatePlanParams{
+		Name:              name,
+		RequestsPerMinute: int32(req.RequestsPerMinute),
+		Burst:             int32(req.Burst),
+		DailyQuota:        req.DailyQuota,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save rate plan: %w", err)
+	}
+
+	s.mu.Lock()
+	s.plansAt = time.Time{}
+	s.mu.Unlock()
+
+	plan := toPlan(row)
+	s.record(ctx, actor, "rate_plan.update", "rate_plan", name, map[string]any{
+		"requests_per_minute": plan.RequestsPerMinute,
+		"burst":               plan.Burst,
+		"daily_quota":         plan.DailyQuota,
+	})
+	return &plan, nil
+}
+
+func (s *Service) ListKeys(ctx context.Context) ([]Key, error) {
+	rows, err := s.queries.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	keys := make([]Key, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, *toKey(row))
+	}
+	return keys, nil
+}
+
+// CreateKey issues a key on a rate plan
+func (s *Service) CreateKey(ctx context.Context, req KeyRequest, actor string) (*CreatedKey, error) {
+	secret := newSecret()
+	row, err := s.queries.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		Name:      req.Name,
+		Prefix:    secret[:prefixLength],
+		KeyHash:   hash(secret),
+		Plan:      req.Plan,
+		CreatedBy: actor,
+	})
+	if err != nil {
+		return nil, planError(err, "failed to create API key")
+	}
+
+	key := toKey(row)
+	s.record(ctx, actor, "api_key.create", "api_key", key.ID.String(), map[string]any{"plan": key.Plan})
+	return &CreatedKey{Key: *key, Secret: secret}, nil
+}
+
+// SetKeyPlan moves a key to another rate plan
+func (s *Service) SetKeyPlan(ctx context.Context, id uuid.UUID, plan, actor string) (*Key, error) {
+	row, err := s.queries.SetAPIKeyPlan(ctx, db.SetAPIKeyPlanParams{
+		ID:   pgtype.UUID{Bytes: id, Valid: true},
+		Plan: plan,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, planError(err, "failed to change API key plan")
+	}
+
+	key := toKey(row)
+	s.forget(key.ID)
+	s.record(ctx, actor, "api_key.plan", "api_key", key.ID.String(), map[string]any{"plan": key.Plan})
+	return key, nil
+}
+
+// RevokeKey stops a key from being accepted
+func (s *Service) RevokeKey(ctx context.Context, id uuid.UUID, actor string) (*Key, error) {
+	row, err := s.queries.RevokeAPIKey(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	key := toKey(row)
+	s.forget(key.ID)
+	s.record(ctx, actor, "api_key.revoke", "api_key", key.ID.String(), nil)
+	return key, nil
+}
+
+// ListOwnKeys returns the keys actor created, revoked ones included
+func (s *Service) ListOwnKeys(ctx context.Context, actor string) ([]Key, error) {
+	if actor == "" {
+		return nil, ErrUnauthenticated
+	}
+	rows, err := s.queries.ListAPIKeysByCreator(ctx, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	keys := make([]Key, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, *toKey(row))
+	}
+	return keys, nil
+}
+
+// CreateOwnKey issues a key on the default plan to actor. Only admins move
+// keys to other plans.
+func (s *Service) CreateOwnKey(ctx context.Context, req OwnKeyRequest, actor string) (*CreatedKey, error) {
+	if actor == "" {
+		return nil, ErrUnauthenticated
+	}
+	active, err := s.queries.CountActiveAPIKeysByCreator(ctx, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count API keys: %w", err)
+	}
+	if active >= MaxOwnKeys {
+		return nil, ErrTooManyKeys
+	}
+	return s.CreateKey(ctx, KeyRequest{Name: req.Name, Plan: DefaultPlan}, actor)
+}
+
+// RotateOwnKey replaces one of actor's active keys with a new secret on the
+// same plan. The old key is revoked in the same statement, so a failed
+// rotation leaves it working.
+func (s *Service) RotateOwnKey(ctx context.Context, id uuid.UUID, actor string) (*CreatedKey, error) {
+	old, err := s.ownKey(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if old.RevokedAt != nil {
+		return nil, ErrKeyRevoked
+	}
+
+	secret := newSecret()
+	row, err := s.queries.RotateAPIKey(ctx, db.RotateAPIKeyParams{
+		ID:        pgtype.UUID{Bytes: id, Valid: true},
+		CreatedBy: actor,
+		Prefix:    secret[:prefixLength],
+		KeyHash:   hash(secret),
+	})
+	if err != nil {
+		// Revoked since it was read
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrKeyRevoked
+		}
+		return nil, fmt.Errorf("failed to rotate API key: %w", err)
+	}
+
+	key := toKey(row)
+	s.forget(id)
+	s.record(ctx, actor, "api_key.rotate", "api_key", key.ID.String(), map[string]any{"replaces": id.String()})
+	return &CreatedKey{Key: *key, Secret: secret}, nil
+}
+
+// RevokeOwnKey revokes one of actor's keys
+func (s *Service) RevokeOwnKey(ctx context.Context, id uuid.UUID, actor string) (*Key, error) {
+	if _, err := s.ownKey(ctx, id, actor); err != nil {
+		return nil, err
+	}
+	return s.RevokeKey(ctx, id, actor)
+}
+
+// KeyUsage returns the requests counted against one of actor's keys per
+// UTC day over the last days days, oldest first. Days without requests are
+// included with a count of 0 so the series can be graphed as is.
+func (s *Service) KeyUsage(ctx context.Context, id uuid.UUID, days int, actor string) (*KeyUsage, error) {
+	if days < 1 || days > MaxUsageDays {
+		return nil, ErrInvalidDays
+	}
+	key, err := s.ownKey(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+	rows, err := s.queries.ListAPIKeyUsage(ctx, db.ListAPIKeyUsageParams{
+		KeyID: pgtype.UUID{Bytes: id, Valid: true},
+		Day:   pgtype.Date{Time: since, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API key usage: %w", err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Day.Time.Format(time.DateOnly)] = row.Requests
+	}
+
+	usage := &KeyUsage{Key: *key, Daily: make([]DailyRequests, days)}
+	if plan, err := s.plan(ctx, key.Plan); err == nil {
+		usage.DailyQuota = plan.DailyQuota
+	}
+	for i := range usage.Daily {
+		day := since.AddDate(0, 0, i).Format(time.DateOnly)
+		usage.Daily[i] = DailyRequests{Day: day, Requests: counts[day]}
+	}
+	return usage, nil
+}
+
+// ownKey returns the key with id if actor created it. Keys of others are
+// reported as not found, so their IDs cannot be probed.
+func (s *Service) ownKey(ctx context.Context, id uuid.UUID, actor string) (*Key, error) {
+	if actor == "" {
+		return nil, ErrUnauthenticated
+	}
+	row, err := s.queries.GetAPIKeyByCreator(ctx, db.GetAPIKeyByCreatorParams{
+		ID:        pgtype.UUID{Bytes: id, Valid: true},
+		CreatedBy: actor,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return toKey(row), nil
+}
+
+// lookup finds the active key with secret, from the cache while it is fresh
+func (s *Service) lookup(ctx context.Context, secret string) (*Key, error) {
+	if !strings.HasPrefix(secret, secretPrefix) {
+		return nil, ErrInvalidKey
+	}
+	h := hash(secret)
+
+	s.mu.Lock()
+	cached, ok := s.keys[h]
+	s.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < s.refresh {
+		return cached.key, nil
+	}
+
+	row, err := s.queries.GetActiveAPIKeyByHash(ctx, h)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.mu.Lock()
+			delete(s.keys, h)
+			s.mu.Unlock()
+			return nil, ErrInvalidKey
+		}
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	key := toKey(row)
+	s.mu.Lock()
+	s.keys[h] = cachedKey{key: key, fetchedAt: time.Now()}
+	s.mu.Unlock()
+	return key, nil
+}
+
+// plan returns a rate plan, reloading the plans once they are stale. Stale
+// plans are kept while the database is unreachable.
+func (s *Service) plan(ctx context.Context, name string) (Plan, error) {
+	s.mu.Lock()
+	plans, loadedAt := s.plans, s.plansAt
+	s.mu.Unlock()
+
+	if time.Since(loadedAt) >= s.refresh {
+		rows, err := s.queries.ListRatePlans(ctx)
+		switch {
+		case err == nil:
+			plans = make(map[string]Plan, len(rows))
+			for _, row := range rows {
+				plans[row.Name] = toPlan(row)
+			}
+			s.mu.Lock()
+			s.plans, s.plansAt = plans, time.Now()
+			s.mu.Unlock()
+		case plans == nil:
+			return Plan{}, fmt.Errorf("failed to load rate plans: %w", err)
+		default:
+			s.logger.Warn("failed to reload rate plans, using cached plans", "error", err)
+		}
+	}
+
+	plan, ok := plans[name]
+	if !ok {
+		return Plan{}, fmt.Errorf("%w: %s", ErrPlanNotFound, name)
+	}
+	return plan, nil
+}
+
+// limiter returns the key's token bucket, adjusted to the plan's current
+// rate and burst
+func (s *Service) limiter(id uuid.UUID, plan Plan) *rate.Limiter {
+	limit := rate.Limit(float64(plan.RequestsPerMinute) / 60)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.limiters[id]
+	if !ok {
+		l = rate.NewLimiter(limit, plan.Burst)
+		s.limiters[id] = l
+	}
+	if l.Limit() != limit {
+		l.SetLimit(limit)
+	}
+	if l.Burst() != plan.Burst {
+		l.SetBurst(plan.Burst)
+	}
+	return l
+}
+
+// forget drops a changed key from the cache
+func (s *Service) forget(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for h, cached := range s.keys {
+		if cached.key.ID == id {
+			delete(s.keys, h)
+		}
+	}
+	delete(s.limiters, id)
+}
+
+func (s *Service) record(ctx context.Context, actor, action, resourceType, resourceID string, metadata map[string]any) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Metadata:     metadata,
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+// planError maps a foreign key violation on the plan column to
+// ErrPlanNotFound
+func planError(err error, msg string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrPlanNotFound
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+func newSecret() string {
+	return secretPrefix + strings.ToLower(rand.Text())
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func toPlan(row db.RatePlan) Plan {
+	return Plan{
+		Name:              row.Name,
+		RequestsPerMinute: int(row.RequestsPerMinute),
+		Burst:             int(row.Burst),
+		DailyQuota:        row.DailyQuota,
+		UpdatedAt:         row.UpdatedAt.Time,
+	}
+}
+
+func toKey(row db.ApiKey) *Key {
+	key := &Key{
+		ID:        uuid.UUID(row.ID.Bytes),
+		Name:      row.Name,
+		Prefix:    row.Prefix,
+		Plan:      row.Plan,
+		CreatedBy: row.CreatedBy,
+		CreatedAt: row.CreatedAt.Time,
+	}
+	if row.RevokedAt.Valid {
+		key.RevokedAt = &row.RevokedAt.Time
+	}
+	return key
+}
@@ -0,0 +1,114 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/operations"
+	"starterkit/internal/platform/apierror"
+)
+
+type ServiceInterface interface {
+	ArchiveAuditLogs(ctx context.Context, olderThan time.Duration) (*Result, error)
+	ListSegments(ctx context.Context) ([]*Segment, error)
+	FetchAuditLogs(ctx context.Context, from, to time.Time) ([]*audit.Log, error)
+}
+
+// OperationRunner runs long mutations in the background and returns a receipt
+type OperationRunner interface {
+	Start(ctx context.Context, kind, owner string, fn operations.Func) (*operations.Operation, error)
+}
+
+type Handler struct {
+	service    ServiceInterface
+	operations OperationRunner
+	olderThan  time.Duration
+	logger     *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, ops OperationRunner, olderThan time.Duration, logger *slog.Logger) *Handler {
+	return &Handler{
+		service:    service,
+		operations: ops,
+		olderThan:  olderThan,
+		logger:     logger,
+	}
+}
+
+func (h *Handler) HandleListSegments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		segments, err := h.service.ListSegments(r.Context())
+		if err != nil {
+			h.logger.Error("failed to list archive segments", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"segments": segments,
+		})
+	}
+}
+
+func (h *Handler) HandleFetchAuditLogs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid from parameter, expected RFC 3339 timestamp"))
+			return
+		}
+		to, err := time.Parse(time.RFC3339, r.URL.Query().Get("to"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid to parameter, expected RFC 3339 timestamp"))
+			return
+		}
+
+		logs, err := h.service.FetchAuditLogs(r.Context(), from, to)
+		if err != nil {
+			if errors.Is(err, ErrInvalidRange) {
+				apierror.Write(w, r, apierror.BadRequest("invalid_range", err.Error()))
+				return
+			}
+			h.logger.Error("failed to fetch archived audit logs", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"audit_logs": logs,
+			"from":       from,
+			"to":         to,
+		})
+	}
+}
+
+// HandleRunArchive starts an archival run in the background and responds
+// with an operation receipt
+func (h *Handler) HandleRunArchive() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op, err := h.operations.Start(r.Context(), "archive.audit_logs", operations.Owner(r), func(ctx context.Context) (any, error) {
+			return h.service.ArchiveAuditLogs(ctx, h.olderThan)
+		})
+		if err != nil {
+			h.logger.Error("failed to start archive operation", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		w.Header().Set("Location", "/api/v1/operations/"+op.ID.String())
+		h.respondWithJSON(w, http.StatusAccepted, op)
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
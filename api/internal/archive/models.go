@@ -0,0 +1,27 @@
+package archive
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TableAuditLogs identifies archived audit log segments
+const TableAuditLogs = "audit_logs"
+
+// Segment describes a batch of rows moved to object storage
+type Segment struct {
+	ID         uuid.UUID `json:"id"`
+	TableName  string    `json:"table_name"`
+	ObjectKey  string    `json:"object_key"`
+	RangeStart time.Time `json:"range_start"`
+	RangeEnd   time.Time `json:"range_end"`
+	RowCount   int64     `json:"row_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Result summarizes an archival run
+type Result struct {
+	Segments     int   `json:"segments"`
+	ArchivedRows int64 `json:"archived_rows"`
+}
@@ -0,0 +1,268 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrInvalidRange = errors.New("invalid archive range")
+
+type Querier interface {
+	ListAuditLogsBefore(ctx context.Context, arg db.ListAuditLogsBeforeParams) ([]db.AuditLog, error)
+	DeleteAuditLogsByIDs(ctx context.Context, ids []pgtype.UUID) (int64, error)
+	CreateArchiveSegment(ctx context.Context, arg db.CreateArchiveSegmentParams) (db.ArchiveSegment, error)
+	ListArchiveSegments(ctx context.Context, tableName string) ([]db.ArchiveSegment, error)
+	ListArchiveSegmentsInRange(ctx context.Context, arg db.ListArchiveSegmentsInRangeParams) ([]db.ArchiveSegment, error)
+}
+
+type Service struct {
+	queries   Querier
+	storage   storage.Storage
+	batchSize int
+}
+
+func NewService(queries Querier, store storage.Storage, batchSize int) *Service {
+	if batchSize <= 0 {
+		batchSize = 5000
+	}
+	return &Service{
+		queries:   queries,
+		storage:   store,
+		batchSize: batchSize,
+	}
+}
+
+var auditLogColumns = []string{"id", "actor", "action", "resource_type", "resource_id", "metadata", "created_at"}
+
+// ArchiveAuditLogs moves audit log rows older than the given age into
+// gzip-compressed CSV objects and removes them from Postgres. Each batch is
+// uploaded and recorded in the segment manifest before its rows are deleted,
+// so an interrupted run never loses data.
+func (s *Service) ArchiveAuditLogs(ctx context.Context, olderThan time.Duration) (*Result, error) {
+	ctx = database.WithWorkload(ctx, database.WorkloadReporting)
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(-olderThan), Valid: true}
+	result := &Result{}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		rows, err := s.queries.ListAuditLogsBefore(ctx, db.ListAuditLogsBeforeParams{
+			Cutoff:   cutoff,
+			RowLimit: int32(s.batchSize),
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to read audit logs: %w", err)
+		}
+		if len(rows) == 0 {
+			return result, nil
+		}
+
+		archived, err := s.archiveAuditBatch(ctx, rows)
+		if err != nil {
+			return result, err
+		}
+		result.Segments++
+		result.ArchivedRows += archived
+	}
+}
+
+func (s *Service) archiveAuditBatch(ctx context.Context, rows []db.AuditLog) (int64, error) {
+	first, last := rows[0], rows[len(rows)-1]
+	key := fmt.Sprintf("archive/%s/%s_%s_%s.csv.gz",
+		TableAuditLogs,
+		first.CreatedAt.Time.UTC().Format("20060102T150405Z"),
+		last.CreatedAt.Time.UTC().Format("20060102T150405Z"),
+		uuid.UUID(first.ID.Bytes).String()[:8],
+	)
+
+	body, err := encodeAuditLogs(rows)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.storage.Put(ctx, key, bytes.NewReader(body)); err != nil {
+		return 0, fmt.Errorf("failed to upload archive segment: %w", err)
+	}
+
+	if _, err := s.queries.CreateArchiveSegment(ctx, db.CreateArchiveSegmentParams{
+		TableName:  TableAuditLogs,
+		ObjectKey:  key,
+		RangeStart: first.CreatedAt,
+		RangeEnd:   last.CreatedAt,
+		RowCount:   int64(len(rows)),
+	}); err != nil {
+		return 0, fmt.Errorf("failed to record archive segment: %w", err)
+	}
+
+	ids := make([]pgtype.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	deleted, err := s.queries.DeleteAuditLogsByIDs(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete archived audit logs: %w", err)
+	}
+	return deleted, nil
+}
+
+// ListSegments returns the archive manifest for audit logs
+func (s *Service) ListSegments(ctx context.Context) ([]*Segment, error) {
+	dbSegments, err := s.queries.ListArchiveSegments(ctx, TableAuditLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]*Segment, len(dbSegments))
+	for i, dbSegment := range dbSegments {
+		segments[i] = toSegment(dbSegment)
+	}
+	return segments, nil
+}
+
+// FetchAuditLogs reads archived audit logs created within [from, to] back
+// from object storage
+func (s *Service) FetchAuditLogs(ctx context.Context, from, to time.Time) ([]*audit.Log, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: 'to' must not be before 'from'", ErrInvalidRange)
+	}
+
+	dbSegments, err := s.queries.ListArchiveSegmentsInRange(ctx, db.ListArchiveSegmentsInRangeParams{
+		TableName: TableAuditLogs,
+		RangeTo:   pgtype.Timestamptz{Time: to, Valid: true},
+		RangeFrom: pgtype.Timestamptz{Time: from, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logs := []*audit.Log{}
+	for _, segment := range dbSegments {
+		segmentLogs, err := s.readAuditSegment(ctx, segment.ObjectKey)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range segmentLogs {
+			if !log.CreatedAt.Before(from) && !log.CreatedAt.After(to) {
+				logs = append(logs, log)
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (s *Service) readAuditSegment(ctx context.Context, key string) ([]*audit.Log, error) {
+	obj, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive segment %s: %w", key, err)
+	}
+	defer obj.Close()
+
+	gz, err := gzip.NewReader(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress archive segment %s: %w", key, err)
+	}
+	defer gz.Close()
+
+	return decodeAuditLogs(gz)
+}
+
+func encodeAuditLogs(rows []db.AuditLog) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	w := csv.NewWriter(gz)
+
+	if err := w.Write(auditLogColumns); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := []string{
+			uuid.UUID(row.ID.Bytes).String(),
+			row.Actor,
+			row.Action,
+			row.ResourceType,
+			row.ResourceID,
+			string(row.Metadata),
+			row.CreatedAt.Time.UTC().Format(time.RFC3339Nano),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to encode archive segment: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress archive segment: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeAuditLogs(r io.Reader) ([]*audit.Log, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(auditLogColumns)
+
+	// Skip header
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("failed to read archive header: %w", err)
+	}
+
+	logs := []*audit.Log{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return logs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive row: %w", err)
+		}
+
+		id, err := uuid.Parse(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid archived id %q: %w", record[0], err)
+		}
+		createdAt, err := time.Parse(time.RFC3339Nano, record[6])
+		if err != nil {
+			return nil, fmt.Errorf("invalid archived timestamp %q: %w", record[6], err)
+		}
+
+		logs = append(logs, &audit.Log{
+			ID:           id,
+			Actor:        record[1],
+			Action:       record[2],
+			ResourceType: record[3],
+			ResourceID:   record[4],
+			Metadata:     json.RawMessage(record[5]),
+			CreatedAt:    createdAt,
+		})
+	}
+}
+
+func toSegment(s db.ArchiveSegment) *Segment {
+	return &Segment{
+		ID:         uuid.UUID(s.ID.Bytes),
+		TableName:  s.TableName,
+		ObjectKey:  s.ObjectKey,
+		RangeStart: s.RangeStart.Time,
+		RangeEnd:   s.RangeEnd.Time,
+		RowCount:   s.RowCount,
+		CreatedAt:  s.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"starterkit/internal/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Cursor is a position in the audit log, for readers that page through it
+// such as the SIEM forwarder and tenant exports. created_at is when an entry's transaction
+// started rather than when it committed, so the log is read in order of the
+// transaction that recorded each entry, then created_at and ID, and only up
+// to the oldest transaction still running: no entry can commit behind a
+// cursor. Entries from before transactions were recorded have Txid 0 and
+// keep their created_at order.
+type Cursor struct {
+	Txid      int64
+	CreatedAt pgtype.Timestamptz
+	ID        pgtype.UUID
+}
+
+// CursorQuerier finds where a reader of the audit log starts
+type CursorQuerier interface {
+	GetAuditLogTxidSince(ctx context.Context, since pgtype.Timestamptz) (int64, error)
+}
+
+// CursorSince returns the position just before the entries recorded since t
+func CursorSince(ctx context.Context, queries CursorQuerier, t time.Time) (Cursor, error) {
+	since := pgtype.Timestamptz{Time: t, Valid: true}
+	txid, err := queries.GetAuditLogTxidSince(ctx, since)
+	if err != nil {
+		return Cursor{}, err
+	}
+	return Cursor{Txid: txid, CreatedAt: since, ID: pgtype.UUID{Valid: true}}, nil
+}
+
+// CursorOf returns the position of an entry
+func CursorOf(l db.AuditLog) Cursor {
+	return Cursor{Txid: l.Txid, CreatedAt: l.CreatedAt, ID: l.ID}
+}
+
+// AfterParams returns the parameters listing up to limit entries after c
+func (c Cursor) AfterParams(limit int) db.ListAuditLogsAfterParams {
+	return db.ListAuditLogsAfterParams{
+		AfterTxid:      c.Txid,
+		AfterCreatedAt: c.CreatedAt,
+		AfterID:        c.ID,
+		RowLimit:       int32(limit),
+	}
+}
+
+// TenantAfterParams returns the parameters listing up to limit entries of a
+// tenant after c
+func (c Cursor) TenantAfterParams(tenantID string, limit int) db.ListTenantAuditLogsAfterParams {
+	return db.ListTenantAuditLogsAfterParams{
+		TenantID:       pgtype.Text{String: tenantID, Valid: true},
+		AfterTxid:      c.Txid,
+		AfterCreatedAt: c.CreatedAt,
+		AfterID:        c.ID,
+		RowLimit:       int32(limit),
+	}
+}
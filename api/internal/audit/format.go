@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"starterkit/internal/db"
+
+	"github.com/google/uuid"
+)
+
+// siemEvent is the JSON record forwarded to a SIEM
+type siemEvent struct {
+	ID           uuid.UUID       `json:"id"`
+	Timestamp    string          `json:"timestamp"`
+	Service      string          `json:"service"`
+	Version      string          `json:"version"`
+	Actor        string          `json:"actor"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	Metadata     json.RawMessage `json:"metadata"`
+}
+
+// encodeJSON renders a log entry as a single-line JSON object
+func encodeJSON(l db.AuditLog, service, version string) ([]byte, error) {
+	metadata := json.RawMessage(l.Metadata)
+	if len(metadata) == 0 {
+		metadata = json.RawMessage("{}")
+	}
+	return json.Marshal(siemEvent{
+		ID:           uuid.UUID(l.ID.Bytes),
+		Timestamp:    l.CreatedAt.Time.UTC().Format(time.RFC3339Nano),
+		Service:      service,
+		Version:      version,
+		Actor:        l.Actor,
+		Action:       l.Action,
+		ResourceType: l.ResourceType,
+		ResourceID:   l.ResourceID,
+		Metadata:     metadata,
+	})
+}
+
+// encodeCEF renders a log entry in ArcSight Common Event Format
+func encodeCEF(l db.AuditLog, service, version string) ([]byte, error) {
+	extension := []string{
+		"rt=" + strconv.FormatInt(l.CreatedAt.Time.UnixMilli(), 10),
+		"externalId=" + uuid.UUID(l.ID.Bytes).String(),
+		"suser=" + cefValue(l.Actor),
+		"act=" + cefValue(l.Action),
+		"cs1Label=resourceType",
+		"cs1=" + cefValue(l.ResourceType),
+		"cs2Label=resourceId",
+		"cs2=" + cefValue(l.ResourceID),
+		"msg=" + cefValue(string(l.Metadata)),
+	}
+
+	line := fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		cefHeader("starterkit"),
+		cefHeader(service),
+		cefHeader(version),
+		cefHeader(l.Action),
+		cefHeader(l.Action),
+		cefSeverity(l.Action),
+		strings.Join(extension, " "),
+	)
+	return []byte(line), nil
+}
+
+// cefSeverity rates security-relevant actions higher than routine changes
+func cefSeverity(action string) int {
+	for _, marker := range []string{"rejected", "banned", "blocked", "deleted", "purged"} {
+		if strings.Contains(action, marker) {
+			return 6
+		}
+	}
+	return 3
+}
+
+var (
+	cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefValueEscaper  = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+func cefHeader(s string) string {
+	return cefHeaderEscaper.Replace(s)
+}
+
+func cefValue(s string) string {
+	return cefValueEscaper.Replace(s)
+}
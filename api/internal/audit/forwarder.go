@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/siem"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// forwarderName keys the checkpoint row of the SIEM forwarder
+	forwarderName = "siem"
+
+	maxRetryBackoff = 30 * time.Second
+)
+
+type ForwarderQuerier interface {
+	ListAuditLogsAfter(ctx context.Context, arg db.ListAuditLogsAfterParams) ([]db.AuditLog, error)
+	GetAuditForwarderCheckpoint(ctx context.Context, name string) (db.AuditForwarderCheckpoint, error)
+	UpsertAuditForwarderCheckpoint(ctx context.Context, arg db.UpsertAuditForwarderCheckpointParams) error
+}
+
+// Forwarder streams the audit log to a SIEM. The audit_logs table is the
+// buffer: the checkpoint only advances after a batch is delivered, so a slow
+// or unavailable SIEM delays delivery without dropping entries or holding
+// them in memory. Delivery is at least once.
+type Forwarder struct {
+	queries    ForwarderQuerier
+	sink       siem.Sink
+	encode     func(db.AuditLog, string, string) ([]byte, error)
+	service    string
+	version    string
+	batchSize  int
+	maxBatches int
+	maxRetries int
+}
+
+// NewForwarder creates a forwarder encoding entries as "json" or "cef".
+// Each Poll delivers at most maxBatches batches of batchSize entries.
+func NewForwarder(queries ForwarderQuerier, sink siem.Sink, format, service, version string, batchSize, maxBatches, maxRetries int) *Forwarder {
+	encode := encodeJSON
+	if format == "cef" {
+		encode = encodeCEF
+	}
+	return &Forwarder{
+		queries:    queries,
+		sink:       sink,
+		encode:     encode,
+		service:    service,
+		version:    version,
+		batchSize:  batchSize,
+		maxBatches: maxBatches,
+		maxRetries: maxRetries,
+	}
+}
+
+// Poll forwards entries recorded since the last delivered batch
+func (f *Forwarder) Poll(ctx context.Context) error {
+	checkpoint, err := f.queries.GetAuditForwarderCheckpoint(ctx, forwarderName)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to read forwarder checkpoint: %w", err)
+	}
+	// A missing checkpoint starts from the oldest retained entry
+	cursor := Cursor{
+		Txid:      checkpoint.LastTxid,
+		CreatedAt: pgtype.Timestamptz{Time: checkpoint.LastCreatedAt.Time, Valid: true},
+		ID:        pgtype.UUID{Bytes: checkpoint.LastID.Bytes, Valid: true},
+	}
+
+	for range f.maxBatches {
+		logs, err := f.queries.ListAuditLogsAfter(ctx, cursor.AfterParams(f.batchSize))
+		if err != nil {
+			return fmt.Errorf("failed to read audit logs: %w", err)
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+
+		records := make([][]byte, len(logs))
+		for i, l := range logs {
+			if records[i], err = f.encode(l, f.service, f.version); err != nil {
+				return fmt.Errorf("failed to encode audit log: %w", err)
+			}
+		}
+		if err := f.send(ctx, records); err != nil {
+			return err
+		}
+
+		cursor = CursorOf(logs[len(logs)-1])
+		if err := f.queries.UpsertAuditForwarderCheckpoint(ctx, db.UpsertAuditForwarderCheckpointParams{
+			Name:          forwarderName,
+			LastCreatedAt: cursor.CreatedAt,
+			LastID:        cursor.ID,
+			LastTxid:      cursor.Txid,
+		}); err != nil {
+			return fmt.Errorf("failed to save forwarder checkpoint: %w", err)
+		}
+
+		if len(logs) < f.batchSize {
+			return nil
+		}
+	}
+
+	// Leave the rest for the next poll so one run cannot monopolize the sink
+	logger.FromContext(ctx).Warn("audit forwarder is behind, backlog remains",
+		"forwarded_through", cursor.CreatedAt.Time,
+	)
+	return nil
+}
+
+// send delivers a batch, retrying with exponential backoff
+func (f *Forwarder) send(ctx context.Context, records [][]byte) error {
+	backoff := time.Second
+	var err error
+	for attempt := 0; attempt <= f.maxRetries; attempt++ {
+		if attempt > 0 {
+			logger.FromContext(ctx).Warn("retrying SIEM delivery", "error", err, "attempt", attempt)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, maxRetryBackoff)
+		}
+
+		if err = f.sink.Send(ctx, records); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to deliver %d audit logs to SIEM: %w", len(records), err)
+}
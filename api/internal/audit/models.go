@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entry describes a single auditable action
+type Entry struct {
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Metadata     map[string]any
+	// TenantID is the organization the entry belongs to, for actions taken
+	// outside a request; during one it defaults to the caller's
+	TenantID string
+}
+
+// SystemActor is recorded for actions performed by background processes
+const SystemActor = "system"
+
+// Log is a stored audit entry
+type Log struct {
+	ID           uuid.UUID       `json:"id"`
+	Actor        string          `json:"actor"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	Metadata     json.RawMessage `json:"metadata"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/geoip"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Querier interface {
+	CreateAuditLog(ctx context.Context, arg db.CreateAuditLogParams) error
+}
+
+// Recorder records audit entries; Service, or a decorator of it that also
+// notifies
+type Recorder interface {
+	Record(ctx context.Context, entry Entry) error
+}
+
+type Service struct {
+	queries Querier
+}
+
+func NewService(queries Querier) *Service {
+	return &Service{
+		queries: queries,
+	}
+}
+
+// Record persists an audit entry
+func (s *Service) Record(ctx context.Context, entry Entry) error {
+	metadata := entry.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	// Actions taken during a request record where the client was
+	if loc, ok := geoip.FromContext(ctx); ok {
+		if _, set := metadata["geo"]; !set {
+			metadata = maps.Clone(metadata)
+			metadata["geo"] = loc
+		}
+	}
+
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit metadata: %w", err)
+	}
+
+	actor := entry.Actor
+	if actor == "" {
+		actor = SystemActor
+	}
+	tenant := entry.TenantID
+	if p, ok := auth.FromContext(ctx); ok && tenant == "" {
+		tenant = p.Tenant
+	}
+
+	return s.queries.CreateAuditLog(ctx, db.CreateAuditLogParams{
+		Actor:        actor,
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		ResourceID:   entry.ResourceID,
+		Metadata:     raw,
+		TenantID:     pgtype.Text{String: tenant, Valid: tenant != ""},
+	})
+}
@@ -0,0 +1,84 @@
+package auditexports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+)
+
+// csvHeader names the columns of CSV exports
+var csvHeader = []string{"id", "created_at", "actor", "action", "resource_type", "resource_id", "metadata"}
+
+// document is the body of JSON exports
+type document struct {
+	ExportID    uuid.UUID   `json:"export_id"`
+	TenantID    string      `json:"tenant_id"`
+	PeriodStart time.Time   `json:"period_start"`
+	PeriodEnd   time.Time   `json:"period_end"`
+	Entries     []audit.Log `json:"entries"`
+}
+
+// encode renders entries in format, returning the body, its content type,
+// and the file extension for attachments
+func encode(format string, doc document) ([]byte, string, string, error) {
+	if format == FormatJSON {
+		body, err := json.Marshal(doc)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to encode export: %w", err)
+		}
+		return body, "application/json", "json", nil
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(csvHeader)
+	for _, e := range doc.Entries {
+		w.Write([]string{
+			e.ID.String(),
+			e.CreatedAt.UTC().Format(time.RFC3339Nano),
+			cell(e.Actor),
+			cell(e.Action),
+			cell(e.ResourceType),
+			cell(e.ResourceID),
+			string(e.Metadata),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, "", "", fmt.Errorf("failed to encode export: %w", err)
+	}
+	return buf.Bytes(), "text/csv; charset=utf-8", "csv", nil
+}
+
+// cell keeps a value from being read as a formula when the export is opened
+// in a spreadsheet
+func cell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func toLog(row db.AuditLog) audit.Log {
+	metadata := json.RawMessage(row.Metadata)
+	if len(metadata) == 0 {
+		metadata = json.RawMessage("{}")
+	}
+	return audit.Log{
+		ID:           uuid.UUID(row.ID.Bytes),
+		Actor:        row.Actor,
+		Action:       row.Action,
+		ResourceType: row.ResourceType,
+		ResourceID:   row.ResourceID,
+		Metadata:     metadata,
+		CreatedAt:    row.CreatedAt.Time,
+	}
+}
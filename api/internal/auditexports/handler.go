@@ -0,0 +1,227 @@
+package auditexports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/request"
+)
+
+type ServiceInterface interface {
+	List(ctx context.Context, tenantID string) ([]Export, error)
+	Get(ctx context.Context, tenantID string, id uuid.UUID) (*Export, error)
+	Create(ctx context.Context, tenantID string, req CreateRequest, actor string) (*Export, error)
+	Update(ctx context.Context, tenantID string, id uuid.UUID, req UpdateRequest, actor string) (*Export, error)
+	Delete(ctx context.Context, tenantID string, id uuid.UUID, actor string) error
+	Run(ctx context.Context, tenantID string, id uuid.UUID, actor string) (*Export, error)
+	Deliveries(ctx context.Context, tenantID string, id uuid.UUID) ([]Delivery, error)
+}
+
+// Handler serves the audit exports of the caller's organization. Every
+// route acts on that organization only, so exports of others are not found.
+type Handler struct {
+	service ServiceInterface
+	// tenantHeader names the organization of callers without a principal,
+	// with bearer auth disabled
+	tenantHeader string
+	logger       *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, tenantHeader string, logger *slog.Logger) *Handler {
+	return &Handler{
+		service:      service,
+		tenantHeader: tenantHeader,
+		logger:       logger,
+	}
+}
+
+// HandleList returns the organization's exports
+func (h *Handler) HandleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := h.tenant(w, r)
+		if !ok {
+			return
+		}
+		exports, err := h.service.List(r.Context(), tenant)
+		if err != nil {
+			h.respondWithExportError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, exportList{Exports: exports})
+	}
+}
+
+// HandleCreate schedules an export
+func (h *Handler) HandleCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := h.tenant(w, r)
+		if !ok {
+			return
+		}
+		var req CreateRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		e, err := h.service.Create(r.Context(), tenant, req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithExportError(w, r, err)
+			return
+		}
+		w.Header().Set("Location", "/api/v1/audit-exports/"+e.ID.String())
+		// The signing secret is only shown now
+		w.Header().Set("Cache-Control", "no-store")
+		h.respondWithJSON(w, http.StatusCreated, e)
+	}
+}
+
+// HandleGet returns an export
+func (h *Handler) HandleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant, id, ok := h.target(w, r)
+		if !ok {
+			return
+		}
+		e, err := h.service.Get(r.Context(), tenant, id)
+		if err != nil {
+			h.respondWithExportError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, e)
+	}
+}
+
+// HandleUpdate changes the format or frequency of an export, or pauses or
+// resumes it
+func (h *Handler) HandleUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant, id, ok := h.target(w, r)
+		if !ok {
+			return
+		}
+		var req UpdateRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		e, err := h.service.Update(r.Context(), tenant, id, req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithExportError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, e)
+	}
+}
+
+// HandleDelete stops an export
+func (h *Handler) HandleDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant, id, ok := h.target(w, r)
+		if !ok {
+			return
+		}
+		if err := h.service.Delete(r.Context(), tenant, id, actorFromRequest(r)); err != nil {
+			h.respondWithExportError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleRun makes an export due now. The scheduler runs it on its next
+// pass, so the response is 202 and the outcome shows in its deliveries.
+func (h *Handler) HandleRun() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant, id, ok := h.target(w, r)
+		if !ok {
+			return
+		}
+		e, err := h.service.Run(r.Context(), tenant, id, actorFromRequest(r))
+		if err != nil {
+			h.respondWithExportError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusAccepted, e)
+	}
+}
+
+// HandleDeliveries returns the recent deliveries of an export, newest first
+func (h *Handler) HandleDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant, id, ok := h.target(w, r)
+		if !ok {
+			return
+		}
+		deliveries, err := h.service.Deliveries(r.Context(), tenant, id)
+		if err != nil {
+			h.respondWithExportError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, deliveryList{Deliveries: deliveries})
+	}
+}
+
+// tenant returns the caller's organization: the tenant claim of its token,
+// or with bearer auth disabled the tenant header as sent
+func (h *Handler) tenant(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var tenant string
+	if p, ok := auth.FromContext(r.Context()); ok {
+		tenant = p.Tenant
+	} else {
+		tenant = r.Header.Get(h.tenantHeader)
+	}
+	if tenant == "" {
+		apierror.Write(w, r, apierror.New(http.StatusForbidden, "no_tenant", ErrNoTenant.Error()))
+		return "", false
+	}
+	return tenant, true
+}
+
+// target returns the caller's organization and the export in the path
+func (h *Handler) target(w http.ResponseWriter, r *http.Request) (string, uuid.UUID, bool) {
+	tenant, ok := h.tenant(w, r)
+	if !ok {
+		return "", uuid.Nil, false
+	}
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_export_id", "invalid audit export ID format"))
+		return "", uuid.Nil, false
+	}
+	return tenant, id, true
+}
+
+func (h *Handler) respondWithExportError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrExportNotFound):
+		apierror.Write(w, r, apierror.NotFound("export_not_found", err.Error()))
+	case errors.Is(err, ErrTooManyExports):
+		apierror.Write(w, r, apierror.Conflict("too_many_exports", err.Error()))
+	case errors.Is(err, ErrInvalidWebhook):
+		apierror.Write(w, r, apierror.Unprocessable("invalid_webhook_url", err.Error()))
+	default:
+		h.logger.Error("audit export request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
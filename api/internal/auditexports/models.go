@@ -0,0 +1,148 @@
+package auditexports
+
+import (
+	"errors"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"starterkit/internal/platform/request"
+)
+
+var (
+	ErrExportNotFound = errors.New("audit export not found")
+	ErrNoTenant       = errors.New("the caller belongs to no organization")
+	ErrTooManyExports = errors.New("the organization has scheduled as many audit exports as it may")
+	ErrInvalidWebhook = errors.New("webhook URL is not allowed")
+)
+
+// Formats of an export
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Frequencies exports are delivered at
+const (
+	FrequencyHourly = "hourly"
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+)
+
+// Destinations exports are delivered to
+const (
+	DestinationEmail   = "email"
+	DestinationWebhook = "webhook"
+)
+
+// Delivery statuses. A run with no new entries is recorded as empty and
+// sends nothing.
+const (
+	DeliveryDelivered = "delivered"
+	DeliveryEmpty     = "empty"
+	DeliveryFailed    = "failed"
+)
+
+// periods are how far apart the runs of each frequency are
+var periods = map[string]time.Duration{
+	FrequencyHourly: time.Hour,
+	FrequencyDaily:  24 * time.Hour,
+	FrequencyWeekly: 7 * 24 * time.Hour,
+}
+
+// Export is a recurring export of an organization's audit events
+type Export struct {
+	ID          uuid.UUID `json:"id"`
+	Format      string    `json:"format" enum:"csv,json"`
+	Frequency   string    `json:"frequency" enum:"hourly,daily,weekly"`
+	Destination string    `json:"destination" enum:"email,webhook"`
+	Email       string    `json:"email,omitempty" doc:"Address email exports are sent to"`
+	WebhookURL  string    `json:"webhook_url,omitempty" doc:"URL webhook exports are posted to"`
+	// SigningSecret is only returned when a webhook export is created
+	SigningSecret       string    `json:"signing_secret,omitempty" doc:"Secret webhook deliveries are signed with; only returned when the export is created"`
+	Paused              bool      `json:"paused" doc:"Paused exports are not run; exports pause themselves after repeated failures"`
+	NextRunAt           time.Time `json:"next_run_at"`
+	ExportedThrough     time.Time `json:"exported_through" doc:"When the last delivered entry was recorded; the next delivery starts after it"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	CreatedBy           string    `json:"created_by"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// Delivery is one run of an export
+type Delivery struct {
+	ID          uuid.UUID `json:"id"`
+	Status      string    `json:"status" enum:"delivered,empty,failed"`
+	EntryCount  int       `json:"entry_count"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	// EmailMessageID is the queued email of an email delivery, whose
+	// sending status the email log has
+	EmailMessageID *uuid.UUID `json:"email_message_id,omitempty"`
+	ResponseStatus *int       `json:"response_status,omitempty" doc:"Status the webhook answered with"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CreateRequest schedules an export
+type CreateRequest struct {
+	Format      string `json:"format" enum:"csv,json" example:"csv"`
+	Frequency   string `json:"frequency" enum:"hourly,daily,weekly" example:"daily"`
+	Destination string `json:"destination" enum:"email,webhook" example:"email"`
+	Email       string `json:"email,omitempty" doc:"Required for email exports" example:"security@example.com"`
+	WebhookURL  string `json:"webhook_url,omitempty" doc:"Required for webhook exports; must be https"`
+	// Since backfills entries recorded before the export is created
+	Since *time.Time `json:"since,omitempty" doc:"Export entries recorded since; defaults to now"`
+}
+
+func (r *CreateRequest) Validate() error {
+	var v request.Validation
+	v.Check(r.Format == FormatCSV || r.Format == FormatJSON, "format", "must be csv or json")
+	_, ok := periods[r.Frequency]
+	v.Check(ok, "frequency", "must be hourly, daily, or weekly")
+	switch r.Destination {
+	case DestinationEmail:
+		_, err := mail.ParseAddress(r.Email)
+		v.Check(err == nil, "email", "must be an email address")
+		v.Check(r.WebhookURL == "", "webhook_url", "is only allowed for webhook exports")
+	case DestinationWebhook:
+		u, err := url.Parse(r.WebhookURL)
+		v.Check(err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != "", "webhook_url", "must be an absolute URL")
+		v.Check(r.Email == "", "email", "is only allowed for email exports")
+	default:
+		v.Check(false, "destination", "must be email or webhook")
+	}
+	v.Check(r.Since == nil || !r.Since.After(time.Now()), "since", "must not be in the future")
+	return v.Err()
+}
+
+// target is where the export is delivered to
+func (r *CreateRequest) target() string {
+	if r.Destination == DestinationEmail {
+		return r.Email
+	}
+	return r.WebhookURL
+}
+
+// UpdateRequest changes an export; fields left out are kept
+type UpdateRequest struct {
+	Format    *string `json:"format,omitempty" enum:"csv,json"`
+	Frequency *string `json:"frequency,omitempty" enum:"hourly,daily,weekly"`
+	// Paused exports are not run. Resuming one runs it right away and
+	// resets its failures.
+	Paused *bool `json:"paused,omitempty" doc:"Pause or resume the export; resuming runs it right away"`
+}
+
+func (r *UpdateRequest) Validate() error {
+	var v request.Validation
+	if r.Format != nil {
+		v.Check(*r.Format == FormatCSV || *r.Format == FormatJSON, "format", "must be csv or json")
+	}
+	if r.Frequency != nil {
+		_, ok := periods[*r.Frequency]
+		v.Check(ok, "frequency", "must be hourly, daily, or weekly")
+	}
+	return v.Err()
+}
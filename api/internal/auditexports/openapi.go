@@ -0,0 +1,181 @@
+package auditexports
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"starterkit/internal/openapi"
+)
+
+// exportList is the body of the export list
+type exportList struct {
+	Exports []Export `json:"exports"`
+}
+
+// deliveryList is the body of an export's delivery history
+type deliveryList struct {
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+// exampleExport is the export the examples of the API document show
+var exampleExport = Export{
+	ID:              uuid.MustParse("0190c6b2-7d4e-7a21-9f3b-2c8d1e5a4b60"),
+	Format:          FormatCSV,
+	Frequency:       FrequencyDaily,
+	Destination:     DestinationEmail,
+	Email:           "security@example.com",
+	NextRunAt:       time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+	ExportedThrough: time.Date(2024, 1, 1, 8, 59, 41, 0, time.UTC),
+	CreatedBy:       "admin@example.com",
+	CreatedAt:       time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+	UpdatedAt:       time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+}
+
+// Describe registers the audit export endpoints in the OpenAPI document
+func Describe(api *openapi.Registry) {
+	api.Define("AuditExport", Export{})
+	api.Define("AuditExportList", exportList{})
+	api.Define("AuditExportDelivery", Delivery{})
+	api.Define("AuditExportDeliveryList", deliveryList{})
+	api.Define("AuditExportCreateRequest", CreateRequest{})
+	api.Define("AuditExportUpdateRequest", UpdateRequest{})
+
+	tags := []string{"Audit Exports"}
+	exportID := openapi.PathParam("id", "Audit export UUID", openapi.String("uuid"))
+	forbidden := openapi.Problem(http.StatusForbidden, "The caller is not an organization admin, or belongs to no organization")
+	notFound := openapi.Problem(http.StatusNotFound, "The organization has no such export")
+	internal := openapi.Problem(http.StatusInternalServerError, "Internal server error")
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/audit-exports",
+		ID:          "listAuditExports",
+		Summary:     "List audit exports",
+		Description: "Returns the recurring audit log exports of the caller's organization. Available to organization admins when AUDIT_EXPORTS_ENABLED is set.",
+		Tags:        tags,
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "The organization's exports", Body: exportList{}, Example: exportList{Exports: []Export{exampleExport}}},
+			forbidden,
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:         http.MethodPost,
+		Path:           "/api/v1/audit-exports",
+		ID:             "createAuditExport",
+		Summary:        "Schedule audit export",
+		Description:    "Schedules a recurring export of the audit events of the caller's organization, as CSV or JSON, to an email address or a webhook. Each run delivers the events recorded since the last delivery. Webhook deliveries are signed like signed requests to this API, with the export's ID as the key ID and the signing_secret, which only this response returns.",
+		Tags:           tags,
+		Request:        CreateRequest{},
+		RequestExample: CreateRequest{Format: FormatCSV, Frequency: FrequencyDaily, Destination: DestinationEmail, Email: "security@example.com"},
+		Responses: []openapi.Response{
+			{Status: http.StatusCreated, Description: "Scheduled export", Body: Export{}, Example: exampleExport},
+			openapi.Problem(http.StatusBadRequest, "Malformed request body"),
+			forbidden,
+			openapi.Problem(http.StatusConflict, "The organization has scheduled as many exports as it may"),
+			openapi.Problem(http.StatusUnprocessableEntity, "Invalid fields, or a webhook URL that is not https or names a private address"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/audit-exports/{id}",
+		ID:          "getAuditExport",
+		Summary:     "Get audit export",
+		Description: "Returns an export of the caller's organization",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{exportID},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "The export", Body: Export{}, Example: exampleExport},
+			openapi.Problem(http.StatusBadRequest, "Invalid export ID"),
+			forbidden,
+			notFound,
+			internal,
+		},
+	})
+
+	paused := true
+	api.Add(openapi.Operation{
+		Method:         http.MethodPatch,
+		Path:           "/api/v1/audit-exports/{id}",
+		ID:             "updateAuditExport",
+		Summary:        "Update audit export",
+		Description:    "Changes the format or frequency of an export, or pauses or resumes it. A new frequency counts from now; a resumed export runs right away and its failures are reset.",
+		Tags:           tags,
+		Parameters:     []openapi.Parameter{exportID},
+		Request:        UpdateRequest{},
+		RequestExample: UpdateRequest{Paused: &paused},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Updated export", Body: Export{}},
+			openapi.Problem(http.StatusBadRequest, "Malformed request body or invalid export ID"),
+			forbidden,
+			notFound,
+			openapi.Problem(http.StatusUnprocessableEntity, "Invalid fields"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/audit-exports/{id}",
+		ID:          "deleteAuditExport",
+		Summary:     "Delete audit export",
+		Description: "Stops an export and deletes its delivery history",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{exportID},
+		Responses: []openapi.Response{
+			{Status: http.StatusNoContent, Description: "Export deleted"},
+			openapi.Problem(http.StatusBadRequest, "Invalid export ID"),
+			forbidden,
+			notFound,
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodPost,
+		Path:        "/api/v1/audit-exports/{id}/run",
+		ID:          "runAuditExport",
+		Summary:     "Run audit export",
+		Description: "Makes an export due now, resuming it if it was paused. The scheduler runs it within AUDIT_EXPORTS_INTERVAL; the outcome shows in its deliveries.",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{exportID},
+		Responses: []openapi.Response{
+			{Status: http.StatusAccepted, Description: "Export due", Body: Export{}},
+			openapi.Problem(http.StatusBadRequest, "Invalid export ID"),
+			forbidden,
+			notFound,
+			internal,
+		},
+	})
+
+	status := http.StatusNoContent
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/audit-exports/{id}/deliveries",
+		ID:          "listAuditExportDeliveries",
+		Summary:     "List audit export deliveries",
+		Description: "Returns the most recent runs of an export, newest first: how many events each delivered and for what period, and the queued email, the webhook's answer, or why it failed. Runs with no new events are recorded as empty and send nothing.",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{exportID},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Deliveries, newest first", Body: deliveryList{}, Example: deliveryList{Deliveries: []Delivery{{
+				ID:             uuid.MustParse("0190c9d8-1a2b-7c3d-8e4f-5a6b7c8d9e0f"),
+				Status:         DeliveryDelivered,
+				EntryCount:     42,
+				PeriodStart:    time.Date(2024, 1, 1, 8, 59, 41, 0, time.UTC),
+				PeriodEnd:      time.Date(2024, 1, 2, 8, 59, 55, 0, time.UTC),
+				ResponseStatus: &status,
+				CreatedAt:      time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+			}}}},
+			openapi.Problem(http.StatusBadRequest, "Invalid export ID"),
+			forbidden,
+			notFound,
+			internal,
+		},
+	})
+}
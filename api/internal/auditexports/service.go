@@ -0,0 +1,495 @@
+package auditexports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/email"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/storage"
+)
+
+// maxDeliveries is how many of an export's deliveries are listed
+const maxDeliveries = 100
+
+type Querier interface {
+	CreateAuditExport(ctx context.Context, arg db.CreateAuditExportParams) (db.AuditExport, error)
+	GetAuditExport(ctx context.Context, arg db.GetAuditExportParams) (db.AuditExport, error)
+	ListAuditExports(ctx context.Context, tenantID string) ([]db.AuditExport, error)
+	UpdateAuditExport(ctx context.Context, arg db.UpdateAuditExportParams) (db.AuditExport, error)
+	DeleteAuditExport(ctx context.Context, arg db.DeleteAuditExportParams) (int64, error)
+	ListDueAuditExports(ctx context.Context, rowLimit int32) ([]db.AuditExport, error)
+	RecordAuditExportSuccess(ctx context.Context, arg db.RecordAuditExportSuccessParams) error
+	RecordAuditExportFailure(ctx context.Context, arg db.RecordAuditExportFailureParams) (db.AuditExport, error)
+	CreateAuditExportDelivery(ctx context.Context, arg db.CreateAuditExportDeliveryParams) (db.AuditExportDelivery, error)
+	ListAuditExportDeliveries(ctx context.Context, arg db.ListAuditExportDeliveriesParams) ([]db.AuditExportDelivery, error)
+	ListTenantAuditLogsAfter(ctx context.Context, arg db.ListTenantAuditLogsAfterParams) ([]db.AuditLog, error)
+	GetAuditLogTxidSince(ctx context.Context, since pgtype.Timestamptz) (int64, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Mailer queues the emails of email exports
+type Mailer interface {
+	Queue(ctx context.Context, email email.Email) (*email.Message, error)
+}
+
+// Service schedules the audit exports of organizations and runs them.
+// Each export delivers the entries of its organization recorded since its
+// last delivery; a failed delivery is retried from the same entry, so
+// delivery is at least once.
+type Service struct {
+	queries Querier
+	store   storage.Storage
+	mailer  Mailer
+	auditor Auditor
+	client  *http.Client
+	cfg     config.AuditExportsConfig
+	logger  *slog.Logger
+}
+
+func NewService(queries Querier, store storage.Storage, mailer Mailer, auditor Auditor, client *http.Client, cfg config.AuditExportsConfig, logger *slog.Logger) *Service {
+	return &Service{
+		queries: queries,
+		store:   store,
+		mailer:  mailer,
+		auditor: auditor,
+		client:  client,
+		cfg:     cfg,
+		logger:  logger,
+	}
+}
+
+// List returns the exports of an organization
+func (s *Service) List(ctx context.Context, tenantID string) ([]Export, error) {
+	rows, err := s.queries.ListAuditExports(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit exports: %w", err)
+	}
+	exports := make([]Export, len(rows))
+	for i, row := range rows {
+		exports[i] = *toExport(row)
+	}
+	return exports, nil
+}
+
+// Get returns an export of an organization
+func (s *Service) Get(ctx context.Context, tenantID string, id uuid.UUID) (*Export, error) {
+	row, err := s.get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toExport(row), nil
+}
+
+// Create schedules an export. Webhook exports get a signing secret, which
+// is only returned now.
+func (s *Service) Create(ctx context.Context, tenantID string, req CreateRequest, actor string) (*Export, error) {
+	if req.Destination == DestinationWebhook {
+		if err := checkWebhookURL(req.WebhookURL, s.cfg.WebhookAllowPrivate); err != nil {
+			return nil, err
+		}
+	}
+	existing, err := s.queries.ListAuditExports(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count audit exports: %w", err)
+	}
+	if len(existing) >= s.cfg.MaxPerTenant {
+		return nil, ErrTooManyExports
+	}
+
+	now := time.Now()
+	since := now
+	if req.Since != nil {
+		since = *req.Since
+	}
+	start, err := audit.CursorSince(ctx, s.queries, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the start of the audit log: %w", err)
+	}
+	var secret pgtype.Text
+	if req.Destination == DestinationWebhook {
+		if secret.String, err = newSigningSecret(); err != nil {
+			return nil, err
+		}
+		secret.Valid = true
+	}
+	row, err := s.queries.CreateAuditExport(ctx, db.CreateAuditExportParams{
+		TenantID:            tenantID,
+		Format:              req.Format,
+		Frequency:           req.Frequency,
+		Destination:         req.Destination,
+		Target:              req.target(),
+		SigningSecret:       secret,
+		NextRunAt:           pgtype.Timestamptz{Time: now.Add(periods[req.Frequency]), Valid: true},
+		ExportedThrough:     start.CreatedAt,
+		ExportedThroughTxid: start.Txid,
+		CreatedBy:           actor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit export: %w", err)
+	}
+	s.record(ctx, actor, "audit_export.created", row, map[string]any{
+		"format":      row.Format,
+		"frequency":   row.Frequency,
+		"destination": row.Destination,
+	})
+
+	e := toExport(row)
+	e.SigningSecret = secret.String
+	return e, nil
+}
+
+// Update changes the format or frequency of an export, or pauses or
+// resumes it. A new frequency takes effect from now, and a resumed export
+// runs right away.
+func (s *Service) Update(ctx context.Context, tenantID string, id uuid.UUID, req UpdateRequest, actor string) (*Export, error) {
+	row, err := s.get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	params := db.UpdateAuditExportParams{
+		ID:        row.ID,
+		TenantID:  tenantID,
+		Format:    row.Format,
+		Frequency: row.Frequency,
+		Paused:    row.Paused,
+		NextRunAt: row.NextRunAt,
+	}
+	changes := map[string]any{}
+	if req.Format != nil && *req.Format != row.Format {
+		params.Format = *req.Format
+		changes["format"] = *req.Format
+	}
+	if req.Frequency != nil && *req.Frequency != row.Frequency {
+		params.Frequency = *req.Frequency
+		params.NextRunAt = pgtype.Timestamptz{Time: time.Now().Add(periods[*req.Frequency]), Valid: true}
+		changes["frequency"] = *req.Frequency
+	}
+	if req.Paused != nil && *req.Paused != row.Paused {
+		params.Paused = *req.Paused
+		if !*req.Paused {
+			params.NextRunAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+		}
+		changes["paused"] = *req.Paused
+	}
+	if len(changes) == 0 {
+		return toExport(row), nil
+	}
+
+	updated, err := s.queries.UpdateAuditExport(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update audit export: %w", err)
+	}
+	s.record(ctx, actor, "audit_export.updated", updated, changes)
+	return toExport(updated), nil
+}
+
+// Delete stops an export and deletes its delivery history
+func (s *Service) Delete(ctx context.Context, tenantID string, id uuid.UUID, actor string) error {
+	row, err := s.get(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	n, err := s.queries.DeleteAuditExport(ctx, db.DeleteAuditExportParams{ID: row.ID, TenantID: tenantID})
+	if err != nil {
+		return fmt.Errorf("failed to delete audit export: %w", err)
+	}
+	if n == 0 {
+		return ErrExportNotFound
+	}
+	s.record(ctx, actor, "audit_export.deleted", row, nil)
+	return nil
+}
+
+// Run makes an export due, resuming it if it was paused, so the scheduler
+// runs it on its next pass
+func (s *Service) Run(ctx context.Context, tenantID string, id uuid.UUID, actor string) (*Export, error) {
+	row, err := s.get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.queries.UpdateAuditExport(ctx, db.UpdateAuditExportParams{
+		ID:        row.ID,
+		TenantID:  tenantID,
+		Format:    row.Format,
+		Frequency: row.Frequency,
+		Paused:    false,
+		NextRunAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update audit export: %w", err)
+	}
+	s.record(ctx, actor, "audit_export.run_requested", updated, nil)
+	return toExport(updated), nil
+}
+
+// Deliveries returns the most recent deliveries of an export, newest first
+func (s *Service) Deliveries(ctx context.Context, tenantID string, id uuid.UUID) ([]Delivery, error) {
+	row, err := s.get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.queries.ListAuditExportDeliveries(ctx, db.ListAuditExportDeliveriesParams{
+		ExportID: row.ID,
+		RowLimit: maxDeliveries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit export deliveries: %w", err)
+	}
+	deliveries := make([]Delivery, len(rows))
+	for i, d := range rows {
+		deliveries[i] = toDelivery(d)
+	}
+	return deliveries, nil
+}
+
+// RunDue runs the exports that are due, oldest first. One export failing
+// does not keep the others from running.
+func (s *Service) RunDue(ctx context.Context) error {
+	due, err := s.queries.ListDueAuditExports(ctx, int32(s.cfg.BatchSize))
+	if err != nil {
+		return fmt.Errorf("failed to list due audit exports: %w", err)
+	}
+	for _, row := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.run(ctx, row); err != nil {
+			logger.FromContext(ctx).Error("failed to run audit export",
+				"export_id", uuid.UUID(row.ID.Bytes),
+				"tenant_id", row.TenantID,
+				"error", err,
+			)
+		}
+	}
+	return nil
+}
+
+// run delivers the entries of an export's organization recorded since its
+// last delivery, up to MaxEntries; when there are more, it is left due so
+// the rest follow on the next pass
+func (s *Service) run(ctx context.Context, row db.AuditExport) error {
+	through := audit.Cursor{
+		Txid:      row.ExportedThroughTxid,
+		CreatedAt: row.ExportedThrough,
+		ID:        row.ExportedThroughID,
+	}
+	logs, err := s.queries.ListTenantAuditLogsAfter(ctx, through.TenantAfterParams(row.TenantID, s.cfg.MaxEntries))
+	if err != nil {
+		return fmt.Errorf("failed to read audit logs: %w", err)
+	}
+
+	delivery := db.CreateAuditExportDeliveryParams{
+		ExportID:    row.ID,
+		Status:      DeliveryEmpty,
+		EntryCount:  int32(len(logs)),
+		PeriodStart: row.ExportedThrough,
+		PeriodEnd:   pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	next := nextRun(row.NextRunAt.Time, periods[row.Frequency], time.Now())
+	if len(logs) == s.cfg.MaxEntries {
+		delivery.PeriodEnd = logs[len(logs)-1].CreatedAt
+		next = row.NextRunAt.Time
+	}
+
+	if len(logs) > 0 {
+		entries := make([]audit.Log, len(logs))
+		for i, l := range logs {
+			entries[i] = toLog(l)
+		}
+		doc := document{
+			ExportID:    uuid.UUID(row.ID.Bytes),
+			TenantID:    row.TenantID,
+			PeriodStart: delivery.PeriodStart.Time,
+			PeriodEnd:   delivery.PeriodEnd.Time,
+			Entries:     entries,
+		}
+		if err := s.deliver(ctx, row, doc, &delivery); err != nil {
+			return s.fail(ctx, row, delivery, err)
+		}
+		delivery.Status = DeliveryDelivered
+		through = audit.CursorOf(logs[len(logs)-1])
+	}
+
+	if _, err := s.queries.CreateAuditExportDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to record audit export delivery: %w", err)
+	}
+	if err := s.queries.RecordAuditExportSuccess(ctx, db.RecordAuditExportSuccessParams{
+		ID:                  row.ID,
+		NextRunAt:           pgtype.Timestamptz{Time: next, Valid: true},
+		ExportedThrough:     through.CreatedAt,
+		ExportedThroughID:   through.ID,
+		ExportedThroughTxid: through.Txid,
+	}); err != nil {
+		return fmt.Errorf("failed to save audit export checkpoint: %w", err)
+	}
+	return nil
+}
+
+// deliver sends an export to its destination, noting the queued email or
+// the webhook's answer in delivery
+func (s *Service) deliver(ctx context.Context, row db.AuditExport, doc document, delivery *db.CreateAuditExportDeliveryParams) error {
+	body, contentType, ext, err := encode(row.Format, doc)
+	if err != nil {
+		return err
+	}
+
+	if row.Destination == DestinationWebhook {
+		status, err := postWebhook(ctx, s.client, row.Target, row.SigningSecret.String, doc.ExportID, contentType, body)
+		if status != 0 {
+			delivery.ResponseStatus = pgtype.Int4{Int32: int32(status), Valid: true}
+		}
+		return err
+	}
+
+	// The file is attached from storage, where the email job reads it
+	filename := fmt.Sprintf("audit-%s-%s.%s", row.TenantID, doc.PeriodEnd.UTC().Format("20060102T150405Z"), ext)
+	key := fmt.Sprintf("audit-exports/%s/%s.%s", doc.ExportID, uuid.NewString(), ext)
+	if err := s.store.Put(ctx, key, bytes.NewReader(body)); err != nil {
+		return fmt.Errorf("failed to store export: %w", err)
+	}
+	msg, err := s.mailer.Queue(ctx, email.Email{
+		To:      row.Target,
+		Subject: fmt.Sprintf("Audit log export for %s", row.TenantID),
+		Body: fmt.Sprintf("Attached are the %d audit events of %s recorded from %s to %s.\n",
+			len(doc.Entries), row.TenantID, doc.PeriodStart.UTC().Format(time.RFC3339), doc.PeriodEnd.UTC().Format(time.RFC3339)),
+		Attachments: []email.Attachment{{Key: key, Filename: filename, ContentType: contentType}},
+	})
+	if err != nil {
+		s.store.Delete(context.WithoutCancel(ctx), key)
+		return fmt.Errorf("failed to queue export email: %w", err)
+	}
+	if msg.Status == email.StatusSuppressed {
+		return fmt.Errorf("%s is on the email suppression list", row.Target)
+	}
+	delivery.EmailMessageID = pgtype.UUID{Bytes: msg.ID, Valid: true}
+	return nil
+}
+
+// fail records a failed delivery and schedules a retry, pausing the export
+// once it has failed MaxFailures times in a row
+func (s *Service) fail(ctx context.Context, row db.AuditExport, delivery db.CreateAuditExportDeliveryParams, cause error) error {
+	delivery.Status = DeliveryFailed
+	delivery.Error = pgtype.Text{String: cause.Error(), Valid: true}
+	if _, err := s.queries.CreateAuditExportDelivery(ctx, delivery); err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to record audit export delivery: %w", err))
+	}
+	updated, err := s.queries.RecordAuditExportFailure(ctx, db.RecordAuditExportFailureParams{
+		ID:          row.ID,
+		NextRunAt:   pgtype.Timestamptz{Time: time.Now().Add(s.cfg.RetryInterval), Valid: true},
+		MaxFailures: int32(s.cfg.MaxFailures),
+	})
+	if err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to record audit export failure: %w", err))
+	}
+	if updated.Paused {
+		s.record(ctx, audit.SystemActor, "audit_export.paused", updated, map[string]any{
+			"consecutive_failures": updated.ConsecutiveFailures,
+			"error":                cause.Error(),
+		})
+	}
+	return cause
+}
+
+func (s *Service) get(ctx context.Context, tenantID string, id uuid.UUID) (db.AuditExport, error) {
+	row, err := s.queries.GetAuditExport(ctx, db.GetAuditExportParams{
+		ID:       pgtype.UUID{Bytes: id, Valid: true},
+		TenantID: tenantID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.AuditExport{}, ErrExportNotFound
+	}
+	if err != nil {
+		return db.AuditExport{}, fmt.Errorf("failed to get audit export: %w", err)
+	}
+	return row, nil
+}
+
+// record audits a change to an export as an entry of its organization, so
+// organization admins see their own changes in their exports
+func (s *Service) record(ctx context.Context, actor, action string, row db.AuditExport, metadata map[string]any) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "audit_export",
+		ResourceID:   uuid.UUID(row.ID.Bytes).String(),
+		Metadata:     metadata,
+		TenantID:     row.TenantID,
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+// nextRun steps from the previous run by period until it is past now, so an
+// export keeps its time of day however late a run was
+func nextRun(previous time.Time, period time.Duration, now time.Time) time.Time {
+	next := previous.Add(period)
+	if next.After(now) {
+		return next
+	}
+	return next.Add(now.Sub(next).Truncate(period) + period)
+}
+
+func toExport(row db.AuditExport) *Export {
+	e := &Export{
+		ID:                  uuid.UUID(row.ID.Bytes),
+		Format:              row.Format,
+		Frequency:           row.Frequency,
+		Destination:         row.Destination,
+		Paused:              row.Paused,
+		NextRunAt:           row.NextRunAt.Time,
+		ExportedThrough:     row.ExportedThrough.Time,
+		ConsecutiveFailures: int(row.ConsecutiveFailures),
+		CreatedBy:           row.CreatedBy,
+		CreatedAt:           row.CreatedAt.Time,
+		UpdatedAt:           row.UpdatedAt.Time,
+	}
+	if row.Destination == DestinationEmail {
+		e.Email = row.Target
+	} else {
+		e.WebhookURL = row.Target
+	}
+	return e
+}
+
+func toDelivery(row db.AuditExportDelivery) Delivery {
+	d := Delivery{
+		ID:          uuid.UUID(row.ID.Bytes),
+		Status:      row.Status,
+		EntryCount:  int(row.EntryCount),
+		PeriodStart: row.PeriodStart.Time,
+		PeriodEnd:   row.PeriodEnd.Time,
+		Error:       row.Error.String,
+		CreatedAt:   row.CreatedAt.Time,
+	}
+	if row.EmailMessageID.Valid {
+		id := uuid.UUID(row.EmailMessageID.Bytes)
+		d.EmailMessageID = &id
+	}
+	if row.ResponseStatus.Valid {
+		status := int(row.ResponseStatus.Int32)
+		d.ResponseStatus = &status
+	}
+	return d
+}
@@ -0,0 +1,120 @@
+package auditexports
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	"starterkit/internal/platform/auth"
+)
+
+// maxErrorBody bounds how much of a failed webhook response is kept
+const maxErrorBody = 512
+
+// ExportIDHeader names the export a webhook delivery is from; deliveries
+// also carry the request signature headers of package auth
+const ExportIDHeader = "X-Audit-Export-Id"
+
+// NewWebhookClient returns the client webhook deliveries are posted with.
+// Unless allowPrivate is set it refuses to connect to private, loopback,
+// and link-local addresses, whatever the webhook's host name resolves to,
+// so organization admins cannot reach internal services through it.
+func NewWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("%w: %s is not a public address", ErrInvalidWebhook, host)
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// Redirects could lead past the URL check
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsUnspecified() && !ip.IsMulticast()
+}
+
+// checkWebhookURL turns away webhook URLs that are not https, or name a
+// private address, unless allowPrivate is set
+func checkWebhookURL(raw string, allowPrivate bool) error {
+	if allowPrivate {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" {
+		return fmt.Errorf("%w: it must be https", ErrInvalidWebhook)
+	}
+	if ip := net.ParseIP(u.Hostname()); u.Hostname() == "localhost" || (ip != nil && !publicIP(ip)) {
+		return fmt.Errorf("%w: it must not name a private address", ErrInvalidWebhook)
+	}
+	return nil
+}
+
+// newSigningSecret returns the secret a webhook export's deliveries are
+// signed with
+func newSigningSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate signing secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// postWebhook posts an export to its webhook, signed as clients sign their
+// requests to this API (see auth.Sign) with the export's ID as the key ID,
+// and returns the status the webhook answered with
+func postWebhook(ctx context.Context, client *http.Client, target, secret string, exportID uuid.UUID, contentType string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	nonce := uuid.NewString()
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(ExportIDHeader, exportID.String())
+	req.Header.Set(auth.SignatureKeyIDHeader, exportID.String())
+	req.Header.Set(auth.SignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(auth.SignatureNonceHeader, nonce)
+	req.Header.Set(auth.SignatureHeader, auth.Sign(secret, req.Method, req.URL.RequestURI(), timestamp, nonce, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, errors.New("webhook answered " + resp.Status + ": " + string(bytes.TrimSpace(detail)))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,115 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Fake names are picked by hashing the user ID, so a user gets the same
+// name and email on every refresh and references by email stay consistent
+var (
+	fakeFirstNames = []string{"Alex", "Blake", "Casey", "Dana", "Eli", "Frankie", "Gray", "Harper", "Indy", "Jordan", "Kai", "Logan", "Morgan", "Noel", "Parker", "Quinn", "Riley", "Sage", "Taylor", "Val"}
+	fakeLastNames  = []string{"Adams", "Brooks", "Carter", "Diaz", "Ellis", "Foster", "Garcia", "Hayes", "Ito", "Jensen", "Khan", "Lopez", "Moreau", "Nguyen", "Okafor", "Patel", "Reyes", "Silva", "Tanaka", "Weber"}
+)
+
+// anonymizeStatements scrub personal data from a restored database so
+// staging never holds production PII. Emails recorded as actors or owners
+// are rewritten to the matching user's fake email, keeping ownership
+// relationships intact.
+var anonymizeStatements = []string{
+	`CREATE TEMP TABLE pii_users ON COMMIT DROP AS
+		SELECT id,
+			email AS original,
+			first_name || ' ' || last_name AS name,
+			LOWER(first_name || '.' || last_name) || '.' || id || '@example.com' AS replacement
+		FROM (
+			SELECT id,
+				email,
+				` + pickByHash("id::text || ':first'", fakeFirstNames) + ` AS first_name,
+				` + pickByHash("id::text || ':last'", fakeLastNames) + ` AS last_name
+			FROM users
+		) u`,
+	`UPDATE audit_logs SET
+		actor = COALESCE((SELECT replacement FROM pii_users WHERE original = actor), 'anonymized'),
+		metadata = '{}'
+	WHERE actor <> 'system'`,
+	`UPDATE uploads SET
+		owner = COALESCE((SELECT replacement FROM pii_users WHERE original = owner), 'anonymized'),
+		filename = 'file-' || LEFT(id::text, 8)`,
+	`UPDATE operations SET
+		owner = COALESCE((SELECT replacement FROM pii_users WHERE original = owner), 'anonymized')`,
+	`UPDATE moderation_flags SET
+		actor = COALESCE((SELECT replacement FROM pii_users WHERE original = actor), 'anonymized'),
+		reviewed_by = CASE WHEN reviewed_by IS NULL THEN NULL ELSE 'anonymized' END,
+		content = '[redacted]',
+		review_note = NULL`,
+	`UPDATE message_template_versions SET created_by = 'anonymized' WHERE created_by <> 'system'`,
+	`UPDATE users u SET
+		email = p.replacement,
+		name = p.name,
+		bio = '',
+		phone = NULL,
+		phone_verified_at = NULL,
+		handle = CASE WHEN u.handle IS NULL THEN NULL ELSE 'user_' || left(replace(u.id::text, '-', ''), 24) END,
+		pending_email = NULL,
+		shadow_ban_reason = CASE WHEN u.shadow_ban_reason IS NULL THEN NULL ELSE 'redacted' END
+	FROM pii_users p
+	WHERE p.id = u.id`,
+	// Change history and device registrations carry the original values
+	`DELETE FROM user_changes`,
+	`DELETE FROM user_snapshots`,
+	`DELETE FROM user_events`,
+	`DELETE FROM user_handle_history`,
+	`DELETE FROM phone_verifications`,
+	`DELETE FROM push_subscriptions`,
+	`DELETE FROM notifications`,
+	`DELETE FROM notification_read_cursors`,
+	// Assistant usage and sessions are keyed by the original emails
+	`DELETE FROM ai_usage`,
+	`DELETE FROM user_sessions`,
+	`DELETE FROM login_fingerprints`,
+	`DELETE FROM security_events`,
+	`DELETE FROM magic_links`,
+	`DELETE FROM auth_sessions`,
+	`DELETE FROM webauthn_credentials`,
+	`DELETE FROM webauthn_challenges`,
+	`DELETE FROM user_identities`,
+	`DELETE FROM account_merges`,
+	`DELETE FROM email_changes`,
+	// Queued job payloads may hold personal data, and staging must not
+	// send production notifications
+	`DELETE FROM jobs`,
+	// Sent email holds the original addresses; the suppression list does
+	// too and matches nothing once they are scrubbed
+	`DELETE FROM email_messages`,
+	`DELETE FROM email_suppressions`,
+	// Projections are rebuilt from the scrubbed tables on their next run
+	`DELETE FROM user_search`,
+	`DELETE FROM user_summaries`,
+	`DELETE FROM user_embeddings`,
+	`DELETE FROM projection_checkpoints`,
+}
+
+// pickByHash returns a SQL expression selecting one of values by a stable
+// hash of expr
+func pickByHash(expr string, values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + v + "'"
+	}
+	// Shift the signed hash into a non-negative range before taking the modulus
+	return fmt.Sprintf("(ARRAY[%s])[1 + MOD(hashtext(%s)::bigint + 2147483648, %d)]", strings.Join(quoted, ", "), expr, len(values))
+}
+
+// anonymize runs the scrubbing statements inside the restore transaction
+func anonymize(ctx context.Context, tx pgx.Tx) error {
+	for _, stmt := range anonymizeStatements {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to anonymize restored data: %w", err)
+		}
+	}
+	return nil
+}
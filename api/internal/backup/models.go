@@ -0,0 +1,54 @@
+package backup
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrBackupNotFound = errors.New("backup not found")
+	ErrSchemaMismatch = errors.New("backup schema version does not match database")
+	ErrInvalidArchive = errors.New("invalid backup archive")
+)
+
+// manifestName is the first entry of every backup archive
+const manifestName = "manifest.json"
+
+// formatVersion identifies the archive layout written by Create
+const formatVersion = 1
+
+// Backup is a logical backup recorded in the catalog
+type Backup struct {
+	ID            uuid.UUID `json:"id"`
+	ObjectKey     string    `json:"object_key"`
+	SchemaVersion int64     `json:"schema_version"`
+	TableCount    int       `json:"table_count"`
+	RowCount      int64     `json:"row_count"`
+	SizeBytes     int64     `json:"size_bytes"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Manifest describes the tables in a backup archive
+type Manifest struct {
+	FormatVersion int             `json:"format_version"`
+	SchemaVersion int64           `json:"schema_version"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Tables        []TableManifest `json:"tables"`
+}
+
+// TableManifest lists a table's columns in the order they appear in its CSV
+type TableManifest struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+}
+
+// RestoreResult summarizes a completed restore
+type RestoreResult struct {
+	ObjectKey     string `json:"object_key,omitempty"`
+	SchemaVersion int64  `json:"schema_version"`
+	Tables        int    `json:"tables"`
+	Rows          int64  `json:"rows"`
+}
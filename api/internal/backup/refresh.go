@@ -0,0 +1,67 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RefreshFrom clones a source database, typically a production replica,
+// into this one with PII anonymized. The source is read from a single
+// snapshot and the load is one transaction, so staging either gets a
+// complete consistent copy or keeps its previous data.
+func (s *Service) RefreshFrom(ctx context.Context, sourceDSN, actor string) (*RestoreResult, error) {
+	source, err := pgx.Connect(ctx, sourceDSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to source database: %w", err)
+	}
+	defer source.Close(context.Background())
+
+	tx, err := source.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin source transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	dir, err := os.MkdirTemp("", "refresh-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh workspace: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, err := exportTables(ctx, tx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var open []io.Closer
+	defer func() {
+		for _, f := range open {
+			f.Close()
+		}
+	}()
+	result, err := s.load(ctx, manifest, func(table TableManifest) (io.Reader, error) {
+		f, err := os.Open(filepath.Join(dir, table.Name+".csv"))
+		if err != nil {
+			return nil, err
+		}
+		open = append(open, f)
+		return f, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, actor, "staging.refreshed", source.Config().Host+"/"+source.Config().Database, map[string]any{
+		"schema_version": result.SchemaVersion,
+		"tables":         result.Tables,
+		"rows":           result.Rows,
+		"anonymized":     true,
+	})
+	s.logger.Info("staging refreshed", "tables", result.Tables, "rows", result.Rows)
+	return result, nil
+}
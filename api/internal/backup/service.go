@@ -0,0 +1,478 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// excludedTables are never backed up or restored: migration state belongs
+// to the target database and the catalog describes the source's objects
+var excludedTables = []string{"goose_db_version", "backups"}
+
+type Querier interface {
+	CreateBackup(ctx context.Context, arg db.CreateBackupParams) (db.Backup, error)
+	GetLatestBackup(ctx context.Context) (db.Backup, error)
+	ListBackups(ctx context.Context) ([]db.Backup, error)
+	ListExpiredBackups(ctx context.Context, arg db.ListExpiredBackupsParams) ([]db.Backup, error)
+	DeleteBackup(ctx context.Context, id pgtype.UUID) error
+}
+
+// DB starts the transactions backups and restores run in
+type DB interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service writes COPY-based logical backups to object storage, prunes them
+// by retention, and restores them with PII anonymized
+type Service struct {
+	db          DB
+	queries     Querier
+	storage     storage.Storage
+	auditor     Auditor
+	logger      *slog.Logger
+	retainCount int
+	retainFor   time.Duration
+}
+
+func NewService(database DB, queries Querier, store storage.Storage, auditor Auditor, logger *slog.Logger, retainCount int, retainFor time.Duration) *Service {
+	return &Service{
+		db:          database,
+		queries:     queries,
+		storage:     store,
+		auditor:     auditor,
+		logger:      logger,
+		retainCount: retainCount,
+		retainFor:   retainFor,
+	}
+}
+
+// RunScheduled creates a backup and prunes expired ones
+func (s *Service) RunScheduled(ctx context.Context) error {
+	if _, err := s.Create(ctx, audit.SystemActor); err != nil {
+		return err
+	}
+	_, err := s.Prune(ctx, audit.SystemActor)
+	return err
+}
+
+// Create exports every application table from one consistent snapshot into
+// a gzip-compressed tar of CSV files and uploads it to storage
+func (s *Service) Create(ctx context.Context, actor string) (*Backup, error) {
+	ctx = database.WithWorkload(ctx, database.WorkloadReporting)
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin backup transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	dir, err := os.MkdirTemp("", "backup-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup workspace: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, err := exportTables(ctx, tx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	archivePath := filepath.Join(dir, "backup.tar.gz")
+	size, err := writeArchive(archivePath, dir, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("backups/%s_%s.tar.gz", manifest.CreatedAt.UTC().Format("20060102T150405Z"), uuid.NewString()[:8])
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	if err := s.storage.Put(ctx, key, archive); err != nil {
+		return nil, fmt.Errorf("failed to upload backup: %w", err)
+	}
+
+	var rows int64
+	for _, table := range manifest.Tables {
+		rows += table.Rows
+	}
+	record, err := s.queries.CreateBackup(ctx, db.CreateBackupParams{
+		ObjectKey:     key,
+		SchemaVersion: manifest.SchemaVersion,
+		TableCount:    int32(len(manifest.Tables)),
+		RowCount:      rows,
+		SizeBytes:     size,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record backup: %w", err)
+	}
+
+	backup := toBackup(record)
+	s.record(ctx, actor, "backup.created", backup.ObjectKey, map[string]any{
+		"schema_version": backup.SchemaVersion,
+		"tables":         backup.TableCount,
+		"rows":           backup.RowCount,
+		"size_bytes":     backup.SizeBytes,
+	})
+	s.logger.Info("backup created", "key", key, "tables", backup.TableCount, "rows", rows, "size_bytes", size)
+	return backup, nil
+}
+
+// List returns the backup catalog, newest first
+func (s *Service) List(ctx context.Context) ([]*Backup, error) {
+	records, err := s.queries.ListBackups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	backups := make([]*Backup, len(records))
+	for i, record := range records {
+		backups[i] = toBackup(record)
+	}
+	return backups, nil
+}
+
+// Latest returns the most recent backup in the catalog
+func (s *Service) Latest(ctx context.Context) (*Backup, error) {
+	record, err := s.queries.GetLatestBackup(ctx)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBackupNotFound
+		}
+		return nil, fmt.Errorf("failed to get latest backup: %w", err)
+	}
+	return toBackup(record), nil
+}
+
+// Prune deletes backups that are both beyond the newest retainCount and
+// older than retainFor, removing the object before its catalog entry
+func (s *Service) Prune(ctx context.Context, actor string) (int, error) {
+	expired, err := s.queries.ListExpiredBackups(ctx, db.ListExpiredBackupsParams{
+		Cutoff:    pgtype.Timestamptz{Time: time.Now().Add(-s.retainFor), Valid: true},
+		KeepCount: int32(s.retainCount),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired backups: %w", err)
+	}
+
+	for i, record := range expired {
+		if err := s.storage.Delete(ctx, record.ObjectKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return i, fmt.Errorf("failed to delete backup object %s: %w", record.ObjectKey, err)
+		}
+		if err := s.queries.DeleteBackup(ctx, record.ID); err != nil {
+			return i, fmt.Errorf("failed to delete backup record: %w", err)
+		}
+		s.record(ctx, actor, "backup.deleted", record.ObjectKey, nil)
+	}
+	return len(expired), nil
+}
+
+// Restore replaces the contents of every table in the backup with the
+// archived rows and anonymizes PII, all in one transaction so a failed
+// restore leaves the database untouched. The database must be migrated to
+// the backup's schema version. Intended for refreshing staging from
+// production backups; the caller decides where that is allowed.
+func (s *Service) Restore(ctx context.Context, key, actor string) (*RestoreResult, error) {
+	obj, err := s.storage.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrBackupNotFound
+		}
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer obj.Close()
+
+	gz, err := gzip.NewReader(obj)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	manifest, err := readManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.load(ctx, manifest, func(table TableManifest) (io.Reader, error) {
+		header, err := tr.Next()
+		if err != nil {
+			return nil, fmt.Errorf("%w: missing data for %s: %v", ErrInvalidArchive, table.Name, err)
+		}
+		if header.Name != table.Name+".csv" {
+			return nil, fmt.Errorf("%w: expected %s.csv, found %s", ErrInvalidArchive, table.Name, header.Name)
+		}
+		return tr, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.ObjectKey = key
+
+	s.record(ctx, actor, "backup.restored", key, map[string]any{
+		"schema_version": result.SchemaVersion,
+		"tables":         result.Tables,
+		"rows":           result.Rows,
+		"anonymized":     true,
+	})
+	return result, nil
+}
+
+// load replaces the contents of the manifest's tables with the CSV data
+// returned by next, in manifest order, then anonymizes PII and commits
+func (s *Service) load(ctx context.Context, manifest *Manifest, next func(TableManifest) (io.Reader, error)) (*RestoreResult, error) {
+	ctx = database.WithWorkload(ctx, database.WorkloadReporting)
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin restore transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	schemaVersion, err := currentSchemaVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if schemaVersion != manifest.SchemaVersion {
+		return nil, fmt.Errorf("%w: source is at %d, database is at %d", ErrSchemaMismatch, manifest.SchemaVersion, schemaVersion)
+	}
+
+	// Skip triggers and foreign key checks while tables are reloaded in
+	// arbitrary order; this requires a superuser or equivalent role
+	if _, err := tx.Exec(ctx, "SET LOCAL session_replication_role = replica"); err != nil {
+		return nil, fmt.Errorf("failed to disable triggers for restore: %w", err)
+	}
+
+	names := make([]string, len(manifest.Tables))
+	for i, table := range manifest.Tables {
+		names[i] = pgx.Identifier{table.Name}.Sanitize()
+	}
+	if _, err := tx.Exec(ctx, "TRUNCATE "+strings.Join(names, ", ")); err != nil {
+		return nil, fmt.Errorf("failed to truncate tables: %w", err)
+	}
+
+	result := &RestoreResult{SchemaVersion: manifest.SchemaVersion, Tables: len(manifest.Tables)}
+	for _, table := range manifest.Tables {
+		data, err := next(table)
+		if err != nil {
+			return nil, err
+		}
+		tag, err := tx.Conn().PgConn().CopyFrom(ctx, data, copyStatement(table, "FROM STDIN"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", table.Name, err)
+		}
+		result.Rows += tag.RowsAffected()
+	}
+
+	if err := anonymize(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit restore: %w", err)
+	}
+	return result, nil
+}
+
+// exportTables copies each table to a CSV file in dir
+func exportTables(ctx context.Context, tx pgx.Tx, dir string) (*Manifest, error) {
+	schemaVersion, err := currentSchemaVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := listTables(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		FormatVersion: formatVersion,
+		SchemaVersion: schemaVersion,
+		CreatedAt:     time.Now().UTC(),
+	}
+	for _, table := range tables {
+		f, err := os.Create(filepath.Join(dir, table.Name+".csv"))
+		if err != nil {
+			return nil, err
+		}
+		tag, err := tx.Conn().PgConn().CopyTo(ctx, f, copyStatement(table, "TO STDOUT"))
+		closeErr := f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", table.Name, err)
+		}
+		if closeErr != nil {
+			return nil, closeErr
+		}
+
+		table.Rows = tag.RowsAffected()
+		manifest.Tables = append(manifest.Tables, table)
+	}
+	return manifest, nil
+}
+
+// writeArchive packs the manifest and table CSVs into a tar.gz at path and
+// returns its size
+func writeArchive(path, dir string, manifest *Manifest) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0o600, Size: int64(len(encoded)), ModTime: manifest.CreatedAt}); err != nil {
+		return 0, err
+	}
+	if _, err := tw.Write(encoded); err != nil {
+		return 0, err
+	}
+
+	for _, table := range manifest.Tables {
+		if err := addFile(tw, filepath.Join(dir, table.Name+".csv"), table.Name+".csv", manifest.CreatedAt); err != nil {
+			return 0, fmt.Errorf("failed to archive %s: %w", table.Name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func addFile(tw *tar.Writer, path, name string, modTime time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: info.Size(), ModTime: modTime}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func readManifest(tr *tar.Reader) (*Manifest, error) {
+	header, err := tr.Next()
+	if err != nil || header.Name != manifestName {
+		return nil, fmt.Errorf("%w: missing manifest", ErrInvalidArchive)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if manifest.FormatVersion != formatVersion {
+		return nil, fmt.Errorf("%w: unsupported format version %d", ErrInvalidArchive, manifest.FormatVersion)
+	}
+	return &manifest, nil
+}
+
+// currentSchemaVersion returns the highest applied goose migration
+func currentSchemaVersion(ctx context.Context, tx pgx.Tx) (int64, error) {
+	var version int64
+	if err := tx.QueryRow(ctx, "SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// listTables returns the application tables and their columns
+func listTables(ctx context.Context, tx pgx.Tx) ([]TableManifest, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT c.table_name, array_agg(c.column_name::text ORDER BY c.ordinal_position)
+		FROM information_schema.columns c
+		JOIN information_schema.tables t
+			ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = 'public'
+			AND t.table_type = 'BASE TABLE'
+			AND c.table_name <> ALL($1)
+		GROUP BY c.table_name
+		ORDER BY c.table_name`, excludedTables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []TableManifest
+	for rows.Next() {
+		var table TableManifest
+		if err := rows.Scan(&table.Name, &table.Columns); err != nil {
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+// copyStatement builds a CSV COPY for the table's listed columns
+func copyStatement(table TableManifest, direction string) string {
+	columns := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		columns[i] = pgx.Identifier{column}.Sanitize()
+	}
+	return fmt.Sprintf("COPY %s (%s) %s WITH (FORMAT csv)", pgx.Identifier{table.Name}.Sanitize(), strings.Join(columns, ", "), direction)
+}
+
+func (s *Service) record(ctx context.Context, actor, action, key string, metadata map[string]any) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "backup",
+		ResourceID:   key,
+		Metadata:     metadata,
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "action", action, "error", err)
+	}
+}
+
+func toBackup(record db.Backup) *Backup {
+	return &Backup{
+		ID:            uuid.UUID(record.ID.Bytes),
+		ObjectKey:     record.ObjectKey,
+		SchemaVersion: record.SchemaVersion,
+		TableCount:    int(record.TableCount),
+		RowCount:      record.RowCount,
+		SizeBytes:     record.SizeBytes,
+		CreatedAt:     record.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,220 @@
+package campaigns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/request"
+	"starterkit/internal/templates"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	Create(ctx context.Context, req CreateRequest, actor string) (*Campaign, error)
+	Start(ctx context.Context, id uuid.UUID, actor string) (*Campaign, error)
+	Pause(ctx context.Context, id uuid.UUID, actor string) (*Campaign, error)
+	Resume(ctx context.Context, id uuid.UUID, actor string) (*Campaign, error)
+	Get(ctx context.Context, id uuid.UUID) (*Campaign, error)
+	List(ctx context.Context, limit int) ([]*Campaign, error)
+	Recipients(ctx context.Context, id uuid.UUID, after, limit int) ([]Recipient, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleCreate drafts a campaign; nothing is sent until it is started
+func (h *Handler) HandleCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		campaign, err := h.service.Create(r.Context(), req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithCampaignError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Location", "/admin/campaigns/"+campaign.ID.String())
+		h.respondWithJSON(w, http.StatusCreated, campaign)
+	}
+}
+
+func (h *Handler) HandleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, ok := h.parseLimit(w, r, 20, 100)
+		if !ok {
+			return
+		}
+
+		campaigns, err := h.service.List(r.Context(), limit)
+		if err != nil {
+			h.respondWithCampaignError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, map[string]any{"campaigns": campaigns})
+	}
+}
+
+// HandleGet returns a campaign with its progress and delivery stats
+func (h *Handler) HandleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		campaignID, ok := h.parseCampaignID(w, r)
+		if !ok {
+			return
+		}
+
+		campaign, err := h.service.Get(r.Context(), campaignID)
+		if err != nil {
+			h.respondWithCampaignError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, campaign)
+	}
+}
+
+// HandleRecipients returns the per-user report of a campaign a page at a
+// time. next_after is the ?after to pass for the following page and is
+// left out on the last one.
+func (h *Handler) HandleRecipients() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		campaignID, ok := h.parseCampaignID(w, r)
+		if !ok {
+			return
+		}
+		after := 0
+		if afterStr := r.URL.Query().Get("after"); afterStr != "" {
+			parsed, err := strconv.Atoi(afterStr)
+			if err != nil || parsed < 0 {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid after parameter"))
+				return
+			}
+			after = parsed
+		}
+		limit, ok := h.parseLimit(w, r, 100, 1000)
+		if !ok {
+			return
+		}
+
+		campaign, err := h.service.Get(r.Context(), campaignID)
+		if err != nil {
+			h.respondWithCampaignError(w, r, err)
+			return
+		}
+		recipients, err := h.service.Recipients(r.Context(), campaignID, after, limit)
+		if err != nil {
+			h.respondWithCampaignError(w, r, err)
+			return
+		}
+		resp := map[string]any{"campaign": campaign, "recipients": recipients}
+		if len(recipients) == limit && recipients[len(recipients)-1].Position < campaign.Total {
+			resp["next_after"] = recipients[len(recipients)-1].Position
+		}
+		h.respondWithJSON(w, http.StatusOK, resp)
+	}
+}
+
+// HandleStart selects a draft campaign's recipients and starts queueing
+// their messages
+func (h *Handler) HandleStart() http.HandlerFunc {
+	return h.handleTransition(ServiceInterface.Start, http.StatusAccepted)
+}
+
+// HandlePause stops a running campaign from queueing more messages
+func (h *Handler) HandlePause() http.HandlerFunc {
+	return h.handleTransition(ServiceInterface.Pause, http.StatusOK)
+}
+
+// HandleResume continues a paused campaign
+func (h *Handler) HandleResume() http.HandlerFunc {
+	return h.handleTransition(ServiceInterface.Resume, http.StatusAccepted)
+}
+
+// handleTransition serves a status change. The service method is bound per
+// request, as routes are also declared before the handler has a service.
+func (h *Handler) handleTransition(transition func(s ServiceInterface, ctx context.Context, id uuid.UUID, actor string) (*Campaign, error), status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		campaignID, ok := h.parseCampaignID(w, r)
+		if !ok {
+			return
+		}
+
+		campaign, err := transition(h.service, r.Context(), campaignID, actorFromRequest(r))
+		if err != nil {
+			h.respondWithCampaignError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, status, campaign)
+	}
+}
+
+func (h *Handler) parseCampaignID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	campaignID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_campaign_id", "invalid campaign ID format"))
+		return uuid.Nil, false
+	}
+	return campaignID, true
+}
+
+func (h *Handler) parseLimit(w http.ResponseWriter, r *http.Request, fallback, maxLimit int) (int, bool) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return fallback, true
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > maxLimit {
+		apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "limit must be between 1 and "+strconv.Itoa(maxLimit)))
+		return 0, false
+	}
+	return limit, true
+}
+
+func (h *Handler) respondWithCampaignError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrCampaignNotFound):
+		apierror.Write(w, r, apierror.NotFound("campaign_not_found", err.Error()))
+	case errors.Is(err, ErrStatusConflict):
+		apierror.Write(w, r, apierror.Conflict("invalid_campaign_status", err.Error()))
+	case errors.Is(err, ErrNoRecipients):
+		apierror.Write(w, r, apierror.Unprocessable("no_recipients", err.Error()))
+	case errors.Is(err, templates.ErrTemplateNotFound):
+		apierror.Write(w, r, apierror.Unprocessable("template_not_found", err.Error()))
+	case errors.Is(err, templates.ErrMissingVariable):
+		apierror.Write(w, r, apierror.Unprocessable("missing_variable", err.Error()))
+	case errors.Is(err, templates.ErrRenderFailed):
+		apierror.Write(w, r, apierror.Unprocessable("render_failed", err.Error()))
+	default:
+		h.logger.Error("campaign request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
@@ -0,0 +1,130 @@
+package campaigns
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrCampaignNotFound = errors.New("campaign not found")
+	ErrNoRecipients     = errors.New("no users match the audience")
+	ErrStatusConflict   = errors.New("campaign cannot do that in its status")
+)
+
+// Campaign statuses. Campaigns are drafted, then run until every recipient
+// has been queued; a running campaign can be paused and resumed.
+const (
+	StatusDraft     = "draft"
+	StatusRunning   = "running"
+	StatusPaused    = "paused"
+	StatusCompleted = "completed"
+)
+
+// Recipient statuses
+const (
+	RecipientPending = "pending"
+	RecipientQueued  = "queued"
+	RecipientSkipped = "skipped"
+	RecipientFailed  = "failed"
+)
+
+const (
+	maxNameLength = 200
+	maxKeyLength  = 100
+)
+
+var rolePattern = regexp.MustCompile(`^[a-z][a-z0-9_.:-]{0,99}$`)
+
+// Audience selects the active users a campaign is sent to. Every criterion
+// given must match; without any, every active user is selected.
+type Audience struct {
+	EmailDomain   string     `json:"email_domain,omitempty" doc:"Domain of the users' emails" example:"example.com"`
+	Query         string     `json:"query,omitempty" doc:"Text the users' email or name contains"`
+	Role          string     `json:"role,omitempty" doc:"Role granted to the users"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty" doc:"Users created at or after this time"`
+	CreatedBefore *time.Time `json:"created_before,omitempty" doc:"Users created before this time"`
+}
+
+// CreateRequest drafts a campaign sending the email template stored under
+// TemplateKey. Data holds the template variables shared by all recipients;
+// name and email are set per recipient.
+type CreateRequest struct {
+	Name        string         `json:"name"`
+	TemplateKey string         `json:"template_key"`
+	Locale      string         `json:"locale,omitempty" doc:"Locale of the template to send; defaults to the request locale"`
+	Data        map[string]any `json:"data,omitempty"`
+	Audience    Audience       `json:"audience"`
+}
+
+func (r *CreateRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+
+	var v request.Validation
+	v.Check(r.Name != "" && utf8.RuneCountInString(r.Name) <= maxNameLength, "name", fmt.Sprintf("must be 1-%d characters", maxNameLength))
+	v.Check(r.TemplateKey != "" && len(r.TemplateKey) <= maxKeyLength, "template_key", fmt.Sprintf("must be 1-%d characters", maxKeyLength))
+	if r.Locale != "" {
+		_, err := language.Parse(r.Locale)
+		v.Check(err == nil, "locale", "must be a BCP 47 language tag")
+	}
+	if r.Audience.Role != "" {
+		v.Check(rolePattern.MatchString(r.Audience.Role), "audience.role", "must be a lowercase role name")
+	}
+	if r.Audience.CreatedAfter != nil && r.Audience.CreatedBefore != nil {
+		v.Check(r.Audience.CreatedAfter.Before(*r.Audience.CreatedBefore), "audience.created_before", "must be after created_after")
+	}
+	return v.Err()
+}
+
+// Campaign is an email sent to an audience of users. Total and the
+// recipient counts cover the users selected when it started; Delivery
+// follows their messages.
+type Campaign struct {
+	ID          uuid.UUID      `json:"id"`
+	Name        string         `json:"name"`
+	TemplateKey string         `json:"template_key"`
+	Locale      string         `json:"locale"`
+	Data        map[string]any `json:"data,omitempty"`
+	Audience    Audience       `json:"audience"`
+	Status      string         `json:"status" enum:"draft,running,paused,completed"`
+	Total       int            `json:"total" doc:"Users the campaign is sent to"`
+	Queued      int            `json:"queued" doc:"Recipients whose message was queued"`
+	Skipped     int            `json:"skipped" doc:"Recipients deactivated or opted out of email since the campaign started"`
+	Failed      int            `json:"failed"`
+	Delivery    *Delivery      `json:"delivery,omitempty"`
+	CreatedBy   string         `json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	FinishedAt  *time.Time     `json:"finished_at,omitempty"`
+}
+
+// Delivery counts a campaign's messages by status, as reported by the
+// provider's webhooks. Opened counts messages opened at least once, for
+// providers that track opens.
+type Delivery struct {
+	Queued     int64 `json:"queued"`
+	Sent       int64 `json:"sent"`
+	Delivered  int64 `json:"delivered"`
+	Bounced    int64 `json:"bounced"`
+	Complained int64 `json:"complained"`
+	Suppressed int64 `json:"suppressed"`
+	Failed     int64 `json:"failed"`
+	Opened     int64 `json:"opened"`
+}
+
+// Recipient is the outcome of a campaign for one user
+type Recipient struct {
+	Position int       `json:"position" doc:"Order of the user in the campaign, from 1"`
+	UserID   uuid.UUID `json:"user_id"`
+	Status   string    `json:"status" enum:"pending,queued,skipped,failed"`
+	Error    string    `json:"error,omitempty" doc:"Why the user was skipped or failed"`
+}
@@ -0,0 +1,61 @@
+package campaigns
+
+import (
+	"log/slog"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/email"
+	"starterkit/internal/jobs"
+	"starterkit/internal/notifications"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/module"
+	"starterkit/internal/platform/wiring"
+	"starterkit/internal/templates"
+)
+
+func init() {
+	module.Register(&Module{})
+}
+
+// Module serves the admin API of email campaigns and works through their
+// recipients on the job queue
+type Module struct {
+	module.Base
+	handler *Handler
+}
+
+func (m *Module) Name() string {
+	return "campaigns"
+}
+
+func (m *Module) Init(c *wiring.Container) error {
+	cfg, logger := wiring.Use[*config.Config](c), wiring.Use[*slog.Logger](c)
+	queue := wiring.Use[*jobs.Queue](c)
+	service := NewService(
+		wiring.Use[*db.Queries](c),
+		wiring.Use[*database.TxManager](c),
+		wiring.Use[*templates.Service](c),
+		wiring.Use[*email.Service](c),
+		wiring.Use[*notifications.Service](c),
+		queue,
+		wiring.Use[audit.Recorder](c),
+		cfg.Email.CampaignBatchSize,
+		cfg.Email.CampaignBatchInterval,
+		logger,
+	)
+	queue.Register(JobSend, service.RunSendJob)
+	m.handler = NewHandler(service, logger)
+	return nil
+}
+
+func (m *Module) RegisterRoutes(r module.Routes) {
+	r.Admin.HandleFunc("GET /campaigns", m.handler.HandleList())
+	r.Admin.HandleFunc("POST /campaigns", m.handler.HandleCreate())
+	r.Admin.HandleFunc("GET /campaigns/{id}", m.handler.HandleGet())
+	r.Admin.HandleFunc("GET /campaigns/{id}/recipients", m.handler.HandleRecipients())
+	r.Admin.HandleFunc("POST /campaigns/{id}/start", m.handler.HandleStart())
+	r.Admin.HandleFunc("POST /campaigns/{id}/pause", m.handler.HandlePause())
+	r.Admin.HandleFunc("POST /campaigns/{id}/resume", m.handler.HandleResume())
+}
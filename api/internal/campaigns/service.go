@@ -0,0 +1,508 @@
+package campaigns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/email"
+	"starterkit/internal/jobs"
+	"starterkit/internal/notifications"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/locale"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/templates"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/text/language"
+)
+
+// JobSend is the job kind that queues one batch of a campaign's messages
+const JobSend = "campaigns.send"
+
+type Querier interface {
+	CreateEmailCampaign(ctx context.Context, arg db.CreateEmailCampaignParams) (db.EmailCampaign, error)
+	GetEmailCampaign(ctx context.Context, id pgtype.UUID) (db.EmailCampaign, error)
+	ListEmailCampaigns(ctx context.Context, limit int32) ([]db.EmailCampaign, error)
+	PauseEmailCampaign(ctx context.Context, id pgtype.UUID) (db.EmailCampaign, error)
+	ResumeEmailCampaign(ctx context.Context, id pgtype.UUID) (db.EmailCampaign, error)
+	ListPendingEmailCampaignRecipients(ctx context.Context, arg db.ListPendingEmailCampaignRecipientsParams) ([]db.ListPendingEmailCampaignRecipientsRow, error)
+	FinishEmailCampaignRecipient(ctx context.Context, arg db.FinishEmailCampaignRecipientParams) error
+	RefreshEmailCampaign(ctx context.Context, id pgtype.UUID) (db.EmailCampaign, error)
+	ListEmailCampaignRecipients(ctx context.Context, arg db.ListEmailCampaignRecipientsParams) ([]db.EmailCampaignRecipient, error)
+	GetEmailCampaignDelivery(ctx context.Context, campaignID pgtype.UUID) (db.GetEmailCampaignDeliveryRow, error)
+}
+
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(q *db.Queries) error, opts ...database.TxOption) error
+}
+
+// Renderer renders stored message templates for a channel and locale
+type Renderer interface {
+	Render(ctx context.Context, key, channel string, locale language.Tag, data map[string]any) (*templates.Rendered, error)
+}
+
+// Mailer queues outbound email
+type Mailer interface {
+	Queue(ctx context.Context, msg email.Email) (*email.Message, error)
+}
+
+// PreferenceLister returns users' notification channel preferences
+type PreferenceLister interface {
+	GetPreferencesForUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]notifications.Preferences, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Enqueuer queues background jobs
+type Enqueuer interface {
+	Schedule(ctx context.Context, kind string, payload any, opts jobs.Options) (*jobs.Job, error)
+}
+
+// sendJob is the payload of a JobSend job
+type sendJob struct {
+	CampaignID uuid.UUID `json:"campaign_id"`
+	// Run is the campaign's run the job was queued in; jobs of an earlier
+	// run, queued before a pause, do nothing
+	Run int32 `json:"run"`
+}
+
+// Service sends admin email campaigns. A campaign's audience is resolved
+// into recipients when it starts, then each job renders and queues the
+// messages of one batch and schedules the next after an interval, so the
+// email queue's rate limit is shared with transactional mail. Recipients
+// keep their outcome, so a retried batch skips those already queued.
+type Service struct {
+	queries   Querier
+	tx        Transactor
+	renderer  Renderer
+	mail      Mailer
+	prefs     PreferenceLister
+	jobs      Enqueuer
+	auditor   Auditor
+	batchSize int
+	interval  time.Duration
+	logger    *slog.Logger
+}
+
+// NewService queues batchSize recipients per job, waiting interval between
+// jobs
+func NewService(queries Querier, tx Transactor, renderer Renderer, mailer Mailer, prefs PreferenceLister, queue Enqueuer, auditor Auditor, batchSize int, interval time.Duration, logger *slog.Logger) *Service {
+	return &Service{
+		queries:   queries,
+		tx:        tx,
+		renderer:  renderer,
+		mail:      mailer,
+		prefs:     prefs,
+		jobs:      queue,
+		auditor:   auditor,
+		batchSize: max(batchSize, 1),
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// Create drafts a campaign. The template must render in the campaign's
+// locale with its data, so a campaign cannot start that would fail for
+// every recipient. The request must have been validated.
+func (s *Service) Create(ctx context.Context, req CreateRequest, actor string) (*Campaign, error) {
+	tag := locale.FromContext(ctx).Locale
+	if req.Locale != "" {
+		tag = language.Make(req.Locale)
+	}
+	if _, err := s.renderer.Render(ctx, req.TemplateKey, templates.ChannelEmail, tag, variables(req.Data, "", "")); err != nil {
+		return nil, err
+	}
+
+	if req.Data == nil {
+		req.Data = map[string]any{}
+	}
+	data, err := json.Marshal(req.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode campaign data: %w", err)
+	}
+	audience, err := json.Marshal(req.Audience)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode campaign audience: %w", err)
+	}
+	row, err := s.queries.CreateEmailCampaign(ctx, db.CreateEmailCampaignParams{
+		Name:        req.Name,
+		TemplateKey: req.TemplateKey,
+		Locale:      tag.String(),
+		Data:        data,
+		Audience:    audience,
+		CreatedBy:   actor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create campaign: %w", err)
+	}
+	campaign := toCampaign(row)
+	s.record(ctx, actor, "email_campaign.created", campaign)
+	return campaign, nil
+}
+
+// Start resolves a draft campaign's audience into recipients and queues
+// its first batch. It is audited as email_campaign.started.
+func (s *Service) Start(ctx context.Context, id uuid.UUID, actor string) (*Campaign, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	var row db.EmailCampaign
+	err := s.tx.WithTx(ctx, func(q *db.Queries) error {
+		var err error
+		row, err = q.StartEmailCampaign(ctx, pgID)
+		if err != nil {
+			return err
+		}
+		var audience Audience
+		if err := json.Unmarshal(row.Audience, &audience); err != nil {
+			return fmt.Errorf("failed to decode campaign audience: %w", err)
+		}
+		total, err := q.AddEmailCampaignRecipients(ctx, db.AddEmailCampaignRecipientsParams{
+			CampaignID:    pgID,
+			EmailDomain:   pgtype.Text{String: audience.EmailDomain, Valid: audience.EmailDomain != ""},
+			Query:         pgtype.Text{String: audience.Query, Valid: audience.Query != ""},
+			CreatedAfter:  optionalTime(audience.CreatedAfter),
+			CreatedBefore: optionalTime(audience.CreatedBefore),
+			Role:          pgtype.Text{String: audience.Role, Valid: audience.Role != ""},
+		})
+		if err != nil {
+			return err
+		}
+		if total == 0 {
+			return ErrNoRecipients
+		}
+		if row, err = q.RefreshEmailCampaign(ctx, pgID); err != nil {
+			return err
+		}
+		return audit.NewService(q).Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "email_campaign.started",
+			ResourceType: "email_campaign",
+			ResourceID:   id.String(),
+			Metadata:     map[string]any{"total": total},
+		})
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, s.conflict(ctx, pgID)
+		}
+		if errors.Is(err, ErrNoRecipients) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to start campaign: %w", err)
+	}
+
+	if err := s.enqueue(ctx, row, 1, time.Time{}); err != nil {
+		return nil, err
+	}
+	return toCampaign(row), nil
+}
+
+// Pause stops a running campaign after the batch being queued, if any.
+// Messages already queued are still sent.
+func (s *Service) Pause(ctx context.Context, id uuid.UUID, actor string) (*Campaign, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	row, err := s.queries.PauseEmailCampaign(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, s.conflict(ctx, pgID)
+		}
+		return nil, fmt.Errorf("failed to pause campaign: %w", err)
+	}
+	campaign := toCampaign(row)
+	s.record(ctx, actor, "email_campaign.paused", campaign)
+	return campaign, nil
+}
+
+// Resume continues a paused campaign with its next pending recipients
+func (s *Service) Resume(ctx context.Context, id uuid.UUID, actor string) (*Campaign, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	row, err := s.queries.ResumeEmailCampaign(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, s.conflict(ctx, pgID)
+		}
+		return nil, fmt.Errorf("failed to resume campaign: %w", err)
+	}
+	if err := s.enqueue(ctx, row, 0, time.Time{}); err != nil {
+		return nil, err
+	}
+	campaign := toCampaign(row)
+	s.record(ctx, actor, "email_campaign.resumed", campaign)
+	return campaign, nil
+}
+
+// conflict explains why a campaign could not change status: it is missing,
+// or in a status that does not allow the change
+func (s *Service) conflict(ctx context.Context, id pgtype.UUID) error {
+	row, err := s.queries.GetEmailCampaign(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCampaignNotFound
+		}
+		return fmt.Errorf("failed to get campaign: %w", err)
+	}
+	return fmt.Errorf("%w: it is %s", ErrStatusConflict, row.Status)
+}
+
+// enqueue queues the batch of a campaign's current run starting at
+// position, at runAt. Keying the job by run and position keeps a retried
+// batch from queueing the next one twice.
+func (s *Service) enqueue(ctx context.Context, row db.EmailCampaign, position int32, runAt time.Time) error {
+	id := uuid.UUID(row.ID.Bytes)
+	_, err := s.jobs.Schedule(ctx, JobSend, sendJob{CampaignID: id, Run: row.Run}, jobs.Options{
+		RunAt:     runAt,
+		UniqueKey: fmt.Sprintf("%s:%d:%d", id, row.Run, position),
+	})
+	if err != nil && !errors.Is(err, jobs.ErrDuplicateJob) {
+		return fmt.Errorf("failed to queue campaign batch: %w", err)
+	}
+	return nil
+}
+
+// RunSendJob queues the messages of a campaign's next batch of pending
+// recipients and schedules the batch after it. Recipients who were
+// deactivated or turned email off since the campaign started are skipped.
+// An unexpected error fails the job so the batch is retried; on the last
+// attempt the recipient is marked failed instead, so one user cannot
+// stall the campaign.
+func (s *Service) RunSendJob(ctx context.Context, payload json.RawMessage) error {
+	var job sendJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("failed to decode campaign job: %w", err)
+	}
+	campaignID := pgtype.UUID{Bytes: job.CampaignID, Valid: true}
+	row, err := s.queries.GetEmailCampaign(ctx, campaignID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to get campaign: %w", err)
+	}
+	if row.Status != StatusRunning || row.Run != job.Run {
+		return nil
+	}
+	var data map[string]any
+	if err := json.Unmarshal(row.Data, &data); err != nil {
+		return fmt.Errorf("failed to decode campaign data: %w", err)
+	}
+
+	recipients, err := s.queries.ListPendingEmailCampaignRecipients(ctx, db.ListPendingEmailCampaignRecipientsParams{
+		CampaignID: campaignID,
+		RowLimit:   int32(s.batchSize),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list campaign recipients: %w", err)
+	}
+	userIDs := make([]uuid.UUID, len(recipients))
+	for i, r := range recipients {
+		userIDs[i] = uuid.UUID(r.UserID.Bytes)
+	}
+	prefs, err := s.prefs.GetPreferencesForUsers(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	log := logger.FromContext(ctx).With("campaign_id", job.CampaignID)
+	tag := language.Make(row.Locale)
+	for _, r := range recipients {
+		status, reason, err := s.send(ctx, row, r, prefs[uuid.UUID(r.UserID.Bytes)], tag, data)
+		if err != nil {
+			if !jobs.LastAttempt(ctx) {
+				return fmt.Errorf("failed to queue campaign message for user %s: %w", uuid.UUID(r.UserID.Bytes), err)
+			}
+			log.Error("campaign message failed", "user_id", uuid.UUID(r.UserID.Bytes), "error", err)
+			status, reason = RecipientFailed, "internal error"
+		}
+		if err := s.queries.FinishEmailCampaignRecipient(ctx, db.FinishEmailCampaignRecipientParams{
+			CampaignID: campaignID,
+			Position:   r.Position,
+			Status:     status,
+			Error:      pgtype.Text{String: reason, Valid: reason != ""},
+		}); err != nil {
+			return fmt.Errorf("failed to record campaign recipient: %w", err)
+		}
+	}
+
+	row, err = s.queries.RefreshEmailCampaign(ctx, campaignID)
+	if err != nil {
+		return fmt.Errorf("failed to update campaign: %w", err)
+	}
+	if row.Status == StatusCompleted {
+		log.Info("campaign completed", "queued", row.Queued, "skipped", row.Skipped, "failed", row.Failed)
+		return nil
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+	return s.enqueue(ctx, row, recipients[len(recipients)-1].Position+1, time.Now().Add(s.interval))
+}
+
+// send renders and queues the message of one recipient and returns its
+// outcome. Errors are unexpected; a recipient who can no longer be mailed
+// is an outcome.
+func (s *Service) send(ctx context.Context, row db.EmailCampaign, r db.ListPendingEmailCampaignRecipientsRow, prefs notifications.Preferences, tag language.Tag, data map[string]any) (string, string, error) {
+	if !r.Email.Valid {
+		return RecipientSkipped, "user not found or deactivated", nil
+	}
+	if !prefs[notifications.ChannelEmail] {
+		return RecipientSkipped, "email disabled by user", nil
+	}
+
+	rendered, err := s.renderer.Render(ctx, row.TemplateKey, templates.ChannelEmail, tag, variables(data, r.Name.String, r.Email.String))
+	switch {
+	case errors.Is(err, templates.ErrTemplateNotFound), errors.Is(err, templates.ErrMissingVariable), errors.Is(err, templates.ErrRenderFailed):
+		return RecipientFailed, err.Error(), nil
+	case err != nil:
+		return "", "", err
+	}
+
+	userID, campaignID := uuid.UUID(r.UserID.Bytes), uuid.UUID(row.ID.Bytes)
+	_, err = s.mail.Queue(ctx, email.Email{
+		UserID:     &userID,
+		CampaignID: &campaignID,
+		To:         r.Email.String,
+		Subject:    rendered.Subject,
+		HTML:       rendered.Body,
+	})
+	if errors.Is(err, email.ErrInvalidAddress) {
+		return RecipientFailed, err.Error(), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return RecipientQueued, "", nil
+}
+
+// Get returns a campaign with its progress and delivery
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Campaign, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	row, err := s.queries.GetEmailCampaign(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, fmt.Errorf("failed to get campaign: %w", err)
+	}
+	delivery, err := s.queries.GetEmailCampaignDelivery(ctx, pgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count campaign messages: %w", err)
+	}
+
+	campaign := toCampaign(row)
+	campaign.Delivery = &Delivery{
+		Queued:     delivery.Queued,
+		Sent:       delivery.Sent,
+		Delivered:  delivery.Delivered,
+		Bounced:    delivery.Bounced,
+		Complained: delivery.Complained,
+		Suppressed: delivery.Suppressed,
+		Failed:     delivery.Failed,
+		Opened:     delivery.Opened,
+	}
+	return campaign, nil
+}
+
+// List returns the latest campaigns, newest first
+func (s *Service) List(ctx context.Context, limit int) ([]*Campaign, error) {
+	rows, err := s.queries.ListEmailCampaigns(ctx, int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list campaigns: %w", err)
+	}
+	campaigns := make([]*Campaign, len(rows))
+	for i, row := range rows {
+		campaigns[i] = toCampaign(row)
+	}
+	return campaigns, nil
+}
+
+// Recipients returns up to limit outcomes of a campaign after position, in
+// order. Recipients not yet queued are pending.
+func (s *Service) Recipients(ctx context.Context, id uuid.UUID, after, limit int) ([]Recipient, error) {
+	rows, err := s.queries.ListEmailCampaignRecipients(ctx, db.ListEmailCampaignRecipientsParams{
+		CampaignID: pgtype.UUID{Bytes: id, Valid: true},
+		After:      int32(after),
+		RowLimit:   int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list campaign recipients: %w", err)
+	}
+	recipients := make([]Recipient, len(rows))
+	for i, row := range rows {
+		recipients[i] = Recipient{
+			Position: int(row.Position),
+			UserID:   uuid.UUID(row.UserID.Bytes),
+			Status:   row.Status,
+			Error:    row.Error.String,
+		}
+	}
+	return recipients, nil
+}
+
+// record writes an audit entry; failures are logged since the change has
+// already been committed
+func (s *Service) record(ctx context.Context, actor, action string, campaign *Campaign) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "email_campaign",
+		ResourceID:   campaign.ID.String(),
+		Metadata:     map[string]any{"name": campaign.Name, "template_key": campaign.TemplateKey},
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+// variables are the template data of one recipient: the campaign's data
+// with the recipient's name and email
+func variables(data map[string]any, name, address string) map[string]any {
+	vars := maps.Clone(data)
+	if vars == nil {
+		vars = make(map[string]any, 2)
+	}
+	vars["name"] = name
+	vars["email"] = address
+	return vars
+}
+
+func toCampaign(row db.EmailCampaign) *Campaign {
+	campaign := &Campaign{
+		ID:          uuid.UUID(row.ID.Bytes),
+		Name:        row.Name,
+		TemplateKey: row.TemplateKey,
+		Locale:      row.Locale,
+		Status:      row.Status,
+		Total:       int(row.Total),
+		Queued:      int(row.Queued),
+		Skipped:     int(row.Skipped),
+		Failed:      int(row.Failed),
+		CreatedBy:   row.CreatedBy,
+		CreatedAt:   row.CreatedAt.Time,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}
+	_ = json.Unmarshal(row.Data, &campaign.Data)
+	_ = json.Unmarshal(row.Audience, &campaign.Audience)
+	if row.StartedAt.Valid {
+		campaign.StartedAt = &row.StartedAt.Time
+	}
+	if row.FinishedAt.Valid {
+		campaign.FinishedAt = &row.FinishedAt.Time
+	}
+	return campaign
+}
+
+func optionalTime(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
@@ -0,0 +1,56 @@
+package clientsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/fieldaccess"
+)
+
+type ServiceInterface interface {
+	Sync(ctx context.Context, token, viewer string) (*Response, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) HandleSync() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := h.service.Sync(r.Context(), r.URL.Query().Get("token"), r.Header.Get("X-User-Email"))
+		if err != nil {
+			if errors.Is(err, ErrInvalidSyncToken) {
+				// 410 tells the client to discard its cache and resync from scratch
+				apierror.Write(w, r, apierror.New(http.StatusGone, "invalid_sync_token", "invalid sync token, full resync required"))
+				return
+			}
+			h.logger.Error("failed to sync", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, r, http.StatusOK, resp)
+	}
+}
+
+// respondWithJSON writes payload with the fields the caller may not see
+// stripped or masked
+func (h *Handler) respondWithJSON(w http.ResponseWriter, r *http.Request, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(fieldaccess.Apply(r.Context(), payload)); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
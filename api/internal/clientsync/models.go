@@ -0,0 +1,24 @@
+package clientsync
+
+import (
+	"starterkit/internal/users"
+
+	"github.com/google/uuid"
+)
+
+// EntityChanges groups the upserts and tombstones for one entity type
+type EntityChanges[T any] struct {
+	Upserts []T         `json:"upserts"`
+	Deletes []uuid.UUID `json:"deletes"`
+}
+
+// Response is returned by the sync endpoint. Clients persist SyncToken and
+// send it back on the next call; when HasMore is true they should call
+// again immediately. Full is set on the first page of a snapshot, when
+// clients drop every entity they hold before applying the page.
+type Response struct {
+	Users     EntityChanges[*users.User] `json:"users"`
+	SyncToken string                     `json:"sync_token"`
+	HasMore   bool                       `json:"has_more"`
+	Full      bool                       `json:"full"`
+}
@@ -0,0 +1,243 @@
+package clientsync
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"starterkit/internal/db"
+	"starterkit/internal/users"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrInvalidSyncToken = errors.New("invalid sync token")
+
+const (
+	tokenPrefix = "v2:"
+	batchSize   = 1000
+)
+
+type Querier interface {
+	GetUserChangeLogEnd(ctx context.Context) (int64, error)
+	ListUserChangesSince(ctx context.Context, arg db.ListUserChangesSinceParams) ([]db.UserChange, error)
+	ListActiveUsersSnapshot(ctx context.Context, arg db.ListActiveUsersSnapshotParams) ([]db.ListActiveUsersSnapshotRow, error)
+}
+
+type Service struct {
+	queries Querier
+}
+
+func NewService(queries Querier) *Service {
+	return &Service{
+		queries: queries,
+	}
+}
+
+// Sync returns entities changed since the given token as seen by viewer,
+// the caller's email. An empty token starts a full snapshot of all live
+// entities, which is returned over as many pages as it takes.
+func (s *Service) Sync(ctx context.Context, token, viewer string) (*Response, error) {
+	if token == "" {
+		// Read the change log position first so that changes racing with
+		// the snapshot are replayed once it is done instead of being lost
+		end, err := s.queries.GetUserChangeLogEnd(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.snapshot(ctx, syncToken{position: users.ChangePosition{Txid: end}}, viewer)
+		if err != nil {
+			return nil, err
+		}
+		resp.Full = true
+		return resp, nil
+	}
+
+	decoded, err := decodeToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if decoded.after != nil {
+		return s.snapshot(ctx, decoded, viewer)
+	}
+	since := decoded.position
+
+	dbChanges, err := s.queries.ListUserChangesSince(ctx, db.ListUserChangesSinceParams{
+		SinceTxid: since.Txid,
+		SinceID:   since.ID,
+		RowLimit:  batchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Response{
+		Users:     EntityChanges[*users.User]{Upserts: []*users.User{}, Deletes: []uuid.UUID{}},
+		SyncToken: encodeToken(syncToken{position: since}),
+		HasMore:   len(dbChanges) == batchSize,
+	}
+	if len(dbChanges) == 0 {
+		return resp, nil
+	}
+
+	// Collapse the change log to the final state of each user
+	latest := make(map[uuid.UUID]db.UserChange)
+	order := []uuid.UUID{}
+	for _, change := range dbChanges {
+		id := uuid.UUID(change.UserID.Bytes)
+		if _, seen := latest[id]; !seen {
+			order = append(order, id)
+		}
+		latest[id] = change
+	}
+
+	for _, id := range order {
+		change := latest[id]
+		user, deleted, err := decodeUserRow(change, viewer)
+		if err != nil {
+			return nil, err
+		}
+		if deleted {
+			resp.Users.Deletes = append(resp.Users.Deletes, id)
+			continue
+		}
+		resp.Users.Upserts = append(resp.Users.Upserts, user)
+	}
+
+	last := dbChanges[len(dbChanges)-1]
+	resp.SyncToken = encodeToken(syncToken{position: users.ChangePosition{Txid: last.Txid, ID: last.ID}})
+	return resp, nil
+}
+
+// snapshot returns the page of the snapshot after the user the token names,
+// and a token for the next page, or for the changes since the snapshot
+// started once it is done
+func (s *Service) snapshot(ctx context.Context, token syncToken, viewer string) (*Response, error) {
+	after := pgtype.UUID{Valid: true}
+	if token.after != nil {
+		after.Bytes = *token.after
+	}
+	rows, err := s.queries.ListActiveUsersSnapshot(ctx, db.ListActiveUsersSnapshotParams{
+		Viewer:   viewer,
+		After:    after,
+		RowLimit: batchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	upserts := make([]*users.User, len(rows))
+	for i, row := range rows {
+		var phone *string
+		if row.Phone.Valid {
+			phone = &row.Phone.String
+		}
+		upserts[i] = &users.User{
+			ID:            uuid.UUID(row.ID.Bytes),
+			Email:         row.Email,
+			Name:          row.Name,
+			Bio:           row.Bio,
+			Phone:         phone,
+			PhoneVerified: row.PhoneVerifiedAt.Valid,
+			CreatedAt:     row.CreatedAt.Time,
+			UpdatedAt:     row.UpdatedAt.Time,
+		}
+	}
+
+	next := syncToken{position: token.position}
+	if len(rows) == batchSize {
+		last := uuid.UUID(rows[len(rows)-1].ID.Bytes)
+		next.after = &last
+	}
+	return &Response{
+		Users:     EntityChanges[*users.User]{Upserts: upserts, Deletes: []uuid.UUID{}},
+		SyncToken: encodeToken(next),
+		HasMore:   next.after != nil,
+	}, nil
+}
+
+// userRow mirrors the JSON written by the record_user_change trigger
+type userRow struct {
+	ID              uuid.UUID  `json:"id"`
+	Email           string     `json:"email"`
+	Name            string     `json:"name"`
+	Bio             string     `json:"bio"`
+	Phone           *string    `json:"phone"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at"`
+	ShadowBannedAt  *time.Time `json:"shadow_banned_at"`
+}
+
+func decodeUserRow(change db.UserChange, viewer string) (*users.User, bool, error) {
+	if change.Operation == "DELETE" || len(change.NewData) == 0 {
+		return nil, true, nil
+	}
+
+	var row userRow
+	if err := json.Unmarshal(change.NewData, &row); err != nil {
+		return nil, false, err
+	}
+	// Shadow banned users look deleted to everyone but themselves
+	if row.DeletedAt != nil || (row.ShadowBannedAt != nil && row.Email != viewer) {
+		return nil, true, nil
+	}
+
+	return &users.User{
+		ID:            row.ID,
+		Email:         row.Email,
+		Name:          row.Name,
+		Bio:           row.Bio,
+		Phone:         row.Phone,
+		PhoneVerified: row.PhoneVerifiedAt != nil,
+		CreatedAt:     row.CreatedAt,
+		UpdatedAt:     row.UpdatedAt,
+	}, false, nil
+}
+
+// syncToken is the change log position a client has synced to and, while it
+// is fetching a snapshot, the last user of the snapshot it has received
+type syncToken struct {
+	position users.ChangePosition
+	after    *uuid.UUID
+}
+
+// Tokens of the previous version hold change IDs, which are not in commit
+// order; they are rejected so clients resync from a snapshot
+func encodeToken(token syncToken) string {
+	value := tokenPrefix + token.position.String()
+	if token.after != nil {
+		value += "/" + token.after.String()
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func decodeToken(token string) (syncToken, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return syncToken{}, ErrInvalidSyncToken
+	}
+	value, ok := strings.CutPrefix(string(raw), tokenPrefix)
+	if !ok {
+		return syncToken{}, ErrInvalidSyncToken
+	}
+	value, after, paging := strings.Cut(value, "/")
+	position, err := users.ParseChangePosition(value)
+	if err != nil {
+		return syncToken{}, ErrInvalidSyncToken
+	}
+	decoded := syncToken{position: position}
+	if paging {
+		id, err := uuid.Parse(after)
+		if err != nil {
+			return syncToken{}, ErrInvalidSyncToken
+		}
+		decoded.after = &id
+	}
+	return decoded, nil
+}
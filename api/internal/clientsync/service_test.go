@@ -0,0 +1,99 @@
+package clientsync
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"starterkit/internal/db"
+)
+
+// snapshotQuerier serves fixed users, ordered by ID, and an empty change log
+type snapshotQuerier struct {
+	users []db.ListActiveUsersSnapshotRow
+	since []db.ListUserChangesSinceParams
+}
+
+func (q *snapshotQuerier) GetUserChangeLogEnd(context.Context) (int64, error) {
+	return 812, nil
+}
+
+func (q *snapshotQuerier) ListUserChangesSince(_ context.Context, arg db.ListUserChangesSinceParams) ([]db.UserChange, error) {
+	q.since = append(q.since, arg)
+	return nil, nil
+}
+
+func (q *snapshotQuerier) ListActiveUsersSnapshot(_ context.Context, arg db.ListActiveUsersSnapshotParams) ([]db.ListActiveUsersSnapshotRow, error) {
+	var out []db.ListActiveUsersSnapshotRow
+	for _, u := range q.users {
+		if bytes.Compare(u.ID.Bytes[:], arg.After.Bytes[:]) > 0 && len(out) < int(arg.RowLimit) {
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+func TestSnapshotIsPaged(t *testing.T) {
+	queries := &snapshotQuerier{}
+	for range 2*batchSize + 10 {
+		id, err := uuid.NewV7()
+		if err != nil {
+			t.Fatal(err)
+		}
+		queries.users = append(queries.users, db.ListActiveUsersSnapshotRow{
+			ID:    pgtype.UUID{Bytes: id, Valid: true},
+			Email: id.String() + "@example.com",
+		})
+	}
+	slices.SortFunc(queries.users, func(a, b db.ListActiveUsersSnapshotRow) int {
+		return bytes.Compare(a.ID.Bytes[:], b.ID.Bytes[:])
+	})
+	service := NewService(queries)
+
+	seen := make(map[uuid.UUID]bool)
+	token := ""
+	for page := 0; ; page++ {
+		if page > 3 {
+			t.Fatal("snapshot did not end")
+		}
+		resp, err := service.Sync(context.Background(), token, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Full != (page == 0) {
+			t.Errorf("page %d: full = %v", page, resp.Full)
+		}
+		if len(resp.Users.Upserts) > batchSize {
+			t.Errorf("page %d has %d users", page, len(resp.Users.Upserts))
+		}
+		for _, u := range resp.Users.Upserts {
+			if seen[u.ID] {
+				t.Errorf("user %s returned twice", u.ID)
+			}
+			seen[u.ID] = true
+		}
+		token = resp.SyncToken
+		if !resp.HasMore {
+			break
+		}
+	}
+	if len(seen) != len(queries.users) {
+		t.Errorf("snapshot returned %d users, want %d", len(seen), len(queries.users))
+	}
+	if len(queries.since) != 0 {
+		t.Fatal("snapshot read the change log")
+	}
+
+	// The changes made while paging are replayed from where the snapshot
+	// started
+	if _, err := service.Sync(context.Background(), token, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries.since) != 1 || queries.since[0].SinceTxid != 812 || queries.since[0].SinceID != 0 {
+		t.Errorf("delta sync read the change log with %+v, want from the snapshot's start", queries.since)
+	}
+}
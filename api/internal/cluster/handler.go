@@ -0,0 +1,43 @@
+package cluster
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleState returns the replicas and their health as this replica sees
+// them
+func (h *Handler) HandleState() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state, err := h.service.State(r.Context())
+		if err != nil {
+			h.logger.Error("failed to get cluster state", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, state)
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
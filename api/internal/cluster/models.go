@@ -0,0 +1,36 @@
+package cluster
+
+import "time"
+
+// Peer is a replica as its heartbeat describes it
+type Peer struct {
+	ID        string    `json:"id"`
+	Version   string    `json:"version"`
+	StartedAt time.Time `json:"started_at"`
+	// Ready is whether the replica passed its readiness checks when it
+	// last renewed its heartbeat
+	Ready       bool      `json:"ready"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// Alive means the heartbeat has not expired. Peers that stopped are
+	// listed for a while after.
+	Alive bool `json:"alive"`
+	// Self marks the replica that answered
+	Self bool `json:"self"`
+}
+
+// Healthy reports whether the peer is running and ready to serve
+func (p Peer) Healthy() bool {
+	return p.Alive && p.Ready
+}
+
+// State is a replica's view of the cluster
+type State struct {
+	Self    string `json:"self"`
+	Backend string `json:"backend"`
+	// Replicas counts the live peers, this one included; per-replica
+	// limits are divided by it
+	Replicas int    `json:"replicas"`
+	Healthy  int    `json:"healthy"`
+	Peers    []Peer `json:"peers"`
+}
@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces heartbeats in Redis
+const keyPrefix = "cluster:replica:"
+
+// Redis keeps heartbeats as JSON values that Redis forgets lostRetention
+// after they expire. Expiry is decided by each replica's clock.
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis creates a store on the Redis server at url
+// (redis://[user:password@]host:port/db)
+func NewRedis(url string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+	return &Redis{client: redis.NewClient(opts)}, nil
+}
+
+func (s *Redis) Beat(ctx context.Context, p Peer, ttl time.Duration) error {
+	p.HeartbeatAt = time.Now()
+	p.ExpiresAt = p.HeartbeatAt.Add(ttl)
+	p.Alive, p.Self = false, false
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode heartbeat: %w", err)
+	}
+	if err := s.client.Set(ctx, keyPrefix+p.ID, data, ttl+lostRetention).Err(); err != nil {
+		return fmt.Errorf("failed to renew heartbeat: %w", err)
+	}
+	return nil
+}
+
+func (s *Redis) List(ctx context.Context) ([]Peer, error) {
+	var keys []string
+	iter := s.client.Scan(ctx, 0, keyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list heartbeats: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read heartbeats: %w", err)
+	}
+	now := time.Now()
+	peers := make([]Peer, 0, len(values))
+	for _, v := range values {
+		// Keys expiring between SCAN and MGET come back nil
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var p Peer
+		if err := json.Unmarshal([]byte(data), &p); err != nil {
+			return nil, fmt.Errorf("failed to decode heartbeat: %w", err)
+		}
+		p.Alive = p.ExpiresAt.After(now)
+		peers = append(peers, p)
+	}
+	slices.SortFunc(peers, func(a, b Peer) int {
+		if c := a.StartedAt.Compare(b.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return peers, nil
+}
+
+func (s *Redis) Leave(ctx context.Context, id string) error {
+	if err := s.client.Del(ctx, keyPrefix+id).Err(); err != nil {
+		return fmt.Errorf("failed to delete heartbeat: %w", err)
+	}
+	return nil
+}
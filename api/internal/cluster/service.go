@@ -0,0 +1,122 @@
+// Package cluster makes each replica aware of its peers. Every replica
+// renews a heartbeat in a shared store and reads everyone else's, so it
+// knows how many replicas are running and which of them are ready.
+package cluster
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Service renews this replica's heartbeat and keeps count of its peers
+type Service struct {
+	store   Store
+	backend string
+	self    Peer
+	ttl     time.Duration
+	// ready reports whether this replica passes its readiness checks
+	ready  func(ctx context.Context) bool
+	logger *slog.Logger
+
+	// replicas is the number of live replicas at the last heartbeat
+	replicas atomic.Int64
+	gauge    metric.Int64Gauge
+}
+
+// NewService creates the membership of the replica id running version
+func NewService(store Store, cfg config.ClusterConfig, id, version string, ready func(ctx context.Context) bool, logger *slog.Logger) *Service {
+	s := &Service{
+		store:   store,
+		backend: cfg.Backend,
+		self:    Peer{ID: id, Version: version, StartedAt: time.Now()},
+		ttl:     cfg.HeartbeatTTL,
+		ready:   ready,
+		logger:  logger,
+		gauge:   metrics.Int64Gauge(metrics.ClusterReplicas),
+	}
+	s.replicas.Store(1)
+	return s
+}
+
+// NewStore creates the heartbeat store selected by cfg
+func NewStore(cfg config.ClusterConfig, queries Querier) (Store, error) {
+	if cfg.Backend == "redis" {
+		return NewRedis(cfg.RedisURL)
+	}
+	return NewPostgres(queries), nil
+}
+
+// Beat renews this replica's heartbeat and recounts the live replicas. It
+// runs every heartbeat interval on every replica.
+func (s *Service) Beat(ctx context.Context) error {
+	self := s.self
+	self.Ready = s.ready(ctx)
+	if err := s.store.Beat(ctx, self, s.ttl); err != nil {
+		return err
+	}
+
+	peers, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	alive, healthy := count(peers)
+	replicas := int64(max(alive, 1))
+	if previous := s.replicas.Swap(replicas); previous != replicas {
+		s.logger.Info("replica count changed", "replicas", replicas, "previous", previous)
+	}
+	s.gauge.Record(ctx, int64(alive), metric.WithAttributes(attribute.String("state", "alive")))
+	s.gauge.Record(ctx, int64(healthy), metric.WithAttributes(attribute.String("state", "healthy")))
+	return nil
+}
+
+// Leave removes this replica's heartbeat so peers stop counting it at
+// once rather than when it expires
+func (s *Service) Leave(ctx context.Context) error {
+	return s.store.Leave(ctx, s.self.ID)
+}
+
+// Replicas returns the number of live replicas, this one included, as of
+// the last heartbeat. It is at least 1.
+func (s *Service) Replicas() int {
+	return int(s.replicas.Load())
+}
+
+// State lists the peers as the store has them now
+func (s *Service) State(ctx context.Context) (*State, error) {
+	peers, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range peers {
+		peers[i].Self = peers[i].ID == s.self.ID
+	}
+	alive, healthy := count(peers)
+	return &State{
+		Self:     s.self.ID,
+		Backend:  s.backend,
+		Replicas: max(alive, 1),
+		Healthy:  healthy,
+		Peers:    peers,
+	}, nil
+}
+
+// count returns how many peers are alive, and how many of those are ready
+func count(peers []Peer) (alive, healthy int) {
+	for _, p := range peers {
+		if p.Alive {
+			alive++
+		}
+		if p.Healthy() {
+			healthy++
+		}
+	}
+	return alive, healthy
+}
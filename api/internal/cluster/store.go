@@ -0,0 +1,87 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"starterkit/internal/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// lostRetention is how long peers are listed after their heartbeat
+// expired
+const lostRetention = 15 * time.Minute
+
+// Store keeps the replicas' heartbeats
+type Store interface {
+	// Beat publishes p's heartbeat, expiring after ttl
+	Beat(ctx context.Context, p Peer, ttl time.Duration) error
+	// List returns the heartbeats, including those that expired within
+	// lostRetention
+	List(ctx context.Context) ([]Peer, error)
+	// Leave removes the heartbeat of the replica id
+	Leave(ctx context.Context, id string) error
+}
+
+type Querier interface {
+	UpsertReplicaHeartbeat(ctx context.Context, arg db.UpsertReplicaHeartbeatParams) error
+	ListReplicaHeartbeats(ctx context.Context) ([]db.ListReplicaHeartbeatsRow, error)
+	DeleteReplicaHeartbeat(ctx context.Context, id string) error
+	DeleteExpiredReplicaHeartbeats(ctx context.Context, retentionSeconds float64) error
+}
+
+// Postgres keeps heartbeats in the replica_heartbeats table. Expiry is
+// decided by the database clock, so replica clock skew does not matter.
+type Postgres struct {
+	queries Querier
+}
+
+func NewPostgres(queries Querier) *Postgres {
+	return &Postgres{queries: queries}
+}
+
+func (s *Postgres) Beat(ctx context.Context, p Peer, ttl time.Duration) error {
+	err := s.queries.UpsertReplicaHeartbeat(ctx, db.UpsertReplicaHeartbeatParams{
+		ID:         p.ID,
+		Version:    p.Version,
+		Ready:      p.Ready,
+		StartedAt:  pgtype.Timestamptz{Time: p.StartedAt, Valid: true},
+		TtlSeconds: ttl.Seconds(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to renew heartbeat: %w", err)
+	}
+	if err := s.queries.DeleteExpiredReplicaHeartbeats(ctx, lostRetention.Seconds()); err != nil {
+		return fmt.Errorf("failed to delete expired heartbeats: %w", err)
+	}
+	return nil
+}
+
+func (s *Postgres) List(ctx context.Context) ([]Peer, error) {
+	rows, err := s.queries.ListReplicaHeartbeats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list heartbeats: %w", err)
+	}
+	peers := make([]Peer, len(rows))
+	for i, row := range rows {
+		peers[i] = Peer{
+			ID:          row.ID,
+			Version:     row.Version,
+			StartedAt:   row.StartedAt.Time,
+			Ready:       row.Ready,
+			HeartbeatAt: row.HeartbeatAt.Time,
+			ExpiresAt:   row.ExpiresAt.Time,
+			Alive:       row.Alive,
+		}
+	}
+	return peers, nil
+}
+
+func (s *Postgres) Leave(ctx context.Context, id string) error {
+	if err := s.queries.DeleteReplicaHeartbeat(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete heartbeat: %w", err)
+	}
+	return nil
+}
@@ -1,8 +1,16 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -10,16 +18,89 @@ import (
 
 // Config holds all application configuration
 type Config struct {
-	Service   ServiceConfig
-	Server    ServerConfig
-	Database  DatabaseConfig
-	Telemetry TelemetryConfig
+	Service         ServiceConfig
+	Server          ServerConfig
+	TLS             TLSConfig
+	Database        DatabaseConfig
+	Telemetry       TelemetryConfig
+	Retention       RetentionConfig
+	Storage         StorageConfig
+	Archive         ArchiveConfig
+	Trash           TrashConfig
+	Backup          BackupConfig
+	Refresh         StagingRefreshConfig
+	Events          EventsConfig
+	Watchdog        WatchdogConfig
+	Lock            LockConfig
+	Leader          LeaderConfig
+	Cluster         ClusterConfig
+	SLO             SLOConfig
+	QueryCost       QueryCostConfig
+	Probe           ProbeConfig
+	CORS            CORSConfig
+	Auth            AuthConfig
+	Routing         RoutingConfig
+	LegacyAPI       LegacyAPIConfig
+	RequestID       RequestIDConfig
+	Locale          LocaleConfig
+	SMS             SMSConfig
+	Email           EmailConfig
+	Invites         InvitesConfig
+	Links           LinksConfig
+	Announcements   AnnouncementsConfig
+	Sitemap         SitemapConfig
+	APIKeys         APIKeysConfig
+	ServiceAccounts ServiceAccountsConfig
+	KillSwitches    KillSwitchesConfig
+	ReadOnly        ReadOnlyConfig
+	ResponseCache   ResponseCacheConfig
+	Delta           DeltaConfig
+	Users           UsersConfig
+	Projection      ProjectionConfig
+	Workflow        WorkflowConfig
+	Jobs            JobsConfig
+	Temporal        TemporalConfig
+	Connectors      ConnectorsConfig
+	Slack           SlackConfig
+	StatusHooks     StatusHooksConfig
+	AI              AIConfig
+	Embeddings      EmbeddingsConfig
+	Phone           PhoneConfig
+	Push            PushConfig
+	Uploads         UploadsConfig
+	Scan            ScanConfig
+	Moderation      ModerationConfig
+	Risk            RiskConfig
+	GeoIP           GeoIPConfig
+	GeoBlock        GeoBlockConfig
+	Sessions        SessionsConfig
+	MagicLinks      MagicLinksConfig
+	WebAuthn        WebAuthnConfig
+	Accounts        AccountsConfig
+	SIEM            SIEMConfig
+	AuditExports    AuditExportsConfig
+	Domains         DomainsConfig
+
+	// settings are the variables Load consulted, for Dump
+	settings []Setting
 }
 
+// Deployment environments selected by APP_ENV
+const (
+	EnvDev     = "dev"
+	EnvStaging = "staging"
+	EnvProd    = "prod"
+	// EnvLoadtest is a performance environment, which can provision
+	// synthetic data through the admin API
+	EnvLoadtest = "loadtest"
+)
+
 // ServiceConfig contains service metadata
 type ServiceConfig struct {
-	Name    string
-	Version string
+	Name        string
+	Version     string
+	Environment string
+	LogFormat   string
 }
 
 // ServerConfig contains HTTP server configuration
@@ -29,30 +110,988 @@ type ServerConfig struct {
 	WriteTimeout    time.Duration
 	IdleTimeout     time.Duration
 	ShutdownTimeout time.Duration
+	TLSCertFile     string
+	TLSKeyFile      string
+
+	// StartupTimeout bounds the startup hooks run before requests are
+	// served, such as modules warming their caches
+	StartupTimeout time.Duration
+	// DrainDelay is how long readiness fails before shutdown stops accepting
+	// connections, so load balancers take the replica out of rotation first
+	DrainDelay time.Duration
+	// HealthCheckTimeout bounds each readiness check
+	HealthCheckTimeout time.Duration
+
+	// TrustedProxies are the load balancers and proxies whose
+	// X-Forwarded-For and X-Real-IP headers are believed; the client IP of
+	// requests from other peers is the connection's address
+	TrustedProxies []netip.Prefix
+	// Timing adds a Server-Timing header breaking down where each request's
+	// time went
+	Timing bool
+}
+
+// TLSConfig is the crypto policy applied to every TLS connection the
+// service makes or accepts
+type TLSConfig struct {
+	Profile          string
+	MinVersion       string
+	CipherSuites     []string
+	CurvePreferences []string
+	// ClientCAFile holds the PEM CAs whose client certificates the server
+	// accepts for mutual TLS; clients without a certificate are still
+	// served
+	ClientCAFile string
 }
 
 // DatabaseConfig contains database connection configuration
 type DatabaseConfig struct {
+	// Host and Port may be comma-separated lists for failover; connections
+	// go to the first host matching TargetSessionAttrs
 	Host            string
 	Port            string
 	User            string
 	Password        string
+	Auth            string
+	AWSRegion       string
 	Database        string
 	SSLMode         string
+	SSLRootCert     string
+	SSLCert         string
+	SSLKey          string
+	SSLRootCertPEM  string
+	SSLCertPEM      string
+	SSLKeyPEM       string
+	SSLReload       time.Duration
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+
+	// Background jobs and reporting exports get their own pools so they
+	// can't starve API requests; 0 shares the interactive pool
+	BackgroundMaxConns int
+	ReportingMaxConns  int
+
+	// Reads failing with transient connection errors, and transactions
+	// failing with serialization failures or deadlocks, are retried up to
+	// RetryAttempts times in total with backoff capped at RetryMaxBackoff
+	RetryAttempts   int
+	RetryMaxBackoff time.Duration
+
+	// ExplainThreshold opts into logging the plan of queries slower than
+	// it; 0 disables plan capture
+	ExplainThreshold time.Duration
+
+	// ReadReplicas are host:port addresses of streaming replicas, reached
+	// with the primary's credentials through pools of ReplicaMaxConns.
+	// Reads hinted as latency-critical go to one of them, and with two or
+	// more are hedged: if the first has not answered within the
+	// HedgePercentile of recent replica reads, or HedgeMinDelay if longer,
+	// the read is sent to a second and the slower one is cancelled. A
+	// HedgePercentile of 0 disables hedging.
+	ReadReplicas    []string
+	ReplicaMaxConns int
+	HedgePercentile float64
+	HedgeMinDelay   time.Duration
+
+	// TargetSessionAttrs restricts which servers connections are made to,
+	// read-write by default for multi-host lists. The failover watcher resets
+	// the pools every FailoverCheckInterval if the primary moved.
+	TargetSessionAttrs    string
+	FailoverCheckInterval time.Duration
+
+	// LazyConnect lets the server start while the database is unreachable;
+	// API requests get 503 until a ping every ReconnectInterval succeeds
+	LazyConnect       bool
+	ReconnectInterval time.Duration
+
+	// AutoMigrate applies pending migrations when the server starts
+	AutoMigrate bool
 }
 
 // TelemetryConfig contains observability configuration
 type TelemetryConfig struct {
 	OTLPEndpoint string
+	OTLPInsecure bool
+	SampleRatio  float64
+	Enabled      bool
+	// MetricsExporters lists otlp, prometheus, or none. Prometheus serves
+	// GET /metrics.
+	MetricsExporters []string
+	// MetricsInterval is how often metrics are pushed over OTLP
+	MetricsInterval time.Duration
+	// PushgatewayURL is the Prometheus Pushgateway batch commands such as
+	// migrate and task push their run metrics to; empty to not push
+	PushgatewayURL string
+	// ResourceDetectors lists host, container, k8s, and cloud: what is
+	// detected about where the process runs and added to its telemetry
+	ResourceDetectors []string
+	// DebugScope lets callers whose token grants it send X-Debug-Trace,
+	// which records their request in full; empty to ignore the header
+	DebugScope string
+}
+
+// RetentionConfig controls scheduled execution of data retention policies
+type RetentionConfig struct {
+	Enabled  bool
+	Interval time.Duration
+	DryRun   bool
+}
+
+// StorageConfig selects the object storage backend
+type StorageConfig struct {
+	Backend   string
+	LocalPath string
+}
+
+// ArchiveConfig controls archival of cold rows to object storage
+type ArchiveConfig struct {
+	Enabled   bool
+	Interval  time.Duration
+	OlderThan time.Duration
+	BatchSize int
+}
+
+// TrashConfig controls how long deleted resources can be restored from the
+// trash before they are purged for good
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+// BackupConfig controls scheduled logical backups to object storage.
+// Backups are pruned once they are both beyond the newest RetainCount and
+// older than RetainFor.
+type BackupConfig struct {
+	Enabled     bool
+	Interval    time.Duration
+	RetainCount int
+	RetainFor   time.Duration
+}
+
+// StagingRefreshConfig controls periodic cloning of a source database
+// (typically a production replica) into this one with PII anonymized
+type StagingRefreshConfig struct {
+	Enabled   bool
+	Interval  time.Duration
+	SourceDSN string
+}
+
+// EventsConfig controls domain event publishing and async operations
+type EventsConfig struct {
+	ChangeRelayInterval time.Duration
+	OperationTimeout    time.Duration
+	// UndoWindow is how long staged destructive operations, such as
+	// deleting a template, can be undone before they are carried out
+	UndoWindow time.Duration
+}
+
+// WatchdogConfig controls stall detection for background loops
+type WatchdogConfig struct {
+	CheckInterval time.Duration
+	StallTimeout  time.Duration
+	MaxRestarts   int
+}
+
+// LockConfig selects the backend for locks shared across replicas
+type LockConfig struct {
+	Backend  string
+	RedisURL string
+	// JobTTL bounds how long a crashed replica keeps a scheduled job locked
+	JobTTL time.Duration
+}
+
+// LeaderConfig controls election of the replica that runs singleton jobs
+type LeaderConfig struct {
+	// ID names this replica in the lease, defaulting to hostname and pid
+	ID       string
+	LeaseTTL time.Duration
+}
+
+// ClusterConfig controls peer awareness. Each replica publishes a
+// heartbeat, named by the leader ID, so every replica knows how many are
+// running and which are healthy.
+type ClusterConfig struct {
+	Enabled bool
+	// Backend is postgres or redis
+	Backend  string
+	RedisURL string
+	// HeartbeatInterval is how often each replica renews its heartbeat;
+	// a replica not renewing it for HeartbeatTTL is no longer counted
+	HeartbeatInterval time.Duration
+	HeartbeatTTL      time.Duration
+}
+
+// SLOConfig controls error budget tracking for routes with objectives
+type SLOConfig struct {
+	Window time.Duration
+	// AlertInterval is how often budgets are checked for Slack alerts
+	AlertInterval time.Duration
+}
+
+// QueryCostConfig controls the budgets that limit how much database work
+// each caller's list requests may cause per minute
+type QueryCostConfig struct {
+	// Budget is the cost points each caller may spend per minute; 0 turns
+	// budgets off
+	Budget int
+	// Enforce rejects requests over budget. Otherwise they are only logged,
+	// to tune the budget against real traffic.
+	Enforce bool
+}
+
+// ProbeConfig controls the end-to-end self-check probe
+type ProbeConfig struct {
+	Enabled  bool
+	Interval time.Duration
+	// BaseURL is where the probe reaches this server, defaulting to the
+	// listen address on localhost
+	BaseURL string
+	Email   string
+	// Token is sent as a bearer token when authentication is enabled; it
+	// needs the users:read and users:write scopes
+	Token string
+}
+
+// AuthConfig controls bearer token authentication. When enabled, API and
+// admin requests need an access token signed by Issuer for Audience, and
+// keys are fetched from the issuer's OIDC discovery document unless
+// JWKSURL is set. When disabled, the X-User-Email header is trusted as is,
+// for local development without an identity provider.
+type AuthConfig struct {
+	Enabled  bool
+	Issuer   string
+	Audience string
+	JWKSURL  string
+	// JWKSRefresh is how long fetched signing keys are cached
+	JWKSRefresh time.Duration
+	// RolesClaim names the claim listing the caller's roles; dots reach
+	// into nested objects, e.g. realm_access.roles
+	RolesClaim string
+	// ProviderClaim names the claim a brokering issuer puts the upstream
+	// identity provider in, which linked identities are keyed by
+	ProviderClaim string
+	// TenantClaim names the claim holding the organization the caller acts
+	// in; dots reach into nested objects
+	TenantClaim string
+	// AdminRole is required for the /admin routes
+	AdminRole string
+	// OrgAdminRole is required for the routes where organization admins
+	// manage their own organization
+	OrgAdminRole string
+	// ClockSkew is tolerated when checking expiry and not-before times
+	ClockSkew time.Duration
+	// SigningClients authenticate by signing each request with a shared
+	// secret instead of sending a bearer token, keyed by client ID
+	SigningClients map[string]SigningClient
+	// SignatureMaxSkew is how far a signed request's timestamp may be from
+	// the server clock; nonces are remembered for as long
+	SignatureMaxSkew time.Duration
+	// ReplayProtection is "off", "optional" to reject replayed mutations
+	// that carry a nonce, or "required" to also reject those without one
+	ReplayProtection string
+	// ReplayMaxSkew is how far a protected request's timestamp may be from
+	// the server clock; nonces are remembered for as long
+	ReplayMaxSkew time.Duration
+	// ServiceClients authenticate with a client certificate over mutual
+	// TLS, keyed by client ID
+	ServiceClients map[string]ServiceClient
+	// FieldRules override the access tags of response fields, keyed by
+	// "Type.field"
+	FieldRules map[string]FieldRule
+	// FailureDelay is how long a client's next sign-in attempt or bearer
+	// token is held back after it fails; each further failure doubles it
+	// up to FailureMaxDelay. 0 disables the delays.
+	FailureDelay    time.Duration
+	FailureMaxDelay time.Duration
+	// FailureForget is how long a client must go without failing for its
+	// failures to be forgotten
+	FailureForget time.Duration
+	// ResponseFloor is the least time sign-in endpoints take to answer, so
+	// unknown accounts cannot be told apart by how quickly they fail
+	ResponseFloor time.Duration
+	// NarrowingSecret signs the narrowed tokens callers exchange their
+	// token for, limited to some of its scopes; narrowing is off while it
+	// is empty. NarrowedTokenTTL is how long they last unless asked for
+	// less, and at most their caller's token.
+	NarrowingSecret  string
+	NarrowedTokenTTL time.Duration
+}
+
+// FieldRule shows a response field only to callers holding one of Grants
+// ("self", "admin", "role:<name>", "scope:<name>"); others get the field
+// masked with Mask ("email" or "last4") or, without one, left out
+type FieldRule struct {
+	Grants []string
+	Mask   string
+}
+
+// ServiceClient is an internal service identified by its client
+// certificate. Identity is matched against the certificate's URI, DNS, and
+// email SANs and its subject common name.
+type ServiceClient struct {
+	Identity string
+	Email    string
+	Scopes   []string
+	Roles    []string
+}
+
+// SigningClient is a client that signs its requests with HMAC-SHA256. The
+// request is handled as the given email with the given scopes and roles.
+type SigningClient struct {
+	Secret string
+	Email  string
+	Scopes []string
+	Roles  []string
+}
+
+// UsersConfig selects how user writes are persisted: "state" updates the
+// users row in place, "events" appends to a per-user event stream and
+// projects it onto the row
+type UsersConfig struct {
+	Persistence   string
+	SnapshotEvery int
+	// DeletionGrace is how long a deleted account is kept, deactivated,
+	// before it is purged
+	DeletionGrace time.Duration
+	// HandleCooldown is how long a user must wait between handle changes
+	HandleCooldown time.Duration
+	// HandleHold is how long a previous handle is held for its user before
+	// anyone else may take it
+	HandleHold time.Duration
+	// ReservedHandles are refused in addition to the built-in reserved words
+	ReservedHandles []string
+	// EmailChangeTTL is how long both addresses have to confirm an email
+	// change
+	EmailChangeTTL time.Duration
+	// EmailChangeURL is the app page confirmation links open, which posts
+	// the token back to the API
+	EmailChangeURL string
+	// IDFormat is how new users' IDs are generated: uuid (random), or
+	// uuidv7 or ulid, which are time-ordered and keep inserts at the end
+	// of the primary key index
+	IDFormat string
+	// AvailabilityLimit is how many unauthenticated availability checks
+	// each client address may make a minute; 0 disables the limit
+	AvailabilityLimit int
+	// BulkBatchSize is how many users each job of a bulk operation acts
+	// on, and BulkMaxTargets how many users one operation may select
+	BulkBatchSize  int
+	BulkMaxTargets int
+}
+
+// ProjectionConfig controls how often read-model projections catch up with
+// the user change log
+type ProjectionConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// WorkflowConfig controls the durable workflow engine. A failing step is
+// retried up to MaxAttempts times, waiting Backoff and doubling each time;
+// a workflow being advanced is not picked up again until Lease has passed.
+type WorkflowConfig struct {
+	Interval    time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	Lease       time.Duration
+}
+
+// JobsConfig controls the background job queue. Every replica polls for
+// due jobs each Interval; a failing job is retried up to MaxAttempts times,
+// waiting Backoff and doubling each time, and finished jobs are kept for
+// Retention.
+type JobsConfig struct {
+	Interval    time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	Lease       time.Duration
+	Retention   time.Duration
+	// Lanes are the priority lanes this replica works: high, default, low
+	Lanes []string
+}
+
+// TemporalConfig connects to a Temporal cluster for teams that run their
+// durable workflows there instead of the built-in engine
+type TemporalConfig struct {
+	Enabled   bool
+	HostPort  string
+	Namespace string
+	TaskQueue string
+	// APIKey authenticates to Temporal Cloud and turns on TLS
+	APIKey string
+}
+
+// ConnectorsConfig configures integrations with external APIs. Connectors
+// listed in CONNECTORS read their settings from CONNECTOR_<NAME>_* variables.
+type ConnectorsConfig struct {
+	HealthInterval time.Duration
+	Providers      map[string]ConnectorConfig
+}
+
+// ConnectorConfig holds the settings shared by every connector
+type ConnectorConfig struct {
+	BaseURL string
+	Token   string
+	Timeout time.Duration
+	// RateLimit is requests per second, 0 for no limit
+	RateLimit    float64
+	Burst        int
+	MaxRetries   int
+	RetryBackoff time.Duration
+}
+
+// Provider returns the settings of a connector listed in CONNECTORS
+func (c ConnectorsConfig) Provider(name string) (ConnectorConfig, bool) {
+	cfg, ok := c.Providers[name]
+	return cfg, ok
+}
+
+// SlackConfig controls operational alerts posted to Slack. The connection
+// comes from the "slack" connector: a bot token posts to Channel, otherwise
+// the base URL is used as an incoming webhook.
+type SlackConfig struct {
+	Channel string
+	// Throttle is the minimum time between repeats of the same alert
+	Throttle time.Duration
+	// AuditActions are the audited admin actions that are posted
+	AuditActions []string
+	// Templates override the built-in message template per event
+	Templates map[string]string
+}
+
+// StatusHooksConfig controls the pings sent to uptime monitors and status
+// pages when a replica starts, stops, flips readiness, or runs a
+// maintenance task. Each of Connectors names a connector in CONNECTORS
+// whose base URL receives the pings and whose token signs them.
+type StatusHooksConfig struct {
+	Connectors []string
+	// ReadinessInterval is how often readiness is checked for flips
+	// between pings
+	ReadinessInterval time.Duration
+}
+
+// AIConfig controls the in-product assistant. The openai, anthropic, and
+// local providers connect through the connector of the same name, which
+// must be listed in CONNECTORS; echo answers without a model for
+// development.
+type AIConfig struct {
+	Provider string
+	// Model defaults to a small model of the provider
+	Model        string
+	SystemPrompt string
+	// MaxTokens caps each reply
+	MaxTokens      int
+	MaxPromptChars int
+	// RateLimit is the requests each user may make per RateWindow
+	RateLimit        int
+	RateWindow       time.Duration
+	DailyTokenBudget int64
+}
+
+// EmbeddingsConfig controls the vectors behind semantic search. The openai
+// and local providers connect through the connector of the same name; hash
+// embeds words locally without a model for development.
+type EmbeddingsConfig struct {
+	Provider string
+	// Model defaults to a small embedding model of the provider
+	Model string
+	// Dimensions shortens vectors where the model supports it; 0 keeps the
+	// model's size
+	Dimensions int
+	// BatchSize is the profiles embedded per provider request
+	BatchSize int
+	Interval  time.Duration
+	// MaxChars truncates each text before it is embedded
+	MaxChars int
+}
+
+// RoutingConfig controls request path normalization applied before routing
+type RoutingConfig struct {
+	CollapseSlashes       bool
+	RedirectTrailingSlash bool
+	RejectTraversal       bool
+	MethodOverride        bool
+}
+
+// LegacyAPIConfig controls the shim serving endpoints of the legacy API
+// from the current routes while its clients move over
+type LegacyAPIConfig struct {
+	Enabled bool
+	// IDSystem is the external ID system legacy user IDs are resolved in
+	IDSystem string
+	// Sunset is when the shim is to be removed, announced to its clients;
+	// zero while undecided
+	Sunset time.Time
+}
+
+// RequestIDConfig controls how each request's correlation ID is chosen
+// and returned
+type RequestIDConfig struct {
+	// Header is the inbound header whose value is taken as the request ID,
+	// e.g. X-Request-ID, CF-Ray, or X-Amzn-Trace-Id
+	Header string
+	// Trust is whom Header is believed from: all, proxies (the
+	// TrustedProxies only), or none, to always generate the ID
+	Trust string
+	// MaxLength bounds an inbound ID; longer ones are replaced
+	MaxLength int
+	// Format of generated IDs: uuid (v4), uuidv7, or ulid
+	Format string
+	// ResponseHeader carries the request ID back to the client
+	ResponseHeader string
+}
+
+// Request ID trust policies
+const (
+	RequestIDTrustAll     = "all"
+	RequestIDTrustProxies = "proxies"
+	RequestIDTrustNone    = "none"
+)
+
+// LocaleConfig controls per-request locale, currency, and unit resolution
+type LocaleConfig struct {
+	SupportedLocales []string
+	DefaultCurrency  string
+	DefaultUnits     string
+}
+
+// SMSConfig selects the SMS provider used for outbound text messages
+type SMSConfig struct {
+	Provider         string
+	From             string
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	SNSRegion        string
+}
+
+// EmailConfig controls outbound email. Every message is sent from the job
+// queue at no more than RateLimit per second per replica. Providers are
+// tried in order, skipping any that failed FailoverThreshold times in a row
+// until FailoverCooldown has passed. The postmark and sendgrid providers
+// connect through the connector of the same name, which must be listed in
+// CONNECTORS; log only logs messages, for development.
+type EmailConfig struct {
+	Providers           []string
+	From                string
+	SMTPHost            string
+	SMTPPort            int
+	SMTPUsername        string
+	SMTPPassword        string
+	PostmarkStream      string
+	SESRegion           string
+	SESConfigurationSet string
+	FailoverThreshold   int
+	FailoverCooldown    time.Duration
+	// MaxAttachmentSize limits the total size of a message's attachments
+	MaxAttachmentSize int64
+	// InlineImages maps content IDs to storage keys. HTML messages that
+	// refer to cid:<id> get the image attached inline.
+	InlineImages map[string]string
+	// RateLimit is messages per second, 0 for no limit
+	RateLimit float64
+	Burst     int
+	// WebhookSecret is the basic auth password bounce and complaint
+	// webhooks must present; webhooks are rejected when it is empty
+	WebhookSecret string
+	// Retention is how long sent messages and their status are kept
+	Retention time.Duration
+	// CampaignBatchSize is how many recipients of a campaign are queued at
+	// a time, and CampaignBatchInterval how long to wait before the next
+	// batch, so a large campaign does not flood the queue
+	CampaignBatchSize     int
+	CampaignBatchInterval time.Duration
+}
+
+// PushConfig holds the VAPID identity used to send Web Push notifications.
+// Push delivery is disabled when the keys are empty.
+type PushConfig struct {
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	VAPIDSubject    string
+	TTL             time.Duration
+}
+
+// InvitesConfig controls calendar invitations
+type InvitesConfig struct {
+	// Organizer is the address invites are sent from and replies go to;
+	// invites cannot be sent without one
+	Organizer string
+}
+
+// LinksConfig controls short links
+type LinksConfig struct {
+	// BaseURL is the public origin short links are served from, such as
+	// https://example.com; codes are appended as /l/{code}
+	BaseURL string
+}
+
+// AnnouncementsConfig controls the public Atom feed of announcements
+type AnnouncementsConfig struct {
+	// FeedTitle names the feed and its author
+	FeedTitle string
+	// BaseURL is the public origin the feed is served from
+	BaseURL string
+	// FeedSize is how many of the latest announcements the feed holds
+	FeedSize int
+	// CacheTTL is how long a rendered feed is reused, and how long clients
+	// may cache it
+	CacheTTL time.Duration
+}
+
+// SitemapConfig controls /sitemap.xml and /robots.txt
+type SitemapConfig struct {
+	// BaseURL is the public origin of the site
+	BaseURL string
+	// Pages are the paths of the app's public pages
+	Pages []string
+	// PageSize is how many URLs each sitemap page holds
+	PageSize int
+	// AllowIndexing lets crawlers in. It defaults to on in prod only, so
+	// staging and dev stay out of search results.
+	AllowIndexing bool
+}
+
+// APIKeysConfig controls the rate plans of API keys
+type APIKeysConfig struct {
+	// RefreshInterval is how long each replica caches rate plans and key
+	// lookups; changes made through another replica apply after it
+	RefreshInterval time.Duration
+}
+
+// ServiceAccountsConfig controls the credentials of service accounts
+type ServiceAccountsConfig struct {
+	// CredentialTTL is how long a credential is valid after it is issued
+	CredentialTTL time.Duration
+	// RotationGrace is how long the credentials replaced by a rotation keep
+	// working, so callers can switch to the new one
+	RotationGrace time.Duration
+	// ExpiryWarning is how long before a credential expires the account's
+	// owner is notified
+	ExpiryWarning time.Duration
+	// RefreshInterval is how long each replica caches credential lookups;
+	// revocations made through another replica apply after it
+	RefreshInterval time.Duration
+}
+
+// KillSwitchesConfig controls the switches that turn off single routes
+type KillSwitchesConfig struct {
+	// Routes maps route patterns, as listed by server routes, to the reason
+	// they answer 503 with
+	Routes map[string]string
+	// RefreshInterval is how often each replica reads the switches set
+	// through the admin API; switches set through another replica apply
+	// after it
+	RefreshInterval time.Duration
+}
+
+// ReadOnlyConfig controls read-only mode, under which mutating requests
+// answer 503 with a reason while reads continue
+type ReadOnlyConfig struct {
+	// Enabled turns the mode on from startup, for when the database cannot
+	// store it; Reason is what requests are refused with
+	Enabled bool
+	Reason  string
+	// RefreshInterval is how often each replica reads the mode set through
+	// the admin API
+	RefreshInterval time.Duration
+}
+
+// ResponseCacheConfig controls caching of whole responses to chosen read
+// routes
+type ResponseCacheConfig struct {
+	// Backend is memory, which each replica keeps for itself, or redis,
+	// which replicas share
+	Backend  string
+	RedisURL string
+	// MaxEntries bounds the memory backend; the least recently used
+	// entries are dropped beyond it
+	MaxEntries int
+	// TenantHeader identifies the tenant for routes that vary by tenant
+	TenantHeader string
+	// Routes maps route patterns, as listed by server routes, to how their
+	// responses are cached
+	Routes map[string]ResponseCacheRule
+}
+
+// DeltaConfig controls delta responses to list routes: clients polling a
+// list may get a JSON Patch against the version they have
+type DeltaConfig struct {
+	Enabled bool
+	// Backend is memory, which each replica keeps for itself, or redis,
+	// which replicas share so polls reaching another replica get a delta
+	Backend    string
+	RedisURL   string
+	MaxEntries int
+	// TTL is how long a version of a response is kept to diff against
+	TTL time.Duration
+	// MaxBodySize bounds the responses kept; larger ones are sent whole
+	MaxBodySize int
+}
+
+// ResponseCacheRule is how one route's responses are cached
+type ResponseCacheRule struct {
+	// TTL is how long a response is served without running the handler
+	TTL time.Duration
+	// Stale is how long after TTL a response is still served while it is
+	// refreshed in the background
+	Stale time.Duration
+	// Vary lists what else besides the path tells responses apart: user,
+	// tenant, and query
+	Vary []string
+}
+
+// UploadsConfig controls user file uploads
+type UploadsConfig struct {
+	MaxSize      int64
+	ScanInterval time.Duration
+	ScanBatch    int
+	// ResumableMaxSize is the largest file accepted over the tus protocol,
+	// which sends it in chunks
+	ResumableMaxSize int64
+	// ResumableTTL is how long an incomplete resumable upload is kept
+	// after its last chunk
+	ResumableTTL time.Duration
+	// DownloadRate is the bytes per second each user may download across
+	// their concurrent downloads on a replica, 0 for no limit
+	DownloadRate int
+	// DownloadBurst is the bytes a user may download at full speed before
+	// DownloadRate applies
+	DownloadBurst int
+}
+
+// ScanConfig selects the malware scanner applied to quarantined uploads
+type ScanConfig struct {
+	Backend       string
+	ClamAVAddress string
+	HTTPURL       string
+	HTTPToken     string
+	Timeout       time.Duration
+}
+
+// ModerationConfig selects how user-generated text is moderated
+type ModerationConfig struct {
+	Backend       string
+	RejectWords   []string
+	FlagWords     []string
+	SanitizeWords []string
+	HTTPURL       string
+	HTTPToken     string
+	Timeout       time.Duration
+}
+
+// GeoIPConfig controls client location lookup from a MaxMind GeoIP2 or
+// GeoLite2 City or Country database. The client IP is taken from
+// X-Forwarded-For only for requests from TRUSTED_PROXIES.
+type GeoIPConfig struct {
 	Enabled      bool
+	DatabasePath string
+	// RefreshInterval is how often the file is checked for a new version,
+	// such as one written by geoipupdate
+	RefreshInterval time.Duration
+	// CountryHeader names a header a trusted CDN sets to the client's
+	// country, such as CF-IPCountry, used when the database has no answer
+	CountryHeader string
+}
+
+// GeoBlockConfig restricts access by client location and needs GeoIP.
+// Entries are ISO country codes such as "CU" or region codes such as
+// "UA-43". Tenants listed in GEOBLOCK_TENANTS use GEOBLOCK_TENANT_<ID>_ALLOW
+// and _DENY instead of the default rules on their custom domains and for
+// callers whose token names them.
+type GeoBlockConfig struct {
+	Enabled bool
+	Default GeoBlockRules
+	// BlockUnknown rejects clients whose location cannot be resolved
+	BlockUnknown bool
+	Tenants      map[string]GeoBlockRules
+}
+
+// GeoBlockRules list the locations admitted and refused. A non-empty Allow
+// admits only those locations, and Deny wins over Allow.
+type GeoBlockRules struct {
+	Allow []string
+	Deny  []string
+}
+
+// SessionsConfig controls tracking of the devices users make requests from
+type SessionsConfig struct {
+	Enabled bool
+	// UserAgentParser is builtin or none
+	UserAgentParser string
+	// TouchInterval is how often a session's last-seen time is written
+	TouchInterval time.Duration
+	// Retention deletes sessions not seen for this long, and login
+	// fingerprints and security events older than it
+	Retention time.Duration
+	// LoginAlerts emails users when they sign in from a new device or
+	// country, with a link that revokes the session
+	LoginAlerts bool
+	// AlertBaseURL is the public origin of the API, which revoke links
+	// point to
+	AlertBaseURL string
+	// RevokeLinkTTL is how long the revoke link of an alert works
+	RevokeLinkTTL time.Duration
+}
+
+// MagicLinksConfig controls passwordless sign-in through emailed links
+type MagicLinksConfig struct {
+	Enabled bool
+	// Secret signs the tokens in the links
+	Secret string
+	// CallbackURL is the app page the links open, which posts the token
+	// back to the API
+	CallbackURL string
+	// TTL is how long a link works
+	TTL time.Duration
+	// SessionTTL is how long the session a link signs in to lasts
+	SessionTTL time.Duration
+	// ResendInterval is the least time between links to one email
+	ResendInterval time.Duration
+	// MaxPerEmail and MaxPerIP cap the links sent per hour to one email
+	// and at the request of one client IP
+	MaxPerEmail int
+	MaxPerIP    int
+}
+
+// WebAuthnConfig controls passkey registration and sign-in
+type WebAuthnConfig struct {
+	Enabled bool
+	// RPID is the domain passkeys are scoped to; the app's origins must be
+	// on it or its subdomains
+	RPID   string
+	RPName string
+	// Origins are the app origins ceremonies may run on
+	Origins []string
+	// Timeout is how long a ceremony's challenge can be answered
+	Timeout time.Duration
+	// UserVerification is required, preferred, or discouraged
+	UserVerification string
+	// SessionTTL is how long the session a passkey signs in to lasts
+	SessionTTL time.Duration
+}
+
+// AccountsConfig controls identity linking and the accounts tokens act as
+type AccountsConfig struct {
+	// LinkingEnabled lets users link more identity provider identities to
+	// their account
+	LinkingEnabled bool
+	// ResolveCacheTTL is how long each replica caches the account a token's
+	// identity acts as
+	ResolveCacheTTL time.Duration
+}
+
+// RiskConfig controls abuse scoring of API writes and automatic shadow bans
+type RiskConfig struct {
+	Enabled            bool
+	IPDenylist         []string
+	VelocityWindow     time.Duration
+	VelocityLimit      int
+	NewAccountAge      time.Duration
+	BlockThreshold     int
+	ShadowBanThreshold int
+}
+
+// SIEMConfig controls forwarding of the audit log to a SIEM
+type SIEMConfig struct {
+	Enabled       bool
+	Sink          string
+	Format        string
+	HTTPURL       string
+	HTTPToken     string
+	SyslogNetwork string
+	SyslogAddress string
+	SyslogAppName string
+	Interval      time.Duration
+	BatchSize     int
+	MaxBatches    int
+	MaxRetries    int
+	Timeout       time.Duration
+}
+
+// AuditExportsConfig controls the recurring exports of their audit events
+// organization admins schedule
+type AuditExportsConfig struct {
+	Enabled bool
+	// Interval is how often exports that are due are run, at most
+	// BatchSize of them each time
+	Interval  time.Duration
+	BatchSize int
+	// MaxEntries caps the entries in one delivery; the rest follow in the
+	// next run
+	MaxEntries int
+	// MaxPerTenant caps the exports an organization may schedule
+	MaxPerTenant int
+	// RetryInterval is how long a failed delivery waits to be retried, and
+	// MaxFailures how many failures in a row pause the export
+	RetryInterval time.Duration
+	MaxFailures   int
+	// WebhookTimeout bounds each webhook delivery
+	WebhookTimeout time.Duration
+	// WebhookAllowPrivate allows http webhook URLs and ones reaching
+	// private, loopback, and link-local addresses, for development
+	WebhookAllowPrivate bool
+	// TenantHeader names the organization with bearer auth disabled, when
+	// callers carry no tenant claim
+	TenantHeader string
+}
+
+// DomainsConfig controls the custom domains tenants serve the app on
+type DomainsConfig struct {
+	Enabled bool
+	// ChallengeLabel is prepended to a domain to name the TXT record that
+	// proves the tenant controls it
+	ChallengeLabel string
+	// VerifyInterval is how often domains waiting for their TXT record are
+	// looked up, for VerifyWindow after they are added
+	VerifyInterval time.Duration
+	VerifyWindow   time.Duration
+	// RefreshInterval is how often each replica reloads the verified domains
+	RefreshInterval time.Duration
+	// ACMEEnabled provisions certificates for verified domains from the
+	// ACME directory at ACMEDirectoryURL, over TLS-ALPN-01
+	ACMEEnabled      bool
+	ACMEEmail        string
+	ACMEDirectoryURL string
+}
+
+// PhoneConfig controls phone number normalization and verification
+type PhoneConfig struct {
+	DefaultRegion           string
+	VerificationTTL         time.Duration
+	MaxVerificationAttempts int
+}
+
+// CORSConfig holds cross-origin policies per route group
+type CORSConfig struct {
+	API   CORSPolicy
+	Admin CORSPolicy
+}
+
+// CORSPolicy describes which cross-origin requests a route group accepts.
+// Origins may be exact ("https://app.example.com"), a subdomain wildcard
+// ("https://*.example.com"), or "*" for any origin.
+type CORSPolicy struct {
+	AllowedOrigins      []string
+	AllowedMethods      []string
+	AllowedHeaders      []string
+	ExposedHeaders      []string
+	AllowCredentials    bool
+	AllowPrivateNetwork bool
+	MaxAge              time.Duration
 }
 
 // Load reads configuration from environment variables
 func Load() (*Config, error) {
+	// Variables set before .env is read win over it; remember them so
+	// Settings can tell the two apart
+	preset := make(map[string]bool)
+	for _, entry := range os.Environ() {
+		key, _, _ := strings.Cut(entry, "=")
+		preset[key] = true
+	}
+
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
 		// It's ok if .env doesn't exist in production
@@ -61,10 +1100,35 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Replace ssm:// and gcpsm:// references with their secret values
+	if err := resolveSecretReferences(); err != nil {
+		return nil, err
+	}
+
+	// The deployment environment picks defaults for settings left unset
+	env := getEnv("APP_ENV", EnvDev)
+	if env != EnvDev && env != EnvStaging && env != EnvProd && env != EnvLoadtest {
+		return nil, fmt.Errorf("unsupported APP_ENV: %s", env)
+	}
+	dev := env == EnvDev
+
+	logFormat, corsOrigins, sampleRatio := "json", []string(nil), 1.0
+	resourceDetectors := []string{"host", "container", "k8s", "cloud"}
+	if dev {
+		logFormat, corsOrigins = "text", []string{"*"}
+		resourceDetectors = []string{"host", "container"}
+	}
+	// Tracing every request would skew load test results
+	if env == EnvProd || env == EnvLoadtest {
+		sampleRatio = 0.1
+	}
+
 	cfg := &Config{
 		Service: ServiceConfig{
-			Name:    getEnv("SERVICE_NAME", "starterkit"),
-			Version: getEnv("SERVICE_VERSION", "1.0.0"),
+			Name:        getEnv("SERVICE_NAME", "starterkit"),
+			Version:     getEnv("SERVICE_VERSION", "1.0.0"),
+			Environment: env,
+			LogFormat:   getEnv("LOG_FORMAT", logFormat),
 		},
 		Server: ServerConfig{
 			Address:         getEnv("SERVER_ADDRESS", ":8080"),
@@ -72,45 +1136,1225 @@ func Load() (*Config, error) {
 			WriteTimeout:    getDuration("SERVER_WRITE_TIMEOUT", 15*time.Second),
 			IdleTimeout:     getDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
 			ShutdownTimeout: getDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+			TLSCertFile:     getEnv("SERVER_TLS_CERT_FILE", ""),
+			TLSKeyFile:      getEnv("SERVER_TLS_KEY_FILE", ""),
+
+			StartupTimeout:     getDuration("SERVER_STARTUP_TIMEOUT", 30*time.Second),
+			DrainDelay:         getDuration("SERVER_DRAIN_DELAY", 5*time.Second),
+			HealthCheckTimeout: getDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+			// Off by default in prod, where it would tell anyone how long
+			// the database takes
+			Timing: getBoolEnv("SERVER_TIMING", env != EnvProd),
+		},
+		TLS: TLSConfig{
+			Profile:          getEnv("TLS_PROFILE", "default"),
+			MinVersion:       getEnv("TLS_MIN_VERSION", ""),
+			CipherSuites:     getListEnv("TLS_CIPHER_SUITES", nil),
+			CurvePreferences: getListEnv("TLS_CURVE_PREFERENCES", nil),
+			ClientCAFile:     getEnv("TLS_CLIENT_CA_FILE", ""),
 		},
 		Database: DatabaseConfig{
 			Host:            getEnv("DB_HOST", "localhost"),
 			Port:            getEnv("DB_PORT", "5432"),
 			User:            getEnv("DB_USER", "postgres"),
 			Password:        getEnv("DB_PASSWORD", ""),
+			Auth:            getEnv("DB_AUTH", "password"),
+			AWSRegion:       getEnv("DB_AWS_REGION", ""),
 			Database:        getEnv("DB_NAME", "starterkit"),
 			SSLMode:         getEnv("DB_SSLMODE", "disable"),
+			SSLRootCert:     getEnv("DB_SSLROOTCERT", ""),
+			SSLCert:         getEnv("DB_SSLCERT", ""),
+			SSLKey:          getEnv("DB_SSLKEY", ""),
+			SSLRootCertPEM:  getEnv("DB_SSLROOTCERT_PEM", ""),
+			SSLCertPEM:      getEnv("DB_SSLCERT_PEM", ""),
+			SSLKeyPEM:       getEnv("DB_SSLKEY_PEM", ""),
+			SSLReload:       getDuration("DB_SSL_RELOAD_INTERVAL", time.Minute),
 			MaxOpenConns:    getIntEnv("DB_MAX_OPEN_CONNS", 25),
 			MaxIdleConns:    getIntEnv("DB_MAX_IDLE_CONNS", 5),
 			ConnMaxLifetime: getDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
 			ConnMaxIdleTime: getDuration("DB_CONN_MAX_IDLE_TIME", 1*time.Minute),
+
+			BackgroundMaxConns: getIntEnv("DB_BACKGROUND_MAX_CONNS", 5),
+			ReportingMaxConns:  getIntEnv("DB_REPORTING_MAX_CONNS", 2),
+
+			RetryAttempts:   getIntEnv("DB_RETRY_ATTEMPTS", 3),
+			RetryMaxBackoff: getDuration("DB_RETRY_MAX_BACKOFF", time.Second),
+
+			ExplainThreshold: getDuration("DB_EXPLAIN_THRESHOLD", 0),
+
+			ReadReplicas:    getListEnv("DB_READ_REPLICAS", nil),
+			ReplicaMaxConns: getIntEnv("DB_REPLICA_MAX_CONNS", 10),
+			HedgePercentile: getFloatEnv("DB_HEDGE_PERCENTILE", 0.95),
+			HedgeMinDelay:   getDuration("DB_HEDGE_MIN_DELAY", 10*time.Millisecond),
+
+			TargetSessionAttrs:    getEnv("DB_TARGET_SESSION_ATTRS", ""),
+			FailoverCheckInterval: getDuration("DB_FAILOVER_CHECK_INTERVAL", 15*time.Second),
+
+			LazyConnect:       getBoolEnv("DB_LAZY_CONNECT", false),
+			ReconnectInterval: getDuration("DB_RECONNECT_INTERVAL", 5*time.Second),
+
+			AutoMigrate: getBoolEnv("DB_AUTO_MIGRATE", false),
 		},
 		Telemetry: TelemetryConfig{
-			OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317"),
-			Enabled:      getBoolEnv("TELEMETRY_ENABLED", true),
+			OTLPEndpoint:     getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317"),
+			OTLPInsecure:     getBoolEnv("OTEL_EXPORTER_OTLP_INSECURE", true),
+			SampleRatio:      getFloatEnv("OTEL_TRACES_SAMPLER_ARG", sampleRatio),
+			Enabled:          getBoolEnv("TELEMETRY_ENABLED", true),
+			MetricsExporters: getListEnv("OTEL_METRICS_EXPORTER", []string{"otlp"}),
+			MetricsInterval:  getDuration("METRICS_EXPORT_INTERVAL", time.Minute),
+			PushgatewayURL:   strings.TrimSuffix(getEnv("PROMETHEUS_PUSHGATEWAY_URL", ""), "/"),
+			// Off by default in dev: cloud detection probes metadata
+			// services, which takes a second to time out off the cloud
+			ResourceDetectors: getListEnv("TELEMETRY_RESOURCE_DETECTORS", resourceDetectors),
+			DebugScope:        getEnv("TELEMETRY_DEBUG_SCOPE", "admin:debug"),
+		},
+		Retention: RetentionConfig{
+			Enabled:  getBoolEnv("RETENTION_ENABLED", false),
+			Interval: getDuration("RETENTION_INTERVAL", 24*time.Hour),
+			DryRun:   getBoolEnv("RETENTION_DRY_RUN", false),
+		},
+		Storage: StorageConfig{
+			Backend:   getEnv("STORAGE_BACKEND", "local"),
+			LocalPath: getEnv("STORAGE_LOCAL_PATH", "./data/storage"),
+		},
+		Backup: BackupConfig{
+			Enabled:     getBoolEnv("BACKUP_ENABLED", false),
+			Interval:    getDuration("BACKUP_INTERVAL", 24*time.Hour),
+			RetainCount: getIntEnv("BACKUP_RETAIN_COUNT", 7),
+			RetainFor:   getDuration("BACKUP_RETAIN_FOR", 30*24*time.Hour),
+		},
+		Refresh: StagingRefreshConfig{
+			Enabled:   getBoolEnv("STAGING_REFRESH_ENABLED", false),
+			Interval:  getDuration("STAGING_REFRESH_INTERVAL", 7*24*time.Hour),
+			SourceDSN: getEnv("STAGING_REFRESH_SOURCE_DSN", ""),
+		},
+		Archive: ArchiveConfig{
+			Enabled:   getBoolEnv("ARCHIVE_ENABLED", false),
+			Interval:  getDuration("ARCHIVE_INTERVAL", 24*time.Hour),
+			OlderThan: getDuration("ARCHIVE_OLDER_THAN", 90*24*time.Hour),
+			BatchSize: getIntEnv("ARCHIVE_BATCH_SIZE", 5000),
+		},
+		Trash: TrashConfig{
+			Retention:     getDuration("TRASH_RETENTION", 30*24*time.Hour),
+			PurgeInterval: getDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		},
+		Routing: RoutingConfig{
+			CollapseSlashes:       getBoolEnv("ROUTING_COLLAPSE_SLASHES", true),
+			RedirectTrailingSlash: getBoolEnv("ROUTING_REDIRECT_TRAILING_SLASH", true),
+			RejectTraversal:       getBoolEnv("ROUTING_REJECT_TRAVERSAL", true),
+			MethodOverride:        getBoolEnv("ROUTING_METHOD_OVERRIDE", false),
+		},
+		LegacyAPI: LegacyAPIConfig{
+			Enabled:  getBoolEnv("LEGACY_API_ENABLED", false),
+			IDSystem: getEnv("LEGACY_API_ID_SYSTEM", "legacy"),
+		},
+		RequestID: RequestIDConfig{
+			Header:         getEnv("REQUEST_ID_HEADER", "X-Request-ID"),
+			Trust:          getEnv("REQUEST_ID_TRUST", RequestIDTrustAll),
+			MaxLength:      getIntEnv("REQUEST_ID_MAX_LENGTH", 128),
+			Format:         getEnv("REQUEST_ID_FORMAT", "uuid"),
+			ResponseHeader: getEnv("REQUEST_ID_RESPONSE_HEADER", "X-Request-ID"),
+		},
+		Locale: LocaleConfig{
+			SupportedLocales: getListEnv("LOCALE_SUPPORTED", []string{"en-US", "en-GB", "de-DE", "fr-FR", "es-ES", "ja-JP"}),
+			DefaultCurrency:  getEnv("LOCALE_DEFAULT_CURRENCY", "USD"),
+			DefaultUnits:     getEnv("LOCALE_DEFAULT_UNITS", ""),
+		},
+		SMS: SMSConfig{
+			Provider:         getEnv("SMS_PROVIDER", "log"),
+			From:             getEnv("SMS_FROM", ""),
+			TwilioAccountSID: getEnv("SMS_TWILIO_ACCOUNT_SID", ""),
+			TwilioAuthToken:  getEnv("SMS_TWILIO_AUTH_TOKEN", ""),
+			SNSRegion:        getEnv("SMS_SNS_REGION", ""),
+		},
+		Email: EmailConfig{
+			Providers:             getListEnv("EMAIL_PROVIDERS", []string{getEnv("EMAIL_PROVIDER", "log")}),
+			From:                  getEnv("EMAIL_FROM", ""),
+			SMTPHost:              getEnv("EMAIL_SMTP_HOST", ""),
+			SMTPPort:              getIntEnv("EMAIL_SMTP_PORT", 587),
+			SMTPUsername:          getEnv("EMAIL_SMTP_USERNAME", ""),
+			SMTPPassword:          getEnv("EMAIL_SMTP_PASSWORD", ""),
+			PostmarkStream:        getEnv("EMAIL_POSTMARK_STREAM", "outbound"),
+			SESRegion:             getEnv("EMAIL_SES_REGION", ""),
+			SESConfigurationSet:   getEnv("EMAIL_SES_CONFIGURATION_SET", ""),
+			FailoverThreshold:     getIntEnv("EMAIL_FAILOVER_THRESHOLD", 3),
+			FailoverCooldown:      getDuration("EMAIL_FAILOVER_COOLDOWN", time.Minute),
+			MaxAttachmentSize:     int64(getIntEnv("EMAIL_MAX_ATTACHMENT_SIZE", 10<<20)),
+			InlineImages:          map[string]string{},
+			RateLimit:             getFloatEnv("EMAIL_RATE_LIMIT", 10),
+			Burst:                 getIntEnv("EMAIL_BURST", 10),
+			WebhookSecret:         getEnv("EMAIL_WEBHOOK_SECRET", ""),
+			Retention:             getDuration("EMAIL_RETENTION", 30*24*time.Hour),
+			CampaignBatchSize:     getIntEnv("EMAIL_CAMPAIGN_BATCH_SIZE", 100),
+			CampaignBatchInterval: getDuration("EMAIL_CAMPAIGN_BATCH_INTERVAL", 20*time.Second),
 		},
+		Auth: AuthConfig{
+			Enabled:       getBoolEnv("AUTH_ENABLED", false),
+			Issuer:        getEnv("AUTH_ISSUER", ""),
+			Audience:      getEnv("AUTH_AUDIENCE", ""),
+			JWKSURL:       getEnv("AUTH_JWKS_URL", ""),
+			JWKSRefresh:   getDuration("AUTH_JWKS_REFRESH", time.Hour),
+			RolesClaim:    getEnv("AUTH_ROLES_CLAIM", "roles"),
+			ProviderClaim: getEnv("AUTH_PROVIDER_CLAIM", "idp"),
+			TenantClaim:   getEnv("AUTH_TENANT_CLAIM", "org_id"),
+			AdminRole:     getEnv("AUTH_ADMIN_ROLE", "admin"),
+			OrgAdminRole:  getEnv("AUTH_ORG_ADMIN_ROLE", "org-admin"),
+			ClockSkew:     getDuration("AUTH_CLOCK_SKEW", time.Minute),
+
+			SigningClients:   loadSigningClients(getListEnv("AUTH_SIGNING_CLIENTS", nil)),
+			SignatureMaxSkew: getDuration("AUTH_SIGNATURE_MAX_SKEW", 5*time.Minute),
+			ReplayProtection: getEnv("AUTH_REPLAY_PROTECTION", "off"),
+			ReplayMaxSkew:    getDuration("AUTH_REPLAY_MAX_SKEW", 5*time.Minute),
+			ServiceClients:   loadServiceClients(getListEnv("AUTH_MTLS_CLIENTS", nil)),
+			FieldRules:       loadFieldRules(getListEnv("AUTH_FIELD_RULES", nil)),
+
+			FailureDelay:    getDuration("AUTH_FAILURE_DELAY", 250*time.Millisecond),
+			FailureMaxDelay: getDuration("AUTH_FAILURE_MAX_DELAY", 10*time.Second),
+			FailureForget:   getDuration("AUTH_FAILURE_FORGET", 15*time.Minute),
+			ResponseFloor:   getDuration("AUTH_RESPONSE_FLOOR", 300*time.Millisecond),
+
+			NarrowingSecret:  getEnv("AUTH_NARROWING_SECRET", ""),
+			NarrowedTokenTTL: getDuration("AUTH_NARROWED_TOKEN_TTL", 15*time.Minute),
+		},
+		Users: UsersConfig{
+			Persistence:       getEnv("USERS_PERSISTENCE", "state"),
+			SnapshotEvery:     getIntEnv("USERS_SNAPSHOT_EVERY", 50),
+			DeletionGrace:     getDuration("USERS_DELETION_GRACE", 7*24*time.Hour),
+			HandleCooldown:    getDuration("USERS_HANDLE_COOLDOWN", 30*24*time.Hour),
+			HandleHold:        getDuration("USERS_HANDLE_HOLD", 90*24*time.Hour),
+			ReservedHandles:   getListEnv("USERS_RESERVED_HANDLES", nil),
+			EmailChangeTTL:    getDuration("USERS_EMAIL_CHANGE_TTL", 24*time.Hour),
+			EmailChangeURL:    getEnv("USERS_EMAIL_CHANGE_URL", "http://localhost:5173/account/email-change"),
+			IDFormat:          getEnv("USERS_ID_FORMAT", "uuid"),
+			AvailabilityLimit: getIntEnv("USERS_AVAILABILITY_RATE_LIMIT", 30),
+			BulkBatchSize:     getIntEnv("USERS_BULK_BATCH_SIZE", 100),
+			BulkMaxTargets:    getIntEnv("USERS_BULK_MAX_TARGETS", 10000),
+		},
+		Projection: ProjectionConfig{
+			Interval:  getDuration("PROJECTION_INTERVAL", 5*time.Second),
+			BatchSize: getIntEnv("PROJECTION_BATCH_SIZE", 500),
+		},
+		Workflow: WorkflowConfig{
+			Interval:    getDuration("WORKFLOW_INTERVAL", 5*time.Second),
+			MaxAttempts: getIntEnv("WORKFLOW_MAX_ATTEMPTS", 5),
+			Backoff:     getDuration("WORKFLOW_BACKOFF", 30*time.Second),
+			Lease:       getDuration("WORKFLOW_LEASE", 5*time.Minute),
+		},
+		Jobs: JobsConfig{
+			Interval:    getDuration("JOBS_INTERVAL", time.Second),
+			MaxAttempts: getIntEnv("JOBS_MAX_ATTEMPTS", 5),
+			Backoff:     getDuration("JOBS_BACKOFF", 10*time.Second),
+			Lease:       getDuration("JOBS_LEASE", 5*time.Minute),
+			Retention:   getDuration("JOBS_RETENTION", 7*24*time.Hour),
+			Lanes:       getListEnv("JOBS_LANES", []string{"high", "default", "low"}),
+		},
+		Temporal: TemporalConfig{
+			Enabled:   getBoolEnv("TEMPORAL_ENABLED", false),
+			HostPort:  getEnv("TEMPORAL_HOST_PORT", "localhost:7233"),
+			Namespace: getEnv("TEMPORAL_NAMESPACE", "default"),
+			TaskQueue: getEnv("TEMPORAL_TASK_QUEUE", "starterkit"),
+			APIKey:    getEnv("TEMPORAL_API_KEY", ""),
+		},
+		Connectors: ConnectorsConfig{
+			HealthInterval: getDuration("CONNECTORS_HEALTH_INTERVAL", time.Minute),
+			Providers:      loadConnectors(getListEnv("CONNECTORS", nil)),
+		},
+		Slack: SlackConfig{
+			Channel:      getEnv("SLACK_CHANNEL", ""),
+			Throttle:     getDuration("SLACK_THROTTLE", 10*time.Minute),
+			AuditActions: getListEnv("SLACK_AUDIT_ACTIONS", []string{"user.erased", "user.shadow_banned", "retention.policy.executed"}),
+			Templates: map[string]string{
+				"panic": getEnv("SLACK_TEMPLATE_PANIC", ""),
+				"slo":   getEnv("SLACK_TEMPLATE_SLO", ""),
+				"audit": getEnv("SLACK_TEMPLATE_AUDIT", ""),
+				"test":  getEnv("SLACK_TEMPLATE_TEST", ""),
+			},
+		},
+		StatusHooks: StatusHooksConfig{
+			Connectors:        getListEnv("STATUS_HOOKS", nil),
+			ReadinessInterval: getDuration("STATUS_HOOKS_READINESS_INTERVAL", 15*time.Second),
+		},
+		AI: AIConfig{
+			Provider:         getEnv("AI_PROVIDER", "echo"),
+			Model:            getEnv("AI_MODEL", ""),
+			SystemPrompt:     getEnv("AI_SYSTEM_PROMPT", "You are the in-product help assistant for this app. Answer questions about using the app briefly and say so when you do not know."),
+			MaxTokens:        getIntEnv("AI_MAX_TOKENS", 1024),
+			MaxPromptChars:   getIntEnv("AI_MAX_PROMPT_CHARS", 8000),
+			RateLimit:        getIntEnv("AI_RATE_LIMIT", 10),
+			RateWindow:       getDuration("AI_RATE_WINDOW", time.Minute),
+			DailyTokenBudget: int64(getIntEnv("AI_DAILY_TOKEN_BUDGET", 50000)),
+		},
+		Embeddings: EmbeddingsConfig{
+			Provider:   getEnv("EMBEDDINGS_PROVIDER", "hash"),
+			Model:      getEnv("EMBEDDINGS_MODEL", ""),
+			Dimensions: getIntEnv("EMBEDDINGS_DIMENSIONS", 0),
+			BatchSize:  getIntEnv("EMBEDDINGS_BATCH_SIZE", 32),
+			Interval:   getDuration("EMBEDDINGS_INTERVAL", 30*time.Second),
+			MaxChars:   getIntEnv("EMBEDDINGS_MAX_CHARS", 8000),
+		},
+		Phone: PhoneConfig{
+			DefaultRegion:           getEnv("PHONE_DEFAULT_REGION", "US"),
+			VerificationTTL:         getDuration("PHONE_VERIFICATION_TTL", 10*time.Minute),
+			MaxVerificationAttempts: getIntEnv("PHONE_MAX_VERIFICATION_ATTEMPTS", 5),
+		},
+		Push: PushConfig{
+			VAPIDPublicKey:  getEnv("PUSH_VAPID_PUBLIC_KEY", ""),
+			VAPIDPrivateKey: getEnv("PUSH_VAPID_PRIVATE_KEY", ""),
+			VAPIDSubject:    getEnv("PUSH_VAPID_SUBJECT", "mailto:admin@example.com"),
+			TTL:             getDuration("PUSH_TTL", 24*time.Hour),
+		},
+		Invites: InvitesConfig{
+			Organizer: getEnv("INVITES_ORGANIZER", getEnv("EMAIL_FROM", "")),
+		},
+		APIKeys: APIKeysConfig{
+			RefreshInterval: getDuration("API_KEYS_REFRESH_INTERVAL", 30*time.Second),
+		},
+		ServiceAccounts: ServiceAccountsConfig{
+			CredentialTTL:   getDuration("SERVICE_ACCOUNTS_CREDENTIAL_TTL", 90*24*time.Hour),
+			RotationGrace:   getDuration("SERVICE_ACCOUNTS_ROTATION_GRACE", 24*time.Hour),
+			ExpiryWarning:   getDuration("SERVICE_ACCOUNTS_EXPIRY_WARNING", 7*24*time.Hour),
+			RefreshInterval: getDuration("SERVICE_ACCOUNTS_REFRESH_INTERVAL", 30*time.Second),
+		},
+		KillSwitches: KillSwitchesConfig{
+			Routes:          loadKillSwitches(getListEnv("KILL_SWITCHES", nil)),
+			RefreshInterval: getDuration("KILL_SWITCH_REFRESH_INTERVAL", 5*time.Second),
+		},
+		ReadOnly: ReadOnlyConfig{
+			Enabled:         getBoolEnv("READ_ONLY", false),
+			Reason:          getEnv("READ_ONLY_REASON", "The service is read-only during maintenance"),
+			RefreshInterval: getDuration("READ_ONLY_REFRESH_INTERVAL", 5*time.Second),
+		},
+		ResponseCache: ResponseCacheConfig{
+			Backend:      getEnv("RESPONSE_CACHE_BACKEND", "memory"),
+			RedisURL:     getEnv("RESPONSE_CACHE_REDIS_URL", "redis://localhost:6379/0"),
+			MaxEntries:   getIntEnv("RESPONSE_CACHE_MAX_ENTRIES", 10000),
+			TenantHeader: getEnv("RESPONSE_CACHE_TENANT_HEADER", "X-Tenant-ID"),
+			Routes:       loadResponseCacheRules(getListEnv("RESPONSE_CACHE_ROUTES", nil)),
+		},
+		Delta: DeltaConfig{
+			Enabled:     getBoolEnv("DELTA_ENABLED", false),
+			Backend:     getEnv("DELTA_BACKEND", "memory"),
+			RedisURL:    getEnv("DELTA_REDIS_URL", "redis://localhost:6379/0"),
+			MaxEntries:  getIntEnv("DELTA_MAX_ENTRIES", 10000),
+			TTL:         getDuration("DELTA_TTL", 2*time.Minute),
+			MaxBodySize: getIntEnv("DELTA_MAX_BODY_SIZE", 1<<20),
+		},
+		Links: LinksConfig{
+			BaseURL: strings.TrimSuffix(getEnv("LINKS_BASE_URL", "http://localhost:8080"), "/"),
+		},
+		Announcements: AnnouncementsConfig{
+			FeedTitle: getEnv("ANNOUNCEMENTS_FEED_TITLE", "Announcements"),
+			BaseURL:   strings.TrimSuffix(getEnv("ANNOUNCEMENTS_BASE_URL", getEnv("LINKS_BASE_URL", "http://localhost:8080")), "/"),
+			FeedSize:  getIntEnv("ANNOUNCEMENTS_FEED_SIZE", 20),
+			CacheTTL:  getDuration("ANNOUNCEMENTS_FEED_CACHE_TTL", 5*time.Minute),
+		},
+		Sitemap: SitemapConfig{
+			BaseURL:       strings.TrimSuffix(getEnv("SITEMAP_BASE_URL", getEnv("LINKS_BASE_URL", "http://localhost:8080")), "/"),
+			Pages:         getListEnv("SITEMAP_PAGES", []string{"/"}),
+			PageSize:      getIntEnv("SITEMAP_PAGE_SIZE", 10000),
+			AllowIndexing: getBoolEnv("ROBOTS_ALLOW_INDEXING", env == EnvProd),
+		},
+		Uploads: UploadsConfig{
+			MaxSize:          int64(getIntEnv("UPLOADS_MAX_SIZE", 25<<20)),
+			ScanInterval:     getDuration("UPLOADS_SCAN_INTERVAL", 5*time.Second),
+			ScanBatch:        getIntEnv("UPLOADS_SCAN_BATCH", 10),
+			ResumableMaxSize: int64(getIntEnv("UPLOADS_RESUMABLE_MAX_SIZE", 1<<30)),
+			ResumableTTL:     getDuration("UPLOADS_RESUMABLE_TTL", 24*time.Hour),
+			DownloadRate:     getIntEnv("UPLOADS_DOWNLOAD_RATE", 0),
+			DownloadBurst:    getIntEnv("UPLOADS_DOWNLOAD_BURST", 1<<20),
+		},
+		Scan: ScanConfig{
+			Backend:       getEnv("SCAN_BACKEND", "none"),
+			ClamAVAddress: getEnv("SCAN_CLAMAV_ADDRESS", "localhost:3310"),
+			HTTPURL:       getEnv("SCAN_HTTP_URL", ""),
+			HTTPToken:     getEnv("SCAN_HTTP_TOKEN", ""),
+			Timeout:       getDuration("SCAN_TIMEOUT", time.Minute),
+		},
+		Moderation: ModerationConfig{
+			Backend:       getEnv("MODERATION_BACKEND", "none"),
+			RejectWords:   getListEnv("MODERATION_REJECT_WORDS", nil),
+			FlagWords:     getListEnv("MODERATION_FLAG_WORDS", nil),
+			SanitizeWords: getListEnv("MODERATION_SANITIZE_WORDS", nil),
+			HTTPURL:       getEnv("MODERATION_HTTP_URL", ""),
+			HTTPToken:     getEnv("MODERATION_HTTP_TOKEN", ""),
+			Timeout:       getDuration("MODERATION_TIMEOUT", 5*time.Second),
+		},
+		GeoIP: GeoIPConfig{
+			Enabled:         getBoolEnv("GEOIP_ENABLED", false),
+			DatabasePath:    getEnv("GEOIP_DATABASE_PATH", ""),
+			RefreshInterval: getDuration("GEOIP_REFRESH_INTERVAL", time.Hour),
+			CountryHeader:   getEnv("GEOIP_COUNTRY_HEADER", ""),
+		},
+		GeoBlock: GeoBlockConfig{
+			Enabled: getBoolEnv("GEOBLOCK_ENABLED", false),
+			Default: GeoBlockRules{
+				Allow: getListEnv("GEOBLOCK_ALLOW", nil),
+				Deny:  getListEnv("GEOBLOCK_DENY", nil),
+			},
+			BlockUnknown: getBoolEnv("GEOBLOCK_BLOCK_UNKNOWN", false),
+			Tenants:      loadGeoBlockTenants(getListEnv("GEOBLOCK_TENANTS", nil)),
+		},
+		Sessions: SessionsConfig{
+			Enabled:         getBoolEnv("SESSIONS_ENABLED", true),
+			UserAgentParser: getEnv("USERAGENT_PARSER", "builtin"),
+			TouchInterval:   getDuration("SESSIONS_TOUCH_INTERVAL", time.Minute),
+			Retention:       getDuration("SESSIONS_RETENTION", 90*24*time.Hour),
+			LoginAlerts:     getBoolEnv("SESSIONS_LOGIN_ALERTS", true),
+			AlertBaseURL:    strings.TrimSuffix(getEnv("SESSIONS_ALERT_BASE_URL", "http://localhost:8080"), "/"),
+			RevokeLinkTTL:   getDuration("SESSIONS_REVOKE_LINK_TTL", 7*24*time.Hour),
+		},
+		MagicLinks: MagicLinksConfig{
+			Enabled:        getBoolEnv("MAGIC_LINK_ENABLED", false),
+			Secret:         getEnv("MAGIC_LINK_SECRET", ""),
+			CallbackURL:    getEnv("MAGIC_LINK_CALLBACK_URL", "http://localhost:5173/auth/magic-link"),
+			TTL:            getDuration("MAGIC_LINK_TTL", 15*time.Minute),
+			SessionTTL:     getDuration("MAGIC_LINK_SESSION_TTL", 30*24*time.Hour),
+			ResendInterval: getDuration("MAGIC_LINK_RESEND_INTERVAL", time.Minute),
+			MaxPerEmail:    getIntEnv("MAGIC_LINK_MAX_PER_EMAIL", 5),
+			MaxPerIP:       getIntEnv("MAGIC_LINK_MAX_PER_IP", 20),
+		},
+		WebAuthn: WebAuthnConfig{
+			Enabled:          getBoolEnv("WEBAUTHN_ENABLED", false),
+			RPID:             getEnv("WEBAUTHN_RP_ID", "localhost"),
+			RPName:           getEnv("WEBAUTHN_RP_NAME", "Starterkit"),
+			Origins:          getListEnv("WEBAUTHN_ORIGINS", []string{"http://localhost:5173"}),
+			Timeout:          getDuration("WEBAUTHN_TIMEOUT", 5*time.Minute),
+			UserVerification: getEnv("WEBAUTHN_USER_VERIFICATION", "preferred"),
+			SessionTTL:       getDuration("WEBAUTHN_SESSION_TTL", 30*24*time.Hour),
+		},
+		Accounts: AccountsConfig{
+			LinkingEnabled:  getBoolEnv("ACCOUNT_LINKING_ENABLED", false),
+			ResolveCacheTTL: getDuration("ACCOUNT_RESOLVE_CACHE_TTL", time.Minute),
+		},
+		Risk: RiskConfig{
+			Enabled:            getBoolEnv("RISK_ENABLED", true),
+			IPDenylist:         getListEnv("RISK_IP_DENYLIST", nil),
+			VelocityWindow:     getDuration("RISK_VELOCITY_WINDOW", time.Minute),
+			VelocityLimit:      getIntEnv("RISK_VELOCITY_LIMIT", 30),
+			NewAccountAge:      getDuration("RISK_NEW_ACCOUNT_AGE", 24*time.Hour),
+			BlockThreshold:     getIntEnv("RISK_BLOCK_THRESHOLD", 100),
+			ShadowBanThreshold: getIntEnv("RISK_SHADOW_BAN_THRESHOLD", 0),
+		},
+		Domains: DomainsConfig{
+			Enabled:          getBoolEnv("DOMAINS_ENABLED", false),
+			ChallengeLabel:   getEnv("DOMAINS_CHALLENGE_LABEL", "_starterkit-challenge"),
+			VerifyInterval:   getDuration("DOMAINS_VERIFY_INTERVAL", 5*time.Minute),
+			VerifyWindow:     getDuration("DOMAINS_VERIFY_WINDOW", 72*time.Hour),
+			RefreshInterval:  getDuration("DOMAINS_REFRESH_INTERVAL", 30*time.Second),
+			ACMEEnabled:      getBoolEnv("DOMAINS_ACME_ENABLED", false),
+			ACMEEmail:        getEnv("DOMAINS_ACME_EMAIL", ""),
+			ACMEDirectoryURL: getEnv("DOMAINS_ACME_DIRECTORY_URL", "https://acme-v02.api.letsencrypt.org/directory"),
+		},
+		SIEM: SIEMConfig{
+			Enabled:       getBoolEnv("SIEM_ENABLED", false),
+			Sink:          getEnv("SIEM_SINK", "https"),
+			Format:        getEnv("SIEM_FORMAT", "json"),
+			HTTPURL:       getEnv("SIEM_HTTP_URL", ""),
+			HTTPToken:     getEnv("SIEM_HTTP_TOKEN", ""),
+			SyslogNetwork: getEnv("SIEM_SYSLOG_NETWORK", "tcp+tls"),
+			SyslogAddress: getEnv("SIEM_SYSLOG_ADDRESS", ""),
+			SyslogAppName: getEnv("SIEM_SYSLOG_APP_NAME", "starterkit"),
+			Interval:      getDuration("SIEM_INTERVAL", 10*time.Second),
+			BatchSize:     getIntEnv("SIEM_BATCH_SIZE", 100),
+			MaxBatches:    getIntEnv("SIEM_MAX_BATCHES", 10),
+			MaxRetries:    getIntEnv("SIEM_MAX_RETRIES", 5),
+			Timeout:       getDuration("SIEM_TIMEOUT", 10*time.Second),
+		},
+		AuditExports: AuditExportsConfig{
+			Enabled:             getBoolEnv("AUDIT_EXPORTS_ENABLED", false),
+			Interval:            getDuration("AUDIT_EXPORTS_INTERVAL", time.Minute),
+			BatchSize:           getIntEnv("AUDIT_EXPORTS_BATCH_SIZE", 20),
+			MaxEntries:          getIntEnv("AUDIT_EXPORTS_MAX_ENTRIES", 10000),
+			MaxPerTenant:        getIntEnv("AUDIT_EXPORTS_MAX_PER_TENANT", 10),
+			RetryInterval:       getDuration("AUDIT_EXPORTS_RETRY_INTERVAL", 15*time.Minute),
+			MaxFailures:         getIntEnv("AUDIT_EXPORTS_MAX_FAILURES", 5),
+			WebhookTimeout:      getDuration("AUDIT_EXPORTS_WEBHOOK_TIMEOUT", 30*time.Second),
+			WebhookAllowPrivate: getBoolEnv("AUDIT_EXPORTS_WEBHOOK_ALLOW_PRIVATE", false),
+			TenantHeader:        getEnv("AUDIT_EXPORTS_TENANT_HEADER", "X-Tenant-ID"),
+		},
+		CORS: CORSConfig{
+			API:   loadCORSPolicy("CORS_API", corsOrigins),
+			Admin: loadCORSPolicy("CORS_ADMIN", corsOrigins),
+		},
+		Events: EventsConfig{
+			ChangeRelayInterval: getDuration("EVENTS_CHANGE_RELAY_INTERVAL", 5*time.Second),
+			OperationTimeout:    getDuration("EVENTS_OPERATION_TIMEOUT", 10*time.Minute),
+			UndoWindow:          getDuration("EVENTS_UNDO_WINDOW", 30*time.Second),
+		},
+		Probe: ProbeConfig{
+			Enabled:  getBoolEnv("PROBE_ENABLED", false),
+			Interval: getDuration("PROBE_INTERVAL", time.Minute),
+			BaseURL:  getEnv("PROBE_BASE_URL", ""),
+			Email:    getEnv("PROBE_EMAIL", "probe-canary@example.invalid"),
+			Token:    getEnv("PROBE_TOKEN", ""),
+		},
+		Lock: LockConfig{
+			Backend:  getEnv("LOCK_BACKEND", "postgres"),
+			RedisURL: getEnv("LOCK_REDIS_URL", "redis://localhost:6379/0"),
+			JobTTL:   getDuration("LOCK_JOB_TTL", 30*time.Second),
+		},
+		Leader: LeaderConfig{
+			ID:       getEnv("LEADER_ID", ""),
+			LeaseTTL: getDuration("LEADER_LEASE_TTL", 15*time.Second),
+		},
+		Cluster: ClusterConfig{
+			Enabled:           getBoolEnv("CLUSTER_ENABLED", false),
+			Backend:           getEnv("CLUSTER_BACKEND", "postgres"),
+			RedisURL:          getEnv("CLUSTER_REDIS_URL", "redis://localhost:6379/0"),
+			HeartbeatInterval: getDuration("CLUSTER_HEARTBEAT_INTERVAL", 5*time.Second),
+			HeartbeatTTL:      getDuration("CLUSTER_HEARTBEAT_TTL", 15*time.Second),
+		},
+		SLO: SLOConfig{
+			Window:        getDuration("SLO_WINDOW", 24*time.Hour),
+			AlertInterval: getDuration("SLO_ALERT_INTERVAL", time.Minute),
+		},
+		QueryCost: QueryCostConfig{
+			Budget:  getIntEnv("QUERY_COST_BUDGET", 600),
+			Enforce: getBoolEnv("QUERY_COST_ENFORCE", true),
+		},
+		Watchdog: WatchdogConfig{
+			CheckInterval: getDuration("WATCHDOG_CHECK_INTERVAL", 30*time.Second),
+			StallTimeout:  getDuration("WATCHDOG_STALL_TIMEOUT", 30*time.Minute),
+			MaxRestarts:   getIntEnv("WATCHDOG_MAX_RESTARTS", 3),
+		},
+	}
+
+	if cfg.Users.Persistence != "state" && cfg.Users.Persistence != "events" {
+		return nil, fmt.Errorf("unsupported USERS_PERSISTENCE: %s", cfg.Users.Persistence)
+	}
+	switch cfg.Users.IDFormat {
+	case "uuid", "uuidv7", "ulid":
+	default:
+		return nil, fmt.Errorf("unsupported USERS_ID_FORMAT: %s", cfg.Users.IDFormat)
+	}
+	if cfg.Users.HandleCooldown < 0 || cfg.Users.HandleHold < 0 {
+		return nil, errors.New("USERS_HANDLE_COOLDOWN and USERS_HANDLE_HOLD must not be negative")
+	}
+	if cfg.Users.AvailabilityLimit < 0 {
+		return nil, errors.New("USERS_AVAILABILITY_RATE_LIMIT must not be negative")
+	}
+	if cfg.Users.BulkBatchSize < 1 || cfg.Users.BulkMaxTargets < 1 {
+		return nil, errors.New("USERS_BULK_BATCH_SIZE and USERS_BULK_MAX_TARGETS must be at least 1")
+	}
+	if cfg.QueryCost.Budget < 0 {
+		return nil, errors.New("QUERY_COST_BUDGET must not be negative")
+	}
+	switch cfg.RequestID.Trust {
+	case RequestIDTrustAll, RequestIDTrustProxies, RequestIDTrustNone:
+	default:
+		return nil, fmt.Errorf("unsupported REQUEST_ID_TRUST: %s", cfg.RequestID.Trust)
+	}
+	switch cfg.RequestID.Format {
+	case "uuid", "uuidv7", "ulid":
+	default:
+		return nil, fmt.Errorf("unsupported REQUEST_ID_FORMAT: %s", cfg.RequestID.Format)
+	}
+	if cfg.RequestID.MaxLength < 1 || cfg.RequestID.MaxLength > 1024 {
+		return nil, errors.New("REQUEST_ID_MAX_LENGTH must be between 1 and 1024")
+	}
+	if cfg.RequestID.Trust != RequestIDTrustNone && !validHeaderName(cfg.RequestID.Header) {
+		return nil, fmt.Errorf("REQUEST_ID_HEADER is not a valid header name: %q", cfg.RequestID.Header)
+	}
+	if !validHeaderName(cfg.RequestID.ResponseHeader) {
+		return nil, fmt.Errorf("REQUEST_ID_RESPONSE_HEADER is not a valid header name: %q", cfg.RequestID.ResponseHeader)
+	}
+	if cfg.Events.UndoWindow <= 0 {
+		return nil, errors.New("EVENTS_UNDO_WINDOW must be positive")
+	}
+	if cfg.Trash.Retention <= 0 {
+		return nil, errors.New("TRASH_RETENTION must be positive")
+	}
+	if cfg.Uploads.ResumableMaxSize <= 0 || cfg.Uploads.ResumableTTL <= 0 {
+		return nil, errors.New("UPLOADS_RESUMABLE_MAX_SIZE and UPLOADS_RESUMABLE_TTL must be positive")
+	}
+	if cfg.Uploads.DownloadRate < 0 || cfg.Uploads.DownloadBurst <= 0 {
+		return nil, errors.New("UPLOADS_DOWNLOAD_RATE must not be negative and UPLOADS_DOWNLOAD_BURST must be positive")
+	}
+	if len(cfg.StatusHooks.Connectors) > 0 && cfg.StatusHooks.ReadinessInterval <= 0 {
+		return nil, errors.New("STATUS_HOOKS_READINESS_INTERVAL must be positive")
+	}
+	if cfg.Users.EmailChangeTTL <= 0 {
+		return nil, errors.New("USERS_EMAIL_CHANGE_TTL must be positive")
+	}
+	if u, err := url.Parse(cfg.Users.EmailChangeURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("USERS_EMAIL_CHANGE_URL must be an http or https URL: %s", cfg.Users.EmailChangeURL)
+	}
+
+	proxies, err := parseTrustedProxies(getListEnv("TRUSTED_PROXIES", nil))
+	if err != nil {
+		return nil, err
+	}
+	// RISK_TRUST_FORWARDED_FOR predates TRUSTED_PROXIES and trusts any peer
+	if len(proxies) == 0 && getBoolEnv("RISK_TRUST_FORWARDED_FOR", false) {
+		proxies = []netip.Prefix{netip.MustParsePrefix("0.0.0.0/0"), netip.MustParsePrefix("::/0")}
+	}
+	cfg.Server.TrustedProxies = proxies
+
+	if cfg.Server.StartupTimeout <= 0 {
+		return nil, fmt.Errorf("SERVER_STARTUP_TIMEOUT must be positive: %s", cfg.Server.StartupTimeout)
+	}
+	if cfg.Server.DrainDelay >= cfg.Server.ShutdownTimeout {
+		return nil, errors.New("SERVER_DRAIN_DELAY must be shorter than SERVER_SHUTDOWN_TIMEOUT")
+	}
+
+	if cfg.Database.AutoMigrate && cfg.Database.LazyConnect {
+		return nil, errors.New("DB_AUTO_MIGRATE cannot be combined with DB_LAZY_CONNECT")
+	}
+
+	if len(cfg.Database.ReadReplicas) > 0 && cfg.Database.ReplicaMaxConns <= 0 {
+		return nil, fmt.Errorf("DB_REPLICA_MAX_CONNS must be positive: %d", cfg.Database.ReplicaMaxConns)
+	}
+	if cfg.Database.HedgePercentile < 0 || cfg.Database.HedgePercentile >= 1 {
+		return nil, fmt.Errorf("DB_HEDGE_PERCENTILE must be at least 0 and below 1: %g", cfg.Database.HedgePercentile)
+	}
+	if cfg.Database.HedgeMinDelay < 0 {
+		return nil, fmt.Errorf("DB_HEDGE_MIN_DELAY must not be negative: %s", cfg.Database.HedgeMinDelay)
+	}
+
+	if cfg.GeoBlock.Enabled && !cfg.GeoIP.Enabled {
+		return nil, errors.New("GEOBLOCK_ENABLED requires GEOIP_ENABLED")
+	}
+
+	if cfg.Auth.Enabled && (cfg.Auth.Issuer == "" || cfg.Auth.Audience == "") {
+		return nil, errors.New("AUTH_ENABLED requires AUTH_ISSUER and AUTH_AUDIENCE")
+	}
+
+	for id, client := range cfg.Auth.SigningClients {
+		if len(client.Secret) < 32 {
+			return nil, fmt.Errorf("AUTH_SIGNING_CLIENT_%s_SECRET must be at least 32 characters", envKey(id))
+		}
+	}
+	if len(cfg.Auth.SigningClients) > 0 && cfg.Auth.SignatureMaxSkew <= 0 {
+		return nil, errors.New("AUTH_SIGNATURE_MAX_SKEW must be positive")
+	}
+	switch cfg.Auth.ReplayProtection {
+	case "off", "optional", "required":
+	default:
+		return nil, fmt.Errorf("unsupported AUTH_REPLAY_PROTECTION: %s", cfg.Auth.ReplayProtection)
+	}
+	if cfg.Auth.ReplayProtection != "off" && cfg.Auth.ReplayMaxSkew <= 0 {
+		return nil, errors.New("AUTH_REPLAY_MAX_SKEW must be positive")
+	}
+	if cfg.Auth.FailureDelay < 0 || cfg.Auth.ResponseFloor < 0 {
+		return nil, errors.New("AUTH_FAILURE_DELAY and AUTH_RESPONSE_FLOOR must not be negative")
+	}
+	if cfg.Auth.NarrowingSecret != "" {
+		// Scopes are only checked with bearer auth on
+		if !cfg.Auth.Enabled {
+			return nil, errors.New("AUTH_NARROWING_SECRET requires AUTH_ENABLED")
+		}
+		if len(cfg.Auth.NarrowingSecret) < 32 {
+			return nil, errors.New("AUTH_NARROWING_SECRET must be at least 32 characters")
+		}
+		if cfg.Auth.NarrowedTokenTTL <= 0 || cfg.Auth.NarrowedTokenTTL > time.Hour {
+			return nil, fmt.Errorf("AUTH_NARROWED_TOKEN_TTL must be positive and at most 1h: %s", cfg.Auth.NarrowedTokenTTL)
+		}
+	}
+	if cfg.Auth.FailureDelay > 0 && (cfg.Auth.FailureMaxDelay < cfg.Auth.FailureDelay || cfg.Auth.FailureForget <= 0) {
+		return nil, errors.New("AUTH_FAILURE_MAX_DELAY must be at least AUTH_FAILURE_DELAY, and AUTH_FAILURE_FORGET positive")
+	}
+
+	if cfg.AuditExports.Enabled {
+		e := cfg.AuditExports
+		if e.Interval <= 0 || e.RetryInterval <= 0 || e.WebhookTimeout <= 0 {
+			return nil, errors.New("AUDIT_EXPORTS_INTERVAL, AUDIT_EXPORTS_RETRY_INTERVAL and AUDIT_EXPORTS_WEBHOOK_TIMEOUT must be positive")
+		}
+		if e.BatchSize <= 0 || e.MaxEntries <= 0 || e.MaxPerTenant <= 0 || e.MaxFailures < 0 {
+			return nil, errors.New("AUDIT_EXPORTS_BATCH_SIZE, AUDIT_EXPORTS_MAX_ENTRIES and AUDIT_EXPORTS_MAX_PER_TENANT must be positive, and AUDIT_EXPORTS_MAX_FAILURES not negative")
+		}
+		if !validHeaderName(e.TenantHeader) {
+			return nil, fmt.Errorf("AUDIT_EXPORTS_TENANT_HEADER is not a valid header name: %q", e.TenantHeader)
+		}
+	}
+	if cfg.Domains.Enabled {
+		if cfg.Domains.VerifyInterval <= 0 || cfg.Domains.VerifyWindow <= 0 || cfg.Domains.RefreshInterval <= 0 {
+			return nil, errors.New("DOMAINS_VERIFY_INTERVAL, DOMAINS_VERIFY_WINDOW and DOMAINS_REFRESH_INTERVAL must be positive")
+		}
+		if !validChallengeLabel(cfg.Domains.ChallengeLabel) {
+			return nil, fmt.Errorf("DOMAINS_CHALLENGE_LABEL must be a DNS label starting with an underscore: %q", cfg.Domains.ChallengeLabel)
+		}
+	}
+	if cfg.Domains.ACMEEnabled {
+		if !cfg.Domains.Enabled {
+			return nil, errors.New("DOMAINS_ACME_ENABLED requires DOMAINS_ENABLED")
+		}
+		// The server's own hosts keep the configured certificate
+		if cfg.Server.TLSCertFile == "" || cfg.Server.TLSKeyFile == "" {
+			return nil, errors.New("DOMAINS_ACME_ENABLED requires SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE")
+		}
+		if u, err := url.Parse(cfg.Domains.ACMEDirectoryURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("DOMAINS_ACME_DIRECTORY_URL must be an https URL: %s", cfg.Domains.ACMEDirectoryURL)
+		}
+	}
+	if cfg.TLS.ClientCAFile != "" && (cfg.Server.TLSCertFile == "" || cfg.Server.TLSKeyFile == "") {
+		return nil, errors.New("TLS_CLIENT_CA_FILE requires SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE")
+	}
+	if len(cfg.Auth.ServiceClients) > 0 && cfg.TLS.ClientCAFile == "" {
+		return nil, errors.New("AUTH_MTLS_CLIENTS requires TLS_CLIENT_CA_FILE")
+	}
+	identities := make(map[string]string, len(cfg.Auth.ServiceClients))
+	for id, client := range cfg.Auth.ServiceClients {
+		if client.Identity == "" {
+			return nil, fmt.Errorf("AUTH_MTLS_CLIENT_%s_IDENTITY is required", envKey(id))
+		}
+		if other, dup := identities[client.Identity]; dup {
+			return nil, fmt.Errorf("mTLS clients %s and %s share identity %s", other, id, client.Identity)
+		}
+		identities[client.Identity] = id
+	}
+	for field, rule := range cfg.Auth.FieldRules {
+		if typ, name, ok := strings.Cut(field, "."); !ok || typ == "" || name == "" {
+			return nil, fmt.Errorf("AUTH_FIELD_RULES entry %q must be Type.field", field)
+		}
+		if len(rule.Grants) == 0 {
+			return nil, fmt.Errorf("AUTH_FIELD_RULE_%s_GRANTS is required", envKey(field))
+		}
+		for _, grant := range rule.Grants {
+			kind, name, _ := strings.Cut(grant, ":")
+			valid := grant == "self" || grant == "admin" || ((kind == "role" || kind == "scope") && name != "")
+			if !valid {
+				return nil, fmt.Errorf("AUTH_FIELD_RULE_%s_GRANTS has unknown grant %q", envKey(field), grant)
+			}
+		}
+		if rule.Mask != "" && rule.Mask != "email" && rule.Mask != "last4" {
+			return nil, fmt.Errorf("AUTH_FIELD_RULE_%s_MASK must be email or last4", envKey(field))
+		}
+	}
+
+	for _, provider := range cfg.Email.Providers {
+		if provider != "log" && cfg.Email.From == "" {
+			return nil, fmt.Errorf("email provider %s requires EMAIL_FROM", provider)
+		}
+	}
+	for _, entry := range getListEnv("EMAIL_INLINE_IMAGES", nil) {
+		id, key, ok := strings.Cut(entry, "=")
+		if !ok || id == "" || key == "" {
+			return nil, fmt.Errorf("EMAIL_INLINE_IMAGES entries must be <content id>=<storage key>: %s", entry)
+		}
+		cfg.Email.InlineImages[id] = key
+	}
+
+	if cfg.Invites.Organizer != "" {
+		if _, err := mail.ParseAddress(cfg.Invites.Organizer); err != nil {
+			return nil, fmt.Errorf("invalid INVITES_ORGANIZER: %w", err)
+		}
+	}
+
+	if u, err := url.Parse(cfg.Links.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("LINKS_BASE_URL must be an http or https URL: %s", cfg.Links.BaseURL)
+	}
+
+	if u, err := url.Parse(cfg.Announcements.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("ANNOUNCEMENTS_BASE_URL must be an http or https URL: %s", cfg.Announcements.BaseURL)
+	}
+	if cfg.Announcements.FeedSize < 1 || cfg.Announcements.FeedSize > 100 {
+		return nil, fmt.Errorf("ANNOUNCEMENTS_FEED_SIZE must be between 1 and 100: %d", cfg.Announcements.FeedSize)
+	}
+	if cfg.Announcements.CacheTTL < 0 {
+		return nil, fmt.Errorf("ANNOUNCEMENTS_FEED_CACHE_TTL must not be negative: %s", cfg.Announcements.CacheTTL)
+	}
+
+	if u, err := url.Parse(cfg.Sitemap.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("SITEMAP_BASE_URL must be an http or https URL: %s", cfg.Sitemap.BaseURL)
+	}
+	for _, page := range cfg.Sitemap.Pages {
+		if !strings.HasPrefix(page, "/") {
+			return nil, fmt.Errorf("SITEMAP_PAGES entries must be paths starting with /: %s", page)
+		}
+	}
+	if cfg.Sitemap.PageSize < 1 || cfg.Sitemap.PageSize > 50000 {
+		return nil, fmt.Errorf("SITEMAP_PAGE_SIZE must be between 1 and 50000: %d", cfg.Sitemap.PageSize)
+	}
+
+	if cfg.Sessions.LoginAlerts {
+		if cfg.Sessions.AlertBaseURL == "" {
+			return nil, errors.New("SESSIONS_ALERT_BASE_URL is required when SESSIONS_LOGIN_ALERTS is on")
+		}
+		if cfg.Sessions.RevokeLinkTTL <= 0 {
+			return nil, fmt.Errorf("SESSIONS_REVOKE_LINK_TTL must be positive: %s", cfg.Sessions.RevokeLinkTTL)
+		}
+	}
+
+	if cfg.MagicLinks.Enabled {
+		if len(cfg.MagicLinks.Secret) < 32 {
+			return nil, errors.New("MAGIC_LINK_SECRET must be at least 32 characters when MAGIC_LINK_ENABLED is on")
+		}
+		if u, err := url.Parse(cfg.MagicLinks.CallbackURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("MAGIC_LINK_CALLBACK_URL must be an http or https URL: %s", cfg.MagicLinks.CallbackURL)
+		}
+		if cfg.MagicLinks.TTL <= 0 || cfg.MagicLinks.SessionTTL <= 0 {
+			return nil, errors.New("MAGIC_LINK_TTL and MAGIC_LINK_SESSION_TTL must be positive")
+		}
+		if cfg.MagicLinks.ResendInterval < 0 {
+			return nil, fmt.Errorf("MAGIC_LINK_RESEND_INTERVAL must not be negative: %s", cfg.MagicLinks.ResendInterval)
+		}
+		if cfg.MagicLinks.MaxPerEmail < 1 || cfg.MagicLinks.MaxPerIP < 1 {
+			return nil, errors.New("MAGIC_LINK_MAX_PER_EMAIL and MAGIC_LINK_MAX_PER_IP must be at least 1")
+		}
+	}
+
+	if cfg.WebAuthn.Enabled {
+		if cfg.WebAuthn.RPID == "" || strings.ContainsAny(cfg.WebAuthn.RPID, ":/") {
+			return nil, fmt.Errorf("WEBAUTHN_RP_ID must be a domain: %q", cfg.WebAuthn.RPID)
+		}
+		if len(cfg.WebAuthn.Origins) == 0 {
+			return nil, errors.New("WEBAUTHN_ORIGINS is required when WEBAUTHN_ENABLED is on")
+		}
+		for _, origin := range cfg.WebAuthn.Origins {
+			u, err := url.Parse(origin)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+				return nil, fmt.Errorf("WEBAUTHN_ORIGINS must be http or https origins: %s", origin)
+			}
+			if host := u.Hostname(); host != cfg.WebAuthn.RPID && !strings.HasSuffix(host, "."+cfg.WebAuthn.RPID) {
+				return nil, fmt.Errorf("WEBAUTHN_ORIGINS must be on WEBAUTHN_RP_ID %s: %s", cfg.WebAuthn.RPID, origin)
+			}
+		}
+		switch cfg.WebAuthn.UserVerification {
+		case "required", "preferred", "discouraged":
+		default:
+			return nil, fmt.Errorf("WEBAUTHN_USER_VERIFICATION must be required, preferred, or discouraged: %s", cfg.WebAuthn.UserVerification)
+		}
+		if cfg.WebAuthn.Timeout <= 0 || cfg.WebAuthn.SessionTTL <= 0 {
+			return nil, errors.New("WEBAUTHN_TIMEOUT and WEBAUTHN_SESSION_TTL must be positive")
+		}
+	}
+
+	if cfg.Accounts.LinkingEnabled && !cfg.Auth.Enabled {
+		return nil, errors.New("ACCOUNT_LINKING_ENABLED requires AUTH_ENABLED")
+	}
+	if cfg.Accounts.ResolveCacheTTL < 0 {
+		return nil, fmt.Errorf("ACCOUNT_RESOLVE_CACHE_TTL must not be negative: %s", cfg.Accounts.ResolveCacheTTL)
+	}
+
+	if cfg.ServiceAccounts.CredentialTTL <= 0 {
+		return nil, fmt.Errorf("SERVICE_ACCOUNTS_CREDENTIAL_TTL must be positive: %s", cfg.ServiceAccounts.CredentialTTL)
+	}
+	if cfg.ServiceAccounts.RotationGrace < 0 || cfg.ServiceAccounts.RotationGrace >= cfg.ServiceAccounts.CredentialTTL {
+		return nil, fmt.Errorf("SERVICE_ACCOUNTS_ROTATION_GRACE must be at least 0 and shorter than SERVICE_ACCOUNTS_CREDENTIAL_TTL: %s", cfg.ServiceAccounts.RotationGrace)
+	}
+	if cfg.ServiceAccounts.ExpiryWarning <= 0 || cfg.ServiceAccounts.ExpiryWarning >= cfg.ServiceAccounts.CredentialTTL {
+		return nil, fmt.Errorf("SERVICE_ACCOUNTS_EXPIRY_WARNING must be positive and shorter than SERVICE_ACCOUNTS_CREDENTIAL_TTL: %s", cfg.ServiceAccounts.ExpiryWarning)
+	}
+	if cfg.ServiceAccounts.RefreshInterval <= 0 {
+		return nil, fmt.Errorf("SERVICE_ACCOUNTS_REFRESH_INTERVAL must be positive: %s", cfg.ServiceAccounts.RefreshInterval)
+	}
+
+	for route := range cfg.KillSwitches.Routes {
+		if method, path, ok := strings.Cut(route, " "); !strings.HasPrefix(route, "/") && (!ok || method != strings.ToUpper(method) || !strings.HasPrefix(path, "/")) {
+			return nil, fmt.Errorf("KILL_SWITCHES entries must be route patterns such as POST /api/v1/links: %s", route)
+		}
+	}
+	if cfg.ResponseCache.Backend != "memory" && cfg.ResponseCache.Backend != "redis" {
+		return nil, fmt.Errorf("unsupported RESPONSE_CACHE_BACKEND: %s", cfg.ResponseCache.Backend)
+	}
+	if cfg.Delta.Enabled {
+		if cfg.Delta.Backend != "memory" && cfg.Delta.Backend != "redis" {
+			return nil, fmt.Errorf("unsupported DELTA_BACKEND: %s", cfg.Delta.Backend)
+		}
+		if cfg.Delta.MaxEntries <= 0 {
+			return nil, fmt.Errorf("DELTA_MAX_ENTRIES must be positive: %d", cfg.Delta.MaxEntries)
+		}
+		if cfg.Delta.TTL <= 0 {
+			return nil, fmt.Errorf("DELTA_TTL must be positive: %s", cfg.Delta.TTL)
+		}
+		if cfg.Delta.MaxBodySize <= 0 {
+			return nil, fmt.Errorf("DELTA_MAX_BODY_SIZE must be positive: %d", cfg.Delta.MaxBodySize)
+		}
+	}
+	if cfg.ResponseCache.MaxEntries <= 0 {
+		return nil, fmt.Errorf("RESPONSE_CACHE_MAX_ENTRIES must be positive: %d", cfg.ResponseCache.MaxEntries)
+	}
+	for route, rule := range cfg.ResponseCache.Routes {
+		if method, path, ok := strings.Cut(route, " "); !ok || method != "GET" || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("RESPONSE_CACHE_ROUTES entries must be GET route patterns such as GET /api/v1/announcements: %s", route)
+		}
+		if rule.TTL <= 0 || rule.Stale < 0 {
+			return nil, fmt.Errorf("RESPONSE_CACHE_ROUTE_%s_TTL must be positive and _STALE not negative", envKey(route))
+		}
+		for _, v := range rule.Vary {
+			if v != "user" && v != "tenant" && v != "query" {
+				return nil, fmt.Errorf("unsupported RESPONSE_CACHE_ROUTE_%s_VARY entry: %s", envKey(route), v)
+			}
+		}
+	}
+	if cfg.KillSwitches.RefreshInterval <= 0 {
+		return nil, fmt.Errorf("KILL_SWITCH_REFRESH_INTERVAL must be positive: %s", cfg.KillSwitches.RefreshInterval)
+	}
+	if cfg.ReadOnly.RefreshInterval <= 0 {
+		return nil, fmt.Errorf("READ_ONLY_REFRESH_INTERVAL must be positive: %s", cfg.ReadOnly.RefreshInterval)
+	}
+	if cfg.ReadOnly.Enabled && strings.TrimSpace(cfg.ReadOnly.Reason) == "" {
+		return nil, fmt.Errorf("READ_ONLY_REASON is required with READ_ONLY")
+	}
+
+	for _, exporter := range cfg.Telemetry.MetricsExporters {
+		if exporter != "otlp" && exporter != "prometheus" && exporter != "none" {
+			return nil, fmt.Errorf("unsupported OTEL_METRICS_EXPORTER entry: %s", exporter)
+		}
+	}
+	for _, detector := range cfg.Telemetry.ResourceDetectors {
+		if detector != "host" && detector != "container" && detector != "k8s" && detector != "cloud" {
+			return nil, fmt.Errorf("unsupported TELEMETRY_RESOURCE_DETECTORS entry: %s", detector)
+		}
+	}
+	if cfg.Telemetry.PushgatewayURL != "" {
+		if u, err := url.Parse(cfg.Telemetry.PushgatewayURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("PROMETHEUS_PUSHGATEWAY_URL must be an http or https URL: %s", cfg.Telemetry.PushgatewayURL)
+		}
+	}
+
+	for _, lane := range cfg.Jobs.Lanes {
+		if lane != "high" && lane != "default" && lane != "low" {
+			return nil, fmt.Errorf("unsupported JOBS_LANES entry: %s", lane)
+		}
+	}
+
+	if cfg.Leader.ID == "" {
+		host, _ := os.Hostname()
+		cfg.Leader.ID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+
+	if cfg.Cluster.Backend != "postgres" && cfg.Cluster.Backend != "redis" {
+		return nil, fmt.Errorf("unsupported CLUSTER_BACKEND: %s", cfg.Cluster.Backend)
+	}
+	if cfg.Cluster.HeartbeatInterval <= 0 {
+		return nil, fmt.Errorf("CLUSTER_HEARTBEAT_INTERVAL must be positive: %s", cfg.Cluster.HeartbeatInterval)
+	}
+	if cfg.Cluster.HeartbeatTTL <= cfg.Cluster.HeartbeatInterval {
+		return nil, errors.New("CLUSTER_HEARTBEAT_TTL must be longer than CLUSTER_HEARTBEAT_INTERVAL")
+	}
+
+	if sunset := getEnv("LEGACY_API_SUNSET", ""); sunset != "" {
+		t, err := time.Parse(time.DateOnly, sunset)
+		if err != nil {
+			return nil, fmt.Errorf("LEGACY_API_SUNSET must be a date such as 2027-06-30: %s", sunset)
+		}
+		cfg.LegacyAPI.Sunset = t
+	}
+
+	if cfg.Probe.BaseURL == "" {
+		cfg.Probe.BaseURL = cfg.Server.LocalURL()
 	}
 
+	if env == EnvProd {
+		if err := cfg.validateProduction(); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.settings = settings(preset)
 	return cfg, nil
 }
 
-// DSN returns the PostgreSQL connection string
+// validateProduction rejects settings that are only acceptable for local
+// development
+func (c *Config) validateProduction() error {
+	var errs []error
+	if c.Service.LogFormat != "json" {
+		errs = append(errs, errors.New("LOG_FORMAT must be json"))
+	}
+	if c.Database.SSLMode == "disable" {
+		errs = append(errs, errors.New("DB_SSLMODE must not be disable"))
+	}
+	if c.Database.Auth == "password" && (c.Database.Password == "" || c.Database.Password == "postgres") {
+		errs = append(errs, errors.New("DB_PASSWORD must be set to a non-default value"))
+	}
+	for prefix, policy := range map[string]CORSPolicy{"CORS_API": c.CORS.API, "CORS_ADMIN": c.CORS.Admin} {
+		for _, origin := range policy.AllowedOrigins {
+			if origin == "*" {
+				errs = append(errs, fmt.Errorf("%s_ALLOWED_ORIGINS must list explicit origins", prefix))
+				break
+			}
+		}
+	}
+	if c.Refresh.Enabled {
+		errs = append(errs, errors.New("STAGING_REFRESH_ENABLED must not be set in prod"))
+	}
+	if c.Telemetry.SampleRatio < 0 || c.Telemetry.SampleRatio > 1 {
+		errs = append(errs, errors.New("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid production configuration: %w", err)
+	}
+	return nil
+}
+
+// MissingSecrets lists the environment variables holding secrets that the
+// enabled features need but are empty, sorted
+func (c *Config) MissingSecrets() []string {
+	var missing []string
+	need := func(name, value string) {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if c.Database.Auth == "password" {
+		need("DB_PASSWORD", c.Database.Password)
+	}
+	if c.SMS.Provider == "twilio" {
+		need("SMS_TWILIO_AUTH_TOKEN", c.SMS.TwilioAuthToken)
+	}
+	for _, provider := range c.Email.Providers {
+		switch provider {
+		case "smtp":
+			if c.Email.SMTPUsername != "" {
+				need("EMAIL_SMTP_PASSWORD", c.Email.SMTPPassword)
+			}
+		case "postmark", "sendgrid", "ses":
+			need("EMAIL_WEBHOOK_SECRET", c.Email.WebhookSecret)
+		}
+	}
+	for name, conn := range c.Connectors.Providers {
+		need("CONNECTOR_"+strings.ToUpper(name)+"_TOKEN", conn.Token)
+	}
+	for id, client := range c.Auth.SigningClients {
+		need("AUTH_SIGNING_CLIENT_"+envKey(id)+"_SECRET", client.Secret)
+	}
+	if c.Push.VAPIDPublicKey != "" {
+		need("PUSH_VAPID_PRIVATE_KEY", c.Push.VAPIDPrivateKey)
+	}
+	if c.MagicLinks.Enabled {
+		need("MAGIC_LINK_SECRET", c.MagicLinks.Secret)
+	}
+	if c.SIEM.Enabled && c.SIEM.Sink == "https" {
+		need("SIEM_HTTP_TOKEN", c.SIEM.HTTPToken)
+	}
+	if c.Probe.Enabled && c.Auth.Enabled {
+		need("PROBE_TOKEN", c.Probe.Token)
+	}
+	slices.Sort(missing)
+	return slices.Compact(missing)
+}
+
+// DSN returns the PostgreSQL connection string. Certificate file paths are
+// included so the DSN also works with external tools; inline PEM values can
+// only be used by database.Connect.
 func (c DatabaseConfig) DSN() string {
-	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		c.Host, c.Port, c.User, c.Password, c.Database, c.SSLMode)
+	if c.SSLRootCert != "" {
+		dsn += " sslrootcert=" + c.SSLRootCert
+	}
+	if c.SSLCert != "" {
+		dsn += " sslcert=" + c.SSLCert
+	}
+	if c.SSLKey != "" {
+		dsn += " sslkey=" + c.SSLKey
+	}
+	if c.TargetSessionAttrs != "" {
+		dsn += " target_session_attrs=" + c.TargetSessionAttrs
+	} else if strings.Contains(c.Host, ",") {
+		dsn += " target_session_attrs=read-write"
+	}
+	return dsn
+}
+
+// LocalURL returns the URL this server is reachable at from the same host
+func (c ServerConfig) LocalURL() string {
+	scheme := "http"
+	if c.TLSCertFile != "" && c.TLSKeyFile != "" {
+		scheme = "https"
+	}
+	host, port, err := net.SplitHostPort(c.Address)
+	if err != nil {
+		return scheme + "://" + c.Address
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+// loadCORSPolicy reads a CORS policy from variables sharing the given prefix
+func loadCORSPolicy(prefix string, defaultOrigins []string) CORSPolicy {
+	return CORSPolicy{
+		AllowedOrigins:      getListEnv(prefix+"_ALLOWED_ORIGINS", defaultOrigins),
+		AllowedMethods:      getListEnv(prefix+"_ALLOWED_METHODS", []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+		AllowedHeaders:      getListEnv(prefix+"_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "X-User-Email", "X-Request-ID", "X-HTTP-Method-Override", "X-Locale", "X-Currency", "X-Measurement-System", "X-Session-ID", "X-API-Key", "X-Signature-Key-Id", "X-Signature-Timestamp", "X-Signature-Nonce", "X-Signature", "X-Request-Nonce", "X-Request-Timestamp", "X-Debug-Trace", "X-Dry-Run", "Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata", "Range", "If-Range"}),
+		ExposedHeaders:      getListEnv(prefix+"_EXPOSED_HEADERS", []string{"X-Request-ID", "Location", "Retry-After", "X-RateLimit-Limit", "X-Quota-Limit", "X-Quota-Remaining", "X-Query-Cost", "X-Query-Budget-Limit", "X-Query-Budget-Remaining", "X-Trace-ID", "X-Dry-Run", "X-Cache", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Offset", "Upload-Length", "Upload-Expires", "Accept-Ranges", "Content-Range", "ETag"}),
+		AllowCredentials:    getBoolEnv(prefix+"_ALLOW_CREDENTIALS", false),
+		AllowPrivateNetwork: getBoolEnv(prefix+"_ALLOW_PRIVATE_NETWORK", false),
+		MaxAge:              getDuration(prefix+"_MAX_AGE", time.Hour),
+	}
+}
+
+// loadConnectors reads CONNECTOR_<NAME>_* variables for each named connector
+func loadConnectors(names []string) map[string]ConnectorConfig {
+	providers := make(map[string]ConnectorConfig, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		prefix := "CONNECTOR_" + strings.ToUpper(name)
+		providers[name] = ConnectorConfig{
+			BaseURL:      getEnv(prefix+"_BASE_URL", ""),
+			Token:        getEnv(prefix+"_TOKEN", ""),
+			Timeout:      getDuration(prefix+"_TIMEOUT", 10*time.Second),
+			RateLimit:    getFloatEnv(prefix+"_RATE_LIMIT", 0),
+			Burst:        getIntEnv(prefix+"_BURST", 1),
+			MaxRetries:   getIntEnv(prefix+"_MAX_RETRIES", 3),
+			RetryBackoff: getDuration(prefix+"_RETRY_BACKOFF", 500*time.Millisecond),
+		}
+	}
+	return providers
+}
+
+// loadGeoBlockTenants reads each tenant's rules from
+// GEOBLOCK_TENANT_<ID>_*, where ID is the tenant ID upper-cased with
+// characters other than letters and digits replaced by underscores
+func loadGeoBlockTenants(ids []string) map[string]GeoBlockRules {
+	tenants := make(map[string]GeoBlockRules, len(ids))
+	for _, id := range ids {
+		prefix := "GEOBLOCK_TENANT_" + envKey(id)
+		tenants[id] = GeoBlockRules{
+			Allow: getListEnv(prefix+"_ALLOW", nil),
+			Deny:  getListEnv(prefix+"_DENY", nil),
+		}
+	}
+	return tenants
+}
+
+// loadSigningClients reads each client's secret and identity from
+// AUTH_SIGNING_CLIENT_<ID>_*, with ID formed as for geo-block tenants
+func loadSigningClients(ids []string) map[string]SigningClient {
+	clients := make(map[string]SigningClient, len(ids))
+	for _, id := range ids {
+		prefix := "AUTH_SIGNING_CLIENT_" + envKey(id)
+		clients[id] = SigningClient{
+			Secret: getEnv(prefix+"_SECRET", ""),
+			Email:  getEnv(prefix+"_EMAIL", ""),
+			Scopes: getListEnv(prefix+"_SCOPES", nil),
+			Roles:  getListEnv(prefix+"_ROLES", nil),
+		}
+	}
+	return clients
+}
+
+// loadKillSwitches reads "route=reason" entries; the reason is optional
+func loadKillSwitches(entries []string) map[string]string {
+	routes := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		route, reason, _ := strings.Cut(entry, "=")
+		if reason = strings.TrimSpace(reason); reason == "" {
+			reason = "this endpoint is temporarily disabled"
+		}
+		routes[strings.TrimSpace(route)] = reason
+	}
+	return routes
+}
+
+// loadResponseCacheRules reads each route's rule from
+// RESPONSE_CACHE_ROUTE_<ROUTE>_*, with ROUTE formed as for geo-block
+// tenants: GET /api/v1/announcements becomes GET__API_V1_ANNOUNCEMENTS
+func loadResponseCacheRules(routes []string) map[string]ResponseCacheRule {
+	rules := make(map[string]ResponseCacheRule, len(routes))
+	for _, route := range routes {
+		prefix := "RESPONSE_CACHE_ROUTE_" + envKey(route)
+		rules[route] = ResponseCacheRule{
+			TTL:   getDuration(prefix+"_TTL", time.Minute),
+			Stale: getDuration(prefix+"_STALE", 0),
+			Vary:  getListEnv(prefix+"_VARY", nil),
+		}
+	}
+	return rules
+}
+
+// loadServiceClients reads each client's certificate identity and roles
+// from AUTH_MTLS_CLIENT_<ID>_*, with ID formed as for geo-block tenants
+func loadServiceClients(ids []string) map[string]ServiceClient {
+	clients := make(map[string]ServiceClient, len(ids))
+	for _, id := range ids {
+		prefix := "AUTH_MTLS_CLIENT_" + envKey(id)
+		clients[id] = ServiceClient{
+			Identity: getEnv(prefix+"_IDENTITY", ""),
+			Email:    getEnv(prefix+"_EMAIL", ""),
+			Scopes:   getListEnv(prefix+"_SCOPES", nil),
+			Roles:    getListEnv(prefix+"_ROLES", nil),
+		}
+	}
+	return clients
+}
+
+// loadFieldRules reads the grants and mask of each field from
+// AUTH_FIELD_RULE_<FIELD>_*, with User.email becoming USER_EMAIL
+func loadFieldRules(fields []string) map[string]FieldRule {
+	rules := make(map[string]FieldRule, len(fields))
+	for _, field := range fields {
+		prefix := "AUTH_FIELD_RULE_" + envKey(field)
+		rules[field] = FieldRule{
+			Grants: getListEnv(prefix+"_GRANTS", nil),
+			Mask:   getEnv(prefix+"_MASK", ""),
+		}
+	}
+	return rules
+}
+
+// parseTrustedProxies reads TRUSTED_PROXIES entries, which are CIDRs or
+// single addresses
+func parseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES entry %q is not a CIDR or address", entry)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// envKey upper-cases an ID and replaces characters other than letters and
+// digits with underscores, for use in environment variable names
+func envKey(id string) string {
+	return strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(id))
+}
+
+// validHeaderName reports whether name is an HTTP field name: a non-empty
+// token of letters, digits, and the symbols RFC 9110 allows
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && !('0' <= r && r <= '9') && !strings.ContainsRune("!#$%&'*+-.^_`|~", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// validChallengeLabel reports whether label is a DNS label for a
+// verification record: an underscore, then letters, digits, and hyphens
+func validChallengeLabel(label string) bool {
+	if len(label) < 2 || len(label) > 63 || label[0] != '_' {
+		return false
+	}
+	for _, r := range label[1:] {
+		if !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && !('0' <= r && r <= '9') && r != '-' {
+			return false
+		}
+	}
+	return true
 }
 
 // Helper functions
 
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getIntEnv(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		var intValue int
 		if _, err := fmt.Sscanf(value, "%d", &intValue); err == nil {
 			return intValue
@@ -119,15 +2363,37 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := lookupEnv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getBoolEnv(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return value == "true" || value == "1" || value == "yes"
 	}
 	return defaultValue
 }
 
+func getListEnv(key string, defaultValue []string) []string {
+	if value := lookupEnv(key); value != "" {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return defaultValue
+}
+
 func getDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
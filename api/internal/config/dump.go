@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// masked replaces secret values in dumps
+const masked = "********"
+
+// Sources of a setting's value
+const (
+	SourceEnv     = "env"
+	SourceDotenv  = ".env"
+	SourceSSM     = "ssm"
+	SourceGCPSM   = "gcpsm"
+	SourceDefault = "default"
+)
+
+// Setting is an environment variable Load consulted and where its value
+// came from. Value is masked for secrets and empty when the default won.
+type Setting struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Value  string `json:"value,omitempty"`
+}
+
+// Dump is the effective configuration with secrets masked: every field
+// with defaults applied, and the variables that were read to get there
+type Dump struct {
+	Config   any       `json:"config"`
+	Settings []Setting `json:"settings"`
+}
+
+// Dump returns the configuration for operators to inspect. Fields named
+// like credentials, values fetched from a secret store, and URL passwords
+// are masked.
+func (c *Config) Dump() Dump {
+	return Dump{Config: dumpValue(reflect.ValueOf(*c)), Settings: c.settings}
+}
+
+// settings reports where each consulted variable's value came from. preset
+// holds the variables set before .env was read.
+func settings(preset map[string]bool) []Setting {
+	secretSources.mu.Lock()
+	defer secretSources.mu.Unlock()
+
+	list := make([]Setting, 0, len(secretSources.consulted))
+	for _, key := range secretSources.consulted {
+		raw := os.Getenv(key)
+		setting := Setting{Name: key}
+		switch {
+		case raw == "":
+			setting.Source = SourceDefault
+		case strings.HasPrefix(raw, ssmPrefix):
+			setting.Source, setting.Value = SourceSSM, masked
+		case strings.HasPrefix(raw, gcpsmPrefix):
+			setting.Source, setting.Value = SourceGCPSM, masked
+		default:
+			setting.Source = SourceEnv
+			if !preset[key] {
+				setting.Source = SourceDotenv
+			}
+			setting.Value = maskString(raw, secretName(strings.ReplaceAll(key, "_", "")))
+		}
+		list = append(list, setting)
+	}
+	return list
+}
+
+// secretName reports whether a field or variable name, without
+// separators, holds a credential
+func secretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, suffix := range []string{"password", "secret", "token", "dsn", "apikey", "privatekey", "keypem"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskString hides a secret, or the password of a URL with credentials
+func maskString(s string, secret bool) string {
+	if s == "" {
+		return ""
+	}
+	if secret {
+		return masked
+	}
+	if u, err := url.Parse(s); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			// Rebuilt by hand, as url.URL would escape the mask
+			scheme, rest, _ := strings.Cut(s, "://")
+			end := strings.IndexAny(rest, "/?#")
+			if end < 0 {
+				end = len(rest)
+			}
+			at := strings.LastIndex(rest[:end], "@")
+			return scheme + "://" + u.User.Username() + ":" + masked + rest[at:]
+		}
+	}
+	return s
+}
+
+// dumpValue converts a configuration value to JSON-ready maps, slices, and
+// scalars, masking secret fields
+func dumpValue(v reflect.Value) any {
+	switch value := v.Interface().(type) {
+	case time.Duration:
+		return value.String()
+	case fmt.Stringer:
+		return value.String()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return maskString(v.String(), false)
+	case reflect.Struct:
+		fields := make(map[string]any, v.NumField())
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Type.Kind() == reflect.String && secretName(field.Name) {
+				fields[field.Name] = maskString(v.Field(i).String(), true)
+				continue
+			}
+			fields[field.Name] = dumpValue(v.Field(i))
+		}
+		return fields
+	case reflect.Slice, reflect.Array:
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = dumpValue(v.Index(i))
+		}
+		return items
+	case reflect.Map:
+		entries := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			entries[fmt.Sprint(iter.Key().Interface())] = dumpValue(iter.Value())
+		}
+		return entries
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return dumpValue(v.Elem())
+	}
+	return v.Interface()
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"golang.org/x/oauth2/google"
+)
+
+// Environment values with these prefixes are references to a secret store
+// and are replaced with the stored value when configuration is loaded
+const (
+	ssmPrefix   = "ssm://"
+	gcpsmPrefix = "gcpsm://"
+)
+
+// resolveTimeout bounds how long startup waits on secret stores
+const resolveTimeout = 30 * time.Second
+
+// sources resolves secret references with credentials from the runtime's
+// IAM identity (ECS task role, IRSA, GKE workload identity) and caches
+// values for the life of the process so each reference is fetched once
+type sources struct {
+	mu       sync.Mutex
+	cache    map[string]string
+	resolved map[string]string
+	// consulted lists every variable looked up, in order, for Settings
+	consulted []string
+	seen      map[string]bool
+	ssm       *ssm.Client
+	gcp       *http.Client
+}
+
+var secretSources = &sources{
+	cache:    make(map[string]string),
+	resolved: make(map[string]string),
+	seen:     make(map[string]bool),
+}
+
+// lookupEnv returns the value of an environment variable, substituting the
+// resolved secret when the variable holds a reference
+func lookupEnv(key string) string {
+	secretSources.mu.Lock()
+	defer secretSources.mu.Unlock()
+
+	if !secretSources.seen[key] {
+		secretSources.seen[key] = true
+		secretSources.consulted = append(secretSources.consulted, key)
+	}
+	if value, ok := secretSources.resolved[key]; ok {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+// resolveSecretReferences fetches every referenced secret in the environment
+func resolveSecretReferences() error {
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	secretSources.mu.Lock()
+	defer secretSources.mu.Unlock()
+
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(value, ssmPrefix) && !strings.HasPrefix(value, gcpsmPrefix) {
+			continue
+		}
+
+		secret, err := secretSources.resolve(ctx, value)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", key, err)
+		}
+		secretSources.resolved[key] = secret
+	}
+	return nil
+}
+
+// resolve returns the secret behind a reference; callers must hold mu
+func (s *sources) resolve(ctx context.Context, ref string) (string, error) {
+	if value, ok := s.cache[ref]; ok {
+		return value, nil
+	}
+
+	var value string
+	var err error
+	switch {
+	case strings.HasPrefix(ref, ssmPrefix):
+		value, err = s.fetchSSM(ctx, strings.TrimPrefix(ref, ssmPrefix))
+	case strings.HasPrefix(ref, gcpsmPrefix):
+		value, err = s.fetchGCP(ctx, strings.TrimPrefix(ref, gcpsmPrefix))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s.cache[ref] = value
+	return value, nil
+}
+
+// fetchSSM reads a Parameter Store value, decrypting SecureString parameters
+func (s *sources) fetchSSM(ctx context.Context, name string) (string, error) {
+	// Hierarchical parameter names are rooted at "/"
+	if strings.Contains(name, "/") && !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+
+	if s.ssm == nil {
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to load AWS configuration: %w", err)
+		}
+		s.ssm = ssm.NewFromConfig(awsCfg)
+	}
+
+	out, err := s.ssm.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get SSM parameter %s: %w", name, err)
+	}
+	return aws.ToString(out.Parameter.Value), nil
+}
+
+// fetchGCP reads a Secret Manager version. The name is either a full
+// resource name (projects/p/secrets/s[/versions/v]) or a bare secret name
+// in GOOGLE_CLOUD_PROJECT; the latest version is used when none is given.
+func (s *sources) fetchGCP(ctx context.Context, name string) (string, error) {
+	if !strings.HasPrefix(name, "projects/") {
+		project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+		if project == "" {
+			return "", fmt.Errorf("GOOGLE_CLOUD_PROJECT is required for secret %s", name)
+		}
+		name = "projects/" + project + "/secrets/" + name
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	if s.gcp == nil {
+		client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/cloud-platform")
+		if err != nil {
+			return "", fmt.Errorf("failed to load Google credentials: %w", err)
+		}
+		s.gcp = client
+	}
+
+	endpoint := "https://secretmanager.googleapis.com/v1/" + (&url.URL{Path: name}).EscapedPath() + ":access"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.gcp.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to access secret %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("failed to access secret %s: status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode secret %s: %w", name, err)
+	}
+	data, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret %s: %w", name, err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,60 @@
+package connectors
+
+import (
+	"crypto/rand"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"starterkit/internal/platform/auth"
+)
+
+// Auth adds a provider's credentials to an outgoing request
+type Auth func(req *http.Request)
+
+// NoAuth sends requests without credentials, e.g. to signed webhook URLs
+func NoAuth() Auth {
+	return func(*http.Request) {}
+}
+
+// BearerToken sends token in the Authorization header
+func BearerToken(token string) Auth {
+	return func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// BasicAuth sends HTTP basic credentials
+func BasicAuth(username, password string) Auth {
+	return func(req *http.Request) {
+		req.SetBasicAuth(username, password)
+	}
+}
+
+// APIKeyHeader sends key in the named header
+func APIKeyHeader(header, key string) Auth {
+	return func(req *http.Request) {
+		req.Header.Set(header, key)
+	}
+}
+
+// Signature signs each request with secret the way signed requests to the
+// API are signed, so receivers can verify them as described for
+// auth.Sign. keyID is sent to tell the receiver which secret was used.
+func Signature(keyID, secret string) Auth {
+	return func(req *http.Request) {
+		var body []byte
+		if req.GetBody != nil {
+			if rc, err := req.GetBody(); err == nil {
+				body, _ = io.ReadAll(rc)
+				rc.Close()
+			}
+		}
+		timestamp, nonce := time.Now().Unix(), rand.Text()
+		req.Header.Set(auth.SignatureKeyIDHeader, keyID)
+		req.Header.Set(auth.SignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(auth.SignatureNonceHeader, nonce)
+		req.Header.Set(auth.SignatureHeader, auth.Sign(secret, req.Method, req.URL.RequestURI(), timestamp, nonce, body))
+	}
+}
@@ -0,0 +1,185 @@
+package connectors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+)
+
+// maxRetryWait caps how long a Retry-After header can delay a retry
+const maxRetryWait = time.Minute
+
+// Client sends requests to one provider with its auth, client-side rate
+// limit, retries, and request metrics applied
+type Client struct {
+	name       string
+	baseURL    string
+	auth       Auth
+	http       *http.Client
+	limiter    *rate.Limiter
+	maxRetries int
+	backoff    time.Duration
+	requests   metric.Int64Counter
+}
+
+// NewClient creates the transport for the named connector
+func NewClient(name string, cfg config.ConnectorConfig, auth Auth) *Client {
+	limit := rate.Inf
+	if cfg.RateLimit > 0 {
+		limit = rate.Limit(cfg.RateLimit)
+	}
+	if auth == nil {
+		auth = NoAuth()
+	}
+	return &Client{
+		name:       name,
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		auth:       auth,
+		http:       &http.Client{Timeout: cfg.Timeout},
+		limiter:    rate.NewLimiter(limit, max(cfg.Burst, 1)),
+		maxRetries: max(cfg.MaxRetries, 0),
+		backoff:    cfg.RetryBackoff,
+		requests:   metrics.Int64Counter(metrics.ConnectorRequests),
+	}
+}
+
+// Name returns the connector name used in errors and metrics
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Do sends body as JSON to path under the base URL and decodes the response
+// into out when it is non-nil. Rate-limited and unavailable responses are
+// retried with exponential backoff, honouring Retry-After.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.Open(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", c.name, err)
+	}
+	return nil
+}
+
+// Open sends a request like Do but hands the successful response to the
+// caller, e.g. to read a stream. The caller must close the body. The
+// connector timeout covers reading the whole body.
+func (c *Client) Open(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to encode %s request: %w", c.name, err)
+		}
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		resp, wait, err := c.send(ctx, method, path, payload)
+		c.requests.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("connector", c.name),
+			attribute.String("outcome", outcome(err)),
+		))
+
+		var connErr *Error
+		if err == nil || attempt >= c.maxRetries || !errors.As(err, &connErr) || !connErr.Retryable() {
+			return resp, err
+		}
+		if wait == 0 {
+			wait = backoff
+			backoff *= 2
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// send makes one attempt. A failure also returns how long the provider
+// asked to wait before retrying.
+func (c *Client) send(ctx context.Context, method, path string, payload []byte) (*http.Response, time.Duration, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create %s request: %w", c.name, err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	c.auth(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		return nil, 0, &Error{Connector: c.name, Message: err.Error(), Kind: ErrUnavailable}
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, retryAfter(resp), &Error{
+			Connector:  c.name,
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(data)),
+			Kind:       kindForStatus(resp.StatusCode),
+		}
+	}
+	return resp, 0, nil
+}
+
+func kindForStatus(status int) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrUnauthorized
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status >= 500 || status == http.StatusRequestTimeout:
+		return ErrUnavailable
+	default:
+		return ErrInvalidRequest
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return min(time.Duration(seconds)*time.Second, maxRetryWait)
+}
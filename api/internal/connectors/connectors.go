@@ -0,0 +1,70 @@
+package connectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Kinds of connector failure, matched with errors.Is
+var (
+	// ErrUnauthorized means the provider rejected the credentials
+	ErrUnauthorized = errors.New("credentials were rejected")
+	// ErrRateLimited means the provider throttled the request
+	ErrRateLimited = errors.New("rate limit exceeded")
+	// ErrUnavailable means the provider could not be reached or failed
+	ErrUnavailable = errors.New("provider unavailable")
+	// ErrInvalidRequest means the provider refused the request as sent
+	ErrInvalidRequest = errors.New("request rejected")
+	// ErrNotConfigured means the connector is missing from CONNECTORS
+	ErrNotConfigured = errors.New("connector is not configured")
+)
+
+// Connector is an integration with an external API. Integrations embed a
+// *Client for the transport and add typed methods for the provider's API.
+type Connector interface {
+	Name() string
+	// Check verifies the provider is reachable and accepts the credentials
+	Check(ctx context.Context) error
+}
+
+// Error is a failed call to a provider. It unwraps to one of the kinds
+// above.
+type Error struct {
+	Connector  string
+	StatusCode int
+	Message    string
+	Kind       error
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("%s: %v: %s", e.Connector, e.Kind, e.Message)
+	}
+	return fmt.Sprintf("%s returned status %d: %v: %s", e.Connector, e.StatusCode, e.Kind, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Retryable reports whether the same request may succeed later
+func (e *Error) Retryable() bool {
+	return e.Kind == ErrRateLimited || e.Kind == ErrUnavailable
+}
+
+// outcome is the metric label for the result of a request
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrInvalidRequest):
+		return "invalid_request"
+	default:
+		return "unavailable"
+	}
+}
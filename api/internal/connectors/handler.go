@@ -0,0 +1,38 @@
+package connectors
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+type Handler struct {
+	registry *Registry
+	logger   *slog.Logger
+}
+
+func NewHandler(registry *Registry, logger *slog.Logger) *Handler {
+	return &Handler{
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// HandleHealth returns the last health check of every connector. Pass
+// ?refresh=true to check them now.
+func (h *Handler) HandleHealth() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("refresh") == "true" {
+			_ = h.registry.CheckAll(r.Context())
+		}
+		h.respondWithJSON(w, http.StatusOK, map[string]any{"connectors": h.registry.Statuses()})
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
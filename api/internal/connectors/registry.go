@@ -0,0 +1,125 @@
+package connectors
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// checkTimeout bounds each connector's health check
+const checkTimeout = 10 * time.Second
+
+// Status is the result of a connector's last health check
+type Status struct {
+	Name      string    `json:"name"`
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Registry holds the enabled connectors and their health
+type Registry struct {
+	mu         sync.RWMutex
+	connectors map[string]Connector
+	statuses   map[string]Status
+	up         metric.Int64Gauge
+}
+
+// NewRegistry creates an empty registry; integrations register themselves
+// when their connector is configured
+func NewRegistry() *Registry {
+	return &Registry{
+		connectors: make(map[string]Connector),
+		statuses:   make(map[string]Status),
+		up:         metrics.Int64Gauge(metrics.ConnectorUp),
+	}
+}
+
+// Register adds a connector, replacing any with the same name
+func (r *Registry) Register(c Connector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connectors[c.Name()] = c
+}
+
+// Get returns the named connector
+func (r *Registry) Get(name string) (Connector, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.connectors[name]
+	return c, ok
+}
+
+// CheckAll runs every connector's health check concurrently and records
+// the results. A failing check is reported, not returned, so one broken
+// provider does not fail the job.
+func (r *Registry) CheckAll(ctx context.Context) error {
+	r.mu.RLock()
+	connectors := make([]Connector, 0, len(r.connectors))
+	for _, c := range r.connectors {
+		connectors = append(connectors, c)
+	}
+	r.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, c := range connectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.check(ctx, c)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (r *Registry) check(ctx context.Context, c Connector) {
+	checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Check(checkCtx)
+	status := Status{
+		Name:      c.Name(),
+		Healthy:   err == nil,
+		LatencyMS: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	var up int64
+	if err == nil {
+		up = 1
+	} else {
+		status.Error = err.Error()
+		logger.FromContext(ctx).Warn("connector health check failed", "connector", c.Name(), "error", err)
+	}
+	r.up.Record(ctx, up, metric.WithAttributes(attribute.String("connector", c.Name())))
+
+	r.mu.Lock()
+	r.statuses[c.Name()] = status
+	r.mu.Unlock()
+}
+
+// Statuses returns the last health check of every connector by name. A
+// connector that has not been checked yet is reported as unhealthy.
+func (r *Registry) Statuses() []Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	statuses := make([]Status, 0, len(r.connectors))
+	for name := range r.connectors {
+		status, ok := r.statuses[name]
+		if !ok {
+			status = Status{Name: name, Error: "not checked yet"}
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
@@ -0,0 +1,72 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/platform/panics"
+)
+
+// PanicReporter passes recovered panics to next and posts them to Slack,
+// at most once per subsystem per throttle interval
+type PanicReporter struct {
+	next     panics.Reporter
+	notifier *Notifier
+}
+
+func NewPanicReporter(next panics.Reporter, notifier *Notifier) *PanicReporter {
+	return &PanicReporter{next: next, notifier: notifier}
+}
+
+func (r *PanicReporter) Report(ctx context.Context, p *panics.Panic) {
+	r.next.Report(ctx, p)
+	r.notifier.NotifyAsync(ctx, EventPanic, p.Subsystem, map[string]any{
+		"Subsystem": p.Subsystem,
+		"Value":     trimValue(fmt.Sprint(p.Value)),
+	})
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// AuditNotifier records audit entries with next and posts the configured
+// admin actions to Slack once they are recorded
+type AuditNotifier struct {
+	next     Auditor
+	notifier *Notifier
+	actions  map[string]bool
+}
+
+func NewAuditNotifier(next Auditor, notifier *Notifier, actions []string) *AuditNotifier {
+	a := &AuditNotifier{
+		next:     next,
+		notifier: notifier,
+		actions:  make(map[string]bool, len(actions)),
+	}
+	for _, action := range actions {
+		a.actions[action] = true
+	}
+	return a
+}
+
+func (a *AuditNotifier) Record(ctx context.Context, entry audit.Entry) error {
+	if err := a.next.Record(ctx, entry); err != nil {
+		return err
+	}
+	if a.actions[entry.Action] {
+		actor := entry.Actor
+		if actor == "" {
+			actor = audit.SystemActor
+		}
+		a.notifier.NotifyAsync(ctx, EventAudit, "", map[string]any{
+			"Action":       entry.Action,
+			"Actor":        actor,
+			"ResourceType": entry.ResourceType,
+			"ResourceID":   entry.ResourceID,
+			"Metadata":     entry.Metadata,
+		})
+	}
+	return nil
+}
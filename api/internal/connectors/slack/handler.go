@@ -0,0 +1,63 @@
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/connectors"
+	"starterkit/internal/platform/apierror"
+)
+
+type Handler struct {
+	notifier *Notifier
+	logger   *slog.Logger
+}
+
+// NewHandler creates the admin handler; notifier is nil when Slack is not
+// configured
+func NewHandler(notifier *Notifier, logger *slog.Logger) *Handler {
+	return &Handler{
+		notifier: notifier,
+		logger:   logger,
+	}
+}
+
+// HandleTest posts a test message so operators can verify the integration
+func (h *Handler) HandleTest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.notifier == nil {
+			apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, "slack_not_configured", "slack is not configured"))
+			return
+		}
+
+		actor := actorFromRequest(r)
+		if actor == "" {
+			actor = "unknown"
+		}
+		if err := h.notifier.Notify(r.Context(), EventTest, "", map[string]any{"Actor": actor}); err != nil {
+			var connErr *connectors.Error
+			if errors.As(err, &connErr) {
+				apierror.Write(w, r, apierror.New(http.StatusBadGateway, "slack_unavailable", connErr.Error()))
+				return
+			}
+			h.logger.Error("failed to send slack test message", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
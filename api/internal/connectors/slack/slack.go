@@ -0,0 +1,205 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+)
+
+// Name is the connector name configured in CONNECTORS
+const Name = "slack"
+
+const apiBaseURL = "https://slack.com/api"
+
+// asyncTimeout bounds an alert posted in the background, including retries
+const asyncTimeout = 2 * time.Minute
+
+// Events with built-in message templates
+const (
+	EventPanic = "panic"
+	EventSLO   = "slo"
+	EventAudit = "audit"
+	EventTest  = "test"
+)
+
+var defaultTemplates = map[string]string{
+	EventPanic: `:rotating_light: Panic recovered in *{{.Subsystem}}*: {{.Value}}`,
+	EventSLO: `{{if eq .Status "ok"}}:white_check_mark:{{else}}:warning:{{end}} Error budget for *{{.Route}}* is *{{.Status}}*` +
+		` (availability burn {{printf "%.1f" .AvailabilityBurnRate}}x, latency burn {{printf "%.1f" .LatencyBurnRate}}x over the last hour)`,
+	EventAudit: `:memo: *{{.Action}}* by {{.Actor}} on {{.ResourceType}} {{.ResourceID}}`,
+	EventTest:  `:wave: Test message sent by {{.Actor}}`,
+}
+
+// Notifier posts operational alerts to Slack through an incoming webhook
+// or, with a bot token, the chat.postMessage API. Repeats of an alert with
+// the same key are dropped until the throttle interval has passed.
+type Notifier struct {
+	client    *connectors.Client
+	bot       bool
+	channel   string
+	templates map[string]*template.Template
+	throttle  time.Duration
+	logger    *slog.Logger
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+// New creates a notifier from the slack connector settings
+func New(conn config.ConnectorConfig, cfg config.SlackConfig, logger *slog.Logger) (*Notifier, error) {
+	n := &Notifier{
+		bot:       conn.Token != "",
+		channel:   cfg.Channel,
+		templates: make(map[string]*template.Template, len(defaultTemplates)),
+		throttle:  cfg.Throttle,
+		logger:    logger,
+		sent:      make(map[string]time.Time),
+	}
+
+	auth := connectors.NoAuth()
+	if n.bot {
+		if cfg.Channel == "" {
+			return nil, fmt.Errorf("slack bot token requires SLACK_CHANNEL")
+		}
+		if conn.BaseURL == "" {
+			conn.BaseURL = apiBaseURL
+		}
+		auth = connectors.BearerToken(conn.Token)
+	} else if conn.BaseURL == "" {
+		return nil, fmt.Errorf("slack requires a webhook URL or a bot token")
+	}
+	n.client = connectors.NewClient(Name, conn, auth)
+
+	for event, text := range defaultTemplates {
+		if override := cfg.Templates[event]; override != "" {
+			text = override
+		}
+		tmpl, err := template.New(event).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse slack %s template: %w", event, err)
+		}
+		n.templates[event] = tmpl
+	}
+	return n, nil
+}
+
+// Name implements connectors.Connector
+func (n *Notifier) Name() string {
+	return Name
+}
+
+// Check verifies the bot token. Webhooks cannot be checked without posting,
+// so they always pass.
+func (n *Notifier) Check(ctx context.Context) error {
+	if !n.bot {
+		return nil
+	}
+	var resp apiResponse
+	if err := n.client.Do(ctx, http.MethodPost, "/auth.test", nil, &resp); err != nil {
+		return err
+	}
+	return resp.err()
+}
+
+// Notify renders the event's template with data and posts it. A non-empty
+// key throttles repeats: the alert is dropped if the same key was posted
+// within the throttle interval.
+func (n *Notifier) Notify(ctx context.Context, event, key string, data any) error {
+	tmpl, ok := n.templates[event]
+	if !ok {
+		return fmt.Errorf("unknown slack event: %s", event)
+	}
+	if key != "" && !n.claim(event+":"+key) {
+		return nil
+	}
+
+	var text bytes.Buffer
+	if err := tmpl.Execute(&text, data); err != nil {
+		return fmt.Errorf("failed to render slack %s message: %w", event, err)
+	}
+	return n.post(ctx, text.String())
+}
+
+// NotifyAsync posts in the background so callers on the request path or
+// in a panic handler are not held up by Slack
+func (n *Notifier) NotifyAsync(ctx context.Context, event, key string, data any) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), asyncTimeout)
+	go func() {
+		defer cancel()
+		if err := n.Notify(ctx, event, key, data); err != nil {
+			n.logger.Warn("failed to post slack alert", "event", event, "error", err)
+		}
+	}()
+}
+
+// claim reports whether key may be posted now and records it if so
+func (n *Notifier) claim(key string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := time.Now()
+	if last, ok := n.sent[key]; ok && now.Sub(last) < n.throttle {
+		return false
+	}
+	n.sent[key] = now
+	// Forget expired keys so the map does not grow without bound
+	for k, at := range n.sent {
+		if now.Sub(at) >= n.throttle {
+			delete(n.sent, k)
+		}
+	}
+	return true
+}
+
+func (n *Notifier) post(ctx context.Context, text string) error {
+	if !n.bot {
+		return n.client.Do(ctx, http.MethodPost, "", map[string]string{"text": text}, nil)
+	}
+	var resp apiResponse
+	if err := n.client.Do(ctx, http.MethodPost, "/chat.postMessage", map[string]string{
+		"channel": n.channel,
+		"text":    text,
+	}, &resp); err != nil {
+		return err
+	}
+	return resp.err()
+}
+
+// apiResponse is the envelope of Web API responses, which report failures
+// with status 200 and ok set to false
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func (r apiResponse) err() error {
+	if r.OK {
+		return nil
+	}
+	kind := connectors.ErrInvalidRequest
+	switch r.Error {
+	case "not_authed", "invalid_auth", "account_inactive", "token_revoked", "token_expired", "missing_scope":
+		kind = connectors.ErrUnauthorized
+	case "ratelimited":
+		kind = connectors.ErrRateLimited
+	}
+	return &connectors.Error{Connector: Name, StatusCode: http.StatusOK, Message: r.Error, Kind: kind}
+}
+
+// trimValue shortens panic values and errors for a chat message
+func trimValue(v string) string {
+	const limit = 500
+	v = strings.TrimSpace(v)
+	if len(v) > limit {
+		return v[:limit] + "…"
+	}
+	return v
+}
@@ -0,0 +1,168 @@
+// Package statushooks pings uptime monitors and status pages when a
+// replica starts, stops, flips readiness, or runs a maintenance task, so
+// they can tell planned restarts and maintenance from outages
+package statushooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+	"starterkit/internal/platform/health"
+)
+
+// Events pinged
+const (
+	EventStarted             = "started"
+	EventStopping            = "stopping"
+	EventReady               = "ready"
+	EventUnready             = "unready"
+	EventMaintenanceStarted  = "maintenance.started"
+	EventMaintenanceFinished = "maintenance.finished"
+)
+
+// planned are the events operators cause, which monitors should not count
+// as outages
+var planned = map[string]bool{
+	EventStopping:            true,
+	EventMaintenanceStarted:  true,
+	EventMaintenanceFinished: true,
+}
+
+// asyncTimeout bounds a ping sent in the background, including retries
+const asyncTimeout = 30 * time.Second
+
+// Ping is the JSON body posted to each hook. Instance identifies the
+// replica or task process that sent it.
+type Ping struct {
+	Event       string         `json:"event"`
+	Planned     bool           `json:"planned"`
+	Service     string         `json:"service"`
+	Version     string         `json:"version"`
+	Environment string         `json:"environment"`
+	Instance    string         `json:"instance"`
+	Time        time.Time      `json:"time"`
+	Detail      map[string]any `json:"detail,omitempty"`
+}
+
+// Notifier posts pings to the connectors listed in STATUS_HOOKS, signed
+// with each connector's token
+type Notifier struct {
+	hooks  []*connectors.Client
+	source Ping
+	logger *slog.Logger
+	wg     sync.WaitGroup
+}
+
+// New creates a notifier for the configured hooks, or returns nil when
+// there are none
+func New(cfg *config.Config, logger *slog.Logger) (*Notifier, error) {
+	if len(cfg.StatusHooks.Connectors) == 0 {
+		return nil, nil
+	}
+	n := &Notifier{
+		source: Ping{
+			Service:     cfg.Service.Name,
+			Version:     cfg.Service.Version,
+			Environment: cfg.Service.Environment,
+			Instance:    cfg.Leader.ID,
+		},
+		logger: logger,
+	}
+	for _, name := range cfg.StatusHooks.Connectors {
+		name = strings.ToLower(name)
+		conn, ok := cfg.Connectors.Provider(name)
+		if !ok {
+			return nil, fmt.Errorf("status hook %s requires CONNECTORS to include %s", name, name)
+		}
+		if conn.BaseURL == "" || conn.Token == "" {
+			return nil, fmt.Errorf("status hook %s requires a base URL and a token to sign with", name)
+		}
+		n.hooks = append(n.hooks, connectors.NewClient(name, conn, connectors.Signature(cfg.Service.Name, conn.Token)))
+	}
+	return n, nil
+}
+
+// Send posts the event to every hook at once and returns their failures
+func (n *Notifier) Send(ctx context.Context, event string, detail map[string]any) error {
+	ping := n.source
+	ping.Event, ping.Planned, ping.Time, ping.Detail = event, planned[event], time.Now().UTC(), detail
+
+	errs := make([]error, len(n.hooks))
+	var wg sync.WaitGroup
+	for i, hook := range n.hooks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = hook.Do(ctx, http.MethodPost, "", ping, nil)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// SendAsync posts in the background so startup and draining are not held
+// up by the hooks. Wait blocks until the pings have gone out.
+func (n *Notifier) SendAsync(ctx context.Context, event string, detail map[string]any) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), asyncTimeout)
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		defer cancel()
+		if err := n.Send(ctx, event, detail); err != nil {
+			n.logger.Warn("failed to send status ping", "event", event, "error", err)
+		}
+	}()
+}
+
+// Started pings that the replica is up. It is meant as the OnStart of a
+// lifecycle hook.
+func (n *Notifier) Started(ctx context.Context) error {
+	n.SendAsync(ctx, EventStarted, nil)
+	return nil
+}
+
+// Wait blocks until the pings sent in the background have gone out or ctx
+// is done. It is meant as the OnStop of a lifecycle hook, so the stopping
+// ping is sent before the process exits.
+func (n *Notifier) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Observe pings a readiness change reported by the health registry.
+// Draining is pinged as stopping; an unready ping names the failed checks.
+func (n *Notifier) Observe(report health.Report) {
+	switch report.Status {
+	case health.StatusOK:
+		n.SendAsync(context.Background(), EventReady, nil)
+	case health.StatusDraining:
+		n.SendAsync(context.Background(), EventStopping, nil)
+	default:
+		failed := make([]string, 0, len(report.Checks))
+		for name, result := range report.Checks {
+			if result.Status != health.StatusOK {
+				failed = append(failed, name)
+			}
+		}
+		slices.Sort(failed)
+		n.SendAsync(context.Background(), EventUnready, map[string]any{"failed_checks": failed})
+	}
+}
@@ -8,11 +8,744 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type User struct {
+type AccountMerge struct {
+	ID           pgtype.UUID        `json:"id"`
+	SourceUserID pgtype.UUID        `json:"source_user_id"`
+	SourceEmail  string             `json:"source_email"`
+	TargetUserID pgtype.UUID        `json:"target_user_id"`
+	MergedBy     string             `json:"merged_by"`
+	Report       []byte             `json:"report"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type AiUsage struct {
+	UserEmail    string      `json:"user_email"`
+	Day          pgtype.Date `json:"day"`
+	Requests     int32       `json:"requests"`
+	InputTokens  int64       `json:"input_tokens"`
+	OutputTokens int64       `json:"output_tokens"`
+}
+
+type Announcement struct {
+	ID          pgtype.UUID        `json:"id"`
+	Title       string             `json:"title"`
+	Body        string             `json:"body"`
+	Link        string             `json:"link"`
+	CreatedBy   string             `json:"created_by"`
+	PublishedAt pgtype.Timestamptz `json:"published_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type ApiKey struct {
 	ID        pgtype.UUID        `json:"id"`
-	Email     string             `json:"email"`
 	Name      string             `json:"name"`
+	Prefix    string             `json:"prefix"`
+	KeyHash   string             `json:"key_hash"`
+	Plan      string             `json:"plan"`
+	CreatedBy string             `json:"created_by"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+}
+
+type ApiKeyUsage struct {
+	KeyID    pgtype.UUID `json:"key_id"`
+	Day      pgtype.Date `json:"day"`
+	Requests int64       `json:"requests"`
+}
+
+type ArchiveSegment struct {
+	ID         pgtype.UUID        `json:"id"`
+	TableName  string             `json:"table_name"`
+	ObjectKey  string             `json:"object_key"`
+	RangeStart pgtype.Timestamptz `json:"range_start"`
+	RangeEnd   pgtype.Timestamptz `json:"range_end"`
+	RowCount   int64              `json:"row_count"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type AuditExport struct {
+	ID                  pgtype.UUID        `json:"id"`
+	TenantID            string             `json:"tenant_id"`
+	Format              string             `json:"format"`
+	Frequency           string             `json:"frequency"`
+	Destination         string             `json:"destination"`
+	Target              string             `json:"target"`
+	SigningSecret       pgtype.Text        `json:"signing_secret"`
+	Paused              bool               `json:"paused"`
+	NextRunAt           pgtype.Timestamptz `json:"next_run_at"`
+	ExportedThrough     pgtype.Timestamptz `json:"exported_through"`
+	ExportedThroughID   pgtype.UUID        `json:"exported_through_id"`
+	ConsecutiveFailures int32              `json:"consecutive_failures"`
+	CreatedBy           string             `json:"created_by"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	ExportedThroughTxid int64              `json:"exported_through_txid"`
+}
+
+type AuditExportDelivery struct {
+	ID             pgtype.UUID        `json:"id"`
+	ExportID       pgtype.UUID        `json:"export_id"`
+	Status         string             `json:"status"`
+	EntryCount     int32              `json:"entry_count"`
+	PeriodStart    pgtype.Timestamptz `json:"period_start"`
+	PeriodEnd      pgtype.Timestamptz `json:"period_end"`
+	EmailMessageID pgtype.UUID        `json:"email_message_id"`
+	ResponseStatus pgtype.Int4        `json:"response_status"`
+	Error          pgtype.Text        `json:"error"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+type AuditForwarderCheckpoint struct {
+	Name          string             `json:"name"`
+	LastCreatedAt pgtype.Timestamptz `json:"last_created_at"`
+	LastID        pgtype.UUID        `json:"last_id"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	LastTxid      int64              `json:"last_txid"`
+}
+
+type AuditLog struct {
+	ID           pgtype.UUID        `json:"id"`
+	Actor        string             `json:"actor"`
+	Action       string             `json:"action"`
+	ResourceType string             `json:"resource_type"`
+	ResourceID   string             `json:"resource_id"`
+	Metadata     []byte             `json:"metadata"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	TenantID     pgtype.Text        `json:"tenant_id"`
+	Txid         int64              `json:"txid"`
+}
+
+type AuthSession struct {
+	ID          pgtype.UUID        `json:"id"`
+	UserID      pgtype.UUID        `json:"user_id"`
+	MagicLinkID pgtype.UUID        `json:"magic_link_id"`
+	TokenHash   string             `json:"token_hash"`
+	Device      string             `json:"device"`
+	IpAddress   pgtype.Text        `json:"ip_address"`
+	Country     pgtype.Text        `json:"country"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
+	Method      string             `json:"method"`
+}
+
+type Backup struct {
+	ID            pgtype.UUID        `json:"id"`
+	ObjectKey     string             `json:"object_key"`
+	SchemaVersion int64              `json:"schema_version"`
+	TableCount    int32              `json:"table_count"`
+	RowCount      int64              `json:"row_count"`
+	SizeBytes     int64              `json:"size_bytes"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+type BulkOperation struct {
+	ID         pgtype.UUID        `json:"id"`
+	Action     string             `json:"action"`
+	Role       pgtype.Text        `json:"role"`
+	Status     string             `json:"status"`
+	Total      int32              `json:"total"`
+	Succeeded  int32              `json:"succeeded"`
+	Skipped    int32              `json:"skipped"`
+	Failed     int32              `json:"failed"`
+	CreatedBy  string             `json:"created_by"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+	FinishedAt pgtype.Timestamptz `json:"finished_at"`
+}
+
+type BulkOperationItem struct {
+	OperationID pgtype.UUID        `json:"operation_id"`
+	Position    int32              `json:"position"`
+	UserID      pgtype.UUID        `json:"user_id"`
+	Status      string             `json:"status"`
+	Error       pgtype.Text        `json:"error"`
+	FinishedAt  pgtype.Timestamptz `json:"finished_at"`
+}
+
+type CalendarInvite struct {
+	ID          pgtype.UUID        `json:"id"`
+	Summary     string             `json:"summary"`
+	Description string             `json:"description"`
+	Location    string             `json:"location"`
+	StartsAt    pgtype.Timestamptz `json:"starts_at"`
+	EndsAt      pgtype.Timestamptz `json:"ends_at"`
+	Timezone    string             `json:"timezone"`
+	Recurrence  []byte             `json:"recurrence"`
+	Attendees   []string           `json:"attendees"`
+	Sequence    int32              `json:"sequence"`
+	Status      string             `json:"status"`
+	CreatedBy   string             `json:"created_by"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type EmailCampaign struct {
+	ID          pgtype.UUID        `json:"id"`
+	Name        string             `json:"name"`
+	TemplateKey string             `json:"template_key"`
+	Locale      string             `json:"locale"`
+	Data        []byte             `json:"data"`
+	Audience    []byte             `json:"audience"`
+	Status      string             `json:"status"`
+	Run         int32              `json:"run"`
+	Total       int32              `json:"total"`
+	Queued      int32              `json:"queued"`
+	Skipped     int32              `json:"skipped"`
+	Failed      int32              `json:"failed"`
+	CreatedBy   string             `json:"created_by"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	StartedAt   pgtype.Timestamptz `json:"started_at"`
+	FinishedAt  pgtype.Timestamptz `json:"finished_at"`
+}
+
+type EmailCampaignRecipient struct {
+	CampaignID pgtype.UUID        `json:"campaign_id"`
+	Position   int32              `json:"position"`
+	UserID     pgtype.UUID        `json:"user_id"`
+	Status     string             `json:"status"`
+	Error      pgtype.Text        `json:"error"`
+	FinishedAt pgtype.Timestamptz `json:"finished_at"`
+}
+
+type EmailChange struct {
+	UserID         pgtype.UUID        `json:"user_id"`
+	OldEmail       string             `json:"old_email"`
+	NewEmail       string             `json:"new_email"`
+	OldTokenHash   string             `json:"old_token_hash"`
+	NewTokenHash   string             `json:"new_token_hash"`
+	OldConfirmedAt pgtype.Timestamptz `json:"old_confirmed_at"`
+	NewConfirmedAt pgtype.Timestamptz `json:"new_confirmed_at"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+type EmailMessage struct {
+	ID                pgtype.UUID        `json:"id"`
+	UserID            pgtype.UUID        `json:"user_id"`
+	NotificationID    pgtype.UUID        `json:"notification_id"`
+	ToAddress         string             `json:"to_address"`
+	Subject           string             `json:"subject"`
+	Body              string             `json:"body"`
+	Status            string             `json:"status"`
+	ProviderMessageID pgtype.Text        `json:"provider_message_id"`
+	Attempts          int32              `json:"attempts"`
+	LastError         pgtype.Text        `json:"last_error"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	SentAt            pgtype.Timestamptz `json:"sent_at"`
+	HtmlBody          string             `json:"html_body"`
+	Attachments       []byte             `json:"attachments"`
+	CampaignID        pgtype.UUID        `json:"campaign_id"`
+	OpenedAt          pgtype.Timestamptz `json:"opened_at"`
+}
+
+type EmailSuppression struct {
+	Email     string             `json:"email"`
+	Reason    string             `json:"reason"`
+	Detail    string             `json:"detail"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type Job struct {
+	ID           pgtype.UUID        `json:"id"`
+	Kind         string             `json:"kind"`
+	Payload      []byte             `json:"payload"`
+	Status       string             `json:"status"`
+	Attempts     int32              `json:"attempts"`
+	MaxAttempts  int32              `json:"max_attempts"`
+	LastError    pgtype.Text        `json:"last_error"`
+	TraceContext []byte             `json:"trace_context"`
+	RequestID    pgtype.Text        `json:"request_id"`
+	RunAt        pgtype.Timestamptz `json:"run_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	FinishedAt   pgtype.Timestamptz `json:"finished_at"`
+	Priority     int16              `json:"priority"`
+}
+
+type JobEffect struct {
+	JobID       pgtype.UUID        `json:"job_id"`
+	Effect      string             `json:"effect"`
+	CompletedAt pgtype.Timestamptz `json:"completed_at"`
+}
+
+type JobUniqueKey struct {
+	UniqueKey string             `json:"unique_key"`
+	JobID     pgtype.UUID        `json:"job_id"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+type KillSwitch struct {
+	Route     string             `json:"route"`
+	Reason    string             `json:"reason"`
+	CreatedBy string             `json:"created_by"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type LeaderLease struct {
+	Name       string             `json:"name"`
+	Holder     string             `json:"holder"`
+	AcquiredAt pgtype.Timestamptz `json:"acquired_at"`
+	RenewedAt  pgtype.Timestamptz `json:"renewed_at"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+}
+
+type LoginFingerprint struct {
+	UserEmail   string             `json:"user_email"`
+	IpRange     string             `json:"ip_range"`
+	Device      string             `json:"device"`
+	Country     string             `json:"country"`
+	FirstSeenAt pgtype.Timestamptz `json:"first_seen_at"`
+	LastSeenAt  pgtype.Timestamptz `json:"last_seen_at"`
+}
+
+type MagicLink struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Email     string             `json:"email"`
+	TokenHash string             `json:"token_hash"`
+	DeviceKey string             `json:"device_key"`
+	Device    string             `json:"device"`
+	IpAddress pgtype.Text        `json:"ip_address"`
+	Country   pgtype.Text        `json:"country"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+}
+
+type MessageTemplate struct {
+	ID            pgtype.UUID        `json:"id"`
+	Key           string             `json:"key"`
+	Channel       string             `json:"channel"`
+	Locale        string             `json:"locale"`
+	Description   string             `json:"description"`
+	ActiveVersion int32              `json:"active_version"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+type MessageTemplateVersion struct {
+	TemplateID pgtype.UUID        `json:"template_id"`
+	Version    int32              `json:"version"`
+	Subject    pgtype.Text        `json:"subject"`
+	Body       string             `json:"body"`
+	Variables  []string           `json:"variables"`
+	CreatedBy  string             `json:"created_by"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type ModerationFlag struct {
+	ID           pgtype.UUID        `json:"id"`
+	ResourceType string             `json:"resource_type"`
+	ResourceID   string             `json:"resource_id"`
+	Field        string             `json:"field"`
+	Content      string             `json:"content"`
+	Reasons      []string           `json:"reasons"`
+	Actor        string             `json:"actor"`
+	Status       string             `json:"status"`
+	ReviewedBy   pgtype.Text        `json:"reviewed_by"`
+	ReviewNote   pgtype.Text        `json:"review_note"`
+	ReviewedAt   pgtype.Timestamptz `json:"reviewed_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type Notification struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Title     string             `json:"title"`
+	Body      string             `json:"body"`
+	Url       string             `json:"url"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type NotificationPreference struct {
+	UserID    pgtype.UUID        `json:"user_id"`
+	Channel   string             `json:"channel"`
+	Enabled   bool               `json:"enabled"`
 	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
+}
+
+type NotificationReadCursor struct {
+	UserID      pgtype.UUID        `json:"user_id"`
+	DeviceID    string             `json:"device_id"`
+	ReadThrough pgtype.Timestamptz `json:"read_through"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Operation struct {
+	ID        pgtype.UUID        `json:"id"`
+	Kind      string             `json:"kind"`
+	Owner     string             `json:"owner"`
+	Status    string             `json:"status"`
+	Result    []byte             `json:"result"`
+	Error     pgtype.Text        `json:"error"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	Payload   []byte             `json:"payload"`
+	UndoUntil pgtype.Timestamptz `json:"undo_until"`
+}
+
+type PhoneVerification struct {
+	UserID    pgtype.UUID        `json:"user_id"`
+	Phone     string             `json:"phone"`
+	CodeHash  string             `json:"code_hash"`
+	Attempts  int32              `json:"attempts"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type ProjectionCheckpoint struct {
+	Name      string             `json:"name"`
+	Position  int64              `json:"position"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	Txid      int64              `json:"txid"`
+}
+
+type PushSubscription struct {
+	ID         pgtype.UUID        `json:"id"`
+	UserID     pgtype.UUID        `json:"user_id"`
+	Endpoint   string             `json:"endpoint"`
+	P256dh     string             `json:"p256dh"`
+	Auth       string             `json:"auth"`
+	UserAgent  pgtype.Text        `json:"user_agent"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+}
+
+type RatePlan struct {
+	Name              string             `json:"name"`
+	RequestsPerMinute int32              `json:"requests_per_minute"`
+	Burst             int32              `json:"burst"`
+	DailyQuota        int64              `json:"daily_quota"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+}
+
+type ReadOnlyMode struct {
+	ID        bool               `json:"id"`
+	Reason    string             `json:"reason"`
+	EnabledBy string             `json:"enabled_by"`
+	EnabledAt pgtype.Timestamptz `json:"enabled_at"`
+}
+
+type RecurringJob struct {
+	Kind           string             `json:"kind"`
+	NextRunAt      pgtype.Timestamptz `json:"next_run_at"`
+	LastEnqueuedAt pgtype.Timestamptz `json:"last_enqueued_at"`
+}
+
+type ReplicaHeartbeat struct {
+	ID          string             `json:"id"`
+	Version     string             `json:"version"`
+	Ready       bool               `json:"ready"`
+	StartedAt   pgtype.Timestamptz `json:"started_at"`
+	HeartbeatAt pgtype.Timestamptz `json:"heartbeat_at"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+}
+
+type RequestNonce struct {
+	ClientID  string             `json:"client_id"`
+	Nonce     string             `json:"nonce"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+type ResumableUpload struct {
+	ID           pgtype.UUID        `json:"id"`
+	Owner        string             `json:"owner"`
+	Filename     string             `json:"filename"`
+	ContentType  string             `json:"content_type"`
+	UploadLength int64              `json:"upload_length"`
+	UploadOffset int64              `json:"upload_offset"`
+	ChunkKeys    []string           `json:"chunk_keys"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+}
+
+type RetentionPolicy struct {
+	ID            pgtype.UUID        `json:"id"`
+	Name          string             `json:"name"`
+	Target        string             `json:"target"`
+	Action        string             `json:"action"`
+	RetentionDays int32              `json:"retention_days"`
+	Enabled       bool               `json:"enabled"`
+	LastRunAt     pgtype.Timestamptz `json:"last_run_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+type RetentionRun struct {
+	ID           pgtype.UUID        `json:"id"`
+	PolicyID     pgtype.UUID        `json:"policy_id"`
+	DryRun       bool               `json:"dry_run"`
+	Cutoff       pgtype.Timestamptz `json:"cutoff"`
+	AffectedRows int64              `json:"affected_rows"`
+	Error        pgtype.Text        `json:"error"`
+	StartedAt    pgtype.Timestamptz `json:"started_at"`
+	FinishedAt   pgtype.Timestamptz `json:"finished_at"`
+}
+
+type SecurityEvent struct {
+	ID              pgtype.UUID        `json:"id"`
+	UserEmail       string             `json:"user_email"`
+	Kind            string             `json:"kind"`
+	Reasons         []string           `json:"reasons"`
+	SessionKey      string             `json:"session_key"`
+	Device          string             `json:"device"`
+	IpAddress       pgtype.Text        `json:"ip_address"`
+	Country         pgtype.Text        `json:"country"`
+	RevokeTokenHash pgtype.Text        `json:"revoke_token_hash"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	RevokedAt       pgtype.Timestamptz `json:"revoked_at"`
+}
+
+type ServiceAccount struct {
+	ID          pgtype.UUID        `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Scopes      []string           `json:"scopes"`
+	OwnerID     pgtype.UUID        `json:"owner_id"`
+	CreatedBy   string             `json:"created_by"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	DisabledAt  pgtype.Timestamptz `json:"disabled_at"`
+}
+
+type ServiceAccountCredential struct {
+	ID             pgtype.UUID        `json:"id"`
+	AccountID      pgtype.UUID        `json:"account_id"`
+	Prefix         string             `json:"prefix"`
+	SecretHash     string             `json:"secret_hash"`
+	CreatedBy      string             `json:"created_by"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt     pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt      pgtype.Timestamptz `json:"revoked_at"`
+	ExpiryWarnedAt pgtype.Timestamptz `json:"expiry_warned_at"`
+}
+
+type ShortLink struct {
+	ID            pgtype.UUID        `json:"id"`
+	Code          string             `json:"code"`
+	TargetUrl     string             `json:"target_url"`
+	Clicks        int64              `json:"clicks"`
+	CreatedBy     string             `json:"created_by"`
+	ExpiresAt     pgtype.Timestamptz `json:"expires_at"`
+	LastClickedAt pgtype.Timestamptz `json:"last_clicked_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+type ShortLinkClick struct {
+	ID          int64              `json:"id"`
+	LinkID      pgtype.UUID        `json:"link_id"`
+	RefererHost string             `json:"referer_host"`
+	DeviceClass string             `json:"device_class"`
+	Country     string             `json:"country"`
+	ClickedAt   pgtype.Timestamptz `json:"clicked_at"`
+}
+
+type TenantDomain struct {
+	Domain            string             `json:"domain"`
+	TenantID          string             `json:"tenant_id"`
+	VerificationToken string             `json:"verification_token"`
+	VerifiedAt        pgtype.Timestamptz `json:"verified_at"`
+	LastCheckedAt     pgtype.Timestamptz `json:"last_checked_at"`
+	CheckError        pgtype.Text        `json:"check_error"`
+	CorsOrigins       []string           `json:"cors_origins"`
+	CookieDomain      pgtype.Text        `json:"cookie_domain"`
+	CookieSameSite    pgtype.Text        `json:"cookie_same_site"`
+	CreatedBy         string             `json:"created_by"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Upload struct {
+	ID                  pgtype.UUID        `json:"id"`
+	Owner               string             `json:"owner"`
+	Filename            string             `json:"filename"`
+	ContentType         string             `json:"content_type"`
+	Size                int64              `json:"size"`
+	Sha256              string             `json:"sha256"`
+	ObjectKey           string             `json:"object_key"`
+	Status              string             `json:"status"`
+	ScanEngine          pgtype.Text        `json:"scan_engine"`
+	ScanSignature       pgtype.Text        `json:"scan_signature"`
+	ScanError           pgtype.Text        `json:"scan_error"`
+	ScannedAt           pgtype.Timestamptz `json:"scanned_at"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	SniffedType         pgtype.Text        `json:"sniffed_type"`
+	Width               pgtype.Int4        `json:"width"`
+	Height              pgtype.Int4        `json:"height"`
+	DurationMs          pgtype.Int8        `json:"duration_ms"`
+	MetadataExtractedAt pgtype.Timestamptz `json:"metadata_extracted_at"`
+}
+
+type User struct {
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	Name            string             `json:"name"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt       pgtype.Timestamptz `json:"deleted_at"`
+	Phone           pgtype.Text        `json:"phone"`
+	PhoneVerifiedAt pgtype.Timestamptz `json:"phone_verified_at"`
+	Bio             string             `json:"bio"`
+	ShadowBannedAt  pgtype.Timestamptz `json:"shadow_banned_at"`
+	ShadowBanReason pgtype.Text        `json:"shadow_ban_reason"`
+}
+
+type UserChange struct {
+	ID        int64              `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Operation string             `json:"operation"`
+	OldData   []byte             `json:"old_data"`
+	NewData   []byte             `json:"new_data"`
+	ChangedAt pgtype.Timestamptz `json:"changed_at"`
+	Txid      int64              `json:"txid"`
+}
+
+type UserEmbedding struct {
+	UserID      pgtype.UUID        `json:"user_id"`
+	ContentHash string             `json:"content_hash"`
+	Model       pgtype.Text        `json:"model"`
+	Embedding   interface{}        `json:"embedding"`
+	EmbeddedAt  pgtype.Timestamptz `json:"embedded_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type UserEvent struct {
+	UserID     pgtype.UUID        `json:"user_id"`
+	Version    int64              `json:"version"`
+	Type       string             `json:"type"`
+	Data       []byte             `json:"data"`
+	RecordedAt pgtype.Timestamptz `json:"recorded_at"`
+}
+
+type UserExternalID struct {
+	System     string             `json:"system"`
+	ExternalID string             `json:"external_id"`
+	UserID     pgtype.UUID        `json:"user_id"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type UserHandleHistory struct {
+	Handle     string             `json:"handle"`
+	UserID     pgtype.UUID        `json:"user_id"`
+	ReleasedAt pgtype.Timestamptz `json:"released_at"`
+}
+
+type UserIdentity struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Provider  string             `json:"provider"`
+	Subject   string             `json:"subject"`
+	Email     string             `json:"email"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type UserRole struct {
+	UserID    pgtype.UUID        `json:"user_id"`
+	Role      string             `json:"role"`
+	GrantedBy string             `json:"granted_by"`
+	GrantedAt pgtype.Timestamptz `json:"granted_at"`
+}
+
+type UserSearch struct {
+	UserID        pgtype.UUID        `json:"user_id"`
+	Email         string             `json:"email"`
+	Name          string             `json:"name"`
+	Bio           string             `json:"bio"`
+	Phone         pgtype.Text        `json:"phone"`
+	PhoneVerified bool               `json:"phone_verified"`
+	ShadowBanned  bool               `json:"shadow_banned"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	Document      interface{}        `json:"document"`
+}
+
+type UserSession struct {
+	ID             pgtype.UUID        `json:"id"`
+	UserEmail      string             `json:"user_email"`
+	SessionKey     string             `json:"session_key"`
+	UserAgent      string             `json:"user_agent"`
+	Browser        string             `json:"browser"`
+	BrowserVersion string             `json:"browser_version"`
+	Os             string             `json:"os"`
+	DeviceClass    string             `json:"device_class"`
+	IpAddress      pgtype.Text        `json:"ip_address"`
+	Country        pgtype.Text        `json:"country"`
+	FirstSeenAt    pgtype.Timestamptz `json:"first_seen_at"`
+	LastSeenAt     pgtype.Timestamptz `json:"last_seen_at"`
+	RevokedAt      pgtype.Timestamptz `json:"revoked_at"`
+}
+
+type UserSnapshot struct {
+	UserID  pgtype.UUID        `json:"user_id"`
+	Version int64              `json:"version"`
+	State   []byte             `json:"state"`
+	TakenAt pgtype.Timestamptz `json:"taken_at"`
+}
+
+type UserSummary struct {
+	UserID        pgtype.UUID        `json:"user_id"`
+	Email         string             `json:"email"`
+	Name          string             `json:"name"`
+	Handle        pgtype.Text        `json:"handle"`
+	Bio           string             `json:"bio"`
+	Phone         pgtype.Text        `json:"phone"`
+	PhoneVerified bool               `json:"phone_verified"`
+	PendingEmail  pgtype.Text        `json:"pending_email"`
+	ShadowBanned  bool               `json:"shadow_banned"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WebauthnChallenge struct {
+	Challenge []byte             `json:"challenge"`
+	Kind      string             `json:"kind"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+type WebauthnCredential struct {
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	CredentialID   []byte             `json:"credential_id"`
+	PublicKey      []byte             `json:"public_key"`
+	Algorithm      int32              `json:"algorithm"`
+	SignCount      int64              `json:"sign_count"`
+	Aaguid         []byte             `json:"aaguid"`
+	Transports     []string           `json:"transports"`
+	BackupEligible bool               `json:"backup_eligible"`
+	BackedUp       bool               `json:"backed_up"`
+	Name           string             `json:"name"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	LastUsedAt     pgtype.Timestamptz `json:"last_used_at"`
+}
+
+type Workflow struct {
+	ID           pgtype.UUID        `json:"id"`
+	Kind         string             `json:"kind"`
+	Status       string             `json:"status"`
+	Step         int32              `json:"step"`
+	Compensating bool               `json:"compensating"`
+	Data         []byte             `json:"data"`
+	Attempts     int32              `json:"attempts"`
+	LastError    pgtype.Text        `json:"last_error"`
+	NextRunAt    pgtype.Timestamptz `json:"next_run_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WorkflowStep struct {
+	ID         int64              `json:"id"`
+	WorkflowID pgtype.UUID        `json:"workflow_id"`
+	Step       int32              `json:"step"`
+	Name       string             `json:"name"`
+	Action     string             `json:"action"`
+	Error      pgtype.Text        `json:"error"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 }
@@ -11,8 +11,365 @@ import (
 )
 
 type Querier interface {
+	AcquireLeaderLease(ctx context.Context, arg AcquireLeaderLeaseParams) (LeaderLease, error)
+	AddAIUsage(ctx context.Context, arg AddAIUsageParams) error
+	AddBulkOperationItems(ctx context.Context, arg AddBulkOperationItemsParams) error
+	AddEmailCampaignRecipients(ctx context.Context, arg AddEmailCampaignRecipientsParams) (int64, error)
+	AdvanceNotificationReadCursor(ctx context.Context, arg AdvanceNotificationReadCursorParams) (NotificationReadCursor, error)
+	AnonymizeInactiveUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	AppendResumableUploadChunk(ctx context.Context, arg AppendResumableUploadChunkParams) (ResumableUpload, error)
+	AppendUserEvent(ctx context.Context, arg AppendUserEventParams) error
+	CancelCalendarInvite(ctx context.Context, id pgtype.UUID) (CalendarInvite, error)
+	CancelJob(ctx context.Context, id pgtype.UUID) (Job, error)
+	ChangeUserEmail(ctx context.Context, arg ChangeUserEmailParams) (int64, error)
+	ClaimDueJobs(ctx context.Context, arg ClaimDueJobsParams) ([]ClaimDueJobsRow, error)
+	ClaimDueStagedOperations(ctx context.Context, rowLimit int32) ([]Operation, error)
+	ClaimDueWorkflows(ctx context.Context, arg ClaimDueWorkflowsParams) ([]Workflow, error)
+	ClaimPendingUploads(ctx context.Context, arg ClaimPendingUploadsParams) ([]Upload, error)
+	ClaimProjectionCheckpoint(ctx context.Context, name string) (ClaimProjectionCheckpointRow, error)
+	ClaimRecurringJob(ctx context.Context, arg ClaimRecurringJobParams) (string, error)
+	ClearReadOnlyMode(ctx context.Context) (int64, error)
+	ClearUserSearch(ctx context.Context) error
+	ClearUserShadowBan(ctx context.Context, id pgtype.UUID) (int64, error)
+	ClearUserSummaries(ctx context.Context) error
+	CompleteJob(ctx context.Context, id pgtype.UUID) error
+	CompleteOperation(ctx context.Context, arg CompleteOperationParams) (Operation, error)
+	CompleteUploadScan(ctx context.Context, arg CompleteUploadScanParams) (Upload, error)
+	ConfirmEmailChange(ctx context.Context, tokenHash string) (EmailChange, error)
+	ConsumeWebauthnChallenge(ctx context.Context, arg ConsumeWebauthnChallengeParams) (WebauthnChallenge, error)
+	CountActiveAPIKeysByCreator(ctx context.Context, createdBy string) (int64, error)
+	CountActiveSessions(ctx context.Context, since pgtype.Timestamptz) (int64, error)
+	CountAuditLogsBefore(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	CountInactiveUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	CountLoginFingerprintMatches(ctx context.Context, arg CountLoginFingerprintMatchesParams) (CountLoginFingerprintMatchesRow, error)
+	CountModerationFlags(ctx context.Context, status string) (int64, error)
+	CountNotifications(ctx context.Context, arg CountNotificationsParams) (int64, error)
+	CountPurgeableUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	CountQueuedJobs(ctx context.Context) ([]CountQueuedJobsRow, error)
+	CountRecentMagicLinks(ctx context.Context, arg CountRecentMagicLinksParams) (CountRecentMagicLinksRow, error)
+	CountSessionsByDevice(ctx context.Context, since pgtype.Timestamptz) ([]CountSessionsByDeviceRow, error)
+	CountShadowBannedUsers(ctx context.Context) (int64, error)
+	CountShortLinkClicksByDay(ctx context.Context, arg CountShortLinkClicksByDayParams) ([]CountShortLinkClicksByDayRow, error)
+	CountUserEmbeddings(ctx context.Context, model pgtype.Text) (CountUserEmbeddingsRow, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
+	CreateAccountMerge(ctx context.Context, arg CreateAccountMergeParams) error
+	CreateAnnouncement(ctx context.Context, arg CreateAnnouncementParams) (Announcement, error)
+	CreateArchiveSegment(ctx context.Context, arg CreateArchiveSegmentParams) (ArchiveSegment, error)
+	CreateAuditExport(ctx context.Context, arg CreateAuditExportParams) (AuditExport, error)
+	CreateAuditExportDelivery(ctx context.Context, arg CreateAuditExportDeliveryParams) (AuditExportDelivery, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error
+	CreateAuthSession(ctx context.Context, arg CreateAuthSessionParams) (AuthSession, error)
+	CreateBackup(ctx context.Context, arg CreateBackupParams) (Backup, error)
+	CreateBulkOperation(ctx context.Context, arg CreateBulkOperationParams) (BulkOperation, error)
+	CreateCalendarInvite(ctx context.Context, arg CreateCalendarInviteParams) (CalendarInvite, error)
+	CreateEmailCampaign(ctx context.Context, arg CreateEmailCampaignParams) (EmailCampaign, error)
+	CreateEmailChange(ctx context.Context, arg CreateEmailChangeParams) (EmailChange, error)
+	CreateEmailMessage(ctx context.Context, arg CreateEmailMessageParams) (EmailMessage, error)
+	CreateMagicLink(ctx context.Context, arg CreateMagicLinkParams) (MagicLink, error)
+	CreateMessageTemplate(ctx context.Context, arg CreateMessageTemplateParams) (MessageTemplate, error)
+	CreateMessageTemplateVersion(ctx context.Context, arg CreateMessageTemplateVersionParams) (MessageTemplateVersion, error)
+	CreateModerationFlag(ctx context.Context, arg CreateModerationFlagParams) (ModerationFlag, error)
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
+	CreateOperation(ctx context.Context, arg CreateOperationParams) (Operation, error)
+	CreateResumableUpload(ctx context.Context, arg CreateResumableUploadParams) (ResumableUpload, error)
+	CreateRetentionPolicy(ctx context.Context, arg CreateRetentionPolicyParams) (RetentionPolicy, error)
+	CreateRetentionRun(ctx context.Context, arg CreateRetentionRunParams) (RetentionRun, error)
+	CreateSecurityEvent(ctx context.Context, arg CreateSecurityEventParams) (SecurityEvent, error)
+	CreateServiceAccount(ctx context.Context, arg CreateServiceAccountParams) (ServiceAccount, error)
+	CreateShortLink(ctx context.Context, arg CreateShortLinkParams) (ShortLink, error)
+	CreateTenantDomain(ctx context.Context, arg CreateTenantDomainParams) (TenantDomain, error)
+	CreateUpload(ctx context.Context, arg CreateUploadParams) (Upload, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error)
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
+	CreateWebauthnChallenge(ctx context.Context, arg CreateWebauthnChallengeParams) error
+	CreateWebauthnCredential(ctx context.Context, arg CreateWebauthnCredentialParams) (WebauthnCredential, error)
+	CreateWorkflow(ctx context.Context, arg CreateWorkflowParams) (Workflow, error)
+	DeactivateUser(ctx context.Context, id pgtype.UUID) (int64, error)
+	DeleteAuditExport(ctx context.Context, arg DeleteAuditExportParams) (int64, error)
+	DeleteAuditLogsBefore(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	DeleteAuditLogsByIDs(ctx context.Context, ids []pgtype.UUID) (int64, error)
+	DeleteBackup(ctx context.Context, id pgtype.UUID) error
+	DeleteConflictingLoginFingerprints(ctx context.Context, arg DeleteConflictingLoginFingerprintsParams) (int64, error)
+	DeleteConflictingNotificationPreferences(ctx context.Context, arg DeleteConflictingNotificationPreferencesParams) (int64, error)
+	DeleteConflictingNotificationReadCursors(ctx context.Context, arg DeleteConflictingNotificationReadCursorsParams) (int64, error)
+	DeleteConflictingUserSessions(ctx context.Context, arg DeleteConflictingUserSessionsParams) (int64, error)
+	DeleteDeactivatedUser(ctx context.Context, id pgtype.UUID) (int64, error)
+	DeleteEmailChange(ctx context.Context, userID pgtype.UUID) (int64, error)
+	DeleteEmailSuppression(ctx context.Context, lower string) (int64, error)
+	DeleteExpiredJobUniqueKeys(ctx context.Context) (int64, error)
+	DeleteExpiredReplicaHeartbeats(ctx context.Context, retentionSeconds float64) error
+	DeleteExpiredRequestNonces(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	DeleteExpiredResumableUpload(ctx context.Context, id pgtype.UUID) (int64, error)
+	DeleteExternalID(ctx context.Context, arg DeleteExternalIDParams) (UserExternalID, error)
+	DeleteFinishedJobs(ctx context.Context, finishedAt pgtype.Timestamptz) (int64, error)
+	DeleteKillSwitch(ctx context.Context, route string) (int64, error)
+	DeleteOldEmailMessages(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	DeletePhoneVerification(ctx context.Context, userID pgtype.UUID) error
+	DeletePushSubscription(ctx context.Context, arg DeletePushSubscriptionParams) (int64, error)
+	DeletePushSubscriptionByEndpoint(ctx context.Context, endpoint string) error
+	DeleteReplicaHeartbeat(ctx context.Context, id string) error
+	DeleteResumableUpload(ctx context.Context, id pgtype.UUID) error
+	DeleteStaleUserEmbeddings(ctx context.Context) (int64, error)
+	DeleteTenantDomain(ctx context.Context, domain string) (int64, error)
+	DeleteUserEmailMessages(ctx context.Context, userID pgtype.UUID) error
+	DeleteUserEmbedding(ctx context.Context, userID pgtype.UUID) error
+	DeleteUserIdentity(ctx context.Context, arg DeleteUserIdentityParams) (UserIdentity, error)
+	DeleteUserPushSubscriptions(ctx context.Context, userID pgtype.UUID) error
+	DeleteUserSearch(ctx context.Context, userID pgtype.UUID) error
+	DeleteUserSessions(ctx context.Context, id pgtype.UUID) error
+	DeleteUserSummary(ctx context.Context, userID pgtype.UUID) error
+	DeleteWebauthnCredential(ctx context.Context, arg DeleteWebauthnCredentialParams) (int64, error)
+	DisableServiceAccount(ctx context.Context, id pgtype.UUID) (ServiceAccount, error)
+	EnqueueJob(ctx context.Context, arg EnqueueJobParams) (Job, error)
+	EnqueueUniqueJob(ctx context.Context, arg EnqueueUniqueJobParams) (Job, error)
+	EnsureProbeUser(ctx context.Context, arg EnsureProbeUserParams) (pgtype.UUID, error)
+	FailJob(ctx context.Context, arg FailJobParams) error
+	FindEmailOwner(ctx context.Context, email string) (FindEmailOwnerRow, error)
+	FindExternalID(ctx context.Context, arg FindExternalIDParams) (pgtype.UUID, error)
+	FindHandle(ctx context.Context, handle pgtype.Text) (FindHandleRow, error)
+	FindUploadObjectBySHA256(ctx context.Context, arg FindUploadObjectBySHA256Params) (string, error)
+	FinishBulkItem(ctx context.Context, arg FinishBulkItemParams) error
+	FinishEmailCampaignRecipient(ctx context.Context, arg FinishEmailCampaignRecipientParams) error
+	FollowShortLink(ctx context.Context, code string) (ShortLink, error)
+	GetAITokensToday(ctx context.Context, userEmail string) (int64, error)
+	GetAPIKeyByCreator(ctx context.Context, arg GetAPIKeyByCreatorParams) (ApiKey, error)
+	GetAccountUser(ctx context.Context, id pgtype.UUID) (GetAccountUserRow, error)
+	GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
+	GetActiveAuthSession(ctx context.Context, tokenHash string) (GetActiveAuthSessionRow, error)
+	GetActiveServiceAccountCredential(ctx context.Context, secretHash string) (GetActiveServiceAccountCredentialRow, error)
+	GetActiveUserIDByEmail(ctx context.Context, email string) (pgtype.UUID, error)
+	GetAuditExport(ctx context.Context, arg GetAuditExportParams) (AuditExport, error)
+	GetAuditForwarderCheckpoint(ctx context.Context, name string) (AuditForwarderCheckpoint, error)
+	GetAuditLogTxidSince(ctx context.Context, since pgtype.Timestamptz) (int64, error)
+	GetBulkOperation(ctx context.Context, id pgtype.UUID) (BulkOperation, error)
+	GetCalendarInvite(ctx context.Context, id pgtype.UUID) (CalendarInvite, error)
+	GetEmailCampaign(ctx context.Context, id pgtype.UUID) (EmailCampaign, error)
+	GetEmailCampaignDelivery(ctx context.Context, campaignID pgtype.UUID) (GetEmailCampaignDeliveryRow, error)
+	GetEmailChange(ctx context.Context, userID pgtype.UUID) (EmailChange, error)
+	GetEmailMessage(ctx context.Context, id pgtype.UUID) (EmailMessage, error)
+	GetJob(ctx context.Context, id pgtype.UUID) (Job, error)
+	GetJobByUniqueKey(ctx context.Context, uniqueKey string) (Job, error)
+	GetLatestBackup(ctx context.Context) (Backup, error)
+	GetLatestUserChangeID(ctx context.Context) (int64, error)
+	GetLeaderLease(ctx context.Context, name string) (LeaderLease, error)
+	GetMagicLink(ctx context.Context, tokenHash string) (MagicLink, error)
+	GetMessageTemplate(ctx context.Context, id pgtype.UUID) (MessageTemplate, error)
+	GetMessageTemplateVersion(ctx context.Context, arg GetMessageTemplateVersionParams) (MessageTemplateVersion, error)
+	GetModerationFlag(ctx context.Context, id pgtype.UUID) (ModerationFlag, error)
+	GetOperation(ctx context.Context, id pgtype.UUID) (Operation, error)
+	GetPhoneVerification(ctx context.Context, userID pgtype.UUID) (PhoneVerification, error)
+	GetProjectionLag(ctx context.Context, name string) (GetProjectionLagRow, error)
+	GetReadOnlyMode(ctx context.Context) (ReadOnlyMode, error)
+	GetResumableUpload(ctx context.Context, id pgtype.UUID) (ResumableUpload, error)
+	GetRetentionPolicy(ctx context.Context, id pgtype.UUID) (RetentionPolicy, error)
+	GetServiceAccount(ctx context.Context, id pgtype.UUID) (ServiceAccount, error)
+	GetShortLink(ctx context.Context, code string) (ShortLink, error)
+	GetTenantDomain(ctx context.Context, domain string) (TenantDomain, error)
+	GetUpload(ctx context.Context, id pgtype.UUID) (Upload, error)
 	GetUserByID(ctx context.Context, id pgtype.UUID) (GetUserByIDRow, error)
-	ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error)
+	GetUserChangeLogEnd(ctx context.Context) (int64, error)
+	GetUserHandle(ctx context.Context, id pgtype.UUID) (GetUserHandleRow, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
+	GetUserRiskSignals(ctx context.Context, id pgtype.UUID) (GetUserRiskSignalsRow, error)
+	GetUserRiskSignalsByEmail(ctx context.Context, email string) (GetUserRiskSignalsByEmailRow, error)
+	GetUserSnapshot(ctx context.Context, userID pgtype.UUID) (UserSnapshot, error)
+	GetWebauthnCredentialForSignIn(ctx context.Context, credentialID []byte) (GetWebauthnCredentialForSignInRow, error)
+	GetWebauthnUser(ctx context.Context, email string) (GetWebauthnUserRow, error)
+	GetWorkflow(ctx context.Context, id pgtype.UUID) (Workflow, error)
+	GrantUserRole(ctx context.Context, arg GrantUserRoleParams) (int64, error)
+	HasJobEffect(ctx context.Context, arg HasJobEffectParams) (bool, error)
+	ImportExternalIDs(ctx context.Context, arg ImportExternalIDsParams) ([]string, error)
+	IncrementAPIKeyUsage(ctx context.Context, keyID pgtype.UUID) (int64, error)
+	IncrementPhoneVerificationAttempts(ctx context.Context, userID pgtype.UUID) error
+	IsEmailSuppressed(ctx context.Context, lower string) (bool, error)
+	IsUserDeactivated(ctx context.Context, id pgtype.UUID) (bool, error)
+	IssueServiceAccountCredential(ctx context.Context, arg IssueServiceAccountCredentialParams) (ServiceAccountCredential, error)
+	ListAPIKeyUsage(ctx context.Context, arg ListAPIKeyUsageParams) ([]ListAPIKeyUsageRow, error)
+	ListAPIKeys(ctx context.Context) ([]ApiKey, error)
+	ListAPIKeysByCreator(ctx context.Context, createdBy string) ([]ApiKey, error)
+	ListActiveUsersSnapshot(ctx context.Context, arg ListActiveUsersSnapshotParams) ([]ListActiveUsersSnapshotRow, error)
+	ListAnnouncementSitemapEntries(ctx context.Context, arg ListAnnouncementSitemapEntriesParams) ([]ListAnnouncementSitemapEntriesRow, error)
+	ListAnnouncementSitemapPages(ctx context.Context, arg ListAnnouncementSitemapPagesParams) ([]ListAnnouncementSitemapPagesRow, error)
+	ListArchiveSegments(ctx context.Context, tableName string) ([]ArchiveSegment, error)
+	ListArchiveSegmentsInRange(ctx context.Context, arg ListArchiveSegmentsInRangeParams) ([]ArchiveSegment, error)
+	ListAuditExportDeliveries(ctx context.Context, arg ListAuditExportDeliveriesParams) ([]AuditExportDelivery, error)
+	ListAuditExports(ctx context.Context, tenantID string) ([]AuditExport, error)
+	ListAuditLogsAfter(ctx context.Context, arg ListAuditLogsAfterParams) ([]AuditLog, error)
+	ListAuditLogsBefore(ctx context.Context, arg ListAuditLogsBeforeParams) ([]AuditLog, error)
+	ListBackups(ctx context.Context) ([]Backup, error)
+	ListBulkItems(ctx context.Context, arg ListBulkItemsParams) ([]BulkOperationItem, error)
+	ListBulkOperations(ctx context.Context, limit int32) ([]BulkOperation, error)
+	ListBulkTargets(ctx context.Context, arg ListBulkTargetsParams) ([]pgtype.UUID, error)
+	ListDueAuditExports(ctx context.Context, rowLimit int32) ([]AuditExport, error)
+	ListEmailCampaignRecipients(ctx context.Context, arg ListEmailCampaignRecipientsParams) ([]EmailCampaignRecipient, error)
+	ListEmailCampaigns(ctx context.Context, limit int32) ([]EmailCampaign, error)
+	ListEmailMessages(ctx context.Context, arg ListEmailMessagesParams) ([]EmailMessage, error)
+	ListEmailSuppressions(ctx context.Context, limit int32) ([]EmailSuppression, error)
+	ListEnabledRetentionPolicies(ctx context.Context) ([]RetentionPolicy, error)
+	ListExpiredBackups(ctx context.Context, arg ListExpiredBackupsParams) ([]Backup, error)
+	ListExpiredResumableUploads(ctx context.Context, limit int32) ([]ResumableUpload, error)
+	ListExpiringServiceAccountCredentials(ctx context.Context, expiresAt pgtype.Timestamptz) ([]ListExpiringServiceAccountCredentialsRow, error)
+	ListExternalIDsByValue(ctx context.Context, arg ListExternalIDsByValueParams) ([]UserExternalID, error)
+	ListJobs(ctx context.Context, arg ListJobsParams) ([]Job, error)
+	ListKillSwitches(ctx context.Context) ([]KillSwitch, error)
+	ListMessageTemplateVersions(ctx context.Context, templateID pgtype.UUID) ([]MessageTemplateVersion, error)
+	ListMessageTemplates(ctx context.Context) ([]MessageTemplate, error)
+	ListMessageTemplatesByKey(ctx context.Context, arg ListMessageTemplatesByKeyParams) ([]MessageTemplate, error)
+	ListModerationFlags(ctx context.Context, arg ListModerationFlagsParams) ([]ModerationFlag, error)
+	ListNotificationPreferences(ctx context.Context, userID pgtype.UUID) ([]NotificationPreference, error)
+	ListNotificationPreferencesForUsers(ctx context.Context, userIds []pgtype.UUID) ([]NotificationPreference, error)
+	ListNotificationReadCursors(ctx context.Context, userID pgtype.UUID) ([]NotificationReadCursor, error)
+	ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error)
+	ListNotificationsSince(ctx context.Context, arg ListNotificationsSinceParams) ([]Notification, error)
+	ListPendingBulkItems(ctx context.Context, arg ListPendingBulkItemsParams) ([]BulkOperationItem, error)
+	ListPendingEmailCampaignRecipients(ctx context.Context, arg ListPendingEmailCampaignRecipientsParams) ([]ListPendingEmailCampaignRecipientsRow, error)
+	ListPendingUserEmbeddings(ctx context.Context, arg ListPendingUserEmbeddingsParams) ([]ListPendingUserEmbeddingsRow, error)
+	ListPreviousHandles(ctx context.Context, userID pgtype.UUID) ([]UserHandleHistory, error)
+	ListProjectionCheckpoints(ctx context.Context) ([]ListProjectionCheckpointsRow, error)
+	ListPublishedAnnouncements(ctx context.Context, rowLimit int32) ([]Announcement, error)
+	ListPushSubscriptions(ctx context.Context, userID pgtype.UUID) ([]PushSubscription, error)
+	ListRatePlans(ctx context.Context) ([]RatePlan, error)
+	ListReplicaHeartbeats(ctx context.Context) ([]ListReplicaHeartbeatsRow, error)
+	ListRetentionPolicies(ctx context.Context) ([]RetentionPolicy, error)
+	ListRetentionRuns(ctx context.Context, arg ListRetentionRunsParams) ([]RetentionRun, error)
+	ListSecurityEvents(ctx context.Context, userEmail string) ([]SecurityEvent, error)
+	ListServiceAccountCredentials(ctx context.Context, accountIds []pgtype.UUID) ([]ServiceAccountCredential, error)
+	ListServiceAccounts(ctx context.Context) ([]ServiceAccount, error)
+	ListShadowBannedUsers(ctx context.Context, arg ListShadowBannedUsersParams) ([]ListShadowBannedUsersRow, error)
+	ListTenantAuditLogsAfter(ctx context.Context, arg ListTenantAuditLogsAfterParams) ([]AuditLog, error)
+	ListTenantDomains(ctx context.Context, tenantID pgtype.Text) ([]TenantDomain, error)
+	ListTrash(ctx context.Context, arg ListTrashParams) ([]ListTrashRow, error)
+	ListUnverifiedTenantDomains(ctx context.Context, arg ListUnverifiedTenantDomainsParams) ([]TenantDomain, error)
+	ListUploadsWithoutMetadata(ctx context.Context, limit int32) ([]Upload, error)
+	ListUserChangesSince(ctx context.Context, arg ListUserChangesSinceParams) ([]UserChange, error)
+	ListUserEventsAfter(ctx context.Context, arg ListUserEventsAfterParams) ([]UserEvent, error)
+	ListUserExternalIDs(ctx context.Context, userID pgtype.UUID) ([]UserExternalID, error)
+	ListUserIdentities(ctx context.Context, userID pgtype.UUID) ([]UserIdentity, error)
+	ListUserRoles(ctx context.Context, userID pgtype.UUID) ([]string, error)
+	ListUserSessions(ctx context.Context, userEmail string) ([]UserSession, error)
+	ListUserSessionsForEmails(ctx context.Context, userEmails []string) ([]UserSession, error)
+	ListUserSummaries(ctx context.Context, arg ListUserSummariesParams) ([]ListUserSummariesRow, error)
+	ListVerifiedTenantDomains(ctx context.Context) ([]TenantDomain, error)
+	ListWebauthnCredentials(ctx context.Context, userID pgtype.UUID) ([]WebauthnCredential, error)
+	ListWorkflowSteps(ctx context.Context, workflowID pgtype.UUID) ([]WorkflowStep, error)
+	ListWorkflows(ctx context.Context, arg ListWorkflowsParams) ([]Workflow, error)
+	LockUserForUpdate(ctx context.Context, id pgtype.UUID) (LockUserForUpdateRow, error)
+	MarkEmailOpenedByProviderID(ctx context.Context, arg MarkEmailOpenedByProviderIDParams) (int64, error)
+	MarkEmailSent(ctx context.Context, arg MarkEmailSentParams) error
+	MarkRetentionPolicyRun(ctx context.Context, id pgtype.UUID) error
+	MarkServiceAccountCredentialWarned(ctx context.Context, id pgtype.UUID) error
+	MarkUserPhoneVerified(ctx context.Context, arg MarkUserPhoneVerifiedParams) (int64, error)
+	MergeAIUsage(ctx context.Context, arg MergeAIUsageParams) (int64, error)
+	MoveAPIKeys(ctx context.Context, arg MoveAPIKeysParams) (int64, error)
+	MoveAccountMerges(ctx context.Context, arg MoveAccountMergesParams) (int64, error)
+	MoveCalendarInvites(ctx context.Context, arg MoveCalendarInvitesParams) (int64, error)
+	MoveEmailMessages(ctx context.Context, arg MoveEmailMessagesParams) (int64, error)
+	MoveLoginFingerprints(ctx context.Context, arg MoveLoginFingerprintsParams) (int64, error)
+	MoveNotificationPreferences(ctx context.Context, arg MoveNotificationPreferencesParams) (int64, error)
+	MoveNotificationReadCursors(ctx context.Context, arg MoveNotificationReadCursorsParams) (int64, error)
+	MoveNotifications(ctx context.Context, arg MoveNotificationsParams) (int64, error)
+	MoveOperations(ctx context.Context, arg MoveOperationsParams) (int64, error)
+	MovePushSubscriptions(ctx context.Context, arg MovePushSubscriptionsParams) (int64, error)
+	MoveSecurityEvents(ctx context.Context, arg MoveSecurityEventsParams) (int64, error)
+	MoveServiceAccountOwners(ctx context.Context, arg MoveServiceAccountOwnersParams) (int64, error)
+	MoveShortLinks(ctx context.Context, arg MoveShortLinksParams) (int64, error)
+	MoveUploads(ctx context.Context, arg MoveUploadsParams) (int64, error)
+	MoveUserExternalIDs(ctx context.Context, arg MoveUserExternalIDsParams) (int64, error)
+	MoveUserIdentities(ctx context.Context, arg MoveUserIdentitiesParams) (int64, error)
+	MoveUserSessions(ctx context.Context, arg MoveUserSessionsParams) (int64, error)
+	MoveWebauthnCredentials(ctx context.Context, arg MoveWebauthnCredentialsParams) (int64, error)
+	PauseEmailCampaign(ctx context.Context, id pgtype.UUID) (EmailCampaign, error)
+	ProjectUser(ctx context.Context, arg ProjectUserParams) (ProjectUserRow, error)
+	PruneAuthSessions(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	PruneEmailChanges(ctx context.Context) (int64, error)
+	PruneLoginFingerprints(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	PruneMagicLinks(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	PruneSecurityEvents(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	PruneUserSessions(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	PruneWebauthnChallenges(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	PurgeDeletedUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	PurgeMessageTemplate(ctx context.Context, id pgtype.UUID) (int64, error)
+	PurgeRetentionPolicy(ctx context.Context, id pgtype.UUID) (int64, error)
+	PurgeTrashedMessageTemplates(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	PurgeTrashedRetentionPolicies(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	ReactivateUser(ctx context.Context, id pgtype.UUID) (int64, error)
+	RebuildUserEmbeddings(ctx context.Context) (int64, error)
+	RebuildUserSearch(ctx context.Context) (int64, error)
+	RebuildUserSummaries(ctx context.Context) (int64, error)
+	RecordAuditExportFailure(ctx context.Context, arg RecordAuditExportFailureParams) (AuditExport, error)
+	RecordAuditExportSuccess(ctx context.Context, arg RecordAuditExportSuccessParams) error
+	RecordJobEffect(ctx context.Context, arg RecordJobEffectParams) error
+	RecordPreviousHandle(ctx context.Context, arg RecordPreviousHandleParams) error
+	RecordShortLinkClick(ctx context.Context, arg RecordShortLinkClickParams) error
+	RecordTenantDomainCheck(ctx context.Context, arg RecordTenantDomainCheckParams) (TenantDomain, error)
+	RecordWorkflowStep(ctx context.Context, arg RecordWorkflowStepParams) error
+	RefreshBulkOperation(ctx context.Context, id pgtype.UUID) (BulkOperation, error)
+	RefreshEmailCampaign(ctx context.Context, id pgtype.UUID) (EmailCampaign, error)
+	ReleaseHandle(ctx context.Context, handle string) error
+	ReleaseLeaderLease(ctx context.Context, arg ReleaseLeaderLeaseParams) error
+	RenameWebauthnCredential(ctx context.Context, arg RenameWebauthnCredentialParams) (WebauthnCredential, error)
+	ResetUserEmbeddings(ctx context.Context) (int64, error)
+	ResolveIdentity(ctx context.Context, arg ResolveIdentityParams) (string, error)
+	ResolveModerationFlag(ctx context.Context, arg ResolveModerationFlagParams) (ModerationFlag, error)
+	RestoreMessageTemplate(ctx context.Context, id pgtype.UUID) (int64, error)
+	RestoreRetentionPolicy(ctx context.Context, id pgtype.UUID) (int64, error)
+	ResumeEmailCampaign(ctx context.Context, id pgtype.UUID) (EmailCampaign, error)
+	RetryJob(ctx context.Context, id pgtype.UUID) (Job, error)
+	RetryJobLater(ctx context.Context, arg RetryJobLaterParams) error
+	RetryWorkflow(ctx context.Context, id pgtype.UUID) (Workflow, error)
+	RevokeAPIKey(ctx context.Context, id pgtype.UUID) (ApiKey, error)
+	RevokeAuthSession(ctx context.Context, tokenHash string) (int64, error)
+	RevokeServiceAccountCredential(ctx context.Context, arg RevokeServiceAccountCredentialParams) (ServiceAccountCredential, error)
+	RevokeUserAuthSessions(ctx context.Context, userID pgtype.UUID) (int64, error)
+	RotateAPIKey(ctx context.Context, arg RotateAPIKeyParams) (ApiKey, error)
+	SaveProjectionCheckpoint(ctx context.Context, arg SaveProjectionCheckpointParams) error
+	SaveUserEmbedding(ctx context.Context, arg SaveUserEmbeddingParams) (int64, error)
+	SaveUserSnapshot(ctx context.Context, arg SaveUserSnapshotParams) error
+	SaveWorkflow(ctx context.Context, arg SaveWorkflowParams) error
+	SearchUserEmbeddings(ctx context.Context, arg SearchUserEmbeddingsParams) ([]SearchUserEmbeddingsRow, error)
+	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error)
+	SetAPIKeyPlan(ctx context.Context, arg SetAPIKeyPlanParams) (ApiKey, error)
+	SetEmailStatusByProviderID(ctx context.Context, arg SetEmailStatusByProviderIDParams) (EmailMessage, error)
+	SetJobPriority(ctx context.Context, arg SetJobPriorityParams) (Job, error)
+	SetMessageTemplateActiveVersion(ctx context.Context, arg SetMessageTemplateActiveVersionParams) (MessageTemplate, error)
+	SetReadOnlyMode(ctx context.Context, arg SetReadOnlyModeParams) (ReadOnlyMode, error)
+	SetServiceAccountScopes(ctx context.Context, arg SetServiceAccountScopesParams) (ServiceAccount, error)
+	SetUploadMetadata(ctx context.Context, arg SetUploadMetadataParams) error
+	SetUserHandle(ctx context.Context, arg SetUserHandleParams) (SetUserHandleRow, error)
+	SetUserPendingEmail(ctx context.Context, arg SetUserPendingEmailParams) (int64, error)
+	SetUserShadowBan(ctx context.Context, arg SetUserShadowBanParams) (SetUserShadowBanRow, error)
+	StageOperation(ctx context.Context, arg StageOperationParams) (Operation, error)
+	StartEmailCampaign(ctx context.Context, id pgtype.UUID) (EmailCampaign, error)
+	TouchAuthSession(ctx context.Context, id pgtype.UUID) error
+	TouchPushSubscription(ctx context.Context, id pgtype.UUID) error
+	TouchServiceAccountCredential(ctx context.Context, id pgtype.UUID) error
+	TouchUserSession(ctx context.Context, arg TouchUserSessionParams) (bool, error)
+	TrashMessageTemplate(ctx context.Context, arg TrashMessageTemplateParams) (int64, error)
+	TrashRetentionPolicy(ctx context.Context, arg TrashRetentionPolicyParams) (int64, error)
+	UndoOperation(ctx context.Context, id pgtype.UUID) (Operation, error)
+	UpdateAuditExport(ctx context.Context, arg UpdateAuditExportParams) (AuditExport, error)
+	UpdateCalendarInvite(ctx context.Context, arg UpdateCalendarInviteParams) (CalendarInvite, error)
+	UpdateQueuedEmail(ctx context.Context, arg UpdateQueuedEmailParams) error
+	UpdateTenantDomainSettings(ctx context.Context, arg UpdateTenantDomainSettingsParams) (TenantDomain, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (UpdateUserRow, error)
+	UpdateUserPhone(ctx context.Context, arg UpdateUserPhoneParams) (UpdateUserPhoneRow, error)
+	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (UpdateUserProfileRow, error)
+	UpsertAuditForwarderCheckpoint(ctx context.Context, arg UpsertAuditForwarderCheckpointParams) error
+	UpsertEmailSuppression(ctx context.Context, arg UpsertEmailSuppressionParams) (EmailSuppression, error)
+	UpsertKillSwitch(ctx context.Context, arg UpsertKillSwitchParams) (KillSwitch, error)
+	UpsertLoginFingerprint(ctx context.Context, arg UpsertLoginFingerprintParams) (bool, error)
+	UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) error
+	UpsertPhoneVerification(ctx context.Context, arg UpsertPhoneVerificationParams) error
+	UpsertPushSubscription(ctx context.Context, arg UpsertPushSubscriptionParams) (PushSubscription, error)
+	UpsertRatePlan(ctx context.Context, arg UpsertRatePlanParams) (RatePlan, error)
+	UpsertReplicaHeartbeat(ctx context.Context, arg UpsertReplicaHeartbeatParams) error
+	UpsertUserEmbeddingFromChange(ctx context.Context, data []byte) error
+	UpsertUserSearchFromChange(ctx context.Context, data []byte) error
+	UpsertUserSummaryFromChange(ctx context.Context, data []byte) error
+	UseMagicLink(ctx context.Context, id pgtype.UUID) (MagicLink, error)
+	UseRequestNonce(ctx context.Context, arg UseRequestNonceParams) (int64, error)
+	UseRevokeToken(ctx context.Context, arg UseRevokeTokenParams) (SecurityEvent, error)
+	UseWebauthnCredential(ctx context.Context, arg UseWebauthnCredentialParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
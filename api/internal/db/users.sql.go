@@ -11,23 +11,254 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const anonymizeInactiveUsers = `-- name: AnonymizeInactiveUsers :execrows
+WITH inactive AS (
+    SELECT id
+    FROM users
+    WHERE updated_at < $1
+        AND deleted_at IS NULL
+        AND email NOT LIKE 'anonymized+%'
+),
+dropped_events AS (
+    DELETE FROM user_events
+    WHERE user_id IN (
+            SELECT id
+            FROM inactive
+        )
+),
+dropped_snapshots AS (
+    DELETE FROM user_snapshots
+    WHERE user_id IN (
+            SELECT id
+            FROM inactive
+        )
+),
+dropped_handles AS (
+    DELETE FROM user_handle_history
+    WHERE user_id IN (
+            SELECT id
+            FROM inactive
+        )
+),
+dropped_email_changes AS (
+    DELETE FROM email_changes
+    WHERE user_id IN (
+            SELECT id
+            FROM inactive
+        )
+)
+UPDATE users
+SET email = 'anonymized+' || id::text || '@invalid',
+    name = 'Anonymized User',
+    handle = NULL,
+    pending_email = NULL,
+    updated_at = NOW()
+WHERE updated_at < $1
+    AND deleted_at IS NULL
+    AND email NOT LIKE 'anonymized+%'
+`
+
+func (q *Queries) AnonymizeInactiveUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, anonymizeInactiveUsers, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const countInactiveUsers = `-- name: CountInactiveUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE updated_at < $1
+    AND deleted_at IS NULL
+    AND email NOT LIKE 'anonymized+%'
+`
+
+func (q *Queries) CountInactiveUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error) {
+	row := q.db.QueryRow(ctx, countInactiveUsers, cutoff)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPurgeableUsers = `-- name: CountPurgeableUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE deleted_at < $1
+`
+
+func (q *Queries) CountPurgeableUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error) {
+	row := q.db.QueryRow(ctx, countPurgeableUsers, cutoff)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (id, email, name, bio)
+VALUES ($1, $2, $3, $4)
+RETURNING id,
+    email,
+    name,
+    created_at,
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email
+`
+
+type CreateUserParams struct {
+	ID    pgtype.UUID `json:"id"`
+	Email string      `json:"email"`
+	Name  string      `json:"name"`
+	Bio   string      `json:"bio"`
+}
+
+type CreateUserRow struct {
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	Name            string             `json:"name"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	Phone           pgtype.Text        `json:"phone"`
+	PhoneVerifiedAt pgtype.Timestamptz `json:"phone_verified_at"`
+	Bio             string             `json:"bio"`
+	Handle          pgtype.Text        `json:"handle"`
+	PendingEmail    pgtype.Text        `json:"pending_email"`
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
+	row := q.db.QueryRow(ctx, createUser,
+		arg.ID,
+		arg.Email,
+		arg.Name,
+		arg.Bio,
+	)
+	var i CreateUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Phone,
+		&i.PhoneVerifiedAt,
+		&i.Bio,
+		&i.Handle,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const deactivateUser = `-- name: DeactivateUser :execrows
+UPDATE users
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NULL
+`
+
+func (q *Queries) DeactivateUser(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deactivateUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteDeactivatedUser = `-- name: DeleteDeactivatedUser :execrows
+DELETE FROM users
+WHERE id = $1
+    AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) DeleteDeactivatedUser(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDeactivatedUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const ensureProbeUser = `-- name: EnsureProbeUser :one
+INSERT INTO users (email, name)
+VALUES ($1, $2)
+ON CONFLICT (email) DO UPDATE
+SET deleted_at = NULL
+RETURNING id
+`
+
+type EnsureProbeUserParams struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+func (q *Queries) EnsureProbeUser(ctx context.Context, arg EnsureProbeUserParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, ensureProbeUser, arg.Email, arg.Name)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const findEmailOwner = `-- name: FindEmailOwner :one
+SELECT id,
+    deleted_at IS NOT NULL AS deactivated,
+    email = $1 AS current
+FROM users
+WHERE email = $1
+    OR pending_email = $1
+ORDER BY email = $1 DESC
+LIMIT 1
+`
+
+type FindEmailOwnerRow struct {
+	ID          pgtype.UUID `json:"id"`
+	Deactivated bool        `json:"deactivated"`
+	Current     bool        `json:"current"`
+}
+
+func (q *Queries) FindEmailOwner(ctx context.Context, email string) (FindEmailOwnerRow, error) {
+	row := q.db.QueryRow(ctx, findEmailOwner, email)
+	var i FindEmailOwnerRow
+	err := row.Scan(
+		&i.ID,
+		&i.Deactivated,
+		&i.Current,
+	)
+	return i, err
+}
+
 const getUserByID = `-- name: GetUserByID :one
 SELECT id,
     email,
     name,
     created_at,
-    updated_at
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email,
+    shadow_banned_at
 FROM users
 WHERE id = $1
     AND deleted_at IS NULL
 `
 
 type GetUserByIDRow struct {
-	ID        pgtype.UUID        `json:"id"`
-	Email     string             `json:"email"`
-	Name      string             `json:"name"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	Name            string             `json:"name"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	Phone           pgtype.Text        `json:"phone"`
+	PhoneVerifiedAt pgtype.Timestamptz `json:"phone_verified_at"`
+	Bio             string             `json:"bio"`
+	Handle          pgtype.Text        `json:"handle"`
+	PendingEmail    pgtype.Text        `json:"pending_email"`
+	ShadowBannedAt  pgtype.Timestamptz `json:"shadow_banned_at"`
 }
 
 func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (GetUserByIDRow, error) {
@@ -39,50 +270,77 @@ func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (GetUserByIDR
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Phone,
+		&i.PhoneVerifiedAt,
+		&i.Bio,
+		&i.Handle,
+		&i.PendingEmail,
+		&i.ShadowBannedAt,
 	)
 	return i, err
 }
 
-const listUsers = `-- name: ListUsers :many
+const listActiveUsersSnapshot = `-- name: ListActiveUsersSnapshot :many
 SELECT id,
     email,
     name,
     created_at,
-    updated_at
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email
 FROM users
 WHERE deleted_at IS NULL
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+    AND (
+        shadow_banned_at IS NULL
+        OR email = $1
+    )
+    AND id > $2
+ORDER BY id
+LIMIT $3
 `
 
-type ListUsersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type ListActiveUsersSnapshotParams struct {
+	Viewer   string      `json:"viewer"`
+	After    pgtype.UUID `json:"after"`
+	RowLimit int32       `json:"row_limit"`
 }
 
-type ListUsersRow struct {
-	ID        pgtype.UUID        `json:"id"`
-	Email     string             `json:"email"`
-	Name      string             `json:"name"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+type ListActiveUsersSnapshotRow struct {
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	Name            string             `json:"name"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	Phone           pgtype.Text        `json:"phone"`
+	PhoneVerifiedAt pgtype.Timestamptz `json:"phone_verified_at"`
+	Bio             string             `json:"bio"`
+	Handle          pgtype.Text        `json:"handle"`
+	PendingEmail    pgtype.Text        `json:"pending_email"`
 }
 
-func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
-	rows, err := q.db.Query(ctx, listUsers, arg.Limit, arg.Offset)
+func (q *Queries) ListActiveUsersSnapshot(ctx context.Context, arg ListActiveUsersSnapshotParams) ([]ListActiveUsersSnapshotRow, error) {
+	rows, err := q.db.Query(ctx, listActiveUsersSnapshot, arg.Viewer, arg.After, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListUsersRow{}
+	items := []ListActiveUsersSnapshotRow{}
 	for rows.Next() {
-		var i ListUsersRow
+		var i ListActiveUsersSnapshotRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Email,
 			&i.Name,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Phone,
+			&i.PhoneVerifiedAt,
+			&i.Bio,
+			&i.Handle,
+			&i.PendingEmail,
 		); err != nil {
 			return nil, err
 		}
@@ -93,3 +351,262 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUse
 	}
 	return items, nil
 }
+
+const lockUserForUpdate = `-- name: LockUserForUpdate :one
+SELECT id,
+    email,
+    name,
+    bio
+FROM users
+WHERE id = $1
+    AND deleted_at IS NULL
+FOR UPDATE
+`
+
+type LockUserForUpdateRow struct {
+	ID    pgtype.UUID `json:"id"`
+	Email string      `json:"email"`
+	Name  string      `json:"name"`
+	Bio   string      `json:"bio"`
+}
+
+func (q *Queries) LockUserForUpdate(ctx context.Context, id pgtype.UUID) (LockUserForUpdateRow, error) {
+	row := q.db.QueryRow(ctx, lockUserForUpdate, id)
+	var i LockUserForUpdateRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.Bio,
+	)
+	return i, err
+}
+
+const markUserPhoneVerified = `-- name: MarkUserPhoneVerified :execrows
+UPDATE users
+SET phone_verified_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+    AND phone = $2
+    AND deleted_at IS NULL
+`
+
+type MarkUserPhoneVerifiedParams struct {
+	ID    pgtype.UUID `json:"id"`
+	Phone pgtype.Text `json:"phone"`
+}
+
+func (q *Queries) MarkUserPhoneVerified(ctx context.Context, arg MarkUserPhoneVerifiedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markUserPhoneVerified, arg.ID, arg.Phone)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedUsers, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reactivateUser = `-- name: ReactivateUser :execrows
+UPDATE users
+SET deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) ReactivateUser(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, reactivateUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET email = $2,
+    name = $3,
+    bio = $4,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NULL
+RETURNING id,
+    email,
+    name,
+    created_at,
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email
+`
+
+type UpdateUserParams struct {
+	ID    pgtype.UUID `json:"id"`
+	Email string      `json:"email"`
+	Name  string      `json:"name"`
+	Bio   string      `json:"bio"`
+}
+
+type UpdateUserRow struct {
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	Name            string             `json:"name"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	Phone           pgtype.Text        `json:"phone"`
+	PhoneVerifiedAt pgtype.Timestamptz `json:"phone_verified_at"`
+	Bio             string             `json:"bio"`
+	Handle          pgtype.Text        `json:"handle"`
+	PendingEmail    pgtype.Text        `json:"pending_email"`
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (UpdateUserRow, error) {
+	row := q.db.QueryRow(ctx, updateUser,
+		arg.ID,
+		arg.Email,
+		arg.Name,
+		arg.Bio,
+	)
+	var i UpdateUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Phone,
+		&i.PhoneVerifiedAt,
+		&i.Bio,
+		&i.Handle,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const updateUserPhone = `-- name: UpdateUserPhone :one
+UPDATE users
+SET phone = $2,
+    phone_verified_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NULL
+RETURNING id,
+    email,
+    name,
+    created_at,
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email
+`
+
+type UpdateUserPhoneParams struct {
+	ID    pgtype.UUID `json:"id"`
+	Phone pgtype.Text `json:"phone"`
+}
+
+type UpdateUserPhoneRow struct {
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	Name            string             `json:"name"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	Phone           pgtype.Text        `json:"phone"`
+	PhoneVerifiedAt pgtype.Timestamptz `json:"phone_verified_at"`
+	Bio             string             `json:"bio"`
+	Handle          pgtype.Text        `json:"handle"`
+	PendingEmail    pgtype.Text        `json:"pending_email"`
+}
+
+func (q *Queries) UpdateUserPhone(ctx context.Context, arg UpdateUserPhoneParams) (UpdateUserPhoneRow, error) {
+	row := q.db.QueryRow(ctx, updateUserPhone, arg.ID, arg.Phone)
+	var i UpdateUserPhoneRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Phone,
+		&i.PhoneVerifiedAt,
+		&i.Bio,
+		&i.Handle,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET name = $2,
+    bio = $3,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NULL
+RETURNING id,
+    email,
+    name,
+    created_at,
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email
+`
+
+type UpdateUserProfileParams struct {
+	ID   pgtype.UUID `json:"id"`
+	Name string      `json:"name"`
+	Bio  string      `json:"bio"`
+}
+
+type UpdateUserProfileRow struct {
+	ID              pgtype.UUID        `json:"id"`
+	Email           string             `json:"email"`
+	Name            string             `json:"name"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	Phone           pgtype.Text        `json:"phone"`
+	PhoneVerifiedAt pgtype.Timestamptz `json:"phone_verified_at"`
+	Bio             string             `json:"bio"`
+	Handle          pgtype.Text        `json:"handle"`
+	PendingEmail    pgtype.Text        `json:"pending_email"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (UpdateUserProfileRow, error) {
+	row := q.db.QueryRow(ctx, updateUserProfile,
+		arg.ID,
+		arg.Name,
+		arg.Bio,
+	)
+	var i UpdateUserProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Phone,
+		&i.PhoneVerifiedAt,
+		&i.Bio,
+		&i.Handle,
+		&i.PendingEmail,
+	)
+	return i, err
+}
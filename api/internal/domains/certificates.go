@@ -0,0 +1,93 @@
+package domains
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"starterkit/internal/platform/storage"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Certificates provisions certificates for verified domains from an ACME
+// directory, answering its TLS-ALPN-01 challenges on the server's own
+// listener. Certificates and the account key are kept in storage, so every
+// replica shares them and they outlive restarts.
+type Certificates struct {
+	service *Service
+	manager *autocert.Manager
+}
+
+// NewCertificates creates the certificate manager of the verified domains
+// of service, kept in its storage
+func NewCertificates(service *Service) *Certificates {
+	c := &Certificates{service: service}
+	c.manager = &autocert.Manager{
+		Prompt: autocert.AcceptTOS,
+		Email:  service.cfg.ACMEEmail,
+		Client: &acme.Client{DirectoryURL: service.cfg.ACMEDirectoryURL},
+		Cache:  certificateCache{store: service.store},
+		HostPolicy: func(_ context.Context, host string) error {
+			if _, ok := service.Resolve(host); !ok {
+				return fmt.Errorf("%s is not a verified domain", host)
+			}
+			return nil
+		},
+	}
+	return c
+}
+
+// Apply makes config serve the certificates of verified domains, and the
+// ACME challenges for them. Other hosts keep the static certificate.
+func (c *Certificates) Apply(config *tls.Config) {
+	for _, proto := range []string{"h2", "http/1.1", acme.ALPNProto} {
+		if !slices.Contains(config.NextProtos, proto) {
+			config.NextProtos = append(config.NextProtos, proto)
+		}
+	}
+	config.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if _, ok := c.service.Resolve(hello.ServerName); !ok {
+			// nil lets crypto/tls fall back to config.Certificates
+			return nil, nil
+		}
+		return c.manager.GetCertificate(hello)
+	}
+}
+
+// certificateCache keeps autocert's certificates and account key in storage
+type certificateCache struct {
+	store storage.Storage
+}
+
+func (c certificateCache) Get(ctx context.Context, key string) ([]byte, error) {
+	rc, err := c.store.Get(ctx, certificateKey(key))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, autocert.ErrCacheMiss
+		}
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func (c certificateCache) Put(ctx context.Context, key string, data []byte) error {
+	return c.store.Put(ctx, certificateKey(key), bytes.NewReader(data))
+}
+
+func (c certificateCache) Delete(ctx context.Context, key string) error {
+	if err := c.store.Delete(ctx, certificateKey(key)); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func certificateKey(key string) string {
+	return "acme/" + key
+}
@@ -0,0 +1,85 @@
+package domains
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CookieWriter rewrites the cookies a response sets to the cookie settings
+// of the custom domain the request was made on, before the header is sent
+type CookieWriter struct {
+	http.ResponseWriter
+	domain  *Domain
+	written bool
+}
+
+// NewCookieWriter returns w rewriting cookies for d
+func NewCookieWriter(w http.ResponseWriter, d *Domain) *CookieWriter {
+	return &CookieWriter{ResponseWriter: w, domain: d}
+}
+
+func (w *CookieWriter) WriteHeader(code int) {
+	w.rewrite()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *CookieWriter) Write(p []byte) (int, error) {
+	w.rewrite()
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush rewrites cookies first, as flushing sends the header
+func (w *CookieWriter) Flush() {
+	w.rewrite()
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *CookieWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// rewrite sets the Domain, SameSite, and Secure attributes of each
+// Set-Cookie header. Cookies that do not parse are left as they are.
+func (w *CookieWriter) rewrite() {
+	if w.written {
+		return
+	}
+	w.written = true
+	if w.domain.CookieDomain == "" && w.domain.CookieSameSite == "" {
+		return
+	}
+
+	header := w.Header()
+	values := header.Values("Set-Cookie")
+	if len(values) == 0 {
+		return
+	}
+	rewritten := make([]string, 0, len(values))
+	for _, value := range values {
+		cookie, err := http.ParseSetCookie(value)
+		if err != nil {
+			rewritten = append(rewritten, value)
+			continue
+		}
+		if w.domain.CookieDomain != "" {
+			cookie.Domain = w.domain.CookieDomain
+		}
+		switch w.domain.CookieSameSite {
+		case SameSiteLax:
+			cookie.SameSite = http.SameSiteLaxMode
+		case SameSiteStrict:
+			cookie.SameSite = http.SameSiteStrictMode
+		case SameSiteNone:
+			// browsers drop SameSite=None cookies that are not Secure
+			cookie.SameSite = http.SameSiteNoneMode
+			cookie.Secure = true
+		}
+		if s := cookie.String(); s != "" && !strings.ContainsAny(s, "\r\n") {
+			rewritten = append(rewritten, s)
+		} else {
+			rewritten = append(rewritten, value)
+		}
+	}
+	header["Set-Cookie"] = rewritten
+}
@@ -0,0 +1,145 @@
+package domains
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/request"
+)
+
+type ServiceInterface interface {
+	Add(ctx context.Context, req AddRequest, actor string) (*Domain, error)
+	Get(ctx context.Context, domain string) (*Domain, error)
+	List(ctx context.Context, tenantID string) ([]Domain, error)
+	Update(ctx context.Context, domain string, settings Settings, actor string) (*Domain, error)
+	Remove(ctx context.Context, domain, actor string) error
+	Verify(ctx context.Context, domain, actor string) (*Domain, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleList returns the domains of the tenant in the tenant_id query
+// parameter, or of every tenant without it
+func (h *Handler) HandleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := h.service.List(r.Context(), r.URL.Query().Get("tenant_id"))
+		if err != nil {
+			h.respondWithDomainError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, map[string]any{"domains": list})
+	}
+}
+
+// HandleAdd adds a pending domain to a tenant. The response has the TXT
+// record to create; the domain is verified once it is found.
+func (h *Handler) HandleAdd() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AddRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		d, err := h.service.Add(r.Context(), req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithDomainError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Location", "/admin/tenant-domains/"+d.Domain)
+		h.respondWithJSON(w, http.StatusCreated, d)
+	}
+}
+
+// HandleGet returns a domain
+func (h *Handler) HandleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		d, err := h.service.Get(r.Context(), r.PathValue("domain"))
+		if err != nil {
+			h.respondWithDomainError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, d)
+	}
+}
+
+// HandleUpdate replaces the CORS and cookie settings of a domain
+func (h *Handler) HandleUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := UpdateRequest{domain: Normalize(r.PathValue("domain"))}
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		d, err := h.service.Update(r.Context(), req.domain, req.Settings, actorFromRequest(r))
+		if err != nil {
+			h.respondWithDomainError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, d)
+	}
+}
+
+// HandleRemove deletes a domain and its certificate
+func (h *Handler) HandleRemove() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h.service.Remove(r.Context(), r.PathValue("domain"), actorFromRequest(r)); err != nil {
+			h.respondWithDomainError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleVerify looks up the verification record of a domain now. The
+// response is the domain, with check_error set if it is still pending.
+func (h *Handler) HandleVerify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		d, err := h.service.Verify(r.Context(), r.PathValue("domain"), actorFromRequest(r))
+		if err != nil {
+			h.respondWithDomainError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, d)
+	}
+}
+
+func (h *Handler) respondWithDomainError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrDomainNotFound):
+		apierror.Write(w, r, apierror.NotFound("domain_not_found", err.Error()))
+	case errors.Is(err, ErrDomainTaken):
+		apierror.Write(w, r, apierror.Conflict("domain_taken", err.Error()))
+	default:
+		h.logger.Error("domain request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
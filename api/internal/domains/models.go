@@ -0,0 +1,179 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"starterkit/internal/platform/request"
+)
+
+const (
+	maxDomainLength   = 253
+	maxCORSOrigins    = 20
+	recordType        = "TXT"
+	verificationValue = "starterkit-verification="
+)
+
+var (
+	ErrDomainNotFound = errors.New("domain not found")
+	ErrDomainTaken    = errors.New("domain is already added")
+)
+
+// Domain statuses. Requests are only routed to a domain's tenant, and
+// certificates only provisioned for it, once it is verified.
+const (
+	StatusPending  = "pending"
+	StatusVerified = "verified"
+)
+
+// SameSite values of the cookies set on a domain
+const (
+	SameSiteLax    = "lax"
+	SameSiteStrict = "strict"
+	SameSiteNone   = "none"
+)
+
+var (
+	labelPattern  = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	tenantPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,99}$`)
+)
+
+// Domain is a host name a tenant serves the app on
+type Domain struct {
+	Domain        string       `json:"domain" example:"app.example.com"`
+	TenantID      string       `json:"tenant_id" example:"acme"`
+	Status        string       `json:"status" enum:"pending,verified"`
+	Verification  Verification `json:"verification"`
+	Settings                   // per-domain CORS and cookie settings
+	VerifiedAt    *time.Time   `json:"verified_at,omitempty"`
+	LastCheckedAt *time.Time   `json:"last_checked_at,omitempty"`
+	CheckError    string       `json:"check_error,omitempty" doc:"Why the last lookup of the verification record failed"`
+	CreatedBy     string       `json:"created_by"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// Verification is the DNS record proving the tenant controls a domain
+type Verification struct {
+	RecordName  string `json:"record_name" example:"_starterkit-challenge.app.example.com"`
+	RecordType  string `json:"record_type" example:"TXT"`
+	RecordValue string `json:"record_value"`
+}
+
+// Settings are how requests on a domain are treated
+type Settings struct {
+	CORSOrigins    []string `json:"cors_origins,omitempty" doc:"Origins allowed to call the API on this domain; https://<domain> when empty"`
+	CookieDomain   string   `json:"cookie_domain,omitempty" doc:"Domain attribute of the cookies set on this domain: the domain or a parent of it"`
+	CookieSameSite string   `json:"cookie_same_site,omitempty" enum:"lax,strict,none" doc:"SameSite attribute of the cookies set on this domain"`
+}
+
+// AllowedOrigins returns the origins allowed to call the API on d
+func (d *Domain) AllowedOrigins() []string {
+	if len(d.CORSOrigins) > 0 {
+		return d.CORSOrigins
+	}
+	return []string{"https://" + d.Domain}
+}
+
+// AddRequest adds a domain to a tenant
+type AddRequest struct {
+	Domain   string `json:"domain" example:"app.example.com"`
+	TenantID string `json:"tenant_id" example:"acme"`
+	Settings
+}
+
+// Validate normalizes the domain to lowercase without a trailing dot
+func (r *AddRequest) Validate() error {
+	r.Domain = Normalize(r.Domain)
+
+	var v request.Validation
+	v.Check(validDomain(r.Domain), "domain", fmt.Sprintf("must be a host name of at most %d characters", maxDomainLength))
+	v.Check(tenantPattern.MatchString(r.TenantID), "tenant_id", "must be up to 100 letters, digits, dots, underscores, or hyphens, starting with a letter or digit")
+	r.Settings.validate(&v, r.Domain)
+	return v.Err()
+}
+
+// UpdateRequest replaces the settings of a domain
+type UpdateRequest struct {
+	Settings
+	// domain is set from the path before decoding, to check CookieDomain
+	domain string
+}
+
+func (r *UpdateRequest) Validate() error {
+	var v request.Validation
+	r.Settings.validate(&v, r.domain)
+	return v.Err()
+}
+
+func (s *Settings) validate(v *request.Validation, domain string) {
+	s.CookieDomain = Normalize(s.CookieDomain)
+	s.CookieSameSite = strings.ToLower(s.CookieSameSite)
+
+	v.Check(len(s.CORSOrigins) <= maxCORSOrigins, "cors_origins", fmt.Sprintf("must have at most %d origins", maxCORSOrigins))
+	for i, origin := range s.CORSOrigins {
+		v.Check(validOrigin(origin), fmt.Sprintf("cors_origins[%d]", i), "must be an http or https origin, such as https://app.example.com or https://*.example.com")
+	}
+	if s.CookieDomain != "" {
+		v.Check(s.CookieDomain == domain || strings.HasSuffix(domain, "."+s.CookieDomain), "cookie_domain", "must be the domain or a parent of it")
+	}
+	switch s.CookieSameSite {
+	case "", SameSiteLax, SameSiteStrict, SameSiteNone:
+	default:
+		v.Check(false, "cookie_same_site", "must be lax, strict, or none")
+	}
+}
+
+// Normalize returns host without a port or trailing dot, in lowercase
+func Normalize(host string) string {
+	host = strings.TrimSpace(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// validDomain reports whether domain is a normalized host name of at least
+// two labels. IP addresses are not, as their last label is numeric.
+func validDomain(domain string) bool {
+	if len(domain) > maxDomainLength {
+		return false
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if !labelPattern.MatchString(label) {
+			return false
+		}
+	}
+	return strings.Trim(labels[len(labels)-1], "0123456789") != ""
+}
+
+// validOrigin reports whether origin is a scheme and host, optionally with
+// a port, where the host may start with a *. subdomain wildcard
+func validOrigin(origin string) bool {
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://", 1))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.Path == "" && u.RawQuery == "" && u.User == nil && u.Fragment == ""
+}
+
+type contextKey struct{}
+
+// WithDomain returns ctx carrying the custom domain a request was made on
+func WithDomain(ctx context.Context, d *Domain) context.Context {
+	return context.WithValue(ctx, contextKey{}, d)
+}
+
+// FromContext returns the custom domain a request was made on, if any
+func FromContext(ctx context.Context) (*Domain, bool) {
+	d, ok := ctx.Value(contextKey{}).(*Domain)
+	return d, ok
+}
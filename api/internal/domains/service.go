@@ -0,0 +1,344 @@
+package domains
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// verifyBatchSize bounds the pending domains looked up per run
+const verifyBatchSize = 100
+
+type Querier interface {
+	CreateTenantDomain(ctx context.Context, arg db.CreateTenantDomainParams) (db.TenantDomain, error)
+	GetTenantDomain(ctx context.Context, domain string) (db.TenantDomain, error)
+	ListTenantDomains(ctx context.Context, tenantID pgtype.Text) ([]db.TenantDomain, error)
+	ListVerifiedTenantDomains(ctx context.Context) ([]db.TenantDomain, error)
+	ListUnverifiedTenantDomains(ctx context.Context, arg db.ListUnverifiedTenantDomainsParams) ([]db.TenantDomain, error)
+	UpdateTenantDomainSettings(ctx context.Context, arg db.UpdateTenantDomainSettingsParams) (db.TenantDomain, error)
+	RecordTenantDomainCheck(ctx context.Context, arg db.RecordTenantDomainCheckParams) (db.TenantDomain, error)
+	DeleteTenantDomain(ctx context.Context, domain string) (int64, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Resolver looks up the TXT records of a name; net.Resolver implements it
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Service keeps the custom domains tenants serve the app on. A domain is
+// added pending, and verified once the TXT record named by its
+// verification is found. Verified domains are read into memory by Reload,
+// so resolving a request's host never waits on the database.
+type Service struct {
+	queries  Querier
+	store    storage.Storage
+	auditor  Auditor
+	resolver Resolver
+	cfg      config.DomainsConfig
+	logger   *slog.Logger
+
+	mu       sync.RWMutex
+	verified map[string]*Domain
+}
+
+// NewService creates the custom domains. store keeps the certificates
+// provisioned for them, so removing a domain can delete its certificate.
+func NewService(queries Querier, store storage.Storage, auditor Auditor, resolver Resolver, cfg config.DomainsConfig, logger *slog.Logger) *Service {
+	return &Service{
+		queries:  queries,
+		store:    store,
+		auditor:  auditor,
+		resolver: resolver,
+		cfg:      cfg,
+		logger:   logger,
+		verified: make(map[string]*Domain),
+	}
+}
+
+// Resolve returns the verified domain host names, if any
+func (s *Service) Resolve(host string) (*Domain, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.verified[Normalize(host)]
+	return d, ok
+}
+
+// Reload reads the verified domains from the database
+func (s *Service) Reload(ctx context.Context) error {
+	rows, err := s.queries.ListVerifiedTenantDomains(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list verified domains: %w", err)
+	}
+	verified := make(map[string]*Domain, len(rows))
+	for _, row := range rows {
+		verified[row.Domain] = s.toDomain(row)
+	}
+	s.mu.Lock()
+	s.verified = verified
+	s.mu.Unlock()
+	return nil
+}
+
+// Add adds a pending domain to a tenant, returning the TXT record to
+// create to verify it
+func (s *Service) Add(ctx context.Context, req AddRequest, actor string) (*Domain, error) {
+	row, err := s.queries.CreateTenantDomain(ctx, db.CreateTenantDomainParams{
+		Domain:            req.Domain,
+		TenantID:          req.TenantID,
+		VerificationToken: rand.Text(),
+		CorsOrigins:       nonNil(req.CORSOrigins),
+		CookieDomain:      optionalText(req.CookieDomain),
+		CookieSameSite:    optionalText(req.CookieSameSite),
+		CreatedBy:         actor,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDomainTaken
+		}
+		return nil, fmt.Errorf("failed to add domain: %w", err)
+	}
+
+	s.logger.Info("domain added", "domain", row.Domain, "tenant_id", row.TenantID, "actor", actor)
+	s.record(ctx, actor, "tenant_domain.added", row.Domain, map[string]any{"tenant_id": row.TenantID})
+	return s.toDomain(row), nil
+}
+
+// Get returns a domain, verified or not
+func (s *Service) Get(ctx context.Context, domain string) (*Domain, error) {
+	row, err := s.queries.GetTenantDomain(ctx, Normalize(domain))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDomainNotFound
+		}
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	return s.toDomain(row), nil
+}
+
+// List returns the domains of tenantID, or of every tenant when it is empty
+func (s *Service) List(ctx context.Context, tenantID string) ([]Domain, error) {
+	rows, err := s.queries.ListTenantDomains(ctx, optionalText(tenantID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	list := make([]Domain, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, *s.toDomain(row))
+	}
+	return list, nil
+}
+
+// Update replaces the CORS and cookie settings of a domain
+func (s *Service) Update(ctx context.Context, domain string, settings Settings, actor string) (*Domain, error) {
+	row, err := s.queries.UpdateTenantDomainSettings(ctx, db.UpdateTenantDomainSettingsParams{
+		CorsOrigins:    nonNil(settings.CORSOrigins),
+		CookieDomain:   optionalText(settings.CookieDomain),
+		CookieSameSite: optionalText(settings.CookieSameSite),
+		Domain:         Normalize(domain),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDomainNotFound
+		}
+		return nil, fmt.Errorf("failed to update domain: %w", err)
+	}
+
+	d := s.toDomain(row)
+	s.cache(d)
+	s.record(ctx, actor, "tenant_domain.updated", d.Domain, map[string]any{
+		"cors_origins":     d.CORSOrigins,
+		"cookie_domain":    d.CookieDomain,
+		"cookie_same_site": d.CookieSameSite,
+	})
+	return d, nil
+}
+
+// Remove deletes a domain and the certificate provisioned for it. Requests
+// on it stop resolving to the tenant at once on this replica, and on the
+// others after their next reload.
+func (s *Service) Remove(ctx context.Context, domain, actor string) error {
+	domain = Normalize(domain)
+	deleted, err := s.queries.DeleteTenantDomain(ctx, domain)
+	if err != nil {
+		return fmt.Errorf("failed to delete domain: %w", err)
+	}
+	s.mu.Lock()
+	delete(s.verified, domain)
+	s.mu.Unlock()
+	if deleted == 0 {
+		return ErrDomainNotFound
+	}
+
+	// autocert caches an ECDSA certificate under the domain, and an RSA one
+	// for clients without ECDSA support
+	for _, key := range []string{certificateKey(domain), certificateKey(domain + "+rsa")} {
+		if err := s.store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			s.logger.Warn("failed to delete domain certificate", "error", err, "domain", domain)
+		}
+	}
+
+	s.logger.Info("domain removed", "domain", domain, "actor", actor)
+	s.record(ctx, actor, "tenant_domain.removed", domain, nil)
+	return nil
+}
+
+// Verify looks up the verification record of a domain now, instead of
+// waiting for the next scheduled check. A verified domain stays verified.
+func (s *Service) Verify(ctx context.Context, domain, actor string) (*Domain, error) {
+	d, err := s.Get(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status == StatusVerified {
+		return d, nil
+	}
+	return s.check(ctx, d, actor)
+}
+
+// VerifyPending looks up the verification records of the domains added
+// within the verify window that are not verified yet
+func (s *Service) VerifyPending(ctx context.Context) error {
+	rows, err := s.queries.ListUnverifiedTenantDomains(ctx, db.ListUnverifiedTenantDomainsParams{
+		CreatedAfter: pgtype.Timestamptz{Time: time.Now().Add(-s.cfg.VerifyWindow), Valid: true},
+		RowLimit:     verifyBatchSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pending domains: %w", err)
+	}
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := s.check(ctx, s.toDomain(row), audit.SystemActor); err != nil {
+			s.logger.Warn("failed to verify domain", "error", err, "domain", row.Domain)
+		}
+	}
+	return nil
+}
+
+// check looks up the verification record of d and records the outcome
+func (s *Service) check(ctx context.Context, d *Domain, actor string) (*Domain, error) {
+	var checkErr string
+	records, err := s.resolver.LookupTXT(ctx, d.Verification.RecordName)
+	switch {
+	case err != nil:
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			checkErr = "no TXT record found at " + d.Verification.RecordName
+		} else {
+			checkErr = "failed to look up " + d.Verification.RecordName
+			s.logger.Warn("failed to look up domain verification record", "error", err, "domain", d.Domain)
+		}
+	case !slices.Contains(records, d.Verification.RecordValue):
+		checkErr = "TXT record at " + d.Verification.RecordName + " does not have the expected value"
+	}
+
+	row, err := s.queries.RecordTenantDomainCheck(ctx, db.RecordTenantDomainCheckParams{
+		Verified:   checkErr == "",
+		CheckError: optionalText(checkErr),
+		Domain:     d.Domain,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDomainNotFound
+		}
+		return nil, fmt.Errorf("failed to record domain check: %w", err)
+	}
+
+	checked := s.toDomain(row)
+	if checked.Status == StatusVerified {
+		s.cache(checked)
+		s.logger.Info("domain verified", "domain", checked.Domain, "tenant_id", checked.TenantID)
+		s.record(ctx, actor, "tenant_domain.verified", checked.Domain, map[string]any{"tenant_id": checked.TenantID})
+	}
+	return checked, nil
+}
+
+// cache replaces d in the verified domains of this replica, if verified
+func (s *Service) cache(d *Domain) {
+	if d.Status != StatusVerified {
+		return
+	}
+	s.mu.Lock()
+	s.verified[d.Domain] = d
+	s.mu.Unlock()
+}
+
+func (s *Service) record(ctx context.Context, actor, action, domain string, metadata map[string]any) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "tenant_domain",
+		ResourceID:   domain,
+		Metadata:     metadata,
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+func (s *Service) toDomain(row db.TenantDomain) *Domain {
+	d := &Domain{
+		Domain:   row.Domain,
+		TenantID: row.TenantID,
+		Status:   StatusPending,
+		Verification: Verification{
+			RecordName:  s.cfg.ChallengeLabel + "." + row.Domain,
+			RecordType:  recordType,
+			RecordValue: verificationValue + row.VerificationToken,
+		},
+		Settings: Settings{
+			CORSOrigins:    row.CorsOrigins,
+			CookieDomain:   row.CookieDomain.String,
+			CookieSameSite: row.CookieSameSite.String,
+		},
+		CheckError: row.CheckError.String,
+		CreatedBy:  row.CreatedBy,
+		CreatedAt:  row.CreatedAt.Time,
+		UpdatedAt:  row.UpdatedAt.Time,
+	}
+	if row.VerifiedAt.Valid {
+		d.Status = StatusVerified
+		d.VerifiedAt = &row.VerifiedAt.Time
+	}
+	if row.LastCheckedAt.Valid {
+		d.LastCheckedAt = &row.LastCheckedAt.Time
+	}
+	return d
+}
+
+func optionalText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// nonNil returns origins, or an empty list when nil, as cors_origins is
+// not nullable
+func nonNil(origins []string) []string {
+	if origins == nil {
+		return []string{}
+	}
+	return origins
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,222 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	HandleWebhook(provider string, r *http.Request) error
+	GetMessage(ctx context.Context, id uuid.UUID) (*Message, error)
+	ListMessages(ctx context.Context, userID, notificationID, campaignID *uuid.UUID, status string, limit int) ([]*Message, error)
+	ListSuppressions(ctx context.Context, limit int) ([]*Suppression, error)
+	Suppress(ctx context.Context, address, detail, actor string) (*Suppression, error)
+	Unsuppress(ctx context.Context, address, actor string) error
+}
+
+type Handler struct {
+	service       ServiceInterface
+	webhookSecret string
+	logger        *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, webhookSecret string, logger *slog.Logger) *Handler {
+	return &Handler{
+		service:       service,
+		webhookSecret: webhookSecret,
+		logger:        logger,
+	}
+}
+
+// HandleWebhook receives bounce, complaint, and delivery events from the
+// email provider named in the path. The provider authenticates with HTTP basic auth whose
+// password is EMAIL_WEBHOOK_SECRET.
+func (h *Handler) HandleWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.webhookSecret == "" {
+			apierror.Write(w, r, apierror.NotFound("webhooks_not_configured", "email webhooks are not configured"))
+			return
+		}
+		_, password, ok := r.BasicAuth()
+		if !ok || !auth.Equal(password, h.webhookSecret) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="email-webhooks"`)
+			apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "invalid_webhook_credentials", "invalid webhook credentials"))
+			return
+		}
+
+		if err := h.service.HandleWebhook(r.PathValue("provider"), r); err != nil {
+			if errors.Is(err, ErrWebhooksUnsupported) {
+				apierror.Write(w, r, apierror.NotFound("webhooks_unsupported", err.Error()))
+				return
+			}
+			// A non-2xx response makes the provider retry the webhook
+			h.logger.Error("failed to handle email webhook", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleListMessages lists recent messages and their delivery status,
+// optionally by user_id, notification_id, campaign_id, or status
+func (h *Handler) HandleListMessages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, ok := h.parseLimit(w, r)
+		if !ok {
+			return
+		}
+		userID, ok := h.parseOptionalID(w, r, "user_id")
+		if !ok {
+			return
+		}
+		notificationID, ok := h.parseOptionalID(w, r, "notification_id")
+		if !ok {
+			return
+		}
+		campaignID, ok := h.parseOptionalID(w, r, "campaign_id")
+		if !ok {
+			return
+		}
+
+		messages, err := h.service.ListMessages(r.Context(), userID, notificationID, campaignID, r.URL.Query().Get("status"), limit)
+		if err != nil {
+			h.logger.Error("failed to list email messages", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"messages": messages,
+		})
+	}
+}
+
+// HandleGetMessage returns a message and its delivery status
+func (h *Handler) HandleGetMessage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_message_id", "invalid message ID format"))
+			return
+		}
+		msg, err := h.service.GetMessage(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, ErrMessageNotFound) {
+				apierror.Write(w, r, apierror.NotFound("email_message_not_found", "email message not found"))
+				return
+			}
+			h.logger.Error("failed to get email message", "error", err, "message_id", id)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, msg)
+	}
+}
+
+// HandleListSuppressions lists the most recently suppressed addresses
+func (h *Handler) HandleListSuppressions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, ok := h.parseLimit(w, r)
+		if !ok {
+			return
+		}
+		suppressions, err := h.service.ListSuppressions(r.Context(), limit)
+		if err != nil {
+			h.logger.Error("failed to list email suppressions", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"suppressions": suppressions,
+		})
+	}
+}
+
+// HandleSuppress stops email to an address
+func (h *Handler) HandleSuppress() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Email  string `json:"email"`
+			Detail string `json:"detail"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+		suppression, err := h.service.Suppress(r.Context(), req.Email, req.Detail, actorFromRequest(r))
+		if err != nil {
+			if errors.Is(err, ErrInvalidAddress) {
+				apierror.Write(w, r, apierror.Unprocessable("invalid_address", err.Error()))
+				return
+			}
+			h.logger.Error("failed to suppress email address", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, http.StatusCreated, suppression)
+	}
+}
+
+// HandleUnsuppress allows email to an address again
+func (h *Handler) HandleUnsuppress() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h.service.Unsuppress(r.Context(), r.PathValue("email"), actorFromRequest(r)); err != nil {
+			if errors.Is(err, ErrSuppressionNotFound) {
+				apierror.Write(w, r, apierror.NotFound("email_suppression_not_found", "email suppression not found"))
+				return
+			}
+			h.logger.Error("failed to delete email suppression", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	limit := 50 // default
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit < 1 {
+			apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid limit parameter"))
+			return 0, false
+		}
+		limit = parsedLimit
+	}
+	return limit, true
+}
+
+func (h *Handler) parseOptionalID(w http.ResponseWriter, r *http.Request, param string) (*uuid.UUID, bool) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid "+param+" parameter"))
+		return nil, false
+	}
+	return &id, true
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// actorFromRequest identifies the caller for audit purposes
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
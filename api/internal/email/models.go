@@ -0,0 +1,95 @@
+package email
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMessageNotFound     = errors.New("email message not found")
+	ErrSuppressionNotFound = errors.New("email suppression not found")
+	ErrInvalidAddress      = errors.New("invalid email address")
+	ErrInvalidAttachment   = errors.New("invalid email attachment")
+	ErrWebhooksUnsupported = errors.New("email provider is not configured or does not send webhooks")
+)
+
+// Message statuses. Messages start queued, or suppressed when the address
+// is on the suppression list; provider webhooks move sent messages on to
+// delivered, bounced, or complained.
+const (
+	StatusQueued     = "queued"
+	StatusSuppressed = "suppressed"
+	StatusSent       = "sent"
+	StatusFailed     = "failed"
+	StatusDelivered  = "delivered"
+	StatusBounced    = "bounced"
+	StatusComplained = "complained"
+)
+
+// Suppression reasons
+const (
+	ReasonBounce    = "bounce"
+	ReasonComplaint = "complaint"
+	ReasonManual    = "manual"
+)
+
+// Email is an outbound message to queue
+type Email struct {
+	// UserID links the message to a user account, if any, so it is deleted
+	// with the account
+	UserID *uuid.UUID
+	// CampaignID links the message to the campaign that sent it. Campaign
+	// messages are sent in the job queue's low priority lane, behind
+	// transactional email.
+	CampaignID *uuid.UUID
+	To         string
+	Subject    string
+	// Body is the plain text version and HTML the HTML version; either may
+	// be empty
+	Body        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Attachment is a file in the storage backend sent with a message. With a
+// ContentID it is shown inline where the HTML body refers to
+// cid:<ContentID>. The file is read when the message is sent.
+type Attachment struct {
+	Key         string `json:"key"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+// Message is a queued or sent email and its delivery status
+type Message struct {
+	ID     uuid.UUID  `json:"id"`
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+	// NotificationID is the notification delivery job that sent the message
+	NotificationID *uuid.UUID `json:"notification_id,omitempty"`
+	// CampaignID is the campaign that sent the message
+	CampaignID        *uuid.UUID `json:"campaign_id,omitempty"`
+	To                string     `json:"to"`
+	Subject           string     `json:"subject"`
+	Status            string     `json:"status"`
+	ProviderMessageID string     `json:"provider_message_id,omitempty"`
+	Attempts          int        `json:"attempts"`
+	LastError         *string    `json:"last_error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+	// OpenedAt is when the recipient first opened the message, for
+	// providers that track opens
+	OpenedAt    *time.Time   `json:"opened_at,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Suppression is an address that is no longer mailed
+type Suppression struct {
+	Email     string    `json:"email"`
+	Reason    string    `json:"reason"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
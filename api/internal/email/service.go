@@ -0,0 +1,607 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+	"starterkit/internal/db"
+	"starterkit/internal/jobs"
+	"starterkit/internal/platform/kpi"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/mailer"
+	"starterkit/internal/platform/metrics"
+	"starterkit/internal/platform/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+)
+
+// JobSend is the job kind that sends one queued message
+const JobSend = "email.send"
+
+type Querier interface {
+	CreateEmailMessage(ctx context.Context, arg db.CreateEmailMessageParams) (db.EmailMessage, error)
+	GetEmailMessage(ctx context.Context, id pgtype.UUID) (db.EmailMessage, error)
+	ListEmailMessages(ctx context.Context, arg db.ListEmailMessagesParams) ([]db.EmailMessage, error)
+	MarkEmailSent(ctx context.Context, arg db.MarkEmailSentParams) error
+	UpdateQueuedEmail(ctx context.Context, arg db.UpdateQueuedEmailParams) error
+	SetEmailStatusByProviderID(ctx context.Context, arg db.SetEmailStatusByProviderIDParams) (db.EmailMessage, error)
+	MarkEmailOpenedByProviderID(ctx context.Context, arg db.MarkEmailOpenedByProviderIDParams) (int64, error)
+	DeleteOldEmailMessages(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	IsEmailSuppressed(ctx context.Context, lower string) (bool, error)
+	UpsertEmailSuppression(ctx context.Context, arg db.UpsertEmailSuppressionParams) (db.EmailSuppression, error)
+	ListEmailSuppressions(ctx context.Context, limit int32) ([]db.EmailSuppression, error)
+	DeleteEmailSuppression(ctx context.Context, lower string) (int64, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Enqueuer queues background jobs
+type Enqueuer interface {
+	Schedule(ctx context.Context, kind string, payload any, opts jobs.Options) (*jobs.Job, error)
+}
+
+// Sender sends through the configured providers and finds the one a
+// webhook comes from
+type Sender interface {
+	mailer.Sender
+	Webhook(provider string) (mailer.WebhookParser, bool)
+}
+
+// sendJob is the payload of a JobSend job
+type sendJob struct {
+	MessageID uuid.UUID `json:"message_id"`
+}
+
+// eventTransitions lists the statuses each webhook event may move a message
+// from, so late or repeated events do not undo a later outcome
+var eventTransitions = map[string]struct {
+	status string
+	from   []string
+}{
+	mailer.EventDelivered:  {StatusDelivered, []string{StatusSent}},
+	mailer.EventBounced:    {StatusBounced, []string{StatusSent, StatusDelivered}},
+	mailer.EventComplained: {StatusComplained, []string{StatusSent, StatusDelivered, StatusBounced}},
+}
+
+// Service queues outbound email and sends it from the job queue at the
+// provider's rate limit, skipping suppressed addresses
+type Service struct {
+	queries   Querier
+	sender    Sender
+	store     storage.Storage
+	jobs      Enqueuer
+	auditor   Auditor
+	limiter   *rate.Limiter
+	retention time.Duration
+	// maxAttachmentSize bounds how much is read from storage per message
+	maxAttachmentSize int64
+	inlineImages      map[string]string
+	messages          metric.Int64Counter
+	logger            *slog.Logger
+}
+
+func NewService(queries Querier, sender Sender, store storage.Storage, jobs Enqueuer, auditor Auditor, cfg config.EmailConfig, logger *slog.Logger) *Service {
+	limit := rate.Inf
+	if cfg.RateLimit > 0 {
+		limit = rate.Limit(cfg.RateLimit)
+	}
+	return &Service{
+		queries:           queries,
+		sender:            sender,
+		store:             store,
+		jobs:              jobs,
+		auditor:           auditor,
+		limiter:           rate.NewLimiter(limit, max(cfg.Burst, 1)),
+		retention:         cfg.Retention,
+		maxAttachmentSize: cfg.MaxAttachmentSize,
+		inlineImages:      cfg.InlineImages,
+		messages:          metrics.Int64Counter(metrics.EmailMessages),
+		logger:            logger,
+	}
+}
+
+// Queue stores a message and queues it for sending. Messages to suppressed
+// addresses are stored with status suppressed and never sent. Inside a
+// notification delivery job, the job's ID is kept as the notification ID,
+// unless the message belongs to a campaign. Configured inline images the
+// HTML body refers to are attached.
+func (s *Service) Queue(ctx context.Context, email Email) (*Message, error) {
+	addr, err := mail.ParseAddress(email.To)
+	if err != nil {
+		return nil, ErrInvalidAddress
+	}
+	attachments := s.withInlineImages(email.HTML, email.Attachments)
+	for _, a := range attachments {
+		if a.Key == "" || a.Filename == "" {
+			return nil, fmt.Errorf("%w: key and filename are required", ErrInvalidAttachment)
+		}
+		if a.ContentID != "" && !strings.Contains(email.HTML, "cid:"+a.ContentID) {
+			return nil, fmt.Errorf("%w: HTML body does not refer to cid:%s", ErrInvalidAttachment, a.ContentID)
+		}
+	}
+	encoded, err := json.Marshal(attachments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode email attachments: %w", err)
+	}
+	suppressed, err := s.queries.IsEmailSuppressed(ctx, addr.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email suppression: %w", err)
+	}
+
+	params := db.CreateEmailMessageParams{
+		ToAddress:   addr.Address,
+		Subject:     email.Subject,
+		Body:        email.Body,
+		HtmlBody:    email.HTML,
+		Attachments: encoded,
+		Status:      StatusQueued,
+	}
+	if email.UserID != nil {
+		params.UserID = pgtype.UUID{Bytes: *email.UserID, Valid: true}
+	}
+	opts := jobs.Options{}
+	if email.CampaignID != nil {
+		params.CampaignID = pgtype.UUID{Bytes: *email.CampaignID, Valid: true}
+		opts.Priority = jobs.PriorityLow
+	} else if jobID, ok := jobs.JobID(ctx); ok {
+		params.NotificationID = pgtype.UUID{Bytes: jobID, Valid: true}
+	}
+	if suppressed {
+		params.Status = StatusSuppressed
+	}
+	row, err := s.queries.CreateEmailMessage(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save email message: %w", err)
+	}
+	if suppressed {
+		s.count(ctx, StatusSuppressed)
+		return toMessage(row), nil
+	}
+
+	if _, err := s.jobs.Schedule(ctx, JobSend, sendJob{MessageID: uuid.UUID(row.ID.Bytes)}, opts); err != nil {
+		s.finish(ctx, row.ID, StatusFailed, "failed to queue message", false)
+		return nil, fmt.Errorf("failed to queue email: %w", err)
+	}
+	return toMessage(row), nil
+}
+
+// RunSendJob sends a queued message, waiting for the provider rate limit.
+// Transient provider errors fail the job so it is retried; the message is
+// marked failed after the last attempt, and bounced when the provider
+// rejects the address, which also suppresses it.
+func (s *Service) RunSendJob(ctx context.Context, payload json.RawMessage) error {
+	var job sendJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("failed to decode email job: %w", err)
+	}
+	row, err := s.queries.GetEmailMessage(ctx, pgtype.UUID{Bytes: job.MessageID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Deleted with its user or pruned before it ran
+			return nil
+		}
+		return fmt.Errorf("failed to get email message: %w", err)
+	}
+	if row.Status != StatusQueued {
+		return nil
+	}
+
+	// The address may have been suppressed since the message was queued
+	suppressed, err := s.queries.IsEmailSuppressed(ctx, row.ToAddress)
+	if err != nil {
+		return fmt.Errorf("failed to check email suppression: %w", err)
+	}
+	if suppressed {
+		s.finish(ctx, row.ID, StatusSuppressed, "", false)
+		return nil
+	}
+
+	attachments, err := s.loadAttachments(ctx, row.Attachments)
+	if err == nil {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		msg := mailer.Message{To: row.ToAddress, Subject: row.Subject, Body: row.Body, HTML: row.HtmlBody, Attachments: attachments}
+		_, err = jobs.Once(ctx, "send", func(ctx context.Context) error {
+			providerID, err := s.sender.Send(ctx, msg)
+			if err != nil {
+				return err
+			}
+			if err := s.queries.MarkEmailSent(context.WithoutCancel(ctx), db.MarkEmailSentParams{
+				ID:                row.ID,
+				ProviderMessageID: pgtype.Text{String: providerID, Valid: providerID != ""},
+			}); err != nil {
+				// Failing now would send the message again
+				logger.FromContext(ctx).Warn("failed to mark email sent", "error", err, "message_id", job.MessageID)
+			}
+			s.count(ctx, StatusSent)
+			kpi.EmailSent(ctx)
+			return nil
+		})
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mailer.ErrRecipientRejected):
+		if err := s.suppress(ctx, row.ToAddress, ReasonBounce, err.Error()); err != nil {
+			return err
+		}
+		s.finish(ctx, row.ID, StatusBounced, err.Error(), true)
+		return nil
+	case errors.Is(err, connectors.ErrInvalidRequest), errors.Is(err, mailer.ErrInvalidMessage),
+		errors.Is(err, mailer.ErrMessageTooLarge), errors.Is(err, storage.ErrNotFound), jobs.LastAttempt(ctx):
+		// Resending the same message cannot succeed, or this was the last try
+		s.finish(ctx, row.ID, StatusFailed, err.Error(), true)
+		return nil
+	default:
+		s.finish(ctx, row.ID, StatusQueued, err.Error(), true)
+		return err
+	}
+}
+
+// withInlineImages adds the configured inline images the HTML body refers
+// to and that are not attached already
+func (s *Service) withInlineImages(html string, attachments []Attachment) []Attachment {
+	if html == "" {
+		return attachments
+	}
+	ids := make([]string, 0, len(s.inlineImages))
+	for id := range s.inlineImages {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		attached := slices.ContainsFunc(attachments, func(a Attachment) bool { return a.ContentID == id })
+		if !attached && strings.Contains(html, "cid:"+id) {
+			key := s.inlineImages[id]
+			attachments = append(attachments, Attachment{Key: key, Filename: path.Base(key), ContentID: id})
+		}
+	}
+	return attachments
+}
+
+// loadAttachments reads a message's attachments from storage, stopping as
+// soon as they exceed the size limit
+func (s *Service) loadAttachments(ctx context.Context, data []byte) ([]mailer.Attachment, error) {
+	var refs []Attachment
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &refs); err != nil {
+			return nil, fmt.Errorf("failed to decode email attachments: %w", err)
+		}
+	}
+
+	attachments := make([]mailer.Attachment, 0, len(refs))
+	remaining := s.maxAttachmentSize
+	for _, ref := range refs {
+		content, err := s.readAttachment(ctx, ref.Key, remaining)
+		if err != nil {
+			return nil, err
+		}
+		if s.maxAttachmentSize > 0 {
+			remaining -= int64(len(content))
+		}
+		attachments = append(attachments, mailer.Attachment{
+			Filename:    ref.Filename,
+			ContentType: ref.ContentType,
+			ContentID:   ref.ContentID,
+			Content:     content,
+		})
+	}
+	return attachments, nil
+}
+
+// readAttachment reads one file, failing with mailer.ErrMessageTooLarge
+// when it is over limit bytes. A limit of 0 is no limit.
+func (s *Service) readAttachment(ctx context.Context, key string, limit int64) ([]byte, error) {
+	r, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment %s: %w", key, err)
+	}
+	defer r.Close()
+
+	var src io.Reader = r
+	if s.maxAttachmentSize > 0 {
+		src = io.LimitReader(r, limit+1)
+	}
+	content, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment %s: %w", key, err)
+	}
+	if s.maxAttachmentSize > 0 && int64(len(content)) > limit {
+		return nil, fmt.Errorf("%w: limit is %d bytes", mailer.ErrMessageTooLarge, s.maxAttachmentSize)
+	}
+	return content, nil
+}
+
+// finish records a send attempt, or the final status of a queued message.
+// Failures are logged since the job outcome does not depend on them.
+func (s *Service) finish(ctx context.Context, id pgtype.UUID, status, lastError string, attempted bool) {
+	if err := s.queries.UpdateQueuedEmail(context.WithoutCancel(ctx), db.UpdateQueuedEmailParams{
+		ID:        id,
+		Status:    status,
+		LastError: pgtype.Text{String: lastError, Valid: lastError != ""},
+		Attempted: attempted,
+	}); err != nil {
+		logger.FromContext(ctx).Warn("failed to update email message", "error", err, "message_id", uuid.UUID(id.Bytes))
+	}
+	if status != StatusQueued {
+		s.count(ctx, status)
+	}
+}
+
+// HandleWebhook applies the delivery events in a webhook from provider:
+// message statuses are updated, opens are recorded, and permanent bounces
+// and complaints suppress the address
+func (s *Service) HandleWebhook(provider string, r *http.Request) error {
+	parser, ok := s.sender.Webhook(provider)
+	if !ok {
+		return ErrWebhooksUnsupported
+	}
+	events, err := parser.ParseWebhook(r)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := s.applyEvent(r.Context(), event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) applyEvent(ctx context.Context, event mailer.Event) error {
+	if event.Type == mailer.EventOpened {
+		return s.applyOpen(ctx, event)
+	}
+
+	switch {
+	case event.Type == mailer.EventComplained:
+		if err := s.suppress(ctx, event.Email, ReasonComplaint, event.Detail); err != nil {
+			return err
+		}
+	case event.Type == mailer.EventBounced && event.Permanent:
+		if err := s.suppress(ctx, event.Email, ReasonBounce, event.Detail); err != nil {
+			return err
+		}
+	}
+
+	transition, ok := eventTransitions[event.Type]
+	if !ok || event.MessageID == "" {
+		return nil
+	}
+	params := db.SetEmailStatusByProviderIDParams{
+		Status:            transition.status,
+		ProviderMessageID: pgtype.Text{String: event.MessageID, Valid: true},
+		FromStatuses:      transition.from,
+	}
+	if event.Type != mailer.EventDelivered {
+		params.LastError = pgtype.Text{String: event.Detail, Valid: event.Detail != ""}
+	}
+	if _, err := s.queries.SetEmailStatusByProviderID(ctx, params); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Sent by another system, pruned, or already past this status
+			return nil
+		}
+		return fmt.Errorf("failed to update email status: %w", err)
+	}
+	s.count(ctx, transition.status)
+	return nil
+}
+
+// applyOpen records when a message was first opened. Opens leave the
+// status alone, since a message can be opened before its delivery is
+// reported.
+func (s *Service) applyOpen(ctx context.Context, event mailer.Event) error {
+	if event.MessageID == "" {
+		return nil
+	}
+	at := event.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	rows, err := s.queries.MarkEmailOpenedByProviderID(ctx, db.MarkEmailOpenedByProviderIDParams{
+		OpenedAt:          pgtype.Timestamptz{Time: at, Valid: true},
+		ProviderMessageID: pgtype.Text{String: event.MessageID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record email open: %w", err)
+	}
+	if rows > 0 {
+		s.count(ctx, "opened")
+	}
+	return nil
+}
+
+// suppress adds an address to the suppression list
+func (s *Service) suppress(ctx context.Context, address, reason, detail string) error {
+	if address == "" {
+		return nil
+	}
+	if _, err := s.queries.UpsertEmailSuppression(ctx, db.UpsertEmailSuppressionParams{
+		Lower:  address,
+		Reason: reason,
+		Detail: detail,
+	}); err != nil {
+		return fmt.Errorf("failed to suppress email address: %w", err)
+	}
+	logger.FromContext(ctx).Info("email address suppressed", "reason", reason)
+	return nil
+}
+
+// GetMessage returns a message and its delivery status
+func (s *Service) GetMessage(ctx context.Context, id uuid.UUID) (*Message, error) {
+	row, err := s.queries.GetEmailMessage(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, fmt.Errorf("failed to get email message: %w", err)
+	}
+	return toMessage(row), nil
+}
+
+// ListMessages returns recent messages, optionally for one user, one
+// notification, one campaign, or one status
+func (s *Service) ListMessages(ctx context.Context, userID, notificationID, campaignID *uuid.UUID, status string, limit int) ([]*Message, error) {
+	params := db.ListEmailMessagesParams{
+		Status:   pgtype.Text{String: status, Valid: status != ""},
+		RowLimit: int32(limit),
+	}
+	if userID != nil {
+		params.UserID = pgtype.UUID{Bytes: *userID, Valid: true}
+	}
+	if notificationID != nil {
+		params.NotificationID = pgtype.UUID{Bytes: *notificationID, Valid: true}
+	}
+	if campaignID != nil {
+		params.CampaignID = pgtype.UUID{Bytes: *campaignID, Valid: true}
+	}
+	rows, err := s.queries.ListEmailMessages(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email messages: %w", err)
+	}
+
+	result := make([]*Message, len(rows))
+	for i, row := range rows {
+		result[i] = toMessage(row)
+	}
+	return result, nil
+}
+
+// ListSuppressions returns the most recently suppressed addresses
+func (s *Service) ListSuppressions(ctx context.Context, limit int) ([]*Suppression, error) {
+	rows, err := s.queries.ListEmailSuppressions(ctx, int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email suppressions: %w", err)
+	}
+
+	result := make([]*Suppression, len(rows))
+	for i, row := range rows {
+		result[i] = toSuppression(row)
+	}
+	return result, nil
+}
+
+// Suppress adds an address to the suppression list by hand
+func (s *Service) Suppress(ctx context.Context, address, detail, actor string) (*Suppression, error) {
+	addr, err := mail.ParseAddress(address)
+	if err != nil {
+		return nil, ErrInvalidAddress
+	}
+	row, err := s.queries.UpsertEmailSuppression(ctx, db.UpsertEmailSuppressionParams{
+		Lower:  addr.Address,
+		Reason: ReasonManual,
+		Detail: detail,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suppress email address: %w", err)
+	}
+	s.record(ctx, actor, "email.suppress", row.Email)
+	return toSuppression(row), nil
+}
+
+// Unsuppress removes an address from the suppression list, e.g. after the
+// recipient fixed their mailbox
+func (s *Service) Unsuppress(ctx context.Context, address, actor string) error {
+	rows, err := s.queries.DeleteEmailSuppression(ctx, address)
+	if err != nil {
+		return fmt.Errorf("failed to delete email suppression: %w", err)
+	}
+	if rows == 0 {
+		return ErrSuppressionNotFound
+	}
+	s.record(ctx, actor, "email.unsuppress", strings.ToLower(address))
+	return nil
+}
+
+// Prune deletes messages older than the retention period
+func (s *Service) Prune(ctx context.Context) error {
+	before := pgtype.Timestamptz{Time: time.Now().Add(-s.retention), Valid: true}
+	if _, err := s.queries.DeleteOldEmailMessages(ctx, before); err != nil {
+		return fmt.Errorf("failed to prune email messages: %w", err)
+	}
+	return nil
+}
+
+// record writes an audit entry; failures are logged since the change has
+// already been committed
+func (s *Service) record(ctx context.Context, actor, action, address string) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "email_suppression",
+		ResourceID:   address,
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+func (s *Service) count(ctx context.Context, status string) {
+	s.messages.Add(ctx, 1, metric.WithAttributes(attribute.String("status", status)))
+}
+
+func toMessage(row db.EmailMessage) *Message {
+	msg := &Message{
+		ID:                uuid.UUID(row.ID.Bytes),
+		To:                row.ToAddress,
+		Subject:           row.Subject,
+		Status:            row.Status,
+		ProviderMessageID: row.ProviderMessageID.String,
+		Attempts:          int(row.Attempts),
+		CreatedAt:         row.CreatedAt.Time,
+		UpdatedAt:         row.UpdatedAt.Time,
+	}
+	if row.UserID.Valid {
+		id := uuid.UUID(row.UserID.Bytes)
+		msg.UserID = &id
+	}
+	if row.NotificationID.Valid {
+		id := uuid.UUID(row.NotificationID.Bytes)
+		msg.NotificationID = &id
+	}
+	if row.CampaignID.Valid {
+		id := uuid.UUID(row.CampaignID.Bytes)
+		msg.CampaignID = &id
+	}
+	if row.LastError.Valid {
+		msg.LastError = &row.LastError.String
+	}
+	if row.SentAt.Valid {
+		msg.SentAt = &row.SentAt.Time
+	}
+	if row.OpenedAt.Valid {
+		msg.OpenedAt = &row.OpenedAt.Time
+	}
+	_ = json.Unmarshal(row.Attachments, &msg.Attachments)
+	return msg
+}
+
+func toSuppression(row db.EmailSuppression) *Suppression {
+	return &Suppression{
+		Email:     row.Email,
+		Reason:    row.Reason,
+		Detail:    row.Detail,
+		CreatedAt: row.CreatedAt.Time,
+	}
+}
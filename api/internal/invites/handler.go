@@ -0,0 +1,175 @@
+package invites
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/ics"
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	Create(ctx context.Context, req InviteRequest, actor string) (*Invite, error)
+	Get(ctx context.Context, id uuid.UUID) (*Invite, error)
+	Calendar(ctx context.Context, id uuid.UUID) ([]byte, error)
+	Update(ctx context.Context, id uuid.UUID, req InviteRequest, actor string) (*Invite, error)
+	Cancel(ctx context.Context, id uuid.UUID, actor string) (*Invite, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleCreate creates an invite and emails it to the attendees
+func (h *Handler) HandleCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req InviteRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		invite, err := h.service.Create(r.Context(), req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithInviteError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Location", "/api/v1/invites/"+invite.ID.String())
+		h.respondWithJSON(w, http.StatusCreated, invite)
+	}
+}
+
+func (h *Handler) HandleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inviteID, ok := h.parseInviteID(w, r)
+		if !ok {
+			return
+		}
+
+		invite, err := h.service.Get(r.Context(), inviteID)
+		if err != nil {
+			h.respondWithInviteError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, invite)
+	}
+}
+
+// HandleCalendar serves the invite as an .ics file
+func (h *Handler) HandleCalendar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inviteID, ok := h.parseInviteID(w, r)
+		if !ok {
+			return
+		}
+
+		calendar, err := h.service.Calendar(r.Context(), inviteID)
+		if err != nil {
+			h.respondWithInviteError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", ics.ContentType(ics.MethodPublish))
+		w.Header().Set("Content-Length", strconv.Itoa(len(calendar)))
+		w.Header().Set("Content-Disposition", `attachment; filename="invite.ics"`)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(calendar); err != nil {
+			h.logger.Error("failed to write calendar", "error", err, "invite_id", inviteID)
+		}
+	}
+}
+
+// HandleUpdate replaces an invite and emails the update to the attendees
+func (h *Handler) HandleUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inviteID, ok := h.parseInviteID(w, r)
+		if !ok {
+			return
+		}
+
+		var req InviteRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		invite, err := h.service.Update(r.Context(), inviteID, req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithInviteError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, invite)
+	}
+}
+
+// HandleCancel cancels an invite and emails the cancellation to the
+// attendees
+func (h *Handler) HandleCancel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inviteID, ok := h.parseInviteID(w, r)
+		if !ok {
+			return
+		}
+
+		invite, err := h.service.Cancel(r.Context(), inviteID, actorFromRequest(r))
+		if err != nil {
+			h.respondWithInviteError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, invite)
+	}
+}
+
+func (h *Handler) parseInviteID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	inviteID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_invite_id", "invalid invite ID format"))
+		return uuid.Nil, false
+	}
+	return inviteID, true
+}
+
+func (h *Handler) respondWithInviteError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrInviteNotFound):
+		apierror.Write(w, r, apierror.NotFound("invite_not_found", err.Error()))
+	case errors.Is(err, ErrInviteCancelled):
+		apierror.Write(w, r, apierror.Conflict("invite_cancelled", err.Error()))
+	case errors.Is(err, ErrNotConfigured):
+		apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, "not_configured", err.Error()))
+	default:
+		h.logger.Error("invite request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
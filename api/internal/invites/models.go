@@ -0,0 +1,132 @@
+package invites
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"starterkit/internal/platform/ics"
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInviteNotFound  = errors.New("calendar invite not found")
+	ErrInviteCancelled = errors.New("calendar invite is cancelled")
+	ErrNotConfigured   = errors.New("calendar invites need an organizer address")
+)
+
+// Invite statuses
+const (
+	StatusConfirmed = "confirmed"
+	StatusCancelled = "cancelled"
+)
+
+const (
+	maxSummaryLength     = 200
+	maxDescriptionLength = 5000
+	maxLocationLength    = 500
+	maxAttendees         = 100
+)
+
+// localTimeLayout is a wall clock time in the invite's time zone
+const localTimeLayout = "2006-01-02T15:04:05"
+
+// Invite is a calendar event emailed to its attendees. Start and End are in
+// the invite's time zone.
+type Invite struct {
+	ID          uuid.UUID       `json:"id"`
+	Summary     string          `json:"summary"`
+	Description string          `json:"description,omitempty"`
+	Location    string          `json:"location,omitempty"`
+	Start       time.Time       `json:"start"`
+	End         time.Time       `json:"end"`
+	TimeZone    string          `json:"timezone"`
+	Recurrence  *ics.Recurrence `json:"recurrence,omitempty"`
+	Attendees   []string        `json:"attendees"`
+	Sequence    int             `json:"sequence"`
+	Status      string          `json:"status"`
+	CreatedBy   string          `json:"created_by"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// InviteRequest creates or reschedules an invite. Start and end are wall
+// clock times in the time zone, such as 2026-10-20T10:00:00, so recurring
+// invites keep their local time across daylight saving changes. Times with
+// an offset are converted into the time zone.
+type InviteRequest struct {
+	Summary     string          `json:"summary"`
+	Description string          `json:"description"`
+	Location    string          `json:"location"`
+	Start       string          `json:"start"`
+	End         string          `json:"end"`
+	TimeZone    string          `json:"timezone"`
+	Recurrence  *ics.Recurrence `json:"recurrence"`
+	Attendees   []string        `json:"attendees"`
+
+	start time.Time
+	end   time.Time
+}
+
+// Validate trims the fields, normalizes the attendees, and parses the times
+func (r *InviteRequest) Validate() error {
+	r.Summary = strings.TrimSpace(r.Summary)
+	r.Description = strings.TrimSpace(r.Description)
+	r.Location = strings.TrimSpace(r.Location)
+
+	var v request.Validation
+	v.Check(r.Summary != "" && utf8.RuneCountInString(r.Summary) <= maxSummaryLength, "summary", fmt.Sprintf("must be 1-%d characters", maxSummaryLength))
+	v.Check(utf8.RuneCountInString(r.Description) <= maxDescriptionLength, "description", fmt.Sprintf("must be at most %d characters", maxDescriptionLength))
+	v.Check(utf8.RuneCountInString(r.Location) <= maxLocationLength, "location", fmt.Sprintf("must be at most %d characters", maxLocationLength))
+
+	loc, err := time.LoadLocation(r.TimeZone)
+	v.Check(err == nil && r.TimeZone != "" && r.TimeZone != "Local", "timezone", "must be an IANA time zone such as Europe/Berlin")
+	if err == nil {
+		var startErr, endErr error
+		r.start, startErr = parseTime(r.Start, loc)
+		r.end, endErr = parseTime(r.End, loc)
+		v.Check(startErr == nil, "start", "must be a date and time such as 2026-10-20T10:00:00")
+		v.Check(endErr == nil, "end", "must be a date and time such as 2026-10-20T11:00:00")
+		v.Check(startErr != nil || endErr != nil || r.end.After(r.start), "end", "must be after start")
+	}
+
+	if r.Recurrence != nil {
+		if err := r.Recurrence.Validate(); err != nil {
+			v.Check(false, "recurrence", strings.TrimPrefix(err.Error(), ics.ErrInvalidEvent.Error()+": "))
+		} else {
+			v.Check(r.Recurrence.Until.IsZero() || !r.Recurrence.Until.Before(r.start), "recurrence", "must not end before the invite starts")
+		}
+	}
+
+	attendees := make([]string, 0, len(r.Attendees))
+	valid := true
+	for _, a := range r.Attendees {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if addr, err := mail.ParseAddress(a); err != nil || addr.Address != a {
+			valid = false
+			continue
+		}
+		if !slices.Contains(attendees, a) {
+			attendees = append(attendees, a)
+		}
+	}
+	r.Attendees = attendees
+	v.Check(valid, "attendees", "must be email addresses")
+	v.Check(len(attendees) > 0 && len(attendees) <= maxAttendees, "attendees", fmt.Sprintf("must list 1-%d addresses", maxAttendees))
+	return v.Err()
+}
+
+// parseTime reads a wall clock time in loc, or a time with an offset
+// converted into loc
+func parseTime(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.In(loc), nil
+	}
+	return time.ParseInLocation(localTimeLayout, s, loc)
+}
@@ -0,0 +1,42 @@
+package invites
+
+import (
+	"log/slog"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/email"
+	"starterkit/internal/platform/module"
+	"starterkit/internal/platform/storage"
+	"starterkit/internal/platform/wiring"
+)
+
+func init() {
+	module.Register(&Module{})
+}
+
+// Module serves the API of calendar invites emailed to attendees
+type Module struct {
+	module.Base
+	handler *Handler
+}
+
+func (m *Module) Name() string {
+	return "invites"
+}
+
+func (m *Module) Init(c *wiring.Container) error {
+	cfg, logger := wiring.Use[*config.Config](c), wiring.Use[*slog.Logger](c)
+	service := NewService(wiring.Use[*db.Queries](c), wiring.Use[storage.Storage](c), wiring.Use[*email.Service](c), wiring.Use[audit.Recorder](c), cfg.Invites.Organizer, logger)
+	m.handler = NewHandler(service, logger)
+	return nil
+}
+
+func (m *Module) RegisterRoutes(r module.Routes) {
+	r.API.HandleFunc("POST /invites", m.handler.HandleCreate())
+	r.API.HandleFunc("GET /invites/{id}", m.handler.HandleGet())
+	r.API.HandleFunc("GET /invites/{id}/calendar", m.handler.HandleCalendar())
+	r.API.HandleFunc("PUT /invites/{id}", m.handler.HandleUpdate())
+	r.API.HandleFunc("DELETE /invites/{id}", m.handler.HandleCancel())
+}
@@ -0,0 +1,349 @@
+package invites
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/email"
+	"starterkit/internal/platform/ics"
+	"starterkit/internal/platform/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Querier interface {
+	CreateCalendarInvite(ctx context.Context, arg db.CreateCalendarInviteParams) (db.CalendarInvite, error)
+	GetCalendarInvite(ctx context.Context, id pgtype.UUID) (db.CalendarInvite, error)
+	UpdateCalendarInvite(ctx context.Context, arg db.UpdateCalendarInviteParams) (db.CalendarInvite, error)
+	CancelCalendarInvite(ctx context.Context, id pgtype.UUID) (db.CalendarInvite, error)
+}
+
+// Mailer queues outbound email
+type Mailer interface {
+	Queue(ctx context.Context, msg email.Email) (*email.Message, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service stores calendar invites and emails them to attendees as .ics
+// attachments. Each version of the calendar is written to storage once and
+// attached to every attendee's message.
+type Service struct {
+	queries   Querier
+	store     storage.Storage
+	mail      Mailer
+	auditor   Auditor
+	organizer *mail.Address
+	logger    *slog.Logger
+}
+
+// NewService creates the invite service. Invites are sent from organizer;
+// without one, invites cannot be created.
+func NewService(queries Querier, store storage.Storage, mailer Mailer, auditor Auditor, organizer string, logger *slog.Logger) *Service {
+	s := &Service{
+		queries: queries,
+		store:   store,
+		mail:    mailer,
+		auditor: auditor,
+		logger:  logger,
+	}
+	if addr, err := mail.ParseAddress(organizer); err == nil {
+		s.organizer = addr
+	}
+	return s
+}
+
+// Create stores an invite and emails it to the attendees
+func (s *Service) Create(ctx context.Context, req InviteRequest, actor string) (*Invite, error) {
+	if s.organizer == nil {
+		return nil, ErrNotConfigured
+	}
+	recurrence, err := encodeRecurrence(req.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := s.queries.CreateCalendarInvite(ctx, db.CreateCalendarInviteParams{
+		Summary:     req.Summary,
+		Description: req.Description,
+		Location:    req.Location,
+		StartsAt:    pgtype.Timestamptz{Time: req.start, Valid: true},
+		EndsAt:      pgtype.Timestamptz{Time: req.end, Valid: true},
+		Timezone:    req.TimeZone,
+		Recurrence:  recurrence,
+		Attendees:   req.Attendees,
+		CreatedBy:   actor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar invite: %w", err)
+	}
+	invite, err := toInvite(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.send(ctx, invite, ics.MethodRequest, invite.Attendees); err != nil {
+		return nil, err
+	}
+	s.record(ctx, actor, "invite.create", invite)
+	return invite, nil
+}
+
+// Get returns an invite
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Invite, error) {
+	row, err := s.queries.GetCalendarInvite(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInviteNotFound
+		}
+		return nil, fmt.Errorf("failed to get calendar invite: %w", err)
+	}
+	return toInvite(row)
+}
+
+// Calendar returns the current version of an invite as an iCalendar file
+// for adding to a calendar by hand
+func (s *Service) Calendar(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	invite, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.organizer == nil {
+		return nil, ErrNotConfigured
+	}
+	return ics.Build(ics.MethodPublish, s.event(invite, invite.Attendees))
+}
+
+// Update reschedules or edits a confirmed invite. The attendees get the
+// new version, and attendees no longer listed get a cancellation.
+func (s *Service) Update(ctx context.Context, id uuid.UUID, req InviteRequest, actor string) (*Invite, error) {
+	if s.organizer == nil {
+		return nil, ErrNotConfigured
+	}
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == StatusCancelled {
+		return nil, ErrInviteCancelled
+	}
+	recurrence, err := encodeRecurrence(req.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := s.queries.UpdateCalendarInvite(ctx, db.UpdateCalendarInviteParams{
+		ID:          pgtype.UUID{Bytes: id, Valid: true},
+		Summary:     req.Summary,
+		Description: req.Description,
+		Location:    req.Location,
+		StartsAt:    pgtype.Timestamptz{Time: req.start, Valid: true},
+		EndsAt:      pgtype.Timestamptz{Time: req.end, Valid: true},
+		Timezone:    req.TimeZone,
+		Recurrence:  recurrence,
+		Attendees:   req.Attendees,
+	})
+	if err != nil {
+		// Cancelled since it was read
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInviteCancelled
+		}
+		return nil, fmt.Errorf("failed to update calendar invite: %w", err)
+	}
+	invite, err := toInvite(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.send(ctx, invite, ics.MethodRequest, invite.Attendees); err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, a := range current.Attendees {
+		if !slices.Contains(invite.Attendees, a) {
+			removed = append(removed, a)
+		}
+	}
+	if len(removed) > 0 {
+		if err := s.send(ctx, invite, ics.MethodCancel, removed); err != nil {
+			return nil, err
+		}
+	}
+	s.record(ctx, actor, "invite.update", invite)
+	return invite, nil
+}
+
+// Cancel cancels an invite and emails the cancellation to the attendees
+func (s *Service) Cancel(ctx context.Context, id uuid.UUID, actor string) (*Invite, error) {
+	if s.organizer == nil {
+		return nil, ErrNotConfigured
+	}
+	row, err := s.queries.CancelCalendarInvite(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, err := s.Get(ctx, id); err != nil {
+				return nil, err
+			}
+			return nil, ErrInviteCancelled
+		}
+		return nil, fmt.Errorf("failed to cancel calendar invite: %w", err)
+	}
+	invite, err := toInvite(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.send(ctx, invite, ics.MethodCancel, invite.Attendees); err != nil {
+		return nil, err
+	}
+	s.record(ctx, actor, "invite.cancel", invite)
+	return invite, nil
+}
+
+// send stores the invite's calendar for method and queues it to each of
+// attendees
+func (s *Service) send(ctx context.Context, invite *Invite, method string, attendees []string) error {
+	calendar, err := ics.Build(method, s.event(invite, attendees))
+	if err != nil {
+		return fmt.Errorf("failed to build calendar: %w", err)
+	}
+	key := fmt.Sprintf("invites/%s/%d-%s.ics", invite.ID, invite.Sequence, strings.ToLower(method))
+	if err := s.store.Put(ctx, key, bytes.NewReader(calendar)); err != nil {
+		return fmt.Errorf("failed to store calendar: %w", err)
+	}
+
+	subject, body := message(invite, method)
+	attachment := email.Attachment{Key: key, Filename: "invite.ics", ContentType: ics.ContentType(method)}
+	for _, to := range attendees {
+		if _, err := s.mail.Queue(ctx, email.Email{
+			To:          to,
+			Subject:     subject,
+			Body:        body,
+			Attachments: []email.Attachment{attachment},
+		}); err != nil {
+			return fmt.Errorf("failed to queue invite to %s: %w", to, err)
+		}
+	}
+	return nil
+}
+
+// event is the calendar event of an invite addressed to attendees
+func (s *Service) event(invite *Invite, attendees []string) ics.Event {
+	e := ics.Event{
+		UID:         invite.ID.String() + "@" + s.organizer.Address[strings.LastIndex(s.organizer.Address, "@")+1:],
+		Sequence:    invite.Sequence,
+		Summary:     invite.Summary,
+		Description: invite.Description,
+		Location:    invite.Location,
+		Start:       invite.Start,
+		End:         invite.End,
+		Recurrence:  invite.Recurrence,
+		Organizer:   *s.organizer,
+		Cancelled:   invite.Status == StatusCancelled,
+		Stamp:       invite.UpdatedAt,
+	}
+	for _, a := range attendees {
+		e.Attendees = append(e.Attendees, mail.Address{Address: a})
+	}
+	return e
+}
+
+// message is the plain text email that carries a calendar
+func message(invite *Invite, method string) (subject, body string) {
+	var b strings.Builder
+	switch {
+	case method == ics.MethodCancel:
+		subject = "Cancelled: " + invite.Summary
+		b.WriteString("This event has been cancelled.\n\n")
+	case invite.Sequence > 0:
+		subject = "Updated invitation: " + invite.Summary
+		b.WriteString("This event has been updated.\n\n")
+	default:
+		subject = "Invitation: " + invite.Summary
+	}
+
+	endLayout := "15:04 MST"
+	if invite.End.YearDay() != invite.Start.YearDay() || invite.End.Year() != invite.Start.Year() {
+		endLayout = "Mon Jan 2, 2006 15:04 MST"
+	}
+	fmt.Fprintf(&b, "%s\n\nWhen: %s - %s (%s)\n", invite.Summary,
+		invite.Start.Format("Mon Jan 2, 2006 15:04"), invite.End.Format(endLayout), invite.TimeZone)
+	if invite.Recurrence != nil {
+		fmt.Fprintf(&b, "Repeats: %s\n", invite.Recurrence.Frequency)
+	}
+	if invite.Location != "" {
+		fmt.Fprintf(&b, "Where: %s\n", invite.Location)
+	}
+	if invite.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", invite.Description)
+	}
+	return subject, b.String()
+}
+
+func (s *Service) record(ctx context.Context, actor, action string, invite *Invite) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "calendar_invite",
+		ResourceID:   invite.ID.String(),
+		Metadata: map[string]any{
+			"sequence":  invite.Sequence,
+			"attendees": len(invite.Attendees),
+		},
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+func encodeRecurrence(r *ics.Recurrence) ([]byte, error) {
+	if r == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode recurrence: %w", err)
+	}
+	return data, nil
+}
+
+func toInvite(row db.CalendarInvite) (*Invite, error) {
+	loc, err := time.LoadLocation(row.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load invite time zone: %w", err)
+	}
+	invite := &Invite{
+		ID:          uuid.UUID(row.ID.Bytes),
+		Summary:     row.Summary,
+		Description: row.Description,
+		Location:    row.Location,
+		Start:       row.StartsAt.Time.In(loc),
+		End:         row.EndsAt.Time.In(loc),
+		TimeZone:    row.Timezone,
+		Attendees:   row.Attendees,
+		Sequence:    int(row.Sequence),
+		Status:      row.Status,
+		CreatedBy:   row.CreatedBy,
+		CreatedAt:   row.CreatedAt.Time,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}
+	if row.Recurrence != nil {
+		if err := json.Unmarshal(row.Recurrence, &invite.Recurrence); err != nil {
+			return nil, fmt.Errorf("failed to decode recurrence: %w", err)
+		}
+	}
+	return invite, nil
+}
@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/logger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type runningKey struct{}
+
+// runningJob identifies the job being run and records the side effects it
+// completes
+type runningJob struct {
+	queries     Querier
+	jobID       pgtype.UUID
+	lastAttempt bool
+}
+
+// JobID returns the ID of the job ctx is running, if any
+func JobID(ctx context.Context) (uuid.UUID, bool) {
+	j, ok := ctx.Value(runningKey{}).(*runningJob)
+	if !ok {
+		return uuid.Nil, false
+	}
+	return uuid.UUID(j.jobID.Bytes), true
+}
+
+// LastAttempt reports whether the job ctx is running will not be retried if
+// this attempt fails, so handlers can record a final outcome
+func LastAttempt(ctx context.Context) bool {
+	j, ok := ctx.Value(runningKey{}).(*runningJob)
+	return !ok || j.lastAttempt
+}
+
+// Once guards a side effect of a job, such as sending an email, so that a
+// retried attempt skips it once an earlier attempt completed it. key names
+// the effect within the job. fn is recorded only after it succeeds, so a
+// crash in between can still repeat it; effects should be idempotent on
+// the receiving side where that matters. Outside a job, fn always runs.
+// Once reports whether fn ran.
+func Once(ctx context.Context, key string, fn func(ctx context.Context) error) (bool, error) {
+	e, ok := ctx.Value(runningKey{}).(*runningJob)
+	if !ok {
+		return true, fn(ctx)
+	}
+
+	params := db.HasJobEffectParams{JobID: e.jobID, Effect: key}
+	done, err := e.queries.HasJobEffect(ctx, params)
+	if err != nil {
+		return false, fmt.Errorf("failed to check job effect: %w", err)
+	}
+	if done {
+		return false, nil
+	}
+
+	if err := fn(ctx); err != nil {
+		return true, err
+	}
+	if err := e.queries.RecordJobEffect(context.WithoutCancel(ctx), db.RecordJobEffectParams{JobID: e.jobID, Effect: key}); err != nil {
+		// Failing the job now would repeat the effect on retry
+		logger.FromContext(ctx).Warn("failed to record job effect", "effect", key, "error", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,169 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"starterkit/internal/platform/apierror"
+)
+
+type ServiceInterface interface {
+	Get(ctx context.Context, id uuid.UUID) (*Job, error)
+	List(ctx context.Context, status, kind string, limit int) ([]*Job, error)
+	Retry(ctx context.Context, id uuid.UUID, actor string) (*Job, error)
+	Cancel(ctx context.Context, id uuid.UUID, actor string) (*Job, error)
+	SetPriority(ctx context.Context, id uuid.UUID, priority Priority, actor string) (*Job, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleList lists recently created jobs, optionally by status and kind,
+// e.g. ?status=failed for jobs that need attention
+func (h *Handler) HandleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 50 // default
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsedLimit, err := strconv.Atoi(limitStr)
+			if err != nil || parsedLimit < 1 {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid limit parameter"))
+				return
+			}
+			limit = parsedLimit
+		}
+
+		jobs, err := h.service.List(r.Context(), r.URL.Query().Get("status"), r.URL.Query().Get("kind"), limit)
+		if err != nil {
+			h.logger.Error("failed to list jobs", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"jobs": jobs,
+		})
+	}
+}
+
+// HandleGet returns a job
+func (h *Handler) HandleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseJobID(w, r)
+		if !ok {
+			return
+		}
+		job, err := h.service.Get(r.Context(), id)
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to get job", id)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, job)
+	}
+}
+
+// HandleRetry queues a failed or cancelled job to run again
+func (h *Handler) HandleRetry() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseJobID(w, r)
+		if !ok {
+			return
+		}
+		job, err := h.service.Retry(r.Context(), id, actorFromRequest(r))
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to retry job", id)
+			return
+		}
+		h.respondWithJSON(w, http.StatusAccepted, job)
+	}
+}
+
+// HandleCancel stops a queued job from running
+func (h *Handler) HandleCancel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseJobID(w, r)
+		if !ok {
+			return
+		}
+		job, err := h.service.Cancel(r.Context(), id, actorFromRequest(r))
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to cancel job", id)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, job)
+	}
+}
+
+// HandleSetPriority moves a queued job to another lane
+func (h *Handler) HandleSetPriority() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseJobID(w, r)
+		if !ok {
+			return
+		}
+		var req struct {
+			Priority Priority `json:"priority"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+		job, err := h.service.SetPriority(r.Context(), id, req.Priority, actorFromRequest(r))
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to reprioritize job", id)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, job)
+	}
+}
+
+func (h *Handler) parseJobID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_job_id", "invalid job ID format"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) handleServiceError(w http.ResponseWriter, r *http.Request, err error, msg string, id uuid.UUID) {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		apierror.Write(w, r, apierror.NotFound("job_not_found", "job not found"))
+	case errors.Is(err, ErrNotRetryable):
+		apierror.Write(w, r, apierror.Conflict("not_retryable", err.Error()))
+	case errors.Is(err, ErrNotQueued):
+		apierror.Write(w, r, apierror.Conflict("not_queued", err.Error()))
+	case errors.Is(err, ErrInvalidPriority):
+		apierror.Write(w, r, apierror.Unprocessable("invalid_priority", err.Error()))
+	default:
+		h.logger.Error(msg, "error", err, "job_id", id)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// actorFromRequest identifies the caller for audit purposes
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrJobNotFound     = errors.New("job not found")
+	ErrUnknownKind     = errors.New("unknown job kind")
+	ErrInvalidPriority = errors.New("priority must be high, default, or low")
+	ErrNotRetryable    = errors.New("only failed or cancelled jobs can be retried")
+	ErrNotQueued       = errors.New("only queued jobs can be changed")
+	ErrDuplicateJob    = errors.New("a job with this unique key was already enqueued")
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Priority is a lane of the queue. Workers claim due jobs from higher lanes
+// first, and a replica can be limited to some lanes with JOBS_LANES.
+type Priority string
+
+const (
+	PriorityHigh    Priority = "high"
+	PriorityDefault Priority = "default"
+	PriorityLow     Priority = "low"
+)
+
+// priorityRanks orders the lanes as stored; lower ranks are claimed first
+var priorityRanks = map[Priority]int16{
+	PriorityHigh:    0,
+	PriorityDefault: 1,
+	PriorityLow:     2,
+}
+
+// Func runs one job. It must be safe to repeat, since a crash after it
+// succeeds but before the job is marked done runs it again.
+type Func func(ctx context.Context, payload json.RawMessage) error
+
+// Options control when and in which lane a job runs
+type Options struct {
+	// RunAt delays the job until then; zero runs it as soon as possible
+	RunAt time.Time
+	// Priority defaults to PriorityDefault
+	Priority Priority
+	// UniqueKey dedupes enqueues: while a job of the same kind and key was
+	// enqueued within UniqueFor (24 hours if zero), that job is returned
+	// instead of a new one
+	UniqueKey string
+	UniqueFor time.Duration
+}
+
+// Recurring is a job enqueued every interval, at most once across all
+// replicas
+type Recurring struct {
+	Kind     string
+	Every    time.Duration
+	Payload  any
+	Priority Priority
+}
+
+// Job is a queued unit of background work
+type Job struct {
+	ID          uuid.UUID       `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Priority    Priority        `json:"priority"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   *string         `json:"last_error,omitempty"`
+	RequestID   string          `json:"request_id,omitempty"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	// Duplicate is set when an enqueue was deduped by its unique key and
+	// this is the job enqueued earlier
+	Duplicate bool `json:"duplicate,omitempty"`
+}
@@ -0,0 +1,571 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/ids"
+	"starterkit/internal/platform/kpi"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/metrics"
+	"starterkit/internal/platform/panics"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// claimBatch is how many due jobs one Work call claims at a time
+	claimBatch = 20
+	// maxBackoff caps the delay between attempts of a failing job
+	maxBackoff = time.Hour
+	// defaultUniqueFor is how long a unique key dedupes when no window is set
+	defaultUniqueFor = 24 * time.Hour
+	// KindPrune deletes finished jobs past the retention period
+	KindPrune = "jobs.prune"
+)
+
+var tracer = otel.Tracer("starterkit/jobs")
+
+type Querier interface {
+	EnqueueJob(ctx context.Context, arg db.EnqueueJobParams) (db.Job, error)
+	EnqueueUniqueJob(ctx context.Context, arg db.EnqueueUniqueJobParams) (db.Job, error)
+	GetJobByUniqueKey(ctx context.Context, uniqueKey string) (db.Job, error)
+	GetJob(ctx context.Context, id pgtype.UUID) (db.Job, error)
+	ListJobs(ctx context.Context, arg db.ListJobsParams) ([]db.Job, error)
+	ClaimDueJobs(ctx context.Context, arg db.ClaimDueJobsParams) ([]db.ClaimDueJobsRow, error)
+	CompleteJob(ctx context.Context, id pgtype.UUID) error
+	RetryJobLater(ctx context.Context, arg db.RetryJobLaterParams) error
+	FailJob(ctx context.Context, arg db.FailJobParams) error
+	RetryJob(ctx context.Context, id pgtype.UUID) (db.Job, error)
+	CancelJob(ctx context.Context, id pgtype.UUID) (db.Job, error)
+	SetJobPriority(ctx context.Context, arg db.SetJobPriorityParams) (db.Job, error)
+	CountQueuedJobs(ctx context.Context) ([]db.CountQueuedJobsRow, error)
+	DeleteFinishedJobs(ctx context.Context, finishedAt pgtype.Timestamptz) (int64, error)
+	DeleteExpiredJobUniqueKeys(ctx context.Context) (int64, error)
+	HasJobEffect(ctx context.Context, arg db.HasJobEffectParams) (bool, error)
+	RecordJobEffect(ctx context.Context, arg db.RecordJobEffectParams) error
+	ClaimRecurringJob(ctx context.Context, arg db.ClaimRecurringJobParams) (string, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Queue is a Postgres-backed job queue. Workers on every replica claim due
+// jobs with SKIP LOCKED, higher priority lanes first; a claimed job is not
+// claimed again until its lease has passed, so a job whose worker crashed
+// is picked up elsewhere. Failed jobs are retried with doubling backoff
+// until they run out of attempts.
+//
+// Enqueue records the caller's trace context and request ID with the job,
+// and each attempt runs in a span that continues that trace and links to
+// the enqueue span, so a request can be followed into its side effects.
+type Queue struct {
+	queries   Querier
+	auditor   Auditor
+	guard     *panics.Guard
+	logger    *slog.Logger
+	handlers  map[string]Func
+	recurring []Recurring
+	cfg       config.JobsConfig
+	lanes     []int16
+	queued    metric.Int64Gauge
+	wait      metric.Float64Histogram
+	duration  metric.Float64Histogram
+}
+
+// NewQueue creates a job queue that works the lanes in cfg. Finished jobs
+// are pruned hourly once cfg.Retention has passed.
+func NewQueue(queries Querier, auditor Auditor, guard *panics.Guard, cfg config.JobsConfig, logger *slog.Logger) *Queue {
+	cfg.MaxAttempts = max(cfg.MaxAttempts, 1)
+	q := &Queue{
+		queries:  queries,
+		auditor:  auditor,
+		guard:    guard,
+		logger:   logger,
+		handlers: make(map[string]Func),
+		cfg:      cfg,
+		queued:   metrics.Int64Gauge(metrics.JobsQueued),
+		wait:     metrics.Float64Histogram(metrics.JobsWait),
+		duration: metrics.Float64Histogram(metrics.JobsDuration),
+	}
+	for _, lane := range cfg.Lanes {
+		if rank, ok := priorityRanks[Priority(lane)]; ok {
+			q.lanes = append(q.lanes, rank)
+		}
+	}
+
+	q.Register(KindPrune, func(ctx context.Context, _ json.RawMessage) error {
+		return q.Prune(ctx)
+	})
+	q.RegisterRecurring(Recurring{Kind: KindPrune, Every: time.Hour, Priority: PriorityLow})
+	return q
+}
+
+// Register makes a job kind available to Enqueue
+func (q *Queue) Register(kind string, fn Func) {
+	q.handlers[kind] = fn
+}
+
+// RegisterRecurring enqueues a registered job kind every r.Every
+func (q *Queue) RegisterRecurring(r Recurring) {
+	q.recurring = append(q.recurring, r)
+}
+
+// Enqueue stores a job that runs as soon as a worker is free
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) (*Job, error) {
+	return q.Schedule(ctx, kind, payload, Options{})
+}
+
+// Schedule stores a job that runs at opts.RunAt in the opts.Priority lane
+func (q *Queue) Schedule(ctx context.Context, kind string, payload any, opts Options) (*Job, error) {
+	if _, ok := q.handlers[kind]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+	rank, err := parsePriority(opts.Priority)
+	if err != nil {
+		return nil, err
+	}
+	runAt := opts.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	ctx, span := tracer.Start(ctx, "jobs.enqueue "+kind,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("job.kind", kind)),
+	)
+	defer span.End()
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	traceContext, err := json.Marshal(carrier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job trace context: %w", err)
+	}
+	params := db.EnqueueJobParams{
+		Kind:         kind,
+		Payload:      raw,
+		MaxAttempts:  int32(q.cfg.MaxAttempts),
+		TraceContext: traceContext,
+		Priority:     rank,
+		RunAt:        pgtype.Timestamptz{Time: runAt, Valid: true},
+	}
+	if requestID := logger.RequestID(ctx); requestID != "" {
+		params.RequestID = pgtype.Text{String: requestID, Valid: true}
+	}
+
+	var row db.Job
+	if opts.UniqueKey != "" {
+		row, err = q.enqueueUnique(ctx, kind+":"+opts.UniqueKey, opts.UniqueFor, params)
+	} else {
+		row, err = q.queries.EnqueueJob(ctx, params)
+	}
+	if errors.Is(err, errDuplicate) {
+		span.SetAttributes(attribute.Bool("job.duplicate", true))
+		job := toJob(row)
+		job.Duplicate = true
+		return job, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	job := toJob(row)
+	span.SetAttributes(attribute.String("job.id", job.ID.String()))
+	return job, nil
+}
+
+// errDuplicate reports that enqueueUnique returned the existing job
+var errDuplicate = errors.New("duplicate job")
+
+// enqueueUnique inserts the job unless the unique key is held by a job
+// enqueued within its window, in which case that job is returned with
+// errDuplicate
+func (q *Queue) enqueueUnique(ctx context.Context, key string, window time.Duration, params db.EnqueueJobParams) (db.Job, error) {
+	if window <= 0 {
+		window = defaultUniqueFor
+	}
+	row, err := q.queries.EnqueueUniqueJob(ctx, db.EnqueueUniqueJobParams{
+		UniqueKey:    key,
+		ID:           ids.PG(ids.NewV7()),
+		ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(window), Valid: true},
+		Kind:         params.Kind,
+		Payload:      params.Payload,
+		MaxAttempts:  params.MaxAttempts,
+		TraceContext: params.TraceContext,
+		RequestID:    params.RequestID,
+		Priority:     params.Priority,
+		RunAt:        params.RunAt,
+	})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return row, err
+	}
+
+	existing, err := q.queries.GetJobByUniqueKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The earlier job finished and was pruned while its key is live
+			return db.Job{}, ErrDuplicateJob
+		}
+		return db.Job{}, fmt.Errorf("failed to get job by unique key: %w", err)
+	}
+	return existing, errDuplicate
+}
+
+// Work enqueues recurring jobs that are due, then runs every due job in
+// this replica's lanes, claiming batches until none are left
+func (q *Queue) Work(ctx context.Context) error {
+	q.enqueueRecurring(ctx)
+	if len(q.lanes) == 0 {
+		return nil
+	}
+
+	for {
+		rows, err := q.queries.ClaimDueJobs(ctx, db.ClaimDueJobsParams{
+			Priorities:   q.lanes,
+			RowLimit:     claimBatch,
+			LeaseSeconds: q.cfg.Lease.Seconds(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to claim jobs: %w", err)
+		}
+		for _, row := range rows {
+			if err := q.run(ctx, row); err != nil {
+				q.logger.Error("failed to record job result", "job_id", uuid.UUID(row.ID.Bytes), "kind", row.Kind, "error", err)
+			}
+		}
+		if len(rows) < claimBatch {
+			return nil
+		}
+	}
+}
+
+// enqueueRecurring enqueues each recurring job whose interval has passed.
+// Claiming the next run in the database keeps replicas from enqueueing
+// the same run twice.
+func (q *Queue) enqueueRecurring(ctx context.Context) {
+	for _, r := range q.recurring {
+		_, err := q.queries.ClaimRecurringJob(ctx, db.ClaimRecurringJobParams{
+			Kind:      r.Kind,
+			NextRunAt: pgtype.Timestamptz{Time: time.Now().Add(r.Every), Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err == nil {
+			_, err = q.Schedule(ctx, r.Kind, r.Payload, Options{Priority: r.Priority})
+		}
+		if err != nil {
+			q.logger.Error("failed to enqueue recurring job", "kind", r.Kind, "error", err)
+		}
+	}
+}
+
+// run executes one claimed job in the trace and request it was enqueued
+// from, and records the outcome
+func (q *Queue) run(ctx context.Context, row db.ClaimDueJobsRow) error {
+	priority := priorityName(row.Priority)
+	q.wait.Record(ctx, time.Since(row.DueAt.Time).Seconds(), metric.WithAttributes(
+		attribute.String("kind", row.Kind),
+		attribute.String("priority", string(priority)),
+	))
+
+	ctx, span := q.startSpan(ctx, row)
+	defer span.End()
+
+	if row.RequestID.Valid {
+		ctx = logger.WithRequestID(ctx, row.RequestID.String)
+	}
+	ctx = logger.WithContext(ctx, q.logger.With("job_id", uuid.UUID(row.ID.Bytes), "kind", row.Kind, "attempt", row.Attempts))
+	log := logger.FromContext(ctx)
+	ctx = context.WithValue(ctx, runningKey{}, &runningJob{
+		queries:     q.queries,
+		jobID:       row.ID,
+		lastAttempt: row.Attempts >= row.MaxAttempts,
+	})
+
+	start := time.Now()
+	var err error
+	if fn, ok := q.handlers[row.Kind]; ok {
+		err = q.guard.Call(ctx, "jobs."+row.Kind, func(ctx context.Context) error {
+			return fn(ctx, row.Payload)
+		})
+	} else {
+		err = fmt.Errorf("%w: %s", ErrUnknownKind, row.Kind)
+	}
+	result := "succeeded"
+	if err != nil {
+		result = "failed"
+	}
+	q.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+		attribute.String("kind", row.Kind),
+		attribute.String("result", result),
+	))
+
+	// Persist the outcome even if the worker is shutting down
+	saveCtx := context.WithoutCancel(ctx)
+	if err == nil {
+		kpi.JobProcessed(ctx, row.Kind, true)
+		return q.queries.CompleteJob(saveCtx, row.ID)
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	lastError := pgtype.Text{String: err.Error(), Valid: true}
+	var p *panics.Panic
+	if errors.As(err, &p) {
+		lastError.String = "internal error"
+	}
+	if row.Attempts >= row.MaxAttempts || errors.Is(err, ErrUnknownKind) {
+		log.Error("job failed", "error", err)
+		kpi.JobProcessed(ctx, row.Kind, false)
+		return q.queries.FailJob(saveCtx, db.FailJobParams{ID: row.ID, LastError: lastError})
+	}
+	log.Warn("job attempt failed", "error", err)
+	return q.queries.RetryJobLater(saveCtx, db.RetryJobLaterParams{
+		ID:        row.ID,
+		LastError: lastError,
+		RunAt:     pgtype.Timestamptz{Time: time.Now().Add(q.retryDelay(int(row.Attempts))), Valid: true},
+	})
+}
+
+// startSpan starts the span of one attempt as a child of the enqueue span,
+// linked to it so backends that split traces at queues still connect them
+func (q *Queue) startSpan(ctx context.Context, row db.ClaimDueJobsRow) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("job.id", uuid.UUID(row.ID.Bytes).String()),
+			attribute.String("job.kind", row.Kind),
+			attribute.String("job.priority", string(priorityName(row.Priority))),
+			attribute.Int("job.attempt", int(row.Attempts)),
+		),
+	}
+	var carrier propagation.MapCarrier
+	if err := json.Unmarshal(row.TraceContext, &carrier); err == nil && len(carrier) > 0 {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+		opts = append(opts, trace.WithLinks(trace.LinkFromContext(ctx)))
+	}
+	return tracer.Start(ctx, "jobs.run "+row.Kind, opts...)
+}
+
+func (q *Queue) retryDelay(attempts int) time.Duration {
+	delay := q.cfg.Backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// RecordDepth reports how many queued jobs each lane holds
+func (q *Queue) RecordDepth(ctx context.Context) error {
+	rows, err := q.queries.CountQueuedJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count queued jobs: %w", err)
+	}
+	// Lanes without jobs report zero rather than their last count
+	counts := make(map[Priority]map[string]int64, len(priorityRanks))
+	for priority := range priorityRanks {
+		counts[priority] = map[string]int64{"ready": 0, "scheduled": 0}
+	}
+	for _, row := range rows {
+		state := "scheduled"
+		if row.Ready {
+			state = "ready"
+		}
+		counts[priorityName(row.Priority)][state] += row.Jobs
+	}
+	for priority, states := range counts {
+		for state, n := range states {
+			q.queued.Record(ctx, n, metric.WithAttributes(
+				attribute.String("priority", string(priority)),
+				attribute.String("state", state),
+			))
+		}
+	}
+	return nil
+}
+
+// Prune deletes jobs that finished longer ago than the retention period,
+// and unique keys whose window has passed
+func (q *Queue) Prune(ctx context.Context) error {
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(-q.cfg.Retention), Valid: true}
+	if _, err := q.queries.DeleteFinishedJobs(ctx, cutoff); err != nil {
+		return fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+	if _, err := q.queries.DeleteExpiredJobUniqueKeys(ctx); err != nil {
+		return fmt.Errorf("failed to delete expired job unique keys: %w", err)
+	}
+	return nil
+}
+
+// Get returns a job
+func (q *Queue) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	row, err := q.queries.GetJob(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return toJob(row), nil
+}
+
+// List returns the most recently created jobs, optionally only those with
+// the given status and kind
+func (q *Queue) List(ctx context.Context, status, kind string, limit int) ([]*Job, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	rows, err := q.queries.ListJobs(ctx, db.ListJobsParams{
+		Status:   pgtype.Text{String: status, Valid: status != ""},
+		Kind:     pgtype.Text{String: kind, Valid: kind != ""},
+		RowLimit: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	jobs := make([]*Job, len(rows))
+	for i, row := range rows {
+		jobs[i] = toJob(row)
+	}
+	return jobs, nil
+}
+
+// Retry queues a failed or cancelled job to run again now with fresh
+// attempts
+func (q *Queue) Retry(ctx context.Context, id uuid.UUID, actor string) (*Job, error) {
+	row, err := q.queries.RetryJob(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		return nil, q.changeError(ctx, id, err, ErrNotRetryable, "retry")
+	}
+	job := toJob(row)
+	q.record(ctx, actor, "job.retried", job, nil)
+	return job, nil
+}
+
+// Cancel stops a queued job from running
+func (q *Queue) Cancel(ctx context.Context, id uuid.UUID, actor string) (*Job, error) {
+	row, err := q.queries.CancelJob(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		return nil, q.changeError(ctx, id, err, ErrNotQueued, "cancel")
+	}
+	job := toJob(row)
+	q.record(ctx, actor, "job.cancelled", job, nil)
+	return job, nil
+}
+
+// SetPriority moves a queued job to another lane
+func (q *Queue) SetPriority(ctx context.Context, id uuid.UUID, priority Priority, actor string) (*Job, error) {
+	if priority == "" {
+		return nil, ErrInvalidPriority
+	}
+	rank, err := parsePriority(priority)
+	if err != nil {
+		return nil, err
+	}
+	row, err := q.queries.SetJobPriority(ctx, db.SetJobPriorityParams{
+		ID:       pgtype.UUID{Bytes: id, Valid: true},
+		Priority: rank,
+	})
+	if err != nil {
+		return nil, q.changeError(ctx, id, err, ErrNotQueued, "reprioritize")
+	}
+	job := toJob(row)
+	q.record(ctx, actor, "job.reprioritized", job, map[string]any{"priority": job.Priority})
+	return job, nil
+}
+
+// changeError tells a missing job apart from one whose status does not
+// allow the change
+func (q *Queue) changeError(ctx context.Context, id uuid.UUID, err, conflict error, action string) error {
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to %s job: %w", action, err)
+	}
+	if _, getErr := q.Get(ctx, id); getErr != nil {
+		return getErr
+	}
+	return conflict
+}
+
+// record writes an audit entry; failures are logged since the change has
+// already been committed
+func (q *Queue) record(ctx context.Context, actor, action string, job *Job, extra map[string]any) {
+	metadata := map[string]any{"kind": job.Kind}
+	for k, v := range extra {
+		metadata[k] = v
+	}
+	if err := q.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "job",
+		ResourceID:   job.ID.String(),
+		Metadata:     metadata,
+	}); err != nil {
+		logger.FromContext(ctx).Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+func parsePriority(p Priority) (int16, error) {
+	if p == "" {
+		p = PriorityDefault
+	}
+	rank, ok := priorityRanks[p]
+	if !ok {
+		return 0, ErrInvalidPriority
+	}
+	return rank, nil
+}
+
+func priorityName(rank int16) Priority {
+	for p, r := range priorityRanks {
+		if r == rank {
+			return p
+		}
+	}
+	return PriorityDefault
+}
+
+func toJob(row db.Job) *Job {
+	job := &Job{
+		ID:          uuid.UUID(row.ID.Bytes),
+		Kind:        row.Kind,
+		Payload:     json.RawMessage(row.Payload),
+		Status:      row.Status,
+		Priority:    priorityName(row.Priority),
+		Attempts:    int(row.Attempts),
+		MaxAttempts: int(row.MaxAttempts),
+		RequestID:   row.RequestID.String,
+		RunAt:       row.RunAt.Time,
+		CreatedAt:   row.CreatedAt.Time,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}
+	if row.LastError.Valid {
+		job.LastError = &row.LastError.String
+	}
+	if row.FinishedAt.Valid {
+		job.FinishedAt = &row.FinishedAt.Time
+	}
+	return job
+}
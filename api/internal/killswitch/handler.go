@@ -0,0 +1,104 @@
+package killswitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/request"
+)
+
+type ServiceInterface interface {
+	List() []Switch
+	Set(ctx context.Context, req SwitchRequest, actor string) (*Switch, error)
+	Clear(ctx context.Context, route, actor string) error
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleList returns the switches in effect on the replica serving the
+// request
+func (h *Handler) HandleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.respondWithJSON(w, http.StatusOK, map[string]any{"kill_switches": h.service.List()})
+	}
+}
+
+// HandleSet switches off the route in the body, which answers 503 with the
+// reason until the switch is cleared
+func (h *Handler) HandleSet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SwitchRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		sw, err := h.service.Set(r.Context(), req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithSwitchError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, sw)
+	}
+}
+
+// HandleClear switches the route in the route query parameter back on
+func (h *Handler) HandleClear() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Query().Get("route")
+		if route == "" {
+			apierror.Write(w, r, apierror.BadRequest("route_parameter_required", "route parameter is required"))
+			return
+		}
+
+		if err := h.service.Clear(r.Context(), route, actorFromRequest(r)); err != nil {
+			h.respondWithSwitchError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) respondWithSwitchError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrSwitchNotFound):
+		apierror.Write(w, r, apierror.NotFound("switch_not_found", err.Error()))
+	case errors.Is(err, ErrUnknownRoute):
+		apierror.Write(w, r, apierror.Unprocessable("unknown_route", err.Error()))
+	case errors.Is(err, ErrProtectedRoute):
+		apierror.Write(w, r, apierror.Unprocessable("protected_route", err.Error()))
+	case errors.Is(err, ErrConfigured):
+		apierror.Write(w, r, apierror.Conflict("switch_configured", err.Error()))
+	default:
+		h.logger.Error("kill switch request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
@@ -0,0 +1,78 @@
+package killswitch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"starterkit/internal/platform/request"
+)
+
+const (
+	maxRouteLength  = 255
+	maxReasonLength = 500
+)
+
+// AdminPath is where switches are managed; its routes cannot be switched
+// off, so a switch can always be cleared
+const AdminPath = "/admin/kill-switches"
+
+var (
+	ErrSwitchNotFound = errors.New("kill switch not found")
+	ErrUnknownRoute   = errors.New("no route is registered with this pattern")
+	ErrConfigured     = errors.New("kill switch is set in KILL_SWITCHES and is removed by changing that setting")
+	ErrProtectedRoute = errors.New("the routes managing kill switches cannot be switched off")
+)
+
+// Sources of kill switches
+const (
+	SourceConfig = "config"
+	SourceAdmin  = "admin"
+)
+
+// Switch turns off a route. Route is the registered pattern as listed by
+// server routes, such as "POST /api/v1/links".
+type Switch struct {
+	Route     string    `json:"route"`
+	Reason    string    `json:"reason"`
+	Source    string    `json:"source"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+// SwitchRequest turns off a route
+type SwitchRequest struct {
+	Route  string `json:"route"`
+	Reason string `json:"reason"`
+}
+
+// Validate trims the route and reason
+func (r *SwitchRequest) Validate() error {
+	r.Route = strings.TrimSpace(r.Route)
+	r.Reason = strings.TrimSpace(r.Reason)
+
+	var v request.Validation
+	v.Check(validRoute(r.Route), "route", fmt.Sprintf("must be a route pattern such as \"POST /api/v1/links\" of at most %d characters", maxRouteLength))
+	v.Check(r.Reason != "" && utf8.RuneCountInString(r.Reason) <= maxReasonLength, "reason", fmt.Sprintf("must be 1-%d characters", maxReasonLength))
+	return v.Err()
+}
+
+// protected reports whether route manages kill switches
+func protected(route string) bool {
+	_, path, _ := strings.Cut(route, " ")
+	return path == AdminPath
+}
+
+// validRoute reports whether route is a path pattern, optionally preceded
+// by a method
+func validRoute(route string) bool {
+	if route == "" || len(route) > maxRouteLength {
+		return false
+	}
+	if method, path, ok := strings.Cut(route, " "); ok {
+		return method != "" && method == strings.ToUpper(method) && strings.HasPrefix(path, "/")
+	}
+	return strings.HasPrefix(route, "/")
+}
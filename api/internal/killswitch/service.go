@@ -0,0 +1,204 @@
+package killswitch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+)
+
+type Querier interface {
+	UpsertKillSwitch(ctx context.Context, arg db.UpsertKillSwitchParams) (db.KillSwitch, error)
+	ListKillSwitches(ctx context.Context) ([]db.KillSwitch, error)
+	DeleteKillSwitch(ctx context.Context, route string) (int64, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service decides which routes are switched off. Switches come from
+// KILL_SWITCHES, which apply from startup, and from the database, where
+// operators set them at runtime. Database switches are read into memory by
+// Reload, so checking a request never waits on the database; a switch set
+// through this replica applies at once, and through another replica after
+// the next reload.
+type Service struct {
+	queries Querier
+	auditor Auditor
+	logger  *slog.Logger
+
+	mu       sync.RWMutex
+	static   map[string]Switch
+	switches map[string]Switch
+	// routes are the registered patterns switches may name, once known
+	routes map[string]bool
+}
+
+// NewService creates the kill switches, with static mapping routes to the
+// reasons they are switched off for
+func NewService(queries Querier, auditor Auditor, static map[string]string, logger *slog.Logger) *Service {
+	s := &Service{
+		queries:  queries,
+		auditor:  auditor,
+		logger:   logger,
+		static:   make(map[string]Switch, len(static)),
+		switches: make(map[string]Switch),
+	}
+	for route, reason := range static {
+		if protected(route) {
+			logger.Warn("ignoring kill switch of a route managing kill switches", "route", route)
+			continue
+		}
+		s.static[route] = Switch{Route: route, Reason: reason, Source: SourceConfig}
+	}
+	return s
+}
+
+// SetRoutes lists the registered route patterns, so switches naming a route
+// that does not exist are rejected instead of silently matching nothing
+func (s *Service) SetRoutes(routes []string) {
+	known := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		known[route] = true
+	}
+	s.mu.Lock()
+	s.routes = known
+	s.mu.Unlock()
+
+	for route := range s.static {
+		if !known[route] {
+			s.logger.Warn("kill switch names no registered route", "route", route)
+		}
+	}
+}
+
+// Check returns the switch turning off route, if any
+func (s *Service) Check(route string) (Switch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if sw, ok := s.static[route]; ok {
+		return sw, true
+	}
+	sw, ok := s.switches[route]
+	return sw, ok
+}
+
+// Reload reads the switches set at runtime from the database
+func (s *Service) Reload(ctx context.Context) error {
+	rows, err := s.queries.ListKillSwitches(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list kill switches: %w", err)
+	}
+	switches := make(map[string]Switch, len(rows))
+	for _, row := range rows {
+		switches[row.Route] = toSwitch(row)
+	}
+	s.mu.Lock()
+	s.switches = switches
+	s.mu.Unlock()
+	return nil
+}
+
+// List returns the switches in effect on this replica, ordered by route
+func (s *Service) List() []Switch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Switch, 0, len(s.static)+len(s.switches))
+	for _, sw := range s.static {
+		list = append(list, sw)
+	}
+	for route, sw := range s.switches {
+		if _, ok := s.static[route]; !ok {
+			list = append(list, sw)
+		}
+	}
+	slices.SortFunc(list, func(a, b Switch) int {
+		return strings.Compare(a.Route, b.Route)
+	})
+	return list
+}
+
+// Set switches off a route, or changes the reason it is switched off for
+func (s *Service) Set(ctx context.Context, req SwitchRequest, actor string) (*Switch, error) {
+	if protected(req.Route) {
+		return nil, ErrProtectedRoute
+	}
+	s.mu.RLock()
+	known := s.routes == nil || s.routes[req.Route]
+	s.mu.RUnlock()
+	if !known {
+		return nil, ErrUnknownRoute
+	}
+
+	row, err := s.queries.UpsertKillSwitch(ctx, db.UpsertKillSwitchParams{
+		Route:     req.Route,
+		Reason:    req.Reason,
+		CreatedBy: actor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save kill switch: %w", err)
+	}
+
+	sw := toSwitch(row)
+	s.mu.Lock()
+	s.switches[sw.Route] = sw
+	s.mu.Unlock()
+
+	s.logger.Warn("route switched off", "route", sw.Route, "reason", sw.Reason, "actor", actor)
+	s.record(ctx, actor, "kill_switch.set", sw.Route, map[string]any{"reason": sw.Reason})
+	return &sw, nil
+}
+
+// Clear switches a route back on. Switches set in KILL_SWITCHES stay on
+// until the setting changes.
+func (s *Service) Clear(ctx context.Context, route, actor string) error {
+	s.mu.RLock()
+	_, configured := s.static[route]
+	s.mu.RUnlock()
+	if configured {
+		return ErrConfigured
+	}
+
+	deleted, err := s.queries.DeleteKillSwitch(ctx, route)
+	if err != nil {
+		return fmt.Errorf("failed to delete kill switch: %w", err)
+	}
+	s.mu.Lock()
+	delete(s.switches, route)
+	s.mu.Unlock()
+	if deleted == 0 {
+		return ErrSwitchNotFound
+	}
+
+	s.logger.Info("route switched back on", "route", route, "actor", actor)
+	s.record(ctx, actor, "kill_switch.clear", route, nil)
+	return nil
+}
+
+func (s *Service) record(ctx context.Context, actor, action, route string, metadata map[string]any) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "kill_switch",
+		ResourceID:   route,
+		Metadata:     metadata,
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+func toSwitch(row db.KillSwitch) Switch {
+	return Switch{
+		Route:     row.Route,
+		Reason:    row.Reason,
+		Source:    SourceAdmin,
+		CreatedBy: row.CreatedBy,
+		CreatedAt: row.CreatedAt.Time,
+	}
+}
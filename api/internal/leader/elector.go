@@ -0,0 +1,155 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type Querier interface {
+	AcquireLeaderLease(ctx context.Context, arg db.AcquireLeaderLeaseParams) (db.LeaderLease, error)
+	GetLeaderLease(ctx context.Context, name string) (db.LeaderLease, error)
+	ReleaseLeaderLease(ctx context.Context, arg db.ReleaseLeaderLeaseParams) error
+}
+
+// Elector campaigns for a named lease in the leader_leases table. The
+// holder renews it every third of its TTL; when the holder stops renewing,
+// another replica takes over once the lease expires. Lease times come from
+// the database clock, so replica clock skew does not matter.
+type Elector struct {
+	queries Querier
+	name    string
+	holder  string
+	ttl     time.Duration
+	logger  *slog.Logger
+	leading atomic.Bool
+	// renewedAt is when this replica last renewed, as seen by Run
+	renewedAt time.Time
+
+	isLeader    metric.Int64Gauge
+	transitions metric.Int64Counter
+}
+
+func NewElector(queries Querier, name, holder string, ttl time.Duration, logger *slog.Logger) *Elector {
+	return &Elector{
+		queries:     queries,
+		name:        name,
+		holder:      holder,
+		ttl:         ttl,
+		logger:      logger,
+		isLeader:    metrics.Int64Gauge(metrics.LeaderIsLeader),
+		transitions: metrics.Int64Counter(metrics.LeaderTransitions),
+	}
+}
+
+// IsLeader reports whether this replica currently holds the lease
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Run campaigns and renews until ctx is cancelled, then releases the lease
+// so another replica can take over without waiting for it to expire
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		e.campaign(ctx)
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) campaign(ctx context.Context) {
+	_, err := e.queries.AcquireLeaderLease(ctx, db.AcquireLeaderLeaseParams{
+		Name:       e.name,
+		Holder:     e.holder,
+		TtlSeconds: e.ttl.Seconds(),
+	})
+	switch {
+	case err == nil:
+		e.renewedAt = time.Now()
+		e.setLeading(true)
+	case errors.Is(err, pgx.ErrNoRows):
+		e.setLeading(false)
+	default:
+		e.logger.Error("failed to renew leader lease", "lease", e.name, "error", err)
+		// Keep leading through a brief outage, but stop a renewal interval
+		// before the lease could expire and pass to another replica
+		if time.Since(e.renewedAt) > e.ttl-e.ttl/3 {
+			e.setLeading(false)
+		}
+	}
+}
+
+func (e *Elector) release() {
+	if !e.leading.Load() {
+		return
+	}
+	e.setLeading(false)
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl/3)
+	defer cancel()
+	if err := e.queries.ReleaseLeaderLease(ctx, db.ReleaseLeaderLeaseParams{
+		Name:   e.name,
+		Holder: e.holder,
+	}); err != nil {
+		e.logger.Error("failed to release leader lease", "lease", e.name, "error", err)
+	}
+}
+
+func (e *Elector) setLeading(leading bool) {
+	attrs := metric.WithAttributes(attribute.String("leader.name", e.name))
+	if leading {
+		e.isLeader.Record(context.Background(), 1, attrs)
+	} else {
+		e.isLeader.Record(context.Background(), 0, attrs)
+	}
+	if e.leading.Swap(leading) == leading {
+		return
+	}
+
+	transition := "lost"
+	if leading {
+		transition = "acquired"
+	}
+	e.transitions.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("leader.name", e.name),
+		attribute.String("transition", transition),
+	))
+	e.logger.Info("leadership changed", "lease", e.name, "holder", e.holder, "transition", transition)
+}
+
+// Status returns this replica's role and the current lease
+func (e *Elector) Status(ctx context.Context) (Status, error) {
+	status := Status{Self: e.holder, IsLeader: e.IsLeader()}
+	lease, err := e.queries.GetLeaderLease(ctx, e.name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return status, nil
+	}
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to get leader lease: %w", err)
+	}
+	status.Lease = &Lease{
+		Name:       lease.Name,
+		Holder:     lease.Holder,
+		AcquiredAt: lease.AcquiredAt.Time,
+		RenewedAt:  lease.RenewedAt.Time,
+		ExpiresAt:  lease.ExpiresAt.Time,
+		Expired:    !lease.ExpiresAt.Time.After(time.Now()),
+	}
+	return status, nil
+}
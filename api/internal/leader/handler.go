@@ -0,0 +1,42 @@
+package leader
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+)
+
+type Handler struct {
+	elector *Elector
+	logger  *slog.Logger
+}
+
+func NewHandler(elector *Elector, logger *slog.Logger) *Handler {
+	return &Handler{
+		elector: elector,
+		logger:  logger,
+	}
+}
+
+// HandleStatus returns the current leader and this replica's role
+func (h *Handler) HandleStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, err := h.elector.Status(r.Context())
+		if err != nil {
+			h.logger.Error("failed to get leader status", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, status)
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
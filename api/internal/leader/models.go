@@ -0,0 +1,21 @@
+package leader
+
+import "time"
+
+// Lease records which replica holds leadership
+type Lease struct {
+	Name       string    `json:"name"`
+	Holder     string    `json:"holder"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Expired means the holder stopped renewing and no replica leads
+	Expired bool `json:"expired"`
+}
+
+// Status is a replica's view of the election
+type Status struct {
+	Self     string `json:"self"`
+	IsLeader bool   `json:"is_leader"`
+	Lease    *Lease `json:"lease"`
+}
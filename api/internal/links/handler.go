@@ -0,0 +1,196 @@
+package links
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/geoip"
+	"starterkit/internal/platform/qrcode"
+	"starterkit/internal/platform/request"
+	"starterkit/internal/platform/useragent"
+)
+
+// QR code image sizes in pixels
+const (
+	defaultQRSize = 256
+	minQRSize     = 64
+	maxQRSize     = 1024
+)
+
+type ServiceInterface interface {
+	Create(ctx context.Context, req LinkRequest, actor string) (*Link, error)
+	Get(ctx context.Context, code string) (*Stats, error)
+	ShortURL(ctx context.Context, code string) (string, error)
+	Follow(ctx context.Context, code string, click Click) (string, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleCreate creates a short link
+func (h *Handler) HandleCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req LinkRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		link, err := h.service.Create(r.Context(), req, r.Header.Get("X-User-Email"))
+		if err != nil {
+			h.respondWithLinkError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Location", "/api/v1/links/"+link.Code)
+		h.respondWithJSON(w, http.StatusCreated, link)
+	}
+}
+
+// HandleGet returns a link with its click counts
+func (h *Handler) HandleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := h.service.Get(r.Context(), r.PathValue("code"))
+		if err != nil {
+			h.respondWithLinkError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, stats)
+	}
+}
+
+// HandleQRCode serves a QR code of a link's short URL
+func (h *Handler) HandleQRCode() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, size, ok := h.parseQROptions(w, r)
+		if !ok {
+			return
+		}
+
+		shortURL, err := h.service.ShortURL(r.Context(), r.PathValue("code"))
+		if err != nil {
+			h.respondWithLinkError(w, r, err)
+			return
+		}
+
+		h.writeQRCode(w, r, shortURL, format, size)
+	}
+}
+
+// HandleQR serves a QR code of the data query parameter, such as a device
+// verification URI shown for scanning with a phone
+func (h *Handler) HandleQR() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, size, ok := h.parseQROptions(w, r)
+		if !ok {
+			return
+		}
+
+		h.writeQRCode(w, r, r.URL.Query().Get("data"), format, size)
+	}
+}
+
+// HandleRedirect sends the caller to a link's target and counts the click
+func (h *Handler) HandleRedirect() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var click Click
+		if referer, err := url.Parse(r.Referer()); err == nil {
+			click.RefererHost = referer.Hostname()
+		}
+		if agent, ok := useragent.FromContext(r.Context()); ok {
+			click.DeviceClass = agent.DeviceClass
+		}
+		if loc, ok := geoip.FromContext(r.Context()); ok {
+			click.Country = loc.Country
+		}
+
+		target, err := h.service.Follow(r.Context(), r.PathValue("code"), click)
+		if err != nil {
+			h.respondWithLinkError(w, r, err)
+			return
+		}
+
+		// 302 rather than 301, so browsers come back and every click counts
+		http.Redirect(w, r, target, http.StatusFound)
+	}
+}
+
+func (h *Handler) parseQROptions(w http.ResponseWriter, r *http.Request) (format string, size int, ok bool) {
+	format = r.URL.Query().Get("format")
+	if format == "" {
+		format = qrcode.FormatPNG
+	}
+	if format != qrcode.FormatPNG && format != qrcode.FormatSVG {
+		apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "format must be png or svg"))
+		return "", 0, false
+	}
+
+	size = defaultQRSize
+	if s := r.URL.Query().Get("size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < minQRSize || n > maxQRSize {
+			apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "size must be 64-1024"))
+			return "", 0, false
+		}
+		size = n
+	}
+	return format, size, true
+}
+
+func (h *Handler) writeQRCode(w http.ResponseWriter, r *http.Request, content, format string, size int) {
+	image, err := qrcode.Render(content, format, size)
+	if err != nil {
+		if errors.Is(err, qrcode.ErrInvalidContent) {
+			apierror.Write(w, r, apierror.BadRequest("invalid_content", err.Error()))
+			return
+		}
+		h.logger.Error("failed to render QR code", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+		return
+	}
+
+	// The same content always renders the same image
+	w.Header().Set("Content-Type", qrcode.ContentType(format))
+	w.Header().Set("Content-Length", strconv.Itoa(len(image)))
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(image); err != nil {
+		h.logger.Error("failed to write QR code", "error", err)
+	}
+}
+
+func (h *Handler) respondWithLinkError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrLinkNotFound):
+		apierror.Write(w, r, apierror.NotFound("link_not_found", err.Error()))
+	case errors.Is(err, ErrLinkExpired):
+		apierror.Write(w, r, apierror.New(http.StatusGone, "link_expired", err.Error()))
+	default:
+		h.logger.Error("short link request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,68 @@
+package links
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"starterkit/internal/platform/request"
+)
+
+var (
+	ErrLinkNotFound = errors.New("short link not found")
+	ErrLinkExpired  = errors.New("short link has expired")
+)
+
+const maxURLLength = 2048
+
+// statsDays is how far back the daily click counts go
+const statsDays = 30
+
+// Link redirects its short URL to a target URL
+type Link struct {
+	Code          string     `json:"code"`
+	URL           string     `json:"url"`
+	ShortURL      string     `json:"short_url"`
+	Clicks        int64      `json:"clicks"`
+	CreatedBy     string     `json:"created_by"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	LastClickedAt *time.Time `json:"last_clicked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Stats is a link with its clicks per UTC day over the last 30 days. Days
+// without clicks are left out.
+type Stats struct {
+	Link
+	Daily []DailyClicks `json:"daily"`
+}
+
+type DailyClicks struct {
+	Day    string `json:"day"`
+	Clicks int64  `json:"clicks"`
+}
+
+// Click describes a redirect for click tracking. No IP address is kept.
+type Click struct {
+	RefererHost string
+	DeviceClass string
+	Country     string
+}
+
+// LinkRequest creates a short link, optionally expiring
+type LinkRequest struct {
+	URL       string     `json:"url"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Validate checks that the target is an absolute http or https URL and
+// that the expiry is in the future
+func (r *LinkRequest) Validate() error {
+	var v request.Validation
+	u, err := url.Parse(r.URL)
+	v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && len(r.URL) <= maxURLLength,
+		"url", fmt.Sprintf("must be an http or https URL of at most %d characters", maxURLLength))
+	v.Check(r.ExpiresAt == nil || r.ExpiresAt.After(time.Now()), "expires_at", "must be in the future")
+	return v.Err()
+}
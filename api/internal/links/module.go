@@ -0,0 +1,44 @@
+package links
+
+import (
+	"log/slog"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/module"
+	"starterkit/internal/platform/wiring"
+)
+
+func init() {
+	module.Register(&Module{})
+}
+
+// Module serves short link redirects and the API managing links and QR
+// codes
+type Module struct {
+	module.Base
+	handler *Handler
+}
+
+func (m *Module) Name() string {
+	return "links"
+}
+
+func (m *Module) Init(c *wiring.Container) error {
+	cfg, logger := wiring.Use[*config.Config](c), wiring.Use[*slog.Logger](c)
+	service := NewService(wiring.Use[*db.Queries](c), wiring.Use[audit.Recorder](c), cfg.Links.BaseURL, logger)
+	m.handler = NewHandler(service, logger)
+	return nil
+}
+
+func (m *Module) RegisterRoutes(r module.Routes) {
+	// Short link redirects
+	r.Public.HandleFunc("GET /l/{code}", m.handler.HandleRedirect())
+
+	// Short links with click counts, and QR codes of links or any text
+	r.API.HandleFunc("POST /links", m.handler.HandleCreate())
+	r.API.HandleFunc("GET /links/{code}", m.handler.HandleGet())
+	r.API.HandleFunc("GET /links/{code}/qr", m.handler.HandleQRCode())
+	r.API.HandleFunc("GET /qr", m.handler.HandleQR())
+}
@@ -0,0 +1,220 @@
+package links
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// codeAlphabet leaves out characters that are easily misread, such as 0
+// and O or 1 and l, so codes can be typed from print
+const codeAlphabet = "23456789abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+
+const (
+	codeLength = 7
+	// maxCodeLength is the width of the code column
+	maxCodeLength = 16
+	// codeAttempts bounds the retries after a generated code collides
+	codeAttempts = 3
+)
+
+type Querier interface {
+	CreateShortLink(ctx context.Context, arg db.CreateShortLinkParams) (db.ShortLink, error)
+	GetShortLink(ctx context.Context, code string) (db.ShortLink, error)
+	FollowShortLink(ctx context.Context, code string) (db.ShortLink, error)
+	RecordShortLinkClick(ctx context.Context, arg db.RecordShortLinkClickParams) error
+	CountShortLinkClicksByDay(ctx context.Context, arg db.CountShortLinkClicksByDayParams) ([]db.CountShortLinkClicksByDayRow, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service creates short links and resolves them, counting every click
+type Service struct {
+	queries Querier
+	auditor Auditor
+	baseURL string
+	logger  *slog.Logger
+}
+
+// NewService creates the short link service. Short URLs are baseURL
+// followed by /l/{code}.
+func NewService(queries Querier, auditor Auditor, baseURL string, logger *slog.Logger) *Service {
+	return &Service{
+		queries: queries,
+		auditor: auditor,
+		baseURL: baseURL,
+		logger:  logger,
+	}
+}
+
+// Create stores a short link with a new random code
+func (s *Service) Create(ctx context.Context, req LinkRequest, actor string) (*Link, error) {
+	expiresAt := pgtype.Timestamptz{}
+	if req.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	for attempt := 1; ; attempt++ {
+		row, err := s.queries.CreateShortLink(ctx, db.CreateShortLinkParams{
+			Code:      newCode(),
+			TargetUrl: req.URL,
+			CreatedBy: actor,
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && attempt < codeAttempts {
+				continue
+			}
+			return nil, fmt.Errorf("failed to create short link: %w", err)
+		}
+
+		link := s.toLink(row)
+		if err := s.auditor.Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "link.create",
+			ResourceType: "short_link",
+			ResourceID:   link.Code,
+			Metadata:     map[string]any{"url": link.URL},
+		}); err != nil {
+			s.logger.Warn("failed to record audit entry", "error", err, "action", "link.create")
+		}
+		return link, nil
+	}
+}
+
+// Get returns a link with its recent daily clicks
+func (s *Service) Get(ctx context.Context, code string) (*Stats, error) {
+	row, err := s.get(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-statsDays)
+	days, err := s.queries.CountShortLinkClicksByDay(ctx, db.CountShortLinkClicksByDayParams{
+		LinkID: row.ID,
+		Since:  pgtype.Timestamptz{Time: since, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count short link clicks: %w", err)
+	}
+
+	stats := &Stats{Link: *s.toLink(row), Daily: make([]DailyClicks, 0, len(days))}
+	for _, d := range days {
+		stats.Daily = append(stats.Daily, DailyClicks{Day: d.Day.Time.Format(time.DateOnly), Clicks: d.Clicks})
+	}
+	return stats, nil
+}
+
+// ShortURL returns the public URL of an existing link
+func (s *Service) ShortURL(ctx context.Context, code string) (string, error) {
+	if _, err := s.get(ctx, code); err != nil {
+		return "", err
+	}
+	return s.shortURL(code), nil
+}
+
+// Follow counts a click on a link and returns its target. Failing to
+// record the click's details does not fail the redirect.
+func (s *Service) Follow(ctx context.Context, code string, click Click) (string, error) {
+	if !validCode(code) {
+		return "", ErrLinkNotFound
+	}
+	row, err := s.queries.FollowShortLink(ctx, code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, err := s.get(ctx, code); err != nil {
+				return "", err
+			}
+			return "", ErrLinkExpired
+		}
+		return "", fmt.Errorf("failed to follow short link: %w", err)
+	}
+
+	if err := s.queries.RecordShortLinkClick(ctx, db.RecordShortLinkClickParams{
+		LinkID:      row.ID,
+		RefererHost: click.RefererHost,
+		DeviceClass: click.DeviceClass,
+		Country:     click.Country,
+	}); err != nil {
+		s.logger.Warn("failed to record short link click", "error", err, "code", code)
+	}
+	return row.TargetUrl, nil
+}
+
+func (s *Service) get(ctx context.Context, code string) (db.ShortLink, error) {
+	if !validCode(code) {
+		return db.ShortLink{}, ErrLinkNotFound
+	}
+	row, err := s.queries.GetShortLink(ctx, code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.ShortLink{}, ErrLinkNotFound
+		}
+		return db.ShortLink{}, fmt.Errorf("failed to get short link: %w", err)
+	}
+	return row, nil
+}
+
+func (s *Service) shortURL(code string) string {
+	return s.baseURL + "/l/" + code
+}
+
+func (s *Service) toLink(row db.ShortLink) *Link {
+	link := &Link{
+		Code:      row.Code,
+		URL:       row.TargetUrl,
+		ShortURL:  s.shortURL(row.Code),
+		Clicks:    row.Clicks,
+		CreatedBy: row.CreatedBy,
+		CreatedAt: row.CreatedAt.Time,
+	}
+	if row.ExpiresAt.Valid {
+		link.ExpiresAt = &row.ExpiresAt.Time
+	}
+	if row.LastClickedAt.Valid {
+		link.LastClickedAt = &row.LastClickedAt.Time
+	}
+	return link
+}
+
+func newCode() string {
+	code := make([]byte, 0, codeLength)
+	b := make([]byte, 1)
+	for len(code) < codeLength {
+		_, _ = rand.Read(b)
+		// Bytes past the last whole multiple of the alphabet are skipped so
+		// every character is equally likely
+		if int(b[0]) < 256-256%len(codeAlphabet) {
+			code = append(code, codeAlphabet[int(b[0])%len(codeAlphabet)])
+		}
+	}
+	return string(code)
+}
+
+// validCode rejects codes that could not have been generated before
+// querying for them
+func validCode(code string) bool {
+	if code == "" || len(code) > maxCodeLength {
+		return false
+	}
+	for _, c := range code {
+		if !strings.ContainsRune(codeAlphabet, c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/operations"
+	"starterkit/internal/platform/apierror"
+)
+
+type ServiceInterface interface {
+	GenerateUsers(ctx context.Context, count int, actor string) (*Result, error)
+	Reset(ctx context.Context, actor string) (*Result, error)
+}
+
+// OperationRunner runs long mutations in the background and returns a receipt
+type OperationRunner interface {
+	Start(ctx context.Context, kind, owner string, fn operations.Func) (*operations.Operation, error)
+}
+
+type Handler struct {
+	service    ServiceInterface
+	operations OperationRunner
+	logger     *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, ops OperationRunner, logger *slog.Logger) *Handler {
+	return &Handler{
+		service:    service,
+		operations: ops,
+		logger:     logger,
+	}
+}
+
+// HandleGenerateUsers starts generating synthetic users in the background
+// and responds with an operation receipt
+func (h *Handler) HandleGenerateUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+		if req.Count < 1 || req.Count > MaxUsers {
+			apierror.Write(w, r, apierror.BadRequest("invalid_count", ErrInvalidCount.Error()))
+			return
+		}
+
+		actor := actorFromRequest(r)
+		h.start(w, r, "loadtest.generate_users", func(ctx context.Context) (any, error) {
+			return h.service.GenerateUsers(ctx, req.Count, actor)
+		})
+	}
+}
+
+// HandleReset starts deleting every synthetic user in the background and
+// responds with an operation receipt
+func (h *Handler) HandleReset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor := actorFromRequest(r)
+		h.start(w, r, "loadtest.reset", func(ctx context.Context) (any, error) {
+			return h.service.Reset(ctx, actor)
+		})
+	}
+}
+
+func (h *Handler) start(w http.ResponseWriter, r *http.Request, kind string, fn operations.Func) {
+	op, err := h.operations.Start(r.Context(), kind, operations.Owner(r), fn)
+	if err != nil {
+		h.logger.Error("failed to start load test operation", "error", err, "kind", kind)
+		apierror.Write(w, r, apierror.Internal())
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/operations/"+op.ID.String())
+	h.respondWithJSON(w, http.StatusAccepted, op)
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// actorFromRequest identifies the caller for audit purposes
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
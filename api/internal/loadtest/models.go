@@ -0,0 +1,19 @@
+package loadtest
+
+// Domain is the email domain of synthetic users. Reset deletes users at
+// this domain and nothing else; .invalid can never receive mail.
+const Domain = "loadtest.invalid"
+
+// MaxUsers bounds one generation request
+const MaxUsers = 1_000_000
+
+// GenerateRequest asks for Count synthetic users
+type GenerateRequest struct {
+	Count int `json:"count"`
+}
+
+// Result summarizes a generation or reset
+type Result struct {
+	Users      int64 `json:"users"`
+	DurationMS int64 `json:"duration_ms"`
+}
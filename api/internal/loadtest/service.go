@@ -0,0 +1,143 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/ids"
+	"starterkit/internal/platform/logger"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrInvalidCount = fmt.Errorf("count must be between 1 and %d", MaxUsers)
+
+// DB starts the transactions fixtures are written in
+type DB interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service provisions synthetic data for load tests. It is only wired in
+// when APP_ENV is loadtest.
+type Service struct {
+	db      DB
+	auditor Auditor
+	ids     ids.Generator
+}
+
+func NewService(database DB, auditor Auditor, idGen ids.Generator) *Service {
+	return &Service{
+		db:      database,
+		auditor: auditor,
+		ids:     idGen,
+	}
+}
+
+var (
+	firstNames = []string{"Ada", "Ben", "Chloe", "Dev", "Elena", "Farah", "Gus", "Hana", "Ivan", "Jun", "Kofi", "Lena", "Mateo", "Nia", "Omar", "Priya", "Quinn", "Rosa", "Sven", "Tariq", "Uma", "Victor", "Wen", "Yara", "Zoe"}
+	lastNames  = []string{"Abara", "Becker", "Costa", "Dubois", "Eriksen", "Fischer", "Garcia", "Haddad", "Ito", "Jensen", "Kowalski", "Lopez", "Mensah", "Nguyen", "Okafor", "Patel", "Rossi", "Silva", "Tanaka", "Varga", "Weber", "Yilmaz", "Zhang"}
+	bios       = []string{"", "", "Coffee first.", "Building things on weekends.", "Runner, reader, occasional baker.", "Here for the product updates.", "Trying out the new dashboard."}
+)
+
+// userColumns are the columns Generate copies into users
+var userColumns = []string{"id", "email", "name", "bio", "handle", "created_at", "updated_at"}
+
+// GenerateUsers bulk inserts count synthetic users with COPY. Names, bios,
+// and signup times spread over the past year are picked at random; half of
+// the users get a handle. Emails carry a per-call tag, so repeated calls
+// add users rather than colliding.
+func (s *Service) GenerateUsers(ctx context.Context, count int, actor string) (*Result, error) {
+	if count < 1 || count > MaxUsers {
+		return nil, ErrInvalidCount
+	}
+	ctx = database.WithWorkload(ctx, database.WorkloadBackground)
+	start := time.Now()
+	tag := s.ids.New().String()[:8]
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin generation: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	i := 0
+	rows := pgx.CopyFromFunc(func() ([]any, error) {
+		if i == count {
+			return nil, nil
+		}
+		i++
+		first, last := firstNames[rand.IntN(len(firstNames))], lastNames[rand.IntN(len(lastNames))]
+		var handle *string
+		if rand.IntN(2) == 0 {
+			h := fmt.Sprintf("lt_%s_%d", tag, i)
+			handle = &h
+		}
+		createdAt := start.Add(-time.Duration(rand.Int64N(int64(365 * 24 * time.Hour))))
+		return []any{
+			s.ids.New(),
+			fmt.Sprintf("user%d.%s@%s", i, tag, Domain),
+			first + " " + last,
+			bios[rand.IntN(len(bios))],
+			handle,
+			createdAt,
+			createdAt,
+		}, nil
+	})
+	copied, err := tx.CopyFrom(ctx, pgx.Identifier{"users"}, userColumns, rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy users: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit generation: %w", err)
+	}
+
+	result := &Result{Users: copied, DurationMS: time.Since(start).Milliseconds()}
+	s.record(ctx, actor, "loadtest.users_generated", result)
+	return result, nil
+}
+
+// Reset deletes every synthetic user, and with them the rows that cascade
+// from users. Real users are never touched.
+func (s *Service) Reset(ctx context.Context, actor string) (*Result, error) {
+	ctx = database.WithWorkload(ctx, database.WorkloadBackground)
+	start := time.Now()
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin reset: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	tag, err := tx.Exec(ctx, "DELETE FROM users WHERE email LIKE '%@' || $1", Domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete synthetic users: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit reset: %w", err)
+	}
+
+	result := &Result{Users: tag.RowsAffected(), DurationMS: time.Since(start).Milliseconds()}
+	s.record(ctx, actor, "loadtest.reset", result)
+	return result, nil
+}
+
+// record writes an audit entry; failures are not propagated because the
+// fixtures have already been committed
+func (s *Service) record(ctx context.Context, actor, action string, result *Result) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "loadtest",
+		Metadata:     map[string]any{"users": result.Users},
+	}); err != nil {
+		logger.FromContext(ctx).Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
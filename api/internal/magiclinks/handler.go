@@ -0,0 +1,142 @@
+package magiclinks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/netip"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/geoip"
+	"starterkit/internal/platform/request"
+	"starterkit/internal/platform/useragent"
+	"starterkit/internal/sessions"
+)
+
+type ServiceInterface interface {
+	RequestLink(ctx context.Context, req LinkRequest, client Client) error
+	SignIn(ctx context.Context, req SignInRequest, client Client) (*Session, error)
+	SignOut(ctx context.Context, token string) error
+}
+
+type Handler struct {
+	service  ServiceInterface
+	clientIP func(*http.Request) netip.Addr
+	logger   *slog.Logger
+}
+
+// NewHandler creates the sign-in handlers; clientIP returns the caller's
+// address as the server resolves it behind trusted proxies
+func NewHandler(service ServiceInterface, clientIP func(*http.Request) netip.Addr, logger *slog.Logger) *Handler {
+	return &Handler{
+		service:  service,
+		clientIP: clientIP,
+		logger:   logger,
+	}
+}
+
+// HandleRequestLink emails a sign-in link. The response is the same whether
+// or not the email has an account.
+func (h *Handler) HandleRequestLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req LinkRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		if err := h.service.RequestLink(r.Context(), req, h.client(r)); err != nil {
+			h.respondWithLinkError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusAccepted, map[string]string{
+			"message": "If an account exists for that email, a sign-in link is on its way.",
+		})
+	}
+}
+
+// HandleSignIn exchanges a sign-in link's token for a session token
+func (h *Handler) HandleSignIn() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SignInRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		session, err := h.service.SignIn(r.Context(), req, h.client(r))
+		if err != nil {
+			h.respondWithLinkError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		h.respondWithJSON(w, http.StatusOK, session)
+	}
+}
+
+// HandleSignOut revokes the session token the request is authenticated with
+func (h *Handler) HandleSignOut() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.BearerToken(r)
+		if err != nil {
+			apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "invalid_token", err.Error()))
+			return
+		}
+
+		if err := h.service.SignOut(r.Context(), token); err != nil {
+			h.respondWithLinkError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// client describes the device of r
+func (h *Handler) client(r *http.Request) Client {
+	agent, _ := useragent.FromContext(r.Context())
+	client := Client{
+		Key:    sessions.Key(r.Header.Get("X-Session-ID"), r.UserAgent()),
+		Device: sessions.Device(agent),
+	}
+	if ip := h.clientIP(r); ip.IsValid() {
+		client.IPAddress = ip.String()
+	}
+	if loc, ok := geoip.FromContext(r.Context()); ok {
+		client.Country = loc.Country
+	}
+	return client
+}
+
+func (h *Handler) respondWithLinkError(w http.ResponseWriter, r *http.Request, err error) {
+	var confirm *DeviceConfirmationError
+	switch {
+	case errors.As(err, &confirm):
+		h.respondWithJSON(w, http.StatusConflict, map[string]any{
+			"error":          confirm.Error(),
+			"requested_from": confirm.RequestedFrom,
+		})
+	case errors.Is(err, ErrRateLimited):
+		apierror.Write(w, r, apierror.TooManyRequests("rate_limited", err.Error()))
+	case errors.Is(err, ErrInvalidLink):
+		apierror.Write(w, r, apierror.BadRequest("invalid_link", err.Error()))
+	case errors.Is(err, ErrInvalidSession):
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "invalid_session", err.Error()))
+	default:
+		h.logger.Error("sign-in request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
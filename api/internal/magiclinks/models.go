@@ -0,0 +1,98 @@
+package magiclinks
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRateLimited                = errors.New("too many sign-in links requested; try again later")
+	ErrInvalidLink                = errors.New("sign-in link is invalid, expired, or already used")
+	ErrDeviceConfirmationRequired = errors.New("sign-in link was requested from another device")
+	ErrInvalidSession             = errors.New("invalid session token")
+)
+
+// LinkRequest asks for a sign-in link to be emailed
+type LinkRequest struct {
+	Email string `json:"email"`
+}
+
+func (r *LinkRequest) Validate() error {
+	r.Email = strings.TrimSpace(r.Email)
+
+	var v request.Validation
+	addr, err := mail.ParseAddress(r.Email)
+	v.Check(err == nil && addr.Address == r.Email, "email", "must be an email address")
+	return v.Err()
+}
+
+// SignInRequest signs in with the token of a sign-in link
+type SignInRequest struct {
+	Token string `json:"token"`
+	// ConfirmDevice signs in on a device other than the one the link was
+	// requested from
+	ConfirmDevice bool `json:"confirm_device"`
+}
+
+func (r *SignInRequest) Validate() error {
+	r.Token = strings.TrimSpace(r.Token)
+
+	var v request.Validation
+	v.Check(r.Token != "", "token", "is required")
+	return v.Err()
+}
+
+// Client is the device a request comes from
+type Client struct {
+	// Key identifies the device as sessions.Key does
+	Key       string
+	Device    string
+	IPAddress string
+	Country   string
+}
+
+// Session is a signed-in session; the response is the only time its token
+// is shown
+type Session struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RequestedFrom is the device a sign-in link was requested from
+type RequestedFrom struct {
+	Device      string    `json:"device"`
+	Country     *string   `json:"country,omitempty"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// DeviceConfirmationError is returned for a link opened on another device
+// than it was requested from, until the sign-in is confirmed
+type DeviceConfirmationError struct {
+	RequestedFrom RequestedFrom
+}
+
+func (e *DeviceConfirmationError) Error() string {
+	return ErrDeviceConfirmationRequired.Error()
+}
+
+func (e *DeviceConfirmationError) Unwrap() error {
+	return ErrDeviceConfirmationRequired
+}
+
+// Identity is the user a session token authenticates
+type Identity struct {
+	SessionID uuid.UUID
+	UserID    uuid.UUID
+	Email     string
+	ExpiresAt time.Time
+	// Roles were granted to the user in the app
+	Roles []string
+}
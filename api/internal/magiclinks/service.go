@@ -0,0 +1,393 @@
+package magiclinks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/email"
+	"starterkit/internal/platform/auth"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// tokenPrefix marks session tokens, so they are told apart from the
+// identity provider's tokens and are recognizable in leaked logs
+const tokenPrefix = "sess_"
+
+// nonceLength is the random part of a link token
+const nonceLength = 24
+
+// rateWindow is the period the per-email and per-IP link caps apply to
+const rateWindow = time.Hour
+
+// Sign-in methods recorded with sessions
+const (
+	MethodMagicLink = "magic_link"
+	MethodPasskey   = "passkey"
+)
+
+// touchInterval is how often each replica writes a session's last use
+const touchInterval = time.Minute
+
+type Querier interface {
+	GetActiveUserIDByEmail(ctx context.Context, email string) (pgtype.UUID, error)
+	CountRecentMagicLinks(ctx context.Context, arg db.CountRecentMagicLinksParams) (db.CountRecentMagicLinksRow, error)
+	CreateMagicLink(ctx context.Context, arg db.CreateMagicLinkParams) (db.MagicLink, error)
+	GetMagicLink(ctx context.Context, tokenHash string) (db.MagicLink, error)
+	UseMagicLink(ctx context.Context, id pgtype.UUID) (db.MagicLink, error)
+	PruneMagicLinks(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	CreateAuthSession(ctx context.Context, arg db.CreateAuthSessionParams) (db.AuthSession, error)
+	GetActiveAuthSession(ctx context.Context, tokenHash string) (db.GetActiveAuthSessionRow, error)
+	TouchAuthSession(ctx context.Context, id pgtype.UUID) error
+	RevokeAuthSession(ctx context.Context, tokenHash string) (int64, error)
+	PruneAuthSessions(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+}
+
+// Mailer queues outbound email
+type Mailer interface {
+	Queue(ctx context.Context, msg email.Email) (*email.Message, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service emails single-use sign-in links and exchanges them for session
+// tokens, which the auth middleware accepts as bearer tokens
+type Service struct {
+	queries Querier
+	mail    Mailer
+	auditor Auditor
+	cfg     config.MagicLinksConfig
+	logger  *slog.Logger
+
+	mu      sync.Mutex
+	touched map[uuid.UUID]time.Time
+}
+
+func NewService(queries Querier, mailer Mailer, auditor Auditor, cfg config.MagicLinksConfig, logger *slog.Logger) *Service {
+	return &Service{
+		queries: queries,
+		mail:    mailer,
+		auditor: auditor,
+		cfg:     cfg,
+		logger:  logger,
+		touched: make(map[uuid.UUID]time.Time),
+	}
+}
+
+// IsSessionToken reports whether a bearer token is a session token rather
+// than a token of the identity provider
+func IsSessionToken(token string) bool {
+	return strings.HasPrefix(token, tokenPrefix)
+}
+
+// RequestLink emails a sign-in link to the user with req.Email. Requests
+// for unknown emails, and for emails already sent their hourly cap of
+// links or sent one within the resend interval, succeed without sending,
+// so callers cannot tell which emails have accounts. Only the per-IP cap
+// returns ErrRateLimited.
+func (s *Service) RequestLink(ctx context.Context, req LinkRequest, client Client) error {
+	now := time.Now()
+	ip := pgtype.Text{String: client.IPAddress, Valid: client.IPAddress != ""}
+	counts, err := s.queries.CountRecentMagicLinks(ctx, db.CountRecentMagicLinksParams{
+		Email:       req.Email,
+		IpAddress:   ip,
+		ResentAfter: pgtype.Timestamptz{Time: now.Add(-s.cfg.ResendInterval), Valid: true},
+		Since:       pgtype.Timestamptz{Time: now.Add(-rateWindow), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to count sign-in links: %w", err)
+	}
+	if ip.Valid && counts.FromIp >= int64(s.cfg.MaxPerIP) {
+		return ErrRateLimited
+	}
+	if counts.FromEmail >= int64(s.cfg.MaxPerEmail) || counts.Resent > 0 {
+		s.logger.Info("sign-in link throttled", "from_email", counts.FromEmail, "resent", counts.Resent)
+		return nil
+	}
+
+	userID, err := s.queries.GetActiveUserIDByEmail(ctx, req.Email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	expiresAt := now.Add(s.cfg.TTL)
+	token := s.sign(expiresAt)
+	if _, err := s.queries.CreateMagicLink(ctx, db.CreateMagicLinkParams{
+		UserID:    userID,
+		Email:     req.Email,
+		TokenHash: hash(token),
+		DeviceKey: client.Key,
+		Device:    client.Device,
+		IpAddress: ip,
+		Country:   pgtype.Text{String: client.Country, Valid: client.Country != ""},
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to store sign-in link: %w", err)
+	}
+
+	id := uuid.UUID(userID.Bytes)
+	if _, err := s.mail.Queue(ctx, email.Email{
+		UserID:  &id,
+		To:      req.Email,
+		Subject: "Your sign-in link",
+		Body:    s.linkBody(token, client),
+	}); err != nil {
+		return fmt.Errorf("failed to queue sign-in link: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) linkBody(token string, client Client) string {
+	link := s.cfg.CallbackURL
+	if strings.Contains(link, "?") {
+		link += "&token=" + url.QueryEscape(token)
+	} else {
+		link += "?token=" + url.QueryEscape(token)
+	}
+
+	var b strings.Builder
+	b.WriteString("Use this link to sign in:\n\n")
+	fmt.Fprintf(&b, "%s\n\n", link)
+	fmt.Fprintf(&b, "The link works once and expires in %s.\n", s.cfg.TTL)
+	fmt.Fprintf(&b, "It was requested from %s", client.Device)
+	if client.Country != "" {
+		fmt.Fprintf(&b, " in %s", client.Country)
+	}
+	b.WriteString(". If you didn't request it, you can ignore this email.\n")
+	return b.String()
+}
+
+// SignIn uses the link with req.Token and starts a session for its user.
+// A link opened on another device than it was requested from returns a
+// DeviceConfirmationError, and stays usable, until req.ConfirmDevice is set.
+func (s *Service) SignIn(ctx context.Context, req SignInRequest, client Client) (*Session, error) {
+	if !s.verify(req.Token, time.Now()) {
+		return nil, ErrInvalidLink
+	}
+	link, err := s.queries.GetMagicLink(ctx, hash(req.Token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidLink
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sign-in link: %w", err)
+	}
+	if link.UsedAt.Valid || !time.Now().Before(link.ExpiresAt.Time) {
+		return nil, ErrInvalidLink
+	}
+
+	otherDevice := link.DeviceKey != client.Key
+	if otherDevice && !req.ConfirmDevice {
+		return nil, &DeviceConfirmationError{RequestedFrom: RequestedFrom{
+			Device:      link.Device,
+			Country:     textPtr(link.Country),
+			RequestedAt: link.CreatedAt.Time,
+		}}
+	}
+
+	// Only one of concurrent sign-ins with the same link gets the row
+	link, err = s.queries.UseMagicLink(ctx, link.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidLink
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to use sign-in link: %w", err)
+	}
+
+	session, sessionID, err := s.startSession(ctx, db.CreateAuthSessionParams{
+		UserID:      link.UserID,
+		MagicLinkID: link.ID,
+		Method:      MethodMagicLink,
+	}, link.Email, client, s.cfg.SessionTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        link.Email,
+		Action:       "auth.magic_link_sign_in",
+		ResourceType: "user",
+		ResourceID:   uuid.UUID(link.UserID.Bytes).String(),
+		Metadata: map[string]any{
+			"session_id":   sessionID.String(),
+			"device":       client.Device,
+			"other_device": otherDevice,
+		},
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", "auth.magic_link_sign_in")
+	}
+	return session, nil
+}
+
+// IssueSession starts a session lasting ttl for a user another sign-in
+// method authenticated, such as a passkey; method is recorded with it
+func (s *Service) IssueSession(ctx context.Context, userID uuid.UUID, email, method string, client Client, ttl time.Duration) (*Session, error) {
+	session, _, err := s.startSession(ctx, db.CreateAuthSessionParams{
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+		Method: method,
+	}, email, client, ttl)
+	return session, err
+}
+
+// startSession creates a session row from arg and the client, and returns
+// the session with its token
+func (s *Service) startSession(ctx context.Context, arg db.CreateAuthSessionParams, email string, client Client, ttl time.Duration) (*Session, uuid.UUID, error) {
+	token := tokenPrefix + strings.ToLower(rand.Text())
+	arg.TokenHash = hash(token)
+	arg.Device = client.Device
+	arg.IpAddress = pgtype.Text{String: client.IPAddress, Valid: client.IPAddress != ""}
+	arg.Country = pgtype.Text{String: client.Country, Valid: client.Country != ""}
+	arg.ExpiresAt = pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true}
+	row, err := s.queries.CreateAuthSession(ctx, arg)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return &Session{
+		Token:     token,
+		TokenType: "Bearer",
+		Email:     email,
+		ExpiresAt: row.ExpiresAt.Time,
+	}, uuid.UUID(row.ID.Bytes), nil
+}
+
+// Authenticate returns the user of an active session token and records the
+// session's use
+func (s *Service) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	if !IsSessionToken(token) {
+		return nil, ErrInvalidSession
+	}
+	row, err := s.queries.GetActiveAuthSession(ctx, hash(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up session: %w", err)
+	}
+
+	identity := &Identity{
+		SessionID: uuid.UUID(row.ID.Bytes),
+		UserID:    uuid.UUID(row.UserID.Bytes),
+		Email:     row.Email,
+		ExpiresAt: row.ExpiresAt.Time,
+		Roles:     row.Roles,
+	}
+	if s.due(identity.SessionID, time.Now()) {
+		if err := s.queries.TouchAuthSession(ctx, row.ID); err != nil {
+			s.logger.Warn("failed to record session use", "error", err, "session_id", identity.SessionID)
+		}
+	}
+	return identity, nil
+}
+
+// SignOut revokes a session token
+func (s *Service) SignOut(ctx context.Context, token string) error {
+	if !IsSessionToken(token) {
+		return ErrInvalidSession
+	}
+	rows, err := s.queries.RevokeAuthSession(ctx, hash(token))
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if rows == 0 {
+		return ErrInvalidSession
+	}
+	return nil
+}
+
+// Prune deletes links past the rate window and their expiry, and expired
+// or revoked sessions
+func (s *Service) Prune(ctx context.Context) error {
+	now := time.Now()
+	if _, err := s.queries.PruneMagicLinks(ctx, pgtype.Timestamptz{Time: now.Add(-max(rateWindow, s.cfg.TTL)), Valid: true}); err != nil {
+		return fmt.Errorf("failed to prune sign-in links: %w", err)
+	}
+	if _, err := s.queries.PruneAuthSessions(ctx, pgtype.Timestamptz{Time: now, Valid: true}); err != nil {
+		return fmt.Errorf("failed to prune sessions: %w", err)
+	}
+
+	s.mu.Lock()
+	for id, last := range s.touched {
+		if now.Sub(last) >= touchInterval {
+			delete(s.touched, id)
+		}
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// sign returns a link token expiring at expiresAt: a random nonce and the
+// expiry, signed with the configured secret
+func (s *Service) sign(expiresAt time.Time) string {
+	payload := make([]byte, nonceLength+8)
+	_, _ = rand.Read(payload[:nonceLength])
+	binary.BigEndian.PutUint64(payload[nonceLength:], uint64(expiresAt.Unix()))
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + s.mac(encoded)
+}
+
+// verify reports whether token was signed with the configured secret and
+// has not expired, before it is looked up
+func (s *Service) verify(token string, now time.Time) bool {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !auth.Equal(signature, s.mac(encoded)) {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(payload) != nonceLength+8 {
+		return false
+	}
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[nonceLength:])), 0)
+	return now.Before(expiresAt)
+}
+
+func (s *Service) mac(payload string) string {
+	m := hmac.New(sha256.New, []byte(s.cfg.Secret))
+	m.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// due reports whether a session's last use should be written, at most once
+// per touch interval
+func (s *Service) due(id uuid.UUID, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.touched[id]; ok && now.Sub(last) < touchInterval {
+		return false
+	}
+	s.touched[id] = now
+	return true
+}
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func textPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
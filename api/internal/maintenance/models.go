@@ -0,0 +1,49 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"starterkit/internal/db"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrTaskNotFound = errors.New("maintenance task not found")
+	ErrInvalidArgs  = errors.New("invalid task arguments")
+)
+
+// Task is a one-off maintenance script such as a backfill or data fix
+type Task struct {
+	Name        string
+	Description string
+	// Params lists the key=value arguments the task accepts
+	Params []string
+	Run    func(ctx context.Context, env *Env) (Result, error)
+}
+
+// Env is the context a task runs with. Queries and Tx share one
+// transaction that is rolled back on error or in dry-run mode, so tasks
+// write normally and never check DryRun just to avoid changes.
+type Env struct {
+	Queries *db.Queries
+	Tx      pgx.Tx
+	Logger  *slog.Logger
+	DryRun  bool
+	Args    map[string]string
+}
+
+// Result summarizes what a task changed
+type Result struct {
+	Affected int64
+	Message  string
+}
+
+// RunOptions controls a single task execution
+type RunOptions struct {
+	DryRun bool
+	Actor  string
+	Args   map[string]string
+}
@@ -0,0 +1,42 @@
+package maintenance
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Registry holds the tasks available to the runner
+type Registry struct {
+	tasks map[string]Task
+}
+
+func NewRegistry() *Registry {
+	return &Registry{tasks: make(map[string]Task)}
+}
+
+// Register adds a task; names must be unique
+func (r *Registry) Register(task Task) {
+	if _, exists := r.tasks[task.Name]; exists {
+		panic(fmt.Sprintf("maintenance task %q registered twice", task.Name))
+	}
+	r.tasks[task.Name] = task
+}
+
+// Get returns the named task
+func (r *Registry) Get(name string) (Task, error) {
+	task, ok := r.tasks[name]
+	if !ok {
+		return Task{}, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// List returns all tasks sorted by name
+func (r *Registry) List() []Task {
+	tasks := make([]Task, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	return tasks
+}
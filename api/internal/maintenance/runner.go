@@ -0,0 +1,119 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Runner executes registered tasks in a transaction and audits each run
+type Runner struct {
+	registry *Registry
+	pool     *pgxpool.Pool
+	auditor  Auditor
+	logger   *slog.Logger
+}
+
+func NewRunner(registry *Registry, pool *pgxpool.Pool, auditor Auditor, logger *slog.Logger) *Runner {
+	return &Runner{
+		registry: registry,
+		pool:     pool,
+		auditor:  auditor,
+		logger:   logger,
+	}
+}
+
+// Run executes the named task. Its changes are committed only if it
+// succeeds and opts.DryRun is false. Every execution, including failed and
+// dry runs, is written to the audit log.
+func (r *Runner) Run(ctx context.Context, name string, opts RunOptions) (Result, error) {
+	task, err := r.registry.Get(name)
+	if err != nil {
+		return Result{}, err
+	}
+	for key := range opts.Args {
+		if !slices.Contains(task.Params, key) {
+			return Result{}, fmt.Errorf("%w: unknown argument %q", ErrInvalidArgs, key)
+		}
+	}
+
+	ctx, span := otel.Tracer("starterkit/maintenance").Start(ctx, "maintenance."+name)
+	defer span.End()
+	span.SetAttributes(attribute.Bool("maintenance.dry_run", opts.DryRun))
+
+	start := time.Now()
+	result, committed, err := r.execute(ctx, task, opts)
+	duration := time.Since(start)
+
+	metadata := map[string]any{
+		"dry_run":     opts.DryRun,
+		"args":        opts.Args,
+		"committed":   committed,
+		"affected":    result.Affected,
+		"duration_ms": duration.Milliseconds(),
+	}
+	if err != nil {
+		metadata["error"] = err.Error()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if auditErr := r.auditor.Record(ctx, audit.Entry{
+		Actor:        opts.Actor,
+		Action:       "maintenance.task.executed",
+		ResourceType: "maintenance_task",
+		ResourceID:   name,
+		Metadata:     metadata,
+	}); auditErr != nil {
+		r.logger.Warn("failed to record audit entry", "task", name, "error", auditErr)
+	}
+
+	return result, err
+}
+
+// execute runs the task in a transaction and reports whether it was committed
+func (r *Runner) execute(ctx context.Context, task Task, opts RunOptions) (Result, bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Result{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	args := opts.Args
+	if args == nil {
+		args = map[string]string{}
+	}
+	env := &Env{
+		Queries: db.New(tx),
+		Tx:      tx,
+		Logger:  r.logger.With("task", task.Name, "dry_run", opts.DryRun),
+		DryRun:  opts.DryRun,
+		Args:    args,
+	}
+
+	result, err := task.Run(ctx, env)
+	if err != nil {
+		return result, false, err
+	}
+	if opts.DryRun {
+		return result, false, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return result, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, true, nil
+}
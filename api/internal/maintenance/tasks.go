@@ -0,0 +1,67 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Builtin returns a registry with the repository's maintenance tasks.
+// Add new backfills and data fixes here instead of running ad-hoc SQL.
+func Builtin() *Registry {
+	r := NewRegistry()
+	r.Register(Task{
+		Name:        "normalize-user-emails",
+		Description: "Trim and lowercase user emails, skipping any that would collide with an existing address",
+		Run:         normalizeUserEmails,
+	})
+	r.Register(Task{
+		Name:        "purge-expired-phone-verifications",
+		Description: "Delete phone verification codes that expired more than grace ago",
+		Params:      []string{"grace"},
+		Run:         purgeExpiredPhoneVerifications,
+	})
+	return r
+}
+
+func normalizeUserEmails(ctx context.Context, env *Env) (Result, error) {
+	tag, err := env.Tx.Exec(ctx, `
+		UPDATE users u
+		SET email = LOWER(TRIM(u.email)), updated_at = NOW()
+		WHERE u.email <> LOWER(TRIM(u.email))
+			AND NOT EXISTS (
+				SELECT 1 FROM users o
+				WHERE o.id <> u.id AND o.email = LOWER(TRIM(u.email))
+			)`)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to normalize emails: %w", err)
+	}
+
+	var conflicts int64
+	if err := env.Tx.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE email <> LOWER(TRIM(email))`).Scan(&conflicts); err != nil {
+		return Result{}, fmt.Errorf("failed to count remaining emails: %w", err)
+	}
+
+	return Result{
+		Affected: tag.RowsAffected(),
+		Message:  fmt.Sprintf("%d emails still need manual review", conflicts),
+	}, nil
+}
+
+func purgeExpiredPhoneVerifications(ctx context.Context, env *Env) (Result, error) {
+	grace := 24 * time.Hour
+	if v := env.Args["grace"]; v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil || parsed < 0 {
+			return Result{}, fmt.Errorf("%w: grace must be a duration, got %s", ErrInvalidArgs, strconv.Quote(v))
+		}
+		grace = parsed
+	}
+
+	tag, err := env.Tx.Exec(ctx, `DELETE FROM phone_verifications WHERE expires_at < $1`, time.Now().Add(-grace))
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to purge phone verifications: %w", err)
+	}
+	return Result{Affected: tag.RowsAffected()}, nil
+}
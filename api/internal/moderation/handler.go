@@ -0,0 +1,104 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/listing"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	ListFlags(ctx context.Context, status string, newestFirst bool, limit, offset int) ([]*Flag, error)
+	CountFlags(ctx context.Context, status string) (int64, error)
+	Resolve(ctx context.Context, id uuid.UUID, req ResolveRequest, actor string) (*Flag, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// QueueList declares the query parameters of the review queue
+var QueueList = listing.Spec{
+	Items:        "flags",
+	DefaultLimit: 50,
+	MaxLimit:     200,
+	Filters: []listing.Filter{
+		{Name: "status", Description: "Review status of the entries", Values: Statuses, Default: StatusPending},
+	},
+	Sorts: []string{"created_at", "-created_at"},
+}
+
+// HandleListQueue lists review queue entries; its route declares QueueList
+func (h *Handler) HandleListQueue() http.HandlerFunc {
+	source := listing.Source[*Flag]{
+		List: func(ctx context.Context, q listing.Query) ([]*Flag, error) {
+			return h.service.ListFlags(ctx, q.Filter("status"), q.Sort == "-created_at", q.Limit, q.Offset)
+		},
+		Count: func(ctx context.Context, q listing.Query) (int64, error) {
+			return h.service.CountFlags(ctx, q.Filter("status"))
+		},
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		listing.Serve(w, r, source)
+	}
+}
+
+func (h *Handler) HandleResolve() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flagID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_flag_id", "invalid flag ID format"))
+			return
+		}
+
+		var req ResolveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		flag, err := h.service.Resolve(r.Context(), flagID, req, actorFromRequest(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrInvalidDecision):
+				apierror.Write(w, r, apierror.BadRequest("invalid_decision", err.Error()))
+			case errors.Is(err, ErrFlagNotFound):
+				apierror.Write(w, r, apierror.NotFound("moderation_flag_not_found", "moderation flag not found"))
+			case errors.Is(err, ErrAlreadyResolved):
+				apierror.Write(w, r, apierror.Conflict("already_resolved", err.Error()))
+			default:
+				h.logger.Error("failed to resolve moderation flag", "error", err, "flag_id", flagID)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, flag)
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
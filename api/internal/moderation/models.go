@@ -0,0 +1,39 @@
+package moderation
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Review queue statuses. Flagged content stays published while pending.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRemoved  = "removed"
+)
+
+// Statuses lists the review queue statuses
+var Statuses = []string{StatusPending, StatusApproved, StatusRemoved}
+
+// Flag is a piece of user-generated content held for review
+type Flag struct {
+	ID           uuid.UUID  `json:"id"`
+	ResourceType string     `json:"resource_type"`
+	ResourceID   string     `json:"resource_id"`
+	Field        string     `json:"field"`
+	Content      string     `json:"content"`
+	Reasons      []string   `json:"reasons"`
+	Actor        string     `json:"actor"`
+	Status       string     `json:"status"`
+	ReviewedBy   *string    `json:"reviewed_by,omitempty"`
+	ReviewNote   *string    `json:"review_note,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ResolveRequest is a reviewer's decision on a flag
+type ResolveRequest struct {
+	Decision string `json:"decision"`
+	Note     string `json:"note"`
+}
@@ -0,0 +1,295 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/dryrun"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/moderator"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrRejected        = errors.New("content rejected by moderation")
+	ErrFlagNotFound    = errors.New("moderation flag not found")
+	ErrAlreadyResolved = errors.New("moderation flag already resolved")
+	ErrInvalidDecision = errors.New("decision must be approve or remove")
+	ErrInvalidStatus   = errors.New("invalid status")
+)
+
+type Querier interface {
+	CreateModerationFlag(ctx context.Context, arg db.CreateModerationFlagParams) (db.ModerationFlag, error)
+	GetModerationFlag(ctx context.Context, id pgtype.UUID) (db.ModerationFlag, error)
+	ListModerationFlags(ctx context.Context, arg db.ListModerationFlagsParams) ([]db.ModerationFlag, error)
+	CountModerationFlags(ctx context.Context, status string) (int64, error)
+	ResolveModerationFlag(ctx context.Context, arg db.ResolveModerationFlagParams) (db.ModerationFlag, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Remover takes down flagged content when a reviewer removes it. content is
+// the flagged value so implementations can skip fields edited since.
+type Remover interface {
+	RemoveContent(ctx context.Context, resourceID, field, content string) error
+}
+
+type Service struct {
+	queries   Querier
+	moderator moderator.Moderator
+	auditor   Auditor
+	removers  map[string]Remover
+}
+
+func NewService(queries Querier, mod moderator.Moderator, auditor Auditor) *Service {
+	return &Service{
+		queries:   queries,
+		moderator: mod,
+		auditor:   auditor,
+		removers:  make(map[string]Remover),
+	}
+}
+
+// RegisterRemover sets how flagged content of a resource type is removed
+func (s *Service) RegisterRemover(resourceType string, r Remover) {
+	s.removers[resourceType] = r
+}
+
+// Screen moderates user-generated fields before they are stored. It returns
+// the values to store, with sanitized text substituted, or ErrRejected if
+// any field is rejected. Flagged fields are stored as-is and queued for review.
+// A dry run screens the fields without queueing or auditing anything.
+func (s *Service) Screen(ctx context.Context, resourceType, resourceID, actor string, fields map[string]string) (map[string]string, error) {
+	// Check fields in a stable order so rejections are deterministic
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make(map[string]string, len(fields))
+	flagged := make(map[string][]string)
+	for _, name := range names {
+		text := fields[name]
+		result[name] = text
+		if text == "" {
+			continue
+		}
+
+		verdict, err := s.moderator.Check(ctx, name, text)
+		if err != nil {
+			// Keep accepting content when the moderator is down, but make sure a human sees it
+			logger.FromContext(ctx).Warn("content moderation failed", "error", err, "resource_type", resourceType, "field", name)
+			flagged[name] = []string{"moderation unavailable"}
+			continue
+		}
+
+		switch verdict.Action {
+		case moderator.ActionReject:
+			s.record(ctx, audit.Entry{
+				Actor:        actor,
+				Action:       "moderation.rejected",
+				ResourceType: resourceType,
+				ResourceID:   resourceID,
+				Metadata: map[string]any{
+					"field":   name,
+					"reasons": verdict.Reasons,
+				},
+			})
+			return nil, fmt.Errorf("%w: %s", ErrRejected, name)
+		case moderator.ActionSanitize:
+			result[name] = verdict.Sanitized
+		case moderator.ActionFlag:
+			if verdict.Sanitized != "" {
+				result[name] = verdict.Sanitized
+			}
+			flagged[name] = verdict.Reasons
+		}
+	}
+
+	// A dry run reports the screened content but queues nothing for review
+	if dryrun.Enabled(ctx) {
+		return result, nil
+	}
+	for _, name := range names {
+		reasons, ok := flagged[name]
+		if !ok {
+			continue
+		}
+		if reasons == nil {
+			reasons = []string{}
+		}
+		if _, err := s.queries.CreateModerationFlag(ctx, db.CreateModerationFlagParams{
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			Field:        name,
+			Content:      result[name],
+			Reasons:      reasons,
+			Actor:        actor,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to create moderation flag: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// ListFlags returns review queue entries with the given status, oldest
+// first unless newestFirst
+func (s *Service) ListFlags(ctx context.Context, status string, newestFirst bool, limit, offset int) ([]*Flag, error) {
+	if !validStatus(status) {
+		return nil, ErrInvalidStatus
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := s.queries.ListModerationFlags(ctx, db.ListModerationFlagsParams{
+		Status:      status,
+		NewestFirst: newestFirst,
+		RowLimit:    int32(limit),
+		RowOffset:   int32(offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list moderation flags: %w", err)
+	}
+
+	flags := make([]*Flag, len(rows))
+	for i, row := range rows {
+		flags[i] = toFlag(row)
+	}
+	return flags, nil
+}
+
+// CountFlags returns the number of review queue entries with the given
+// status
+func (s *Service) CountFlags(ctx context.Context, status string) (int64, error) {
+	if !validStatus(status) {
+		return 0, ErrInvalidStatus
+	}
+	count, err := s.queries.CountModerationFlags(ctx, status)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count moderation flags: %w", err)
+	}
+	return count, nil
+}
+
+func validStatus(status string) bool {
+	return slices.Contains(Statuses, status)
+}
+
+// Resolve records a reviewer's decision. Removing content calls the
+// resource type's registered Remover before the flag is closed.
+func (s *Service) Resolve(ctx context.Context, id uuid.UUID, req ResolveRequest, actor string) (*Flag, error) {
+	var status string
+	switch req.Decision {
+	case "approve":
+		status = StatusApproved
+	case "remove":
+		status = StatusRemoved
+	default:
+		return nil, ErrInvalidDecision
+	}
+
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	flag, err := s.queries.GetModerationFlag(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFlagNotFound
+		}
+		return nil, fmt.Errorf("failed to get moderation flag: %w", err)
+	}
+	if flag.Status != StatusPending {
+		return nil, ErrAlreadyResolved
+	}
+
+	if status == StatusRemoved {
+		remover, ok := s.removers[flag.ResourceType]
+		if !ok {
+			return nil, fmt.Errorf("no remover registered for %s", flag.ResourceType)
+		}
+		if err := remover.RemoveContent(ctx, flag.ResourceID, flag.Field, flag.Content); err != nil {
+			return nil, fmt.Errorf("failed to remove content: %w", err)
+		}
+	}
+
+	note := pgtype.Text{}
+	if req.Note != "" {
+		note = pgtype.Text{String: req.Note, Valid: true}
+	}
+	resolved, err := s.queries.ResolveModerationFlag(ctx, db.ResolveModerationFlagParams{
+		ID:         pgID,
+		Status:     status,
+		ReviewedBy: pgtype.Text{String: actor, Valid: actor != ""},
+		ReviewNote: note,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAlreadyResolved
+		}
+		return nil, fmt.Errorf("failed to resolve moderation flag: %w", err)
+	}
+
+	s.record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "moderation." + status,
+		ResourceType: flag.ResourceType,
+		ResourceID:   flag.ResourceID,
+		Metadata: map[string]any{
+			"flag_id": id.String(),
+			"field":   flag.Field,
+			"note":    req.Note,
+		},
+	})
+
+	return toFlag(resolved), nil
+}
+
+func (s *Service) record(ctx context.Context, entry audit.Entry) {
+	if dryrun.Enabled(ctx) {
+		return
+	}
+	if err := s.auditor.Record(ctx, entry); err != nil {
+		logger.FromContext(ctx).Warn("failed to record audit entry", "error", err, "action", entry.Action)
+	}
+}
+
+func toFlag(f db.ModerationFlag) *Flag {
+	flag := &Flag{
+		ID:           uuid.UUID(f.ID.Bytes),
+		ResourceType: f.ResourceType,
+		ResourceID:   f.ResourceID,
+		Field:        f.Field,
+		Content:      f.Content,
+		Reasons:      f.Reasons,
+		Actor:        f.Actor,
+		Status:       f.Status,
+		CreatedAt:    f.CreatedAt.Time,
+	}
+	if flag.Reasons == nil {
+		flag.Reasons = []string{}
+	}
+	if f.ReviewedBy.Valid {
+		flag.ReviewedBy = &f.ReviewedBy.String
+	}
+	if f.ReviewNote.Valid {
+		flag.ReviewNote = &f.ReviewNote.String
+	}
+	if f.ReviewedAt.Valid {
+		flag.ReviewedAt = &f.ReviewedAt.Time
+	}
+	return flag
+}
@@ -0,0 +1,10 @@
+// Package modules links in the feature packages that register themselves
+// as server modules. Binaries that build the server or apply migrations
+// import it for its side effects.
+package modules
+
+import (
+	_ "starterkit/internal/campaigns"
+	_ "starterkit/internal/invites"
+	_ "starterkit/internal/links"
+)
@@ -0,0 +1,418 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"starterkit/internal/jobs"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/listing"
+	"starterkit/internal/platform/sse"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	VAPIDPublicKey() string
+	Subscribe(ctx context.Context, userID uuid.UUID, req SubscribeRequest, userAgent string) (*PushSubscription, error)
+	ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]*PushSubscription, error)
+	Unsubscribe(ctx context.Context, userID, subscriptionID uuid.UUID) error
+	GetPreferences(ctx context.Context, userID uuid.UUID) (Preferences, error)
+	UpdatePreferences(ctx context.Context, userID uuid.UUID, update Preferences) (Preferences, error)
+	Notify(ctx context.Context, userID uuid.UUID, msg Message) ([]Delivery, error)
+	NotifyTemplate(ctx context.Context, userID uuid.UUID, key string, data map[string]any) ([]Delivery, error)
+	NotifyLater(ctx context.Context, userID uuid.UUID, msg Message, idempotencyKey string) (*jobs.Job, error)
+	NotifyTemplateLater(ctx context.Context, userID uuid.UUID, key string, data map[string]any, idempotencyKey string) (*jobs.Job, error)
+	ListInbox(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit, offset int) ([]*Notification, error)
+	CountInbox(ctx context.Context, userID uuid.UUID, unreadOnly bool) (int64, error)
+	InboxSince(ctx context.Context, userID uuid.UUID, after time.Time, limit int) ([]*Notification, error)
+	ReadState(ctx context.Context, userID uuid.UUID) (*ReadState, error)
+	Sync(ctx context.Context, userID uuid.UUID, req SyncRequest) (*ReadState, error)
+	InboxChanges(userID uuid.UUID) (<-chan sse.Message, func())
+}
+
+// How often inbox streams look for changes made on other replicas, and
+// how many new notifications they read at a time
+const (
+	inboxPollInterval = 5 * time.Second
+	inboxBatchSize    = 100
+)
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) HandleVAPIDPublicKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := h.service.VAPIDPublicKey()
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"enabled":    key != "",
+			"public_key": key,
+		})
+	}
+}
+
+func (h *Handler) HandleListSubscriptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+
+		subs, err := h.service.ListSubscriptions(r.Context(), userID)
+		if err != nil {
+			h.logger.Error("failed to list push subscriptions", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"subscriptions": subs,
+		})
+	}
+}
+
+func (h *Handler) HandleSubscribe() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+
+		var req SubscribeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		sub, err := h.service.Subscribe(r.Context(), userID, req, r.UserAgent())
+		if err != nil {
+			if errors.Is(err, ErrInvalidSubscription) {
+				apierror.Write(w, r, apierror.Unprocessable("invalid_subscription", err.Error()))
+				return
+			}
+			h.logger.Error("failed to save push subscription", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusCreated, sub)
+	}
+}
+
+func (h *Handler) HandleUnsubscribe() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+		subscriptionID, err := uuid.Parse(r.PathValue("subscriptionId"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_subscription_id", "invalid subscription ID format"))
+			return
+		}
+
+		if err := h.service.Unsubscribe(r.Context(), userID, subscriptionID); err != nil {
+			if errors.Is(err, ErrSubscriptionNotFound) {
+				apierror.Write(w, r, apierror.NotFound("push_subscription_not_found", "push subscription not found"))
+				return
+			}
+			h.logger.Error("failed to delete push subscription", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) HandleGetPreferences() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+
+		prefs, err := h.service.GetPreferences(r.Context(), userID)
+		if err != nil {
+			h.logger.Error("failed to get notification preferences", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"channels": prefs,
+		})
+	}
+}
+
+func (h *Handler) HandleUpdatePreferences() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Channels Preferences `json:"channels"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		prefs, err := h.service.UpdatePreferences(r.Context(), userID, req.Channels)
+		if err != nil {
+			if errors.Is(err, ErrUnknownChannel) {
+				apierror.Write(w, r, apierror.Unprocessable("unknown_channel", err.Error()))
+				return
+			}
+			h.logger.Error("failed to update notification preferences", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"channels": prefs,
+		})
+	}
+}
+
+// HandleSendTest delivers an admin-supplied message, or a stored template
+// rendered with the given data, so channel configuration can be checked
+// end to end. With async set, delivery is queued as a job and the job is
+// returned with 202; repeating the request with the same Idempotency-Key
+// header returns the same job.
+func (h *Handler) HandleSendTest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Message
+			Template string         `json:"template"`
+			Data     map[string]any `json:"data"`
+			Async    bool           `json:"async"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Body == "" && req.Template == "") {
+			apierror.Write(w, r, apierror.BadRequest("body_or_template_required", "body or template is required"))
+			return
+		}
+
+		if req.Async {
+			key := r.Header.Get("Idempotency-Key")
+			var job *jobs.Job
+			var err error
+			if req.Template != "" {
+				job, err = h.service.NotifyTemplateLater(r.Context(), userID, req.Template, req.Data, key)
+			} else {
+				job, err = h.service.NotifyLater(r.Context(), userID, req.Message, key)
+			}
+			if errors.Is(err, jobs.ErrDuplicateJob) {
+				apierror.Write(w, r, apierror.Conflict("duplicate_job", err.Error()))
+				return
+			}
+			if err != nil {
+				h.logger.Error("failed to queue test notification", "error", err, "user_id", userID)
+				apierror.Write(w, r, apierror.Internal())
+				return
+			}
+			h.respondWithJSON(w, http.StatusAccepted, map[string]any{
+				"job": job,
+			})
+			return
+		}
+
+		var deliveries []Delivery
+		var err error
+		if req.Template != "" {
+			deliveries, err = h.service.NotifyTemplate(r.Context(), userID, req.Template, req.Data)
+		} else {
+			deliveries, err = h.service.Notify(r.Context(), userID, req.Message)
+		}
+		if err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+				return
+			}
+			h.logger.Error("failed to send test notification", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"deliveries": deliveries,
+		})
+	}
+}
+
+// HandleListInbox lists a user's inbox, newest first; its route declares
+// InboxList
+func (h *Handler) HandleListInbox() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+
+		listing.Serve(w, r, listing.Source[*Notification]{
+			List: func(ctx context.Context, q listing.Query) ([]*Notification, error) {
+				return h.service.ListInbox(ctx, userID, q.Filter("state") == "unread", q.Limit, q.Offset)
+			},
+			Count: func(ctx context.Context, q listing.Query) (int64, error) {
+				return h.service.CountInbox(ctx, userID, q.Filter("state") == "unread")
+			},
+		})
+	}
+}
+
+// HandleSyncInbox records how far the calling device has read the inbox
+// and answers with the read state across all of the user's devices
+func (h *Handler) HandleSyncInbox() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+
+		var req SyncRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		state, err := h.service.Sync(r.Context(), userID, req)
+		if err != nil {
+			if errors.Is(err, ErrInvalidDevice) {
+				apierror.Write(w, r, apierror.Unprocessable("invalid_device", err.Error()))
+				return
+			}
+			h.logger.Error("failed to sync notification read state", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, state)
+	}
+}
+
+// HandleInboxEvents streams a user's inbox as server-sent events: a
+// notifications.read event with the read state when the stream opens and
+// whenever it changes, and a notification.created event for each
+// notification added after that. Changes made on this replica are sent at
+// once, others within inboxPollInterval.
+func (h *Handler) HandleInboxEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+
+		changes, stop := h.service.InboxChanges(userID)
+		defer stop()
+
+		ctx := r.Context()
+		state, err := h.service.ReadState(ctx, userID)
+		if err != nil {
+			h.logger.Error("failed to get notification read state", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		latest, err := h.service.ListInbox(ctx, userID, false, 1, 0)
+		if err != nil {
+			h.logger.Error("failed to list notifications", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		var since time.Time
+		if len(latest) > 0 {
+			since = latest[0].CreatedAt
+		}
+
+		stream := sse.NewWriter(w)
+		if err := stream.Send(sse.Message{Event: EventReadStateUpdated, Data: state}); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(inboxPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-changes:
+			}
+
+			for {
+				added, err := h.service.InboxSince(ctx, userID, since, inboxBatchSize)
+				if err != nil {
+					h.logger.Error("failed to list notifications", "error", err, "user_id", userID)
+					return
+				}
+				for _, n := range added {
+					if err := stream.Send(sse.Message{Event: EventNotificationCreated, Data: n}); err != nil {
+						return
+					}
+					since = n.CreatedAt
+				}
+				if len(added) < inboxBatchSize {
+					break
+				}
+			}
+
+			next, err := h.service.ReadState(ctx, userID)
+			if err != nil {
+				h.logger.Error("failed to get notification read state", "error", err, "user_id", userID)
+				return
+			}
+			if sameReadState(state, next) {
+				continue
+			}
+			state = next
+			if err := stream.Send(sse.Message{Event: EventReadStateUpdated, Data: state}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sameReadState reports whether a and b read through the same time and
+// count the same unread notifications
+func sameReadState(a, b *ReadState) bool {
+	if a.Unread != b.Unread || (a.ReadThrough == nil) != (b.ReadThrough == nil) {
+		return false
+	}
+	return a.ReadThrough == nil || a.ReadThrough.Equal(*b.ReadThrough)
+}
+
+func (h *Handler) parseUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
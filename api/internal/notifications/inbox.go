@@ -0,0 +1,185 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"starterkit/internal/db"
+	"starterkit/internal/jobs"
+	"starterkit/internal/platform/listing"
+	"starterkit/internal/platform/sse"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrInvalidDevice is returned for a missing or overlong device ID
+var ErrInvalidDevice = errors.New("device_id must be 1 to 100 characters")
+
+const maxDeviceIDLength = 100
+
+// InboxList declares the query parameters of a user's inbox
+var InboxList = listing.Spec{
+	Items:        "notifications",
+	DefaultLimit: 20,
+	MaxLimit:     100,
+	Filters: []listing.Filter{
+		{Name: "state", Description: "unread for only the notifications no device has read", Values: []string{"all", "unread"}, Default: "all"},
+	},
+}
+
+func (s *Service) notifyInApp(ctx context.Context, userID pgtype.UUID, msg Message, d *Delivery) {
+	// A retried delivery job does not add the notification again
+	if _, err := jobs.Once(ctx, "in_app", func(ctx context.Context) error {
+		_, err := s.queries.CreateNotification(ctx, db.CreateNotificationParams{
+			UserID: userID,
+			Title:  msg.Title,
+			Body:   msg.Body,
+			Url:    msg.URL,
+		})
+		return err
+	}); err != nil {
+		s.logger.Error("failed to store in-app notification", "error", err, "user_id", uuid.UUID(userID.Bytes))
+		d.Error = "delivery failed"
+		return
+	}
+	s.changed(uuid.UUID(userID.Bytes))
+	d.Delivered = 1
+}
+
+// ListInbox returns a page of a user's inbox, newest first, optionally only
+// the notifications no device has read
+func (s *Service) ListInbox(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit, offset int) ([]*Notification, error) {
+	pgID := pgtype.UUID{Bytes: userID, Valid: true}
+	state, err := s.ReadState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.queries.ListNotifications(ctx, db.ListNotificationsParams{
+		UserID:     pgID,
+		UnreadOnly: unreadOnly,
+		RowLimit:   int32(limit),
+		RowOffset:  int32(offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return toNotifications(rows, state.ReadThrough), nil
+}
+
+// CountInbox returns the number of notifications in a user's inbox,
+// optionally only the unread ones
+func (s *Service) CountInbox(ctx context.Context, userID uuid.UUID, unreadOnly bool) (int64, error) {
+	count, err := s.queries.CountNotifications(ctx, db.CountNotificationsParams{
+		UserID:     pgtype.UUID{Bytes: userID, Valid: true},
+		UnreadOnly: unreadOnly,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	return count, nil
+}
+
+// InboxSince returns up to limit notifications added to a user's inbox
+// after the given time, oldest first
+func (s *Service) InboxSince(ctx context.Context, userID uuid.UUID, after time.Time, limit int) ([]*Notification, error) {
+	state, err := s.ReadState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.queries.ListNotificationsSince(ctx, db.ListNotificationsSinceParams{
+		UserID:   pgtype.UUID{Bytes: userID, Valid: true},
+		After:    pgtype.Timestamptz{Time: after, Valid: true},
+		RowLimit: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return toNotifications(rows, state.ReadThrough), nil
+}
+
+// ReadState returns how far a user has read their inbox on each device, and
+// how many notifications none of them has read
+func (s *Service) ReadState(ctx context.Context, userID uuid.UUID) (*ReadState, error) {
+	pgID := pgtype.UUID{Bytes: userID, Valid: true}
+	cursors, err := s.queries.ListNotificationReadCursors(ctx, pgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list read cursors: %w", err)
+	}
+	unread, err := s.queries.CountNotifications(ctx, db.CountNotificationsParams{UserID: pgID, UnreadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+
+	state := &ReadState{Unread: unread, Devices: make([]DeviceCursor, len(cursors))}
+	for i, c := range cursors {
+		state.Devices[i] = DeviceCursor{
+			DeviceID:    c.DeviceID,
+			ReadThrough: c.ReadThrough.Time,
+			UpdatedAt:   c.UpdatedAt.Time,
+		}
+		if state.ReadThrough == nil || c.ReadThrough.Time.After(*state.ReadThrough) {
+			readThrough := c.ReadThrough.Time
+			state.ReadThrough = &readThrough
+		}
+	}
+	return state, nil
+}
+
+// Sync moves the device's read cursor up to req.ReadThrough, if given, and
+// returns the user's ReadState. Cursors never move back, and not past the
+// present, so a device that is behind cannot mark read notifications
+// unread or ones yet to arrive read.
+func (s *Service) Sync(ctx context.Context, userID uuid.UUID, req SyncRequest) (*ReadState, error) {
+	if req.DeviceID == "" || len(req.DeviceID) > maxDeviceIDLength {
+		return nil, ErrInvalidDevice
+	}
+
+	if req.ReadThrough != nil {
+		readThrough := *req.ReadThrough
+		if now := time.Now(); readThrough.After(now) {
+			readThrough = now
+		}
+		if _, err := s.queries.AdvanceNotificationReadCursor(ctx, db.AdvanceNotificationReadCursorParams{
+			UserID:      pgtype.UUID{Bytes: userID, Valid: true},
+			DeviceID:    req.DeviceID,
+			ReadThrough: pgtype.Timestamptz{Time: readThrough, Valid: true},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to save read cursor: %w", err)
+		}
+		s.changed(userID)
+	}
+	return s.ReadState(ctx, userID)
+}
+
+// InboxChanges signals when a user's inbox or read state changes on this
+// replica. The returned function must be called to stop watching.
+func (s *Service) InboxChanges(userID uuid.UUID) (<-chan sse.Message, func()) {
+	return s.hub.Subscribe(inboxTopic(userID))
+}
+
+// changed wakes the user's inbox streams on this replica
+func (s *Service) changed(userID uuid.UUID) {
+	s.hub.Publish(inboxTopic(userID), sse.Message{Event: EventReadStateUpdated})
+}
+
+func inboxTopic(userID uuid.UUID) string {
+	return "inbox:" + userID.String()
+}
+
+func toNotifications(rows []db.Notification, readThrough *time.Time) []*Notification {
+	result := make([]*Notification, len(rows))
+	for i, n := range rows {
+		result[i] = &Notification{
+			ID:        uuid.UUID(n.ID.Bytes),
+			Title:     n.Title,
+			Body:      n.Body,
+			URL:       n.Url,
+			Read:      readThrough != nil && !n.CreatedAt.Time.After(*readThrough),
+			CreatedAt: n.CreatedAt.Time,
+		}
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"starterkit/internal/jobs"
+	"starterkit/internal/platform/locale"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
+)
+
+// JobDeliver is the job kind that delivers a notification in the background
+const JobDeliver = "notifications.deliver"
+
+// Enqueuer queues background jobs
+type Enqueuer interface {
+	Schedule(ctx context.Context, kind string, payload any, opts jobs.Options) (*jobs.Job, error)
+}
+
+// deliverJob is the payload of a JobDeliver job: either a message or a
+// template to render in the locale of the request that queued it
+type deliverJob struct {
+	UserID   uuid.UUID      `json:"user_id"`
+	Message  *Message       `json:"message,omitempty"`
+	Template string         `json:"template,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+	Locale   string         `json:"locale"`
+}
+
+// NotifyLater queues msg for delivery to a user. A non-empty
+// idempotencyKey makes repeated calls with the same key, within a day,
+// return the job queued first instead of notifying again.
+func (s *Service) NotifyLater(ctx context.Context, userID uuid.UUID, msg Message, idempotencyKey string) (*jobs.Job, error) {
+	return s.enqueue(ctx, deliverJob{UserID: userID, Message: &msg}, idempotencyKey)
+}
+
+// NotifyTemplateLater queues a template notification for a user; it is
+// rendered in the request locale when the job runs
+func (s *Service) NotifyTemplateLater(ctx context.Context, userID uuid.UUID, key string, data map[string]any, idempotencyKey string) (*jobs.Job, error) {
+	return s.enqueue(ctx, deliverJob{UserID: userID, Template: key, Data: data}, idempotencyKey)
+}
+
+func (s *Service) enqueue(ctx context.Context, job deliverJob, idempotencyKey string) (*jobs.Job, error) {
+	job.Locale = locale.FromContext(ctx).Locale.String()
+	opts := jobs.Options{}
+	if idempotencyKey != "" {
+		opts.UniqueKey = job.UserID.String() + ":" + idempotencyKey
+	}
+	queued, err := s.jobs.Schedule(ctx, JobDeliver, job, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue notification: %w", err)
+	}
+	return queued, nil
+}
+
+// RunDeliverJob delivers a queued notification. The job is retried while
+// any channel fails; messages already sent by earlier attempts are not
+// sent again.
+func (s *Service) RunDeliverJob(ctx context.Context, payload json.RawMessage) error {
+	var job deliverJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("failed to decode notification job: %w", err)
+	}
+	if tag, err := language.Parse(job.Locale); err == nil {
+		prefs := locale.FromContext(ctx)
+		prefs.Locale = tag
+		ctx = locale.WithContext(ctx, prefs)
+	}
+
+	var deliveries []Delivery
+	var err error
+	if job.Template != "" {
+		deliveries, err = s.NotifyTemplate(ctx, job.UserID, job.Template, job.Data)
+	} else if job.Message != nil {
+		deliveries, err = s.Notify(ctx, job.UserID, *job.Message)
+	}
+	if err != nil {
+		return err
+	}
+	for _, d := range deliveries {
+		if d.Error != "" {
+			return fmt.Errorf("%s delivery failed: %s", d.Channel, d.Error)
+		}
+	}
+	return nil
+}
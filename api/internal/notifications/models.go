@@ -0,0 +1,110 @@
+package notifications
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery channels
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+	// ChannelInApp stores the message in the user's inbox in the app
+	ChannelInApp = "in_app"
+)
+
+// Channels lists every supported channel
+var Channels = []string{ChannelEmail, ChannelSMS, ChannelPush, ChannelInApp}
+
+// defaultEnabled applies when a user has not set a preference for a channel.
+// SMS is opt-in because it costs money per message.
+var defaultEnabled = map[string]bool{
+	ChannelEmail: true,
+	ChannelSMS:   false,
+	ChannelPush:  true,
+	ChannelInApp: true,
+}
+
+// Events sent on a user's inbox stream
+const (
+	// EventNotificationCreated carries a Notification added to the inbox
+	EventNotificationCreated = "notification.created"
+	// EventReadStateUpdated carries the ReadState after a device read
+	// further or a notification arrived
+	EventReadStateUpdated = "notifications.read"
+)
+
+// Message is a channel-independent notification
+type Message struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url,omitempty"`
+	// html is set when Body is HTML, as rendered from an email template
+	html bool
+}
+
+// Delivery reports how a message was delivered on each channel
+type Delivery struct {
+	Channel   string `json:"channel"`
+	Delivered int    `json:"delivered"`
+	Skipped   string `json:"skipped,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// EmailID is the queued email, whose delivery status is tracked until
+	// the provider reports it delivered, bounced, or complained about
+	EmailID *uuid.UUID `json:"email_id,omitempty"`
+}
+
+// PushSubscription is a registered Web Push endpoint for a user
+type PushSubscription struct {
+	ID         uuid.UUID  `json:"id"`
+	Endpoint   string     `json:"endpoint"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type SubscribeRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// Preferences maps channel name to whether it is enabled
+type Preferences map[string]bool
+
+// Notification is a message in a user's inbox
+type Notification struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+	Body  string    `json:"body"`
+	URL   string    `json:"url,omitempty"`
+	// Read is set once any of the user's devices has read through it
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReadState is how far a user has read their inbox. Each device keeps a
+// cursor, and notifications up to the furthest of them are read on all.
+type ReadState struct {
+	ReadThrough *time.Time     `json:"read_through"`
+	Unread      int64          `json:"unread"`
+	Devices     []DeviceCursor `json:"devices"`
+}
+
+// DeviceCursor is how far one device has read the inbox
+type DeviceCursor struct {
+	DeviceID    string    `json:"device_id"`
+	ReadThrough time.Time `json:"read_through"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SyncRequest reports how far a device has read the inbox. Without
+// ReadThrough it only asks for the current ReadState.
+type SyncRequest struct {
+	DeviceID    string     `json:"device_id"`
+	ReadThrough *time.Time `json:"read_through"`
+}
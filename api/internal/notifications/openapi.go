@@ -0,0 +1,74 @@
+package notifications
+
+import (
+	"net/http"
+
+	"starterkit/internal/openapi"
+)
+
+// inboxPage is the envelope listing.Serve answers the inbox with
+type inboxPage struct {
+	Notifications []*Notification `json:"notifications"`
+	Limit         int             `json:"limit"`
+	Offset        int             `json:"offset"`
+	NextCursor    string          `json:"next_cursor,omitempty"`
+	Total         *int64          `json:"total,omitempty"`
+}
+
+// Describe registers the inbox endpoints in the OpenAPI document
+func Describe(api *openapi.Registry) {
+	api.Define("NotificationPage", inboxPage{})
+	api.Define("NotificationReadState", ReadState{})
+	api.Define("NotificationDeviceCursor", DeviceCursor{})
+	api.Define("NotificationSyncRequest", SyncRequest{})
+
+	tags := []string{"Notifications"}
+	userID := openapi.PathParam("id", "User UUID", openapi.String("uuid"))
+	internal := openapi.Problem(http.StatusInternalServerError, "Internal server error")
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/{id}/notifications",
+		ID:          "listNotifications",
+		Summary:     "List notifications",
+		Description: "Returns the user's in-app notifications, newest first. A notification is read once any of the user's devices has synced a read cursor past it.",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Successful response", Body: inboxPage{}},
+			openapi.Problem(http.StatusBadRequest, "Invalid parameters"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodPost,
+		Path:        "/api/v1/users/{id}/notifications/sync",
+		ID:          "syncNotifications",
+		Summary:     "Sync notification read state",
+		Description: "Moves the calling device's read cursor up to read_through, never back, and returns the read state across all of the user's devices. Without read_through it only returns the read state.",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Request:     SyncRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Read state across the user's devices", Body: ReadState{}},
+			openapi.Problem(http.StatusBadRequest, "Malformed request body"),
+			openapi.Problem(http.StatusUnprocessableEntity, "Missing or overlong device_id"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/{id}/notifications/events",
+		ID:          "streamNotificationEvents",
+		Summary:     "Notification events",
+		Description: "Server-sent event stream emitting notifications.read with the read state when it opens and whenever it changes, and notification.created for each notification added to the inbox",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Event stream", Body: openapi.String(""), ContentType: "text/event-stream"},
+			internal,
+		},
+	})
+}
@@ -0,0 +1,428 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"starterkit/internal/db"
+	"starterkit/internal/email"
+	"starterkit/internal/jobs"
+	"starterkit/internal/platform/locale"
+	"starterkit/internal/platform/sms"
+	"starterkit/internal/platform/sse"
+	"starterkit/internal/platform/webpush"
+	"starterkit/internal/templates"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("push subscription not found")
+	ErrInvalidSubscription  = errors.New("invalid push subscription")
+	ErrUnknownChannel       = errors.New("unknown notification channel")
+	ErrUserNotFound         = errors.New("user not found")
+)
+
+type Querier interface {
+	GetUserByID(ctx context.Context, id pgtype.UUID) (db.GetUserByIDRow, error)
+	UpsertPushSubscription(ctx context.Context, arg db.UpsertPushSubscriptionParams) (db.PushSubscription, error)
+	ListPushSubscriptions(ctx context.Context, userID pgtype.UUID) ([]db.PushSubscription, error)
+	DeletePushSubscription(ctx context.Context, arg db.DeletePushSubscriptionParams) (int64, error)
+	DeletePushSubscriptionByEndpoint(ctx context.Context, endpoint string) error
+	TouchPushSubscription(ctx context.Context, id pgtype.UUID) error
+	ListNotificationPreferences(ctx context.Context, userID pgtype.UUID) ([]db.NotificationPreference, error)
+	ListNotificationPreferencesForUsers(ctx context.Context, userIds []pgtype.UUID) ([]db.NotificationPreference, error)
+	UpsertNotificationPreference(ctx context.Context, arg db.UpsertNotificationPreferenceParams) error
+	CreateNotification(ctx context.Context, arg db.CreateNotificationParams) (db.Notification, error)
+	ListNotifications(ctx context.Context, arg db.ListNotificationsParams) ([]db.Notification, error)
+	CountNotifications(ctx context.Context, arg db.CountNotificationsParams) (int64, error)
+	ListNotificationsSince(ctx context.Context, arg db.ListNotificationsSinceParams) ([]db.Notification, error)
+	AdvanceNotificationReadCursor(ctx context.Context, arg db.AdvanceNotificationReadCursorParams) (db.NotificationReadCursor, error)
+	ListNotificationReadCursors(ctx context.Context, userID pgtype.UUID) ([]db.NotificationReadCursor, error)
+}
+
+// Mailer queues outbound email
+type Mailer interface {
+	Queue(ctx context.Context, msg email.Email) (*email.Message, error)
+}
+
+// Renderer renders stored message templates for a channel and locale
+type Renderer interface {
+	Render(ctx context.Context, key, channel string, locale language.Tag, data map[string]any) (*templates.Rendered, error)
+}
+
+type Service struct {
+	queries  Querier
+	mail     Mailer
+	sms      sms.Sender
+	push     *webpush.Sender
+	renderer Renderer
+	jobs     Enqueuer
+	// hub wakes inbox streams when an inbox changes on this replica
+	hub    *sse.Hub
+	logger *slog.Logger
+}
+
+func NewService(queries Querier, mail Mailer, smsSender sms.Sender, push *webpush.Sender, renderer Renderer, jobs Enqueuer, hub *sse.Hub, logger *slog.Logger) *Service {
+	return &Service{
+		queries:  queries,
+		mail:     mail,
+		sms:      smsSender,
+		push:     push,
+		renderer: renderer,
+		jobs:     jobs,
+		hub:      hub,
+		logger:   logger,
+	}
+}
+
+// VAPIDPublicKey returns the key browsers need to create push subscriptions,
+// or an empty string when push is disabled
+func (s *Service) VAPIDPublicKey() string {
+	if !s.push.Enabled() {
+		return ""
+	}
+	return s.push.PublicKey()
+}
+
+// Subscribe registers a browser push subscription for a user. Re-registering
+// an endpoint moves it to the given user and refreshes its keys.
+func (s *Service) Subscribe(ctx context.Context, userID uuid.UUID, req SubscribeRequest, userAgent string) (*PushSubscription, error) {
+	endpoint, err := url.Parse(req.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" || req.Keys.P256dh == "" || req.Keys.Auth == "" {
+		return nil, ErrInvalidSubscription
+	}
+
+	sub, err := s.queries.UpsertPushSubscription(ctx, db.UpsertPushSubscriptionParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: pgtype.Text{String: userAgent, Valid: userAgent != ""},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save push subscription: %w", err)
+	}
+	return toPushSubscription(sub), nil
+}
+
+// ListSubscriptions returns a user's push subscriptions
+func (s *Service) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]*PushSubscription, error) {
+	subs, err := s.queries.ListPushSubscriptions(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list push subscriptions: %w", err)
+	}
+
+	result := make([]*PushSubscription, len(subs))
+	for i, sub := range subs {
+		result[i] = toPushSubscription(sub)
+	}
+	return result, nil
+}
+
+// Unsubscribe removes one of a user's push subscriptions
+func (s *Service) Unsubscribe(ctx context.Context, userID, subscriptionID uuid.UUID) error {
+	rows, err := s.queries.DeletePushSubscription(ctx, db.DeletePushSubscriptionParams{
+		ID:     pgtype.UUID{Bytes: subscriptionID, Valid: true},
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+	if rows == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// GetPreferences returns whether each channel is enabled for a user,
+// filling in defaults for channels without a stored preference
+func (s *Service) GetPreferences(ctx context.Context, userID uuid.UUID) (Preferences, error) {
+	stored, err := s.queries.ListNotificationPreferences(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification preferences: %w", err)
+	}
+
+	return toPreferences(stored), nil
+}
+
+// GetPreferencesForUsers returns the preferences of each user in one query
+func (s *Service) GetPreferencesForUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]Preferences, error) {
+	ids := make([]pgtype.UUID, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = pgtype.UUID{Bytes: id, Valid: true}
+	}
+	stored, err := s.queries.ListNotificationPreferencesForUsers(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification preferences: %w", err)
+	}
+
+	byUser := make(map[uuid.UUID][]db.NotificationPreference, len(userIDs))
+	for _, p := range stored {
+		id := uuid.UUID(p.UserID.Bytes)
+		byUser[id] = append(byUser[id], p)
+	}
+	prefs := make(map[uuid.UUID]Preferences, len(userIDs))
+	for _, id := range userIDs {
+		prefs[id] = toPreferences(byUser[id])
+	}
+	return prefs, nil
+}
+
+// toPreferences fills in defaults for channels without a stored preference
+func toPreferences(stored []db.NotificationPreference) Preferences {
+	prefs := make(Preferences, len(Channels))
+	for _, channel := range Channels {
+		prefs[channel] = defaultEnabled[channel]
+	}
+	for _, p := range stored {
+		if _, ok := prefs[p.Channel]; ok {
+			prefs[p.Channel] = p.Enabled
+		}
+	}
+	return prefs
+}
+
+// UpdatePreferences stores the given channel settings; channels that are
+// not mentioned keep their current value
+func (s *Service) UpdatePreferences(ctx context.Context, userID uuid.UUID, update Preferences) (Preferences, error) {
+	for channel := range update {
+		if _, ok := defaultEnabled[channel]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+		}
+	}
+
+	pgID := pgtype.UUID{Bytes: userID, Valid: true}
+	for channel, enabled := range update {
+		if err := s.queries.UpsertNotificationPreference(ctx, db.UpsertNotificationPreferenceParams{
+			UserID:  pgID,
+			Channel: channel,
+			Enabled: enabled,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to save notification preference: %w", err)
+		}
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
+// Notify delivers a message to a user on every channel they have enabled.
+// Channel failures are reported per channel rather than aborting delivery.
+func (s *Service) Notify(ctx context.Context, userID uuid.UUID, msg Message) ([]Delivery, error) {
+	return s.deliver(ctx, userID, func(ctx context.Context, channel string) (*Message, error) {
+		return &msg, nil
+	})
+}
+
+// NotifyTemplate renders the template stored under key for each enabled
+// channel, in the request locale, and delivers the result. Channels without
+// a template for key are skipped; the inbox uses the push template.
+func (s *Service) NotifyTemplate(ctx context.Context, userID uuid.UUID, key string, data map[string]any) ([]Delivery, error) {
+	tag := locale.FromContext(ctx).Locale
+	return s.deliver(ctx, userID, func(ctx context.Context, channel string) (*Message, error) {
+		templateChannel := channel
+		if channel == ChannelInApp {
+			templateChannel = ChannelPush
+		}
+		rendered, err := s.renderer.Render(ctx, key, templateChannel, tag, data)
+		if err != nil {
+			if errors.Is(err, templates.ErrTemplateNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return &Message{Title: rendered.Subject, Body: rendered.Body, html: channel == ChannelEmail}, nil
+	})
+}
+
+// composeFunc builds the message for a channel; a nil message skips the channel
+type composeFunc func(ctx context.Context, channel string) (*Message, error)
+
+func (s *Service) deliver(ctx context.Context, userID uuid.UUID, compose composeFunc) ([]Delivery, error) {
+	pgID := pgtype.UUID{Bytes: userID, Valid: true}
+	user, err := s.queries.GetUserByID(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	prefs, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]Delivery, 0, len(Channels))
+	for _, channel := range Channels {
+		d := Delivery{Channel: channel}
+		if !prefs[channel] {
+			d.Skipped = "disabled by user"
+			deliveries = append(deliveries, d)
+			continue
+		}
+
+		msg, err := compose(ctx, channel)
+		switch {
+		case err != nil:
+			s.logger.Error("failed to compose notification", "error", err, "channel", channel)
+			d.Error = "failed to compose message"
+		case msg == nil:
+			d.Skipped = "no template for channel"
+		case channel == ChannelEmail:
+			s.notifyEmail(ctx, user, *msg, &d)
+		case channel == ChannelSMS:
+			s.notifySMS(ctx, user, *msg, &d)
+		case channel == ChannelPush:
+			s.notifyPush(ctx, pgID, *msg, &d)
+		case channel == ChannelInApp:
+			s.notifyInApp(ctx, pgID, *msg, &d)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+func (s *Service) notifyEmail(ctx context.Context, user db.GetUserByIDRow, msg Message, d *Delivery) {
+	queue := email.Email{To: user.Email, Subject: msg.Title}
+	if msg.html {
+		queue.HTML = msg.Body
+		if msg.URL != "" {
+			queue.HTML += fmt.Sprintf(`<p><a href="%s">%s</a></p>`, html.EscapeString(msg.URL), html.EscapeString(msg.URL))
+		}
+	} else {
+		queue.Body = msg.Body
+		if msg.URL != "" {
+			queue.Body += "\n\n" + msg.URL
+		}
+	}
+
+	// The email queue sends the message; a retried delivery job does not
+	// queue it again
+	userID := uuid.UUID(user.ID.Bytes)
+	queue.UserID = &userID
+	var queued *email.Message
+	_, err := jobs.Once(ctx, "email", func(ctx context.Context) error {
+		var err error
+		queued, err = s.mail.Queue(ctx, queue)
+		return err
+	})
+	switch {
+	case errors.Is(err, email.ErrInvalidAddress):
+		d.Skipped = "no valid email address"
+		return
+	case err != nil:
+		s.logger.Error("failed to queue email notification", "error", err, "user_id", userID)
+		d.Error = "delivery failed"
+		return
+	case queued != nil && queued.Status == email.StatusSuppressed:
+		d.Skipped = "email address suppressed"
+		return
+	case queued != nil:
+		d.EmailID = &queued.ID
+	}
+	d.Delivered = 1
+}
+
+func (s *Service) notifySMS(ctx context.Context, user db.GetUserByIDRow, msg Message, d *Delivery) {
+	// Only verified numbers receive messages
+	if !user.Phone.Valid || !user.PhoneVerifiedAt.Valid {
+		d.Skipped = "no verified phone number"
+		return
+	}
+
+	body := msg.Body
+	if msg.Title != "" {
+		body = msg.Title + ": " + body
+	}
+	if msg.URL != "" {
+		body += " " + msg.URL
+	}
+
+	// In a retried delivery job, an SMS sent by an earlier attempt is not resent
+	if _, err := jobs.Once(ctx, "sms", func(ctx context.Context) error {
+		return s.sms.Send(ctx, user.Phone.String, body)
+	}); err != nil {
+		s.logger.Error("failed to send sms notification", "error", err, "user_id", uuid.UUID(user.ID.Bytes))
+		d.Error = "delivery failed"
+		return
+	}
+	d.Delivered = 1
+}
+
+func (s *Service) notifyPush(ctx context.Context, userID pgtype.UUID, msg Message, d *Delivery) {
+	if !s.push.Enabled() {
+		d.Skipped = "push not configured"
+		return
+	}
+
+	subs, err := s.queries.ListPushSubscriptions(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to list push subscriptions", "error", err)
+		d.Error = "delivery failed"
+		return
+	}
+	if len(subs) == 0 {
+		d.Skipped = "no push subscriptions"
+		return
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		d.Error = "delivery failed"
+		return
+	}
+
+	failed := 0
+	for _, sub := range subs {
+		sent, err := jobs.Once(ctx, "push:"+uuid.UUID(sub.ID.Bytes).String(), func(ctx context.Context) error {
+			return s.push.Send(ctx, webpush.Subscription{
+				Endpoint: sub.Endpoint,
+				Keys:     webpush.Keys{P256dh: sub.P256dh, Auth: sub.Auth},
+			}, payload)
+		})
+		switch {
+		case err == nil && !sent:
+			// Delivered by an earlier attempt of this job
+			d.Delivered++
+		case err == nil:
+			d.Delivered++
+			if err := s.queries.TouchPushSubscription(ctx, sub.ID); err != nil {
+				s.logger.Warn("failed to update push subscription", "error", err)
+			}
+		case errors.Is(err, webpush.ErrSubscriptionGone):
+			// The browser revoked the subscription; stop sending to it
+			if err := s.queries.DeletePushSubscriptionByEndpoint(ctx, sub.Endpoint); err != nil {
+				s.logger.Warn("failed to delete expired push subscription", "error", err)
+			}
+		default:
+			failed++
+			s.logger.Error("failed to send push notification", "error", err, "subscription_id", uuid.UUID(sub.ID.Bytes))
+		}
+	}
+	if failed > 0 {
+		d.Error = fmt.Sprintf("%d of %d subscriptions failed", failed, len(subs))
+	}
+}
+
+func toPushSubscription(sub db.PushSubscription) *PushSubscription {
+	var lastUsedAt *time.Time
+	if sub.LastUsedAt.Valid {
+		lastUsedAt = &sub.LastUsedAt.Time
+	}
+	return &PushSubscription{
+		ID:         uuid.UUID(sub.ID.Bytes),
+		Endpoint:   sub.Endpoint,
+		UserAgent:  sub.UserAgent.String,
+		CreatedAt:  sub.CreatedAt.Time,
+		LastUsedAt: lastUsedAt,
+	}
+}
@@ -0,0 +1,109 @@
+package openapi
+
+// methods are the path item keys that hold operations
+var methods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// Document is a built OpenAPI document
+type Document struct {
+	doc  map[string]any
+	data []byte
+	// primary is the first successful status each registered operation
+	// lists, keyed by method and path, which mocks answer with
+	primary map[string]int
+}
+
+// JSON returns the whole document encoded
+func (d *Document) JSON() []byte {
+	return d.data
+}
+
+// For returns the document without the operations the caller cannot use,
+// judged by the scopes in each operation's security requirements. An
+// operation is kept when any one of its requirements lists only granted
+// scopes. Tags left without operations are dropped. The result shares
+// unchanged parts with the document and must not be modified.
+func (d *Document) For(granted func(scope string) bool) map[string]any {
+	out := make(map[string]any, len(d.doc))
+	for k, v := range d.doc {
+		out[k] = v
+	}
+
+	used := make(map[string]bool)
+	paths := make(map[string]any)
+	for path, item := range asMap(d.doc["paths"]) {
+		kept := make(map[string]any)
+		operations := 0
+		for key, op := range asMap(item) {
+			if !methods[key] {
+				kept[key] = op
+				continue
+			}
+			operation := asMap(op)
+			security, ok := operation["security"]
+			if !ok {
+				security = d.doc["security"]
+			}
+			if !allowed(security, granted) {
+				continue
+			}
+			kept[key] = op
+			operations++
+			for _, tag := range asSlice(operation["tags"]) {
+				if name, ok := tag.(string); ok {
+					used[name] = true
+				}
+			}
+		}
+		if operations > 0 {
+			paths[path] = kept
+		}
+	}
+	out["paths"] = paths
+
+	if tags, ok := d.doc["tags"]; ok {
+		var kept []any
+		for _, tag := range asSlice(tags) {
+			if name, ok := asMap(tag)["name"].(string); ok && used[name] {
+				kept = append(kept, tag)
+			}
+		}
+		out["tags"] = kept
+	}
+	return out
+}
+
+// allowed reports whether any of the security requirements needs only
+// granted scopes. No requirements means the operation is public.
+func allowed(security any, granted func(scope string) bool) bool {
+	requirements := asSlice(security)
+	if len(requirements) == 0 {
+		return true
+	}
+	for _, req := range requirements {
+		ok := true
+		for _, scopes := range asMap(req) {
+			for _, scope := range asSlice(scopes) {
+				if name, _ := scope.(string); !granted(name) {
+					ok = false
+				}
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
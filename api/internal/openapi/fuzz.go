@@ -0,0 +1,563 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxFuzzDepth bounds how deep the fuzzer mutates nested body properties
+const maxFuzzDepth = 2
+
+// FuzzCase is a request the fuzzer sends to an operation: malformed,
+// mistyped, or at the edges of what the operation accepts. Every other
+// part of the request is valid, so the case tests one thing.
+type FuzzCase struct {
+	// Operation is the operation's ID, or its method and path without one
+	Operation string
+	Method    string
+	// Path has the operation's path parameters filled in, and its query
+	// string
+	Path        string
+	ContentType string
+	Body        []byte
+	// Description says what the case varies, for reports
+	Description string
+	// Responses are the statuses the operation describes, mapped to the
+	// content types of their bodies
+	Responses map[int][]string
+}
+
+// mutation is a value the fuzzer puts in place of a valid one, encoded as
+// JSON so numbers keep the exact text sent
+type mutation struct {
+	label string
+	value json.RawMessage
+}
+
+// Parse reads an OpenAPI document, such as one written by cmd/openapi or
+// served at /api/v1/openapi.json
+func Parse(data []byte) (*Document, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	return &Document{doc: doc, data: data, primary: map[string]int{}}, nil
+}
+
+// FuzzCases returns the requests that fuzz every operation of the
+// document: a valid one built from the examples, then ones with each
+// parameter and each body property replaced by values of the wrong type,
+// boundary values, and malformed text, and bodies that are not valid JSON
+// or lack required properties. The cases are the same for the same
+// document, so a failure can be reproduced.
+func (d *Document) FuzzCases() []FuzzCase {
+	schemas := asMap(asMap(d.doc["components"])["schemas"])
+	paths := asMap(d.doc["paths"])
+	keys := make([]string, 0, len(paths))
+	for path := range paths {
+		keys = append(keys, path)
+	}
+	slices.Sort(keys)
+
+	var cases []FuzzCase
+	for _, path := range keys {
+		item := asMap(paths[path])
+		var ops []string
+		for key := range item {
+			if methods[key] {
+				ops = append(ops, key)
+			}
+		}
+		slices.Sort(ops)
+		for _, key := range ops {
+			f := fuzzer{
+				method:  strings.ToUpper(key),
+				path:    path,
+				op:      asMap(item[key]),
+				common:  asSlice(item["parameters"]),
+				schemas: schemas,
+			}
+			cases = append(cases, f.cases()...)
+		}
+	}
+	return cases
+}
+
+// fuzzer builds the cases of one operation
+type fuzzer struct {
+	method  string
+	path    string
+	op      map[string]any
+	common  []any
+	schemas map[string]any
+}
+
+// param is a path or query parameter with its valid value
+type param struct {
+	name     string
+	in       string
+	required bool
+	schema   map[string]any
+	valid    string
+}
+
+func (f fuzzer) cases() []FuzzCase {
+	var params []param
+	for _, p := range append(slices.Clone(f.common), asSlice(f.op["parameters"])...) {
+		p := f.resolve(asMap(p))
+		in, _ := p["in"].(string)
+		if in != "path" && in != "query" {
+			continue
+		}
+		name, _ := p["name"].(string)
+		required, _ := p["required"].(bool)
+		schema := f.resolve(asMap(p["schema"]))
+		params = append(params, param{
+			name:     name,
+			in:       in,
+			required: required || in == "path",
+			schema:   schema,
+			valid:    paramString(mockValue(schema, f.schemas, 0)),
+		})
+	}
+
+	// The valid body is the operation's request example, or one built from
+	// its schema
+	var bodySchema map[string]any
+	var valid any
+	var otherTypes []string
+	hasBody := false
+	if content := asMap(asMap(f.resolve(asMap(f.op["requestBody"])))["content"]); len(content) > 0 {
+		hasBody = true
+		if media, ok := content["application/json"]; ok {
+			bodySchema = f.resolve(asMap(asMap(media)["schema"]))
+			if example, ok := asMap(media)["example"]; ok {
+				valid = example
+			} else {
+				valid = mockValue(bodySchema, f.schemas, 0)
+			}
+		}
+		for contentType := range content {
+			if contentType != "application/json" {
+				otherTypes = append(otherTypes, contentType)
+			}
+		}
+		slices.Sort(otherTypes)
+	}
+
+	var cases []FuzzCase
+	add := func(description string, values map[string]string, contentType string, body []byte) {
+		cases = append(cases, FuzzCase{
+			Operation:   f.id(),
+			Method:      f.method,
+			Path:        f.target(params, values),
+			ContentType: contentType,
+			Body:        body,
+			Description: description,
+			Responses:   f.responses(),
+		})
+	}
+	validBody, _ := json.Marshal(valid)
+	jsonBody := func(description string, body []byte) {
+		add(description, nil, "application/json", body)
+	}
+
+	switch {
+	case bodySchema != nil:
+		jsonBody("valid request", validBody)
+	case hasBody:
+		add("valid request", nil, otherTypes[0], nil)
+	default:
+		add("valid request", nil, "", nil)
+	}
+
+	for _, p := range params {
+		for _, m := range paramMutations(p.schema) {
+			if p.in == "path" && m == "" {
+				// An empty segment routes elsewhere
+				continue
+			}
+			add(fmt.Sprintf("%s parameter %s: %q", p.in, p.name, truncate(m)), map[string]string{p.name: m}, bodyType(bodySchema, otherTypes), defaultBody(bodySchema, validBody))
+		}
+	}
+
+	if bodySchema != nil {
+		for _, m := range malformedBodies(validBody) {
+			jsonBody("body: "+m.label, m.value)
+		}
+		add("body with no content type", nil, "", validBody)
+		add("body sent as text/plain", nil, "text/plain", validBody)
+
+		if obj, ok := valid.(map[string]any); ok {
+			f.mutateObject(bodySchema, obj, nil, 0, func(path []string, m mutation) {
+				body, err := json.Marshal(setPath(obj, path, m.value))
+				if err == nil {
+					jsonBody(fmt.Sprintf("body property %s: %s", strings.Join(path, "."), m.label), body)
+				}
+			})
+			for _, name := range f.required(bodySchema) {
+				rest := make(map[string]any, len(obj))
+				for k, v := range obj {
+					if k != name {
+						rest[k] = v
+					}
+				}
+				body, _ := json.Marshal(rest)
+				jsonBody("body without required property "+name, body)
+			}
+			withUnknown := setPath(obj, []string{"fuzz_unknown_property"}, json.RawMessage(`"unexpected"`))
+			body, _ := json.Marshal(withUnknown)
+			jsonBody("body with an unknown property", body)
+		} else {
+			for _, m := range valueMutations(bodySchema, f.schemas) {
+				jsonBody("body: "+m.label, m.value)
+			}
+		}
+	}
+	for _, contentType := range otherTypes {
+		add("empty "+contentType+" body", nil, contentType, nil)
+		add("garbage "+contentType+" body", nil, contentType, []byte("\x00\xff--fuzz--\r\n\r\n{"))
+	}
+	return cases
+}
+
+// mutateObject calls fn with every mutation of every property of obj, and
+// of the properties of objects in it up to maxFuzzDepth. path leads to obj.
+func (f fuzzer) mutateObject(schema map[string]any, obj map[string]any, path []string, depth int, fn func([]string, mutation)) {
+	props := asMap(f.objectSchema(schema)["properties"])
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		prop := f.resolve(asMap(props[name]))
+		at := append(slices.Clone(path), name)
+		for _, m := range valueMutations(prop, f.schemas) {
+			fn(at, m)
+		}
+		if nested, ok := obj[name].(map[string]any); ok && depth < maxFuzzDepth {
+			f.mutateObject(prop, nested, at, depth+1, fn)
+		}
+	}
+}
+
+// objectSchema returns the object schema of a possibly nullable schema
+func (f fuzzer) objectSchema(schema map[string]any) map[string]any {
+	if anyOf := asSlice(schema["anyOf"]); len(anyOf) > 0 {
+		return f.resolve(asMap(anyOf[0]))
+	}
+	return schema
+}
+
+func (f fuzzer) required(schema map[string]any) []string {
+	var names []string
+	for _, name := range asSlice(f.objectSchema(schema)["required"]) {
+		if s, ok := name.(string); ok {
+			names = append(names, s)
+		}
+	}
+	return names
+}
+
+// resolve follows a schema or parameter reference
+func (f fuzzer) resolve(v map[string]any) map[string]any {
+	for range maxMockDepth {
+		ref, ok := v["$ref"].(string)
+		if !ok {
+			return v
+		}
+		if name, ok := strings.CutPrefix(ref, "#/components/schemas/"); ok {
+			v = asMap(f.schemas[name])
+			continue
+		}
+		return v
+	}
+	return v
+}
+
+func (f fuzzer) id() string {
+	if id, ok := f.op["operationId"].(string); ok && id != "" {
+		return id
+	}
+	return f.method + " " + f.path
+}
+
+// target is the operation's path with its parameters filled in, taking the
+// values given and the valid values of the others. Optional query
+// parameters are only sent when given.
+func (f fuzzer) target(params []param, values map[string]string) string {
+	path := f.path
+	query := url.Values{}
+	for _, p := range params {
+		value, given := values[p.name]
+		if !given {
+			value = p.valid
+		}
+		switch {
+		case p.in == "path":
+			path = strings.ReplaceAll(path, "{"+p.name+"}", url.PathEscape(value))
+		case given || p.required:
+			query.Set(p.name, value)
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path
+}
+
+func (f fuzzer) responses() map[int][]string {
+	out := make(map[int][]string)
+	for key, resp := range asMap(f.op["responses"]) {
+		status, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		var types []string
+		for contentType := range asMap(f.resolve(asMap(resp))["content"]) {
+			types = append(types, contentType)
+		}
+		slices.Sort(types)
+		out[status] = types
+	}
+	return out
+}
+
+// paramMutations are the values sent in place of a valid parameter
+func paramMutations(schema map[string]any) []string {
+	values := []string{
+		"",
+		strings.Repeat("a", 4096),
+		"ünïcödé ☃ 𝄞",
+		"\x00\x01\x1f",
+		"../../../etc/passwd",
+		"%",
+		"' OR '1'='1",
+		"null",
+		"[]",
+	}
+	switch typeOf(schema) {
+	case "integer", "number":
+		values = append(values, "abc", "1.5", "-1", "0", "9223372036854775808", "-9223372036854775809", "1e309", "NaN", "0x10")
+		if minimum, ok := schema["minimum"].(float64); ok {
+			values = append(values, strconv.FormatFloat(minimum-1, 'f', -1, 64))
+		}
+		if maximum, ok := schema["maximum"].(float64); ok {
+			values = append(values, strconv.FormatFloat(maximum+1, 'f', -1, 64))
+		}
+	case "boolean":
+		values = append(values, "maybe", "2", "-1")
+	case "string":
+		switch schema["format"] {
+		case "uuid":
+			values = append(values, "not-a-uuid", "00000000-0000-0000-0000-00000000000g", "123e4567e89b12d3a456426614174000")
+		case "date-time", "date":
+			values = append(values, "yesterday", "2024-13-45T25:61:61Z", "0000-00-00", "99999-01-01T00:00:00Z")
+		case "email":
+			values = append(values, "not-an-email", "@", "a@b@c")
+		}
+	}
+	if len(asSlice(schema["enum"])) > 0 {
+		values = append(values, "not-a-choice")
+	}
+	return values
+}
+
+// valueMutations are the JSON values put in place of a valid value of
+// schema: null, values of each wrong type, and values at or past its
+// bounds
+func valueMutations(schema map[string]any, schemas map[string]any) []mutation {
+	raw := func(label, value string) mutation {
+		return mutation{label: label, value: json.RawMessage(value)}
+	}
+	str := func(label, value string) mutation {
+		encoded, _ := json.Marshal(value)
+		return mutation{label: label, value: encoded}
+	}
+	out := []mutation{raw("null", "null")}
+
+	typ := typeOf(schema)
+	for _, wrong := range []struct{ typ, value string }{
+		{"string", `"fuzz"`},
+		{"integer", `42`},
+		{"boolean", `true`},
+		{"array", `["fuzz"]`},
+		{"object", `{"fuzz":1}`},
+	} {
+		if wrong.typ != typ && !(typ == "number" && wrong.typ == "integer") {
+			out = append(out, raw(wrong.typ+" instead of "+typ, wrong.value))
+		}
+	}
+
+	switch typ {
+	case "string":
+		long := 65536
+		if maxLength, ok := schema["maxLength"].(float64); ok {
+			out = append(out, str("one past maxLength", strings.Repeat("a", int(maxLength)+1)))
+		}
+		out = append(out,
+			str("empty string", ""),
+			str("whitespace", "   "),
+			str("long string", strings.Repeat("a", long)),
+			str("unicode", "ünïcödé ☃ 𝄞 \u202e"),
+			str("control characters", "\x00\x01\x1f\x7f"),
+			str("markup", "<script>alert(1)</script>"),
+			str("SQL", "' OR '1'='1'; --"),
+		)
+		switch schema["format"] {
+		case "uuid":
+			out = append(out, str("invalid uuid", "not-a-uuid"), str("nil uuid", "00000000-0000-0000-0000-000000000000"))
+		case "date-time":
+			out = append(out, str("invalid date-time", "yesterday"), str("out of range date-time", "9999-12-31T23:59:60Z"), str("zero date-time", "0001-01-01T00:00:00Z"))
+		case "date":
+			out = append(out, str("invalid date", "2024-02-30"))
+		case "email":
+			out = append(out, str("invalid email", "not-an-email"), str("email with display name", "Fuzz <fuzz@example.com>"))
+		case "uri", "url":
+			out = append(out, str("invalid URL", "://fuzz"), str("javascript URL", "javascript:alert(1)"))
+		}
+	case "integer", "number":
+		out = append(out,
+			raw("zero", "0"),
+			raw("negative", "-1"),
+			raw("fraction", "1.5"),
+			raw("past int64", "9223372036854775808"),
+			raw("below int64", "-9223372036854775809"),
+			raw("huge", "1e308"),
+			raw("overflowing", "1e400"),
+			raw("negative zero", "-0"),
+		)
+		if minimum, ok := schema["minimum"].(float64); ok {
+			out = append(out, raw("one below minimum", strconv.FormatFloat(minimum-1, 'f', -1, 64)))
+		}
+		if maximum, ok := schema["maximum"].(float64); ok {
+			out = append(out, raw("one past maximum", strconv.FormatFloat(maximum+1, 'f', -1, 64)))
+		}
+	case "boolean":
+		out = append(out, raw("string boolean", `"true"`), raw("numeric boolean", "1"))
+	case "array":
+		item, _ := json.Marshal(mockValue(asMap(schema["items"]), schemas, 0))
+		many := "[" + strings.TrimSuffix(strings.Repeat(string(item)+",", 10000), ",") + "]"
+		out = append(out,
+			raw("empty array", "[]"),
+			raw("array of nulls", "[null,null]"),
+			raw("array of arrays", "[[[]]]"),
+			raw("10000 items", many),
+		)
+	case "object":
+		out = append(out, raw("empty object", "{}"))
+	}
+	if len(asSlice(schema["enum"])) > 0 {
+		out = append(out, str("value outside enum", "not-a-choice"))
+	}
+	return out
+}
+
+// malformedBodies are request bodies that are not a valid JSON value of
+// the operation's type
+func malformedBodies(valid []byte) []mutation {
+	raw := func(label, value string) mutation {
+		return mutation{label: label, value: json.RawMessage(value)}
+	}
+	out := []mutation{
+		raw("empty", ""),
+		raw("whitespace", " \n\t"),
+		raw("null", "null"),
+		raw("string", `"fuzz"`),
+		raw("number", "42"),
+		raw("array", "[]"),
+		raw("unterminated object", "{"),
+		raw("trailing comma", `{"a":1,}`),
+		raw("single quotes", `{'a':1}`),
+		raw("invalid UTF-8", "{\"a\":\"\xff\xfe\"}"),
+		raw("byte order mark", "\ufeff{}"),
+		raw("nested 100000 deep", strings.Repeat("[", 100000)+strings.Repeat("]", 100000)),
+		raw("duplicate keys", `{"a":1,"a":"b"}`),
+	}
+	if len(valid) > 1 {
+		out = append(out,
+			raw("truncated", string(valid[:len(valid)/2])),
+			raw("two values", string(valid)+string(valid)),
+			raw("trailing garbage", string(valid)+"garbage"),
+		)
+	}
+	return out
+}
+
+// typeOf returns the type of a schema, the first non-null one of a
+// nullable schema
+func typeOf(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if anyOf := asSlice(schema["anyOf"]); len(anyOf) > 0 {
+		return typeOf(asMap(anyOf[0]))
+	}
+	return ""
+}
+
+// setPath returns a copy of obj with the value at path replaced
+func setPath(obj map[string]any, path []string, value any) map[string]any {
+	out := make(map[string]any, len(obj)+1)
+	for k, v := range obj {
+		out[k] = v
+	}
+	if len(path) == 1 {
+		out[path[0]] = value
+		return out
+	}
+	nested, _ := obj[path[0]].(map[string]any)
+	out[path[0]] = setPath(nested, path[1:], value)
+	return out
+}
+
+// paramString formats a valid parameter value as sent in a URL
+func paramString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func bodyType(schema map[string]any, otherTypes []string) string {
+	if schema != nil {
+		return "application/json"
+	}
+	if len(otherTypes) > 0 {
+		return otherTypes[0]
+	}
+	return ""
+}
+
+func defaultBody(schema map[string]any, valid []byte) []byte {
+	if schema != nil {
+		return valid
+	}
+	return nil
+}
+
+// truncate shortens long values for descriptions
+func truncate(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "…"
+	}
+	return s
+}
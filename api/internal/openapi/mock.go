@@ -0,0 +1,253 @@
+package openapi
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"starterkit/internal/platform/apierror"
+)
+
+// maxMockDepth bounds how deep examples are built from recursive schemas
+const maxMockDepth = 8
+
+// mockRoute is an operation of the document, with its path split into
+// segments
+type mockRoute struct {
+	method    string
+	segments  []string
+	operation map[string]any
+	// primary is the status answered unless another is preferred, 0 for
+	// the lowest successful one
+	primary int
+}
+
+// Mock serves the document's operations from their examples, without
+// running them, so clients can be built against endpoints that are not
+// finished. Each request gets the first successful response the operation
+// was registered with, or its lowest successful one, or the response for the status asked for with Prefer: code=<status>.
+// Its body is the response's example, or one built from the examples,
+// defaults, and types of its schema, and failures are answered with
+// problem details for their status.
+func (d *Document) Mock() http.Handler {
+	var routes []mockRoute
+	for path, item := range asMap(d.doc["paths"]) {
+		for key, op := range asMap(item) {
+			if methods[key] {
+				routes = append(routes, mockRoute{
+					method:    strings.ToUpper(key),
+					segments:  strings.Split(strings.Trim(path, "/"), "/"),
+					operation: asMap(op),
+					primary:   d.primary[strings.ToUpper(key)+" "+path],
+				})
+			}
+		}
+	}
+	// More specific paths are matched first, so /users/changes wins over
+	// /users/{id}
+	slices.SortFunc(routes, func(a, b mockRoute) int {
+		if n := cmp.Compare(len(a.segments), len(b.segments)); n != 0 {
+			return n
+		}
+		for i := range a.segments {
+			if pa, pb := isParam(a.segments[i]), isParam(b.segments[i]); pa != pb {
+				if pb {
+					return -1
+				}
+				return 1
+			}
+		}
+		return 0
+	})
+	schemas := asMap(asMap(d.doc["components"])["schemas"])
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		pathFound := false
+		for _, route := range routes {
+			if !route.matches(segments) {
+				continue
+			}
+			pathFound = true
+			if route.method == r.Method || (r.Method == http.MethodHead && route.method == http.MethodGet) {
+				mockResponse(w, r, route, schemas)
+				return
+			}
+		}
+		if pathFound {
+			apierror.Write(w, r, apierror.New(http.StatusMethodNotAllowed, "method_not_allowed", "the mock has no example for this method"))
+			return
+		}
+		apierror.Write(w, r, apierror.NotFound("not_found", "the API document describes no operation at this path"))
+	})
+}
+
+func (m mockRoute) matches(segments []string) bool {
+	if len(segments) != len(m.segments) {
+		return false
+	}
+	for i, s := range m.segments {
+		if !isParam(s) && s != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// mockResponse answers with the response of route the request prefers
+func mockResponse(w http.ResponseWriter, r *http.Request, route mockRoute, schemas map[string]any) {
+	responses := asMap(route.operation["responses"])
+	var statuses []int
+	for key := range responses {
+		if status, err := strconv.Atoi(key); err == nil {
+			statuses = append(statuses, status)
+		}
+	}
+	slices.Sort(statuses)
+	if len(statuses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	status := route.primary
+	if status == 0 {
+		status = statuses[0]
+		for _, s := range statuses {
+			if s >= 200 && s < 300 {
+				status = s
+				break
+			}
+		}
+	}
+	if preferred, ok := preferredCode(r); ok {
+		if !slices.Contains(statuses, preferred) {
+			apierror.Write(w, r, apierror.BadRequest("unknown_example", "the operation describes no response with status "+strconv.Itoa(preferred)))
+			return
+		}
+		status = preferred
+	}
+	response := asMap(responses[strconv.Itoa(status)])
+
+	content := asMap(response["content"])
+	if len(content) == 0 {
+		w.WriteHeader(status)
+		return
+	}
+	contentType := "application/json"
+	if _, ok := content[contentType]; !ok {
+		types := make([]string, 0, len(content))
+		for t := range content {
+			types = append(types, t)
+		}
+		slices.Sort(types)
+		contentType = types[0]
+	}
+	media := asMap(content[contentType])
+
+	example, ok := media["example"]
+	if !ok && contentType == apierror.ContentType {
+		description, _ := response["description"].(string)
+		code := strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+		apierror.Write(w, r, apierror.New(status, code, description))
+		return
+	}
+	if !ok {
+		example = mockValue(asMap(media["schema"]), schemas, 0)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if s, isString := example.(string); isString && !strings.HasSuffix(contentType, "json") {
+		w.Write([]byte(s))
+		return
+	}
+	json.NewEncoder(w).Encode(example)
+}
+
+// preferredCode reads the status asked for with Prefer: code=<status>
+func preferredCode(r *http.Request) (int, bool) {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(pref), "code="); ok {
+				status, err := strconv.Atoi(value)
+				return status, err == nil
+			}
+		}
+	}
+	return 0, false
+}
+
+// mockValue builds a value matching schema from its examples, defaults,
+// and types. References are resolved in schemas.
+func mockValue(schema map[string]any, schemas map[string]any, depth int) any {
+	if depth > maxMockDepth {
+		return nil
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		return mockValue(asMap(schemas[strings.TrimPrefix(ref, "#/components/schemas/")]), schemas, depth+1)
+	}
+	if example, ok := schema["example"]; ok {
+		return example
+	}
+	if examples := asSlice(schema["examples"]); len(examples) > 0 {
+		return examples[0]
+	}
+	if def, ok := schema["default"]; ok {
+		return def
+	}
+	if enum := asSlice(schema["enum"]); len(enum) > 0 {
+		return enum[0]
+	}
+	if anyOf := asSlice(schema["anyOf"]); len(anyOf) > 0 {
+		return mockValue(asMap(anyOf[0]), schemas, depth+1)
+	}
+
+	typ := schema["type"]
+	if types := asSlice(typ); len(types) > 0 {
+		// Nullable values are mocked as their non-null type
+		typ = types[0]
+	}
+	switch typ {
+	case "object":
+		out := map[string]any{}
+		for name, prop := range asMap(schema["properties"]) {
+			out[name] = mockValue(asMap(prop), schemas, depth+1)
+		}
+		return out
+	case "array":
+		return []any{mockValue(asMap(schema["items"]), schemas, depth+1)}
+	case "integer", "number":
+		if minimum, ok := schema["minimum"].(float64); ok {
+			return minimum
+		}
+		return 0
+	case "boolean":
+		return false
+	case "string":
+		switch schema["format"] {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	default:
+		return nil
+	}
+}
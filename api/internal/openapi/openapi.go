@@ -0,0 +1,292 @@
+// Package openapi generates the API's OpenAPI 3.1 document. Feature
+// packages register their operations with request and response types, and
+// the schemas are reflected from those types, so the document follows the
+// code. Operations not yet registered come from a hand-written base
+// document. Examples registered with operations are added to the document,
+// and Mock serves them.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"starterkit/internal/platform/apierror"
+)
+
+// Registry collects operations and the schemas of their types
+type Registry struct {
+	operations []Operation
+	schemas    map[string]*Schema
+	names      map[reflect.Type]string
+	types      map[string]reflect.Type
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+		types:   make(map[string]reflect.Type),
+	}
+}
+
+// Types returns the Go types with component schemas, ordered by schema
+// name. Schemas are reflected by Build, so call it first.
+func (r *Registry) Types() []reflect.Type {
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	types := make([]reflect.Type, len(names))
+	for i, name := range names {
+		types[i] = r.types[name]
+	}
+	return types
+}
+
+// Operation describes one route. Path is the full request path, such as
+// /api/v1/users/{id}.
+type Operation struct {
+	Method      string
+	Path        string
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	// Scopes are the bearer token or signing client scopes the operation
+	// needs, and Public operations need no bearer token. Both are usually
+	// left to Secure, from the route serving the operation.
+	Scopes []string
+	Public bool
+	// Deprecated operations are still served but should not be used
+	Deprecated bool
+	Parameters []Parameter
+	// Request is a value of the JSON request body's type, or a *Schema;
+	// nil when the operation takes no body
+	Request any
+	// RequestExample is an example JSON request body
+	RequestExample any
+	// Requests are request bodies of other media types the operation
+	// accepts, each a value of the body's type or a *Schema
+	Requests  map[string]any
+	Responses []Response
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// PathParam is a parameter in the operation's path
+func PathParam(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "path", Required: true, Description: description, Schema: schema}
+}
+
+// QueryParam is an optional query string parameter
+func QueryParam(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+// Response is one outcome of an operation. Body is a value of the JSON
+// response body's type, or a *Schema; nil when there is no body.
+type Response struct {
+	Status      int
+	Description string
+	Body        any
+	// ContentType defaults to application/json
+	ContentType string
+	// Example is an example body, which mock mode serves. Bodies without
+	// one are mocked from their schema's examples.
+	Example any
+}
+
+// Problem is a failure answered with RFC 9457 problem details
+func Problem(status int, description string) Response {
+	return Response{Status: status, Description: description, Body: apierror.Problem{}, ContentType: apierror.ContentType}
+}
+
+// Define names the component schema of v's type, for types whose Go name
+// would be unclear in the document
+func (r *Registry) Define(name string, v any) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	r.name(t, name)
+}
+
+func (r *Registry) name(t reflect.Type, name string) {
+	r.names[t] = name
+	r.types[name] = t
+}
+
+// Add registers an operation. The schemas of its types are reflected when
+// the document is built.
+func (r *Registry) Add(op Operation) {
+	r.operations = append(r.operations, op)
+}
+
+// Secure sets who may call the operations at method and path, as the route
+// serving them declares: the scopes they need, or that they are public. It
+// overrides what the operations describe.
+func (r *Registry) Secure(method, path string, scopes []string, public bool) {
+	for i := range r.operations {
+		if op := &r.operations[i]; op.Method == method && op.Path == path {
+			op.Scopes, op.Public = scopes, public
+		}
+	}
+}
+
+// Parameters adds params to the operations at method and path, as list
+// routes declare them. Parameters the operations describe themselves are
+// kept.
+func (r *Registry) Parameters(method, path string, params ...Parameter) {
+	for i := range r.operations {
+		op := &r.operations[i]
+		if op.Method != method || op.Path != path {
+			continue
+		}
+		for _, param := range params {
+			if !slices.ContainsFunc(op.Parameters, func(p Parameter) bool { return p.Name == param.Name && p.In == param.In }) {
+				op.Parameters = append(op.Parameters, param)
+			}
+		}
+	}
+}
+
+// Build returns the base document, which must be OpenAPI 3.1, with the
+// registered operations and their schemas added. They replace base
+// operations with the same method and path, and base schemas with the same
+// name.
+func (r *Registry) Build(base []byte) (*Document, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(base, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse base OpenAPI document: %w", err)
+	}
+
+	paths := asMap(doc["paths"])
+	if paths == nil {
+		paths = make(map[string]any)
+		doc["paths"] = paths
+	}
+	primary := make(map[string]int)
+	for _, op := range r.operations {
+		for _, resp := range op.Responses {
+			if resp.Status >= 200 && resp.Status < 300 {
+				primary[op.Method+" "+op.Path] = resp.Status
+				break
+			}
+		}
+		encoded, err := toMap(r.operation(op))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode operation %s: %w", op.ID, err)
+		}
+		item := asMap(paths[op.Path])
+		if item == nil {
+			item = make(map[string]any)
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = encoded
+	}
+
+	components := asMap(doc["components"])
+	if components == nil {
+		components = make(map[string]any)
+		doc["components"] = components
+	}
+	schemas := asMap(components["schemas"])
+	if schemas == nil {
+		schemas = make(map[string]any)
+		components["schemas"] = schemas
+	}
+	for name, schema := range r.schemas {
+		encoded, err := toMap(schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode schema %s: %w", name, err)
+		}
+		schemas[name] = encoded
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	return &Document{doc: doc, data: data, primary: primary}, nil
+}
+
+// operation is the OpenAPI operation object of op
+func (r *Registry) operation(op Operation) map[string]any {
+	out := map[string]any{
+		"operationId": op.ID,
+		"summary":     op.Summary,
+		"tags":        op.Tags,
+	}
+	if op.Description != "" {
+		out["description"] = op.Description
+	}
+	if op.Deprecated {
+		out["deprecated"] = true
+	}
+	switch {
+	case op.Public:
+		out["security"] = []any{}
+	case len(op.Scopes) > 0:
+		// Signing clients are granted scopes in config rather than a token
+		out["security"] = []any{map[string]any{"bearerAuth": op.Scopes}, map[string]any{"requestSignature": op.Scopes}}
+	}
+	if len(op.Parameters) > 0 {
+		out["parameters"] = op.Parameters
+	}
+	if op.Request != nil {
+		media := map[string]any{"schema": r.schemaOf(op.Request)}
+		if op.RequestExample != nil {
+			media["example"] = op.RequestExample
+		}
+		content := map[string]any{"application/json": media}
+		for mediaType, body := range op.Requests {
+			content[mediaType] = map[string]any{"schema": r.schemaOf(body)}
+		}
+		out["requestBody"] = map[string]any{
+			"required": true,
+			"content":  content,
+		}
+	}
+	responses := make(map[string]any, len(op.Responses))
+	for _, resp := range op.Responses {
+		encoded := map[string]any{"description": resp.Description}
+		if resp.Body != nil {
+			contentType := resp.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			media := map[string]any{"schema": r.schemaOf(resp.Body)}
+			if resp.Example != nil {
+				media["example"] = resp.Example
+			}
+			encoded["content"] = map[string]any{contentType: media}
+		}
+		responses[strconv.Itoa(resp.Status)] = encoded
+	}
+	out["responses"] = responses
+	return out
+}
+
+// toMap round-trips v through JSON so it can be merged into the document
+func toMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
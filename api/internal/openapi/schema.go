@@ -0,0 +1,249 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema is a JSON Schema as used by OpenAPI 3.1. Type is a string, or a
+// list of strings for nullable values.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Examples             []any              `json:"examples,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty"`
+}
+
+// Describer is implemented by types that refine their reflected schema,
+// such as with length limits kept in constants
+type Describer interface {
+	DescribeSchema(s *Schema)
+}
+
+// Ref refers to a schema in the document's components, including ones the
+// base document declares
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// String is a string schema, with a format when given one
+func String(format string) *Schema {
+	return &Schema{Type: "string", Format: format}
+}
+
+// Integer is an integer schema between min and max with a default
+func Integer(min, max, def int) *Schema {
+	lo, hi := float64(min), float64(max)
+	return &Schema{Type: "integer", Minimum: &lo, Maximum: &hi, Default: def}
+}
+
+// Len sets the length limits of a string schema; 0 leaves a limit unset
+func (s *Schema) Len(min, max int) *Schema {
+	if min > 0 {
+		s.MinLength = &min
+	}
+	if max > 0 {
+		s.MaxLength = &max
+	}
+	return s
+}
+
+var (
+	timeType    = reflect.TypeFor[time.Time]()
+	uuidType    = reflect.TypeFor[uuid.UUID]()
+	rawJSONType = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemaOf reflects a Go value's JSON encoding into a schema. Named
+// structs become components and are referred to; a *Schema is used as is.
+func (r *Registry) schemaOf(v any) *Schema {
+	if s, ok := v.(*Schema); ok {
+		return s
+	}
+	return r.schemaFor(reflect.TypeOf(v))
+}
+
+func (r *Registry) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return String("date-time")
+	case uuidType:
+		return String("uuid")
+	case rawJSONType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return String("byte")
+		}
+		return &Schema{Type: "array", Items: r.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return r.component(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// component adds a named struct to the components once and refers to it
+func (r *Registry) component(t reflect.Type) *Schema {
+	name, ok := r.names[t]
+	if !ok {
+		name = t.Name()
+		if other, taken := r.types[name]; taken && other != t {
+			// Same name in another package
+			name = strings.ToUpper(path(t)[:1]) + path(t)[1:] + name
+		}
+		r.name(t, name)
+	}
+	if _, done := r.schemas[name]; !done {
+		// Reserve the name first so recursive types refer to themselves
+		r.schemas[name] = nil
+		r.schemas[name] = r.structSchema(t)
+	}
+	return Ref(name)
+}
+
+// path is the last element of a type's package path
+func path(t reflect.Type) string {
+	p := t.PkgPath()
+	return p[strings.LastIndex(p, "/")+1:]
+}
+
+// structSchema describes a struct's exported fields as properties. Fields
+// without omitempty are required unless they are pointers, which are
+// nullable instead. Fields take their description, format, example, and
+// allowed values from the doc, format, example, and enum struct tags, and
+// are marked deprecated by a deprecated tag.
+func (r *Registry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.addFields(s, t)
+	if d, ok := reflect.New(t).Interface().(Describer); ok {
+		d.DescribeSchema(s)
+	}
+	return s
+}
+
+func (r *Registry) addFields(s *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addFields(s, ft)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := r.schemaFor(ft)
+		if prop.Ref == "" {
+			prop.Description = f.Tag.Get("doc")
+			if format := f.Tag.Get("format"); format != "" {
+				prop.Format = format
+			}
+			if example, ok := f.Tag.Lookup("example"); ok {
+				prop.Examples = []any{exampleValue(prop, example)}
+			}
+			if enum := f.Tag.Get("enum"); enum != "" {
+				for _, v := range strings.Split(enum, ",") {
+					prop.Enum = append(prop.Enum, v)
+				}
+			}
+		} else {
+			// OpenAPI 3.1 allows a description beside a $ref
+			prop.Description = f.Tag.Get("doc")
+		}
+		if _, ok := f.Tag.Lookup("deprecated"); ok {
+			prop.Deprecated = true
+		}
+
+		omitempty := strings.Contains(","+opts+",", ",omitempty,")
+		switch {
+		case ft.Kind() == reflect.Pointer && !omitempty:
+			prop = nullable(prop)
+		case !omitempty && ft.Kind() != reflect.Pointer:
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// nullable lets a schema also be null
+func nullable(s *Schema) *Schema {
+	if typ, ok := s.Type.(string); ok {
+		s.Type = []string{typ, "null"}
+		return s
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
+
+// exampleValue converts an example tag to the property's type
+func exampleValue(s *Schema, example string) any {
+	switch s.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(example, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(example, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(example); err == nil {
+			return b
+		}
+	}
+	return example
+}
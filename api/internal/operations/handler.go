@@ -0,0 +1,108 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/sse"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	Get(ctx context.Context, id uuid.UUID, owner string) (*Operation, error)
+	Undo(ctx context.Context, id uuid.UUID, owner string) (*Operation, error)
+	Subscribe(owner string) (<-chan sse.Message, func())
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) HandleGetOperation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		operationID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_operation_id", "invalid operation ID format"))
+			return
+		}
+
+		op, err := h.service.Get(r.Context(), operationID, Owner(r))
+		if err != nil {
+			if errors.Is(err, ErrOperationNotFound) {
+				apierror.Write(w, r, apierror.NotFound("operation_not_found", "operation not found"))
+				return
+			}
+			h.logger.Error("failed to get operation", "error", err, "operation_id", operationID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, op)
+	}
+}
+
+// HandleUndo cancels one of the caller's staged operations while its undo
+// window is open
+func (h *Handler) HandleUndo() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		operationID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_operation_id", "invalid operation ID format"))
+			return
+		}
+
+		op, err := h.service.Undo(r.Context(), operationID, Owner(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrOperationNotFound):
+				apierror.Write(w, r, apierror.NotFound("operation_not_found", "operation not found"))
+			case errors.Is(err, ErrNotUndoable):
+				apierror.Write(w, r, apierror.Conflict("not_undoable", err.Error()))
+			default:
+				h.logger.Error("failed to undo operation", "error", err, "operation_id", operationID)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, op)
+	}
+}
+
+// HandleEvents streams operation.updated events for the caller's operations
+func (h *Handler) HandleEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ch, unsubscribe := h.service.Subscribe(Owner(r))
+		defer unsubscribe()
+
+		if err := sse.Stream(w, r, ch); err != nil {
+			h.logger.Debug("operation event stream closed", "error", err)
+		}
+	}
+}
+
+// Owner identifies the caller that operations are scoped to
+func Owner(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
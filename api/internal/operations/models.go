@@ -0,0 +1,43 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Operation statuses
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	// StatusStaged operations wait out their undo window before running
+	StatusStaged = "staged"
+	StatusUndone = "undone"
+)
+
+// EventOperationUpdated is the SSE event name emitted when an operation is
+// staged, undone, or completes
+const EventOperationUpdated = "operation.updated"
+
+// Operation is the receipt returned for an asynchronous mutation
+type Operation struct {
+	ID        uuid.UUID       `json:"id"`
+	Kind      string          `json:"kind"`
+	Status    string          `json:"status"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	UndoUntil *time.Time      `json:"undo_until,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Func performs the work behind an operation; its return value is stored as the result
+type Func func(ctx context.Context) (any, error)
+
+// Action carries out a staged operation of a kind once its undo window has
+// passed, from the payload it was staged with. Like Func, its return value
+// is stored as the result.
+type Action func(ctx context.Context, payload json.RawMessage) (any, error)
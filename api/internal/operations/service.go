@@ -0,0 +1,270 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/panics"
+	"starterkit/internal/platform/sse"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrOperationNotFound = errors.New("operation not found")
+	ErrNotUndoable       = errors.New("operation can no longer be undone")
+)
+
+// claimBatch is how many due staged operations FinalizeDue claims at a time
+const claimBatch = 50
+
+type Querier interface {
+	CreateOperation(ctx context.Context, arg db.CreateOperationParams) (db.Operation, error)
+	GetOperation(ctx context.Context, id pgtype.UUID) (db.Operation, error)
+	CompleteOperation(ctx context.Context, arg db.CompleteOperationParams) (db.Operation, error)
+	StageOperation(ctx context.Context, arg db.StageOperationParams) (db.Operation, error)
+	UndoOperation(ctx context.Context, id pgtype.UUID) (db.Operation, error)
+	ClaimDueStagedOperations(ctx context.Context, rowLimit int32) ([]db.Operation, error)
+}
+
+type Service struct {
+	queries Querier
+	hub     *sse.Hub
+	logger  *slog.Logger
+	guard   *panics.Guard
+	timeout time.Duration
+	wg      sync.WaitGroup
+
+	undoWindow time.Duration
+	actions    map[string]Action
+}
+
+// NewService runs operations for up to timeout. Staged operations can be
+// undone for undoWindow.
+func NewService(queries Querier, hub *sse.Hub, logger *slog.Logger, guard *panics.Guard, timeout, undoWindow time.Duration) *Service {
+	return &Service{
+		queries:    queries,
+		hub:        hub,
+		logger:     logger,
+		guard:      guard,
+		timeout:    timeout,
+		undoWindow: undoWindow,
+		actions:    make(map[string]Action),
+	}
+}
+
+// RegisterAction sets how staged operations of kind are carried out. It
+// must be called before operations of kind are staged.
+func (s *Service) RegisterAction(kind string, action Action) {
+	s.actions[kind] = action
+}
+
+// Start records a pending operation and runs fn in the background. The
+// returned receipt can be polled or watched over SSE until it completes.
+func (s *Service) Start(ctx context.Context, kind, owner string, fn Func) (*Operation, error) {
+	dbOp, err := s.queries.CreateOperation(ctx, db.CreateOperationParams{
+		Kind:  kind,
+		Owner: owner,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create operation: %w", err)
+	}
+
+	// Keep request-scoped values such as the logger but not the cancellation,
+	// and move the work off the interactive pool
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.timeout)
+	runCtx = database.WithWorkload(runCtx, database.WorkloadBackground)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		s.run(runCtx, dbOp.ID, kind, owner, fn)
+	}()
+
+	return toOperation(dbOp), nil
+}
+
+// Stage records a destructive operation of a registered kind without
+// carrying it out. Until its undo_until the owner can take it back with
+// Undo; after that FinalizeDue runs the kind's action with payload.
+func (s *Service) Stage(ctx context.Context, kind, owner string, payload any) (*Operation, error) {
+	if _, ok := s.actions[kind]; !ok {
+		return nil, fmt.Errorf("no action registered for operation kind %q", kind)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode operation payload: %w", err)
+	}
+	dbOp, err := s.queries.StageOperation(ctx, db.StageOperationParams{
+		Kind:        kind,
+		Owner:       owner,
+		Payload:     raw,
+		UndoSeconds: s.undoWindow.Seconds(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage operation: %w", err)
+	}
+	op := toOperation(dbOp)
+	s.publish(owner, op)
+	return op, nil
+}
+
+// Undo cancels a staged operation owned by the caller. It fails with
+// ErrNotUndoable once the undo window has passed or the operation has run.
+func (s *Service) Undo(ctx context.Context, id uuid.UUID, owner string) (*Operation, error) {
+	if _, err := s.Get(ctx, id, owner); err != nil {
+		return nil, err
+	}
+	dbOp, err := s.queries.UndoOperation(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotUndoable
+		}
+		return nil, fmt.Errorf("failed to undo operation: %w", err)
+	}
+	op := toOperation(dbOp)
+	s.publish(owner, op)
+	return op, nil
+}
+
+// FinalizeDue carries out the staged operations whose undo window has
+// passed, each as if it had been started then
+func (s *Service) FinalizeDue(ctx context.Context) error {
+	for {
+		due, err := s.queries.ClaimDueStagedOperations(ctx, claimBatch)
+		if err != nil {
+			return fmt.Errorf("failed to claim staged operations: %w", err)
+		}
+		for _, dbOp := range due {
+			action, ok := s.actions[dbOp.Kind]
+			if !ok {
+				action = func(context.Context, json.RawMessage) (any, error) {
+					return nil, fmt.Errorf("unknown operation kind %q", dbOp.Kind)
+				}
+			}
+			runCtx, cancel := context.WithTimeout(ctx, s.timeout)
+			s.run(runCtx, dbOp.ID, dbOp.Kind, dbOp.Owner, func(ctx context.Context) (any, error) {
+				return action(ctx, dbOp.Payload)
+			})
+			cancel()
+		}
+		if len(due) < claimBatch {
+			return nil
+		}
+	}
+}
+
+func (s *Service) run(ctx context.Context, id pgtype.UUID, kind, owner string, fn Func) {
+	var result any
+	runErr := s.guard.Call(ctx, "operations."+kind, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+
+	params := db.CompleteOperationParams{
+		ID:     id,
+		Status: StatusSucceeded,
+	}
+	var p *panics.Panic
+	if errors.As(runErr, &p) {
+		// The panic value is internal; the owner only learns that it failed
+		params.Status = StatusFailed
+		params.Error = pgtype.Text{String: "internal error", Valid: true}
+	} else if runErr != nil {
+		params.Status = StatusFailed
+		params.Error = pgtype.Text{String: runErr.Error(), Valid: true}
+	} else if result != nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			params.Status = StatusFailed
+			params.Error = pgtype.Text{String: "failed to encode result", Valid: true}
+		} else {
+			params.Result = raw
+		}
+	}
+
+	// Persist even if the operation itself timed out
+	dbOp, err := s.queries.CompleteOperation(context.WithoutCancel(ctx), params)
+	if err != nil {
+		s.logger.Error("failed to complete operation", "error", err, "operation_id", uuid.UUID(id.Bytes))
+		return
+	}
+
+	s.publish(owner, toOperation(dbOp))
+}
+
+// publish tells the owner's event streams about a change to op
+func (s *Service) publish(owner string, op *Operation) {
+	s.hub.Publish(topic(owner), sse.Message{
+		Event: EventOperationUpdated,
+		Data:  op,
+	})
+}
+
+// Get returns an operation owned by the caller
+func (s *Service) Get(ctx context.Context, id uuid.UUID, owner string) (*Operation, error) {
+	dbOp, err := s.queries.GetOperation(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOperationNotFound
+		}
+		return nil, err
+	}
+	if dbOp.Owner != owner {
+		return nil, ErrOperationNotFound
+	}
+	return toOperation(dbOp), nil
+}
+
+// Subscribe streams completion events for operations owned by the caller
+func (s *Service) Subscribe(owner string) (<-chan sse.Message, func()) {
+	return s.hub.Subscribe(topic(owner))
+}
+
+// Wait blocks until all in-flight operations have finished or ctx expires
+func (s *Service) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("operations still running at shutdown: %w", ctx.Err())
+	}
+}
+
+func topic(owner string) string {
+	return "operations:" + owner
+}
+
+func toOperation(o db.Operation) *Operation {
+	var undoUntil *time.Time
+	if o.UndoUntil.Valid && o.Status == StatusStaged {
+		undoUntil = &o.UndoUntil.Time
+	}
+	return &Operation{
+		ID:        uuid.UUID(o.ID.Bytes),
+		Kind:      o.Kind,
+		Status:    o.Status,
+		Result:    json.RawMessage(o.Result),
+		Error:     o.Error.String,
+		UndoUntil: undoUntil,
+		CreatedAt: o.CreatedAt.Time,
+		UpdatedAt: o.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,228 @@
+package passkeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/netip"
+
+	"starterkit/internal/magiclinks"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/geoip"
+	"starterkit/internal/platform/request"
+	"starterkit/internal/platform/useragent"
+	"starterkit/internal/platform/webauthn"
+	"starterkit/internal/sessions"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	RegistrationOptions(ctx context.Context, actor string) (*webauthn.CreationOptions, error)
+	Register(ctx context.Context, actor string, req RegisterRequest, client magiclinks.Client) (*Passkey, error)
+	LoginOptions(ctx context.Context, req LoginOptionsRequest) (*webauthn.RequestOptions, error)
+	Login(ctx context.Context, req LoginRequest, client magiclinks.Client) (*magiclinks.Session, error)
+	List(ctx context.Context, actor string) ([]Passkey, error)
+	Rename(ctx context.Context, actor string, id uuid.UUID, req RenameRequest) (*Passkey, error)
+	Delete(ctx context.Context, actor string, id uuid.UUID) error
+}
+
+type Handler struct {
+	service  ServiceInterface
+	clientIP func(*http.Request) netip.Addr
+	logger   *slog.Logger
+}
+
+// NewHandler creates the passkey handlers; clientIP returns the caller's
+// address as the server resolves it behind trusted proxies
+func NewHandler(service ServiceInterface, clientIP func(*http.Request) netip.Addr, logger *slog.Logger) *Handler {
+	return &Handler{
+		service:  service,
+		clientIP: clientIP,
+		logger:   logger,
+	}
+}
+
+// HandleRegistrationOptions returns the options to pass to
+// navigator.credentials.create
+func (h *Handler) HandleRegistrationOptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		options, err := h.service.RegistrationOptions(r.Context(), actorFromRequest(r))
+		if err != nil {
+			h.respondWithPasskeyError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		h.respondWithJSON(w, http.StatusOK, map[string]any{"publicKey": options})
+	}
+}
+
+// HandleRegister stores the credential navigator.credentials.create made
+func (h *Handler) HandleRegister() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RegisterRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		passkey, err := h.service.Register(r.Context(), actorFromRequest(r), req, h.client(r))
+		if err != nil {
+			h.respondWithPasskeyError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusCreated, passkey)
+	}
+}
+
+// HandleLoginOptions returns the options to pass to
+// navigator.credentials.get
+func (h *Handler) HandleLoginOptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req LoginOptionsRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		options, err := h.service.LoginOptions(r.Context(), req)
+		if err != nil {
+			h.respondWithPasskeyError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		h.respondWithJSON(w, http.StatusOK, map[string]any{"publicKey": options})
+	}
+}
+
+// HandleLogin exchanges a passkey's assertion for a session token
+func (h *Handler) HandleLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req LoginRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		session, err := h.service.Login(r.Context(), req, h.client(r))
+		if err != nil {
+			h.respondWithPasskeyError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		h.respondWithJSON(w, http.StatusOK, session)
+	}
+}
+
+// HandleList lists the caller's passkeys
+func (h *Handler) HandleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		passkeys, err := h.service.List(r.Context(), actorFromRequest(r))
+		if err != nil {
+			h.respondWithPasskeyError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, map[string]any{"passkeys": passkeys})
+	}
+}
+
+// HandleRename renames one of the caller's passkeys
+func (h *Handler) HandleRename() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseID(w, r)
+		if !ok {
+			return
+		}
+		var req RenameRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		passkey, err := h.service.Rename(r.Context(), actorFromRequest(r), id, req)
+		if err != nil {
+			h.respondWithPasskeyError(w, r, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, passkey)
+	}
+}
+
+// HandleDelete removes one of the caller's passkeys
+func (h *Handler) HandleDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseID(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.service.Delete(r.Context(), actorFromRequest(r), id); err != nil {
+			h.respondWithPasskeyError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// client describes the device of r
+func (h *Handler) client(r *http.Request) magiclinks.Client {
+	agent, _ := useragent.FromContext(r.Context())
+	client := magiclinks.Client{
+		Key:    sessions.Key(r.Header.Get("X-Session-ID"), r.UserAgent()),
+		Device: sessions.Device(agent),
+	}
+	if ip := h.clientIP(r); ip.IsValid() {
+		client.IPAddress = ip.String()
+	}
+	if loc, ok := geoip.FromContext(r.Context()); ok {
+		client.Country = loc.Country
+	}
+	return client
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_id", "invalid ID format"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) respondWithPasskeyError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "unauthenticated", err.Error()))
+	case errors.Is(err, ErrUserNotFound):
+		apierror.Write(w, r, apierror.NotFound("user_not_found", err.Error()))
+	case errors.Is(err, ErrPasskeyNotFound):
+		apierror.Write(w, r, apierror.NotFound("passkey_not_found", err.Error()))
+	case errors.Is(err, ErrPasskeyExists):
+		apierror.Write(w, r, apierror.Conflict("passkey_exists", err.Error()))
+	case errors.Is(err, ErrInvalidChallenge):
+		apierror.Write(w, r, apierror.BadRequest("invalid_challenge", err.Error()))
+	case errors.Is(err, ErrInvalidCredential):
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "invalid_credential", err.Error()))
+	default:
+		h.logger.Error("passkey request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,109 @@
+package passkeys
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"starterkit/internal/platform/request"
+	"starterkit/internal/platform/webauthn"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnauthenticated   = errors.New("X-User-Email header is required")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrPasskeyNotFound   = errors.New("passkey not found")
+	ErrPasskeyExists     = errors.New("passkey is already registered")
+	ErrInvalidChallenge  = errors.New("passkey challenge is invalid or expired; start again")
+	ErrInvalidCredential = errors.New("passkey could not be verified")
+)
+
+// Challenge kinds
+const (
+	kindRegistration   = "registration"
+	kindAuthentication = "authentication"
+)
+
+// maxNameLength caps the names users give passkeys
+const maxNameLength = 100
+
+// Passkey is a registered WebAuthn credential, as listed in account settings
+type Passkey struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Transports []string  `json:"transports"`
+	// Synced passkeys are backed up to a cloud account, so they survive the
+	// loss of the device
+	BackupEligible bool       `json:"backup_eligible"`
+	Synced         bool       `json:"synced"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
+
+// RegisterRequest completes registration with the new credential
+type RegisterRequest struct {
+	// Name labels the passkey in account settings; it defaults to the
+	// device it was registered on
+	Name       string                        `json:"name"`
+	Credential webauthn.RegistrationResponse `json:"credential"`
+}
+
+func (r *RegisterRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+
+	var v request.Validation
+	v.Check(utf8.RuneCountInString(r.Name) <= maxNameLength, "name", "must be at most 100 characters")
+	v.Check(len(r.Credential.RawID) > 0, "credential.rawId", "is required")
+	v.Check(len(r.Credential.Response.ClientDataJSON) > 0, "credential.response.clientDataJSON", "is required")
+	v.Check(len(r.Credential.Response.AttestationObject) > 0, "credential.response.attestationObject", "is required")
+	return v.Err()
+}
+
+// LoginOptionsRequest starts sign-in. With an email the user's passkeys are
+// offered; without one the browser offers any passkey for the site.
+type LoginOptionsRequest struct {
+	Email string `json:"email"`
+}
+
+func (r *LoginOptionsRequest) Validate() error {
+	r.Email = strings.TrimSpace(r.Email)
+
+	var v request.Validation
+	if r.Email != "" {
+		addr, err := mail.ParseAddress(r.Email)
+		v.Check(err == nil && addr.Address == r.Email, "email", "must be an email address")
+	}
+	return v.Err()
+}
+
+// LoginRequest completes sign-in with a credential's assertion
+type LoginRequest struct {
+	Credential webauthn.AssertionResponse `json:"credential"`
+}
+
+func (r *LoginRequest) Validate() error {
+	var v request.Validation
+	v.Check(len(r.Credential.RawID) > 0, "credential.rawId", "is required")
+	v.Check(len(r.Credential.Response.ClientDataJSON) > 0, "credential.response.clientDataJSON", "is required")
+	v.Check(len(r.Credential.Response.AuthenticatorData) > 0, "credential.response.authenticatorData", "is required")
+	v.Check(len(r.Credential.Response.Signature) > 0, "credential.response.signature", "is required")
+	return v.Err()
+}
+
+// RenameRequest renames a passkey
+type RenameRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *RenameRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+
+	var v request.Validation
+	v.Check(r.Name != "", "name", "is required")
+	v.Check(utf8.RuneCountInString(r.Name) <= maxNameLength, "name", "must be at most 100 characters")
+	return v.Err()
+}
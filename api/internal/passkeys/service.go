@@ -0,0 +1,419 @@
+package passkeys
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/magiclinks"
+	"starterkit/internal/platform/webauthn"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Querier interface {
+	GetWebauthnUser(ctx context.Context, email string) (db.GetWebauthnUserRow, error)
+	CreateWebauthnChallenge(ctx context.Context, arg db.CreateWebauthnChallengeParams) error
+	ConsumeWebauthnChallenge(ctx context.Context, arg db.ConsumeWebauthnChallengeParams) (db.WebauthnChallenge, error)
+	PruneWebauthnChallenges(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	CreateWebauthnCredential(ctx context.Context, arg db.CreateWebauthnCredentialParams) (db.WebauthnCredential, error)
+	ListWebauthnCredentials(ctx context.Context, userID pgtype.UUID) ([]db.WebauthnCredential, error)
+	GetWebauthnCredentialForSignIn(ctx context.Context, credentialID []byte) (db.GetWebauthnCredentialForSignInRow, error)
+	UseWebauthnCredential(ctx context.Context, arg db.UseWebauthnCredentialParams) (int64, error)
+	RenameWebauthnCredential(ctx context.Context, arg db.RenameWebauthnCredentialParams) (db.WebauthnCredential, error)
+	DeleteWebauthnCredential(ctx context.Context, arg db.DeleteWebauthnCredentialParams) (int64, error)
+}
+
+// Sessions issues the session a passkey signs in to
+type Sessions interface {
+	IssueSession(ctx context.Context, userID uuid.UUID, email, method string, client magiclinks.Client, ttl time.Duration) (*magiclinks.Session, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service registers passkeys and signs users in with them, to the same
+// sessions magic links sign in to
+type Service struct {
+	queries  Querier
+	sessions Sessions
+	auditor  Auditor
+	rp       *webauthn.RelyingParty
+	cfg      config.WebAuthnConfig
+	logger   *slog.Logger
+	// decoyKey derives the made-up passkeys offered to emails without any
+	decoyKey []byte
+}
+
+func NewService(queries Querier, sessions Sessions, auditor Auditor, cfg config.WebAuthnConfig, logger *slog.Logger) *Service {
+	return &Service{
+		queries:  queries,
+		sessions: sessions,
+		auditor:  auditor,
+		rp: &webauthn.RelyingParty{
+			ID:               cfg.RPID,
+			Name:             cfg.RPName,
+			Origins:          cfg.Origins,
+			UserVerification: cfg.UserVerification,
+			Timeout:          cfg.Timeout,
+		},
+		cfg:      cfg,
+		logger:   logger,
+		decoyKey: []byte(rand.Text()),
+	}
+}
+
+// RegistrationOptions starts registering a passkey for the signed-in user
+func (s *Service) RegistrationOptions(ctx context.Context, actor string) (*webauthn.CreationOptions, error) {
+	user, err := s.user(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := s.queries.ListWebauthnCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passkeys: %w", err)
+	}
+
+	challenge, err := s.challenge(ctx, kindRegistration, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	// The user handle is the account ID, which reveals nothing about the
+	// user to the authenticator
+	options := s.rp.CreationOptions(webauthn.User{
+		ID:          user.ID.Bytes[:],
+		Name:        user.Email,
+		DisplayName: user.Name,
+	}, challenge, descriptors(credentials))
+	return &options, nil
+}
+
+// Register verifies a new credential and stores it as a passkey of the
+// signed-in user
+func (s *Service) Register(ctx context.Context, actor string, req RegisterRequest, client magiclinks.Client) (*Passkey, error) {
+	user, err := s.user(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := s.consume(ctx, req.Credential.Response.ClientDataJSON, kindRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UserID != user.ID {
+		return nil, ErrInvalidChallenge
+	}
+
+	credential, err := s.rp.VerifyRegistration(&req.Credential, challenge.Challenge)
+	if err != nil {
+		s.logger.Info("rejected passkey registration", "error", err)
+		return nil, ErrInvalidCredential
+	}
+
+	name := req.Name
+	if name == "" {
+		name = client.Device
+	}
+	row, err := s.queries.CreateWebauthnCredential(ctx, db.CreateWebauthnCredentialParams{
+		UserID:         user.ID,
+		CredentialID:   credential.ID,
+		PublicKey:      credential.PublicKey,
+		Algorithm:      int32(credential.Algorithm),
+		SignCount:      int64(credential.SignCount),
+		Aaguid:         credential.AAGUID,
+		Transports:     nonNil(credential.Transports),
+		BackupEligible: credential.BackupEligible,
+		BackedUp:       credential.BackedUp,
+		Name:           name,
+	})
+	if isUniqueViolation(err) {
+		return nil, ErrPasskeyExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store passkey: %w", err)
+	}
+
+	passkey := toPasskey(row)
+	s.record(ctx, actor, "auth.passkey_registered", user.ID, map[string]any{
+		"passkey_id": passkey.ID.String(),
+		"name":       passkey.Name,
+		"synced":     passkey.Synced,
+	})
+	return &passkey, nil
+}
+
+// LoginOptions starts signing in. Emails without an account or without
+// passkeys are offered a made-up passkey in place of their own, so callers
+// cannot tell which emails have accounts; without an email the options
+// have no credentials, for discoverable sign-in.
+func (s *Service) LoginOptions(ctx context.Context, req LoginOptionsRequest) (*webauthn.RequestOptions, error) {
+	var allow []webauthn.CredentialDescriptor
+	if req.Email != "" {
+		user, err := s.queries.GetWebauthnUser(ctx, req.Email)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if err == nil {
+			credentials, err := s.queries.ListWebauthnCredentials(ctx, user.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list passkeys: %w", err)
+			}
+			allow = descriptors(credentials)
+		}
+		if len(allow) == 0 {
+			allow = s.decoy(req.Email)
+		}
+	}
+
+	challenge, err := s.challenge(ctx, kindAuthentication, pgtype.UUID{})
+	if err != nil {
+		return nil, err
+	}
+	options := s.rp.RequestOptions(challenge, allow)
+	return &options, nil
+}
+
+// decoy returns the made-up passkey offered to email when it has no
+// account or no passkeys, so login options look alike for every email.
+// It is the same for an email on every request to this replica.
+func (s *Service) decoy(email string) []webauthn.CredentialDescriptor {
+	m := hmac.New(sha256.New, s.decoyKey)
+	m.Write([]byte(strings.ToLower(email)))
+	return []webauthn.CredentialDescriptor{webauthn.NewDescriptor(m.Sum(nil), []string{"hybrid", "internal"})}
+}
+
+// Login verifies a passkey's assertion and starts a session for its user
+func (s *Service) Login(ctx context.Context, req LoginRequest, client magiclinks.Client) (*magiclinks.Session, error) {
+	challenge, err := s.consume(ctx, req.Credential.Response.ClientDataJSON, kindAuthentication)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := s.queries.GetWebauthnCredentialForSignIn(ctx, req.Credential.RawID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidCredential
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkey: %w", err)
+	}
+	if handle := req.Credential.Response.UserHandle; len(handle) > 0 && !bytes.Equal(handle, credential.UserID.Bytes[:]) {
+		return nil, ErrInvalidCredential
+	}
+
+	assertion, err := s.rp.VerifyAssertion(&req.Credential, challenge.Challenge, credential.PublicKey, uint32(credential.SignCount))
+	if errors.Is(err, webauthn.ErrCounterRegression) {
+		s.logger.Warn("passkey signature counter went backwards; it may be cloned", "passkey_id", uuid.UUID(credential.ID.Bytes))
+		s.record(ctx, credential.Email, "auth.passkey_counter_regression", credential.UserID, map[string]any{
+			"passkey_id": uuid.UUID(credential.ID.Bytes).String(),
+		})
+		return nil, ErrInvalidCredential
+	}
+	if err != nil {
+		s.logger.Info("rejected passkey sign-in", "error", err)
+		return nil, ErrInvalidCredential
+	}
+
+	// Only one of concurrent sign-ins with the same counter value wins
+	rows, err := s.queries.UseWebauthnCredential(ctx, db.UseWebauthnCredentialParams{
+		SignCount:         int64(assertion.SignCount),
+		BackedUp:          assertion.BackedUp,
+		ID:                credential.ID,
+		PreviousSignCount: credential.SignCount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record passkey use: %w", err)
+	}
+	if rows == 0 {
+		return nil, ErrInvalidCredential
+	}
+
+	session, err := s.sessions.IssueSession(ctx, uuid.UUID(credential.UserID.Bytes), credential.Email, magiclinks.MethodPasskey, client, s.cfg.SessionTTL)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, credential.Email, "auth.passkey_sign_in", credential.UserID, map[string]any{
+		"passkey_id":    uuid.UUID(credential.ID.Bytes).String(),
+		"device":        client.Device,
+		"user_verified": assertion.UserVerified,
+	})
+	return session, nil
+}
+
+// List returns the signed-in user's passkeys
+func (s *Service) List(ctx context.Context, actor string) ([]Passkey, error) {
+	user, err := s.user(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.queries.ListWebauthnCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passkeys: %w", err)
+	}
+
+	passkeys := make([]Passkey, len(rows))
+	for i, row := range rows {
+		passkeys[i] = toPasskey(row)
+	}
+	return passkeys, nil
+}
+
+// Rename renames one of the signed-in user's passkeys
+func (s *Service) Rename(ctx context.Context, actor string, id uuid.UUID, req RenameRequest) (*Passkey, error) {
+	user, err := s.user(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	row, err := s.queries.RenameWebauthnCredential(ctx, db.RenameWebauthnCredentialParams{
+		ID:     pgtype.UUID{Bytes: id, Valid: true},
+		UserID: user.ID,
+		Name:   req.Name,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPasskeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename passkey: %w", err)
+	}
+
+	passkey := toPasskey(row)
+	return &passkey, nil
+}
+
+// Delete removes one of the signed-in user's passkeys. Sessions it signed
+// in to stay active.
+func (s *Service) Delete(ctx context.Context, actor string, id uuid.UUID) error {
+	user, err := s.user(ctx, actor)
+	if err != nil {
+		return err
+	}
+	rows, err := s.queries.DeleteWebauthnCredential(ctx, db.DeleteWebauthnCredentialParams{
+		ID:     pgtype.UUID{Bytes: id, Valid: true},
+		UserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+	if rows == 0 {
+		return ErrPasskeyNotFound
+	}
+
+	s.record(ctx, actor, "auth.passkey_removed", user.ID, map[string]any{
+		"passkey_id": id.String(),
+	})
+	return nil
+}
+
+// Prune deletes expired challenges
+func (s *Service) Prune(ctx context.Context) error {
+	if _, err := s.queries.PruneWebauthnChallenges(ctx, pgtype.Timestamptz{Time: time.Now(), Valid: true}); err != nil {
+		return fmt.Errorf("failed to prune passkey challenges: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) user(ctx context.Context, actor string) (db.GetWebauthnUserRow, error) {
+	if actor == "" {
+		return db.GetWebauthnUserRow{}, ErrUnauthenticated
+	}
+	user, err := s.queries.GetWebauthnUser(ctx, actor)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.GetWebauthnUserRow{}, ErrUserNotFound
+	}
+	if err != nil {
+		return db.GetWebauthnUserRow{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// challenge stores a new challenge for a ceremony of kind
+func (s *Service) challenge(ctx context.Context, kind string, userID pgtype.UUID) (webauthn.Bytes, error) {
+	challenge := webauthn.NewChallenge()
+	if err := s.queries.CreateWebauthnChallenge(ctx, db.CreateWebauthnChallengeParams{
+		Challenge: challenge,
+		Kind:      kind,
+		UserID:    userID,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(s.cfg.Timeout), Valid: true},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to store passkey challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// consume deletes the challenge a ceremony response answers, so it cannot
+// be answered again, and returns it
+func (s *Service) consume(ctx context.Context, clientDataJSON []byte, kind string) (db.WebauthnChallenge, error) {
+	challenge, err := webauthn.Challenge(clientDataJSON)
+	if err != nil {
+		return db.WebauthnChallenge{}, ErrInvalidChallenge
+	}
+	row, err := s.queries.ConsumeWebauthnChallenge(ctx, db.ConsumeWebauthnChallengeParams{
+		Challenge: challenge,
+		Kind:      kind,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.WebauthnChallenge{}, ErrInvalidChallenge
+	}
+	if err != nil {
+		return db.WebauthnChallenge{}, fmt.Errorf("failed to consume passkey challenge: %w", err)
+	}
+	return row, nil
+}
+
+func (s *Service) record(ctx context.Context, actor, action string, userID pgtype.UUID, metadata map[string]any) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "user",
+		ResourceID:   uuid.UUID(userID.Bytes).String(),
+		Metadata:     metadata,
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+func descriptors(credentials []db.WebauthnCredential) []webauthn.CredentialDescriptor {
+	out := make([]webauthn.CredentialDescriptor, len(credentials))
+	for i, c := range credentials {
+		out[i] = webauthn.NewDescriptor(c.CredentialID, c.Transports)
+	}
+	return out
+}
+
+func toPasskey(row db.WebauthnCredential) Passkey {
+	passkey := Passkey{
+		ID:             uuid.UUID(row.ID.Bytes),
+		Name:           row.Name,
+		Transports:     nonNil(row.Transports),
+		BackupEligible: row.BackupEligible,
+		Synced:         row.BackedUp,
+		CreatedAt:      row.CreatedAt.Time,
+	}
+	if row.LastUsedAt.Valid {
+		passkey.LastUsedAt = &row.LastUsedAt.Time
+	}
+	return passkey
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,131 @@
+// Package apierror reports errors to API clients as RFC 9457 problem
+// details, so every handler answers failures with the same shape
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/request"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContentType is the media type of problem details
+const ContentType = "application/problem+json"
+
+// Error is an application error reported to the client with Status. Code
+// is a stable identifier clients can branch on; Detail is safe to show.
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+	Fields []request.FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+func BadRequest(code, detail string) *Error {
+	return New(http.StatusBadRequest, code, detail)
+}
+
+func NotFound(code, detail string) *Error {
+	return New(http.StatusNotFound, code, detail)
+}
+
+func Conflict(code, detail string) *Error {
+	return New(http.StatusConflict, code, detail)
+}
+
+func Unprocessable(code, detail string) *Error {
+	return New(http.StatusUnprocessableEntity, code, detail)
+}
+
+func TooManyRequests(code, detail string) *Error {
+	return New(http.StatusTooManyRequests, code, detail)
+}
+
+// Internal reports a failure whose cause must not reach the client
+func Internal() *Error {
+	return New(http.StatusInternalServerError, "internal_error", "internal server error")
+}
+
+// Problem is the problem details body written for an error
+type Problem struct {
+	Type      string               `json:"type" doc:"Always about:blank; the code member identifies the problem" example:"about:blank"`
+	Title     string               `json:"title" doc:"HTTP status text" example:"Not Found"`
+	Status    int                  `json:"status" example:"404"`
+	Detail    string               `json:"detail,omitempty" doc:"Explanation safe to show to users" example:"user not found"`
+	Instance  string               `json:"instance,omitempty" doc:"Path of the request that failed" example:"/api/v1/users/123e4567-e89b-12d3-a456-426614174000"`
+	Code      string               `json:"code" doc:"Stable machine-readable problem code" example:"user_not_found"`
+	RequestID string               `json:"request_id,omitempty" doc:"X-Request-ID of the request, for support"`
+	TraceID   string               `json:"trace_id,omitempty" doc:"Trace of the request when tracing is on"`
+	Errors    []request.FieldError `json:"errors,omitempty" doc:"Field violations of an invalid request body"`
+}
+
+// Write sends err as problem details. Decoding and validation failures
+// from the request package keep their status and field errors; any other
+// error that is not an *Error is logged and reported as internal.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *Error
+	var reqErr *request.Error
+	switch {
+	case errors.As(err, &apiErr):
+	case errors.As(err, &reqErr):
+		apiErr = &Error{Status: reqErr.Status, Code: requestCode(reqErr.Status), Detail: reqErr.Message, Fields: reqErr.Fields}
+	default:
+		logger.FromContext(r.Context()).Error("unhandled request error", "error", err)
+		apiErr = Internal()
+	}
+
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(apiErr.Status),
+		Status:    apiErr.Status,
+		Detail:    apiErr.Detail,
+		Instance:  path(r),
+		Code:      apiErr.Code,
+		RequestID: logger.RequestID(r.Context()),
+		Errors:    apiErr.Fields,
+	}
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		problem.TraceID = sc.TraceID().String()
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(apiErr.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		logger.FromContext(r.Context()).Error("failed to encode problem", "error", err)
+	}
+}
+
+// path is the request's path as the client sent it, before mounted
+// routers stripped their prefix
+func path(r *http.Request) string {
+	if r.RequestURI == "" {
+		return r.URL.Path
+	}
+	p, _, _ := strings.Cut(r.RequestURI, "?")
+	return p
+}
+
+// requestCode names the problem of a request body failure
+func requestCode(status int) string {
+	switch status {
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusUnprocessableEntity:
+		return "validation_failed"
+	default:
+		return "invalid_request"
+	}
+}
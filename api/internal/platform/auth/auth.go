@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"starterkit/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrMissingToken = errors.New("bearer token is required")
+	ErrInvalidToken = errors.New("invalid bearer token")
+)
+
+// signingMethods are the asymmetric algorithms accepted from the issuer;
+// HMAC and "none" are never accepted
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject string
+	Email   string
+	Scopes  []string
+	Roles   []string
+	// Client is the application making the request: the OAuth client a
+	// token was issued to, or the ID of a signing or service client
+	Client string
+	// Provider is the upstream identity provider a token's issuer names,
+	// such as google, or "oidc" for the issuer's own accounts
+	Provider string
+	// Tenant is the organization the caller acts in, from the claim
+	// AUTH_TENANT_CLAIM names, empty for callers outside any
+	Tenant string
+	// ExpiresAt is when the credential stops being accepted, zero when it
+	// does not expire
+	ExpiresAt time.Time
+	// Narrowed is set for narrowed tokens, which reach only routes needing
+	// scopes they were granted
+	Narrowed bool
+}
+
+// HasScope reports whether the token was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// HasRole reports whether the caller has role
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+type contextKey string
+
+const principalKey contextKey = "principal"
+
+// WithPrincipal adds the authenticated caller to the context
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// FromContext returns the authenticated caller, if the request had one
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey).(*Principal)
+	return p, ok
+}
+
+// BearerToken extracts the token from the Authorization header
+func BearerToken(r *http.Request) (string, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", ErrMissingToken
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// Verifier validates access tokens issued by an OIDC provider
+type Verifier struct {
+	keys          *KeySet
+	parser        *jwt.Parser
+	rolesClaim    []string
+	providerClaim []string
+	tenantClaim   []string
+}
+
+// NewVerifier creates a verifier for the configured issuer and audience
+func NewVerifier(cfg config.AuthConfig) *Verifier {
+	return &Verifier{
+		keys: NewKeySet(cfg.Issuer, cfg.JWKSURL, cfg.JWKSRefresh),
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(signingMethods),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(cfg.Audience),
+			jwt.WithLeeway(cfg.ClockSkew),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+		),
+		rolesClaim:    strings.Split(cfg.RolesClaim, "."),
+		providerClaim: strings.Split(cfg.ProviderClaim, "."),
+		tenantClaim:   strings.Split(cfg.TenantClaim, "."),
+	}
+}
+
+// Verify checks the token's signature, issuer, audience, and lifetime and
+// returns the caller it identifies
+func (v *Verifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidToken)
+	}
+	email, _ := claims["email"].(string)
+	client, _ := claims["azp"].(string)
+	if client == "" {
+		client, _ = claims["client_id"].(string)
+	}
+
+	// Providers grant scopes as a space-separated "scope" string or a
+	// "scp" list
+	var scopes []string
+	if scope, ok := claims["scope"].(string); ok {
+		scopes = strings.Fields(scope)
+	}
+	scopes = append(scopes, stringList(claims["scp"])...)
+
+	provider, _ := lookup(claims, v.providerClaim).(string)
+	if provider == "" {
+		provider = "oidc"
+	}
+	tenant, _ := lookup(claims, v.tenantClaim).(string)
+	var expiresAt time.Time
+	if exp, _ := claims.GetExpirationTime(); exp != nil {
+		expiresAt = exp.Time
+	}
+
+	return &Principal{
+		Subject:   subject,
+		Email:     email,
+		Scopes:    scopes,
+		Roles:     stringList(lookup(claims, v.rolesClaim)),
+		Client:    client,
+		Provider:  provider,
+		Tenant:    tenant,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// lookup follows path through nested claim objects
+func lookup(claims map[string]any, path []string) any {
+	var value any = claims
+	for _, name := range path {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[name]
+	}
+	return value
+}
+
+// stringList reads a claim holding a list of strings or a single
+// space-separated string
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"starterkit/internal/config"
+)
+
+// CertificateIdentities lists the names a client certificate presents:
+// its URI SANs (such as SPIFFE IDs), DNS SANs, email SANs, and the subject
+// common name
+func CertificateIdentities(cert *x509.Certificate) []string {
+	var names []string
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	return names
+}
+
+// CertificateMapper maps verified client certificates of internal services
+// to principals
+type CertificateMapper struct {
+	clients map[string]config.ServiceClient
+	// ids finds a client by certificate identity
+	ids map[string]string
+}
+
+// NewCertificateMapper creates a mapper for the configured service
+// clients, or returns nil when there are none
+func NewCertificateMapper(cfg config.AuthConfig) *CertificateMapper {
+	if len(cfg.ServiceClients) == 0 {
+		return nil
+	}
+	m := &CertificateMapper{
+		clients: cfg.ServiceClients,
+		ids:     make(map[string]string, len(cfg.ServiceClients)),
+	}
+	for id, client := range cfg.ServiceClients {
+		m.ids[client.Identity] = id
+	}
+	return m
+}
+
+// Principal returns the service whose certificate the connection verified.
+// It reports false when the client sent no certificate or one that names
+// no configured service, so the request can authenticate another way.
+func (m *CertificateMapper) Principal(state *tls.ConnectionState) (*Principal, bool) {
+	// Only chains verified against the client CAs count
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	for _, name := range CertificateIdentities(state.VerifiedChains[0][0]) {
+		id, ok := m.ids[name]
+		if !ok {
+			continue
+		}
+		client := m.clients[id]
+		return &Principal{
+			Subject: "service:" + id,
+			Email:   client.Email,
+			Scopes:  client.Scopes,
+			Roles:   client.Roles,
+			Client:  id,
+		}, true
+	}
+	return nil, false
+}
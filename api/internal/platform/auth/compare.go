@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// Equal reports whether the secrets a and b are equal in time independent
+// of their contents and lengths. Both are hashed first, since
+// subtle.ConstantTimeCompare returns early on a length mismatch and would
+// reveal how long the expected secret is.
+func Equal(a, b string) bool {
+	return EqualBytes([]byte(a), []byte(b))
+}
+
+// EqualBytes is Equal for byte slices
+func EqualBytes(a, b []byte) bool {
+	x, y := sha256.Sum256(a), sha256.Sum256(b)
+	return subtle.ConstantTimeCompare(x[:], y[:]) == 1
+}
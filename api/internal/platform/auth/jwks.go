@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minRefetch limits how often an unknown key ID triggers a JWKS fetch, so
+// forged tokens cannot make the server hammer the identity provider
+const minRefetch = time.Minute
+
+var errUnknownKey = errors.New("signing key not found")
+
+// KeySet caches the issuer's signing keys. Keys are fetched on first use,
+// again once the refresh interval has passed, and early when a token names
+// a key that is not cached yet, as happens after the issuer rotates keys.
+type KeySet struct {
+	issuer  string
+	jwksURL string
+	refresh time.Duration
+	client  *http.Client
+
+	// fetchMu lets one caller fetch while the others wait for its result
+	fetchMu   sync.Mutex
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewKeySet creates a key set for issuer. An empty jwksURL is discovered
+// from the issuer's OpenID configuration.
+func NewKeySet(issuer, jwksURL string, refresh time.Duration) *KeySet {
+	return &KeySet{
+		issuer:  strings.TrimSuffix(issuer, "/"),
+		jwksURL: jwksURL,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Key returns the public key with the given ID. A token without a key ID
+// is accepted only while the issuer publishes a single key.
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, ok, fetchedAt := k.cached(kid)
+	if ok && time.Since(fetchedAt) < k.refresh {
+		return key, nil
+	}
+	if !ok && !fetchedAt.IsZero() && time.Since(fetchedAt) < minRefetch {
+		return nil, errUnknownKey
+	}
+
+	k.fetchMu.Lock()
+	defer k.fetchMu.Unlock()
+	// Another caller may have fetched while this one waited
+	if key, ok, latest := k.cached(kid); latest.After(fetchedAt) {
+		if ok {
+			return key, nil
+		}
+		return nil, errUnknownKey
+	}
+
+	if err := k.fetch(ctx); err != nil {
+		if ok {
+			// Keep using the cached key while the issuer is unreachable
+			return key, nil
+		}
+		return nil, err
+	}
+	if key, ok, _ := k.cached(kid); ok {
+		return key, nil
+	}
+	return nil, errUnknownKey
+}
+
+func (k *KeySet) cached(kid string) (crypto.PublicKey, bool, time.Time) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true, k.fetchedAt
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok, k.fetchedAt
+}
+
+// fetch replaces the cached keys with the issuer's current ones
+func (k *KeySet) fetch(ctx context.Context) error {
+	if k.jwksURL == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := k.getJSON(ctx, k.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return fmt.Errorf("failed to discover signing keys: %w", err)
+		}
+		if strings.TrimSuffix(discovery.Issuer, "/") != k.issuer || discovery.JWKSURI == "" {
+			return fmt.Errorf("openid configuration of %s does not match the issuer", k.issuer)
+		}
+		k.jwksURL = discovery.JWKSURI
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := k.getJSON(ctx, k.jwksURL, &set); err != nil {
+		return fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, j := range set.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		key, err := j.publicKey()
+		if err != nil {
+			// Skip key types this server does not verify with
+			continue
+		}
+		keys[j.Kid] = key
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	k.mu.Unlock()
+	return nil
+}
+
+func (k *KeySet) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// jwk is one JSON Web Key (RFC 7517)
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (j jwk) publicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", j.Crv)
+		}
+		x, err := decodeInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", j.Kty)
+	}
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"starterkit/internal/config"
+)
+
+// Headers of a signed request
+const (
+	SignatureKeyIDHeader     = "X-Signature-Key-Id"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	SignatureNonceHeader     = "X-Signature-Nonce"
+	SignatureHeader          = "X-Signature"
+)
+
+var ErrInvalidSignature = errors.New("invalid request signature")
+
+// nonceFormat keeps nonces short enough to store and free of the
+// newlines that separate the signed fields
+var nonceFormat = regexp.MustCompile(`^[A-Za-z0-9_-]{16,128}$`)
+
+// NonceStore remembers the nonces of accepted requests
+type NonceStore interface {
+	// UseNonce records a client's nonce until expires and reports whether
+	// it was unused
+	UseNonce(ctx context.Context, clientID, nonce string, expires time.Time) (bool, error)
+}
+
+// IsSigned reports whether the request carries a signature rather than a
+// bearer token
+func IsSigned(r *http.Request) bool {
+	return r.Header.Get(SignatureHeader) != ""
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of a request. The signed string
+// is the method, the request URI with its query, the Unix timestamp, the
+// nonce, and the hex-encoded SHA-256 of the body, joined by newlines.
+func Sign(secret, method, requestURI string, timestamp int64, nonce string, body []byte) string {
+	sum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%d\n%s\n%s", strings.ToUpper(method), requestURI, timestamp, nonce, hex.EncodeToString(sum[:]))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureVerifier authenticates clients that sign their requests with a
+// shared secret
+type SignatureVerifier struct {
+	clients map[string]config.SigningClient
+	maxSkew time.Duration
+	nonces  NonceStore
+	now     func() time.Time
+}
+
+// NewSignatureVerifier creates a verifier for the configured signing
+// clients, or returns nil when there are none
+func NewSignatureVerifier(cfg config.AuthConfig, nonces NonceStore) *SignatureVerifier {
+	if len(cfg.SigningClients) == 0 {
+		return nil
+	}
+	return &SignatureVerifier{
+		clients: cfg.SigningClients,
+		maxSkew: cfg.SignatureMaxSkew,
+		nonces:  nonces,
+		now:     time.Now,
+	}
+}
+
+// Verify checks the signature of r, whose body has already been read, and
+// returns the client it identifies. The nonce is only recorded once the
+// signature matches, so forged requests cannot use up a client's nonces.
+func (v *SignatureVerifier) Verify(ctx context.Context, r *http.Request, body []byte) (*Principal, error) {
+	id := r.Header.Get(SignatureKeyIDHeader)
+	client, ok := v.clients[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidSignature, id)
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(SignatureTimestampHeader), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed timestamp", ErrInvalidSignature)
+	}
+	signedAt := time.Unix(timestamp, 0)
+	if skew := v.now().Sub(signedAt); skew > v.maxSkew || skew < -v.maxSkew {
+		return nil, fmt.Errorf("%w: timestamp outside the allowed skew", ErrInvalidSignature)
+	}
+
+	nonce := r.Header.Get(SignatureNonceHeader)
+	if !nonceFormat.MatchString(nonce) {
+		return nil, fmt.Errorf("%w: malformed nonce", ErrInvalidSignature)
+	}
+
+	got, err := hex.DecodeString(r.Header.Get(SignatureHeader))
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+	want, _ := hex.DecodeString(Sign(client.Secret, r.Method, r.URL.RequestURI(), timestamp, nonce, body))
+	if !EqualBytes(got, want) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+	}
+
+	// The nonce only needs remembering while the timestamp is acceptable
+	fresh, err := v.nonces.UseNonce(ctx, id, nonce, signedAt.Add(v.maxSkew))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record nonce: %w", err)
+	}
+	if !fresh {
+		return nil, fmt.Errorf("%w: nonce already used", ErrInvalidSignature)
+	}
+
+	return &Principal{
+		Subject: "client:" + id,
+		Email:   client.Email,
+		Scopes:  client.Scopes,
+		Roles:   client.Roles,
+		Client:  id,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Throttle slows down clients that keep failing to authenticate. Each
+// failure doubles the delay before the client's next attempt is answered,
+// from the base delay up to the max, and a client's failures are forgotten
+// once it has gone the forget interval without one. Delays are jittered so
+// their length reveals nothing about the attempt. Failures are counted in
+// memory, so each replica throttles the clients it serves.
+type Throttle struct {
+	mu       sync.Mutex
+	base     time.Duration
+	max      time.Duration
+	forget   time.Duration
+	failures map[string]failures
+}
+
+type failures struct {
+	count int
+	last  time.Time
+}
+
+// NewThrottle returns a throttle delaying clients from base after their
+// first failure up to maxDelay
+func NewThrottle(base, maxDelay, forget time.Duration) *Throttle {
+	return &Throttle{
+		base:     base,
+		max:      maxDelay,
+		forget:   forget,
+		failures: make(map[string]failures),
+	}
+}
+
+// Delay returns how long to hold back the answer to client's attempt: a
+// random duration between half and all of its backoff
+func (t *Throttle) Delay(client string) time.Duration {
+	t.mu.Lock()
+	f, ok := t.failures[client]
+	t.mu.Unlock()
+	if !ok || time.Since(f.last) >= t.forget {
+		return 0
+	}
+
+	backoff := t.base
+	for i := 1; i < f.count && backoff < t.max; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, t.max)
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// Failure records a failed attempt by client
+func (t *Throttle) Failure(client string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	f := t.failures[client]
+	if now.Sub(f.last) >= t.forget {
+		f.count = 0
+	}
+	t.failures[client] = failures{count: f.count + 1, last: now}
+}
+
+// Prune forgets clients that have gone the forget interval without failing
+func (t *Throttle) Prune(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for client, f := range t.failures {
+		if time.Since(f.last) >= t.forget {
+			delete(t.failures, client)
+		}
+	}
+	return nil
+}
+
+// Wait sleeps for d, returning early with the context's error if it is
+// done first
+func Wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
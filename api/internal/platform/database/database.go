@@ -2,16 +2,41 @@ package database
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"strings"
 	"time"
 
 	"starterkit/internal/config"
+	"starterkit/internal/platform/tlspolicy"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// Connect establishes a connection pool to PostgreSQL
-func Connect(cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+// Connect establishes a connection pool to PostgreSQL and verifies the
+// database is reachable. When sslmode enables TLS, the TLS policy constrains
+// the negotiated version and ciphers.
+func Connect(cfg config.DatabaseConfig, policy *tlspolicy.Policy) (*pgxpool.Pool, error) {
+	pool, err := Open(cfg, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify connection
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return pool, nil
+}
+
+// Open creates a connection pool without waiting for the database. Queries
+// fail until it becomes reachable; the pool dials again on each acquire.
+func Open(cfg config.DatabaseConfig, policy *tlspolicy.Policy) (*pgxpool.Pool, error) {
 	// Build connection string
 	connStr := cfg.DSN()
 
@@ -21,25 +46,62 @@ func Connect(cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("failed to parse connection string: %w", err)
 	}
 
+	certs, err := newCertSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// sslmode=prefer adds a fallback per host, so configure every candidate
+	tlsConfigs := []*tls.Config{poolConfig.ConnConfig.TLSConfig}
+	for _, fallback := range poolConfig.ConnConfig.Fallbacks {
+		tlsConfigs = append(tlsConfigs, fallback.TLSConfig)
+	}
+	for _, tlsCfg := range tlsConfigs {
+		if tlsCfg == nil {
+			continue
+		}
+		certs.configure(tlsCfg, cfg.SSLMode == "verify-full")
+		policy.Apply(tlsCfg)
+	}
+
+	// IAM auth replaces the static password with a fresh token per connection
+	tokens, err := newTokenProvider(context.Background(), cfg.Auth, cfg.Host, cfg.Port, cfg.User, cfg.AWSRegion)
+	if err != nil {
+		return nil, err
+	}
+	if tokens != nil {
+		if cfg.SSLMode == "disable" {
+			return nil, fmt.Errorf("database auth mode %s requires TLS", cfg.Auth)
+		}
+		// Tokens are signed for one endpoint; use the cluster endpoint for failover
+		if strings.Contains(cfg.Host, ",") {
+			return nil, fmt.Errorf("database auth mode %s requires a single host", cfg.Auth)
+		}
+		poolConfig.BeforeConnect = func(ctx context.Context, cc *pgx.ConnConfig) error {
+			token, err := tokens.Token(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get database auth token: %w", err)
+			}
+			cc.Password = token
+			return nil
+		}
+	}
+
 	// Configure pool settings
 	poolConfig.MaxConns = int32(cfg.MaxOpenConns)
 	poolConfig.MinConns = int32(cfg.MaxIdleConns)
 	poolConfig.MaxConnLifetime = cfg.ConnMaxLifetime
 	poolConfig.MaxConnIdleTime = cfg.ConnMaxIdleTime
+	poolConfig.ConnConfig.Tracer = timingTracer{}
 
 	// Create connection pool
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
 
-	// Verify connection
-	if err := pool.Ping(ctx); err != nil {
-		pool.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	if certs.watchesFiles() && cfg.SSLReload > 0 {
+		go certs.watch(pool, cfg.SSLReload)
 	}
 
 	return pool, nil
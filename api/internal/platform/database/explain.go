@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"starterkit/internal/platform/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// explainCooldown limits how often the same statement is explained so a
+// query that is slow under load doesn't double that load
+const explainCooldown = time.Minute
+
+// Explaining captures the plan of statements slower than a threshold. The
+// statement is re-planned with EXPLAIN (without ANALYZE, so it is not run
+// again) and the plan is logged and attached to the active span.
+type Explaining struct {
+	next      DBTX
+	threshold time.Duration
+
+	mu        sync.Mutex
+	explained map[string]time.Time
+}
+
+// NewExplaining wraps next to explain statements slower than threshold
+func NewExplaining(next DBTX, threshold time.Duration) *Explaining {
+	return &Explaining{
+		next:      next,
+		threshold: threshold,
+		explained: make(map[string]time.Time),
+	}
+}
+
+func (e *Explaining) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	start := time.Now()
+	tag, err := e.next.Exec(ctx, sql, args...)
+	e.observe(ctx, start, sql, args)
+	return tag, err
+}
+
+func (e *Explaining) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	start := time.Now()
+	rows, err := e.next.Query(ctx, sql, args...)
+	if err != nil {
+		return rows, err
+	}
+	// Count the time to read the rows; explain once they are released
+	return &explainingRows{Rows: rows, done: func() { e.observe(ctx, start, sql, args) }}, nil
+}
+
+func (e *Explaining) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &explainingRow{e: e, ctx: ctx, sql: sql, args: args}
+}
+
+type explainingRow struct {
+	e    *Explaining
+	ctx  context.Context
+	sql  string
+	args []any
+}
+
+func (row *explainingRow) Scan(dest ...any) error {
+	start := time.Now()
+	err := row.e.next.QueryRow(row.ctx, row.sql, row.args...).Scan(dest...)
+	row.e.observe(row.ctx, start, row.sql, row.args)
+	return err
+}
+
+type explainingRows struct {
+	pgx.Rows
+	once sync.Once
+	done func()
+}
+
+func (r *explainingRows) Close() {
+	r.Rows.Close()
+	r.once.Do(r.done)
+}
+
+func (r *explainingRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	// pgx closes rows once they are exhausted
+	r.once.Do(r.done)
+	return false
+}
+
+func (e *Explaining) observe(ctx context.Context, start time.Time, sql string, args []any) {
+	elapsed := time.Since(start)
+	if elapsed < e.threshold || ctx.Err() != nil {
+		return
+	}
+	name := statementName(sql)
+	if !e.claim(name) {
+		return
+	}
+
+	plan, err := e.explain(ctx, sql, args)
+	log := logger.FromContext(ctx)
+	if err != nil {
+		log.Warn("slow query", "statement", name, "duration", elapsed, "explain_error", err)
+		return
+	}
+	log.Warn("slow query", "statement", name, "duration", elapsed, "plan", plan)
+	trace.SpanFromContext(ctx).AddEvent("db.slow_query", trace.WithAttributes(
+		attribute.String("db.operation.name", name),
+		attribute.Int64("db.duration_ms", elapsed.Milliseconds()),
+		attribute.String("db.query.plan", plan),
+	))
+}
+
+// claim reports whether name may be explained now, starting its cooldown
+func (e *Explaining) claim(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := e.explained[name]; ok && now.Sub(last) < explainCooldown {
+		return false
+	}
+	e.explained[name] = now
+	return true
+}
+
+func (e *Explaining) explain(ctx context.Context, sql string, args []any) (string, error) {
+	rows, err := e.next.Query(ctx, "EXPLAIN (VERBOSE, SETTINGS) "+sql, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to explain query: %w", err)
+	}
+	lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return "", fmt.Errorf("failed to read query plan: %w", err)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// statementName returns the sqlc query name, or the statement's first line
+func statementName(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if rest, ok := strings.CutPrefix(sql, "-- name: "); ok {
+		name, _, _ := strings.Cut(rest, " ")
+		return name
+	}
+	line, _, _ := strings.Cut(sql, "\n")
+	return line
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"starterkit/internal/platform/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// FailoverFunc is called after a failover invalidated the pools. from is
+// the address of the former primary and to the new one, empty if not yet
+// known.
+type FailoverFunc func(ctx context.Context, from, to string)
+
+// FailoverWatcher probes which server the pools are connected to. When that
+// server is in recovery (demoted to a standby) or a different server has
+// become primary, it resets the pools so new connections pick the primary
+// again through target_session_attrs.
+type FailoverWatcher struct {
+	pools     *Pools
+	logger    *slog.Logger
+	failovers metric.Int64Counter
+
+	mu      sync.Mutex
+	hooks   []FailoverFunc
+	primary string
+}
+
+// NewFailoverWatcher creates a watcher for pools
+func NewFailoverWatcher(pools *Pools, logger *slog.Logger) *FailoverWatcher {
+	return &FailoverWatcher{
+		pools:     pools,
+		logger:    logger,
+		failovers: metrics.Int64Counter(metrics.DBFailovers),
+	}
+}
+
+// OnFailover registers fn to run after each failover, e.g. to drop caches
+// or connections held outside the pools
+func (w *FailoverWatcher) OnFailover(fn FailoverFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.hooks = append(w.hooks, fn)
+}
+
+// Run probes the primary every interval until ctx is cancelled
+func (w *FailoverWatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.check(ctx, interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *FailoverWatcher) check(ctx context.Context, timeout time.Duration) {
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	addr, inRecovery, err := w.probe(probeCtx)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.Warn("failed to probe database primary", "error", err)
+		}
+		return
+	}
+
+	w.mu.Lock()
+	previous := w.primary
+	if !inRecovery {
+		w.primary = addr
+	}
+	w.mu.Unlock()
+
+	switch {
+	case inRecovery:
+		// Connected to a demoted server; the next connections find the primary
+		w.failover(ctx, addr, "")
+	case previous != "" && previous != addr:
+		// The pool already reconnected elsewhere; drop any stragglers
+		w.failover(ctx, previous, addr)
+	}
+}
+
+func (w *FailoverWatcher) probe(ctx context.Context) (string, bool, error) {
+	conn, err := w.pools.Pool(WorkloadInteractive).Acquire(ctx)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var inRecovery bool
+	if err := conn.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return "", false, fmt.Errorf("failed to check recovery status: %w", err)
+	}
+	return conn.Conn().PgConn().Conn().RemoteAddr().String(), inRecovery, nil
+}
+
+func (w *FailoverWatcher) failover(ctx context.Context, from, to string) {
+	w.logger.Warn("database failover detected, resetting connection pools", "from", from, "to", to)
+	w.pools.Reset()
+	w.failovers.Add(ctx, 1, metric.WithAttributes(attribute.String("db.server.previous", from)))
+
+	w.mu.Lock()
+	hooks := append([]FailoverFunc(nil), w.hooks...)
+	w.mu.Unlock()
+	for _, fn := range hooks {
+		fn(ctx, from, to)
+	}
+}
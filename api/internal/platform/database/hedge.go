@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"starterkit/internal/platform/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// hedgeWindow is how many recent replica read latencies the hedge
+// threshold is computed from; it is recomputed every hedgeRecompute reads
+const (
+	hedgeWindow    = 512
+	hedgeRecompute = 32
+)
+
+type replicaReadKey struct{}
+
+// WithReplicaRead marks reads made with ctx as latency-critical and
+// tolerant of replication lag, so they may be served by read replicas
+func WithReplicaRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadKey{}, true)
+}
+
+func replicaRead(ctx context.Context) bool {
+	on, _ := ctx.Value(replicaReadKey{}).(bool)
+	return on
+}
+
+// Hedging sends reads marked with WithReplicaRead to read replicas in
+// turn. With two or more replicas a read still unanswered after the hedge
+// threshold is sent to the next replica too; the first answer is used and
+// the other query cancelled. Everything else goes to next.
+type Hedging struct {
+	next       DBTX
+	replicas   []*pgxpool.Pool
+	percentile float64
+	minDelay   time.Duration
+	turn       atomic.Uint64
+	outcomes   metric.Int64Counter
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	recorded  int
+	threshold time.Duration
+}
+
+// NewHedging wraps next to serve replica reads from replicas, hedging them
+// after the percentile of recent replica read latencies, or minDelay if
+// longer. A percentile of 0 disables hedging.
+func NewHedging(next DBTX, replicas []*pgxpool.Pool, percentile float64, minDelay time.Duration) *Hedging {
+	return &Hedging{
+		next:       next,
+		replicas:   replicas,
+		percentile: percentile,
+		minDelay:   minDelay,
+		outcomes:   metrics.Int64Counter(metrics.DBHedgedReads),
+		latencies:  make([]time.Duration, 0, hedgeWindow),
+		threshold:  minDelay,
+	}
+}
+
+func (h *Hedging) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return h.next.Exec(ctx, sql, args...)
+}
+
+func (h *Hedging) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if len(h.replicas) == 0 || !replicaRead(ctx) || !isRead(sql) {
+		return h.next.Query(ctx, sql, args...)
+	}
+	return h.query(ctx, sql, args)
+}
+
+func (h *Hedging) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if len(h.replicas) == 0 || !replicaRead(ctx) || !isRead(sql) {
+		return h.next.QueryRow(ctx, sql, args...)
+	}
+	rows, err := h.query(ctx, sql, args)
+	return &hedgedRow{rows: rows, err: err}
+}
+
+// attempt is one replica's answer to a read
+type attempt struct {
+	rows   pgx.Rows
+	err    error
+	cancel context.CancelFunc
+	// index orders the attempts; the second is the hedge
+	index int
+}
+
+// query sends the read to the next replica in turn, and to the one after it
+// if the first has not answered or has failed by the hedge threshold
+func (h *Hedging) query(ctx context.Context, sql string, args []any) (pgx.Rows, error) {
+	first := int(h.turn.Add(1) % uint64(len(h.replicas)))
+	results := make(chan attempt, 2)
+	var cancels []context.CancelFunc
+	send := func(pool *pgxpool.Pool) {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			rows, err := pool.Query(attemptCtx, sql, args...)
+			results <- attempt{rows: rows, err: err, cancel: cancel, index: index}
+		}()
+	}
+
+	start := time.Now()
+	send(h.replicas[first])
+	pending, hedged := 1, false
+	hedge := func() {
+		hedged = true
+		pending++
+		send(h.replicas[(first+1)%len(h.replicas)])
+	}
+
+	var timeout <-chan time.Time
+	canHedge := h.percentile > 0 && len(h.replicas) > 1
+	threshold := h.currentThreshold()
+	if canHedge {
+		timer := time.NewTimer(threshold)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	for pending > 0 {
+		select {
+		case <-timeout:
+			if !hedged {
+				hedge()
+			}
+		case res := <-results:
+			pending--
+			if res.err != nil {
+				res.cancel()
+				err = res.err
+				// A failed first attempt is hedged at once rather than at
+				// the threshold
+				if canHedge && !hedged && ctx.Err() == nil {
+					hedge()
+				}
+				continue
+			}
+
+			h.record(time.Since(start))
+			outcome := "fast"
+			if hedged {
+				outcome = "first"
+				if res.index > 0 {
+					outcome = "hedge"
+				}
+			}
+			h.outcomes.Add(ctx, 1, metric.WithAttributes(attribute.String("outcome", outcome)))
+			trace.SpanFromContext(ctx).SetAttributes(
+				attribute.Bool("db.hedged", hedged),
+				attribute.Int64("db.hedge.threshold_ms", threshold.Milliseconds()),
+			)
+
+			// Cancel the slower query and release its connection once it
+			// gives up
+			for i, cancel := range cancels {
+				if i != res.index {
+					cancel()
+				}
+			}
+			if pending > 0 {
+				go func() {
+					if loser := <-results; loser.rows != nil {
+						loser.rows.Close()
+					}
+				}()
+			}
+			return &hedgedRows{Rows: res.rows, cancel: res.cancel}, nil
+		}
+	}
+	return nil, err
+}
+
+// currentThreshold returns how long to wait for a replica before hedging
+func (h *Hedging) currentThreshold() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.threshold
+}
+
+// record adds the latency of an answered read to the window, recomputing
+// the threshold every hedgeRecompute reads
+func (h *Hedging) record(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.latencies) < hedgeWindow {
+		h.latencies = append(h.latencies, d)
+	} else {
+		h.latencies[h.recorded%hedgeWindow] = d
+	}
+	h.recorded++
+	if h.recorded%hedgeRecompute != 0 || h.percentile == 0 {
+		return
+	}
+	sorted := slices.Clone(h.latencies)
+	slices.Sort(sorted)
+	h.threshold = max(h.minDelay, sorted[int(h.percentile*float64(len(sorted)))])
+}
+
+// hedgedRows releases the winning attempt's context with its rows
+type hedgedRows struct {
+	pgx.Rows
+	cancel context.CancelFunc
+}
+
+func (r *hedgedRows) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+func (r *hedgedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	// pgx closes rows once they are read
+	r.cancel()
+	return false
+}
+
+// hedgedRow reads the first row of a hedged query, as pgx's QueryRow does
+type hedgedRow struct {
+	rows pgx.Rows
+	err  error
+}
+
+func (row *hedgedRow) Scan(dest ...any) error {
+	if row.err != nil {
+		return row.err
+	}
+	defer row.rows.Close()
+	if !row.rows.Next() {
+		if err := row.rows.Err(); err != nil {
+			return err
+		}
+		return pgx.ErrNoRows
+	}
+	if err := row.rows.Scan(dest...); err != nil {
+		return err
+	}
+	row.rows.Close()
+	return row.rows.Err()
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// rdsTokenLifetime is how long RDS accepts a signed auth token
+	rdsTokenLifetime = 15 * time.Minute
+
+	// tokenRefreshMargin renews tokens this long before they expire so a
+	// connection attempt never races the expiry
+	tokenRefreshMargin = 2 * time.Minute
+
+	// cloudSQLLoginScope grants IAM database login on Cloud SQL
+	cloudSQLLoginScope = "https://www.googleapis.com/auth/sqlservice.login"
+
+	// emptyPayloadHash is the SHA-256 of an empty body, used when presigning
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// tokenProvider issues short-lived passwords for IAM database authentication
+type tokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// newTokenProvider returns the provider for the configured auth mode, or
+// nil for static password authentication
+func newTokenProvider(ctx context.Context, mode, host, port, user, region string) (tokenProvider, error) {
+	switch mode {
+	case "password", "":
+		return nil, nil
+	case "aws-iam":
+		return newRDSTokenProvider(ctx, net.JoinHostPort(host, port), user, region)
+	case "gcp-iam":
+		return newCloudSQLTokenProvider(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported database auth mode: %s", mode)
+	}
+}
+
+// rdsTokenProvider signs RDS IAM auth tokens with the default AWS
+// credential chain, caching each token until shortly before it expires
+type rdsTokenProvider struct {
+	endpoint    string
+	user        string
+	region      string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newRDSTokenProvider(ctx context.Context, endpoint, user, region string) (*rdsTokenProvider, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("aws-iam database auth requires a region")
+	}
+
+	return &rdsTokenProvider{
+		endpoint:    endpoint,
+		user:        user,
+		region:      awsCfg.Region,
+		credentials: awsCfg.Credentials,
+		signer:      v4.NewSigner(),
+	}, nil
+}
+
+// Token returns a cached token or signs a new one
+func (p *rdsTokenProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Until(p.expires) > tokenRefreshMargin {
+		return p.token, nil
+	}
+
+	creds, err := p.credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	// The token is a presigned connect request without its scheme
+	query := url.Values{
+		"Action":        {"connect"},
+		"DBUser":        {p.user},
+		"X-Amz-Expires": {fmt.Sprint(int(rdsTokenLifetime.Seconds()))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+p.endpoint+"/?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	signed, _, err := p.signer.PresignHTTP(ctx, creds, req, emptyPayloadHash, "rds-db", p.region, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign RDS auth token: %w", err)
+	}
+
+	p.token = strings.TrimPrefix(signed, "https://")
+	p.expires = now.Add(rdsTokenLifetime)
+	return p.token, nil
+}
+
+// cloudSQLTokenProvider uses OAuth2 access tokens from Google application
+// default credentials as Cloud SQL IAM passwords
+type cloudSQLTokenProvider struct {
+	source oauth2.TokenSource
+}
+
+func newCloudSQLTokenProvider(ctx context.Context) (*cloudSQLTokenProvider, error) {
+	source, err := google.DefaultTokenSource(ctx, cloudSQLLoginScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Google credentials: %w", err)
+	}
+	return &cloudSQLTokenProvider{source: source}, nil
+}
+
+// Token returns the current access token; the source refreshes it before expiry
+func (p *cloudSQLTokenProvider) Token(ctx context.Context) (string, error) {
+	token, err := p.source.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to get Google access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Pinger is a pool or set of pools the monitor checks
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Monitor tracks whether the database behind a lazily opened pool is
+// reachable, so requests can fail fast while it is down
+type Monitor struct {
+	db        Pinger
+	logger    *slog.Logger
+	available atomic.Bool
+}
+
+// NewMonitor creates a monitor that reports the database unavailable until
+// its first successful ping
+func NewMonitor(db Pinger, logger *slog.Logger) *Monitor {
+	return &Monitor{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Available reports whether the last ping succeeded
+func (m *Monitor) Available() bool {
+	return m.available.Load()
+}
+
+// Run pings the database every interval until ctx is cancelled, logging
+// each change in availability
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.ping(ctx, interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Monitor) ping(ctx context.Context, timeout time.Duration) {
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := m.db.Ping(pingCtx)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		if m.available.Swap(false) {
+			m.logger.Error("database became unavailable", "error", err)
+		} else {
+			m.logger.Warn("database unavailable, retrying", "error", err)
+		}
+		return
+	}
+	if !m.available.Swap(true) {
+		m.logger.Info("database available")
+	}
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrReadOnly is wrapped by the errors of writes refused in read-only mode
+var ErrReadOnly = errors.New("the application is in read-only mode")
+
+// ReadOnlyMode is whether the application refuses writes, and why. It is
+// shared by the HTTP middleware, the write guard on queries, and
+// transactions, so turning it on stops writes everywhere at once.
+type ReadOnlyMode struct {
+	mu     sync.RWMutex
+	on     bool
+	reason string
+	since  time.Time
+}
+
+// Set turns read-only mode on with reason, or updates the reason
+func (m *ReadOnlyMode) Set(reason string, since time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.on, m.reason, m.since = true, reason, since
+}
+
+// Clear turns read-only mode off
+func (m *ReadOnlyMode) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.on, m.reason, m.since = false, "", time.Time{}
+}
+
+// State returns the reason and start of read-only mode, if on. A nil mode
+// is always off.
+func (m *ReadOnlyMode) State() (reason string, since time.Time, on bool) {
+	if m == nil {
+		return "", time.Time{}, false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.reason, m.since, m.on
+}
+
+// refused returns the error of a write made with ctx, or nil if allowed
+func (m *ReadOnlyMode) refused(ctx context.Context) error {
+	if writesAllowed(ctx) {
+		return nil
+	}
+	if reason, _, on := m.State(); on {
+		return fmt.Errorf("%w: %s", ErrReadOnly, reason)
+	}
+	return nil
+}
+
+type allowWritesKey struct{}
+
+// AllowWrites exempts writes made with ctx from read-only mode, for the
+// writes that manage the mode itself
+func AllowWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowWritesKey{}, true)
+}
+
+func writesAllowed(ctx context.Context) bool {
+	on, _ := ctx.Value(allowWritesKey{}).(bool)
+	return on
+}
+
+// WriteGuard refuses statements that are not plain reads while read-only
+// mode is on, so background jobs and any request that slips past the HTTP
+// check cannot write. Statements are classified as Retrying does.
+type WriteGuard struct {
+	next DBTX
+	mode *ReadOnlyMode
+}
+
+// NewWriteGuard wraps next to refuse writes while mode is on
+func NewWriteGuard(next DBTX, mode *ReadOnlyMode) *WriteGuard {
+	return &WriteGuard{next: next, mode: mode}
+}
+
+func (g *WriteGuard) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if err := g.mode.refused(ctx); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return g.next.Exec(ctx, sql, args...)
+}
+
+func (g *WriteGuard) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if !isRead(sql) {
+		if err := g.mode.refused(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return g.next.Query(ctx, sql, args...)
+}
+
+func (g *WriteGuard) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if !isRead(sql) {
+		if err := g.mode.refused(ctx); err != nil {
+			return refusedRow{err: err}
+		}
+	}
+	return g.next.QueryRow(ctx, sql, args...)
+}
+
+// refusedRow reports a refused write from Scan, where pgx reports row errors
+type refusedRow struct {
+	err error
+}
+
+func (r refusedRow) Scan(...any) error {
+	return r.err
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// retryBaseBackoff is the delay before the first retry; it doubles up to
+// the configured cap
+const retryBaseBackoff = 50 * time.Millisecond
+
+// DBTX is the query interface shared by pools, transactions, and sqlc
+type DBTX interface {
+	Exec(context.Context, string, ...any) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...any) pgx.Row
+}
+
+// Retrying retries read-only statements that fail with transient
+// connection errors, such as resets during a Postgres failover. Writes pass
+// through untouched since they may have been applied before the failure.
+type Retrying struct {
+	next       DBTX
+	attempts   int
+	maxBackoff time.Duration
+}
+
+// NewRetrying wraps next so reads are attempted up to attempts times
+func NewRetrying(next DBTX, attempts int, maxBackoff time.Duration) *Retrying {
+	return &Retrying{
+		next:       next,
+		attempts:   max(attempts, 1),
+		maxBackoff: maxBackoff,
+	}
+}
+
+func (r *Retrying) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return r.next.Exec(ctx, sql, args...)
+}
+
+// Query retries failures to start the query. Errors surfacing while rows
+// are read are returned to the caller, since rows may already be consumed.
+func (r *Retrying) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if !isRead(sql) {
+		return r.next.Query(ctx, sql, args...)
+	}
+
+	var rows pgx.Rows
+	err := r.retry(ctx, func() error {
+		var err error
+		rows, err = r.next.Query(ctx, sql, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (r *Retrying) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if !isRead(sql) {
+		return r.next.QueryRow(ctx, sql, args...)
+	}
+	return &retryingRow{r: r, ctx: ctx, sql: sql, args: args}
+}
+
+// retryingRow defers the query to Scan, where pgx reports row errors
+type retryingRow struct {
+	r    *Retrying
+	ctx  context.Context
+	sql  string
+	args []any
+}
+
+func (row *retryingRow) Scan(dest ...any) error {
+	return row.r.retry(row.ctx, func() error {
+		return row.r.next.QueryRow(row.ctx, row.sql, row.args...).Scan(dest...)
+	})
+}
+
+func (r *Retrying) retry(ctx context.Context, fn func() error) error {
+	backoff := retryBaseBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= r.attempts || !isTransient(err) {
+			if attempt > 1 {
+				trace.SpanFromContext(ctx).SetAttributes(attribute.Int("db.retry.attempts", attempt))
+			}
+			return err
+		}
+
+		trace.SpanFromContext(ctx).AddEvent("db.retry", trace.WithAttributes(
+			attribute.Int("db.retry.attempt", attempt),
+			attribute.String("error.message", err.Error()),
+		))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, r.maxBackoff)
+	}
+}
+
+// isRead reports whether a statement is a plain SELECT, skipping the
+// leading "-- name:" comment sqlc adds to every query
+func isRead(sql string) bool {
+	for {
+		sql = strings.TrimSpace(sql)
+		if !strings.HasPrefix(sql, "--") {
+			break
+		}
+		_, rest, ok := strings.Cut(sql, "\n")
+		if !ok {
+			return false
+		}
+		sql = rest
+	}
+	upper := strings.ToUpper(sql)
+	return strings.HasPrefix(upper, "SELECT") && !strings.Contains(upper, "FOR UPDATE")
+}
+
+// isTransient reports whether err is a connection-level failure worth
+// retrying rather than a query error
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case strings.HasPrefix(pgErr.Code, "08"): // connection exception
+			return true
+		case pgErr.Code == "57P01", pgErr.Code == "57P02", pgErr.Code == "57P03": // shutdown or starting up
+			return true
+		case pgErr.Code == "40001": // serialization failure
+			return true
+		}
+		return false
+	}
+
+	var connectErr *pgconn.ConnectError
+	return errors.As(err, &connectErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
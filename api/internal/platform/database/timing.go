@@ -0,0 +1,29 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"starterkit/internal/platform/servertiming"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type queryStartKey struct{}
+
+// timingTracer adds the time of each query to the Server-Timing of the
+// request it runs for. Rows are timed until they are released.
+type timingTracer struct{}
+
+func (timingTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	if servertiming.FromContext(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, queryStartKey{}, time.Now())
+}
+
+func (timingTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	if start, ok := ctx.Value(queryStartKey{}).(time.Time); ok {
+		servertiming.Query(ctx, time.Since(start))
+	}
+}
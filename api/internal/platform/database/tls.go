@@ -0,0 +1,179 @@
+package database
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"starterkit/internal/config"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// certSource holds the CA bundle and client certificate for database TLS.
+// Values come from files or inline PEM; files are re-read by reload so
+// rotated certificates apply to new connections without a restart.
+type certSource struct {
+	cfg config.DatabaseConfig
+
+	mu      sync.RWMutex
+	loaded  bool
+	rootPEM []byte
+	roots   *x509.CertPool
+	certPEM []byte
+	keyPEM  []byte
+	cert    *tls.Certificate
+}
+
+func newCertSource(cfg config.DatabaseConfig) (*certSource, error) {
+	src := &certSource{cfg: cfg}
+	if _, err := src.reload(); err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+// hasRoots reports whether a custom CA bundle is configured
+func (s *certSource) hasRoots() bool {
+	return s.cfg.SSLRootCert != "" || s.cfg.SSLRootCertPEM != ""
+}
+
+// hasClientCert reports whether a client certificate is configured
+func (s *certSource) hasClientCert() bool {
+	return s.cfg.SSLCert != "" || s.cfg.SSLCertPEM != ""
+}
+
+// watchesFiles reports whether any value comes from a file that may rotate
+func (s *certSource) watchesFiles() bool {
+	return s.cfg.SSLRootCert != "" || s.cfg.SSLCert != "" || s.cfg.SSLKey != ""
+}
+
+// reload reads the configured material and reports whether it changed.
+// Invalid material is rejected and the previous values are kept.
+func (s *certSource) reload() (bool, error) {
+	rootPEM, err := readPEM(s.cfg.SSLRootCert, s.cfg.SSLRootCertPEM)
+	if err != nil {
+		return false, fmt.Errorf("failed to read database CA: %w", err)
+	}
+	certPEM, err := readPEM(s.cfg.SSLCert, s.cfg.SSLCertPEM)
+	if err != nil {
+		return false, fmt.Errorf("failed to read database client certificate: %w", err)
+	}
+	keyPEM, err := readPEM(s.cfg.SSLKey, s.cfg.SSLKeyPEM)
+	if err != nil {
+		return false, fmt.Errorf("failed to read database client key: %w", err)
+	}
+
+	s.mu.RLock()
+	unchanged := s.loaded && bytes.Equal(rootPEM, s.rootPEM) &&
+		bytes.Equal(certPEM, s.certPEM) && bytes.Equal(keyPEM, s.keyPEM)
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	var roots *x509.CertPool
+	if rootPEM != nil {
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(rootPEM) {
+			return false, errors.New("database CA contains no certificates")
+		}
+	}
+
+	var cert *tls.Certificate
+	if certPEM != nil || keyPEM != nil {
+		if certPEM == nil || keyPEM == nil {
+			return false, errors.New("database client certificate and key must be set together")
+		}
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return false, fmt.Errorf("invalid database client certificate: %w", err)
+		}
+		cert = &pair
+	}
+
+	s.mu.Lock()
+	s.loaded = true
+	s.rootPEM, s.roots = rootPEM, roots
+	s.certPEM, s.keyPEM, s.cert = certPEM, keyPEM, cert
+	s.mu.Unlock()
+	return true, nil
+}
+
+// configure makes tlsCfg read certificates from the source on every
+// handshake. verifyHostname selects verify-full over verify-ca semantics.
+func (s *certSource) configure(tlsCfg *tls.Config, verifyHostname bool) {
+	if s.hasClientCert() {
+		tlsCfg.Certificates = nil
+		tlsCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+			return s.cert, nil
+		}
+	}
+
+	if s.hasRoots() {
+		// Verification moves into VerifyConnection so the current CA bundle is used
+		serverName := tlsCfg.ServerName
+		tlsCfg.InsecureSkipVerify = true
+		tlsCfg.VerifyPeerCertificate = nil
+		tlsCfg.VerifyConnection = func(state tls.ConnectionState) error {
+			s.mu.RLock()
+			roots := s.roots
+			s.mu.RUnlock()
+
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("database server presented no certificate")
+			}
+			opts := x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range state.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			if verifyHostname {
+				opts.DNSName = serverName
+			}
+			_, err := state.PeerCertificates[0].Verify(opts)
+			return err
+		}
+	}
+}
+
+// watch reloads files every interval for the life of the process. When
+// the material changes the pool is reset so existing connections, which
+// were authenticated with the old certificates, are replaced.
+func (s *certSource) watch(pool *pgxpool.Pool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		changed, err := s.reload()
+		if err != nil {
+			slog.Warn("failed to reload database certificates", "error", err)
+			continue
+		}
+		if changed {
+			slog.Info("database certificates rotated, resetting connection pool")
+			pool.Reset()
+		}
+	}
+}
+
+// readPEM returns the file contents if path is set, otherwise the inline value
+func readPEM(path, inline string) ([]byte, error) {
+	if path != "" {
+		return os.ReadFile(path)
+	}
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/dryrun"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TxBeginner starts transactions, such as Pools or a pgxpool.Pool
+type TxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOption adjusts the transactions started by WithTx
+type TxOption func(*pgx.TxOptions)
+
+// Isolation sets the isolation level; Postgres defaults to read committed
+func Isolation(level pgx.TxIsoLevel) TxOption {
+	return func(o *pgx.TxOptions) {
+		o.IsoLevel = level
+	}
+}
+
+// ReadOnly starts a read-only transaction
+func ReadOnly() TxOption {
+	return func(o *pgx.TxOptions) {
+		o.AccessMode = pgx.ReadOnly
+	}
+}
+
+// TxManager runs units of work in a transaction so services get atomic
+// writes without handling pgx transactions themselves
+type TxManager struct {
+	db         TxBeginner
+	queries    *db.Queries
+	mode       *ReadOnlyMode
+	attempts   int
+	maxBackoff time.Duration
+}
+
+// NewTxManager runs transactions on txdb. Transactions failing with a
+// serialization failure or deadlock are attempted up to attempts times.
+// While mode is on, transactions are started read-only.
+func NewTxManager(txdb TxBeginner, queries *db.Queries, mode *ReadOnlyMode, attempts int, maxBackoff time.Duration) *TxManager {
+	return &TxManager{
+		db:         txdb,
+		queries:    queries,
+		mode:       mode,
+		attempts:   max(attempts, 1),
+		maxBackoff: maxBackoff,
+	}
+}
+
+// WithTx calls fn with queries bound to a new transaction and commits it
+// when fn returns nil. The transaction is rolled back when fn fails or
+// panics, and always in a dry run. Conflicts with concurrent transactions
+// rerun fn in a fresh transaction, so fn must not have effects outside the
+// database. In read-only mode a write in fn fails with ErrReadOnly.
+func (m *TxManager) WithTx(ctx context.Context, fn func(q *db.Queries) error, opts ...TxOption) error {
+	var txOptions pgx.TxOptions
+	for _, opt := range opts {
+		opt(&txOptions)
+	}
+	// Postgres refuses the writes of a read-only transaction; the queries
+	// bound to it bypass the write guard
+	refused := m.mode.refused(ctx)
+	if refused != nil {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+
+	backoff := retryBaseBackoff
+	for attempt := 1; ; attempt++ {
+		err := m.run(ctx, txOptions, fn)
+		if refused != nil && isReadOnlyViolation(err) {
+			return refused
+		}
+		if err == nil || attempt >= m.attempts || !isConflict(err) {
+			if attempt > 1 {
+				trace.SpanFromContext(ctx).SetAttributes(attribute.Int("db.tx.attempts", attempt))
+			}
+			return err
+		}
+
+		trace.SpanFromContext(ctx).AddEvent("db.tx.retry", trace.WithAttributes(
+			attribute.Int("db.tx.attempt", attempt),
+			attribute.String("error.message", err.Error()),
+		))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, m.maxBackoff)
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, txOptions pgx.TxOptions, fn func(q *db.Queries) error) error {
+	tx, err := m.db.BeginTx(ctx, txOptions)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Deferred calls run while a panic unwinds, so a panicking fn never
+	// leaves the connection in an open transaction. The rollback outlives a
+	// canceled ctx and is a no-op after commit.
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if err := fn(m.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	if dryrun.Enabled(ctx) {
+		// The deferred rollback discards the dry run's writes
+		return nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isReadOnlyViolation reports whether err is a write refused by a read-only
+// transaction
+func isReadOnlyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "25006"
+}
+
+// isConflict reports whether a transaction lost to a concurrent one and
+// would likely succeed if run again
+func isConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01" // serialization failure, deadlock
+}
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/metrics"
+	"starterkit/internal/platform/tlspolicy"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Workload classifies queries so each class draws from its own pool
+type Workload int
+
+const (
+	// WorkloadInteractive is API request traffic and the default
+	WorkloadInteractive Workload = iota
+	// WorkloadBackground is scheduled jobs and async operations
+	WorkloadBackground
+	// WorkloadReporting is long-running exports and scans
+	WorkloadReporting
+)
+
+func (w Workload) String() string {
+	switch w {
+	case WorkloadBackground:
+		return "background"
+	case WorkloadReporting:
+		return "reporting"
+	default:
+		return "interactive"
+	}
+}
+
+type workloadKey struct{}
+
+// WithWorkload hints which pool queries made with ctx should use
+func WithWorkload(ctx context.Context, w Workload) context.Context {
+	return context.WithValue(ctx, workloadKey{}, w)
+}
+
+// WorkloadFromContext returns the workload hint, defaulting to interactive
+func WorkloadFromContext(ctx context.Context) Workload {
+	if w, ok := ctx.Value(workloadKey{}).(Workload); ok {
+		return w
+	}
+	return WorkloadInteractive
+}
+
+// Pools partitions connections by workload so a heavy export can't starve
+// API traffic. It satisfies db.DBTX, picking the pool from the context hint;
+// workloads without a configured pool size share the interactive pool.
+type Pools struct {
+	pools [3]*pgxpool.Pool
+	// replicas are the read replicas' pools in configuration order
+	replicas []*pgxpool.Pool
+}
+
+// ConnectPools connects a pool per workload and verifies the database is
+// reachable
+func ConnectPools(cfg config.DatabaseConfig, policy *tlspolicy.Policy) (*Pools, error) {
+	return newPools(cfg, policy, Connect)
+}
+
+// OpenPools creates a pool per workload without waiting for the database
+func OpenPools(cfg config.DatabaseConfig, policy *tlspolicy.Policy) (*Pools, error) {
+	return newPools(cfg, policy, Open)
+}
+
+func newPools(cfg config.DatabaseConfig, policy *tlspolicy.Policy, open func(config.DatabaseConfig, *tlspolicy.Policy) (*pgxpool.Pool, error)) (*Pools, error) {
+	p := &Pools{}
+	sizes := [3]int{cfg.MaxOpenConns, cfg.BackgroundMaxConns, cfg.ReportingMaxConns}
+	for w, size := range sizes {
+		if size <= 0 {
+			p.pools[w] = p.pools[WorkloadInteractive]
+			continue
+		}
+		poolCfg := cfg
+		poolCfg.MaxOpenConns = size
+		poolCfg.MaxIdleConns = min(cfg.MaxIdleConns, size)
+		pool, err := open(poolCfg, policy)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.pools[w] = pool
+	}
+
+	for _, addr := range cfg.ReadReplicas {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			host, port = addr, cfg.Port
+		}
+		replicaCfg := cfg
+		replicaCfg.Host, replicaCfg.Port = host, port
+		replicaCfg.TargetSessionAttrs = ""
+		replicaCfg.MaxOpenConns = cfg.ReplicaMaxConns
+		replicaCfg.MaxIdleConns = min(cfg.MaxIdleConns, cfg.ReplicaMaxConns)
+		pool, err := open(replicaCfg, policy)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("read replica %s: %w", addr, err)
+		}
+		p.replicas = append(p.replicas, pool)
+	}
+	return p, nil
+}
+
+// Pool returns the pool serving a workload
+func (p *Pools) Pool(w Workload) *pgxpool.Pool {
+	return p.pools[w]
+}
+
+// Replicas returns the read replicas' pools, none unless DB_READ_REPLICAS
+// is set
+func (p *Pools) Replicas() []*pgxpool.Pool {
+	return p.replicas
+}
+
+func (p *Pools) pool(ctx context.Context) *pgxpool.Pool {
+	return p.pools[WorkloadFromContext(ctx)]
+}
+
+func (p *Pools) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return p.pool(ctx).Exec(ctx, sql, args...)
+}
+
+func (p *Pools) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return p.pool(ctx).Query(ctx, sql, args...)
+}
+
+func (p *Pools) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return p.pool(ctx).QueryRow(ctx, sql, args...)
+}
+
+func (p *Pools) Begin(ctx context.Context) (pgx.Tx, error) {
+	return p.pool(ctx).Begin(ctx)
+}
+
+func (p *Pools) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	return p.pool(ctx).BeginTx(ctx, txOptions)
+}
+
+// Ping checks every distinct workload pool. Replicas are left out, since
+// the primary serves every request that does not ask for one.
+func (p *Pools) Ping(ctx context.Context) error {
+	var errs []error
+	p.each(func(pool *pgxpool.Pool) {
+		errs = append(errs, pool.Ping(ctx))
+	})
+	return errors.Join(errs...)
+}
+
+// Reset closes every connection in every pool; new connections are dialed
+// on demand
+func (p *Pools) Reset() {
+	p.each((*pgxpool.Pool).Reset)
+}
+
+// Close closes every distinct pool and the replicas'
+func (p *Pools) Close() {
+	p.each((*pgxpool.Pool).Close)
+	for _, pool := range p.replicas {
+		pool.Close()
+	}
+}
+
+// ObserveMetrics reports each distinct pool's acquired, idle, and maximum
+// connections on every metrics collection
+func (p *Pools) ObserveMetrics() error {
+	_, err := metrics.Int64ObservableGauge(metrics.DBPoolConnections, func(ctx context.Context, o metric.Int64Observer) error {
+		seen := make(map[*pgxpool.Pool]bool)
+		for w, pool := range p.pools {
+			if pool == nil || seen[pool] {
+				continue
+			}
+			seen[pool] = true
+			observePool(o, Workload(w).String(), pool)
+		}
+		for i, pool := range p.replicas {
+			observePool(o, fmt.Sprintf("replica-%d", i), pool)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to observe database pools: %w", err)
+	}
+	return nil
+}
+
+func observePool(o metric.Int64Observer, name string, pool *pgxpool.Pool) {
+	stat := pool.Stat()
+	attr := attribute.String("pool", name)
+	o.Observe(int64(stat.AcquiredConns()), metric.WithAttributes(attr, attribute.String("state", "acquired")))
+	o.Observe(int64(stat.IdleConns()), metric.WithAttributes(attr, attribute.String("state", "idle")))
+	o.Observe(int64(stat.MaxConns()), metric.WithAttributes(attr, attribute.String("state", "max")))
+}
+
+func (p *Pools) each(fn func(*pgxpool.Pool)) {
+	seen := make(map[*pgxpool.Pool]bool)
+	for _, pool := range p.pools {
+		if pool != nil && !seen[pool] {
+			seen[pool] = true
+			fn(pool)
+		}
+	}
+}
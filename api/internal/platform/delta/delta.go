@@ -0,0 +1,219 @@
+// Package delta answers clients polling list routes with only what changed
+// since the version they have, as a JSON Patch. It follows delta encoding
+// in HTTP (RFC 3229): a client sends the ETag it has in If-None-Match and
+// json-patch in A-IM, and while that version is still kept the response is
+// 226 IM Used with a patch against it. Otherwise the whole list is sent.
+package delta
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/jsonpatch"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/respcache"
+)
+
+const (
+	// Encoding is the instance manipulation clients ask for in A-IM
+	Encoding = "json-patch"
+	// StatusIMUsed answers with a delta instead of the whole response
+	StatusIMUsed = 226
+)
+
+// Encoder keeps recent versions of list responses, so later requests can
+// be answered with a patch against one
+type Encoder struct {
+	store        respcache.Store
+	ttl          time.Duration
+	maxBodySize  int
+	tenantHeader string
+}
+
+// New creates an encoder keeping versions on cfg's backend. tenantHeader
+// tells apart the versions of tenants.
+func New(cfg config.DeltaConfig, tenantHeader string) (*Encoder, error) {
+	var store respcache.Store
+	switch cfg.Backend {
+	case "redis":
+		s, err := respcache.NewRedis(cfg.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		store = s
+	default:
+		store = respcache.NewMemory(cfg.MaxEntries)
+	}
+	return &Encoder{
+		store:        store,
+		ttl:          cfg.TTL,
+		maxBodySize:  cfg.MaxBodySize,
+		tenantHeader: tenantHeader,
+	}, nil
+}
+
+// Serve answers a GET request with next, tagging its JSON response with
+// an ETag and keeping it as a version. Requests naming the current version
+// are answered 304, and those naming a kept version and accepting
+// json-patch are answered with a patch if it is smaller than the response.
+func (e *Encoder) Serve(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	w.Header().Add("Vary", "A-IM")
+
+	buf := &buffer{header: w.Header(), status: http.StatusOK}
+	next.ServeHTTP(buf, r)
+	body := buf.body.Bytes()
+	if buf.status != http.StatusOK || !isJSON(w.Header()) || len(body) > e.maxBodySize {
+		buf.flush(w)
+		return
+	}
+
+	ctx := r.Context()
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+		w.Header().Set("ETag", etag)
+	}
+	// Versions are kept and matched by opaque tag, weakly
+	tag := strings.TrimPrefix(etag, "W/")
+	key := e.key(r)
+	if err := e.store.Set(ctx, key+tag, &respcache.Entry{Status: buf.status, Body: body, StoredAt: time.Now()}, e.ttl); err != nil {
+		// Versions are an optimization; the whole response still goes out
+		logger.FromContext(ctx).Warn("failed to keep response version", "error", err)
+	}
+
+	have := splitETags(r.Header.Get("If-None-Match"))
+	if slices.Contains(have, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if accepts(r.Header.Get("A-IM"), Encoding) {
+		for _, base := range have {
+			if patch, ok := e.patch(ctx, key+base, body); ok {
+				h := w.Header()
+				h.Set("Content-Type", jsonpatch.MediaType)
+				h.Set("Content-Length", strconv.Itoa(len(patch)))
+				h.Set("IM", Encoding)
+				h.Set("Delta-Base", base)
+				w.WriteHeader(StatusIMUsed)
+				w.Write(patch)
+				return
+			}
+		}
+	}
+	buf.flush(w)
+}
+
+// patch returns the patch from the version under key to body, if the
+// version is kept and the patch is smaller than body
+func (e *Encoder) patch(ctx context.Context, key string, body []byte) ([]byte, bool) {
+	base, err := e.store.Get(ctx, key)
+	if err != nil {
+		logger.FromContext(ctx).Warn("failed to get response version", "error", err)
+		return nil, false
+	}
+	if base == nil {
+		return nil, false
+	}
+	ops, err := jsonpatch.Diff(base.Body, body)
+	if err != nil {
+		return nil, false
+	}
+	if ops == nil {
+		ops = jsonpatch.Patch{}
+	}
+	patch, err := json.Marshal(ops)
+	if err != nil || len(patch) >= len(body) {
+		return nil, false
+	}
+	return patch, true
+}
+
+// key identifies the response r gets among those of other paths, queries,
+// callers, and tenants; the ETag of a version is appended to it
+func (e *Encoder) key(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.URL.Path)
+	// Encode sorts by key, so parameter order does not matter
+	b.WriteString("\nquery=" + r.URL.Query().Encode())
+	// Scopes and roles are part of who is asking, since field access trims
+	// responses by them
+	if p, ok := auth.FromContext(r.Context()); ok {
+		b.WriteString("\nuser=" + p.Subject)
+		b.WriteString(";" + strings.Join(slices.Sorted(slices.Values(p.Scopes)), ","))
+		b.WriteString(";" + strings.Join(slices.Sorted(slices.Values(p.Roles)), ","))
+	}
+	if e.tenantHeader != "" {
+		b.WriteString("\ntenant=" + r.Header.Get(e.tenantHeader))
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return "delta:" + hex.EncodeToString(sum[:]) + ":"
+}
+
+func isJSON(h http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// accepts reports whether the A-IM header value lists encoding
+func accepts(header, encoding string) bool {
+	for _, v := range strings.Split(header, ",") {
+		name, _, _ := strings.Cut(v, ";")
+		if strings.EqualFold(strings.TrimSpace(name), encoding) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitETags returns the entity tags of an If-None-Match value, compared
+// weakly as If-None-Match requires
+func splitETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// buffer holds a response until it is known how it is answered. It shares
+// the header of the writer it stands in for.
+type buffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (b *buffer) Header() http.Header { return b.header }
+
+func (b *buffer) WriteHeader(code int) {
+	if !b.wrote {
+		b.status = code
+		b.wrote = true
+	}
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.wrote = true
+	return b.body.Write(p)
+}
+
+// flush sends the held response as it is
+func (b *buffer) flush(w http.ResponseWriter) {
+	w.WriteHeader(b.status)
+	w.Write(b.body.Bytes())
+}
@@ -0,0 +1,185 @@
+// Package deprecation marks API routes and request fields as deprecated.
+// Responses to deprecated routes carry Deprecation (RFC 9745) and Sunset
+// (RFC 8594) headers, every deprecated route or field a request uses adds
+// an entry to the warnings array of a JSON object response, and uses are
+// counted per client so owners know who still has to migrate.
+package deprecation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Notice describes a deprecation
+type Notice struct {
+	// Since is when the surface was deprecated
+	Since time.Time
+	// Sunset is when it stops working; zero while undecided
+	Sunset time.Time
+	// Link points to migration documentation or the replacement
+	Link string
+	// Message tells clients what to use instead
+	Message string
+}
+
+// Date builds a notice date from a calendar day in UTC
+func Date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+var uses = metrics.Int64Counter(metrics.APIDeprecatedUsage)
+
+type contextKey struct{}
+
+// warnings collects the deprecation warnings of one request
+type warnings struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (w *warnings) add(message string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.list = append(w.list, message)
+}
+
+func (w *warnings) get() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.list
+}
+
+// Middleware collects the warnings that deprecated routes and fields add
+// while a request is handled and writes them into JSON object responses
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		collected := &warnings{}
+		rw := &responseWriter{ResponseWriter: w, warnings: collected}
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), contextKey{}, collected)))
+		rw.finish()
+	})
+}
+
+// Route marks the handler of surface, such as "GET /health", deprecated
+func Route(surface string, n Notice, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("Deprecation", "@"+strconv.FormatInt(n.Since.Unix(), 10))
+		if !n.Sunset.IsZero() {
+			header.Set("Sunset", n.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if n.Link != "" {
+			header.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, n.Link))
+		}
+		use(r.Context(), surface, n)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Field records that a request used the deprecated field, such as
+// "UserRequest.nickname", adding a warning to the response
+func Field(ctx context.Context, field string, n Notice) {
+	use(ctx, field, n)
+}
+
+func use(ctx context.Context, surface string, n Notice) {
+	client := "anonymous"
+	if p, ok := auth.FromContext(ctx); ok && p.Client != "" {
+		client = p.Client
+	}
+	uses.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("surface", surface),
+		attribute.String("client", client),
+	))
+
+	if collected, ok := ctx.Value(contextKey{}).(*warnings); ok {
+		message := surface + " is deprecated"
+		if n.Message != "" {
+			message += "; " + n.Message
+		}
+		if !n.Sunset.IsZero() {
+			message += fmt.Sprintf(" (sunset %s)", n.Sunset.UTC().Format(time.DateOnly))
+		}
+		collected.add(message)
+	}
+}
+
+// responseWriter holds back a JSON body when the request collected
+// warnings, so they can be added to it once the handler is done
+type responseWriter struct {
+	http.ResponseWriter
+	warnings    *warnings
+	wroteHeader bool
+	status      int
+	// body is non-nil while the response is held back
+	body *bytes.Buffer
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+	if len(rw.warnings.get()) > 0 && isJSON(rw.Header().Get("Content-Type")) {
+		rw.status = code
+		rw.body = &bytes.Buffer{}
+		return
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.body != nil {
+		return rw.body.Write(b)
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// finish writes a held back body with the warnings added as its first
+// member. Bodies that are not JSON objects are written unchanged.
+func (rw *responseWriter) finish() {
+	if rw.body == nil {
+		return
+	}
+	body := rw.body.Bytes()
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		list, _ := json.Marshal(rw.warnings.get())
+		rest := bytes.TrimLeft(trimmed[1:], " \t\r\n")
+		spliced := append([]byte(`{"warnings":`), list...)
+		if len(rest) > 0 && rest[0] != '}' {
+			spliced = append(spliced, ',')
+		}
+		body = append(spliced, rest...)
+	}
+	rw.Header().Del("Content-Length")
+	rw.ResponseWriter.WriteHeader(rw.status)
+	_, _ = rw.ResponseWriter.Write(body)
+}
+
+// isJSON reports whether a content type is JSON, including problem+json
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || mediaType == "application/problem+json")
+}
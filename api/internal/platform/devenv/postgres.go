@@ -0,0 +1,132 @@
+// Package devenv provides the zero-setup local environment behind the
+// server's --dev flag: a throwaway Postgres container and demo data.
+package devenv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	postgresImage    = "postgres:17-alpine"
+	postgresUser     = "postgres"
+	postgresPassword = "postgres"
+	postgresDatabase = "starterkit"
+
+	// readyTimeout bounds how long to wait for the container to accept connections
+	readyTimeout = 60 * time.Second
+)
+
+// Postgres is a database container started for this process
+type Postgres struct {
+	ID       string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Database string
+}
+
+// StartPostgres runs a Postgres container on a random loopback port with
+// Docker and waits until it accepts connections. The container is removed
+// when stopped.
+func StartPostgres(ctx context.Context) (*Postgres, error) {
+	id, err := docker(ctx, "run", "--detach", "--rm",
+		"--publish", "127.0.0.1::5432",
+		"--env", "POSTGRES_USER="+postgresUser,
+		"--env", "POSTGRES_PASSWORD="+postgresPassword,
+		"--env", "POSTGRES_DB="+postgresDatabase,
+		postgresImage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start postgres container: %w", err)
+	}
+	pg := &Postgres{
+		ID:       id,
+		User:     postgresUser,
+		Password: postgresPassword,
+		Database: postgresDatabase,
+	}
+
+	mapping, err := docker(ctx, "port", id, "5432/tcp")
+	if err != nil {
+		pg.Stop()
+		return nil, fmt.Errorf("failed to get postgres container port: %w", err)
+	}
+	// Docker may list one mapping per address family
+	mapping, _, _ = strings.Cut(mapping, "\n")
+	if pg.Host, pg.Port, err = net.SplitHostPort(mapping); err != nil {
+		pg.Stop()
+		return nil, fmt.Errorf("failed to parse postgres container port %q: %w", mapping, err)
+	}
+
+	if err := pg.waitReady(ctx); err != nil {
+		pg.Stop()
+		return nil, err
+	}
+	return pg, nil
+}
+
+// waitReady polls until the server accepts connections. The image restarts
+// Postgres once after initialization, so a single successful ping right
+// after startup is not enough; a query must succeed on a fresh connection.
+func (p *Postgres) waitReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		p.Host, p.Port, p.User, p.Password, p.Database)
+	successes := 0
+	for {
+		conn, err := pgx.Connect(ctx, dsn)
+		if err == nil {
+			_, err = conn.Exec(ctx, "SELECT 1")
+			conn.Close(ctx)
+		}
+		if err == nil {
+			if successes++; successes == 2 {
+				return nil
+			}
+		} else {
+			successes = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("postgres container did not become ready: %w", ctx.Err())
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// Stop removes the container and its data
+func (p *Postgres) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := docker(ctx, "stop", p.ID); err != nil {
+		return fmt.Errorf("failed to stop postgres container: %w", err)
+	}
+	return nil
+}
+
+// docker runs a docker CLI command and returns its trimmed output
+func docker(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,41 @@
+package devenv
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// demoUsers mirrors hack/scripts/seed.sql
+var demoUsers = []struct {
+	id, email, name, age string
+}{
+	{"123e4567-e89b-12d3-a456-426614174000", "john.doe@example.com", "John Doe", "30 days"},
+	{"223e4567-e89b-12d3-a456-426614174001", "jane.smith@example.com", "Jane Smith", "25 days"},
+	{"323e4567-e89b-12d3-a456-426614174002", "bob.johnson@example.com", "Bob Johnson", "20 days"},
+	{"423e4567-e89b-12d3-a456-426614174003", "alice.williams@example.com", "Alice Williams", "15 days"},
+	{"523e4567-e89b-12d3-a456-426614174004", "charlie.brown@example.com", "Charlie Brown", "10 days"},
+}
+
+// Seed inserts demo users into an empty database and reports how many were
+// created. Databases that already hold users are left untouched.
+func Seed(ctx context.Context, pool *pgxpool.Pool) (int, error) {
+	var exists bool
+	if err := pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM users)").Scan(&exists); err != nil {
+		return 0, fmt.Errorf("failed to check for existing users: %w", err)
+	}
+	if exists {
+		return 0, nil
+	}
+
+	for _, u := range demoUsers {
+		if _, err := pool.Exec(ctx,
+			"INSERT INTO users (id, email, name, created_at) VALUES ($1, $2, $3, NOW() - $4::interval) ON CONFLICT DO NOTHING",
+			u.id, u.email, u.name, u.age,
+		); err != nil {
+			return 0, fmt.Errorf("failed to seed user %s: %w", u.email, err)
+		}
+	}
+	return len(demoUsers), nil
+}
@@ -0,0 +1,114 @@
+// Package dryrun lets a request that writes run every check and query it
+// normally would and then throw its writes away, so admin tools can preview
+// what a change would do. Requests ask for it with ?dry_run=true or the
+// X-Dry-Run header; transactions run in a dry-run context are rolled back
+// instead of committed, and services skip effects outside the database.
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+const (
+	// Param is the query parameter asking for a dry run
+	Param = "dry_run"
+	// Header asks for a dry run like Param, and marks dry-run responses
+	Header = "X-Dry-Run"
+)
+
+type contextKey struct{}
+
+// WithContext marks ctx as serving a dry run
+func WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, true)
+}
+
+// Enabled reports whether ctx serves a dry run
+func Enabled(ctx context.Context) bool {
+	on, _ := ctx.Value(contextKey{}).(bool)
+	return on
+}
+
+// Requested reports whether r asks for a dry run. Values that are not
+// booleans are an error rather than being taken as no.
+func Requested(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get(Param)
+	if raw == "" {
+		raw = r.Header.Get(Header)
+	}
+	if raw == "" {
+		return false, nil
+	}
+	on, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", Param)
+	}
+	return on, nil
+}
+
+// Change is one field a request would change
+type Change struct {
+	Field string `json:"field"`
+	From  any    `json:"from" doc:"Value before the change; null for fields being created"`
+	To    any    `json:"to" doc:"Value after the change; null for fields being removed"`
+}
+
+// Preview answers a dry run: what would have changed, and the response the
+// request would have got
+type Preview struct {
+	DryRun   bool     `json:"dry_run"`
+	Changes  []Change `json:"changes,omitempty"`
+	Response any      `json:"response,omitempty" doc:"Body the request would have been answered with"`
+}
+
+// Diff lists the top-level JSON fields that differ between before and
+// after, by name. Either may be nil, for resources being created or
+// removed.
+func Diff(before, after any) ([]Change, error) {
+	from, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(from)+len(to))
+	for name := range from {
+		names = append(names, name)
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	changes := []Change{}
+	for _, name := range names {
+		if !reflect.DeepEqual(from[name], to[name]) {
+			changes = append(changes, Change{Field: name, From: from[name], To: to[name]})
+		}
+	}
+	return changes, nil
+}
+
+// fields decodes v's JSON form into its top-level fields
+func fields(v any) (map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %T: %w", v, err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode %T: %w", v, err)
+	}
+	return m, nil
+}
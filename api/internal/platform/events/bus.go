@@ -0,0 +1,69 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"starterkit/internal/platform/panics"
+)
+
+// Event is a domain event published on the bus
+type Event struct {
+	Type       string    `json:"type"`
+	Payload    any       `json:"payload"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Handler processes a published event
+type Handler func(ctx context.Context, event Event) error
+
+// Bus is an in-process publish/subscribe event bus
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	logger   *slog.Logger
+	guard    *panics.Guard
+}
+
+// NewBus creates an empty event bus
+func NewBus(logger *slog.Logger, guard *panics.Guard) *Bus {
+	return &Bus{
+		handlers: make(map[string][]Handler),
+		logger:   logger,
+		guard:    guard,
+	}
+}
+
+// Subscribe registers a handler for the given event type
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish delivers the event synchronously to all subscribers.
+// Handler errors and panics are logged and do not stop delivery to other
+// handlers.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[event.Type]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		err := b.guard.Call(ctx, "events."+event.Type, func(ctx context.Context) error {
+			return handler(ctx, event)
+		})
+		if err != nil {
+			b.logger.Error("event handler failed",
+				"event_type", event.Type,
+				"error", err,
+			)
+		}
+	}
+}
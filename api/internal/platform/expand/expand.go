@@ -0,0 +1,145 @@
+// Package expand embeds related resources in responses on request, as in
+// ?expand=preferences,sessions. Each resource type has a registry of
+// relations whose loaders fetch the related resources of a whole page of
+// parents at once, so expanding a list costs a query per relation rather
+// than per item.
+package expand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrUnknownRelation = errors.New("unknown relation")
+	ErrTooDeep         = errors.New("expansion too deep")
+)
+
+// Expandable is a resource relations can be embedded in
+type Expandable interface {
+	SetExpanded(relation string, v any)
+}
+
+// Loader returns the related resource of each parent, in the same order;
+// nil leaves a parent without the relation
+type Loader func(ctx context.Context, parents []Expandable) ([]any, error)
+
+// Relation is a resource that can be embedded in its parent
+type Relation struct {
+	Load Loader
+	// Nested holds the relations of the related resource, for paths such
+	// as "sessions.device"; Load then returns Expandable values or []any
+	// of them
+	Nested *Registry
+}
+
+// Registry holds the relations of one resource type
+type Registry struct {
+	relations map[string]Relation
+}
+
+func NewRegistry() *Registry {
+	return &Registry{relations: make(map[string]Relation)}
+}
+
+// Register adds a relation under the name clients expand it by
+func (r *Registry) Register(name string, rel Relation) {
+	r.relations[name] = rel
+}
+
+// Names lists the registered relations in order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.relations))
+	for name := range r.relations {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Tree is a parsed expand parameter: each relation with the relations to
+// expand within it
+type Tree map[string]Tree
+
+// Parse reads a comma-separated list of dotted relation paths, refusing
+// unknown relations and paths longer than maxDepth
+func (r *Registry) Parse(param string, maxDepth int) (Tree, error) {
+	tree := Tree{}
+	for _, path := range strings.Split(param, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		names := strings.Split(path, ".")
+		if len(names) > maxDepth {
+			return nil, fmt.Errorf("%w: %s has more than %d levels", ErrTooDeep, path, maxDepth)
+		}
+
+		registry, node := r, tree
+		for _, name := range names {
+			if registry == nil {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownRelation, path)
+			}
+			rel, ok := registry.relations[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownRelation, path)
+			}
+			if node[name] == nil {
+				node[name] = Tree{}
+			}
+			registry, node = rel.Nested, node[name]
+		}
+	}
+	return tree, nil
+}
+
+// Load runs the loader of every relation in tree for parents and embeds
+// the results, then expands nested relations of what was loaded
+func (r *Registry) Load(ctx context.Context, tree Tree, parents []Expandable) error {
+	if len(parents) == 0 {
+		return nil
+	}
+	for name, children := range tree {
+		rel := r.relations[name]
+		related, err := rel.Load(ctx, parents)
+		if err != nil {
+			return fmt.Errorf("failed to expand %s: %w", name, err)
+		}
+		if len(related) != len(parents) {
+			return fmt.Errorf("failed to expand %s: loader returned %d results for %d parents", name, len(related), len(parents))
+		}
+
+		var next []Expandable
+		for i, v := range related {
+			if v == nil {
+				continue
+			}
+			parents[i].SetExpanded(name, v)
+			next = appendExpandable(next, v)
+		}
+		if len(children) > 0 && rel.Nested != nil {
+			if err := rel.Nested.Load(ctx, children, next); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appendExpandable adds v, or the items of a slice v, that are Expandable
+func appendExpandable(list []Expandable, v any) []Expandable {
+	switch v := v.(type) {
+	case Expandable:
+		return append(list, v)
+	case []Expandable:
+		return append(list, v...)
+	case []any:
+		for _, item := range v {
+			list = appendExpandable(list, item)
+		}
+	}
+	return list
+}
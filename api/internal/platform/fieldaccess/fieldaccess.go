@@ -0,0 +1,401 @@
+// Package fieldaccess strips or masks response fields the caller may not
+// see. A struct field tagged `access:"self,admin,role:support,scope:users:pii"`
+// is only shown to callers holding one of the grants; with `mask:"email"` or
+// `mask:"last4"` other callers get a masked string instead of no field.
+// Configured rules, keyed by "Type.field" with the Go type name and JSON
+// field name, override the tags.
+package fieldaccess
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/auth"
+)
+
+// Masks that replace a hidden string field
+const (
+	// MaskEmail keeps the first character of the local part and the domain
+	MaskEmail = "email"
+	// MaskLast4 keeps the last four characters
+	MaskLast4 = "last4"
+)
+
+// Owner is implemented by resources the "self" grant applies to
+type Owner interface {
+	OwnedBy(p *auth.Principal) bool
+}
+
+// Filter applies field rules to response values
+type Filter struct {
+	rules     map[string]config.FieldRule
+	adminRole string
+	enforce   bool
+	// restricted caches whether a type has rules anywhere inside it
+	restricted sync.Map
+}
+
+// New creates a filter for the configured rules. Without enforce, as when
+// auth is disabled, every field is shown.
+func New(cfg config.AuthConfig, enforce bool) *Filter {
+	return &Filter{rules: cfg.FieldRules, adminRole: cfg.AdminRole, enforce: enforce}
+}
+
+type contextKey struct{}
+
+// Middleware makes f available to Apply in handlers
+func Middleware(f *Filter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, f)))
+		})
+	}
+}
+
+// Apply returns v as the request's caller may see it, ready to be encoded
+// as JSON. Values without restricted fields are returned unchanged.
+func Apply(ctx context.Context, v any) any {
+	f, ok := ctx.Value(contextKey{}).(*Filter)
+	if !ok || !f.enforce || v == nil {
+		return v
+	}
+	p, _ := auth.FromContext(ctx)
+	return f.apply(p, reflect.ValueOf(v))
+}
+
+var (
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func (f *Filter) apply(p *auth.Principal, v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if !f.isRestricted(v.Type(), map[reflect.Type]bool{}) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return f.apply(p, v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = f.apply(p, v.Index(i))
+		}
+		return items
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		entries := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			entries[iter.Key().String()] = f.apply(p, iter.Value())
+		}
+		return entries
+	case reflect.Struct:
+		obj := object{}
+		f.appendFields(&obj, p, v, owner(v))
+		return obj
+	}
+	return v.Interface()
+}
+
+// appendFields adds the visible fields of struct v to obj, flattening
+// embedded structs as encoding/json does
+func (f *Filter) appendFields(obj *object, p *auth.Principal, v reflect.Value, owner Owner) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		fv := v.Field(i)
+
+		if field.Anonymous && name == "" {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				f.appendFields(obj, p, fv, owner)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if rule, ok := f.rule(t, field, name); ok && !f.allowed(p, owner, rule) {
+			if masked, ok := mask(fv, rule.Mask); ok {
+				*obj = append(*obj, member{name, masked})
+			}
+			continue
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmpty(fv) {
+			continue
+		}
+		*obj = append(*obj, member{name, f.apply(p, fv)})
+	}
+}
+
+// rule returns the configured rule of a field, falling back to its tags.
+// "self" grants the resource's owner, "admin" the admin role.
+func (f *Filter) rule(t reflect.Type, field reflect.StructField, name string) (config.FieldRule, bool) {
+	if rule, ok := f.rules[t.Name()+"."+name]; ok {
+		return rule, true
+	}
+	grants, ok := field.Tag.Lookup("access")
+	if !ok {
+		return config.FieldRule{}, false
+	}
+	return config.FieldRule{Grants: strings.Split(grants, ","), Mask: field.Tag.Get("mask")}, true
+}
+
+// allowed reports whether p holds one of the rule's grants
+func (f *Filter) allowed(p *auth.Principal, owner Owner, rule config.FieldRule) bool {
+	if p == nil {
+		return false
+	}
+	for _, grant := range rule.Grants {
+		kind, name, _ := strings.Cut(strings.TrimSpace(grant), ":")
+		switch kind {
+		case "self":
+			if owner != nil && owner.OwnedBy(p) {
+				return true
+			}
+		case "admin":
+			if p.HasRole(f.adminRole) {
+				return true
+			}
+		case "role":
+			if p.HasRole(name) {
+				return true
+			}
+		case "scope":
+			if p.HasScope(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isRestricted reports whether values of t can contain a field with a
+// rule, so values without any skip the reflective walk
+func (f *Filter) isRestricted(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if cached, ok := f.restricted.Load(t); ok {
+		return cached.(bool)
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	var restricted bool
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		restricted = f.isRestricted(t.Elem(), visiting)
+	case reflect.Interface:
+		// The dynamic value is checked when it is walked
+		restricted = true
+	case reflect.Map:
+		restricted = t.Key().Kind() == reflect.String && f.isRestricted(t.Elem(), visiting)
+	case reflect.Struct:
+		// Types with their own encoding are written as they are
+		if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) ||
+			t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+			break
+		}
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" {
+				name = field.Name
+			}
+			if _, ok := f.rules[t.Name()+"."+name]; ok {
+				restricted = true
+			} else if _, ok := field.Tag.Lookup("access"); ok {
+				restricted = true
+			} else if field.IsExported() {
+				restricted = f.isRestricted(field.Type, visiting)
+			}
+			if restricted {
+				break
+			}
+		}
+	}
+	if t.Kind() != reflect.Interface {
+		f.restricted.Store(t, restricted)
+	}
+	return restricted
+}
+
+// Policy is the rule of one response field
+type Policy struct {
+	// Field is "Type.field", as configured rules name it
+	Field  string   `json:"field"`
+	Grants []string `json:"grants"`
+	Mask   string   `json:"mask,omitempty"`
+	// Configured is set for rules from AUTH_FIELD_RULES rather than tags
+	Configured bool `json:"configured"`
+}
+
+// Policies lists the rules of the fields of types and of the structs they
+// contain, then the configured rules of other types, ordered by field
+func (f *Filter) Policies(types []reflect.Type) []Policy {
+	seen := make(map[string]bool)
+	var policies []Policy
+	visited := make(map[reflect.Type]bool)
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || visited[t] {
+			return
+		}
+		visited[t] = true
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if rule, ok := f.rule(t, field, name); ok {
+				key := t.Name() + "." + name
+				_, configured := f.rules[key]
+				seen[key] = true
+				policies = append(policies, Policy{Field: key, Grants: rule.Grants, Mask: rule.Mask, Configured: configured})
+			}
+			walk(field.Type)
+		}
+	}
+	for _, t := range types {
+		walk(t)
+	}
+	for key, rule := range f.rules {
+		if !seen[key] {
+			policies = append(policies, Policy{Field: key, Grants: rule.Grants, Mask: rule.Mask, Configured: true})
+		}
+	}
+	slices.SortFunc(policies, func(a, b Policy) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return policies
+}
+
+// owner returns the struct as an Owner, if it is one
+func owner(v reflect.Value) Owner {
+	if o, ok := v.Interface().(Owner); ok {
+		return o
+	}
+	if v.CanAddr() {
+		if o, ok := v.Addr().Interface().(Owner); ok {
+			return o
+		}
+	}
+	return nil
+}
+
+// mask returns the masked form of a string field, or false when the field
+// is to be left out
+func mask(v reflect.Value, kind string) (string, bool) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String {
+		return "", false
+	}
+	s := v.String()
+	switch kind {
+	case MaskEmail:
+		local, domain, ok := strings.Cut(s, "@")
+		if !ok || local == "" {
+			return "***", true
+		}
+		first, _ := utf8.DecodeRuneInString(local)
+		return string(first) + "***@" + domain, true
+	case MaskLast4:
+		runes := []rune(s)
+		keep := max(len(runes)-4, 0)
+		return strings.Repeat("*", keep) + string(runes[keep:]), true
+	}
+	return "", false
+}
+
+// isEmpty mirrors the omitempty rule of encoding/json
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// object is a filtered struct, encoded with its fields in declaration order
+type object []member
+
+type member struct {
+	name  string
+	value any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(m.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
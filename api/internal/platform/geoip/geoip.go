@@ -0,0 +1,211 @@
+// Package geoip resolves client IP addresses to a country and region using
+// a MaxMind GeoIP2 or GeoLite2 database
+package geoip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/memcache"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+)
+
+// Location is where a client IP is registered. Country is an ISO 3166-1
+// alpha-2 code and Region an ISO 3166-2 subdivision code such as "US-CA",
+// which is empty for country-level databases.
+type Location struct {
+	Country string `json:"country"`
+	Region  string `json:"region,omitempty"`
+}
+
+type contextKey string
+
+const locationKey contextKey = "geo_location"
+
+// WithContext adds the client's location to the context
+func WithContext(ctx context.Context, loc Location) context.Context {
+	return context.WithValue(ctx, locationKey, loc)
+}
+
+// FromContext extracts the client's location; ok is false when it is unknown
+func FromContext(ctx context.Context) (Location, bool) {
+	loc, ok := ctx.Value(locationKey).(Location)
+	return loc, ok
+}
+
+// record is the part of a City or Country database entry that is read
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+}
+
+// Lookups are cached per address, since the same clients send request after
+// request. Reload clears the cache when the database changes.
+const (
+	cachedLookups   = 10000
+	cachedLookupTTL = time.Hour
+)
+
+// lookup is a cached result of Lookup
+type lookup struct {
+	loc Location
+	ok  bool
+}
+
+// Resolver looks up client locations. The database file is reopened by
+// Reload when it changes, so it can be updated in place by geoipupdate.
+// Without the file, locations come from the configured CDN header, if any,
+// and are otherwise unknown.
+type Resolver struct {
+	path          string
+	countryHeader string
+	logger        *slog.Logger
+
+	mu      sync.RWMutex
+	reader  *maxminddb.Reader
+	modTime time.Time
+	cache   *memcache.Cache[netip.Addr, lookup]
+}
+
+// New opens the database if it exists. A missing or unreadable file is
+// logged, not returned, so requests are served without locations.
+func New(cfg config.GeoIPConfig, logger *slog.Logger) *Resolver {
+	r := &Resolver{
+		path:          cfg.DatabasePath,
+		countryHeader: cfg.CountryHeader,
+		logger:        logger,
+		cache:         memcache.New[netip.Addr, lookup]("geoip.lookups", cachedLookups, cachedLookupTTL),
+	}
+	if r.path == "" {
+		logger.Warn("GEOIP_DATABASE_PATH is not set, client locations come from the country header only")
+		return r
+	}
+	if err := r.Reload(context.Background()); err != nil {
+		logger.Warn("failed to open geoip database, client locations come from the country header only", "error", err)
+	} else if r.reader == nil {
+		logger.Warn("geoip database not found, client locations come from the country header only", "path", r.path)
+	}
+	return r
+}
+
+// Reload reopens the database when the file has changed since it was last
+// opened. A file that disappears keeps the database already loaded.
+func (r *Resolver) Reload(ctx context.Context) error {
+	if r.path == "" {
+		return nil
+	}
+	info, err := os.Stat(r.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat geoip database: %w", err)
+	}
+
+	r.mu.RLock()
+	unchanged := r.reader != nil && info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	reader, err := maxminddb.Open(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to open geoip database: %w", err)
+	}
+
+	r.mu.Lock()
+	previous := r.reader
+	r.reader, r.modTime = reader, info.ModTime()
+	r.mu.Unlock()
+	r.cache.Clear()
+
+	// The write lock waited for lookups on the previous reader to finish
+	if previous != nil {
+		previous.Close()
+	}
+	r.logger.Info("loaded geoip database",
+		"path", r.path,
+		"type", reader.Metadata.DatabaseType,
+		"built", reader.Metadata.BuildTime(),
+	)
+	return nil
+}
+
+// Lookup returns the location of addr; ok is false for private addresses
+// and those the database does not know
+func (r *Resolver) Lookup(addr netip.Addr) (Location, bool) {
+	if !addr.IsValid() {
+		return Location{}, false
+	}
+	// Lookups are not traced, so there is no request context to record
+	// the cache metrics with
+	ctx := context.Background()
+	if cached, ok := r.cache.Get(ctx, addr); ok {
+		return cached.loc, cached.ok
+	}
+	loc, ok := r.find(addr)
+	r.cache.Set(ctx, addr, lookup{loc: loc, ok: ok})
+	return loc, ok
+}
+
+// find reads the location of addr from the database
+func (r *Resolver) find(addr netip.Addr) (Location, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.reader == nil {
+		return Location{}, false
+	}
+
+	var rec record
+	if err := r.reader.Lookup(addr.Unmap()).Decode(&rec); err != nil {
+		r.logger.Debug("geoip lookup failed", "error", err)
+		return Location{}, false
+	}
+	loc := Location{Country: rec.Country.ISOCode}
+	if loc.Country == "" {
+		loc.Country = rec.RegisteredCountry.ISOCode
+	}
+	if loc.Country == "" {
+		return Location{}, false
+	}
+	if len(rec.Subdivisions) > 0 && rec.Subdivisions[0].ISOCode != "" {
+		loc.Region = loc.Country + "-" + rec.Subdivisions[0].ISOCode
+	}
+	return loc, true
+}
+
+// Locate returns the location of the client at addr, falling back to the
+// country header when the database has no answer
+func (r *Resolver) Locate(req *http.Request, addr netip.Addr) (Location, bool) {
+	if loc, ok := r.Lookup(addr); ok {
+		return loc, true
+	}
+	if r.countryHeader == "" {
+		return Location{}, false
+	}
+	country := strings.ToUpper(strings.TrimSpace(req.Header.Get(r.countryHeader)))
+	// CDNs send XX for unknown and T1 for Tor exit nodes
+	if len(country) != 2 || country == "XX" || country == "T1" {
+		return Location{}, false
+	}
+	return Location{Country: country}, true
+}
@@ -0,0 +1,94 @@
+package geoip
+
+import (
+	"context"
+	"strings"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Reasons a location is blocked
+const (
+	ReasonDenied     = "denied"
+	ReasonNotAllowed = "not_allowed"
+	ReasonUnknown    = "unknown_location"
+)
+
+type rules struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+func newRules(cfg config.GeoBlockRules) rules {
+	r := rules{allow: make(map[string]bool), deny: make(map[string]bool)}
+	for _, code := range cfg.Allow {
+		r.allow[strings.ToUpper(code)] = true
+	}
+	for _, code := range cfg.Deny {
+		r.deny[strings.ToUpper(code)] = true
+	}
+	return r
+}
+
+func (r rules) check(loc Location) string {
+	if r.deny[loc.Country] || r.deny[loc.Region] {
+		return ReasonDenied
+	}
+	if len(r.allow) > 0 && !r.allow[loc.Country] && !r.allow[loc.Region] {
+		return ReasonNotAllowed
+	}
+	return ""
+}
+
+// Policy decides which client locations may use the service
+type Policy struct {
+	defaults     rules
+	tenants      map[string]rules
+	blockUnknown bool
+	blocked      metric.Int64Counter
+}
+
+func NewPolicy(cfg config.GeoBlockConfig) *Policy {
+	p := &Policy{
+		defaults:     newRules(cfg.Default),
+		tenants:      make(map[string]rules, len(cfg.Tenants)),
+		blockUnknown: cfg.BlockUnknown,
+		blocked:      metrics.Int64Counter(metrics.GeoBlockedRequests),
+	}
+	for id, r := range cfg.Tenants {
+		p.tenants[id] = newRules(r)
+	}
+	return p
+}
+
+// Check returns why a client at loc is blocked, or "" when it is admitted.
+// known is false when the location could not be resolved. Tenants with
+// their own rules are checked against those instead of the defaults.
+func (p *Policy) Check(ctx context.Context, loc Location, known bool, tenant string) string {
+	var reason string
+	if !known {
+		if p.blockUnknown {
+			reason = ReasonUnknown
+		}
+	} else if r, ok := p.tenants[tenant]; ok {
+		reason = r.check(loc)
+	} else {
+		reason = p.defaults.check(loc)
+	}
+
+	if reason != "" {
+		country := loc.Country
+		if !known {
+			country = "unknown"
+		}
+		p.blocked.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("country", country),
+			attribute.String("reason", reason),
+		))
+	}
+	return reason
+}
@@ -0,0 +1,192 @@
+// Package health runs the dependency checks behind the readiness probe
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Report statuses
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+)
+
+// Check returns an error when a dependency cannot serve. It should honor
+// the context's deadline.
+type Check func(ctx context.Context) error
+
+// Reason is a check failure whose message is safe to serve
+type Reason string
+
+func (r Reason) Error() string {
+	return string(r)
+}
+
+// Result is the outcome of one check. Unless the check failed with a
+// Reason, Error is a summary; the full error is logged rather than served,
+// as it may name hosts or users.
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of all checks. Status is ok only when every check
+// passed and the server is not draining.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Ready reports whether traffic should be routed to the server
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// Registry holds the named checks. Checks run concurrently, each bounded
+// by the registry's timeout.
+type Registry struct {
+	timeout time.Duration
+	logger  *slog.Logger
+
+	mu        sync.RWMutex
+	names     []string
+	checks    map[string]Check
+	observers []func(Report)
+	// status is the last status observers were told of
+	status   string
+	draining atomic.Bool
+}
+
+func NewRegistry(timeout time.Duration, logger *slog.Logger) *Registry {
+	return &Registry{
+		timeout: timeout,
+		logger:  logger,
+		checks:  make(map[string]Check),
+	}
+}
+
+// Register adds a check, replacing any with the same name
+func (r *Registry) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.checks[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.checks[name] = check
+}
+
+// Observe registers fn to be called when the status changes: when a run
+// reports a different status than the last, and when draining starts.
+// fn is called synchronously and should not block.
+func (r *Registry) Observe(fn func(Report)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observers = append(r.observers, fn)
+}
+
+// Drain marks the server as shutting down. Reports stay unavailable from
+// then on, so load balancers stop routing to it before it stops accepting
+// connections.
+func (r *Registry) Drain() {
+	if !r.draining.Swap(true) {
+		r.observe(Report{Status: StatusDraining, Checks: map[string]Result{}})
+	}
+}
+
+// Run executes every check and reports the results
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	names := append([]string(nil), r.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = r.checks[name]
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, names[i], check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(names))}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	if r.draining.Load() {
+		report.Status = StatusDraining
+	}
+	r.observe(report)
+	return report
+}
+
+// observe tells the observers of report if its status changed. Once
+// draining, a run that began before is not reported as a change back.
+func (r *Registry) observe(report Report) {
+	r.mu.Lock()
+	if report.Status == r.status || (r.draining.Load() && report.Status != StatusDraining) {
+		r.mu.Unlock()
+		return
+	}
+	r.status = report.Status
+	observers := r.observers
+	r.mu.Unlock()
+
+	for _, fn := range observers {
+		fn(report)
+	}
+}
+
+func (r *Registry) run(ctx context.Context, name string, check Check) (result Result) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+		if p := recover(); p != nil {
+			r.logger.Error("health check panicked", "check", name, "panic", p)
+			result.Status, result.Error = StatusUnavailable, "check failed"
+		}
+	}()
+
+	err := check(ctx)
+	if err == nil {
+		return Result{Status: StatusOK}
+	}
+	r.logger.Warn("health check failed", "check", name, "error", err)
+	var reason Reason
+	switch {
+	case errors.As(err, &reason):
+		return Result{Status: StatusUnavailable, Error: string(reason)}
+	case errors.Is(err, context.DeadlineExceeded):
+		return Result{Status: StatusUnavailable, Error: fmt.Sprintf("timed out after %s", r.timeout)}
+	default:
+		return Result{Status: StatusUnavailable, Error: "check failed"}
+	}
+}
+
+// Ping checks that a connection can be acquired from pool and answers
+func Ping(pool *pgxpool.Pool) Check {
+	return func(ctx context.Context) error {
+		return pool.Ping(ctx)
+	}
+}
@@ -0,0 +1,218 @@
+// Package ics builds iCalendar (RFC 5545) invitations for the iTIP methods
+// email clients understand (RFC 5546)
+package ics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInvalidEvent means an event cannot be written as given
+var ErrInvalidEvent = errors.New("invalid calendar event")
+
+// iTIP methods. A request invites the attendees or updates an earlier
+// invitation with a lower sequence; a cancel withdraws it.
+const (
+	MethodPublish = "PUBLISH"
+	MethodRequest = "REQUEST"
+	MethodCancel  = "CANCEL"
+)
+
+// ContentType is the MIME type of a calendar with the given method
+func ContentType(method string) string {
+	return "text/calendar; method=" + method + "; charset=utf-8"
+}
+
+const prodID = "-//starterkit//Calendar Invites//EN"
+
+// Event is a single or recurring meeting. Start and End carry the event's
+// time zone, which recurrences follow across daylight saving changes.
+type Event struct {
+	UID string
+	// Sequence is raised for every update or cancellation so clients
+	// replace the copy they have
+	Sequence    int
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	Recurrence  *Recurrence
+	Organizer   mail.Address
+	Attendees   []mail.Address
+	Cancelled   bool
+	// Stamp is when this version was written, now when zero
+	Stamp time.Time
+}
+
+func (e Event) validate() error {
+	switch {
+	case e.UID == "":
+		return fmt.Errorf("%w: uid is required", ErrInvalidEvent)
+	case e.Summary == "":
+		return fmt.Errorf("%w: summary is required", ErrInvalidEvent)
+	case e.Organizer.Address == "":
+		return fmt.Errorf("%w: organizer is required", ErrInvalidEvent)
+	case !e.End.After(e.Start):
+		return fmt.Errorf("%w: end must be after start", ErrInvalidEvent)
+	case e.Start.Location().String() != e.End.Location().String():
+		return fmt.Errorf("%w: start and end must be in the same time zone", ErrInvalidEvent)
+	case e.Start.Location().String() == "Local":
+		return fmt.Errorf("%w: time zone must be named", ErrInvalidEvent)
+	}
+	if e.Recurrence != nil {
+		if err := e.Recurrence.Validate(); err != nil {
+			return err
+		}
+		if !e.Recurrence.Until.IsZero() && e.Recurrence.Until.Before(e.Start) {
+			return fmt.Errorf("%w: recurrence ends before the event starts", ErrInvalidEvent)
+		}
+	}
+	return nil
+}
+
+// Build writes a calendar holding e for the given method. Events outside
+// UTC carry a VTIMEZONE with the zone's transitions over the event's span,
+// so clients without a time zone database still place every occurrence.
+func Build(method string, e Event) ([]byte, error) {
+	if method != MethodPublish && method != MethodRequest && method != MethodCancel {
+		return nil, fmt.Errorf("%w: unsupported method %q", ErrInvalidEvent, method)
+	}
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+	stamp := e.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	var w writer
+	w.line("BEGIN:VCALENDAR")
+	w.prop("PRODID", prodID)
+	w.prop("VERSION", "2.0")
+	w.prop("CALSCALE", "GREGORIAN")
+	w.prop("METHOD", method)
+
+	loc := e.Start.Location()
+	if loc != time.UTC {
+		writeTimezone(&w, loc, e.Start, e.lastEnd())
+	}
+
+	w.line("BEGIN:VEVENT")
+	w.text("UID", e.UID)
+	w.prop("SEQUENCE", fmt.Sprint(e.Sequence))
+	w.prop("DTSTAMP", formatUTC(stamp))
+	w.time("DTSTART", e.Start)
+	w.time("DTEND", e.End)
+	if e.Recurrence != nil {
+		w.prop("RRULE", e.Recurrence.String())
+	}
+	w.text("SUMMARY", e.Summary)
+	if e.Description != "" {
+		w.text("DESCRIPTION", e.Description)
+	}
+	if e.Location != "" {
+		w.text("LOCATION", e.Location)
+	}
+	w.address("ORGANIZER", "", e.Organizer)
+	for _, a := range e.Attendees {
+		w.address("ATTENDEE", ";ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE", a)
+	}
+	if e.Cancelled {
+		w.prop("STATUS", "CANCELLED")
+	} else {
+		w.prop("STATUS", "CONFIRMED")
+	}
+	w.line("END:VEVENT")
+	w.line("END:VCALENDAR")
+	return w.buf.Bytes(), nil
+}
+
+// lastEnd is when the last occurrence ends, or the end of the time zone
+// span for recurrences without an end
+func (e Event) lastEnd() time.Time {
+	if e.Recurrence == nil {
+		return e.End
+	}
+	return e.Recurrence.end(e.Start).Add(e.End.Sub(e.Start))
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func formatLocal(t time.Time) string {
+	return t.Format("20060102T150405")
+}
+
+// writer writes content lines, folded at 75 octets
+type writer struct {
+	buf bytes.Buffer
+}
+
+func (w *writer) line(s string) {
+	limit := 75
+	for len(s) > limit {
+		// Fold between characters, never inside a UTF-8 sequence
+		n := limit
+		for !utf8.RuneStart(s[n]) {
+			n--
+		}
+		w.buf.WriteString(s[:n])
+		w.buf.WriteString("\r\n ")
+		s = s[n:]
+		// The leading space of a continuation counts toward its length
+		limit = 74
+	}
+	w.buf.WriteString(s)
+	w.buf.WriteString("\r\n")
+}
+
+func (w *writer) prop(name, value string) {
+	w.line(name + ":" + value)
+}
+
+func (w *writer) text(name, value string) {
+	w.prop(name, escapeText(value))
+}
+
+func (w *writer) time(name string, t time.Time) {
+	if t.Location() == time.UTC {
+		w.prop(name, formatUTC(t))
+		return
+	}
+	w.line(name + ";TZID=" + paramValue(t.Location().String()) + ":" + formatLocal(t))
+}
+
+func (w *writer) address(name, params string, a mail.Address) {
+	if a.Name != "" {
+		params = ";CN=" + paramValue(a.Name) + params
+	}
+	w.line(name + params + ":mailto:" + a.Address)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "")
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// paramValue quotes a parameter value when it holds separators. Quotes
+// and control characters cannot be escaped and are dropped.
+func paramValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '"' || r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	if strings.ContainsAny(s, ":;,") {
+		return `"` + s + `"`
+	}
+	return s
+}
@@ -0,0 +1,124 @@
+package ics
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequencies
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+	Yearly  = "yearly"
+)
+
+// maxSpan bounds the time zone transitions written for recurrences
+// without an end
+const maxSpan = 10 * 365 * 24 * time.Hour
+
+var weekdays = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// Recurrence repeats an event. Occurrences keep the local time of the
+// first one in the event's time zone. At most one of Count and Until is
+// set; without either the event repeats forever.
+type Recurrence struct {
+	Frequency string `json:"frequency"`
+	// Interval is the number of periods between occurrences, 1 when zero
+	Interval int       `json:"interval,omitempty"`
+	Count    int       `json:"count,omitempty"`
+	Until    time.Time `json:"until,omitzero"`
+	// ByDay lists weekdays as MO to SU. Monthly and yearly rules may prefix
+	// an ordinal, such as 1MO for the first Monday or -1FR for the last
+	// Friday.
+	ByDay []string `json:"by_day,omitempty"`
+}
+
+// Validate checks that the rule can be written
+func (r Recurrence) Validate() error {
+	switch r.Frequency {
+	case Daily, Weekly, Monthly, Yearly:
+	default:
+		return fmt.Errorf("%w: frequency must be daily, weekly, monthly, or yearly", ErrInvalidEvent)
+	}
+	if r.Interval < 0 || r.Count < 0 {
+		return fmt.Errorf("%w: interval and count must not be negative", ErrInvalidEvent)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("%w: recurrence takes a count or an end, not both", ErrInvalidEvent)
+	}
+	for _, day := range r.ByDay {
+		ordinal, weekday := splitDay(day)
+		if !slices.Contains(weekdays, weekday) {
+			return fmt.Errorf("%w: invalid weekday %q", ErrInvalidEvent, day)
+		}
+		if ordinal == "" {
+			continue
+		}
+		n, err := strconv.Atoi(ordinal)
+		if err != nil || n == 0 || n < -5 || n > 5 || (r.Frequency != Monthly && r.Frequency != Yearly) {
+			return fmt.Errorf("%w: invalid weekday %q", ErrInvalidEvent, day)
+		}
+	}
+	return nil
+}
+
+func splitDay(day string) (ordinal, weekday string) {
+	if len(day) < 2 {
+		return "", day
+	}
+	return day[:len(day)-2], day[len(day)-2:]
+}
+
+// String formats the rule as an RRULE value. UNTIL is written in UTC, as
+// RFC 5545 requires when the start has a time zone.
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + strings.ToUpper(r.Frequency)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(r.ByDay, ","))
+	}
+	switch {
+	case r.Count > 0:
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	case !r.Until.IsZero():
+		parts = append(parts, "UNTIL="+formatUTC(r.Until))
+	}
+	return strings.Join(parts, ";")
+}
+
+// end is a bound on the start of the last occurrence. Counted rules have
+// an occurrence in every period, except on dates some periods lack: the
+// 31st comes at least every two months and February 29 at least every
+// eight years.
+func (r Recurrence) end(start time.Time) time.Time {
+	limit := start.Add(maxSpan)
+	var last time.Time
+	switch {
+	case !r.Until.IsZero():
+		last = r.Until
+	case r.Count > 0:
+		n := r.Count * max(r.Interval, 1)
+		switch r.Frequency {
+		case Daily:
+			last = start.AddDate(0, 0, n)
+		case Weekly:
+			last = start.AddDate(0, 0, 7*n)
+		case Monthly:
+			last = start.AddDate(0, 2*n, 0)
+		default:
+			last = start.AddDate(8*n, 0, 0)
+		}
+	default:
+		return limit
+	}
+	if last.After(limit) {
+		return limit
+	}
+	return last
+}
@@ -0,0 +1,61 @@
+package ics
+
+import (
+	"fmt"
+	"time"
+)
+
+// writeTimezone writes loc as a VTIMEZONE with one observance for the zone
+// in effect at from and one for each transition up to until
+func writeTimezone(w *writer, loc *time.Location, from, until time.Time) {
+	w.line("BEGIN:VTIMEZONE")
+	w.prop("TZID", loc.String())
+
+	zoneStart, next := from.In(loc).ZoneBounds()
+	if zoneStart.IsZero() {
+		// The zone has always been in effect
+		zoneStart = from.In(loc)
+	}
+	writeObservance(w, zoneStart)
+	for !next.IsZero() && !next.After(until) {
+		writeObservance(w, next)
+		_, next = next.ZoneBounds()
+	}
+
+	w.line("END:VTIMEZONE")
+}
+
+// writeObservance writes the zone that starts at at. Its DTSTART is local
+// time in the offset before the transition.
+func writeObservance(w *writer, at time.Time) {
+	name, offset := at.Zone()
+	_, before := at.Add(-time.Second).Zone()
+
+	kind := "STANDARD"
+	if at.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	w.line("BEGIN:" + kind)
+	w.prop("DTSTART", formatLocal(at.In(time.FixedZone("", before))))
+	w.prop("TZOFFSETFROM", formatOffset(before))
+	w.prop("TZOFFSETTO", formatOffset(offset))
+	if name != "" {
+		w.text("TZNAME", name)
+	}
+	w.line("END:" + kind)
+}
+
+// formatOffset formats seconds east of UTC as ±hhmm, with seconds only
+// when the offset has them
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset/60%60)
+	if offset%60 != 0 {
+		s += fmt.Sprintf("%02d", offset%60)
+	}
+	return s
+}
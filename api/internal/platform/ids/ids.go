@@ -0,0 +1,140 @@
+// Package ids generates the 128-bit IDs of resources and requests. Random
+// UUIDs (v4) scatter inserts across a primary key index, so tables that
+// grow quickly use time-ordered IDs instead: UUIDv7 or ULID, which both
+// start with the creation time in milliseconds. New rows land at the end
+// of the index, keeping its hot pages in memory. Either is stored in a
+// uuid column; a ULID is only written differently.
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Formats of generated IDs
+const (
+	// UUID is a random UUID (v4)
+	UUID = "uuid"
+	// UUIDv7 is a time-ordered UUID (RFC 9562)
+	UUIDv7 = "uuidv7"
+	// ULID is a time-ordered ID written as 26 characters of Crockford
+	// base32
+	ULID = "ulid"
+)
+
+// Valid reports whether format is one Generator supports
+func Valid(format string) bool {
+	return format == UUID || format == UUIDv7 || format == ULID
+}
+
+// Generator makes IDs in one format
+type Generator struct {
+	format string
+}
+
+// NewGenerator returns a generator of format, one of UUID, UUIDv7, and
+// ULID. Configuration validates the format; others generate UUIDs.
+func NewGenerator(format string) Generator {
+	return Generator{format: format}
+}
+
+// New returns a new ID
+func (g Generator) New() uuid.UUID {
+	switch g.format {
+	case UUIDv7:
+		return NewV7()
+	case ULID:
+		return NewULID()
+	}
+	return uuid.New()
+}
+
+// NewPG returns a new ID as sqlc's generated code takes it
+func (g Generator) NewPG() pgtype.UUID {
+	return PG(g.New())
+}
+
+// String returns a new ID written in the generator's format
+func (g Generator) String() string {
+	id := g.New()
+	if g.format == ULID {
+		return FormatULID(id)
+	}
+	return id.String()
+}
+
+// NewV7 returns a new UUIDv7. New resources use it for their keys.
+func NewV7() uuid.UUID {
+	id, err := uuid.NewV7()
+	if err != nil {
+		// Only fails when the system's randomness does
+		panic(fmt.Sprintf("ids: failed to generate UUIDv7: %v", err))
+	}
+	return id
+}
+
+// NewULID returns a new ULID: 48 bits of Unix milliseconds followed by 80
+// random bits
+func NewULID() uuid.UUID {
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[:8], uint64(time.Now().UnixMilli())<<16)
+	rand.Read(id[6:])
+	return id
+}
+
+// PG converts id for sqlc's generated code
+func PG(id uuid.UUID) pgtype.UUID {
+	return pgtype.UUID{Bytes: id, Valid: true}
+}
+
+// Time returns when a UUIDv7 or ULID was generated. IDs of other formats
+// give meaningless times.
+func Time(id uuid.UUID) time.Time {
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(id[:8]) >> 16))
+}
+
+// crockford is the base32 alphabet of ULIDs, which leaves out I, L, O,
+// and U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var errInvalidULID = errors.New("invalid ULID")
+
+// FormatULID writes id as a ULID
+func FormatULID(id uuid.UUID) string {
+	hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// ParseULID reads a ULID written by FormatULID, in either case
+func ParseULID(s string) (uuid.UUID, error) {
+	// 26 characters hold 130 bits, so the first may only hold 3
+	if len(s) != 26 || s[0] > '7' {
+		return uuid.Nil, errInvalidULID
+	}
+	var hi, lo uint64
+	for _, c := range strings.ToUpper(s) {
+		v := strings.IndexRune(crockford, c)
+		if v < 0 {
+			return uuid.Nil, errInvalidULID
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[:8], hi)
+	binary.BigEndian.PutUint64(id[8:], lo)
+	return id, nil
+}
@@ -0,0 +1,154 @@
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Diff returns the patch turning the JSON document from into to
+func Diff(from, to []byte) (Patch, error) {
+	a, err := decode(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := decode(to)
+	if err != nil {
+		return nil, err
+	}
+	var ops Patch
+	diff(&ops, "", a, b)
+	return ops, nil
+}
+
+// decode keeps numbers as written, so they compare and patch exactly
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func diff(ops *Patch, path string, a, b any) {
+	switch a := a.(type) {
+	case map[string]any:
+		if b, ok := b.(map[string]any); ok {
+			diffObjects(ops, path, a, b)
+			return
+		}
+	case []any:
+		if b, ok := b.([]any); ok {
+			diffArrays(ops, path, a, b)
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*ops = append(*ops, Op{Op: OpReplace, Path: path, Value: b})
+	}
+}
+
+// diffObjects walks keys in order, so the same change patches the same way
+func diffObjects(ops *Patch, path string, a, b map[string]any) {
+	for _, k := range slices.Sorted(maps.Keys(a)) {
+		if _, ok := b[k]; !ok {
+			*ops = append(*ops, Op{Op: OpRemove, Path: path + "/" + escape(k)})
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(b)) {
+		av, ok := a[k]
+		if !ok {
+			*ops = append(*ops, Op{Op: OpAdd, Path: path + "/" + escape(k), Value: b[k]})
+			continue
+		}
+		diff(ops, path+"/"+escape(k), av, b[k])
+	}
+}
+
+// maxMatchCells bounds the table matching the items of two arrays; larger
+// arrays are diffed by position
+const maxMatchCells = 250_000
+
+// diffArrays patches the items between the common prefix and suffix of a
+// and b. Between them, items are matched by longest common subsequence, so
+// an item entering or leaving a page shifts the rest without patching them.
+func diffArrays(ops *Patch, path string, a, b []any) {
+	start := 0
+	for start < len(a) && start < len(b) && reflect.DeepEqual(a[start], b[start]) {
+		start++
+	}
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && reflect.DeepEqual(a[endA-1], b[endB-1]) {
+		endA--
+		endB--
+	}
+	a, b = a[start:endA], b[start:endB]
+	if (len(a)+1)*(len(b)+1) > maxMatchCells {
+		patchRun(ops, path, start, a, b)
+		return
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if reflect.DeepEqual(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// Walk the matches, patching the run of unmatched items before each.
+	// idx is where the run starts in the document as patched so far.
+	idx, i, j := start, 0, 0
+	runA, runB := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && reflect.DeepEqual(a[i], b[j]):
+			patchRun(ops, path, idx, a[runA:i], b[runB:j])
+			idx += j - runB + 1
+			i++
+			j++
+			runA, runB = i, j
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			j++
+		default:
+			i++
+		}
+	}
+	patchRun(ops, path, idx, a[runA:], b[runB:])
+}
+
+// patchRun replaces the items removed, starting at idx, with those added:
+// items at the same position are diffed, then surplus ones removed or
+// missing ones inserted
+func patchRun(ops *Patch, path string, idx int, removed, added []any) {
+	paired := min(len(removed), len(added))
+	for k := range paired {
+		diff(ops, path+"/"+strconv.Itoa(idx+k), removed[k], added[k])
+	}
+	// Each removal shifts the rest down onto the same index
+	for range len(removed) - paired {
+		*ops = append(*ops, Op{Op: OpRemove, Path: path + "/" + strconv.Itoa(idx+paired)})
+	}
+	for k := paired; k < len(added); k++ {
+		*ops = append(*ops, Op{Op: OpAdd, Path: path + "/" + strconv.Itoa(idx+k), Value: added[k]})
+	}
+}
+
+// escape encodes a key as a JSON Pointer reference token
+func escape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,448 @@
+// Package jsonpatch reads, applies, and computes JSON Patch (RFC 6902)
+// documents. Handlers accept patches next to whole replacements with
+// Requested and Decode, which only lets a patch touch the paths a route
+// allows.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"starterkit/internal/platform/request"
+)
+
+// MediaType is the content type of JSON Patch documents
+const MediaType = "application/json-patch+json"
+
+// Operations a patch may use
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+var (
+	// ErrInvalid is a patch that cannot be applied to the document, such as
+	// one removing a member that does not exist
+	ErrInvalid = errors.New("patch cannot be applied")
+	// ErrTestFailed is a patch whose test operation did not match the
+	// document
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// Op is one JSON Patch operation
+type Op struct {
+	Op    string `json:"op" doc:"Operation" enum:"add,remove,replace,move,copy,test"`
+	Path  string `json:"path" doc:"JSON Pointer to the value operated on"`
+	From  string `json:"from,omitempty" doc:"JSON Pointer to the value moved or copied"`
+	Value any    `json:"value" doc:"Value added, replaced with, or tested for"`
+}
+
+// MarshalJSON leaves out the value of operations that take none, as a null
+// value of the others is meaningful
+func (o Op) MarshalJSON() ([]byte, error) {
+	switch o.Op {
+	case OpRemove, OpMove, OpCopy:
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+			From string `json:"from,omitempty"`
+		}{o.Op, o.Path, o.From})
+	}
+	type op Op
+	return json.Marshal(op(o))
+}
+
+// Patch is a JSON Patch document, applied in order
+type Patch []Op
+
+// Requested reports whether the body of r is a JSON Patch
+func Requested(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == MediaType
+}
+
+// Decode reads a patch of at most request.MaxBodySize bytes from the body
+// of r. Every operation must be well-formed, and its path and from must be
+// one of paths or lie within one. Failures are returned as *request.Error.
+func Decode(w http.ResponseWriter, r *http.Request, paths ...string) (Patch, error) {
+	var raw []struct {
+		Op    string          `json:"op"`
+		Path  *string         `json:"path"`
+		From  *string         `json:"from"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := request.Decode(w, r, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, &request.Error{Status: http.StatusBadRequest, Message: "patch must have at least one operation"}
+	}
+
+	var v request.Validation
+	patch := make(Patch, len(raw))
+	for i, r := range raw {
+		field := "[" + strconv.Itoa(i) + "]"
+		patch[i].Op = r.Op
+		switch r.Op {
+		case OpAdd, OpReplace, OpTest:
+			if r.Value == nil {
+				v.Check(false, field+".value", "is required for "+r.Op)
+			} else if value, err := decode(r.Value); err == nil {
+				patch[i].Value = value
+			}
+		case OpMove, OpCopy:
+			if r.From == nil {
+				v.Check(false, field+".from", "is required for "+r.Op)
+			} else {
+				patch[i].From = *r.From
+				v.Check(allowed(*r.From, paths), field+".from", "must be one of "+strings.Join(paths, ", ")+" or within one")
+			}
+		case OpRemove:
+		default:
+			v.Check(false, field+".op", "must be one of add, remove, replace, move, copy, test")
+		}
+		if r.Path == nil {
+			v.Check(false, field+".path", "is required")
+		} else {
+			patch[i].Path = *r.Path
+			v.Check(allowed(*r.Path, paths), field+".path", "must be one of "+strings.Join(paths, ", ")+" or within one")
+		}
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	return patch, nil
+}
+
+// allowed reports whether path is a valid pointer to one of paths or to
+// something within one
+func allowed(path string, paths []string) bool {
+	if _, err := parsePointer(path); err != nil {
+		return false
+	}
+	return slices.ContainsFunc(paths, func(p string) bool {
+		return path == p || strings.HasPrefix(path, p+"/")
+	})
+}
+
+// ApplyTo applies the patch to what v points to, read and written through
+// its JSON form. v is left unchanged if the patch cannot be applied.
+func (p Patch) ApplyTo(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	doc, err := decode(data)
+	if err != nil {
+		return err
+	}
+	if doc, err = p.Apply(doc); err != nil {
+		return err
+	}
+	if data, err = json.Marshal(doc); err != nil {
+		return err
+	}
+
+	// A patch may leave a document that no longer fits v
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	next := reflect.New(reflect.TypeOf(v).Elem())
+	if err := dec.Decode(next.Interface()); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalid, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	reflect.ValueOf(v).Elem().Set(next.Elem())
+	return nil
+}
+
+// Apply applies the patch to doc, a document decoded from JSON, and
+// returns the result. doc may be modified even if the patch fails.
+func (p Patch) Apply(doc any) (any, error) {
+	for i, op := range p {
+		var err error
+		if doc, err = op.apply(doc); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func (o Op) apply(doc any) (any, error) {
+	path, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch o.Op {
+	case OpAdd:
+		return add(doc, path, clone(o.Value))
+	case OpRemove:
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case OpReplace:
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return clone(o.Value), nil
+		}
+		return update(doc, path, func(node any, key string) (any, error) {
+			return replaceAt(node, key, clone(o.Value))
+		})
+	case OpMove:
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(o.Path+"/", o.From+"/") && o.Path != o.From {
+			return nil, fmt.Errorf("%w: cannot move %s into itself", ErrInvalid, o.From)
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case OpCopy:
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, clone(value))
+	case OpTest:
+		value, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, o.Value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalid, o.Op)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped
+// reference tokens
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalid, p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		for j := 0; j < len(t); j++ {
+			if t[j] == '~' && (j+1 == len(t) || (t[j+1] != '0' && t[j+1] != '1')) {
+				return nil, fmt.Errorf("%w: pointer %q has an invalid escape", ErrInvalid, p)
+			}
+		}
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+// get returns the value path points to
+func get(doc any, path []string) (any, error) {
+	for _, key := range path {
+		var err error
+		if doc, err = child(doc, key); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// add inserts value at path, returning the document it is part of
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(node any, key string) (any, error) {
+		return addAt(node, key, value)
+	})
+}
+
+// remove takes out the value at path, returning the document left and the
+// value removed
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalid)
+	}
+	var removed any
+	doc, err := update(doc, path, func(node any, key string) (any, error) {
+		var err error
+		node, removed, err = removeAt(node, key)
+		return node, err
+	})
+	return doc, removed, err
+}
+
+// update replaces the container holding the last token of path with what
+// fn makes of it. Arrays grow and shrink by reallocation, so each
+// container on the way is stored back into its parent.
+func update(doc any, path []string, fn func(node any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	next, err := child(doc, path[0])
+	if err != nil {
+		return nil, err
+	}
+	if next, err = update(next, path[1:], fn); err != nil {
+		return nil, err
+	}
+	return replaceAt(doc, path[0], next)
+}
+
+func child(node any, key string) (any, error) {
+	switch n := node.(type) {
+	case map[string]any:
+		v, ok := n[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q does not exist", ErrInvalid, key)
+		}
+		return v, nil
+	case []any:
+		i, err := index(key, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		return n[i], nil
+	}
+	return nil, fmt.Errorf("%w: %q is not within an object or array", ErrInvalid, key)
+}
+
+func addAt(node any, key string, value any) (any, error) {
+	switch n := node.(type) {
+	case map[string]any:
+		n[key] = value
+		return n, nil
+	case []any:
+		if key == "-" {
+			return append(n, value), nil
+		}
+		i, err := index(key, len(n))
+		if err != nil {
+			return nil, err
+		}
+		return slices.Insert(n, i, value), nil
+	}
+	return nil, fmt.Errorf("%w: %q is not within an object or array", ErrInvalid, key)
+}
+
+func replaceAt(node any, key string, value any) (any, error) {
+	switch n := node.(type) {
+	case map[string]any:
+		if _, ok := n[key]; !ok {
+			return nil, fmt.Errorf("%w: member %q does not exist", ErrInvalid, key)
+		}
+		n[key] = value
+		return n, nil
+	case []any:
+		i, err := index(key, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = value
+		return n, nil
+	}
+	return nil, fmt.Errorf("%w: %q is not within an object or array", ErrInvalid, key)
+}
+
+func removeAt(node any, key string) (any, any, error) {
+	switch n := node.(type) {
+	case map[string]any:
+		v, ok := n[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: member %q does not exist", ErrInvalid, key)
+		}
+		delete(n, key)
+		return n, v, nil
+	case []any:
+		i, err := index(key, len(n)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Read the value first: Delete shifts the elements after it down
+		v := n[i]
+		return slices.Delete(n, i, i+1), v, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %q is not within an object or array", ErrInvalid, key)
+}
+
+// index parses an array index of at most last. Leading zeros are not
+// allowed by RFC 6901.
+func index(key string, last int) (int, error) {
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 || (len(key) > 1 && key[0] == '0') {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrInvalid, key)
+	}
+	if i > last {
+		return 0, fmt.Errorf("%w: index %d is out of range", ErrInvalid, i)
+	}
+	return i, nil
+}
+
+// clone copies a decoded value, so values added by a patch do not share
+// containers with the patch or with each other
+func clone(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for k, e := range v {
+			c[k] = clone(e)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, e := range v {
+			c[i] = clone(e)
+		}
+		return c
+	}
+	return v
+}
+
+// equal compares decoded values as JSON does, so 1 and 1.0 are equal
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			if w, ok := b[k]; !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		return ok && slices.EqualFunc(a, b, equal)
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	}
+	return a == b
+}
@@ -0,0 +1,100 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   error
+	}{
+		{"add member", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`, nil},
+		{"add replaces member", `{"a":1}`, `[{"op":"add","path":"/a","value":2}]`, `{"a":2}`, nil},
+		{"add first element", `["a","b"]`, `[{"op":"add","path":"/0","value":"x"}]`, `["x","a","b"]`, nil},
+		{"add at end index", `["a","b"]`, `[{"op":"add","path":"/2","value":"x"}]`, `["a","b","x"]`, nil},
+		{"add with dash", `["a","b"]`, `[{"op":"add","path":"/-","value":"x"}]`, `["a","b","x"]`, nil},
+		{"add past end", `["a","b"]`, `[{"op":"add","path":"/3","value":"x"}]`, ``, ErrInvalid},
+		{"add nested", `{"a":{"b":[1]}}`, `[{"op":"add","path":"/a/b/0","value":0}]`, `{"a":{"b":[0,1]}}`, nil},
+
+		{"remove member", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`, nil},
+		{"remove missing member", `{"a":1}`, `[{"op":"remove","path":"/b"}]`, ``, ErrInvalid},
+		{"remove first element", `["a","b","c"]`, `[{"op":"remove","path":"/0"}]`, `["b","c"]`, nil},
+		{"remove last element", `["a","b","c"]`, `[{"op":"remove","path":"/2"}]`, `["a","b"]`, nil},
+		{"remove past end", `["a"]`, `[{"op":"remove","path":"/1"}]`, ``, ErrInvalid},
+
+		{"replace member", `{"a":1}`, `[{"op":"replace","path":"/a","value":"x"}]`, `{"a":"x"}`, nil},
+		{"replace missing member", `{"a":1}`, `[{"op":"replace","path":"/b","value":"x"}]`, ``, ErrInvalid},
+		{"replace first element", `["a","b"]`, `[{"op":"replace","path":"/0","value":"x"}]`, `["x","b"]`, nil},
+		{"replace last element", `["a","b"]`, `[{"op":"replace","path":"/1","value":"x"}]`, `["a","x"]`, nil},
+		{"replace document", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`, nil},
+
+		{"move member", `{"a":1}`, `[{"op":"move","from":"/a","path":"/b"}]`, `{"b":1}`, nil},
+		{"move first element", `["a","b","c"]`, `[{"op":"move","from":"/0","path":"/-"}]`, `["b","c","a"]`, nil},
+		{"move last element", `["a","b","c"]`, `[{"op":"move","from":"/2","path":"/0"}]`, `["c","a","b"]`, nil},
+		{"move element to object", `{"l":["a","b"],"o":{}}`, `[{"op":"move","from":"/l/0","path":"/o/x"}]`, `{"l":["b"],"o":{"x":"a"}}`, nil},
+		{"move into itself", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, ``, ErrInvalid},
+
+		{"copy member", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`, nil},
+		{"copy first element", `["a","b"]`, `[{"op":"copy","from":"/0","path":"/-"}]`, `["a","b","a"]`, nil},
+		{"copy last element", `["a","b"]`, `[{"op":"copy","from":"/1","path":"/0"}]`, `["b","a","b"]`, nil},
+		{"copy is not shared", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`, nil},
+
+		{"test member", `{"a":1}`, `[{"op":"test","path":"/a","value":1.0}]`, `{"a":1}`, nil},
+		{"test member fails", `{"a":1}`, `[{"op":"test","path":"/a","value":2}]`, ``, ErrTestFailed},
+		{"test first element", `["a","b"]`, `[{"op":"test","path":"/0","value":"a"}]`, `["a","b"]`, nil},
+		{"test last element", `["a","b"]`, `[{"op":"test","path":"/1","value":"b"}]`, `["a","b"]`, nil},
+		{"test past end", `["a","b"]`, `[{"op":"test","path":"/2","value":"b"}]`, ``, ErrInvalid},
+
+		{"escaped pointer", `{"a/b":1,"c~d":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/c~0d"}]`, `{}`, nil},
+		{"leading zero index", `["a","b"]`, `[{"op":"remove","path":"/01"}]`, ``, ErrInvalid},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := decode([]byte(tc.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var patch Patch
+			if err := json.Unmarshal([]byte(tc.patch), &patch); err != nil {
+				t.Fatal(err)
+			}
+			for i := range patch {
+				if patch[i].Value, err = decode(mustMarshal(t, patch[i].Value)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := patch.Apply(doc)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("error = %v, want %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := decode([]byte(tc.want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equal(got, want) {
+				t.Errorf("got %s, want %s", mustMarshal(t, got), tc.want)
+			}
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
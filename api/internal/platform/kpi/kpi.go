@@ -0,0 +1,60 @@
+// Package kpi records business metrics: the product numbers, such as
+// signups and email sent, that dashboards outside engineering follow.
+// Modules call its functions where the event happens; the instruments are
+// defined in package metrics and exported under the business namespace.
+//
+// Nothing is recorded for dry runs, which do not really happen.
+package kpi
+
+import (
+	"context"
+	"sync"
+
+	"starterkit/internal/platform/dryrun"
+	"starterkit/internal/platform/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	signups        = sync.OnceValue(func() metric.Int64Counter { return metrics.Int64Counter(metrics.BusinessSignups) })
+	activeSessions = sync.OnceValue(func() metric.Int64Gauge { return metrics.Int64Gauge(metrics.BusinessActiveSessions) })
+	jobsProcessed  = sync.OnceValue(func() metric.Int64Counter { return metrics.Int64Counter(metrics.BusinessJobsProcessed) })
+	emailsSent     = sync.OnceValue(func() metric.Int64Counter { return metrics.Int64Counter(metrics.BusinessEmailsSent) })
+)
+
+// Signup records a new user
+func Signup(ctx context.Context) {
+	if dryrun.Enabled(ctx) {
+		return
+	}
+	signups().Add(ctx, 1)
+}
+
+// ActiveSessions reports how many sessions have been seen recently. Only
+// the leader reports it, from a count across replicas.
+func ActiveSessions(ctx context.Context, n int64) {
+	activeSessions().Record(ctx, n)
+}
+
+// JobProcessed records a background job that finished, successfully or
+// for the last time
+func JobProcessed(ctx context.Context, kind string, succeeded bool) {
+	result := "succeeded"
+	if !succeeded {
+		result = "failed"
+	}
+	jobsProcessed().Add(ctx, 1, metric.WithAttributes(
+		attribute.String("kind", kind),
+		attribute.String("result", result),
+	))
+}
+
+// EmailSent records an email handed to a provider
+func EmailSent(ctx context.Context) {
+	if dryrun.Enabled(ctx) {
+		return
+	}
+	emailsSent().Add(ctx, 1)
+}
@@ -0,0 +1,246 @@
+// Package legacyapi serves endpoints of a legacy API from the current
+// handlers. A legacy request is translated into a request to the current
+// route: its path is rewritten, and query parameters and the members of
+// JSON bodies are renamed. Response members are renamed back on the way
+// out.
+package legacyapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/request"
+)
+
+// defaultPerPage is the page size of paged requests that give none, the
+// default limit of the current list routes
+const defaultPerPage = 20
+
+// Endpoint is a legacy endpoint and the current route serving it
+type Endpoint struct {
+	// Pattern is the legacy route, such as "GET /api/users/{id}"
+	Pattern string
+	// Target is the current path serving it, such as "/api/v1/users/{id}";
+	// its wildcards are filled from Pattern's
+	Target string
+	// Query renames query parameters, legacy name to current
+	Query map[string]string
+	// Paged translates page and per_page into limit and offset
+	Paged bool
+	// Fields renames the members of JSON objects in request and response
+	// bodies at any depth, legacy name to current
+	Fields map[string]string
+}
+
+// Handler serves e by translating its requests for next, the router holding
+// the current routes
+func Handler(e Endpoint, next http.Handler) http.Handler {
+	// Responses are renamed the other way
+	outgoing := make(map[string]string, len(e.Fields))
+	for legacy, current := range e.Fields {
+		outgoing[current] = legacy
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := e.translate(w, r)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+		if len(outgoing) == 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		rec := &recorder{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(rec, req)
+		for name, values := range rec.header {
+			w.Header()[name] = values
+		}
+		body := rec.body.Bytes()
+		if isJSON(rec.header.Get("Content-Type")) {
+			if renamed, err := renameBody(body, outgoing); err == nil {
+				body = renamed
+				w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			}
+		}
+		w.WriteHeader(rec.status)
+		_, _ = w.Write(body)
+	})
+}
+
+// translate returns r as a request to the current route
+func (e Endpoint) translate(w http.ResponseWriter, r *http.Request) (*http.Request, error) {
+	path, err := e.path(r)
+	if err != nil {
+		return nil, err
+	}
+	query, err := e.query(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	req := r.Clone(r.Context())
+	req.URL.Path, req.URL.RawPath = path, ""
+	req.URL.RawQuery = query.Encode()
+	req.RequestURI = req.URL.RequestURI()
+
+	if len(e.Fields) > 0 && r.Body != nil && isJSON(r.Header.Get("Content-Type")) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, request.MaxBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return nil, apierror.New(http.StatusRequestEntityTooLarge, "body_too_large", fmt.Sprintf("request body must be at most %d bytes", maxBytesErr.Limit))
+			}
+			return nil, apierror.BadRequest("invalid_body", "failed to read request body")
+		}
+		// Bodies that are not JSON go through as they are, for the current
+		// handler to refuse
+		if renamed, err := renameBody(body, e.Fields); err == nil {
+			body = renamed
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	return req, nil
+}
+
+// path fills the wildcards of the target path from the legacy request
+func (e Endpoint) path(r *http.Request) (string, error) {
+	segments := strings.Split(e.Target, "/")
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, "{")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSuffix(name, "}")
+		// An escaped slash would move the request to another route
+		value := r.PathValue(name)
+		if value == "" || strings.Contains(value, "/") {
+			return "", apierror.NotFound("not_found", "no route matches "+r.URL.Path)
+		}
+		segments[i] = value
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// query renames the legacy query parameters, and turns pages into limit
+// and offset
+func (e Endpoint) query(legacy url.Values) (url.Values, error) {
+	query := make(url.Values, len(legacy))
+	for name, values := range legacy {
+		if current, ok := e.Query[name]; ok {
+			name = current
+		}
+		query[name] = values
+	}
+	if !e.Paged {
+		return query, nil
+	}
+
+	page, err := positive(query, "page", 1)
+	if err != nil {
+		return nil, err
+	}
+	perPage, err := positive(query, "per_page", defaultPerPage)
+	if err != nil {
+		return nil, err
+	}
+	query.Del("page")
+	query.Del("per_page")
+	query.Set("limit", strconv.Itoa(perPage))
+	query.Set("offset", strconv.Itoa((page-1)*perPage))
+	return query, nil
+}
+
+// positive returns the named parameter, which must be a positive integer
+// when given
+func positive(query url.Values, name string, defaultValue int) (int, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, apierror.BadRequest("invalid_parameter", name+" must be a positive integer")
+	}
+	return n, nil
+}
+
+// renameBody renames the members of every object in a JSON document
+func renameBody(body []byte, names map[string]string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(rename(doc, names)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func rename(v any, names map[string]string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		renamed := make(map[string]any, len(v))
+		for name, member := range v {
+			if to, ok := names[name]; ok {
+				name = to
+			}
+			renamed[name] = rename(member, names)
+		}
+		return renamed
+	case []any:
+		for i, item := range v {
+			v[i] = rename(item, names)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// isJSON reports whether a content type is JSON, including problem+json
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || mediaType == "application/problem+json")
+}
+
+// recorder holds the current route's response so its members can be
+// renamed before it is written
+type recorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *recorder) Header() http.Header { return rec.header }
+
+func (rec *recorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.status = code
+		rec.wroteHeader = true
+	}
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.body.Write(b)
+}
@@ -0,0 +1,119 @@
+// Package lifecycle runs the work done when the server starts and stops,
+// such as warming caches before requests are served and flushing buffers
+// once they no longer are. Hooks are run in order, each bounded by its
+// timeout, and stop in reverse.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// Orders of the hooks main registers. Hooks of modules default to 0, so
+// they start after telemetry and the components they use, and stop before
+// them.
+const (
+	OrderTelemetry  = -200
+	OrderComponents = -100
+)
+
+// Hook is work run when the server starts, stops, or both
+type Hook struct {
+	// Name identifies the hook in logs and errors
+	Name string
+	// Order sorts hooks: the lowest start first and stop last. Hooks of
+	// the same order run in the order they were appended.
+	Order int
+	// Timeout bounds each of OnStart and OnStop; 0 leaves them bounded by
+	// the context they are given
+	Timeout time.Duration
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Manager runs the hooks appended to it
+type Manager struct {
+	logger *slog.Logger
+	hooks  []Hook
+	// started are the hooks whose OnStart succeeded, or that have none,
+	// in the order they started
+	started []Hook
+}
+
+func New(logger *slog.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Append adds h to the hooks run by Start and Stop. It is meant to be
+// called before Start.
+func (m *Manager) Append(h Hook) {
+	m.hooks = append(m.hooks, h)
+}
+
+// Start runs the OnStart of every hook in order. When one fails, the hooks
+// started before it are stopped and its error returned.
+func (m *Manager) Start(ctx context.Context) error {
+	hooks := slices.Clone(m.hooks)
+	slices.SortStableFunc(hooks, func(a, b Hook) int {
+		return a.Order - b.Order
+	})
+
+	for _, h := range hooks {
+		if h.OnStart != nil {
+			start := time.Now()
+			if err := run(ctx, h.Timeout, h.OnStart); err != nil {
+				err = fmt.Errorf("failed to start %s: %w", h.Name, err)
+				if stopErr := m.Stop(ctx); stopErr != nil {
+					err = errors.Join(err, stopErr)
+				}
+				return err
+			}
+			m.logger.Debug("started", "hook", h.Name, "duration_ms", time.Since(start).Milliseconds())
+		}
+		m.started = append(m.started, h)
+	}
+	return nil
+}
+
+// Stop runs the OnStop of the started hooks, last started first. Every
+// hook is stopped even when others fail; their failures are returned
+// together.
+func (m *Manager) Stop(ctx context.Context) error {
+	var errs []error
+	for i := len(m.started) - 1; i >= 0; i-- {
+		h := m.started[i]
+		if h.OnStop == nil {
+			continue
+		}
+		if err := run(ctx, h.Timeout, h.OnStop); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", h.Name, err))
+		}
+	}
+	m.started = nil
+	return errors.Join(errs...)
+}
+
+// run calls fn with ctx bounded by timeout, returning once fn does or the
+// timeout expires, whether or not fn heeds it
+func run(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,304 @@
+// Package listing serves list endpoints. A route declares its Spec: page
+// sizes, the filters it takes, and the orders it can be sorted in. The
+// query string is read and checked against it before the handler runs, and
+// Serve answers with a page of what the handler's query function returns,
+// a cursor to the next page, and a total when asked for one.
+package listing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"starterkit/internal/openapi"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/fieldaccess"
+)
+
+// Page size bounds of specs that set none
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// Spec declares the query parameters of a list endpoint
+type Spec struct {
+	// Items names the envelope member holding the page's items, such as
+	// "flags"
+	Items string
+	// DefaultLimit and MaxLimit bound the page size; larger limits are
+	// lowered to MaxLimit
+	DefaultLimit int
+	MaxLimit     int
+	Filters      []Filter
+	// Sorts are the orders the list can be sorted in, such as
+	// "created_at" and "-created_at" for newest first. The first is the
+	// default; without any the list has one fixed order.
+	Sorts []string
+}
+
+// Filter is a query parameter narrowing the list
+type Filter struct {
+	Name        string
+	Description string
+	// Values are the values allowed; nil allows any
+	Values []string
+	// Default applies when the parameter is not given
+	Default string
+}
+
+// Query is what a request asks of a list endpoint, checked against its
+// Spec
+type Query struct {
+	Limit  int
+	Offset int
+	// Sort is one of the Spec's Sorts, or empty when it has none
+	Sort    string
+	Filters map[string]string
+	// Count asks for the number of items the filters match
+	Count bool
+}
+
+// Filter returns the value of the named filter, or its default
+func (q Query) Filter(name string) string {
+	return q.Filters[name]
+}
+
+func (s Spec) limits() (def, max int) {
+	def, max = s.DefaultLimit, s.MaxLimit
+	if max <= 0 {
+		max = maxLimit
+	}
+	if def <= 0 {
+		def = min(defaultLimit, max)
+	}
+	return def, max
+}
+
+// Parse reads a query from values, refusing parameters that do not follow
+// s with a 400 problem. Parameters s does not name are left alone.
+func (s Spec) Parse(values url.Values) (Query, error) {
+	def, max := s.limits()
+	q := Query{Limit: def, Filters: make(map[string]string, len(s.Filters))}
+
+	if raw := values.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return Query{}, apierror.BadRequest("invalid_parameter", "limit must be a non-negative integer")
+		}
+		if n > 0 {
+			q.Limit = min(n, max)
+		}
+	}
+
+	for _, f := range s.Filters {
+		value := values.Get(f.Name)
+		if value == "" {
+			value = f.Default
+		}
+		if value != "" && f.Values != nil && !slices.Contains(f.Values, value) {
+			return Query{}, apierror.BadRequest("invalid_parameter", fmt.Sprintf("%s must be one of %s", f.Name, strings.Join(f.Values, ", ")))
+		}
+		q.Filters[f.Name] = value
+	}
+
+	if len(s.Sorts) > 0 {
+		q.Sort = s.Sorts[0]
+		if raw := values.Get("sort"); raw != "" {
+			if !slices.Contains(s.Sorts, raw) {
+				return Query{}, apierror.BadRequest("invalid_parameter", "sort must be one of "+strings.Join(s.Sorts, ", "))
+			}
+			q.Sort = raw
+		}
+	}
+
+	if raw := values.Get("count"); raw != "" {
+		count, err := strconv.ParseBool(raw)
+		if err != nil {
+			return Query{}, apierror.BadRequest("invalid_parameter", "count must be true or false")
+		}
+		q.Count = count
+	}
+
+	cursor, offset := values.Get("cursor"), values.Get("offset")
+	switch {
+	case cursor != "" && offset != "":
+		return Query{}, apierror.BadRequest("invalid_parameter", "cursor and offset cannot be combined")
+	case cursor != "":
+		n, err := q.decodeCursor(cursor)
+		if err != nil {
+			return Query{}, apierror.BadRequest("invalid_cursor", "cursor is malformed or belongs to a list with other filters or sort order")
+		}
+		q.Offset = n
+	case offset != "":
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return Query{}, apierror.BadRequest("invalid_parameter", "offset must be a non-negative integer")
+		}
+		q.Offset = n
+	}
+	return q, nil
+}
+
+// cursor is the encoded position of a page. Key fingerprints the filters
+// and sort order it was issued for, so it cannot page through another
+// list.
+type cursor struct {
+	Offset int    `json:"o"`
+	Key    uint32 `json:"k"`
+}
+
+// key fingerprints the filters and sort order of q
+func (q Query) key() uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(q.Sort))
+	for _, name := range slices.Sorted(maps.Keys(q.Filters)) {
+		h.Write([]byte{0})
+		h.Write([]byte(name + "=" + q.Filters[name]))
+	}
+	return h.Sum32()
+}
+
+func (q Query) encodeCursor(offset int) string {
+	data, _ := json.Marshal(cursor{Offset: offset, Key: q.key()})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (q Query) decodeCursor(raw string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return 0, err
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return 0, err
+	}
+	if c.Offset < 0 || c.Key != q.key() {
+		return 0, fmt.Errorf("cursor does not match the list")
+	}
+	return c.Offset, nil
+}
+
+// Parameters describes the query parameters of s for the OpenAPI document
+func (s Spec) Parameters() []openapi.Parameter {
+	def, max := s.limits()
+	params := []openapi.Parameter{
+		openapi.QueryParam("limit", fmt.Sprintf("Number of items to return (max %d)", max), openapi.Integer(1, max, def)),
+		openapi.QueryParam("cursor", "next_cursor of the previous page; pages follow the filters and sort order the cursor was issued for", openapi.String("")),
+		openapi.QueryParam("offset", "Number of items to skip, instead of a cursor", &openapi.Schema{Type: "integer", Minimum: new(float64), Default: 0}),
+	}
+	for _, f := range s.Filters {
+		schema := openapi.String("")
+		for _, v := range f.Values {
+			schema.Enum = append(schema.Enum, v)
+		}
+		if f.Default != "" {
+			schema.Default = f.Default
+		}
+		params = append(params, openapi.QueryParam(f.Name, f.Description, schema))
+	}
+	if len(s.Sorts) > 0 {
+		schema := &openapi.Schema{Type: "string", Default: s.Sorts[0]}
+		for _, sort := range s.Sorts {
+			schema.Enum = append(schema.Enum, sort)
+		}
+		params = append(params, openapi.QueryParam("sort", "Order of the items; a leading - sorts descending", schema))
+	}
+	return append(params, openapi.QueryParam("count", "Include the total number of matching items", &openapi.Schema{Type: "boolean", Default: false}))
+}
+
+type contextKey struct{}
+
+// Middleware reads the query of a request to a list endpoint declared by
+// spec, for Serve, and turns away requests that do not follow it
+func Middleware(spec Spec, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q, err := spec.Parse(r.URL.Query())
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+		ctx := context.WithValue(r.Context(), contextKey{}, request{spec: spec, query: q})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type request struct {
+	spec  Spec
+	query Query
+}
+
+// FromContext returns the query Middleware read
+func FromContext(ctx context.Context) (Query, bool) {
+	req, ok := ctx.Value(contextKey{}).(request)
+	return req.query, ok
+}
+
+// Source fetches the items of a list endpoint
+type Source[T any] struct {
+	// List returns up to q.Limit items from q.Offset on, filtered and
+	// sorted as q says. It is asked for one item more than the page holds,
+	// which tells whether another page follows.
+	List func(ctx context.Context, q Query) ([]T, error)
+	// Count returns the number of items q's filters match. Lists without
+	// one refuse ?count=true.
+	Count func(ctx context.Context, q Query) (int64, error)
+}
+
+// Serve answers a request to a list endpoint from source, with the page's
+// items under the Spec's Items name, its limit and offset, next_cursor
+// when another page follows, and total when asked for. The route must
+// declare a Spec, whose query Middleware reads.
+func Serve[T any](w http.ResponseWriter, r *http.Request, source Source[T]) {
+	req, ok := r.Context().Value(contextKey{}).(request)
+	if !ok {
+		apierror.Write(w, r, fmt.Errorf("list route %s declares no listing spec", r.URL.Path))
+		return
+	}
+	q := req.query
+	if q.Count && source.Count == nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "this list cannot be counted"))
+		return
+	}
+
+	fetch := q
+	fetch.Limit++
+	items, err := source.List(r.Context(), fetch)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	page := map[string]any{"limit": q.Limit, "offset": q.Offset}
+	if len(items) > q.Limit {
+		items = items[:q.Limit]
+		page["next_cursor"] = q.encodeCursor(q.Offset + q.Limit)
+	}
+	if items == nil {
+		items = []T{}
+	}
+	name := req.spec.Items
+	if name == "" {
+		name = "items"
+	}
+	page[name] = fieldaccess.Apply(r.Context(), items)
+	if q.Count {
+		total, err := source.Count(r.Context(), q)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+		page["total"] = total
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(page)
+}
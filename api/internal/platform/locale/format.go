@@ -0,0 +1,68 @@
+package locale
+
+import (
+	"context"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Formatter renders numeric values according to request preferences
+type Formatter struct {
+	prefs   Preferences
+	printer *message.Printer
+}
+
+// NewFormatter creates a formatter for the given preferences
+func NewFormatter(prefs Preferences) *Formatter {
+	return &Formatter{
+		prefs:   prefs,
+		printer: message.NewPrinter(prefs.Locale),
+	}
+}
+
+// FormatterFromContext creates a formatter for the request preferences in ctx
+func FormatterFromContext(ctx context.Context) *Formatter {
+	return NewFormatter(FromContext(ctx))
+}
+
+// Number formats a number with locale-specific grouping and decimal separators
+func (f *Formatter) Number(v float64, decimals int) string {
+	return f.printer.Sprint(number.Decimal(v, number.MaxFractionDigits(decimals), number.MinFractionDigits(decimals)))
+}
+
+// Integer formats an integer with locale-specific grouping
+func (f *Formatter) Integer(v int64) string {
+	return f.printer.Sprint(number.Decimal(v))
+}
+
+// Percent formats a ratio (0.25 = 25%)
+func (f *Formatter) Percent(ratio float64, decimals int) string {
+	return f.printer.Sprint(number.Percent(ratio, number.MaxFractionDigits(decimals)))
+}
+
+// Money formats an amount given in minor units (e.g. cents) of the currency.
+// If cur is the zero value the caller's preferred currency is used.
+func (f *Formatter) Money(minorUnits int64, cur currency.Unit) string {
+	if cur == (currency.Unit{}) {
+		cur = f.prefs.Currency
+	}
+	scale, _ := currency.Standard.Rounding(cur)
+	amount := float64(minorUnits)
+	for range scale {
+		amount /= 10
+	}
+	return f.printer.Sprint(currency.Symbol(cur.Amount(amount)))
+}
+
+// Distance formats a distance given in meters in the caller's measurement system
+func (f *Formatter) Distance(meters float64) string {
+	if f.prefs.Units == UnitsImperial {
+		return f.Number(meters/1609.344, 2) + " mi"
+	}
+	if meters < 1000 {
+		return f.Number(meters, 0) + " m"
+	}
+	return f.Number(meters/1000, 2) + " km"
+}
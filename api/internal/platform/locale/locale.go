@@ -0,0 +1,66 @@
+package locale
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+)
+
+// Measurement systems
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// Preferences describe how values should be presented to the caller
+type Preferences struct {
+	Locale   language.Tag
+	Currency currency.Unit
+	Units    string
+}
+
+type contextKey string
+
+const preferencesKey contextKey = "locale_preferences"
+
+// WithContext adds preferences to the context
+func WithContext(ctx context.Context, prefs Preferences) context.Context {
+	return context.WithValue(ctx, preferencesKey, prefs)
+}
+
+// FromContext extracts preferences from context, falling back to en-US/USD/metric
+func FromContext(ctx context.Context) Preferences {
+	if prefs, ok := ctx.Value(preferencesKey).(Preferences); ok {
+		return prefs
+	}
+	return Preferences{
+		Locale:   language.AmericanEnglish,
+		Currency: currency.USD,
+		Units:    UnitsMetric,
+	}
+}
+
+// ParseUnits validates a measurement system name
+func ParseUnits(s string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case UnitsMetric:
+		return UnitsMetric, true
+	case UnitsImperial:
+		return UnitsImperial, true
+	default:
+		return "", false
+	}
+}
+
+// DefaultUnits returns the customary measurement system for a locale
+func DefaultUnits(tag language.Tag) string {
+	region, _ := tag.Region()
+	switch region.String() {
+	case "US", "LR", "MM":
+		return UnitsImperial
+	default:
+		return UnitsMetric
+	}
+}
@@ -0,0 +1,127 @@
+package locale
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+)
+
+// ProfileLookup returns stored preferences for the caller, if any. Fields
+// left at their zero value are resolved from headers and defaults.
+type ProfileLookup func(ctx context.Context, r *http.Request) (Preferences, bool)
+
+// Resolver determines request preferences from explicit headers, the
+// caller's profile, Accept-Language, and configured defaults, in that order
+type Resolver struct {
+	matcher   language.Matcher
+	supported []language.Tag
+	defaults  Preferences
+	profile   ProfileLookup
+}
+
+// NewResolver creates a resolver for the supported locales. The first
+// supported locale is the fallback when nothing matches.
+func NewResolver(supported []string, defaultCurrency, defaultUnits string, profile ProfileLookup) (*Resolver, error) {
+	if len(supported) == 0 {
+		return nil, fmt.Errorf("at least one supported locale is required")
+	}
+
+	tags := make([]language.Tag, len(supported))
+	for i, s := range supported {
+		tag, err := language.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locale %q: %w", s, err)
+		}
+		tags[i] = tag
+	}
+
+	cur, err := currency.ParseISO(defaultCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid currency %q: %w", defaultCurrency, err)
+	}
+
+	// An empty default derives the measurement system from the resolved locale
+	units, _ := ParseUnits(defaultUnits)
+
+	return &Resolver{
+		matcher:   language.NewMatcher(tags),
+		supported: tags,
+		defaults: Preferences{
+			Locale:   tags[0],
+			Currency: cur,
+			Units:    units,
+		},
+		profile: profile,
+	}, nil
+}
+
+// Resolve computes the preferences for a request
+func (res *Resolver) Resolve(r *http.Request) Preferences {
+	prefs := Preferences{}
+
+	// Explicit headers win
+	if tag, err := language.Parse(r.Header.Get("X-Locale")); err == nil && r.Header.Get("X-Locale") != "" {
+		prefs.Locale = res.match(tag)
+	}
+	if cur, err := currency.ParseISO(r.Header.Get("X-Currency")); err == nil {
+		prefs.Currency = cur
+	}
+	if units, ok := ParseUnits(r.Header.Get("X-Measurement-System")); ok {
+		prefs.Units = units
+	}
+
+	// Then the caller's stored profile
+	if res.profile != nil {
+		if stored, ok := res.profile(r.Context(), r); ok {
+			if prefs.Locale == (language.Tag{}) && stored.Locale != (language.Tag{}) {
+				prefs.Locale = res.match(stored.Locale)
+			}
+			if prefs.Currency == (currency.Unit{}) {
+				prefs.Currency = stored.Currency
+			}
+			if prefs.Units == "" {
+				prefs.Units = stored.Units
+			}
+		}
+	}
+
+	// Then Accept-Language, then defaults
+	if prefs.Locale == (language.Tag{}) {
+		prefs.Locale = res.defaults.Locale
+		if accepted, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language")); err == nil && len(accepted) > 0 {
+			tag, _, confidence := res.matcher.Match(accepted...)
+			if confidence != language.No {
+				prefs.Locale = res.normalize(tag)
+			}
+		}
+	}
+	if prefs.Currency == (currency.Unit{}) {
+		prefs.Currency = res.defaults.Currency
+	}
+	if prefs.Units == "" {
+		prefs.Units = res.defaults.Units
+	}
+	if prefs.Units == "" {
+		prefs.Units = DefaultUnits(prefs.Locale)
+	}
+
+	return prefs
+}
+
+// match maps a requested tag onto the closest supported locale
+func (res *Resolver) match(tag language.Tag) language.Tag {
+	matched, _, confidence := res.matcher.Match(tag)
+	if confidence == language.No {
+		return res.defaults.Locale
+	}
+	return res.normalize(matched)
+}
+
+// normalize strips the -u-rg extension the matcher adds to returned tags
+func (res *Resolver) normalize(tag language.Tag) language.Tag {
+	_, index, _ := res.matcher.Match(tag)
+	return res.supported[index]
+}
@@ -0,0 +1,79 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"starterkit/internal/config"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrNotAcquired means another owner holds the lock
+	ErrNotAcquired = errors.New("lock is held by another owner")
+	// ErrLost means renewal failed and another owner may now hold the lock
+	ErrLost = errors.New("lock was lost")
+)
+
+// Locker acquires named locks shared by every replica
+type Locker interface {
+	// TryAcquire takes the lock if it is free and returns ErrNotAcquired
+	// otherwise. A held lock is renewed in the background so it outlives
+	// ttl until released; if the owner dies it expires after ttl.
+	TryAcquire(ctx context.Context, name string, ttl time.Duration) (Lock, error)
+}
+
+// Lock is a held lock
+type Lock interface {
+	// Lost is closed when renewal fails; work guarded by the lock must stop
+	Lost() <-chan struct{}
+	// Release frees the lock. Releasing a lost lock returns ErrLost.
+	Release(ctx context.Context) error
+}
+
+// New creates the locker selected by cfg. The Postgres backend holds its
+// locks on one dedicated connection taken from pool.
+func New(cfg config.LockConfig, pool *pgxpool.Pool) (Locker, error) {
+	switch cfg.Backend {
+	case "", "postgres":
+		return NewPostgres(pool), nil
+	case "redis":
+		return NewRedis(cfg.RedisURL)
+	default:
+		return nil, fmt.Errorf("unsupported lock backend: %s", cfg.Backend)
+	}
+}
+
+// Do runs fn while holding the named lock. fn's context is cancelled if the
+// lock is lost. It returns ErrNotAcquired without running fn when another
+// owner holds the lock.
+func Do(ctx context.Context, locker Locker, name string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	l, err := locker.TryAcquire(ctx, name, ttl)
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		select {
+		case <-l.Lost():
+			cancel(ErrLost)
+		case <-runCtx.Done():
+		}
+	}()
+
+	err = fn(runCtx)
+	if releaseErr := l.Release(context.WithoutCancel(ctx)); releaseErr != nil && err == nil {
+		err = releaseErr
+	}
+	return err
+}
+
+// renewInterval is how often a lock with the given ttl is renewed
+func renewInterval(ttl time.Duration) time.Duration {
+	return max(ttl/3, 100*time.Millisecond)
+}
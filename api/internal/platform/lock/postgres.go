@@ -0,0 +1,169 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres holds session-level advisory locks on a dedicated connection, so
+// locks never compete with queries for pool connections. Postgres frees the
+// locks as soon as that session ends, so ttl only sets how often the
+// connection is checked.
+type Postgres struct {
+	pool *pgxpool.Pool
+
+	mu   sync.Mutex
+	conn *pgx.Conn
+	held map[string]*pgLock
+}
+
+type pgLock struct {
+	locker *Postgres
+	name   string
+	lost   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewPostgres creates an advisory-lock locker using a connection from pool
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{
+		pool: pool,
+		held: make(map[string]*pgLock),
+	}
+}
+
+func (p *Postgres) TryAcquire(ctx context.Context, name string, ttl time.Duration) (Lock, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Advisory locks are reentrant within a session, so track local owners
+	if _, ok := p.held[name]; ok {
+		return nil, ErrNotAcquired
+	}
+
+	conn, err := p.connLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var acquired bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtextextended($1, 0))", name).Scan(&acquired); err != nil {
+		p.dropLocked()
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if !acquired {
+		return nil, ErrNotAcquired
+	}
+
+	l := &pgLock{
+		locker: p,
+		name:   name,
+		lost:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	p.held[name] = l
+	go l.heartbeat(renewInterval(ttl))
+	return l, nil
+}
+
+// connLocked returns the lock session, dialing it through the pool and
+// taking it out of the pool's accounting
+func (p *Postgres) connLocked(ctx context.Context) (*pgx.Conn, error) {
+	if p.conn != nil {
+		return p.conn, nil
+	}
+	pooled, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock connection: %w", err)
+	}
+	p.conn = pooled.Hijack()
+	return p.conn, nil
+}
+
+// dropLocked closes the lock session after an error; every lock it held
+// is gone with it
+func (p *Postgres) dropLocked() {
+	if p.conn == nil {
+		return
+	}
+	p.conn.Close(context.Background())
+	p.conn = nil
+	for name, l := range p.held {
+		l.markLost()
+		delete(p.held, name)
+	}
+}
+
+func (p *Postgres) ping(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return ErrLost
+	}
+	if err := p.conn.Ping(ctx); err != nil {
+		p.dropLocked()
+		return err
+	}
+	return nil
+}
+
+func (l *pgLock) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-l.lost:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := l.locker.ping(ctx)
+			cancel()
+			if err != nil {
+				l.markLost()
+				return
+			}
+		}
+	}
+}
+
+func (l *pgLock) markLost() {
+	select {
+	case <-l.lost:
+	default:
+		close(l.lost)
+	}
+}
+
+func (l *pgLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *pgLock) Release(ctx context.Context) error {
+	var err error
+	l.once.Do(func() {
+		close(l.done)
+
+		p := l.locker
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		if p.held[l.name] != l {
+			err = ErrLost
+			return
+		}
+		delete(p.held, l.name)
+		if _, execErr := p.conn.Exec(ctx, "SELECT pg_advisory_unlock(hashtextextended($1, 0))", l.name); execErr != nil {
+			// Closing the session is the only way to be sure the lock is freed
+			p.dropLocked()
+			err = fmt.Errorf("failed to release lock %s: %w", l.name, execErr)
+		}
+	})
+	return err
+}
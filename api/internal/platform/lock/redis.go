@@ -0,0 +1,125 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces lock keys in Redis
+const keyPrefix = "lock:"
+
+// Only the owner's token may extend or delete a key
+var (
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// Redis holds locks as keys with an expiry, extended while held
+type Redis struct {
+	client *redis.Client
+}
+
+type redisLock struct {
+	client *redis.Client
+	key    string
+	token  string
+	ttl    time.Duration
+	lost   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewRedis creates a locker for the Redis server at url
+// (redis://[user:password@]host:port/db)
+func NewRedis(url string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+	return &Redis{client: redis.NewClient(opts)}, nil
+}
+
+func (r *Redis) TryAcquire(ctx context.Context, name string, ttl time.Duration) (Lock, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	l := &redisLock{
+		client: r.client,
+		key:    keyPrefix + name,
+		token:  hex.EncodeToString(raw),
+		ttl:    ttl,
+		lost:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	ok, err := r.client.SetNX(ctx, l.key, l.token, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if !ok {
+		return nil, ErrNotAcquired
+	}
+	go l.heartbeat(renewInterval(ttl))
+	return l, nil
+}
+
+func (l *redisLock) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	renewedAt := time.Now()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			renewed, err := renewScript.Run(ctx, l.client, []string{l.key}, l.token, l.ttl.Milliseconds()).Int()
+			cancel()
+			if err == nil && renewed == 1 {
+				renewedAt = time.Now()
+				continue
+			}
+			// A failed call leaves the key alive until its ttl could have run out
+			if err == nil || time.Since(renewedAt)+interval >= l.ttl {
+				close(l.lost)
+				return
+			}
+		}
+	}
+}
+
+func (l *redisLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *redisLock) Release(ctx context.Context) error {
+	var err error
+	l.once.Do(func() {
+		close(l.done)
+		var deleted int
+		deleted, err = releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Int()
+		if err != nil {
+			err = fmt.Errorf("failed to release lock %s: %w", l.key, err)
+			return
+		}
+		if deleted == 0 {
+			err = ErrLost
+		}
+	})
+	return err
+}
@@ -8,7 +8,8 @@ import (
 type contextKey string
 
 const (
-	loggerKey contextKey = "logger"
+	loggerKey    contextKey = "logger"
+	requestIDKey contextKey = "request_id"
 )
 
 // WithContext adds a logger to the context
@@ -16,10 +17,50 @@ func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
 	return context.WithValue(ctx, loggerKey, logger)
 }
 
-// FromContext extracts the logger from context
+// FromContext extracts the logger from context. It logs with ctx, so a
+// handler from NewHandler correlates its records with the request and trace
+// of ctx even when they are logged without a context.
 func FromContext(ctx context.Context) *slog.Logger {
-	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
-		return logger
+	logger, ok := ctx.Value(loggerKey).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
 	}
-	return slog.Default()
+	return slog.New(bind(logger.Handler(), ctx))
+}
+
+// WithRequestID adds the ID of the request being served to the context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID extracts the request ID from context
+func RequestID(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// WithLevel returns a logger that writes records at level and above, even
+// those below the level its handler was created with
+func WithLevel(l *slog.Logger, level slog.Level) *slog.Logger {
+	return slog.New(levelHandler{Handler: l.Handler(), level: level})
+}
+
+// levelHandler overrides the minimum level of the handler it wraps
+type levelHandler struct {
+	slog.Handler
+	level slog.Level
+}
+
+func (h levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
 }
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewHandler returns a handler that adds the request ID and the trace and
+// span IDs of the context a record is logged with, when it has them, before
+// passing the record to h. Loggers from FromContext log with the context
+// they were taken from, so call sites that log without one are correlated
+// too.
+func NewHandler(h slog.Handler) slog.Handler {
+	return contextHandler{Handler: h}
+}
+
+// contextHandler adds the correlation attributes of the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// boundHandler logs records with ctx unless they were logged with a context
+// of their own that carries a request or trace
+type boundHandler struct {
+	slog.Handler
+	ctx context.Context
+}
+
+// bind returns h logging with ctx, replacing any context it was bound to
+func bind(h slog.Handler, ctx context.Context) slog.Handler {
+	if b, ok := h.(boundHandler); ok {
+		h = b.Handler
+	}
+	return boundHandler{Handler: h, ctx: ctx}
+}
+
+func (h boundHandler) context(ctx context.Context) context.Context {
+	if RequestID(ctx) != "" || trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	return h.ctx
+}
+
+func (h boundHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.Handler.Enabled(h.context(ctx), level)
+}
+
+func (h boundHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.Handler.Handle(h.context(ctx), r)
+}
+
+func (h boundHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return boundHandler{Handler: h.Handler.WithAttrs(attrs), ctx: h.ctx}
+}
+
+func (h boundHandler) WithGroup(name string) slog.Handler {
+	return boundHandler{Handler: h.Handler.WithGroup(name), ctx: h.ctx}
+}
@@ -0,0 +1,178 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"starterkit/internal/connectors"
+	"starterkit/internal/platform/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Provider is a named email backend
+type Provider struct {
+	Name   string
+	Sender Sender
+}
+
+// Failover sends each message through the first healthy provider. A
+// provider that fails threshold times in a row is skipped until cooldown
+// has passed, after which the next message tries it again. Rejected
+// recipients do not count as failures and are not retried elsewhere, since
+// another provider would reject them too. Messages whose attachments
+// exceed maxSize are refused before any provider is tried.
+type Failover struct {
+	providers []*member
+	threshold int
+	cooldown  time.Duration
+	maxSize   int64
+	logger    *slog.Logger
+	sends     metric.Int64Counter
+	healthy   metric.Int64Gauge
+}
+
+type member struct {
+	Provider
+
+	mu        sync.Mutex
+	failures  int
+	downUntil time.Time
+}
+
+// NewFailover creates a sender over providers, in order of preference
+func NewFailover(providers []Provider, threshold int, cooldown time.Duration, maxSize int64, logger *slog.Logger) *Failover {
+	f := &Failover{
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		maxSize:   maxSize,
+		logger:    logger,
+		sends:     metrics.Int64Counter(metrics.EmailProviderSends),
+		healthy:   metrics.Int64Gauge(metrics.EmailProviderHealthy),
+	}
+	for _, p := range providers {
+		f.providers = append(f.providers, &member{Provider: p})
+		f.healthy.Record(context.Background(), 1, metric.WithAttributes(attribute.String("provider", p.Name)))
+	}
+	return f
+}
+
+// Send delivers the message through the first healthy provider, moving on
+// to the next when one fails. When every provider is down they are all
+// tried anyway.
+func (f *Failover) Send(ctx context.Context, msg Message) (string, error) {
+	if err := msg.Validate(f.maxSize); err != nil {
+		return "", err
+	}
+	candidates := f.available()
+	var lastErr error
+	for _, m := range candidates {
+		id, err := m.Sender.Send(ctx, msg)
+		switch {
+		case err == nil:
+			f.record(ctx, m, "ok")
+			f.succeeded(ctx, m)
+			return id, nil
+		case errors.Is(err, ErrRecipientRejected):
+			f.record(ctx, m, "rejected")
+			f.succeeded(ctx, m)
+			return "", err
+		case ctx.Err() != nil:
+			return "", err
+		}
+		f.record(ctx, m, "failed")
+		f.failed(ctx, m, err)
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// available returns the healthy providers in order, or every provider when
+// none is healthy
+func (f *Failover) available() []*member {
+	now := time.Now()
+	var healthy []*member
+	for _, m := range f.providers {
+		m.mu.Lock()
+		down := now.Before(m.downUntil)
+		m.mu.Unlock()
+		if !down {
+			healthy = append(healthy, m)
+		}
+	}
+	if len(healthy) == 0 {
+		return f.providers
+	}
+	return healthy
+}
+
+func (f *Failover) succeeded(ctx context.Context, m *member) {
+	m.mu.Lock()
+	recovered := !m.downUntil.IsZero()
+	m.failures = 0
+	m.downUntil = time.Time{}
+	m.mu.Unlock()
+	if recovered {
+		f.logger.InfoContext(ctx, "email provider recovered", "provider", m.Name)
+		f.healthy.Record(ctx, 1, metric.WithAttributes(attribute.String("provider", m.Name)))
+	}
+}
+
+func (f *Failover) failed(ctx context.Context, m *member, err error) {
+	m.mu.Lock()
+	m.failures++
+	tripped := m.failures >= f.threshold
+	if tripped {
+		m.downUntil = time.Now().Add(f.cooldown)
+	}
+	m.mu.Unlock()
+	if tripped {
+		f.logger.WarnContext(ctx, "email provider marked down", "provider", m.Name, "error", err, "cooldown", f.cooldown)
+		f.healthy.Record(ctx, 0, metric.WithAttributes(attribute.String("provider", m.Name)))
+	} else {
+		f.logger.WarnContext(ctx, "email provider failed", "provider", m.Name, "error", err)
+	}
+}
+
+func (f *Failover) record(ctx context.Context, m *member, outcome string) {
+	f.sends.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("provider", m.Name),
+		attribute.String("outcome", outcome),
+	))
+}
+
+// Providers returns the providers in order of preference
+func (f *Failover) Providers() []Provider {
+	providers := make([]Provider, len(f.providers))
+	for i, m := range f.providers {
+		providers[i] = m.Provider
+	}
+	return providers
+}
+
+// Webhook returns the webhook parser of the named provider
+func (f *Failover) Webhook(provider string) (WebhookParser, bool) {
+	for _, m := range f.providers {
+		if m.Name == provider {
+			parser, ok := m.Sender.(WebhookParser)
+			return parser, ok
+		}
+	}
+	return nil, false
+}
+
+// Connectors returns the providers that are connectors, so their health is
+// reported with the others
+func (f *Failover) Connectors() []connectors.Connector {
+	var conns []connectors.Connector
+	for _, m := range f.providers {
+		if conn, ok := m.Sender.(connectors.Connector); ok {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
@@ -0,0 +1,133 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"starterkit/internal/config"
+
+	"github.com/google/uuid"
+)
+
+// ErrRecipientRejected means the provider refused the address permanently,
+// e.g. because it does not exist or has bounced before
+var ErrRecipientRejected = errors.New("recipient rejected")
+
+// Message is an email with a plain text body, an HTML body, or both
+type Message struct {
+	To          string
+	Subject     string
+	Body        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Sender delivers email. Send returns the provider's ID for the message,
+// which bounce and complaint webhooks refer to.
+type Sender interface {
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// Event types reported by provider webhooks
+const (
+	EventDelivered  = "delivered"
+	EventBounced    = "bounced"
+	EventComplained = "complained"
+	// EventOpened is reported when the recipient opens the message, where
+	// the provider tracks opens
+	EventOpened = "opened"
+)
+
+// Event is a delivery outcome reported by the provider after sending
+type Event struct {
+	Type      string
+	MessageID string
+	Email     string
+	// Permanent is set for bounces that will not succeed on retry
+	Permanent bool
+	Detail    string
+	At        time.Time
+}
+
+// WebhookParser is implemented by providers that report bounces and
+// complaints with webhooks
+type WebhookParser interface {
+	ParseWebhook(r *http.Request) ([]Event, error)
+}
+
+// New creates the email providers listed in configuration, tried in that
+// order
+func New(ctx context.Context, cfg config.EmailConfig, conns config.ConnectorsConfig, logger *slog.Logger) (*Failover, error) {
+	providers := make([]Provider, 0, len(cfg.Providers))
+	for _, name := range cfg.Providers {
+		sender, err := newSender(ctx, name, cfg, conns, logger)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, Provider{Name: name, Sender: sender})
+	}
+	return NewFailover(providers, cfg.FailoverThreshold, cfg.FailoverCooldown, cfg.MaxAttachmentSize, logger), nil
+}
+
+func newSender(ctx context.Context, name string, cfg config.EmailConfig, conns config.ConnectorsConfig, logger *slog.Logger) (Sender, error) {
+	switch name {
+	case "log":
+		return NewLog(logger), nil
+	case "smtp":
+		return NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	case "postmark":
+		conn, err := connector(conns, name)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostmark(conn, cfg.From, cfg.PostmarkStream), nil
+	case "sendgrid":
+		conn, err := connector(conns, name)
+		if err != nil {
+			return nil, err
+		}
+		return NewSendGrid(conn, cfg.From), nil
+	case "ses":
+		conn, err := connector(conns, name)
+		if err != nil {
+			return nil, err
+		}
+		return NewSES(ctx, conn, cfg.SESRegion, cfg.From, cfg.SESConfigurationSet)
+	default:
+		return nil, fmt.Errorf("unsupported email provider: %s", name)
+	}
+}
+
+// connector returns the settings of the connector a provider goes through
+func connector(conns config.ConnectorsConfig, name string) (config.ConnectorConfig, error) {
+	conn, ok := conns.Provider(name)
+	if !ok {
+		return config.ConnectorConfig{}, fmt.Errorf("email provider %s requires CONNECTORS to include %s", name, name)
+	}
+	return conn, nil
+}
+
+// Log writes messages to the logger instead of sending them, for development
+type Log struct {
+	logger *slog.Logger
+}
+
+// NewLog creates a sender that only logs messages
+func NewLog(logger *slog.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+// Send logs the message
+func (l *Log) Send(ctx context.Context, msg Message) (string, error) {
+	id := uuid.NewString()
+	names := make([]string, len(msg.Attachments))
+	for i, a := range msg.Attachments {
+		names[i] = a.Filename
+	}
+	l.logger.InfoContext(ctx, "email not sent (log provider)", "to", msg.To, "subject", msg.Subject, "body", msg.Body, "html", msg.HTML, "attachments", names, "message_id", id)
+	return id, nil
+}
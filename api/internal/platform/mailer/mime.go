@@ -0,0 +1,242 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMessageTooLarge means the attachments exceed the size limit
+	ErrMessageTooLarge = errors.New("email attachments are too large")
+	// ErrInvalidMessage means the message cannot be assembled as given
+	ErrInvalidMessage = errors.New("invalid email message")
+)
+
+// Attachment is a file sent with a message. An attachment with a ContentID
+// is shown inline where the HTML body refers to cid:<ContentID>.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Content     []byte
+}
+
+// Inline reports whether the attachment is shown in the HTML body
+func (a Attachment) Inline() bool {
+	return a.ContentID != ""
+}
+
+func (a Attachment) contentType() string {
+	if a.ContentType != "" {
+		return a.ContentType
+	}
+	if ct := mime.TypeByExtension(fileExt(a.Filename)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+func fileExt(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i:]
+	}
+	return ""
+}
+
+// Validate checks that the message can be assembled and that its
+// attachments total at most maxSize bytes, or any size when maxSize is 0
+func (m Message) Validate(maxSize int64) error {
+	if strings.ContainsAny(m.To+m.Subject, "\r\n") {
+		return fmt.Errorf("%w: headers must not contain line breaks", ErrInvalidMessage)
+	}
+	var size int64
+	for _, a := range m.Attachments {
+		if a.Filename == "" || strings.ContainsAny(a.Filename, "\r\n") {
+			return fmt.Errorf("%w: attachment needs a file name without line breaks", ErrInvalidMessage)
+		}
+		if strings.ContainsAny(a.ContentID, "<>\r\n\t ") {
+			return fmt.Errorf("%w: invalid content ID %q", ErrInvalidMessage, a.ContentID)
+		}
+		if a.Inline() && m.HTML == "" {
+			return fmt.Errorf("%w: inline attachment %s needs an HTML body", ErrInvalidMessage, a.ContentID)
+		}
+		if _, _, err := mime.ParseMediaType(a.contentType()); err != nil {
+			return fmt.Errorf("%w: invalid content type %q", ErrInvalidMessage, a.ContentType)
+		}
+		size += int64(len(a.Content))
+	}
+	if maxSize > 0 && size > maxSize {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrMessageTooLarge, size, maxSize)
+	}
+	return nil
+}
+
+// BuildMIME assembles msg as an RFC 5322 message from the given address
+// with Message-ID id, for providers that send raw messages. Text and HTML
+// bodies are sent as alternatives, inline images are related to the HTML,
+// and other attachments are mixed in after the body.
+func BuildMIME(from *mail.Address, id string, msg Message) ([]byte, error) {
+	if err := msg.Validate(0); err != nil {
+		return nil, err
+	}
+
+	body, err := mimeBody(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s>\r\n", id)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	writeHeader(&buf, body.header)
+	buf.WriteString("\r\n")
+	buf.Write(body.body)
+	return buf.Bytes(), nil
+}
+
+// mimePart is an encoded body part and its headers
+type mimePart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+func mimeBody(msg Message) (mimePart, error) {
+	var alternatives []mimePart
+	if msg.Body != "" || msg.HTML == "" {
+		part, err := textPart("text/plain", msg.Body)
+		if err != nil {
+			return mimePart{}, err
+		}
+		alternatives = append(alternatives, part)
+	}
+	if msg.HTML != "" {
+		part, err := textPart("text/html", msg.HTML)
+		if err != nil {
+			return mimePart{}, err
+		}
+		alternatives = append(alternatives, part)
+	}
+
+	body := alternatives[0]
+	if len(alternatives) > 1 {
+		var err error
+		if body, err = multipartOf("alternative", alternatives); err != nil {
+			return mimePart{}, err
+		}
+	}
+
+	related := []mimePart{body}
+	mixed := []mimePart{}
+	for _, a := range msg.Attachments {
+		if a.Inline() {
+			related = append(related, filePart(a))
+		} else {
+			mixed = append(mixed, filePart(a))
+		}
+	}
+	if len(related) > 1 {
+		var err error
+		if body, err = multipartOf("related", related); err != nil {
+			return mimePart{}, err
+		}
+	}
+	if len(mixed) > 0 {
+		return multipartOf("mixed", append([]mimePart{body}, mixed...))
+	}
+	return body, nil
+}
+
+func textPart(contentType, text string) (mimePart, error) {
+	var buf bytes.Buffer
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(text)); err != nil {
+		return mimePart{}, fmt.Errorf("failed to encode message body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return mimePart{}, fmt.Errorf("failed to encode message body: %w", err)
+	}
+	return mimePart{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		body: buf.Bytes(),
+	}, nil
+}
+
+func filePart(a Attachment) mimePart {
+	disposition := "attachment"
+	if a.Inline() {
+		disposition = "inline"
+	}
+	header := textproto.MIMEHeader{
+		"Content-Type":              {a.contentType()},
+		"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename})},
+		"Content-Transfer-Encoding": {"base64"},
+	}
+	if a.Inline() {
+		header.Set("Content-ID", "<"+a.ContentID+">")
+	}
+
+	// Base64 lines are limited to 76 characters
+	encoded := base64.StdEncoding.EncodeToString(a.Content)
+	var buf bytes.Buffer
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteString("\r\n")
+	return mimePart{header: header, body: buf.Bytes()}
+}
+
+func multipartOf(subtype string, parts []mimePart) (mimePart, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		pw, err := w.CreatePart(p.header)
+		if err != nil {
+			return mimePart{}, fmt.Errorf("failed to assemble message: %w", err)
+		}
+		if _, err := pw.Write(p.body); err != nil {
+			return mimePart{}, fmt.Errorf("failed to assemble message: %w", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return mimePart{}, fmt.Errorf("failed to assemble message: %w", err)
+	}
+	return mimePart{
+		header: textproto.MIMEHeader{
+			"Content-Type": {"multipart/" + subtype + "; boundary=" + w.Boundary()},
+		},
+		body: buf.Bytes(),
+	}, nil
+}
+
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			fmt.Fprintf(buf, "%s: %s\r\n", k, v)
+		}
+	}
+}
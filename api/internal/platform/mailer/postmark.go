@@ -0,0 +1,170 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+)
+
+const postmarkBaseURL = "https://api.postmarkapp.com"
+
+// Postmark error codes for addresses that will not accept mail
+const (
+	postmarkInvalidEmail      = 300
+	postmarkInactiveRecipient = 406
+)
+
+// postmarkPermanentBounces are the bounce types that suppress an address
+var postmarkPermanentBounces = map[string]bool{
+	"HardBounce":          true,
+	"BadEmailAddress":     true,
+	"ManuallyDeactivated": true,
+	"SpamNotification":    true,
+}
+
+// Postmark sends messages through the Postmark Email API and parses its
+// bounce, spam complaint, and delivery webhooks
+type Postmark struct {
+	client *connectors.Client
+	from   string
+	stream string
+}
+
+// NewPostmark creates a provider for the postmark connector, whose token is
+// a server API token
+func NewPostmark(conn config.ConnectorConfig, from, stream string) *Postmark {
+	if conn.BaseURL == "" {
+		conn.BaseURL = postmarkBaseURL
+	}
+	return &Postmark{
+		client: connectors.NewClient("postmark", conn, connectors.APIKeyHeader("X-Postmark-Server-Token", conn.Token)),
+		from:   from,
+		stream: stream,
+	}
+}
+
+func (p *Postmark) Name() string {
+	return p.client.Name()
+}
+
+// Check reads the server settings, which needs a valid server token
+func (p *Postmark) Check(ctx context.Context) error {
+	return p.client.Do(ctx, http.MethodGet, "/server", nil, nil)
+}
+
+// Send delivers a message
+func (p *Postmark) Send(ctx context.Context, msg Message) (string, error) {
+	var resp struct {
+		MessageID string
+	}
+	req := map[string]any{
+		"From":          p.from,
+		"To":            msg.To,
+		"Subject":       msg.Subject,
+		"MessageStream": p.stream,
+	}
+	if msg.Body != "" || msg.HTML == "" {
+		req["TextBody"] = msg.Body
+	}
+	if msg.HTML != "" {
+		req["HtmlBody"] = msg.HTML
+	}
+	if len(msg.Attachments) > 0 {
+		attachments := make([]map[string]any, len(msg.Attachments))
+		for i, a := range msg.Attachments {
+			attachments[i] = map[string]any{
+				"Name":        a.Filename,
+				"Content":     a.Content,
+				"ContentType": a.contentType(),
+			}
+			if a.Inline() {
+				attachments[i]["ContentID"] = "cid:" + a.ContentID
+			}
+		}
+		req["Attachments"] = attachments
+	}
+	err := p.client.Do(ctx, http.MethodPost, "/email", req, &resp)
+	if err != nil {
+		var connErr *connectors.Error
+		if errors.As(err, &connErr) && connErr.StatusCode == http.StatusUnprocessableEntity {
+			var apiErr struct {
+				ErrorCode int
+			}
+			if json.Unmarshal([]byte(connErr.Message), &apiErr) == nil &&
+				(apiErr.ErrorCode == postmarkInactiveRecipient || apiErr.ErrorCode == postmarkInvalidEmail) {
+				return "", fmt.Errorf("%w: %v", ErrRecipientRejected, err)
+			}
+		}
+		return "", err
+	}
+	return resp.MessageID, nil
+}
+
+// postmarkEvent is the body of a Bounce, SpamComplaint, Delivery, or Open
+// webhook
+type postmarkEvent struct {
+	RecordType  string
+	MessageID   string
+	Type        string
+	Email       string
+	Recipient   string
+	Description string
+	Details     string
+	BouncedAt   time.Time
+	DeliveredAt time.Time
+	ReceivedAt  time.Time
+}
+
+// ParseWebhook reads one webhook. Record types other than bounces,
+// complaints, deliveries, and opens yield no events.
+func (p *Postmark) ParseWebhook(r *http.Request) ([]Event, error) {
+	var e postmarkEvent
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&e); err != nil {
+		return nil, fmt.Errorf("failed to decode postmark webhook: %w", err)
+	}
+
+	switch e.RecordType {
+	case "Bounce":
+		return []Event{{
+			Type:      EventBounced,
+			MessageID: e.MessageID,
+			Email:     e.Email,
+			Permanent: postmarkPermanentBounces[e.Type],
+			Detail:    e.Type + ": " + e.Description,
+			At:        e.BouncedAt,
+		}}, nil
+	case "SpamComplaint":
+		return []Event{{
+			Type:      EventComplained,
+			MessageID: e.MessageID,
+			Email:     e.Email,
+			Permanent: true,
+			Detail:    "spam complaint",
+			At:        e.BouncedAt,
+		}}, nil
+	case "Delivery":
+		return []Event{{
+			Type:      EventDelivered,
+			MessageID: e.MessageID,
+			Email:     e.Recipient,
+			Detail:    e.Details,
+			At:        e.DeliveredAt,
+		}}, nil
+	case "Open":
+		return []Event{{
+			Type:      EventOpened,
+			MessageID: e.MessageID,
+			Email:     e.Recipient,
+			At:        e.ReceivedAt,
+		}}, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,184 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+)
+
+const sendGridBaseURL = "https://api.sendgrid.com"
+
+// sendGridDroppedBounces are the reasons SendGrid drops a message without
+// sending it because the address bounced or complained before
+var sendGridDroppedBounces = map[string]string{
+	"Bounced Address":        EventBounced,
+	"Invalid":                EventBounced,
+	"Spam Reporting Address": EventComplained,
+}
+
+// SendGrid sends messages through the SendGrid v3 Mail Send API and parses
+// its event webhook
+type SendGrid struct {
+	client *connectors.Client
+	from   string
+}
+
+// NewSendGrid creates a provider for the sendgrid connector, whose token is
+// an API key with mail send access
+func NewSendGrid(conn config.ConnectorConfig, from string) *SendGrid {
+	if conn.BaseURL == "" {
+		conn.BaseURL = sendGridBaseURL
+	}
+	return &SendGrid{
+		client: connectors.NewClient("sendgrid", conn, connectors.BearerToken(conn.Token)),
+		from:   from,
+	}
+}
+
+func (s *SendGrid) Name() string {
+	return s.client.Name()
+}
+
+// Check reads the API key's scopes, which needs a valid key
+func (s *SendGrid) Check(ctx context.Context) error {
+	return s.client.Do(ctx, http.MethodGet, "/v3/scopes", nil, nil)
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// Send delivers a message. SendGrid returns the message ID in a header.
+func (s *SendGrid) Send(ctx context.Context, msg Message) (string, error) {
+	from := sendGridAddress{Email: s.from}
+	if addr, err := mail.ParseAddress(s.from); err == nil {
+		from = sendGridAddress{Email: addr.Address, Name: addr.Name}
+	}
+
+	// SendGrid requires text/plain before text/html
+	var content []map[string]string
+	if msg.Body != "" || msg.HTML == "" {
+		content = append(content, map[string]string{"type": "text/plain", "value": msg.Body})
+	}
+	if msg.HTML != "" {
+		content = append(content, map[string]string{"type": "text/html", "value": msg.HTML})
+	}
+	req := map[string]any{
+		"personalizations": []map[string]any{
+			{"to": []sendGridAddress{{Email: msg.To}}},
+		},
+		"from":    from,
+		"subject": msg.Subject,
+		"content": content,
+	}
+	if len(msg.Attachments) > 0 {
+		attachments := make([]map[string]any, len(msg.Attachments))
+		for i, a := range msg.Attachments {
+			attachments[i] = map[string]any{
+				"content":     a.Content,
+				"type":        a.contentType(),
+				"filename":    a.Filename,
+				"disposition": "attachment",
+			}
+			if a.Inline() {
+				attachments[i]["disposition"] = "inline"
+				attachments[i]["content_id"] = a.ContentID
+			}
+		}
+		req["attachments"] = attachments
+	}
+
+	resp, err := s.client.Open(ctx, http.MethodPost, "/v3/mail/send", req)
+	if err != nil {
+		var connErr *connectors.Error
+		if errors.As(err, &connErr) && connErr.StatusCode == http.StatusBadRequest {
+			var apiErr struct {
+				Errors []struct {
+					Field string `json:"field"`
+				} `json:"errors"`
+			}
+			// Errors in the personalizations are about the recipient
+			if json.Unmarshal([]byte(connErr.Message), &apiErr) == nil {
+				for _, e := range apiErr.Errors {
+					if strings.HasPrefix(e.Field, "personalizations") {
+						return "", fmt.Errorf("%w: %v", ErrRecipientRejected, err)
+					}
+				}
+			}
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Header.Get("X-Message-Id"), nil
+}
+
+// sendGridEvent is one entry of an event webhook
+type sendGridEvent struct {
+	Email       string `json:"email"`
+	Timestamp   int64  `json:"timestamp"`
+	Event       string `json:"event"`
+	SGMessageID string `json:"sg_message_id"`
+	Type        string `json:"type"`
+	Reason      string `json:"reason"`
+	Response    string `json:"response"`
+}
+
+// ParseWebhook reads a batch of events. Events other than deliveries,
+// bounces, drops, spam reports, and opens are ignored.
+func (s *SendGrid) ParseWebhook(r *http.Request) ([]Event, error) {
+	var batch []sendGridEvent
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&batch); err != nil {
+		return nil, fmt.Errorf("failed to decode sendgrid webhook: %w", err)
+	}
+
+	var events []Event
+	for _, e := range batch {
+		// sg_message_id is the X-Message-Id of the send followed by a
+		// suffix for the recipient
+		messageID, _, _ := strings.Cut(e.SGMessageID, ".")
+		event := Event{
+			MessageID: messageID,
+			Email:     e.Email,
+			Detail:    e.Reason,
+			At:        time.Unix(e.Timestamp, 0),
+		}
+		switch e.Event {
+		case "delivered":
+			event.Type = EventDelivered
+			event.Detail = e.Response
+		case "bounce":
+			// "blocked" bounces are temporary refusals by the receiver
+			event.Type = EventBounced
+			event.Permanent = e.Type != "blocked"
+		case "dropped":
+			eventType, ok := sendGridDroppedBounces[e.Reason]
+			if !ok {
+				continue
+			}
+			event.Type = eventType
+			event.Permanent = true
+		case "spamreport":
+			event.Type = EventComplained
+			event.Permanent = true
+			event.Detail = "spam complaint"
+		case "open":
+			event.Type = EventOpened
+			event.Detail = ""
+		default:
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
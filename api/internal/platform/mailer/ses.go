@@ -0,0 +1,272 @@
+package mailer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/google/uuid"
+)
+
+// SES sends messages through the Amazon SES v2 API, signing requests with
+// the default AWS credential chain. Bounce, complaint, and delivery
+// notifications arrive through an SNS topic subscribed to the webhook.
+type SES struct {
+	client           *connectors.Client
+	credentials      aws.CredentialsProvider
+	from             *mail.Address
+	configurationSet string
+}
+
+// NewSES creates a provider for the ses connector. The connector token is
+// unused; its base URL defaults to the regional SES endpoint.
+func NewSES(ctx context.Context, conn config.ConnectorConfig, region, from, configurationSet string) (*SES, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("email provider ses requires a region")
+	}
+	if conn.BaseURL == "" {
+		conn.BaseURL = "https://email." + awsCfg.Region + ".amazonaws.com"
+	}
+
+	s := &SES{
+		credentials:      awsCfg.Credentials,
+		from:             addr,
+		configurationSet: configurationSet,
+	}
+	s.client = connectors.NewClient("ses", conn, sigV4(awsCfg.Credentials, awsCfg.Region))
+	return s, nil
+}
+
+// sigV4 signs each request for SES. A request that cannot be signed is
+// sent as is and refused by SES as unauthorized.
+func sigV4(credentials aws.CredentialsProvider, region string) connectors.Auth {
+	signer := v4.NewSigner()
+	return func(req *http.Request) {
+		var payload []byte
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return
+			}
+			payload, _ = io.ReadAll(body)
+		}
+		creds, err := credentials.Retrieve(req.Context())
+		if err != nil {
+			return
+		}
+		hash := sha256.Sum256(payload)
+		_ = signer.SignHTTP(req.Context(), creds, req, hex.EncodeToString(hash[:]), "ses", region, time.Now())
+	}
+}
+
+func (s *SES) Name() string {
+	return s.client.Name()
+}
+
+// Check reads the account's sending status, which needs valid credentials
+func (s *SES) Check(ctx context.Context) error {
+	return s.client.Do(ctx, http.MethodGet, "/v2/email/account", nil, nil)
+}
+
+// Send delivers a message, assembled as a raw MIME message so attachments
+// and inline images are sent as built here
+func (s *SES) Send(ctx context.Context, msg Message) (string, error) {
+	// Fail with the credential error rather than an unsigned request
+	if _, err := s.credentials.Retrieve(ctx); err != nil {
+		return "", fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	id := uuid.NewString() + "@" + s.from.Address[strings.LastIndex(s.from.Address, "@")+1:]
+	raw, err := BuildMIME(s.from, id, msg)
+	if err != nil {
+		return "", err
+	}
+
+	req := map[string]any{
+		"FromEmailAddress": s.from.String(),
+		"Destination": map[string]any{
+			"ToAddresses": []string{msg.To},
+		},
+		"Content": map[string]any{
+			// Data is encoded as base64 in JSON
+			"Raw": map[string][]byte{"Data": raw},
+		},
+	}
+	if s.configurationSet != "" {
+		req["ConfigurationSetName"] = s.configurationSet
+	}
+
+	var resp struct {
+		MessageID string `json:"MessageId"`
+	}
+	if err := s.client.Do(ctx, http.MethodPost, "/v2/email/outbound-emails", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.MessageID, nil
+}
+
+// snsMessage is an SNS HTTP delivery
+type snsMessage struct {
+	Type         string
+	Message      string
+	SubscribeURL string
+}
+
+// sesNotification is an SES notification or configuration set event
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"`
+	Mail             struct {
+		MessageID string `json:"messageId"`
+	} `json:"mail"`
+	Bounce struct {
+		BounceType        string    `json:"bounceType"`
+		BounceSubType     string    `json:"bounceSubType"`
+		Timestamp         time.Time `json:"timestamp"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		Timestamp            time.Time `json:"timestamp"`
+		ComplainedRecipients []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+	Delivery struct {
+		Timestamp    time.Time `json:"timestamp"`
+		Recipients   []string  `json:"recipients"`
+		SMTPResponse string    `json:"smtpResponse"`
+	} `json:"delivery"`
+	Open struct {
+		Timestamp time.Time `json:"timestamp"`
+	} `json:"open"`
+}
+
+// ParseWebhook reads one SNS delivery. A subscription confirmation is
+// accepted by visiting its confirmation URL and yields no events.
+func (s *SES) ParseWebhook(r *http.Request) ([]Event, error) {
+	var sns snsMessage
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&sns); err != nil {
+		return nil, fmt.Errorf("failed to decode ses webhook: %w", err)
+	}
+
+	switch sns.Type {
+	case "SubscriptionConfirmation":
+		return nil, confirmSubscription(r.Context(), sns.SubscribeURL)
+	case "Notification":
+	default:
+		return nil, nil
+	}
+
+	var n sesNotification
+	if err := json.Unmarshal([]byte(sns.Message), &n); err != nil {
+		return nil, fmt.Errorf("failed to decode ses notification: %w", err)
+	}
+	// Identity notifications set notificationType; configuration set
+	// events set eventType
+	kind := n.NotificationType
+	if kind == "" {
+		kind = n.EventType
+	}
+
+	var events []Event
+	switch kind {
+	case "Bounce":
+		for _, rcpt := range n.Bounce.BouncedRecipients {
+			detail := n.Bounce.BounceType + " " + n.Bounce.BounceSubType
+			if rcpt.DiagnosticCode != "" {
+				detail += ": " + rcpt.DiagnosticCode
+			}
+			events = append(events, Event{
+				Type:      EventBounced,
+				MessageID: n.Mail.MessageID,
+				Email:     rcpt.EmailAddress,
+				Permanent: n.Bounce.BounceType == "Permanent",
+				Detail:    detail,
+				At:        n.Bounce.Timestamp,
+			})
+		}
+	case "Complaint":
+		for _, rcpt := range n.Complaint.ComplainedRecipients {
+			events = append(events, Event{
+				Type:      EventComplained,
+				MessageID: n.Mail.MessageID,
+				Email:     rcpt.EmailAddress,
+				Permanent: true,
+				Detail:    "spam complaint",
+				At:        n.Complaint.Timestamp,
+			})
+		}
+	case "Delivery":
+		for _, rcpt := range n.Delivery.Recipients {
+			events = append(events, Event{
+				Type:      EventDelivered,
+				MessageID: n.Mail.MessageID,
+				Email:     rcpt,
+				Detail:    n.Delivery.SMTPResponse,
+				At:        n.Delivery.Timestamp,
+			})
+		}
+	case "Open":
+		// Opens are only reported as configuration set events, for the
+		// message as a whole
+		events = append(events, Event{
+			Type:      EventOpened,
+			MessageID: n.Mail.MessageID,
+			At:        n.Open.Timestamp,
+		})
+	}
+	return events, nil
+}
+
+// confirmSubscription confirms an SNS subscription. Only SNS endpoints are
+// visited, so the webhook cannot be used to make arbitrary requests.
+func confirmSubscription(ctx context.Context, subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" || !strings.HasPrefix(u.Hostname(), "sns.") || !strings.HasSuffix(u.Hostname(), ".amazonaws.com") {
+		return fmt.Errorf("invalid sns subscribe url: %q", subscribeURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to confirm sns subscription: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to confirm sns subscription: status %d", resp.StatusCode)
+	}
+	return nil
+}
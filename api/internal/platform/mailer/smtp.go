@@ -0,0 +1,134 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// smtpTimeout bounds a whole SMTP conversation
+const smtpTimeout = 30 * time.Second
+
+// SMTP sends messages through an SMTP relay, upgrading to TLS when the
+// server offers STARTTLS
+type SMTP struct {
+	host     string
+	addr     string
+	from     *mail.Address
+	username string
+	password string
+}
+
+// NewSMTP creates an SMTP sender. Credentials are optional for relays that
+// authorize by network.
+func NewSMTP(host string, port int, username, password, from string) (*SMTP, error) {
+	if host == "" {
+		return nil, errors.New("smtp requires a host")
+	}
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	return &SMTP{
+		host:     host,
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		from:     addr,
+		username: username,
+		password: password,
+	}, nil
+}
+
+// Send delivers a message and returns the Message-ID header it was sent with
+func (s *SMTP) Send(ctx context.Context, msg Message) (string, error) {
+	id := uuid.NewString() + "@" + s.from.Address[strings.LastIndex(s.from.Address, "@")+1:]
+	data, err := BuildMIME(s.from, id, msg)
+	if err != nil {
+		return "", err
+	}
+
+	c, err := s.session(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	if err := c.Mail(s.from.Address); err != nil {
+		return "", fmt.Errorf("smtp server rejected sender: %w", err)
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		// 5xx replies are permanent; anything else may succeed later
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+			return "", fmt.Errorf("%w: %v", ErrRecipientRejected, err)
+		}
+		return "", fmt.Errorf("smtp server rejected recipient: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return "", fmt.Errorf("failed to start message data: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("smtp server rejected message: %w", err)
+	}
+	// The message was accepted even if QUIT fails
+	_ = c.Quit()
+	return id, nil
+}
+
+// Check logs in to the relay without sending anything, for preflight checks
+func (s *SMTP) Check(ctx context.Context) error {
+	c, err := s.session(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Quit()
+}
+
+// session connects to the relay, upgrading to TLS when offered, and logs in
+// when credentials are set
+func (s *SMTP) session(ctx context.Context) (*smtp.Client, error) {
+	dialer := net.Dialer{Timeout: smtpTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start smtp session: %w", err)
+	}
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+	if s.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to authenticate to smtp server: %w", err)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,270 @@
+// Package memcache keeps values in this process for a while, for lookups
+// read far more often than they change. Entries expire after the cache's
+// TTL and the least recently used are dropped beyond its capacity. The
+// cache is split into shards, each with its own lock, so busy caches do not
+// serialize on one mutex, and GetOrLoad runs one load per missing key
+// however many callers ask for it at once.
+package memcache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"hash/maphash"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/metrics"
+)
+
+// shardCount is how many locks a cache is split under
+const shardCount = 16
+
+// loadTimeout bounds a GetOrLoad load, which no caller's ctx cancels
+const loadTimeout = 30 * time.Second
+
+// Results and reasons recorded by the cache's counters
+const (
+	resultHit  = "hit"
+	resultMiss = "miss"
+
+	reasonExpired  = "expired"
+	reasonCapacity = "capacity"
+)
+
+// errLoadPanicked is returned to callers waiting on a load that panicked
+var errLoadPanicked = errors.New("memcache: load panicked")
+
+// instruments are shared by every cache, told apart by the cache attribute
+var instruments = sync.OnceValues(func() (metric.Int64Counter, metric.Int64Counter) {
+	return metrics.Int64Counter(metrics.MemcacheLookups), metrics.Int64Counter(metrics.MemcacheEvictions)
+})
+
+// Cache maps keys to values that expire
+type Cache[K comparable, V any] struct {
+	ttl    time.Duration
+	seed   maphash.Seed
+	shards [shardCount]*shard[K, V]
+
+	lookups   metric.Int64Counter
+	evictions metric.Int64Counter
+	name      attribute.KeyValue
+}
+
+type shard[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[K]*list.Element
+	// loads are the GetOrLoad calls in flight, which later callers for the
+	// same key wait for
+	loads map[K]*load[V]
+	// gen counts the clears of the shard; a load started before the last
+	// one does not store its result
+	gen uint64
+}
+
+type item[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+type load[V any] struct {
+	done  chan struct{}
+	gen   uint64
+	value V
+	err   error
+}
+
+// New creates a cache holding at most capacity entries, each for ttl. name
+// tells its lookups and evictions apart in metrics.
+func New[K comparable, V any](name string, capacity int, ttl time.Duration) *Cache[K, V] {
+	lookups, evictions := instruments()
+	c := &Cache[K, V]{
+		ttl:       ttl,
+		seed:      maphash.MakeSeed(),
+		lookups:   lookups,
+		evictions: evictions,
+		name:      attribute.String("cache", name),
+	}
+	// Every shard holds at least one entry, so small caches may hold a few
+	// more than capacity
+	perShard := max((capacity+shardCount-1)/shardCount, 1)
+	for i := range c.shards {
+		c.shards[i] = &shard[K, V]{
+			capacity: perShard,
+			order:    list.New(),
+			items:    make(map[K]*list.Element),
+			loads:    make(map[K]*load[V]),
+		}
+	}
+	return c
+}
+
+// Get returns the value stored under key, if it has not expired
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	value, ok := c.get(ctx, s, key)
+	s.mu.Unlock()
+	c.count(ctx, ok)
+	return value, ok
+}
+
+// Set stores value under key for the cache's TTL
+func (c *Cache[K, V]) Set(ctx context.Context, key K, value V) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.set(ctx, s, key, value)
+}
+
+// GetOrLoad returns the value stored under key, or loads it with fn and
+// stores it. Callers asking for a key while it loads wait for that load
+// rather than starting their own, and get its error too, which is not
+// stored. The load runs apart from its callers, with the first caller's
+// ctx values but not its cancellation, for at most loadTimeout, so one
+// caller going away does not fail the others; each caller stops waiting
+// when its own ctx is done.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (V, error) {
+	s := c.shard(key)
+	s.mu.Lock()
+	if value, ok := c.get(ctx, s, key); ok {
+		s.mu.Unlock()
+		c.count(ctx, true)
+		return value, nil
+	}
+	l, loading := s.loads[key]
+	if !loading {
+		l = &load[V]{done: make(chan struct{}), gen: s.gen, err: errLoadPanicked}
+		s.loads[key] = l
+		go c.load(context.WithoutCancel(ctx), s, key, l, fn)
+	}
+	s.mu.Unlock()
+	c.count(ctx, false)
+
+	select {
+	case <-l.done:
+		return l.value, l.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// load runs fn for the GetOrLoad l of key and stores its result, unless s
+// was cleared meanwhile
+func (c *Cache[K, V]) load(ctx context.Context, s *shard[K, V], key K, l *load[V], fn func(ctx context.Context) (V, error)) {
+	ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+	defer cancel()
+
+	// A panicking load fails its waiters rather than leaving them waiting
+	defer func() {
+		if p := recover(); p != nil {
+			logger.FromContext(ctx).Error("cache load panicked", "cache", c.name.Value.AsString(), "panic", p)
+		}
+		s.mu.Lock()
+		if s.loads[key] == l {
+			delete(s.loads, key)
+		}
+		if l.err == nil && l.gen == s.gen {
+			c.set(ctx, s, key, l.value)
+		}
+		s.mu.Unlock()
+		close(l.done)
+	}()
+	l.value, l.err = fn(ctx)
+}
+
+// Delete drops the value stored under key
+func (c *Cache[K, V]) Delete(key K) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.items[key]; ok {
+		s.order.Remove(el)
+		delete(s.items, key)
+	}
+}
+
+// Clear drops every value, such as after what they were read from changed.
+// Loads in flight finish for the callers waiting on them but do not store
+// their results, and later callers start loads of their own.
+func (c *Cache[K, V]) Clear() {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		s.order.Init()
+		clear(s.items)
+		clear(s.loads)
+		s.gen++
+		s.mu.Unlock()
+	}
+}
+
+// Len returns how many values are stored, including expired ones not yet
+// dropped
+func (c *Cache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += s.order.Len()
+		s.mu.Unlock()
+	}
+	return n
+}
+
+func (c *Cache[K, V]) shard(key K) *shard[K, V] {
+	return c.shards[maphash.Comparable(c.seed, key)%shardCount]
+}
+
+// get looks key up in s, which must be locked, dropping it if it expired
+func (c *Cache[K, V]) get(ctx context.Context, s *shard[K, V], key K) (V, bool) {
+	var zero V
+	el, ok := s.items[key]
+	if !ok {
+		return zero, false
+	}
+	it := el.Value.(*item[K, V])
+	if time.Now().After(it.expiresAt) {
+		s.order.Remove(el)
+		delete(s.items, key)
+		c.evicted(ctx, reasonExpired)
+		return zero, false
+	}
+	s.order.MoveToFront(el)
+	return it.value, true
+}
+
+// set stores value under key in s, which must be locked
+func (c *Cache[K, V]) set(ctx context.Context, s *shard[K, V], key K, value V) {
+	it := &item[K, V]{key: key, value: value, expiresAt: time.Now().Add(c.ttl)}
+	if el, ok := s.items[key]; ok {
+		el.Value = it
+		s.order.MoveToFront(el)
+		return
+	}
+	s.items[key] = s.order.PushFront(it)
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*item[K, V]).key)
+		c.evicted(ctx, reasonCapacity)
+	}
+}
+
+func (c *Cache[K, V]) count(ctx context.Context, hit bool) {
+	result := resultMiss
+	if hit {
+		result = resultHit
+	}
+	c.lookups.Add(ctx, 1, metric.WithAttributes(c.name, attribute.String("result", result)))
+}
+
+func (c *Cache[K, V]) evicted(ctx context.Context, reason string) {
+	c.evictions.Add(ctx, 1, metric.WithAttributes(c.name, attribute.String("reason", reason)))
+}
@@ -0,0 +1,97 @@
+package memcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoadOutlivesFirstCaller(t *testing.T) {
+	cache := New[string, int]("test", 10, time.Minute)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		close(started)
+		select {
+		case <-release:
+			return 1, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := cache.GetOrLoad(first, "k", load)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan error)
+	go func() {
+		v, err := cache.GetOrLoad(context.Background(), "k", func(context.Context) (int, error) {
+			return 0, errors.New("second load ran")
+		})
+		if err == nil && v != 1 {
+			err = errors.New("wrong value")
+		}
+		second <- err
+	}()
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller got %v, want context.Canceled", err)
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Errorf("second caller: %v", err)
+	}
+	if v, ok := cache.Get(context.Background(), "k"); !ok || v != 1 {
+		t.Errorf("stored %d, %v, want 1", v, ok)
+	}
+}
+
+func TestClearDropsLoadsInFlight(t *testing.T) {
+	cache := New[string, int]("test", 10, time.Minute)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v, err := cache.GetOrLoad(context.Background(), "k", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		if err != nil || v != 1 {
+			t.Errorf("caller of the cleared load got %d, %v", v, err)
+		}
+	}()
+	<-started
+
+	cache.Clear()
+	close(release)
+	<-done
+	if _, ok := cache.Get(context.Background(), "k"); ok {
+		t.Error("load started before Clear stored its result")
+	}
+
+	v, err := cache.GetOrLoad(context.Background(), "k", func(context.Context) (int, error) {
+		return 2, nil
+	})
+	if err != nil || v != 2 {
+		t.Errorf("load after Clear got %d, %v, want 2", v, err)
+	}
+}
+
+func TestGetOrLoadPanic(t *testing.T) {
+	cache := New[string, int]("test", 10, time.Minute)
+	_, err := cache.GetOrLoad(context.Background(), "k", func(context.Context) (int, error) {
+		panic("boom")
+	})
+	if !errors.Is(err, errLoadPanicked) {
+		t.Errorf("got %v, want errLoadPanicked", err)
+	}
+}
@@ -0,0 +1,49 @@
+package metrics
+
+// Business metrics are product KPIs rather than signs of the server's
+// health. They are recorded through package kpi and exported beside the
+// operational metrics under the business namespace, so product dashboards
+// can select them with {__name__=~"business_.*"}.
+
+// BusinessSignups counts users created
+var BusinessSignups = Definition{
+	Name:        "business.signups",
+	Description: "Users signed up",
+	Unit:        "{user}",
+	Kind:        KindCounter,
+}
+
+// BusinessActiveSessions is how many sessions have been seen recently
+var BusinessActiveSessions = Definition{
+	Name:        "business.sessions.active",
+	Description: "Sessions seen in the last 15 minutes",
+	Unit:        "{session}",
+	Kind:        KindGauge,
+}
+
+// BusinessJobsProcessed counts background jobs that finished, by kind and
+// whether they succeeded; attempts that will be retried are not counted
+var BusinessJobsProcessed = Definition{
+	Name:        "business.jobs.processed",
+	Description: "Background jobs processed by kind and result",
+	Unit:        "{job}",
+	Kind:        KindCounter,
+	Labels:      []string{"kind", "result"},
+}
+
+// BusinessEmailsSent counts email handed to a provider
+var BusinessEmailsSent = Definition{
+	Name:        "business.emails.sent",
+	Description: "Email sent",
+	Unit:        "{email}",
+	Kind:        KindCounter,
+}
+
+// Business lists the business metrics, which are exported on a dashboard
+// of their own
+var Business = []Definition{
+	BusinessSignups,
+	BusinessActiveSessions,
+	BusinessJobsProcessed,
+	BusinessEmailsSent,
+}
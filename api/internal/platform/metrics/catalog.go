@@ -0,0 +1,505 @@
+package metrics
+
+// HTTP server metrics are recorded by the server's metrics middleware.
+// http.route is the matched route pattern, and is absent for requests that
+// match no route.
+
+// HTTPServerRequests counts inbound HTTP requests
+var HTTPServerRequests = Definition{
+	Name:        "http.server.requests",
+	Description: "Inbound HTTP requests",
+	Unit:        "{request}",
+	Kind:        KindCounter,
+	Labels:      []string{"http.request.method", "http.route", "http.response.status_code"},
+}
+
+// HTTPServerDuration is the time to serve inbound HTTP requests
+var HTTPServerDuration = Definition{
+	Name:        "http.server.request.duration",
+	Description: "Duration of inbound HTTP requests",
+	Unit:        "s",
+	Kind:        KindHistogram,
+	Labels:      []string{"http.request.method", "http.route", "http.response.status_code"},
+	Buckets:     []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10},
+	Alerts: []Alert{
+		{
+			Name:        "HighServerErrorRate",
+			Expr:        `sum(rate({{metric}}_count{http_response_status_code=~"5.."}[5m])) / sum(rate({{metric}}_count[5m])) > 0.05`,
+			For:         "10m",
+			Severity:    "critical",
+			Summary:     "More than 5% of requests fail with 5xx",
+			Description: "The API has returned 5xx for over 5% of requests for 10 minutes.",
+		},
+		{
+			Name:        "HighRequestLatency",
+			Expr:        `histogram_quantile(0.95, sum by (le) (rate({{metric}}_bucket[5m]))) > 1`,
+			For:         "10m",
+			Severity:    "warning",
+			Summary:     "p95 request latency above 1s",
+			Description: "The 95th percentile request duration has exceeded 1 second for 10 minutes.",
+		},
+	},
+}
+
+// HTTPServerActiveRequests is the number of requests being served
+var HTTPServerActiveRequests = Definition{
+	Name:        "http.server.active_requests",
+	Description: "Inbound HTTP requests in flight",
+	Unit:        "{request}",
+	Kind:        KindUpDownCounter,
+	Labels:      []string{"http.request.method"},
+}
+
+// HTTPServerResponseSize is the size of response bodies
+var HTTPServerResponseSize = Definition{
+	Name:        "http.server.response.body.size",
+	Description: "Size of HTTP response bodies",
+	Unit:        "By",
+	Kind:        KindHistogram,
+	Labels:      []string{"http.request.method", "http.route", "http.response.status_code"},
+	Buckets:     []float64{100, 1000, 10_000, 100_000, 1_000_000, 10_000_000},
+}
+
+// HTTPServerCacheLookups counts lookups in the response cache by route and
+// result: hit, stale, or miss
+var HTTPServerCacheLookups = Definition{
+	Name:        "http.server.cache.lookups",
+	Description: "Response cache lookups by route and result",
+	Unit:        "{lookup}",
+	Kind:        KindCounter,
+	Labels:      []string{"http.route", "result"},
+}
+
+// MemcacheLookups counts lookups in the in-process caches of memcache by
+// cache and result: hit or miss
+var MemcacheLookups = Definition{
+	Name:        "memcache.lookups",
+	Description: "In-process cache lookups by cache and result",
+	Unit:        "{lookup}",
+	Kind:        KindCounter,
+	Labels:      []string{"cache", "result"},
+}
+
+// MemcacheEvictions counts entries dropped from the in-process caches of
+// memcache by cache and reason: expired or capacity
+var MemcacheEvictions = Definition{
+	Name:        "memcache.evictions",
+	Description: "In-process cache entries dropped by cache and reason",
+	Unit:        "{entry}",
+	Kind:        KindCounter,
+	Labels:      []string{"cache", "reason"},
+}
+
+// DBPoolConnections reports each database pool's connections by state
+var DBPoolConnections = Definition{
+	Name:        "db.client.connections",
+	Description: "Database pool connections by workload pool and state",
+	Unit:        "{connection}",
+	Kind:        KindGauge,
+	Labels:      []string{"pool", "state"},
+	Alerts: []Alert{
+		{
+			Name:        "DatabasePoolExhausted",
+			Expr:        `sum by (pool) ({{metric}}{state="acquired"}) / sum by (pool) ({{metric}}{state="max"}) > 0.9`,
+			For:         "10m",
+			Severity:    "warning",
+			Summary:     "Database pool {{ $labels.pool }} is nearly exhausted",
+			Description: "Over 90% of the {{ $labels.pool }} pool's connections have been in use for 10 minutes; requests wait for connections.",
+		},
+	},
+}
+
+// PanicsRecovered counts panics caught by subsystem panic boundaries
+var PanicsRecovered = Definition{
+	Name:        "panics.recovered",
+	Description: "Panics recovered by subsystem panic boundaries",
+	Unit:        "{panic}",
+	Kind:        KindCounter,
+	Labels:      []string{"subsystem"},
+	Alerts: []Alert{
+		{
+			Name:        "PanicsRecovered",
+			Expr:        `sum by (subsystem) (increase({{metric}}[15m])) > 0`,
+			Severity:    "warning",
+			Summary:     "Panics recovered in {{ $labels.subsystem }}",
+			Description: "{{ $value }} panics were recovered in {{ $labels.subsystem }} over the last 15 minutes; check the logs for stack traces.",
+		},
+	},
+}
+
+// DBHedgedReads counts latency-critical reads sent to read replicas by
+// outcome: answered before the hedge threshold (fast), or hedged and won by
+// the first or the second replica
+var DBHedgedReads = Definition{
+	Name:        "db.client.hedged_reads",
+	Description: "Replica reads by hedging outcome",
+	Unit:        "{read}",
+	Kind:        KindCounter,
+	Labels:      []string{"outcome"},
+}
+
+// DBFailovers counts failovers that reset the database pools
+var DBFailovers = Definition{
+	Name:        "db.failovers",
+	Description: "Database failovers that invalidated the connection pools",
+	Unit:        "{failover}",
+	Kind:        KindCounter,
+	Labels:      []string{"db.server.previous"},
+	Alerts: []Alert{
+		{
+			Name:        "DatabaseFailover",
+			Expr:        `sum(increase({{metric}}[15m])) > 0`,
+			Severity:    "info",
+			Summary:     "Database failover detected",
+			Description: "The connection pools followed a new primary in the last 15 minutes.",
+		},
+	},
+}
+
+// ProbeChecks counts self-check probe steps by outcome
+var ProbeChecks = Definition{
+	Name:        "probe.checks",
+	Description: "Self-check probe steps by outcome",
+	Unit:        "{check}",
+	Kind:        KindCounter,
+	Labels:      []string{"check", "result"},
+}
+
+// ProbeUp is 1 while a probe step last passed and 0 after it failed
+var ProbeUp = Definition{
+	Name:        "probe.up",
+	Description: "Whether the last self-check probe step passed",
+	Unit:        "1",
+	Kind:        KindGauge,
+	Labels:      []string{"check"},
+	Alerts: []Alert{
+		{
+			Name:        "SelfProbeFailing",
+			Expr:        `min by (check) ({{metric}}) == 0`,
+			For:         "5m",
+			Severity:    "critical",
+			Summary:     "Self-check probe {{ $labels.check }} failing",
+			Description: "The end-to-end self-check step {{ $labels.check }} has failed for 5 minutes.",
+		},
+	},
+}
+
+// LeaderIsLeader is 1 on the replica holding a leader lease and 0 elsewhere
+var LeaderIsLeader = Definition{
+	Name:        "leader.is_leader",
+	Description: "Whether this replica holds the leader lease",
+	Unit:        "1",
+	Kind:        KindGauge,
+	Labels:      []string{"leader.name"},
+	Alerts: []Alert{
+		{
+			Name:        "NoLeader",
+			Expr:        `max by (leader_name) ({{metric}}) == 0`,
+			For:         "5m",
+			Severity:    "critical",
+			Summary:     "No replica holds the {{ $labels.leader_name }} lease",
+			Description: "No replica has led {{ $labels.leader_name }} for 5 minutes, so singleton background jobs are not running.",
+		},
+	},
+}
+
+// LeaderTransitions counts leadership gained and lost by this replica
+var LeaderTransitions = Definition{
+	Name:        "leader.transitions",
+	Description: "Leader lease acquisitions and losses",
+	Unit:        "{transition}",
+	Kind:        KindCounter,
+	Labels:      []string{"leader.name", "transition"},
+	Alerts: []Alert{
+		{
+			Name:        "LeaderFlapping",
+			Expr:        `sum by (leader_name) (increase({{metric}}{transition="acquired"}[30m])) > 3`,
+			Severity:    "warning",
+			Summary:     "Leadership of {{ $labels.leader_name }} is flapping",
+			Description: "The {{ $labels.leader_name }} lease changed hands {{ $value }} times in 30 minutes; check database latency and replica restarts.",
+		},
+	},
+}
+
+// ClusterReplicas is the number of replicas each replica sees, by state:
+// alive, or healthy when also ready
+var ClusterReplicas = Definition{
+	Name:        "cluster.replicas",
+	Description: "Replicas seen through heartbeats by state",
+	Unit:        "{replica}",
+	Kind:        KindGauge,
+	Labels:      []string{"state"},
+}
+
+// ProjectionLag is how many user changes a read-model projection is behind
+var ProjectionLag = Definition{
+	Name:        "projection.lag",
+	Description: "User changes not yet applied to a read-model projection",
+	Unit:        "{change}",
+	Kind:        KindGauge,
+	Labels:      []string{"projection"},
+	Alerts: []Alert{
+		{
+			Name:        "ProjectionLagging",
+			Expr:        `max by (projection) ({{metric}}) > 1000`,
+			For:         "10m",
+			Severity:    "warning",
+			Summary:     "Projection {{ $labels.projection }} is falling behind",
+			Description: "{{ $labels.projection }} has been over 1000 changes behind for 10 minutes, so listings read from it are stale.",
+		},
+	},
+}
+
+// ConnectorRequests counts calls to external APIs by outcome
+var ConnectorRequests = Definition{
+	Name:        "connector.requests",
+	Description: "Requests to external APIs by connector and outcome",
+	Unit:        "{request}",
+	Kind:        KindCounter,
+	Labels:      []string{"connector", "outcome"},
+	Alerts: []Alert{
+		{
+			Name:        "ConnectorErrors",
+			Expr:        `sum by (connector) (rate({{metric}}{outcome!="ok"}[5m])) / sum by (connector) (rate({{metric}}[5m])) > 0.2`,
+			For:         "10m",
+			Severity:    "warning",
+			Summary:     "Requests to {{ $labels.connector }} are failing",
+			Description: "Over 20% of requests to {{ $labels.connector }} have failed for 10 minutes.",
+		},
+	},
+}
+
+// ConnectorUp is 1 while a connector's last health check passed
+var ConnectorUp = Definition{
+	Name:        "connector.up",
+	Description: "Whether the last health check of an external API passed",
+	Unit:        "1",
+	Kind:        KindGauge,
+	Labels:      []string{"connector"},
+	Alerts: []Alert{
+		{
+			Name:        "ConnectorDown",
+			Expr:        `min by (connector) ({{metric}}) == 0`,
+			For:         "10m",
+			Severity:    "warning",
+			Summary:     "Connector {{ $labels.connector }} is unhealthy",
+			Description: "The health check of {{ $labels.connector }} has failed for 10 minutes; check its credentials and the provider's status page.",
+		},
+	},
+}
+
+// EmbeddingsPending is how many profiles are waiting to be embedded
+var EmbeddingsPending = Definition{
+	Name:        "embeddings.pending",
+	Description: "User profiles not yet embedded for semantic search",
+	Unit:        "{profile}",
+	Kind:        KindGauge,
+	Labels:      []string{"model"},
+	Alerts: []Alert{
+		{
+			Name:        "EmbeddingsBacklog",
+			Expr:        `max by (model) ({{metric}}) > 1000`,
+			For:         "30m",
+			Severity:    "warning",
+			Summary:     "Semantic search embeddings are falling behind",
+			Description: "Over 1000 profiles have waited 30 minutes to be embedded, so semantic search misses them. Check the embeddings connector.",
+		},
+	},
+}
+
+// GeoBlockedRequests counts requests refused by the geo-blocking policy
+var GeoBlockedRequests = Definition{
+	Name:        "geo.blocked_requests",
+	Description: "Requests refused by the geo-blocking policy by client country",
+	Unit:        "{request}",
+	Kind:        KindCounter,
+	Labels:      []string{"country", "reason"},
+}
+
+// APIDeprecatedUsage counts uses of deprecated routes and fields by the
+// calling client, to find who still needs to migrate before a sunset
+var APIDeprecatedUsage = Definition{
+	Name:        "api.deprecated.usage",
+	Description: "Uses of deprecated API routes and fields by client",
+	Unit:        "{use}",
+	Kind:        KindCounter,
+	Labels:      []string{"surface", "client"},
+}
+
+// JobsQueued is how many queued jobs are ready to run or scheduled for
+// later, by priority lane
+var JobsQueued = Definition{
+	Name:        "jobs.queued",
+	Description: "Queued background jobs by priority lane and whether they are due",
+	Unit:        "{job}",
+	Kind:        KindGauge,
+	Labels:      []string{"priority", "state"},
+	Alerts: []Alert{
+		{
+			Name:        "JobQueueBacklog",
+			Expr:        `max by (priority) ({{metric}}{state="ready"}) > 1000`,
+			For:         "15m",
+			Severity:    "warning",
+			Summary:     "Job queue backlog in the {{ $labels.priority }} lane",
+			Description: "Over 1000 due jobs have waited in the {{ $labels.priority }} lane for 15 minutes. Check the job workers and JOBS_LANES.",
+		},
+	},
+}
+
+// JobsWait is how long due jobs waited before a worker claimed them
+var JobsWait = Definition{
+	Name:        "jobs.wait.duration",
+	Description: "Time from when a job was due until a worker claimed it",
+	Unit:        "s",
+	Kind:        KindHistogram,
+	Labels:      []string{"kind", "priority"},
+	Alerts: []Alert{
+		{
+			Name:        "JobWaitHigh",
+			Expr:        `histogram_quantile(0.95, sum by (le, priority) (rate({{metric}}_bucket[10m]))) > 60`,
+			For:         "15m",
+			Severity:    "warning",
+			Summary:     "Jobs in the {{ $labels.priority }} lane wait over a minute",
+			Description: "The 95th percentile wait of due jobs has exceeded 60 seconds for 15 minutes.",
+		},
+	},
+}
+
+// JobsDuration is how long job attempts took to run, by outcome
+var JobsDuration = Definition{
+	Name:        "jobs.run.duration",
+	Description: "Duration of background job attempts",
+	Unit:        "s",
+	Kind:        KindHistogram,
+	Labels:      []string{"kind", "result"},
+}
+
+// EmailMessages counts outbound email by outcome: sent, suppressed before
+// sending, and the failures, deliveries, bounces, and complaints reported
+// afterwards
+var EmailMessages = Definition{
+	Name:        "email.messages",
+	Description: "Outbound email messages by delivery status",
+	Unit:        "{message}",
+	Kind:        KindCounter,
+	Labels:      []string{"status"},
+	Alerts: []Alert{
+		{
+			Name:        "EmailBounceRateHigh",
+			Expr:        `sum(rate({{metric}}{status=~"bounced|complained"}[1h])) / sum(rate({{metric}}{status="sent"}[1h])) > 0.05`,
+			For:         "30m",
+			Severity:    "warning",
+			Summary:     "Email bounce and complaint rate is high",
+			Description: "Over 5% of sent email has bounced or drawn complaints for 30 minutes, which risks the sending reputation. Check where the recipients come from.",
+		},
+	},
+}
+
+// EmailProviderSends counts send attempts per email provider: ok,
+// rejected recipients, and failures that moved on to the next provider
+var EmailProviderSends = Definition{
+	Name:        "email.provider.sends",
+	Description: "Email send attempts by provider and outcome",
+	Unit:        "{attempt}",
+	Kind:        KindCounter,
+	Labels:      []string{"provider", "outcome"},
+}
+
+// EmailProviderHealthy is 1 while an email provider is used and 0 while it
+// is skipped after repeated failures
+var EmailProviderHealthy = Definition{
+	Name:        "email.provider.healthy",
+	Description: "Whether an email provider is in use (1) or failed over (0)",
+	Unit:        "1",
+	Kind:        KindGauge,
+	Labels:      []string{"provider"},
+	Alerts: []Alert{
+		{
+			Name:        "EmailProviderDown",
+			Expr:        `min by (provider) ({{metric}}) == 0`,
+			For:         "15m",
+			Severity:    "warning",
+			Summary:     "Email provider {{ $labels.provider }} is failing",
+			Description: "Email has been failing over from {{ $labels.provider }} for 15 minutes. Check the provider's status page and credentials.",
+		},
+	},
+}
+
+// Batch command metrics are recorded once per run of migrate, task, and
+// backup. The commands exit before any scrape, so they push them over OTLP
+// or to a Prometheus Pushgateway as they finish. batch.command is the
+// command and subcommand, such as "migrate up".
+
+// BatchRuns counts batch command runs by outcome
+var BatchRuns = Definition{
+	Name:        "batch.runs",
+	Description: "Batch command runs",
+	Unit:        "{run}",
+	Kind:        KindCounter,
+	Labels:      []string{"batch.command", "batch.outcome"},
+}
+
+// BatchDuration is how long batch command runs take
+var BatchDuration = Definition{
+	Name:        "batch.duration",
+	Description: "Duration of batch command runs",
+	Unit:        "s",
+	Kind:        KindHistogram,
+	Labels:      []string{"batch.command", "batch.outcome"},
+	Buckets:     []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+}
+
+// BatchLastSuccess is the Unix time a batch command last succeeded. Failed
+// runs leave it alone, so a Pushgateway keeps the previous success.
+var BatchLastSuccess = Definition{
+	Name:        "batch.last_success",
+	Description: "Unix time of the last successful batch command run",
+	Unit:        "s",
+	Kind:        KindGauge,
+	Labels:      []string{"batch.command"},
+	Alerts: []Alert{
+		{
+			Name:        "BackupNotSucceeding",
+			Expr:        `time() - max({{metric}}{batch_command="backup run"}) > 2 * 86400`,
+			For:         "1h",
+			Severity:    "warning",
+			Summary:     "No successful backup in two days",
+			Description: "backup run has not succeeded for over two days. Check the logs of the scheduled backup job.",
+		},
+	},
+}
+
+// All lists every metric the server emits
+var All = []Definition{
+	HTTPServerRequests,
+	HTTPServerDuration,
+	HTTPServerActiveRequests,
+	HTTPServerResponseSize,
+	HTTPServerCacheLookups,
+	MemcacheLookups,
+	MemcacheEvictions,
+	DBPoolConnections,
+	DBHedgedReads,
+	PanicsRecovered,
+	DBFailovers,
+	ProbeChecks,
+	ProbeUp,
+	LeaderIsLeader,
+	LeaderTransitions,
+	ClusterReplicas,
+	ProjectionLag,
+	ConnectorRequests,
+	ConnectorUp,
+	EmbeddingsPending,
+	GeoBlockedRequests,
+	APIDeprecatedUsage,
+	JobsQueued,
+	JobsWait,
+	JobsDuration,
+	EmailMessages,
+	EmailProviderSends,
+	EmailProviderHealthy,
+	BatchRuns,
+	BatchDuration,
+	BatchLastSuccess,
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Dashboard renders a Grafana dashboard with one panel per definition
+func Dashboard(title string, defs []Definition) ([]byte, error) {
+	panels := make([]map[string]any, 0, len(defs))
+	for i, d := range defs {
+		panels = append(panels, map[string]any{
+			"id":          i + 1,
+			"type":        "timeseries",
+			"title":       d.Description,
+			"description": d.Name,
+			"datasource":  map[string]any{"type": "prometheus", "uid": "${datasource}"},
+			"gridPos":     map[string]any{"x": (i % 2) * 12, "y": (i / 2) * 8, "w": 12, "h": 8},
+			"fieldConfig": map[string]any{"defaults": map[string]any{"unit": grafanaUnit(d)}, "overrides": []any{}},
+			"targets":     panelTargets(d),
+		})
+	}
+
+	dashboard := map[string]any{
+		"title":         title,
+		"uid":           sanitize(strings.ToLower(title)),
+		"schemaVersion": 39,
+		"time":          map[string]any{"from": "now-6h", "to": "now"},
+		"refresh":       "30s",
+		"tags":          []string{"generated"},
+		"templating": map[string]any{"list": []any{map[string]any{
+			"name":  "datasource",
+			"type":  "datasource",
+			"query": "prometheus",
+		}}},
+		"panels": panels,
+	}
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+func panelTargets(d Definition) []map[string]any {
+	name := d.PrometheusName()
+	by := strings.Join(d.PrometheusLabels(), ", ")
+	switch d.Kind {
+	case KindHistogram:
+		var targets []map[string]any
+		for _, q := range []struct{ value, label string }{{"0.5", "p50"}, {"0.95", "p95"}, {"0.99", "p99"}} {
+			targets = append(targets, map[string]any{
+				"refId":        q.label,
+				"expr":         fmt.Sprintf("histogram_quantile(%s, sum by (le) (rate(%s_bucket[$__rate_interval])))", q.value, name),
+				"legendFormat": q.label,
+			})
+		}
+		return targets
+	case KindCounter:
+		return []map[string]any{{
+			"refId":        "A",
+			"expr":         fmt.Sprintf("sum by (%s) (rate(%s[$__rate_interval]))", by, name),
+			"legendFormat": legend(d),
+		}}
+	default:
+		return []map[string]any{{
+			"refId":        "A",
+			"expr":         fmt.Sprintf("sum by (%s) (%s)", by, name),
+			"legendFormat": legend(d),
+		}}
+	}
+}
+
+func legend(d Definition) string {
+	var parts []string
+	for _, l := range d.PrometheusLabels() {
+		parts = append(parts, "{{"+l+"}}")
+	}
+	if len(parts) == 0 {
+		return "__auto"
+	}
+	return strings.Join(parts, " ")
+}
+
+func grafanaUnit(d Definition) string {
+	switch {
+	case d.Kind == KindCounter:
+		return "ops"
+	case d.Unit == "s":
+		return "s"
+	case d.Unit == "ms":
+		return "ms"
+	case d.Unit == "By":
+		return "bytes"
+	default:
+		return "short"
+	}
+}
+
+// AlertRules renders a Prometheus rule file with every definition's alerts
+func AlertRules(group string, defs []Definition) []byte {
+	var b strings.Builder
+	b.WriteString("groups:\n")
+	fmt.Fprintf(&b, "  - name: %s\n", group)
+	b.WriteString("    rules:\n")
+	for _, d := range defs {
+		for _, a := range d.Alerts {
+			fmt.Fprintf(&b, "      - alert: %s\n", a.Name)
+			fmt.Fprintf(&b, "        expr: %q\n", strings.ReplaceAll(a.Expr, "{{metric}}", d.PrometheusName()))
+			if a.For != "" {
+				fmt.Fprintf(&b, "        for: %s\n", a.For)
+			}
+			b.WriteString("        labels:\n")
+			fmt.Fprintf(&b, "          severity: %s\n", a.Severity)
+			b.WriteString("        annotations:\n")
+			fmt.Fprintf(&b, "          summary: %q\n", a.Summary)
+			fmt.Fprintf(&b, "          description: %q\n", a.Description)
+		}
+	}
+	return []byte(b.String())
+}
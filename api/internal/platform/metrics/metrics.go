@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Kind is the instrument type of a metric
+type Kind string
+
+const (
+	KindCounter       Kind = "counter"
+	KindHistogram     Kind = "histogram"
+	KindGauge         Kind = "gauge"
+	KindUpDownCounter Kind = "updowncounter"
+)
+
+// Definition describes a metric the server emits. Instruments are created
+// from definitions so exported dashboards and alerts match the code.
+type Definition struct {
+	// Name is the OpenTelemetry instrument name, e.g. "db.failovers"
+	Name        string
+	Description string
+	// Unit is a UCUM unit; annotations such as "{panic}" are dropped from
+	// the Prometheus name
+	Unit string
+	Kind Kind
+	// Labels are the attribute keys recorded with each measurement
+	Labels []string
+	// Buckets are the histogram bucket boundaries, the SDK defaults when
+	// empty
+	Buckets []float64
+	Alerts  []Alert
+}
+
+// Alert is a Prometheus alerting rule on a metric. Expr may reference the
+// metric's Prometheus series as {{metric}}.
+type Alert struct {
+	Name        string
+	Expr        string
+	For         string
+	Severity    string
+	Summary     string
+	Description string
+}
+
+// unitSuffixes maps UCUM units to the suffix Prometheus exporters append
+var unitSuffixes = map[string]string{
+	"s":  "seconds",
+	"ms": "milliseconds",
+	"By": "bytes",
+	"1":  "ratio",
+}
+
+// PrometheusName returns the base series name Prometheus exporters derive
+// from the instrument: dots become underscores, the unit is appended, and
+// counters end in _total
+func (d Definition) PrometheusName() string {
+	name := sanitize(d.Name)
+	if suffix, ok := unitSuffixes[d.Unit]; ok && !strings.HasSuffix(name, "_"+suffix) {
+		name += "_" + suffix
+	}
+	if d.Kind == KindCounter {
+		name += "_total"
+	}
+	return name
+}
+
+// PrometheusLabels returns the label names Prometheus exporters derive from
+// the attribute keys
+func (d Definition) PrometheusLabels() []string {
+	labels := make([]string, len(d.Labels))
+	for i, l := range d.Labels {
+		labels[i] = sanitize(l)
+	}
+	return labels
+}
+
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// Int64Counter creates the counter for d from the global meter, which is a
+// no-op until a meter provider is installed
+func Int64Counter(d Definition) metric.Int64Counter {
+	counter, err := otel.Meter("starterkit").Int64Counter(d.Name,
+		metric.WithDescription(d.Description),
+		metric.WithUnit(d.Unit),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	return counter
+}
+
+// Int64Gauge creates the gauge for d from the global meter
+func Int64Gauge(d Definition) metric.Int64Gauge {
+	gauge, err := otel.Meter("starterkit").Int64Gauge(d.Name,
+		metric.WithDescription(d.Description),
+		metric.WithUnit(d.Unit),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	return gauge
+}
+
+// Int64UpDownCounter creates the up-down counter for d from the global
+// meter
+func Int64UpDownCounter(d Definition) metric.Int64UpDownCounter {
+	counter, err := otel.Meter("starterkit").Int64UpDownCounter(d.Name,
+		metric.WithDescription(d.Description),
+		metric.WithUnit(d.Unit),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	return counter
+}
+
+// Float64Histogram creates the histogram for d from the global meter
+func Float64Histogram(d Definition) metric.Float64Histogram {
+	opts := []metric.Float64HistogramOption{
+		metric.WithDescription(d.Description),
+		metric.WithUnit(d.Unit),
+	}
+	if len(d.Buckets) > 0 {
+		opts = append(opts, metric.WithExplicitBucketBoundaries(d.Buckets...))
+	}
+	histogram, err := otel.Meter("starterkit").Float64Histogram(d.Name, opts...)
+	if err != nil {
+		otel.Handle(err)
+	}
+	return histogram
+}
+
+// Int64Histogram creates the histogram for d from the global meter
+func Int64Histogram(d Definition) metric.Int64Histogram {
+	opts := []metric.Int64HistogramOption{
+		metric.WithDescription(d.Description),
+		metric.WithUnit(d.Unit),
+	}
+	if len(d.Buckets) > 0 {
+		opts = append(opts, metric.WithExplicitBucketBoundaries(d.Buckets...))
+	}
+	histogram, err := otel.Meter("starterkit").Int64Histogram(d.Name, opts...)
+	if err != nil {
+		otel.Handle(err)
+	}
+	return histogram
+}
+
+// Int64ObservableGauge registers callback to report the gauge for d on
+// every collection
+func Int64ObservableGauge(d Definition, callback metric.Int64Callback) (metric.Int64ObservableGauge, error) {
+	return otel.Meter("starterkit").Int64ObservableGauge(d.Name,
+		metric.WithDescription(d.Description),
+		metric.WithUnit(d.Unit),
+		metric.WithInt64Callback(callback),
+	)
+}
@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+const template = `-- +goose Up
+-- Key new tables with id UUID PRIMARY KEY DEFAULT uuid_generate_v7(), or
+-- generate the ID in Go with ids.NewV7(); random UUIDs scatter inserts
+-- across the index. migrate create -table writes such a table.
+
+-- +goose Down
+`
+
+// tableTemplate creates a table keyed by a time-ordered UUID. sqlc maps
+// the key to pgtype.UUID; ids.PG converts IDs generated in Go.
+const tableTemplate = `-- +goose Up
+CREATE TABLE %[1]s (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS %[1]s;
+`
+
+var (
+	nonWord   = regexp.MustCompile(`[^a-z0-9]+`)
+	tableName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+)
+
+// Create writes an SQL migration to dir, numbered after the last one, and
+// returns its path. The migration is empty, or creates table when one is
+// named.
+func Create(dir, name, table string) (string, error) {
+	name = strings.Trim(nonWord.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", errors.New("migration name must contain letters or digits")
+	}
+	content := template
+	if table != "" {
+		if !tableName.MatchString(table) {
+			return "", fmt.Errorf("invalid table name: %s", table)
+		}
+		content = fmt.Sprintf(tableTemplate, table)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+	var last int64
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		if version, err := goose.NumericComponent(entry.Name()); err == nil {
+			last = max(last, version)
+		}
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%03d_%s.sql", last+1, name))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create migration: %w", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write migration: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write migration: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,130 @@
+// Package migrate applies the SQL migrations embedded from db/migrations,
+// then those of registered modules, each module versioned in its own
+// goose_db_version_<module> table. Every run holds a Postgres advisory
+// lock, so servers starting together apply each migration once.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"time"
+
+	"starterkit/db/migrations"
+	"starterkit/internal/platform/module"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// Migration is an embedded migration and whether it has been applied
+type Migration struct {
+	// Module is empty for the shared migrations
+	Module    string
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// source is a set of migrations with its own version table
+type source struct {
+	module string
+	fsys   fs.FS
+	table  string
+}
+
+// sources lists the shared migrations, then those of modules
+func sources() []source {
+	list := []source{{fsys: migrations.FS, table: goose.DefaultTablename}}
+	for _, m := range module.All() {
+		if fsys := m.Migrations(); fsys != nil {
+			list = append(list, source{module: m.Name(), fsys: fsys, table: goose.DefaultTablename + "_" + m.Name()})
+		}
+	}
+	return list
+}
+
+// Up applies all pending migrations
+func Up(ctx context.Context, pool *pgxpool.Pool, logger *slog.Logger) error {
+	for _, src := range sources() {
+		err := withProvider(pool, src, func(provider *goose.Provider) error {
+			results, err := provider.Up(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to apply migrations: %w", err)
+			}
+			for _, result := range results {
+				logger.Info("applied migration", "module", src.module, "version", result.Source.Version, "duration", result.Duration)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down rolls back the most recently applied shared migration
+func Down(ctx context.Context, pool *pgxpool.Pool, logger *slog.Logger) error {
+	return withProvider(pool, sources()[0], func(provider *goose.Provider) error {
+		result, err := provider.Down(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to roll back migration: %w", err)
+		}
+		logger.Info("rolled back migration", "version", result.Source.Version, "duration", result.Duration)
+		return nil
+	})
+}
+
+// Status lists the shared migrations in version order, then those of each
+// module
+func Status(ctx context.Context, pool *pgxpool.Pool) ([]Migration, error) {
+	var list []Migration
+	for _, src := range sources() {
+		err := withProvider(pool, src, func(provider *goose.Provider) error {
+			statuses, err := provider.Status(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read migration status: %w", err)
+			}
+			for _, s := range statuses {
+				list = append(list, Migration{
+					Module:    src.module,
+					Version:   s.Source.Version,
+					Name:      s.Source.Path,
+					Applied:   s.State == goose.StateApplied,
+					AppliedAt: s.AppliedAt,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func withProvider(pool *pgxpool.Pool, src source, fn func(*goose.Provider) error) error {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return fmt.Errorf("failed to create migration lock: %w", err)
+	}
+
+	sqlDB := stdlib.OpenDBFromPool(pool)
+	defer sqlDB.Close()
+
+	store, err := database.NewStore(database.DialectPostgres, src.table)
+	if err != nil {
+		return fmt.Errorf("failed to create migration store: %w", err)
+	}
+	provider, err := goose.NewProvider("", sqlDB, src.fsys, goose.WithStore(store), goose.WithSessionLocker(locker))
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return fn(provider)
+}
@@ -0,0 +1,75 @@
+package moderator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTP delegates moderation to an external API. The API receives
+// {"field": "...", "text": "..."} and must respond with
+// {"action": "allow|sanitize|flag|reject", "sanitized": "...", "reasons": [...]}.
+type HTTP struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewHTTP creates a moderator for the API at url; token is sent as a bearer token if set
+func NewHTTP(url, token string, timeout time.Duration) *HTTP {
+	return &HTTP{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Check sends text to the moderation API
+func (h *HTTP) Check(ctx context.Context, field, text string) (Verdict, error) {
+	payload, err := json.Marshal(map[string]string{"field": field, "text": text})
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(payload))
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to create moderation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to call moderation API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Verdict{}, fmt.Errorf("moderation API returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Action    string   `json:"action"`
+		Sanitized string   `json:"sanitized"`
+		Reasons   []string `json:"reasons"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Verdict{}, fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+	if _, ok := severity[body.Action]; !ok {
+		return Verdict{}, fmt.Errorf("moderation API returned unknown action %q", body.Action)
+	}
+	if body.Action == ActionSanitize && body.Sanitized == "" {
+		return Verdict{}, fmt.Errorf("moderation API returned sanitize without sanitized text")
+	}
+	return Verdict{
+		Action:    body.Action,
+		Sanitized: body.Sanitized,
+		Reasons:   body.Reasons,
+	}, nil
+}
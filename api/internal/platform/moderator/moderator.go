@@ -0,0 +1,100 @@
+package moderator
+
+import (
+	"context"
+	"fmt"
+
+	"starterkit/internal/config"
+)
+
+// Actions a moderator can take on a piece of text, in increasing severity
+const (
+	ActionAllow    = "allow"
+	ActionSanitize = "sanitize"
+	ActionFlag     = "flag"
+	ActionReject   = "reject"
+)
+
+var severity = map[string]int{
+	ActionAllow:    0,
+	ActionSanitize: 1,
+	ActionFlag:     2,
+	ActionReject:   3,
+}
+
+// Verdict is a moderator's decision about a piece of text. Sanitized holds
+// the replacement text when Action is sanitize.
+type Verdict struct {
+	Action    string
+	Sanitized string
+	Reasons   []string
+}
+
+// Moderator inspects user-generated text. field names the kind of content
+// (e.g. "name", "bio") so providers can apply field-specific rules.
+type Moderator interface {
+	Check(ctx context.Context, field, text string) (Verdict, error)
+}
+
+// New creates the moderator selected in configuration
+func New(cfg config.ModerationConfig) (Moderator, error) {
+	switch cfg.Backend {
+	case "none", "":
+		return Allow{}, nil
+	case "wordlist":
+		return NewWordList(cfg.RejectWords, cfg.FlagWords, cfg.SanitizeWords), nil
+	case "http":
+		if cfg.HTTPURL == "" {
+			return nil, fmt.Errorf("http moderator requires a URL")
+		}
+		return NewHTTP(cfg.HTTPURL, cfg.HTTPToken, cfg.Timeout), nil
+	case "chain":
+		if cfg.HTTPURL == "" {
+			return nil, fmt.Errorf("chain moderator requires a URL")
+		}
+		return Chain{
+			NewWordList(cfg.RejectWords, cfg.FlagWords, cfg.SanitizeWords),
+			NewHTTP(cfg.HTTPURL, cfg.HTTPToken, cfg.Timeout),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported moderation backend: %s", cfg.Backend)
+	}
+}
+
+// Allow accepts all text, for development without moderation
+type Allow struct{}
+
+// Check allows text unchanged
+func (Allow) Check(ctx context.Context, field, text string) (Verdict, error) {
+	return Verdict{Action: ActionAllow}, nil
+}
+
+// Chain runs moderators in order. Sanitized output feeds the next
+// moderator, and the most severe action wins.
+type Chain []Moderator
+
+// Check runs every moderator in the chain
+func (c Chain) Check(ctx context.Context, field, text string) (Verdict, error) {
+	result := Verdict{Action: ActionAllow}
+	current := text
+	for _, m := range c {
+		v, err := m.Check(ctx, field, current)
+		if err != nil {
+			return Verdict{}, err
+		}
+		if v.Action == ActionSanitize {
+			current = v.Sanitized
+		}
+		if severity[v.Action] > severity[result.Action] {
+			result.Action = v.Action
+		}
+		result.Reasons = append(result.Reasons, v.Reasons...)
+	}
+	if current != text {
+		result.Sanitized = current
+		if result.Action == ActionAllow {
+			result.Action = ActionSanitize
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package moderator
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// WordList moderates text against configured word lists. Matching is
+// case-insensitive on whole words.
+type WordList struct {
+	reject   *regexp.Regexp
+	flag     *regexp.Regexp
+	sanitize *regexp.Regexp
+}
+
+// NewWordList creates a word-list moderator; empty lists are skipped
+func NewWordList(reject, flag, sanitize []string) *WordList {
+	return &WordList{
+		reject:   compileWords(reject),
+		flag:     compileWords(flag),
+		sanitize: compileWords(sanitize),
+	}
+}
+
+// Check applies the reject, flag, and sanitize lists in that order
+func (w *WordList) Check(ctx context.Context, field, text string) (Verdict, error) {
+	if matches := findAll(w.reject, text); len(matches) > 0 {
+		return Verdict{Action: ActionReject, Reasons: reasons("blocked term", matches)}, nil
+	}
+
+	verdict := Verdict{Action: ActionAllow}
+	if matches := findAll(w.sanitize, text); len(matches) > 0 {
+		verdict.Action = ActionSanitize
+		verdict.Sanitized = w.sanitize.ReplaceAllStringFunc(text, func(m string) string {
+			return strings.Repeat("*", len([]rune(m)))
+		})
+		verdict.Reasons = reasons("masked term", matches)
+	}
+	if matches := findAll(w.flag, text); len(matches) > 0 {
+		verdict.Action = ActionFlag
+		verdict.Reasons = append(verdict.Reasons, reasons("review term", matches)...)
+	}
+	return verdict, nil
+}
+
+func compileWords(words []string) *regexp.Regexp {
+	var quoted []string
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
+
+func findAll(re *regexp.Regexp, text string) []string {
+	if re == nil {
+		return nil
+	}
+	return re.FindAllString(text, -1)
+}
+
+func reasons(kind string, matches []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, m := range matches {
+		m = strings.ToLower(m)
+		if !seen[m] {
+			seen[m] = true
+			out = append(out, kind+": "+m)
+		}
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+// Package module lets feature packages plug themselves into the server.
+// A feature package registers its Module from an init function, and the
+// server builds every registered module and lets it add its routes,
+// background jobs, startup and shutdown hooks, and readiness check, so
+// adding a feature does not mean wiring it by hand in the server package.
+// Migrations of modules are applied after the shared ones in db/migrations.
+package module
+
+import (
+	"io/fs"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"starterkit/internal/platform/health"
+	"starterkit/internal/platform/lifecycle"
+	"starterkit/internal/platform/scheduler"
+	"starterkit/internal/platform/wiring"
+)
+
+// Module is a feature package's entry point
+type Module interface {
+	// Name identifies the module in logs, readiness reports, and its
+	// migration version table; lowercase letters and underscores
+	Name() string
+	// Init builds the module's services from the components in c, which
+	// it may wiring.Use. It is called before the other methods, except
+	// Migrations.
+	Init(c *wiring.Container) error
+	RegisterRoutes(r Routes)
+	RegisterJobs(s *scheduler.Scheduler)
+	// RegisterHooks appends work to run when the server starts, before it
+	// serves requests, and when it stops, once it no longer does
+	RegisterHooks(l *lifecycle.Manager)
+	// Migrations are the module's own goose migrations, or nil
+	Migrations() fs.FS
+	// Health is a readiness check of what the module depends on, or nil
+	Health() health.Check
+}
+
+// Router registers handlers for "METHOD /path" patterns
+type Router interface {
+	Handle(pattern string, handler http.Handler)
+	HandleFunc(pattern string, handler http.HandlerFunc)
+}
+
+// Routes are the groups a module registers its routes in
+type Routes struct {
+	// Public routes are served at the root without authentication, for
+	// links and feeds opened outside the app
+	Public Router
+	// API routes are served under /api/v1
+	API Router
+	// Admin routes are served under /admin to callers with the admin role
+	Admin Router
+}
+
+// Base implements the optional methods of Module as no-ops, for modules
+// to embed
+type Base struct{}
+
+func (Base) RegisterRoutes(Routes)             {}
+func (Base) RegisterJobs(*scheduler.Scheduler) {}
+func (Base) RegisterHooks(*lifecycle.Manager)  {}
+func (Base) Migrations() fs.FS                 { return nil }
+func (Base) Health() health.Check              { return nil }
+
+var (
+	mu      sync.Mutex
+	modules []Module
+)
+
+// Register adds m to the modules the server builds. It is meant to be
+// called from init functions and panics when the name is taken.
+func Register(m Module) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, existing := range modules {
+		if existing.Name() == m.Name() {
+			panic("module: " + m.Name() + " is registered twice")
+		}
+	}
+	modules = append(modules, m)
+}
+
+// All returns the registered modules ordered by name
+func All() []Module {
+	mu.Lock()
+	defer mu.Unlock()
+	all := slices.Clone(modules)
+	slices.SortFunc(all, func(a, b Module) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return all
+}
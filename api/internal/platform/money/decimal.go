@@ -0,0 +1,154 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// jsonMoney is the wire format. Amounts travel as decimal strings so that
+// clients never round-trip them through floating point.
+type jsonMoney struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes m as {"amount":"12.34","currency":"USD"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{
+		Amount:   m.Decimal(),
+		Currency: m.Currency(),
+	})
+}
+
+// UnmarshalJSON decodes {"amount":"12.34","currency":"USD"}
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v jsonMoney
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := Parse(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Numeric converts m to a NUMERIC value in major units for storage
+func (m Money) Numeric() pgtype.Numeric {
+	return pgtype.Numeric{
+		Int:   big.NewInt(m.amount),
+		Exp:   int32(-m.Scale()),
+		Valid: true,
+	}
+}
+
+// FromNumeric converts a NUMERIC value in major units to an amount in the
+// given currency. Values with more precision than the currency allows are
+// rejected rather than rounded.
+func FromNumeric(n pgtype.Numeric, code string) (Money, error) {
+	cur, err := parseCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	if !n.Valid || n.NaN || n.InfinityModifier != pgtype.Finite {
+		return Money{}, fmt.Errorf("%w: not a finite number", ErrInvalidAmount)
+	}
+
+	// Rescale so the exponent matches the currency's minor unit
+	minor := new(big.Int).Set(n.Int)
+	shift := int(n.Exp) + scaleOf(cur)
+	ten := big.NewInt(10)
+	if shift > 0 {
+		minor.Mul(minor, new(big.Int).Exp(ten, big.NewInt(int64(shift)), nil))
+	} else if shift < 0 {
+		var rem big.Int
+		minor.QuoRem(minor, new(big.Int).Exp(ten, big.NewInt(int64(-shift)), nil), &rem)
+		if rem.Sign() != 0 {
+			return Money{}, fmt.Errorf("%w: too many decimal places for %s", ErrInvalidAmount, cur)
+		}
+	}
+
+	if !minor.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: minor.Int64(), currency: cur}, nil
+}
+
+// parseDecimal converts a decimal string to minor units at the given scale
+func parseDecimal(s string, scale int) (int64, error) {
+	if s == "" {
+		return 0, fmt.Errorf("%w: empty", ErrInvalidAmount)
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && frac == "") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(frac) > scale {
+		return 0, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, s, scale)
+	}
+	frac += strings.Repeat("0", scale-len(frac))
+
+	for _, part := range []string{whole, frac} {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+			}
+		}
+	}
+
+	// Parse as unsigned so the full negative range is reachable
+	digits := strings.TrimLeft(whole+frac, "0")
+	if digits == "" {
+		return 0, nil
+	}
+	u, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, ErrOverflow
+	}
+	if negative {
+		if u > uint64(math.MaxInt64)+1 {
+			return 0, ErrOverflow
+		}
+		return int64(-u), nil
+	}
+	if u > math.MaxInt64 {
+		return 0, ErrOverflow
+	}
+	return int64(u), nil
+}
+
+// formatDecimal renders minor units as a decimal string at the given scale
+func formatDecimal(minor int64, scale int) string {
+	sign := ""
+	u := uint64(minor)
+	if minor < 0 {
+		sign = "-"
+		u = uint64(-minor)
+	}
+
+	digits := strconv.FormatUint(u, 10)
+	if scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
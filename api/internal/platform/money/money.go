@@ -0,0 +1,243 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+
+	"golang.org/x/text/currency"
+)
+
+var (
+	// ErrInvalidCurrency is returned for codes that are not ISO 4217 currencies
+	ErrInvalidCurrency = errors.New("invalid currency")
+	// ErrInvalidAmount is returned when an amount cannot be represented exactly
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrCurrencyMismatch is returned when combining amounts in different currencies
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	// ErrOverflow is returned when a result does not fit in int64 minor units
+	ErrOverflow = errors.New("amount overflow")
+)
+
+// Money is an exact amount stored as integer minor units (e.g. cents) of a currency
+type Money struct {
+	amount   int64
+	currency currency.Unit
+}
+
+// New creates an amount from minor units of the currency with the given ISO code
+func New(minorUnits int64, code string) (Money, error) {
+	cur, err := parseCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{amount: minorUnits, currency: cur}, nil
+}
+
+// Zero returns a zero amount in the given currency
+func Zero(code string) (Money, error) {
+	return New(0, code)
+}
+
+// Parse creates an amount from a decimal string such as "12.34". More
+// fractional digits than the currency allows is an error, never a rounding.
+func Parse(s, code string) (Money, error) {
+	cur, err := parseCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+
+	minor, err := parseDecimal(strings.TrimSpace(s), scaleOf(cur))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{amount: minor, currency: cur}, nil
+}
+
+// MinorUnits returns the amount in minor units of the currency
+func (m Money) MinorUnits() int64 {
+	return m.amount
+}
+
+// Currency returns the ISO 4217 currency code
+func (m Money) Currency() string {
+	return m.currency.String()
+}
+
+// Unit returns the currency for use with locale formatting
+func (m Money) Unit() currency.Unit {
+	return m.currency
+}
+
+// Scale returns the number of minor unit digits of the currency
+func (m Money) Scale() int {
+	return scaleOf(m.currency)
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// Add returns m + o
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	sum := m.amount + o.amount
+	if (o.amount > 0 && sum < m.amount) || (o.amount < 0 && sum > m.amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: sum, currency: m.currency}, nil
+}
+
+// Sub returns m - o
+func (m Money) Sub(o Money) (Money, error) {
+	if o.amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(Money{amount: -o.amount, currency: o.currency})
+}
+
+// Neg returns -m
+func (m Money) Neg() (Money, error) {
+	if m.amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: -m.amount, currency: m.currency}, nil
+}
+
+// Mul returns m multiplied by an integer quantity
+func (m Money) Mul(n int64) (Money, error) {
+	if m.amount == 0 || n == 0 {
+		return Money{amount: 0, currency: m.currency}, nil
+	}
+	product := m.amount * n
+	if product/n != m.amount || (m.amount == -1 && n == math.MinInt64) || (n == -1 && m.amount == math.MinInt64) {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: product, currency: m.currency}, nil
+}
+
+// Allocate splits m across the given ratios without losing minor units.
+// Remainders are distributed one unit at a time starting from the first share.
+func (m Money) Allocate(ratios ...int64) ([]Money, error) {
+	var total int64
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, fmt.Errorf("negative ratio %d", r)
+		}
+		if r > math.MaxInt64-total {
+			return nil, ErrOverflow
+		}
+		total += r
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("ratios must sum to a positive value")
+	}
+
+	shares := make([]Money, len(ratios))
+	remainder := m.amount
+	for i, r := range ratios {
+		share, err := mulDiv(m.amount, r, total)
+		if err != nil {
+			return nil, err
+		}
+		shares[i] = Money{amount: share, currency: m.currency}
+		remainder -= share
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(shares) {
+		if ratios[i] == 0 {
+			continue
+		}
+		shares[i].amount += step
+		remainder -= step
+	}
+	return shares, nil
+}
+
+// Cmp compares m and o, returning -1, 0, or +1
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.amount < o.amount:
+		return -1, nil
+	case m.amount > o.amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Decimal returns the amount as an exact decimal string, e.g. "12.34"
+func (m Money) Decimal() string {
+	return formatDecimal(m.amount, m.Scale())
+}
+
+// String returns the amount and currency code, e.g. "12.34 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency()
+}
+
+func (m Money) sameCurrency(o Money) error {
+	if m.currency != o.currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency(), o.Currency())
+	}
+	return nil
+}
+
+func parseCurrency(code string) (currency.Unit, error) {
+	cur, err := currency.ParseISO(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return currency.Unit{}, fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+	}
+	return cur, nil
+}
+
+func scaleOf(cur currency.Unit) int {
+	scale, _ := currency.Standard.Rounding(cur)
+	return scale
+}
+
+// mulDiv computes a*b/c truncated toward zero, failing if the result
+// overflows. a*b is taken in 128 bits, so it may exceed int64 as long as
+// the quotient does not.
+func mulDiv(a, b, c int64) (int64, error) {
+	hi, lo := bits.Mul64(abs(a), abs(b))
+	if hi >= abs(c) {
+		return 0, ErrOverflow
+	}
+	q, _ := bits.Div64(hi, lo, abs(c))
+	if (a < 0) != (b < 0) != (c < 0) {
+		if q > 1<<63 {
+			return 0, ErrOverflow
+		}
+		return -int64(q), nil
+	}
+	if q > math.MaxInt64 {
+		return 0, ErrOverflow
+	}
+	return int64(q), nil
+}
+
+// abs returns the magnitude of n, which fits in uint64 even for MinInt64
+func abs(n int64) uint64 {
+	if n < 0 {
+		return uint64(-n)
+	}
+	return uint64(n)
+}
@@ -0,0 +1,125 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+// errAny marks a case that must fail without caring how
+var errAny = errors.New("any error")
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		ratios []int64
+		want   []int64
+		err    error
+	}{
+		{name: "even", amount: 100, ratios: []int64{1, 1}, want: []int64{50, 50}},
+		{name: "remainder to the first shares", amount: 100, ratios: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{name: "negative remainder", amount: -100, ratios: []int64{1, 1, 1}, want: []int64{-34, -33, -33}},
+		{name: "remainder skips zero ratios", amount: 5, ratios: []int64{0, 1, 1}, want: []int64{0, 3, 2}},
+		{name: "uneven ratios", amount: 1000, ratios: []int64{1, 2, 4}, want: []int64{143, 286, 571}},
+		{name: "less than a unit each", amount: 2, ratios: []int64{1, 1, 1}, want: []int64{1, 1, 0}},
+		{
+			name:   "product beyond int64",
+			amount: math.MaxInt64,
+			ratios: []int64{3, 7},
+			want:   []int64{2767011611056432743, 6456360425798343064},
+		},
+		{
+			name:   "max ratios",
+			amount: math.MaxInt64,
+			ratios: []int64{math.MaxInt64 - 1, 1},
+			want:   []int64{math.MaxInt64 - 1, 1},
+		},
+		{
+			name:   "min amount",
+			amount: math.MinInt64,
+			ratios: []int64{1, 1},
+			want:   []int64{math.MinInt64 / 2, math.MinInt64 / 2},
+		},
+		{name: "ratios overflow", amount: 100, ratios: []int64{math.MaxInt64, 1}, err: ErrOverflow},
+		{name: "zero ratios", amount: 100, ratios: []int64{0, 0}, err: errAny},
+		{name: "negative ratio", amount: 100, ratios: []int64{1, -1}, err: errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.amount, "USD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			shares, err := m.Allocate(tt.ratios...)
+			if tt.err != nil {
+				if err == nil || (tt.err != errAny && !errors.Is(err, tt.err)) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]int64, len(shares))
+			for i, s := range shares {
+				got[i] = s.MinorUnits()
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		a, b, c int64
+		want    int64
+		err     error
+	}{
+		{a: 7, b: 3, c: 2, want: 10},
+		{a: -7, b: 3, c: 2, want: -10},
+		{a: 7, b: -3, c: -2, want: 10},
+		{a: 0, b: math.MaxInt64, c: 1, want: 0},
+		{a: math.MaxInt64, b: math.MaxInt64, c: math.MaxInt64, want: math.MaxInt64},
+		{a: math.MaxInt64, b: 10, c: 20, want: math.MaxInt64 / 2},
+		{a: math.MinInt64, b: 1, c: 1, want: math.MinInt64},
+		{a: math.MinInt64, b: 3, c: 3, want: math.MinInt64},
+		{a: math.MinInt64, b: -1, c: 1, err: ErrOverflow},
+		{a: math.MaxInt64, b: 2, c: 1, err: ErrOverflow},
+		{a: math.MaxInt64, b: math.MaxInt64, c: 1, err: ErrOverflow},
+	}
+	for _, tt := range tests {
+		got, err := mulDiv(tt.a, tt.b, tt.c)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("mulDiv(%d, %d, %d) = %d, %v, want %d, %v", tt.a, tt.b, tt.c, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestArithmeticOverflow(t *testing.T) {
+	largest, _ := New(math.MaxInt64, "USD")
+	smallest, _ := New(math.MinInt64, "USD")
+	one, _ := New(1, "USD")
+
+	if _, err := largest.Add(one); !errors.Is(err, ErrOverflow) {
+		t.Errorf("largest + 1: %v", err)
+	}
+	if _, err := smallest.Sub(one); !errors.Is(err, ErrOverflow) {
+		t.Errorf("smallest - 1: %v", err)
+	}
+	if _, err := smallest.Neg(); !errors.Is(err, ErrOverflow) {
+		t.Errorf("-smallest: %v", err)
+	}
+	if _, err := largest.Mul(2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("largest * 2: %v", err)
+	}
+	if _, err := smallest.Mul(-1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("smallest * -1: %v", err)
+	}
+	if got, err := largest.Sub(one); err != nil || got.MinorUnits() != math.MaxInt64-1 {
+		t.Errorf("largest - 1 = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,126 @@
+package panics
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Restart backoff for supervised loops. A loop that stayed up for at least
+// maxBackoff before panicking starts again from minBackoff.
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// Panic is a recovered panic with the stack of the goroutine that raised it
+type Panic struct {
+	Subsystem string
+	Value     any
+	Stack     []byte
+}
+
+func (p *Panic) Error() string {
+	return fmt.Sprintf("panic in %s: %v", p.Subsystem, p.Value)
+}
+
+// Reporter receives recovered panics, e.g. to forward them to an error tracker
+type Reporter interface {
+	Report(ctx context.Context, p *Panic)
+}
+
+// LogReporter writes recovered panics to the context logger
+type LogReporter struct{}
+
+func (LogReporter) Report(ctx context.Context, p *Panic) {
+	logger.FromContext(ctx).Error("panic recovered",
+		"subsystem", p.Subsystem,
+		"panic", fmt.Sprint(p.Value),
+		"stack", string(p.Stack),
+	)
+}
+
+// Guard is the panic boundary shared by background subsystems. Every
+// recovered panic is reported, recorded on the active span, and counted.
+type Guard struct {
+	reporter Reporter
+	crashes  metric.Int64Counter
+}
+
+// NewGuard creates a guard that sends recovered panics to reporter
+func NewGuard(reporter Reporter) *Guard {
+	return &Guard{
+		reporter: reporter,
+		crashes:  metrics.Int64Counter(metrics.PanicsRecovered),
+	}
+}
+
+// Handle reports a value obtained from recover. It must be called from the
+// deferred function so the captured stack includes the panicking frames.
+func (g *Guard) Handle(ctx context.Context, subsystem string, value any) *Panic {
+	p := &Panic{
+		Subsystem: subsystem,
+		Value:     value,
+		Stack:     debug.Stack(),
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(p, trace.WithAttributes(attribute.String("panic.stack", string(p.Stack))))
+	span.SetStatus(codes.Error, p.Error())
+
+	g.crashes.Add(ctx, 1, metric.WithAttributes(attribute.String("subsystem", subsystem)))
+	g.reporter.Report(ctx, p)
+	return p
+}
+
+// Call runs fn and converts a panic into a *Panic error
+func (g *Guard) Call(ctx context.Context, subsystem string, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = g.Handle(ctx, subsystem, v)
+		}
+	}()
+	return fn(ctx)
+}
+
+// Supervise runs fn until it returns or ctx is cancelled, restarting it with
+// exponential backoff whenever it panics
+func (g *Guard) Supervise(ctx context.Context, subsystem string, fn func(ctx context.Context)) {
+	backoff := minBackoff
+	for {
+		start := time.Now()
+		err := g.Call(ctx, subsystem, func(ctx context.Context) error {
+			fn(ctx)
+			return nil
+		})
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(start) >= maxBackoff {
+			backoff = minBackoff
+		}
+		logger.FromContext(ctx).Warn("restarting after panic",
+			"subsystem", subsystem,
+			"backoff", backoff,
+		)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
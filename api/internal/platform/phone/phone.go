@@ -0,0 +1,54 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// ErrInvalid is returned for numbers that cannot be parsed or are not
+// valid for their region
+var ErrInvalid = errors.New("invalid phone number")
+
+// Normalize parses a user-entered phone number and returns it in E.164
+// format. Numbers without a leading + are interpreted in defaultRegion
+// (ISO 3166-1 alpha-2, e.g. "US").
+func Normalize(raw, defaultRegion string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ErrInvalid
+	}
+
+	num, err := phonenumbers.Parse(raw, strings.ToUpper(defaultRegion))
+	if err != nil {
+		return "", ErrInvalid
+	}
+	if !phonenumbers.IsValidNumber(num) {
+		return "", ErrInvalid
+	}
+	return phonenumbers.Format(num, phonenumbers.E164), nil
+}
+
+// CanReceiveSMS reports whether an E.164 number is of a type that can
+// plausibly receive text messages
+func CanReceiveSMS(e164 string) bool {
+	num, err := phonenumbers.Parse(e164, "")
+	if err != nil {
+		return false
+	}
+	switch phonenumbers.GetNumberType(num) {
+	case phonenumbers.MOBILE, phonenumbers.FIXED_LINE_OR_MOBILE, phonenumbers.VOIP, phonenumbers.PERSONAL_NUMBER:
+		return true
+	default:
+		return false
+	}
+}
+
+// Mask hides all but the last two digits, e.g. "+1*******67"
+func Mask(e164 string) string {
+	if len(e164) <= 4 {
+		return e164
+	}
+	return e164[:2] + strings.Repeat("*", len(e164)-4) + e164[len(e164)-2:]
+}
@@ -0,0 +1,158 @@
+// Package protobuf writes responses in the protobuf binary format for
+// clients that ask for it with Accept. Messages encode themselves with the
+// Append helpers, following the schemas in api/proto; there is no code
+// generation or reflection.
+package protobuf
+
+import (
+	"encoding/binary"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of protobuf responses
+const ContentType = "application/x-protobuf"
+
+// Message is a response that can be written as protobuf
+type Message interface {
+	// AppendProto appends the encoded message to b
+	AppendProto(b []byte) []byte
+}
+
+// Wire types
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// Preferred reports whether r asks for protobuf over JSON: Accept must
+// list ContentType, with a quality no lower than any range JSON matches
+func Preferred(r *http.Request) bool {
+	var proto, json float64
+	for _, header := range r.Header.Values("Accept") {
+		for _, accepted := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(accepted)
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if raw, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(raw, 64); err != nil {
+					continue
+				}
+			}
+			switch mediaType {
+			case ContentType:
+				proto = max(proto, q)
+			case "application/json", "application/*", "*/*":
+				json = max(json, q)
+			}
+		}
+	}
+	return proto > 0 && proto >= json
+}
+
+// Write answers with m encoded as protobuf
+func Write(w http.ResponseWriter, code int, m Message) {
+	body := m.AppendProto(nil)
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
+
+func appendTag(b []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+// AppendString appends a string field, omitted when empty as proto3 does
+func AppendString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return AppendBytes(b, field, []byte(s))
+}
+
+// AppendOptionalString appends an optional string field, present whenever
+// s is set
+func AppendOptionalString(b []byte, field int, s *string) []byte {
+	if s == nil {
+		return b
+	}
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(*s)))
+	return append(b, *s...)
+}
+
+// AppendBytes appends a bytes field, omitted when empty
+func AppendBytes(b []byte, field int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// AppendBool appends a bool field, omitted when false
+func AppendBool(b []byte, field int, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = appendTag(b, field, wireVarint)
+	return append(b, 1)
+}
+
+// AppendInt64 appends an int64 or int32 field, omitted when zero. Negative
+// values take ten bytes, as protobuf's int types do.
+func AppendInt64(b []byte, field int, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendTag(b, field, wireVarint)
+	return binary.AppendUvarint(b, uint64(v))
+}
+
+// AppendMessage appends an embedded message field. It is always present,
+// even when m encodes to nothing.
+func AppendMessage(b []byte, field int, m Message) []byte {
+	// Embedded messages are length-prefixed, so m is encoded first
+	body := m.AppendProto(nil)
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(body)))
+	return append(b, body...)
+}
+
+// AppendTimestamp appends t as a google.protobuf.Timestamp field, omitted
+// when t is zero
+func AppendTimestamp(b []byte, field int, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	return AppendMessage(b, field, timestamp(t))
+}
+
+// AppendMapEntry appends one entry of a map<string, bytes> field
+func AppendMapEntry(b []byte, field int, key string, value []byte) []byte {
+	return AppendMessage(b, field, mapEntry{key, value})
+}
+
+type timestamp time.Time
+
+func (t timestamp) AppendProto(b []byte) []byte {
+	b = AppendInt64(b, 1, time.Time(t).Unix())
+	return AppendInt64(b, 2, int64(time.Time(t).Nanosecond()))
+}
+
+type mapEntry struct {
+	key   string
+	value []byte
+}
+
+func (e mapEntry) AppendProto(b []byte) []byte {
+	b = AppendString(b, 1, e.key)
+	return AppendBytes(b, 2, e.value)
+}
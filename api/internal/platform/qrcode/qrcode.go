@@ -0,0 +1,81 @@
+// Package qrcode renders QR codes as PNG or SVG images
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// Image formats
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+// MaxContentLength bounds the encoded text; longer content makes codes too
+// dense to scan reliably from a screen
+const MaxContentLength = 1024
+
+var ErrInvalidContent = errors.New("invalid QR code content")
+
+// ContentType is the media type of images in format
+func ContentType(format string) string {
+	if format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Render encodes content as a QR code of size pixels square, including
+// the quiet zone. Medium error correction survives small logos and glare.
+func Render(content, format string, size int) ([]byte, error) {
+	if content == "" || len(content) > MaxContentLength {
+		return nil, fmt.Errorf("%w: must be 1-%d bytes", ErrInvalidContent, MaxContentLength)
+	}
+	code, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	switch format {
+	case FormatPNG:
+		png, err := code.PNG(size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render QR code: %w", err)
+		}
+		return png, nil
+	case FormatSVG:
+		return svg(code.Bitmap(), size), nil
+	default:
+		return nil, fmt.Errorf("unsupported QR code format: %s", format)
+	}
+}
+
+// svg draws each run of dark modules in a row as one rectangle in a single
+// path, scaled to size by the viewBox
+func svg(bitmap [][]bool, size int) []byte {
+	n := len(bitmap)
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x := 0; x < n; {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < n && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path d="%s" fill="#000"/></svg>`, n, n, path.String())
+	b.WriteString("\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,126 @@
+// Package querycost prices list requests by how hard they work the
+// database, and meters each caller's spending against a budget per minute.
+// Cheap pages cost little, so ordinary clients never notice the budget,
+// while clients that repeat deep offsets, ranked searches, and large pages
+// run out of it.
+package querycost
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Model prices the requests of one list endpoint from their query
+// parameters
+type Model struct {
+	// Base is charged for every request
+	Base float64
+	// PerRow is charged for each row of the page, ?limit after DefaultLimit
+	// and MaxLimit have been applied as the endpoint applies them
+	PerRow       float64
+	DefaultLimit int
+	MaxLimit     int
+	// PerSkipped is charged for each row ?offset skips, which the database
+	// reads and discards
+	PerSkipped float64
+	// Filters are charged when their query parameter is set, once per
+	// comma-separated value
+	Filters map[string]float64
+	// Sorts multiply the row costs when their query parameter is set. They
+	// are for parameters that order results by a computed score rather
+	// than an index, so every match is read before the first is returned.
+	Sorts map[string]float64
+}
+
+// Estimate returns the cost of a request with query q, rounded up to whole
+// points. Values the endpoint would reject are priced as their defaults.
+func (m Model) Estimate(q url.Values) int {
+	limit := m.DefaultLimit
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	if m.MaxLimit > 0 {
+		limit = min(limit, m.MaxLimit)
+	}
+	offset := 0
+	if n, err := strconv.Atoi(q.Get("offset")); err == nil && n > 0 {
+		offset = n
+	}
+
+	sort := 1.0
+	for param, factor := range m.Sorts {
+		if strings.TrimSpace(q.Get(param)) != "" {
+			sort = max(sort, factor)
+		}
+	}
+	cost := m.Base + (float64(limit)*m.PerRow+float64(offset)*m.PerSkipped)*sort
+	for param, each := range m.Filters {
+		for _, v := range strings.Split(q.Get(param), ",") {
+			if strings.TrimSpace(v) != "" {
+				cost += each
+			}
+		}
+	}
+	return int(math.Ceil(cost))
+}
+
+// Budget is how many points each caller may spend per minute. Spending is
+// counted in memory, so each replica enforces the budget on its own
+// traffic, or its share of the budget once spread across replicas.
+type Budget struct {
+	mu      sync.Mutex
+	limit   int
+	spent   map[string]int
+	started time.Time
+	// replicas returns how many replicas share the budget
+	replicas func() int
+}
+
+// NewBudget gives every caller limit points per minute
+func NewBudget(limit int) *Budget {
+	return &Budget{
+		limit:    limit,
+		spent:    make(map[string]int),
+		started:  time.Now(),
+		replicas: func() int { return 1 },
+	}
+}
+
+// Spread divides the budget evenly among the replicas, replicas reporting
+// how many there are. It is meant to be called before the budget is used.
+func (b *Budget) Spread(replicas func() int) {
+	b.replicas = replicas
+}
+
+// Limit returns the points each caller may spend per minute on this
+// replica
+func (b *Budget) Limit() int {
+	n := max(b.replicas(), 1)
+	return max((b.limit+n-1)/n, 1)
+}
+
+// Spend charges cost to caller and reports whether it fit in their budget,
+// with the points left and when the budget refills. A request that does not
+// fit is not charged. One costing more than the whole budget is charged the
+// whole budget, so it is only let through on a fresh one.
+func (b *Budget) Spend(caller string, cost int) (remaining int, reset time.Time, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if time.Since(b.started) >= time.Minute {
+		clear(b.spent)
+		b.started = time.Now()
+	}
+	reset = b.started.Add(time.Minute)
+	limit := b.Limit()
+	cost = min(cost, limit)
+	if b.spent[caller]+cost > limit {
+		return max(limit-b.spent[caller], 0), reset, false
+	}
+	b.spent[caller] += cost
+	return limit - b.spent[caller], reset, true
+}
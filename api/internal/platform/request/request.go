@@ -0,0 +1,136 @@
+// Package request decodes and validates JSON request bodies for handlers
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// MaxBodySize limits JSON request bodies
+const MaxBodySize = 1 << 20
+
+// FieldError is a problem with one field of a request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a request body that could not be decoded or failed validation.
+// It is written to the client as the response body with Status.
+type Error struct {
+	Status  int          `json:"-"`
+	Message string       `json:"error"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return e.Message + ": " + strings.Join(parts, ", ")
+}
+
+// Validator is implemented by request bodies that check their own fields
+// after decoding
+type Validator interface {
+	Validate() error
+}
+
+// Decode reads a JSON body of at most MaxBodySize bytes into dst. Unknown
+// fields and trailing data are rejected. When dst is a Validator it is
+// validated too. Failures are returned as *Error.
+func Decode(w http.ResponseWriter, r *http.Request, dst any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return &Error{Status: http.StatusBadRequest, Message: "request body must be a single JSON object"}
+	}
+
+	if v, ok := dst.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, io.EOF):
+		return &Error{Status: http.StatusBadRequest, Message: "request body is required"}
+	case errors.As(err, &maxBytesErr):
+		return &Error{Status: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("request body must be at most %d bytes", maxBytesErr.Limit)}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return &Error{Status: http.StatusBadRequest, Message: "request body is not valid JSON"}
+	case errors.As(err, &typeErr):
+		return &Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid request body",
+			Fields:  []FieldError{{Field: typeErr.Field, Message: "must be " + typeName(typeErr.Type)}},
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &Error{
+			Status:  http.StatusBadRequest,
+			Message: "invalid request body",
+			Fields:  []FieldError{{Field: field, Message: "unknown field"}},
+		}
+	default:
+		return &Error{Status: http.StatusBadRequest, Message: "invalid request body"}
+	}
+}
+
+// typeName describes a Go type in JSON terms
+func typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a number"
+	}
+}
+
+// Validation collects field errors while a request body is checked
+type Validation struct {
+	fields []FieldError
+}
+
+// Check records message for field unless ok
+func (v *Validation) Check(ok bool, field, message string) {
+	if !ok {
+		v.fields = append(v.fields, FieldError{Field: field, Message: message})
+	}
+}
+
+// Err returns the collected errors as an unprocessable entity *Error, or
+// nil when every check passed
+func (v *Validation) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &Error{Status: http.StatusUnprocessableEntity, Message: "validation failed", Fields: v.fields}
+}
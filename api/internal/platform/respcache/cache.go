@@ -0,0 +1,278 @@
+// Package respcache caches whole responses to read routes chosen in
+// configuration, serving them again without running the handler until they
+// expire
+package respcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/locale"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/metrics"
+	"starterkit/internal/platform/panics"
+	"starterkit/internal/platform/protobuf"
+)
+
+// Vary values naming what tells responses apart besides the path
+const (
+	VaryUser   = "user"
+	VaryTenant = "tenant"
+	VaryQuery  = "query"
+)
+
+// storedHeaders are the response headers kept with a cached response;
+// the rest describe the one request that produced it
+var storedHeaders = []string{
+	"Cache-Control",
+	"Content-Language",
+	"Content-Type",
+	"ETag",
+	"Last-Modified",
+	"Link",
+	"Vary",
+}
+
+// Cache serves responses to the configured routes from a Store
+type Cache struct {
+	store        Store
+	rules        map[string]config.ResponseCacheRule
+	tenantHeader string
+	guard        *panics.Guard
+	lookups      metric.Int64Counter
+
+	mu sync.Mutex
+	// refreshing holds the keys being refreshed in the background, so a
+	// burst of stale hits runs the handler once
+	refreshing map[string]bool
+}
+
+// New creates a cache for cfg's routes on its backend
+func New(cfg config.ResponseCacheConfig, guard *panics.Guard) (*Cache, error) {
+	var store Store
+	switch cfg.Backend {
+	case "redis":
+		s, err := NewRedis(cfg.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		store = s
+	default:
+		store = NewMemory(cfg.MaxEntries)
+	}
+	return &Cache{
+		store:        store,
+		rules:        cfg.Routes,
+		tenantHeader: cfg.TenantHeader,
+		guard:        guard,
+		lookups:      metrics.Int64Counter(metrics.HTTPServerCacheLookups),
+		refreshing:   make(map[string]bool),
+	}, nil
+}
+
+// Rule returns how responses to route are cached, if they are
+func (c *Cache) Rule(route string) (config.ResponseCacheRule, bool) {
+	rule, ok := c.rules[route]
+	return rule, ok
+}
+
+// Serve answers r from the cache while its entry is fresh, and while it is
+// stale serves it and runs next in the background to refresh it. Otherwise
+// next answers r, and its response is stored if it can be shared.
+func (c *Cache) Serve(w http.ResponseWriter, r *http.Request, route string, rule config.ResponseCacheRule, next http.Handler) {
+	ctx := r.Context()
+	key := c.key(r, route, rule)
+
+	e, err := c.store.Get(ctx, key)
+	if err != nil {
+		// The cache is an optimization; the handler still answers
+		logger.FromContext(ctx).Warn("response cache lookup failed", "route", route, "error", err)
+	}
+	if e != nil {
+		age := time.Since(e.StoredAt)
+		switch {
+		case age < rule.TTL:
+			c.record(ctx, route, "hit")
+			write(w, e, age, "HIT")
+			return
+		case age < rule.TTL+rule.Stale:
+			c.record(ctx, route, "stale")
+			write(w, e, age, "STALE")
+			c.refresh(r, route, key, rule, next)
+			return
+		}
+	}
+
+	c.record(ctx, route, "miss")
+	w.Header().Set("X-Cache", "MISS")
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+	c.save(ctx, route, key, rule, rec)
+}
+
+// refresh runs next for a copy of r detached from it, replacing the stale
+// entry under key with the response
+func (c *Cache) refresh(r *http.Request, route, key string, rule config.ResponseCacheRule, next http.Handler) {
+	c.mu.Lock()
+	if c.refreshing[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	ctx := context.WithoutCancel(r.Context())
+	req := r.Clone(ctx)
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+		err := c.guard.Call(ctx, "respcache", func(ctx context.Context) error {
+			rec := &recorder{ResponseWriter: discard{header: make(http.Header)}, status: http.StatusOK}
+			next.ServeHTTP(rec, req)
+			c.save(ctx, route, key, rule, rec)
+			return nil
+		})
+		if err != nil {
+			logger.FromContext(ctx).Error("response cache refresh failed", "route", route, "error", err)
+		}
+	}()
+}
+
+// save stores the response rec recorded if it can be shared: a 200 that
+// sets no cookie and does not forbid storing
+func (c *Cache) save(ctx context.Context, route, key string, rule config.ResponseCacheRule, rec *recorder) {
+	h := rec.Header()
+	if rec.status != http.StatusOK || h.Get("Set-Cookie") != "" {
+		return
+	}
+	cc := strings.ToLower(h.Get("Cache-Control"))
+	if strings.Contains(cc, "no-store") || strings.Contains(cc, "private") {
+		return
+	}
+
+	e := &Entry{
+		Status:   rec.status,
+		Header:   make(http.Header),
+		Body:     rec.body.Bytes(),
+		StoredAt: time.Now(),
+	}
+	for _, name := range storedHeaders {
+		if v := h.Values(name); len(v) > 0 {
+			e.Header[name] = slices.Clone(v)
+		}
+	}
+	if err := c.store.Set(ctx, key, e, rule.TTL+rule.Stale); err != nil {
+		logger.FromContext(ctx).Warn("failed to store cached response", "route", route, "error", err)
+	}
+}
+
+// key identifies r's response among route's: its path, the caller's
+// locale preferences, and whatever else the rule varies by
+func (c *Cache) key(r *http.Request, route string, rule config.ResponseCacheRule) string {
+	var b strings.Builder
+	b.WriteString(route)
+	b.WriteByte('\n')
+	b.WriteString(r.URL.Path)
+	// Routes that negotiate protobuf answer the same path two ways
+	if protobuf.Preferred(r) {
+		b.WriteString("\nformat=protobuf")
+	}
+	// Responses are formatted for the caller's locale, currency, and units
+	prefs := locale.FromContext(r.Context())
+	b.WriteString("\nlocale=" + prefs.Locale.String() + ";" + prefs.Currency.String() + ";" + prefs.Units)
+	for _, v := range rule.Vary {
+		b.WriteByte('\n')
+		switch v {
+		case VaryQuery:
+			// Encode sorts by key, so parameter order does not matter
+			b.WriteString("query=" + r.URL.Query().Encode())
+		case VaryUser:
+			// Handlers know callers by X-User-Email, which is all there is
+			// with authentication off. Scopes and roles are part of who is
+			// asking too, since field access trims responses by them.
+			b.WriteString("email=" + r.Header.Get("X-User-Email"))
+			if p, ok := auth.FromContext(r.Context()); ok {
+				b.WriteString(";user=" + p.Subject)
+				b.WriteString(";" + strings.Join(slices.Sorted(slices.Values(p.Scopes)), ","))
+				b.WriteString(";" + strings.Join(slices.Sorted(slices.Values(p.Roles)), ","))
+			}
+		case VaryTenant:
+			b.WriteString("tenant=" + r.Header.Get(c.tenantHeader))
+		}
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) record(ctx context.Context, route, result string) {
+	c.lookups.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("http.route", route),
+		attribute.String("result", result),
+	))
+}
+
+// write answers with e, aged age
+func write(w http.ResponseWriter, e *Entry, age time.Duration, result string) {
+	h := w.Header()
+	for name, values := range e.Header {
+		h[name] = slices.Clone(values)
+	}
+	h.Set("Age", strconv.Itoa(int(age.Seconds())))
+	h.Set("X-Cache", result)
+	h.Set("Content-Length", strconv.Itoa(len(e.Body)))
+	w.WriteHeader(e.Status)
+	w.Write(e.Body)
+}
+
+// recorder passes a response through while keeping a copy of its status
+// and body
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.status = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// discard is the writer background refreshes answer to
+type discard struct {
+	header http.Header
+}
+
+func (d discard) Header() http.Header         { return d.header }
+func (d discard) Write(b []byte) (int, error) { return len(b), nil }
+func (d discard) WriteHeader(int)             {}
@@ -0,0 +1,74 @@
+package respcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/locale"
+	"starterkit/internal/platform/panics"
+)
+
+const route = "GET /feed"
+
+// countingCache caches route for callers varying by user, answering with
+// the number of times the handler ran
+func countingCache(t *testing.T) (*Cache, http.Handler) {
+	t.Helper()
+	rule := config.ResponseCacheRule{TTL: time.Minute, Vary: []string{VaryUser}}
+	c, err := New(config.ResponseCacheConfig{
+		MaxEntries: 10,
+		Routes:     map[string]config.ResponseCacheRule{route: rule},
+	}, panics.NewGuard(panics.LogReporter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runs++
+		w.Write([]byte{byte('0' + runs)})
+	})
+	return c, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Serve(w, r, route, rule, handler)
+	})
+}
+
+func get(h http.Handler, email string, prefs locale.Preferences) string {
+	r := httptest.NewRequest(http.MethodGet, "/feed", nil)
+	if email != "" {
+		r.Header.Set("X-User-Email", email)
+	}
+	r = r.WithContext(locale.WithContext(r.Context(), prefs))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Body.String()
+}
+
+func TestKeyVaries(t *testing.T) {
+	english := locale.Preferences{Locale: language.AmericanEnglish, Currency: currency.USD, Units: locale.UnitsImperial}
+	german := locale.Preferences{Locale: language.German, Currency: currency.EUR, Units: locale.UnitsMetric}
+
+	_, h := countingCache(t)
+	steps := []struct {
+		name  string
+		email string
+		prefs locale.Preferences
+		want  string
+	}{
+		{"first request", "a@example.com", english, "1"},
+		{"same caller and locale", "a@example.com", english, "1"},
+		{"other locale", "a@example.com", german, "2"},
+		{"other caller without a principal", "b@example.com", english, "3"},
+		{"no caller", "", english, "4"},
+	}
+	for _, s := range steps {
+		if got := get(h, s.email, s.prefs); got != s.want {
+			t.Errorf("%s: got response %s, want %s", s.name, got, s.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package respcache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces cached responses in Redis
+const keyPrefix = "respcache:"
+
+// Redis keeps entries as JSON values expiring with them, shared by every
+// replica
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis creates a store on the Redis server at url
+// (redis://[user:password@]host:port/db)
+func NewRedis(url string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+	return &Redis{client: redis.NewClient(opts)}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) (*Entry, error) {
+	raw, err := r.client.Get(ctx, keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return nil, fmt.Errorf("failed to decode cached response: %w", err)
+	}
+	return &e, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, e *Entry, ttl time.Duration) error {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached response: %w", err)
+	}
+	return r.client.Set(ctx, keyPrefix+key, raw, ttl).Err()
+}
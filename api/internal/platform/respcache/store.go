@@ -0,0 +1,86 @@
+package respcache
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Entry is a stored response
+type Entry struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// Store keeps entries until they expire
+type Store interface {
+	// Get returns the entry stored under key, or nil if there is none
+	Get(ctx context.Context, key string) (*Entry, error)
+	// Set stores e under key for ttl
+	Set(ctx context.Context, key string, e *Entry, ttl time.Duration) error
+}
+
+// Memory keeps entries in this process, dropping the least recently used
+// beyond its capacity
+type Memory struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type memoryItem struct {
+	key       string
+	entry     *Entry
+	expiresAt time.Time
+}
+
+// NewMemory creates a store holding at most capacity entries
+func NewMemory(capacity int) *Memory {
+	return &Memory{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) (*Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		return nil, nil
+	}
+	item := el.Value.(*memoryItem)
+	if time.Now().After(item.expiresAt) {
+		m.order.Remove(el)
+		delete(m.items, key)
+		return nil, nil
+	}
+	m.order.MoveToFront(el)
+	return item.entry, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, e *Entry, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item := &memoryItem{key: key, entry: e, expiresAt: time.Now().Add(ttl)}
+	if el, ok := m.items[key]; ok {
+		el.Value = item
+		m.order.MoveToFront(el)
+		return nil
+	}
+	m.items[key] = m.order.PushFront(item)
+	for m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamChunkSize is the INSTREAM chunk size; it must stay below clamd's StreamMaxLength
+const clamChunkSize = 64 * 1024
+
+// ClamAV scans files by streaming them to clamd over TCP
+type ClamAV struct {
+	address string
+	timeout time.Duration
+}
+
+// NewClamAV creates a scanner for the clamd instance at address (host:port)
+func NewClamAV(address string, timeout time.Duration) *ClamAV {
+	return &ClamAV{
+		address: address,
+		timeout: timeout,
+	}
+}
+
+// Scan streams r to clamd using the INSTREAM command
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return Result{}, err
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, fmt.Errorf("failed to start clamd stream: %w", err)
+	}
+
+	// Each chunk is prefixed with its length; a zero-length chunk ends the stream
+	buf := make([]byte, clamChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return Result{}, fmt.Errorf("failed to stream to clamd: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return Result{}, fmt.Errorf("failed to stream to clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return Result{}, readErr
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return Result{}, fmt.Errorf("failed to end clamd stream: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return Result{}, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamReply interprets "stream: OK" and "stream: <signature> FOUND"
+func parseClamReply(reply string) (Result, error) {
+	status := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case status == "OK":
+		return Result{Engine: "clamav"}, nil
+	case strings.HasSuffix(status, "FOUND"):
+		return Result{
+			Infected:  true,
+			Signature: strings.TrimSpace(strings.TrimSuffix(status, "FOUND")),
+			Engine:    "clamav",
+		}, nil
+	default:
+		return Result{}, fmt.Errorf("clamd error: %s", status)
+	}
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTP scans files by posting them to an external scanning API. The API
+// receives the raw file body and must respond with
+// {"infected": bool, "signature": "..."}.
+type HTTP struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewHTTP creates a scanner for the API at url; token is sent as a bearer token if set
+func NewHTTP(url, token string, timeout time.Duration) *HTTP {
+	return &HTTP{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Scan uploads r to the scanning API
+func (h *HTTP) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, r)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create scan request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to call scan API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("scan API returned status %d", resp.StatusCode)
+	}
+
+	var verdict struct {
+		Infected  bool   `json:"infected"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return Result{}, fmt.Errorf("failed to decode scan response: %w", err)
+	}
+	return Result{
+		Infected:  verdict.Infected,
+		Signature: verdict.Signature,
+		Engine:    "http",
+	}, nil
+}
@@ -0,0 +1,49 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"starterkit/internal/config"
+)
+
+// Result is the verdict for a scanned file
+type Result struct {
+	Infected  bool
+	Signature string
+	Engine    string
+}
+
+// Scanner inspects file contents for malware
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// New creates the scanner selected in configuration
+func New(cfg config.ScanConfig) (Scanner, error) {
+	switch cfg.Backend {
+	case "none", "":
+		return Noop{}, nil
+	case "clamav":
+		return NewClamAV(cfg.ClamAVAddress, cfg.Timeout), nil
+	case "http":
+		if cfg.HTTPURL == "" {
+			return nil, fmt.Errorf("http scanner requires a URL")
+		}
+		return NewHTTP(cfg.HTTPURL, cfg.HTTPToken, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("unsupported scan backend: %s", cfg.Backend)
+	}
+}
+
+// Noop reports every file as clean, for development without a scanner
+type Noop struct{}
+
+// Scan drains the reader and reports it clean
+func (Noop) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return Result{}, err
+	}
+	return Result{Engine: "none"}, nil
+}
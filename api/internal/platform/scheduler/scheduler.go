@@ -0,0 +1,192 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/lock"
+	"starterkit/internal/platform/panics"
+	"starterkit/internal/platform/watchdog"
+)
+
+// stopGrace is how long a restart waits for a stalled job to honor
+// cancellation before giving up on it
+const stopGrace = 10 * time.Second
+
+// JobFunc is the unit of work executed by the scheduler
+type JobFunc func(ctx context.Context) error
+
+// Leadership reports whether this replica should run singleton jobs
+type Leadership interface {
+	IsLeader() bool
+}
+
+type job struct {
+	name      string
+	interval  time.Duration
+	fn        JobFunc
+	local     bool
+	heartbeat *watchdog.Heartbeat
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Scheduler runs registered jobs at fixed intervals
+type Scheduler struct {
+	logger *slog.Logger
+	guard  *panics.Guard
+	dog    *watchdog.Watchdog
+	leader Leadership
+	locker lock.Locker
+	ttl    time.Duration
+	jobs   []*job
+	wg     sync.WaitGroup
+}
+
+// New creates a new scheduler. Job loops beat a heartbeat on dog so stalled
+// jobs are restarted. Registered jobs run only while leader reports this
+// replica as leader, and each run holds a lock from locker, held for
+// lockTTL past a crash, so a job never overlaps itself during a handover.
+func New(logger *slog.Logger, guard *panics.Guard, dog *watchdog.Watchdog, leader Leadership, locker lock.Locker, lockTTL time.Duration) *Scheduler {
+	return &Scheduler{
+		logger: logger,
+		guard:  guard,
+		dog:    dog,
+		leader: leader,
+		locker: locker,
+		ttl:    lockTTL,
+	}
+}
+
+// Register adds a job that runs every interval on the leader once the
+// scheduler is started
+func (s *Scheduler) Register(name string, interval time.Duration, fn JobFunc) {
+	s.jobs = append(s.jobs, &job{
+		name:     name,
+		interval: interval,
+		fn:       fn,
+	})
+}
+
+// RegisterLocal adds a job that runs every interval on every replica, for
+// work about the replica itself
+func (s *Scheduler) RegisterLocal(name string, interval time.Duration, fn JobFunc) {
+	s.jobs = append(s.jobs, &job{
+		name:     name,
+		interval: interval,
+		fn:       fn,
+		local:    true,
+	})
+}
+
+// Start launches all registered jobs and returns immediately.
+// Jobs stop when ctx is cancelled; use Wait to block until they have exited.
+// A job that panics has its loop restarted with backoff, and one that
+// stalls is cancelled and restarted by the watchdog.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		j.heartbeat = s.dog.Register("scheduler."+j.name, j.interval, func() error {
+			return s.restart(ctx, j)
+		})
+		s.launch(ctx, j)
+	}
+}
+
+// Wait blocks until all running jobs have exited
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) launch(ctx context.Context, j *job) {
+	loopCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	j.mu.Lock()
+	j.cancel = cancel
+	j.done = done
+	j.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(done)
+		defer cancel()
+		s.guard.Supervise(loopCtx, "scheduler."+j.name, func(ctx context.Context) {
+			s.loop(ctx, j)
+		})
+	}()
+}
+
+// restart cancels a job's loop and launches a new one once the old loop has
+// exited, so a job never runs twice concurrently
+func (s *Scheduler) restart(ctx context.Context, j *job) error {
+	j.mu.Lock()
+	cancel, done := j.cancel, j.done
+	j.mu.Unlock()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(stopGrace):
+		return errors.New("job did not stop after cancellation")
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	s.logger.Warn("restarting stalled job", "job", j.name)
+	s.launch(ctx, j)
+	return nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.heartbeat.Beat()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.run(ctx, j)
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, j *job) {
+	ctx = database.WithWorkload(ctx, database.WorkloadBackground)
+	if !j.local && !s.leader.IsLeader() {
+		return
+	}
+
+	start := time.Now()
+	var err error
+	if j.local {
+		err = j.fn(ctx)
+	} else {
+		err = lock.Do(ctx, s.locker, "scheduler:"+j.name, s.ttl, j.fn)
+	}
+	if errors.Is(err, lock.ErrNotAcquired) {
+		s.logger.Debug("scheduled job skipped, running elsewhere", "job", j.name)
+		return
+	}
+	if err != nil {
+		s.logger.Error("scheduled job failed",
+			"job", j.name,
+			"error", err,
+			"duration", time.Since(start),
+		)
+		return
+	}
+	s.logger.Debug("scheduled job completed",
+		"job", j.name,
+		"duration", time.Since(start),
+	)
+}
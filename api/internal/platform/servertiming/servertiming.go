@@ -0,0 +1,188 @@
+// Package servertiming breaks the time a request took down into
+// middleware, handler, database, and serialization time, and reports it in
+// a Server-Timing header so browser devtools show where backend latency
+// goes. Parts of the request record their time in the Timings of its
+// context; the header is written just before the response headers are.
+package servertiming
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type contextKey struct{}
+
+// Timings collects the time spent serving one request
+type Timings struct {
+	mu      sync.Mutex
+	start   time.Time
+	handler time.Time
+	// db is the query time before and after the handler started
+	db      [2]time.Duration
+	queries int
+	budget  time.Duration
+}
+
+// FromContext returns the timings of the request being served, or nil
+func FromContext(ctx context.Context) *Timings {
+	t, _ := ctx.Value(contextKey{}).(*Timings)
+	return t
+}
+
+// Handler marks the handler of the request starting. Routers mark it as
+// they dispatch, so the time before is the middleware's; the last mark
+// wins when routers are nested.
+func Handler(ctx context.Context) {
+	if t := FromContext(ctx); t != nil {
+		t.mu.Lock()
+		t.handler = time.Now()
+		t.mu.Unlock()
+	}
+}
+
+// Query records a database query that took d
+func Query(ctx context.Context, d time.Duration) {
+	if t := FromContext(ctx); t != nil {
+		t.mu.Lock()
+		phase := 0
+		if !t.handler.IsZero() {
+			phase = 1
+		}
+		t.db[phase] += d
+		t.queries++
+		t.mu.Unlock()
+	}
+}
+
+// Budget records how long the route is meant to take, which the header
+// compares the request against
+func Budget(ctx context.Context, d time.Duration) {
+	if t := FromContext(ctx); t != nil {
+		t.mu.Lock()
+		t.budget = d
+		t.mu.Unlock()
+	}
+}
+
+// header formats the timings as of now. serialization is the time the
+// response took to encode, spent in the handler if it had started.
+func (t *Timings) header(now time.Time, serialization time.Duration) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	total := now.Sub(t.start)
+	var handler time.Duration
+	if !t.handler.IsZero() {
+		handler = now.Sub(t.handler)
+	}
+	middleware := total - handler - t.db[0]
+	if handler > 0 {
+		handler -= t.db[1] + serialization
+	} else {
+		middleware -= serialization
+	}
+
+	queries := fmt.Sprintf("%d queries", t.queries)
+	if t.queries == 1 {
+		queries = "1 query"
+	}
+	metrics := []string{
+		metric("mw", max(middleware, 0), "middleware"),
+		metric("handler", max(handler, 0), "handler"),
+		metric("db", t.db[0]+t.db[1], queries),
+		metric("ser", serialization, "serialization"),
+	}
+	if t.budget > 0 {
+		desc := "within budget"
+		if total > t.budget {
+			desc = "over budget"
+		}
+		metrics = append(metrics, metric("total", total, desc), metric("budget", t.budget, "latency objective"))
+	} else {
+		metrics = append(metrics, metric("total", total, "total"))
+	}
+	return strings.Join(metrics, ", ")
+}
+
+func metric(name string, d time.Duration, desc string) string {
+	return name + ";dur=" + strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 1, 64) + ";desc=" + strconv.Quote(desc)
+}
+
+// Middleware times each request and adds its Server-Timing header. It
+// should wrap all other middleware, so their time is counted.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := &Timings{start: time.Now()}
+		tw := &writer{ResponseWriter: w, timings: t}
+		next.ServeHTTP(tw, r.WithContext(context.WithValue(r.Context(), contextKey{}, t)))
+		tw.writeHeader()
+	})
+}
+
+// writer holds back the response headers until the body is first written,
+// so the header can count the time spent encoding it. Handlers write the
+// status and then encode the body, so that time runs from WriteHeader to
+// the first Write.
+type writer struct {
+	http.ResponseWriter
+	timings *Timings
+	status  int
+	pending time.Time
+	sent    bool
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.sent || code < http.StatusOK {
+		// Informational responses go out as they are written
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status, w.pending = code, time.Now()
+	}
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	w.writeHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+// FlushError sends the held headers before flushing, for streaming
+// handlers using http.ResponseController
+func (w *writer) FlushError() error {
+	w.writeHeader()
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *writer) writeHeader() {
+	if w.sent {
+		return
+	}
+	w.sent = true
+
+	now := time.Now()
+	var serialization time.Duration
+	if w.status == 0 {
+		w.status = http.StatusOK
+	} else {
+		serialization = now.Sub(w.pending)
+	}
+	h := w.ResponseWriter.Header()
+	h.Set("Server-Timing", w.timings.header(now, serialization))
+	// Lets the app read the timings through the Resource Timing API from
+	// the origins CORS allows
+	if origin := h.Get("Access-Control-Allow-Origin"); origin != "" {
+		h.Set("Timing-Allow-Origin", origin)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
@@ -0,0 +1,61 @@
+package siem
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTP posts batches to an HTTPS collector (e.g. a Splunk HEC raw
+// endpoint) as newline-delimited records
+type HTTP struct {
+	url         string
+	token       string
+	contentType string
+	client      *http.Client
+}
+
+// NewHTTP creates a sink for the collector at url; token is sent as a bearer token if set
+func NewHTTP(url, token, contentType string, timeout time.Duration) *HTTP {
+	return &HTTP{
+		url:         url,
+		token:       token,
+		contentType: contentType,
+		client:      &http.Client{Timeout: timeout},
+	}
+}
+
+// Send posts records in a single request
+func (h *HTTP) Send(ctx context.Context, records [][]byte) error {
+	body := bytes.Join(records, []byte("\n"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create SIEM request: %w", err)
+	}
+	req.Header.Set("Content-Type", h.contentType)
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send to SIEM: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("SIEM collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close releases idle connections
+func (h *HTTP) Close() error {
+	h.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,43 @@
+package siem
+
+import (
+	"context"
+	"fmt"
+
+	"starterkit/internal/config"
+)
+
+// Sink delivers encoded security events to a SIEM. Send either delivers the
+// whole batch or returns an error, in which case the caller retries it.
+type Sink interface {
+	Send(ctx context.Context, records [][]byte) error
+	Close() error
+}
+
+// New creates the sink selected in configuration
+func New(cfg config.SIEMConfig) (Sink, error) {
+	var contentType string
+	switch cfg.Format {
+	case "json":
+		contentType = "application/x-ndjson"
+	case "cef":
+		contentType = "text/plain"
+	default:
+		return nil, fmt.Errorf("unsupported SIEM format: %s", cfg.Format)
+	}
+
+	switch cfg.Sink {
+	case "https":
+		if cfg.HTTPURL == "" {
+			return nil, fmt.Errorf("https sink requires a URL")
+		}
+		return NewHTTP(cfg.HTTPURL, cfg.HTTPToken, contentType, cfg.Timeout), nil
+	case "syslog":
+		if cfg.SyslogAddress == "" {
+			return nil, fmt.Errorf("syslog sink requires an address")
+		}
+		return NewSyslog(cfg.SyslogNetwork, cfg.SyslogAddress, cfg.SyslogAppName, cfg.Timeout)
+	default:
+		return nil, fmt.Errorf("unsupported SIEM sink: %s", cfg.Sink)
+	}
+}
@@ -0,0 +1,122 @@
+package siem
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// priority is facility log audit (13) at severity informational (6)
+const priority = 13*8 + 6
+
+// Syslog sends records as RFC 5424 messages over udp, tcp, or tcp+tls.
+// Stream transports use octet-counting framing (RFC 6587).
+type Syslog struct {
+	network  string
+	address  string
+	appName  string
+	hostname string
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslog creates a sink for the syslog receiver at address
+func NewSyslog(network, address, appName string, timeout time.Duration) (*Syslog, error) {
+	switch network {
+	case "udp", "tcp", "tcp+tls":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %s", network)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+	return &Syslog{
+		network:  network,
+		address:  address,
+		appName:  appName,
+		hostname: hostname,
+		timeout:  timeout,
+	}, nil
+}
+
+// Send writes each record as one syslog message, reconnecting once if the
+// connection has gone stale
+func (s *Syslog) Send(ctx context.Context, records [][]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.write(ctx, records)
+	if err != nil && s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+		err = s.write(ctx, records)
+	}
+	return err
+}
+
+// Close closes the connection to the receiver
+func (s *Syslog) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *Syslog) write(ctx context.Context, records [][]byte) error {
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		s.conn = conn
+	}
+
+	if err := s.conn.SetWriteDeadline(time.Now().Add(s.timeout)); err != nil {
+		return err
+	}
+	for _, record := range records {
+		msg := s.format(record)
+		if s.network != "udp" {
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+		if _, err := s.conn.Write(msg); err != nil {
+			return fmt.Errorf("failed to write to syslog: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Syslog) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: s.timeout}
+	if s.network == "tcp+tls" {
+		tlsDialer := &tls.Dialer{NetDialer: dialer}
+		return tlsDialer.DialContext(ctx, "tcp", s.address)
+	}
+	return dialer.DialContext(ctx, s.network, s.address)
+}
+
+// format wraps a record in an RFC 5424 header
+func (s *Syslog) format(record []byte) []byte {
+	header := fmt.Sprintf("<%d>1 %s %s %s %d - - ",
+		priority,
+		time.Now().UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		s.appName,
+		os.Getpid(),
+	)
+	return append([]byte(header), record...)
+}
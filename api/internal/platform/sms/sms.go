@@ -0,0 +1,44 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"starterkit/internal/config"
+)
+
+// Sender delivers text messages to E.164 phone numbers
+type Sender interface {
+	Send(ctx context.Context, to, body string) error
+}
+
+// New creates the SMS provider selected in configuration
+func New(cfg config.SMSConfig, logger *slog.Logger) (Sender, error) {
+	switch cfg.Provider {
+	case "log", "":
+		return NewLog(logger), nil
+	case "twilio":
+		return NewTwilio(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.From)
+	case "sns":
+		return NewSNS(context.Background(), cfg.SNSRegion, cfg.From)
+	default:
+		return nil, fmt.Errorf("unsupported SMS provider: %s", cfg.Provider)
+	}
+}
+
+// Log writes messages to the logger instead of sending them, for development
+type Log struct {
+	logger *slog.Logger
+}
+
+// NewLog creates a sender that only logs messages
+func NewLog(logger *slog.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+// Send logs the message
+func (l *Log) Send(ctx context.Context, to, body string) error {
+	l.logger.InfoContext(ctx, "sms not sent (log provider)", "to", to, "body", body)
+	return nil
+}
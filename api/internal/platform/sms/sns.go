@@ -0,0 +1,67 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// SNS sends messages through Amazon SNS direct-to-phone publishing
+type SNS struct {
+	client   *sns.Client
+	senderID string
+}
+
+// NewSNS creates an SNS sender using the default AWS credential chain. An
+// empty region falls back to AWS_REGION. senderID is optional and only
+// honoured in countries that support alphanumeric sender IDs.
+func NewSNS(ctx context.Context, region, senderID string) (*SNS, error) {
+	// Use the default transport so the process TLS policy applies
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithHTTPClient(&http.Client{}),
+	}
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return &SNS{
+		client:   sns.NewFromConfig(awsCfg),
+		senderID: senderID,
+	}, nil
+}
+
+// Send delivers a message
+func (s *SNS) Send(ctx context.Context, to, body string) error {
+	attrs := map[string]types.MessageAttributeValue{
+		"AWS.SNS.SMS.SMSType": {
+			DataType:    aws.String("String"),
+			StringValue: aws.String("Transactional"),
+		},
+	}
+	if s.senderID != "" {
+		attrs["AWS.SNS.SMS.SenderID"] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(s.senderID),
+		}
+	}
+
+	_, err := s.client.Publish(ctx, &sns.PublishInput{
+		PhoneNumber:       aws.String(to),
+		Message:           aws.String(body),
+		MessageAttributes: attrs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	return nil
+}
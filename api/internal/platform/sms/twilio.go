@@ -0,0 +1,71 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const twilioBaseURL = "https://api.twilio.com/2010-04-01"
+
+// Twilio sends messages through the Twilio Messages API
+type Twilio struct {
+	accountSID string
+	authToken  string
+	from       string
+	client     *http.Client
+}
+
+// NewTwilio creates a Twilio sender
+func NewTwilio(accountSID, authToken, from string) (*Twilio, error) {
+	if accountSID == "" || authToken == "" || from == "" {
+		return nil, fmt.Errorf("twilio requires an account SID, auth token, and from number")
+	}
+	return &Twilio{
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Send delivers a message
+func (t *Twilio) Send(ctx context.Context, to, body string) error {
+	form := url.Values{
+		"To":   {to},
+		"From": {t.from},
+		"Body": {body},
+	}
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioBaseURL, url.PathEscape(t.accountSID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create twilio request: %w", err)
+	}
+	req.SetBasicAuth(t.accountSID, t.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("twilio error %d: %s", apiErr.Code, apiErr.Message)
+		}
+		return fmt.Errorf("twilio returned status %d", resp.StatusCode)
+	}
+	return nil
+}
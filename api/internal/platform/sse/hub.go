@@ -0,0 +1,170 @@
+package sse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Message is a single server-sent event
+type Message struct {
+	Event string
+	Data  any
+}
+
+// Hub fans out messages to subscribers grouped by topic
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan Message]struct{}
+	bufferSize  int
+}
+
+// NewHub creates an empty hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[string]map[chan Message]struct{}),
+		bufferSize:  16,
+	}
+}
+
+// Subscribe registers interest in a topic. The returned function must be
+// called to release the subscription.
+func (h *Hub) Subscribe(topic string) (<-chan Message, func()) {
+	ch := make(chan Message, h.bufferSize)
+
+	h.mu.Lock()
+	if h.subscribers[topic] == nil {
+		h.subscribers[topic] = make(map[chan Message]struct{})
+	}
+	h.subscribers[topic][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[topic], ch)
+			if len(h.subscribers[topic]) == 0 {
+				delete(h.subscribers, topic)
+			}
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Publish sends a message to all subscribers of a topic. Slow subscribers
+// whose buffer is full miss the message rather than blocking the publisher.
+func (h *Hub) Publish(topic string, msg Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subscribers[topic] {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// Stream writes messages from ch to the client as an event stream until the
+// request is cancelled. A comment line is sent periodically to keep
+// intermediaries from closing idle connections.
+func Stream(w http.ResponseWriter, r *http.Request, ch <-chan Message) error {
+	rc, err := start(w)
+	if err != nil {
+		return err
+	}
+
+	keepAlive := time.NewTicker(25 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return err
+			}
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := writeMessage(w, msg); err != nil {
+				return err
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// Writer sends events as a handler produces them, for streams that are not
+// fed from a hub. The stream starts with the first message, so a handler can
+// still answer with a plain error response until then.
+type Writer struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// NewWriter creates a writer for w
+func NewWriter(w http.ResponseWriter) *Writer {
+	return &Writer{w: w}
+}
+
+// Started reports whether the event stream has begun
+func (sw *Writer) Started() bool {
+	return sw.rc != nil
+}
+
+// Send writes msg and flushes it to the client
+func (sw *Writer) Send(msg Message) error {
+	if sw.rc == nil {
+		rc, err := start(sw.w)
+		if err != nil {
+			return err
+		}
+		sw.rc = rc
+	}
+	if err := writeMessage(sw.w, msg); err != nil {
+		return err
+	}
+	return sw.rc.Flush()
+}
+
+// start writes the event stream headers
+func start(w http.ResponseWriter) (*http.ResponseController, error) {
+	rc := http.NewResponseController(w)
+
+	// Event streams outlive the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, err
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
+
+func writeMessage(w http.ResponseWriter, msg Message) error {
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if msg.Event != "" {
+		if _, err := fmt.Fprintf(w, "event: %s\n", msg.Event); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
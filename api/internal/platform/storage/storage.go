@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"starterkit/internal/config"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Storage is a minimal object storage abstraction
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens an object. Backends that can seek return an io.ReadSeeker,
+	// which downloads use to serve byte ranges.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New creates the storage backend selected in configuration
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Backend {
+	case "local", "":
+		return NewLocal(cfg.LocalPath)
+	default:
+		return nil, fmt.Errorf("unsupported storage backend: %s", cfg.Backend)
+	}
+}
@@ -0,0 +1,174 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BatchOptions configure the telemetry of a short-lived command
+type BatchOptions struct {
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+	// Detectors are the resource detectors describing where the run
+	// happened
+	Detectors []string
+	// Traces exports a span covering the run over OTLP
+	Traces bool
+	// Exporters are OTEL_METRICS_EXPORTER entries. otlp pushes the run's
+	// metrics to Endpoint as it ends; prometheus has nothing to scrape a
+	// command and is covered by PushgatewayURL instead.
+	Exporters []string
+	Endpoint  string
+	// TLS is nil to export over plaintext gRPC
+	TLS *tls.Config
+	// PushgatewayURL, when set, pushes the run's metrics to a Prometheus
+	// Pushgateway, grouped under job ServiceName
+	PushgatewayURL string
+}
+
+// BatchConfig returns the configured telemetry for the command binary
+// name, such as "migrate". Its service name is the server's with the
+// binary's appended.
+func BatchConfig(cfg *config.Config, name string, tlsConfig *tls.Config) BatchOptions {
+	return BatchOptions{
+		ServiceName:    cfg.Service.Name + "-" + name,
+		ServiceVersion: cfg.Service.Version,
+		Environment:    cfg.Service.Environment,
+		Detectors:      cfg.Telemetry.ResourceDetectors,
+		Traces:         cfg.Telemetry.Enabled,
+		Exporters:      cfg.Telemetry.MetricsExporters,
+		Endpoint:       cfg.Telemetry.OTLPEndpoint,
+		TLS:            tlsConfig,
+		PushgatewayURL: cfg.Telemetry.PushgatewayURL,
+	}
+}
+
+// Batch reports one run of a command that exits before a scrape or
+// periodic export would see it. The run is traced under a root span, and
+// its duration and outcome are recorded and pushed when it ends.
+type Batch struct {
+	command   string
+	opts      BatchOptions
+	started   time.Time
+	span      trace.Span
+	provider  *sdkmetric.MeterProvider
+	registry  *prometheus.Registry
+	stopTrace func()
+}
+
+// StartBatch starts reporting a run of command, such as "migrate up". The
+// returned context carries the run's span; End must be called with the
+// run's result before the process exits.
+func StartBatch(ctx context.Context, command string, opts BatchOptions) (context.Context, *Batch, error) {
+	b := &Batch{command: command, opts: opts, started: time.Now(), stopTrace: func() {}}
+
+	res, err := NewResource(ctx, opts.ServiceName, opts.ServiceVersion, opts.Environment, opts.Detectors)
+	if err != nil {
+		return ctx, nil, err
+	}
+	if opts.Traces {
+		// A run is one trace, so every run is recorded
+		shutdown, err := Init(ctx, res, opts.Endpoint, opts.TLS, 1)
+		if err != nil {
+			return ctx, nil, err
+		}
+		b.stopTrace = shutdown
+	}
+
+	meterOpts := []sdkmetric.Option{sdkmetric.WithResource(res)}
+	if slices.Contains(opts.Exporters, ExporterOTLP) {
+		exporter, err := newOTLPMetricExporter(ctx, opts.Endpoint, opts.TLS)
+		if err != nil {
+			b.stopTrace()
+			return ctx, nil, err
+		}
+		// Shutting the provider down collects and exports once more, so
+		// the interval only matters for runs that outlast it
+		meterOpts = append(meterOpts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)))
+	}
+	if opts.PushgatewayURL != "" {
+		b.registry = prometheus.NewRegistry()
+		exporter, err := otelprometheus.New(
+			otelprometheus.WithRegisterer(b.registry),
+			otelprometheus.WithoutScopeInfo(),
+			otelprometheus.WithoutTargetInfo(),
+		)
+		if err != nil {
+			b.stopTrace()
+			return ctx, nil, fmt.Errorf("failed to create Prometheus exporter: %w", err)
+		}
+		meterOpts = append(meterOpts, sdkmetric.WithReader(exporter))
+	}
+	if len(meterOpts) > 1 {
+		b.provider = sdkmetric.NewMeterProvider(meterOpts...)
+		otel.SetMeterProvider(b.provider)
+	}
+
+	ctx, b.span = otel.Tracer("starterkit/batch").Start(ctx, command,
+		trace.WithAttributes(attribute.String("batch.command", command)),
+	)
+	return ctx, b, nil
+}
+
+// End records the run's outcome, err being its result, and flushes its
+// span and metrics. Export failures are logged rather than returned so
+// they never change the command's exit status.
+func (b *Batch) End(err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+		b.span.RecordError(err)
+		b.span.SetStatus(codes.Error, err.Error())
+	}
+	b.span.SetAttributes(attribute.String("batch.outcome", outcome))
+	b.span.End()
+
+	ctx := context.Background()
+	attrs := metric.WithAttributes(attribute.String("batch.command", b.command), attribute.String("batch.outcome", outcome))
+	metrics.Int64Counter(metrics.BatchRuns).Add(ctx, 1, attrs)
+	metrics.Float64Histogram(metrics.BatchDuration).Record(ctx, time.Since(b.started).Seconds(), attrs)
+	if err == nil {
+		metrics.Int64Gauge(metrics.BatchLastSuccess).Record(ctx, time.Now().Unix(), metric.WithAttributes(attribute.String("batch.command", b.command)))
+	}
+
+	if b.registry != nil {
+		// Each command has its own group, as pushing replaces every series
+		// of a metric in the group. Add leaves metrics that were not pushed,
+		// so a failed run keeps the last success time of an earlier one. The
+		// grouping label must differ from the metrics' own labels, and its
+		// value is a path segment, so spaces become underscores.
+		if err := push.New(b.opts.PushgatewayURL, b.opts.ServiceName).
+			Grouping("command", strings.ReplaceAll(b.command, " ", "_")).
+			Gatherer(b.registry).
+			Add(); err != nil {
+			slog.Warn("failed to push metrics to Pushgateway", "error", err, "url", b.opts.PushgatewayURL)
+		}
+	}
+	if b.provider != nil {
+		shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := b.provider.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("failed to flush metrics", "error", err)
+		}
+	}
+	b.stopTrace()
+}
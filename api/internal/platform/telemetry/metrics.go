@@ -0,0 +1,95 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"google.golang.org/grpc/credentials"
+)
+
+// Metrics exporters selected in OTEL_METRICS_EXPORTER
+const (
+	ExporterOTLP       = "otlp"
+	ExporterPrometheus = "prometheus"
+	ExporterNone       = "none"
+)
+
+// InitMetrics installs a meter provider with the given exporters,
+// attributing metrics to res. OTLP pushes to endpoint every interval; a nil
+// tlsConfig exports over plaintext gRPC. Prometheus is scraped through the
+// returned handler, which is nil when that exporter is off, in the
+// Prometheus text format or OpenMetrics as the scraper asks. Without
+// exporters the global meter stays a no-op.
+func InitMetrics(ctx context.Context, res *resource.Resource, exporters []string, endpoint string, tlsConfig *tls.Config, interval time.Duration) (http.Handler, func(), error) {
+	opts := []sdkmetric.Option{sdkmetric.WithResource(res)}
+	var handler http.Handler
+	for _, name := range exporters {
+		switch name {
+		case ExporterOTLP:
+			exporter, err := newOTLPMetricExporter(ctx, endpoint, tlsConfig)
+			if err != nil {
+				return nil, nil, err
+			}
+			opts = append(opts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(interval))))
+		case ExporterPrometheus:
+			// A private registry keeps the scrape to this provider plus Go
+			// runtime and process metrics
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+			exporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to create Prometheus exporter: %w", err)
+			}
+			opts = append(opts, sdkmetric.WithReader(exporter))
+			// Scrapers that ask for OpenMetrics get it, with exemplars and
+			// _created series; others get the classic text format
+			handler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+		case ExporterNone:
+		default:
+			return nil, nil, fmt.Errorf("unsupported metrics exporter: %s", name)
+		}
+	}
+	if len(opts) == 1 {
+		return nil, func() {}, nil
+	}
+
+	mp := sdkmetric.NewMeterProvider(opts...)
+	otel.SetMeterProvider(mp)
+
+	return handler, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := mp.Shutdown(shutdownCtx); err != nil {
+			fmt.Printf("error shutting down meter provider: %v\n", err)
+		}
+	}, nil
+}
+
+func newOTLPMetricExporter(ctx context.Context, endpoint string, tlsConfig *tls.Config) (*otlpmetricgrpc.Exporter, error) {
+	opts := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(endpoint),
+		otlpmetricgrpc.WithTimeout(5 * time.Second),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	exporter, err := otlpmetricgrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+	return exporter, nil
+}
@@ -0,0 +1,307 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// Resource detectors, named in TELEMETRY_RESOURCE_DETECTORS
+const (
+	DetectorHost      = "host"
+	DetectorContainer = "container"
+	DetectorK8s       = "k8s"
+	DetectorCloud     = "cloud"
+)
+
+// metadataTimeout bounds the cloud metadata probes, which hang rather than
+// fail off the cloud
+const metadataTimeout = time.Second
+
+// NewResource describes this process to telemetry backends: the service
+// from configuration, what the named detectors find about where it runs,
+// and OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME, which override both.
+// Detectors that find nothing add nothing. service.instance.id, unless set
+// in the environment, is the pod, container, or host, so replicas are told
+// apart.
+func NewResource(ctx context.Context, serviceName, serviceVersion, environment string, detectors []string) (*resource.Resource, error) {
+	opts := []resource.Option{resource.WithTelemetrySDK()}
+	for _, name := range detectors {
+		switch name {
+		case DetectorHost:
+			opts = append(opts, resource.WithHost(), resource.WithOSType(), resource.WithProcessPID())
+		case DetectorContainer:
+			opts = append(opts, resource.WithDetectors(containerDetector{}))
+		case DetectorK8s:
+			opts = append(opts, resource.WithDetectors(k8sDetector{}))
+		case DetectorCloud:
+			opts = append(opts, resource.WithDetectors(cloudDetector{}))
+		default:
+			return nil, fmt.Errorf("unknown resource detector: %s", name)
+		}
+	}
+	opts = append(opts,
+		resource.WithAttributes(
+			semconv.ServiceNameKey.String(serviceName),
+			semconv.ServiceVersionKey.String(serviceVersion),
+			semconv.DeploymentEnvironmentNameKey.String(environment),
+		),
+		resource.WithFromEnv(),
+	)
+
+	res, err := resource.New(ctx, opts...)
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+	if _, ok := res.Set().Value(semconv.ServiceInstanceIDKey); !ok {
+		for _, key := range []attribute.Key{semconv.K8SPodNameKey, semconv.ContainerIDKey, semconv.HostNameKey} {
+			if v, ok := res.Set().Value(key); ok && v.AsString() != "" {
+				return resource.Merge(res, resource.NewSchemaless(semconv.ServiceInstanceIDKey.String(v.AsString())))
+			}
+		}
+	}
+	return res, nil
+}
+
+// Container IDs are the 64 hex digits in the process's cgroup v1 paths, or
+// under cgroup v2, where the cgroup is just /, in the paths of the files
+// Docker and Podman mount into the container, such as its /etc/hostname
+var (
+	cgroupContainerID = regexp.MustCompile(`[0-9a-f]{64}`)
+	mountContainerID  = regexp.MustCompile(`containers/([0-9a-f]{64})/`)
+)
+
+// containerDetector reads the ID of the container the process runs in
+type containerDetector struct{}
+
+func (containerDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	id := findContainerID("/proc/self/cgroup", cgroupContainerID)
+	if id == "" {
+		id = findContainerID("/proc/self/mountinfo", mountContainerID)
+	}
+	if id == "" {
+		return resource.Empty(), nil
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, semconv.ContainerIDKey.String(id)), nil
+}
+
+// findContainerID returns the first match of pattern in the file, or its
+// group if it has one
+func findContainerID(path string, pattern *regexp.Regexp) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := pattern.FindStringSubmatch(scanner.Text()); m != nil {
+			return m[len(m)-1]
+		}
+	}
+	return ""
+}
+
+// k8sDetector names the pod from the downward API variables the deployment
+// sets, falling back to the hostname Kubernetes gives pods and the service
+// account's namespace
+type k8sDetector struct{}
+
+func (k8sDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return resource.Empty(), nil
+	}
+
+	var attrs []attribute.KeyValue
+	add := func(key attribute.Key, values ...string) {
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v != "" {
+				attrs = append(attrs, key.String(v))
+				return
+			}
+		}
+	}
+	namespace, _ := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	add(semconv.K8SPodNameKey, os.Getenv("K8S_POD_NAME"), os.Getenv("HOSTNAME"))
+	add(semconv.K8SPodUIDKey, os.Getenv("K8S_POD_UID"))
+	add(semconv.K8SNamespaceNameKey, os.Getenv("K8S_NAMESPACE"), string(namespace))
+	add(semconv.K8SNodeNameKey, os.Getenv("K8S_NODE_NAME"))
+	add(semconv.K8SDeploymentNameKey, os.Getenv("K8S_DEPLOYMENT_NAME"))
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...), nil
+}
+
+// cloudDetector recognizes managed platforms by the variables they set, and
+// otherwise asks the instance metadata services of AWS, Google Cloud, and
+// Azure at once, keeping whichever answers
+type cloudDetector struct{}
+
+func (cloudDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	if attrs := cloudFromEnv(); attrs != nil {
+		return resource.NewWithAttributes(semconv.SchemaURL, attrs...), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+	probes := []func(context.Context) []attribute.KeyValue{awsMetadata, gcpMetadata, azureMetadata}
+	found := make(chan []attribute.KeyValue, len(probes))
+	for _, probe := range probes {
+		go func() { found <- probe(ctx) }()
+	}
+	for range probes {
+		if attrs := <-found; attrs != nil {
+			return resource.NewWithAttributes(semconv.SchemaURL, attrs...), nil
+		}
+	}
+	return resource.Empty(), nil
+}
+
+func cloudFromEnv() []attribute.KeyValue {
+	switch {
+	case os.Getenv("K_SERVICE") != "" && os.Getenv("K_REVISION") != "":
+		attrs := []attribute.KeyValue{semconv.CloudProviderGCP, semconv.CloudPlatformGCPCloudRun, semconv.FaaSNameKey.String(os.Getenv("K_SERVICE")), semconv.FaaSVersionKey.String(os.Getenv("K_REVISION"))}
+		if project := os.Getenv("GOOGLE_CLOUD_PROJECT"); project != "" {
+			attrs = append(attrs, semconv.CloudAccountIDKey.String(project))
+		}
+		return attrs
+	case os.Getenv("ECS_CONTAINER_METADATA_URI_V4") != "":
+		attrs := []attribute.KeyValue{semconv.CloudProviderAWS, semconv.CloudPlatformAWSECS}
+		if region := os.Getenv("AWS_REGION"); region != "" {
+			attrs = append(attrs, semconv.CloudRegionKey.String(region))
+		}
+		return attrs
+	case os.Getenv("WEBSITE_SITE_NAME") != "":
+		attrs := []attribute.KeyValue{semconv.CloudProviderAzure, semconv.CloudPlatformAzureAppService}
+		if region := os.Getenv("REGION_NAME"); region != "" {
+			attrs = append(attrs, semconv.CloudRegionKey.String(region))
+		}
+		return attrs
+	}
+	return nil
+}
+
+// awsMetadata reads the EC2 instance identity document through IMDSv2
+func awsMetadata(ctx context.Context) []attribute.KeyValue {
+	token, err := metadataGet(ctx, http.MethodPut, "http://169.254.169.254/latest/api/token", map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err != nil {
+		return nil
+	}
+	body, err := metadataGet(ctx, http.MethodGet, "http://169.254.169.254/latest/dynamic/instance-identity/document", map[string]string{"X-aws-ec2-metadata-token": string(token)})
+	if err != nil {
+		return nil
+	}
+	var doc struct {
+		AccountID        string `json:"accountId"`
+		AvailabilityZone string `json:"availabilityZone"`
+		Region           string `json:"region"`
+		InstanceID       string `json:"instanceId"`
+	}
+	if json.Unmarshal(body, &doc) != nil || doc.InstanceID == "" {
+		return nil
+	}
+	return []attribute.KeyValue{
+		semconv.CloudProviderAWS,
+		semconv.CloudPlatformAWSEC2,
+		semconv.CloudAccountIDKey.String(doc.AccountID),
+		semconv.CloudRegionKey.String(doc.Region),
+		semconv.CloudAvailabilityZoneKey.String(doc.AvailabilityZone),
+		semconv.HostIDKey.String(doc.InstanceID),
+	}
+}
+
+// gcpMetadata reads the Compute Engine metadata server
+func gcpMetadata(ctx context.Context) []attribute.KeyValue {
+	body, err := metadataGet(ctx, http.MethodGet, "http://169.254.169.254/computeMetadata/v1/?recursive=true", map[string]string{"Metadata-Flavor": "Google"})
+	if err != nil {
+		return nil
+	}
+	var doc struct {
+		Instance struct {
+			ID   json.Number `json:"id"`
+			Zone string      `json:"zone"`
+		} `json:"instance"`
+		Project struct {
+			ProjectID string `json:"projectId"`
+		} `json:"project"`
+	}
+	if json.Unmarshal(body, &doc) != nil || doc.Project.ProjectID == "" {
+		return nil
+	}
+	// The zone is projects/<number>/zones/<region>-<letter>
+	zone := doc.Instance.Zone[strings.LastIndex(doc.Instance.Zone, "/")+1:]
+	attrs := []attribute.KeyValue{
+		semconv.CloudProviderGCP,
+		semconv.CloudPlatformGCPComputeEngine,
+		semconv.CloudAccountIDKey.String(doc.Project.ProjectID),
+		semconv.CloudAvailabilityZoneKey.String(zone),
+		semconv.HostIDKey.String(doc.Instance.ID.String()),
+	}
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		attrs = append(attrs, semconv.CloudRegionKey.String(zone[:i]))
+	}
+	return attrs
+}
+
+// azureMetadata reads the Azure Instance Metadata Service
+func azureMetadata(ctx context.Context) []attribute.KeyValue {
+	body, err := metadataGet(ctx, http.MethodGet, "http://169.254.169.254/metadata/instance/compute?api-version=2021-02-01", map[string]string{"Metadata": "true"})
+	if err != nil {
+		return nil
+	}
+	var doc struct {
+		Location       string `json:"location"`
+		SubscriptionID string `json:"subscriptionId"`
+		VMID           string `json:"vmId"`
+		ResourceID     string `json:"resourceId"`
+		Zone           string `json:"zone"`
+	}
+	if json.Unmarshal(body, &doc) != nil || doc.VMID == "" {
+		return nil
+	}
+	attrs := []attribute.KeyValue{
+		semconv.CloudProviderAzure,
+		semconv.CloudPlatformAzureVM,
+		semconv.CloudAccountIDKey.String(doc.SubscriptionID),
+		semconv.CloudRegionKey.String(doc.Location),
+		semconv.CloudResourceIDKey.String(doc.ResourceID),
+		semconv.HostIDKey.String(doc.VMID),
+	}
+	if doc.Zone != "" {
+		attrs = append(attrs, semconv.CloudAvailabilityZoneKey.String(doc.Zone))
+	}
+	return attrs
+}
+
+// metadataGet calls a link-local metadata endpoint directly, never through
+// a proxy, and returns the body of a 200 response
+func metadataGet(ctx context.Context, method, url string, header map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	client := &http.Client{Transport: &http.Transport{Proxy: nil}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata service answered %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
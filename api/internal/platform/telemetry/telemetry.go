@@ -2,6 +2,7 @@ package telemetry
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"time"
 
@@ -11,33 +12,54 @@ import (
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/credentials"
 )
 
-// Init initializes OpenTelemetry SDK
-func Init(ctx context.Context, serviceName, serviceVersion string) (func(), error) {
-	// Create resource
-	res, err := resource.Merge(
-		resource.Default(),
-		resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(serviceName),
-			semconv.ServiceVersionKey.String(serviceVersion),
-		),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
+type contextKey struct{}
+
+// ForceSampling returns a context whose new spans are recorded whatever the
+// sample ratio, and so are their children
+func ForceSampling(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, true)
+}
+
+// forcedSampler samples spans started from a ForceSampling context and
+// leaves the rest to base
+type forcedSampler struct {
+	base sdktrace.Sampler
+}
+
+func (s forcedSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if forced, _ := p.ParentContext.Value(contextKey{}).(bool); forced {
+		return sdktrace.SamplingResult{
+			Decision:   sdktrace.RecordAndSample,
+			Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+		}
 	}
+	return s.base.ShouldSample(p)
+}
+
+func (s forcedSampler) Description() string {
+	return "Forced{" + s.base.Description() + "}"
+}
 
+// Init initializes OpenTelemetry SDK, attributing spans to res, which
+// NewResource builds. A nil tlsConfig exports over plaintext gRPC.
+// sampleRatio is the fraction of new traces recorded; child spans follow
+// their parent's sampling decision, unless ForceSampling marks them.
+func Init(ctx context.Context, res *resource.Resource, endpoint string, tlsConfig *tls.Config, sampleRatio float64) (func(), error) {
 	// Create OTLP exporter
-	exporter, err := otlptrace.New(
-		ctx,
-		otlptracegrpc.NewClient(
-			otlptracegrpc.WithInsecure(),
-			otlptracegrpc.WithEndpoint("localhost:4317"),
-			otlptracegrpc.WithTimeout(5*time.Second),
-		),
-	)
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(endpoint),
+		otlptracegrpc.WithTimeout(5 * time.Second),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptrace.New(ctx, otlptracegrpc.NewClient(opts...))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
 	}
@@ -46,7 +68,7 @@ func Init(ctx context.Context, serviceName, serviceVersion string) (func(), erro
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSampler(forcedSampler{sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))}),
 	)
 
 	// Register as global tracer provider
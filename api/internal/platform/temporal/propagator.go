@@ -0,0 +1,94 @@
+package temporal
+
+import (
+	"context"
+	"fmt"
+
+	"starterkit/internal/platform/logger"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/workflow"
+)
+
+// headerKey names the Temporal header holding the propagated context
+const headerKey = "starterkit-context"
+
+// requestIDField is stored next to the W3C trace context fields
+const requestIDField = "request_id"
+
+type workflowContextKey struct{}
+
+// Propagator carries the request ID and OpenTelemetry trace context from
+// the code that starts a workflow into the workflow and its activities
+type Propagator struct{}
+
+// Inject writes the request ID and trace context of ctx to the headers
+func (Propagator) Inject(ctx context.Context, w workflow.HeaderWriter) error {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if id := logger.RequestID(ctx); id != "" {
+		carrier[requestIDField] = id
+	}
+	return writeHeader(w, carrier)
+}
+
+// Extract restores the request ID and trace context in an activity context
+func (Propagator) Extract(ctx context.Context, r workflow.HeaderReader) (context.Context, error) {
+	carrier, err := readHeader(r)
+	if err != nil || carrier == nil {
+		return ctx, err
+	}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	if id := carrier[requestIDField]; id != "" {
+		ctx = logger.WithRequestID(ctx, id)
+	}
+	return ctx, nil
+}
+
+// InjectFromWorkflow passes the workflow's context on to the activities and
+// child workflows it starts
+func (Propagator) InjectFromWorkflow(ctx workflow.Context, w workflow.HeaderWriter) error {
+	carrier, _ := ctx.Value(workflowContextKey{}).(propagation.MapCarrier)
+	return writeHeader(w, carrier)
+}
+
+// ExtractToWorkflow keeps the propagated context in the workflow context
+func (Propagator) ExtractToWorkflow(ctx workflow.Context, r workflow.HeaderReader) (workflow.Context, error) {
+	carrier, err := readHeader(r)
+	if err != nil || carrier == nil {
+		return ctx, err
+	}
+	return workflow.WithValue(ctx, workflowContextKey{}, carrier), nil
+}
+
+// RequestID returns the ID of the request that started the workflow
+func RequestID(ctx workflow.Context) string {
+	carrier, _ := ctx.Value(workflowContextKey{}).(propagation.MapCarrier)
+	return carrier[requestIDField]
+}
+
+func writeHeader(w workflow.HeaderWriter, carrier propagation.MapCarrier) error {
+	if len(carrier) == 0 {
+		return nil
+	}
+	payload, err := converter.GetDefaultDataConverter().ToPayload(map[string]string(carrier))
+	if err != nil {
+		return fmt.Errorf("failed to encode temporal context header: %w", err)
+	}
+	w.Set(headerKey, payload)
+	return nil
+}
+
+func readHeader(r workflow.HeaderReader) (propagation.MapCarrier, error) {
+	payload, ok := r.Get(headerKey)
+	if !ok {
+		return nil, nil
+	}
+	var fields map[string]string
+	if err := converter.GetDefaultDataConverter().FromPayload(payload, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode temporal context header: %w", err)
+	}
+	return propagation.MapCarrier(fields), nil
+}
@@ -0,0 +1,36 @@
+package temporal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/tlspolicy"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/workflow"
+)
+
+// NewClient connects to the Temporal cluster in cfg. Workflows started
+// through the client carry the caller's request ID and trace context into
+// their activities.
+func NewClient(ctx context.Context, cfg config.TemporalConfig, policy *tlspolicy.Policy, logger *slog.Logger) (client.Client, error) {
+	opts := client.Options{
+		HostPort:           cfg.HostPort,
+		Namespace:          cfg.Namespace,
+		Logger:             log.NewStructuredLogger(logger),
+		ContextPropagators: []workflow.ContextPropagator{Propagator{}},
+	}
+	if cfg.APIKey != "" {
+		opts.Credentials = client.NewAPIKeyStaticCredentials(cfg.APIKey)
+		opts.ConnectionOptions.TLS = policy.Config()
+	}
+
+	c, err := client.DialContext(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to temporal: %w", err)
+	}
+	return c, nil
+}
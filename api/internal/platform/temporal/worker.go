@@ -0,0 +1,110 @@
+package temporal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"starterkit/internal/platform/logger"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/worker"
+)
+
+// Worker runs the workflows and activities feature packages register on
+// one task queue
+type Worker struct {
+	worker     worker.Worker
+	taskQueue  string
+	registered bool
+	logger     *slog.Logger
+}
+
+// NewWorker creates a worker polling taskQueue. Activities it runs get a
+// span and a logger carrying the request ID of the workflow's starter.
+func NewWorker(c client.Client, taskQueue string, logger *slog.Logger) *Worker {
+	return &Worker{
+		worker: worker.New(c, taskQueue, worker.Options{
+			Interceptors: []interceptor.WorkerInterceptor{&workerInterceptor{}},
+		}),
+		taskQueue: taskQueue,
+		logger:    logger,
+	}
+}
+
+// RegisterWorkflow registers a workflow function
+func (w *Worker) RegisterWorkflow(fn any) {
+	w.worker.RegisterWorkflow(fn)
+	w.registered = true
+}
+
+// RegisterActivity registers an activity function, or a struct whose
+// exported methods are activities
+func (w *Worker) RegisterActivity(a any) {
+	w.worker.RegisterActivity(a)
+	w.registered = true
+}
+
+// Run polls the task queue until ctx is cancelled, then waits for running
+// activities to finish. It returns at once when nothing is registered.
+func (w *Worker) Run(ctx context.Context) error {
+	if !w.registered {
+		w.logger.Info("no temporal workflows registered, worker not started")
+		return nil
+	}
+	if err := w.worker.Start(); err != nil {
+		return fmt.Errorf("failed to start temporal worker: %w", err)
+	}
+	w.logger.Info("temporal worker started", "task_queue", w.taskQueue)
+
+	<-ctx.Done()
+	w.worker.Stop()
+	return nil
+}
+
+type workerInterceptor struct {
+	interceptor.WorkerInterceptorBase
+}
+
+func (workerInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	i := &activityInterceptor{}
+	i.Next = next
+	return i
+}
+
+// activityInterceptor traces each activity under the propagated trace
+// context and adds its identity to the context logger
+type activityInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+}
+
+func (i *activityInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (any, error) {
+	info := activity.GetInfo(ctx)
+	ctx, span := otel.Tracer("starterkit/temporal").Start(ctx, "temporal.activity."+info.ActivityType.Name,
+		trace.WithAttributes(
+			attribute.String("temporal.workflow_id", info.WorkflowExecution.ID),
+			attribute.String("temporal.activity_type", info.ActivityType.Name),
+			attribute.Int("temporal.attempt", int(info.Attempt)),
+		),
+	)
+	defer span.End()
+
+	ctx = logger.WithContext(ctx, logger.FromContext(ctx).With(
+		"workflow_id", info.WorkflowExecution.ID,
+		"activity", info.ActivityType.Name,
+		"attempt", info.Attempt,
+	))
+
+	result, err := i.Next.ExecuteActivity(ctx, in)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return result, err
+}
@@ -0,0 +1,180 @@
+package tlspolicy
+
+import (
+	"crypto/fips140"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"starterkit/internal/config"
+)
+
+// Policy is the process-wide TLS policy applied to every crypto surface:
+// the HTTPS listener, outbound HTTP clients, the OTLP gRPC exporter, and
+// database connections
+type Policy struct {
+	profile          string
+	minVersion       uint16
+	cipherSuites     []uint16
+	curvePreferences []tls.CurveID
+	// clientCAs verify client certificates on the listener; nil disables
+	// mutual TLS
+	clientCAs *x509.CertPool
+}
+
+var versions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var curves = map[string]tls.CurveID{
+	"X25519":         tls.X25519,
+	"P256":           tls.CurveP256,
+	"P384":           tls.CurveP384,
+	"P521":           tls.CurveP521,
+	"X25519MLKEM768": tls.X25519MLKEM768,
+}
+
+// fipsCipherSuites are the TLS 1.2 suites approved under FIPS 140-3
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// New builds the policy selected in configuration. A profile sets the
+// baseline, and explicit min version, cipher suites, or curves override it:
+//   - default: Go's defaults (TLS 1.2+)
+//   - modern: TLS 1.3 only
+//   - fips: TLS 1.2+, AES-GCM ECDHE suites, NIST curves
+//
+// Cipher suites only apply to TLS 1.2; Go does not allow configuring TLS 1.3 suites.
+func New(cfg config.TLSConfig) (*Policy, error) {
+	p := &Policy{profile: cfg.Profile, minVersion: tls.VersionTLS12}
+
+	switch cfg.Profile {
+	case "default", "":
+		p.profile = "default"
+	case "modern":
+		p.minVersion = tls.VersionTLS13
+	case "fips":
+		p.cipherSuites = fipsCipherSuites
+		p.curvePreferences = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+	default:
+		return nil, fmt.Errorf("unsupported TLS profile: %s", cfg.Profile)
+	}
+
+	if cfg.MinVersion != "" {
+		v, ok := versions[cfg.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS min version: %s", cfg.MinVersion)
+		}
+		p.minVersion = v
+	}
+
+	if len(cfg.CipherSuites) > 0 {
+		suites, err := parseCipherSuites(cfg.CipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		p.cipherSuites = suites
+	}
+
+	if len(cfg.CurvePreferences) > 0 {
+		p.curvePreferences = nil
+		for _, name := range cfg.CurvePreferences {
+			id, ok := curves[name]
+			if !ok {
+				return nil, fmt.Errorf("unsupported TLS curve: %s", name)
+			}
+			p.curvePreferences = append(p.curvePreferences, id)
+		}
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS client CA file: %w", err)
+		}
+		p.clientCAs = x509.NewCertPool()
+		if !p.clientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in TLS client CA file %s", cfg.ClientCAFile)
+		}
+	}
+
+	return p, nil
+}
+
+// Profile returns the name of the selected profile
+func (p *Policy) Profile() string {
+	return p.profile
+}
+
+// FIPSMode reports whether the Go Cryptographic Module runs in FIPS 140-3
+// mode (GODEBUG=fips140=on). The fips profile restricts negotiation but
+// only FIPS mode guarantees validated primitives are used.
+func (p *Policy) FIPSMode() bool {
+	return fips140.Enabled()
+}
+
+// Apply sets the policy's version, cipher suite, and curve constraints on
+// cfg, leaving certificates and server name verification untouched
+func (p *Policy) Apply(cfg *tls.Config) {
+	cfg.MinVersion = p.minVersion
+	if p.cipherSuites != nil {
+		cfg.CipherSuites = p.cipherSuites
+	}
+	if p.curvePreferences != nil {
+		cfg.CurvePreferences = p.curvePreferences
+	}
+}
+
+// Config returns a new tls.Config that follows the policy
+func (p *Policy) Config() *tls.Config {
+	cfg := &tls.Config{}
+	p.Apply(cfg)
+	return cfg
+}
+
+// ServerConfig returns the listener's tls.Config. With client CAs
+// configured it asks for a client certificate and verifies any that is
+// sent; clients without one are still served and authenticate otherwise.
+func (p *Policy) ServerConfig() *tls.Config {
+	cfg := p.Config()
+	if p.clientCAs != nil {
+		cfg.ClientCAs = p.clientCAs
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return cfg
+}
+
+// InstallDefaultTransport applies the policy to http.DefaultTransport. The
+// platform HTTP clients leave Transport unset, so they inherit it.
+func (p *Policy) InstallDefaultTransport() {
+	transport := http.DefaultTransport.(*http.Transport)
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	p.Apply(transport.TLSClientConfig)
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	byName := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		byName[s.Name] = s.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := byName[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported or insecure TLS cipher suite: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
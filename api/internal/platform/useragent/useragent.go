@@ -0,0 +1,161 @@
+// Package useragent classifies User-Agent headers by browser, operating
+// system, and device class
+package useragent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Device classes
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+// Agent is what a parser recognized in a User-Agent header. Unrecognized
+// fields are empty, except DeviceClass, which is DeviceUnknown.
+type Agent struct {
+	Browser        string `json:"browser"`
+	BrowserVersion string `json:"browser_version"`
+	OS             string `json:"os"`
+	DeviceClass    string `json:"device_class"`
+}
+
+// Parser classifies User-Agent headers. The builtin parser needs no
+// dependency; an implementation backed by a library such as uap-go can be
+// plugged in through New when more precision is needed.
+type Parser interface {
+	Parse(ua string) Agent
+}
+
+// New creates the parser selected in configuration
+func New(name string) (Parser, error) {
+	switch name {
+	case "builtin", "":
+		return Builtin{}, nil
+	case "none":
+		return None{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported user agent parser: %s", name)
+	}
+}
+
+// None recognizes nothing, for deployments that keep only the raw header
+type None struct{}
+
+func (None) Parse(ua string) Agent {
+	return Agent{DeviceClass: DeviceUnknown}
+}
+
+type contextKey string
+
+const agentKey contextKey = "user_agent"
+
+// WithContext adds the caller's parsed user agent to the context
+func WithContext(ctx context.Context, agent Agent) context.Context {
+	return context.WithValue(ctx, agentKey, agent)
+}
+
+// FromContext extracts the caller's parsed user agent
+func FromContext(ctx context.Context) (Agent, bool) {
+	agent, ok := ctx.Value(agentKey).(Agent)
+	return agent, ok
+}
+
+// Builtin recognizes the major browsers, operating systems, and crawlers
+// from well-known User-Agent tokens
+type Builtin struct{}
+
+// botTokens mark automated clients; matched case-insensitively
+var botTokens = []string{"bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-requests", "go-http-client", "okhttp", "headless"}
+
+// browsers are checked in order, since most browsers also send the tokens
+// of the engines they are built on
+var browsers = []struct{ name, token string }{
+	{"Edge", "Edg/"},
+	{"Edge", "EdgiOS/"},
+	{"Opera", "OPR/"},
+	{"Samsung Internet", "SamsungBrowser/"},
+	{"Firefox", "Firefox/"},
+	{"Firefox", "FxiOS/"},
+	{"Chrome", "CriOS/"},
+	{"Chrome", "Chrome/"},
+	{"Safari", "Version/"},
+}
+
+func (Builtin) Parse(ua string) Agent {
+	agent := Agent{DeviceClass: DeviceUnknown}
+	if ua == "" {
+		return agent
+	}
+	lower := strings.ToLower(ua)
+
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPod"):
+		agent.OS = "iOS"
+	case strings.Contains(ua, "iPad"):
+		agent.OS = "iPadOS"
+	case strings.Contains(ua, "Android"):
+		agent.OS = "Android"
+	case strings.Contains(ua, "Windows"):
+		agent.OS = "Windows"
+	case strings.Contains(ua, "Mac OS X"), strings.Contains(ua, "Macintosh"):
+		agent.OS = "macOS"
+	case strings.Contains(ua, "CrOS"):
+		agent.OS = "ChromeOS"
+	case strings.Contains(ua, "Linux"), strings.Contains(ua, "X11"):
+		agent.OS = "Linux"
+	}
+
+	for _, b := range browsers {
+		if version, ok := tokenVersion(ua, b.token); ok {
+			// Safari's Version/ token only identifies Safari itself
+			if b.name == "Safari" && !strings.Contains(ua, "Safari/") {
+				continue
+			}
+			agent.Browser, agent.BrowserVersion = b.name, version
+			break
+		}
+	}
+
+	switch {
+	case containsAny(lower, botTokens):
+		agent.DeviceClass = DeviceBot
+	case agent.OS == "iPadOS", strings.Contains(lower, "tablet"),
+		agent.OS == "Android" && !strings.Contains(ua, "Mobile"):
+		agent.DeviceClass = DeviceTablet
+	case agent.OS == "iOS", agent.OS == "Android", strings.Contains(ua, "Mobile"):
+		agent.DeviceClass = DeviceMobile
+	case agent.OS != "":
+		agent.DeviceClass = DeviceDesktop
+	}
+	return agent
+}
+
+// tokenVersion returns the major version following token, such as "126"
+// for "Chrome/126.0.6478.127"
+func tokenVersion(ua, token string) (string, bool) {
+	i := strings.Index(ua, token)
+	if i < 0 {
+		return "", false
+	}
+	version := ua[i+len(token):]
+	if end := strings.IndexAny(version, ". ;)"); end >= 0 {
+		version = version[:end]
+	}
+	return version, true
+}
+
+func containsAny(s string, tokens []string) bool {
+	for _, t := range tokens {
+		if strings.Contains(s, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package watchdog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxDumpBytes caps the goroutine dump attached to a stall report
+const maxDumpBytes = 64 << 10
+
+// RestartFunc stops a stalled loop and starts a fresh one. It returns an
+// error when the old loop could not be stopped.
+type RestartFunc func() error
+
+// Heartbeat is beaten by a loop each time it makes progress
+type Heartbeat struct {
+	name    string
+	timeout time.Duration
+	restart RestartFunc
+	last    atomic.Int64
+
+	// Owned by the watchdog goroutine
+	restartedAt time.Time
+	failures    int
+}
+
+// Beat records progress, clearing any earlier stall
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+func (h *Heartbeat) lastBeat() time.Time {
+	return time.Unix(0, h.last.Load())
+}
+
+// Watchdog restarts loops whose heartbeat stalls and reports the process
+// unready once restarts stop helping
+type Watchdog struct {
+	logger      *slog.Logger
+	stall       time.Duration
+	maxRestarts int
+
+	mu     sync.Mutex
+	beats  []*Heartbeat
+	failed map[string]bool
+}
+
+// New creates a watchdog. A loop counts as stalled when it has not beaten
+// for its interval plus stall; after maxRestarts consecutive stalls the
+// watchdog keeps restarting it but readiness fails.
+func New(logger *slog.Logger, stall time.Duration, maxRestarts int) *Watchdog {
+	return &Watchdog{
+		logger:      logger,
+		stall:       stall,
+		maxRestarts: maxRestarts,
+		failed:      make(map[string]bool),
+	}
+}
+
+// Register starts watching a loop expected to beat at least every interval
+func (w *Watchdog) Register(name string, interval time.Duration, restart RestartFunc) *Heartbeat {
+	h := &Heartbeat{
+		name:    name,
+		timeout: interval + w.stall,
+		restart: restart,
+	}
+	h.Beat()
+
+	w.mu.Lock()
+	w.beats = append(w.beats, h)
+	w.mu.Unlock()
+	return h
+}
+
+// Run checks heartbeats every interval until ctx is cancelled
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// Stalled returns the loops that are failing readiness
+func (w *Watchdog) Stalled() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var names []string
+	for _, h := range w.beats {
+		if w.failed[h.name] {
+			names = append(names, h.name)
+		}
+	}
+	return names
+}
+
+func (w *Watchdog) check() {
+	w.mu.Lock()
+	beats := append([]*Heartbeat(nil), w.beats...)
+	w.mu.Unlock()
+
+	now := time.Now()
+	for _, h := range beats {
+		last := h.lastBeat()
+		// A restarted loop gets a full timeout before it is judged again
+		since := now.Sub(last)
+		if h.restartedAt.After(last) {
+			since = now.Sub(h.restartedAt)
+		}
+		if since <= h.timeout {
+			if h.failures > 0 && last.After(h.restartedAt) {
+				w.logger.Info("heartbeat recovered", "loop", h.name, "restarts", h.failures)
+				h.failures = 0
+				w.setFailed(h.name, false)
+			}
+			continue
+		}
+
+		h.failures++
+		w.logger.Error("heartbeat stalled",
+			"loop", h.name,
+			"since_last_beat", now.Sub(last),
+			"timeout", h.timeout,
+			"consecutive_stalls", h.failures,
+			"goroutines", goroutineDump(),
+		)
+		if h.failures > w.maxRestarts {
+			w.setFailed(h.name, true)
+		}
+
+		if err := h.restart(); err != nil {
+			w.logger.Error("failed to restart stalled loop", "loop", h.name, "error", err)
+		}
+		h.restartedAt = time.Now()
+	}
+}
+
+func (w *Watchdog) setFailed(name string, failed bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if failed {
+		if !w.failed[name] {
+			w.logger.Error("loop keeps stalling after restarts, marking not ready", "loop", name)
+		}
+		w.failed[name] = true
+		return
+	}
+	delete(w.failed, name)
+}
+
+func goroutineDump() string {
+	var buf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, 2)
+	if buf.Len() > maxDumpBytes {
+		return buf.String()[:maxDumpBytes] + "\n... truncated"
+	}
+	return buf.String()
+}
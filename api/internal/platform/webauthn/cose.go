@@ -0,0 +1,123 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithms (RFC 9053) a credential's key may use
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// Algorithms are offered to authenticators in order of preference
+var Algorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1
+	coseX         = -2
+	coseY         = -3
+	coseRSAN      = -1
+	coseRSAE      = -2
+)
+
+// COSE key types and curves
+const (
+	keyTypeOKP = 1
+	keyTypeEC2 = 2
+	keyTypeRSA = 3
+	curveP256  = 1
+	curveEd    = 6
+)
+
+// ErrUnsupportedKey is returned for credential keys of an algorithm this
+// package does not verify
+var ErrUnsupportedKey = errors.New("unsupported credential public key")
+
+// publicKey is a parsed COSE key
+type publicKey struct {
+	algorithm int
+	key       crypto.PublicKey
+}
+
+// parsePublicKey parses a COSE_Key as stored with a credential
+func parsePublicKey(data []byte) (*publicKey, error) {
+	decoded, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrUnsupportedKey)
+	}
+	return publicKeyFromMap(decoded)
+}
+
+func publicKeyFromMap(decoded any) (*publicKey, error) {
+	m, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: not a map", ErrUnsupportedKey)
+	}
+	kty, _ := m[int64(coseKeyType)].(int64)
+	alg, _ := m[int64(coseAlgorithm)].(int64)
+
+	switch {
+	case kty == keyTypeEC2 && alg == AlgES256:
+		crv, _ := m[int64(coseCurve)].(int64)
+		x, _ := m[int64(coseX)].([]byte)
+		y, _ := m[int64(coseY)].([]byte)
+		if crv != curveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("%w: invalid P-256 key", ErrUnsupportedKey)
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("%w: point not on curve", ErrUnsupportedKey)
+		}
+		return &publicKey{algorithm: AlgES256, key: key}, nil
+	case kty == keyTypeOKP && alg == AlgEdDSA:
+		crv, _ := m[int64(coseCurve)].(int64)
+		x, _ := m[int64(coseX)].([]byte)
+		if crv != curveEd || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: invalid Ed25519 key", ErrUnsupportedKey)
+		}
+		return &publicKey{algorithm: AlgEdDSA, key: ed25519.PublicKey(x)}, nil
+	case kty == keyTypeRSA && alg == AlgRS256:
+		n, _ := m[int64(coseRSAN)].([]byte)
+		e, _ := m[int64(coseRSAE)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("%w: invalid RSA key", ErrUnsupportedKey)
+		}
+		exponent := 0
+		for _, b := range e {
+			exponent = exponent<<8 | int(b)
+		}
+		return &publicKey{algorithm: AlgRS256, key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}}, nil
+	}
+	return nil, fmt.Errorf("%w: key type %d, algorithm %d", ErrUnsupportedKey, kty, alg)
+}
+
+// verify checks signature over message
+func (k *publicKey) verify(message, signature []byte) bool {
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, message, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
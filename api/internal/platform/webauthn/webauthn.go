@@ -0,0 +1,439 @@
+// Package webauthn runs the relying party side of WebAuthn registration
+// and authentication ceremonies, for passkeys. Attestation is not
+// requested, so new credentials are trusted on first use rather than
+// checked against the makers of authenticators.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidResponse means a ceremony response failed verification
+	ErrInvalidResponse = errors.New("invalid WebAuthn response")
+	// ErrCounterRegression means an authenticator's signature counter did
+	// not increase, a sign that the credential was cloned
+	ErrCounterRegression = errors.New("authenticator signature counter did not increase")
+)
+
+// User verification requirements
+const (
+	VerificationRequired    = "required"
+	VerificationPreferred   = "preferred"
+	VerificationDiscouraged = "discouraged"
+)
+
+// challengeLength is the size of random challenges
+const challengeLength = 32
+
+// Authenticator data flags
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackedUp       = 0x10
+	flagAttestedData   = 0x40
+	flagExtensionData  = 0x80
+)
+
+// RelyingParty is the site credentials are registered with
+type RelyingParty struct {
+	// ID is the domain credentials are scoped to, such as example.com
+	ID   string
+	Name string
+	// Origins are the origins ceremonies may run on, such as
+	// https://app.example.com
+	Origins []string
+	// UserVerification is required, preferred, or discouraged
+	UserVerification string
+	Timeout          time.Duration
+}
+
+// Bytes is binary data, encoded in JSON as unpadded base64url as the
+// WebAuthn JSON serialization is
+type Bytes []byte
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return fmt.Errorf("invalid base64url: %w", err)
+	}
+	*b = decoded
+	return nil
+}
+
+// NewChallenge returns a random challenge for a ceremony
+func NewChallenge() Bytes {
+	challenge := make([]byte, challengeLength)
+	_, _ = rand.Read(challenge)
+	return challenge
+}
+
+// User is the account a credential is registered for. ID is an opaque
+// handle the authenticator stores and returns when signing in.
+type User struct {
+	ID          Bytes  `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialDescriptor names a registered credential
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         Bytes    `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// NewDescriptor describes the public key credential with id
+func NewDescriptor(id []byte, transports []string) CredentialDescriptor {
+	return CredentialDescriptor{Type: "public-key", ID: id, Transports: transports}
+}
+
+type rpEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	RequireResident  bool   `json:"requireResidentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions are passed to navigator.credentials.create as publicKey
+type CreationOptions struct {
+	RP                     rpEntity               `json:"rp"`
+	User                   User                   `json:"user"`
+	Challenge              Bytes                  `json:"challenge"`
+	PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are passed to navigator.credentials.get as publicKey
+type RequestOptions struct {
+	Challenge        Bytes                  `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// CreationOptions asks for a discoverable credential for user, excluding
+// the credentials the user already has
+func (rp *RelyingParty) CreationOptions(user User, challenge Bytes, exclude []CredentialDescriptor) CreationOptions {
+	params := make([]credentialParameter, len(Algorithms))
+	for i, alg := range Algorithms {
+		params[i] = credentialParameter{Type: "public-key", Alg: alg}
+	}
+	return CreationOptions{
+		RP:                 rpEntity{ID: rp.ID, Name: rp.Name},
+		User:               user,
+		Challenge:          challenge,
+		PubKeyCredParams:   params,
+		Timeout:            rp.Timeout.Milliseconds(),
+		ExcludeCredentials: nonNil(exclude),
+		AuthenticatorSelection: authenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: rp.UserVerification,
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions asks for an assertion from one of allow, or from any
+// discoverable credential when allow is empty
+func (rp *RelyingParty) RequestOptions(challenge Bytes, allow []CredentialDescriptor) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.Timeout.Milliseconds(),
+		RPID:             rp.ID,
+		AllowCredentials: nonNil(allow),
+		UserVerification: rp.UserVerification,
+	}
+}
+
+// RegistrationResponse is the credential navigator.credentials.create
+// returns, as its toJSON method serializes it
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	RawID    Bytes  `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    Bytes    `json:"clientDataJSON"`
+		AttestationObject Bytes    `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the credential navigator.credentials.get returns,
+// as its toJSON method serializes it
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    Bytes  `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    Bytes `json:"clientDataJSON"`
+		AuthenticatorData Bytes `json:"authenticatorData"`
+		Signature         Bytes `json:"signature"`
+		UserHandle        Bytes `json:"userHandle"`
+	} `json:"response"`
+}
+
+// Credential is a verified new credential
+type Credential struct {
+	ID Bytes
+	// PublicKey is the COSE key assertions are verified with
+	PublicKey []byte
+	Algorithm int
+	SignCount uint32
+	// AAGUID identifies the authenticator model, or is zero
+	AAGUID         []byte
+	Transports     []string
+	BackupEligible bool
+	BackedUp       bool
+	UserVerified   bool
+}
+
+// Assertion is a verified sign-in with a credential
+type Assertion struct {
+	CredentialID Bytes
+	// UserHandle is the user ID the credential was registered with; only
+	// discoverable credentials return it
+	UserHandle   Bytes
+	SignCount    uint32
+	BackedUp     bool
+	UserVerified bool
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// Challenge returns the challenge a ceremony response answers, to find the
+// ceremony it belongs to before verifying it
+func Challenge(clientDataJSON []byte) (Bytes, error) {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return nil, fmt.Errorf("%w: client data: %v", ErrInvalidResponse, err)
+	}
+	challenge, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(data.Challenge, "="))
+	if err != nil || len(challenge) == 0 {
+		return nil, fmt.Errorf("%w: client data challenge", ErrInvalidResponse)
+	}
+	return challenge, nil
+}
+
+// VerifyRegistration checks a registration response to challenge and
+// returns the new credential
+func (rp *RelyingParty) VerifyRegistration(resp *RegistrationResponse, challenge []byte) (*Credential, error) {
+	if resp.Type != "public-key" {
+		return nil, fmt.Errorf("%w: credential type %q", ErrInvalidResponse, resp.Type)
+	}
+	if err := rp.checkClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := decodeCBOR(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: attestation object: %v", ErrInvalidResponse, err)
+	}
+	attestation, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: attestation object is not a map", ErrInvalidResponse)
+	}
+	authData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: attestation object has no authData", ErrInvalidResponse)
+	}
+
+	data, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if data.flags&flagAttestedData == 0 {
+		return nil, fmt.Errorf("%w: no attested credential data", ErrInvalidResponse)
+	}
+	if !bytes.Equal(data.credentialID, resp.RawID) {
+		return nil, fmt.Errorf("%w: credential ID does not match", ErrInvalidResponse)
+	}
+	key, err := parsePublicKey(data.publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	return &Credential{
+		ID:             data.credentialID,
+		PublicKey:      data.publicKey,
+		Algorithm:      key.algorithm,
+		SignCount:      data.signCount,
+		AAGUID:         data.aaguid,
+		Transports:     resp.Response.Transports,
+		BackupEligible: data.flags&flagBackupEligible != 0,
+		BackedUp:       data.flags&flagBackedUp != 0,
+		UserVerified:   data.flags&flagUserVerified != 0,
+	}, nil
+}
+
+// VerifyAssertion checks an authentication response to challenge against
+// the stored public key and signature counter of its credential
+func (rp *RelyingParty) VerifyAssertion(resp *AssertionResponse, challenge, publicKey []byte, signCount uint32) (*Assertion, error) {
+	if resp.Type != "public-key" {
+		return nil, fmt.Errorf("%w: credential type %q", ErrInvalidResponse, resp.Type)
+	}
+	if err := rp.checkClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return nil, err
+	}
+	data, err := rp.parseAuthenticatorData(resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	signed := append(slices.Clip(resp.Response.AuthenticatorData), clientDataHash[:]...)
+	if !key.verify(signed, resp.Response.Signature) {
+		return nil, fmt.Errorf("%w: signature does not verify", ErrInvalidResponse)
+	}
+
+	// Authenticators without a counter always report zero
+	if (data.signCount != 0 || signCount != 0) && data.signCount <= signCount {
+		return nil, ErrCounterRegression
+	}
+
+	return &Assertion{
+		CredentialID: resp.RawID,
+		UserHandle:   resp.Response.UserHandle,
+		SignCount:    data.signCount,
+		BackedUp:     data.flags&flagBackedUp != 0,
+		UserVerified: data.flags&flagUserVerified != 0,
+	}, nil
+}
+
+func (rp *RelyingParty) checkClientData(raw []byte, ceremony string, challenge []byte) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("%w: client data: %v", ErrInvalidResponse, err)
+	}
+	if data.Type != ceremony {
+		return fmt.Errorf("%w: client data type %q", ErrInvalidResponse, data.Type)
+	}
+	got, err := Challenge(raw)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return fmt.Errorf("%w: challenge does not match", ErrInvalidResponse)
+	}
+	if !slices.Contains(rp.Origins, data.Origin) {
+		return fmt.Errorf("%w: origin %q is not allowed", ErrInvalidResponse, data.Origin)
+	}
+	if data.CrossOrigin {
+		return fmt.Errorf("%w: cross-origin ceremony", ErrInvalidResponse)
+	}
+	return nil
+}
+
+type authenticatorData struct {
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+// parseAuthenticatorData parses authenticator data and checks it was made
+// for this relying party with the user present, and verified when required
+func (rp *RelyingParty) parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data too short", ErrInvalidResponse)
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(raw[:32], rpIDHash[:]) {
+		return nil, fmt.Errorf("%w: relying party ID does not match", ErrInvalidResponse)
+	}
+	data := &authenticatorData{
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	if data.flags&flagUserPresent == 0 {
+		return nil, fmt.Errorf("%w: user not present", ErrInvalidResponse)
+	}
+	if rp.UserVerification == VerificationRequired && data.flags&flagUserVerified == 0 {
+		return nil, fmt.Errorf("%w: user not verified", ErrInvalidResponse)
+	}
+	if data.flags&flagBackedUp != 0 && data.flags&flagBackupEligible == 0 {
+		return nil, fmt.Errorf("%w: backed up but not backup eligible", ErrInvalidResponse)
+	}
+
+	rest := raw[37:]
+	if data.flags&flagAttestedData != 0 {
+		if len(rest) < 18 {
+			return nil, fmt.Errorf("%w: attested credential data too short", ErrInvalidResponse)
+		}
+		data.aaguid = rest[:16]
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength == 0 || idLength > 1023 || len(rest) < idLength {
+			return nil, fmt.Errorf("%w: invalid credential ID length", ErrInvalidResponse)
+		}
+		data.credentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: credential public key: %v", ErrInvalidResponse, err)
+		}
+		data.publicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+	if data.flags&flagExtensionData != 0 {
+		var err error
+		if _, rest, err = decodeCBOR(rest); err != nil {
+			return nil, fmt.Errorf("%w: extensions: %v", ErrInvalidResponse, err)
+		}
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%w: trailing authenticator data", ErrInvalidResponse)
+	}
+	return data, nil
+}
+
+func nonNil(descriptors []CredentialDescriptor) []CredentialDescriptor {
+	if descriptors == nil {
+		return []CredentialDescriptor{}
+	}
+	return descriptors
+}
@@ -0,0 +1,109 @@
+package webpush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"starterkit/internal/config"
+
+	webpushgo "github.com/SherClockHolmes/webpush-go"
+)
+
+var (
+	// ErrDisabled is returned when no VAPID keys are configured
+	ErrDisabled = errors.New("web push is not configured")
+	// ErrSubscriptionGone is returned when the push service reports that a
+	// subscription has expired or been revoked; it should be deleted
+	ErrSubscriptionGone = errors.New("push subscription gone")
+)
+
+// Subscription is a browser PushSubscription as returned by pushManager.subscribe
+type Subscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     Keys   `json:"keys"`
+}
+
+// Keys are the client public key and auth secret of a subscription
+type Keys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// Sender delivers encrypted Web Push messages signed with a VAPID key pair
+type Sender struct {
+	publicKey  string
+	privateKey string
+	subject    string
+	ttl        time.Duration
+	client     *http.Client
+}
+
+// New creates a sender from configuration
+func New(cfg config.PushConfig) *Sender {
+	// The library adds the mailto: scheme itself for non-HTTPS subjects
+	subject := strings.TrimPrefix(cfg.VAPIDSubject, "mailto:")
+
+	return &Sender{
+		publicKey:  cfg.VAPIDPublicKey,
+		privateKey: cfg.VAPIDPrivateKey,
+		subject:    subject,
+		ttl:        cfg.TTL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled reports whether VAPID keys are configured
+func (s *Sender) Enabled() bool {
+	return s.publicKey != "" && s.privateKey != ""
+}
+
+// PublicKey returns the VAPID application server key clients subscribe with
+func (s *Sender) PublicKey() string {
+	return s.publicKey
+}
+
+// Send encrypts and delivers payload to a subscription
+func (s *Sender) Send(ctx context.Context, sub Subscription, payload []byte) error {
+	if !s.Enabled() {
+		return ErrDisabled
+	}
+
+	resp, err := webpushgo.SendNotificationWithContext(ctx, payload, &webpushgo.Subscription{
+		Endpoint: sub.Endpoint,
+		Keys: webpushgo.Keys{
+			P256dh: sub.Keys.P256dh,
+			Auth:   sub.Keys.Auth,
+		},
+	}, &webpushgo.Options{
+		HTTPClient:      s.client,
+		Subscriber:      s.subject,
+		VAPIDPublicKey:  s.publicKey,
+		VAPIDPrivateKey: s.privateKey,
+		TTL:             int(s.ttl.Seconds()),
+		Urgency:         webpushgo.UrgencyNormal,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send push notification: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("push service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GenerateKeys creates a new VAPID key pair for configuration
+func GenerateKeys() (publicKey, privateKey string, err error) {
+	privateKey, publicKey, err = webpushgo.GenerateVAPIDKeys()
+	return publicKey, privateKey, err
+}
@@ -0,0 +1,161 @@
+// Package wiring assembles the application's components. Each component
+// type has a provider that builds it from the components it depends on,
+// which it asks the container for, so modules declare their dependencies
+// where they are built instead of in one constructor. Components are built
+// once, on first use, in dependency order, and stopped in reverse.
+package wiring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Container holds the providers and the components built so far. It is
+// meant for startup and is not safe for concurrent use.
+type Container struct {
+	providers map[reflect.Type]func(c *Container) (any, error)
+	built     map[reflect.Type]any
+	// building is the chain of components under construction, to report
+	// dependency cycles
+	building []reflect.Type
+	stops    []stop
+}
+
+type stop struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func New() *Container {
+	return &Container{
+		providers: make(map[reflect.Type]func(c *Container) (any, error)),
+		built:     make(map[reflect.Type]any),
+	}
+}
+
+// Provide registers how to build the component of type T. It panics when
+// T already has a provider.
+func Provide[T any](c *Container, build func(c *Container) (T, error)) {
+	t := reflect.TypeFor[T]()
+	if _, ok := c.providers[t]; ok {
+		panic("wiring: " + t.String() + " is provided twice")
+	}
+	c.providers[t] = func(c *Container) (any, error) {
+		return build(c)
+	}
+}
+
+// Supply registers a component that is already built
+func Supply[T any](c *Container, v T) {
+	Provide(c, func(*Container) (T, error) {
+		return v, nil
+	})
+}
+
+// Get returns the component of type T, building it and its dependencies
+// first if needed
+func Get[T any](c *Container) (T, error) {
+	v, err := c.get(reflect.TypeFor[T]())
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	// A nil interface component is stored as a nil any
+	t, _ := v.(T)
+	return t, nil
+}
+
+// Use is Get for providers, which mostly depend on several components.
+// It may only be called from a provider or a function run by Invoke: a
+// failure stops it and is returned by the Get building the provider's
+// component, or by Invoke.
+func Use[T any](c *Container) T {
+	v, err := Get[T](c)
+	if err != nil {
+		panic(failure{err})
+	}
+	return v
+}
+
+// Invoke calls fn, which may Use components as providers do, and returns
+// its error or the failure of Use that stopped it
+func Invoke(c *Container, fn func(c *Container) error) error {
+	_, err := c.build(func(c *Container) (any, error) {
+		return nil, fn(c)
+	})
+	return err
+}
+
+// failure carries the error of Use out of the provider calling it
+type failure struct {
+	err error
+}
+
+func (c *Container) get(t reflect.Type) (any, error) {
+	if v, ok := c.built[t]; ok {
+		return v, nil
+	}
+	build, ok := c.providers[t]
+	if !ok {
+		return nil, fmt.Errorf("no provider for %s", t)
+	}
+	for i, b := range c.building {
+		if b == t {
+			return nil, fmt.Errorf("dependency cycle: %s", chain(append(slices.Clone(c.building[i:]), t)))
+		}
+	}
+
+	c.building = append(c.building, t)
+	v, err := c.build(build)
+	c.building = c.building[:len(c.building)-1]
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s: %w", t, err)
+	}
+	c.built[t] = v
+	return v, nil
+}
+
+func (c *Container) build(build func(c *Container) (any, error)) (v any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			f, ok := r.(failure)
+			if !ok {
+				panic(r)
+			}
+			err = f.err
+		}
+	}()
+	return build(c)
+}
+
+func chain(types []reflect.Type) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.String()
+	}
+	return strings.Join(names, " -> ")
+}
+
+// OnStop registers fn to run when the container is closed. Providers
+// register the cleanup of the component they build, so components stop in
+// reverse order of construction, before the components they depend on.
+func (c *Container) OnStop(name string, fn func(ctx context.Context) error) {
+	c.stops = append(c.stops, stop{name: name, fn: fn})
+}
+
+// Close stops the built components, last built first, and returns their
+// failures
+func (c *Container) Close(ctx context.Context) error {
+	var errs []error
+	for i := len(c.stops) - 1; i >= 0; i-- {
+		if err := c.stops[i].fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.stops[i].name, err))
+		}
+	}
+	c.stops = nil
+	return errors.Join(errs...)
+}
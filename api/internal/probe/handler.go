@@ -0,0 +1,41 @@
+package probe
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleLast returns the most recent self-check probe result
+func (h *Handler) HandleLast() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result := h.service.Last()
+		if result == nil {
+			apierror.Write(w, r, apierror.NotFound("probe_not_run", "probe has not run yet"))
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, result)
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
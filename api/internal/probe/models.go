@@ -0,0 +1,25 @@
+package probe
+
+import "time"
+
+// Check outcomes
+const (
+	ResultPassed  = "passed"
+	ResultFailed  = "failed"
+	ResultSkipped = "skipped"
+)
+
+// Result is one run of the self-check probe
+type Result struct {
+	StartedAt time.Time `json:"started_at"`
+	Passed    bool      `json:"passed"`
+	Checks    []Check   `json:"checks"`
+}
+
+// Check is one step of a probe run
+type Check struct {
+	Name       string `json:"name"`
+	Result     string `json:"result"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
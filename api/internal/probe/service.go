@@ -0,0 +1,191 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/metrics"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// probeName is the canary user's display name
+const probeName = "Self-check Probe"
+
+type Querier interface {
+	EnsureProbeUser(ctx context.Context, arg db.EnsureProbeUserParams) (pgtype.UUID, error)
+}
+
+// Service exercises key API endpoints end to end against the running
+// server with a dedicated canary user, catching regressions that
+// dependency pings miss
+type Service struct {
+	queries Querier
+	client  *http.Client
+	baseURL string
+	email   string
+	token   string
+	logger  *slog.Logger
+	checks  metric.Int64Counter
+	up      metric.Int64Gauge
+
+	mu   sync.RWMutex
+	last *Result
+}
+
+// NewService creates the probe; token is sent as a bearer token when set
+func NewService(queries Querier, client *http.Client, baseURL, email, token string, logger *slog.Logger) *Service {
+	return &Service{
+		queries: queries,
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		email:   email,
+		token:   token,
+		logger:  logger,
+		checks:  metrics.Int64Counter(metrics.ProbeChecks),
+		up:      metrics.Int64Gauge(metrics.ProbeUp),
+	}
+}
+
+// step is a probe check; later steps are skipped once one fails
+type step struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Run performs one probe pass and returns an error if any check failed
+func (s *Service) Run(ctx context.Context) error {
+	id, err := s.queries.EnsureProbeUser(ctx, db.EnsureProbeUserParams{Email: s.email, Name: probeName})
+	if err != nil {
+		return fmt.Errorf("failed to ensure probe user: %w", err)
+	}
+	userPath := "/api/v1/users/" + uuid.UUID(id.Bytes).String()
+	bio := "probe " + time.Now().UTC().Format(time.RFC3339)
+
+	steps := []step{
+		{"health", func(ctx context.Context) error {
+			return s.call(ctx, http.MethodGet, "/health", nil, nil)
+		}},
+		{"get-user", func(ctx context.Context) error {
+			return s.call(ctx, http.MethodGet, userPath, nil, nil)
+		}},
+		{"update-profile", func(ctx context.Context) error {
+			return s.call(ctx, http.MethodPatch, userPath+"/profile", map[string]string{"bio": bio}, nil)
+		}},
+		{"read-back", func(ctx context.Context) error {
+			var user struct {
+				Bio string `json:"bio"`
+			}
+			if err := s.call(ctx, http.MethodGet, userPath, nil, &user); err != nil {
+				return err
+			}
+			if user.Bio != bio {
+				return fmt.Errorf("read back bio %q, want %q", user.Bio, bio)
+			}
+			return nil
+		}},
+	}
+
+	result := &Result{StartedAt: time.Now(), Passed: true}
+	for _, st := range steps {
+		check := Check{Name: st.name, Result: ResultSkipped}
+		if result.Passed {
+			start := time.Now()
+			err := st.run(ctx)
+			check.DurationMS = time.Since(start).Milliseconds()
+			check.Result = ResultPassed
+			if err != nil {
+				check.Result, check.Error = ResultFailed, err.Error()
+				result.Passed = false
+			}
+		}
+		result.Checks = append(result.Checks, check)
+		s.observe(ctx, check)
+	}
+
+	s.mu.Lock()
+	s.last = result
+	s.mu.Unlock()
+
+	if !result.Passed {
+		return errors.New("self-check probe failed")
+	}
+	return nil
+}
+
+// Last returns the most recent probe result, or nil before the first run
+func (s *Service) Last() *Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.last
+}
+
+func (s *Service) observe(ctx context.Context, check Check) {
+	s.checks.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("check", check.Name),
+		attribute.String("result", check.Result),
+	))
+	var up int64
+	if check.Result == ResultPassed {
+		up = 1
+	}
+	s.up.Record(ctx, up, metric.WithAttributes(attribute.String("check", check.Name)))
+
+	if check.Result == ResultFailed {
+		s.logger.Error("self-check probe failed", "check", check.Name, "error", check.Error)
+	}
+}
+
+// call sends a request as the canary user and decodes a 2xx JSON response
+// into out when given
+func (s *Service) call(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-User-Email", s.email)
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: unexpected status %d", method, path, resp.StatusCode)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("%s %s: failed to decode response: %w", method, path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package projections
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrProjectionNotFound = errors.New("projection not found")
+
+// Status reports how far a projection has caught up with the change log
+type Status struct {
+	Name      string    `json:"name"`
+	Position  int64     `json:"position"`
+	Latest    int64     `json:"latest"`
+	Lag       int64     `json:"lag"`
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+}
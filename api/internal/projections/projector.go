@@ -0,0 +1,227 @@
+package projections
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Projection builds a denormalized read table from the user change log
+type Projection interface {
+	// Name identifies the projection's checkpoint
+	Name() string
+	// Apply updates the read table for one change; it must be idempotent
+	Apply(ctx context.Context, q *db.Queries, change db.UserChange) error
+	// Rebuild repopulates the read table from the source tables
+	Rebuild(ctx context.Context, q *db.Queries) error
+}
+
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Projector feeds captured user changes to projections. Each batch is
+// applied in one transaction together with the projection's checkpoint, so
+// a change is never applied twice or skipped, and the checkpoint row lock
+// keeps replicas and rebuilds from running the same projection at once.
+type Projector struct {
+	db          TxBeginner
+	queries     *db.Queries
+	projections []Projection
+	batchSize   int
+	logger      *slog.Logger
+	lag         metric.Int64Gauge
+}
+
+func NewProjector(txdb TxBeginner, queries *db.Queries, batchSize int, logger *slog.Logger, projections ...Projection) *Projector {
+	return &Projector{
+		db:          txdb,
+		queries:     queries,
+		projections: projections,
+		batchSize:   batchSize,
+		logger:      logger,
+		lag:         metrics.Int64Gauge(metrics.ProjectionLag),
+	}
+}
+
+// Run catches every projection up with the change log. A projection that
+// has never run is rebuilt first, since the change log may not reach back
+// to rows created before it.
+func (p *Projector) Run(ctx context.Context) error {
+	statuses, err := p.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for i, proj := range p.projections {
+		if statuses[i].UpdatedAt.IsZero() {
+			if err := p.Rebuild(ctx, proj.Name()); err != nil {
+				return err
+			}
+		}
+		if err := p.catchUp(ctx, proj); err != nil {
+			return fmt.Errorf("failed to run projection %s: %w", proj.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (p *Projector) catchUp(ctx context.Context, proj Projection) error {
+	for {
+		applied, err := p.applyBatch(ctx, proj)
+		if err != nil {
+			return err
+		}
+		if applied < p.batchSize {
+			break
+		}
+	}
+
+	// Lag is only reported once caught up, not for every batch
+	statuses, err := p.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if status.Name == proj.Name() {
+			p.lag.Record(ctx, status.Lag, metric.WithAttributes(attribute.String("projection", status.Name)))
+		}
+	}
+	return nil
+}
+
+func (p *Projector) applyBatch(ctx context.Context, proj Projection) (int, error) {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := p.queries.WithTx(tx)
+
+	checkpoint, err := qtx.ClaimProjectionCheckpoint(ctx, proj.Name())
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim checkpoint: %w", err)
+	}
+	changes, err := qtx.ListUserChangesSince(ctx, db.ListUserChangesSinceParams{
+		SinceTxid: checkpoint.Txid,
+		SinceID:   checkpoint.Position,
+		RowLimit:  int32(p.batchSize),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read user changes: %w", err)
+	}
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	for _, change := range changes {
+		if err := proj.Apply(ctx, qtx, change); err != nil {
+			return 0, fmt.Errorf("failed to apply change %d: %w", change.ID, err)
+		}
+	}
+	last := changes[len(changes)-1]
+	if err := qtx.SaveProjectionCheckpoint(ctx, db.SaveProjectionCheckpointParams{
+		Name:     proj.Name(),
+		Position: last.ID,
+		Txid:     last.Txid,
+	}); err != nil {
+		return 0, fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit projection batch: %w", err)
+	}
+	return len(changes), nil
+}
+
+// Rebuild repopulates the named projection from the source tables and moves
+// its checkpoint to the end of the change log
+func (p *Projector) Rebuild(ctx context.Context, name string) error {
+	proj := p.find(name)
+	if proj == nil {
+		return ErrProjectionNotFound
+	}
+
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := p.queries.WithTx(tx)
+
+	if _, err := qtx.ClaimProjectionCheckpoint(ctx, name); err != nil {
+		return fmt.Errorf("failed to claim checkpoint: %w", err)
+	}
+	// Read the position first: changes recorded during the rebuild are
+	// replayed afterwards, which Apply tolerates
+	end, err := qtx.GetUserChangeLogEnd(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read change log position: %w", err)
+	}
+	if err := proj.Rebuild(ctx, qtx); err != nil {
+		return fmt.Errorf("failed to rebuild projection %s: %w", name, err)
+	}
+	if err := qtx.SaveProjectionCheckpoint(ctx, db.SaveProjectionCheckpointParams{
+		Name: name,
+		Txid: end,
+	}); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit rebuild: %w", err)
+	}
+	p.logger.Info("rebuilt projection", "projection", name, "txid", end)
+	return nil
+}
+
+// Names lists the registered projections
+func (p *Projector) Names() []string {
+	names := make([]string, len(p.projections))
+	for i, proj := range p.projections {
+		names[i] = proj.Name()
+	}
+	return names
+}
+
+// Status reports each registered projection's position and lag
+func (p *Projector) Status(ctx context.Context) ([]Status, error) {
+	latest, err := p.queries.GetLatestUserChangeID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change log position: %w", err)
+	}
+	checkpoints, err := p.queries.ListProjectionCheckpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	byName := make(map[string]db.ListProjectionCheckpointsRow, len(checkpoints))
+	for _, c := range checkpoints {
+		byName[c.Name] = c
+	}
+
+	statuses := make([]Status, len(p.projections))
+	for i, proj := range p.projections {
+		c := byName[proj.Name()]
+		statuses[i] = Status{
+			Name:      proj.Name(),
+			Position:  c.Position,
+			Latest:    latest,
+			Lag:       c.Lag,
+			UpdatedAt: c.UpdatedAt.Time,
+		}
+	}
+	return statuses, nil
+}
+
+func (p *Projector) find(name string) Projection {
+	for _, proj := range p.projections {
+		if proj.Name() == name {
+			return proj
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"starterkit/internal/db"
+)
+
+// UserEmbeddings tracks which profiles need embedding for semantic search.
+// It only records a hash of each profile's text, clearing the embedding
+// when the text changes; the embedding job calls the model outside the
+// projection transaction.
+type UserEmbeddings struct{}
+
+func (UserEmbeddings) Name() string {
+	return "user_embeddings"
+}
+
+func (UserEmbeddings) Apply(ctx context.Context, q *db.Queries, change db.UserChange) error {
+	var row struct {
+		DeletedAt *string `json:"deleted_at"`
+	}
+	if change.NewData != nil {
+		if err := json.Unmarshal(change.NewData, &row); err != nil {
+			return fmt.Errorf("failed to decode user change %d: %w", change.ID, err)
+		}
+	}
+	if change.Operation == "DELETE" || row.DeletedAt != nil {
+		return q.DeleteUserEmbedding(ctx, change.UserID)
+	}
+	return q.UpsertUserEmbeddingFromChange(ctx, change.NewData)
+}
+
+// Rebuild keeps the embeddings of profiles whose text is unchanged, so it
+// does not re-embed every user
+func (UserEmbeddings) Rebuild(ctx context.Context, q *db.Queries) error {
+	if _, err := q.DeleteStaleUserEmbeddings(ctx); err != nil {
+		return err
+	}
+	_, err := q.RebuildUserEmbeddings(ctx)
+	return err
+}
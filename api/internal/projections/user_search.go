@@ -0,0 +1,39 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"starterkit/internal/db"
+)
+
+// UserSearch maintains user_search, the full-text searchable user listing
+type UserSearch struct{}
+
+func (UserSearch) Name() string {
+	return "user_search"
+}
+
+func (UserSearch) Apply(ctx context.Context, q *db.Queries, change db.UserChange) error {
+	var row struct {
+		DeletedAt *string `json:"deleted_at"`
+	}
+	if change.NewData != nil {
+		if err := json.Unmarshal(change.NewData, &row); err != nil {
+			return fmt.Errorf("failed to decode user change %d: %w", change.ID, err)
+		}
+	}
+	if change.Operation == "DELETE" || row.DeletedAt != nil {
+		return q.DeleteUserSearch(ctx, change.UserID)
+	}
+	return q.UpsertUserSearchFromChange(ctx, change.NewData)
+}
+
+func (UserSearch) Rebuild(ctx context.Context, q *db.Queries) error {
+	if err := q.ClearUserSearch(ctx); err != nil {
+		return err
+	}
+	_, err := q.RebuildUserSearch(ctx)
+	return err
+}
@@ -0,0 +1,40 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"starterkit/internal/db"
+)
+
+// UserSummaries maintains user_summaries, the denormalized user listing that
+// GET /api/v1/users reads
+type UserSummaries struct{}
+
+func (UserSummaries) Name() string {
+	return "user_summaries"
+}
+
+func (UserSummaries) Apply(ctx context.Context, q *db.Queries, change db.UserChange) error {
+	var row struct {
+		DeletedAt *string `json:"deleted_at"`
+	}
+	if change.NewData != nil {
+		if err := json.Unmarshal(change.NewData, &row); err != nil {
+			return fmt.Errorf("failed to decode user change %d: %w", change.ID, err)
+		}
+	}
+	if change.Operation == "DELETE" || row.DeletedAt != nil {
+		return q.DeleteUserSummary(ctx, change.UserID)
+	}
+	return q.UpsertUserSummaryFromChange(ctx, change.NewData)
+}
+
+func (UserSummaries) Rebuild(ctx context.Context, q *db.Queries) error {
+	if err := q.ClearUserSummaries(ctx); err != nil {
+		return err
+	}
+	_, err := q.RebuildUserSummaries(ctx)
+	return err
+}
@@ -0,0 +1,93 @@
+package readonly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/request"
+)
+
+type ServiceInterface interface {
+	Status() Mode
+	Enable(ctx context.Context, req EnableRequest, actor string) (*Mode, error)
+	Disable(ctx context.Context, actor string) error
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleStatus returns the mode in effect on the replica serving the
+// request
+func (h *Handler) HandleStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.respondWithJSON(w, http.StatusOK, h.service.Status())
+	}
+}
+
+// HandleEnable turns read-only mode on with the reason in the body
+func (h *Handler) HandleEnable() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req EnableRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		mode, err := h.service.Enable(r.Context(), req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithModeError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, mode)
+	}
+}
+
+// HandleDisable turns read-only mode off
+func (h *Handler) HandleDisable() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h.service.Disable(r.Context(), actorFromRequest(r)); err != nil {
+			h.respondWithModeError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) respondWithModeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrNotEnabled):
+		apierror.Write(w, r, apierror.NotFound("read_only_off", err.Error()))
+	case errors.Is(err, ErrConfigured):
+		apierror.Write(w, r, apierror.Conflict("mode_configured", err.Error()))
+	default:
+		h.logger.Error("read-only mode request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
@@ -0,0 +1,52 @@
+package readonly
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"starterkit/internal/platform/request"
+)
+
+const maxReasonLength = 500
+
+// AdminPath is where the mode is managed; its routes stay writable, so the
+// mode can always be turned off
+const AdminPath = "/admin/read-only"
+
+var (
+	ErrNotEnabled = errors.New("read-only mode is not on")
+	ErrConfigured = errors.New("read-only mode is set by READ_ONLY and is turned off by changing that setting")
+)
+
+// Sources of read-only mode
+const (
+	SourceConfig = "config"
+	SourceAdmin  = "admin"
+)
+
+// Mode is whether the application is read-only. While it is, mutating
+// requests answer 503 with Reason and writes to the database are refused.
+type Mode struct {
+	Enabled   bool      `json:"enabled"`
+	Reason    string    `json:"reason,omitempty"`
+	Source    string    `json:"source,omitempty" enum:"config,admin"`
+	EnabledBy string    `json:"enabled_by,omitempty"`
+	EnabledAt time.Time `json:"enabled_at,omitzero"`
+}
+
+// EnableRequest turns read-only mode on, or changes its reason
+type EnableRequest struct {
+	Reason string `json:"reason" example:"Database failover in progress"`
+}
+
+// Validate trims the reason
+func (r *EnableRequest) Validate() error {
+	r.Reason = strings.TrimSpace(r.Reason)
+
+	var v request.Validation
+	v.Check(r.Reason != "" && utf8.RuneCountInString(r.Reason) <= maxReasonLength, "reason", fmt.Sprintf("must be 1-%d characters", maxReasonLength))
+	return v.Err()
+}
@@ -0,0 +1,163 @@
+package readonly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/database"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Querier interface {
+	GetReadOnlyMode(ctx context.Context) (db.ReadOnlyMode, error)
+	SetReadOnlyMode(ctx context.Context, arg db.SetReadOnlyModeParams) (db.ReadOnlyMode, error)
+	ClearReadOnlyMode(ctx context.Context) (int64, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service turns read-only mode on and off. READ_ONLY turns it on from
+// startup, for failovers where the database cannot store it; otherwise
+// operators set it at runtime, stored in the database and read by every
+// replica on Reload. The mode set through this replica applies at once,
+// and through another replica after the next reload.
+type Service struct {
+	queries Querier
+	mode    *database.ReadOnlyMode
+	auditor Auditor
+	logger  *slog.Logger
+	// static is the mode READ_ONLY sets, if on
+	static *Mode
+
+	mu      sync.RWMutex
+	current Mode
+}
+
+// NewService manages mode, which the write guard and transactions check
+func NewService(queries Querier, mode *database.ReadOnlyMode, auditor Auditor, cfg config.ReadOnlyConfig, logger *slog.Logger) *Service {
+	s := &Service{
+		queries: queries,
+		mode:    mode,
+		auditor: auditor,
+		logger:  logger,
+	}
+	if cfg.Enabled {
+		s.static = &Mode{Enabled: true, Reason: cfg.Reason, Source: SourceConfig, EnabledAt: time.Now()}
+		s.apply(*s.static)
+		logger.Warn("read-only mode is on", "reason", cfg.Reason, "source", SourceConfig)
+	}
+	return s
+}
+
+// Status returns the mode in effect on this replica
+func (s *Service) Status() Mode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Reload reads the mode set at runtime from the database
+func (s *Service) Reload(ctx context.Context) error {
+	if s.static != nil {
+		return nil
+	}
+	row, err := s.queries.GetReadOnlyMode(ctx)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.apply(Mode{})
+			return nil
+		}
+		return fmt.Errorf("failed to get read-only mode: %w", err)
+	}
+	s.apply(toMode(row))
+	return nil
+}
+
+// Enable turns read-only mode on, or changes the reason requests are
+// refused with
+func (s *Service) Enable(ctx context.Context, req EnableRequest, actor string) (*Mode, error) {
+	if s.static != nil {
+		return nil, ErrConfigured
+	}
+
+	// Writing the mode itself is exempt from it
+	ctx = database.AllowWrites(ctx)
+	row, err := s.queries.SetReadOnlyMode(ctx, db.SetReadOnlyModeParams{
+		Reason:    req.Reason,
+		EnabledBy: actor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save read-only mode: %w", err)
+	}
+
+	mode := toMode(row)
+	s.apply(mode)
+	s.logger.Warn("read-only mode turned on", "reason", mode.Reason, "actor", actor)
+	s.record(ctx, actor, "read_only.enable", map[string]any{"reason": mode.Reason})
+	return &mode, nil
+}
+
+// Disable turns read-only mode off. The mode set by READ_ONLY stays on
+// until the setting changes.
+func (s *Service) Disable(ctx context.Context, actor string) error {
+	if s.static != nil {
+		return ErrConfigured
+	}
+
+	ctx = database.AllowWrites(ctx)
+	deleted, err := s.queries.ClearReadOnlyMode(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to clear read-only mode: %w", err)
+	}
+	s.apply(Mode{})
+	if deleted == 0 {
+		return ErrNotEnabled
+	}
+
+	s.logger.Info("read-only mode turned off", "actor", actor)
+	s.record(ctx, actor, "read_only.disable", nil)
+	return nil
+}
+
+// apply makes mode the one in effect on this replica
+func (s *Service) apply(mode Mode) {
+	s.mu.Lock()
+	s.current = mode
+	s.mu.Unlock()
+	if mode.Enabled {
+		s.mode.Set(mode.Reason, mode.EnabledAt)
+	} else {
+		s.mode.Clear()
+	}
+}
+
+func (s *Service) record(ctx context.Context, actor, action string, metadata map[string]any) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "read_only_mode",
+		Metadata:     metadata,
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+func toMode(row db.ReadOnlyMode) Mode {
+	return Mode{
+		Enabled:   true,
+		Reason:    row.Reason,
+		Source:    SourceAdmin,
+		EnabledBy: row.EnabledBy,
+		EnabledAt: row.EnabledAt.Time,
+	}
+}
@@ -0,0 +1,213 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"starterkit/internal/operations"
+	"starterkit/internal/platform/apierror"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	CreatePolicy(ctx context.Context, req CreatePolicyRequest, actor string) (*Policy, error)
+	ListPolicies(ctx context.Context) ([]*Policy, error)
+	GetPolicy(ctx context.Context, id uuid.UUID) (*Policy, error)
+	ListReports(ctx context.Context, id uuid.UUID, limit int) ([]*Report, error)
+	Execute(ctx context.Context, id uuid.UUID, dryRun bool, actor string) (*Report, error)
+}
+
+// OperationStager stages destructive operations behind an undo window
+type OperationStager interface {
+	Stage(ctx context.Context, kind, owner string, payload any) (*operations.Operation, error)
+}
+
+type Handler struct {
+	service    ServiceInterface
+	operations OperationStager
+	logger     *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, ops OperationStager, logger *slog.Logger) *Handler {
+	return &Handler{
+		service:    service,
+		operations: ops,
+		logger:     logger,
+	}
+}
+
+func (h *Handler) HandleListPolicies() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policies, err := h.service.ListPolicies(r.Context())
+		if err != nil {
+			h.logger.Error("failed to list retention policies", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"policies": policies,
+		})
+	}
+}
+
+func (h *Handler) HandleCreatePolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreatePolicyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		policy, err := h.service.CreatePolicy(r.Context(), req, actorFromRequest(r))
+		if err != nil {
+			if errors.Is(err, ErrInvalidPolicy) {
+				apierror.Write(w, r, apierror.BadRequest("invalid_policy", err.Error()))
+				return
+			}
+			h.logger.Error("failed to create retention policy", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusCreated, policy)
+	}
+}
+
+func (h *Handler) HandleGetPolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policyID, ok := h.parsePolicyID(w, r)
+		if !ok {
+			return
+		}
+
+		policy, err := h.service.GetPolicy(r.Context(), policyID)
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to get retention policy", policyID)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, policy)
+	}
+}
+
+// HandleDeletePolicy stages the policy's deletion and answers with the
+// operation, which can be undone through the operations API until its
+// undo_until
+func (h *Handler) HandleDeletePolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policyID, ok := h.parsePolicyID(w, r)
+		if !ok {
+			return
+		}
+
+		if _, err := h.service.GetPolicy(r.Context(), policyID); err != nil {
+			h.handleServiceError(w, r, err, "failed to get retention policy", policyID)
+			return
+		}
+
+		op, err := h.operations.Stage(r.Context(), OperationDeletePolicy, operations.Owner(r), Deletion{PolicyID: policyID, Actor: actorFromRequest(r)})
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to stage retention policy deletion", policyID)
+			return
+		}
+
+		w.Header().Set("Location", "/api/v1/operations/"+op.ID.String())
+		h.respondWithJSON(w, http.StatusAccepted, op)
+	}
+}
+
+func (h *Handler) HandleRunPolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policyID, ok := h.parsePolicyID(w, r)
+		if !ok {
+			return
+		}
+
+		dryRun := false
+		if dryRunStr := r.URL.Query().Get("dry_run"); dryRunStr != "" {
+			parsed, err := strconv.ParseBool(dryRunStr)
+			if err != nil {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid dry_run parameter"))
+				return
+			}
+			dryRun = parsed
+		}
+
+		report, err := h.service.Execute(r.Context(), policyID, dryRun, actorFromRequest(r))
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to execute retention policy", policyID)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, report)
+	}
+}
+
+func (h *Handler) HandleListReports() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policyID, ok := h.parsePolicyID(w, r)
+		if !ok {
+			return
+		}
+
+		limit := 20 // default
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsedLimit, err := strconv.Atoi(limitStr)
+			if err != nil || parsedLimit < 0 {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid limit parameter"))
+				return
+			}
+			limit = parsedLimit
+		}
+
+		reports, err := h.service.ListReports(r.Context(), policyID, limit)
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to list retention reports", policyID)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"reports": reports,
+		})
+	}
+}
+
+func (h *Handler) parsePolicyID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	policyID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_policy_id", "invalid policy ID format"))
+		return uuid.Nil, false
+	}
+	return policyID, true
+}
+
+func (h *Handler) handleServiceError(w http.ResponseWriter, r *http.Request, err error, msg string, policyID uuid.UUID) {
+	switch {
+	case errors.Is(err, ErrPolicyNotFound):
+		apierror.Write(w, r, apierror.NotFound("retention_policy_not_found", "retention policy not found"))
+	case errors.Is(err, ErrInvalidPolicy):
+		apierror.Write(w, r, apierror.Unprocessable("invalid_policy", err.Error()))
+	default:
+		h.logger.Error(msg, "error", err, "policy_id", policyID)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// actorFromRequest identifies the caller for audit purposes
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
@@ -0,0 +1,51 @@
+package retention
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supported policy targets
+const (
+	TargetAuditLogs = "audit_logs"
+	TargetUsers     = "users"
+)
+
+// Supported policy actions
+const (
+	ActionDelete    = "delete"
+	ActionAnonymize = "anonymize"
+)
+
+type Policy struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	Target        string     `json:"target"`
+	Action        string     `json:"action"`
+	RetentionDays int        `json:"retention_days"`
+	Enabled       bool       `json:"enabled"`
+	LastRunAt     *time.Time `json:"last_run_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Report is the outcome of a single policy execution
+type Report struct {
+	ID           uuid.UUID `json:"id"`
+	PolicyID     uuid.UUID `json:"policy_id"`
+	DryRun       bool      `json:"dry_run"`
+	Cutoff       time.Time `json:"cutoff"`
+	AffectedRows int64     `json:"affected_rows"`
+	Error        string    `json:"error,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+}
+
+type CreatePolicyRequest struct {
+	Name          string `json:"name"`
+	Target        string `json:"target"`
+	Action        string `json:"action"`
+	RetentionDays int    `json:"retention_days"`
+	Enabled       *bool  `json:"enabled"`
+}
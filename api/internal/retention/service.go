@@ -0,0 +1,339 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/logger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrPolicyNotFound = errors.New("retention policy not found")
+	ErrInvalidPolicy  = errors.New("invalid retention policy")
+)
+
+type Querier interface {
+	CreateRetentionPolicy(ctx context.Context, arg db.CreateRetentionPolicyParams) (db.RetentionPolicy, error)
+	GetRetentionPolicy(ctx context.Context, id pgtype.UUID) (db.RetentionPolicy, error)
+	ListRetentionPolicies(ctx context.Context) ([]db.RetentionPolicy, error)
+	ListEnabledRetentionPolicies(ctx context.Context) ([]db.RetentionPolicy, error)
+	TrashRetentionPolicy(ctx context.Context, arg db.TrashRetentionPolicyParams) (int64, error)
+	MarkRetentionPolicyRun(ctx context.Context, id pgtype.UUID) error
+	CreateRetentionRun(ctx context.Context, arg db.CreateRetentionRunParams) (db.RetentionRun, error)
+	ListRetentionRuns(ctx context.Context, arg db.ListRetentionRunsParams) ([]db.RetentionRun, error)
+
+	CountAuditLogsBefore(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	DeleteAuditLogsBefore(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	CountInactiveUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	AnonymizeInactiveUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	CountPurgeableUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	PurgeDeletedUsers(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// operation pairs a dry-run count with the destructive statement it previews
+type operation struct {
+	count func(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	apply func(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+}
+
+type Service struct {
+	queries    Querier
+	auditor    Auditor
+	operations map[string]operation
+}
+
+func NewService(queries Querier, auditor Auditor) *Service {
+	return &Service{
+		queries: queries,
+		auditor: auditor,
+		operations: map[string]operation{
+			operationKey(TargetAuditLogs, ActionDelete): {
+				count: queries.CountAuditLogsBefore,
+				apply: queries.DeleteAuditLogsBefore,
+			},
+			operationKey(TargetUsers, ActionAnonymize): {
+				count: queries.CountInactiveUsers,
+				apply: queries.AnonymizeInactiveUsers,
+			},
+			operationKey(TargetUsers, ActionDelete): {
+				count: queries.CountPurgeableUsers,
+				apply: queries.PurgeDeletedUsers,
+			},
+		},
+	}
+}
+
+func operationKey(target, action string) string {
+	return target + ":" + action
+}
+
+func (s *Service) CreatePolicy(ctx context.Context, req CreatePolicyRequest, actor string) (*Policy, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidPolicy)
+	}
+	if req.RetentionDays <= 0 {
+		return nil, fmt.Errorf("%w: retention_days must be positive", ErrInvalidPolicy)
+	}
+	if _, ok := s.operations[operationKey(req.Target, req.Action)]; !ok {
+		return nil, fmt.Errorf("%w: unsupported target/action %q/%q", ErrInvalidPolicy, req.Target, req.Action)
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	dbPolicy, err := s.queries.CreateRetentionPolicy(ctx, db.CreateRetentionPolicyParams{
+		Name:          req.Name,
+		Target:        req.Target,
+		Action:        req.Action,
+		RetentionDays: int32(req.RetentionDays),
+		Enabled:       enabled,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	policy := toPolicy(dbPolicy)
+	s.record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "retention.policy.created",
+		ResourceType: "retention_policy",
+		ResourceID:   policy.ID.String(),
+		Metadata: map[string]any{
+			"name":           policy.Name,
+			"target":         policy.Target,
+			"action":         policy.Action,
+			"retention_days": policy.RetentionDays,
+		},
+	})
+
+	return policy, nil
+}
+
+func (s *Service) ListPolicies(ctx context.Context) ([]*Policy, error) {
+	dbPolicies, err := s.queries.ListRetentionPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]*Policy, len(dbPolicies))
+	for i, dbPolicy := range dbPolicies {
+		policies[i] = toPolicy(dbPolicy)
+	}
+	return policies, nil
+}
+
+func (s *Service) GetPolicy(ctx context.Context, id uuid.UUID) (*Policy, error) {
+	dbPolicy, err := s.queries.GetRetentionPolicy(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPolicyNotFound
+		}
+		return nil, err
+	}
+	return toPolicy(dbPolicy), nil
+}
+
+// OperationDeletePolicy is the staged operation kind that moves a policy to
+// the trash once its undo window has passed
+const OperationDeletePolicy = "retention.delete_policy"
+
+// Deletion is the payload of a staged policy deletion
+type Deletion struct {
+	PolicyID uuid.UUID `json:"policy_id"`
+	Actor    string    `json:"actor"`
+}
+
+// RunDeleteAction carries out a staged deletion. A policy that is already
+// gone, such as by an earlier deletion, is left at that.
+func (s *Service) RunDeleteAction(ctx context.Context, payload json.RawMessage) (any, error) {
+	var d Deletion
+	if err := json.Unmarshal(payload, &d); err != nil {
+		return nil, fmt.Errorf("invalid retention policy deletion: %w", err)
+	}
+	if err := s.DeletePolicy(ctx, d.PolicyID, d.Actor); err != nil && !errors.Is(err, ErrPolicyNotFound) {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// DeletePolicy moves a policy to the trash, from which it can be restored
+// until it is purged
+func (s *Service) DeletePolicy(ctx context.Context, id uuid.UUID, actor string) error {
+	affected, err := s.queries.TrashRetentionPolicy(ctx, db.TrashRetentionPolicyParams{
+		ID:        pgtype.UUID{Bytes: id, Valid: true},
+		DeletedBy: pgtype.Text{String: actor, Valid: actor != ""},
+	})
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrPolicyNotFound
+	}
+
+	s.record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "retention.policy.deleted",
+		ResourceType: "retention_policy",
+		ResourceID:   id.String(),
+	})
+	return nil
+}
+
+func (s *Service) ListReports(ctx context.Context, id uuid.UUID, limit int) ([]*Report, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	dbRuns, err := s.queries.ListRetentionRuns(ctx, db.ListRetentionRunsParams{
+		PolicyID: pgtype.UUID{Bytes: id, Valid: true},
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*Report, len(dbRuns))
+	for i, dbRun := range dbRuns {
+		reports[i] = toReport(dbRun)
+	}
+	return reports, nil
+}
+
+// Execute applies a single policy. In dry-run mode only the number of
+// affected rows is computed and nothing is modified.
+func (s *Service) Execute(ctx context.Context, id uuid.UUID, dryRun bool, actor string) (*Report, error) {
+	policy, err := s.GetPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.execute(ctx, policy, dryRun, actor)
+}
+
+// RunAll executes every enabled policy and is intended to be called by the scheduler
+func (s *Service) RunAll(ctx context.Context, dryRun bool) error {
+	dbPolicies, err := s.queries.ListEnabledRetentionPolicies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list retention policies: %w", err)
+	}
+
+	var errs []error
+	for _, dbPolicy := range dbPolicies {
+		if _, err := s.execute(ctx, toPolicy(dbPolicy), dryRun, audit.SystemActor); err != nil {
+			errs = append(errs, fmt.Errorf("policy %s: %w", dbPolicy.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Service) execute(ctx context.Context, policy *Policy, dryRun bool, actor string) (*Report, error) {
+	op, ok := s.operations[operationKey(policy.Target, policy.Action)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported target/action %q/%q", ErrInvalidPolicy, policy.Target, policy.Action)
+	}
+
+	startedAt := time.Now()
+	cutoff := startedAt.Add(-time.Duration(policy.RetentionDays) * 24 * time.Hour)
+	pgCutoff := pgtype.Timestamptz{Time: cutoff, Valid: true}
+
+	var affected int64
+	var execErr error
+	if dryRun {
+		affected, execErr = op.count(ctx, pgCutoff)
+	} else {
+		affected, execErr = op.apply(ctx, pgCutoff)
+	}
+
+	runErr := pgtype.Text{}
+	if execErr != nil {
+		runErr = pgtype.Text{String: execErr.Error(), Valid: true}
+	}
+
+	dbRun, err := s.queries.CreateRetentionRun(ctx, db.CreateRetentionRunParams{
+		PolicyID:     pgtype.UUID{Bytes: policy.ID, Valid: true},
+		DryRun:       dryRun,
+		Cutoff:       pgCutoff,
+		AffectedRows: affected,
+		Error:        runErr,
+		StartedAt:    pgtype.Timestamptz{Time: startedAt, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store retention report: %w", err)
+	}
+	if execErr != nil {
+		return nil, execErr
+	}
+
+	if !dryRun {
+		if err := s.queries.MarkRetentionPolicyRun(ctx, pgtype.UUID{Bytes: policy.ID, Valid: true}); err != nil {
+			return nil, err
+		}
+		s.record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "retention.policy.executed",
+			ResourceType: "retention_policy",
+			ResourceID:   policy.ID.String(),
+			Metadata: map[string]any{
+				"target":        policy.Target,
+				"action":        policy.Action,
+				"cutoff":        cutoff,
+				"affected_rows": affected,
+			},
+		})
+	}
+
+	return toReport(dbRun), nil
+}
+
+// record writes an audit entry; failures are not propagated because the
+// retention action itself has already been committed
+func (s *Service) record(ctx context.Context, entry audit.Entry) {
+	if err := s.auditor.Record(ctx, entry); err != nil {
+		logger.FromContext(ctx).Warn("failed to record audit entry", "error", err, "action", entry.Action)
+	}
+}
+
+func toPolicy(p db.RetentionPolicy) *Policy {
+	policy := &Policy{
+		ID:            uuid.UUID(p.ID.Bytes),
+		Name:          p.Name,
+		Target:        p.Target,
+		Action:        p.Action,
+		RetentionDays: int(p.RetentionDays),
+		Enabled:       p.Enabled,
+		CreatedAt:     p.CreatedAt.Time,
+		UpdatedAt:     p.UpdatedAt.Time,
+	}
+	if p.LastRunAt.Valid {
+		lastRun := p.LastRunAt.Time
+		policy.LastRunAt = &lastRun
+	}
+	return policy
+}
+
+func toReport(r db.RetentionRun) *Report {
+	return &Report{
+		ID:           uuid.UUID(r.ID.Bytes),
+		PolicyID:     uuid.UUID(r.PolicyID.Bytes),
+		DryRun:       r.DryRun,
+		Cutoff:       r.Cutoff.Time,
+		AffectedRows: r.AffectedRows,
+		Error:        r.Error.String,
+		StartedAt:    r.StartedAt.Time,
+		FinishedAt:   r.FinishedAt.Time,
+	}
+}
@@ -0,0 +1,144 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/listing"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	AssessAccount(ctx context.Context, id uuid.UUID) (*Assessment, error)
+	ShadowBan(ctx context.Context, id uuid.UUID, reason, actor string) (*ShadowBan, error)
+	LiftShadowBan(ctx context.Context, id uuid.UUID, actor string) error
+	ListShadowBans(ctx context.Context, limit, offset int) ([]*ShadowBan, error)
+	CountShadowBans(ctx context.Context) (int64, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) HandleAssessUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+
+		assessment, err := h.service.AssessAccount(r.Context(), userID)
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to assess user", userID)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, assessment)
+	}
+}
+
+// ShadowBanList declares the query parameters of the shadow ban list
+var ShadowBanList = listing.Spec{Items: "shadow_bans", DefaultLimit: 50, MaxLimit: 200}
+
+// HandleListShadowBans lists shadow banned accounts, most recent first;
+// its route declares ShadowBanList
+func (h *Handler) HandleListShadowBans() http.HandlerFunc {
+	source := listing.Source[*ShadowBan]{
+		List: func(ctx context.Context, q listing.Query) ([]*ShadowBan, error) {
+			return h.service.ListShadowBans(ctx, q.Limit, q.Offset)
+		},
+		Count: func(ctx context.Context, _ listing.Query) (int64, error) {
+			return h.service.CountShadowBans(ctx)
+		},
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		listing.Serve(w, r, source)
+	}
+}
+
+func (h *Handler) HandleShadowBan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+
+		var req ShadowBanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		ban, err := h.service.ShadowBan(r.Context(), userID, req.Reason, actorFromRequest(r))
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to shadow ban user", userID)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, ban)
+	}
+}
+
+func (h *Handler) HandleLiftShadowBan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.parseUserID(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.service.LiftShadowBan(r.Context(), userID, actorFromRequest(r)); err != nil {
+			h.handleServiceError(w, r, err, "failed to lift shadow ban", userID)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) parseUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func (h *Handler) handleServiceError(w http.ResponseWriter, r *http.Request, err error, msg string, userID uuid.UUID) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+	case errors.Is(err, ErrNotShadowBanned):
+		apierror.Write(w, r, apierror.NotFound("not_shadow_banned", err.Error()))
+	case errors.Is(err, ErrReasonRequired):
+		apierror.Write(w, r, apierror.BadRequest("reason_required", err.Error()))
+	default:
+		h.logger.Error(msg, "error", err, "user_id", userID)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
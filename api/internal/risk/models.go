@@ -0,0 +1,65 @@
+package risk
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Policy configures how requests are scored and what happens at each level
+type Policy struct {
+	IPDenylist         []netip.Prefix
+	VelocityWindow     time.Duration
+	VelocityLimit      int
+	NewAccountAge      time.Duration
+	BlockThreshold     int
+	ShadowBanThreshold int
+}
+
+// Signal weights. Scores are the sum of the weights of matching signals.
+const (
+	weightIPDenylisted   = 60
+	weightIPVelocity     = 40
+	weightActorVelocity  = 40
+	weightAnonymous      = 10
+	weightNewAccount     = 20
+	weightUnverified     = 10
+	weightModerationFlag = 10
+	maxModerationWeight  = 30
+)
+
+// Signal is one contributor to a risk score
+type Signal struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Assessment is the scored risk of a request or account
+type Assessment struct {
+	Score        int       `json:"score"`
+	Signals      []Signal  `json:"signals"`
+	UserID       uuid.UUID `json:"user_id,omitempty"`
+	ShadowBanned bool      `json:"shadow_banned"`
+}
+
+func (a *Assessment) add(name string, weight int, detail string) {
+	a.Score += weight
+	a.Signals = append(a.Signals, Signal{Name: name, Weight: weight, Detail: detail})
+}
+
+// ShadowBan describes a shadow banned account. Banned accounts keep
+// working normally but their content is hidden from everyone else.
+type ShadowBan struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	Name     string    `json:"name"`
+	Reason   string    `json:"reason,omitempty"`
+	BannedAt time.Time `json:"banned_at"`
+}
+
+// ShadowBanRequest is the body for banning an account
+type ShadowBanRequest struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,257 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/logger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrNotShadowBanned  = errors.New("user is not shadow banned")
+	ErrReasonRequired   = errors.New("reason is required")
+	ErrRequestBlocked   = errors.New("request blocked")
+	ErrInvalidIPPattern = errors.New("invalid IP denylist entry")
+)
+
+type Querier interface {
+	GetUserRiskSignals(ctx context.Context, id pgtype.UUID) (db.GetUserRiskSignalsRow, error)
+	GetUserRiskSignalsByEmail(ctx context.Context, email string) (db.GetUserRiskSignalsByEmailRow, error)
+	SetUserShadowBan(ctx context.Context, arg db.SetUserShadowBanParams) (db.SetUserShadowBanRow, error)
+	ClearUserShadowBan(ctx context.Context, id pgtype.UUID) (int64, error)
+	ListShadowBannedUsers(ctx context.Context, arg db.ListShadowBannedUsersParams) ([]db.ListShadowBannedUsersRow, error)
+	CountShadowBannedUsers(ctx context.Context) (int64, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+type Service struct {
+	queries  Querier
+	auditor  Auditor
+	policy   Policy
+	ipHits   *velocity
+	userHits *velocity
+}
+
+func NewService(queries Querier, auditor Auditor, policy Policy) *Service {
+	return &Service{
+		queries:  queries,
+		auditor:  auditor,
+		policy:   policy,
+		ipHits:   newVelocity(policy.VelocityWindow),
+		userHits: newVelocity(policy.VelocityWindow),
+	}
+}
+
+// ParseIPDenylist parses addresses and CIDR prefixes for Policy.IPDenylist
+func ParseIPDenylist(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidIPPattern, entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// AssessWrite scores a write request from ip by the account with the given
+// email, which may be empty for anonymous callers. It returns
+// ErrRequestBlocked when the score reaches the block threshold, and shadow
+// bans the account when it reaches the shadow ban threshold.
+func (s *Service) AssessWrite(ctx context.Context, ip netip.Addr, email string) (*Assessment, error) {
+	assessment := &Assessment{Signals: []Signal{}}
+
+	if ip.IsValid() {
+		ip = ip.Unmap()
+		for _, prefix := range s.policy.IPDenylist {
+			if prefix.Contains(ip) {
+				assessment.add("ip_reputation", weightIPDenylisted, "address is denylisted")
+				break
+			}
+		}
+		if n := s.ipHits.hit(ip.String()); n > s.policy.VelocityLimit {
+			assessment.add("ip_velocity", weightIPVelocity, strconv.Itoa(n)+" writes in window")
+		}
+	}
+
+	if email == "" {
+		assessment.add("anonymous", weightAnonymous, "")
+	} else {
+		if n := s.userHits.hit(email); n > s.policy.VelocityLimit {
+			assessment.add("account_velocity", weightActorVelocity, strconv.Itoa(n)+" writes in window")
+		}
+
+		signals, err := s.queries.GetUserRiskSignalsByEmail(ctx, email)
+		switch {
+		case err == nil:
+			s.addAccountSignals(assessment, signals)
+		case errors.Is(err, pgx.ErrNoRows):
+			assessment.add("unknown_account", weightAnonymous, "")
+		default:
+			return nil, fmt.Errorf("failed to get account risk signals: %w", err)
+		}
+	}
+
+	if s.policy.ShadowBanThreshold > 0 && assessment.Score >= s.policy.ShadowBanThreshold &&
+		assessment.UserID != uuid.Nil && !assessment.ShadowBanned {
+		reason := fmt.Sprintf("automatic: risk score %d", assessment.Score)
+		if _, err := s.ShadowBan(ctx, assessment.UserID, reason, audit.SystemActor); err != nil {
+			logger.FromContext(ctx).Warn("failed to shadow ban account", "error", err, "user_id", assessment.UserID)
+		} else {
+			assessment.ShadowBanned = true
+		}
+	}
+
+	if s.policy.BlockThreshold > 0 && assessment.Score >= s.policy.BlockThreshold {
+		return assessment, ErrRequestBlocked
+	}
+	return assessment, nil
+}
+
+// AssessAccount scores the account signals of a user, for admin review
+func (s *Service) AssessAccount(ctx context.Context, id uuid.UUID) (*Assessment, error) {
+	signals, err := s.queries.GetUserRiskSignals(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get account risk signals: %w", err)
+	}
+
+	assessment := &Assessment{Signals: []Signal{}}
+	s.addAccountSignals(assessment, db.GetUserRiskSignalsByEmailRow(signals))
+	return assessment, nil
+}
+
+func (s *Service) addAccountSignals(a *Assessment, row db.GetUserRiskSignalsByEmailRow) {
+	a.UserID = uuid.UUID(row.ID.Bytes)
+	a.ShadowBanned = row.ShadowBannedAt.Valid
+
+	if age := time.Since(row.CreatedAt.Time); age < s.policy.NewAccountAge {
+		a.add("new_account", weightNewAccount, "created "+age.Round(time.Minute).String()+" ago")
+	}
+	if !row.PhoneVerifiedAt.Valid {
+		a.add("unverified_phone", weightUnverified, "")
+	}
+	if row.ModerationFlags > 0 {
+		weight := min(int(row.ModerationFlags)*weightModerationFlag, maxModerationWeight)
+		a.add("moderation_flags", weight, strconv.FormatInt(row.ModerationFlags, 10)+" flagged items")
+	}
+}
+
+// ShadowBan hides an account's content from everyone but the account itself
+func (s *Service) ShadowBan(ctx context.Context, id uuid.UUID, reason, actor string) (*ShadowBan, error) {
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	row, err := s.queries.SetUserShadowBan(ctx, db.SetUserShadowBanParams{
+		ID:              pgtype.UUID{Bytes: id, Valid: true},
+		ShadowBanReason: pgtype.Text{String: reason, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to shadow ban user: %w", err)
+	}
+
+	s.record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "user.shadow_banned",
+		ResourceType: "user",
+		ResourceID:   id.String(),
+		Metadata: map[string]any{
+			"reason": reason,
+		},
+	})
+
+	return toShadowBan(db.ListShadowBannedUsersRow(row)), nil
+}
+
+// LiftShadowBan makes a shadow banned account visible again
+func (s *Service) LiftShadowBan(ctx context.Context, id uuid.UUID, actor string) error {
+	rows, err := s.queries.ClearUserShadowBan(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to lift shadow ban: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotShadowBanned
+	}
+
+	s.record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "user.shadow_ban_lifted",
+		ResourceType: "user",
+		ResourceID:   id.String(),
+	})
+	return nil
+}
+
+// ListShadowBans returns shadow banned accounts, most recent first
+func (s *Service) ListShadowBans(ctx context.Context, limit, offset int) ([]*ShadowBan, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := s.queries.ListShadowBannedUsers(ctx, db.ListShadowBannedUsersParams{
+		RowLimit:  int32(limit),
+		RowOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shadow bans: %w", err)
+	}
+
+	bans := make([]*ShadowBan, len(rows))
+	for i, row := range rows {
+		bans[i] = toShadowBan(row)
+	}
+	return bans, nil
+}
+
+// CountShadowBans returns the number of shadow banned accounts
+func (s *Service) CountShadowBans(ctx context.Context) (int64, error) {
+	count, err := s.queries.CountShadowBannedUsers(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count shadow bans: %w", err)
+	}
+	return count, nil
+}
+
+func (s *Service) record(ctx context.Context, entry audit.Entry) {
+	if err := s.auditor.Record(ctx, entry); err != nil {
+		logger.FromContext(ctx).Warn("failed to record audit entry", "error", err, "action", entry.Action)
+	}
+}
+
+func toShadowBan(row db.ListShadowBannedUsersRow) *ShadowBan {
+	return &ShadowBan{
+		UserID:   uuid.UUID(row.ID.Bytes),
+		Email:    row.Email,
+		Name:     row.Name,
+		Reason:   row.ShadowBanReason.String,
+		BannedAt: row.ShadowBannedAt.Time,
+	}
+}
@@ -0,0 +1,37 @@
+package risk
+
+import (
+	"sync"
+	"time"
+)
+
+// velocity counts events per key in fixed windows. Counts are per process,
+// so with several replicas each enforces its own share of the limit.
+type velocity struct {
+	mu      sync.Mutex
+	window  time.Duration
+	counts  map[string]int
+	started time.Time
+}
+
+func newVelocity(window time.Duration) *velocity {
+	return &velocity{
+		window:  window,
+		counts:  make(map[string]int),
+		started: time.Now(),
+	}
+}
+
+// hit records an event for key and returns the count in the current window
+func (v *velocity) hit(key string) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Start a fresh window rather than tracking per-key timestamps
+	if time.Since(v.started) >= v.window {
+		clear(v.counts)
+		v.started = time.Now()
+	}
+	v.counts[key]++
+	return v.counts[key]
+}
@@ -0,0 +1,69 @@
+package scopedtokens
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/request"
+)
+
+type ServiceInterface interface {
+	Narrow(ctx context.Context, p *auth.Principal, req NarrowRequest) (*NarrowedToken, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleNarrow exchanges the caller's bearer token for a narrowed one
+func (h *Handler) HandleNarrow() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok {
+			apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "unauthenticated", ErrUnauthenticated.Error()))
+			return
+		}
+
+		var req NarrowRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		token, err := h.service.Narrow(r.Context(), principal, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrScopeNotGranted):
+				apierror.Write(w, r, apierror.New(http.StatusForbidden, "scope_not_granted", err.Error()))
+			case errors.Is(err, ErrAlreadyNarrowed):
+				apierror.Write(w, r, apierror.New(http.StatusForbidden, "already_narrowed", err.Error()))
+			case errors.Is(err, ErrTTLTooLong):
+				apierror.Write(w, r, apierror.Unprocessable("ttl_too_long", err.Error()))
+			default:
+				h.logger.Error("failed to narrow token", "error", err)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(token); err != nil {
+			h.logger.Error("failed to encode response", "error", err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package scopedtokens
+
+import (
+	"errors"
+	"time"
+
+	"starterkit/internal/platform/request"
+)
+
+var (
+	ErrUnauthenticated = errors.New("a bearer token is required")
+	ErrAlreadyNarrowed = errors.New("narrowed tokens cannot be narrowed again")
+	ErrTTLTooLong      = errors.New("expires_in exceeds the longest lifetime of a narrowed token")
+)
+
+// NarrowRequest asks for a token with only some of the caller's scopes
+type NarrowRequest struct {
+	Scopes []string `json:"scopes" doc:"Scopes the token is limited to, each granted to the caller's token" example:"users:read"`
+	// ExpiresIn shortens the token's lifetime below the configured one
+	ExpiresIn int `json:"expires_in,omitempty" doc:"Seconds the token lasts; defaults to AUTH_NARROWED_TOKEN_TTL, which it may not exceed" example:"300"`
+}
+
+func (r *NarrowRequest) Validate() error {
+	var v request.Validation
+	v.Check(len(r.Scopes) > 0, "scopes", "is required")
+	for _, scope := range r.Scopes {
+		v.Check(scope != "", "scopes", "must not contain empty scopes")
+	}
+	v.Check(r.ExpiresIn >= 0, "expires_in", "must not be negative")
+	return v.Err()
+}
+
+// NarrowedToken is a narrowed token; the response is the only time it is
+// shown
+type NarrowedToken struct {
+	AccessToken string    `json:"access_token" doc:"Bearer token acting as the caller with only the listed scopes"`
+	TokenType   string    `json:"token_type" example:"Bearer"`
+	Scopes      []string  `json:"scopes" doc:"Scopes the token grants"`
+	ExpiresAt   time.Time `json:"expires_at" doc:"When the token stops working, no later than the token it was narrowed from"`
+	ExpiresIn   int       `json:"expires_in" doc:"Seconds until the token stops working" example:"900"`
+}
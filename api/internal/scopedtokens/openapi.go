@@ -0,0 +1,36 @@
+package scopedtokens
+
+import (
+	"net/http"
+	"time"
+
+	"starterkit/internal/openapi"
+)
+
+// Describe registers the token narrowing endpoint in the OpenAPI document
+func Describe(api *openapi.Registry) {
+	api.Add(openapi.Operation{
+		Method:         http.MethodPost,
+		Path:           "/api/v1/auth/token/narrow",
+		ID:             "narrowToken",
+		Summary:        "Narrow token",
+		Description:    "Exchanges the caller's bearer token for a short-lived one acting as the same caller with only some of its scopes and none of its roles, for a page to hand to embedded widgets or third-party iframes. Narrowed tokens reach only routes needing scopes they grant, cannot be narrowed again, and expire no later than the token they came from. Each one is recorded in the audit log. Available when AUTH_NARROWING_SECRET is set.",
+		Tags:           []string{"Auth"},
+		Request:        NarrowRequest{},
+		RequestExample: NarrowRequest{Scopes: []string{"users:read"}, ExpiresIn: 300},
+		Responses: []openapi.Response{
+			{Status: http.StatusCreated, Description: "Narrowed token", Body: NarrowedToken{}, Example: NarrowedToken{
+				AccessToken: "nt_eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...",
+				TokenType:   "Bearer",
+				Scopes:      []string{"users:read"},
+				ExpiresAt:   time.Date(2024, 1, 1, 9, 5, 0, 0, time.UTC),
+				ExpiresIn:   300,
+			}},
+			openapi.Problem(http.StatusBadRequest, "Malformed request body"),
+			openapi.Problem(http.StatusUnauthorized, "Missing or invalid bearer token"),
+			openapi.Problem(http.StatusForbidden, "A scope is not granted to the caller, or the token is already narrowed"),
+			openapi.Problem(http.StatusUnprocessableEntity, "No scopes, or expires_in longer than narrowed tokens last"),
+			openapi.Problem(http.StatusInternalServerError, "Internal server error"),
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package scopedtokens
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/platform/auth"
+)
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service exchanges the caller's token for a narrowed one, limited to some
+// of its scopes, that a page can hand to an embedded widget or third-party
+// iframe without giving it everything the page may do
+type Service struct {
+	narrower *auth.Narrower
+	auditor  Auditor
+	ttl      time.Duration
+	logger   *slog.Logger
+}
+
+// NewService creates the service; narrowed tokens last ttl unless asked for
+// less
+func NewService(narrower *auth.Narrower, auditor Auditor, ttl time.Duration, logger *slog.Logger) *Service {
+	return &Service{
+		narrower: narrower,
+		auditor:  auditor,
+		ttl:      ttl,
+		logger:   logger,
+	}
+}
+
+// Narrow issues a token acting as p with only req.Scopes, which p must
+// hold, and records it in the audit log
+func (s *Service) Narrow(ctx context.Context, p *auth.Principal, req NarrowRequest) (*NarrowedToken, error) {
+	if p.Narrowed {
+		return nil, ErrAlreadyNarrowed
+	}
+	ttl := s.ttl
+	if req.ExpiresIn > 0 {
+		requested := time.Duration(req.ExpiresIn) * time.Second
+		if requested > s.ttl {
+			return nil, ErrTTLTooLong
+		}
+		ttl = requested
+	}
+
+	token, id, expiresAt, err := s.narrower.Narrow(p, req.Scopes, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := p.Email
+	if actor == "" {
+		actor = p.Subject
+	}
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "token.narrowed",
+		ResourceType: "narrowed_token",
+		ResourceID:   id,
+		Metadata:     map[string]any{"scopes": req.Scopes, "expires_at": expiresAt, "client": p.Client},
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", "token.narrowed")
+	}
+
+	return &NarrowedToken{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		Scopes:      req.Scopes,
+		ExpiresAt:   expiresAt,
+		ExpiresIn:   int(time.Until(expiresAt).Seconds()),
+	}, nil
+}
@@ -0,0 +1,83 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"starterkit/internal/connectors"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/querycost"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// SemanticCost prices GET /search/semantic: embedding the query calls the
+// provider, and ranking by distance reads every candidate vector
+var SemanticCost = querycost.Model{
+	Base:         10,
+	PerRow:       0.2,
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+// HandleSemantic ranks users by how closely their profile matches the
+// meaning of q, rather than its exact words
+func (h *Handler) HandleSemantic() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 20 // default
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsedLimit, err := strconv.Atoi(limitStr)
+			if err != nil || parsedLimit < 0 {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid limit parameter"))
+				return
+			}
+			limit = parsedLimit
+		}
+
+		results, err := h.service.Semantic(r.Context(), r.URL.Query().Get("q"), limit, viewerFromRequest(r))
+		if err != nil {
+			var connErr *connectors.Error
+			switch {
+			case errors.Is(err, ErrInvalidQuery):
+				apierror.Write(w, r, apierror.BadRequest("invalid_query", err.Error()))
+			case errors.As(err, &connErr):
+				h.logger.Error("embeddings provider failed", "error", err)
+				apierror.Write(w, r, apierror.New(http.StatusBadGateway, "search_unavailable", "semantic search is unavailable"))
+			default:
+				h.logger.Error("failed to search", "error", err)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"results": results,
+			"limit":   limit,
+		})
+	}
+}
+
+func viewerFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
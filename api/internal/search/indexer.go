@@ -0,0 +1,128 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"starterkit/internal/ai"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/metrics"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type IndexQueries interface {
+	ListPendingUserEmbeddings(ctx context.Context, arg db.ListPendingUserEmbeddingsParams) ([]db.ListPendingUserEmbeddingsRow, error)
+	SaveUserEmbedding(ctx context.Context, arg db.SaveUserEmbeddingParams) (int64, error)
+	ResetUserEmbeddings(ctx context.Context) (int64, error)
+	CountUserEmbeddings(ctx context.Context, model pgtype.Text) (db.CountUserEmbeddingsRow, error)
+}
+
+// Indexer embeds the profiles the user_embeddings projection marked as
+// new or changed, and those embedded by a previous model
+type Indexer struct {
+	embedder  ai.Embedder
+	queries   IndexQueries
+	batchSize int
+	maxChars  int
+	logger    *slog.Logger
+	pending   metric.Int64Gauge
+}
+
+func NewIndexer(embedder ai.Embedder, queries IndexQueries, batchSize, maxChars int, logger *slog.Logger) *Indexer {
+	return &Indexer{
+		embedder:  embedder,
+		queries:   queries,
+		batchSize: max(batchSize, 1),
+		maxChars:  maxChars,
+		logger:    logger,
+		pending:   metrics.Int64Gauge(metrics.EmbeddingsPending),
+	}
+}
+
+// Run embeds pending profiles a batch at a time until none are left
+func (i *Indexer) Run(ctx context.Context) error {
+	var embedded int
+	for {
+		n, err := i.embedBatch(ctx)
+		embedded += n
+		if err != nil {
+			return err
+		}
+		if n < i.batchSize {
+			break
+		}
+	}
+	if embedded > 0 {
+		i.logger.Info("embedded user profiles", "count", embedded, "model", i.embedder.Model())
+	}
+
+	status, err := i.Status(ctx)
+	if err != nil {
+		return err
+	}
+	i.pending.Record(ctx, status.Pending, metric.WithAttributes(attribute.String("model", status.Model)))
+	return nil
+}
+
+func (i *Indexer) embedBatch(ctx context.Context) (int, error) {
+	rows, err := i.queries.ListPendingUserEmbeddings(ctx, db.ListPendingUserEmbeddingsParams{
+		Model:    pgtype.Text{String: i.embedder.Model(), Valid: true},
+		RowLimit: int32(i.batchSize),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pending embeddings: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	texts := make([]string, len(rows))
+	for j, row := range rows {
+		texts[j] = truncate(row.Name+"\n"+row.Bio, i.maxChars)
+	}
+	vectors, err := i.embedder.Embed(ctx, texts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to embed profiles: %w", err)
+	}
+
+	// A profile edited meanwhile keeps its new hash and is embedded again
+	// in the next batch
+	for j, row := range rows {
+		if _, err := i.queries.SaveUserEmbedding(ctx, db.SaveUserEmbeddingParams{
+			Model:       pgtype.Text{String: i.embedder.Model(), Valid: true},
+			Embedding:   formatVector(vectors[j]),
+			UserID:      row.UserID,
+			ContentHash: row.ContentHash,
+		}); err != nil {
+			return 0, fmt.Errorf("failed to save embedding: %w", err)
+		}
+	}
+	return len(rows), nil
+}
+
+// Reset clears every embedding so the next run embeds all profiles again.
+// Semantic search returns nothing for a profile until it is re-embedded.
+func (i *Indexer) Reset(ctx context.Context) (int64, error) {
+	count, err := i.queries.ResetUserEmbeddings(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset embeddings: %w", err)
+	}
+	return count, nil
+}
+
+// Status reports the embedding backlog for the current model
+func (i *Indexer) Status(ctx context.Context) (IndexStatus, error) {
+	counts, err := i.queries.CountUserEmbeddings(ctx, pgtype.Text{String: i.embedder.Model(), Valid: true})
+	if err != nil {
+		return IndexStatus{}, fmt.Errorf("failed to count embeddings: %w", err)
+	}
+	return IndexStatus{
+		Model:   i.embedder.Model(),
+		Total:   counts.Total,
+		Pending: counts.Pending,
+	}, nil
+}
@@ -0,0 +1,23 @@
+package search
+
+import (
+	"errors"
+
+	"starterkit/internal/users"
+)
+
+var ErrInvalidQuery = errors.New("search query is required")
+
+// Result is a user whose profile matches a semantic query. Score is the
+// cosine similarity, from -1 to 1, higher meaning closer.
+type Result struct {
+	User  *users.User `json:"user"`
+	Score float64     `json:"score"`
+}
+
+// IndexStatus reports how many profiles are embedded with the current model
+type IndexStatus struct {
+	Model   string `json:"model"`
+	Total   int64  `json:"total"`
+	Pending int64  `json:"pending"`
+}
@@ -0,0 +1,122 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"starterkit/internal/ai"
+	"starterkit/internal/db"
+	"starterkit/internal/users"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Queries interface {
+	SearchUserEmbeddings(ctx context.Context, arg db.SearchUserEmbeddingsParams) ([]db.SearchUserEmbeddingsRow, error)
+}
+
+type Service struct {
+	embedder ai.Embedder
+	queries  Queries
+	maxChars int
+}
+
+func NewService(embedder ai.Embedder, queries Queries, maxChars int) *Service {
+	return &Service{
+		embedder: embedder,
+		queries:  queries,
+		maxChars: maxChars,
+	}
+}
+
+// Semantic returns the users whose profiles are closest in meaning to
+// query, as seen by viewer. Profiles are embedded in the background, so
+// recent edits appear after the embedding job runs.
+func (s *Service) Semantic(ctx context.Context, query string, limit int, viewer string) ([]*Result, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrInvalidQuery
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	vectors, err := s.embedder.Embed(ctx, []string{truncate(query, s.maxChars)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	// A query with no words has no direction to compare against
+	if isZero(vectors[0]) {
+		return []*Result{}, nil
+	}
+
+	rows, err := s.queries.SearchUserEmbeddings(ctx, db.SearchUserEmbeddingsParams{
+		Embedding: formatVector(vectors[0]),
+		Model:     pgtype.Text{String: s.embedder.Model(), Valid: true},
+		Viewer:    viewer,
+		RowLimit:  int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Result, len(rows))
+	for i, row := range rows {
+		var phone *string
+		if row.Phone.Valid {
+			phone = &row.Phone.String
+		}
+		results[i] = &Result{
+			User: &users.User{
+				ID:            uuid.UUID(row.ID.Bytes),
+				Email:         row.Email,
+				Name:          row.Name,
+				Bio:           row.Bio,
+				Phone:         phone,
+				PhoneVerified: row.PhoneVerifiedAt.Valid,
+				CreatedAt:     row.CreatedAt.Time,
+				UpdatedAt:     row.UpdatedAt.Time,
+			},
+			Score: row.Score,
+		}
+	}
+	return results, nil
+}
+
+// formatVector renders a vector in pgvector's text format
+func formatVector(vector []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range vector {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func isZero(vector []float32) bool {
+	for _, v := range vector {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// truncate cuts text to at most maxChars characters
+func truncate(text string, maxChars int) string {
+	if maxChars <= 0 || utf8.RuneCountInString(text) <= maxChars {
+		return text
+	}
+	return string([]rune(text)[:maxChars])
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"starterkit/internal/apikeys"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/logger"
+)
+
+// apiKeyMiddleware meters requests sent with an X-API-Key against the key's
+// rate plan, rejecting unknown keys and requests over the plan's rate or
+// daily quota. API keys identify the caller for metering only; they do not
+// replace bearer tokens. If the usage cannot be counted the request is let
+// through.
+func (s *Server) apiKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get("X-API-Key")
+		if secret == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		usage, err := s.apiKeys.Admit(r.Context(), secret)
+		if usage != nil {
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(usage.Plan.RequestsPerMinute))
+			// Requests turned away by the rate limit are not counted, so
+			// their usage today is unknown
+			if quota := usage.Plan.DailyQuota; quota > 0 && usage.Requests > 0 {
+				w.Header().Set("X-Quota-Limit", strconv.FormatInt(quota, 10))
+				w.Header().Set("X-Quota-Remaining", strconv.FormatInt(max(quota-usage.Requests, 0), 10))
+			}
+		}
+
+		switch {
+		case err == nil:
+		case errors.Is(err, apikeys.ErrInvalidKey):
+			apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "invalid_api_key", err.Error()))
+			return
+		case errors.Is(err, apikeys.ErrRateLimited):
+			wait := math.Ceil(60 / float64(usage.Plan.RequestsPerMinute))
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait)))
+			apierror.Write(w, r, apierror.TooManyRequests("rate_limited", err.Error()))
+			return
+		case errors.Is(err, apikeys.ErrQuotaExceeded):
+			now := time.Now().UTC()
+			midnight := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(midnight.Sub(now).Seconds()))))
+			apierror.Write(w, r, apierror.TooManyRequests("quota_exceeded", err.Error()))
+			return
+		default:
+			logger.FromContext(r.Context()).Warn("failed to meter API key", "error", err)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"starterkit/internal/magiclinks"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/serviceaccounts"
+)
+
+// authMiddleware authenticates every request except health checks, the
+// metrics scrape, the API reference, public routes such as short link
+// redirects and sign-in, and provider webhooks, which authenticate
+// themselves, and stores the caller in the request context. Internal
+// services are identified by their mTLS client certificate, signing clients
+// by their request signature, and everyone else by a bearer token, which
+// service accounts send their credential as, users signed in through a
+// magic link or passkey their session token, and embedded widgets the
+// narrowed token their page exchanged its own for. Handlers still identify the
+// caller by X-User-Email, so the header is replaced with the caller's email
+// and clients cannot act as someone else. With bearer auth disabled the header
+// is trusted as sent on requests without a session token.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	if s.verifier == nil && s.signatures == nil && s.certificates == nil && !s.config.MagicLinks.Enabled && !s.config.WebAuthn.Enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Clean the path so dot segments cannot reach a protected route
+		// through a public prefix
+		p := path.Clean(r.URL.Path)
+		if r.Method == http.MethodOptions || s.publicPath(p) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var service *auth.Principal
+		if s.certificates != nil {
+			service, _ = s.certificates.Principal(r.TLS)
+		}
+
+		switch {
+		case service != nil:
+			r = r.WithContext(auth.WithPrincipal(r.Context(), service))
+		case s.signatures != nil && auth.IsSigned(r):
+			var ok bool
+			if r, ok = s.verifySignature(w, r); !ok {
+				return
+			}
+		case s.verifier == nil && !s.hasSessionToken(r):
+			next.ServeHTTP(w, r)
+			return
+		default:
+			token, err := auth.BearerToken(r)
+			if err != nil {
+				writeUnauthorized(w, r, "", err.Error())
+				return
+			}
+			var principal *auth.Principal
+			switch {
+			case s.serviceAccounts != nil && serviceaccounts.IsCredential(token):
+				principal, err = s.serviceAccountPrincipal(r.Context(), token)
+				if err != nil && !errors.Is(err, serviceaccounts.ErrInvalidCredential) {
+					logger.FromContext(r.Context()).Error("failed to authenticate service account", "error", err)
+					apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, "authentication_unavailable", "authentication unavailable"))
+					return
+				}
+			case s.narrower != nil && auth.IsNarrowed(token):
+				principal, err = s.narrower.Verify(token)
+			case s.magicLinks != nil && magiclinks.IsSessionToken(token):
+				principal, err = s.sessionPrincipal(r.Context(), token)
+				if err != nil && !errors.Is(err, magiclinks.ErrInvalidSession) {
+					logger.FromContext(r.Context()).Error("failed to authenticate session", "error", err)
+					apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, "authentication_unavailable", "authentication unavailable"))
+					return
+				}
+			default:
+				principal, err = s.oidcPrincipal(r.Context(), token)
+				if err != nil && !errors.Is(err, auth.ErrInvalidToken) {
+					logger.FromContext(r.Context()).Error("failed to resolve account", "error", err)
+					apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, "authentication_unavailable", "authentication unavailable"))
+					return
+				}
+			}
+			if err != nil {
+				logger.FromContext(r.Context()).Info("rejected bearer token", "error", err)
+				if !s.authFailed(r) {
+					return
+				}
+				writeUnauthorized(w, r, "invalid_token", auth.ErrInvalidToken.Error())
+				return
+			}
+			r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+		}
+
+		principal, _ := auth.FromContext(r.Context())
+		r.Header.Del("X-User-Email")
+		if principal.Email != "" {
+			r.Header.Set("X-User-Email", principal.Email)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// publicPath reports whether requests to the cleaned path p skip
+// authentication, as the routes serving it declare
+func (s *Server) publicPath(p string) bool {
+	for _, public := range s.publicPaths {
+		if p == public || (strings.HasSuffix(public, "/") && strings.HasPrefix(p, public)) {
+			return true
+		}
+	}
+	return false
+}
+
+// publicRouter registers the public routes of modules at the root and
+// exempts their paths from authentication
+type publicRouter struct {
+	router *router
+	server *Server
+}
+
+func (pr publicRouter) Handle(pattern string, handler http.Handler) {
+	pr.server.register(pr.router, routeSpec{Pattern: pattern, Access: accessNone, Handler: handler})
+}
+
+func (pr publicRouter) HandleFunc(pattern string, handler http.HandlerFunc) {
+	pr.Handle(pattern, handler)
+}
+
+// requireScope lets only callers whose token grants scope reach h. It
+// allows every request when auth is disabled.
+func (s *Server) requireScope(scope string, h http.Handler) http.Handler {
+	if s.verifier == nil {
+		return h
+	}
+	return layer{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok || !principal.HasScope(scope) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope))
+			apierror.Write(w, r, apierror.New(http.StatusForbidden, "insufficient_scope", "missing scope "+scope))
+			return
+		}
+		h.ServeHTTP(w, r)
+	}), name: "scope " + scope, next: h, auth: true, scope: scope}
+}
+
+// requireRole lets only callers with role reach h. It allows every request
+// when auth is disabled.
+func (s *Server) requireRole(role string, h http.Handler) http.Handler {
+	if s.verifier == nil {
+		return h
+	}
+	return layer{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok || !principal.HasRole(role) {
+			apierror.Write(w, r, apierror.New(http.StatusForbidden, "insufficient_role", "missing role "+role))
+			return
+		}
+		h.ServeHTTP(w, r)
+	}), name: "role " + role, next: h, auth: true, role: role}
+}
+
+// writeUnauthorized challenges the client for a bearer token as described
+// in RFC 6750. The problem's code is the challenge's error code, or
+// unauthenticated when the request carried no token.
+func writeUnauthorized(w http.ResponseWriter, r *http.Request, code, message string) {
+	challenge := `Bearer realm="api"`
+	problem := "unauthenticated"
+	if code != "" {
+		challenge += fmt.Sprintf(`, error=%q`, code)
+		problem = code
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	apierror.Write(w, r, apierror.New(http.StatusUnauthorized, problem, message))
+}
+
+// serviceAccountPrincipal authenticates a service account credential. The
+// account's scopes apply as a token's would; accounts hold no roles and no
+// email, so handlers see requests from them without X-User-Email.
+func (s *Server) serviceAccountPrincipal(ctx context.Context, credential string) (*auth.Principal, error) {
+	identity, err := s.serviceAccounts.Authenticate(ctx, credential)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Principal{
+		Subject: "service-account:" + identity.AccountID.String(),
+		Scopes:  identity.Scopes,
+		Client:  identity.Name,
+	}, nil
+}
+
+// hasSessionToken reports whether r carries a session token,
+// which is checked even with bearer auth disabled
+func (s *Server) hasSessionToken(r *http.Request) bool {
+	if s.magicLinks == nil {
+		return false
+	}
+	token, err := auth.BearerToken(r)
+	return err == nil && magiclinks.IsSessionToken(token)
+}
+
+// oidcPrincipal verifies an identity provider token. Tokens for an identity
+// linked to an account, or carrying the email of a user merged into one,
+// act as that account.
+func (s *Server) oidcPrincipal(ctx context.Context, token string) (*auth.Principal, error) {
+	principal, err := s.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	email, err := s.accounts.Resolve(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+	if email == "" || email == principal.Email {
+		return principal, nil
+	}
+	linked := *principal
+	linked.Email = email
+	return &linked, nil
+}
+
+// sessionPrincipal authenticates the session token of a user signed in
+// through a magic link or passkey. Sessions carry the user's email and the
+// roles granted to them in the app, but no scopes.
+func (s *Server) sessionPrincipal(ctx context.Context, token string) (*auth.Principal, error) {
+	identity, err := s.magicLinks.Authenticate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Principal{
+		Subject:   "user:" + identity.UserID.String(),
+		Email:     identity.Email,
+		Roles:     identity.Roles,
+		ExpiresAt: identity.ExpiresAt,
+	}, nil
+}
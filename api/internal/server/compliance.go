@@ -0,0 +1,193 @@
+package server
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"starterkit"
+	"starterkit/internal/config"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/fieldaccess"
+)
+
+// AccessMatrix reports which callers may reach which routes and see which
+// response fields, as evidence for access reviews
+type AccessMatrix struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Service     string    `json:"service"`
+	Environment string    `json:"environment"`
+	// Enforced is false when token authentication is off, so the scope and
+	// role requirements are declared but every caller gets through
+	Enforced   bool                 `json:"enforced"`
+	AdminRole  string               `json:"admin_role"`
+	Roles      []string             `json:"roles"`
+	Scopes     []string             `json:"scopes"`
+	Routes     []RouteAccess        `json:"routes"`
+	Principals []PrincipalAccess    `json:"principals"`
+	Fields     []fieldaccess.Policy `json:"fields"`
+}
+
+// RouteAccess is what a caller needs to reach a route
+type RouteAccess struct {
+	// Route is the pattern as server routes lists it
+	Route string `json:"route"`
+	// Public routes skip authentication
+	Public bool `json:"public"`
+	// Callers need every one of Scopes and Roles
+	Scopes []string `json:"scopes"`
+	Roles  []string `json:"roles"`
+}
+
+// PrincipalAccess lists the routes a kind of caller may reach
+type PrincipalAccess struct {
+	// Principal is "anonymous", "authenticated" for callers without roles
+	// or scopes, "role:<name>" or "scope:<name>" for callers with only that
+	// grant, or a configured client: "client:<id>" signing its requests or
+	// "service:<id>" over mutual TLS
+	Principal string   `json:"principal"`
+	Roles     []string `json:"roles"`
+	Scopes    []string `json:"scopes"`
+	Routes    []string `json:"routes"`
+}
+
+// BuildAccessMatrix derives the access matrix of a server started with cfg
+// from its routes' requirements and the field rules of the response types
+// in the API document. Requirements are read as declared with auth on,
+// whether or not cfg enables it.
+func BuildAccessMatrix(cfg *config.Config) (*AccessMatrix, error) {
+	table := declaredRoutes(cfg)
+
+	api := apiRegistry()
+	if _, err := api.Build(starterkit.OpenAPI); err != nil {
+		return nil, err
+	}
+
+	m := &AccessMatrix{
+		GeneratedAt: time.Now().UTC(),
+		Service:     cfg.Service.Name,
+		Environment: cfg.Service.Environment,
+		Enforced:    cfg.Auth.Enabled,
+		AdminRole:   cfg.Auth.AdminRole,
+		Routes:      make([]RouteAccess, 0, len(table.Routes)),
+		Fields:      fieldaccess.New(cfg.Auth, true).Policies(api.Types()),
+	}
+
+	roles, scopes := nameSet{}, nameSet{}
+	roles.add(cfg.Auth.AdminRole)
+	for _, route := range table.Routes {
+		m.Routes = append(m.Routes, RouteAccess{
+			Route:  route.Pattern(),
+			Public: route.public,
+			Scopes: nonNil(route.scopes),
+			Roles:  nonNil(route.roles),
+		})
+		roles.add(route.roles...)
+		scopes.add(route.scopes...)
+	}
+	for _, field := range m.Fields {
+		for _, grant := range field.Grants {
+			kind, name, _ := strings.Cut(strings.TrimSpace(grant), ":")
+			switch kind {
+			case "role":
+				roles.add(name)
+			case "scope":
+				scopes.add(name)
+			}
+		}
+	}
+
+	for _, client := range cfg.Auth.SigningClients {
+		roles.add(client.Roles...)
+		scopes.add(client.Scopes...)
+	}
+	for _, client := range cfg.Auth.ServiceClients {
+		roles.add(client.Roles...)
+		scopes.add(client.Scopes...)
+	}
+	m.Roles, m.Scopes = roles.sorted(), scopes.sorted()
+
+	m.Principals = []PrincipalAccess{
+		m.principal("anonymous", nil, nil, true),
+		m.principal("authenticated", nil, nil, false),
+	}
+	for _, role := range m.Roles {
+		m.Principals = append(m.Principals, m.principal("role:"+role, []string{role}, nil, false))
+	}
+	for _, scope := range m.Scopes {
+		m.Principals = append(m.Principals, m.principal("scope:"+scope, nil, []string{scope}, false))
+	}
+	for _, id := range slices.Sorted(maps.Keys(cfg.Auth.SigningClients)) {
+		client := cfg.Auth.SigningClients[id]
+		m.Principals = append(m.Principals, m.principal("client:"+id, client.Roles, client.Scopes, false))
+	}
+	for _, id := range slices.Sorted(maps.Keys(cfg.Auth.ServiceClients)) {
+		client := cfg.Auth.ServiceClients[id]
+		m.Principals = append(m.Principals, m.principal("service:"+id, client.Roles, client.Scopes, false))
+	}
+	return m, nil
+}
+
+// principal lists the routes a caller holding roles and scopes may reach,
+// or only the public ones for an anonymous caller
+func (m *AccessMatrix) principal(name string, roles, scopes []string, anonymous bool) PrincipalAccess {
+	p := PrincipalAccess{Principal: name, Roles: nonNil(roles), Scopes: nonNil(scopes), Routes: []string{}}
+	for _, route := range m.Routes {
+		if route.Public || (!anonymous && subset(route.Roles, roles) && subset(route.Scopes, scopes)) {
+			p.Routes = append(p.Routes, route.Route)
+		}
+	}
+	return p
+}
+
+// handleAccessMatrix serves the access matrix, as server access-matrix
+// prints it
+func (s *Server) handleAccessMatrix() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		matrix, err := BuildAccessMatrix(s.config)
+		if err != nil {
+			s.logger.Error("failed to build access matrix", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(matrix); err != nil {
+			s.logger.Error("failed to encode access matrix", "error", err)
+		}
+	}
+}
+
+type nameSet map[string]bool
+
+func (s nameSet) add(names ...string) {
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			s[name] = true
+		}
+	}
+}
+
+func (s nameSet) sorted() []string {
+	return slices.Sorted(maps.Keys(s))
+}
+
+func subset(required, held []string) bool {
+	for _, name := range required {
+		if !slices.Contains(held, name) {
+			return false
+		}
+	}
+	return true
+}
+
+func nonNil(names []string) []string {
+	if names == nil {
+		return []string{}
+	}
+	return names
+}
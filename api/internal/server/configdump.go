@@ -0,0 +1,19 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleConfig serves the effective configuration with secrets masked, as
+// server config prints it
+func (s *Server) handleConfig() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(s.config.Dump()); err != nil {
+			s.logger.Error("failed to encode configuration", "error", err)
+		}
+	}
+}
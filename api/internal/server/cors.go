@@ -0,0 +1,123 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"starterkit/internal/config"
+	"starterkit/internal/domains"
+)
+
+// corsGroup binds a CORS policy to a route prefix
+type corsGroup struct {
+	prefix string
+	policy config.CORSPolicy
+}
+
+// corsGroups returns the route groups in match order; the last entry is the fallback
+func (s *Server) corsGroups() []corsGroup {
+	return []corsGroup{
+		{prefix: "/admin/", policy: s.config.CORS.Admin},
+		{prefix: "/", policy: s.config.CORS.API},
+	}
+}
+
+// corsMiddleware applies the CORS policy of the route group a request targets
+// and answers preflight requests before routing
+func (s *Server) corsMiddleware(next http.Handler) http.Handler {
+	groups := s.corsGroups()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Responses differ per origin, so caches must key on it
+		w.Header().Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var policy config.CORSPolicy
+		for _, g := range groups {
+			if strings.HasPrefix(r.URL.Path, g.prefix) {
+				policy = g.policy
+				break
+			}
+		}
+		// A tenant's custom domain allows its own origins to call the API;
+		// admin routes keep their policy
+		if d, ok := domains.FromContext(r.Context()); ok && !strings.HasPrefix(r.URL.Path, "/admin/") {
+			policy.AllowedOrigins = d.AllowedOrigins()
+		}
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		allowed := originAllowed(policy.AllowedOrigins, origin)
+
+		if allowed {
+			if containsWildcard(policy.AllowedOrigins) && !policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if !preflight {
+			if allowed && len(policy.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Handle preflight requests
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Add("Vary", "Access-Control-Request-Private-Network")
+
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+			if policy.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+			}
+			if policy.AllowPrivateNetwork && r.Header.Get("Access-Control-Request-Private-Network") == "true" {
+				w.Header().Set("Access-Control-Allow-Private-Network", "true")
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// originAllowed reports whether origin matches one of the allowed patterns
+func originAllowed(allowed []string, origin string) bool {
+	for _, pattern := range allowed {
+		if pattern == "*" || strings.EqualFold(pattern, origin) {
+			return true
+		}
+
+		// Subdomain wildcard, e.g. https://*.example.com
+		scheme, host, ok := strings.Cut(pattern, "://*.")
+		if !ok {
+			continue
+		}
+		prefix := scheme + "://"
+		if strings.HasPrefix(origin, prefix) && strings.HasSuffix(strings.ToLower(origin), "."+strings.ToLower(host)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWildcard(origins []string) bool {
+	for _, o := range origins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/telemetry"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("starterkit/server")
+
+// debugTraceMiddleware captures one request in full for support when the
+// caller sends X-Debug-Trace: true and their token grants the debug scope.
+// The request is traced whatever the sample ratio, logged at DEBUG, and
+// answered with its trace ID in X-Trace-ID. It runs after authentication,
+// by when the request span has already been sampled or not; an unsampled
+// request is traced from a forced child span, so its trace lacks the
+// request span but keeps the trace ID already logged. Callers without the
+// scope are refused, and the header is ignored without a debug scope.
+func (s *Server) debugTraceMiddleware(next http.Handler) http.Handler {
+	scope := s.config.Telemetry.DebugScope
+	if scope == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if debug, _ := strconv.ParseBool(r.Header.Get("X-Debug-Trace")); !debug {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Like requireScope, everyone has the scope when auth is disabled
+		if principal, ok := auth.FromContext(r.Context()); s.verifier != nil && (!ok || !principal.HasScope(scope)) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope))
+			apierror.Write(w, r, apierror.New(http.StatusForbidden, "insufficient_scope", "missing scope "+scope+" for X-Debug-Trace"))
+			return
+		}
+
+		ctx := r.Context()
+		if s.config.Telemetry.Enabled {
+			span := trace.SpanFromContext(ctx)
+			if !span.SpanContext().IsSampled() {
+				ctx, span = tracer.Start(telemetry.ForceSampling(ctx), "debug-trace")
+				defer span.End()
+			}
+			span.SetAttributes(attribute.Bool("debug.forced", true))
+			if sc := span.SpanContext(); sc.IsValid() {
+				w.Header().Set("X-Trace-ID", sc.TraceID().String())
+			}
+		}
+
+		requestLogger := logger.WithLevel(logger.FromContext(ctx), slog.LevelDebug).With("debug_trace", true)
+		requestLogger.Info("debug trace requested")
+		ctx = logger.WithContext(ctx, requestLogger)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
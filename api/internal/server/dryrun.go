@@ -0,0 +1,42 @@
+package server
+
+import (
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/dryrun"
+)
+
+// dryRunnable lets callers preview h with ?dry_run=true or X-Dry-Run: h
+// runs its validation, policy checks, and queries as usual, but its
+// transactions are rolled back. Only handlers that keep every write in a
+// transaction, or skip the rest in a dry run, may be wrapped; the router
+// turns dry runs of other routes away.
+func (s *Server) dryRunnable(h http.Handler) http.Handler {
+	return layer{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		on, err := dryrun.Requested(r)
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_parameter", err.Error()))
+			return
+		}
+		if on {
+			w.Header().Set(dryrun.Header, "true")
+			r = r.WithContext(dryrun.WithContext(r.Context()))
+		}
+		h.ServeHTTP(w, r)
+	}), name: "dry-run", next: h}
+}
+
+// supportsDryRun reports whether h passes through dryRunnable
+func supportsDryRun(h http.Handler) bool {
+	for {
+		l, ok := h.(layer)
+		if !ok {
+			return false
+		}
+		if l.name == "dry-run" {
+			return true
+		}
+		h = l.next
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+
+	"starterkit/internal/config"
+	"starterkit/internal/notifications"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/expand"
+	"starterkit/internal/sessions"
+	"starterkit/internal/users"
+
+	"github.com/google/uuid"
+)
+
+// userExpansions registers the relations ?expand can embed in users. Each
+// loads a page of users' relations in one query. Users' settings and
+// devices are only embedded for the caller's own account or for admins.
+func userExpansions(cfg *config.Config, notificationService *notifications.Service, sessionService *sessions.Service) *expand.Registry {
+	registry := expand.NewRegistry()
+	registry.Register("preferences", expand.Relation{
+		Load: ownedUsers(cfg.Auth, func(ctx context.Context, parents []*users.User) ([]any, error) {
+			ids := make([]uuid.UUID, len(parents))
+			for i, u := range parents {
+				ids[i] = u.ID
+			}
+			prefs, err := notificationService.GetPreferencesForUsers(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			related := make([]any, len(parents))
+			for i, id := range ids {
+				related[i] = prefs[id]
+			}
+			return related, nil
+		}),
+	})
+	if cfg.Sessions.Enabled {
+		registry.Register("sessions", expand.Relation{
+			Load: ownedUsers(cfg.Auth, func(ctx context.Context, parents []*users.User) ([]any, error) {
+				emails := make([]string, len(parents))
+				for i, u := range parents {
+					emails[i] = u.Email
+				}
+				byEmail, err := sessionService.ListForUsers(ctx, emails)
+				if err != nil {
+					return nil, err
+				}
+				related := make([]any, len(parents))
+				for i, email := range emails {
+					list := byEmail[email]
+					if list == nil {
+						list = []*sessions.Session{}
+					}
+					related[i] = list
+				}
+				return related, nil
+			}),
+		})
+	}
+	return registry
+}
+
+// ownedUsers adapts a user relation loader so it only loads the relation of
+// users the caller may see it for: their own account, or any when they are
+// an admin or auth is disabled
+func ownedUsers(cfg config.AuthConfig, load func(ctx context.Context, parents []*users.User) ([]any, error)) expand.Loader {
+	return func(ctx context.Context, parents []expand.Expandable) ([]any, error) {
+		principal, _ := auth.FromContext(ctx)
+		all := !cfg.Enabled || (principal != nil && principal.HasRole(cfg.AdminRole))
+
+		var visible []*users.User
+		var index []int
+		for i, parent := range parents {
+			u, ok := parent.(*users.User)
+			if !ok || !(all || (principal != nil && u.OwnedBy(principal))) {
+				continue
+			}
+			visible = append(visible, u)
+			index = append(index, i)
+		}
+
+		related := make([]any, len(parents))
+		if len(visible) == 0 {
+			return related, nil
+		}
+		loaded, err := load(ctx, visible)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range index {
+			related[i] = loaded[j]
+		}
+		return related, nil
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"net/http"
+
+	"starterkit/internal/domains"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/geoip"
+	"starterkit/internal/platform/logger"
+)
+
+// geoMiddleware adds the client's country and region to the request
+// context, where request logs and audit entries pick them up. Unknown
+// locations leave the context unchanged.
+func (s *Server) geoMiddleware(next http.Handler) http.Handler {
+	if s.geo == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loc, ok := s.geo.Locate(r, s.clientIP(r))
+		if ok {
+			r = r.WithContext(geoip.WithContext(r.Context(), loc))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// geoBlockMiddleware refuses requests from locations the geo-blocking
+// policy excludes with 451 Unavailable For Legal Reasons. Health checks are
+// always served so load balancers in any region can probe the replica. It
+// runs after authentication, since the tenant whose rules apply is the
+// caller's.
+func (s *Server) geoBlockMiddleware(next http.Handler) http.Handler {
+	if s.geoPolicy == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if probePaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		loc, known := geoip.FromContext(r.Context())
+		reason := s.geoPolicy.Check(r.Context(), loc, known, geoTenant(r))
+		if reason == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		logger.FromContext(r.Context()).Warn("request blocked by geo policy", "reason", reason)
+		apierror.Write(w, r, apierror.New(http.StatusUnavailableForLegalReasons, geoBlockedCodes[reason],
+			"this service is not available in your location"))
+	})
+}
+
+// geoBlockedCodes maps the geo policy's reasons to the codes of 451
+// responses
+var geoBlockedCodes = map[string]string{
+	geoip.ReasonDenied:     "location_denied",
+	geoip.ReasonNotAllowed: "location_not_allowed",
+	geoip.ReasonUnknown:    "location_unknown",
+}
+
+// geoTenant returns the tenant whose geo-blocking rules apply to r: that of
+// the verified custom domain it was made on, or else the caller's. Tenants
+// are never taken from request headers, which clients could set to pick
+// the most permissive rules.
+func geoTenant(r *http.Request) string {
+	if d, ok := domains.FromContext(r.Context()); ok {
+		return d.TenantID
+	}
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return p.Tenant
+	}
+	return ""
+}
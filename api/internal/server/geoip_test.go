@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"starterkit/internal/config"
+	"starterkit/internal/domains"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/geoip"
+)
+
+func TestGeoBlockTenantIsNotTakenFromHeaders(t *testing.T) {
+	s := &Server{geoPolicy: geoip.NewPolicy(config.GeoBlockConfig{
+		Enabled: true,
+		Default: config.GeoBlockRules{Deny: []string{"CU"}},
+		Tenants: map[string]config.GeoBlockRules{"acme": {}},
+	})}
+	h := s.geoBlockMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, tc := range []struct {
+		name    string
+		request func(*http.Request) *http.Request
+		want    int
+	}{
+		{"header", func(r *http.Request) *http.Request {
+			r.Header.Set("X-Tenant-ID", "acme")
+			return r
+		}, http.StatusUnavailableForLegalReasons},
+		{"principal", func(r *http.Request) *http.Request {
+			return r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{Email: "ana@acme.example", Tenant: "acme"}))
+		}, http.StatusNoContent},
+		{"custom domain", func(r *http.Request) *http.Request {
+			return r.WithContext(domains.WithDomain(r.Context(), &domains.Domain{Domain: "app.acme.example", TenantID: "acme"}))
+		}, http.StatusNoContent},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			r = r.WithContext(geoip.WithContext(r.Context(), geoip.Location{Country: "CU"}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, tc.request(r))
+			if rec.Code != tc.want {
+				t.Errorf("status = %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+}
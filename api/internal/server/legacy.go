@@ -0,0 +1,54 @@
+package server
+
+import (
+	"time"
+
+	"starterkit/internal/platform/deprecation"
+	"starterkit/internal/platform/legacyapi"
+)
+
+// legacyUserFields are the legacy names of user fields
+var legacyUserFields = map[string]string{
+	"full_name": "name",
+	"created":   "created_at",
+	"updated":   "updated_at",
+}
+
+// legacyEndpoints are the endpoints of the legacy API still served, and the
+// current routes serving them. Legacy user IDs are numeric, and resolved
+// through the external IDs imported for idSystem.
+func legacyEndpoints(idSystem string) []legacyapi.Endpoint {
+	return []legacyapi.Endpoint{
+		{
+			Pattern: "GET /api/users",
+			Target:  "/api/v1/users",
+			Query:   map[string]string{"search": "q"},
+			Paged:   true,
+			Fields:  legacyUserFields,
+		},
+		{
+			Pattern: "GET /api/users/{id}",
+			Target:  "/api/v1/users/by-external-id/" + idSystem + "/{id}",
+			Fields:  legacyUserFields,
+		},
+		{
+			Pattern: "POST /api/users",
+			Target:  "/api/v1/users",
+			Fields:  legacyUserFields,
+		},
+	}
+}
+
+// legacyRoutes serves the legacy endpoints through mux. They are marked
+// deprecated, so api.deprecated.usage shows which clients still call each
+// one and when the shim can be retired.
+func (s *Server) legacyRoutes(mux *router) {
+	notice := deprecation.Notice{
+		Since:   deprecation.Date(2026, time.October, 15),
+		Sunset:  s.config.LegacyAPI.Sunset,
+		Message: "use the /api/v1 routes",
+	}
+	for _, e := range legacyEndpoints(s.config.LegacyAPI.IDSystem) {
+		s.register(mux, routeSpec{Pattern: e.Pattern, Handler: s.deprecated(e.Pattern, notice, legacyapi.Handler(e, mux))})
+	}
+}
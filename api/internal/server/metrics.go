@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"starterkit/internal/platform/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type routeKey struct{}
+
+// setRoute records the route pattern that matched the request, for
+// metricsMiddleware
+func setRoute(ctx context.Context, route string) {
+	if p, ok := ctx.Value(routeKey{}).(*string); ok {
+		*p = route
+	}
+}
+
+// knownMethods are recorded as is; other methods are recorded as _OTHER so
+// clients cannot create unbounded label values
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// metricsMiddleware records the count, duration, and response size of
+// requests by method, route pattern, and status, and the requests in
+// flight by method
+func (s *Server) metricsMiddleware(next http.Handler) http.Handler {
+	requests := metrics.Int64Counter(metrics.HTTPServerRequests)
+	duration := metrics.Float64Histogram(metrics.HTTPServerDuration)
+	active := metrics.Int64UpDownCounter(metrics.HTTPServerActiveRequests)
+	responseSize := metrics.Int64Histogram(metrics.HTTPServerResponseSize)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := r.Context()
+
+		method := r.Method
+		if !knownMethods[method] {
+			method = "_OTHER"
+		}
+		inFlight := metric.WithAttributes(attribute.String("http.request.method", method))
+		active.Add(ctx, 1, inFlight)
+		defer active.Add(ctx, -1, inFlight)
+
+		var route string
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r.WithContext(context.WithValue(ctx, routeKey{}, &route)))
+
+		attrs := []attribute.KeyValue{
+			attribute.String("http.request.method", method),
+			attribute.Int("http.response.status_code", wrapped.statusCode),
+		}
+		if route != "" {
+			attrs = append(attrs, attribute.String("http.route", route))
+		}
+		set := metric.WithAttributes(attrs...)
+		requests.Add(ctx, 1, set)
+		duration.Record(ctx, time.Since(start).Seconds(), set)
+		responseSize.Record(ctx, int64(wrapped.bytesWritten), set)
+	})
+}
@@ -3,63 +3,138 @@ package server
 import (
 	"context"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/deprecation"
+	"starterkit/internal/platform/fieldaccess"
+	"starterkit/internal/platform/geoip"
+	"starterkit/internal/platform/locale"
 	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/servertiming"
 
-	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/noop"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type contextKey string
 
 const (
-	requestIDKey contextKey = "request_id"
+	originalMethodKey contextKey = "original_method"
 )
 
-// applyMiddleware wraps the handler with all middleware
+// middleware is a named handler wrapper in the global chain
+type middleware struct {
+	name string
+	wrap func(http.Handler) http.Handler
+}
+
+// middlewareChain lists the global middleware, outermost first
+func (s *Server) middlewareChain() []middleware {
+	return []middleware{
+		{"serverTiming", s.serverTimingMiddleware},
+		{"tracing", s.tracingMiddleware},
+		{"tenant", s.tenantMiddleware},
+		{"cors", s.corsMiddleware},
+		{"requestID", s.requestIDMiddleware},
+		{"methodOverride", s.methodOverrideMiddleware},
+		{"geo", s.geoMiddleware},
+		{"metrics", s.metricsMiddleware},
+		{"logging", s.loggingMiddleware},
+		{"readOnly", s.readOnlyMiddleware},
+		{"locale", s.localeMiddleware},
+		{"recovery", s.recoveryMiddleware},
+		{"database", s.databaseMiddleware},
+		{"apiKey", s.apiKeyMiddleware},
+		{"auth", s.authMiddleware},
+		{"geoBlock", s.geoBlockMiddleware},
+		{"debugTrace", s.debugTraceMiddleware},
+		{"replay", s.replayMiddleware},
+		{"risk", s.riskMiddleware},
+		{"userAgent", s.userAgentMiddleware},
+		{"pathNormalization", s.pathNormalizationMiddleware},
+		{"deprecation", deprecation.Middleware},
+		{"fieldAccess", fieldaccess.Middleware(s.fields)},
+	}
+}
+
+// applyMiddleware wraps the handler with all middleware and records the
+// names of those that are enabled, outermost first
 func (s *Server) applyMiddleware(h http.Handler) http.Handler {
+	chain := s.middlewareChain()
+	s.middleware = nil
 	// Apply middleware in reverse order (innermost first)
-	h = s.recoveryMiddleware(h)
-	h = s.loggingMiddleware(h)
-	h = s.requestIDMiddleware(h)
-	h = s.corsMiddleware(h)
+	for i := len(chain) - 1; i >= 0; i-- {
+		wrapped := chain[i].wrap(h)
+		// Disabled middleware return the handler they were given
+		if !sameHandler(wrapped, h) {
+			s.middleware = append([]string{chain[i].name}, s.middleware...)
+		}
+		h = wrapped
+	}
 	return h
 }
 
-// corsMiddleware adds CORS headers to responses
-func (s *Server) corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Set CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-User-Email, X-Request-ID")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-
-		// Handle preflight requests
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
+// serverTimingMiddleware adds the Server-Timing header when enabled
+func (s *Server) serverTimingMiddleware(next http.Handler) http.Handler {
+	if !s.config.Server.Timing {
+		return next
+	}
+	return servertiming.Middleware(next)
+}
 
-		next.ServeHTTP(w, r)
-	})
+// tracingMiddleware adds OpenTelemetry spans when telemetry is enabled.
+// Request metrics come from metricsMiddleware, which labels them by route.
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+	if !s.config.Telemetry.Enabled {
+		return next
+	}
+	return otelhttp.NewHandler(next, "http-server", otelhttp.WithMeterProvider(noop.NewMeterProvider()))
+}
+
+// overridableMethods lists the methods a POST may be tunneled as
+var overridableMethods = map[string]bool{
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
 }
 
-// requestIDMiddleware adds a unique request ID to the context
-func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+// methodOverrideMiddleware lets clients behind proxies that block non-standard
+// methods tunnel PUT/PATCH/DELETE through POST via X-HTTP-Method-Override
+func (s *Server) methodOverrideMiddleware(next http.Handler) http.Handler {
+	if !s.config.Routing.MethodOverride {
+		return next
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestID := r.Header.Get("X-Request-ID")
-		if requestID == "" {
-			requestID = uuid.New().String()
+		override := strings.ToUpper(strings.TrimSpace(r.Header.Get("X-HTTP-Method-Override")))
+		if override == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !overridableMethods[override] {
+			apierror.Write(w, r, apierror.BadRequest("invalid_method_override", "unsupported method override"))
+			return
 		}
 
-		// Add to response header
-		w.Header().Set("X-Request-ID", requestID)
+		// Record the effective method on the server span
+		span := trace.SpanFromContext(r.Context())
+		span.SetAttributes(
+			attribute.String("http.request.method", override),
+			attribute.String("http.request.method_original", r.Method),
+		)
 
-		// Add to context
-		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		ctx := context.WithValue(r.Context(), originalMethodKey, r.Method)
+		r = r.WithContext(ctx)
+		r.Method = override
+		r.Header.Del("X-HTTP-Method-Override")
+
+		next.ServeHTTP(w, r)
 	})
 }
 
@@ -68,29 +143,23 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		// Get request ID from context
-		requestID, _ := r.Context().Value(requestIDKey).(string)
-
-		// Extract trace context if telemetry is enabled
-		var traceID, spanID string
-		if s.config.Telemetry.Enabled {
-			span := trace.SpanFromContext(r.Context())
-			if span.SpanContext().IsValid() {
-				traceID = span.SpanContext().TraceID().String()
-				spanID = span.SpanContext().SpanID().String()
-			}
-		}
-
-		// Create request-specific logger
+		// Create request-specific logger; the request and trace IDs come
+		// from the context it logs with
 		requestLogger := s.logger.With(
-			"request_id", requestID,
 			"method", r.Method,
 			"path", r.URL.Path,
 			"remote_addr", r.RemoteAddr,
+			"client_ip", s.clientIP(r).String(),
 		)
 
-		if traceID != "" {
-			requestLogger = requestLogger.With("trace_id", traceID, "span_id", spanID)
+		if originalMethod, ok := r.Context().Value(originalMethodKey).(string); ok {
+			requestLogger = requestLogger.With("original_method", originalMethod)
+		}
+		if loc, ok := geoip.FromContext(r.Context()); ok {
+			requestLogger = requestLogger.With("country", loc.Country)
+			if loc.Region != "" {
+				requestLogger = requestLogger.With("region", loc.Region)
+			}
 		}
 
 		// Add logger to context
@@ -103,7 +172,7 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(wrapped, r.WithContext(ctx))
 
 		// Log request completion
-		requestLogger.Info("request completed",
+		requestLogger.InfoContext(r.Context(), "request completed",
 			"status", wrapped.statusCode,
 			"duration", time.Since(start),
 			"bytes", wrapped.bytesWritten,
@@ -111,18 +180,140 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// recoveryMiddleware recovers from panics and returns 500
+// slashedPrefixes are subtrees served like directories, whose paths keep
+// their trailing slash. Swagger UI is served at /docs/ and loads its assets
+// relative to it.
+var slashedPrefixes = []string{"/docs/"}
+
+// pathNormalizationMiddleware rejects encoded traversal sequences, collapses
+// duplicate slashes, and redirects paths with a trailing slash to their
+// canonical form so stray slashes in client-built URLs don't 404. Paths
+// under slashedPrefixes are not redirected.
+func (s *Server) pathNormalizationMiddleware(next http.Handler) http.Handler {
+	cfg := s.config.Routing
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.RejectTraversal && hasTraversal(r.URL) {
+			apierror.Write(w, r, apierror.BadRequest("invalid_path", "invalid request path"))
+			return
+		}
+
+		path := r.URL.Path
+		if cfg.CollapseSlashes && strings.Contains(path, "//") {
+			path = collapseSlashes(path)
+			r.URL.Path = path
+			r.URL.RawPath = ""
+		}
+
+		if cfg.RedirectTrailingSlash && len(path) > 1 && strings.HasSuffix(path, "/") && !slashed(path) {
+			target := *r.URL
+			target.Path = strings.TrimRight(path, "/")
+			if target.Path == "" {
+				target.Path = "/"
+			}
+			target.RawPath = ""
+			// 308 preserves the method and body, unlike 301
+			http.Redirect(w, r, target.RequestURI(), http.StatusPermanentRedirect)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// slashed reports whether path is under one of slashedPrefixes
+func slashed(path string) bool {
+	for _, prefix := range slashedPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTraversal reports whether the path contains dot segments or encoded
+// separators that could be used to escape a route prefix
+func hasTraversal(u *url.URL) bool {
+	escaped := strings.ToLower(u.EscapedPath())
+	for _, seq := range []string{"%2f", "%5c", "%00"} {
+		if strings.Contains(escaped, seq) {
+			return true
+		}
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == ".." || segment == "." {
+			return true
+		}
+	}
+	return false
+}
+
+func collapseSlashes(path string) string {
+	var b strings.Builder
+	b.Grow(len(path))
+	prevSlash := false
+	for _, c := range path {
+		if c == '/' {
+			if prevSlash {
+				continue
+			}
+			prevSlash = true
+		} else {
+			prevSlash = false
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// localeMiddleware resolves the caller's locale, currency, and measurement
+// preferences into the request context for response formatting
+func (s *Server) localeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefs := s.localeResolver.Resolve(r)
+
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", prefs.Locale.String())
+
+		ctx := locale.WithContext(r.Context(), prefs)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// databaseMiddleware fails API requests fast with 503 while a lazily
+// connected database is unreachable. Health and readiness stay served.
+func (s *Server) databaseMiddleware(next http.Handler) http.Handler {
+	if s.dbMonitor == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.databaseAvailable() || !(strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, "/admin/")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(max(s.config.Database.ReconnectInterval.Seconds(), 1))))
+		apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, "database_unavailable", "database unavailable"))
+	})
+}
+
+func (s *Server) databaseAvailable() bool {
+	return s.dbMonitor == nil || s.dbMonitor.Available()
+}
+
+// recoveryMiddleware recovers from panics, reports them through the panic
+// guard, and returns 500
 func (s *Server) recoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				reqLogger := logger.FromContext(r.Context())
-				reqLogger.Error("panic recovered",
-					"error", err,
-					"stack", "stack trace would go here",
-				)
+				// Let net/http abort the response as the handler intended
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				s.guard.Handle(r.Context(), "http", err)
 
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				apierror.Write(w, r, apierror.Internal())
 			}
 		}()
 
@@ -148,10 +339,13 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController so
+// streaming handlers can flush and adjust deadlines
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // RequestIDFromContext extracts the request ID from context
 func RequestIDFromContext(ctx context.Context) string {
-	if id, ok := ctx.Value(requestIDKey).(string); ok {
-		return id
-	}
-	return ""
+	return logger.RequestID(ctx)
 }
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/locale"
+	"starterkit/internal/platform/panics"
+	"starterkit/internal/platform/useragent"
+)
+
+// testHandler returns every route and global middleware of a stand-in
+// server for the configuration of the environment, with the dependencies
+// the middleware always use
+func testHandler(t *testing.T) http.Handler {
+	t.Helper()
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := standIn(cfg)
+	if s.localeResolver, err = locale.NewResolver(cfg.Locale.SupportedLocales, cfg.Locale.DefaultCurrency, cfg.Locale.DefaultUnits, nil); err != nil {
+		t.Fatal(err)
+	}
+	if s.uaParser, err = useragent.New(cfg.Sessions.UserAgentParser); err != nil {
+		t.Fatal(err)
+	}
+	s.guard = panics.NewGuard(panics.LogReporter{})
+	return s.routes()
+}
+
+// serve sends a request through h
+func serve(t *testing.T, h http.Handler, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestDocsReachableWithTrailingSlashRedirects(t *testing.T) {
+	t.Setenv("ROUTING_REDIRECT_TRAILING_SLASH", "true")
+	h := testHandler(t)
+
+	target := "/docs"
+	for hops := 0; ; hops++ {
+		if hops > 3 {
+			t.Fatalf("still redirected after %d hops, at %s", hops, target)
+		}
+		rec := serve(t, h, http.MethodGet, target)
+		if rec.Code == http.StatusOK {
+			break
+		}
+		location := rec.Header().Get("Location")
+		if rec.Code < 300 || rec.Code >= 400 || location == "" {
+			t.Fatalf("GET %s = %d, want a redirect or 200: %s", target, rec.Code, rec.Body)
+		}
+		next, err := url.Parse(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		target = next.ResolveReference(&url.URL{Path: location}).Path
+	}
+	if target != "/docs/" {
+		t.Errorf("Swagger UI served at %s, want /docs/", target)
+	}
+
+	// Other paths are still redirected to drop the slash
+	rec := serve(t, h, http.MethodGet, "/healthz/")
+	if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != "/healthz" {
+		t.Errorf("GET /healthz/ = %d to %q, want 308 to /healthz", rec.Code, rec.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"starterkit/internal/config"
+)
+
+// Mock returns a handler serving every operation in the OpenAPI document
+// from its examples, with no database or other dependencies, for frontend
+// development against endpoints that are not finished. The document itself
+// and the Swagger UI are served as usual, and the CORS policies of cfg
+// apply, also allowing the Prefer header that picks a response.
+func Mock(cfg *config.Config, logger *slog.Logger) (http.Handler, error) {
+	doc, err := APIDocument(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OpenAPI document: %w", err)
+	}
+	mockCfg := *cfg
+	mockCfg.CORS.API.AllowedHeaders = append(slices.Clone(cfg.CORS.API.AllowedHeaders), "Prefer")
+	s := &Server{config: &mockCfg, logger: logger, apiDoc: doc}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/v1/openapi.json", s.handleAPIDocument())
+	mux.Handle("GET /docs/", docsHandler())
+	mux.Handle("/", doc.Mock())
+	return s.corsMiddleware(mux), nil
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"starterkit"
+	"starterkit/internal/announcements"
+	"starterkit/internal/auditexports"
+	"starterkit/internal/config"
+	"starterkit/internal/notifications"
+	"starterkit/internal/openapi"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/scopedtokens"
+	"starterkit/internal/users"
+
+	"github.com/swaggest/swgui/v5emb"
+)
+
+// APIDocument builds the OpenAPI document: the hand-written openapi.json
+// with the operations that feature packages describe in code, needing what
+// the routes of a server started with cfg declare, and taking the query
+// parameters of their list routes
+func APIDocument(cfg *config.Config) (*openapi.Document, error) {
+	api := apiRegistry()
+	for _, route := range declaredRoutes(cfg).Routes {
+		api.Secure(route.Method, route.Path, route.scopes, route.public)
+		if route.list != nil {
+			api.Parameters(route.Method, route.Path, route.list.Parameters()...)
+		}
+	}
+	return api.Build(starterkit.OpenAPI)
+}
+
+// apiRegistry collects the operations feature packages describe
+func apiRegistry() *openapi.Registry {
+	api := openapi.NewRegistry()
+	users.Describe(api)
+	announcements.Describe(api)
+	notifications.Describe(api)
+	scopedtokens.Describe(api)
+	auditexports.Describe(api)
+	return api
+}
+
+// handleAPIDocument serves the whole OpenAPI document. It is public, like
+// the Swagger UI that reads it.
+func (s *Server) handleAPIDocument() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.apiDoc == nil {
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(s.apiDoc.JSON()); err != nil {
+			s.logger.Error("failed to write OpenAPI document", "error", err)
+		}
+	}
+}
+
+// handleOpenAPI serves the OpenAPI document trimmed to the operations the
+// caller's token has the scopes for. With auth disabled every operation is
+// listed, as every route is reachable.
+func (s *Server) handleOpenAPI() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.apiDoc == nil {
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		granted := func(string) bool { return true }
+		if s.verifier != nil {
+			principal, _ := auth.FromContext(r.Context())
+			granted = func(scope string) bool {
+				return principal != nil && principal.HasScope(scope)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "private, no-cache")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(s.apiDoc.For(granted)); err != nil {
+			s.logger.Error("failed to encode OpenAPI document", "error", err)
+		}
+	}
+}
+
+// docsHandler serves Swagger UI for the OpenAPI document from assets built
+// into the binary
+func docsHandler() http.Handler {
+	return v5emb.New("Starterkit API", "/api/v1/openapi.json", "/docs/")
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientIP returns the caller's address. Forwarding headers are only
+// believed when the connection comes from a trusted proxy: X-Forwarded-For
+// is read from the right, skipping the trusted proxies that appended to it,
+// and X-Real-IP is used when there is no X-Forwarded-For.
+func (s *Server) clientIP(r *http.Request) netip.Addr {
+	peer := peerAddr(r)
+	if !s.trustedProxy(peer) {
+		return peer
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			client = addr.Unmap()
+			if !s.trustedProxy(client) {
+				break
+			}
+		}
+		return client
+	}
+
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap()
+	}
+	return peer
+}
+
+// peerAddr returns the address of the connection the request came over
+func peerAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, _ := netip.ParseAddr(host)
+	return peer.Unmap()
+}
+
+// trustedProxy reports whether addr is in TRUSTED_PROXIES
+func (s *Server) trustedProxy(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range s.config.Server.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
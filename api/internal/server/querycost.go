@@ -0,0 +1,49 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/querycost"
+)
+
+// costed charges what model estimates each request to h costs against the
+// caller's query budget, turning callers over budget away until it refills.
+// When QUERY_COST_ENFORCE is off they are only logged. Routes are unmetered
+// when budgets are off.
+func (s *Server) costed(model querycost.Model, h http.Handler) http.Handler {
+	if s.queryCosts == nil {
+		return h
+	}
+	return layer{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cost := model.Estimate(r.URL.Query())
+		remaining, reset, ok := s.queryCosts.Spend(s.costCaller(r), cost)
+		w.Header().Set("X-Query-Cost", strconv.Itoa(cost))
+		w.Header().Set("X-Query-Budget-Limit", strconv.Itoa(s.queryCosts.Limit()))
+		w.Header().Set("X-Query-Budget-Remaining", strconv.Itoa(remaining))
+
+		if !ok {
+			logger.FromContext(r.Context()).Warn("query cost budget exceeded", "cost", cost, "remaining", remaining, "enforced", s.config.QueryCost.Enforce)
+			if s.config.QueryCost.Enforce {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(reset).Seconds()))))
+				apierror.Write(w, r, apierror.TooManyRequests("query_cost_exceeded", "query cost budget exceeded; use smaller pages, fewer filters, or cursors instead of deep offsets"))
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	}), name: "cost", next: h}
+}
+
+// costCaller names who a request's cost is charged to: the token's subject,
+// or the client address of unauthenticated requests
+func (s *Server) costCaller(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok && principal.Subject != "" {
+		return "sub:" + principal.Subject
+	}
+	return "ip:" + s.clientIP(r).String()
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/logger"
+)
+
+// clientLimiter counts requests per client address in fixed windows.
+// Counts are kept in memory, so each replica enforces its share of the
+// limit on its own traffic.
+type clientLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	counts  map[string]int
+	started time.Time
+	// replicas returns how many replicas share the limit
+	replicas func() int
+}
+
+// allow records a request by client and reports whether it is within the
+// limit, and when the window resets
+func (l *clientLimiter) allow(client string) (bool, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.started) >= l.window {
+		clear(l.counts)
+		l.started = time.Now()
+	}
+	l.counts[client]++
+	n := max(l.replicas(), 1)
+	limit := max((l.limit+n-1)/n, 1)
+	return l.counts[client] <= limit, l.started.Add(l.window)
+}
+
+// rateLimited lets each client address make limit requests to h a window,
+// turning the rest away with 429. The limit is divided among the replicas
+// when peer awareness is on. Routes are unlimited when limit is 0.
+func (s *Server) rateLimited(limit int, window time.Duration, h http.Handler) http.Handler {
+	if limit <= 0 {
+		return h
+	}
+	l := &clientLimiter{limit: limit, window: window, counts: make(map[string]int), started: time.Now(), replicas: s.replicas}
+	return layer{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, reset := l.allow(s.clientIP(r).String()); !ok {
+			logger.FromContext(r.Context()).Warn("rate limit exceeded", "limit", limit)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(reset).Seconds()))))
+			apierror.Write(w, r, apierror.TooManyRequests("rate_limited", "too many requests; try again later"))
+			return
+		}
+		h.ServeHTTP(w, r)
+	}), name: "rate limit", next: h}
+}
+
+// replicas returns how many replicas share per-replica limits: the live
+// ones seen through heartbeats, or 1 when peer awareness is off
+func (s *Server) replicas() int {
+	if s.cluster == nil {
+		return 1
+	}
+	return s.cluster.Replicas()
+}
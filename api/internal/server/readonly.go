@@ -0,0 +1,29 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/readonly"
+)
+
+// readOnlyMiddleware answers mutating requests with 503 and the reason
+// while read-only mode is on; reads continue. The routes managing the mode
+// stay open so it can be turned off. Writes that get past this check, such
+// as those of background jobs, are refused by the write guard on queries.
+func (s *Server) readOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		mode := s.readOnly.Status()
+		if !mode.Enabled || strings.TrimSuffix(r.URL.Path, "/") == readonly.AdminPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+		apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, "read_only", mode.Reason))
+	})
+}
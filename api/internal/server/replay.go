@@ -0,0 +1,57 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"path"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/logger"
+)
+
+// replayMiddleware rejects state-changing requests that reuse the nonce of
+// an earlier request from the same caller. Safe methods, public routes such
+// as provider webhooks, and signed requests, whose signatures carry their
+// own nonce, are not checked. If the nonce cannot be recorded the request
+// is refused rather than risking a replay.
+func (s *Server) replayMiddleware(next http.Handler) http.Handler {
+	if s.replay == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.publicPath(path.Clean(r.URL.Path)) || (s.signatures != nil && auth.IsSigned(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Nonces are scoped to the verified caller, or to the claimed one
+		// while authentication is off
+		caller := r.Header.Get("X-User-Email")
+		if principal, ok := auth.FromContext(r.Context()); ok {
+			caller = principal.Subject
+		}
+
+		err := s.replay.Check(r.Context(), r, caller)
+		switch {
+		case err == nil:
+			next.ServeHTTP(w, r)
+		case errors.Is(err, auth.ErrNonceRequired):
+			apierror.Write(w, r, apierror.BadRequest("nonce_required", err.Error()))
+		case errors.Is(err, auth.ErrInvalidNonce):
+			apierror.Write(w, r, apierror.BadRequest("invalid_nonce", err.Error()))
+		case errors.Is(err, auth.ErrReplayed):
+			logger.FromContext(r.Context()).Warn("rejected replayed request", "caller", caller)
+			apierror.Write(w, r, apierror.Conflict("replayed", err.Error()))
+		default:
+			logger.FromContext(r.Context()).Error("failed to check request nonce", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+		}
+	})
+}
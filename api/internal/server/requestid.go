@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/ids"
+	"starterkit/internal/platform/logger"
+)
+
+// requestIDMiddleware adds the request's correlation ID to the context and
+// the response. The ID is taken from REQUEST_ID_HEADER when the caller is
+// trusted to send it and it is a plausible ID, and generated otherwise.
+func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+	cfg := s.config.RequestID
+	generate := ids.NewGenerator(cfg.Format)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requestID string
+		if s.trustRequestID(r) {
+			requestID = r.Header.Get(cfg.Header)
+			if !validRequestID(requestID, cfg.MaxLength) {
+				requestID = ""
+			}
+		}
+		if requestID == "" {
+			requestID = generate.String()
+		}
+
+		// Add to response header
+		w.Header().Set(cfg.ResponseHeader, requestID)
+
+		// Add to context
+		ctx := logger.WithRequestID(r.Context(), requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// trustRequestID reports whether the inbound request ID of r is believed
+func (s *Server) trustRequestID(r *http.Request) bool {
+	switch s.config.RequestID.Trust {
+	case config.RequestIDTrustAll:
+		return true
+	case config.RequestIDTrustProxies:
+		return s.trustedProxy(peerAddr(r))
+	}
+	return false
+}
+
+// validRequestID reports whether id is at most maxLength printable ASCII
+// characters without spaces, quotes, or backslashes, so it cannot forge
+// log lines or break out of quoted log fields
+func validRequestID(id string, maxLength int) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if c := id[i]; c <= ' ' || c > '~' || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+
+	"starterkit/internal/platform/respcache"
+)
+
+// checkResponseCache refuses cache rules that would hand one caller's
+// response to another: routes that authenticate their callers must vary
+// by user. Rules naming no registered route are only logged, as kill
+// switches are.
+func (s *Server) checkResponseCache() error {
+	rules := s.config.ResponseCache.Routes
+	known := make(map[string]bool, len(rules))
+	for _, route := range s.Routes().Routes {
+		rule, ok := rules[route.Pattern()]
+		if !ok {
+			continue
+		}
+		known[route.Pattern()] = true
+		if route.Auth != "none" && route.Auth != "public" && !slices.Contains(rule.Vary, respcache.VaryUser) {
+			return fmt.Errorf("response cache route %s authenticates callers, so it must vary by user", route.Pattern())
+		}
+	}
+	for route := range rules {
+		if !known[route] {
+			s.logger.Warn("response cache rule names no registered route", "route", route)
+		}
+	}
+	return nil
+}
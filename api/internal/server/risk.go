@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/risk"
+)
+
+// riskMiddleware scores API writes for abuse and rejects requests whose
+// score reaches the block threshold. Scoring failures never block traffic.
+func (s *Server) riskMiddleware(next http.Handler) http.Handler {
+	if !s.config.Risk.Enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isWrite(r.Method) || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := s.clientIP(r)
+		assessment, err := s.risk.AssessWrite(r.Context(), ip, r.Header.Get("X-User-Email"))
+		if errors.Is(err, risk.ErrRequestBlocked) {
+			logger.FromContext(r.Context()).Warn("request blocked by risk score",
+				"score", assessment.Score,
+				"signals", assessment.Signals,
+				"client_ip", ip.String(),
+			)
+			apierror.Write(w, r, apierror.New(http.StatusForbidden, "request_blocked", "request blocked"))
+			return
+		}
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("failed to assess request risk", "error", err)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+
+	"starterkit/internal/config"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/geoip"
+	"starterkit/internal/platform/listing"
+	"starterkit/internal/platform/module"
+	"starterkit/internal/platform/querycost"
+	"starterkit/internal/slo"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RouteTable lists the registered routes with the global middleware every
+// request passes through, outermost first
+type RouteTable struct {
+	Middleware []string `json:"middleware"`
+	Routes     []Route  `json:"routes"`
+}
+
+// Route is one registered route
+type Route struct {
+	// Method is empty for routes accepting every method
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Auth is "none" when no way of authenticating callers is configured,
+	// "public" for paths that skip authentication, the scope and role
+	// checks of the route, or "authenticated"
+	Auth string `json:"auth"`
+	// Middleware lists the route's own wrappers, outermost first, in
+	// addition to the global ones
+	Middleware []string `json:"middleware"`
+	Handler    string   `json:"handler"`
+
+	// public, scopes, and roles are the auth requirements behind Auth
+	public bool
+	scopes []string
+	roles  []string
+	// list declares the query parameters of list routes
+	list *listing.Spec
+}
+
+// Routes lists the routes of s in registration order
+func (s *Server) Routes() RouteTable {
+	table := RouteTable{Middleware: s.middleware, Routes: []Route{}}
+	s.listRoutes(&table, s.router, nil)
+	return table
+}
+
+func (s *Server) listRoutes(table *RouteTable, rt *router, outer []layer) {
+	for _, e := range rt.entries {
+		layers := slices.Clone(outer)
+		h := e.handler
+		for {
+			l, ok := h.(layer)
+			if !ok {
+				break
+			}
+			layers = append(layers, l)
+			h = l.next
+		}
+		if e.child != nil {
+			s.listRoutes(table, e.child, layers)
+			continue
+		}
+
+		method, path, ok := strings.Cut(e.pattern, " ")
+		if !ok {
+			method, path = "", e.pattern
+		}
+		path = rt.prefix + path
+		route := Route{Method: method, Path: path, Auth: s.routeAuth(path, layers), Middleware: []string{}, Handler: handlerName(h)}
+		route.public = s.publicPath(path)
+		if _, cached := s.config.ResponseCache.Routes[route.Pattern()]; cached {
+			// The cache answers before any of the route's own wrappers
+			route.Middleware = append(route.Middleware, "cache")
+		}
+		for _, l := range layers {
+			route.Middleware = append(route.Middleware, l.name)
+			if l.scope != "" {
+				route.scopes = append(route.scopes, l.scope)
+			}
+			if l.role != "" {
+				route.roles = append(route.roles, l.role)
+			}
+			if l.list != nil {
+				route.list = l.list
+			}
+		}
+		table.Routes = append(table.Routes, route)
+	}
+}
+
+// Patterns returns the patterns the routes were registered with, as kill
+// switches name them
+func (t RouteTable) Patterns() []string {
+	patterns := make([]string, 0, len(t.Routes))
+	for _, route := range t.Routes {
+		patterns = append(patterns, route.Pattern())
+	}
+	return patterns
+}
+
+// Pattern returns the pattern the route was registered with
+func (r Route) Pattern() string {
+	if r.Method == "" {
+		return r.Path
+	}
+	return r.Method + " " + r.Path
+}
+
+// routeAuth describes who may call path through layers
+func (s *Server) routeAuth(path string, layers []layer) string {
+	if !slices.Contains(s.middleware, "auth") {
+		return "none"
+	}
+	if s.publicPath(path) {
+		return "public"
+	}
+	var checks []string
+	for _, l := range layers {
+		if l.auth {
+			checks = append(checks, l.name)
+		}
+	}
+	if len(checks) == 0 {
+		return "authenticated"
+	}
+	return strings.Join(checks, ", ")
+}
+
+// handlerName names the function behind h, such as
+// users.(*Handler).HandleGetUser, or its type
+func handlerName(h http.Handler) string {
+	f, ok := h.(http.HandlerFunc)
+	if !ok {
+		return strings.TrimPrefix(fmt.Sprintf("%T", h), "*")
+	}
+	name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	// Handlers are mostly closures returned by a method, named
+	// pkg.(*Handler).HandleX.func1
+	for {
+		base, suffix, ok := cutLast(name, ".")
+		if !ok || !strings.HasPrefix(suffix, "func") {
+			break
+		}
+		name = base
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// sameHandler reports whether a and b are the same handler, as when a
+// disabled middleware returns the handler it was given
+func sameHandler(a, b http.Handler) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		return false
+	}
+	switch va.Kind() {
+	case reflect.Func, reflect.Pointer:
+		return va.Pointer() == vb.Pointer()
+	}
+	return false
+}
+
+// ListRoutes returns the routes a server started with cfg registers,
+// without connecting to its dependencies. Handlers are registered
+// unconstructed, which is enough to list them but not to serve.
+func ListRoutes(cfg *config.Config) RouteTable {
+	s := standIn(cfg)
+	s.routes()
+	return s.Routes()
+}
+
+// standIn returns a server for cfg whose handlers are registered
+// unconstructed and whose dependencies are stand-ins, enough to build its
+// routes and middleware without connecting to anything
+func standIn(cfg *config.Config) *Server {
+	s := &Server{
+		config: cfg,
+		logger: slog.New(slog.DiscardHandler),
+		slo:    slo.NewTracker(cfg.SLO.Window),
+	}
+	s.configureAuth(nonceStore{})
+	// Modules are not initialized, so their handlers are as unconstructed
+	// as the server's
+	s.modules = module.All()
+
+	// Stand-ins for the dependencies that decide whether a middleware or
+	// route is enabled
+	if slices.Contains(cfg.Telemetry.MetricsExporters, "prometheus") {
+		s.metricsHandler = promhttp.HandlerFor(prometheus.NewRegistry(), promhttp.HandlerOpts{})
+	}
+	if cfg.Database.LazyConnect {
+		s.dbMonitor = &database.Monitor{}
+	}
+	if cfg.GeoIP.Enabled {
+		s.geo = &geoip.Resolver{}
+	}
+	if cfg.GeoBlock.Enabled {
+		s.geoPolicy = &geoip.Policy{}
+	}
+	if cfg.QueryCost.Budget > 0 {
+		s.queryCosts = querycost.NewBudget(cfg.QueryCost.Budget)
+	}
+	return s
+}
+
+// declaredRoutes lists the routes of a server started with cfg with their
+// requirements as declared with auth on, whether or not cfg enables it
+func declaredRoutes(cfg *config.Config) RouteTable {
+	declared := *cfg
+	declared.Auth.Enabled = true
+	return ListRoutes(&declared)
+}
+
+// handleRoutes serves the route table, as server routes prints it
+func (s *Server) handleRoutes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(s.Routes()); err != nil {
+			s.logger.Error("failed to encode routes", "error", err)
+		}
+	}
+}
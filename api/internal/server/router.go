@@ -0,0 +1,237 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"starterkit/internal/killswitch"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/delta"
+	"starterkit/internal/platform/dryrun"
+	"starterkit/internal/platform/listing"
+	"starterkit/internal/platform/respcache"
+	"starterkit/internal/platform/servertiming"
+)
+
+// router wraps http.ServeMux and remembers which methods are registered for
+// each path so unmatched requests get a problem+json 404/405 with an Allow
+// header and
+// OPTIONS requests can be answered for API discovery. Paths registered with
+// their own OPTIONS route, such as for tus discovery, are answered by it.
+type router struct {
+	mux     *http.ServeMux
+	paths   *http.ServeMux
+	methods map[string][]string
+	// prefix is the path the router is mounted at, stripped from requests
+	// before they reach it
+	prefix string
+	// entries are the registrations in order, for route listings
+	entries []entry
+	// killSwitches turns off single routes; mounted routers share their
+	// parent's
+	killSwitches *killswitch.Service
+	// cache answers the read routes configured for response caching;
+	// mounted routers share their parent's
+	cache *respcache.Cache
+	// deltas answers polls of list routes with patches against the version
+	// the client has; mounted routers share their parent's
+	deltas *delta.Encoder
+}
+
+// entry is one registration; child is set for mounted routers
+type entry struct {
+	pattern string
+	handler http.Handler
+	child   *router
+}
+
+// layer is a route-specific wrapper around next, such as a scope check,
+// named so route listings can show what a route passes through
+type layer struct {
+	http.Handler
+	name string
+	next http.Handler
+	// auth is set for layers that restrict who may call the route, to
+	// callers granted scope or holding role
+	auth  bool
+	scope string
+	role  string
+	// list is set for layers reading the query of list routes
+	list *listing.Spec
+}
+
+func newRouter(prefix string) *router {
+	return &router{
+		mux:     http.NewServeMux(),
+		paths:   http.NewServeMux(),
+		methods: make(map[string][]string),
+		prefix:  prefix,
+	}
+}
+
+// HandleFunc registers a handler for a "METHOD /path" pattern
+func (rt *router) HandleFunc(pattern string, handler http.HandlerFunc) {
+	rt.Handle(pattern, handler)
+}
+
+// Handle registers a handler for a "METHOD /path" or "/path" pattern
+func (rt *router) Handle(pattern string, handler http.Handler) {
+	rt.handle(pattern, rt.switchable(pattern, handler), handler)
+}
+
+// handle registers served for pattern, listed as handler
+func (rt *router) handle(pattern string, served, handler http.Handler) {
+	rt.mux.Handle(pattern, served)
+	rt.entries = append(rt.entries, entry{pattern: pattern, handler: handler})
+
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		// Method-less patterns accept every method
+		path, method = pattern, ""
+	}
+
+	if _, seen := rt.methods[path]; !seen {
+		// The path-only mux is used to find the registered pattern for a
+		// request regardless of its method
+		rt.paths.Handle(path, http.NotFoundHandler())
+	}
+	if method != "" {
+		rt.methods[path] = append(rt.methods[path], method)
+	} else if rt.methods[path] == nil {
+		rt.methods[path] = []string{}
+	}
+}
+
+// Mount serves child under its prefix through handler, which strips the
+// prefix and may wrap child in layers applying to all its routes
+func (rt *router) Mount(child *router, handler http.Handler) {
+	rt.handle(child.prefix+"/", handler, handler)
+	rt.entries[len(rt.entries)-1].child = child
+	child.killSwitches = rt.killSwitches
+	child.cache = rt.cache
+	child.deltas = rt.deltas
+}
+
+// switchable answers requests to the route registered with pattern with
+// 503 and the reason while a kill switch turns it off. Dry runs of routes
+// that do not support them are turned away before they can write anything.
+// Narrowed tokens are turned away from routes that need no scope, as they
+// grant only what their scopes name. GET requests to routes configured for
+// response caching are answered through the cache, and those to list routes
+// may be answered with a patch.
+func (rt *router) switchable(pattern string, handler http.Handler) http.Handler {
+	route := rt.prefix + pattern
+	if method, path, ok := strings.Cut(pattern, " "); ok {
+		route = method + " " + rt.prefix + path
+	}
+	dryRunnable := supportsDryRun(handler)
+	list := isList(handler)
+	scoped := needsScope(handler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rt.killSwitches != nil {
+			if sw, off := rt.killSwitches.Check(route); off {
+				apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, "endpoint_disabled", sw.Reason))
+				return
+			}
+		}
+		if !scoped {
+			if principal, ok := auth.FromContext(r.Context()); ok && principal.Narrowed {
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+				apierror.Write(w, r, apierror.New(http.StatusForbidden, "narrowed_token", route+" needs no scope, so narrowed tokens cannot reach it"))
+				return
+			}
+		}
+		if !dryRunnable {
+			if on, err := dryrun.Requested(r); on || err != nil {
+				apierror.Write(w, r, apierror.BadRequest("dry_run_unsupported", route+" does not support dry runs"))
+				return
+			}
+		}
+		serve := handler
+		if rt.cache != nil && r.Method == http.MethodGet {
+			if rule, ok := rt.cache.Rule(route); ok {
+				serve = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					rt.cache.Serve(w, r, route, rule, handler)
+				})
+			}
+		}
+		if rt.deltas != nil && list && r.Method == http.MethodGet {
+			rt.deltas.Serve(w, r, serve)
+			return
+		}
+		serve.ServeHTTP(w, r)
+	})
+}
+
+// isList reports whether h reads the query of a list route
+func isList(h http.Handler) bool {
+	for {
+		l, ok := h.(layer)
+		if !ok {
+			return false
+		}
+		if l.list != nil {
+			return true
+		}
+		h = l.next
+	}
+}
+
+// needsScope reports whether h checks the caller's scopes
+func needsScope(h http.Handler) bool {
+	for {
+		l, ok := h.(layer)
+		if !ok {
+			return false
+		}
+		if l.scope != "" {
+			return true
+		}
+		h = l.next
+	}
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler, pattern := rt.mux.Handler(r)
+	if pattern != "" && (r.Method != http.MethodOptions || strings.HasPrefix(pattern, http.MethodOptions+" ")) {
+		// Mounted routers record their own, longer route
+		_, path, _ := strings.Cut(pattern, " ")
+		setRoute(r.Context(), rt.prefix+path)
+		servertiming.Handler(r.Context())
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	_, pathPattern := rt.paths.Handler(r)
+	methods, known := rt.methods[pathPattern]
+	if pathPattern == "" || !known {
+		apierror.Write(w, r, apierror.NotFound("not_found", "no route matches "+rt.prefix+r.URL.Path))
+		return
+	}
+
+	// Method-less registrations handle everything, including OPTIONS
+	if len(methods) == 0 && pattern != "" {
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Allow", allowHeader(methods))
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	apierror.Write(w, r, apierror.New(http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed on "+rt.prefix+r.URL.Path))
+}
+
+// allowHeader lists the registered methods plus the ones net/http implies
+func allowHeader(methods []string) string {
+	allow := slices.Clone(methods)
+	if slices.Contains(allow, http.MethodGet) && !slices.Contains(allow, http.MethodHead) {
+		allow = append(allow, http.MethodHead)
+	}
+	allow = append(allow, http.MethodOptions)
+	slices.Sort(allow)
+	return strings.Join(slices.Compact(allow), ", ")
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"starterkit/internal/uploads"
+)
+
+// tusService is enough of the upload service for tus discovery
+type tusService struct {
+	uploads.ServiceInterface
+}
+
+func (tusService) ResumableMaxSize() int64 { return 1 << 30 }
+
+func TestRouterDispatchesRegisteredOptions(t *testing.T) {
+	handler := uploads.NewHandler(tusService{}, 0, 0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	root := newRouter("")
+	v1 := newRouter("/api/v1")
+	v1.Handle("OPTIONS /resumable-uploads", handler.HandleTusOptions())
+	v1.Handle("POST /resumable-uploads", ok)
+	v1.Handle("GET /uploads/{id}", ok)
+	root.Mount(v1, http.StripPrefix("/api/v1", v1))
+
+	rec := httptest.NewRecorder()
+	root.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodOptions, "/api/v1/resumable-uploads", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("OPTIONS /resumable-uploads = %d, want 204", rec.Code)
+	}
+	for header, want := range map[string]string{
+		"Tus-Resumable": "1.0.0",
+		"Tus-Version":   "1.0.0",
+		"Tus-Extension": "creation,expiration,termination",
+		"Tus-Max-Size":  "1073741824",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	// Paths without their own OPTIONS route are still answered by the router
+	rec = httptest.NewRecorder()
+	root.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodOptions, "/api/v1/uploads/123", nil))
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("OPTIONS /uploads/123 = %d with Allow %q, want 204 with GET, HEAD, OPTIONS", rec.Code, rec.Header().Get("Allow"))
+	}
+}
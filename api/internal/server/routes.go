@@ -1,35 +1,441 @@
 package server
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"starterkit/internal/announcements"
+	"starterkit/internal/config"
+	"starterkit/internal/moderation"
+	"starterkit/internal/notifications"
+	"starterkit/internal/platform/deprecation"
+	"starterkit/internal/platform/module"
+	"starterkit/internal/risk"
+	"starterkit/internal/search"
+	"starterkit/internal/slo"
+	"starterkit/internal/users"
 )
 
-// routes sets up all application routes
+// probeAliasDeprecation is the notice of an old probe path replaced by path
+func probeAliasDeprecation(path string) deprecation.Notice {
+	return deprecation.Notice{
+		Since:   deprecation.Date(2026, time.October, 15),
+		Message: "use " + path,
+	}
+}
+
+// track reports h's requests against objective under name, as listed at
+// GET /admin/slo
+func (s *Server) track(name string, objective slo.Objective, h http.HandlerFunc) http.Handler {
+	label := fmt.Sprintf("slo %g%% %s", objective.Availability*100, objective.Latency)
+	return layer{Handler: s.slo.Track(name, objective, h), name: label, next: h}
+}
+
+// deprecated marks h, the handler of surface, deprecated
+func (s *Server) deprecated(surface string, n deprecation.Notice, h http.Handler) http.Handler {
+	return layer{Handler: deprecation.Route(surface, n, h), name: "deprecated", next: h}
+}
+
+// routes sets up all application routes. Each is declared with what a
+// caller needs to reach it: Access, Scopes, and a Rate class, which
+// register turns into the checks wrapping its handler. List routes declare
+// their page size, filters, and sort orders, read from the query string
+// before their handler runs.
 func (s *Server) routes() http.Handler {
-	mux := http.NewServeMux()
+	mux := newRouter("")
+	mux.killSwitches = s.killSwitches
+	mux.cache = s.responseCache
+	mux.deltas = s.deltas
+
+	s.register(mux,
+		// Liveness and readiness probes; /health and /ready are the older
+		// names
+		routeSpec{Pattern: "GET /healthz", Access: accessNone, Handler: s.handleHealthCheck()},
+		routeSpec{Pattern: "GET /readyz", Access: accessNone, Handler: s.handleReadiness()},
+		routeSpec{Pattern: "GET /health", Access: accessNone, Handler: s.deprecated("GET /health", probeAliasDeprecation("/healthz"), s.handleHealthCheck())},
+		routeSpec{Pattern: "GET /ready", Access: accessNone, Handler: s.deprecated("GET /ready", probeAliasDeprecation("/readyz"), s.handleReadiness())},
+	)
+
+	// Prometheus scrape endpoint, when that metrics exporter is enabled
+	if s.metricsHandler != nil {
+		s.register(mux, routeSpec{Pattern: "GET /metrics", Access: accessNone, Handler: s.metricsHandler})
+	}
+
+	s.register(mux,
+		// API reference for humans
+		routeSpec{Pattern: "GET /docs/", Access: accessNone, Handler: docsHandler()},
+		routeSpec{Pattern: "GET /docs", Access: accessNone, Handler: http.RedirectHandler("/docs/", http.StatusMovedPermanently)},
+
+		// Atom feed of public announcements
+		routeSpec{Pattern: "GET " + announcements.FeedPath, Access: accessNone, Handler: s.announcementHandler.HandleFeed()},
+
+		// Crawler directives and the sitemap of public pages
+		routeSpec{Pattern: "GET /robots.txt", Access: accessNone, Handler: s.sitemapHandler.HandleRobots()},
+		routeSpec{Pattern: "GET /sitemap.xml", Access: accessNone, Handler: s.sitemapHandler.HandleIndex()},
+		routeSpec{Pattern: "GET /sitemaps/{file}", Access: accessNone, Handler: s.sitemapHandler.HandlePage()},
+
+		// Bounce and complaint webhooks from the email provider, which
+		// authenticate themselves
+		routeSpec{Pattern: "POST /webhooks/email/{provider}", Access: accessNone, Handler: s.emailHandler.HandleWebhook()},
+	)
+
+	// Passwordless sign-in through emailed links. Routes of the sign-in
+	// rate class answer no faster than AUTH_RESPONSE_FLOOR and slow down
+	// clients that keep failing.
+	if s.config.MagicLinks.Enabled {
+		s.register(mux,
+			routeSpec{Pattern: "POST /auth/magic-link", Access: accessNone, Rate: rateSignIn, Handler: s.magicLinkHandler.HandleRequestLink()},
+			routeSpec{Pattern: "POST /auth/magic-link/callback", Access: accessNone, Rate: rateSignIn, Handler: s.magicLinkHandler.HandleSignIn()},
+		)
+	}
+
+	// Passkey ceremonies; registering one takes a signed-in user
+	if s.config.WebAuthn.Enabled {
+		s.register(mux,
+			routeSpec{Pattern: "POST /auth/webauthn/register/options", Handler: s.passkeyHandler.HandleRegistrationOptions()},
+			routeSpec{Pattern: "POST /auth/webauthn/register", Handler: s.passkeyHandler.HandleRegister()},
+			routeSpec{Pattern: "POST /auth/webauthn/login/options", Access: accessNone, Rate: rateSignIn, Handler: s.passkeyHandler.HandleLoginOptions()},
+			routeSpec{Pattern: "POST /auth/webauthn/login", Access: accessNone, Rate: rateSignIn, Handler: s.passkeyHandler.HandleLogin()},
+		)
+	}
+
+	s.register(mux,
+		// Links emailed to both addresses of an email change
+		routeSpec{Pattern: "POST /auth/email-change/confirm", Access: accessNone, Rate: rateSignIn, Handler: s.userHandler.HandleConfirmEmailChange()},
 
-	// Health check endpoint
-	mux.HandleFunc("GET /health", s.handleHealthCheck())
+		// Sign-up forms check an email or handle before submitting. The
+		// answer never says whether it is in use; admins get that from
+		// POST /admin/users/availability.
+		routeSpec{Pattern: "POST /auth/availability", Access: accessNone, Rate: rateAvailability, Handler: s.userHandler.HandleCheckAvailability()},
 
-	// API v1 routes
-	v1Mux := http.NewServeMux()
+		// "This wasn't me" links of login alerts
+		routeSpec{Pattern: "GET /security/revoke", Access: accessNone, Handler: s.sessionHandler.HandleRevokePage()},
+		routeSpec{Pattern: "POST /security/revoke", Access: accessNone, Rate: rateSignIn, Handler: s.sessionHandler.HandleRevoke()},
+	)
 
-	// User endpoints
-	v1Mux.HandleFunc("GET /users", s.userHandler.HandleListUsers())
-	v1Mux.HandleFunc("GET /users/{id}", s.userHandler.HandleGetUser())
+	// API v1 routes. Routes wrapped in s.track declare an objective and
+	// report their error budget at GET /admin/slo. Routes wrapped in
+	// s.costed charge their estimated database cost to the caller's query
+	// budget. Routes wrapped in s.dryRunnable accept ?dry_run=true; other
+	// routes turn dry runs away.
+	v1Mux := newRouter("/api/v1")
+
+	s.register(v1Mux,
+		// User endpoints
+		routeSpec{Pattern: "GET /users", Scopes: []string{"users:read"}, Handler: s.costed(users.ListCost, s.track("GET /api/v1/users", slo.Interactive, s.userHandler.HandleListUsers()))},
+		routeSpec{Pattern: "GET /users/changes", Scopes: []string{"users:read"}, Handler: s.costed(users.ChangesCost, s.userHandler.HandleListChanges())},
+		routeSpec{Pattern: "GET /users/handle-availability", Scopes: []string{"users:read"}, Handler: s.userHandler.HandleCheckHandle()},
+		routeSpec{Pattern: "GET /users/{id}", Scopes: []string{"users:read"}, Handler: s.track("GET /api/v1/users/{id}", slo.Interactive, s.userHandler.HandleGetUser())},
+		routeSpec{Pattern: "POST /users", Scopes: []string{"users:write"}, Handler: s.dryRunnable(s.track("POST /api/v1/users", slo.Interactive, s.userHandler.HandleCreateUser()))},
+		routeSpec{Pattern: "PUT /users/{id}", Scopes: []string{"users:write"}, Handler: s.dryRunnable(s.track("PUT /api/v1/users/{id}", slo.Interactive, s.userHandler.HandleUpdateUser()))},
+		routeSpec{Pattern: "DELETE /users/{id}", Scopes: []string{"users:write"}, Handler: s.dryRunnable(s.userHandler.HandleDeleteUser())},
+		routeSpec{Pattern: "PATCH /users/{id}/profile", Scopes: []string{"users:write"}, Handler: s.track("PATCH /api/v1/users/{id}/profile", slo.Interactive, s.userHandler.HandleUpdateProfile())},
+		routeSpec{Pattern: "GET /users/{id}/handle", Scopes: []string{"users:read"}, Handler: s.userHandler.HandleGetHandle()},
+		routeSpec{Pattern: "PUT /users/{id}/handle", Scopes: []string{"users:write"}, Handler: s.userHandler.HandleSetHandle()},
+		routeSpec{Pattern: "GET /users/{id}/email-change", Scopes: []string{"users:read"}, Handler: s.userHandler.HandleGetEmailChange()},
+		routeSpec{Pattern: "POST /users/{id}/email-change", Scopes: []string{"users:write"}, Handler: s.userHandler.HandleRequestEmailChange()},
+		routeSpec{Pattern: "DELETE /users/{id}/email-change", Scopes: []string{"users:write"}, Handler: s.userHandler.HandleCancelEmailChange()},
+		routeSpec{Pattern: "PUT /users/{id}/phone", Scopes: []string{"users:write"}, Handler: s.userHandler.HandleSetPhone()},
+		routeSpec{Pattern: "POST /users/{id}/phone/verification", Scopes: []string{"users:write"}, Handler: s.userHandler.HandleStartPhoneVerification()},
+		routeSpec{Pattern: "POST /users/{id}/phone/verification/confirm", Scopes: []string{"users:write"}, Handler: s.userHandler.HandleConfirmPhoneVerification()},
+
+		// Notification channel endpoints
+		routeSpec{Pattern: "GET /push/vapid-public-key", Handler: s.notificationHandler.HandleVAPIDPublicKey()},
+		routeSpec{Pattern: "GET /users/{id}/push-subscriptions", Handler: s.notificationHandler.HandleListSubscriptions()},
+		routeSpec{Pattern: "POST /users/{id}/push-subscriptions", Handler: s.notificationHandler.HandleSubscribe()},
+		routeSpec{Pattern: "DELETE /users/{id}/push-subscriptions/{subscriptionId}", Handler: s.notificationHandler.HandleUnsubscribe()},
+		routeSpec{Pattern: "GET /users/{id}/notification-preferences", Handler: s.notificationHandler.HandleGetPreferences()},
+		routeSpec{Pattern: "PUT /users/{id}/notification-preferences", Handler: s.notificationHandler.HandleUpdatePreferences()},
+		routeSpec{Pattern: "GET /users/{id}/notifications", List: &notifications.InboxList, Handler: s.notificationHandler.HandleListInbox()},
+		routeSpec{Pattern: "POST /users/{id}/notifications/sync", Handler: s.notificationHandler.HandleSyncInbox()},
+		routeSpec{Pattern: "GET /users/{id}/notifications/events", Handler: s.notificationHandler.HandleInboxEvents()},
+
+		// Upload endpoints
+		routeSpec{Pattern: "POST /uploads", Handler: s.track("POST /api/v1/uploads", slo.Bulk, s.uploadHandler.HandleCreate())},
+		routeSpec{Pattern: "GET /uploads/{id}", Handler: s.track("GET /api/v1/uploads/{id}", slo.Interactive, s.uploadHandler.HandleGet())},
+		routeSpec{Pattern: "GET /uploads/{id}/content", Handler: s.uploadHandler.HandleDownload()},
+
+		// Resumable uploads over the tus protocol; a complete one becomes
+		// the upload with the same ID
+		routeSpec{Pattern: "OPTIONS /resumable-uploads", Handler: s.uploadHandler.HandleTusOptions()},
+		routeSpec{Pattern: "POST /resumable-uploads", Handler: s.uploadHandler.HandleTusCreate()},
+		routeSpec{Pattern: "HEAD /resumable-uploads/{id}", Handler: s.uploadHandler.HandleTusHead()},
+		routeSpec{Pattern: "PATCH /resumable-uploads/{id}", Handler: s.track("PATCH /api/v1/resumable-uploads/{id}", slo.Bulk, s.uploadHandler.HandleTusPatch())},
+		routeSpec{Pattern: "DELETE /resumable-uploads/{id}", Handler: s.uploadHandler.HandleTusDelete()},
+
+		// Semantic search over user profiles
+		routeSpec{Pattern: "GET /search/semantic", Handler: s.costed(search.SemanticCost, s.searchHandler.HandleSemantic())},
+
+		// Devices the caller has used
+		routeSpec{Pattern: "GET /sessions", Handler: s.sessionHandler.HandleListMine()},
+		routeSpec{Pattern: "GET /me/security-events", Handler: s.sessionHandler.HandleListSecurityEvents()},
+	)
+	if s.config.MagicLinks.Enabled || s.config.WebAuthn.Enabled {
+		s.register(v1Mux, routeSpec{Pattern: "DELETE /auth/session", Handler: s.magicLinkHandler.HandleSignOut()})
+	}
+	if s.config.Auth.NarrowingSecret != "" {
+		s.register(v1Mux, routeSpec{Pattern: "POST /auth/token/narrow", Handler: s.scopedTokenHandler.HandleNarrow()})
+	}
+	if s.config.Accounts.LinkingEnabled {
+		s.register(v1Mux,
+			routeSpec{Pattern: "GET /me/identities", Handler: s.accountHandler.HandleListIdentities()},
+			routeSpec{Pattern: "POST /me/identities", Handler: s.accountHandler.HandleLink()},
+			routeSpec{Pattern: "DELETE /me/identities/{id}", Handler: s.accountHandler.HandleUnlink()},
+		)
+	}
+	if s.config.WebAuthn.Enabled {
+		s.register(v1Mux,
+			routeSpec{Pattern: "GET /me/passkeys", Handler: s.passkeyHandler.HandleList()},
+			routeSpec{Pattern: "PATCH /me/passkeys/{id}", Handler: s.passkeyHandler.HandleRename()},
+			routeSpec{Pattern: "DELETE /me/passkeys/{id}", Handler: s.passkeyHandler.HandleDelete()},
+		)
+	}
+	// Audit exports of the caller's organization, for its admins
+	if s.config.AuditExports.Enabled {
+		s.register(v1Mux,
+			routeSpec{Pattern: "GET /audit-exports", Access: accessOrgAdmin, Handler: s.auditExportHandler.HandleList()},
+			routeSpec{Pattern: "POST /audit-exports", Access: accessOrgAdmin, Handler: s.auditExportHandler.HandleCreate()},
+			routeSpec{Pattern: "GET /audit-exports/{id}", Access: accessOrgAdmin, Handler: s.auditExportHandler.HandleGet()},
+			routeSpec{Pattern: "PATCH /audit-exports/{id}", Access: accessOrgAdmin, Handler: s.auditExportHandler.HandleUpdate()},
+			routeSpec{Pattern: "DELETE /audit-exports/{id}", Access: accessOrgAdmin, Handler: s.auditExportHandler.HandleDelete()},
+			routeSpec{Pattern: "POST /audit-exports/{id}/run", Access: accessOrgAdmin, Handler: s.auditExportHandler.HandleRun()},
+			routeSpec{Pattern: "GET /audit-exports/{id}/deliveries", Access: accessOrgAdmin, Handler: s.auditExportHandler.HandleDeliveries()},
+		)
+	}
+
+	s.register(v1Mux,
+		// Offline sync endpoint
+		routeSpec{Pattern: "GET /sync", Handler: s.track("GET /api/v1/sync", slo.Bulk, s.syncHandler.HandleSync())},
+
+		// Operation receipts for async mutations; staged deletions can be
+		// undone until their undo_until
+		routeSpec{Pattern: "GET /operations/events", Handler: s.operationHandler.HandleEvents()},
+		routeSpec{Pattern: "GET /operations/{id}", Handler: s.operationHandler.HandleGetOperation()},
+		routeSpec{Pattern: "POST /operations/{id}/undo", Handler: s.operationHandler.HandleUndo()},
+
+		// In-product assistant
+		routeSpec{Pattern: "POST /assist", Handler: s.assistHandler.HandleAssist()},
+
+		// OpenAPI document, generated at startup
+		routeSpec{Pattern: "GET /openapi.json", Access: accessNone, Handler: s.handleAPIDocument()},
+
+		// Developer portal: the caller's own API keys, their usage, and
+		// the API reference scoped to the caller's token
+		routeSpec{Pattern: "GET /developer/keys", Handler: s.apiKeyHandler.HandleListOwnKeys()},
+		routeSpec{Pattern: "POST /developer/keys", Handler: s.apiKeyHandler.HandleCreateOwnKey()},
+		routeSpec{Pattern: "POST /developer/keys/{id}/rotate", Handler: s.apiKeyHandler.HandleRotateOwnKey()},
+		routeSpec{Pattern: "DELETE /developer/keys/{id}", Handler: s.apiKeyHandler.HandleRevokeOwnKey()},
+		routeSpec{Pattern: "GET /developer/keys/{id}/usage", Handler: s.apiKeyHandler.HandleKeyUsage()},
+		routeSpec{Pattern: "GET /developer/openapi.json", Handler: s.handleOpenAPI()},
+	)
 
 	// Mount v1 routes
-	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", v1Mux))
+	mux.Mount(v1Mux, http.StripPrefix("/api/v1", v1Mux))
+	// Handle and external ID lookups are registered whole on the root
+	// router, as under v1 the paths would overlap /users/{id}/profile and
+	// its siblings, which ServeMux refuses
+	s.register(mux,
+		routeSpec{Pattern: "GET /api/v1/users/by-handle/{handle}", Scopes: []string{"users:read"}, Handler: s.userHandler.HandleGetUserByHandle()},
+		routeSpec{Pattern: "GET /api/v1/users/by-external-id/{system}/{id}", Scopes: []string{"users:read"}, Handler: s.userHandler.HandleGetUserByExternalID()},
+	)
 
-	// Apply middleware chain
-	handler := s.applyMiddleware(mux)
+	// Endpoints of the legacy API, translated to the routes above until
+	// their clients have moved over
+	if s.config.LegacyAPI.Enabled {
+		s.legacyRoutes(mux)
+	}
 
-	// Wrap with OpenTelemetry instrumentation if enabled
-	if s.config.Telemetry.Enabled {
-		handler = otelhttp.NewHandler(handler, "http-server")
+	// Admin routes. They need the admin role through their mount, so they
+	// declare no access of their own.
+	adminMux := newRouter("/admin")
+
+	s.register(adminMux,
+		// Retention policy endpoints
+		routeSpec{Pattern: "GET /retention/policies", Handler: s.retentionHandler.HandleListPolicies()},
+		routeSpec{Pattern: "POST /retention/policies", Handler: s.retentionHandler.HandleCreatePolicy()},
+		routeSpec{Pattern: "GET /retention/policies/{id}", Handler: s.retentionHandler.HandleGetPolicy()},
+		routeSpec{Pattern: "DELETE /retention/policies/{id}", Handler: s.retentionHandler.HandleDeletePolicy()},
+		routeSpec{Pattern: "POST /retention/policies/{id}/run", Handler: s.retentionHandler.HandleRunPolicy()},
+		routeSpec{Pattern: "GET /retention/policies/{id}/reports", Handler: s.retentionHandler.HandleListReports()},
+
+		// Archive endpoints
+		routeSpec{Pattern: "GET /archive/segments", Handler: s.archiveHandler.HandleListSegments()},
+		routeSpec{Pattern: "GET /archive/audit-logs", Handler: s.archiveHandler.HandleFetchAuditLogs()},
+		routeSpec{Pattern: "POST /archive/audit-logs/run", Handler: s.archiveHandler.HandleRunArchive()},
+
+		// Deleted templates and retention policies wait in the trash until
+		// restored or purged
+		routeSpec{Pattern: "GET /trash", Handler: s.trashHandler.HandleList()},
+		routeSpec{Pattern: "POST /trash/{type}/{id}/restore", Handler: s.trashHandler.HandleRestore()},
+		routeSpec{Pattern: "DELETE /trash/{type}/{id}", Handler: s.trashHandler.HandlePurge()},
+
+		// Message template endpoints
+		routeSpec{Pattern: "GET /templates", Handler: s.templateHandler.HandleListTemplates()},
+		routeSpec{Pattern: "POST /templates", Handler: s.templateHandler.HandleCreateTemplate()},
+		routeSpec{Pattern: "GET /templates/{id}", Handler: s.templateHandler.HandleGetTemplate()},
+		routeSpec{Pattern: "PUT /templates/{id}", Handler: s.templateHandler.HandleUpdateTemplate()},
+		routeSpec{Pattern: "DELETE /templates/{id}", Handler: s.templateHandler.HandleDeleteTemplate()},
+		routeSpec{Pattern: "GET /templates/{id}/versions", Handler: s.templateHandler.HandleListVersions()},
+		routeSpec{Pattern: "POST /templates/{id}/rollback", Handler: s.templateHandler.HandleRollback()},
+		routeSpec{Pattern: "POST /templates/{id}/preview", Handler: s.templateHandler.HandlePreview()},
+
+		// Announcements published in the public feed
+		routeSpec{Pattern: "POST /announcements", Handler: s.announcementHandler.HandleCreate()},
+	)
+
+	// Synthetic data for load tests, only in APP_ENV=loadtest
+	if s.config.Service.Environment == config.EnvLoadtest {
+		s.register(adminMux,
+			routeSpec{Pattern: "POST /loadtest/users", Handler: s.loadtestHandler.HandleGenerateUsers()},
+			routeSpec{Pattern: "DELETE /loadtest/users", Handler: s.loadtestHandler.HandleReset()},
+		)
 	}
 
-	return handler
+	s.register(adminMux,
+		// Whether an email or handle is in use, and by whom
+		routeSpec{Pattern: "POST /users/availability", Handler: s.userHandler.HandleAdminCheckAvailability()},
+
+		// Bulk user operations, worked through by the job queue. Their
+		// progress lives outside /users so it cannot be mistaken for a
+		// user ID.
+		routeSpec{Pattern: "POST /users/bulk", Handler: s.dryRunnable(s.userHandler.HandleStartBulk())},
+		routeSpec{Pattern: "GET /bulk-operations", Handler: s.userHandler.HandleListBulk()},
+		routeSpec{Pattern: "GET /bulk-operations/{id}", Handler: s.userHandler.HandleGetBulk()},
+		routeSpec{Pattern: "GET /bulk-operations/{id}/items", Handler: s.userHandler.HandleBulkItems()},
+		routeSpec{Pattern: "GET /bulk-operations/{id}/events", Handler: s.userHandler.HandleBulkEvents()},
+
+		// IDs users had in systems being migrated from. Mappings live
+		// outside /users so a system name cannot be mistaken for a user ID.
+		routeSpec{Pattern: "GET /users/{id}/external-ids", Handler: s.userHandler.HandleListExternalIDs()},
+		routeSpec{Pattern: "POST /external-ids", Handler: s.userHandler.HandleImportExternalIDs()},
+		routeSpec{Pattern: "DELETE /external-ids/{system}/{externalId}", Handler: s.userHandler.HandleRemoveExternalID()},
+		// CSV files of mappings, imported in the background. The operation's
+		// result links a report of the rows with errors or warnings.
+		routeSpec{Pattern: "POST /external-id-imports", Handler: s.userHandler.HandleImportExternalIDFile()},
+		routeSpec{Pattern: "GET /external-id-imports/{id}/report", Handler: s.userHandler.HandleImportReport()},
+
+		// Notification endpoints
+		routeSpec{Pattern: "POST /users/{id}/notifications/test", Handler: s.notificationHandler.HandleSendTest()},
+
+		// Outbound email delivery status and suppression list
+		routeSpec{Pattern: "GET /email/messages", Handler: s.emailHandler.HandleListMessages()},
+		routeSpec{Pattern: "GET /email/messages/{id}", Handler: s.emailHandler.HandleGetMessage()},
+		routeSpec{Pattern: "GET /email/suppressions", Handler: s.emailHandler.HandleListSuppressions()},
+		routeSpec{Pattern: "POST /email/suppressions", Handler: s.emailHandler.HandleSuppress()},
+		routeSpec{Pattern: "DELETE /email/suppressions/{email}", Handler: s.emailHandler.HandleUnsuppress()},
+
+		// Moderation review queue endpoints
+		routeSpec{Pattern: "GET /moderation/queue", List: &moderation.QueueList, Handler: s.moderationHandler.HandleListQueue()},
+		routeSpec{Pattern: "POST /moderation/queue/{id}/resolve", Handler: s.moderationHandler.HandleResolve()},
+
+		// Abuse risk and shadow ban endpoints
+		routeSpec{Pattern: "GET /risk/users/{id}", Handler: s.riskHandler.HandleAssessUser()},
+		routeSpec{Pattern: "GET /shadow-bans", List: &risk.ShadowBanList, Handler: s.riskHandler.HandleListShadowBans()},
+		routeSpec{Pattern: "PUT /users/{id}/shadow-ban", Handler: s.riskHandler.HandleShadowBan()},
+		routeSpec{Pattern: "DELETE /users/{id}/shadow-ban", Handler: s.riskHandler.HandleLiftShadowBan()},
+
+		// Merges a duplicate user into another, reporting what moved
+		routeSpec{Pattern: "POST /users/{id}/merge", Handler: s.accountHandler.HandleMerge()},
+
+		// Sessions by browser, operating system, and device class
+		routeSpec{Pattern: "GET /analytics/devices", Handler: s.sessionHandler.HandleDeviceAnalytics()},
+
+		// Error budget status for routes with SLOs
+		routeSpec{Pattern: "GET /slo", Handler: s.sloHandler.HandleStatus()},
+
+		// Latest end-to-end self-check probe result
+		routeSpec{Pattern: "GET /probe", Handler: s.probeHandler.HandleLast()},
+
+		// Workflow endpoints
+		routeSpec{Pattern: "GET /workflows", Handler: s.workflowHandler.HandleList()},
+		routeSpec{Pattern: "POST /workflows", Handler: s.workflowHandler.HandleStart()},
+		routeSpec{Pattern: "GET /workflows/{id}", Handler: s.workflowHandler.HandleGet()},
+		routeSpec{Pattern: "POST /workflows/{id}/retry", Handler: s.workflowHandler.HandleRetry()},
+
+		// Background job queue endpoints
+		routeSpec{Pattern: "GET /jobs", Handler: s.jobHandler.HandleList()},
+		routeSpec{Pattern: "GET /jobs/{id}", Handler: s.jobHandler.HandleGet()},
+		routeSpec{Pattern: "POST /jobs/{id}/retry", Handler: s.jobHandler.HandleRetry()},
+		routeSpec{Pattern: "POST /jobs/{id}/cancel", Handler: s.jobHandler.HandleCancel()},
+		routeSpec{Pattern: "PUT /jobs/{id}/priority", Handler: s.jobHandler.HandleSetPriority()},
+
+		// Health of external API connectors
+		routeSpec{Pattern: "GET /connectors", Handler: s.connectorHandler.HandleHealth()},
+		routeSpec{Pattern: "POST /slack/test", Handler: s.slackHandler.HandleTest()},
+
+		// Rate plans and the API keys metered against them
+		routeSpec{Pattern: "GET /rate-plans", Handler: s.apiKeyHandler.HandleListPlans()},
+		routeSpec{Pattern: "PUT /rate-plans/{name}", Handler: s.apiKeyHandler.HandlePutPlan()},
+		routeSpec{Pattern: "GET /api-keys", Handler: s.apiKeyHandler.HandleListKeys()},
+		routeSpec{Pattern: "POST /api-keys", Handler: s.apiKeyHandler.HandleCreateKey()},
+		routeSpec{Pattern: "PUT /api-keys/{id}/plan", Handler: s.apiKeyHandler.HandleSetKeyPlan()},
+		routeSpec{Pattern: "DELETE /api-keys/{id}", Handler: s.apiKeyHandler.HandleRevokeKey()},
+
+		// Service accounts and their rotating credentials
+		routeSpec{Pattern: "GET /service-accounts", Handler: s.serviceAccountHandler.HandleList()},
+		routeSpec{Pattern: "POST /service-accounts", Handler: s.serviceAccountHandler.HandleCreate()},
+		routeSpec{Pattern: "GET /service-accounts/{id}", Handler: s.serviceAccountHandler.HandleGet()},
+		routeSpec{Pattern: "PUT /service-accounts/{id}/scopes", Handler: s.serviceAccountHandler.HandleSetScopes()},
+		routeSpec{Pattern: "DELETE /service-accounts/{id}", Handler: s.serviceAccountHandler.HandleDisable()},
+		routeSpec{Pattern: "POST /service-accounts/{id}/credentials", Handler: s.serviceAccountHandler.HandleRotate()},
+		routeSpec{Pattern: "DELETE /service-accounts/{id}/credentials/{credentialID}", Handler: s.serviceAccountHandler.HandleRevokeCredential()},
+
+		// Replica currently elected to run singleton background jobs
+		routeSpec{Pattern: "GET /leader", Handler: s.leaderHandler.HandleStatus()},
+	)
+
+	// Replicas and their health, as seen through heartbeats
+	if s.config.Cluster.Enabled {
+		s.register(adminMux, routeSpec{Pattern: "GET /cluster", Handler: s.clusterHandler.HandleState()})
+	}
+
+	s.register(adminMux,
+		// Effective configuration of this replica, secrets masked
+		routeSpec{Pattern: "GET /config", Handler: s.handleConfig()},
+
+		// Registered routes with their middleware and auth requirements
+		routeSpec{Pattern: "GET /routes", Handler: s.handleRoutes()},
+
+		// Roles and scopes with the routes each may reach, for access
+		// reviews
+		routeSpec{Pattern: "GET /compliance/access-matrix", Handler: s.handleAccessMatrix()},
+
+		// Kill switches turning off single routes
+		routeSpec{Pattern: "GET /kill-switches", Handler: s.killSwitchHandler.HandleList()},
+		routeSpec{Pattern: "PUT /kill-switches", Handler: s.killSwitchHandler.HandleSet()},
+		routeSpec{Pattern: "DELETE /kill-switches", Handler: s.killSwitchHandler.HandleClear()},
+
+		// Read-only mode for failovers and incidents
+		routeSpec{Pattern: "GET /read-only", Handler: s.readOnlyHandler.HandleStatus()},
+		routeSpec{Pattern: "PUT /read-only", Handler: s.readOnlyHandler.HandleEnable()},
+		routeSpec{Pattern: "DELETE /read-only", Handler: s.readOnlyHandler.HandleDisable()},
+	)
+
+	// Custom domains of tenants
+	if s.config.Domains.Enabled {
+		s.register(adminMux,
+			routeSpec{Pattern: "GET /tenant-domains", Handler: s.domainHandler.HandleList()},
+			routeSpec{Pattern: "POST /tenant-domains", Handler: s.domainHandler.HandleAdd()},
+			routeSpec{Pattern: "GET /tenant-domains/{domain}", Handler: s.domainHandler.HandleGet()},
+			routeSpec{Pattern: "PATCH /tenant-domains/{domain}", Handler: s.domainHandler.HandleUpdate()},
+			routeSpec{Pattern: "DELETE /tenant-domains/{domain}", Handler: s.domainHandler.HandleRemove()},
+			routeSpec{Pattern: "POST /tenant-domains/{domain}/verify", Handler: s.domainHandler.HandleVerify()},
+		)
+	}
+
+	// Routes of feature modules
+	routes := module.Routes{Public: publicRouter{router: mux, server: s}, API: v1Mux, Admin: adminMux}
+	for _, m := range s.modules {
+		m.RegisterRoutes(routes)
+	}
+
+	// Mount admin routes; with auth on they need the admin role
+	mux.Mount(adminMux, s.requireRole(s.config.Auth.AdminRole, http.StripPrefix("/admin", adminMux)))
+
+	// Kept for route listings
+	s.router = mux
+
+	// Apply middleware chain
+	return s.applyMiddleware(mux)
 }
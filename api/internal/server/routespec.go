@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"starterkit/internal/platform/listing"
+)
+
+// Who may call a route
+const (
+	// accessUser routes take any authenticated caller; it is the default
+	accessUser = ""
+	// accessNone routes skip authentication
+	accessNone = "none"
+	// accessAdmin routes need the admin role. Routes under /admin need it
+	// through their mount and do not declare it.
+	accessAdmin = "admin"
+	// accessOrgAdmin routes need the organization admin role, and act on
+	// the caller's organization
+	accessOrgAdmin = "org-admin"
+)
+
+// How a route is throttled, besides the rate plan of the caller's API key
+const (
+	// rateSignIn routes check a secret they were sent; see signIn
+	rateSignIn = "sign-in"
+	// rateAvailability routes take USERS_AVAILABILITY_RATE_LIMIT requests
+	// a minute from each client address
+	rateAvailability = "availability"
+)
+
+// routeSpec declares a route and what a caller needs to reach it. register
+// wraps Handler in the checks the declaration calls for, so route listings,
+// the access matrix, and the OpenAPI document, which read those checks,
+// describe what is enforced.
+type routeSpec struct {
+	// Pattern is a "METHOD /path" or "/path" pattern, relative to the
+	// router's prefix
+	Pattern string
+	Access  string
+	// Scopes are all needed in the caller's token when auth is on
+	Scopes []string
+	Rate   string
+	// List declares the query parameters of list routes, which their
+	// handler answers with listing.Serve
+	List *listing.Spec
+	// Handler serves the route, wrapped in any layers of its own such as
+	// s.track or s.costed
+	Handler http.Handler
+}
+
+// register serves the declared routes through rt
+func (s *Server) register(rt *router, specs ...routeSpec) {
+	for _, spec := range specs {
+		h := spec.Handler
+		if spec.List != nil {
+			h = listed(*spec.List, h)
+		}
+		switch spec.Rate {
+		case rateSignIn:
+			h = s.signIn(h)
+		case rateAvailability:
+			h = s.rateLimited(s.config.Users.AvailabilityLimit, time.Minute, h)
+		}
+		for i := len(spec.Scopes) - 1; i >= 0; i-- {
+			h = s.requireScope(spec.Scopes[i], h)
+		}
+		switch spec.Access {
+		case accessAdmin:
+			h = s.requireRole(s.config.Auth.AdminRole, h)
+		case accessOrgAdmin:
+			h = s.requireRole(s.config.Auth.OrgAdminRole, h)
+		case accessNone:
+			s.exemptPath(rt.prefix, spec.Pattern)
+		}
+		rt.Handle(spec.Pattern, h)
+	}
+}
+
+// listed reads the query of requests to a list route for its handler,
+// turning away ones that do not follow spec
+func listed(spec listing.Spec, h http.Handler) http.Handler {
+	return layer{Handler: listing.Middleware(spec, h), name: "list", next: h, list: &spec}
+}
+
+// exemptPath lets requests to the path of pattern, registered on a router
+// mounted at prefix, skip authentication. A wildcard matches any segment,
+// so the path is public from there.
+func (s *Server) exemptPath(prefix, pattern string) {
+	_, p, ok := strings.Cut(pattern, " ")
+	if !ok {
+		p = pattern
+	}
+	if i := strings.Index(p, "{"); i >= 0 {
+		p = p[:i]
+	}
+	s.publicPaths = append(s.publicPaths, prefix+p)
+}
@@ -2,37 +2,544 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
+	"starterkit/internal/accounts"
+	"starterkit/internal/ai"
+	"starterkit/internal/announcements"
+	"starterkit/internal/apikeys"
+	"starterkit/internal/archive"
+	"starterkit/internal/audit"
+	"starterkit/internal/auditexports"
+	"starterkit/internal/backup"
+	"starterkit/internal/clientsync"
+	"starterkit/internal/cluster"
 	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+	"starterkit/internal/connectors/slack"
+	"starterkit/internal/connectors/statushooks"
 	"starterkit/internal/db"
+	"starterkit/internal/domains"
+	"starterkit/internal/email"
+	"starterkit/internal/jobs"
+	"starterkit/internal/killswitch"
+	"starterkit/internal/leader"
+	"starterkit/internal/loadtest"
+	"starterkit/internal/magiclinks"
+	"starterkit/internal/moderation"
+	"starterkit/internal/notifications"
+	"starterkit/internal/openapi"
+	"starterkit/internal/operations"
+	"starterkit/internal/passkeys"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/delta"
+	"starterkit/internal/platform/events"
+	"starterkit/internal/platform/fieldaccess"
+	"starterkit/internal/platform/geoip"
+	"starterkit/internal/platform/health"
+	"starterkit/internal/platform/lifecycle"
+	"starterkit/internal/platform/locale"
+	"starterkit/internal/platform/module"
+	"starterkit/internal/platform/panics"
+	"starterkit/internal/platform/querycost"
+	"starterkit/internal/platform/respcache"
+	"starterkit/internal/platform/scheduler"
+	"starterkit/internal/platform/siem"
+	"starterkit/internal/platform/temporal"
+	"starterkit/internal/platform/tlspolicy"
+	"starterkit/internal/platform/useragent"
+	"starterkit/internal/platform/watchdog"
+	"starterkit/internal/platform/wiring"
+	"starterkit/internal/probe"
+	"starterkit/internal/projections"
+	"starterkit/internal/readonly"
+	"starterkit/internal/retention"
+	"starterkit/internal/risk"
+	"starterkit/internal/scopedtokens"
+	"starterkit/internal/search"
+	"starterkit/internal/serviceaccounts"
+	"starterkit/internal/sessions"
+	"starterkit/internal/sitemap"
+	"starterkit/internal/slo"
+	"starterkit/internal/templates"
+	"starterkit/internal/trash"
+	"starterkit/internal/uploads"
 	"starterkit/internal/users"
+	"starterkit/internal/workflow"
+
+	_ "starterkit/internal/modules"
+
+	"go.temporal.io/sdk/client"
 )
 
 // Server represents the HTTP server
 type Server struct {
-	httpServer  *http.Server
-	config      *config.Config
-	logger      *slog.Logger
-	queries     *db.Queries
-	userHandler *users.Handler
+	httpServer     *http.Server
+	config         *config.Config
+	logger         *slog.Logger
+	guard          *panics.Guard
+	queries        *db.Queries
+	dbMonitor      *database.Monitor
+	failover       *database.FailoverWatcher
+	elector        *leader.Elector
+	scheduler      *scheduler.Scheduler
+	watchdog       *watchdog.Watchdog
+	events         *events.Bus
+	operations     *operations.Service
+	localeResolver *locale.Resolver
+	slo            *slo.Tracker
+	// queryCosts meters list requests; it is nil when budgets are off
+	queryCosts   *querycost.Budget
+	risk         *risk.Service
+	geo          *geoip.Resolver
+	geoPolicy    *geoip.Policy
+	uaParser     useragent.Parser
+	verifier     *auth.Verifier
+	signatures   *auth.SignatureVerifier
+	certificates *auth.CertificateMapper
+	replay       *auth.ReplayGuard
+	// narrower verifies narrowed tokens; it is nil when narrowing is off
+	narrower *auth.Narrower
+	// failures delays clients that keep failing to authenticate; it is nil
+	// when AUTH_FAILURE_DELAY is 0
+	failures *auth.Throttle
+	fields   *fieldaccess.Filter
+	sessions *sessions.Service
+	health   *health.Registry
+	apiDoc   *openapi.Document
+	// router and middleware are the registered routes and the enabled
+	// global middleware, for route listings
+	router     *router
+	middleware []string
+	// modules are the feature packages that registered themselves, and
+	// publicPaths the paths that routes declared public exempt from auth
+	modules       []module.Module
+	publicPaths   []string
+	apiKeys       *apikeys.Service
+	killSwitches  *killswitch.Service
+	readOnly      *readonly.Service
+	responseCache *respcache.Cache
+	// deltas answers polls of list routes with patches; it is nil unless
+	// DELTA_ENABLED is set
+	deltas          *delta.Encoder
+	serviceAccounts *serviceaccounts.Service
+	// domains resolves the custom domains of tenants; it is nil unless
+	// DOMAINS_ENABLED is set
+	domains *domains.Service
+	// magicLinks issues and authenticates session tokens; it is nil unless
+	// magic links or passkeys are enabled
+	magicLinks            *magiclinks.Service
+	passkeys              *passkeys.Service
+	accounts              *accounts.Service
+	metricsHandler        http.Handler
+	cancelBackground      context.CancelFunc
+	userHandler           *users.Handler
+	retentionHandler      *retention.Handler
+	archiveHandler        *archive.Handler
+	trashHandler          *trash.Handler
+	syncHandler           *clientsync.Handler
+	operationHandler      *operations.Handler
+	notificationHandler   *notifications.Handler
+	emailHandler          *email.Handler
+	announcementHandler   *announcements.Handler
+	sitemapHandler        *sitemap.Handler
+	apiKeyHandler         *apikeys.Handler
+	killSwitchHandler     *killswitch.Handler
+	readOnlyHandler       *readonly.Handler
+	domainHandler         *domains.Handler
+	auditExportHandler    *auditexports.Handler
+	serviceAccountHandler *serviceaccounts.Handler
+	magicLinkHandler      *magiclinks.Handler
+	scopedTokenHandler    *scopedtokens.Handler
+	passkeyHandler        *passkeys.Handler
+	accountHandler        *accounts.Handler
+	templateHandler       *templates.Handler
+	uploadHandler         *uploads.Handler
+	moderationHandler     *moderation.Handler
+	riskHandler           *risk.Handler
+	sloHandler            *slo.Handler
+	probeHandler          *probe.Handler
+	leaderHandler         *leader.Handler
+	workflowHandler       *workflow.Handler
+	jobHandler            *jobs.Handler
+	temporalWorker        *temporal.Worker
+	connectorHandler      *connectors.Handler
+	slackHandler          *slack.Handler
+	assistHandler         *ai.Handler
+	searchHandler         *search.Handler
+	sessionHandler        *sessions.Handler
+	// loadtestHandler provisions synthetic data; it is nil unless APP_ENV
+	// is loadtest
+	loadtestHandler *loadtest.Handler
+	// cluster tracks the peer replicas; it and clusterHandler are nil
+	// unless CLUSTER_ENABLED
+	cluster        *cluster.Service
+	clusterHandler *cluster.Handler
+}
+
+// MetricsHandler serves the Prometheus scrape endpoint; nil unless that
+// exporter is enabled
+type MetricsHandler http.Handler
+
+// New creates a new server instance from the components in c. The caller
+// supplies the infrastructure: the config and logger, the database pools,
+// queries and monitor, the TLS policy, the metrics handler, and the
+// external providers such as storage and the mailer. The server provides
+// its own services on top.
+func New(c *wiring.Container) (*Server, error) {
+	provideServices(c)
+	wiring.Provide(c, newServer)
+	return wiring.Get[*Server](c)
 }
 
-// New creates a new server instance
-func New(cfg *config.Config, logger *slog.Logger, queries *db.Queries) *Server {
-	// Create services
-	userService := users.NewService(queries)
+// newServer assembles the server from its services
+func newServer(c *wiring.Container) (*Server, error) {
+	cfg, logger, queries := common(c)
+	dbPools := wiring.Use[*database.Pools](c)
+	dbMonitor := wiring.Use[*database.Monitor](c)
+	tlsPolicy := wiring.Use[*tlspolicy.Policy](c)
+	temporalClient := wiring.Use[client.Client](c)
+	siemSink := wiring.Use[siem.Sink](c)
+	assistant := wiring.Use[ai.Provider](c)
+	embedder := wiring.Use[ai.Embedder](c)
+
+	// Services, built with their dependencies in the order they are used
+	connectorRegistry := wiring.Use[*connectors.Registry](c)
+	slackNotifier := wiring.Use[*slack.Notifier](c)
+	guard := wiring.Use[*panics.Guard](c)
+	dog := wiring.Use[*watchdog.Watchdog](c)
+	elector := wiring.Use[*leader.Elector](c)
+	auditService := wiring.Use[audit.Recorder](c)
+	operationService := wiring.Use[*operations.Service](c)
+	moderationService := wiring.Use[*moderation.Service](c)
+	riskService := wiring.Use[*risk.Service](c)
+	profileService := wiring.Use[*users.ProfileService](c)
+	phoneService := wiring.Use[*users.PhoneService](c)
+	handleService := wiring.Use[*users.HandleService](c)
+	userService := wiring.Use[*users.Service](c)
+	sessionService := wiring.Use[*sessions.Service](c)
+	retentionService := wiring.Use[*retention.Service](c)
+	archiveService := wiring.Use[*archive.Service](c)
+	trashService := wiring.Use[*trash.Service](c)
+	backupService := wiring.Use[*backup.Service](c)
+	syncService := wiring.Use[*clientsync.Service](c)
+	uploadService := wiring.Use[*uploads.Service](c)
+	workflowService := wiring.Use[*workflow.Service](c)
+	jobQueue := wiring.Use[*jobs.Queue](c)
+	templateService := wiring.Use[*templates.Service](c)
+	emailService := wiring.Use[*email.Service](c)
+	notificationService := wiring.Use[*notifications.Service](c)
+	announcementService := wiring.Use[*announcements.Service](c)
+	apiKeyService := wiring.Use[*apikeys.Service](c)
+	killSwitches := wiring.Use[*killswitch.Service](c)
+	readOnly := wiring.Use[*readonly.Service](c)
+	serviceAccounts := wiring.Use[*serviceaccounts.Service](c)
+	sloTracker := wiring.Use[*slo.Tracker](c)
+	probeService := wiring.Use[*probe.Service](c)
+	geoResolver := wiring.Use[*geoip.Resolver](c)
+	geoPolicy := wiring.Use[*geoip.Policy](c)
+	embeddingIndexer := wiring.Use[*search.Indexer](c)
 
 	// Create handlers
-	userHandler := users.NewHandler(userService, logger)
+	retentionHandler := retention.NewHandler(retentionService, operationService, logger)
+	archiveHandler := archive.NewHandler(archiveService, operationService, cfg.Archive.OlderThan, logger)
+	trashHandler := trash.NewHandler(trashService, logger)
+	syncHandler := clientsync.NewHandler(syncService, logger)
+	operationHandler := operations.NewHandler(operationService, logger)
+	notificationHandler := notifications.NewHandler(notificationService, logger)
+	announcementHandler := announcements.NewHandler(announcementService, cfg.Announcements.CacheTTL, logger)
+	sitemapHandler := sitemap.NewHandler(wiring.Use[*sitemap.Service](c), logger)
+	apiKeyHandler := apikeys.NewHandler(apiKeyService, logger)
+	killSwitchHandler := killswitch.NewHandler(killSwitches, logger)
+	readOnlyHandler := readonly.NewHandler(readOnly, logger)
+	serviceAccountHandler := serviceaccounts.NewHandler(serviceAccounts, logger)
+	emailHandler := email.NewHandler(emailService, cfg.Email.WebhookSecret, logger)
+	templateHandler := templates.NewHandler(templateService, operationService, logger)
+	uploadHandler := uploads.NewHandler(uploadService, cfg.Uploads.DownloadRate, cfg.Uploads.DownloadBurst, logger)
+	moderationHandler := moderation.NewHandler(moderationService, logger)
+	riskHandler := risk.NewHandler(riskService, logger)
+	sessionHandler := sessions.NewHandler(sessionService, logger)
+	userHandler := users.NewHandler(userService, phoneService, profileService, handleService, wiring.Use[*users.BulkService](c), wiring.Use[*users.ExternalIDService](c), operationService, userExpansions(cfg, notificationService, sessionService), logger)
+	sloHandler := slo.NewHandler(sloTracker, logger)
+	probeHandler := probe.NewHandler(probeService, logger)
+	leaderHandler := leader.NewHandler(elector, logger)
+	workflowHandler := workflow.NewHandler(workflowService, logger)
+	jobHandler := jobs.NewHandler(jobQueue, logger)
+	connectorHandler := connectors.NewHandler(connectorRegistry, logger)
+	slackHandler := slack.NewHandler(slackNotifier, logger)
+	assistHandler := ai.NewHandler(ai.NewService(assistant, queries, auditService, cfg.AI, logger), logger)
+	searchHandler := search.NewHandler(search.NewService(embedder, queries, cfg.Embeddings.MaxChars), logger)
 
 	s := &Server{
-		config:      cfg,
-		logger:      logger,
-		queries:     queries,
-		userHandler: userHandler,
+		config:                cfg,
+		logger:                logger,
+		guard:                 guard,
+		queries:               queries,
+		dbMonitor:             dbMonitor,
+		failover:              database.NewFailoverWatcher(dbPools, logger),
+		elector:               elector,
+		scheduler:             wiring.Use[*scheduler.Scheduler](c),
+		watchdog:              dog,
+		events:                wiring.Use[*events.Bus](c),
+		operations:            operationService,
+		localeResolver:        wiring.Use[*locale.Resolver](c),
+		slo:                   sloTracker,
+		risk:                  riskService,
+		geo:                   geoResolver,
+		geoPolicy:             geoPolicy,
+		uaParser:              wiring.Use[useragent.Parser](c),
+		sessions:              sessionService,
+		apiKeys:               apiKeyService,
+		killSwitches:          killSwitches,
+		readOnly:              readOnly,
+		responseCache:         wiring.Use[*respcache.Cache](c),
+		deltas:                wiring.Use[*delta.Encoder](c),
+		serviceAccounts:       serviceAccounts,
+		metricsHandler:        wiring.Use[MetricsHandler](c),
+		userHandler:           userHandler,
+		retentionHandler:      retentionHandler,
+		archiveHandler:        archiveHandler,
+		trashHandler:          trashHandler,
+		syncHandler:           syncHandler,
+		operationHandler:      operationHandler,
+		notificationHandler:   notificationHandler,
+		emailHandler:          emailHandler,
+		announcementHandler:   announcementHandler,
+		sitemapHandler:        sitemapHandler,
+		apiKeyHandler:         apiKeyHandler,
+		killSwitchHandler:     killSwitchHandler,
+		readOnlyHandler:       readOnlyHandler,
+		serviceAccountHandler: serviceAccountHandler,
+		templateHandler:       templateHandler,
+		uploadHandler:         uploadHandler,
+		moderationHandler:     moderationHandler,
+		riskHandler:           riskHandler,
+		sloHandler:            sloHandler,
+		probeHandler:          probeHandler,
+		leaderHandler:         leaderHandler,
+		workflowHandler:       workflowHandler,
+		jobHandler:            jobHandler,
+		connectorHandler:      connectorHandler,
+		slackHandler:          slackHandler,
+		assistHandler:         assistHandler,
+		searchHandler:         searchHandler,
+		sessionHandler:        sessionHandler,
+	}
+
+	if cfg.QueryCost.Budget > 0 {
+		s.queryCosts = querycost.NewBudget(cfg.QueryCost.Budget)
+	}
+
+	// API reference served at /api/v1/openapi.json and /docs
+	if doc, err := APIDocument(cfg); err != nil {
+		logger.Error("failed to build OpenAPI document", "error", err)
+	} else {
+		s.apiDoc = doc
+	}
+
+	// Readiness checks behind /readyz
+	s.health = health.NewRegistry(cfg.Server.HealthCheckTimeout, logger)
+	s.health.Register("database", health.Ping(dbPools.Pool(database.WorkloadInteractive)))
+	s.health.Register("background", func(ctx context.Context) error {
+		if stalled := s.watchdog.Stalled(); len(stalled) > 0 {
+			return health.Reason("stalled: " + strings.Join(stalled, ", "))
+		}
+		return nil
+	})
+
+	// Uptime monitors are pinged on startup, shutdown, and readiness flips.
+	// Readiness is rechecked on a schedule so flips are seen between probes.
+	if notifier := wiring.Use[*statushooks.Notifier](c); notifier != nil {
+		s.health.Observe(notifier.Observe)
+		s.scheduler.RegisterLocal("status-hooks", cfg.StatusHooks.ReadinessInterval, func(ctx context.Context) error {
+			s.health.Run(ctx)
+			return nil
+		})
+		wiring.Use[*lifecycle.Manager](c).Append(lifecycle.Hook{Name: "status hooks", Timeout: 30 * time.Second, OnStart: notifier.Started, OnStop: notifier.Wait})
+	}
+
+	// Peer awareness: each replica renews its heartbeat on every replica's
+	// schedule, and removes it on the way down so peers recount at once
+	if cfg.Cluster.Enabled {
+		store, err := cluster.NewStore(cfg.Cluster, queries)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cluster store: %w", err)
+		}
+		s.cluster = cluster.NewService(store, cfg.Cluster, cfg.Leader.ID, cfg.Service.Version, func(ctx context.Context) bool {
+			return s.health.Run(ctx).Ready()
+		}, logger)
+		s.clusterHandler = cluster.NewHandler(s.cluster, logger)
+		s.scheduler.RegisterLocal("cluster-heartbeat", cfg.Cluster.HeartbeatInterval, s.cluster.Beat)
+		wiring.Use[*lifecycle.Manager](c).Append(lifecycle.Hook{Name: "cluster", Timeout: 5 * time.Second, OnStop: s.cluster.Leave})
+		if s.queryCosts != nil {
+			s.queryCosts.Spread(s.cluster.Replicas)
+		}
+	}
+
+	if cfg.Service.Environment == config.EnvLoadtest {
+		s.loadtestHandler = loadtest.NewHandler(wiring.Use[*loadtest.Service](c), operationService, logger)
+	}
+
+	// Passwordless sign-in; the handlers resolve client IPs as the server does
+	if cfg.MagicLinks.Enabled || cfg.WebAuthn.Enabled {
+		s.magicLinks = wiring.Use[*magiclinks.Service](c)
+		s.magicLinkHandler = magiclinks.NewHandler(s.magicLinks, s.clientIP, logger)
+	}
+	if cfg.WebAuthn.Enabled {
+		s.passkeys = wiring.Use[*passkeys.Service](c)
+		s.passkeyHandler = passkeys.NewHandler(s.passkeys, s.clientIP, logger)
+	}
+
+	// Tokens narrowed to some of their caller's scopes, for embedded widgets
+	if cfg.Auth.NarrowingSecret != "" {
+		s.narrower = wiring.Use[*auth.Narrower](c)
+		s.scopedTokenHandler = scopedtokens.NewHandler(wiring.Use[*scopedtokens.Service](c), logger)
+	}
+
+	// Custom domains; every replica loads the verified ones at startup and
+	// reloads them, and the leader looks up the records of pending ones
+	if cfg.Domains.Enabled {
+		s.domains = wiring.Use[*domains.Service](c)
+		s.domainHandler = domains.NewHandler(s.domains, logger)
+		wiring.Use[*lifecycle.Manager](c).Append(lifecycle.Hook{Name: "tenant domains", Timeout: 10 * time.Second, OnStart: func(ctx context.Context) error {
+			if err := s.domains.Reload(ctx); err != nil {
+				logger.Warn("failed to load custom domains", "error", err)
+			}
+			return nil
+		}})
+		s.scheduler.RegisterLocal("tenant-domains-refresh", cfg.Domains.RefreshInterval, s.domains.Reload)
+		s.scheduler.Register("tenant-domain-verify", cfg.Domains.VerifyInterval, s.domains.VerifyPending)
+	}
+
+	// Audit exports organization admins schedule; the leader runs the due
+	// ones
+	if cfg.AuditExports.Enabled {
+		auditExports := wiring.Use[*auditexports.Service](c)
+		s.auditExportHandler = auditexports.NewHandler(auditExports, cfg.AuditExports.TenantHeader, logger)
+		s.scheduler.Register("audit-exports", cfg.AuditExports.Interval, auditExports.RunDue)
+	}
+
+	nonces := nonceStore{queries: queries}
+	s.configureAuth(nonces)
+
+	// Linked identities and merged users resolve to their account; linking
+	// verifies the linked identity's token as bearer auth does
+	s.accounts = wiring.Use[*accounts.Service](c)
+	var verifyIdentity accounts.VerifyFunc
+	if cfg.Accounts.LinkingEnabled {
+		verifyIdentity = s.verifier.Verify
+	}
+	s.accountHandler = accounts.NewHandler(s.accounts, verifyIdentity, logger)
+
+	// Register background jobs; all but self-probe run on the elected leader
+	changeRelay := users.NewChangeRelay(queries, s.events)
+	s.scheduler.Register("user-change-relay", cfg.Events.ChangeRelayInterval, changeRelay.Poll)
+	projector := projections.NewProjector(dbPools, queries, cfg.Projection.BatchSize, logger, projections.UserSearch{}, projections.UserSummaries{}, projections.UserEmbeddings{})
+	s.scheduler.Register("projections", cfg.Projection.Interval, projector.Run)
+	s.scheduler.Register("embeddings", cfg.Embeddings.Interval, embeddingIndexer.Run)
+	s.scheduler.Register("workflows", cfg.Workflow.Interval, workflowService.RunDue)
+	s.scheduler.Register("staged-operations", 5*time.Second, operationService.FinalizeDue)
+	s.scheduler.Register("trash-purge", cfg.Trash.PurgeInterval, trashService.PurgeExpired)
+	s.scheduler.Register("job-queue-depth", time.Minute, jobQueue.RecordDepth)
+	s.scheduler.Register("email-prune", time.Hour, emailService.Prune)
+	s.scheduler.Register("email-change-prune", time.Hour, userService.PruneEmailChanges)
+	if cfg.Sessions.Enabled {
+		s.scheduler.Register("session-prune", time.Hour, sessionService.Prune)
+		s.scheduler.Register("active-sessions", time.Minute, sessionService.RecordActive)
+	}
+	if s.signatures != nil || s.replay != nil {
+		s.scheduler.Register("nonce-prune", time.Hour, nonces.pruneNonces)
+	}
+	s.scheduler.Register("upload-scan", cfg.Uploads.ScanInterval, uploadService.ScanPending)
+	s.scheduler.Register("upload-metadata", cfg.Uploads.ScanInterval, uploadService.ExtractPending)
+	s.scheduler.Register("resumable-upload-expiry", 10*time.Minute, uploadService.ExpireResumable)
+	s.scheduler.Register("service-account-expiry", time.Hour, serviceAccounts.WarnExpiring)
+	if s.magicLinks != nil {
+		s.scheduler.Register("magic-link-prune", time.Hour, s.magicLinks.Prune)
+	}
+	if s.passkeys != nil {
+		s.scheduler.Register("passkey-challenge-prune", time.Hour, s.passkeys.Prune)
+	}
+	if siemSink != nil {
+		forwarder := audit.NewForwarder(queries, siemSink, cfg.SIEM.Format, cfg.Service.Name, cfg.Service.Version, cfg.SIEM.BatchSize, cfg.SIEM.MaxBatches, cfg.SIEM.MaxRetries)
+		s.scheduler.Register("audit-forward", cfg.SIEM.Interval, forwarder.Poll)
+	}
+	if cfg.Retention.Enabled {
+		s.scheduler.Register("retention", cfg.Retention.Interval, func(ctx context.Context) error {
+			return retentionService.RunAll(ctx, cfg.Retention.DryRun)
+		})
+	}
+	if cfg.Archive.Enabled {
+		s.scheduler.Register("archive", cfg.Archive.Interval, func(ctx context.Context) error {
+			_, err := archiveService.ArchiveAuditLogs(ctx, cfg.Archive.OlderThan)
+			return err
+		})
+	}
+
+	// Feature packages that run on Temporal register their workflows and
+	// activities on s.temporalWorker here
+	if temporalClient != nil {
+		s.temporalWorker = temporal.NewWorker(temporalClient, cfg.Temporal.TaskQueue, logger)
+	}
+
+	if geoResolver != nil {
+		s.scheduler.RegisterLocal("geoip-refresh", cfg.GeoIP.RefreshInterval, geoResolver.Reload)
+	}
+	s.scheduler.RegisterLocal("connector-health", cfg.Connectors.HealthInterval, connectorRegistry.CheckAll)
+	// Every replica reads the kill switches set through the others
+	s.scheduler.RegisterLocal("kill-switch-refresh", cfg.KillSwitches.RefreshInterval, killSwitches.Reload)
+	// and read-only mode, from startup so a restart during an incident
+	// comes up read-only
+	wiring.Use[*lifecycle.Manager](c).Append(lifecycle.Hook{Name: "read-only mode", Timeout: 10 * time.Second, OnStart: func(ctx context.Context) error {
+		if err := readOnly.Reload(ctx); err != nil {
+			logger.Warn("failed to load read-only mode", "error", err)
+		}
+		return nil
+	}})
+	s.scheduler.RegisterLocal("read-only-refresh", cfg.ReadOnly.RefreshInterval, readOnly.Reload)
+	if slackNotifier != nil {
+		// Each replica tracks its own traffic, so each alerts on its own budgets
+		sloAlerter := slo.NewAlerter(sloTracker, slackNotifier, slack.EventSLO)
+		s.scheduler.RegisterLocal("slo-alerts", cfg.SLO.AlertInterval, sloAlerter.Check)
+	}
+	// Each replica counts the sign-in failures of the clients it serves
+	if s.failures != nil {
+		s.scheduler.RegisterLocal("auth-failure-prune", time.Minute, s.failures.Prune)
+	}
+	// Every replica works the job queue; claims never overlap
+	s.scheduler.RegisterLocal("jobs", cfg.Jobs.Interval, jobQueue.Work)
+	if cfg.Probe.Enabled {
+		s.scheduler.RegisterLocal("self-probe", cfg.Probe.Interval, probeService.Run)
+	}
+
+	if cfg.Backup.Enabled {
+		s.scheduler.Register("backup", cfg.Backup.Interval, backupService.RunScheduled)
+	}
+	if cfg.Refresh.Enabled && cfg.Refresh.SourceDSN != "" {
+		s.scheduler.Register("staging-refresh", cfg.Refresh.Interval, func(ctx context.Context) error {
+			_, err := backupService.RefreshFrom(ctx, cfg.Refresh.SourceDSN, audit.SystemActor)
+			return err
+		})
+	}
+
+	// Feature modules build their services and add their jobs and
+	// readiness checks; their routes are added with the server's
+	s.modules = module.All()
+	for _, m := range s.modules {
+		if err := wiring.Invoke(c, m.Init); err != nil {
+			return nil, fmt.Errorf("failed to initialize module %s: %w", m.Name(), err)
+		}
+		m.RegisterJobs(s.scheduler)
+		m.RegisterHooks(wiring.Use[*lifecycle.Manager](c))
+		if check := m.Health(); check != nil {
+			s.health.Register(m.Name(), check)
+		}
 	}
 
 	// Create HTTP server
@@ -42,27 +549,132 @@ func New(cfg *config.Config, logger *slog.Logger, queries *db.Queries) *Server {
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  cfg.Server.IdleTimeout,
+		TLSConfig:    tlsPolicy.ServerConfig(),
+	}
+	if cfg.Domains.ACMEEnabled {
+		domains.NewCertificates(s.domains).Apply(s.httpServer.TLSConfig)
+	}
+	s.killSwitches.SetRoutes(s.Routes().Patterns())
+	if err := s.checkResponseCache(); err != nil {
+		return nil, err
 	}
 
-	return s
+	return s, nil
 }
 
-// Start begins listening for HTTP requests
+// Start launches background jobs and begins listening for HTTP requests
 func (s *Server) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancelBackground = cancel
+	s.scheduler.Start(ctx)
+	go s.guard.Supervise(ctx, "leader-election", func(ctx context.Context) {
+		s.elector.Run(database.WithWorkload(ctx, database.WorkloadBackground))
+	})
+	go s.guard.Supervise(ctx, "watchdog", func(ctx context.Context) {
+		s.watchdog.Run(ctx, s.config.Watchdog.CheckInterval)
+	})
+	if s.dbMonitor != nil {
+		go s.guard.Supervise(ctx, "database-monitor", func(ctx context.Context) {
+			s.dbMonitor.Run(ctx, s.config.Database.ReconnectInterval)
+		})
+	}
+	if s.temporalWorker != nil {
+		go s.guard.Supervise(ctx, "temporal-worker", func(ctx context.Context) {
+			if err := s.temporalWorker.Run(ctx); err != nil {
+				s.logger.Error("temporal worker stopped", "error", err)
+			}
+		})
+	}
+	if s.config.Database.FailoverCheckInterval > 0 {
+		go s.guard.Supervise(ctx, "database-failover", func(ctx context.Context) {
+			s.failover.Run(ctx, s.config.Database.FailoverCheckInterval)
+		})
+	}
+
+	if s.config.Server.TLSCertFile != "" && s.config.Server.TLSKeyFile != "" {
+		return s.httpServer.ListenAndServeTLS(s.config.Server.TLSCertFile, s.config.Server.TLSKeyFile)
+	}
 	return s.httpServer.ListenAndServe()
 }
 
-// Shutdown gracefully shuts down the server
+// Shutdown gracefully shuts down the server and waits for background jobs
+// and in-flight operations to stop. Readiness fails for the drain delay
+// first, while requests are still served.
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	s.health.Drain()
+	select {
+	case <-time.After(s.config.Server.DrainDelay):
+	case <-ctx.Done():
+	}
+
+	if s.cancelBackground != nil {
+		s.cancelBackground()
+	}
+
+	err := s.httpServer.Shutdown(ctx)
+	s.scheduler.Wait()
+	if waitErr := s.operations.Wait(ctx); waitErr != nil && err == nil {
+		err = waitErr
+	}
+	return err
+}
+
+// probePaths are the health endpoints. They are served without auth or
+// geo-blocking so orchestrators and load balancers can always reach them.
+var probePaths = map[string]bool{
+	"/health":  true,
+	"/healthz": true,
+	"/ready":   true,
+	"/readyz":  true,
+}
+
+// handleReadiness runs the readiness checks and returns 503 when any fails
+// or the server is draining, so load balancers stop routing to a replica
+// that cannot serve
+func (s *Server) handleReadiness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := s.health.Run(r.Context())
+		code := http.StatusOK
+		if !report.Ready() {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(report)
+	}
 }
 
-// handleHealthCheck returns a simple health check handler
+// handleHealthCheck is the liveness probe. It succeeds while the process
+// serves requests, whatever the state of its dependencies, since restarting
+// would not bring them back.
 func (s *Server) handleHealthCheck() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"healthy","service":"%s","version":"%s"}`,
-			s.config.Service.Name, s.config.Service.Version)
+		fmt.Fprintf(w, `{"status":"healthy","service":"%s","version":"%s","environment":"%s"}`,
+			s.config.Service.Name, s.config.Service.Version, s.config.Service.Environment)
+	}
+}
+
+// configureAuth sets up the ways callers authenticate from the auth config
+func (s *Server) configureAuth(nonces nonceStore) {
+	// Bearer token authentication; without it X-User-Email is trusted
+	if s.config.Auth.Enabled {
+		s.verifier = auth.NewVerifier(s.config.Auth)
+	}
+	// HMAC-signed requests from the clients in AUTH_SIGNING_CLIENTS
+	s.signatures = auth.NewSignatureVerifier(s.config.Auth, nonces)
+	// Nonces on state-changing requests, when AUTH_REPLAY_PROTECTION is on
+	s.replay = auth.NewReplayGuard(s.config.Auth, nonces)
+	// Internal services presenting a client certificate over mutual TLS
+	s.certificates = auth.NewCertificateMapper(s.config.Auth)
+	// Backoff for clients that keep failing to sign in
+	if s.config.Auth.FailureDelay > 0 {
+		s.failures = auth.NewThrottle(s.config.Auth.FailureDelay, s.config.Auth.FailureMaxDelay, s.config.Auth.FailureForget)
 	}
+	// Response fields are filtered by the caller's roles and scopes when
+	// auth is on, as routes are
+	s.fields = fieldaccess.New(s.config.Auth, s.verifier != nil)
 }
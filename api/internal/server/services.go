@@ -0,0 +1,400 @@
+package server
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+
+	"starterkit/internal/accounts"
+	"starterkit/internal/ai"
+	"starterkit/internal/announcements"
+	"starterkit/internal/apikeys"
+	"starterkit/internal/archive"
+	"starterkit/internal/audit"
+	"starterkit/internal/auditexports"
+	"starterkit/internal/backup"
+	"starterkit/internal/clientsync"
+	"starterkit/internal/config"
+	"starterkit/internal/connectors"
+	"starterkit/internal/connectors/slack"
+	"starterkit/internal/connectors/statushooks"
+	"starterkit/internal/db"
+	"starterkit/internal/domains"
+	"starterkit/internal/email"
+	"starterkit/internal/jobs"
+	"starterkit/internal/killswitch"
+	"starterkit/internal/leader"
+	"starterkit/internal/loadtest"
+	"starterkit/internal/magiclinks"
+	"starterkit/internal/moderation"
+	"starterkit/internal/notifications"
+	"starterkit/internal/operations"
+	"starterkit/internal/passkeys"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/delta"
+	"starterkit/internal/platform/events"
+	"starterkit/internal/platform/geoip"
+	"starterkit/internal/platform/ids"
+	"starterkit/internal/platform/lock"
+	"starterkit/internal/platform/mailer"
+	"starterkit/internal/platform/moderator"
+	"starterkit/internal/platform/panics"
+	"starterkit/internal/platform/respcache"
+	"starterkit/internal/platform/scanner"
+	"starterkit/internal/platform/scheduler"
+	"starterkit/internal/platform/sms"
+	"starterkit/internal/platform/sse"
+	"starterkit/internal/platform/storage"
+	"starterkit/internal/platform/watchdog"
+	"starterkit/internal/platform/webpush"
+	"starterkit/internal/platform/wiring"
+	"starterkit/internal/probe"
+	"starterkit/internal/readonly"
+	"starterkit/internal/retention"
+	"starterkit/internal/risk"
+	"starterkit/internal/scopedtokens"
+	"starterkit/internal/search"
+	"starterkit/internal/serviceaccounts"
+	"starterkit/internal/sessions"
+	"starterkit/internal/sitemap"
+	"starterkit/internal/slo"
+	"starterkit/internal/templates"
+	"starterkit/internal/trash"
+	"starterkit/internal/uploads"
+	"starterkit/internal/users"
+	"starterkit/internal/workflow"
+)
+
+// common returns the components nearly every service depends on
+func common(c *wiring.Container) (*config.Config, *slog.Logger, *db.Queries) {
+	return wiring.Use[*config.Config](c), wiring.Use[*slog.Logger](c), wiring.Use[*db.Queries](c)
+}
+
+// provideServices registers the providers of the server's services. They
+// build on the infrastructure the caller supplies: the config, logger,
+// database pools and queries, and external providers such as storage and
+// the mailer.
+func provideServices(c *wiring.Container) {
+	// Integrations with external APIs enabled in CONNECTORS
+	wiring.Provide(c, func(c *wiring.Container) (*slack.Notifier, error) {
+		cfg, logger := wiring.Use[*config.Config](c), wiring.Use[*slog.Logger](c)
+		conn, ok := cfg.Connectors.Provider(slack.Name)
+		if !ok {
+			return nil, nil
+		}
+		notifier, err := slack.New(conn, cfg.Slack, logger)
+		if err != nil {
+			logger.Error("failed to initialize slack connector, alerts disabled", "error", err)
+			return nil, nil
+		}
+		return notifier, nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*statushooks.Notifier, error) {
+		cfg, logger := wiring.Use[*config.Config](c), wiring.Use[*slog.Logger](c)
+		notifier, err := statushooks.New(cfg, logger)
+		if err != nil {
+			logger.Error("failed to initialize status hooks, pings disabled", "error", err)
+			return nil, nil
+		}
+		return notifier, nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*connectors.Registry, error) {
+		registry := connectors.NewRegistry()
+		if notifier := wiring.Use[*slack.Notifier](c); notifier != nil {
+			registry.Register(notifier)
+		}
+		for _, conn := range wiring.Use[*mailer.Failover](c).Connectors() {
+			registry.Register(conn)
+		}
+		if assistant := wiring.Use[ai.Provider](c); assistant.Name() != "echo" {
+			registry.Register(assistant)
+		}
+		if embedder := wiring.Use[ai.Embedder](c); embedder.Name() != "hash" {
+			registry.Register(embedder)
+		}
+		return registry, nil
+	})
+
+	// Supervision of background work
+	wiring.Provide(c, func(c *wiring.Container) (*panics.Guard, error) {
+		var reporter panics.Reporter = panics.LogReporter{}
+		if notifier := wiring.Use[*slack.Notifier](c); notifier != nil {
+			reporter = slack.NewPanicReporter(reporter, notifier)
+		}
+		return panics.NewGuard(reporter), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*watchdog.Watchdog, error) {
+		cfg, logger := wiring.Use[*config.Config](c), wiring.Use[*slog.Logger](c)
+		return watchdog.New(logger, cfg.Watchdog.StallTimeout, cfg.Watchdog.MaxRestarts), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*leader.Elector, error) {
+		cfg, logger, queries := common(c)
+		return leader.NewElector(queries, "scheduler", cfg.Leader.ID, cfg.Leader.LeaseTTL, logger), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*scheduler.Scheduler, error) {
+		cfg, logger := wiring.Use[*config.Config](c), wiring.Use[*slog.Logger](c)
+		return scheduler.New(logger, wiring.Use[*panics.Guard](c), wiring.Use[*watchdog.Watchdog](c), wiring.Use[*leader.Elector](c), wiring.Use[lock.Locker](c), cfg.Lock.JobTTL), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*events.Bus, error) {
+		return events.NewBus(wiring.Use[*slog.Logger](c), wiring.Use[*panics.Guard](c)), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*slo.Tracker, error) {
+		return slo.NewTracker(wiring.Use[*config.Config](c).SLO.Window), nil
+	})
+
+	// Shared services
+	wiring.Provide(c, func(c *wiring.Container) (*database.TxManager, error) {
+		cfg, _, queries := common(c)
+		return database.NewTxManager(wiring.Use[*database.Pools](c), queries, wiring.Use[*database.ReadOnlyMode](c), cfg.Database.RetryAttempts, cfg.Database.RetryMaxBackoff), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (audit.Recorder, error) {
+		cfg, _, queries := common(c)
+		var auditor audit.Recorder = audit.NewService(queries)
+		if notifier := wiring.Use[*slack.Notifier](c); notifier != nil {
+			auditor = slack.NewAuditNotifier(auditor, notifier, cfg.Slack.AuditActions)
+		}
+		return auditor, nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*operations.Service, error) {
+		cfg, logger, queries := common(c)
+		return operations.NewService(queries, sse.NewHub(), logger, wiring.Use[*panics.Guard](c), cfg.Events.OperationTimeout, cfg.Events.UndoWindow), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*jobs.Queue, error) {
+		cfg, logger, queries := common(c)
+		return jobs.NewQueue(queries, wiring.Use[audit.Recorder](c), wiring.Use[*panics.Guard](c), cfg.Jobs, logger), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*workflow.Service, error) {
+		cfg, logger, queries := common(c)
+		auditor := wiring.Use[audit.Recorder](c)
+		service := workflow.NewService(queries, auditor, wiring.Use[*panics.Guard](c), logger, cfg.Workflow.MaxAttempts, cfg.Workflow.Lease, cfg.Workflow.Backoff)
+		service.Register(users.NewDeletionWorkflow(queries, auditor, cfg.Users.DeletionGrace))
+		return service, nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*moderation.Service, error) {
+		_, _, queries := common(c)
+		return moderation.NewService(queries, wiring.Use[moderator.Moderator](c), wiring.Use[audit.Recorder](c)), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*risk.Service, error) {
+		_, _, queries := common(c)
+		return risk.NewService(queries, wiring.Use[audit.Recorder](c), wiring.Use[risk.Policy](c)), nil
+	})
+
+	// Users, with profiles and phones stored as rows or as events
+	wiring.Provide(c, func(c *wiring.Container) (*users.EventSourcedQueries, error) {
+		cfg, _, queries := common(c)
+		return users.NewEventSourcedQueries(queries, wiring.Use[*database.Pools](c), cfg.Users.SnapshotEvery), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*users.ProfileService, error) {
+		cfg, _, queries := common(c)
+		var profileQueries users.ProfileQuerier = queries
+		if cfg.Users.Persistence == "events" {
+			profileQueries = wiring.Use[*users.EventSourcedQueries](c)
+		}
+		moderationService := wiring.Use[*moderation.Service](c)
+		service := users.NewProfileService(profileQueries, moderationService)
+		moderationService.RegisterRemover(users.ResourceType, service)
+		return service, nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*users.PhoneService, error) {
+		cfg, _, queries := common(c)
+		var phoneQueries users.PhoneQuerier = queries
+		if cfg.Users.Persistence == "events" {
+			phoneQueries = wiring.Use[*users.EventSourcedQueries](c)
+		}
+		return users.NewPhoneService(phoneQueries, wiring.Use[sms.Sender](c), cfg.Phone.DefaultRegion, cfg.Phone.VerificationTTL, cfg.Phone.MaxVerificationAttempts), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*users.HandleService, error) {
+		cfg, _, queries := common(c)
+		return users.NewHandleService(queries, wiring.Use[*database.TxManager](c), cfg.Users.ReservedHandles, cfg.Users.HandleCooldown, cfg.Users.HandleHold), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*users.ExternalIDService, error) {
+		_, _, queries := common(c)
+		return users.NewExternalIDService(queries, wiring.Use[*database.TxManager](c), wiring.Use[storage.Storage](c)), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*users.Service, error) {
+		cfg, _, queries := common(c)
+		emailChange := users.EmailChangeOptions{TTL: cfg.Users.EmailChangeTTL, ConfirmURL: cfg.Users.EmailChangeURL}
+		return users.NewService(queries, wiring.Use[*database.TxManager](c), wiring.Use[*moderation.Service](c), wiring.Use[*workflow.Service](c), wiring.Use[*email.Service](c), emailChange, ids.NewGenerator(cfg.Users.IDFormat)), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*users.BulkService, error) {
+		cfg, _, queries := common(c)
+		jobQueue := wiring.Use[*jobs.Queue](c)
+		service := users.NewBulkService(queries, wiring.Use[*database.TxManager](c), jobQueue, wiring.Use[*workflow.Service](c), cfg.Users.BulkBatchSize, cfg.Users.BulkMaxTargets)
+		jobQueue.Register(users.JobBulk, service.RunBatchJob)
+		return service, nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*sessions.Service, error) {
+		cfg, _, queries := common(c)
+		return sessions.NewService(queries, wiring.Use[*email.Service](c), cfg.Sessions), nil
+	})
+
+	// Data lifecycle
+	wiring.Provide(c, func(c *wiring.Container) (*retention.Service, error) {
+		_, _, queries := common(c)
+		service := retention.NewService(queries, wiring.Use[audit.Recorder](c))
+		wiring.Use[*operations.Service](c).RegisterAction(retention.OperationDeletePolicy, service.RunDeleteAction)
+		return service, nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*archive.Service, error) {
+		cfg, _, queries := common(c)
+		return archive.NewService(queries, wiring.Use[storage.Storage](c), cfg.Archive.BatchSize), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*trash.Service, error) {
+		cfg, _, queries := common(c)
+		return trash.NewService(queries, wiring.Use[audit.Recorder](c), cfg.Trash.Retention), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*backup.Service, error) {
+		cfg, logger, queries := common(c)
+		return backup.NewService(wiring.Use[*database.Pools](c), queries, wiring.Use[storage.Storage](c), wiring.Use[audit.Recorder](c), logger, cfg.Backup.RetainCount, cfg.Backup.RetainFor), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*loadtest.Service, error) {
+		cfg, _, _ := common(c)
+		return loadtest.NewService(wiring.Use[*database.Pools](c), wiring.Use[audit.Recorder](c), ids.NewGenerator(cfg.Users.IDFormat)), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*clientsync.Service, error) {
+		_, _, queries := common(c)
+		return clientsync.NewService(queries), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*uploads.Service, error) {
+		cfg, _, queries := common(c)
+		return uploads.NewService(queries, wiring.Use[storage.Storage](c), wiring.Use[scanner.Scanner](c), wiring.Use[audit.Recorder](c), cfg.Uploads.MaxSize, cfg.Uploads.ScanBatch, cfg.Uploads.ResumableMaxSize, cfg.Uploads.ResumableTTL), nil
+	})
+
+	// Messaging
+	wiring.Provide(c, func(c *wiring.Container) (*templates.Service, error) {
+		cfg, _, queries := common(c)
+		service := templates.NewService(queries, wiring.Use[audit.Recorder](c), cfg.Locale.SupportedLocales[0])
+		wiring.Use[*operations.Service](c).RegisterAction(templates.OperationDeleteTemplate, service.RunDeleteAction)
+		return service, nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*email.Service, error) {
+		cfg, logger, queries := common(c)
+		jobQueue := wiring.Use[*jobs.Queue](c)
+		service := email.NewService(queries, wiring.Use[*mailer.Failover](c), wiring.Use[storage.Storage](c), jobQueue, wiring.Use[audit.Recorder](c), cfg.Email, logger)
+		jobQueue.Register(email.JobSend, service.RunSendJob)
+		return service, nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*notifications.Service, error) {
+		cfg, logger, queries := common(c)
+		jobQueue := wiring.Use[*jobs.Queue](c)
+		service := notifications.NewService(queries, wiring.Use[*email.Service](c), wiring.Use[sms.Sender](c), webpush.New(cfg.Push), wiring.Use[*templates.Service](c), jobQueue, sse.NewHub(), logger)
+		jobQueue.Register(notifications.JobDeliver, service.RunDeliverJob)
+		return service, nil
+	})
+
+	// Public content
+	wiring.Provide(c, func(c *wiring.Container) (*announcements.Service, error) {
+		cfg, logger, queries := common(c)
+		return announcements.NewService(queries, wiring.Use[audit.Recorder](c), announcements.Options{
+			Title:    cfg.Announcements.FeedTitle,
+			BaseURL:  cfg.Announcements.BaseURL,
+			Size:     cfg.Announcements.FeedSize,
+			CacheTTL: cfg.Announcements.CacheTTL,
+		}, logger), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*sitemap.Service, error) {
+		cfg := wiring.Use[*config.Config](c)
+		return sitemapService(cfg.Sitemap, wiring.Use[*announcements.Service](c)), nil
+	})
+
+	// API access
+	wiring.Provide(c, func(c *wiring.Container) (*apikeys.Service, error) {
+		cfg, logger, queries := common(c)
+		return apikeys.NewService(queries, wiring.Use[audit.Recorder](c), cfg.APIKeys.RefreshInterval, logger), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*accounts.Service, error) {
+		cfg, logger, queries := common(c)
+		return accounts.NewService(queries, wiring.Use[*database.TxManager](c), wiring.Use[audit.Recorder](c), cfg.Accounts, logger), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*magiclinks.Service, error) {
+		cfg, logger, queries := common(c)
+		return magiclinks.NewService(queries, wiring.Use[*email.Service](c), wiring.Use[audit.Recorder](c), cfg.MagicLinks, logger), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*passkeys.Service, error) {
+		cfg, logger, queries := common(c)
+		return passkeys.NewService(queries, wiring.Use[*magiclinks.Service](c), wiring.Use[audit.Recorder](c), cfg.WebAuthn, logger), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*auth.Narrower, error) {
+		cfg := wiring.Use[*config.Config](c)
+		return auth.NewNarrower(cfg.Auth.NarrowingSecret, cfg.Auth.ClockSkew), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*scopedtokens.Service, error) {
+		cfg, logger := wiring.Use[*config.Config](c), wiring.Use[*slog.Logger](c)
+		return scopedtokens.NewService(wiring.Use[*auth.Narrower](c), wiring.Use[audit.Recorder](c), cfg.Auth.NarrowedTokenTTL, logger), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*serviceaccounts.Service, error) {
+		cfg, logger, queries := common(c)
+		return serviceaccounts.NewService(queries, wiring.Use[audit.Recorder](c), wiring.Use[*notifications.Service](c), cfg.ServiceAccounts, logger), nil
+	})
+
+	// Recurring exports of organizations' audit events
+	wiring.Provide(c, func(c *wiring.Container) (*auditexports.Service, error) {
+		cfg, logger, queries := common(c)
+		client := auditexports.NewWebhookClient(cfg.AuditExports.WebhookTimeout, cfg.AuditExports.WebhookAllowPrivate)
+		return auditexports.NewService(queries, wiring.Use[storage.Storage](c), wiring.Use[*email.Service](c), wiring.Use[audit.Recorder](c), client, cfg.AuditExports, logger), nil
+	})
+
+	// Custom domains tenants serve the app on
+	wiring.Provide(c, func(c *wiring.Container) (*domains.Service, error) {
+		cfg, logger, queries := common(c)
+		return domains.NewService(queries, wiring.Use[storage.Storage](c), wiring.Use[audit.Recorder](c), net.DefaultResolver, cfg.Domains, logger), nil
+	})
+
+	// Routes switched off by operators
+	wiring.Provide(c, func(c *wiring.Container) (*killswitch.Service, error) {
+		cfg, logger, queries := common(c)
+		return killswitch.NewService(queries, wiring.Use[audit.Recorder](c), cfg.KillSwitches.Routes, logger), nil
+	})
+
+	// Read-only mode for failovers and incidents
+	wiring.Provide(c, func(c *wiring.Container) (*readonly.Service, error) {
+		cfg, logger, queries := common(c)
+		return readonly.NewService(queries, wiring.Use[*database.ReadOnlyMode](c), wiring.Use[audit.Recorder](c), cfg.ReadOnly, logger), nil
+	})
+
+	// Responses to read routes configured for caching; nil when none are
+	wiring.Provide(c, func(c *wiring.Container) (*respcache.Cache, error) {
+		cfg := wiring.Use[*config.Config](c)
+		if len(cfg.ResponseCache.Routes) == 0 {
+			return nil, nil
+		}
+		return respcache.New(cfg.ResponseCache, wiring.Use[*panics.Guard](c))
+	})
+
+	// Patches against earlier versions for clients polling list routes;
+	// nil unless DELTA_ENABLED is set
+	wiring.Provide(c, func(c *wiring.Container) (*delta.Encoder, error) {
+		cfg := wiring.Use[*config.Config](c)
+		if !cfg.Delta.Enabled {
+			return nil, nil
+		}
+		return delta.New(cfg.Delta, cfg.ResponseCache.TenantHeader)
+	})
+
+	// Client location, for geo-blocking and request logs
+	wiring.Provide(c, func(c *wiring.Container) (*geoip.Resolver, error) {
+		cfg, logger := wiring.Use[*config.Config](c), wiring.Use[*slog.Logger](c)
+		if !cfg.GeoIP.Enabled {
+			return nil, nil
+		}
+		return geoip.New(cfg.GeoIP, logger), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*geoip.Policy, error) {
+		cfg := wiring.Use[*config.Config](c)
+		if !cfg.GeoBlock.Enabled {
+			return nil, nil
+		}
+		return geoip.NewPolicy(cfg.GeoBlock), nil
+	})
+
+	// End-to-end self-check and semantic search
+	wiring.Provide(c, func(c *wiring.Container) (*probe.Service, error) {
+		cfg, logger, queries := common(c)
+		return probe.NewService(queries, http.DefaultClient, cfg.Probe.BaseURL, cfg.Probe.Email, cfg.Probe.Token, logger), nil
+	})
+	wiring.Provide(c, func(c *wiring.Container) (*search.Indexer, error) {
+		cfg, logger, queries := common(c)
+		return search.NewIndexer(wiring.Use[ai.Embedder](c), queries, cfg.Embeddings.BatchSize, cfg.Embeddings.MaxChars, logger), nil
+	})
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/geoip"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/useragent"
+	"starterkit/internal/sessions"
+)
+
+// userAgentMiddleware parses the caller's User-Agent into the request
+// context and records the device of signed-in API callers as a session.
+// Requests from a session revoked through a login alert are refused; failing
+// to record a session never fails the request.
+func (s *Server) userAgentMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent := s.uaParser.Parse(r.UserAgent())
+		ctx := useragent.WithContext(r.Context(), agent)
+
+		email := r.Header.Get("X-User-Email")
+		if s.config.Sessions.Enabled && email != "" && strings.HasPrefix(r.URL.Path, "/api/") {
+			visit := sessions.Visit{
+				Email:     email,
+				SessionID: r.Header.Get("X-Session-ID"),
+				UserAgent: r.UserAgent(),
+				Agent:     agent,
+			}
+			if ip := s.clientIP(r); ip.IsValid() {
+				visit.IPAddress = ip.String()
+			}
+			if loc, ok := geoip.FromContext(ctx); ok {
+				visit.Country = loc.Country
+			}
+			if err := s.sessions.Touch(ctx, visit); err != nil {
+				if errors.Is(err, sessions.ErrSessionRevoked) {
+					apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "session_revoked", "session revoked"))
+					return
+				}
+				logger.FromContext(ctx).Warn("failed to record session", "error", err)
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"starterkit/internal/platform/auth"
+)
+
+// signIn guards a route that authenticates a caller by a secret it was
+// sent or holds, such as a sign-in link's token. Every answer takes at
+// least AUTH_RESPONSE_FLOOR, so an unknown account fails as slowly as a
+// wrong secret, and clients whose attempts keep failing wait out a growing,
+// jittered delay before each further attempt is answered. Any 4xx answer
+// but 429 counts as a failure.
+func (s *Server) signIn(h http.Handler) http.Handler {
+	floor := s.config.Auth.ResponseFloor
+	if floor <= 0 && s.failures == nil {
+		return h
+	}
+	return layer{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline := time.Now().Add(floor)
+		if s.failures != nil {
+			deadline = deadline.Add(s.failures.Delay(s.clientIP(r).String()))
+		}
+		fw := &floorWriter{ResponseWriter: w, r: r, deadline: deadline}
+		h.ServeHTTP(fw, r)
+		if fw.status == 0 {
+			fw.wait()
+		}
+		if s.failures != nil && fw.status >= 400 && fw.status < 500 && fw.status != http.StatusTooManyRequests {
+			s.failures.Failure(s.clientIP(r).String())
+		}
+	}), name: "sign-in", next: h}
+}
+
+// authFailed records a failed authentication by r's client and holds the
+// answer back by the client's delay. It reports false when the client went
+// away while waiting, leaving nothing to answer.
+func (s *Server) authFailed(r *http.Request) bool {
+	if s.failures == nil {
+		return true
+	}
+	client := s.clientIP(r).String()
+	delay := s.failures.Delay(client)
+	s.failures.Failure(client)
+	return auth.Wait(r.Context(), delay) == nil
+}
+
+// floorWriter holds the response headers back until the deadline
+type floorWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	deadline time.Time
+	status   int
+}
+
+func (w *floorWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= http.StatusOK {
+		w.status = code
+		w.wait()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *floorWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *floorWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *floorWriter) wait() {
+	_ = auth.Wait(w.r.Context(), time.Until(w.deadline))
+}
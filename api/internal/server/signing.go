@@ -0,0 +1,76 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/logger"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// nonceStore keeps the nonces of signed and replay-protected requests in
+// the database so replays are caught across instances
+type nonceStore struct {
+	queries *db.Queries
+}
+
+func (n nonceStore) UseNonce(ctx context.Context, clientID, nonce string, expires time.Time) (bool, error) {
+	rows, err := n.queries.UseRequestNonce(ctx, db.UseRequestNonceParams{
+		ClientID:  clientID,
+		Nonce:     nonce,
+		ExpiresAt: pgtype.Timestamptz{Time: expires, Valid: true},
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// pruneNonces deletes nonces whose requests would now be rejected for
+// their timestamp anyway
+func (n nonceStore) pruneNonces(ctx context.Context) error {
+	if _, err := n.queries.DeleteExpiredRequestNonces(ctx, pgtype.Timestamptz{Time: time.Now(), Valid: true}); err != nil {
+		return fmt.Errorf("failed to prune request nonces: %w", err)
+	}
+	return nil
+}
+
+// verifySignature authenticates a signed request. The body is read in full
+// to check its hash, up to the upload size limit, and then restored for
+// the handler.
+func (s *Server) verifySignature(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.config.Uploads.MaxSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apierror.Write(w, r, apierror.New(http.StatusRequestEntityTooLarge, "request_too_large", "request body too large"))
+		} else {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "failed to read request body"))
+		}
+		return nil, false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	principal, err := s.signatures.Verify(r.Context(), r, body)
+	if err != nil {
+		if !errors.Is(err, auth.ErrInvalidSignature) {
+			logger.FromContext(r.Context()).Error("failed to verify request signature", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return nil, false
+		}
+		logger.FromContext(r.Context()).Info("rejected request signature", "error", err)
+		w.Header().Set("WWW-Authenticate", `Signature realm="api"`)
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "invalid_signature", auth.ErrInvalidSignature.Error()))
+		return nil, false
+	}
+	return r.WithContext(auth.WithPrincipal(r.Context(), principal)), true
+}
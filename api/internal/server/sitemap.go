@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"starterkit/internal/announcements"
+	"starterkit/internal/config"
+	"starterkit/internal/sitemap"
+)
+
+// sitemapService lists the app's static pages and the pages on the site
+// that published announcements link to
+func sitemapService(cfg config.SitemapConfig, announcementService *announcements.Service) *sitemap.Service {
+	service := sitemap.NewService(sitemap.Options{
+		BaseURL:       cfg.BaseURL,
+		PageSize:      cfg.PageSize,
+		AllowIndexing: cfg.AllowIndexing,
+	})
+	service.Register("pages", sitemap.Static(cfg.BaseURL, cfg.Pages))
+	service.Register("announcements", announcementPages{service: announcementService, prefix: cfg.BaseURL + "/"})
+	return service
+}
+
+// announcementPages adapts announcement links to a sitemap source
+type announcementPages struct {
+	service *announcements.Service
+	prefix  string
+}
+
+func (a announcementPages) Pages(ctx context.Context, size int) ([]time.Time, error) {
+	return a.service.LinkPages(ctx, a.prefix, size)
+}
+
+func (a announcementPages) Entries(ctx context.Context, page, size int) ([]sitemap.Entry, error) {
+	linked, err := a.service.Links(ctx, a.prefix, page, size)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]sitemap.Entry, len(linked))
+	for i, l := range linked {
+		entries[i] = sitemap.Entry{URL: l.URL, LastModified: l.UpdatedAt}
+	}
+	return entries, nil
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"net/http"
+
+	"starterkit/internal/domains"
+)
+
+// tenantMiddleware resolves requests on a tenant's verified custom domain to
+// that tenant. The tenant header that the response cache reads is set from
+// the domain, replacing any the client sent, and cookies the response sets
+// follow the domain's cookie settings.
+func (s *Server) tenantMiddleware(next http.Handler) http.Handler {
+	if s.domains == nil {
+		return next
+	}
+	header := s.config.ResponseCache.TenantHeader
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d, ok := s.domains.Resolve(r.Host)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if header != "" {
+			r.Header.Set(header, d.TenantID)
+		}
+		next.ServeHTTP(domains.NewCookieWriter(w, d), r.WithContext(domains.WithDomain(r.Context(), d)))
+	})
+}
@@ -0,0 +1,204 @@
+package serviceaccounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	List(ctx context.Context) ([]Account, error)
+	Get(ctx context.Context, id uuid.UUID) (*Account, error)
+	Create(ctx context.Context, req AccountRequest, actor string) (*CreatedAccount, error)
+	SetScopes(ctx context.Context, id uuid.UUID, req ScopesRequest, actor string) (*Account, error)
+	Rotate(ctx context.Context, id uuid.UUID, actor string) (*IssuedCredential, error)
+	RevokeCredential(ctx context.Context, accountID, credentialID uuid.UUID, actor string) (*Credential, error)
+	Disable(ctx context.Context, id uuid.UUID, actor string) (*Account, error)
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) HandleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accounts, err := h.service.List(r.Context())
+		if err != nil {
+			h.respondWithAccountError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, accounts)
+	}
+}
+
+func (h *Handler) HandleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseID(w, r, "id")
+		if !ok {
+			return
+		}
+
+		account, err := h.service.Get(r.Context(), id)
+		if err != nil {
+			h.respondWithAccountError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, account)
+	}
+}
+
+// HandleCreate adds an account with its first credential; the response is
+// the only time the secret is shown
+func (h *Handler) HandleCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AccountRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		account, err := h.service.Create(r.Context(), req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithAccountError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusCreated, account)
+	}
+}
+
+func (h *Handler) HandleSetScopes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseID(w, r, "id")
+		if !ok {
+			return
+		}
+
+		var req ScopesRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		account, err := h.service.SetScopes(r.Context(), id, req, actorFromRequest(r))
+		if err != nil {
+			h.respondWithAccountError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, account)
+	}
+}
+
+// HandleRotate issues a new credential; the response is the only time its
+// secret is shown
+func (h *Handler) HandleRotate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseID(w, r, "id")
+		if !ok {
+			return
+		}
+
+		credential, err := h.service.Rotate(r.Context(), id, actorFromRequest(r))
+		if err != nil {
+			h.respondWithAccountError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusCreated, credential)
+	}
+}
+
+func (h *Handler) HandleRevokeCredential() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, ok := h.parseID(w, r, "id")
+		if !ok {
+			return
+		}
+		credentialID, ok := h.parseID(w, r, "credentialID")
+		if !ok {
+			return
+		}
+
+		credential, err := h.service.RevokeCredential(r.Context(), accountID, credentialID, actorFromRequest(r))
+		if err != nil {
+			h.respondWithAccountError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, credential)
+	}
+}
+
+func (h *Handler) HandleDisable() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseID(w, r, "id")
+		if !ok {
+			return
+		}
+
+		account, err := h.service.Disable(r.Context(), id, actorFromRequest(r))
+		if err != nil {
+			h.respondWithAccountError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, account)
+	}
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue(name))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_id", "invalid ID format"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) respondWithAccountError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrAccountNotFound):
+		apierror.Write(w, r, apierror.NotFound("account_not_found", err.Error()))
+	case errors.Is(err, ErrCredentialNotFound):
+		apierror.Write(w, r, apierror.NotFound("credential_not_found", err.Error()))
+	case errors.Is(err, ErrOwnerNotFound):
+		apierror.Write(w, r, apierror.Unprocessable("owner_not_found", err.Error()))
+	case errors.Is(err, ErrNameTaken):
+		apierror.Write(w, r, apierror.Conflict("name_taken", err.Error()))
+	case errors.Is(err, ErrAccountDisabled):
+		apierror.Write(w, r, apierror.Conflict("account_disabled", err.Error()))
+	default:
+		h.logger.Error("service account request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
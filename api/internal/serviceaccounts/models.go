@@ -0,0 +1,148 @@
+package serviceaccounts
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAccountNotFound    = errors.New("service account not found")
+	ErrCredentialNotFound = errors.New("service account credential not found")
+	ErrAccountDisabled    = errors.New("service account is disabled")
+	ErrNameTaken          = errors.New("service account name is already taken")
+	ErrOwnerNotFound      = errors.New("owner not found")
+	ErrInvalidCredential  = errors.New("invalid service account credential")
+)
+
+// Credential states
+const (
+	StatusActive  = "active"
+	StatusExpired = "expired"
+	StatusRevoked = "revoked"
+)
+
+const (
+	maxDescriptionLength = 500
+	maxScopes            = 50
+)
+
+var (
+	// accountName matches the names of service accounts
+	accountName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,99}$`)
+	// scopeName matches scopes such as users:read
+	scopeName = regexp.MustCompile(`^[a-z][a-z0-9_.:-]{0,99}$`)
+)
+
+// Account is a non-human caller holding a fixed set of scopes. Accounts
+// never hold roles.
+type Account struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Scopes      []string   `json:"scopes"`
+	OwnerID     *uuid.UUID `json:"owner_id"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DisabledAt  *time.Time `json:"disabled_at,omitempty"`
+	// LastUsedAt is the last use of any of the account's credentials
+	LastUsedAt  *time.Time   `json:"last_used_at"`
+	Credentials []Credential `json:"credentials"`
+}
+
+// Credential is a secret of an account, without the secret
+type Credential struct {
+	ID     uuid.UUID `json:"id"`
+	Prefix string    `json:"prefix"`
+	// Status is active, expired, or revoked
+	Status     string     `json:"status"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IssuedCredential is a new credential with its secret, which is shown only
+// once
+type IssuedCredential struct {
+	Credential
+	Secret string `json:"secret"`
+}
+
+// CreatedAccount is a new account with the secret of its first credential
+type CreatedAccount struct {
+	Account
+	Secret string `json:"secret"`
+}
+
+// Identity is the service account a request authenticated as
+type Identity struct {
+	AccountID    uuid.UUID
+	Name         string
+	Scopes       []string
+	CredentialID uuid.UUID
+	ExpiresAt    time.Time
+}
+
+// AccountRequest creates a service account
+type AccountRequest struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Scopes      []string  `json:"scopes"`
+	OwnerID     uuid.UUID `json:"owner_id"`
+}
+
+func (r *AccountRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Description = strings.TrimSpace(r.Description)
+	r.Scopes = normalizeScopes(r.Scopes)
+
+	var v request.Validation
+	v.Check(accountName.MatchString(r.Name), "name", "must be 1-100 lowercase letters, digits, or dashes")
+	v.Check(utf8.RuneCountInString(r.Description) <= maxDescriptionLength, "description", fmt.Sprintf("must be at most %d characters", maxDescriptionLength))
+	checkScopes(&v, r.Scopes)
+	v.Check(r.OwnerID != uuid.Nil, "owner_id", "is required")
+	return v.Err()
+}
+
+// ScopesRequest replaces the scopes of a service account
+type ScopesRequest struct {
+	Scopes []string `json:"scopes"`
+}
+
+func (r *ScopesRequest) Validate() error {
+	r.Scopes = normalizeScopes(r.Scopes)
+
+	var v request.Validation
+	checkScopes(&v, r.Scopes)
+	return v.Err()
+}
+
+func checkScopes(v *request.Validation, scopes []string) {
+	v.Check(len(scopes) > 0 && len(scopes) <= maxScopes, "scopes", fmt.Sprintf("must list 1-%d scopes", maxScopes))
+	for _, scope := range scopes {
+		if !scopeName.MatchString(scope) {
+			v.Check(false, "scopes", "must be scope names such as users:read: "+scope)
+			break
+		}
+	}
+}
+
+// normalizeScopes trims, sorts, and deduplicates scopes
+func normalizeScopes(scopes []string) []string {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		normalized = append(normalized, strings.TrimSpace(scope))
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
@@ -0,0 +1,473 @@
+package serviceaccounts
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/jobs"
+	"starterkit/internal/notifications"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// secretPrefix marks credentials, so they are told apart from the identity
+// provider's tokens and are recognizable in leaked logs or source code
+const secretPrefix = "sa_"
+
+// prefixLength is the leading part of a secret shown in listings
+const prefixLength = len(secretPrefix) + 8
+
+// touchInterval is how often each replica writes a credential's last use
+const touchInterval = time.Minute
+
+type Querier interface {
+	CreateServiceAccount(ctx context.Context, arg db.CreateServiceAccountParams) (db.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]db.ServiceAccount, error)
+	GetServiceAccount(ctx context.Context, id pgtype.UUID) (db.ServiceAccount, error)
+	SetServiceAccountScopes(ctx context.Context, arg db.SetServiceAccountScopesParams) (db.ServiceAccount, error)
+	DisableServiceAccount(ctx context.Context, id pgtype.UUID) (db.ServiceAccount, error)
+	IssueServiceAccountCredential(ctx context.Context, arg db.IssueServiceAccountCredentialParams) (db.ServiceAccountCredential, error)
+	ListServiceAccountCredentials(ctx context.Context, accountIds []pgtype.UUID) ([]db.ServiceAccountCredential, error)
+	RevokeServiceAccountCredential(ctx context.Context, arg db.RevokeServiceAccountCredentialParams) (db.ServiceAccountCredential, error)
+	GetActiveServiceAccountCredential(ctx context.Context, secretHash string) (db.GetActiveServiceAccountCredentialRow, error)
+	TouchServiceAccountCredential(ctx context.Context, id pgtype.UUID) error
+	ListExpiringServiceAccountCredentials(ctx context.Context, expiresAt pgtype.Timestamptz) ([]db.ListExpiringServiceAccountCredentialsRow, error)
+	MarkServiceAccountCredentialWarned(ctx context.Context, id pgtype.UUID) error
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Notifier queues notifications to users
+type Notifier interface {
+	NotifyLater(ctx context.Context, userID uuid.UUID, msg notifications.Message, idempotencyKey string) (*jobs.Job, error)
+}
+
+// Service manages service accounts and authenticates their credentials.
+// Credential lookups are cached for the refresh interval and dropped on
+// changes made through this replica, so a revocation through another
+// replica applies after the interval.
+type Service struct {
+	queries  Querier
+	auditor  Auditor
+	notifier Notifier
+	cfg      config.ServiceAccountsConfig
+	logger   *slog.Logger
+
+	mu          sync.Mutex
+	credentials map[string]cachedCredential
+	touched     map[uuid.UUID]time.Time
+}
+
+type cachedCredential struct {
+	identity  *Identity
+	fetchedAt time.Time
+}
+
+func NewService(queries Querier, auditor Auditor, notifier Notifier, cfg config.ServiceAccountsConfig, logger *slog.Logger) *Service {
+	return &Service{
+		queries:     queries,
+		auditor:     auditor,
+		notifier:    notifier,
+		cfg:         cfg,
+		logger:      logger,
+		credentials: make(map[string]cachedCredential),
+		touched:     make(map[uuid.UUID]time.Time),
+	}
+}
+
+// IsCredential reports whether a bearer token is a service account
+// credential rather than a token of the identity provider
+func IsCredential(token string) bool {
+	return strings.HasPrefix(token, secretPrefix)
+}
+
+// Authenticate returns the account holding secret, if the credential is
+// active and the account enabled, and records the credential's use
+func (s *Service) Authenticate(ctx context.Context, secret string) (*Identity, error) {
+	identity, err := s.lookup(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	// Cached credentials may have expired since they were read
+	if !time.Now().Before(identity.ExpiresAt) {
+		return nil, ErrInvalidCredential
+	}
+
+	if s.due(identity.CredentialID, time.Now()) {
+		if err := s.queries.TouchServiceAccountCredential(ctx, pgtype.UUID{Bytes: identity.CredentialID, Valid: true}); err != nil {
+			s.logger.Warn("failed to record service account credential use", "error", err, "credential_id", identity.CredentialID)
+		}
+	}
+	return identity, nil
+}
+
+// List returns every account with its credentials, ordered by name
+func (s *Service) List(ctx context.Context) ([]Account, error) {
+	rows, err := s.queries.ListServiceAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+	return s.withCredentials(ctx, rows)
+}
+
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Account, error) {
+	row, err := s.queries.GetServiceAccount(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to get service account: %w", err)
+	}
+	accounts, err := s.withCredentials(ctx, []db.ServiceAccount{row})
+	if err != nil {
+		return nil, err
+	}
+	return &accounts[0], nil
+}
+
+// Create adds an account and issues its first credential
+func (s *Service) Create(ctx context.Context, req AccountRequest, actor string) (*CreatedAccount, error) {
+	row, err := s.queries.CreateServiceAccount(ctx, db.CreateServiceAccountParams{
+		Name:        req.Name,
+		Description: req.Description,
+		Scopes:      req.Scopes,
+		OwnerID:     pgtype.UUID{Bytes: req.OwnerID, Valid: true},
+		CreatedBy:   actor,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return nil, ErrNameTaken
+			case "23503":
+				return nil, ErrOwnerNotFound
+			}
+		}
+		return nil, fmt.Errorf("failed to create service account: %w", err)
+	}
+
+	account := toAccount(row)
+	s.record(ctx, actor, "service_account.create", account.ID.String(), map[string]any{"name": account.Name, "scopes": account.Scopes})
+
+	credential, err := s.issue(ctx, account.ID, actor)
+	if err != nil {
+		return nil, err
+	}
+	account.Credentials = []Credential{credential.Credential}
+	return &CreatedAccount{Account: *account, Secret: credential.Secret}, nil
+}
+
+// SetScopes replaces the scopes of an enabled account. Requests made with
+// its credentials get the new scopes within the refresh interval.
+func (s *Service) SetScopes(ctx context.Context, id uuid.UUID, req ScopesRequest, actor string) (*Account, error) {
+	row, err := s.queries.SetServiceAccountScopes(ctx, db.SetServiceAccountScopesParams{
+		ID:     pgtype.UUID{Bytes: id, Valid: true},
+		Scopes: req.Scopes,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, s.unavailable(ctx, id)
+		}
+		return nil, fmt.Errorf("failed to change service account scopes: %w", err)
+	}
+
+	s.forget(id)
+	s.record(ctx, actor, "service_account.scopes", id.String(), map[string]any{"scopes": req.Scopes})
+	return s.Get(ctx, uuid.UUID(row.ID.Bytes))
+}
+
+// Rotate issues a new credential. The account's earlier credentials keep
+// working for the rotation grace period, so callers can switch to the new
+// secret without downtime.
+func (s *Service) Rotate(ctx context.Context, id uuid.UUID, actor string) (*IssuedCredential, error) {
+	credential, err := s.issue(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+	s.forget(id)
+	return credential, nil
+}
+
+// RevokeCredential stops one of an account's credentials from being
+// accepted
+func (s *Service) RevokeCredential(ctx context.Context, accountID, credentialID uuid.UUID, actor string) (*Credential, error) {
+	row, err := s.queries.RevokeServiceAccountCredential(ctx, db.RevokeServiceAccountCredentialParams{
+		ID:        pgtype.UUID{Bytes: credentialID, Valid: true},
+		AccountID: pgtype.UUID{Bytes: accountID, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCredentialNotFound
+		}
+		return nil, fmt.Errorf("failed to revoke service account credential: %w", err)
+	}
+
+	s.forget(accountID)
+	s.record(ctx, actor, "service_account.revoke_credential", accountID.String(), map[string]any{"credential_id": credentialID.String()})
+	credential := toCredential(row, time.Now())
+	return &credential, nil
+}
+
+// Disable revokes every credential of an account and refuses new ones
+func (s *Service) Disable(ctx context.Context, id uuid.UUID, actor string) (*Account, error) {
+	if _, err := s.queries.DisableServiceAccount(ctx, pgtype.UUID{Bytes: id, Valid: true}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to disable service account: %w", err)
+	}
+
+	s.forget(id)
+	s.record(ctx, actor, "service_account.disable", id.String(), nil)
+	return s.Get(ctx, id)
+}
+
+// WarnExpiring notifies the owners of accounts whose credentials expire
+// within the warning period. Each credential is warned about once;
+// credentials replaced by a rotation are not warned about.
+func (s *Service) WarnExpiring(ctx context.Context) error {
+	rows, err := s.queries.ListExpiringServiceAccountCredentials(ctx, pgtype.Timestamptz{Time: time.Now().Add(s.cfg.ExpiryWarning), Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to list expiring service account credentials: %w", err)
+	}
+
+	for _, row := range rows {
+		credentialID := uuid.UUID(row.ID.Bytes)
+		if row.OwnerID.Valid {
+			msg := notifications.Message{
+				Title: "Service account credential expiring",
+				Body: fmt.Sprintf("Credential %s of service account %s expires on %s UTC. Rotate it before then to keep the account working.",
+					row.Prefix, row.Name, row.ExpiresAt.Time.UTC().Format(time.DateTime)),
+			}
+			if _, err := s.notifier.NotifyLater(ctx, uuid.UUID(row.OwnerID.Bytes), msg, "service-account-expiry:"+credentialID.String()); err != nil {
+				s.logger.Warn("failed to queue service account expiry warning", "error", err, "credential_id", credentialID)
+				continue
+			}
+		} else {
+			s.logger.Warn("service account credential expiring without an owner to warn", "account", row.Name, "credential_id", credentialID, "expires_at", row.ExpiresAt.Time)
+		}
+
+		if err := s.queries.MarkServiceAccountCredentialWarned(ctx, row.ID); err != nil {
+			return fmt.Errorf("failed to mark service account credential warned: %w", err)
+		}
+	}
+	return nil
+}
+
+// issue creates a credential for an enabled account, shortening the
+// expiry of its earlier credentials to the rotation grace period
+func (s *Service) issue(ctx context.Context, accountID uuid.UUID, actor string) (*IssuedCredential, error) {
+	now := time.Now()
+	secret := newSecret()
+	row, err := s.queries.IssueServiceAccountCredential(ctx, db.IssueServiceAccountCredentialParams{
+		ReplacedUntil: pgtype.Timestamptz{Time: now.Add(s.cfg.RotationGrace), Valid: true},
+		AccountID:     pgtype.UUID{Bytes: accountID, Valid: true},
+		Prefix:        secret[:prefixLength],
+		SecretHash:    hash(secret),
+		CreatedBy:     actor,
+		ExpiresAt:     pgtype.Timestamptz{Time: now.Add(s.cfg.CredentialTTL), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, s.unavailable(ctx, accountID)
+		}
+		return nil, fmt.Errorf("failed to issue service account credential: %w", err)
+	}
+
+	credential := toCredential(row, now)
+	s.record(ctx, actor, "service_account.issue_credential", accountID.String(), map[string]any{
+		"credential_id": credential.ID.String(),
+		"expires_at":    credential.ExpiresAt,
+	})
+	return &IssuedCredential{Credential: credential, Secret: secret}, nil
+}
+
+// unavailable explains why an update matched no enabled account
+func (s *Service) unavailable(ctx context.Context, id uuid.UUID) error {
+	account, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if account.DisabledAt != nil {
+		return ErrAccountDisabled
+	}
+	return ErrAccountNotFound
+}
+
+// withCredentials converts rows to accounts with their credentials
+func (s *Service) withCredentials(ctx context.Context, rows []db.ServiceAccount) ([]Account, error) {
+	ids := make([]pgtype.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	credentials, err := s.queries.ListServiceAccountCredentials(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service account credentials: %w", err)
+	}
+	byAccount := make(map[uuid.UUID][]db.ServiceAccountCredential, len(rows))
+	for _, c := range credentials {
+		id := uuid.UUID(c.AccountID.Bytes)
+		byAccount[id] = append(byAccount[id], c)
+	}
+
+	now := time.Now()
+	accounts := make([]Account, 0, len(rows))
+	for _, row := range rows {
+		account := toAccount(row)
+		for _, c := range byAccount[account.ID] {
+			credential := toCredential(c, now)
+			account.Credentials = append(account.Credentials, credential)
+			if last := credential.LastUsedAt; last != nil && (account.LastUsedAt == nil || last.After(*account.LastUsedAt)) {
+				account.LastUsedAt = last
+			}
+		}
+		accounts = append(accounts, *account)
+	}
+	return accounts, nil
+}
+
+// lookup finds the active credential with secret, from the cache while it
+// is fresh
+func (s *Service) lookup(ctx context.Context, secret string) (*Identity, error) {
+	if !IsCredential(secret) {
+		return nil, ErrInvalidCredential
+	}
+	h := hash(secret)
+
+	s.mu.Lock()
+	cached, ok := s.credentials[h]
+	s.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < s.cfg.RefreshInterval {
+		return cached.identity, nil
+	}
+
+	row, err := s.queries.GetActiveServiceAccountCredential(ctx, h)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.mu.Lock()
+			delete(s.credentials, h)
+			s.mu.Unlock()
+			return nil, ErrInvalidCredential
+		}
+		return nil, fmt.Errorf("failed to look up service account credential: %w", err)
+	}
+
+	identity := &Identity{
+		AccountID:    uuid.UUID(row.AccountID.Bytes),
+		Name:         row.Name,
+		Scopes:       row.Scopes,
+		CredentialID: uuid.UUID(row.ID.Bytes),
+		ExpiresAt:    row.ExpiresAt.Time,
+	}
+	s.mu.Lock()
+	s.credentials[h] = cachedCredential{identity: identity, fetchedAt: time.Now()}
+	s.mu.Unlock()
+	return identity, nil
+}
+
+// due reports whether a credential's last use should be written, at most
+// once per touch interval
+func (s *Service) due(id uuid.UUID, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.touched[id]; ok && now.Sub(last) < touchInterval {
+		return false
+	}
+	s.touched[id] = now
+	return true
+}
+
+// forget drops the cached credentials of an account
+func (s *Service) forget(accountID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for h, cached := range s.credentials {
+		if cached.identity.AccountID == accountID {
+			delete(s.credentials, h)
+			delete(s.touched, cached.identity.CredentialID)
+		}
+	}
+}
+
+func (s *Service) record(ctx context.Context, actor, action, accountID string, metadata map[string]any) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "service_account",
+		ResourceID:   accountID,
+		Metadata:     metadata,
+	}); err != nil {
+		s.logger.Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+func newSecret() string {
+	return secretPrefix + strings.ToLower(rand.Text())
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func toAccount(row db.ServiceAccount) *Account {
+	account := &Account{
+		ID:          uuid.UUID(row.ID.Bytes),
+		Name:        row.Name,
+		Description: row.Description,
+		Scopes:      row.Scopes,
+		CreatedBy:   row.CreatedBy,
+		CreatedAt:   row.CreatedAt.Time,
+		Credentials: []Credential{},
+	}
+	if account.Scopes == nil {
+		account.Scopes = []string{}
+	}
+	if row.OwnerID.Valid {
+		owner := uuid.UUID(row.OwnerID.Bytes)
+		account.OwnerID = &owner
+	}
+	if row.DisabledAt.Valid {
+		account.DisabledAt = &row.DisabledAt.Time
+	}
+	return account
+}
+
+func toCredential(row db.ServiceAccountCredential, now time.Time) Credential {
+	credential := Credential{
+		ID:        uuid.UUID(row.ID.Bytes),
+		Prefix:    row.Prefix,
+		Status:    StatusActive,
+		CreatedBy: row.CreatedBy,
+		CreatedAt: row.CreatedAt.Time,
+		ExpiresAt: row.ExpiresAt.Time,
+	}
+	if row.LastUsedAt.Valid {
+		credential.LastUsedAt = &row.LastUsedAt.Time
+	}
+	switch {
+	case row.RevokedAt.Valid:
+		credential.RevokedAt = &row.RevokedAt.Time
+		credential.Status = StatusRevoked
+	case !now.Before(credential.ExpiresAt):
+		credential.Status = StatusExpired
+	}
+	return credential
+}
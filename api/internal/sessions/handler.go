@@ -0,0 +1,186 @@
+package sessions
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"starterkit/internal/platform/apierror"
+)
+
+// revokePage asks the user to confirm signing out a session from a login
+// alert. Revoking takes a POST, so link scanners that follow the emailed
+// link do not sign the session out.
+var revokePage = template.Must(template.New("revoke").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .Token}}<form method="post" action="/security/revoke">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">Sign out that session</button>
+</form>{{end}}
+</body>
+</html>
+`))
+
+type revokePageData struct {
+	Title   string
+	Message string
+	Token   string
+}
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleListMine lists the devices the caller has made requests from
+func (h *Handler) HandleListMine() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current := Key(r.Header.Get("X-Session-ID"), r.UserAgent())
+		sessions, err := h.service.List(r.Context(), actorFromRequest(r), current)
+		if err != nil {
+			if errors.Is(err, ErrUnauthenticated) {
+				apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "unauthenticated", err.Error()))
+				return
+			}
+			h.logger.Error("failed to list sessions", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, map[string]any{"sessions": sessions})
+	}
+}
+
+// HandleDeviceAnalytics breaks down recently active sessions by browser,
+// operating system, and device class; window defaults to 30 days
+func (h *Handler) HandleDeviceAnalytics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		window := 30 * 24 * time.Hour
+		if raw := r.URL.Query().Get("window"); raw != "" {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid window parameter"))
+				return
+			}
+			window = parsed
+		}
+
+		analytics, err := h.service.DeviceAnalytics(r.Context(), window)
+		if err != nil {
+			if errors.Is(err, ErrInvalidWindow) {
+				apierror.Write(w, r, apierror.BadRequest("invalid_window", err.Error()))
+				return
+			}
+			h.logger.Error("failed to get device analytics", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, analytics)
+	}
+}
+
+// HandleListSecurityEvents lists the caller's recent logins and session
+// revocations
+func (h *Handler) HandleListSecurityEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		events, err := h.service.ListSecurityEvents(r.Context(), actorFromRequest(r))
+		if err != nil {
+			if errors.Is(err, ErrUnauthenticated) {
+				apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "unauthenticated", err.Error()))
+				return
+			}
+			h.logger.Error("failed to list security events", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, map[string]any{"security_events": events})
+	}
+}
+
+// HandleRevokePage shows the confirmation page a login alert's link opens
+func (h *Handler) HandleRevokePage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			h.respondWithPage(w, http.StatusBadRequest, revokePageData{
+				Title:   "Invalid link",
+				Message: ErrInvalidRevokeLink.Error() + ".",
+			})
+			return
+		}
+		h.respondWithPage(w, http.StatusOK, revokePageData{
+			Title:   "Wasn't you?",
+			Message: "Signing out will end the session that was signed in from the new device or location.",
+			Token:   token,
+		})
+	}
+}
+
+// HandleRevoke signs out the session of a login alert's token
+func (h *Handler) HandleRevoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, 4096)
+		if err := r.ParseForm(); err != nil {
+			h.respondWithPage(w, http.StatusBadRequest, revokePageData{
+				Title:   "Invalid link",
+				Message: ErrInvalidRevokeLink.Error() + ".",
+			})
+			return
+		}
+
+		if _, err := h.service.RevokeWithToken(r.Context(), r.PostForm.Get("token")); err != nil {
+			if errors.Is(err, ErrInvalidRevokeLink) {
+				h.respondWithPage(w, http.StatusBadRequest, revokePageData{
+					Title:   "Invalid link",
+					Message: err.Error() + ".",
+				})
+				return
+			}
+			h.logger.Error("failed to revoke session", "error", err)
+			h.respondWithPage(w, http.StatusInternalServerError, revokePageData{
+				Title:   "Something went wrong",
+				Message: "The session could not be signed out. Please try again.",
+			})
+			return
+		}
+		h.respondWithPage(w, http.StatusOK, revokePageData{
+			Title:   "Session signed out",
+			Message: "The session was signed out. Review your account's recent activity for anything else you don't recognize.",
+		})
+	}
+}
+
+func (h *Handler) respondWithPage(w http.ResponseWriter, code int, data revokePageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(code)
+	if err := revokePage.Execute(w, data); err != nil {
+		h.logger.Error("failed to render revoke page", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
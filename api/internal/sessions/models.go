@@ -0,0 +1,96 @@
+package sessions
+
+import (
+	"errors"
+	"time"
+
+	"starterkit/internal/platform/useragent"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnauthenticated   = errors.New("X-User-Email header is required")
+	ErrInvalidWindow     = errors.New("window must be a positive duration")
+	ErrSessionRevoked    = errors.New("session was revoked")
+	ErrInvalidRevokeLink = errors.New("revoke link is invalid, expired, or already used")
+)
+
+// Security event kinds
+const (
+	// EventLogin is a visit from a network, device, or country combination
+	// the user has not been seen with before
+	EventLogin = "login"
+	// EventAnomalousLogin is a login from a new device or country, which the
+	// user is alerted about
+	EventAnomalousLogin = "anomalous_login"
+	// EventSessionRevoked is a session signed out through an alert's link
+	EventSessionRevoked = "session_revoked"
+)
+
+// Reasons a login differs from the user's earlier ones
+const (
+	ReasonNewNetwork = "new_network"
+	ReasonNewDevice  = "new_device"
+	ReasonNewCountry = "new_country"
+)
+
+// Session is a device a user has made requests from
+type Session struct {
+	ID             uuid.UUID `json:"id"`
+	Browser        string    `json:"browser"`
+	BrowserVersion string    `json:"browser_version"`
+	OS             string    `json:"os"`
+	DeviceClass    string    `json:"device_class"`
+	UserAgent      string    `json:"user_agent"`
+	IPAddress      *string   `json:"ip_address,omitempty"`
+	Country        *string   `json:"country,omitempty"`
+	// Current marks the session of the request listing them
+	Current     bool      `json:"current"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	// RevokedAt is when the session was signed out through a login alert
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// SecurityEvent is a login or session revocation shown to the user
+type SecurityEvent struct {
+	ID   uuid.UUID `json:"id"`
+	Kind string    `json:"kind"`
+	// Reasons list how a login differs from the user's earlier ones
+	Reasons   []string  `json:"reasons"`
+	Device    string    `json:"device"`
+	IPAddress *string   `json:"ip_address,omitempty"`
+	Country   *string   `json:"country,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// RevokedAt is when the login's session was signed out through the
+	// alert's link
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Visit is one request by a signed-in user
+type Visit struct {
+	Email string
+	// SessionID is the client's X-Session-ID header, if it sends one
+	SessionID string
+	UserAgent string
+	Agent     useragent.Agent
+	IPAddress string
+	Country   string
+}
+
+// DeviceCount is how many sessions and users were seen with one browser,
+// operating system, or device class
+type DeviceCount struct {
+	Value    string `json:"value"`
+	Sessions int64  `json:"sessions"`
+	Users    int64  `json:"users"`
+}
+
+// DeviceAnalytics breaks down the sessions active since Since
+type DeviceAnalytics struct {
+	Since         time.Time     `json:"since"`
+	Browsers      []DeviceCount `json:"browsers"`
+	OS            []DeviceCount `json:"os"`
+	DeviceClasses []DeviceCount `json:"device_classes"`
+}
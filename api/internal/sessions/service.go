@@ -0,0 +1,456 @@
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/email"
+	"starterkit/internal/platform/kpi"
+	"starterkit/internal/platform/useragent"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxTracked bounds the last-touch times kept in memory; older ones are
+// dropped once it is reached
+const maxTracked = 10000
+
+// activeWindow is how recently a session must have been seen to count as
+// active in the business metrics
+const activeWindow = 15 * time.Minute
+
+type Queries interface {
+	TouchUserSession(ctx context.Context, arg db.TouchUserSessionParams) (bool, error)
+	ListUserSessions(ctx context.Context, userEmail string) ([]db.UserSession, error)
+	ListUserSessionsForEmails(ctx context.Context, userEmails []string) ([]db.UserSession, error)
+	CountSessionsByDevice(ctx context.Context, since pgtype.Timestamptz) ([]db.CountSessionsByDeviceRow, error)
+	CountActiveSessions(ctx context.Context, since pgtype.Timestamptz) (int64, error)
+	PruneUserSessions(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	UpsertLoginFingerprint(ctx context.Context, arg db.UpsertLoginFingerprintParams) (bool, error)
+	CountLoginFingerprintMatches(ctx context.Context, arg db.CountLoginFingerprintMatchesParams) (db.CountLoginFingerprintMatchesRow, error)
+	PruneLoginFingerprints(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+	CreateSecurityEvent(ctx context.Context, arg db.CreateSecurityEventParams) (db.SecurityEvent, error)
+	ListSecurityEvents(ctx context.Context, userEmail string) ([]db.SecurityEvent, error)
+	UseRevokeToken(ctx context.Context, arg db.UseRevokeTokenParams) (db.SecurityEvent, error)
+	PruneSecurityEvents(ctx context.Context, before pgtype.Timestamptz) (int64, error)
+}
+
+// Mailer queues outbound email
+type Mailer interface {
+	Queue(ctx context.Context, msg email.Email) (*email.Message, error)
+}
+
+type Service struct {
+	queries Queries
+	mail    Mailer
+	cfg     config.SessionsConfig
+
+	mu      sync.Mutex
+	touched map[string]time.Time
+	// revoked are the sessions this replica has found revoked
+	revoked map[string]bool
+}
+
+func NewService(queries Queries, mail Mailer, cfg config.SessionsConfig) *Service {
+	return &Service{
+		queries: queries,
+		mail:    mail,
+		cfg:     cfg,
+		touched: make(map[string]time.Time),
+		revoked: make(map[string]bool),
+	}
+}
+
+// Key identifies a session: the client's session ID when it sends one,
+// otherwise its User-Agent, so each browser counts as one device
+func Key(sessionID, userAgent string) string {
+	if sessionID != "" {
+		return "id:" + sessionID
+	}
+	sum := sha256.Sum256([]byte(userAgent))
+	return "ua:" + hex.EncodeToString(sum[:8])
+}
+
+// Touch records a visit. Each session is written at most once per touch
+// interval per replica, so busy clients do not write on every request. A
+// visit from a new network, device, or country is recorded as a security
+// event. Visits from a revoked session return ErrSessionRevoked; other
+// replicas find a session revoked on their next write of it.
+func (s *Service) Touch(ctx context.Context, visit Visit) error {
+	key := Key(visit.SessionID, visit.UserAgent)
+	session := visit.Email + "\x00" + key
+	if s.isRevoked(session) {
+		return ErrSessionRevoked
+	}
+	if !s.due(session, time.Now()) {
+		return nil
+	}
+
+	revoked, err := s.queries.TouchUserSession(ctx, db.TouchUserSessionParams{
+		UserEmail:      visit.Email,
+		SessionKey:     key,
+		UserAgent:      visit.UserAgent,
+		Browser:        visit.Agent.Browser,
+		BrowserVersion: visit.Agent.BrowserVersion,
+		Os:             visit.Agent.OS,
+		DeviceClass:    visit.Agent.DeviceClass,
+		IpAddress:      pgtype.Text{String: visit.IPAddress, Valid: visit.IPAddress != ""},
+		Country:        pgtype.Text{String: visit.Country, Valid: visit.Country != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record session: %w", err)
+	}
+	if revoked {
+		s.markRevoked(session)
+		return ErrSessionRevoked
+	}
+	return s.observe(ctx, visit, key)
+}
+
+// observe records the fingerprint of a visit and, the first time the user is
+// seen with it, a login event. Logins from a new device or country, once the
+// user has logged in before, are anomalous and alert the user by email.
+func (s *Service) observe(ctx context.Context, visit Visit, key string) error {
+	fingerprint := db.UpsertLoginFingerprintParams{
+		UserEmail: visit.Email,
+		IpRange:   ipRange(visit.IPAddress),
+		Device:    Device(visit.Agent),
+		Country:   visit.Country,
+	}
+	inserted, err := s.queries.UpsertLoginFingerprint(ctx, fingerprint)
+	if err != nil {
+		return fmt.Errorf("failed to record login fingerprint: %w", err)
+	}
+	if !inserted {
+		return nil
+	}
+
+	matches, err := s.queries.CountLoginFingerprintMatches(ctx, db.CountLoginFingerprintMatchesParams(fingerprint))
+	if err != nil {
+		return fmt.Errorf("failed to compare login fingerprint: %w", err)
+	}
+	reasons := []string{}
+	if matches.Known > 0 {
+		if matches.SameNetwork == 0 {
+			reasons = append(reasons, ReasonNewNetwork)
+		}
+		if matches.SameDevice == 0 {
+			reasons = append(reasons, ReasonNewDevice)
+		}
+		if fingerprint.Country != "" && matches.SameCountry == 0 {
+			reasons = append(reasons, ReasonNewCountry)
+		}
+	}
+	anomalous := slices.Contains(reasons, ReasonNewDevice) || slices.Contains(reasons, ReasonNewCountry)
+
+	event := db.CreateSecurityEventParams{
+		UserEmail:  visit.Email,
+		Kind:       EventLogin,
+		Reasons:    reasons,
+		SessionKey: key,
+		Device:     fingerprint.Device,
+		IpAddress:  pgtype.Text{String: visit.IPAddress, Valid: visit.IPAddress != ""},
+		Country:    pgtype.Text{String: visit.Country, Valid: visit.Country != ""},
+	}
+	var token string
+	if anomalous {
+		event.Kind = EventAnomalousLogin
+		if s.cfg.LoginAlerts && s.mail != nil {
+			token = strings.ToLower(rand.Text())
+			event.RevokeTokenHash = pgtype.Text{String: hashToken(token), Valid: true}
+		}
+	}
+	if _, err := s.queries.CreateSecurityEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to record security event: %w", err)
+	}
+	if token == "" {
+		return nil
+	}
+
+	if _, err := s.mail.Queue(ctx, email.Email{
+		To:      visit.Email,
+		Subject: "New sign-in to your account",
+		Body:    s.alertBody(event, token),
+	}); err != nil {
+		return fmt.Errorf("failed to queue login alert: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) alertBody(event db.CreateSecurityEventParams, token string) string {
+	var b strings.Builder
+	b.WriteString("Your account was used from a new device or location.\n\n")
+	fmt.Fprintf(&b, "Device: %s\n", event.Device)
+	if event.IpAddress.Valid {
+		fmt.Fprintf(&b, "IP address: %s\n", event.IpAddress.String)
+	}
+	if event.Country.Valid {
+		fmt.Fprintf(&b, "Country: %s\n", event.Country.String)
+	}
+	fmt.Fprintf(&b, "Time: %s\n\n", time.Now().UTC().Format(time.RFC1123))
+	b.WriteString("If this was you, no action is needed. If it wasn't, sign out that session:\n")
+	fmt.Fprintf(&b, "%s/security/revoke?token=%s\n\n", s.cfg.AlertBaseURL, url.QueryEscape(token))
+	fmt.Fprintf(&b, "The link works once and expires in %s.\n", s.cfg.RevokeLinkTTL)
+	return b.String()
+}
+
+// RevokeWithToken signs out the session of the login whose alert held token.
+// Each link works once, within the revoke link TTL of the alert.
+func (s *Service) RevokeWithToken(ctx context.Context, token string) (*SecurityEvent, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrInvalidRevokeLink
+	}
+	row, err := s.queries.UseRevokeToken(ctx, db.UseRevokeTokenParams{
+		RevokeTokenHash: pgtype.Text{String: hashToken(token), Valid: true},
+		IssuedAfter:     pgtype.Timestamptz{Time: time.Now().Add(-s.cfg.RevokeLinkTTL), Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidRevokeLink
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	s.markRevoked(row.UserEmail + "\x00" + row.SessionKey)
+
+	if _, err := s.queries.CreateSecurityEvent(ctx, db.CreateSecurityEventParams{
+		UserEmail:  row.UserEmail,
+		Kind:       EventSessionRevoked,
+		Reasons:    []string{},
+		SessionKey: row.SessionKey,
+		Device:     row.Device,
+		IpAddress:  row.IpAddress,
+		Country:    row.Country,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record security event: %w", err)
+	}
+	return toSecurityEvent(row), nil
+}
+
+// ListSecurityEvents returns the recent logins and session revocations of
+// email, newest first
+func (s *Service) ListSecurityEvents(ctx context.Context, email string) ([]*SecurityEvent, error) {
+	if email == "" {
+		return nil, ErrUnauthenticated
+	}
+	rows, err := s.queries.ListSecurityEvents(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list security events: %w", err)
+	}
+
+	events := make([]*SecurityEvent, len(rows))
+	for i, row := range rows {
+		events[i] = toSecurityEvent(row)
+	}
+	return events, nil
+}
+
+func (s *Service) isRevoked(session string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revoked[session]
+}
+
+func (s *Service) markRevoked(session string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.revoked) >= maxTracked {
+		clear(s.revoked)
+	}
+	s.revoked[session] = true
+}
+
+func (s *Service) due(key string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.touched[key]; ok && now.Sub(last) < s.cfg.TouchInterval {
+		return false
+	}
+	if len(s.touched) >= maxTracked {
+		for k, last := range s.touched {
+			if now.Sub(last) >= s.cfg.TouchInterval {
+				delete(s.touched, k)
+			}
+		}
+	}
+	s.touched[key] = now
+	return true
+}
+
+// List returns the sessions of email, most recently seen first, marking
+// the one with currentKey
+func (s *Service) List(ctx context.Context, email, currentKey string) ([]*Session, error) {
+	if email == "" {
+		return nil, ErrUnauthenticated
+	}
+	rows, err := s.queries.ListUserSessions(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessions := make([]*Session, len(rows))
+	for i, row := range rows {
+		sessions[i] = toSession(row, currentKey)
+	}
+	return sessions, nil
+}
+
+// ListForUsers returns the sessions of each email in one query, most
+// recently seen first
+func (s *Service) ListForUsers(ctx context.Context, emails []string) (map[string][]*Session, error) {
+	rows, err := s.queries.ListUserSessionsForEmails(ctx, emails)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessions := make(map[string][]*Session, len(emails))
+	for _, row := range rows {
+		sessions[row.UserEmail] = append(sessions[row.UserEmail], toSession(row, ""))
+	}
+	return sessions, nil
+}
+
+func toSession(row db.UserSession, currentKey string) *Session {
+	return &Session{
+		ID:             uuid.UUID(row.ID.Bytes),
+		Browser:        row.Browser,
+		BrowserVersion: row.BrowserVersion,
+		OS:             row.Os,
+		DeviceClass:    row.DeviceClass,
+		UserAgent:      row.UserAgent,
+		IPAddress:      textPtr(row.IpAddress),
+		Country:        textPtr(row.Country),
+		Current:        row.SessionKey == currentKey,
+		FirstSeenAt:    row.FirstSeenAt.Time,
+		LastSeenAt:     row.LastSeenAt.Time,
+		RevokedAt:      timePtr(row.RevokedAt),
+	}
+}
+
+// DeviceAnalytics counts the sessions seen within window by browser,
+// operating system, and device class
+func (s *Service) DeviceAnalytics(ctx context.Context, window time.Duration) (*DeviceAnalytics, error) {
+	if window <= 0 {
+		return nil, ErrInvalidWindow
+	}
+	since := time.Now().Add(-window)
+	rows, err := s.queries.CountSessionsByDevice(ctx, pgtype.Timestamptz{Time: since, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	analytics := &DeviceAnalytics{
+		Since:         since,
+		Browsers:      []DeviceCount{},
+		OS:            []DeviceCount{},
+		DeviceClasses: []DeviceCount{},
+	}
+	for _, row := range rows {
+		count := DeviceCount{Value: row.Value, Sessions: row.Sessions, Users: row.Users}
+		switch row.Dimension {
+		case "browser":
+			analytics.Browsers = append(analytics.Browsers, count)
+		case "os":
+			analytics.OS = append(analytics.OS, count)
+		case "device_class":
+			analytics.DeviceClasses = append(analytics.DeviceClasses, count)
+		}
+	}
+	return analytics, nil
+}
+
+// RecordActive reports the sessions seen within activeWindow to the
+// business metrics. It runs on the leader, counting across replicas.
+func (s *Service) RecordActive(ctx context.Context) error {
+	n, err := s.queries.CountActiveSessions(ctx, pgtype.Timestamptz{Time: time.Now().Add(-activeWindow), Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to count active sessions: %w", err)
+	}
+	kpi.ActiveSessions(ctx, n)
+	return nil
+}
+
+// Prune deletes sessions not seen within the retention period, and the
+// login fingerprints and security events older than it
+func (s *Service) Prune(ctx context.Context) error {
+	before := pgtype.Timestamptz{Time: time.Now().Add(-s.cfg.Retention), Valid: true}
+	if _, err := s.queries.PruneUserSessions(ctx, before); err != nil {
+		return fmt.Errorf("failed to prune sessions: %w", err)
+	}
+	if _, err := s.queries.PruneLoginFingerprints(ctx, before); err != nil {
+		return fmt.Errorf("failed to prune login fingerprints: %w", err)
+	}
+	if _, err := s.queries.PruneSecurityEvents(ctx, before); err != nil {
+		return fmt.Errorf("failed to prune security events: %w", err)
+	}
+	return nil
+}
+
+func toSecurityEvent(row db.SecurityEvent) *SecurityEvent {
+	return &SecurityEvent{
+		ID:        uuid.UUID(row.ID.Bytes),
+		Kind:      row.Kind,
+		Reasons:   row.Reasons,
+		Device:    row.Device,
+		IPAddress: textPtr(row.IpAddress),
+		Country:   textPtr(row.Country),
+		CreatedAt: row.CreatedAt.Time,
+		RevokedAt: timePtr(row.RevokedAt),
+	}
+}
+
+// ipRange is the network of an address: its /24 for IPv4 and /48 for IPv6
+func ipRange(address string) string {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
+
+// Device describes a client such as "Chrome on macOS (desktop)"
+func Device(agent useragent.Agent) string {
+	return fmt.Sprintf("%s on %s (%s)", agent.Browser, agent.OS, agent.DeviceClass)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func textPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
+
+func timePtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
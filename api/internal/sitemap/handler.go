@@ -0,0 +1,88 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"starterkit/internal/platform/apierror"
+)
+
+// cacheControl lets crawlers and proxies reuse responses for an hour
+const cacheControl = "public, max-age=3600"
+
+type ServiceInterface interface {
+	AllowIndexing() bool
+	Index(ctx context.Context) ([]byte, error)
+	Page(ctx context.Context, file string) ([]byte, error)
+	Robots() []byte
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleRobots serves robots.txt
+func (h *Handler) HandleRobots() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.write(w, "text/plain; charset=utf-8", h.service.Robots())
+	}
+}
+
+// HandleIndex serves the sitemap index. Sites closed to crawlers have none.
+func (h *Handler) HandleIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.service.AllowIndexing() {
+			apierror.Write(w, r, apierror.NotFound("sitemap_not_found", "sitemap not found"))
+			return
+		}
+		body, err := h.service.Index(r.Context())
+		if err != nil {
+			h.logger.Error("failed to render sitemap index", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.write(w, "application/xml; charset=utf-8", body)
+	}
+}
+
+// HandlePage serves one page of the sitemap
+func (h *Handler) HandlePage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.service.AllowIndexing() {
+			apierror.Write(w, r, apierror.NotFound("sitemap_not_found", "sitemap not found"))
+			return
+		}
+		body, err := h.service.Page(r.Context(), r.PathValue("file"))
+		if err != nil {
+			if errors.Is(err, ErrPageNotFound) {
+				apierror.Write(w, r, apierror.NotFound("sitemap_not_found", err.Error()))
+				return
+			}
+			h.logger.Error("failed to render sitemap page", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.write(w, "application/xml; charset=utf-8", body)
+	}
+}
+
+func (h *Handler) write(w http.ResponseWriter, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Header().Set("Cache-Control", cacheControl)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		h.logger.Error("failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package sitemap serves /sitemap.xml and /robots.txt for search engines.
+// The sitemap is an index of pages, each listing the public URLs of one
+// source, such as the app's static pages or pages linked from
+// announcements.
+package sitemap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrPageNotFound = errors.New("sitemap page not found")
+
+// Entry is a public URL and when it last changed; LastModified is zero
+// when unknown
+type Entry struct {
+	URL          string
+	LastModified time.Time
+}
+
+// Source lists public URLs in a stable order so they can be paged
+type Source interface {
+	// Pages returns when each page of size entries last changed
+	Pages(ctx context.Context, size int) ([]time.Time, error)
+	// Entries returns the entries of page, counted from zero
+	Entries(ctx context.Context, page, size int) ([]Entry, error)
+}
+
+// Options configure the sitemap and robots.txt
+type Options struct {
+	// BaseURL is the public origin of the site
+	BaseURL string
+	// PageSize is how many URLs each sitemap page holds
+	PageSize int
+	// AllowIndexing lets crawlers in; without it robots.txt disallows
+	// everything and no sitemap is served
+	AllowIndexing bool
+}
+
+// Service renders the sitemap index, its pages, and robots.txt
+type Service struct {
+	opts    Options
+	names   []string
+	sources map[string]Source
+}
+
+func NewService(opts Options) *Service {
+	return &Service{opts: opts, sources: make(map[string]Source)}
+}
+
+// Register adds a source whose pages are served as /sitemaps/{name}-{n}.xml
+func (s *Service) Register(name string, source Source) {
+	s.names = append(s.names, name)
+	s.sources[name] = source
+}
+
+// AllowIndexing reports whether crawlers are let in
+func (s *Service) AllowIndexing() bool {
+	return s.opts.AllowIndexing
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name       `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type urlSet struct {
+	XMLName xml.Name       `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapEntry `xml:"url"`
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Index renders the sitemap index, listing every page of every source
+func (s *Service) Index(ctx context.Context) ([]byte, error) {
+	index := sitemapIndex{Sitemaps: []sitemapEntry{}}
+	for _, name := range s.names {
+		pages, err := s.sources[name].Pages(ctx, s.opts.PageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s sitemap pages: %w", name, err)
+		}
+		for i, modified := range pages {
+			index.Sitemaps = append(index.Sitemaps, sitemapEntry{
+				Loc:     fmt.Sprintf("%s/sitemaps/%s-%d.xml", s.opts.BaseURL, name, i+1),
+				LastMod: lastMod(modified),
+			})
+		}
+	}
+	return encode(index)
+}
+
+// Page renders a sitemap page named as the index lists it, such as
+// announcements-1.xml
+func (s *Service) Page(ctx context.Context, file string) ([]byte, error) {
+	name, page, ok := parsePage(file)
+	source := s.sources[name]
+	if !ok || source == nil {
+		return nil, ErrPageNotFound
+	}
+
+	entries, err := source.Entries(ctx, page, s.opts.PageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s sitemap entries: %w", name, err)
+	}
+	if len(entries) == 0 {
+		return nil, ErrPageNotFound
+	}
+
+	set := urlSet{URLs: make([]sitemapEntry, len(entries))}
+	for i, entry := range entries {
+		set.URLs[i] = sitemapEntry{Loc: entry.URL, LastMod: lastMod(entry.LastModified)}
+	}
+	return encode(set)
+}
+
+// Robots renders robots.txt. Indexed sites keep crawlers out of the API
+// and point them at the sitemap; others turn every crawler away.
+func (s *Service) Robots() []byte {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if !s.opts.AllowIndexing {
+		b.WriteString("Disallow: /\n")
+		return []byte(b.String())
+	}
+	for _, prefix := range []string{"/api/", "/admin/", "/l/"} {
+		b.WriteString("Disallow: " + prefix + "\n")
+	}
+	b.WriteString("\nSitemap: " + s.opts.BaseURL + "/sitemap.xml\n")
+	return []byte(b.String())
+}
+
+// parsePage splits a page file name into its source and zero-based page
+func parsePage(file string) (string, int, bool) {
+	base, ok := strings.CutSuffix(file, ".xml")
+	if !ok {
+		return "", 0, false
+	}
+	i := strings.LastIndexByte(base, '-')
+	if i < 0 {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(base[i+1:])
+	if err != nil || n < 1 {
+		return "", 0, false
+	}
+	return base[:i], n - 1, true
+}
+
+func lastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func encode(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to render sitemap: %w", err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
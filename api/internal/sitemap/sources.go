@@ -0,0 +1,31 @@
+package sitemap
+
+import (
+	"context"
+	"time"
+)
+
+// Static lists fixed pages of the app, such as its home page, as paths on
+// baseURL
+func Static(baseURL string, paths []string) Source {
+	return staticSource{baseURL: baseURL, paths: paths}
+}
+
+type staticSource struct {
+	baseURL string
+	paths   []string
+}
+
+func (s staticSource) Pages(ctx context.Context, size int) ([]time.Time, error) {
+	return make([]time.Time, (len(s.paths)+size-1)/size), nil
+}
+
+func (s staticSource) Entries(ctx context.Context, page, size int) ([]Entry, error) {
+	start := min(page*size, len(s.paths))
+	end := min(start+size, len(s.paths))
+	entries := make([]Entry, 0, end-start)
+	for _, path := range s.paths[start:end] {
+		entries = append(entries, Entry{URL: s.baseURL + path})
+	}
+	return entries, nil
+}
@@ -0,0 +1,68 @@
+package slo
+
+import (
+	"context"
+	"time"
+)
+
+// Notifier posts budget alerts, e.g. to Slack
+type Notifier interface {
+	Notify(ctx context.Context, event, key string, data any) error
+}
+
+// Alert is the data passed to the notifier when a route's budget status
+// changes
+type Alert struct {
+	Route                string
+	Status               string
+	PreviousStatus       string
+	AvailabilityBurnRate float64
+	LatencyBurnRate      float64
+	BudgetRemaining      float64
+}
+
+// Alerter notifies when a route starts burning or exhausts its error
+// budget, and again when it recovers
+type Alerter struct {
+	tracker  *Tracker
+	notifier Notifier
+	event    string
+	statuses map[string]string
+}
+
+// NewAlerter posts alerts as event through notifier
+func NewAlerter(tracker *Tracker, notifier Notifier, event string) *Alerter {
+	return &Alerter{
+		tracker:  tracker,
+		notifier: notifier,
+		event:    event,
+		statuses: make(map[string]string),
+	}
+}
+
+// Check compares every route's status with the previous check and posts
+// the changes. It is not safe for concurrent use.
+func (a *Alerter) Check(ctx context.Context) error {
+	for _, rt := range a.tracker.Report(time.Now()).Routes {
+		previous, seen := a.statuses[rt.Route]
+		if !seen {
+			previous = StatusOK
+		}
+		if rt.Status == previous {
+			continue
+		}
+
+		if err := a.notifier.Notify(ctx, a.event, "", Alert{
+			Route:                rt.Route,
+			Status:               rt.Status,
+			PreviousStatus:       previous,
+			AvailabilityBurnRate: rt.Availability.BurnRate1h,
+			LatencyBurnRate:      rt.Latency.BurnRate1h,
+			BudgetRemaining:      min(rt.Availability.BudgetRemaining, rt.Latency.BudgetRemaining),
+		}); err != nil {
+			return err
+		}
+		a.statuses[rt.Route] = rt.Status
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package slo
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type Handler struct {
+	tracker *Tracker
+	logger  *slog.Logger
+}
+
+func NewHandler(tracker *Tracker, logger *slog.Logger) *Handler {
+	return &Handler{
+		tracker: tracker,
+		logger:  logger,
+	}
+}
+
+// HandleStatus reports per-route error budget status for on-call review
+func (h *Handler) HandleStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.respondWithJSON(w, http.StatusOK, h.tracker.Report(time.Now()))
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
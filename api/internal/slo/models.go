@@ -0,0 +1,67 @@
+package slo
+
+import "time"
+
+// Objective is a route's availability and latency target. A request counts
+// against availability when it returns 5xx and against latency when it is
+// slower than Latency.
+type Objective struct {
+	// Availability is the fraction of requests that must not fail, e.g. 0.999
+	Availability float64
+	// Latency is the threshold a LatencyTarget fraction of requests must meet
+	Latency       time.Duration
+	LatencyTarget float64
+}
+
+// Common objectives routes declare
+var (
+	// Interactive suits reads and small writes on the request path
+	Interactive = Objective{Availability: 0.999, Latency: 300 * time.Millisecond, LatencyTarget: 0.99}
+	// Bulk suits uploads and sync payloads that move more data
+	Bulk = Objective{Availability: 0.99, Latency: 2 * time.Second, LatencyTarget: 0.95}
+)
+
+// Budget statuses
+const (
+	StatusOK        = "ok"
+	StatusBurning   = "burning"
+	StatusExhausted = "exhausted"
+)
+
+// fastBurnRate is the short-window burn rate that marks a budget as burning:
+// at this rate a budget lasts a sixth of the window
+const fastBurnRate = 6
+
+// Report is the error budget status of every tracked route
+type Report struct {
+	Window      string         `json:"window"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Routes      []*RouteStatus `json:"routes"`
+}
+
+// RouteStatus is one route's compliance over the window
+type RouteStatus struct {
+	Route        string          `json:"route"`
+	Objective    ObjectiveStatus `json:"objective"`
+	Requests     int64           `json:"requests"`
+	Availability BudgetStatus    `json:"availability"`
+	Latency      BudgetStatus    `json:"latency"`
+	Status       string          `json:"status"`
+}
+
+// ObjectiveStatus is the JSON form of an Objective
+type ObjectiveStatus struct {
+	Availability  float64 `json:"availability"`
+	LatencyMS     int64   `json:"latency_ms"`
+	LatencyTarget float64 `json:"latency_target"`
+}
+
+// BudgetStatus tracks one objective's error budget. Burn rates are how fast
+// the budget is spent relative to spending it evenly over the window.
+type BudgetStatus struct {
+	Bad             int64   `json:"bad"`
+	Compliance      float64 `json:"compliance"`
+	BudgetRemaining float64 `json:"budget_remaining"`
+	BurnRate1h      float64 `json:"burn_rate_1h"`
+	BurnRateWindow  float64 `json:"burn_rate_window"`
+}
@@ -0,0 +1,188 @@
+package slo
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"starterkit/internal/platform/servertiming"
+)
+
+// Tracker records request outcomes for routes with declared objectives in
+// per-minute buckets covering a rolling window. Counts are kept in memory,
+// so each replica reports its own traffic since it started.
+type Tracker struct {
+	window  time.Duration
+	minutes int
+
+	mu     sync.RWMutex
+	routes []*route
+}
+
+type route struct {
+	name      string
+	objective Objective
+
+	mu      sync.Mutex
+	buckets []bucket
+}
+
+type bucket struct {
+	minute int64
+	total  int64
+	failed int64
+	slow   int64
+}
+
+type counts struct {
+	total  int64
+	failed int64
+	slow   int64
+}
+
+// NewTracker creates a tracker computing budgets over window
+func NewTracker(window time.Duration) *Tracker {
+	return &Tracker{
+		window:  window,
+		minutes: max(int(window/time.Minute), 60),
+	}
+}
+
+// Track declares an objective for a route and wraps its handler to record
+// outcomes. route names the endpoint in reports, e.g. "GET /api/v1/users/{id}".
+func (t *Tracker) Track(name string, objective Objective, next http.HandlerFunc) http.HandlerFunc {
+	rt := &route{
+		name:      name,
+		objective: objective,
+		buckets:   make([]bucket, t.minutes),
+	}
+	t.mu.Lock()
+	t.routes = append(t.routes, rt)
+	t.mu.Unlock()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		servertiming.Budget(r.Context(), objective.Latency)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			// A panic becomes a 500 in the recovery middleware
+			v := recover()
+			rt.record(time.Now(), v != nil || rec.status >= 500, time.Since(start) > objective.Latency)
+			if v != nil {
+				panic(v)
+			}
+		}()
+		next(rec, r)
+	}
+}
+
+// Report computes the budget status of every tracked route
+func (t *Tracker) Report(now time.Time) *Report {
+	t.mu.RLock()
+	routes := append([]*route(nil), t.routes...)
+	t.mu.RUnlock()
+
+	report := &Report{
+		Window:      t.window.String(),
+		GeneratedAt: now,
+		Routes:      make([]*RouteStatus, 0, len(routes)),
+	}
+	for _, rt := range routes {
+		report.Routes = append(report.Routes, rt.status(now, t.minutes))
+	}
+	return report
+}
+
+func (rt *route) record(now time.Time, failed, slow bool) {
+	minute := now.Unix() / 60
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	b := &rt.buckets[minute%int64(len(rt.buckets))]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+	b.total++
+	if failed {
+		b.failed++
+	}
+	if slow {
+		b.slow++
+	}
+}
+
+// sum adds up the buckets for the last n minutes
+func (rt *route) sum(now time.Time, n int) counts {
+	minute := now.Unix() / 60
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	var c counts
+	for _, b := range rt.buckets {
+		if b.minute > minute-int64(n) && b.minute <= minute {
+			c.total += b.total
+			c.failed += b.failed
+			c.slow += b.slow
+		}
+	}
+	return c
+}
+
+func (rt *route) status(now time.Time, windowMinutes int) *RouteStatus {
+	window := rt.sum(now, windowMinutes)
+	hour := rt.sum(now, 60)
+
+	s := &RouteStatus{
+		Route: rt.name,
+		Objective: ObjectiveStatus{
+			Availability:  rt.objective.Availability,
+			LatencyMS:     rt.objective.Latency.Milliseconds(),
+			LatencyTarget: rt.objective.LatencyTarget,
+		},
+		Requests:     window.total,
+		Availability: budget(window.failed, window.total, hour.failed, hour.total, rt.objective.Availability),
+		Latency:      budget(window.slow, window.total, hour.slow, hour.total, rt.objective.LatencyTarget),
+		Status:       StatusOK,
+	}
+	switch {
+	case s.Availability.BudgetRemaining <= 0 || s.Latency.BudgetRemaining <= 0:
+		s.Status = StatusExhausted
+	case s.Availability.BurnRate1h >= fastBurnRate || s.Latency.BurnRate1h >= fastBurnRate:
+		s.Status = StatusBurning
+	}
+	return s
+}
+
+func budget(bad, total, badHour, totalHour int64, target float64) BudgetStatus {
+	allowed := 1 - target
+	s := BudgetStatus{
+		Bad:             bad,
+		Compliance:      1,
+		BudgetRemaining: 1,
+	}
+	if total > 0 {
+		badRatio := float64(bad) / float64(total)
+		s.Compliance = 1 - badRatio
+		s.BurnRateWindow = badRatio / allowed
+		s.BudgetRemaining = 1 - s.BurnRateWindow
+	}
+	if totalHour > 0 {
+		s.BurnRate1h = float64(badHour) / float64(totalHour) / allowed
+	}
+	return s
+}
+
+// statusRecorder captures the response status for the tracker
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
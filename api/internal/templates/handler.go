@@ -0,0 +1,250 @@
+package templates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"starterkit/internal/operations"
+	"starterkit/internal/platform/apierror"
+
+	"github.com/google/uuid"
+)
+
+type ServiceInterface interface {
+	CreateTemplate(ctx context.Context, req CreateTemplateRequest, actor string) (*Template, error)
+	ListTemplates(ctx context.Context) ([]*Template, error)
+	GetTemplate(ctx context.Context, id uuid.UUID) (*Template, error)
+	UpdateTemplate(ctx context.Context, id uuid.UUID, req UpdateTemplateRequest, actor string) (*Template, error)
+	ListVersions(ctx context.Context, id uuid.UUID) ([]*Version, error)
+	Rollback(ctx context.Context, id uuid.UUID, version int, actor string) (*Template, error)
+	Preview(ctx context.Context, id uuid.UUID, req PreviewRequest) (*Rendered, error)
+}
+
+// OperationStager stages destructive operations behind an undo window
+type OperationStager interface {
+	Stage(ctx context.Context, kind, owner string, payload any) (*operations.Operation, error)
+}
+
+type Handler struct {
+	service    ServiceInterface
+	operations OperationStager
+	logger     *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, ops OperationStager, logger *slog.Logger) *Handler {
+	return &Handler{
+		service:    service,
+		operations: ops,
+		logger:     logger,
+	}
+}
+
+func (h *Handler) HandleListTemplates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templates, err := h.service.ListTemplates(r.Context())
+		if err != nil {
+			h.logger.Error("failed to list templates", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"templates": templates,
+		})
+	}
+}
+
+func (h *Handler) HandleCreateTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateTemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		template, err := h.service.CreateTemplate(r.Context(), req, actorFromRequest(r))
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to create template", uuid.Nil)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusCreated, template)
+	}
+}
+
+func (h *Handler) HandleGetTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID, ok := h.parseTemplateID(w, r)
+		if !ok {
+			return
+		}
+
+		template, err := h.service.GetTemplate(r.Context(), templateID)
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to get template", templateID)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, template)
+	}
+}
+
+func (h *Handler) HandleUpdateTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID, ok := h.parseTemplateID(w, r)
+		if !ok {
+			return
+		}
+
+		var req UpdateTemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		template, err := h.service.UpdateTemplate(r.Context(), templateID, req, actorFromRequest(r))
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to update template", templateID)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, template)
+	}
+}
+
+// HandleDeleteTemplate stages the template's deletion and answers with the
+// operation, which can be undone through the operations API until its
+// undo_until
+func (h *Handler) HandleDeleteTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID, ok := h.parseTemplateID(w, r)
+		if !ok {
+			return
+		}
+
+		if _, err := h.service.GetTemplate(r.Context(), templateID); err != nil {
+			h.handleServiceError(w, r, err, "failed to get template", templateID)
+			return
+		}
+
+		op, err := h.operations.Stage(r.Context(), OperationDeleteTemplate, operations.Owner(r), Deletion{TemplateID: templateID, Actor: actorFromRequest(r)})
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to stage template deletion", templateID)
+			return
+		}
+
+		w.Header().Set("Location", "/api/v1/operations/"+op.ID.String())
+		h.respondWithJSON(w, http.StatusAccepted, op)
+	}
+}
+
+func (h *Handler) HandleListVersions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID, ok := h.parseTemplateID(w, r)
+		if !ok {
+			return
+		}
+
+		versions, err := h.service.ListVersions(r.Context(), templateID)
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to list template versions", templateID)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"versions": versions,
+		})
+	}
+}
+
+func (h *Handler) HandleRollback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID, ok := h.parseTemplateID(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Version int `json:"version"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Version <= 0 {
+			apierror.Write(w, r, apierror.BadRequest("version_required", "version is required"))
+			return
+		}
+
+		template, err := h.service.Rollback(r.Context(), templateID, req.Version, actorFromRequest(r))
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to roll back template", templateID)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, template)
+	}
+}
+
+func (h *Handler) HandlePreview() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID, ok := h.parseTemplateID(w, r)
+		if !ok {
+			return
+		}
+
+		var req PreviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		rendered, err := h.service.Preview(r.Context(), templateID, req)
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to preview template", templateID)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, rendered)
+	}
+}
+
+func (h *Handler) parseTemplateID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	templateID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_template_id", "invalid template ID format"))
+		return uuid.Nil, false
+	}
+	return templateID, true
+}
+
+func (h *Handler) handleServiceError(w http.ResponseWriter, r *http.Request, err error, msg string, templateID uuid.UUID) {
+	switch {
+	case errors.Is(err, ErrTemplateNotFound):
+		apierror.Write(w, r, apierror.NotFound("template_not_found", "template not found"))
+	case errors.Is(err, ErrVersionNotFound):
+		apierror.Write(w, r, apierror.NotFound("template_version_not_found", "template version not found"))
+	case errors.Is(err, ErrDuplicateTemplate):
+		apierror.Write(w, r, apierror.Conflict("duplicate_template", err.Error()))
+	case errors.Is(err, ErrInvalidTemplate):
+		apierror.Write(w, r, apierror.Unprocessable("invalid_template", err.Error()))
+	case errors.Is(err, ErrMissingVariable):
+		apierror.Write(w, r, apierror.Unprocessable("missing_variable", err.Error()))
+	case errors.Is(err, ErrRenderFailed):
+		apierror.Write(w, r, apierror.Unprocessable("render_failed", err.Error()))
+	default:
+		h.logger.Error(msg, "error", err, "template_id", templateID)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
@@ -0,0 +1,71 @@
+package templates
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supported template channels
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+)
+
+// Template is a localized message template for one channel. Content lives
+// in immutable versions; ActiveVersion selects the one used for rendering.
+type Template struct {
+	ID            uuid.UUID `json:"id"`
+	Key           string    `json:"key"`
+	Channel       string    `json:"channel"`
+	Locale        string    `json:"locale"`
+	Description   string    `json:"description"`
+	ActiveVersion int       `json:"active_version"`
+	Active        *Version  `json:"active,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Version is an immutable revision of a template's content
+type Version struct {
+	Version   int       `json:"version"`
+	Subject   string    `json:"subject,omitempty"`
+	Body      string    `json:"body"`
+	Variables []string  `json:"variables"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Rendered is the output of a template for a specific set of variables
+type Rendered struct {
+	TemplateID uuid.UUID `json:"template_id"`
+	Version    int       `json:"version"`
+	Locale     string    `json:"locale"`
+	Subject    string    `json:"subject,omitempty"`
+	Body       string    `json:"body"`
+}
+
+type CreateTemplateRequest struct {
+	Key         string   `json:"key"`
+	Channel     string   `json:"channel"`
+	Locale      string   `json:"locale"`
+	Description string   `json:"description"`
+	Subject     string   `json:"subject"`
+	Body        string   `json:"body"`
+	Variables   []string `json:"variables"`
+}
+
+// UpdateTemplateRequest creates a new version and makes it active
+type UpdateTemplateRequest struct {
+	Subject   string   `json:"subject"`
+	Body      string   `json:"body"`
+	Variables []string `json:"variables"`
+}
+
+// PreviewRequest renders a stored version (the active one by default) with
+// sample data without sending anything
+type PreviewRequest struct {
+	Version *int           `json:"version"`
+	Data    map[string]any `json:"data"`
+}
@@ -0,0 +1,129 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"slices"
+	"text/template"
+	"text/template/parse"
+)
+
+// compiled is a parsed template version ready for execution
+type compiled struct {
+	subject *template.Template
+	body    interface {
+		Execute(w io.Writer, data any) error
+	}
+	variables []string
+}
+
+// compile parses subject and body for a channel and checks that every
+// top-level field they reference is a declared variable. Email bodies are
+// HTML-escaped; all other content is plain text.
+func compile(channel, subject, body string, variables []string) (*compiled, error) {
+	c := &compiled{variables: variables}
+	var trees []*parse.Tree
+
+	if subject != "" {
+		t, err := template.New("subject").Option("missingkey=error").Parse(subject)
+		if err != nil {
+			return nil, fmt.Errorf("%w: subject: %v", ErrInvalidTemplate, err)
+		}
+		c.subject = t
+		trees = append(trees, t.Tree)
+	}
+
+	if channel == ChannelEmail {
+		t, err := htmltemplate.New("body").Option("missingkey=error").Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: body: %v", ErrInvalidTemplate, err)
+		}
+		c.body = t
+		trees = append(trees, t.Tree)
+	} else {
+		t, err := template.New("body").Option("missingkey=error").Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: body: %v", ErrInvalidTemplate, err)
+		}
+		c.body = t
+		trees = append(trees, t.Tree)
+	}
+
+	for _, tree := range trees {
+		for _, name := range referencedFields(tree.Root) {
+			if !slices.Contains(variables, name) {
+				return nil, fmt.Errorf("%w: undeclared variable %q", ErrInvalidTemplate, name)
+			}
+		}
+	}
+	return c, nil
+}
+
+// render executes the template after checking all declared variables are present
+func (c *compiled) render(data map[string]any) (subject, body string, err error) {
+	for _, name := range c.variables {
+		if _, ok := data[name]; !ok {
+			return "", "", fmt.Errorf("%w: %s", ErrMissingVariable, name)
+		}
+	}
+
+	var buf bytes.Buffer
+	if c.subject != nil {
+		if err := c.subject.Execute(&buf, data); err != nil {
+			return "", "", fmt.Errorf("%w: %v", ErrRenderFailed, err)
+		}
+		subject = buf.String()
+		buf.Reset()
+	}
+	if err := c.body.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrRenderFailed, err)
+	}
+	return subject, buf.String(), nil
+}
+
+// referencedFields returns the top-level fields ({{.name}}) a template uses.
+// Bodies of range and with blocks are skipped because dot is rebound there.
+func referencedFields(node parse.Node) []string {
+	var fields []string
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			fields = append(fields, n.Ident[0])
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.ElseList)
+		}
+	}
+	walk(node)
+	return fields
+}
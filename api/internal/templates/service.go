@@ -0,0 +1,462 @@
+package templates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/logger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrTemplateNotFound  = errors.New("template not found")
+	ErrVersionNotFound   = errors.New("template version not found")
+	ErrDuplicateTemplate = errors.New("template already exists for this key, channel, and locale")
+	ErrInvalidTemplate   = errors.New("invalid template")
+	ErrMissingVariable   = errors.New("missing template variable")
+	ErrRenderFailed      = errors.New("failed to render template")
+)
+
+type Querier interface {
+	CreateMessageTemplate(ctx context.Context, arg db.CreateMessageTemplateParams) (db.MessageTemplate, error)
+	GetMessageTemplate(ctx context.Context, id pgtype.UUID) (db.MessageTemplate, error)
+	ListMessageTemplates(ctx context.Context) ([]db.MessageTemplate, error)
+	ListMessageTemplatesByKey(ctx context.Context, arg db.ListMessageTemplatesByKeyParams) ([]db.MessageTemplate, error)
+	SetMessageTemplateActiveVersion(ctx context.Context, arg db.SetMessageTemplateActiveVersionParams) (db.MessageTemplate, error)
+	TrashMessageTemplate(ctx context.Context, arg db.TrashMessageTemplateParams) (int64, error)
+	CreateMessageTemplateVersion(ctx context.Context, arg db.CreateMessageTemplateVersionParams) (db.MessageTemplateVersion, error)
+	GetMessageTemplateVersion(ctx context.Context, arg db.GetMessageTemplateVersionParams) (db.MessageTemplateVersion, error)
+	ListMessageTemplateVersions(ctx context.Context, templateID pgtype.UUID) ([]db.MessageTemplateVersion, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+type Service struct {
+	queries       Querier
+	auditor       Auditor
+	defaultLocale language.Tag
+}
+
+// NewService creates a template service. defaultLocale is used when no
+// stored locale of a template matches the requested one.
+func NewService(queries Querier, auditor Auditor, defaultLocale string) *Service {
+	tag, err := language.Parse(defaultLocale)
+	if err != nil {
+		tag = language.AmericanEnglish
+	}
+	return &Service{
+		queries:       queries,
+		auditor:       auditor,
+		defaultLocale: tag,
+	}
+}
+
+func (s *Service) CreateTemplate(ctx context.Context, req CreateTemplateRequest, actor string) (*Template, error) {
+	req.Key = strings.TrimSpace(req.Key)
+	if req.Key == "" {
+		return nil, fmt.Errorf("%w: key is required", ErrInvalidTemplate)
+	}
+	if req.Channel != ChannelEmail && req.Channel != ChannelSMS && req.Channel != ChannelPush {
+		return nil, fmt.Errorf("%w: channel must be one of email, sms, push", ErrInvalidTemplate)
+	}
+	tag, err := language.Parse(req.Locale)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid locale %q", ErrInvalidTemplate, req.Locale)
+	}
+	if err := validateContent(req.Channel, req.Subject, req.Body, req.Variables); err != nil {
+		return nil, err
+	}
+
+	dbTemplate, err := s.queries.CreateMessageTemplate(ctx, db.CreateMessageTemplateParams{
+		Key:         req.Key,
+		Channel:     req.Channel,
+		Locale:      tag.String(),
+		Description: req.Description,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrDuplicateTemplate
+		}
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	template, err := s.publish(ctx, dbTemplate.ID, req.Subject, req.Body, req.Variables, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "template.created",
+		ResourceType: "message_template",
+		ResourceID:   template.ID.String(),
+		Metadata: map[string]any{
+			"key":     template.Key,
+			"channel": template.Channel,
+			"locale":  template.Locale,
+		},
+	})
+
+	return template, nil
+}
+
+func (s *Service) ListTemplates(ctx context.Context) ([]*Template, error) {
+	dbTemplates, err := s.queries.ListMessageTemplates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	templates := make([]*Template, len(dbTemplates))
+	for i, t := range dbTemplates {
+		templates[i] = toTemplate(t)
+	}
+	return templates, nil
+}
+
+// GetTemplate returns a template with its active version
+func (s *Service) GetTemplate(ctx context.Context, id uuid.UUID) (*Template, error) {
+	dbTemplate, err := s.getTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	template := toTemplate(dbTemplate)
+	if dbTemplate.ActiveVersion > 0 {
+		version, err := s.getVersion(ctx, id, int(dbTemplate.ActiveVersion))
+		if err != nil {
+			return nil, err
+		}
+		template.Active = toVersion(version)
+	}
+	return template, nil
+}
+
+// UpdateTemplate stores new content as the next version and activates it
+func (s *Service) UpdateTemplate(ctx context.Context, id uuid.UUID, req UpdateTemplateRequest, actor string) (*Template, error) {
+	dbTemplate, err := s.getTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateContent(dbTemplate.Channel, req.Subject, req.Body, req.Variables); err != nil {
+		return nil, err
+	}
+
+	template, err := s.publish(ctx, dbTemplate.ID, req.Subject, req.Body, req.Variables, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "template.updated",
+		ResourceType: "message_template",
+		ResourceID:   template.ID.String(),
+		Metadata: map[string]any{
+			"previous_version": dbTemplate.ActiveVersion,
+			"version":          template.ActiveVersion,
+		},
+	})
+
+	return template, nil
+}
+
+func (s *Service) ListVersions(ctx context.Context, id uuid.UUID) ([]*Version, error) {
+	if _, err := s.getTemplate(ctx, id); err != nil {
+		return nil, err
+	}
+
+	dbVersions, err := s.queries.ListMessageTemplateVersions(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template versions: %w", err)
+	}
+
+	versions := make([]*Version, len(dbVersions))
+	for i, v := range dbVersions {
+		versions[i] = toVersion(v)
+	}
+	return versions, nil
+}
+
+// Rollback makes a previously stored version active again. Versions are
+// immutable, so rolling forward again is another Rollback call.
+func (s *Service) Rollback(ctx context.Context, id uuid.UUID, version int, actor string) (*Template, error) {
+	dbTemplate, err := s.getTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.getVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.queries.SetMessageTemplateActiveVersion(ctx, db.SetMessageTemplateActiveVersionParams{
+		ID:            dbTemplate.ID,
+		ActiveVersion: target.Version,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to activate template version: %w", err)
+	}
+
+	s.record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "template.rolled_back",
+		ResourceType: "message_template",
+		ResourceID:   id.String(),
+		Metadata: map[string]any{
+			"previous_version": dbTemplate.ActiveVersion,
+			"version":          target.Version,
+		},
+	})
+
+	template := toTemplate(updated)
+	template.Active = toVersion(target)
+	return template, nil
+}
+
+// OperationDeleteTemplate is the staged operation kind that moves a
+// template to the trash once its undo window has passed
+const OperationDeleteTemplate = "templates.delete"
+
+// Deletion is the payload of a staged template deletion
+type Deletion struct {
+	TemplateID uuid.UUID `json:"template_id"`
+	Actor      string    `json:"actor"`
+}
+
+// RunDeleteAction carries out a staged deletion. A template that is already
+// gone, such as by an earlier deletion, is left at that.
+func (s *Service) RunDeleteAction(ctx context.Context, payload json.RawMessage) (any, error) {
+	var d Deletion
+	if err := json.Unmarshal(payload, &d); err != nil {
+		return nil, fmt.Errorf("invalid template deletion: %w", err)
+	}
+	if err := s.DeleteTemplate(ctx, d.TemplateID, d.Actor); err != nil && !errors.Is(err, ErrTemplateNotFound) {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// DeleteTemplate moves a template to the trash, from which it can be
+// restored until it is purged
+func (s *Service) DeleteTemplate(ctx context.Context, id uuid.UUID, actor string) error {
+	rows, err := s.queries.TrashMessageTemplate(ctx, db.TrashMessageTemplateParams{
+		ID:        pgtype.UUID{Bytes: id, Valid: true},
+		DeletedBy: pgtype.Text{String: actor, Valid: actor != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	if rows == 0 {
+		return ErrTemplateNotFound
+	}
+
+	s.record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "template.deleted",
+		ResourceType: "message_template",
+		ResourceID:   id.String(),
+	})
+	return nil
+}
+
+// Preview renders a stored version with sample data
+func (s *Service) Preview(ctx context.Context, id uuid.UUID, req PreviewRequest) (*Rendered, error) {
+	dbTemplate, err := s.getTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	version := int(dbTemplate.ActiveVersion)
+	if req.Version != nil {
+		version = *req.Version
+	}
+	dbVersion, err := s.getVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderVersion(dbTemplate, dbVersion, req.Data)
+}
+
+// Render produces message content for a template key and channel in the
+// locale closest to the requested one. This is the entry point used by
+// modules that send messages.
+func (s *Service) Render(ctx context.Context, key, channel string, locale language.Tag, data map[string]any) (*Rendered, error) {
+	candidates, err := s.queries.ListMessageTemplatesByKey(ctx, db.ListMessageTemplatesByKeyParams{
+		Key:     key,
+		Channel: channel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil, ErrTemplateNotFound
+	}
+
+	dbTemplate := s.matchLocale(candidates, locale)
+	dbVersion, err := s.getVersion(ctx, uuid.UUID(dbTemplate.ID.Bytes), int(dbTemplate.ActiveVersion))
+	if err != nil {
+		return nil, err
+	}
+	return renderVersion(dbTemplate, dbVersion, data)
+}
+
+// matchLocale picks the candidate closest to the requested locale, then
+// the default locale, then the first candidate
+func (s *Service) matchLocale(candidates []db.MessageTemplate, locale language.Tag) db.MessageTemplate {
+	tags := make([]language.Tag, 0, len(candidates))
+	indexes := make([]int, 0, len(candidates))
+	for i, c := range candidates {
+		if tag, err := language.Parse(c.Locale); err == nil {
+			tags = append(tags, tag)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(tags) == 0 {
+		return candidates[0]
+	}
+
+	matcher := language.NewMatcher(tags)
+	for _, want := range []language.Tag{locale, s.defaultLocale} {
+		if _, index, confidence := matcher.Match(want); confidence != language.No {
+			return candidates[indexes[index]]
+		}
+	}
+	return candidates[indexes[0]]
+}
+
+// publish stores a new version and makes it active
+func (s *Service) publish(ctx context.Context, id pgtype.UUID, subject, body string, variables []string, actor string) (*Template, error) {
+	if variables == nil {
+		variables = []string{}
+	}
+
+	version, err := s.queries.CreateMessageTemplateVersion(ctx, db.CreateMessageTemplateVersionParams{
+		TemplateID: id,
+		Subject:    pgtype.Text{String: subject, Valid: subject != ""},
+		Body:       body,
+		Variables:  variables,
+		CreatedBy:  actorOrSystem(actor),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template version: %w", err)
+	}
+
+	updated, err := s.queries.SetMessageTemplateActiveVersion(ctx, db.SetMessageTemplateActiveVersionParams{
+		ID:            id,
+		ActiveVersion: version.Version,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to activate template version: %w", err)
+	}
+
+	template := toTemplate(updated)
+	template.Active = toVersion(version)
+	return template, nil
+}
+
+func (s *Service) getTemplate(ctx context.Context, id uuid.UUID) (db.MessageTemplate, error) {
+	dbTemplate, err := s.queries.GetMessageTemplate(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.MessageTemplate{}, ErrTemplateNotFound
+		}
+		return db.MessageTemplate{}, fmt.Errorf("failed to get template: %w", err)
+	}
+	return dbTemplate, nil
+}
+
+func (s *Service) getVersion(ctx context.Context, id uuid.UUID, version int) (db.MessageTemplateVersion, error) {
+	dbVersion, err := s.queries.GetMessageTemplateVersion(ctx, db.GetMessageTemplateVersionParams{
+		TemplateID: pgtype.UUID{Bytes: id, Valid: true},
+		Version:    int32(version),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.MessageTemplateVersion{}, ErrVersionNotFound
+		}
+		return db.MessageTemplateVersion{}, fmt.Errorf("failed to get template version: %w", err)
+	}
+	return dbVersion, nil
+}
+
+// record writes an audit entry; failures are not propagated because the
+// template change itself has already been committed
+func (s *Service) record(ctx context.Context, entry audit.Entry) {
+	if err := s.auditor.Record(ctx, entry); err != nil {
+		logger.FromContext(ctx).Warn("failed to record audit entry", "error", err, "action", entry.Action)
+	}
+}
+
+func validateContent(channel, subject, body string, variables []string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("%w: body is required", ErrInvalidTemplate)
+	}
+	if channel == ChannelEmail && strings.TrimSpace(subject) == "" {
+		return fmt.Errorf("%w: subject is required for email templates", ErrInvalidTemplate)
+	}
+	_, err := compile(channel, subject, body, variables)
+	return err
+}
+
+func renderVersion(t db.MessageTemplate, v db.MessageTemplateVersion, data map[string]any) (*Rendered, error) {
+	c, err := compile(t.Channel, v.Subject.String, v.Body, v.Variables)
+	if err != nil {
+		return nil, err
+	}
+	subject, body, err := c.render(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Rendered{
+		TemplateID: uuid.UUID(t.ID.Bytes),
+		Version:    int(v.Version),
+		Locale:     t.Locale,
+		Subject:    subject,
+		Body:       body,
+	}, nil
+}
+
+func actorOrSystem(actor string) string {
+	if actor == "" {
+		return audit.SystemActor
+	}
+	return actor
+}
+
+func toTemplate(t db.MessageTemplate) *Template {
+	return &Template{
+		ID:            uuid.UUID(t.ID.Bytes),
+		Key:           t.Key,
+		Channel:       t.Channel,
+		Locale:        t.Locale,
+		Description:   t.Description,
+		ActiveVersion: int(t.ActiveVersion),
+		CreatedAt:     t.CreatedAt.Time,
+		UpdatedAt:     t.UpdatedAt.Time,
+	}
+}
+
+func toVersion(v db.MessageTemplateVersion) *Version {
+	return &Version{
+		Version:   int(v.Version),
+		Subject:   v.Subject.String,
+		Body:      v.Body,
+		Variables: v.Variables,
+		CreatedBy: v.CreatedBy,
+		CreatedAt: v.CreatedAt.Time,
+	}
+}
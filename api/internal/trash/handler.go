@@ -0,0 +1,125 @@
+package trash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"starterkit/internal/platform/apierror"
+)
+
+type ServiceInterface interface {
+	List(ctx context.Context, itemType string, limit int) ([]*Item, error)
+	Restore(ctx context.Context, itemType string, id uuid.UUID, actor string) error
+	Purge(ctx context.Context, itemType string, id uuid.UUID, actor string) error
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleList lists the trash across types, or of the one named by ?type
+func (h *Handler) HandleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 50 // default
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsedLimit, err := strconv.Atoi(limitStr)
+			if err != nil || parsedLimit < 1 || parsedLimit > 500 {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "limit must be between 1 and 500"))
+				return
+			}
+			limit = parsedLimit
+		}
+
+		items, err := h.service.List(r.Context(), r.URL.Query().Get("type"), limit)
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to list trash", uuid.Nil)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"items": items,
+		})
+	}
+}
+
+func (h *Handler) HandleRestore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseID(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.service.Restore(r.Context(), r.PathValue("type"), id, actorFromRequest(r)); err != nil {
+			h.handleServiceError(w, r, err, "failed to restore trash item", id)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) HandlePurge() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseID(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.service.Purge(r.Context(), r.PathValue("type"), id, actorFromRequest(r)); err != nil {
+			h.handleServiceError(w, r, err, "failed to purge trash item", id)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) parseID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_id", "invalid ID format"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) handleServiceError(w http.ResponseWriter, r *http.Request, err error, msg string, id uuid.UUID) {
+	switch {
+	case errors.Is(err, ErrUnknownType):
+		apierror.Write(w, r, apierror.BadRequest("unknown_trash_type", err.Error()))
+	case errors.Is(err, ErrItemNotFound):
+		apierror.Write(w, r, apierror.NotFound("item_not_found", err.Error()))
+	case errors.Is(err, ErrConflict):
+		apierror.Write(w, r, apierror.Conflict("name_in_use", err.Error()))
+	default:
+		h.logger.Error(msg, "error", err, "item_id", id)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// actorFromRequest identifies the caller for audit purposes
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
@@ -0,0 +1,24 @@
+package trash
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Resource types that can be in the trash, named like their audit
+// resource types
+const (
+	TypeMessageTemplate = "message_template"
+	TypeRetentionPolicy = "retention_policy"
+)
+
+// Item is a deleted resource waiting in the trash
+type Item struct {
+	Type      string    `json:"type"`
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy string    `json:"deleted_by,omitempty"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
@@ -0,0 +1,207 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/logger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrUnknownType  = errors.New("unknown trash type")
+	ErrItemNotFound = errors.New("item not found in trash")
+	ErrConflict     = errors.New("a live resource already uses this item's name")
+)
+
+type Querier interface {
+	ListTrash(ctx context.Context, arg db.ListTrashParams) ([]db.ListTrashRow, error)
+	RestoreMessageTemplate(ctx context.Context, id pgtype.UUID) (int64, error)
+	PurgeMessageTemplate(ctx context.Context, id pgtype.UUID) (int64, error)
+	PurgeTrashedMessageTemplates(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+	RestoreRetentionPolicy(ctx context.Context, id pgtype.UUID) (int64, error)
+	PurgeRetentionPolicy(ctx context.Context, id pgtype.UUID) (int64, error)
+	PurgeTrashedRetentionPolicies(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// kind holds the statements that act on one type's trashed rows
+type kind struct {
+	restore     func(ctx context.Context, id pgtype.UUID) (int64, error)
+	purge       func(ctx context.Context, id pgtype.UUID) (int64, error)
+	purgeBefore func(ctx context.Context, cutoff pgtype.Timestamptz) (int64, error)
+}
+
+type Service struct {
+	queries   Querier
+	auditor   Auditor
+	retention time.Duration
+	kinds     map[string]kind
+}
+
+// NewService creates a trash service. Items are purged for good once they
+// have been in the trash for longer than retention.
+func NewService(queries Querier, auditor Auditor, retention time.Duration) *Service {
+	return &Service{
+		queries:   queries,
+		auditor:   auditor,
+		retention: retention,
+		kinds: map[string]kind{
+			TypeMessageTemplate: {
+				restore:     queries.RestoreMessageTemplate,
+				purge:       queries.PurgeMessageTemplate,
+				purgeBefore: queries.PurgeTrashedMessageTemplates,
+			},
+			TypeRetentionPolicy: {
+				restore:     queries.RestoreRetentionPolicy,
+				purge:       queries.PurgeRetentionPolicy,
+				purgeBefore: queries.PurgeTrashedRetentionPolicies,
+			},
+		},
+	}
+}
+
+// List returns trashed items, most recently deleted first. An empty
+// itemType lists every type.
+func (s *Service) List(ctx context.Context, itemType string, limit int) ([]*Item, error) {
+	if itemType != "" {
+		if _, ok := s.kinds[itemType]; !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownType, itemType)
+		}
+	}
+
+	rows, err := s.queries.ListTrash(ctx, db.ListTrashParams{
+		ResourceType: pgtype.Text{String: itemType, Valid: itemType != ""},
+		RowLimit:     int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	items := make([]*Item, len(rows))
+	for i, row := range rows {
+		items[i] = &Item{
+			Type:      row.ResourceType,
+			ID:        uuid.UUID(row.ID.Bytes),
+			Name:      row.Name,
+			DeletedAt: row.DeletedAt.Time,
+			DeletedBy: row.DeletedBy,
+			PurgeAt:   row.DeletedAt.Time.Add(s.retention),
+		}
+	}
+	return items, nil
+}
+
+// Restore takes an item out of the trash. It fails with ErrConflict when a
+// live resource has taken the item's name since it was deleted.
+func (s *Service) Restore(ctx context.Context, itemType string, id uuid.UUID, actor string) error {
+	k, ok := s.kinds[itemType]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownType, itemType)
+	}
+
+	affected, err := k.restore(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrConflict
+		}
+		return fmt.Errorf("failed to restore %s: %w", itemType, err)
+	}
+	if affected == 0 {
+		return ErrItemNotFound
+	}
+
+	s.record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "trash.restored",
+		ResourceType: itemType,
+		ResourceID:   id.String(),
+	})
+	return nil
+}
+
+// Purge deletes an item in the trash for good
+func (s *Service) Purge(ctx context.Context, itemType string, id uuid.UUID, actor string) error {
+	k, ok := s.kinds[itemType]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownType, itemType)
+	}
+
+	affected, err := k.purge(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to purge %s: %w", itemType, err)
+	}
+	if affected == 0 {
+		return ErrItemNotFound
+	}
+
+	s.record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       "trash.purged",
+		ResourceType: itemType,
+		ResourceID:   id.String(),
+	})
+	return nil
+}
+
+// PurgeExpired deletes every item that has been in the trash for longer
+// than the retention period. It runs on the scheduler.
+func (s *Service) PurgeExpired(ctx context.Context) error {
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(-s.retention), Valid: true}
+	purged := map[string]int64{}
+
+	var errs []error
+	for _, itemType := range s.types() {
+		n, err := s.kinds[itemType].purgeBefore(ctx, cutoff)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to purge trashed %s: %w", itemType, err))
+			continue
+		}
+		if n > 0 {
+			purged[itemType] = n
+		}
+	}
+
+	if len(purged) > 0 {
+		s.record(ctx, audit.Entry{
+			Actor:        audit.SystemActor,
+			Action:       "trash.expired",
+			ResourceType: "trash",
+			Metadata: map[string]any{
+				"purged":    purged,
+				"retention": s.retention.String(),
+			},
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// types lists the resource types the trash holds
+func (s *Service) types() []string {
+	types := make([]string, 0, len(s.kinds))
+	for itemType := range s.kinds {
+		types = append(types, itemType)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// record writes an audit entry; failures are not propagated because the
+// change itself has already been committed
+func (s *Service) record(ctx context.Context, entry audit.Entry) {
+	if err := s.auditor.Record(ctx, entry); err != nil {
+		logger.FromContext(ctx).Warn("failed to record audit entry", "error", err, "action", entry.Action)
+	}
+}
@@ -0,0 +1,203 @@
+package uploads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"starterkit/internal/platform/apierror"
+)
+
+// multipartOverhead allows for form boundaries and part headers on top of the file itself
+const multipartOverhead = 1 << 20
+
+type ServiceInterface interface {
+	MaxSize() int64
+	Create(ctx context.Context, owner, filename, contentType string, r io.Reader) (*Upload, error)
+	Get(ctx context.Context, id uuid.UUID, owner string) (*Upload, error)
+	Open(ctx context.Context, id uuid.UUID, owner string) (io.ReadCloser, *Upload, error)
+	ResumableMaxSize() int64
+	CreateResumable(ctx context.Context, owner, filename, contentType string, length int64) (*Resumable, error)
+	GetResumable(ctx context.Context, id uuid.UUID, owner string) (*Resumable, error)
+	AppendChunk(ctx context.Context, id uuid.UUID, owner string, offset int64, r io.Reader) (*Resumable, error)
+	CancelResumable(ctx context.Context, id uuid.UUID, owner string) error
+}
+
+type Handler struct {
+	service   ServiceInterface
+	downloads *throttle
+	logger    *slog.Logger
+}
+
+// NewHandler creates the uploads handler. Each user's downloads share
+// downloadRate bytes per second after a burst of downloadBurst bytes; a
+// downloadRate of 0 leaves them unlimited.
+func NewHandler(service ServiceInterface, downloadRate, downloadBurst int, logger *slog.Logger) *Handler {
+	return &Handler{
+		service:   service,
+		downloads: newThrottle(downloadRate, downloadBurst),
+		logger:    logger,
+	}
+}
+
+// HandleCreate accepts a multipart/form-data upload in the "file" field. The
+// file is quarantined and scanned asynchronously; poll the returned
+// Location until status is clean or infected. Metadata is added shortly
+// after a file is found clean.
+func (h *Handler) HandleCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, h.service.MaxSize()+multipartOverhead)
+
+		reader, err := r.MultipartReader()
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("multipart_body_required", "multipart/form-data body is required"))
+			return
+		}
+
+		// Stream the first "file" part without buffering the whole body
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				apierror.Write(w, r, apierror.BadRequest("file_field_required", "file field is required"))
+				return
+			}
+			if err != nil {
+				h.respondWithUploadError(w, r, err)
+				return
+			}
+			if part.FormName() != "file" {
+				part.Close()
+				continue
+			}
+
+			filename := filepath.Base(part.FileName())
+			contentType := part.Header.Get("Content-Type")
+			if _, _, err := mime.ParseMediaType(contentType); err != nil {
+				contentType = "application/octet-stream"
+			}
+
+			upload, err := h.service.Create(r.Context(), actorFromRequest(r), filename, contentType, part)
+			part.Close()
+			if err != nil {
+				h.respondWithUploadError(w, r, err)
+				return
+			}
+
+			w.Header().Set("Location", "/api/v1/uploads/"+upload.ID.String())
+			h.respondWithJSON(w, http.StatusAccepted, upload)
+			return
+		}
+	}
+}
+
+func (h *Handler) HandleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uploadID, ok := h.parseUploadID(w, r)
+		if !ok {
+			return
+		}
+
+		upload, err := h.service.Get(r.Context(), uploadID, actorFromRequest(r))
+		if err != nil {
+			h.respondWithUploadError(w, r, err)
+			return
+		}
+
+		h.respondWithJSON(w, http.StatusOK, upload)
+	}
+}
+
+// HandleDownload streams the contents of a clean upload from storage to
+// its owner, throttled to the owner's download rate. Single and multiple
+// byte ranges are served when the storage backend can seek, with If-Range
+// and If-None-Match checked against the upload's checksum.
+func (h *Handler) HandleDownload() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uploadID, ok := h.parseUploadID(w, r)
+		if !ok {
+			return
+		}
+
+		owner := actorFromRequest(r)
+		rc, upload, err := h.service.Open(r.Context(), uploadID, owner)
+		if err != nil {
+			h.respondWithUploadError(w, r, err)
+			return
+		}
+		defer rc.Close()
+
+		w.Header().Set("Content-Type", upload.ContentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": upload.Filename}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "private, no-cache")
+		w.Header().Set("ETag", `"`+upload.SHA256+`"`)
+
+		dw, release := h.downloads.writer(r.Context(), w, owner)
+		defer release()
+
+		if content, ok := rc.(io.ReadSeeker); ok {
+			http.ServeContent(dw, r, "", upload.CreatedAt, content)
+			return
+		}
+
+		w.Header().Set("Accept-Ranges", "none")
+		w.Header().Set("Content-Length", strconv.FormatInt(upload.Size, 10))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodHead {
+			return
+		}
+		if _, err := io.Copy(dw, rc); err != nil {
+			h.logger.Error("failed to stream upload", "error", err, "upload_id", uploadID)
+		}
+	}
+}
+
+func (h *Handler) parseUploadID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	uploadID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_upload_id", "invalid upload ID format"))
+		return uuid.Nil, false
+	}
+	return uploadID, true
+}
+
+func (h *Handler) respondWithUploadError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrTooLarge), errors.As(err, &maxBytesErr):
+		apierror.Write(w, r, apierror.New(http.StatusRequestEntityTooLarge, "upload_too_large", "upload exceeds maximum size"))
+	case errors.Is(err, ErrChunkTooLarge):
+		apierror.Write(w, r, apierror.New(http.StatusRequestEntityTooLarge, "chunk_too_large", err.Error()))
+	case errors.Is(err, ErrOffsetMismatch):
+		apierror.Write(w, r, apierror.Conflict("offset_mismatch", err.Error()))
+	case errors.Is(err, ErrUploadNotFound):
+		apierror.Write(w, r, apierror.NotFound("upload_not_found", "upload not found"))
+	case errors.Is(err, ErrQuarantined):
+		apierror.Write(w, r, apierror.Conflict("quarantined", err.Error()))
+	case errors.Is(err, ErrRejected):
+		apierror.Write(w, r, apierror.New(http.StatusGone, "rejected", err.Error()))
+	default:
+		h.logger.Error("upload request failed", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
@@ -0,0 +1,235 @@
+package uploads
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+)
+
+const (
+	// sniffLen is how much of a file http.DetectContentType looks at
+	sniffLen = 512
+	// maxMoovSize bounds the MP4 movie header read into memory
+	maxMoovSize = 16 << 20
+	// maxFmtSize bounds the WAV format chunk read into memory
+	maxFmtSize = 1 << 10
+)
+
+var errMalformed = errors.New("malformed media file")
+
+// extractMetadata reads the contents of a clean upload and describes them.
+// Dimensions are read from PNG, JPEG and GIF images and MP4 video, and
+// durations from MP4 and WAV. Other formats, and malformed files, only get
+// their sniffed type. The error is that of reading r.
+func extractMetadata(r io.Reader) (Metadata, error) {
+	src := &errReader{r: r}
+	br := bufio.NewReaderSize(src, sniffLen)
+	head, _ := br.Peek(sniffLen)
+	meta := Metadata{SniffedType: http.DetectContentType(head)}
+
+	switch meta.SniffedType {
+	case "image/png", "image/jpeg", "image/gif":
+		if cfg, _, err := image.DecodeConfig(br); err == nil {
+			meta.Width, meta.Height = &cfg.Width, &cfg.Height
+		}
+	case "video/mp4":
+		_ = readMP4(br, &meta)
+	case "audio/wave":
+		_ = readWAV(br, &meta)
+	}
+	return meta, src.err
+}
+
+// errReader remembers the first error of r other than io.EOF, so failures
+// to read the file can be told apart from malformed contents
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF && e.err == nil {
+		e.err = err
+	}
+	return n, err
+}
+
+// readMP4 finds the movie header box and reads the duration from its mvhd
+// box and the dimensions from the first video track's tkhd box
+func readMP4(r io.Reader, meta *Metadata) error {
+	var header [16]byte
+	for {
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return err
+		}
+		size, typ := uint64(binary.BigEndian.Uint32(header[:4])), string(header[4:8])
+		headerLen := uint64(8)
+		switch size {
+		case 0:
+			// The box runs to the end of the file
+			if typ != "moov" {
+				return nil
+			}
+			data, err := io.ReadAll(io.LimitReader(r, maxMoovSize))
+			if err != nil {
+				return err
+			}
+			readMoov(data, meta)
+			return nil
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return err
+			}
+			size, headerLen = binary.BigEndian.Uint64(header[8:16]), 16
+		}
+		if size < headerLen {
+			return errMalformed
+		}
+
+		if typ == "moov" {
+			if size-headerLen > maxMoovSize {
+				return errMalformed
+			}
+			data := make([]byte, size-headerLen)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return err
+			}
+			readMoov(data, meta)
+			return nil
+		}
+		if _, err := io.CopyN(io.Discard, r, int64(size-headerLen)); err != nil {
+			return err
+		}
+	}
+}
+
+func readMoov(moov []byte, meta *Metadata) {
+	walkBoxes(moov, func(typ string, payload []byte) {
+		switch typ {
+		case "mvhd":
+			if ms, ok := mvhdDuration(payload); ok {
+				meta.DurationMS = &ms
+			}
+		case "trak":
+			walkBoxes(payload, func(typ string, payload []byte) {
+				if typ != "tkhd" || meta.Width != nil {
+					return
+				}
+				if width, height, ok := tkhdDimensions(payload); ok {
+					meta.Width, meta.Height = &width, &height
+				}
+			})
+		}
+	})
+}
+
+// walkBoxes calls fn with the type and payload of each box in data
+func walkBoxes(data []byte, fn func(typ string, payload []byte)) {
+	for len(data) >= 8 {
+		size, typ := uint64(binary.BigEndian.Uint32(data)), string(data[4:8])
+		headerLen := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return
+			}
+			size, headerLen = binary.BigEndian.Uint64(data[8:16]), 16
+		}
+		if size < headerLen || size > uint64(len(data)) {
+			return
+		}
+		fn(typ, data[headerLen:size])
+		data = data[size:]
+	}
+}
+
+// mvhdDuration reads the duration of a movie in milliseconds
+func mvhdDuration(p []byte) (int64, bool) {
+	var timescale, duration uint64
+	switch {
+	case len(p) >= 32 && p[0] == 1:
+		timescale, duration = uint64(binary.BigEndian.Uint32(p[20:])), binary.BigEndian.Uint64(p[24:])
+	case len(p) >= 20 && p[0] == 0:
+		timescale, duration = uint64(binary.BigEndian.Uint32(p[12:])), uint64(binary.BigEndian.Uint32(p[16:]))
+	default:
+		return 0, false
+	}
+	// A duration of all ones means it is unknown
+	if timescale == 0 || duration == 1<<64-1 || (p[0] == 0 && duration == 1<<32-1) {
+		return 0, false
+	}
+	ms := duration/timescale*1000 + duration%timescale*1000/timescale
+	if ms > 1<<63-1 {
+		return 0, false
+	}
+	return int64(ms), true
+}
+
+// tkhdDimensions reads the presentation size of a track, which is zero
+// for tracks other than video
+func tkhdDimensions(p []byte) (int, int, bool) {
+	offset := 76
+	if len(p) > 0 && p[0] == 1 {
+		offset = 88
+	}
+	if len(p) < offset+8 {
+		return 0, 0, false
+	}
+	// Fixed-point 16.16 values
+	width, height := int(binary.BigEndian.Uint32(p[offset:])>>16), int(binary.BigEndian.Uint32(p[offset+4:])>>16)
+	return width, height, width > 0 && height > 0
+}
+
+// readWAV reads the duration of a RIFF WAVE file from its byte rate and
+// the size of its data chunk
+func readWAV(r io.Reader, meta *Metadata) error {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	if !bytes.Equal(header[:4], []byte("RIFF")) || !bytes.Equal(header[8:12], []byte("WAVE")) {
+		return errMalformed
+	}
+
+	var byteRate uint32
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return err
+		}
+		id, size := string(chunk[:4]), binary.LittleEndian.Uint32(chunk[4:])
+		switch id {
+		case "fmt ":
+			if size < 16 || size > maxFmtSize {
+				return errMalformed
+			}
+			data := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return err
+			}
+			byteRate = binary.LittleEndian.Uint32(data[8:12])
+		case "data":
+			if byteRate == 0 {
+				return errMalformed
+			}
+			ms := int64(size) * 1000 / int64(byteRate)
+			meta.DurationMS = &ms
+			return nil
+		default:
+			// Chunks are padded to an even size
+			if _, err := io.CopyN(io.Discard, r, int64(size)+int64(size%2)); err != nil {
+				return err
+			}
+		}
+	}
+}
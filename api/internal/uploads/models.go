@@ -0,0 +1,58 @@
+package uploads
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upload statuses. Files stay in quarantine until they are clean.
+const (
+	StatusPending  = "pending"
+	StatusScanning = "scanning"
+	StatusClean    = "clean"
+	StatusInfected = "infected"
+)
+
+type Upload struct {
+	ID          uuid.UUID   `json:"id"`
+	Filename    string      `json:"filename"`
+	ContentType string      `json:"content_type"`
+	Size        int64       `json:"size"`
+	SHA256      string      `json:"sha256"`
+	Status      string      `json:"status"`
+	Scan        *ScanResult `json:"scan,omitempty"`
+	Metadata    *Metadata   `json:"metadata,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// ScanResult records the outcome of the most recent scan attempt
+type ScanResult struct {
+	Engine    string     `json:"engine,omitempty"`
+	Signature string     `json:"signature,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	ScannedAt *time.Time `json:"scanned_at"`
+}
+
+// Metadata describes the contents of a clean upload. It is extracted in the
+// background once the upload is released from quarantine. Width and height
+// are set for images and video, and DurationMS for audio and video, in the
+// formats that are recognized.
+type Metadata struct {
+	SniffedType string    `json:"sniffed_type" doc:"MIME type detected from the contents, which may differ from content_type"`
+	Width       *int      `json:"width,omitempty"`
+	Height      *int      `json:"height,omitempty"`
+	DurationMS  *int64    `json:"duration_ms,omitempty"`
+	ExtractedAt time.Time `json:"extracted_at"`
+}
+
+// Resumable is an upload sent in chunks over the tus protocol. Once Offset
+// reaches Length the file becomes the upload with the same ID, and
+// ExpiresAt is zero.
+type Resumable struct {
+	ID        uuid.UUID
+	Length    int64
+	Offset    int64
+	ExpiresAt time.Time
+}
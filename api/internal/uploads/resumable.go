@@ -0,0 +1,284 @@
+package uploads
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// expireBatch is how many expired resumable uploads are dropped per run
+const expireBatch = 100
+
+// ResumableMaxSize returns the largest accepted resumable upload in bytes
+func (s *Service) ResumableMaxSize() int64 {
+	return s.resumableMaxSize
+}
+
+// CreateResumable starts a resumable upload of length bytes. An empty file
+// is complete at once.
+func (s *Service) CreateResumable(ctx context.Context, owner, filename, contentType string, length int64) (*Resumable, error) {
+	if length > s.resumableMaxSize {
+		return nil, ErrTooLarge
+	}
+
+	row, err := s.queries.CreateResumableUpload(ctx, db.CreateResumableUploadParams{
+		ID:           pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		Owner:        owner,
+		Filename:     filename,
+		ContentType:  contentType,
+		UploadLength: length,
+		ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(s.resumableTTL), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resumable upload: %w", err)
+	}
+	if length == 0 {
+		return s.finish(ctx, row)
+	}
+	return toResumable(row), nil
+}
+
+// GetResumable reports how much of a resumable upload has been received.
+// A complete one reports the upload it became.
+func (s *Service) GetResumable(ctx context.Context, id uuid.UUID, owner string) (*Resumable, error) {
+	row, err := s.getResumable(ctx, id, owner)
+	if errors.Is(err, ErrUploadNotFound) {
+		return s.completed(ctx, id, owner)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if row.UploadOffset == row.UploadLength {
+		// Every byte arrived but assembling the file failed; try again
+		return s.finish(ctx, row)
+	}
+	return toResumable(row), nil
+}
+
+// AppendChunk stores the chunk read from r, which must start at the
+// upload's current offset, and extends the upload's expiry. Once the last
+// byte has arrived the chunks are assembled into an upload and scanned
+// like any other.
+func (s *Service) AppendChunk(ctx context.Context, id uuid.UUID, owner string, offset int64, r io.Reader) (*Resumable, error) {
+	row, err := s.getResumable(ctx, id, owner)
+	if errors.Is(err, ErrUploadNotFound) {
+		resumable, err := s.completed(ctx, id, owner)
+		if err != nil {
+			return nil, err
+		}
+		if offset != resumable.Offset {
+			return nil, ErrOffsetMismatch
+		}
+		return resumable, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if offset != row.UploadOffset {
+		return nil, ErrOffsetMismatch
+	}
+
+	// Read one byte past the rest of the file to detect chunks that overrun it
+	remaining := row.UploadLength - row.UploadOffset
+	key := chunkKey(id)
+	counter := &countingWriter{}
+	if err := s.store.Put(ctx, key, io.TeeReader(io.LimitReader(r, remaining+1), counter)); err != nil {
+		s.deleteObject(ctx, key)
+		return nil, fmt.Errorf("failed to store chunk: %w", err)
+	}
+	switch {
+	case counter.n > remaining:
+		s.deleteObject(ctx, key)
+		return nil, ErrChunkTooLarge
+	case counter.n == 0:
+		s.deleteObject(ctx, key)
+		if remaining == 0 {
+			return s.finish(ctx, row)
+		}
+		return toResumable(row), nil
+	}
+
+	// The offset only advances from the one the chunk was sent at, so of
+	// two chunks racing for it one is kept
+	row, err = s.queries.AppendResumableUploadChunk(ctx, db.AppendResumableUploadChunkParams{
+		Size:           counter.n,
+		ChunkKey:       key,
+		ExpiresAt:      pgtype.Timestamptz{Time: time.Now().Add(s.resumableTTL), Valid: true},
+		ID:             pgtype.UUID{Bytes: id, Valid: true},
+		ExpectedOffset: offset,
+	})
+	if err != nil {
+		s.deleteObject(ctx, key)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOffsetMismatch
+		}
+		return nil, fmt.Errorf("failed to record chunk: %w", err)
+	}
+	if row.UploadOffset == row.UploadLength {
+		return s.finish(ctx, row)
+	}
+	return toResumable(row), nil
+}
+
+// CancelResumable stops an incomplete resumable upload and drops its chunks
+func (s *Service) CancelResumable(ctx context.Context, id uuid.UUID, owner string) error {
+	row, err := s.getResumable(ctx, id, owner)
+	if err != nil {
+		return err
+	}
+	if err := s.queries.DeleteResumableUpload(ctx, row.ID); err != nil {
+		return fmt.Errorf("failed to delete resumable upload: %w", err)
+	}
+	s.deleteChunks(ctx, row.ChunkKeys)
+	return nil
+}
+
+// ExpireResumable drops resumable uploads that received no chunk within
+// the TTL, with their chunks. It is run periodically by the scheduler.
+func (s *Service) ExpireResumable(ctx context.Context) error {
+	expired, err := s.queries.ListExpiredResumableUploads(ctx, expireBatch)
+	if err != nil {
+		return fmt.Errorf("failed to list expired resumable uploads: %w", err)
+	}
+
+	for _, row := range expired {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// A chunk may have arrived since the upload was listed
+		deleted, err := s.queries.DeleteExpiredResumableUpload(ctx, row.ID)
+		if err != nil {
+			return fmt.Errorf("failed to delete expired resumable upload: %w", err)
+		}
+		if deleted > 0 {
+			s.deleteChunks(ctx, row.ChunkKeys)
+		}
+	}
+	return nil
+}
+
+// finish assembles the chunks of a complete resumable upload into the
+// upload with the same ID and drops them. The file is stored under a key
+// of its own, so a run racing another, or retrying one that failed, never
+// overwrites a registered file: the first upload registered wins.
+func (s *Service) finish(ctx context.Context, row db.ResumableUpload) (*Resumable, error) {
+	id := uuid.UUID(row.ID.Bytes)
+	chunks := &chunkReader{ctx: ctx, store: s.store, keys: row.ChunkKeys}
+	defer chunks.Close()
+
+	_, err := s.create(ctx, id, quarantineKey(uuid.New()), row.Owner, row.Filename, row.ContentType, chunks, row.UploadLength)
+	if err != nil && !isUniqueViolation(err) {
+		return nil, fmt.Errorf("failed to assemble resumable upload: %w", err)
+	}
+	if err := s.queries.DeleteResumableUpload(ctx, row.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete resumable upload: %w", err)
+	}
+	s.deleteChunks(ctx, row.ChunkKeys)
+	return &Resumable{ID: id, Length: row.UploadLength, Offset: row.UploadLength}, nil
+}
+
+// completed reports the upload a resumable upload became
+func (s *Service) completed(ctx context.Context, id uuid.UUID, owner string) (*Resumable, error) {
+	upload, err := s.get(ctx, id, owner)
+	if err != nil {
+		return nil, err
+	}
+	return &Resumable{ID: id, Length: upload.Size, Offset: upload.Size}, nil
+}
+
+func (s *Service) getResumable(ctx context.Context, id uuid.UUID, owner string) (db.ResumableUpload, error) {
+	row, err := s.queries.GetResumableUpload(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.ResumableUpload{}, ErrUploadNotFound
+		}
+		return db.ResumableUpload{}, fmt.Errorf("failed to get resumable upload: %w", err)
+	}
+	// Other users' uploads are indistinguishable from missing ones
+	if row.Owner != owner {
+		return db.ResumableUpload{}, ErrUploadNotFound
+	}
+	return row, nil
+}
+
+func (s *Service) deleteChunks(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		s.deleteObject(ctx, key)
+	}
+}
+
+// chunkKey names a chunk uniquely, so chunks racing for the same offset
+// never overwrite each other
+func chunkKey(id uuid.UUID) string {
+	return "uploads/chunks/" + id.String() + "/" + strings.ToLower(rand.Text())
+}
+
+// chunkReader reads the chunks of a resumable upload in order, opening
+// each one as the previous one ends
+type chunkReader struct {
+	ctx     context.Context
+	store   storage.Storage
+	keys    []string
+	current io.ReadCloser
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if len(c.keys) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := c.store.Get(c.ctx, c.keys[0])
+			if err != nil {
+				return 0, fmt.Errorf("failed to open chunk: %w", err)
+			}
+			c.current, c.keys = rc, c.keys[1:]
+		}
+
+		n, err := c.current.Read(p)
+		if err == io.EOF {
+			c.current.Close()
+			c.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *chunkReader) Close() error {
+	if c.current == nil {
+		return nil
+	}
+	err := c.current.Close()
+	c.current = nil
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func toResumable(row db.ResumableUpload) *Resumable {
+	return &Resumable{
+		ID:        uuid.UUID(row.ID.Bytes),
+		Length:    row.UploadLength,
+		Offset:    row.UploadOffset,
+		ExpiresAt: row.ExpiresAt.Time,
+	}
+}
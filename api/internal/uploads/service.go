@@ -0,0 +1,415 @@
+package uploads
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/scanner"
+	"starterkit/internal/platform/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrUploadNotFound = errors.New("upload not found")
+	ErrTooLarge       = errors.New("upload exceeds maximum size")
+	ErrQuarantined    = errors.New("upload is quarantined until scanned")
+	ErrRejected       = errors.New("upload was rejected by malware scan")
+	ErrOffsetMismatch = errors.New("chunk offset does not match the upload offset")
+	ErrChunkTooLarge  = errors.New("chunk exceeds the upload length")
+)
+
+// claimTimeout is how long an upload may stay in the scanning state before
+// another worker assumes the scan was interrupted and retries it
+const claimTimeout = 10 * time.Minute
+
+type Querier interface {
+	CreateUpload(ctx context.Context, arg db.CreateUploadParams) (db.Upload, error)
+	GetUpload(ctx context.Context, id pgtype.UUID) (db.Upload, error)
+	ClaimPendingUploads(ctx context.Context, arg db.ClaimPendingUploadsParams) ([]db.Upload, error)
+	CompleteUploadScan(ctx context.Context, arg db.CompleteUploadScanParams) (db.Upload, error)
+	FindUploadObjectBySHA256(ctx context.Context, arg db.FindUploadObjectBySHA256Params) (string, error)
+	ListUploadsWithoutMetadata(ctx context.Context, limit int32) ([]db.Upload, error)
+	SetUploadMetadata(ctx context.Context, arg db.SetUploadMetadataParams) error
+	CreateResumableUpload(ctx context.Context, arg db.CreateResumableUploadParams) (db.ResumableUpload, error)
+	GetResumableUpload(ctx context.Context, id pgtype.UUID) (db.ResumableUpload, error)
+	AppendResumableUploadChunk(ctx context.Context, arg db.AppendResumableUploadChunkParams) (db.ResumableUpload, error)
+	DeleteResumableUpload(ctx context.Context, id pgtype.UUID) error
+	ListExpiredResumableUploads(ctx context.Context, limit int32) ([]db.ResumableUpload, error)
+	DeleteExpiredResumableUpload(ctx context.Context, id pgtype.UUID) (int64, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+type Service struct {
+	queries          Querier
+	store            storage.Storage
+	scanner          scanner.Scanner
+	auditor          Auditor
+	maxSize          int64
+	scanBatch        int
+	resumableMaxSize int64
+	resumableTTL     time.Duration
+}
+
+func NewService(queries Querier, store storage.Storage, scan scanner.Scanner, auditor Auditor, maxSize int64, scanBatch int, resumableMaxSize int64, resumableTTL time.Duration) *Service {
+	return &Service{
+		queries:          queries,
+		store:            store,
+		scanner:          scan,
+		auditor:          auditor,
+		maxSize:          maxSize,
+		scanBatch:        scanBatch,
+		resumableMaxSize: resumableMaxSize,
+		resumableTTL:     resumableTTL,
+	}
+}
+
+// MaxSize returns the largest accepted upload in bytes
+func (s *Service) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Create stores r in quarantine and registers it for scanning
+func (s *Service) Create(ctx context.Context, owner, filename, contentType string, r io.Reader) (*Upload, error) {
+	id := uuid.New()
+	return s.create(ctx, id, quarantineKey(id), owner, filename, contentType, r, s.maxSize)
+}
+
+// create stores r in quarantine under key and registers it as upload id.
+// The object is deleted again when the upload cannot be registered.
+func (s *Service) create(ctx context.Context, id uuid.UUID, key, owner, filename, contentType string, r io.Reader, maxSize int64) (*Upload, error) {
+	// Hash and measure while streaming; read one byte past the limit to detect oversize files
+	hasher := sha256.New()
+	counter := &countingWriter{}
+	body := io.TeeReader(io.LimitReader(r, maxSize+1), io.MultiWriter(hasher, counter))
+
+	if err := s.store.Put(ctx, key, body); err != nil {
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+	if counter.n > maxSize {
+		s.deleteObject(ctx, key)
+		return nil, ErrTooLarge
+	}
+
+	dbUpload, err := s.queries.CreateUpload(ctx, db.CreateUploadParams{
+		ID:          pgtype.UUID{Bytes: id, Valid: true},
+		Owner:       owner,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        counter.n,
+		Sha256:      hex.EncodeToString(hasher.Sum(nil)),
+		ObjectKey:   key,
+	})
+	if err != nil {
+		s.deleteObject(ctx, key)
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	return toUpload(dbUpload), nil
+}
+
+// Get returns an upload owned by owner
+func (s *Service) Get(ctx context.Context, id uuid.UUID, owner string) (*Upload, error) {
+	dbUpload, err := s.get(ctx, id, owner)
+	if err != nil {
+		return nil, err
+	}
+	return toUpload(dbUpload), nil
+}
+
+// Open returns the contents of a clean upload. Quarantined and rejected
+// uploads are never served.
+func (s *Service) Open(ctx context.Context, id uuid.UUID, owner string) (io.ReadCloser, *Upload, error) {
+	dbUpload, err := s.get(ctx, id, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch dbUpload.Status {
+	case StatusClean:
+	case StatusInfected:
+		return nil, nil, ErrRejected
+	default:
+		return nil, nil, ErrQuarantined
+	}
+
+	rc, err := s.store.Get(ctx, dbUpload.ObjectKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+	return rc, toUpload(dbUpload), nil
+}
+
+// ScanPending scans a batch of quarantined uploads. It is run periodically
+// by the scheduler.
+func (s *Service) ScanPending(ctx context.Context) error {
+	claimed, err := s.queries.ClaimPendingUploads(ctx, db.ClaimPendingUploadsParams{
+		StaleBefore: pgtype.Timestamptz{Time: time.Now().Add(-claimTimeout), Valid: true},
+		RowLimit:    int32(s.scanBatch),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to claim pending uploads: %w", err)
+	}
+
+	for _, u := range claimed {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.scan(ctx, u)
+	}
+	return nil
+}
+
+// scan runs the scanner on one upload and releases, rejects, or requeues it
+func (s *Service) scan(ctx context.Context, u db.Upload) {
+	log := logger.FromContext(ctx).With("upload_id", uuid.UUID(u.ID.Bytes))
+
+	result, err := s.scanObject(ctx, u.ObjectKey)
+	if err != nil {
+		// Leave the file in quarantine and retry on the next run
+		log.Error("upload scan failed", "error", err)
+		s.complete(ctx, u, StatusPending, u.ObjectKey, scanner.Result{}, err.Error())
+		return
+	}
+
+	if result.Infected {
+		s.deleteObject(ctx, u.ObjectKey)
+		s.complete(ctx, u, StatusInfected, u.ObjectKey, result, "")
+		s.record(ctx, audit.Entry{
+			Actor:        audit.SystemActor,
+			Action:       "upload.rejected",
+			ResourceType: "upload",
+			ResourceID:   uuid.UUID(u.ID.Bytes).String(),
+			Metadata: map[string]any{
+				"owner":     u.Owner,
+				"filename":  u.Filename,
+				"sha256":    u.Sha256,
+				"engine":    result.Engine,
+				"signature": result.Signature,
+			},
+		})
+		log.Warn("infected upload rejected", "signature", result.Signature)
+		return
+	}
+
+	finalKey, err := s.release(ctx, u)
+	if err != nil {
+		log.Error("failed to release upload from quarantine", "error", err)
+		s.complete(ctx, u, StatusPending, u.ObjectKey, result, err.Error())
+		return
+	}
+	s.complete(ctx, u, StatusClean, finalKey, result, "")
+}
+
+// release moves a clean upload out of quarantine and returns its new key.
+// Objects are stored by checksum, and an upload identical to one released
+// before shares its object instead of storing another copy.
+func (s *Service) release(ctx context.Context, u db.Upload) (string, error) {
+	key, err := s.queries.FindUploadObjectBySHA256(ctx, db.FindUploadObjectBySHA256Params{
+		Sha256:    u.Sha256,
+		ExcludeID: u.ID,
+	})
+	switch {
+	case err == nil:
+		s.deleteObject(ctx, u.ObjectKey)
+		return key, nil
+	case !errors.Is(err, pgx.ErrNoRows):
+		return "", fmt.Errorf("failed to look up identical uploads: %w", err)
+	}
+
+	key = releasedKey(u.Sha256)
+	if err := s.moveObject(ctx, u.ObjectKey, key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// ExtractPending extracts the metadata of a batch of clean uploads that
+// have none yet. It is run periodically by the scheduler.
+func (s *Service) ExtractPending(ctx context.Context) error {
+	pending, err := s.queries.ListUploadsWithoutMetadata(ctx, int32(s.scanBatch))
+	if err != nil {
+		return fmt.Errorf("failed to list uploads without metadata: %w", err)
+	}
+
+	for _, u := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.extract(ctx, u)
+	}
+	return nil
+}
+
+// extract reads one upload and stores its metadata. Uploads whose object
+// cannot be read are retried on the next run, unless it is missing.
+func (s *Service) extract(ctx context.Context, u db.Upload) {
+	log := logger.FromContext(ctx).With("upload_id", uuid.UUID(u.ID.Bytes))
+
+	var meta Metadata
+	rc, err := s.store.Get(ctx, u.ObjectKey)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		log.Warn("upload object missing, storing empty metadata", "key", u.ObjectKey)
+	case err != nil:
+		log.Error("failed to read upload for metadata", "error", err)
+		return
+	default:
+		meta, err = extractMetadata(rc)
+		rc.Close()
+		if err != nil {
+			log.Error("failed to read upload for metadata", "error", err)
+			return
+		}
+	}
+
+	params := db.SetUploadMetadataParams{
+		ID:          u.ID,
+		SniffedType: pgtype.Text{String: meta.SniffedType, Valid: meta.SniffedType != ""},
+	}
+	if meta.Width != nil {
+		params.Width = pgtype.Int4{Int32: int32(*meta.Width), Valid: true}
+		params.Height = pgtype.Int4{Int32: int32(*meta.Height), Valid: true}
+	}
+	if meta.DurationMS != nil {
+		params.DurationMs = pgtype.Int8{Int64: *meta.DurationMS, Valid: true}
+	}
+	if err := s.queries.SetUploadMetadata(ctx, params); err != nil {
+		log.Error("failed to store upload metadata", "error", err)
+	}
+}
+
+func (s *Service) scanObject(ctx context.Context, key string) (scanner.Result, error) {
+	rc, err := s.store.Get(ctx, key)
+	if err != nil {
+		return scanner.Result{}, fmt.Errorf("failed to read quarantined upload: %w", err)
+	}
+	defer rc.Close()
+	return s.scanner.Scan(ctx, rc)
+}
+
+func (s *Service) moveObject(ctx context.Context, from, to string) error {
+	rc, err := s.store.Get(ctx, from)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := s.store.Put(ctx, to, rc); err != nil {
+		return err
+	}
+	s.deleteObject(ctx, from)
+	return nil
+}
+
+func (s *Service) complete(ctx context.Context, u db.Upload, status, key string, result scanner.Result, scanErr string) {
+	_, err := s.queries.CompleteUploadScan(ctx, db.CompleteUploadScanParams{
+		ID:            u.ID,
+		Status:        status,
+		ObjectKey:     key,
+		ScanEngine:    pgtype.Text{String: result.Engine, Valid: result.Engine != ""},
+		ScanSignature: pgtype.Text{String: result.Signature, Valid: result.Signature != ""},
+		ScanError:     pgtype.Text{String: scanErr, Valid: scanErr != ""},
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to record upload scan", "error", err, "upload_id", uuid.UUID(u.ID.Bytes))
+	}
+}
+
+func (s *Service) get(ctx context.Context, id uuid.UUID, owner string) (db.Upload, error) {
+	dbUpload, err := s.queries.GetUpload(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Upload{}, ErrUploadNotFound
+		}
+		return db.Upload{}, fmt.Errorf("failed to get upload: %w", err)
+	}
+	// Other users' uploads are indistinguishable from missing ones
+	if dbUpload.Owner != owner {
+		return db.Upload{}, ErrUploadNotFound
+	}
+	return dbUpload, nil
+}
+
+func (s *Service) deleteObject(ctx context.Context, key string) {
+	if err := s.store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		logger.FromContext(ctx).Warn("failed to delete upload object", "error", err, "key", key)
+	}
+}
+
+// record writes an audit entry; failures are not propagated because the
+// scan outcome has already been stored
+func (s *Service) record(ctx context.Context, entry audit.Entry) {
+	if err := s.auditor.Record(ctx, entry); err != nil {
+		logger.FromContext(ctx).Warn("failed to record audit entry", "error", err, "action", entry.Action)
+	}
+}
+
+func quarantineKey(id uuid.UUID) string {
+	return "uploads/quarantine/" + id.String()
+}
+
+// releasedKey addresses clean objects by checksum. Uploads released before
+// objects were deduplicated keep their uploads/files/<id> keys.
+func releasedKey(sha256 string) string {
+	return "uploads/blobs/" + sha256
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+func toUpload(u db.Upload) *Upload {
+	upload := &Upload{
+		ID:          uuid.UUID(u.ID.Bytes),
+		Filename:    u.Filename,
+		ContentType: u.ContentType,
+		Size:        u.Size,
+		SHA256:      u.Sha256,
+		Status:      u.Status,
+		CreatedAt:   u.CreatedAt.Time,
+		UpdatedAt:   u.UpdatedAt.Time,
+	}
+	if u.ScanEngine.Valid || u.ScanError.Valid {
+		upload.Scan = &ScanResult{
+			Engine:    u.ScanEngine.String,
+			Signature: u.ScanSignature.String,
+			Error:     u.ScanError.String,
+		}
+		if u.ScannedAt.Valid {
+			upload.Scan.ScannedAt = &u.ScannedAt.Time
+		}
+	}
+	if u.MetadataExtractedAt.Valid {
+		upload.Metadata = &Metadata{
+			SniffedType: u.SniffedType.String,
+			ExtractedAt: u.MetadataExtractedAt.Time,
+		}
+		if u.Width.Valid && u.Height.Valid {
+			width, height := int(u.Width.Int32), int(u.Height.Int32)
+			upload.Metadata.Width, upload.Metadata.Height = &width, &height
+		}
+		if u.DurationMs.Valid {
+			upload.Metadata.DurationMS = &u.DurationMs.Int64
+		}
+	}
+	return upload
+}
@@ -0,0 +1,126 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// stallTimeout bounds each write of a download. Downloads may outlast the
+	// server's write timeout, but a client that stops reading is dropped.
+	stallTimeout = time.Minute
+	// sweepInterval is how often buckets of users no longer downloading are
+	// dropped
+	sweepInterval = time.Minute
+)
+
+// throttle limits the bandwidth of each user's downloads. A user's
+// concurrent downloads share one token bucket of bytes.
+type throttle struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	active  int
+}
+
+// newThrottle returns a throttle of bytesPerSecond per user, or nil when
+// downloads are not limited
+func newThrottle(bytesPerSecond, burst int) *throttle {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &throttle{
+		limit:   rate.Limit(bytesPerSecond),
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// writer paces w to user's bucket; call release once the download ends
+func (t *throttle) writer(ctx context.Context, w http.ResponseWriter, user string) (*downloadWriter, func()) {
+	dw := &downloadWriter{ResponseWriter: w, ctx: ctx, rc: http.NewResponseController(w)}
+	if t == nil {
+		return dw, func() {}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sweep()
+	b, ok := t.buckets[user]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(t.limit, t.burst)}
+		t.buckets[user] = b
+	}
+	b.active++
+	dw.limiter = b.limiter
+
+	return dw, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		b.active--
+	}
+}
+
+// sweep drops the buckets of users without downloads that have refilled,
+// as a new bucket would start out the same. t.mu must be held.
+func (t *throttle) sweep() {
+	now := time.Now()
+	if now.Sub(t.lastSweep) < sweepInterval {
+		return
+	}
+	t.lastSweep = now
+	for user, b := range t.buckets {
+		if b.active == 0 && b.limiter.TokensAt(now) >= float64(t.burst) {
+			delete(t.buckets, user)
+		}
+	}
+}
+
+// downloadWriter writes a download in pieces no larger than the limiter's
+// burst, waiting for each, and renews the write deadline before every piece
+type downloadWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	rc      *http.ResponseController
+	limiter *rate.Limiter
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := len(p)
+		if w.limiter != nil {
+			n = min(n, w.limiter.Burst())
+			if err := w.limiter.WaitN(w.ctx, n); err != nil {
+				return written, err
+			}
+		}
+		if err := w.rc.SetWriteDeadline(time.Now().Add(stallTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return written, err
+		}
+		m, err := w.ResponseWriter.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *downloadWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,202 @@
+package uploads
+
+import (
+	"encoding/base64"
+	"errors"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"starterkit/internal/platform/apierror"
+)
+
+// Version and extensions of the tus resumable upload protocol served. See
+// https://tus.io/protocols/resumable-upload.
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration,termination"
+)
+
+// offsetContentType is the media type of chunk bodies
+const offsetContentType = "application/offset+octet-stream"
+
+var errInvalidMetadata = errors.New("invalid Upload-Metadata header")
+
+// HandleTusOptions describes the protocol support to tus clients
+func (h *Handler) HandleTusOptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		w.Header().Set("Tus-Version", tusVersion)
+		w.Header().Set("Tus-Extension", tusExtensions)
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(h.service.ResumableMaxSize(), 10))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleTusCreate starts a resumable upload of Upload-Length bytes. The
+// filename and type are read from the filename (or name) and filetype (or
+// type) keys of Upload-Metadata. Send the file with PATCH requests to the
+// returned Location.
+func (h *Handler) HandleTusCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.checkTusVersion(w, r) {
+			return
+		}
+		if r.Header.Get("Upload-Defer-Length") != "" {
+			apierror.Write(w, r, apierror.BadRequest("deferred_length_unsupported", "Upload-Length is required; deferred lengths are not supported"))
+			return
+		}
+		length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		if err != nil || length < 0 {
+			apierror.Write(w, r, apierror.BadRequest("invalid_upload_length", "Upload-Length must be a non-negative integer"))
+			return
+		}
+		meta, err := parseTusMetadata(r.Header.Get("Upload-Metadata"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_upload_metadata", err.Error()))
+			return
+		}
+
+		filename := filepath.Base(firstNonEmpty(meta["filename"], meta["name"], "upload"))
+		contentType := firstNonEmpty(meta["filetype"], meta["type"])
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			contentType = "application/octet-stream"
+		}
+
+		resumable, err := h.service.CreateResumable(r.Context(), actorFromRequest(r), filename, contentType, length)
+		if err != nil {
+			h.respondWithUploadError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Location", "/api/v1/resumable-uploads/"+resumable.ID.String())
+		setTusHeaders(w, resumable)
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// HandleTusHead reports the offset to resume a resumable upload from
+func (h *Handler) HandleTusHead() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.checkTusVersion(w, r) {
+			return
+		}
+		uploadID, ok := h.parseUploadID(w, r)
+		if !ok {
+			return
+		}
+
+		resumable, err := h.service.GetResumable(r.Context(), uploadID, actorFromRequest(r))
+		if err != nil {
+			h.respondWithUploadError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		setTusHeaders(w, resumable)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// HandleTusPatch appends the chunk in the body at Upload-Offset. When the
+// response's Upload-Offset equals Upload-Length the file is complete and
+// is scanned as GET /api/v1/uploads/{id} shows.
+func (h *Handler) HandleTusPatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.checkTusVersion(w, r) {
+			return
+		}
+		uploadID, ok := h.parseUploadID(w, r)
+		if !ok {
+			return
+		}
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != offsetContentType {
+			apierror.Write(w, r, apierror.New(http.StatusUnsupportedMediaType, "unsupported_media_type", "Content-Type must be "+offsetContentType))
+			return
+		}
+		offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+		if err != nil || offset < 0 {
+			apierror.Write(w, r, apierror.BadRequest("invalid_upload_offset", "Upload-Offset must be a non-negative integer"))
+			return
+		}
+
+		resumable, err := h.service.AppendChunk(r.Context(), uploadID, actorFromRequest(r), offset, r.Body)
+		if err != nil {
+			h.respondWithUploadError(w, r, err)
+			return
+		}
+
+		setTusHeaders(w, resumable)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleTusDelete cancels an incomplete resumable upload
+func (h *Handler) HandleTusDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.checkTusVersion(w, r) {
+			return
+		}
+		uploadID, ok := h.parseUploadID(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.service.CancelResumable(r.Context(), uploadID, actorFromRequest(r)); err != nil {
+			h.respondWithUploadError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// checkTusVersion rejects requests for a protocol version not served
+func (h *Handler) checkTusVersion(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		apierror.Write(w, r, apierror.New(http.StatusPreconditionFailed, "unsupported_tus_version", "Tus-Resumable must be "+tusVersion))
+		return false
+	}
+	return true
+}
+
+func setTusHeaders(w http.ResponseWriter, resumable *Resumable) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(resumable.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(resumable.Length, 10))
+	if !resumable.ExpiresAt.IsZero() {
+		w.Header().Set("Upload-Expires", resumable.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// parseTusMetadata decodes Upload-Metadata: comma-separated pairs of a key
+// and, optionally, its base64-encoded value
+func parseTusMetadata(header string) (map[string]string, error) {
+	meta := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return meta, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errInvalidMetadata
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errInvalidMetadata
+		}
+		meta[key] = string(value)
+	}
+	return meta, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
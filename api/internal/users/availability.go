@@ -0,0 +1,113 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Reasons an email is unavailable
+const (
+	EmailInvalid     = "invalid"
+	EmailTaken       = "taken"
+	EmailDeactivated = "deactivated"
+	EmailPending     = "pending"
+)
+
+// AvailabilityRequest names the email, handle, or both to check
+type AvailabilityRequest struct {
+	Email  string `json:"email,omitempty" doc:"Email to check; lowercased" format:"email"`
+	Handle string `json:"handle,omitempty" doc:"Handle to check; a leading @ is ignored and letters are lowercased" example:"jane_doe"`
+}
+
+// Validate normalizes both values and requires one. Malformed values are
+// reported in the response rather than refused.
+func (r *AvailabilityRequest) Validate() error {
+	r.Email = strings.ToLower(strings.TrimSpace(r.Email))
+	r.Handle = NormalizeHandle(r.Handle)
+
+	var v request.Validation
+	v.Check(r.Email != "" || r.Handle != "", "email", "email or handle is required")
+	return v.Err()
+}
+
+// AcceptableValue says whether a value could be taken by someone, but not
+// whether anyone has: a well-formed value that is in use is reported like
+// one that is free
+type AcceptableValue struct {
+	Value      string `json:"value" doc:"Value as it would be stored"`
+	Acceptable bool   `json:"acceptable" doc:"Whether the value is well-formed and not reserved. It may still be in use."`
+	Reason     string `json:"reason,omitempty" doc:"Why the value is not acceptable" enum:"invalid,reserved"`
+}
+
+// AvailabilityCheck answers a check made without credentials
+type AvailabilityCheck struct {
+	Email  *AcceptableValue `json:"email,omitempty"`
+	Handle *AcceptableValue `json:"handle,omitempty"`
+}
+
+// EmailAvailability reports whether an email can be given to a user
+type EmailAvailability struct {
+	Email     string     `json:"email" format:"email"`
+	Available bool       `json:"available" doc:"Whether the email can be taken"`
+	Reason    string     `json:"reason,omitempty" doc:"Why the email cannot be taken: taken by an active user, held by a deactivated one until it is purged, or pending in another user's email change" enum:"invalid,taken,deactivated,pending"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" doc:"User holding the email"`
+}
+
+// AvailabilityReport answers a check made by an admin
+type AvailabilityReport struct {
+	Email  *EmailAvailability  `json:"email,omitempty"`
+	Handle *HandleAvailability `json:"handle,omitempty"`
+}
+
+// AcceptableEmail reports whether email is well-formed. It never looks the
+// email up, so neither its answer nor how long it takes depends on whether
+// the email is in use.
+func AcceptableEmail(email string) *AcceptableValue {
+	if !validEmail(email) {
+		return &AcceptableValue{Value: email, Reason: EmailInvalid}
+	}
+	return &AcceptableValue{Value: email, Acceptable: true}
+}
+
+// EmailAvailability reports whether an email can be given to a user, and
+// who holds it if not
+func (s *Service) EmailAvailability(ctx context.Context, email string) (*EmailAvailability, error) {
+	result := &EmailAvailability{Email: email}
+	if !validEmail(email) {
+		result.Reason = EmailInvalid
+		return result, nil
+	}
+	owner, err := s.queries.FindEmailOwner(ctx, email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			result.Available = true
+			return result, nil
+		}
+		return nil, fmt.Errorf("failed to find email owner: %w", err)
+	}
+
+	id := uuid.UUID(owner.ID.Bytes)
+	result.UserID = &id
+	switch {
+	case !owner.Current:
+		result.Reason = EmailPending
+	case owner.Deactivated:
+		result.Reason = EmailDeactivated
+	default:
+		result.Reason = EmailTaken
+	}
+	return result, nil
+}
+
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email && len(email) <= maxEmailLength
+}
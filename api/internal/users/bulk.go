@@ -0,0 +1,495 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/jobs"
+	"starterkit/internal/platform/dryrun"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/request"
+	"starterkit/internal/workflow"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// JobBulk is the job kind that works through one batch of a bulk operation
+const JobBulk = "users.bulk"
+
+// Bulk actions
+const (
+	BulkDeactivate = "deactivate"
+	BulkAddRole    = "add-role"
+	BulkDelete     = "delete"
+)
+
+// Statuses of bulk operations and their items
+const (
+	BulkRunning   = "running"
+	BulkCompleted = "completed"
+	BulkPending   = "pending"
+	BulkSucceeded = "succeeded"
+	BulkSkipped   = "skipped"
+	BulkFailed    = "failed"
+)
+
+var (
+	ErrBulkNotFound  = errors.New("bulk operation not found")
+	ErrBulkNoTargets = errors.New("no users match")
+	ErrBulkTooMany   = errors.New("too many users for one bulk operation")
+)
+
+var rolePattern = regexp.MustCompile(`^[a-z][a-z0-9_.:-]{0,99}$`)
+
+// BulkFilter selects active users by what they have in common. Every
+// criterion given must match.
+type BulkFilter struct {
+	EmailDomain   string     `json:"email_domain,omitempty" doc:"Domain of the users' emails" example:"example.com"`
+	Query         string     `json:"query,omitempty" doc:"Text the users' email or name contains"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty" doc:"Users created at or after this time"`
+	CreatedBefore *time.Time `json:"created_before,omitempty" doc:"Users created before this time"`
+}
+
+func (f *BulkFilter) empty() bool {
+	return f.EmailDomain == "" && f.Query == "" && f.CreatedAfter == nil && f.CreatedBefore == nil
+}
+
+// BulkRequest applies an action to the users listed in IDs or matching
+// Filter
+type BulkRequest struct {
+	Action string      `json:"action" enum:"deactivate,add-role,delete"`
+	Role   string      `json:"role,omitempty" doc:"Role to grant; required for add-role"`
+	IDs    []uuid.UUID `json:"ids,omitempty" doc:"Users to act on, in order"`
+	Filter *BulkFilter `json:"filter,omitempty" doc:"Active users to act on, oldest first; used when ids is empty"`
+}
+
+// Validate requires either IDs or a filter with at least one criterion,
+// so an empty filter cannot select every user by mistake
+func (r *BulkRequest) Validate() error {
+	var v request.Validation
+	v.Check(r.Action == BulkDeactivate || r.Action == BulkAddRole || r.Action == BulkDelete, "action", "must be deactivate, add-role, or delete")
+	if r.Action == BulkAddRole {
+		v.Check(rolePattern.MatchString(r.Role), "role", "must be a lowercase role name")
+	} else {
+		v.Check(r.Role == "", "role", "is only taken by add-role")
+	}
+	v.Check((len(r.IDs) > 0) != (r.Filter != nil), "ids", "give either ids or a filter")
+	if r.Filter != nil {
+		v.Check(!r.Filter.empty(), "filter", "must have at least one criterion")
+	}
+	return v.Err()
+}
+
+// BulkOperation is an admin's action on many users, worked through in the
+// background
+type BulkOperation struct {
+	ID         uuid.UUID   `json:"id"`
+	Action     string      `json:"action" enum:"deactivate,add-role,delete"`
+	Role       string      `json:"role,omitempty"`
+	Status     string      `json:"status" enum:"running,completed"`
+	Total      int         `json:"total" doc:"Users the operation acts on"`
+	Processed  int         `json:"processed" doc:"Users acted on so far"`
+	Succeeded  int         `json:"succeeded"`
+	Skipped    int         `json:"skipped" doc:"Users the action did not change, such as those already deactivated"`
+	Failed     int         `json:"failed"`
+	CreatedBy  string      `json:"created_by"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	Targets    []uuid.UUID `json:"targets,omitempty" doc:"Users a dry run would act on, in order"`
+}
+
+// BulkItem is the outcome of a bulk operation for one user
+type BulkItem struct {
+	Position int       `json:"position" doc:"Order of the user in the operation, from 1"`
+	UserID   uuid.UUID `json:"user_id"`
+	Status   string    `json:"status" enum:"pending,succeeded,skipped,failed"`
+	Error    string    `json:"error,omitempty" doc:"Why the user was skipped or failed"`
+}
+
+// BulkQuerier is the data access of bulk operations
+type BulkQuerier interface {
+	ListBulkTargets(ctx context.Context, arg db.ListBulkTargetsParams) ([]pgtype.UUID, error)
+	GetBulkOperation(ctx context.Context, id pgtype.UUID) (db.BulkOperation, error)
+	ListBulkOperations(ctx context.Context, limit int32) ([]db.BulkOperation, error)
+	ListPendingBulkItems(ctx context.Context, arg db.ListPendingBulkItemsParams) ([]db.BulkOperationItem, error)
+	FinishBulkItem(ctx context.Context, arg db.FinishBulkItemParams) error
+	RefreshBulkOperation(ctx context.Context, id pgtype.UUID) (db.BulkOperation, error)
+	ListBulkItems(ctx context.Context, arg db.ListBulkItemsParams) ([]db.BulkOperationItem, error)
+	IsUserDeactivated(ctx context.Context, id pgtype.UUID) (bool, error)
+}
+
+// Enqueuer queues background jobs
+type Enqueuer interface {
+	Schedule(ctx context.Context, kind string, payload any, opts jobs.Options) (*jobs.Job, error)
+}
+
+// bulkJob is the payload of a JobBulk job
+type bulkJob struct {
+	OperationID uuid.UUID `json:"operation_id"`
+}
+
+// BulkService runs admin actions on many users. The users are resolved
+// when an operation is submitted, then acted on a batch per job; each job
+// enqueues the next until none are pending. Every user's outcome is kept,
+// so a retried batch skips the users already done.
+type BulkService struct {
+	queries    BulkQuerier
+	tx         Transactor
+	jobs       Enqueuer
+	workflows  WorkflowStarter
+	batchSize  int
+	maxTargets int
+}
+
+// NewBulkService acts on batchSize users per job and at most maxTargets
+// users per operation
+func NewBulkService(queries BulkQuerier, tx Transactor, queue Enqueuer, workflows WorkflowStarter, batchSize, maxTargets int) *BulkService {
+	return &BulkService{
+		queries:    queries,
+		tx:         tx,
+		jobs:       queue,
+		workflows:  workflows,
+		batchSize:  max(batchSize, 1),
+		maxTargets: maxTargets,
+	}
+}
+
+// Start records an operation for the users req selects and queues its
+// first batch. It is audited as user.bulk_started. A dry run lists the
+// users the operation would act on instead. The request must have been
+// validated.
+func (s *BulkService) Start(ctx context.Context, req BulkRequest, actor string) (*BulkOperation, error) {
+	targets, err := s.targets(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var row db.BulkOperation
+	err = s.tx.WithTx(ctx, func(q *db.Queries) error {
+		var err error
+		row, err = q.CreateBulkOperation(ctx, db.CreateBulkOperationParams{
+			Action:    req.Action,
+			Role:      pgtype.Text{String: req.Role, Valid: req.Role != ""},
+			Total:     int32(len(targets)),
+			CreatedBy: actor,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.AddBulkOperationItems(ctx, db.AddBulkOperationItemsParams{OperationID: row.ID, UserIds: targets}); err != nil {
+			return err
+		}
+		metadata := map[string]any{"action": req.Action, "total": len(targets)}
+		if req.Role != "" {
+			metadata["role"] = req.Role
+		}
+		return audit.NewService(q).Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "user.bulk_started",
+			ResourceType: "bulk_operation",
+			ResourceID:   uuid.UUID(row.ID.Bytes).String(),
+			Metadata:     metadata,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bulk operation: %w", err)
+	}
+
+	op := toBulkOperation(row)
+	if dryrun.Enabled(ctx) {
+		// Nothing was saved, so there is no operation to refer to
+		op.ID = uuid.Nil
+		op.Targets = make([]uuid.UUID, len(targets))
+		for i, id := range targets {
+			op.Targets[i] = uuid.UUID(id.Bytes)
+		}
+		return op, nil
+	}
+	if err := s.enqueue(ctx, op.ID, 1); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// targets resolves the users req selects, in order and without repeats
+func (s *BulkService) targets(ctx context.Context, req BulkRequest) ([]pgtype.UUID, error) {
+	var targets []pgtype.UUID
+	if len(req.IDs) > 0 {
+		seen := make(map[uuid.UUID]bool, len(req.IDs))
+		for _, id := range req.IDs {
+			if !seen[id] {
+				seen[id] = true
+				targets = append(targets, pgtype.UUID{Bytes: id, Valid: true})
+			}
+		}
+	} else {
+		f := req.Filter
+		var err error
+		targets, err = s.queries.ListBulkTargets(ctx, db.ListBulkTargetsParams{
+			EmailDomain:   pgtype.Text{String: f.EmailDomain, Valid: f.EmailDomain != ""},
+			Query:         pgtype.Text{String: f.Query, Valid: f.Query != ""},
+			CreatedAfter:  optionalTime(f.CreatedAfter),
+			CreatedBefore: optionalTime(f.CreatedBefore),
+			RowLimit:      int32(s.maxTargets + 1),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list bulk targets: %w", err)
+		}
+	}
+	switch {
+	case len(targets) == 0:
+		return nil, ErrBulkNoTargets
+	case len(targets) > s.maxTargets:
+		return nil, fmt.Errorf("%w: the limit is %d", ErrBulkTooMany, s.maxTargets)
+	}
+	return targets, nil
+}
+
+// enqueue queues the batch of operation id starting at position. Keying
+// the job by its first position keeps a retried batch from queueing the
+// next one twice.
+func (s *BulkService) enqueue(ctx context.Context, id uuid.UUID, position int32) error {
+	_, err := s.jobs.Schedule(ctx, JobBulk, bulkJob{OperationID: id}, jobs.Options{UniqueKey: fmt.Sprintf("%s:%d", id, position)})
+	if err != nil && !errors.Is(err, jobs.ErrDuplicateJob) {
+		return fmt.Errorf("failed to queue bulk operation: %w", err)
+	}
+	return nil
+}
+
+// RunBatchJob acts on the next batch of pending users of an operation and
+// queues the batch after it. An unexpected error fails the job so the
+// batch is retried; on the last attempt the user is marked failed instead,
+// so one user cannot stall the operation.
+func (s *BulkService) RunBatchJob(ctx context.Context, payload json.RawMessage) error {
+	var job bulkJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("failed to decode bulk job: %w", err)
+	}
+	opID := pgtype.UUID{Bytes: job.OperationID, Valid: true}
+	row, err := s.queries.GetBulkOperation(ctx, opID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to get bulk operation: %w", err)
+	}
+	items, err := s.queries.ListPendingBulkItems(ctx, db.ListPendingBulkItemsParams{OperationID: opID, RowLimit: int32(s.batchSize)})
+	if err != nil {
+		return fmt.Errorf("failed to list bulk items: %w", err)
+	}
+
+	log := logger.FromContext(ctx).With("bulk_operation_id", job.OperationID, "action", row.Action)
+	for _, item := range items {
+		status, reason, err := s.apply(ctx, row, item)
+		if err != nil {
+			if !jobs.LastAttempt(ctx) {
+				return fmt.Errorf("failed to %s user %s: %w", row.Action, uuid.UUID(item.UserID.Bytes), err)
+			}
+			log.Error("bulk action failed", "user_id", uuid.UUID(item.UserID.Bytes), "error", err)
+			status, reason = BulkFailed, "internal error"
+		}
+		if err := s.queries.FinishBulkItem(ctx, db.FinishBulkItemParams{
+			OperationID: opID,
+			Position:    item.Position,
+			Status:      status,
+			Error:       pgtype.Text{String: reason, Valid: reason != ""},
+		}); err != nil {
+			return fmt.Errorf("failed to record bulk item: %w", err)
+		}
+	}
+
+	row, err = s.queries.RefreshBulkOperation(ctx, opID)
+	if err != nil {
+		return fmt.Errorf("failed to update bulk operation: %w", err)
+	}
+	if row.Status == BulkCompleted {
+		log.Info("bulk operation completed", "succeeded", row.Succeeded, "skipped", row.Skipped, "failed", row.Failed)
+		return nil
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return s.enqueue(ctx, job.OperationID, items[len(items)-1].Position+1)
+}
+
+// apply acts on the user of item and returns its outcome. Errors are
+// unexpected; a user that is missing or already in the wanted state is an
+// outcome.
+func (s *BulkService) apply(ctx context.Context, op db.BulkOperation, item db.BulkOperationItem) (string, string, error) {
+	metadata := map[string]any{"bulk_operation_id": uuid.UUID(op.ID.Bytes).String()}
+	userID := uuid.UUID(item.UserID.Bytes).String()
+
+	switch op.Action {
+	case BulkDeactivate:
+		var rows int64
+		err := s.tx.WithTx(ctx, func(q *db.Queries) error {
+			var err error
+			rows, err = q.DeactivateUser(ctx, item.UserID)
+			if err != nil || rows == 0 {
+				return err
+			}
+			return audit.NewService(q).Record(ctx, audit.Entry{
+				Actor:        op.CreatedBy,
+				Action:       "user.deactivated",
+				ResourceType: ResourceType,
+				ResourceID:   userID,
+				Metadata:     metadata,
+			})
+		})
+		if err != nil {
+			return "", "", err
+		}
+		if rows == 0 {
+			return s.unchanged(ctx, item.UserID, "already deactivated")
+		}
+		return BulkSucceeded, "", nil
+
+	case BulkAddRole:
+		var rows int64
+		err := s.tx.WithTx(ctx, func(q *db.Queries) error {
+			var err error
+			rows, err = q.GrantUserRole(ctx, db.GrantUserRoleParams{UserID: item.UserID, Role: op.Role.String, GrantedBy: op.CreatedBy})
+			if err != nil || rows == 0 {
+				return err
+			}
+			metadata["role"] = op.Role.String
+			return audit.NewService(q).Record(ctx, audit.Entry{
+				Actor:        op.CreatedBy,
+				Action:       "user.role_granted",
+				ResourceType: ResourceType,
+				ResourceID:   userID,
+				Metadata:     metadata,
+			})
+		})
+		if isForeignKeyViolation(err) {
+			return BulkFailed, ErrUserNotFound.Error(), nil
+		}
+		if err != nil {
+			return "", "", err
+		}
+		if rows == 0 {
+			return BulkSkipped, "already has the role", nil
+		}
+		return BulkSucceeded, "", nil
+
+	case BulkDelete:
+		deactivated, err := s.queries.IsUserDeactivated(ctx, item.UserID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return BulkFailed, ErrUserNotFound.Error(), nil
+		}
+		if err != nil {
+			return "", "", err
+		}
+		if deactivated {
+			return BulkSkipped, "already deactivated or being deleted", nil
+		}
+		if _, err := s.workflows.Start(ctx, DeletionWorkflow, workflow.Data{"user_id": userID}, op.CreatedBy); err != nil {
+			return "", "", err
+		}
+		return BulkSucceeded, "", nil
+	}
+	return BulkFailed, "unknown action " + op.Action, nil
+}
+
+// unchanged returns the outcome for a user the action did not change:
+// skipped with reason if the user exists, failed if not
+func (s *BulkService) unchanged(ctx context.Context, id pgtype.UUID, reason string) (string, string, error) {
+	if _, err := s.queries.IsUserDeactivated(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return BulkFailed, ErrUserNotFound.Error(), nil
+		}
+		return "", "", err
+	}
+	return BulkSkipped, reason, nil
+}
+
+// Get returns an operation with its progress
+func (s *BulkService) Get(ctx context.Context, id uuid.UUID) (*BulkOperation, error) {
+	row, err := s.queries.GetBulkOperation(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBulkNotFound
+		}
+		return nil, fmt.Errorf("failed to get bulk operation: %w", err)
+	}
+	return toBulkOperation(row), nil
+}
+
+// List returns the latest operations, newest first
+func (s *BulkService) List(ctx context.Context, limit int) ([]*BulkOperation, error) {
+	rows, err := s.queries.ListBulkOperations(ctx, int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bulk operations: %w", err)
+	}
+	ops := make([]*BulkOperation, len(rows))
+	for i, row := range rows {
+		ops[i] = toBulkOperation(row)
+	}
+	return ops, nil
+}
+
+// Items returns up to limit outcomes of an operation after position, in
+// order. Users not yet acted on are pending.
+func (s *BulkService) Items(ctx context.Context, id uuid.UUID, after, limit int) ([]BulkItem, error) {
+	rows, err := s.queries.ListBulkItems(ctx, db.ListBulkItemsParams{
+		OperationID: pgtype.UUID{Bytes: id, Valid: true},
+		After:       int32(after),
+		RowLimit:    int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bulk items: %w", err)
+	}
+	items := make([]BulkItem, len(rows))
+	for i, row := range rows {
+		items[i] = BulkItem{
+			Position: int(row.Position),
+			UserID:   uuid.UUID(row.UserID.Bytes),
+			Status:   row.Status,
+			Error:    row.Error.String,
+		}
+	}
+	return items, nil
+}
+
+func toBulkOperation(row db.BulkOperation) *BulkOperation {
+	op := &BulkOperation{
+		ID:        uuid.UUID(row.ID.Bytes),
+		Action:    row.Action,
+		Role:      row.Role.String,
+		Status:    row.Status,
+		Total:     int(row.Total),
+		Processed: int(row.Succeeded + row.Skipped + row.Failed),
+		Succeeded: int(row.Succeeded),
+		Skipped:   int(row.Skipped),
+		Failed:    int(row.Failed),
+		CreatedBy: row.CreatedBy,
+		CreatedAt: row.CreatedAt.Time,
+		UpdatedAt: row.UpdatedAt.Time,
+	}
+	if row.FinishedAt.Valid {
+		op.FinishedAt = &row.FinishedAt.Time
+	}
+	return op
+}
+
+func optionalTime(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
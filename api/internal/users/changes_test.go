@@ -0,0 +1,145 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"starterkit/internal/config"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/fieldaccess"
+	"starterkit/internal/platform/ids"
+)
+
+// changeQuerier serves a fixed change log, in position order
+type changeQuerier struct {
+	Querier
+	changes []db.UserChange
+}
+
+func (q *changeQuerier) ListUserChangesSince(_ context.Context, arg db.ListUserChangesSinceParams) ([]db.UserChange, error) {
+	var out []db.UserChange
+	for _, c := range q.changes {
+		after := c.Txid > arg.SinceTxid || (c.Txid == arg.SinceTxid && c.ID > arg.SinceID)
+		if after && len(out) < int(arg.RowLimit) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func capturedChange(t *testing.T, txid, id int64, row map[string]any) db.UserChange {
+	t.Helper()
+	data, err := json.Marshal(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID := uuid.MustParse(row["id"].(string))
+	return db.UserChange{
+		ID:        id,
+		Txid:      txid,
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Operation: "INSERT",
+		NewData:   data,
+		ChangedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+}
+
+func TestListChangesMasksRestrictedFieldsForNonAdmins(t *testing.T) {
+	queries := &changeQuerier{changes: []db.UserChange{
+		capturedChange(t, 740, 2, map[string]any{
+			"id":                "0190c6b2-7d4e-7a21-9f3b-2c8d1e5a4b60",
+			"email":             "alice@example.com",
+			"name":              "Alice",
+			"pending_email":     "alice.new@example.com",
+			"phone":             "+14155552671",
+			"phone_verified_at": "2024-01-01T00:00:00.123456+00:00",
+			"created_at":        "2024-01-01T00:00:00.123456+00:00",
+			"updated_at":        "2024-01-01T00:00:00.123456+00:00",
+			"shadow_banned_at":  nil,
+		}),
+		capturedChange(t, 741, 1, map[string]any{
+			"id":                "0190c6b2-7d4e-7a21-9f3b-2c8d1e5a4b61",
+			"email":             "mallory@example.com",
+			"name":              "Mallory",
+			"created_at":        "2024-01-01T00:00:00+00:00",
+			"updated_at":        "2024-01-01T00:00:00+00:00",
+			"shadow_banned_at":  "2024-01-02T00:00:00+00:00",
+			"shadow_ban_reason": "spam",
+		}),
+	}}
+	service := NewService(queries, nil, nil, nil, nil, EmailChangeOptions{}, ids.Generator{})
+	handler := NewHandler(service, nil, nil, nil, nil, nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	filter := fieldaccess.New(config.AuthConfig{AdminRole: "admin"}, true)
+	server := fieldaccess.Middleware(filter)(handler.HandleListChanges())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/changes", nil)
+	req.Header.Set("X-User-Email", "bob@example.com")
+	req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{
+		Email:  "bob@example.com",
+		Scopes: []string{"users:read"},
+	}))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Changes []struct {
+			New map[string]any `json:"new"`
+		} `json:"changes"`
+		NextCursor string `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(body.Changes) != 1 {
+		t.Fatalf("got %d changes, want the shadow banned user's change dropped: %s", len(body.Changes), rec.Body)
+	}
+	if body.NextCursor != "741-1" {
+		t.Errorf("next_cursor = %q, want it past the dropped change", body.NextCursor)
+	}
+	user := body.Changes[0].New
+	for field, want := range map[string]string{
+		"email":         "a***@example.com",
+		"pending_email": "a***@example.com",
+		"phone":         "********2671",
+	} {
+		if user[field] != want {
+			t.Errorf("%s = %v, want %q", field, user[field], want)
+		}
+	}
+	if user["phone_verified"] != true {
+		t.Errorf("phone_verified = %v, want true", user["phone_verified"])
+	}
+	for _, field := range []string{"shadow_banned_at", "shadow_ban_reason", "phone_verified_at"} {
+		if _, ok := user[field]; ok {
+			t.Errorf("%s is exposed", field)
+		}
+	}
+}
+
+func TestChangePositionRoundTrips(t *testing.T) {
+	want := ChangePosition{Txid: 9071, ID: 42}
+	got, err := ParseChangePosition(want.String())
+	if err != nil || got != want {
+		t.Fatalf("ParseChangePosition(%q) = %v, %v, want %v", want.String(), got, err, want)
+	}
+	// Cursors from before positions held transactions are change IDs alone
+	for _, cursor := range []string{"42", "-1-2", "1-", "a-b"} {
+		if _, err := ParseChangePosition(cursor); err != ErrInvalidCursor {
+			t.Errorf("ParseChangePosition(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/workflow"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DeletionWorkflow is the workflow kind that deletes an account
+const DeletionWorkflow = "account-deletion"
+
+type DeletionQuerier interface {
+	GetUserByID(ctx context.Context, id pgtype.UUID) (db.GetUserByIDRow, error)
+	DeactivateUser(ctx context.Context, id pgtype.UUID) (int64, error)
+	ReactivateUser(ctx context.Context, id pgtype.UUID) (int64, error)
+	DeleteUserPushSubscriptions(ctx context.Context, userID pgtype.UUID) error
+	DeletePhoneVerification(ctx context.Context, userID pgtype.UUID) error
+	DeleteUserSessions(ctx context.Context, id pgtype.UUID) error
+	DeleteUserEmailMessages(ctx context.Context, userID pgtype.UUID) error
+	DeleteDeactivatedUser(ctx context.Context, id pgtype.UUID) (int64, error)
+}
+
+type DeletionAuditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// NewDeletionWorkflow deletes the account in data["user_id"]: it is hidden
+// and cut off from notifications at once, then purged after grace. A
+// failure before the grace period reactivates the account rather than
+// leaving it half deleted; after it, purging is retried. Reactivating the
+// account during the grace period cancels the purge. The purge is audited
+// as user.erased.
+func NewDeletionWorkflow(queries DeletionQuerier, auditor DeletionAuditor, grace time.Duration) workflow.Definition {
+	steps := []workflow.Step{
+		{
+			Name: "deactivate",
+			Run: func(ctx context.Context, data workflow.Data) error {
+				id, err := deletionUserID(data)
+				if err != nil {
+					return err
+				}
+				_, err = queries.DeactivateUser(ctx, id)
+				return err
+			},
+			Compensate: func(ctx context.Context, data workflow.Data) error {
+				id, err := deletionUserID(data)
+				if err != nil {
+					return err
+				}
+				_, err = queries.ReactivateUser(ctx, id)
+				return err
+			},
+		},
+		{
+			Name: "revoke-channels",
+			Run: func(ctx context.Context, data workflow.Data) error {
+				id, err := deletionUserID(data)
+				if err != nil {
+					return err
+				}
+				if err := queries.DeleteUserPushSubscriptions(ctx, id); err != nil {
+					return fmt.Errorf("failed to delete push subscriptions: %w", err)
+				}
+				if err := queries.DeletePhoneVerification(ctx, id); err != nil {
+					return fmt.Errorf("failed to delete phone verification: %w", err)
+				}
+				if err := queries.DeleteUserSessions(ctx, id); err != nil {
+					return fmt.Errorf("failed to delete sessions: %w", err)
+				}
+				if err := queries.DeleteUserEmailMessages(ctx, id); err != nil {
+					return fmt.Errorf("failed to delete email messages: %w", err)
+				}
+				return nil
+			},
+		},
+	}
+	if grace > 0 {
+		steps = append(steps, workflow.Step{Name: "grace-period", Delay: grace, Pivot: true})
+	}
+	steps = append(steps, workflow.Step{
+		Name: "purge",
+		Run: func(ctx context.Context, data workflow.Data) error {
+			id, err := deletionUserID(data)
+			if err != nil {
+				return err
+			}
+			deleted, err := queries.DeleteDeactivatedUser(ctx, id)
+			if err != nil || deleted == 0 {
+				return err
+			}
+			if err := auditor.Record(ctx, audit.Entry{
+				Actor:        audit.SystemActor,
+				Action:       "user.erased",
+				ResourceType: "user",
+				ResourceID:   data["user_id"],
+			}); err != nil {
+				logger.FromContext(ctx).Warn("failed to record audit entry", "error", err, "action", "user.erased")
+			}
+			return nil
+		},
+	})
+
+	return workflow.Definition{
+		Kind: DeletionWorkflow,
+		Validate: func(ctx context.Context, data workflow.Data) error {
+			id, err := deletionUserID(data)
+			if err != nil {
+				return err
+			}
+			if _, err := queries.GetUserByID(ctx, id); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return ErrUserNotFound
+				}
+				return err
+			}
+			return nil
+		},
+		Steps: steps,
+	}
+}
+
+func deletionUserID(data workflow.Data) (pgtype.UUID, error) {
+	id, err := uuid.Parse(data["user_id"])
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("user_id must be a UUID: %w", err)
+	}
+	return pgtype.UUID{Bytes: id, Valid: true}, nil
+}
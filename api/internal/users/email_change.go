@@ -0,0 +1,302 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/email"
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrEmailUnchanged         = errors.New("email is already the user's email")
+	ErrNoEmailChange          = errors.New("no email change is pending")
+	ErrInvalidEmailChangeLink = errors.New("email change link is invalid or expired")
+)
+
+// Mailer queues outbound email, which is sent by a background job
+type Mailer interface {
+	Queue(ctx context.Context, msg email.Email) (*email.Message, error)
+}
+
+// EmailChangeOptions configures email changes
+type EmailChangeOptions struct {
+	// TTL is how long both addresses have to confirm a change
+	TTL time.Duration
+	// ConfirmURL is the app page the confirmation links open
+	ConfirmURL string
+}
+
+// EmailChangeRequest starts changing a user's email
+type EmailChangeRequest struct {
+	Email string `json:"email" doc:"New email address, stored lowercased" format:"email"`
+}
+
+// Validate trims and lowercases the email and checks it
+func (r *EmailChangeRequest) Validate() error {
+	r.Email = strings.ToLower(strings.TrimSpace(r.Email))
+
+	var v request.Validation
+	addr, err := mail.ParseAddress(r.Email)
+	v.Check(err == nil && addr.Address == r.Email, "email", "must be a valid email address")
+	v.Check(len(r.Email) <= maxEmailLength, "email", fmt.Sprintf("must be at most %d characters", maxEmailLength))
+	return v.Err()
+}
+
+// EmailChangeConfirmation confirms one side of an email change
+type EmailChangeConfirmation struct {
+	Token string `json:"token" doc:"Token from the link emailed to the old or the new address"`
+}
+
+func (r *EmailChangeConfirmation) Validate() error {
+	r.Token = strings.TrimSpace(r.Token)
+
+	var v request.Validation
+	v.Check(r.Token != "", "token", "is required")
+	return v.Err()
+}
+
+// EmailChange is a pending change of a user's email. The email changes
+// once both addresses have confirmed.
+type EmailChange struct {
+	NewEmail     string    `json:"new_email" doc:"Address the email changes to" format:"email" access:"self,admin" mask:"email"`
+	OldConfirmed bool      `json:"old_confirmed" doc:"Whether the current address has confirmed"`
+	NewConfirmed bool      `json:"new_confirmed" doc:"Whether the new address has confirmed"`
+	Completed    bool      `json:"completed" doc:"Whether the email has changed"`
+	ExpiresAt    time.Time `json:"expires_at" doc:"When the confirmation links stop working"`
+}
+
+// RequestEmailChange emails confirmation links to the user's current
+// address and to req.Email, which is kept as the user's pending email
+// until both are used. A new request replaces a pending one. The request
+// is audited as user.email_change_requested and must have been validated.
+func (s *Service) RequestEmailChange(ctx context.Context, id uuid.UUID, req EmailChangeRequest, actor string) (*EmailChange, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	user, err := s.queries.GetUserByID(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if req.Email == user.Email {
+		return nil, ErrEmailUnchanged
+	}
+	if _, err := s.queries.GetActiveUserIDByEmail(ctx, req.Email); err == nil {
+		return nil, ErrEmailConflict
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+
+	oldToken, newToken := rand.Text(), rand.Text()
+	var change db.EmailChange
+	err = s.tx.WithTx(ctx, func(q *db.Queries) error {
+		var err error
+		change, err = q.CreateEmailChange(ctx, db.CreateEmailChangeParams{
+			UserID:       pgID,
+			OldEmail:     user.Email,
+			NewEmail:     req.Email,
+			OldTokenHash: hashEmailChangeToken(oldToken),
+			NewTokenHash: hashEmailChangeToken(newToken),
+			ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(s.emailChange.TTL), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store email change: %w", err)
+		}
+		if _, err := q.SetUserPendingEmail(ctx, db.SetUserPendingEmailParams{
+			ID:           pgID,
+			PendingEmail: pgtype.Text{String: req.Email, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("failed to set pending email: %w", err)
+		}
+		return audit.NewService(q).Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "user.email_change_requested",
+			ResourceType: ResourceType,
+			ResourceID:   id.String(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The old address hears of the change, and has to agree to it
+	if _, err := s.mail.Queue(ctx, email.Email{
+		UserID:  &id,
+		To:      user.Email,
+		Subject: "Confirm your email change",
+		Body: fmt.Sprintf("Someone asked to change the email of your account to %s.\n\n"+
+			"If it was you, confirm the change with this link:\n\n%s\n\n"+
+			"If it wasn't, ignore this email; the email does not change without your confirmation.\n",
+			req.Email, s.emailChangeLink(oldToken)),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to queue email change confirmation: %w", err)
+	}
+	if _, err := s.mail.Queue(ctx, email.Email{
+		UserID:  &id,
+		To:      req.Email,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Confirm that this address should become the email of your account:\n\n%s\n\n"+
+			"The change also needs confirming from your current address.\n",
+			s.emailChangeLink(newToken)),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to queue email change confirmation: %w", err)
+	}
+	return toEmailChange(change), nil
+}
+
+// GetEmailChange returns the user's pending email change
+func (s *Service) GetEmailChange(ctx context.Context, id uuid.UUID) (*EmailChange, error) {
+	change, err := s.queries.GetEmailChange(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoEmailChange
+		}
+		return nil, fmt.Errorf("failed to get email change: %w", err)
+	}
+	return toEmailChange(change), nil
+}
+
+// ConfirmEmailChange confirms the side of an email change the token was
+// sent to. The confirmation that completes the change sets the new email,
+// is audited as user.email_changed, and notifies the old address.
+func (s *Service) ConfirmEmailChange(ctx context.Context, token string) (*EmailChange, error) {
+	var change db.EmailChange
+	var completed, stale bool
+	err := s.tx.WithTx(ctx, func(q *db.Queries) error {
+		var err error
+		change, err = q.ConfirmEmailChange(ctx, hashEmailChangeToken(token))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrInvalidEmailChangeLink
+			}
+			return fmt.Errorf("failed to confirm email change: %w", err)
+		}
+		if !change.OldConfirmedAt.Valid || !change.NewConfirmedAt.Valid {
+			return nil
+		}
+
+		// Matching the old email keeps a change from applying over an
+		// email set since it was requested
+		rows, err := q.ChangeUserEmail(ctx, db.ChangeUserEmailParams{
+			NewEmail: change.NewEmail,
+			ID:       change.UserID,
+			OldEmail: change.OldEmail,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.DeleteEmailChange(ctx, change.UserID); err != nil {
+			return fmt.Errorf("failed to delete email change: %w", err)
+		}
+		if rows == 0 {
+			// The change is dropped, as it can no longer apply
+			stale = true
+			return nil
+		}
+		completed = true
+		return audit.NewService(q).Record(ctx, audit.Entry{
+			Actor:        change.OldEmail,
+			Action:       "user.email_changed",
+			ResourceType: ResourceType,
+			ResourceID:   uuid.UUID(change.UserID.Bytes).String(),
+			Metadata:     map[string]any{"old_email": change.OldEmail, "new_email": change.NewEmail},
+		})
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrEmailConflict
+		}
+		return nil, err
+	}
+	if stale {
+		return nil, ErrInvalidEmailChangeLink
+	}
+
+	result := toEmailChange(change)
+	if !completed {
+		return result, nil
+	}
+	result.Completed = true
+	id := uuid.UUID(change.UserID.Bytes)
+	if _, err := s.mail.Queue(ctx, email.Email{
+		UserID:  &id,
+		To:      change.OldEmail,
+		Subject: "Your email was changed",
+		Body: fmt.Sprintf("The email of your account was changed to %s on %s.\n\n"+
+			"This address no longer receives mail for the account. If you didn't make this change, contact support.\n",
+			change.NewEmail, time.Now().UTC().Format(time.RFC1123)),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to queue email change notice: %w", err)
+	}
+	return result, nil
+}
+
+// CancelEmailChange drops the user's pending email change, audited as
+// user.email_change_cancelled. Its links stop working.
+func (s *Service) CancelEmailChange(ctx context.Context, id uuid.UUID, actor string) error {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	return s.tx.WithTx(ctx, func(q *db.Queries) error {
+		rows, err := q.DeleteEmailChange(ctx, pgID)
+		if err != nil {
+			return fmt.Errorf("failed to delete email change: %w", err)
+		}
+		if rows == 0 {
+			return ErrNoEmailChange
+		}
+		if _, err := q.SetUserPendingEmail(ctx, db.SetUserPendingEmailParams{ID: pgID}); err != nil {
+			return fmt.Errorf("failed to clear pending email: %w", err)
+		}
+		return audit.NewService(q).Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "user.email_change_cancelled",
+			ResourceType: ResourceType,
+			ResourceID:   id.String(),
+		})
+	})
+}
+
+// PruneEmailChanges drops expired email changes and the pending emails
+// they set
+func (s *Service) PruneEmailChanges(ctx context.Context) error {
+	if _, err := s.queries.PruneEmailChanges(ctx); err != nil {
+		return fmt.Errorf("failed to prune email changes: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) emailChangeLink(token string) string {
+	sep := "?"
+	if strings.Contains(s.emailChange.ConfirmURL, "?") {
+		sep = "&"
+	}
+	return s.emailChange.ConfirmURL + sep + "token=" + url.QueryEscape(token)
+}
+
+func hashEmailChangeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func toEmailChange(c db.EmailChange) *EmailChange {
+	return &EmailChange{
+		NewEmail:     c.NewEmail,
+		OldConfirmed: c.OldConfirmedAt.Valid,
+		NewConfirmed: c.NewConfirmedAt.Valid,
+		ExpiresAt:    c.ExpiresAt.Time,
+	}
+}
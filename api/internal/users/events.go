@@ -0,0 +1,284 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"starterkit/internal/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrConcurrentUpdate means another writer changed the user first, such as
+// by appending to the same stream
+var ErrConcurrentUpdate = errors.New("user was modified concurrently")
+
+// User event types
+const (
+	// EventImported seeds a stream from the users row, and resyncs it when
+	// the row was changed outside the stream (retention, state mode)
+	EventImported       = "user.imported"
+	EventProfileUpdated = "user.profile_updated"
+	EventPhoneChanged   = "user.phone_changed"
+	EventPhoneVerified  = "user.phone_verified"
+)
+
+// appendAttempts bounds retries after losing an optimistic concurrency race
+const appendAttempts = 3
+
+// userState is the user aggregate as rebuilt from its events
+type userState struct {
+	Name            string     `json:"name"`
+	Bio             string     `json:"bio"`
+	Phone           *string    `json:"phone,omitempty"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
+}
+
+// userEvent is a stored event; Data holds the fields it sets
+type userEvent struct {
+	Type string
+	Data userState
+}
+
+func (s *userState) apply(e userEvent) {
+	switch e.Type {
+	case EventImported:
+		*s = e.Data
+	case EventProfileUpdated:
+		s.Name, s.Bio = e.Data.Name, e.Data.Bio
+	case EventPhoneChanged:
+		s.Phone, s.PhoneVerifiedAt = e.Data.Phone, nil
+	case EventPhoneVerified:
+		s.PhoneVerifiedAt = e.Data.PhoneVerifiedAt
+	}
+}
+
+func stateFromRow(row db.GetUserByIDRow) userState {
+	state := userState{Name: row.Name, Bio: row.Bio, Phone: textPtr(row.Phone)}
+	if row.PhoneVerifiedAt.Valid {
+		at := row.PhoneVerifiedAt.Time
+		state.PhoneVerifiedAt = &at
+	}
+	return state
+}
+
+func (s userState) equal(o userState) bool {
+	samePhone := (s.Phone == nil) == (o.Phone == nil) && (s.Phone == nil || *s.Phone == *o.Phone)
+	sameVerified := (s.PhoneVerifiedAt == nil) == (o.PhoneVerifiedAt == nil) &&
+		(s.PhoneVerifiedAt == nil || s.PhoneVerifiedAt.Equal(*o.PhoneVerifiedAt))
+	return s.Name == o.Name && s.Bio == o.Bio && samePhone && sameVerified
+}
+
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// EventSourcedQueries stores user writes as an append-only event stream
+// per user instead of updating the row in place. Each write loads the
+// aggregate from its latest snapshot plus later events, appends new events
+// under optimistic concurrency, and projects the result onto the users
+// table, which remains the read model for every query. It is a drop-in for
+// the profile and phone queriers; reads pass through to the embedded
+// queries.
+type EventSourcedQueries struct {
+	*db.Queries
+	db            TxBeginner
+	snapshotEvery int64
+}
+
+// NewEventSourcedQueries snapshots each stream every snapshotEvery events
+func NewEventSourcedQueries(queries *db.Queries, txdb TxBeginner, snapshotEvery int) *EventSourcedQueries {
+	return &EventSourcedQueries{
+		Queries:       queries,
+		db:            txdb,
+		snapshotEvery: int64(max(snapshotEvery, 1)),
+	}
+}
+
+// decideFunc returns the events a command produces from the current state,
+// or none when it does not apply
+type decideFunc func(state userState) []userEvent
+
+func (q *EventSourcedQueries) UpdateUserProfile(ctx context.Context, arg db.UpdateUserProfileParams) (db.UpdateUserProfileRow, error) {
+	row, err := q.execute(ctx, arg.ID, func(userState) []userEvent {
+		return []userEvent{{Type: EventProfileUpdated, Data: userState{Name: arg.Name, Bio: arg.Bio}}}
+	})
+	return db.UpdateUserProfileRow(row), err
+}
+
+func (q *EventSourcedQueries) UpdateUserPhone(ctx context.Context, arg db.UpdateUserPhoneParams) (db.UpdateUserPhoneRow, error) {
+	row, err := q.execute(ctx, arg.ID, func(userState) []userEvent {
+		return []userEvent{{Type: EventPhoneChanged, Data: userState{Phone: textPtr(arg.Phone)}}}
+	})
+	return db.UpdateUserPhoneRow(row), err
+}
+
+func (q *EventSourcedQueries) MarkUserPhoneVerified(ctx context.Context, arg db.MarkUserPhoneVerifiedParams) (int64, error) {
+	var verified bool
+	_, err := q.execute(ctx, arg.ID, func(state userState) []userEvent {
+		verified = state.Phone != nil && *state.Phone == arg.Phone.String
+		if !verified {
+			return nil
+		}
+		// Postgres keeps microseconds; match it so the stream equals the row
+		now := time.Now().Truncate(time.Microsecond)
+		return []userEvent{{Type: EventPhoneVerified, Data: userState{PhoneVerifiedAt: &now}}}
+	})
+	if err != nil || !verified {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = nil
+		}
+		return 0, err
+	}
+	return 1, nil
+}
+
+// execute runs a command, retrying when another writer wins the race
+func (q *EventSourcedQueries) execute(ctx context.Context, id pgtype.UUID, decide decideFunc) (db.ProjectUserRow, error) {
+	var err error
+	for range appendAttempts {
+		var row db.ProjectUserRow
+		row, err = q.executeOnce(ctx, id, decide)
+		if !errors.Is(err, ErrConcurrentUpdate) {
+			return row, err
+		}
+	}
+	return db.ProjectUserRow{}, err
+}
+
+func (q *EventSourcedQueries) executeOnce(ctx context.Context, id pgtype.UUID, decide decideFunc) (db.ProjectUserRow, error) {
+	tx, err := q.db.Begin(ctx)
+	if err != nil {
+		return db.ProjectUserRow{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := q.WithTx(tx)
+
+	current, err := qtx.GetUserByID(ctx, id)
+	if err != nil {
+		return db.ProjectUserRow{}, err
+	}
+	state, version, err := q.load(ctx, qtx, id)
+	if err != nil {
+		return db.ProjectUserRow{}, err
+	}
+
+	// A missing or stale stream is reseeded from the read model first
+	var events []userEvent
+	if rowState := stateFromRow(current); version == 0 || !state.equal(rowState) {
+		events = append(events, userEvent{Type: EventImported, Data: rowState})
+		state = rowState
+	}
+	decided := decide(state)
+	if len(decided) == 0 {
+		return db.ProjectUserRow{}, nil
+	}
+	events = append(events, decided...)
+
+	for _, e := range events {
+		version++
+		if err := q.append(ctx, qtx, id, version, e); err != nil {
+			return db.ProjectUserRow{}, err
+		}
+		state.apply(e)
+		if version%q.snapshotEvery == 0 {
+			if err := q.snapshot(ctx, qtx, id, version, state); err != nil {
+				return db.ProjectUserRow{}, err
+			}
+		}
+	}
+
+	row, err := qtx.ProjectUser(ctx, projection(id, state))
+	if err != nil {
+		return db.ProjectUserRow{}, fmt.Errorf("failed to project user: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return db.ProjectUserRow{}, fmt.Errorf("failed to commit user events: %w", err)
+	}
+	return row, nil
+}
+
+// load rebuilds the aggregate from its snapshot and the events after it
+func (q *EventSourcedQueries) load(ctx context.Context, qtx *db.Queries, id pgtype.UUID) (userState, int64, error) {
+	var state userState
+	var version int64
+	snapshot, err := qtx.GetUserSnapshot(ctx, id)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(snapshot.State, &state); err != nil {
+			return userState{}, 0, fmt.Errorf("failed to decode user snapshot: %w", err)
+		}
+		version = snapshot.Version
+	case !errors.Is(err, pgx.ErrNoRows):
+		return userState{}, 0, fmt.Errorf("failed to get user snapshot: %w", err)
+	}
+
+	stored, err := qtx.ListUserEventsAfter(ctx, db.ListUserEventsAfterParams{
+		UserID:       id,
+		AfterVersion: version,
+	})
+	if err != nil {
+		return userState{}, 0, fmt.Errorf("failed to list user events: %w", err)
+	}
+	for _, e := range stored {
+		event := userEvent{Type: e.Type}
+		if err := json.Unmarshal(e.Data, &event.Data); err != nil {
+			return userState{}, 0, fmt.Errorf("failed to decode user event %d: %w", e.Version, err)
+		}
+		state.apply(event)
+		version = e.Version
+	}
+	return state, version, nil
+}
+
+func (q *EventSourcedQueries) append(ctx context.Context, qtx *db.Queries, id pgtype.UUID, version int64, e userEvent) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode user event: %w", err)
+	}
+	err = qtx.AppendUserEvent(ctx, db.AppendUserEventParams{
+		UserID:  id,
+		Version: version,
+		Type:    e.Type,
+		Data:    data,
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrConcurrentUpdate
+	}
+	if err != nil {
+		return fmt.Errorf("failed to append user event: %w", err)
+	}
+	return nil
+}
+
+func (q *EventSourcedQueries) snapshot(ctx context.Context, qtx *db.Queries, id pgtype.UUID, version int64, state userState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode user snapshot: %w", err)
+	}
+	if err := qtx.SaveUserSnapshot(ctx, db.SaveUserSnapshotParams{
+		UserID:  id,
+		Version: version,
+		State:   data,
+	}); err != nil {
+		return fmt.Errorf("failed to save user snapshot: %w", err)
+	}
+	return nil
+}
+
+func projection(id pgtype.UUID, state userState) db.ProjectUserParams {
+	params := db.ProjectUserParams{ID: id, Name: state.Name, Bio: state.Bio}
+	if state.Phone != nil {
+		params.Phone = pgtype.Text{String: *state.Phone, Valid: true}
+	}
+	if state.PhoneVerifiedAt != nil {
+		params.PhoneVerifiedAt = pgtype.Timestamptz{Time: *state.PhoneVerifiedAt, Valid: true}
+	}
+	return params
+}
@@ -0,0 +1,226 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/storage"
+
+	"github.com/google/uuid"
+)
+
+// OperationImportExternalIDs is the operation kind of a CSV import of
+// external IDs
+const OperationImportExternalIDs = "users.external_ids_import"
+
+// maxExternalIDFileSize bounds a CSV file of external IDs
+const maxExternalIDFileSize = 10 << 20
+
+var ErrImportReportNotFound = errors.New("import report not found")
+
+// Levels of the findings in an import report
+const (
+	ReportError   = "error"
+	ReportWarning = "warning"
+)
+
+// Codes of the findings in an import report, besides the reasons an import
+// rejects a valid row
+const (
+	ReportInvalidRow        = "invalid_row"
+	ReportInvalidExternalID = "invalid_external_id"
+	ReportInvalidUserID     = "invalid_user_id"
+	ReportDuplicate         = "duplicate"
+	ReportUnchanged         = "unchanged"
+	ReportTrimmed           = "trimmed"
+)
+
+// ExternalIDImportReport summarizes the import of a CSV file of external
+// IDs. Each row with an error or warning is listed in the report file,
+// downloaded from ReportURL.
+type ExternalIDImportReport struct {
+	ReportID  uuid.UUID `json:"report_id"`
+	ReportURL string    `json:"report_url" example:"/admin/external-id-imports/0b6f3a52-6d9f-4f4e-9a53-1f1b1c3c9a10/report"`
+	System    string    `json:"system" example:"legacy"`
+	Rows      int       `json:"rows" doc:"Rows in the file, not counting a header"`
+	Imported  int       `json:"imported"`
+	Unchanged int       `json:"unchanged" doc:"Mappings that already existed"`
+	Invalid   int       `json:"invalid" doc:"Rows left out because they could not be read or are invalid"`
+	Rejected  int       `json:"rejected" doc:"Valid rows the import refused, because the ID is taken or the user unknown"`
+	Warnings  int       `json:"warnings" doc:"Rows imported or unchanged with a warning"`
+}
+
+// reportRow is a finding on one line of an imported file
+type reportRow struct {
+	line       int
+	externalID string
+	userID     string
+	level      string
+	code       string
+	message    string
+}
+
+// ImportCSV imports the external_id,user_id rows of a CSV file into system,
+// skipping a header row naming those columns. Unlike Import it does not
+// stop at invalid rows: they are left out, and the valid ones imported in
+// batches, each audited like an Import. Every finding is written to a
+// report kept in storage. system must match systemPattern.
+func (s *ExternalIDService) ImportCSV(ctx context.Context, system string, data []byte, actor string) (*ExternalIDImportReport, error) {
+	report := &ExternalIDImportReport{ReportID: uuid.New(), System: system}
+	report.ReportURL = "/admin/external-id-imports/" + report.ReportID.String() + "/report"
+
+	var findings []reportRow
+	var mappings []ExternalIDMapping
+	lines := make(map[string]int)
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.Rows++
+			report.Invalid++
+			findings = append(findings, reportRow{line: parseErr.Line, level: ReportError, code: ReportInvalidRow, message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read external IDs: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		if line == 1 && len(record) == 2 && record[0] == "external_id" && record[1] == "user_id" {
+			continue
+		}
+		report.Rows++
+
+		row := reportRow{line: line, level: ReportError}
+		if len(record) > 0 {
+			row.externalID = record[0]
+		}
+		if len(record) > 1 {
+			row.userID = record[1]
+		}
+		externalID := strings.TrimSpace(row.externalID)
+		userID, userErr := uuid.Parse(strings.TrimSpace(row.userID))
+		switch {
+		case len(record) != 2:
+			row.code, row.message = ReportInvalidRow, fmt.Sprintf("has %d columns, expected external_id and user_id", len(record))
+		case externalID == "" || len(externalID) > maxExternalIDLength:
+			row.code, row.message = ReportInvalidExternalID, fmt.Sprintf("external_id must be 1-%d characters", maxExternalIDLength)
+		case userErr != nil || userID == uuid.Nil:
+			row.code, row.message = ReportInvalidUserID, "user_id must be a UUID"
+		case lines[externalID] > 0:
+			row.code, row.message = ReportDuplicate, fmt.Sprintf("external_id is also on line %d", lines[externalID])
+		}
+		if row.code != "" {
+			report.Invalid++
+			findings = append(findings, row)
+			continue
+		}
+
+		lines[externalID] = line
+		mappings = append(mappings, ExternalIDMapping{ExternalID: externalID, UserID: userID})
+		if externalID != row.externalID || strings.TrimSpace(row.userID) != row.userID {
+			row.level, row.code, row.message = ReportWarning, ReportTrimmed, "surrounding spaces were removed"
+			findings = append(findings, row)
+		}
+	}
+
+	for start := 0; start < len(mappings); start += maxExternalIDImport {
+		batch := mappings[start:min(start+maxExternalIDImport, len(mappings))]
+		result, imported, err := s.importMappings(ctx, ExternalIDImportRequest{System: system, Mappings: batch}, actor)
+		if err != nil {
+			return nil, err
+		}
+		report.Imported += result.Imported
+		report.Unchanged += result.Unchanged
+		report.Rejected += len(result.Rejected)
+
+		rejected := make(map[string]RejectedExternalID, len(result.Rejected))
+		for _, rej := range result.Rejected {
+			rejected[rej.ExternalID] = rej
+		}
+		for _, m := range batch {
+			if imported[m.ExternalID] {
+				continue
+			}
+			row := reportRow{line: lines[m.ExternalID], externalID: m.ExternalID, userID: m.UserID.String()}
+			if rej, ok := rejected[m.ExternalID]; ok {
+				row.level, row.code, row.message = ReportError, rej.Reason, "user not found or deleted"
+				if rej.MappedTo != nil {
+					row.message = "already mapped to user " + rej.MappedTo.String()
+				}
+			} else {
+				row.level, row.code, row.message = ReportWarning, ReportUnchanged, "already mapped to this user"
+			}
+			findings = append(findings, row)
+		}
+	}
+	report.Warnings = warnedRows(findings)
+
+	if err := s.store.Put(ctx, importReportKey(report.ReportID), bytes.NewReader(encodeReport(findings))); err != nil {
+		return nil, fmt.Errorf("failed to store import report: %w", err)
+	}
+	logger.FromContext(ctx).Info("external IDs imported from file", "system", system, "report_id", report.ReportID,
+		"rows", report.Rows, "imported", report.Imported, "invalid", report.Invalid, "rejected", report.Rejected)
+	return report, nil
+}
+
+// OpenImportReport returns the CSV report of an import
+func (s *ExternalIDService) OpenImportReport(ctx context.Context, id uuid.UUID) (io.ReadCloser, error) {
+	rc, err := s.store.Get(ctx, importReportKey(id))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrImportReportNotFound
+		}
+		return nil, fmt.Errorf("failed to open import report: %w", err)
+	}
+	return rc, nil
+}
+
+// warnedRows counts the rows with warnings but no error, as a row may
+// have been trimmed and then rejected
+func warnedRows(findings []reportRow) int {
+	levels := make(map[int]string)
+	for _, f := range findings {
+		if levels[f.line] != ReportError {
+			levels[f.line] = f.level
+		}
+	}
+	n := 0
+	for _, level := range levels {
+		if level == ReportWarning {
+			n++
+		}
+	}
+	return n
+}
+
+// encodeReport writes findings as CSV in line order, with a header row
+func encodeReport(findings []reportRow) []byte {
+	slices.SortStableFunc(findings, func(a, b reportRow) int { return a.line - b.line })
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"line", "external_id", "user_id", "level", "code", "message"})
+	for _, f := range findings {
+		w.Write([]string{strconv.Itoa(f.line), f.externalID, f.userID, f.level, f.code, f.message})
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+func importReportKey(id uuid.UUID) string {
+	return "reports/external-id-imports/" + id.String() + ".csv"
+}
@@ -0,0 +1,258 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/request"
+	"starterkit/internal/platform/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrExternalIDNotFound = errors.New("external ID not found")
+
+const (
+	maxExternalIDLength = 255
+	// maxExternalIDImport bounds the mappings imported per request; larger
+	// imports are sent in batches
+	maxExternalIDImport = 1000
+)
+
+// systemPattern names a system users are migrated from, such as "legacy"
+var systemPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+
+// Reasons an external ID was not imported
+const (
+	ExternalIDTaken       = "taken"
+	ExternalIDUnknownUser = "unknown_user"
+)
+
+// ExternalID is a user's ID in a system being migrated from, which clients
+// that still hold it can look the user up by
+type ExternalID struct {
+	System     string    `json:"system" example:"legacy"`
+	ExternalID string    `json:"external_id" example:"48213"`
+	UserID     uuid.UUID `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ExternalIDMapping maps an external ID to a user
+type ExternalIDMapping struct {
+	ExternalID string    `json:"external_id" example:"48213"`
+	UserID     uuid.UUID `json:"user_id"`
+}
+
+// ExternalIDImportRequest maps IDs in one system to users
+type ExternalIDImportRequest struct {
+	System   string              `json:"system" doc:"System the IDs come from; lowercase letters, digits, underscores, or hyphens" example:"legacy"`
+	Mappings []ExternalIDMapping `json:"mappings"`
+}
+
+// Validate trims the external IDs and refuses duplicates, so each ID in a
+// batch has one answer
+func (r *ExternalIDImportRequest) Validate() error {
+	var v request.Validation
+	v.Check(systemPattern.MatchString(r.System), "system", "must be up to 50 lowercase letters, digits, underscores, or hyphens, starting with a letter")
+	v.Check(len(r.Mappings) > 0 && len(r.Mappings) <= maxExternalIDImport, "mappings", fmt.Sprintf("must have 1-%d mappings", maxExternalIDImport))
+
+	seen := make(map[string]bool, len(r.Mappings))
+	for i := range r.Mappings {
+		m := &r.Mappings[i]
+		m.ExternalID = strings.TrimSpace(m.ExternalID)
+		field := fmt.Sprintf("mappings[%d]", i)
+		v.Check(m.ExternalID != "" && len(m.ExternalID) <= maxExternalIDLength, field+".external_id", fmt.Sprintf("must be 1-%d characters", maxExternalIDLength))
+		v.Check(m.UserID != uuid.Nil, field+".user_id", "is required")
+		v.Check(!seen[m.ExternalID], field+".external_id", "is mapped twice")
+		seen[m.ExternalID] = true
+	}
+	return v.Err()
+}
+
+// RejectedExternalID is an external ID an import left out
+type RejectedExternalID struct {
+	ExternalID string     `json:"external_id" example:"48213"`
+	UserID     uuid.UUID  `json:"user_id" doc:"User the import mapped it to"`
+	Reason     string     `json:"reason" enum:"taken,unknown_user"`
+	MappedTo   *uuid.UUID `json:"mapped_to,omitempty" doc:"User the ID is already mapped to, for taken"`
+}
+
+// ExternalIDImport reports what an import changed. Importing the same
+// mappings again is safe: they are counted as unchanged.
+type ExternalIDImport struct {
+	System    string               `json:"system"`
+	Imported  int                  `json:"imported"`
+	Unchanged int                  `json:"unchanged" doc:"Mappings that already existed"`
+	Rejected  []RejectedExternalID `json:"rejected"`
+}
+
+type ExternalIDQuerier interface {
+	FindExternalID(ctx context.Context, arg db.FindExternalIDParams) (pgtype.UUID, error)
+	ListUserExternalIDs(ctx context.Context, userID pgtype.UUID) ([]db.UserExternalID, error)
+}
+
+// ExternalIDService maps users to the IDs they had in systems being
+// migrated from. A mapping never changes once made, so old clients keep
+// resolving the same user; a wrong one is removed and imported again.
+type ExternalIDService struct {
+	queries ExternalIDQuerier
+	tx      Transactor
+	store   storage.Storage
+}
+
+// NewExternalIDService keeps the reports of CSV imports in store
+func NewExternalIDService(queries ExternalIDQuerier, tx Transactor, store storage.Storage) *ExternalIDService {
+	return &ExternalIDService{queries: queries, tx: tx, store: store}
+}
+
+// Resolve returns the user mapped to an external ID
+func (s *ExternalIDService) Resolve(ctx context.Context, system, externalID string) (uuid.UUID, error) {
+	if !systemPattern.MatchString(system) || externalID == "" || len(externalID) > maxExternalIDLength {
+		return uuid.Nil, ErrExternalIDNotFound
+	}
+	id, err := s.queries.FindExternalID(ctx, db.FindExternalIDParams{System: system, ExternalID: externalID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrExternalIDNotFound
+		}
+		return uuid.Nil, fmt.Errorf("failed to find external ID: %w", err)
+	}
+	return uuid.UUID(id.Bytes), nil
+}
+
+// List returns the external IDs mapped to a user
+func (s *ExternalIDService) List(ctx context.Context, userID uuid.UUID) ([]ExternalID, error) {
+	rows, err := s.queries.ListUserExternalIDs(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list external IDs: %w", err)
+	}
+	ids := make([]ExternalID, len(rows))
+	for i, row := range rows {
+		ids[i] = toExternalID(row)
+	}
+	return ids, nil
+}
+
+// Import maps the request's external IDs to users, which must have been
+// validated. IDs already mapped to another user, and users that do not
+// exist or are deleted, are reported rather than failing the import. The
+// import is audited as user.external_ids_imported.
+func (s *ExternalIDService) Import(ctx context.Context, req ExternalIDImportRequest, actor string) (*ExternalIDImport, error) {
+	result, _, err := s.importMappings(ctx, req, actor)
+	return result, err
+}
+
+// importMappings imports like Import and also returns the external IDs it
+// mapped, which tells imported mappings from unchanged ones
+func (s *ExternalIDService) importMappings(ctx context.Context, req ExternalIDImportRequest, actor string) (*ExternalIDImport, map[string]bool, error) {
+	externalIDs := make([]string, len(req.Mappings))
+	userIDs := make([]pgtype.UUID, len(req.Mappings))
+	for i, m := range req.Mappings {
+		externalIDs[i] = m.ExternalID
+		userIDs[i] = pgtype.UUID{Bytes: m.UserID, Valid: true}
+	}
+
+	result := &ExternalIDImport{System: req.System, Rejected: []RejectedExternalID{}}
+	var done map[string]bool
+	err := s.tx.WithTx(ctx, func(q *db.Queries) error {
+		imported, err := q.ImportExternalIDs(ctx, db.ImportExternalIDsParams{
+			System:      req.System,
+			ExternalIds: externalIDs,
+			UserIds:     userIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import external IDs: %w", err)
+		}
+		result.Imported = len(imported)
+		done = make(map[string]bool, len(imported))
+		for _, id := range imported {
+			done[id] = true
+		}
+
+		if len(imported) < len(req.Mappings) {
+			var rest []string
+			for _, m := range req.Mappings {
+				if !done[m.ExternalID] {
+					rest = append(rest, m.ExternalID)
+				}
+			}
+			existing, err := q.ListExternalIDsByValue(ctx, db.ListExternalIDsByValueParams{System: req.System, ExternalIds: rest})
+			if err != nil {
+				return fmt.Errorf("failed to list existing external IDs: %w", err)
+			}
+			mapped := make(map[string]uuid.UUID, len(existing))
+			for _, e := range existing {
+				mapped[e.ExternalID] = uuid.UUID(e.UserID.Bytes)
+			}
+
+			for _, m := range req.Mappings {
+				if done[m.ExternalID] {
+					continue
+				}
+				owner, ok := mapped[m.ExternalID]
+				switch {
+				case ok && owner == m.UserID:
+					result.Unchanged++
+				case ok:
+					result.Rejected = append(result.Rejected, RejectedExternalID{ExternalID: m.ExternalID, UserID: m.UserID, Reason: ExternalIDTaken, MappedTo: &owner})
+				default:
+					result.Rejected = append(result.Rejected, RejectedExternalID{ExternalID: m.ExternalID, UserID: m.UserID, Reason: ExternalIDUnknownUser})
+				}
+			}
+		}
+
+		return audit.NewService(q).Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "user.external_ids_imported",
+			ResourceType: ResourceType,
+			ResourceID:   req.System,
+			Metadata:     map[string]any{"system": req.System, "imported": result.Imported, "unchanged": result.Unchanged, "rejected": len(result.Rejected)},
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, done, nil
+}
+
+// Remove deletes the mapping of an external ID, audited as
+// user.external_id_removed
+func (s *ExternalIDService) Remove(ctx context.Context, system, externalID, actor string) error {
+	err := s.tx.WithTx(ctx, func(q *db.Queries) error {
+		row, err := q.DeleteExternalID(ctx, db.DeleteExternalIDParams{System: system, ExternalID: externalID})
+		if err != nil {
+			return err
+		}
+		return audit.NewService(q).Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "user.external_id_removed",
+			ResourceType: ResourceType,
+			ResourceID:   uuid.UUID(row.UserID.Bytes).String(),
+			Metadata:     map[string]any{"system": system, "external_id": externalID},
+		})
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrExternalIDNotFound
+		}
+		return fmt.Errorf("failed to remove external ID: %w", err)
+	}
+	return nil
+}
+
+func toExternalID(row db.UserExternalID) ExternalID {
+	return ExternalID{
+		System:     row.System,
+		ExternalID: row.ExternalID,
+		UserID:     uuid.UUID(row.UserID.Bytes),
+		CreatedAt:  row.CreatedAt.Time,
+	}
+}
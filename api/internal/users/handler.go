@@ -4,27 +4,112 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
+
+	"starterkit/internal/moderation"
+	"starterkit/internal/operations"
+	"starterkit/internal/platform/apierror"
+	"starterkit/internal/platform/dryrun"
+	"starterkit/internal/platform/expand"
+	"starterkit/internal/platform/fieldaccess"
+	"starterkit/internal/platform/jsonpatch"
+	"starterkit/internal/platform/protobuf"
+	"starterkit/internal/platform/querycost"
+	"starterkit/internal/platform/request"
+	"starterkit/internal/platform/sse"
+	"starterkit/internal/workflow"
 
 	"github.com/google/uuid"
 )
 
 type ServiceInterface interface {
-	GetUserByID(ctx context.Context, id uuid.UUID) (*User, error)
-	ListUsers(ctx context.Context, limit, offset int) ([]*User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID, viewer string) (*User, error)
+	ListUsers(ctx context.Context, limit, offset int, viewer string) ([]*User, ListMeta, error)
+	SearchUsers(ctx context.Context, query string, limit, offset int, viewer string) ([]*User, ListMeta, error)
+	ListChanges(ctx context.Context, since string, limit int, viewer string) ([]*Change, string, error)
+	CreateUser(ctx context.Context, req UserRequest, actor string) (*User, error)
+	UpdateUser(ctx context.Context, id uuid.UUID, req UserRequest, actor string) (*User, error)
+	PatchUser(ctx context.Context, id uuid.UUID, patch jsonpatch.Patch, actor string) (*User, error)
+	DeleteUser(ctx context.Context, id uuid.UUID, actor string) (*workflow.Workflow, error)
+	RequestEmailChange(ctx context.Context, id uuid.UUID, req EmailChangeRequest, actor string) (*EmailChange, error)
+	GetEmailChange(ctx context.Context, id uuid.UUID) (*EmailChange, error)
+	ConfirmEmailChange(ctx context.Context, token string) (*EmailChange, error)
+	CancelEmailChange(ctx context.Context, id uuid.UUID, actor string) error
+	EmailAvailability(ctx context.Context, email string) (*EmailAvailability, error)
+}
+
+type PhoneServiceInterface interface {
+	SetPhone(ctx context.Context, id uuid.UUID, raw string) (*User, error)
+	StartVerification(ctx context.Context, id uuid.UUID) (string, time.Time, error)
+	ConfirmVerification(ctx context.Context, id uuid.UUID, code string) error
+}
+
+type HandleServiceInterface interface {
+	Availability(ctx context.Context, raw string) (*HandleAvailability, error)
+	Acceptable(raw string) *AcceptableValue
+	Handle(ctx context.Context, id uuid.UUID) (*HandleInfo, error)
+	SetHandle(ctx context.Context, id uuid.UUID, handle, actor string) (*User, error)
+	Resolve(ctx context.Context, raw string) (uuid.UUID, string, error)
+}
+
+type ExternalIDServiceInterface interface {
+	Resolve(ctx context.Context, system, externalID string) (uuid.UUID, error)
+	List(ctx context.Context, userID uuid.UUID) ([]ExternalID, error)
+	Import(ctx context.Context, req ExternalIDImportRequest, actor string) (*ExternalIDImport, error)
+	ImportCSV(ctx context.Context, system string, data []byte, actor string) (*ExternalIDImportReport, error)
+	OpenImportReport(ctx context.Context, id uuid.UUID) (io.ReadCloser, error)
+	Remove(ctx context.Context, system, externalID, actor string) error
+}
+
+// OperationRunner runs long mutations in the background and returns a receipt
+type OperationRunner interface {
+	Start(ctx context.Context, kind, owner string, fn operations.Func) (*operations.Operation, error)
+}
+
+type ProfileServiceInterface interface {
+	UpdateProfile(ctx context.Context, id uuid.UUID, req UpdateProfileRequest, actor string) (*User, error)
+}
+
+type BulkServiceInterface interface {
+	Start(ctx context.Context, req BulkRequest, actor string) (*BulkOperation, error)
+	Get(ctx context.Context, id uuid.UUID) (*BulkOperation, error)
+	List(ctx context.Context, limit int) ([]*BulkOperation, error)
+	Items(ctx context.Context, id uuid.UUID, after, limit int) ([]BulkItem, error)
 }
 
+// maxExpandDepth bounds ?expand paths such as sessions.device
+const maxExpandDepth = 2
+
 type Handler struct {
-	service ServiceInterface
-	logger  *slog.Logger
+	service     ServiceInterface
+	phone       PhoneServiceInterface
+	profile     ProfileServiceInterface
+	handles     HandleServiceInterface
+	bulk        BulkServiceInterface
+	externalIDs ExternalIDServiceInterface
+	operations  OperationRunner
+	expansions  *expand.Registry
+	logger      *slog.Logger
 }
 
-func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+func NewHandler(service ServiceInterface, phone PhoneServiceInterface, profile ProfileServiceInterface, handles HandleServiceInterface, bulk BulkServiceInterface, externalIDs ExternalIDServiceInterface, ops OperationRunner, expansions *expand.Registry, logger *slog.Logger) *Handler {
 	return &Handler{
-		service: service,
-		logger:  logger,
+		service:     service,
+		phone:       phone,
+		profile:     profile,
+		handles:     handles,
+		bulk:        bulk,
+		externalIDs: externalIDs,
+		operations:  ops,
+		expansions:  expansions,
+		logger:      logger,
 	}
 }
 
@@ -33,44 +118,286 @@ func (h *Handler) HandleGetUser() http.HandlerFunc {
 		// Extract user ID from URL path
 		idStr := r.PathValue("id")
 		if idStr == "" {
-			h.respondWithError(w, http.StatusBadRequest, "user ID is required")
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "user ID is required"))
 			return
 		}
 
 		// Parse UUID
 		userID, err := uuid.Parse(idStr)
 		if err != nil {
-			h.respondWithError(w, http.StatusBadRequest, "invalid user ID format")
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+
+		expansions, err := h.parseExpand(r)
+		if err != nil {
+			apierror.Write(w, r, err)
 			return
 		}
 
 		// Get user from service
-		user, err := h.service.GetUserByID(r.Context(), userID)
+		user, err := h.service.GetUserByID(r.Context(), userID, viewerFromRequest(r))
 		if err != nil {
 			if errors.Is(err, ErrUserNotFound) {
-				h.respondWithError(w, http.StatusNotFound, "user not found")
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
 				return
 			}
 			h.logger.Error("failed to get user", "error", err, "user_id", userID)
-			h.respondWithError(w, http.StatusInternalServerError, "internal server error")
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		if err := h.expansions.Load(r.Context(), expansions, []expand.Expandable{user}); err != nil {
+			h.logger.Error("failed to expand user", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
 			return
 		}
 
 		// Respond with user
-		h.respondWithJSON(w, http.StatusOK, user)
+		h.respondNegotiated(w, r, http.StatusOK, user)
+	}
+}
+
+func (h *Handler) HandleCreateUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req UserRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		user, err := h.service.CreateUser(r.Context(), req, viewerFromRequest(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrEmailConflict):
+				apierror.Write(w, r, apierror.Conflict("email_conflict", err.Error()))
+			case errors.Is(err, moderation.ErrRejected):
+				apierror.Write(w, r, apierror.Unprocessable("content_rejected", err.Error()))
+			default:
+				h.logger.Error("failed to create user", "error", err)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+
+		if dryrun.Enabled(r.Context()) {
+			h.respondDryRun(w, r, nil, user, user)
+			return
+		}
+		w.Header().Set("Location", "/api/v1/users/"+user.ID.String())
+		h.respondWithJSON(w, r, http.StatusCreated, user)
+	}
+}
+
+// HandleUpdateUser replaces a user, or applies a JSON Patch to them when
+// the body is application/json-patch+json
+func (h *Handler) HandleUpdateUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+		if jsonpatch.Requested(r) {
+			h.patchUser(w, r, userID, UserPatchPaths)
+			return
+		}
+
+		var req UserRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		before, ok := h.dryRunBefore(w, r, userID)
+		if !ok {
+			return
+		}
+		user, err := h.service.UpdateUser(r.Context(), userID, req, viewerFromRequest(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrUserNotFound):
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+			case errors.Is(err, ErrEmailConflict):
+				apierror.Write(w, r, apierror.Conflict("email_conflict", err.Error()))
+			case errors.Is(err, ErrEmailChangeRequired):
+				apierror.Write(w, r, apierror.Unprocessable("email_change_required", err.Error()))
+			case errors.Is(err, moderation.ErrRejected):
+				apierror.Write(w, r, apierror.Unprocessable("content_rejected", err.Error()))
+			default:
+				h.logger.Error("failed to update user", "error", err, "user_id", userID)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+
+		if before != nil {
+			h.respondDryRun(w, r, before, user, user)
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, user)
+	}
+}
+
+// patchUser applies the JSON Patch in the body of r, which may only touch
+// paths, to the user
+func (h *Handler) patchUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID, paths []string) {
+	patch, err := jsonpatch.Decode(w, r, paths...)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	before, ok := h.dryRunBefore(w, r, userID)
+	if !ok {
+		return
+	}
+	user, err := h.service.PatchUser(r.Context(), userID, patch, viewerFromRequest(r))
+	if err != nil {
+		var reqErr *request.Error
+		switch {
+		case errors.As(err, &reqErr):
+			apierror.Write(w, r, err)
+		case errors.Is(err, ErrUserNotFound):
+			apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+		case errors.Is(err, jsonpatch.ErrTestFailed):
+			apierror.Write(w, r, apierror.Conflict("patch_test_failed", err.Error()))
+		case errors.Is(err, jsonpatch.ErrInvalid):
+			apierror.Write(w, r, apierror.Unprocessable("invalid_patch", err.Error()))
+		case errors.Is(err, ErrConcurrentUpdate):
+			apierror.Write(w, r, apierror.Conflict("concurrent_update", "user was changed while the patch was applied; retry it"))
+		case errors.Is(err, ErrEmailConflict):
+			apierror.Write(w, r, apierror.Conflict("email_conflict", err.Error()))
+		case errors.Is(err, moderation.ErrRejected):
+			apierror.Write(w, r, apierror.Unprocessable("content_rejected", err.Error()))
+		default:
+			h.logger.Error("failed to patch user", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+		}
+		return
+	}
+
+	if before != nil {
+		h.respondDryRun(w, r, before, user, user)
+		return
+	}
+	h.respondWithJSON(w, r, http.StatusOK, user)
+}
+
+// HandleDeleteUser starts account deletion and returns the workflow that
+// tracks it
+func (h *Handler) HandleDeleteUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+
+		before, ok := h.dryRunBefore(w, r, userID)
+		if !ok {
+			return
+		}
+		wf, err := h.service.DeleteUser(r.Context(), userID, viewerFromRequest(r))
+		if err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+				return
+			}
+			h.logger.Error("failed to delete user", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		if before != nil {
+			h.respondDryRun(w, r, before, nil, wf)
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusAccepted, wf)
 	}
 }
 
-func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+// respondWithJSON writes payload with the fields the caller may not see
+// stripped or masked
+func (h *Handler) respondWithJSON(w http.ResponseWriter, r *http.Request, code int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	if err := json.NewEncoder(w).Encode(payload); err != nil {
+	if err := json.NewEncoder(w).Encode(fieldaccess.Apply(r.Context(), payload)); err != nil {
 		h.logger.Error("failed to encode response", "error", err)
 	}
 }
 
-func (h *Handler) respondWithError(w http.ResponseWriter, code int, message string) {
-	h.respondWithJSON(w, code, map[string]string{"error": message})
+// respondNegotiated answers with payload as protobuf when the request
+// prefers it, and as JSON otherwise
+func (h *Handler) respondNegotiated(w http.ResponseWriter, r *http.Request, code int, payload protobuf.Message) {
+	w.Header().Add("Vary", "Accept")
+	if !protobuf.Preferred(r) {
+		h.respondWithJSON(w, r, code, payload)
+		return
+	}
+	payload, err := visible(r.Context(), payload)
+	if err != nil {
+		h.logger.Error("failed to apply field access", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+		return
+	}
+	protobuf.Write(w, code, payload)
+}
+
+// parseExpand reads the relations to embed from ?expand
+func (h *Handler) parseExpand(r *http.Request) (expand.Tree, error) {
+	tree, err := h.expansions.Parse(r.URL.Query().Get("expand"), maxExpandDepth)
+	if err != nil {
+		return nil, apierror.BadRequest("invalid_expand", fmt.Sprintf("%s; expandable: %s", err, strings.Join(h.expansions.Names(), ", ")))
+	}
+	return tree, nil
+}
+
+// dryRunBefore returns the user a dry run would change, for the diff of
+// what it changes, or nil outside dry runs. It answers the request itself
+// when the user cannot be loaded.
+func (h *Handler) dryRunBefore(w http.ResponseWriter, r *http.Request, id uuid.UUID) (*User, bool) {
+	if !dryrun.Enabled(r.Context()) {
+		return nil, true
+	}
+	user, err := h.service.GetUserByID(r.Context(), id, viewerFromRequest(r))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+			return nil, false
+		}
+		h.logger.Error("failed to get user", "error", err, "user_id", id)
+		apierror.Write(w, r, apierror.Internal())
+		return nil, false
+	}
+	return user, true
+}
+
+// respondDryRun answers a dry run with the fields it would have changed, as
+// the caller may see them, and the response it would have got. before is
+// nil for resources being created and after for those being deleted.
+func (h *Handler) respondDryRun(w http.ResponseWriter, r *http.Request, before, after, response any) {
+	ctx := r.Context()
+	changes, err := dryrun.Diff(fieldaccess.Apply(ctx, before), fieldaccess.Apply(ctx, after))
+	if err != nil {
+		h.logger.Error("failed to diff dry run", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+		return
+	}
+	h.respondWithJSON(w, r, http.StatusOK, dryrun.Preview{DryRun: true, Changes: changes, Response: fieldaccess.Apply(ctx, response)})
+}
+
+// ListCost prices GET /users. Searches read the whole user_search match set
+// to rank it, deep offsets read every skipped row, and each expanded
+// relation is another query.
+var ListCost = querycost.Model{
+	Base:         1,
+	PerRow:       0.1,
+	DefaultLimit: 20,
+	MaxLimit:     100,
+	PerSkipped:   0.01,
+	Filters:      map[string]float64{"q": 5, "expand": 2},
+	Sorts:        map[string]float64{"q": 3},
 }
 
 func (h *Handler) HandleListUsers() http.HandlerFunc {
@@ -83,7 +410,7 @@ func (h *Handler) HandleListUsers() http.HandlerFunc {
 		if limitStr != "" {
 			parsedLimit, err := strconv.Atoi(limitStr)
 			if err != nil || parsedLimit < 0 {
-				h.respondWithError(w, http.StatusBadRequest, "invalid limit parameter")
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid limit parameter"))
 				return
 			}
 			limit = parsedLimit
@@ -93,25 +420,900 @@ func (h *Handler) HandleListUsers() http.HandlerFunc {
 		if offsetStr != "" {
 			parsedOffset, err := strconv.Atoi(offsetStr)
 			if err != nil || parsedOffset < 0 {
-				h.respondWithError(w, http.StatusBadRequest, "invalid offset parameter")
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid offset parameter"))
 				return
 			}
 			offset = parsedOffset
 		}
 
-		// Get users from service
-		users, err := h.service.ListUsers(r.Context(), limit, offset)
+		expansions, err := h.parseExpand(r)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		// Get users from service; lists read the user_summaries projection
+		// and searches the user_search one
+		var users []*User
+		var meta ListMeta
+		if query := strings.TrimSpace(r.URL.Query().Get("q")); query != "" {
+			users, meta, err = h.service.SearchUsers(r.Context(), query, limit, offset, viewerFromRequest(r))
+		} else {
+			users, meta, err = h.service.ListUsers(r.Context(), limit, offset, viewerFromRequest(r))
+		}
 		if err != nil {
 			h.logger.Error("failed to list users", "error", err)
-			h.respondWithError(w, http.StatusInternalServerError, "internal server error")
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		parents := make([]expand.Expandable, len(users))
+		for i, user := range users {
+			parents[i] = user
+		}
+		if err := h.expansions.Load(r.Context(), expansions, parents); err != nil {
+			h.logger.Error("failed to expand users", "error", err)
+			apierror.Write(w, r, apierror.Internal())
 			return
 		}
 
 		// Respond with users
-		h.respondWithJSON(w, http.StatusOK, map[string]any{
-			"users":  users,
-			"limit":  limit,
-			"offset": offset,
+		h.respondNegotiated(w, r, http.StatusOK, &UserList{Users: users, Limit: limit, Offset: offset, Meta: meta})
+	}
+}
+
+// ChangesCost prices GET /users/changes, which reads the change log in
+// order from an indexed cursor
+var ChangesCost = querycost.Model{
+	Base:         1,
+	PerRow:       0.01,
+	DefaultLimit: 100,
+	MaxLimit:     1000,
+}
+
+func (h *Handler) HandleListChanges() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 100 // default
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsedLimit, err := strconv.Atoi(limitStr)
+			if err != nil || parsedLimit < 0 {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid limit parameter"))
+				return
+			}
+			limit = parsedLimit
+		}
+
+		changes, next, err := h.service.ListChanges(r.Context(), r.URL.Query().Get("since"), limit, viewerFromRequest(r))
+		if err != nil {
+			if errors.Is(err, ErrInvalidCursor) {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid since parameter"))
+				return
+			}
+			h.logger.Error("failed to list user changes", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		h.respondWithJSON(w, r, http.StatusOK, ChangeList{Changes: changes, NextCursor: next})
+	}
+}
+
+// HandleUpdateProfile changes the given profile fields, or applies a JSON
+// Patch to the name and bio when the body is application/json-patch+json
+func (h *Handler) HandleUpdateProfile() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+		if jsonpatch.Requested(r) {
+			h.patchUser(w, r, userID, ProfilePatchPaths)
+			return
+		}
+
+		var req UpdateProfileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		user, err := h.profile.UpdateProfile(r.Context(), userID, req, viewerFromRequest(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrInvalidProfile):
+				apierror.Write(w, r, apierror.Unprocessable("invalid_profile", err.Error()))
+			case errors.Is(err, moderation.ErrRejected):
+				apierror.Write(w, r, apierror.Unprocessable("content_rejected", err.Error()))
+			case errors.Is(err, ErrUserNotFound):
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+			default:
+				h.logger.Error("failed to update profile", "error", err, "user_id", userID)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+
+		h.respondWithJSON(w, r, http.StatusOK, user)
+	}
+}
+
+func (h *Handler) HandleSetPhone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+
+		var req PhoneRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		user, err := h.phone.SetPhone(r.Context(), userID, req.Phone)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrInvalidPhone):
+				apierror.Write(w, r, apierror.Unprocessable("invalid_phone", "invalid phone number"))
+			case errors.Is(err, ErrUserNotFound):
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+			default:
+				h.logger.Error("failed to set phone", "error", err, "user_id", userID)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+
+		h.respondWithJSON(w, r, http.StatusOK, user)
+	}
+}
+
+func (h *Handler) HandleStartPhoneVerification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+
+		masked, expiresAt, err := h.phone.StartVerification(r.Context(), userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrUserNotFound):
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+			case errors.Is(err, ErrPhoneNotSet):
+				apierror.Write(w, r, apierror.Conflict("phone_not_set", err.Error()))
+			case errors.Is(err, ErrPhoneNotSMSCapable):
+				apierror.Write(w, r, apierror.Conflict("phone_not_sms_capable", err.Error()))
+			case errors.Is(err, ErrPhoneAlreadyVerified):
+				apierror.Write(w, r, apierror.Conflict("phone_already_verified", err.Error()))
+			case errors.Is(err, ErrVerificationRateLimited):
+				w.Header().Set("Retry-After", strconv.Itoa(int(verificationResendInterval.Seconds())))
+				apierror.Write(w, r, apierror.TooManyRequests("verification_rate_limited", err.Error()))
+			default:
+				h.logger.Error("failed to start phone verification", "error", err, "user_id", userID)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+
+		h.respondWithJSON(w, r, http.StatusAccepted, PhoneVerification{Phone: masked, ExpiresAt: expiresAt})
+	}
+}
+
+func (h *Handler) HandleConfirmPhoneVerification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+
+		var req VerificationCode
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+			apierror.Write(w, r, apierror.BadRequest("code_required", "code is required"))
+			return
+		}
+
+		if err := h.phone.ConfirmVerification(r.Context(), userID, req.Code); err != nil {
+			switch {
+			case errors.Is(err, ErrVerificationCodeInvalid):
+				apierror.Write(w, r, apierror.Unprocessable("verification_code_invalid", err.Error()))
+			case errors.Is(err, ErrVerificationExpired):
+				apierror.Write(w, r, apierror.Unprocessable("verification_expired", err.Error()))
+			case errors.Is(err, ErrVerificationNotFound):
+				apierror.Write(w, r, apierror.Unprocessable("verification_not_found", err.Error()))
+			case errors.Is(err, ErrTooManyAttempts):
+				apierror.Write(w, r, apierror.TooManyRequests("too_many_attempts", err.Error()))
+			default:
+				h.logger.Error("failed to confirm phone verification", "error", err, "user_id", userID)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+
+		user, err := h.service.GetUserByID(r.Context(), userID, viewerFromRequest(r))
+		if err != nil {
+			h.logger.Error("failed to get user", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, user)
+	}
+}
+
+// HandleGetUserByHandle returns the user with a handle. Previous handles
+// redirect to the user's current one.
+func (h *Handler) HandleGetUserByHandle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expansions, err := h.parseExpand(r)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		userID, current, err := h.handles.Resolve(r.Context(), r.PathValue("handle"))
+		if err != nil {
+			if errors.Is(err, ErrHandleNotFound) {
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+				return
+			}
+			h.logger.Error("failed to resolve handle", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		// Shadow banned users are hidden here as they are by ID
+		user, err := h.service.GetUserByID(r.Context(), userID, viewerFromRequest(r))
+		if err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+				return
+			}
+			h.logger.Error("failed to get user", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		if current != "" {
+			// Relative to the request, so the redirect survives path prefixes
+			location := url.PathEscape(current)
+			if r.URL.RawQuery != "" {
+				location += "?" + r.URL.RawQuery
+			}
+			w.Header().Set("Location", location)
+			w.WriteHeader(http.StatusFound)
+			return
+		}
+
+		if err := h.expansions.Load(r.Context(), expansions, []expand.Expandable{user}); err != nil {
+			h.logger.Error("failed to expand user", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, user)
+	}
+}
+
+// HandleGetUserByExternalID returns the user mapped to an ID in a system
+// being migrated from
+func (h *Handler) HandleGetUserByExternalID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expansions, err := h.parseExpand(r)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		userID, err := h.externalIDs.Resolve(r.Context(), r.PathValue("system"), r.PathValue("id"))
+		if err != nil {
+			if errors.Is(err, ErrExternalIDNotFound) {
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+				return
+			}
+			h.logger.Error("failed to resolve external ID", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		user, err := h.service.GetUserByID(r.Context(), userID, viewerFromRequest(r))
+		if err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+				return
+			}
+			h.logger.Error("failed to get user", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		if err := h.expansions.Load(r.Context(), expansions, []expand.Expandable{user}); err != nil {
+			h.logger.Error("failed to expand user", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, user)
+	}
+}
+
+func (h *Handler) HandleListExternalIDs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+
+		ids, err := h.externalIDs.List(r.Context(), userID)
+		if err != nil {
+			h.logger.Error("failed to list external IDs", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, map[string]any{"external_ids": ids})
+	}
+}
+
+func (h *Handler) HandleImportExternalIDs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ExternalIDImportRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		result, err := h.externalIDs.Import(r.Context(), req, viewerFromRequest(r))
+		if err != nil {
+			h.logger.Error("failed to import external IDs", "error", err, "system", req.System)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, result)
+	}
+}
+
+// HandleImportExternalIDFile imports the text/csv body of external_id,user_id
+// rows into ?system= in the background and responds with an operation
+// receipt. Invalid rows do not fail the import; the operation's result
+// summarizes it and links the report of every row with an error or warning.
+func (h *Handler) HandleImportExternalIDFile() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		system := r.URL.Query().Get("system")
+		if !systemPattern.MatchString(system) {
+			apierror.Write(w, r, apierror.BadRequest("invalid_system", "system must be up to 50 lowercase letters, digits, underscores, or hyphens, starting with a letter"))
+			return
+		}
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxExternalIDFileSize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				apierror.Write(w, r, apierror.New(http.StatusRequestEntityTooLarge, "file_too_large", fmt.Sprintf("file must be at most %d bytes", maxExternalIDFileSize)))
+				return
+			}
+			apierror.Write(w, r, apierror.BadRequest("invalid_body", "failed to read file"))
+			return
+		}
+		if len(data) == 0 {
+			apierror.Write(w, r, apierror.BadRequest("empty_file", "file has no rows"))
+			return
+		}
+
+		actor := viewerFromRequest(r)
+		op, err := h.operations.Start(r.Context(), OperationImportExternalIDs, operations.Owner(r), func(ctx context.Context) (any, error) {
+			return h.externalIDs.ImportCSV(ctx, system, data, actor)
 		})
+		if err != nil {
+			h.logger.Error("failed to start external ID import", "error", err, "system", system)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+
+		w.Header().Set("Location", "/api/v1/operations/"+op.ID.String())
+		h.respondWithJSON(w, r, http.StatusAccepted, op)
+	}
+}
+
+// HandleImportReport downloads the CSV report of an external ID file import
+func (h *Handler) HandleImportReport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reportID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_report_id", "invalid report ID format"))
+			return
+		}
+
+		rc, err := h.externalIDs.OpenImportReport(r.Context(), reportID)
+		if err != nil {
+			if errors.Is(err, ErrImportReportNotFound) {
+				apierror.Write(w, r, apierror.NotFound("import_report_not_found", err.Error()))
+				return
+			}
+			h.logger.Error("failed to open import report", "error", err, "report_id", reportID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		defer rc.Close()
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="external-id-import-`+reportID.String()+`.csv"`)
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(w, rc); err != nil {
+			h.logger.Error("failed to stream import report", "error", err, "report_id", reportID)
+		}
+	}
+}
+
+func (h *Handler) HandleRemoveExternalID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := h.externalIDs.Remove(r.Context(), r.PathValue("system"), r.PathValue("externalId"), viewerFromRequest(r))
+		if err != nil {
+			if errors.Is(err, ErrExternalIDNotFound) {
+				apierror.Write(w, r, apierror.NotFound("external_id_not_found", err.Error()))
+				return
+			}
+			h.logger.Error("failed to remove external ID", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) HandleCheckHandle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handle := r.URL.Query().Get("handle")
+		if handle == "" {
+			apierror.Write(w, r, apierror.BadRequest("handle_required", "handle is required"))
+			return
+		}
+
+		availability, err := h.handles.Availability(r.Context(), handle)
+		if err != nil {
+			h.logger.Error("failed to check handle", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, availability)
+	}
+}
+
+// HandleCheckAvailability answers unauthenticated callers, such as a sign-up
+// form, only whether an email or handle is acceptable. Whether it is in use
+// is left out, so the check cannot be used to find out who has an account.
+func (h *Handler) HandleCheckAvailability() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AvailabilityRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		var check AvailabilityCheck
+		if req.Email != "" {
+			check.Email = AcceptableEmail(req.Email)
+		}
+		if req.Handle != "" {
+			check.Handle = h.handles.Acceptable(req.Handle)
+		}
+		h.respondWithJSON(w, r, http.StatusOK, check)
+	}
+}
+
+// HandleAdminCheckAvailability reports whether an email or handle is in use
+// and, for emails, by whom
+func (h *Handler) HandleAdminCheckAvailability() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AvailabilityRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		var report AvailabilityReport
+		if req.Email != "" {
+			email, err := h.service.EmailAvailability(r.Context(), req.Email)
+			if err != nil {
+				h.logger.Error("failed to check email", "error", err)
+				apierror.Write(w, r, apierror.Internal())
+				return
+			}
+			report.Email = email
+		}
+		if req.Handle != "" {
+			handle, err := h.handles.Availability(r.Context(), req.Handle)
+			if err != nil {
+				h.logger.Error("failed to check handle", "error", err)
+				apierror.Write(w, r, apierror.Internal())
+				return
+			}
+			report.Handle = handle
+		}
+		h.respondWithJSON(w, r, http.StatusOK, report)
 	}
 }
+
+func (h *Handler) HandleGetHandle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+
+		info, err := h.handles.Handle(r.Context(), userID)
+		if err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+				return
+			}
+			h.logger.Error("failed to get handle", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, info)
+	}
+}
+
+func (h *Handler) HandleSetHandle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+
+		var req HandleRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		user, err := h.handles.SetHandle(r.Context(), userID, req.Handle, viewerFromRequest(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrUserNotFound):
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+			case errors.Is(err, ErrHandleReserved):
+				apierror.Write(w, r, apierror.Unprocessable("handle_reserved", err.Error()))
+			case errors.Is(err, ErrHandleTaken):
+				apierror.Write(w, r, apierror.Conflict("handle_taken", err.Error()))
+			case errors.Is(err, ErrHandleHeld):
+				apierror.Write(w, r, apierror.Conflict("handle_held", err.Error()))
+			case errors.Is(err, ErrHandleUnchanged):
+				apierror.Write(w, r, apierror.Conflict("handle_unchanged", err.Error()))
+			case errors.Is(err, ErrHandleCooldown):
+				apierror.Write(w, r, apierror.Conflict("handle_cooldown", err.Error()))
+			default:
+				h.logger.Error("failed to set handle", "error", err, "user_id", userID)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, user)
+	}
+}
+
+func (h *Handler) HandleGetEmailChange() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+
+		change, err := h.service.GetEmailChange(r.Context(), userID)
+		if err != nil {
+			if errors.Is(err, ErrNoEmailChange) {
+				apierror.Write(w, r, apierror.NotFound("no_email_change", err.Error()))
+				return
+			}
+			h.logger.Error("failed to get email change", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, change)
+	}
+}
+
+// HandleRequestEmailChange emails confirmation links to the user's current
+// and new addresses
+func (h *Handler) HandleRequestEmailChange() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+
+		var req EmailChangeRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		change, err := h.service.RequestEmailChange(r.Context(), userID, req, viewerFromRequest(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrUserNotFound):
+				apierror.Write(w, r, apierror.NotFound("user_not_found", "user not found"))
+			case errors.Is(err, ErrEmailUnchanged):
+				apierror.Write(w, r, apierror.Conflict("email_unchanged", err.Error()))
+			case errors.Is(err, ErrEmailConflict):
+				apierror.Write(w, r, apierror.Conflict("email_conflict", "email already exists"))
+			default:
+				h.logger.Error("failed to request email change", "error", err, "user_id", userID)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusAccepted, change)
+	}
+}
+
+func (h *Handler) HandleCancelEmailChange() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_user_id", "invalid user ID format"))
+			return
+		}
+
+		if err := h.service.CancelEmailChange(r.Context(), userID, viewerFromRequest(r)); err != nil {
+			if errors.Is(err, ErrNoEmailChange) {
+				apierror.Write(w, r, apierror.NotFound("no_email_change", err.Error()))
+				return
+			}
+			h.logger.Error("failed to cancel email change", "error", err, "user_id", userID)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleConfirmEmailChange confirms an email change with the token from
+// either link. It is public: the token is the credential.
+func (h *Handler) HandleConfirmEmailChange() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req EmailChangeConfirmation
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		change, err := h.service.ConfirmEmailChange(r.Context(), req.Token)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrInvalidEmailChangeLink):
+				apierror.Write(w, r, apierror.Unprocessable("email_change_invalid", err.Error()))
+			case errors.Is(err, ErrEmailConflict):
+				apierror.Write(w, r, apierror.Conflict("email_conflict", "email already exists"))
+			default:
+				h.logger.Error("failed to confirm email change", "error", err)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, change)
+	}
+}
+
+// bulkPollInterval is how often an events stream checks an operation for
+// progress. Batches can run on any replica, so progress is read from the
+// database rather than published in process.
+const bulkPollInterval = time.Second
+
+// HandleStartBulk records a bulk operation and queues its first batch. The
+// operation is answered with 202 while the users are still being acted on.
+func (h *Handler) HandleStartBulk() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req BulkRequest
+		if err := request.Decode(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		op, err := h.bulk.Start(r.Context(), req, viewerFromRequest(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrBulkNoTargets):
+				apierror.Write(w, r, apierror.Unprocessable("no_targets", "no users match"))
+			case errors.Is(err, ErrBulkTooMany):
+				apierror.Write(w, r, apierror.Unprocessable("too_many_targets", err.Error()))
+			default:
+				h.logger.Error("failed to start bulk operation", "error", err)
+				apierror.Write(w, r, apierror.Internal())
+			}
+			return
+		}
+
+		if dryrun.Enabled(r.Context()) {
+			h.respondDryRun(w, r, nil, nil, op)
+			return
+		}
+		w.Header().Set("Location", "/admin/bulk-operations/"+op.ID.String())
+		h.respondWithJSON(w, r, http.StatusAccepted, op)
+	}
+}
+
+func (h *Handler) HandleListBulk() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 20
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsed, err := strconv.Atoi(limitStr)
+			if err != nil || parsed < 1 || parsed > 100 {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "limit must be between 1 and 100"))
+				return
+			}
+			limit = parsed
+		}
+
+		ops, err := h.bulk.List(r.Context(), limit)
+		if err != nil {
+			h.logger.Error("failed to list bulk operations", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, map[string]any{"operations": ops})
+	}
+}
+
+func (h *Handler) HandleGetBulk() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op, ok := h.bulkOperation(w, r)
+		if !ok {
+			return
+		}
+		h.respondWithJSON(w, r, http.StatusOK, op)
+	}
+}
+
+// HandleBulkItems returns the per-user report of an operation a page at a
+// time. next_after is the ?after to pass for the following page and is
+// left out on the last one.
+func (h *Handler) HandleBulkItems() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op, ok := h.bulkOperation(w, r)
+		if !ok {
+			return
+		}
+
+		after, limit := 0, 100
+		if afterStr := r.URL.Query().Get("after"); afterStr != "" {
+			parsed, err := strconv.Atoi(afterStr)
+			if err != nil || parsed < 0 {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid after parameter"))
+				return
+			}
+			after = parsed
+		}
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsed, err := strconv.Atoi(limitStr)
+			if err != nil || parsed < 1 || parsed > 1000 {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "limit must be between 1 and 1000"))
+				return
+			}
+			limit = parsed
+		}
+
+		items, err := h.bulk.Items(r.Context(), op.ID, after, limit)
+		if err != nil {
+			h.logger.Error("failed to list bulk items", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		resp := map[string]any{"operation": op, "items": items}
+		if len(items) == limit && items[len(items)-1].Position < op.Total {
+			resp["next_after"] = items[len(items)-1].Position
+		}
+		h.respondWithJSON(w, r, http.StatusOK, resp)
+	}
+}
+
+// HandleBulkEvents streams an operation's progress as server-sent events:
+// an "item" event for each user once acted on, in order, a "progress" event
+// whenever the counts change, and a final "done" event with the completed
+// operation. Passing ?after skips items already seen, so a client can
+// resume a dropped stream.
+func (h *Handler) HandleBulkEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op, ok := h.bulkOperation(w, r)
+		if !ok {
+			return
+		}
+
+		after := 0
+		if afterStr := r.URL.Query().Get("after"); afterStr != "" {
+			parsed, err := strconv.Atoi(afterStr)
+			if err != nil || parsed < 0 {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid after parameter"))
+				return
+			}
+			after = parsed
+		}
+
+		ctx := r.Context()
+		stream := sse.NewWriter(w)
+		ticker := time.NewTicker(bulkPollInterval)
+		defer ticker.Stop()
+
+		processed := -1
+		for {
+			if processed != op.Processed {
+				processed = op.Processed
+				if err := stream.Send(sse.Message{Event: "progress", Data: op}); err != nil {
+					return
+				}
+			}
+
+			for after < op.Processed {
+				items, err := h.bulk.Items(ctx, op.ID, after, 100)
+				if err != nil {
+					h.logger.Error("failed to list bulk items", "error", err)
+					return
+				}
+				sent := 0
+				for _, item := range items {
+					if item.Status == BulkPending {
+						break
+					}
+					if err := stream.Send(sse.Message{Event: "item", Data: item}); err != nil {
+						return
+					}
+					after = item.Position
+					sent++
+				}
+				if sent < len(items) || len(items) == 0 {
+					break
+				}
+			}
+
+			if op.Status == BulkCompleted && after >= op.Total {
+				_ = stream.Send(sse.Message{Event: "done", Data: op})
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			next, err := h.bulk.Get(ctx, op.ID)
+			if err != nil {
+				h.logger.Error("failed to get bulk operation", "error", err)
+				return
+			}
+			op = next
+		}
+	}
+}
+
+// bulkOperation loads the operation named in the path, answering the
+// request itself when it cannot
+func (h *Handler) bulkOperation(w http.ResponseWriter, r *http.Request) (*BulkOperation, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_id", "invalid bulk operation ID format"))
+		return nil, false
+	}
+	op, err := h.bulk.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrBulkNotFound) {
+			apierror.Write(w, r, apierror.NotFound("bulk_operation_not_found", "bulk operation not found"))
+			return nil, false
+		}
+		h.logger.Error("failed to get bulk operation", "error", err)
+		apierror.Write(w, r, apierror.Internal())
+		return nil, false
+	}
+	return op, true
+}
+
+// viewerFromRequest returns the caller's email, which decides whether shadow
+// banned content is visible
+func viewerFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
@@ -0,0 +1,329 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/openapi"
+	"starterkit/internal/platform/request"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrHandleNotFound  = errors.New("handle not found")
+	ErrHandleReserved  = errors.New("handle is reserved")
+	ErrHandleTaken     = errors.New("handle is taken")
+	ErrHandleHeld      = errors.New("handle was recently given up by another user and is held for them")
+	ErrHandleUnchanged = errors.New("handle is already the user's handle")
+	ErrHandleCooldown  = errors.New("handle was changed too recently")
+)
+
+const (
+	minHandleLength = 3
+	maxHandleLength = 30
+)
+
+// handlePattern is a letter followed by lowercase letters, digits, or
+// underscores
+var handlePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{2,29}$`)
+
+// reservedHandles could be mistaken for the service, its staff, or its
+// routes. USERS_RESERVED_HANDLES adds to them.
+var reservedHandles = []string{
+	"about", "account", "admin", "administrator", "api", "app", "auth",
+	"billing", "contact", "help", "home", "login", "logout",
+	"me", "mod", "moderator", "null", "official", "owner", "root",
+	"security", "settings", "signin", "signup", "staff", "starterkit",
+	"support", "system", "team", "undefined", "user", "users", "www",
+}
+
+// Reasons a handle is unavailable
+const (
+	HandleInvalid  = "invalid"
+	HandleReserved = "reserved"
+	HandleTaken    = "taken"
+	HandleHeld     = "held"
+)
+
+// HandleRequest sets a user's handle
+type HandleRequest struct {
+	Handle string `json:"handle" doc:"New handle; a leading @ is ignored and letters are lowercased" example:"jane_doe"`
+}
+
+// DescribeSchema adds the length limits that Validate enforces
+func (r *HandleRequest) DescribeSchema(s *openapi.Schema) {
+	s.Properties["handle"].Len(minHandleLength, maxHandleLength)
+	s.AdditionalProperties = false
+}
+
+// Validate normalizes the handle and checks its format
+func (r *HandleRequest) Validate() error {
+	r.Handle = NormalizeHandle(r.Handle)
+
+	var v request.Validation
+	v.Check(handlePattern.MatchString(r.Handle), "handle", fmt.Sprintf("must be %d-%d lowercase letters, digits, or underscores, starting with a letter", minHandleLength, maxHandleLength))
+	return v.Err()
+}
+
+// HandleAvailability reports whether a handle can be taken
+type HandleAvailability struct {
+	Handle    string `json:"handle" doc:"Handle as it would be stored" example:"jane_doe"`
+	Available bool   `json:"available" doc:"Whether the handle can be taken"`
+	Reason    string `json:"reason,omitempty" doc:"Why the handle cannot be taken" enum:"invalid,reserved,taken,held"`
+}
+
+// PreviousHandle is a handle a user gave up, which redirects to them until
+// someone else takes it
+type PreviousHandle struct {
+	Handle     string    `json:"handle" example:"jdoe"`
+	ReleasedAt time.Time `json:"released_at" doc:"When the user changed away from the handle"`
+}
+
+// HandleInfo is a user's handle with its history
+type HandleInfo struct {
+	Handle       *string          `json:"handle" doc:"Current handle, null until one is set" example:"jane_doe"`
+	ChangedAt    *time.Time       `json:"changed_at,omitempty" doc:"When the handle was last set"`
+	ChangeableAt *time.Time       `json:"changeable_at,omitempty" doc:"When the handle can next be changed, omitted if it can be now"`
+	Previous     []PreviousHandle `json:"previous" doc:"Previous handles, most recent first"`
+}
+
+// NormalizeHandle returns raw as it is stored: trimmed, without a leading
+// @, and lowercased
+func NormalizeHandle(raw string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(raw), "@"))
+}
+
+type HandleQuerier interface {
+	GetUserHandle(ctx context.Context, id pgtype.UUID) (db.GetUserHandleRow, error)
+	FindHandle(ctx context.Context, handle pgtype.Text) (db.FindHandleRow, error)
+	ListPreviousHandles(ctx context.Context, userID pgtype.UUID) ([]db.UserHandleHistory, error)
+}
+
+// HandleService manages user handles. Handles are unique, may not be
+// reserved words, and can only be changed once per cooldown. A previous
+// handle keeps redirecting to its user, and is held for them for a while
+// before anyone else may take it.
+type HandleService struct {
+	queries  HandleQuerier
+	tx       Transactor
+	reserved map[string]bool
+	cooldown time.Duration
+	hold     time.Duration
+}
+
+// NewHandleService refuses the built-in reserved words plus reserved
+func NewHandleService(queries HandleQuerier, tx Transactor, reserved []string, cooldown, hold time.Duration) *HandleService {
+	words := make(map[string]bool, len(reservedHandles)+len(reserved))
+	for _, w := range reservedHandles {
+		words[w] = true
+	}
+	for _, w := range reserved {
+		words[NormalizeHandle(w)] = true
+	}
+	return &HandleService{
+		queries:  queries,
+		tx:       tx,
+		reserved: words,
+		cooldown: cooldown,
+		hold:     hold,
+	}
+}
+
+// Availability reports whether anyone could take the handle now. A handle
+// held for its previous owner is reported as held, even to them.
+func (s *HandleService) Availability(ctx context.Context, raw string) (*HandleAvailability, error) {
+	handle := NormalizeHandle(raw)
+	reason, err := s.check(ctx, s.queries, handle, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	return &HandleAvailability{Handle: handle, Available: reason == "", Reason: reason}, nil
+}
+
+// Handle returns the user's handle, when it can next change, and the
+// handles they had before
+func (s *HandleService) Handle(ctx context.Context, id uuid.UUID) (*HandleInfo, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	current, err := s.queries.GetUserHandle(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get handle: %w", err)
+	}
+	previous, err := s.queries.ListPreviousHandles(ctx, pgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list previous handles: %w", err)
+	}
+
+	info := &HandleInfo{Handle: textPtr(current.Handle), Previous: make([]PreviousHandle, len(previous))}
+	if current.HandleChangedAt.Valid {
+		changedAt := current.HandleChangedAt.Time
+		info.ChangedAt = &changedAt
+		if at := changedAt.Add(s.cooldown); at.After(time.Now()) {
+			info.ChangeableAt = &at
+		}
+	}
+	for i, p := range previous {
+		info.Previous[i] = PreviousHandle{Handle: p.Handle, ReleasedAt: p.ReleasedAt.Time}
+	}
+	return info, nil
+}
+
+// SetHandle gives the user a handle, which must have been validated. The
+// previous handle is kept in the user's history, and the change is audited
+// as user.handle_changed.
+func (s *HandleService) SetHandle(ctx context.Context, id uuid.UUID, handle, actor string) (*User, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	var row db.SetUserHandleRow
+	err := s.tx.WithTx(ctx, func(q *db.Queries) error {
+		// Locks the user so concurrent changes cannot both pass the cooldown
+		current, err := q.GetUserHandle(ctx, pgID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("failed to get handle: %w", err)
+		}
+		if current.Handle.Valid && current.Handle.String == handle {
+			return ErrHandleUnchanged
+		}
+		if current.HandleChangedAt.Valid {
+			if at := current.HandleChangedAt.Time.Add(s.cooldown); at.After(time.Now()) {
+				return fmt.Errorf("%w; it can be changed again at %s", ErrHandleCooldown, at.UTC().Format(time.RFC3339))
+			}
+		}
+
+		reason, err := s.check(ctx, q, handle, id)
+		if err != nil {
+			return err
+		}
+		switch reason {
+		case HandleReserved:
+			return ErrHandleReserved
+		case HandleTaken:
+			return ErrHandleTaken
+		case HandleHeld:
+			return ErrHandleHeld
+		}
+
+		// A previous handle being taken stops redirecting to its old owner
+		if err := q.ReleaseHandle(ctx, handle); err != nil {
+			return fmt.Errorf("failed to release handle: %w", err)
+		}
+		row, err = q.SetUserHandle(ctx, db.SetUserHandleParams{
+			ID:     pgID,
+			Handle: pgtype.Text{String: handle, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if current.Handle.Valid {
+			if err := q.RecordPreviousHandle(ctx, db.RecordPreviousHandleParams{
+				Handle: current.Handle.String,
+				UserID: pgID,
+			}); err != nil {
+				return fmt.Errorf("failed to record previous handle: %w", err)
+			}
+		}
+
+		return audit.NewService(q).Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "user.handle_changed",
+			ResourceType: ResourceType,
+			ResourceID:   id.String(),
+			Metadata:     map[string]any{"handle": handle, "previous": current.Handle.String},
+		})
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrHandleUnchanged), errors.Is(err, ErrHandleCooldown),
+			errors.Is(err, ErrHandleReserved), errors.Is(err, ErrHandleTaken), errors.Is(err, ErrHandleHeld):
+			return nil, err
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrUserNotFound
+		case isUniqueViolation(err):
+			// Another user took the handle since it was checked
+			return nil, ErrHandleTaken
+		}
+		return nil, fmt.Errorf("failed to set handle: %w", err)
+	}
+	return toUser(db.UpdateUserRow(row)), nil
+}
+
+// Resolve returns the user a handle belongs to. For a previous handle it
+// also returns the user's current handle, which callers redirect to.
+func (s *HandleService) Resolve(ctx context.Context, raw string) (uuid.UUID, string, error) {
+	handle := NormalizeHandle(raw)
+	if !handlePattern.MatchString(handle) {
+		return uuid.Nil, "", ErrHandleNotFound
+	}
+	row, err := s.queries.FindHandle(ctx, pgtype.Text{String: handle, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, "", ErrHandleNotFound
+		}
+		return uuid.Nil, "", fmt.Errorf("failed to find handle: %w", err)
+	}
+
+	id := uuid.UUID(row.UserID.Bytes)
+	if row.ReleasedAt.Valid && row.CurrentHandle.Valid {
+		return id, row.CurrentHandle.String, nil
+	}
+	return id, "", nil
+}
+
+// Acceptable reports whether a handle is well-formed and not reserved.
+// Unlike Availability it never looks the handle up, so neither its answer
+// nor how long it takes depends on whether the handle is in use.
+func (s *HandleService) Acceptable(raw string) *AcceptableValue {
+	handle := NormalizeHandle(raw)
+	reason := s.format(handle)
+	return &AcceptableValue{Value: handle, Acceptable: reason == "", Reason: reason}
+}
+
+// format returns why the handle cannot be taken by anyone, or "" if its
+// format allows it
+func (s *HandleService) format(handle string) string {
+	if !handlePattern.MatchString(handle) {
+		return HandleInvalid
+	}
+	if s.reserved[handle] {
+		return HandleReserved
+	}
+	return ""
+}
+
+// check returns why the handle cannot be taken by the user, or "" if it
+// can. A user may take back their own previous handle at any time.
+func (s *HandleService) check(ctx context.Context, q HandleQuerier, handle string, userID uuid.UUID) (string, error) {
+	if reason := s.format(handle); reason != "" {
+		return reason, nil
+	}
+
+	owner, err := q.FindHandle(ctx, pgtype.Text{String: handle, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to find handle: %w", err)
+	}
+	switch {
+	case uuid.UUID(owner.UserID.Bytes) == userID:
+		return "", nil
+	case !owner.ReleasedAt.Valid:
+		return HandleTaken, nil
+	case time.Since(owner.ReleasedAt.Time) < s.hold:
+		return HandleHeld, nil
+	}
+	return "", nil
+}
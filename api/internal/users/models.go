@@ -1,15 +1,160 @@
 package users
 
 import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	"starterkit/internal/openapi"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/request"
 
 	"github.com/google/uuid"
 )
 
+// maxEmailLength is the size of the users.email column
+const maxEmailLength = 255
+
 type User struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            uuid.UUID `json:"id" doc:"User's unique identifier" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Email         string    `json:"email" doc:"User's email address, masked unless the caller is an admin or the user" format:"email" example:"user@example.com" access:"self,admin" mask:"email"`
+	Name          string    `json:"name" doc:"User's full name" example:"John Doe"`
+	Handle        *string   `json:"handle,omitempty" doc:"Unique public handle, omitted until one is set" example:"john_doe"`
+	Bio           string    `json:"bio" doc:"Free-text profile bio, subject to content moderation"`
+	PendingEmail  *string   `json:"pending_email,omitempty" doc:"Address the email is being changed to, until both addresses confirm; masked like email" format:"email" access:"self,admin" mask:"email"`
+	Phone         *string   `json:"phone,omitempty" doc:"Phone number in E.164 format, masked to its last four digits unless the caller is an admin or the user" example:"+14155552671" access:"self,admin" mask:"last4"`
+	PhoneVerified bool      `json:"phone_verified" doc:"Whether the phone number has been verified by SMS"`
+	CreatedAt     time.Time `json:"created_at" doc:"Timestamp when the user was created" example:"2024-01-01T00:00:00Z"`
+	UpdatedAt     time.Time `json:"updated_at" doc:"Timestamp when the user was last updated" example:"2024-01-01T00:00:00Z"`
+	// Expanded holds the related resources named in ?expand
+	Expanded map[string]any `json:"expanded,omitempty" doc:"Related resources requested with the expand parameter, keyed by relation"`
+}
+
+// SetExpanded embeds a related resource
+func (u *User) SetExpanded(relation string, v any) {
+	if u.Expanded == nil {
+		u.Expanded = make(map[string]any)
+	}
+	u.Expanded[relation] = v
+}
+
+// OwnedBy reports whether p is the user, which lets them see their own
+// restricted fields
+func (u User) OwnedBy(p *auth.Principal) bool {
+	return p.Email != "" && strings.EqualFold(p.Email, u.Email)
+}
+
+// UserList is a page of users
+type UserList struct {
+	Users  []*User  `json:"users" doc:"List of users"`
+	Limit  int      `json:"limit" doc:"Number of users returned" example:"20"`
+	Offset int      `json:"offset" doc:"Number of users skipped" example:"0"`
+	Meta   ListMeta `json:"meta" doc:"How current the list is"`
+}
+
+// ListMeta describes the read model a list was served from and how far it
+// trails writes
+type ListMeta struct {
+	Source string     `json:"source" doc:"Read model the list was served from" enum:"user_summaries,user_search"`
+	Lag    int64      `json:"lag" doc:"User changes recorded but not yet reflected in the list; 0 when it is current" example:"0"`
+	AsOf   *time.Time `json:"as_of,omitempty" doc:"When the read model last caught up, omitted before it first has"`
+}
+
+// UserRequest holds every writable field of a user. Create and full
+// updates take the same fields.
+type UserRequest struct {
+	Email string `json:"email" doc:"Email address, stored lowercased" format:"email"`
+	Name  string `json:"name" doc:"Display name"`
+	Bio   string `json:"bio,omitempty" doc:"Profile bio; an empty value clears it"`
+}
+
+// DescribeSchema adds the length limits that Validate enforces
+func (r *UserRequest) DescribeSchema(s *openapi.Schema) {
+	s.Properties["email"].Len(0, maxEmailLength)
+	s.Properties["name"].Len(1, maxNameLength)
+	s.Properties["bio"].Len(0, maxBioLength)
+	s.AdditionalProperties = false
+}
+
+// Validate trims the fields, lowercases the email, and checks each field
+func (r *UserRequest) Validate() error {
+	r.Email = strings.ToLower(strings.TrimSpace(r.Email))
+	r.Name = strings.TrimSpace(r.Name)
+	r.Bio = strings.TrimSpace(r.Bio)
+
+	var v request.Validation
+	addr, err := mail.ParseAddress(r.Email)
+	v.Check(err == nil && addr.Address == r.Email, "email", "must be a valid email address")
+	v.Check(len(r.Email) <= maxEmailLength, "email", fmt.Sprintf("must be at most %d characters", maxEmailLength))
+	v.Check(r.Name != "" && utf8.RuneCountInString(r.Name) <= maxNameLength, "name", fmt.Sprintf("must be 1-%d characters", maxNameLength))
+	v.Check(utf8.RuneCountInString(r.Bio) <= maxBioLength, "bio", fmt.Sprintf("must be at most %d characters", maxBioLength))
+	return v.Err()
+}
+
+// EventUserChanged is published on the event bus for every captured user mutation
+const EventUserChanged = "user.changed"
+
+// Change is a captured mutation of a user row. The rows are decoded into
+// users, so restricted fields are stripped or masked as on every other user
+// response.
+type Change struct {
+	Cursor    string    `json:"cursor" doc:"Position of this change in the change log"`
+	UserID    uuid.UUID `json:"user_id" doc:"ID of the changed user"`
+	Operation string    `json:"operation" doc:"Type of mutation" enum:"INSERT,UPDATE,DELETE"`
+	Old       *User     `json:"old" doc:"User before the change, null for inserts"`
+	New       *User     `json:"new" doc:"User after the change, null for deletes"`
+	ChangedAt time.Time `json:"changed_at" doc:"Timestamp of the change"`
+	// shadowBanned is set when the user was shadow banned before or after
+	// the change
+	shadowBanned bool
+}
+
+// VisibleTo reports whether viewer, the caller's email, may see the change.
+// Changes of shadow banned users are only visible to themselves, as they
+// are in lists.
+func (c *Change) VisibleTo(viewer string) bool {
+	if !c.shadowBanned {
+		return true
+	}
+	return (c.Old != nil && c.Old.Email == viewer) || (c.New != nil && c.New.Email == viewer)
+}
+
+// ChangePosition is a place in the change log. IDs are taken when a change
+// is recorded rather than when it commits, so the log is read in order of
+// the recording transaction and then ID, and only up to the oldest
+// transaction still running: no change can later commit before a position
+// a reader has passed.
+type ChangePosition struct {
+	Txid int64
+	ID   int64
+}
+
+// ParseChangePosition parses a position formatted by String
+func ParseChangePosition(s string) (ChangePosition, error) {
+	txid, id, ok := strings.Cut(s, "-")
+	if !ok {
+		return ChangePosition{}, ErrInvalidCursor
+	}
+	var p ChangePosition
+	var err error
+	if p.Txid, err = strconv.ParseInt(txid, 10, 64); err != nil || p.Txid < 0 {
+		return ChangePosition{}, ErrInvalidCursor
+	}
+	if p.ID, err = strconv.ParseInt(id, 10, 64); err != nil || p.ID < 0 {
+		return ChangePosition{}, ErrInvalidCursor
+	}
+	return p, nil
+}
+
+func (p ChangePosition) String() string {
+	return strconv.FormatInt(p.Txid, 10) + "-" + strconv.FormatInt(p.ID, 10)
+}
+
+// ChangeList is a page of changes after a cursor
+type ChangeList struct {
+	Changes    []*Change `json:"changes"`
+	NextCursor string    `json:"next_cursor" doc:"Cursor to pass as since on the next call"`
 }
@@ -0,0 +1,392 @@
+package users
+
+import (
+	"net/http"
+	"time"
+
+	"starterkit/internal/openapi"
+	"starterkit/internal/platform/dryrun"
+	"starterkit/internal/platform/jsonpatch"
+	"starterkit/internal/workflow"
+
+	"github.com/google/uuid"
+)
+
+// Examples in the document, which mock mode serves
+var (
+	exampleHandle = "ada"
+	exampleUser   = User{
+		ID:        uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+		Email:     "ada@example.com",
+		Name:      "Ada Lovelace",
+		Handle:    &exampleHandle,
+		Bio:       "Writes programs for the Analytical Engine.",
+		CreatedAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 3, 1, 17, 30, 0, 0, time.UTC),
+	}
+	exampleUserRequest = UserRequest{Email: "ada@example.com", Name: "Ada Lovelace", Bio: "Writes programs for the Analytical Engine."}
+)
+
+// Describe registers the user endpoints in the OpenAPI document
+func Describe(api *openapi.Registry) {
+	api.Define("UserChange", Change{})
+	api.Define("DryRunChange", dryrun.Change{})
+	api.Define("DryRunPreview", dryrun.Preview{})
+	api.Define("JSONPatchOperation", jsonpatch.Op{})
+
+	tags := []string{"Users"}
+	userID := openapi.PathParam("id", "User UUID", openapi.String("uuid"))
+	user := []openapi.Response{{Status: http.StatusOK, Description: "Updated user", Body: User{}, Example: exampleUser}}
+	internal := openapi.Problem(http.StatusInternalServerError, "Internal server error")
+	notFound := openapi.Problem(http.StatusNotFound, "User not found")
+	tooLarge := openapi.Problem(http.StatusRequestEntityTooLarge, "Request body too large")
+	dryRun := openapi.QueryParam("dry_run", "Run every check and report what would change without changing anything. X-Dry-Run: true does the same.", &openapi.Schema{Type: "boolean", Default: false})
+	preview := openapi.Response{Status: http.StatusOK, Description: "Dry run: the fields that would change and the response that would be returned", Body: dryrun.Preview{}}
+	expand := openapi.QueryParam("expand", "Comma-separated relations to embed under expanded: preferences, and sessions when session tracking is on. They are only embedded in the caller's own user, or in any user for admins.", openapi.String(""))
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users",
+		ID:          "listUsers",
+		Summary:     "List users",
+		Description: "Returns a paginated list of users, read from a projection that may trail recent edits by a few seconds. The meta object reports by how much.",
+		Tags:        tags,
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("limit", "Number of users to return (max 100)", openapi.Integer(1, 100, 20)),
+			openapi.QueryParam("offset", "Number of users to skip", &openapi.Schema{Type: "integer", Minimum: new(float64), Default: 0}),
+			openapi.QueryParam("q", "Only return users whose name or email contains every word. Searches a read model that may trail recent edits by a few seconds.", openapi.String("")),
+			expand,
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Successful response", Body: UserList{}, Example: UserList{
+				Users: []*User{&exampleUser},
+				Limit: 20,
+				Meta:  ListMeta{Source: "user_summaries"},
+			}},
+			openapi.Problem(http.StatusBadRequest, "Invalid parameters"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:         http.MethodPost,
+		Path:           "/api/v1/users",
+		ID:             "createUser",
+		Summary:        "Create user",
+		Description:    "Creates a user. Name and bio pass through content moderation.",
+		Tags:           tags,
+		Parameters:     []openapi.Parameter{dryRun},
+		Request:        UserRequest{},
+		RequestExample: exampleUserRequest,
+		Responses: []openapi.Response{
+			{Status: http.StatusCreated, Description: "User created", Body: User{}, Example: exampleUser},
+			preview,
+			openapi.Problem(http.StatusBadRequest, "Malformed request body"),
+			openapi.Problem(http.StatusConflict, "Email already in use"),
+			tooLarge,
+			openapi.Problem(http.StatusUnprocessableEntity, "Validation failed or content rejected by moderation"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/changes",
+		ID:          "listUserChanges",
+		Summary:     "List user changes",
+		Description: "Returns captured user mutations, with the user before and after each, after a cursor, for downstream sync. Restricted fields are stripped or masked as on other user responses, and changes of shadow banned users are only returned to themselves.",
+		Tags:        tags,
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("since", "Cursor returned as next_cursor by the previous call; omit to start from the beginning", openapi.String("")),
+			openapi.QueryParam("limit", "Maximum number of changes to return (max 1000)", openapi.Integer(1, 1000, 100)),
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Successful response", Body: ChangeList{}},
+			openapi.Problem(http.StatusBadRequest, "Invalid parameters"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodPost,
+		Path:        "/auth/availability",
+		ID:          "checkAvailability",
+		Summary:     "Check email and handle",
+		Description: "Reports whether an email or handle is well-formed and not reserved, for sign-up forms. Whether it is already in use is never revealed, so the answer is the same for free and taken values. Each client address is limited to USERS_AVAILABILITY_RATE_LIMIT checks a minute.",
+		Tags:        tags,
+		Request:     AvailabilityRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Whether each value is acceptable", Body: AvailabilityCheck{}},
+			openapi.Problem(http.StatusBadRequest, "Malformed request body"),
+			tooLarge,
+			openapi.Problem(http.StatusUnprocessableEntity, "Neither an email nor a handle was given"),
+			openapi.Problem(http.StatusTooManyRequests, "Too many checks from this address"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/handle-availability",
+		ID:          "checkUserHandle",
+		Summary:     "Check handle availability",
+		Description: "Reports whether a handle can be taken, and if not whether it is invalid, reserved, taken, or held for the user who gave it up",
+		Tags:        tags,
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("handle", "Handle to check", openapi.String("")),
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Availability of the handle", Body: HandleAvailability{}},
+			openapi.Problem(http.StatusBadRequest, "Missing handle"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/by-handle/{handle}",
+		ID:          "getUserByHandle",
+		Summary:     "Get user by handle",
+		Description: "Returns the user with a handle. A previous handle redirects to the user's current one until someone else takes it.",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{openapi.PathParam("handle", "Handle, with or without a leading @", openapi.String("")), expand},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "User found", Body: User{}},
+			{Status: http.StatusFound, Description: "Previous handle; Location names the current one"},
+			openapi.Problem(http.StatusBadRequest, "Invalid expand parameter"),
+			notFound,
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/by-external-id/{system}/{id}",
+		ID:          "getUserByExternalId",
+		Summary:     "Get user by external ID",
+		Description: "Returns the user mapped to their ID in a system being migrated from, for clients that still hold the old ID.",
+		Tags:        tags,
+		Parameters: []openapi.Parameter{
+			openapi.PathParam("system", "System the ID comes from", openapi.String("")),
+			openapi.PathParam("id", "User's ID in that system", openapi.String("")),
+			expand,
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "User found", Body: User{}},
+			openapi.Problem(http.StatusBadRequest, "Invalid expand parameter"),
+			notFound,
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/{id}",
+		ID:          "getUserById",
+		Summary:     "Get user by ID",
+		Description: "Returns a single user by their UUID",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID, expand},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "User found", Body: User{}, Example: exampleUser},
+			openapi.Problem(http.StatusBadRequest, "Invalid user ID format or expand parameter"),
+			notFound,
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:         http.MethodPut,
+		Path:           "/api/v1/users/{id}",
+		ID:             "updateUser",
+		Summary:        "Replace user",
+		Description:    "Replaces every writable field of a user. The email must be the user's current one: it only changes through POST /api/v1/users/{id}/email-change, and any other value is refused with 422 email_change_required. Changed name and bio pass through content moderation. A JSON Patch (application/json-patch+json) on /name and /bio changes only what it names, applied atomically and audited field by field.",
+		Tags:           tags,
+		Parameters:     []openapi.Parameter{userID, dryRun},
+		Request:        UserRequest{},
+		RequestExample: exampleUserRequest,
+		Requests:       map[string]any{jsonpatch.MediaType: jsonpatch.Patch{}},
+		Responses: append(user,
+			preview,
+			openapi.Problem(http.StatusBadRequest, "Invalid user ID or malformed request body"),
+			notFound,
+			openapi.Problem(http.StatusConflict, "Email already in use, a patch test failed, or the user changed while the patch was applied"),
+			tooLarge,
+			openapi.Problem(http.StatusUnprocessableEntity, "Validation failed, email changed outside the email change flow, patch that cannot be applied, or content rejected by moderation"),
+			internal,
+		),
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/users/{id}",
+		ID:          "deleteUser",
+		Summary:     "Delete user",
+		Description: "Starts account deletion. The account is deleted by a workflow once the deletion grace period has passed.",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID, dryRun},
+		Responses: []openapi.Response{
+			{Status: http.StatusAccepted, Description: "Deletion started", Body: workflow.Workflow{}},
+			preview,
+			openapi.Problem(http.StatusBadRequest, "Invalid user ID format"),
+			notFound,
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodPatch,
+		Path:        "/api/v1/users/{id}/profile",
+		ID:          "updateUserProfile",
+		Summary:     "Update profile",
+		Description: "Updates the user-generated profile fields. Changed fields pass through content moderation, which may reject them, mask terms, or queue them for review. A JSON Patch (application/json-patch+json) may change /name and /bio.",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Request:     UpdateProfileRequest{},
+		Requests:    map[string]any{jsonpatch.MediaType: jsonpatch.Patch{}},
+		Responses: append(user,
+			openapi.Problem(http.StatusBadRequest, "Invalid request"),
+			notFound,
+			openapi.Problem(http.StatusConflict, "A patch test failed or the user changed while the patch was applied"),
+			openapi.Problem(http.StatusUnprocessableEntity, "Invalid profile, patch that cannot be applied, or content rejected by moderation"),
+			internal,
+		),
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/{id}/handle",
+		ID:          "getUserHandle",
+		Summary:     "Get handle",
+		Description: "Returns the user's handle, when it can next be changed, and the handles they had before",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Handle and history", Body: HandleInfo{}},
+			openapi.Problem(http.StatusBadRequest, "Invalid user ID format"),
+			notFound,
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodPut,
+		Path:        "/api/v1/users/{id}/handle",
+		ID:          "setUserHandle",
+		Summary:     "Set handle",
+		Description: "Sets or changes the user's handle. Handles can change once per cooldown; the previous handle redirects to the user and is held for them for a while.",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Request:     HandleRequest{},
+		Responses: append(user,
+			openapi.Problem(http.StatusBadRequest, "Invalid user ID or malformed request body"),
+			notFound,
+			openapi.Problem(http.StatusConflict, "Handle taken, held, unchanged, or changed too recently"),
+			openapi.Problem(http.StatusUnprocessableEntity, "Invalid or reserved handle"),
+			internal,
+		),
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/{id}/email-change",
+		ID:          "getEmailChange",
+		Summary:     "Get pending email change",
+		Description: "Returns the user's pending email change and which addresses have confirmed it",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Pending email change", Body: EmailChange{}},
+			openapi.Problem(http.StatusBadRequest, "Invalid user ID format"),
+			openapi.Problem(http.StatusNotFound, "No email change is pending"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodPost,
+		Path:        "/api/v1/users/{id}/email-change",
+		ID:          "requestEmailChange",
+		Summary:     "Request email change",
+		Description: "Emails confirmation links to the user's current address and to the new one, which is kept as the user's pending email. The email changes once both links are used, and the old address is then notified. A new request replaces a pending one.",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Request:     EmailChangeRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusAccepted, Description: "Confirmation links sent", Body: EmailChange{}},
+			openapi.Problem(http.StatusBadRequest, "Invalid user ID or malformed request body"),
+			notFound,
+			openapi.Problem(http.StatusConflict, "Email unchanged or used by another user"),
+			openapi.Problem(http.StatusUnprocessableEntity, "Invalid email"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/users/{id}/email-change",
+		ID:          "cancelEmailChange",
+		Summary:     "Cancel email change",
+		Description: "Drops the pending email change; its links stop working",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Responses: []openapi.Response{
+			{Status: http.StatusNoContent, Description: "Email change cancelled"},
+			openapi.Problem(http.StatusBadRequest, "Invalid user ID format"),
+			openapi.Problem(http.StatusNotFound, "No email change is pending"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodPut,
+		Path:        "/api/v1/users/{id}/phone",
+		ID:          "setUserPhone",
+		Summary:     "Set phone number",
+		Description: "Normalizes the number to E.164 and stores it on the profile. Changing the number resets verification; an empty value removes it.",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Request:     PhoneRequest{},
+		Responses: append(user,
+			openapi.Problem(http.StatusBadRequest, "Invalid user ID format or malformed request body"),
+			notFound,
+			openapi.Problem(http.StatusUnprocessableEntity, "Invalid phone number"),
+			internal,
+		),
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodPost,
+		Path:        "/api/v1/users/{id}/phone/verification",
+		ID:          "startPhoneVerification",
+		Summary:     "Send phone verification code",
+		Description: "Sends a one-time code by SMS to the user's phone number",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Responses: []openapi.Response{
+			{Status: http.StatusAccepted, Description: "Code sent", Body: PhoneVerification{}},
+			openapi.Problem(http.StatusBadRequest, "Invalid user ID format"),
+			notFound,
+			openapi.Problem(http.StatusConflict, "Phone number not set, already verified, or cannot receive SMS"),
+			openapi.Problem(http.StatusTooManyRequests, "A code was requested too recently"),
+			internal,
+		},
+	})
+
+	api.Add(openapi.Operation{
+		Method:      http.MethodPost,
+		Path:        "/api/v1/users/{id}/phone/verification/confirm",
+		ID:          "confirmPhoneVerification",
+		Summary:     "Confirm phone verification",
+		Description: "Checks the code sent by SMS and marks the phone number as verified",
+		Tags:        tags,
+		Parameters:  []openapi.Parameter{userID},
+		Request:     VerificationCode{},
+		Responses: append(user,
+			openapi.Problem(http.StatusBadRequest, "Invalid user ID format or missing code"),
+			openapi.Problem(http.StatusUnprocessableEntity, "Code is invalid or expired, or no verification is pending"),
+			openapi.Problem(http.StatusTooManyRequests, "Too many failed attempts; request a new code"),
+			internal,
+		),
+	})
+}
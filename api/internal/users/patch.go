@@ -0,0 +1,123 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/jsonpatch"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Paths a JSON Patch may touch on PUT /users/{id} and on the profile, as
+// pointers into UserRequest. The email is left out: it only changes through
+// the confirmed email change.
+var (
+	UserPatchPaths    = []string{"/name", "/bio"}
+	ProfilePatchPaths = []string{"/name", "/bio"}
+)
+
+// FieldChange is one field changed by a patch, as recorded in the audit log
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PatchUser applies a JSON Patch to the name and bio of a user.
+// The result is validated and its changed fields moderated as UpdateUser
+// does. It is stored only if the user is unchanged since the patch was
+// applied, checked under a row lock, and ErrConcurrentUpdate is returned
+// otherwise. The audit entry records each field changed.
+func (s *Service) PatchUser(ctx context.Context, id uuid.UUID, patch jsonpatch.Patch, actor string) (*User, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	current, err := s.queries.GetUserByID(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Test operations compare against the stored values, before Validate
+	// normalizes them
+	before := UserRequest{Email: current.Email, Name: current.Name, Bio: current.Bio}
+	req := before
+	if err := patch.ApplyTo(&req); err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]string{}
+	if req.Name != before.Name {
+		fields["name"] = req.Name
+	}
+	if req.Bio != before.Bio {
+		fields["bio"] = req.Bio
+	}
+	screened, err := s.moderator.Screen(ctx, ResourceType, id.String(), actor, fields)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := screened["name"]; ok {
+		req.Name = v
+	}
+	if v, ok := screened["bio"]; ok {
+		req.Bio = v
+	}
+
+	changes := map[string]FieldChange{}
+	for field, values := range map[string][2]string{
+		"name": {before.Name, req.Name},
+		"bio":  {before.Bio, req.Bio},
+	} {
+		if values[0] != values[1] {
+			changes[field] = FieldChange{From: values[0], To: values[1]}
+		}
+	}
+
+	var row db.UpdateUserRow
+	err = s.tx.WithTx(ctx, func(q *db.Queries) error {
+		locked, err := q.LockUserForUpdate(ctx, pgID)
+		if err != nil {
+			return err
+		}
+		if locked.Email != before.Email || locked.Name != before.Name || locked.Bio != before.Bio {
+			return ErrConcurrentUpdate
+		}
+		row, err = q.UpdateUser(ctx, db.UpdateUserParams{
+			ID:    pgID,
+			Email: before.Email,
+			Name:  req.Name,
+			Bio:   req.Bio,
+		})
+		if err != nil {
+			return err
+		}
+		return audit.NewService(q).Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "user.patched",
+			ResourceType: ResourceType,
+			ResourceID:   id.String(),
+			Metadata:     map[string]any{"changes": changes, "operations": len(patch)},
+		})
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrUserNotFound
+		case errors.Is(err, ErrConcurrentUpdate):
+			return nil, err
+		case isUniqueViolation(err):
+			return nil, ErrEmailConflict
+		}
+		return nil, fmt.Errorf("failed to patch user: %w", err)
+	}
+	return toUser(row), nil
+}
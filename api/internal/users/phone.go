@@ -0,0 +1,256 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/auth"
+	"starterkit/internal/platform/phone"
+	"starterkit/internal/platform/sms"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrInvalidPhone            = errors.New("invalid phone number")
+	ErrPhoneNotSet             = errors.New("phone number not set")
+	ErrPhoneNotSMSCapable      = errors.New("phone number cannot receive SMS")
+	ErrPhoneAlreadyVerified    = errors.New("phone number already verified")
+	ErrPhoneNotVerified        = errors.New("phone number not verified")
+	ErrVerificationNotFound    = errors.New("no pending phone verification")
+	ErrVerificationExpired     = errors.New("phone verification expired")
+	ErrVerificationCodeInvalid = errors.New("invalid verification code")
+	ErrTooManyAttempts         = errors.New("too many verification attempts")
+	ErrVerificationRateLimited = errors.New("verification code requested too recently")
+)
+
+// verificationResendInterval is the minimum time between verification SMS
+const verificationResendInterval = 30 * time.Second
+
+// PhoneRequest sets or removes a user's phone number
+type PhoneRequest struct {
+	Phone string `json:"phone" doc:"Phone number; numbers without a country code use the configured default region" example:"(415) 555-2671"`
+}
+
+// PhoneVerification is a verification code on its way by SMS
+type PhoneVerification struct {
+	Phone     string    `json:"phone" doc:"Masked destination number" example:"+1********71"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// VerificationCode confirms a phone number
+type VerificationCode struct {
+	Code string `json:"code" doc:"Code received by SMS" example:"123456"`
+}
+
+type PhoneQuerier interface {
+	GetUserByID(ctx context.Context, id pgtype.UUID) (db.GetUserByIDRow, error)
+	UpdateUserPhone(ctx context.Context, arg db.UpdateUserPhoneParams) (db.UpdateUserPhoneRow, error)
+	MarkUserPhoneVerified(ctx context.Context, arg db.MarkUserPhoneVerifiedParams) (int64, error)
+	UpsertPhoneVerification(ctx context.Context, arg db.UpsertPhoneVerificationParams) error
+	GetPhoneVerification(ctx context.Context, userID pgtype.UUID) (db.PhoneVerification, error)
+	IncrementPhoneVerificationAttempts(ctx context.Context, userID pgtype.UUID) error
+	DeletePhoneVerification(ctx context.Context, userID pgtype.UUID) error
+}
+
+// PhoneService manages profile phone numbers and their SMS verification.
+// A verified number is what the 2FA SMS fallback delivers codes to.
+type PhoneService struct {
+	queries       PhoneQuerier
+	sender        sms.Sender
+	defaultRegion string
+	ttl           time.Duration
+	maxAttempts   int
+}
+
+func NewPhoneService(queries PhoneQuerier, sender sms.Sender, defaultRegion string, ttl time.Duration, maxAttempts int) *PhoneService {
+	return &PhoneService{
+		queries:       queries,
+		sender:        sender,
+		defaultRegion: defaultRegion,
+		ttl:           ttl,
+		maxAttempts:   maxAttempts,
+	}
+}
+
+// SetPhone normalizes raw to E.164 and stores it on the profile, resetting
+// verification. An empty raw value removes the phone number.
+func (s *PhoneService) SetPhone(ctx context.Context, id uuid.UUID, raw string) (*User, error) {
+	number := pgtype.Text{}
+	if raw != "" {
+		normalized, err := phone.Normalize(raw, s.defaultRegion)
+		if err != nil {
+			return nil, ErrInvalidPhone
+		}
+		number = pgtype.Text{String: normalized, Valid: true}
+	}
+
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	row, err := s.queries.UpdateUserPhone(ctx, db.UpdateUserPhoneParams{
+		ID:    pgID,
+		Phone: number,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to update phone: %w", err)
+	}
+
+	// Any code sent to the previous number is no longer valid
+	if err := s.queries.DeletePhoneVerification(ctx, pgID); err != nil {
+		return nil, fmt.Errorf("failed to clear phone verification: %w", err)
+	}
+
+	return &User{
+		ID:            uuid.UUID(row.ID.Bytes),
+		Email:         row.Email,
+		Name:          row.Name,
+		Handle:        textPtr(row.Handle),
+		PendingEmail:  textPtr(row.PendingEmail),
+		Bio:           row.Bio,
+		Phone:         textPtr(row.Phone),
+		PhoneVerified: row.PhoneVerifiedAt.Valid,
+		CreatedAt:     row.CreatedAt.Time,
+		UpdatedAt:     row.UpdatedAt.Time,
+	}, nil
+}
+
+// StartVerification sends a one-time code to the user's phone number and
+// returns the masked number and code expiry
+func (s *PhoneService) StartVerification(ctx context.Context, id uuid.UUID) (string, time.Time, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	user, err := s.queries.GetUserByID(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", time.Time{}, ErrUserNotFound
+		}
+		return "", time.Time{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.Phone.Valid {
+		return "", time.Time{}, ErrPhoneNotSet
+	}
+	if user.PhoneVerifiedAt.Valid {
+		return "", time.Time{}, ErrPhoneAlreadyVerified
+	}
+	if !phone.CanReceiveSMS(user.Phone.String) {
+		return "", time.Time{}, ErrPhoneNotSMSCapable
+	}
+
+	// Throttle resends so codes cannot be requested in a loop
+	pending, err := s.queries.GetPhoneVerification(ctx, pgID)
+	if err == nil && time.Since(pending.CreatedAt.Time) < verificationResendInterval {
+		return "", time.Time{}, ErrVerificationRateLimited
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", time.Time{}, fmt.Errorf("failed to get phone verification: %w", err)
+	}
+
+	code, err := generateCode()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	expiresAt := time.Now().Add(s.ttl)
+
+	if err := s.queries.UpsertPhoneVerification(ctx, db.UpsertPhoneVerificationParams{
+		UserID:    pgID,
+		Phone:     user.Phone.String,
+		CodeHash:  hashCode(id, code),
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	}); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store phone verification: %w", err)
+	}
+
+	body := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(s.ttl.Minutes()))
+	if err := s.sender.Send(ctx, user.Phone.String, body); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to send verification code: %w", err)
+	}
+
+	return phone.Mask(user.Phone.String), expiresAt, nil
+}
+
+// ConfirmVerification checks a code sent by StartVerification and marks the
+// phone number as verified
+func (s *PhoneService) ConfirmVerification(ctx context.Context, id uuid.UUID, code string) error {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	pending, err := s.queries.GetPhoneVerification(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrVerificationNotFound
+		}
+		return fmt.Errorf("failed to get phone verification: %w", err)
+	}
+
+	if time.Now().After(pending.ExpiresAt.Time) {
+		if err := s.queries.DeletePhoneVerification(ctx, pgID); err != nil {
+			return fmt.Errorf("failed to clear phone verification: %w", err)
+		}
+		return ErrVerificationExpired
+	}
+	if int(pending.Attempts) >= s.maxAttempts {
+		return ErrTooManyAttempts
+	}
+
+	if !auth.Equal(hashCode(id, code), pending.CodeHash) {
+		if err := s.queries.IncrementPhoneVerificationAttempts(ctx, pgID); err != nil {
+			return fmt.Errorf("failed to record verification attempt: %w", err)
+		}
+		return ErrVerificationCodeInvalid
+	}
+
+	// The update only matches if the number has not changed since the code was sent
+	rows, err := s.queries.MarkUserPhoneVerified(ctx, db.MarkUserPhoneVerifiedParams{
+		ID:    pgID,
+		Phone: pgtype.Text{String: pending.Phone, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark phone verified: %w", err)
+	}
+	if err := s.queries.DeletePhoneVerification(ctx, pgID); err != nil {
+		return fmt.Errorf("failed to clear phone verification: %w", err)
+	}
+	if rows == 0 {
+		return ErrVerificationNotFound
+	}
+	return nil
+}
+
+// VerifiedPhone returns the user's phone number only if it has been
+// verified, for use as a second-factor delivery channel
+func (s *PhoneService) VerifiedPhone(ctx context.Context, id uuid.UUID) (string, error) {
+	user, err := s.queries.GetUserByID(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.Phone.Valid || !user.PhoneVerifiedAt.Valid {
+		return "", ErrPhoneNotVerified
+	}
+	return user.Phone.String, nil
+}
+
+// generateCode returns a random 6-digit code
+func generateCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashCode binds a code to its user so stored hashes cannot be reused across accounts
+func hashCode(id uuid.UUID, code string) string {
+	sum := sha256.Sum256([]byte(id.String() + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
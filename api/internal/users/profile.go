@@ -0,0 +1,175 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"starterkit/internal/db"
+	"starterkit/internal/openapi"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrInvalidProfile = errors.New("invalid profile")
+
+const (
+	maxNameLength = 100
+	maxBioLength  = 1000
+
+	// removedName replaces a display name taken down by a moderator
+	removedName = "[removed]"
+)
+
+// ResourceType identifies users in moderation flags and audit entries
+const ResourceType = "user"
+
+type ProfileQuerier interface {
+	GetUserByID(ctx context.Context, id pgtype.UUID) (db.GetUserByIDRow, error)
+	UpdateUserProfile(ctx context.Context, arg db.UpdateUserProfileParams) (db.UpdateUserProfileRow, error)
+}
+
+// ContentModerator screens user-generated fields before they are stored
+type ContentModerator interface {
+	Screen(ctx context.Context, resourceType, resourceID, actor string, fields map[string]string) (map[string]string, error)
+}
+
+// UpdateProfileRequest holds editable profile fields; nil fields are unchanged
+type UpdateProfileRequest struct {
+	Name *string `json:"name" doc:"Display name"`
+	Bio  *string `json:"bio" doc:"Profile bio; an empty value clears it"`
+}
+
+// DescribeSchema adds the length limits that UpdateProfile enforces
+func (r *UpdateProfileRequest) DescribeSchema(s *openapi.Schema) {
+	s.Properties["name"].Len(1, maxNameLength)
+	s.Properties["bio"].Len(0, maxBioLength)
+}
+
+// ProfileService edits the user-generated parts of a profile
+type ProfileService struct {
+	queries   ProfileQuerier
+	moderator ContentModerator
+}
+
+func NewProfileService(queries ProfileQuerier, moderator ContentModerator) *ProfileService {
+	return &ProfileService{
+		queries:   queries,
+		moderator: moderator,
+	}
+}
+
+// UpdateProfile moderates and stores the given profile fields
+func (s *ProfileService) UpdateProfile(ctx context.Context, id uuid.UUID, req UpdateProfileRequest, actor string) (*User, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	current, err := s.queries.GetUserByID(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Only changed fields are screened so unrelated edits do not re-flag old content
+	fields := map[string]string{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+			return nil, fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidProfile, maxNameLength)
+		}
+		if name != current.Name {
+			fields["name"] = name
+		}
+	}
+	if req.Bio != nil {
+		bio := strings.TrimSpace(*req.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			return nil, fmt.Errorf("%w: bio must be at most %d characters", ErrInvalidProfile, maxBioLength)
+		}
+		if bio != current.Bio {
+			fields["bio"] = bio
+		}
+	}
+
+	screened, err := s.moderator.Screen(ctx, ResourceType, id.String(), actor, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	name, bio := current.Name, current.Bio
+	if v, ok := screened["name"]; ok {
+		name = v
+	}
+	if v, ok := screened["bio"]; ok {
+		bio = v
+	}
+	return s.update(ctx, pgID, name, bio)
+}
+
+// RemoveContent takes down a moderated profile field, unless it has been
+// edited since it was flagged
+func (s *ProfileService) RemoveContent(ctx context.Context, resourceID, field, content string) error {
+	id, err := uuid.Parse(resourceID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID %q: %w", resourceID, err)
+	}
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	current, err := s.queries.GetUserByID(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Nothing left to take down
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	name, bio := current.Name, current.Bio
+	switch field {
+	case "name":
+		if name != content {
+			return nil
+		}
+		name = removedName
+	case "bio":
+		if bio != content {
+			return nil
+		}
+		bio = ""
+	default:
+		return fmt.Errorf("unknown profile field %q", field)
+	}
+
+	_, err = s.update(ctx, pgID, name, bio)
+	return err
+}
+
+func (s *ProfileService) update(ctx context.Context, id pgtype.UUID, name, bio string) (*User, error) {
+	row, err := s.queries.UpdateUserProfile(ctx, db.UpdateUserProfileParams{
+		ID:   id,
+		Name: name,
+		Bio:  bio,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	return &User{
+		ID:            uuid.UUID(row.ID.Bytes),
+		Email:         row.Email,
+		Name:          row.Name,
+		Handle:        textPtr(row.Handle),
+		PendingEmail:  textPtr(row.PendingEmail),
+		Bio:           row.Bio,
+		Phone:         textPtr(row.Phone),
+		PhoneVerified: row.PhoneVerifiedAt.Valid,
+		CreatedAt:     row.CreatedAt.Time,
+		UpdatedAt:     row.UpdatedAt.Time,
+	}, nil
+}
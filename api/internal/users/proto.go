@@ -0,0 +1,80 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"starterkit/internal/platform/fieldaccess"
+	"starterkit/internal/platform/protobuf"
+)
+
+// Protobuf encodings of the users API, following proto/users/v1/users.proto.
+// Field numbers must match the schema.
+
+// AppendProto encodes the user as a starterkit.users.v1.User
+func (u *User) AppendProto(b []byte) []byte {
+	b = protobuf.AppendString(b, 1, u.ID.String())
+	b = protobuf.AppendString(b, 2, u.Email)
+	b = protobuf.AppendString(b, 3, u.Name)
+	b = protobuf.AppendOptionalString(b, 4, u.Handle)
+	b = protobuf.AppendString(b, 5, u.Bio)
+	b = protobuf.AppendOptionalString(b, 6, u.PendingEmail)
+	b = protobuf.AppendOptionalString(b, 7, u.Phone)
+	b = protobuf.AppendBool(b, 8, u.PhoneVerified)
+	b = protobuf.AppendTimestamp(b, 9, u.CreatedAt)
+	b = protobuf.AppendTimestamp(b, 10, u.UpdatedAt)
+	// Expanded resources have no schema of their own, so they stay JSON
+	for _, relation := range slices.Sorted(maps.Keys(u.Expanded)) {
+		value, err := json.Marshal(u.Expanded[relation])
+		if err != nil {
+			continue
+		}
+		b = protobuf.AppendMapEntry(b, 11, relation, value)
+	}
+	return b
+}
+
+// AppendProto encodes the page as a starterkit.users.v1.UserList
+func (l *UserList) AppendProto(b []byte) []byte {
+	for _, user := range l.Users {
+		b = protobuf.AppendMessage(b, 1, user)
+	}
+	b = protobuf.AppendInt64(b, 2, int64(l.Limit))
+	b = protobuf.AppendInt64(b, 3, int64(l.Offset))
+	return protobuf.AppendMessage(b, 4, l.Meta)
+}
+
+// AppendProto encodes the metadata as a starterkit.users.v1.ListMeta
+func (m ListMeta) AppendProto(b []byte) []byte {
+	b = protobuf.AppendString(b, 1, m.Source)
+	b = protobuf.AppendInt64(b, 2, m.Lag)
+	if m.AsOf != nil {
+		b = protobuf.AppendTimestamp(b, 3, *m.AsOf)
+	}
+	return b
+}
+
+// visible returns m, a pointer, as the request's caller may see it: the
+// fields field access hides are cleared and masked ones replaced, just as
+// in JSON responses
+func visible(ctx context.Context, m protobuf.Message) (protobuf.Message, error) {
+	applied := fieldaccess.Apply(ctx, m)
+	if same, ok := applied.(protobuf.Message); ok {
+		return same, nil
+	}
+	// Restricted values come back as generic objects, read into a fresh
+	// value of m's type through their JSON form
+	data, err := json.Marshal(applied)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode visible fields: %w", err)
+	}
+	out := reflect.New(reflect.TypeOf(m).Elem()).Interface().(protobuf.Message)
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, fmt.Errorf("failed to decode visible fields: %w", err)
+	}
+	return out, nil
+}
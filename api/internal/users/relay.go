@@ -0,0 +1,73 @@
+package users
+
+import (
+	"context"
+	"fmt"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/events"
+)
+
+type ChangeQuerier interface {
+	ListUserChangesSince(ctx context.Context, arg db.ListUserChangesSinceParams) ([]db.UserChange, error)
+	GetUserChangeLogEnd(ctx context.Context) (int64, error)
+}
+
+// ChangeRelay forwards captured user changes to the event bus. It starts
+// at the end of the change log so only mutations made while the process
+// is running are published; consumers that need history use the changes
+// endpoint instead.
+type ChangeRelay struct {
+	queries ChangeQuerier
+	bus     *events.Bus
+	cursor  ChangePosition
+	started bool
+}
+
+func NewChangeRelay(queries ChangeQuerier, bus *events.Bus) *ChangeRelay {
+	return &ChangeRelay{
+		queries: queries,
+		bus:     bus,
+	}
+}
+
+// Poll publishes all changes recorded since the previous call
+func (r *ChangeRelay) Poll(ctx context.Context) error {
+	if !r.started {
+		end, err := r.queries.GetUserChangeLogEnd(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read change log position: %w", err)
+		}
+		r.cursor = ChangePosition{Txid: end}
+		r.started = true
+		return nil
+	}
+
+	for {
+		dbChanges, err := r.queries.ListUserChangesSince(ctx, db.ListUserChangesSinceParams{
+			SinceTxid: r.cursor.Txid,
+			SinceID:   r.cursor.ID,
+			RowLimit:  500,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read user changes: %w", err)
+		}
+
+		for _, dbChange := range dbChanges {
+			change, err := toChange(dbChange)
+			if err != nil {
+				return err
+			}
+			r.bus.Publish(ctx, events.Event{
+				Type:       EventUserChanged,
+				Payload:    change,
+				OccurredAt: dbChange.ChangedAt.Time,
+			})
+			r.cursor = positionOf(dbChange)
+		}
+
+		if len(dbChanges) < 500 {
+			return nil
+		}
+	}
+}
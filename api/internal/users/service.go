@@ -2,33 +2,203 @@ package users
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"starterkit/internal/audit"
 	"starterkit/internal/db"
+	"starterkit/internal/platform/database"
+	"starterkit/internal/platform/ids"
+	"starterkit/internal/platform/kpi"
+	"starterkit/internal/workflow"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-var ErrUserNotFound = errors.New("user not found")
+var (
+	ErrUserNotFound  = errors.New("user not found")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrEmailConflict = errors.New("email is already in use")
+	// ErrEmailChangeRequired is returned when a full update would change the
+	// email, which only the confirmed email change may do
+	ErrEmailChangeRequired = errors.New("email can only be changed with POST /api/v1/users/{id}/email-change, which both addresses confirm")
+)
 
 type Querier interface {
 	GetUserByID(ctx context.Context, id pgtype.UUID) (db.GetUserByIDRow, error)
-	ListUsers(ctx context.Context, arg db.ListUsersParams) ([]db.ListUsersRow, error)
+	ListUserSummaries(ctx context.Context, arg db.ListUserSummariesParams) ([]db.ListUserSummariesRow, error)
+	GetProjectionLag(ctx context.Context, name string) (db.GetProjectionLagRow, error)
+	SearchUsers(ctx context.Context, arg db.SearchUsersParams) ([]db.SearchUsersRow, error)
+	ListUserChangesSince(ctx context.Context, arg db.ListUserChangesSinceParams) ([]db.UserChange, error)
+	GetActiveUserIDByEmail(ctx context.Context, email string) (pgtype.UUID, error)
+	FindEmailOwner(ctx context.Context, email string) (db.FindEmailOwnerRow, error)
+	GetEmailChange(ctx context.Context, userID pgtype.UUID) (db.EmailChange, error)
+	PruneEmailChanges(ctx context.Context) (int64, error)
+}
+
+// WorkflowStarter starts durable workflows such as account deletion
+type WorkflowStarter interface {
+	Start(ctx context.Context, kind string, data workflow.Data, actor string) (*workflow.Workflow, error)
+}
+
+// Transactor runs a unit of work in a database transaction
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(q *db.Queries) error, opts ...database.TxOption) error
 }
 
 type Service struct {
-	queries Querier
+	queries     Querier
+	tx          Transactor
+	moderator   ContentModerator
+	workflows   WorkflowStarter
+	mail        Mailer
+	emailChange EmailChangeOptions
+	ids         ids.Generator
 }
 
-func NewService(queries Querier) *Service {
+// NewService creates users with IDs from idGen
+func NewService(queries Querier, tx Transactor, moderator ContentModerator, workflows WorkflowStarter, mail Mailer, emailChange EmailChangeOptions, idGen ids.Generator) *Service {
 	return &Service{
-		queries: queries,
+		queries:     queries,
+		tx:          tx,
+		moderator:   moderator,
+		workflows:   workflows,
+		mail:        mail,
+		emailChange: emailChange,
+		ids:         idGen,
+	}
+}
+
+// CreateUser moderates the name and bio and stores a new user, audited as
+// user.created. The request must have been validated.
+func (s *Service) CreateUser(ctx context.Context, req UserRequest, actor string) (*User, error) {
+	// The ID is chosen up front so moderation flags can refer to the user
+	id := s.ids.New()
+	screened, err := s.moderator.Screen(ctx, ResourceType, id.String(), actor, map[string]string{"name": req.Name, "bio": req.Bio})
+	if err != nil {
+		return nil, err
+	}
+
+	// The user and its audit entry are written together or not at all
+	var row db.CreateUserRow
+	err = s.tx.WithTx(ctx, func(q *db.Queries) error {
+		var err error
+		row, err = q.CreateUser(ctx, db.CreateUserParams{
+			ID:    pgtype.UUID{Bytes: id, Valid: true},
+			Email: req.Email,
+			Name:  screened["name"],
+			Bio:   screened["bio"],
+		})
+		if err != nil {
+			return err
+		}
+		return audit.NewService(q).Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "user.created",
+			ResourceType: ResourceType,
+			ResourceID:   id.String(),
+		})
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrEmailConflict
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	kpi.Signup(ctx)
+	return toUser(db.UpdateUserRow(row)), nil
 }
 
-func (s *Service) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error) {
+// UpdateUser replaces the name and bio of a user. The email must be left as
+// it is, or ErrEmailChangeRequired is returned: it only changes once both
+// addresses confirm, through RequestEmailChange. Only a changed name or bio
+// is moderated, as in profile updates. The change is audited as
+// user.updated. The request must have been validated.
+func (s *Service) UpdateUser(ctx context.Context, id uuid.UUID, req UserRequest, actor string) (*User, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	current, err := s.queries.GetUserByID(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if req.Email != strings.ToLower(current.Email) {
+		return nil, ErrEmailChangeRequired
+	}
+
+	fields := map[string]string{}
+	if req.Name != current.Name {
+		fields["name"] = req.Name
+	}
+	if req.Bio != current.Bio {
+		fields["bio"] = req.Bio
+	}
+	screened, err := s.moderator.Screen(ctx, ResourceType, id.String(), actor, fields)
+	if err != nil {
+		return nil, err
+	}
+	name, bio := current.Name, current.Bio
+	if v, ok := screened["name"]; ok {
+		name = v
+	}
+	if v, ok := screened["bio"]; ok {
+		bio = v
+	}
+
+	var row db.UpdateUserRow
+	err = s.tx.WithTx(ctx, func(q *db.Queries) error {
+		var err error
+		row, err = q.UpdateUser(ctx, db.UpdateUserParams{
+			ID:    pgID,
+			Email: current.Email,
+			Name:  name,
+			Bio:   bio,
+		})
+		if err != nil {
+			return err
+		}
+		return audit.NewService(q).Record(ctx, audit.Entry{
+			Actor:        actor,
+			Action:       "user.updated",
+			ResourceType: ResourceType,
+			ResourceID:   id.String(),
+		})
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrUserNotFound
+		case isUniqueViolation(err):
+			return nil, ErrEmailConflict
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return toUser(row), nil
+}
+
+// DeleteUser starts the account deletion workflow, which hides the user at
+// once and purges the account after the grace period
+func (s *Service) DeleteUser(ctx context.Context, id uuid.UUID, actor string) (*workflow.Workflow, error) {
+	wf, err := s.workflows.Start(ctx, DeletionWorkflow, workflow.Data{"user_id": id.String()}, actor)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to start account deletion: %w", err)
+	}
+	return wf, nil
+}
+
+// GetUserByID returns a user as seen by viewer, the caller's email. Shadow
+// banned users are only visible to themselves.
+func (s *Service) GetUserByID(ctx context.Context, id uuid.UUID, viewer string) (*User, error) {
 	// Convert uuid.UUID to pgtype.UUID
 	pgID := pgtype.UUID{}
 	if err := pgID.Scan(id.String()); err != nil {
@@ -42,6 +212,9 @@ func (s *Service) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error)
 		}
 		return nil, err
 	}
+	if dbUser.ShadowBannedAt.Valid && dbUser.Email != viewer {
+		return nil, ErrUserNotFound
+	}
 
 	// Convert pgtype.UUID back to uuid.UUID
 	var userID uuid.UUID
@@ -50,15 +223,23 @@ func (s *Service) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error)
 	}
 
 	return &User{
-		ID:        userID,
-		Email:     dbUser.Email,
-		Name:      dbUser.Name,
-		CreatedAt: dbUser.CreatedAt.Time,
-		UpdatedAt: dbUser.UpdatedAt.Time,
+		ID:            userID,
+		Email:         dbUser.Email,
+		Name:          dbUser.Name,
+		Handle:        textPtr(dbUser.Handle),
+		PendingEmail:  textPtr(dbUser.PendingEmail),
+		Bio:           dbUser.Bio,
+		Phone:         textPtr(dbUser.Phone),
+		PhoneVerified: dbUser.PhoneVerifiedAt.Valid,
+		CreatedAt:     dbUser.CreatedAt.Time,
+		UpdatedAt:     dbUser.UpdatedAt.Time,
 	}, nil
 }
 
-func (s *Service) ListUsers(ctx context.Context, limit, offset int) ([]*User, error) {
+// ListUsers returns a page of users as seen by viewer, the caller's email.
+// It reads the user_summaries projection, which trails writes by up to the
+// projection interval; the returned meta says by how much.
+func (s *Service) ListUsers(ctx context.Context, limit, offset int, viewer string) ([]*User, ListMeta, error) {
 	// Set default limit if not provided
 	if limit <= 0 {
 		limit = 20
@@ -69,30 +250,221 @@ func (s *Service) ListUsers(ctx context.Context, limit, offset int) ([]*User, er
 	if offset < 0 {
 		offset = 0
 	}
+	// The projection lags anyway, so a replica may answer
+	ctx = database.WithReplicaRead(ctx)
 
-	dbUsers, err := s.queries.ListUsers(ctx, db.ListUsersParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
+	rows, err := s.queries.ListUserSummaries(ctx, db.ListUserSummariesParams{
+		Viewer:    viewer,
+		RowLimit:  int32(limit),
+		RowOffset: int32(offset),
 	})
 	if err != nil {
-		return nil, err
+		return nil, ListMeta{}, err
+	}
+	meta, err := s.listMeta(ctx, "user_summaries")
+	if err != nil {
+		return nil, ListMeta{}, err
 	}
 
-	users := make([]*User, len(dbUsers))
-	for i, dbUser := range dbUsers {
-		var userID uuid.UUID
-		if dbUser.ID.Valid {
-			userID = uuid.UUID(dbUser.ID.Bytes)
+	users := make([]*User, len(rows))
+	for i, row := range rows {
+		users[i] = &User{
+			ID:            uuid.UUID(row.UserID.Bytes),
+			Email:         row.Email,
+			Name:          row.Name,
+			Handle:        textPtr(row.Handle),
+			PendingEmail:  textPtr(row.PendingEmail),
+			Bio:           row.Bio,
+			Phone:         textPtr(row.Phone),
+			PhoneVerified: row.PhoneVerified,
+			CreatedAt:     row.CreatedAt.Time,
+			UpdatedAt:     row.UpdatedAt.Time,
 		}
+	}
+
+	return users, meta, nil
+}
 
+// SearchUsers returns a page of users matching every word of query, as seen
+// by viewer. It reads the user_search projection, which trails writes by up
+// to the projection interval.
+func (s *Service) SearchUsers(ctx context.Context, query string, limit, offset int, viewer string) ([]*User, ListMeta, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	ctx = database.WithReplicaRead(ctx)
+
+	rows, err := s.queries.SearchUsers(ctx, db.SearchUsersParams{
+		Query:     query,
+		Viewer:    viewer,
+		RowLimit:  int32(limit),
+		RowOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, ListMeta{}, err
+	}
+	meta, err := s.listMeta(ctx, "user_search")
+	if err != nil {
+		return nil, ListMeta{}, err
+	}
+
+	users := make([]*User, len(rows))
+	for i, row := range rows {
 		users[i] = &User{
-			ID:        userID,
-			Email:     dbUser.Email,
-			Name:      dbUser.Name,
-			CreatedAt: dbUser.CreatedAt.Time,
-			UpdatedAt: dbUser.UpdatedAt.Time,
+			ID:            uuid.UUID(row.UserID.Bytes),
+			Email:         row.Email,
+			Name:          row.Name,
+			Bio:           row.Bio,
+			Phone:         textPtr(row.Phone),
+			PhoneVerified: row.PhoneVerified,
+			CreatedAt:     row.CreatedAt.Time,
+			UpdatedAt:     row.UpdatedAt.Time,
+		}
+	}
+	return users, meta, nil
+}
+
+// listMeta reports how far the projection a list was read from trails the
+// change log. A projection that has not run yet trails all of it.
+func (s *Service) listMeta(ctx context.Context, projection string) (ListMeta, error) {
+	meta := ListMeta{Source: projection}
+	lag, err := s.queries.GetProjectionLag(ctx, projection)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return meta, nil
+		}
+		return ListMeta{}, fmt.Errorf("failed to read projection lag: %w", err)
+	}
+	meta.Lag = lag.Lag
+	if lag.UpdatedAt.Valid {
+		asOf := lag.UpdatedAt.Time
+		meta.AsOf = &asOf
+	}
+	return meta, nil
+}
+
+// ListChanges returns captured user mutations after the given cursor, as
+// seen by viewer, the caller's email. An empty cursor starts from the
+// beginning of the change log. The returned cursor should be passed as since
+// on the next call; it moves past changes the viewer may not see.
+func (s *Service) ListChanges(ctx context.Context, since string, limit int, viewer string) ([]*Change, string, error) {
+	var position ChangePosition
+	if since != "" {
+		parsed, err := ParseChangePosition(since)
+		if err != nil {
+			return nil, "", err
+		}
+		position = parsed
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	dbChanges, err := s.queries.ListUserChangesSince(ctx, db.ListUserChangesSinceParams{
+		SinceTxid: position.Txid,
+		SinceID:   position.ID,
+		RowLimit:  int32(limit),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	changes := make([]*Change, 0, len(dbChanges))
+	for _, dbChange := range dbChanges {
+		change, err := toChange(dbChange)
+		if err != nil {
+			return nil, "", err
+		}
+		if change.VisibleTo(viewer) {
+			changes = append(changes, change)
 		}
 	}
 
-	return users, nil
+	if len(dbChanges) > 0 {
+		position = positionOf(dbChanges[len(dbChanges)-1])
+	}
+	return changes, position.String(), nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func toUser(row db.UpdateUserRow) *User {
+	return &User{
+		ID:            uuid.UUID(row.ID.Bytes),
+		Email:         row.Email,
+		Name:          row.Name,
+		Handle:        textPtr(row.Handle),
+		PendingEmail:  textPtr(row.PendingEmail),
+		Bio:           row.Bio,
+		Phone:         textPtr(row.Phone),
+		PhoneVerified: row.PhoneVerifiedAt.Valid,
+		CreatedAt:     row.CreatedAt.Time,
+		UpdatedAt:     row.UpdatedAt.Time,
+	}
+}
+
+func textPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
+
+// changeRow is a users row as the change log captures it
+type changeRow struct {
+	User
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+	ShadowBannedAt  *time.Time `json:"shadow_banned_at"`
+}
+
+// decodeChangeRow returns the user a captured row holds, nil for none, and
+// whether the user was shadow banned
+func decodeChangeRow(data []byte) (*User, bool, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, false, nil
+	}
+	var row changeRow
+	if err := json.Unmarshal(data, &row); err != nil {
+		return nil, false, fmt.Errorf("failed to decode captured user: %w", err)
+	}
+	user := row.User
+	user.PhoneVerified = row.PhoneVerifiedAt != nil
+	return &user, row.ShadowBannedAt != nil, nil
+}
+
+// positionOf returns the position of a recorded change
+func positionOf(c db.UserChange) ChangePosition {
+	return ChangePosition{Txid: c.Txid, ID: c.ID}
+}
+
+func toChange(c db.UserChange) (*Change, error) {
+	old, oldBanned, err := decodeChangeRow(c.OldData)
+	if err != nil {
+		return nil, err
+	}
+	updated, newBanned, err := decodeChangeRow(c.NewData)
+	if err != nil {
+		return nil, err
+	}
+	return &Change{
+		Cursor:       positionOf(c).String(),
+		UserID:       uuid.UUID(c.UserID.Bytes),
+		Operation:    c.Operation,
+		Old:          old,
+		New:          updated,
+		ChangedAt:    c.ChangedAt.Time,
+		shadowBanned: oldBanned || newBanned,
+	}, nil
 }
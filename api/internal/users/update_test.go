@@ -0,0 +1,80 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"starterkit/internal/db"
+	"starterkit/internal/platform/ids"
+)
+
+// userQuerier serves one stored user
+type userQuerier struct {
+	Querier
+	user db.GetUserByIDRow
+}
+
+func (q *userQuerier) GetUserByID(context.Context, pgtype.UUID) (db.GetUserByIDRow, error) {
+	return q.user, nil
+}
+
+// updateHandler serves PUT /users/{id} for a stored alice@example.com
+func updateHandler(t *testing.T) (http.Handler, uuid.UUID) {
+	t.Helper()
+	id := uuid.MustParse("0190c6b2-7d4e-7a21-9f3b-2c8d1e5a4b60")
+	queries := &userQuerier{user: db.GetUserByIDRow{
+		ID:    pgtype.UUID{Bytes: id, Valid: true},
+		Email: "alice@example.com",
+		Name:  "Alice",
+	}}
+	service := NewService(queries, nil, nil, nil, nil, EmailChangeOptions{}, ids.Generator{})
+	handler := NewHandler(service, nil, nil, nil, nil, nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	mux := http.NewServeMux()
+	mux.Handle("PUT /users/{id}", handler.HandleUpdateUser())
+	return mux, id
+}
+
+// problemCode sends a PUT with body and returns the status and problem code
+func problemCode(t *testing.T, h http.Handler, id uuid.UUID, contentType, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPut, "/users/"+id.String(), strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-User-Email", "mallory@example.com")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var problem struct {
+		Code string `json:"code"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &problem)
+	return rec.Code, problem.Code
+}
+
+func TestUpdateUserRefusesToChangeEmail(t *testing.T) {
+	h, id := updateHandler(t)
+	status, code := problemCode(t, h, id, "application/json", `{"email": "mallory@example.com", "name": "Alice"}`)
+	if status != http.StatusUnprocessableEntity || code != "email_change_required" {
+		t.Errorf("PUT with another email = %d %q, want 422 email_change_required", status, code)
+	}
+}
+
+func TestPatchUserRefusesEmail(t *testing.T) {
+	h, id := updateHandler(t)
+	for _, patch := range []string{
+		`[{"op": "replace", "path": "/email", "value": "mallory@example.com"}]`,
+		`[{"op": "copy", "from": "/name", "path": "/email"}]`,
+	} {
+		status, code := problemCode(t, h, id, "application/json-patch+json", patch)
+		if status != http.StatusUnprocessableEntity || code != "validation_failed" {
+			t.Errorf("patch %s = %d %q, want 422 validation_failed", patch, status, code)
+		}
+	}
+}
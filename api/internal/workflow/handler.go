@@ -0,0 +1,153 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"starterkit/internal/platform/apierror"
+)
+
+type ServiceInterface interface {
+	Start(ctx context.Context, kind string, data Data, actor string) (*Workflow, error)
+	Get(ctx context.Context, id uuid.UUID) (*Workflow, error)
+	List(ctx context.Context, status string, limit int) ([]*Workflow, error)
+	Retry(ctx context.Context, id uuid.UUID, actor string) (*Workflow, error)
+}
+
+// StartRequest starts a workflow of a registered kind
+type StartRequest struct {
+	Kind string `json:"kind"`
+	Data Data   `json:"data"`
+}
+
+type Handler struct {
+	service ServiceInterface
+	logger  *slog.Logger
+}
+
+func NewHandler(service ServiceInterface, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// HandleStart starts a workflow; it runs in the background
+func (h *Handler) HandleStart() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req StartRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, r, apierror.BadRequest("invalid_request", "invalid request body"))
+			return
+		}
+
+		wf, err := h.service.Start(r.Context(), req.Kind, req.Data, actorFromRequest(r))
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to start workflow", uuid.Nil)
+			return
+		}
+		h.respondWithJSON(w, http.StatusAccepted, wf)
+	}
+}
+
+// HandleList lists recently updated workflows, optionally by status, e.g.
+// ?status=failed for workflows that need attention
+func (h *Handler) HandleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 50 // default
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsedLimit, err := strconv.Atoi(limitStr)
+			if err != nil || parsedLimit < 1 {
+				apierror.Write(w, r, apierror.BadRequest("invalid_parameter", "invalid limit parameter"))
+				return
+			}
+			limit = parsedLimit
+		}
+
+		workflows, err := h.service.List(r.Context(), r.URL.Query().Get("status"), limit)
+		if err != nil {
+			h.logger.Error("failed to list workflows", "error", err)
+			apierror.Write(w, r, apierror.Internal())
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, map[string]any{
+			"workflows": workflows,
+		})
+	}
+}
+
+// HandleGet returns a workflow with its step history
+func (h *Handler) HandleGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseWorkflowID(w, r)
+		if !ok {
+			return
+		}
+		wf, err := h.service.Get(r.Context(), id)
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to get workflow", id)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, wf)
+	}
+}
+
+// HandleRetry resumes a failed workflow at the step that failed
+func (h *Handler) HandleRetry() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.parseWorkflowID(w, r)
+		if !ok {
+			return
+		}
+		wf, err := h.service.Retry(r.Context(), id, actorFromRequest(r))
+		if err != nil {
+			h.handleServiceError(w, r, err, "failed to retry workflow", id)
+			return
+		}
+		h.respondWithJSON(w, http.StatusAccepted, wf)
+	}
+}
+
+func (h *Handler) parseWorkflowID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("invalid_workflow_id", "invalid workflow ID format"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) handleServiceError(w http.ResponseWriter, r *http.Request, err error, msg string, id uuid.UUID) {
+	switch {
+	case errors.Is(err, ErrWorkflowNotFound):
+		apierror.Write(w, r, apierror.NotFound("workflow_not_found", "workflow not found"))
+	case errors.Is(err, ErrNotRetryable):
+		apierror.Write(w, r, apierror.Conflict("not_retryable", err.Error()))
+	case errors.Is(err, ErrUnknownKind):
+		apierror.Write(w, r, apierror.Unprocessable("unknown_kind", err.Error()))
+	case errors.Is(err, ErrInvalidData):
+		apierror.Write(w, r, apierror.Unprocessable("invalid_data", err.Error()))
+	default:
+		h.logger.Error(msg, "error", err, "workflow_id", id)
+		apierror.Write(w, r, apierror.Internal())
+	}
+}
+
+func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// actorFromRequest identifies the caller for audit purposes
+func actorFromRequest(r *http.Request) string {
+	return r.Header.Get("X-User-Email")
+}
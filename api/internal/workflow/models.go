@@ -0,0 +1,85 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWorkflowNotFound = errors.New("workflow not found")
+	ErrUnknownKind      = errors.New("unknown workflow kind")
+	ErrNotRetryable     = errors.New("only failed workflows can be retried")
+	ErrInvalidData      = errors.New("invalid workflow data")
+)
+
+// Workflow statuses
+const (
+	StatusRunning      = "running"
+	StatusCompensating = "compensating"
+	StatusCompleted    = "completed"
+	StatusCompensated  = "compensated"
+	StatusFailed       = "failed"
+)
+
+// Step history actions
+const (
+	ActionRun        = "run"
+	ActionCompensate = "compensate"
+	ActionWait       = "wait"
+)
+
+// Data is a workflow's persisted input and intermediate state. Steps may
+// add entries; changes are saved after each step that succeeds.
+type Data map[string]string
+
+// Step is one unit of a workflow. Run must be safe to repeat, since a
+// crash after it succeeds but before progress is saved runs it again.
+type Step struct {
+	Name string
+	Run  func(ctx context.Context, data Data) error
+	// Compensate undoes Run when a later step fails for good; nil if the
+	// step cannot or need not be undone
+	Compensate func(ctx context.Context, data Data) error
+	// Delay makes this a timer step: the next step runs after Delay
+	Delay time.Duration
+	// Pivot marks the point of no return: once it completes, later
+	// failures are retried or left for an admin instead of compensated
+	Pivot bool
+}
+
+// Definition describes a kind of workflow
+type Definition struct {
+	Kind string
+	// Validate checks the data a workflow is started with; optional
+	Validate func(ctx context.Context, data Data) error
+	Steps    []Step
+}
+
+// Workflow is a workflow instance
+type Workflow struct {
+	ID           uuid.UUID    `json:"id"`
+	Kind         string       `json:"kind"`
+	Status       string       `json:"status"`
+	Step         int          `json:"step"`
+	StepName     string       `json:"step_name,omitempty"`
+	Compensating bool         `json:"compensating"`
+	Data         Data         `json:"data"`
+	Attempts     int          `json:"attempts"`
+	LastError    *string      `json:"last_error,omitempty"`
+	NextRunAt    time.Time    `json:"next_run_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	History      []StepRecord `json:"history,omitempty"`
+}
+
+// StepRecord is one recorded attempt of a step
+type StepRecord struct {
+	Step      int       `json:"step"`
+	Name      string    `json:"name"`
+	Action    string    `json:"action"`
+	Error     *string   `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,459 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"starterkit/internal/audit"
+	"starterkit/internal/db"
+	"starterkit/internal/platform/dryrun"
+	"starterkit/internal/platform/logger"
+	"starterkit/internal/platform/panics"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// claimBatch is how many due workflows one RunDue call advances
+	claimBatch = 20
+	// maxBackoff caps the delay between attempts of a failing step
+	maxBackoff = time.Hour
+)
+
+type Querier interface {
+	CreateWorkflow(ctx context.Context, arg db.CreateWorkflowParams) (db.Workflow, error)
+	GetWorkflow(ctx context.Context, id pgtype.UUID) (db.Workflow, error)
+	ListWorkflows(ctx context.Context, arg db.ListWorkflowsParams) ([]db.Workflow, error)
+	ClaimDueWorkflows(ctx context.Context, arg db.ClaimDueWorkflowsParams) ([]db.Workflow, error)
+	SaveWorkflow(ctx context.Context, arg db.SaveWorkflowParams) error
+	RetryWorkflow(ctx context.Context, id pgtype.UUID) (db.Workflow, error)
+	RecordWorkflowStep(ctx context.Context, arg db.RecordWorkflowStepParams) error
+	ListWorkflowSteps(ctx context.Context, workflowID pgtype.UUID) ([]db.WorkflowStep, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Service runs durable workflows: sequences of steps whose progress is
+// stored after every step, so a workflow survives restarts and resumes on
+// whichever replica claims it next. A failing step is retried with
+// backoff; once it runs out of attempts the completed steps are
+// compensated in reverse order. A workflow whose compensation also fails
+// stops as failed until an admin retries it.
+type Service struct {
+	queries     Querier
+	auditor     Auditor
+	guard       *panics.Guard
+	logger      *slog.Logger
+	definitions map[string]Definition
+	maxAttempts int
+	lease       time.Duration
+	backoff     time.Duration
+}
+
+// NewService creates a workflow service. A claimed workflow is not claimed
+// again until lease has passed, and failed steps wait backoff, doubling
+// per attempt, before they are retried.
+func NewService(queries Querier, auditor Auditor, guard *panics.Guard, logger *slog.Logger, maxAttempts int, lease, backoff time.Duration) *Service {
+	return &Service{
+		queries:     queries,
+		auditor:     auditor,
+		guard:       guard,
+		logger:      logger,
+		definitions: make(map[string]Definition),
+		maxAttempts: max(maxAttempts, 1),
+		lease:       lease,
+		backoff:     backoff,
+	}
+}
+
+// Register makes a workflow kind available to Start
+func (s *Service) Register(def Definition) {
+	s.definitions[def.Kind] = def
+}
+
+// Start persists a new workflow; it runs on the next RunDue. A dry run
+// validates data and returns the workflow unsaved, without an ID.
+func (s *Service) Start(ctx context.Context, kind string, data Data, actor string) (*Workflow, error) {
+	def, ok := s.definitions[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+	if data == nil {
+		data = Data{}
+	}
+	if def.Validate != nil {
+		if err := def.Validate(ctx, data); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidData, err)
+		}
+	}
+	if dryrun.Enabled(ctx) {
+		now := time.Now()
+		wf := &Workflow{Kind: kind, Status: StatusRunning, Data: data, NextRunAt: now, CreatedAt: now, UpdatedAt: now}
+		if len(def.Steps) > 0 {
+			wf.StepName = def.Steps[0].Name
+		}
+		return wf, nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode workflow data: %w", err)
+	}
+	row, err := s.queries.CreateWorkflow(ctx, db.CreateWorkflowParams{Kind: kind, Data: raw})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workflow: %w", err)
+	}
+	wf, err := toWorkflow(row, &def)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, actor, "workflow.started", wf)
+	return wf, nil
+}
+
+// RunDue advances every workflow that is due, until each completes, waits
+// on a timer, or backs off after a failure
+func (s *Service) RunDue(ctx context.Context) error {
+	for {
+		rows, err := s.queries.ClaimDueWorkflows(ctx, db.ClaimDueWorkflowsParams{
+			LeaseSeconds: s.lease.Seconds(),
+			RowLimit:     claimBatch,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to claim workflows: %w", err)
+		}
+		for _, row := range rows {
+			if err := s.advance(ctx, row); err != nil {
+				s.logger.Error("failed to advance workflow", "workflow_id", uuid.UUID(row.ID.Bytes), "kind", row.Kind, "error", err)
+			}
+		}
+		if len(rows) < claimBatch {
+			return nil
+		}
+	}
+}
+
+// run is a claimed workflow being advanced
+type run struct {
+	id  pgtype.UUID
+	def Definition
+	wf  *Workflow
+}
+
+func (s *Service) advance(ctx context.Context, row db.Workflow) error {
+	def, ok := s.definitions[row.Kind]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKind, row.Kind)
+	}
+	wf, err := toWorkflow(row, &def)
+	if err != nil {
+		return err
+	}
+	r := &run{id: row.ID, def: def, wf: wf}
+
+	for ctx.Err() == nil {
+		var done bool
+		if wf.Compensating {
+			done, err = s.compensateStep(ctx, r)
+		} else {
+			done, err = s.runStep(ctx, r)
+		}
+		if err != nil || done {
+			return err
+		}
+	}
+	return nil
+}
+
+// runStep runs the current step forward and reports whether the workflow
+// should stop advancing for now
+func (s *Service) runStep(ctx context.Context, r *run) (bool, error) {
+	wf := r.wf
+	if wf.Step >= len(r.def.Steps) {
+		wf.Status = StatusCompleted
+		return true, s.save(ctx, r, time.Now())
+	}
+
+	step := r.def.Steps[wf.Step]
+	if step.Delay > 0 {
+		if err := s.recordStep(ctx, r, step, ActionWait, nil); err != nil {
+			return true, err
+		}
+		wf.Step++
+		return true, s.save(ctx, r, time.Now().Add(step.Delay))
+	}
+
+	err := s.guard.Call(ctx, "workflow."+wf.Kind, func(ctx context.Context) error {
+		return step.Run(ctx, wf.Data)
+	})
+	if recordErr := s.recordStep(ctx, r, step, ActionRun, err); recordErr != nil {
+		return true, recordErr
+	}
+	if err == nil {
+		wf.Step++
+		wf.Attempts = 0
+		wf.LastError = nil
+		return false, s.save(ctx, r, time.Now().Add(s.lease))
+	}
+
+	if s.failAttempt(r, err) {
+		// Out of attempts: undo the steps that completed, if any can be
+		if s.compensable(r, wf.Step-1) {
+			wf.Compensating = true
+			wf.Status = StatusCompensating
+			wf.Step--
+			wf.Attempts = 0
+			return false, s.save(ctx, r, time.Now().Add(s.lease))
+		}
+		wf.Status = StatusFailed
+	}
+	return true, s.save(ctx, r, time.Now().Add(s.retryDelay(wf.Attempts)))
+}
+
+// compensateStep undoes the current step and moves backwards
+func (s *Service) compensateStep(ctx context.Context, r *run) (bool, error) {
+	wf := r.wf
+	if wf.Step < 0 {
+		wf.Status = StatusCompensated
+		wf.Step = 0
+		return true, s.save(ctx, r, time.Now())
+	}
+
+	step := r.def.Steps[wf.Step]
+	if step.Compensate == nil {
+		wf.Step--
+		return false, nil
+	}
+
+	err := s.guard.Call(ctx, "workflow."+wf.Kind, func(ctx context.Context) error {
+		return step.Compensate(ctx, wf.Data)
+	})
+	if recordErr := s.recordStep(ctx, r, step, ActionCompensate, err); recordErr != nil {
+		return true, recordErr
+	}
+	if err == nil {
+		wf.Step--
+		wf.Attempts = 0
+		return false, s.save(ctx, r, time.Now().Add(s.lease))
+	}
+
+	if s.failAttempt(r, err) {
+		wf.Status = StatusFailed
+	}
+	return true, s.save(ctx, r, time.Now().Add(s.retryDelay(wf.Attempts)))
+}
+
+// failAttempt records a failed attempt and reports whether the step has
+// run out of attempts
+func (s *Service) failAttempt(r *run, err error) bool {
+	msg := err.Error()
+	r.wf.LastError = &msg
+	r.wf.Attempts++
+	s.logger.Warn("workflow step failed",
+		"workflow_id", r.wf.ID,
+		"kind", r.wf.Kind,
+		"step", r.def.Steps[r.wf.Step].Name,
+		"attempt", r.wf.Attempts,
+		"compensating", r.wf.Compensating,
+		"error", err,
+	)
+	return r.wf.Attempts >= s.maxAttempts
+}
+
+// compensable reports whether any step up to and including last can be
+// undone, stopping at a completed pivot
+func (s *Service) compensable(r *run, last int) bool {
+	for i := last; i >= 0; i-- {
+		if r.def.Steps[i].Pivot {
+			return false
+		}
+		if r.def.Steps[i].Compensate != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) retryDelay(attempts int) time.Duration {
+	delay := s.backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+func (s *Service) recordStep(ctx context.Context, r *run, step Step, action string, stepErr error) error {
+	var errText pgtype.Text
+	if stepErr != nil {
+		errText = pgtype.Text{String: stepErr.Error(), Valid: true}
+	}
+	if err := s.queries.RecordWorkflowStep(ctx, db.RecordWorkflowStepParams{
+		WorkflowID: r.id,
+		Step:       int32(r.wf.Step),
+		Name:       step.Name,
+		Action:     action,
+		Error:      errText,
+	}); err != nil {
+		return fmt.Errorf("failed to record workflow step: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) save(ctx context.Context, r *run, nextRunAt time.Time) error {
+	wf := r.wf
+	raw, err := json.Marshal(wf.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode workflow data: %w", err)
+	}
+	var lastError pgtype.Text
+	if wf.LastError != nil {
+		lastError = pgtype.Text{String: *wf.LastError, Valid: true}
+	}
+	if err := s.queries.SaveWorkflow(ctx, db.SaveWorkflowParams{
+		ID:           r.id,
+		Status:       wf.Status,
+		Step:         int32(wf.Step),
+		Compensating: wf.Compensating,
+		Data:         raw,
+		Attempts:     int32(wf.Attempts),
+		LastError:    lastError,
+		NextRunAt:    pgtype.Timestamptz{Time: nextRunAt, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to save workflow: %w", err)
+	}
+	return nil
+}
+
+// Get returns a workflow with its step history
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Workflow, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	row, err := s.queries.GetWorkflow(ctx, pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWorkflowNotFound
+		}
+		return nil, fmt.Errorf("failed to get workflow: %w", err)
+	}
+	wf, err := s.toWorkflow(row)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := s.queries.ListWorkflowSteps(ctx, pgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow steps: %w", err)
+	}
+	wf.History = make([]StepRecord, len(steps))
+	for i, step := range steps {
+		wf.History[i] = StepRecord{
+			Step:      int(step.Step),
+			Name:      step.Name,
+			Action:    step.Action,
+			Error:     textPtr(step.Error),
+			CreatedAt: step.CreatedAt.Time,
+		}
+	}
+	return wf, nil
+}
+
+// List returns the most recently updated workflows, optionally only those
+// with the given status
+func (s *Service) List(ctx context.Context, status string, limit int) ([]*Workflow, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	rows, err := s.queries.ListWorkflows(ctx, db.ListWorkflowsParams{
+		Status:   pgtype.Text{String: status, Valid: status != ""},
+		RowLimit: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+	workflows := make([]*Workflow, len(rows))
+	for i, row := range rows {
+		if workflows[i], err = s.toWorkflow(row); err != nil {
+			return nil, err
+		}
+	}
+	return workflows, nil
+}
+
+// Retry resumes a failed workflow from the step that failed, in the
+// direction it was going
+func (s *Service) Retry(ctx context.Context, id uuid.UUID, actor string) (*Workflow, error) {
+	row, err := s.queries.RetryWorkflow(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, getErr := s.Get(ctx, id); getErr != nil {
+				return nil, getErr
+			}
+			return nil, ErrNotRetryable
+		}
+		return nil, fmt.Errorf("failed to retry workflow: %w", err)
+	}
+	wf, err := s.toWorkflow(row)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, actor, "workflow.retried", wf)
+	return wf, nil
+}
+
+// record writes an audit entry; failures are logged since the workflow
+// change has already been committed
+func (s *Service) record(ctx context.Context, actor, action string, wf *Workflow) {
+	if err := s.auditor.Record(ctx, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: "workflow",
+		ResourceID:   wf.ID.String(),
+		Metadata:     map[string]any{"kind": wf.Kind, "step": wf.Step},
+	}); err != nil {
+		logger.FromContext(ctx).Warn("failed to record audit entry", "error", err, "action", action)
+	}
+}
+
+func (s *Service) toWorkflow(row db.Workflow) (*Workflow, error) {
+	var def *Definition
+	if d, ok := s.definitions[row.Kind]; ok {
+		def = &d
+	}
+	return toWorkflow(row, def)
+}
+
+func toWorkflow(row db.Workflow, def *Definition) (*Workflow, error) {
+	wf := &Workflow{
+		ID:           uuid.UUID(row.ID.Bytes),
+		Kind:         row.Kind,
+		Status:       row.Status,
+		Step:         int(row.Step),
+		Compensating: row.Compensating,
+		Data:         Data{},
+		Attempts:     int(row.Attempts),
+		LastError:    textPtr(row.LastError),
+		NextRunAt:    row.NextRunAt.Time,
+		CreatedAt:    row.CreatedAt.Time,
+		UpdatedAt:    row.UpdatedAt.Time,
+	}
+	if err := json.Unmarshal(row.Data, &wf.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode workflow data: %w", err)
+	}
+	if def != nil && wf.Step >= 0 && wf.Step < len(def.Steps) {
+		wf.StepName = def.Steps[wf.Step].Name
+	}
+	return wf, nil
+}
+
+func textPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
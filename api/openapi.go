@@ -0,0 +1,8 @@
+// Package starterkit embeds the OpenAPI document so binaries can serve it
+// without the source tree
+package starterkit
+
+import _ "embed"
+
+//go:embed openapi.json
+var OpenAPI []byte
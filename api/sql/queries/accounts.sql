@@ -0,0 +1,222 @@
+-- name: GetAccountUser :one
+SELECT id,
+    email,
+    name,
+    phone,
+    bio
+FROM users
+WHERE id = $1
+    AND deleted_at IS NULL;
+
+-- name: ResolveIdentity :one
+SELECT u.email
+FROM user_identities i
+    JOIN users u ON u.id = i.user_id
+WHERE i.provider = sqlc.arg(provider)
+    AND i.subject = sqlc.arg(subject)
+    AND u.deleted_at IS NULL
+UNION ALL
+SELECT u.email
+FROM account_merges m
+    JOIN users u ON u.id = m.target_user_id
+WHERE m.source_email = sqlc.arg(email)
+    AND u.deleted_at IS NULL
+LIMIT 1;
+
+-- name: ListUserIdentities :many
+SELECT *
+FROM user_identities
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: GetUserIdentity :one
+SELECT *
+FROM user_identities
+WHERE provider = $1
+    AND subject = $2;
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (user_id, provider, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: DeleteUserIdentity :one
+DELETE FROM user_identities
+WHERE id = $1
+    AND user_id = $2
+RETURNING *;
+
+-- name: CreateAccountMerge :exec
+INSERT INTO account_merges (
+        source_user_id,
+        source_email,
+        target_user_id,
+        merged_by,
+        report
+    )
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: MoveAccountMerges :execrows
+UPDATE account_merges
+SET target_user_id = sqlc.arg(target_user_id)
+WHERE target_user_id = sqlc.arg(source_user_id);
+
+-- name: MoveUserIdentities :execrows
+UPDATE user_identities
+SET user_id = sqlc.arg(target_user_id)
+WHERE user_id = sqlc.arg(source_user_id);
+
+-- name: MoveUserExternalIDs :execrows
+UPDATE user_external_ids
+SET user_id = sqlc.arg(target_user_id)
+WHERE user_id = sqlc.arg(source_user_id);
+
+-- name: MovePushSubscriptions :execrows
+UPDATE push_subscriptions
+SET user_id = sqlc.arg(target_user_id)
+WHERE user_id = sqlc.arg(source_user_id);
+
+-- name: DeleteConflictingNotificationPreferences :execrows
+DELETE FROM notification_preferences s
+WHERE s.user_id = sqlc.arg(source_user_id)
+    AND EXISTS (
+        SELECT 1
+        FROM notification_preferences t
+        WHERE t.user_id = sqlc.arg(target_user_id)
+            AND t.channel = s.channel
+    );
+
+-- name: MoveNotificationPreferences :execrows
+UPDATE notification_preferences
+SET user_id = sqlc.arg(target_user_id)
+WHERE user_id = sqlc.arg(source_user_id);
+
+-- name: MoveNotifications :execrows
+UPDATE notifications
+SET user_id = sqlc.arg(target_user_id)
+WHERE user_id = sqlc.arg(source_user_id);
+
+-- name: DeleteConflictingNotificationReadCursors :execrows
+DELETE FROM notification_read_cursors s
+WHERE s.user_id = sqlc.arg(source_user_id)
+    AND EXISTS (
+        SELECT 1
+        FROM notification_read_cursors t
+        WHERE t.user_id = sqlc.arg(target_user_id)
+            AND t.device_id = s.device_id
+    );
+
+-- name: MoveNotificationReadCursors :execrows
+UPDATE notification_read_cursors
+SET user_id = sqlc.arg(target_user_id)
+WHERE user_id = sqlc.arg(source_user_id);
+
+-- name: MoveWebauthnCredentials :execrows
+UPDATE webauthn_credentials
+SET user_id = sqlc.arg(target_user_id)
+WHERE user_id = sqlc.arg(source_user_id);
+
+-- name: MoveEmailMessages :execrows
+UPDATE email_messages
+SET user_id = sqlc.arg(target_user_id)
+WHERE user_id = sqlc.arg(source_user_id);
+
+-- name: MoveServiceAccountOwners :execrows
+UPDATE service_accounts
+SET owner_id = sqlc.arg(target_user_id)
+WHERE owner_id = sqlc.arg(source_user_id);
+
+-- name: RevokeUserAuthSessions :execrows
+UPDATE auth_sessions
+SET revoked_at = NOW()
+WHERE user_id = $1
+    AND revoked_at IS NULL;
+
+-- name: MoveUploads :execrows
+UPDATE uploads
+SET owner = sqlc.arg(target_email)
+WHERE owner = sqlc.arg(source_email);
+
+-- name: MoveOperations :execrows
+UPDATE operations
+SET owner = sqlc.arg(target_email)
+WHERE owner = sqlc.arg(source_email);
+
+-- name: MoveShortLinks :execrows
+UPDATE short_links
+SET created_by = sqlc.arg(target_email)
+WHERE created_by = sqlc.arg(source_email);
+
+-- name: MoveAPIKeys :execrows
+UPDATE api_keys
+SET created_by = sqlc.arg(target_email)
+WHERE created_by = sqlc.arg(source_email);
+
+-- name: MoveCalendarInvites :execrows
+UPDATE calendar_invites
+SET created_by = sqlc.arg(target_email)
+WHERE created_by = sqlc.arg(source_email);
+
+-- name: DeleteConflictingUserSessions :execrows
+DELETE FROM user_sessions s
+WHERE s.user_email = sqlc.arg(source_email)
+    AND EXISTS (
+        SELECT 1
+        FROM user_sessions t
+        WHERE t.user_email = sqlc.arg(target_email)
+            AND t.session_key = s.session_key
+    );
+
+-- name: MoveUserSessions :execrows
+UPDATE user_sessions
+SET user_email = sqlc.arg(target_email)
+WHERE user_email = sqlc.arg(source_email);
+
+-- name: DeleteConflictingLoginFingerprints :execrows
+DELETE FROM login_fingerprints s
+WHERE s.user_email = sqlc.arg(source_email)
+    AND EXISTS (
+        SELECT 1
+        FROM login_fingerprints t
+        WHERE t.user_email = sqlc.arg(target_email)
+            AND t.ip_range = s.ip_range
+            AND t.device = s.device
+            AND t.country = s.country
+    );
+
+-- name: MoveLoginFingerprints :execrows
+UPDATE login_fingerprints
+SET user_email = sqlc.arg(target_email)
+WHERE user_email = sqlc.arg(source_email);
+
+-- name: MoveSecurityEvents :execrows
+UPDATE security_events
+SET user_email = sqlc.arg(target_email)
+WHERE user_email = sqlc.arg(source_email);
+
+-- name: MergeAIUsage :execrows
+WITH moved AS (
+    DELETE FROM ai_usage
+    WHERE ai_usage.user_email = sqlc.arg(source_email)
+    RETURNING day,
+        requests,
+        input_tokens,
+        output_tokens
+)
+INSERT INTO ai_usage (
+        user_email,
+        day,
+        requests,
+        input_tokens,
+        output_tokens
+    )
+SELECT sqlc.arg(target_email),
+    day,
+    requests,
+    input_tokens,
+    output_tokens
+FROM moved
+ON CONFLICT (user_email, day) DO UPDATE
+SET requests = ai_usage.requests + EXCLUDED.requests,
+    input_tokens = ai_usage.input_tokens + EXCLUDED.input_tokens,
+    output_tokens = ai_usage.output_tokens + EXCLUDED.output_tokens;
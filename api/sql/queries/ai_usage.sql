@@ -0,0 +1,26 @@
+-- name: GetAITokensToday :one
+SELECT COALESCE(SUM(input_tokens + output_tokens), 0)::bigint AS tokens
+FROM ai_usage
+WHERE user_email = $1
+    AND day = (NOW() AT TIME ZONE 'UTC')::date;
+
+-- name: AddAIUsage :exec
+INSERT INTO ai_usage (
+        user_email,
+        day,
+        requests,
+        input_tokens,
+        output_tokens
+    )
+VALUES (
+        sqlc.arg(user_email),
+        (NOW() AT TIME ZONE 'UTC')::date,
+        1,
+        sqlc.arg(input_tokens),
+        sqlc.arg(output_tokens)
+    ) ON CONFLICT (user_email, day) DO
+UPDATE
+SET requests = ai_usage.requests + 1,
+    input_tokens = ai_usage.input_tokens + EXCLUDED.input_tokens,
+    output_tokens = ai_usage.output_tokens + EXCLUDED.output_tokens;
+
@@ -0,0 +1,46 @@
+-- name: CreateAnnouncement :one
+INSERT INTO announcements (title, body, link, created_by, published_at)
+VALUES ($1, $2, $3, $4, COALESCE(sqlc.narg(published_at), NOW()))
+RETURNING id,
+    title,
+    body,
+    link,
+    created_by,
+    published_at,
+    updated_at;
+
+-- name: ListPublishedAnnouncements :many
+SELECT id,
+    title,
+    body,
+    link,
+    created_by,
+    published_at,
+    updated_at
+FROM announcements
+WHERE published_at <= NOW()
+ORDER BY published_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListAnnouncementSitemapPages :many
+SELECT ((n - 1) / sqlc.arg(page_size)::int)::int AS page,
+    MAX(updated_at)::timestamptz AS last_modified
+FROM (
+        SELECT updated_at,
+            row_number() OVER (ORDER BY published_at, id) AS n
+        FROM announcements
+        WHERE published_at <= NOW()
+            AND starts_with(link, sqlc.arg(link_prefix)::text)
+    ) AS published
+GROUP BY page
+ORDER BY page;
+
+-- name: ListAnnouncementSitemapEntries :many
+SELECT link,
+    updated_at
+FROM announcements
+WHERE published_at <= NOW()
+    AND starts_with(link, sqlc.arg(link_prefix)::text)
+ORDER BY published_at,
+    id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
@@ -0,0 +1,160 @@
+-- name: ListRatePlans :many
+SELECT name,
+    requests_per_minute,
+    burst,
+    daily_quota,
+    updated_at
+FROM rate_plans
+ORDER BY requests_per_minute,
+    name;
+
+-- name: UpsertRatePlan :one
+INSERT INTO rate_plans (name, requests_per_minute, burst, daily_quota)
+VALUES ($1, $2, $3, $4) ON CONFLICT (name) DO
+UPDATE
+SET requests_per_minute = EXCLUDED.requests_per_minute,
+    burst = EXCLUDED.burst,
+    daily_quota = EXCLUDED.daily_quota,
+    updated_at = NOW()
+RETURNING name,
+    requests_per_minute,
+    burst,
+    daily_quota,
+    updated_at;
+
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (name, prefix, key_hash, plan, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id,
+    name,
+    prefix,
+    key_hash,
+    plan,
+    created_by,
+    created_at,
+    revoked_at;
+
+-- name: ListAPIKeys :many
+SELECT id,
+    name,
+    prefix,
+    key_hash,
+    plan,
+    created_by,
+    created_at,
+    revoked_at
+FROM api_keys
+ORDER BY created_at DESC;
+
+-- name: GetActiveAPIKeyByHash :one
+SELECT id,
+    name,
+    prefix,
+    key_hash,
+    plan,
+    created_by,
+    created_at,
+    revoked_at
+FROM api_keys
+WHERE key_hash = $1
+    AND revoked_at IS NULL;
+
+-- name: SetAPIKeyPlan :one
+UPDATE api_keys
+SET plan = $2
+WHERE id = $1
+RETURNING id,
+    name,
+    prefix,
+    key_hash,
+    plan,
+    created_by,
+    created_at,
+    revoked_at;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = COALESCE(revoked_at, NOW())
+WHERE id = $1
+RETURNING id,
+    name,
+    prefix,
+    key_hash,
+    plan,
+    created_by,
+    created_at,
+    revoked_at;
+
+-- name: IncrementAPIKeyUsage :one
+INSERT INTO api_key_usage (key_id, day, requests)
+VALUES ($1, (NOW() AT TIME ZONE 'UTC')::date, 1) ON CONFLICT (key_id, day) DO
+UPDATE
+SET requests = api_key_usage.requests + 1
+RETURNING requests;
+
+-- name: ListAPIKeysByCreator :many
+SELECT id,
+    name,
+    prefix,
+    key_hash,
+    plan,
+    created_by,
+    created_at,
+    revoked_at
+FROM api_keys
+WHERE created_by = $1
+ORDER BY created_at DESC;
+
+-- name: GetAPIKeyByCreator :one
+SELECT id,
+    name,
+    prefix,
+    key_hash,
+    plan,
+    created_by,
+    created_at,
+    revoked_at
+FROM api_keys
+WHERE id = $1
+    AND created_by = $2;
+
+-- name: CountActiveAPIKeysByCreator :one
+SELECT COUNT(*)
+FROM api_keys
+WHERE created_by = $1
+    AND revoked_at IS NULL;
+
+-- name: RotateAPIKey :one
+WITH old AS (
+    UPDATE api_keys
+    SET revoked_at = NOW()
+    WHERE id = $1
+        AND created_by = $2
+        AND revoked_at IS NULL
+    RETURNING name,
+        plan,
+        created_by
+)
+INSERT INTO api_keys (name, prefix, key_hash, plan, created_by)
+SELECT name,
+    $3,
+    $4,
+    plan,
+    created_by
+FROM old
+RETURNING id,
+    name,
+    prefix,
+    key_hash,
+    plan,
+    created_by,
+    created_at,
+    revoked_at;
+
+-- name: ListAPIKeyUsage :many
+SELECT day,
+    requests
+FROM api_key_usage
+WHERE key_id = $1
+    AND day >= $2
+ORDER BY day;
@@ -0,0 +1,42 @@
+-- name: CreateArchiveSegment :one
+INSERT INTO archive_segments (
+        table_name,
+        object_key,
+        range_start,
+        range_end,
+        row_count
+    )
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id,
+    table_name,
+    object_key,
+    range_start,
+    range_end,
+    row_count,
+    created_at;
+
+-- name: ListArchiveSegments :many
+SELECT id,
+    table_name,
+    object_key,
+    range_start,
+    range_end,
+    row_count,
+    created_at
+FROM archive_segments
+WHERE table_name = $1
+ORDER BY range_start DESC;
+
+-- name: ListArchiveSegmentsInRange :many
+SELECT id,
+    table_name,
+    object_key,
+    range_start,
+    range_end,
+    row_count,
+    created_at
+FROM archive_segments
+WHERE table_name = sqlc.arg(table_name)
+    AND range_start <= sqlc.arg(range_to)
+    AND range_end >= sqlc.arg(range_from)
+ORDER BY range_start;
@@ -0,0 +1,80 @@
+-- name: CreateAuditLog :exec
+INSERT INTO audit_logs (actor, action, resource_type, resource_id, metadata, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: CountAuditLogsBefore :one
+SELECT COUNT(*)
+FROM audit_logs
+WHERE created_at < sqlc.arg(cutoff);
+
+-- name: DeleteAuditLogsBefore :execrows
+DELETE FROM audit_logs
+WHERE created_at < sqlc.arg(cutoff);
+
+-- name: ListAuditLogsBefore :many
+SELECT id,
+    actor,
+    action,
+    resource_type,
+    resource_id,
+    metadata,
+    created_at,
+    tenant_id,
+    txid
+FROM audit_logs
+WHERE created_at < sqlc.arg(cutoff)
+ORDER BY created_at,
+    id
+LIMIT sqlc.arg(row_limit);
+
+-- name: DeleteAuditLogsByIDs :execrows
+DELETE FROM audit_logs
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: ListAuditLogsAfter :many
+SELECT id,
+    actor,
+    action,
+    resource_type,
+    resource_id,
+    metadata,
+    created_at,
+    tenant_id,
+    txid
+FROM audit_logs
+WHERE (txid, created_at, id) > (
+        sqlc.arg(after_txid)::bigint,
+        sqlc.arg(after_created_at)::timestamptz,
+        sqlc.arg(after_id)::uuid
+    )
+    AND txid < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+ORDER BY txid,
+    created_at,
+    id
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetAuditLogTxidSince :one
+SELECT COALESCE(
+        MIN(txid),
+        pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+    )::bigint AS txid
+FROM audit_logs
+WHERE created_at >= sqlc.arg(since);
+
+-- name: GetAuditForwarderCheckpoint :one
+SELECT name,
+    last_created_at,
+    last_id,
+    updated_at,
+    last_txid
+FROM audit_forwarder_checkpoints
+WHERE name = $1;
+
+-- name: UpsertAuditForwarderCheckpoint :exec
+INSERT INTO audit_forwarder_checkpoints (name, last_created_at, last_id, last_txid)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (name) DO UPDATE
+SET last_created_at = EXCLUDED.last_created_at,
+    last_id = EXCLUDED.last_id,
+    last_txid = EXCLUDED.last_txid,
+    updated_at = NOW();
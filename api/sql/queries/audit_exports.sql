@@ -0,0 +1,95 @@
+-- name: CreateAuditExport :one
+INSERT INTO audit_exports (tenant_id, format, frequency, destination, target, signing_secret, next_run_at, exported_through, exported_through_txid, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: GetAuditExport :one
+SELECT *
+FROM audit_exports
+WHERE id = $1
+    AND tenant_id = $2;
+
+-- name: ListAuditExports :many
+SELECT *
+FROM audit_exports
+WHERE tenant_id = $1
+ORDER BY created_at;
+
+-- name: UpdateAuditExport :one
+UPDATE audit_exports
+SET format = sqlc.arg(format),
+    frequency = sqlc.arg(frequency),
+    paused = sqlc.arg(paused),
+    next_run_at = sqlc.arg(next_run_at),
+    consecutive_failures = CASE WHEN sqlc.arg(paused)::boolean THEN consecutive_failures ELSE 0 END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+    AND tenant_id = sqlc.arg(tenant_id)
+RETURNING *;
+
+-- name: DeleteAuditExport :execrows
+DELETE FROM audit_exports
+WHERE id = $1
+    AND tenant_id = $2;
+
+-- name: ListDueAuditExports :many
+SELECT *
+FROM audit_exports
+WHERE NOT paused
+    AND next_run_at <= NOW()
+ORDER BY next_run_at
+LIMIT sqlc.arg(row_limit);
+
+-- name: RecordAuditExportSuccess :exec
+UPDATE audit_exports
+SET next_run_at = sqlc.arg(next_run_at),
+    exported_through = sqlc.arg(exported_through),
+    exported_through_id = sqlc.arg(exported_through_id),
+    exported_through_txid = sqlc.arg(exported_through_txid),
+    consecutive_failures = 0,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: RecordAuditExportFailure :one
+UPDATE audit_exports
+SET next_run_at = sqlc.arg(next_run_at),
+    consecutive_failures = consecutive_failures + 1,
+    paused = sqlc.arg(max_failures)::integer > 0 AND consecutive_failures + 1 >= sqlc.arg(max_failures),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateAuditExportDelivery :one
+INSERT INTO audit_export_deliveries (export_id, status, entry_count, period_start, period_end, email_message_id, response_status, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: ListAuditExportDeliveries :many
+SELECT *
+FROM audit_export_deliveries
+WHERE export_id = $1
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListTenantAuditLogsAfter :many
+SELECT id,
+    actor,
+    action,
+    resource_type,
+    resource_id,
+    metadata,
+    created_at,
+    tenant_id,
+    txid
+FROM audit_logs
+WHERE tenant_id = sqlc.arg(tenant_id)
+    AND (txid, created_at, id) > (
+        sqlc.arg(after_txid)::bigint,
+        sqlc.arg(after_created_at)::timestamptz,
+        sqlc.arg(after_id)::uuid
+    )
+    AND txid < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+ORDER BY txid,
+    created_at,
+    id
+LIMIT sqlc.arg(row_limit);
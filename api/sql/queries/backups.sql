@@ -0,0 +1,61 @@
+-- name: CreateBackup :one
+INSERT INTO backups (
+        object_key,
+        schema_version,
+        table_count,
+        row_count,
+        size_bytes
+    )
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id,
+    object_key,
+    schema_version,
+    table_count,
+    row_count,
+    size_bytes,
+    created_at;
+
+-- name: GetLatestBackup :one
+SELECT id,
+    object_key,
+    schema_version,
+    table_count,
+    row_count,
+    size_bytes,
+    created_at
+FROM backups
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ListBackups :many
+SELECT id,
+    object_key,
+    schema_version,
+    table_count,
+    row_count,
+    size_bytes,
+    created_at
+FROM backups
+ORDER BY created_at DESC;
+
+-- name: ListExpiredBackups :many
+SELECT id,
+    object_key,
+    schema_version,
+    table_count,
+    row_count,
+    size_bytes,
+    created_at
+FROM backups
+WHERE created_at < sqlc.arg(cutoff)
+    AND id NOT IN (
+        SELECT id
+        FROM backups
+        ORDER BY created_at DESC
+        LIMIT sqlc.arg(keep_count)
+    )
+ORDER BY created_at;
+
+-- name: DeleteBackup :exec
+DELETE FROM backups
+WHERE id = $1;
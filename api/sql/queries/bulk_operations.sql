@@ -0,0 +1,112 @@
+-- name: ListBulkTargets :many
+SELECT id
+FROM users
+WHERE deleted_at IS NULL
+    AND (
+        sqlc.narg(email_domain)::text IS NULL
+        OR lower(split_part(email, '@', 2)) = lower(sqlc.narg(email_domain))
+    )
+    AND (
+        sqlc.narg(query)::text IS NULL
+        OR email ILIKE '%' || sqlc.narg(query) || '%'
+        OR name ILIKE '%' || sqlc.narg(query) || '%'
+    )
+    AND (
+        sqlc.narg(created_after)::timestamptz IS NULL
+        OR created_at >= sqlc.narg(created_after)
+    )
+    AND (
+        sqlc.narg(created_before)::timestamptz IS NULL
+        OR created_at < sqlc.narg(created_before)
+    )
+ORDER BY created_at,
+    id
+LIMIT sqlc.arg(row_limit);
+
+-- name: CreateBulkOperation :one
+INSERT INTO bulk_operations (action, role, total, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: AddBulkOperationItems :exec
+INSERT INTO bulk_operation_items (operation_id, position, user_id)
+SELECT sqlc.arg(operation_id),
+    t.position,
+    t.user_id
+FROM unnest(sqlc.arg(user_ids)::uuid[]) WITH ORDINALITY AS t(user_id, position);
+
+-- name: GetBulkOperation :one
+SELECT *
+FROM bulk_operations
+WHERE id = $1;
+
+-- name: ListBulkOperations :many
+SELECT *
+FROM bulk_operations
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: ListPendingBulkItems :many
+SELECT *
+FROM bulk_operation_items
+WHERE operation_id = $1
+    AND status = 'pending'
+ORDER BY position
+LIMIT sqlc.arg(row_limit);
+
+-- name: FinishBulkItem :exec
+UPDATE bulk_operation_items
+SET status = $3,
+    error = $4,
+    finished_at = NOW()
+WHERE operation_id = $1
+    AND position = $2
+    AND status = 'pending';
+
+-- name: RefreshBulkOperation :one
+UPDATE bulk_operations o
+SET succeeded = c.succeeded,
+    skipped = c.skipped,
+    failed = c.failed,
+    status = CASE
+        WHEN c.pending = 0 THEN 'completed'
+        ELSE o.status
+    END,
+    finished_at = CASE
+        WHEN c.pending = 0 THEN COALESCE(o.finished_at, NOW())
+    END,
+    updated_at = NOW()
+FROM (
+        SELECT count(*) FILTER (WHERE status = 'succeeded') AS succeeded,
+            count(*) FILTER (WHERE status = 'skipped') AS skipped,
+            count(*) FILTER (WHERE status = 'failed') AS failed,
+            count(*) FILTER (WHERE status = 'pending') AS pending
+        FROM bulk_operation_items
+        WHERE operation_id = $1
+    ) c
+WHERE o.id = $1
+RETURNING o.*;
+
+-- name: ListBulkItems :many
+SELECT *
+FROM bulk_operation_items
+WHERE operation_id = $1
+    AND position > sqlc.arg(after)
+ORDER BY position
+LIMIT sqlc.arg(row_limit);
+
+-- name: IsUserDeactivated :one
+SELECT deleted_at IS NOT NULL AS deactivated
+FROM users
+WHERE id = $1;
+
+-- name: GrantUserRole :execrows
+INSERT INTO user_roles (user_id, role, granted_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, role) DO NOTHING;
+
+-- name: ListUserRoles :many
+SELECT role
+FROM user_roles
+WHERE user_id = $1
+ORDER BY role;
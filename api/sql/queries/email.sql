@@ -0,0 +1,113 @@
+-- name: CreateEmailMessage :one
+INSERT INTO email_messages (
+        user_id,
+        notification_id,
+        to_address,
+        subject,
+        body,
+        status,
+        html_body,
+        attachments,
+        campaign_id
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetEmailMessage :one
+SELECT *
+FROM email_messages
+WHERE id = $1;
+
+-- name: ListEmailMessages :many
+SELECT *
+FROM email_messages
+WHERE (
+        sqlc.narg(user_id)::uuid IS NULL
+        OR user_id = sqlc.narg(user_id)
+    )
+    AND (
+        sqlc.narg(notification_id)::uuid IS NULL
+        OR notification_id = sqlc.narg(notification_id)
+    )
+    AND (
+        sqlc.narg(campaign_id)::uuid IS NULL
+        OR campaign_id = sqlc.narg(campaign_id)
+    )
+    AND (
+        sqlc.narg(status)::text IS NULL
+        OR status = sqlc.narg(status)
+    )
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: MarkEmailSent :exec
+UPDATE email_messages
+SET status = 'sent',
+    provider_message_id = $2,
+    attempts = attempts + 1,
+    last_error = NULL,
+    sent_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+    AND status = 'queued';
+
+-- name: UpdateQueuedEmail :exec
+UPDATE email_messages
+SET status = sqlc.arg(status),
+    last_error = sqlc.narg(last_error),
+    attempts = attempts + CASE
+        WHEN sqlc.arg(attempted)::boolean THEN 1
+        ELSE 0
+    END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+    AND status = 'queued';
+
+-- name: SetEmailStatusByProviderID :one
+UPDATE email_messages
+SET status = sqlc.arg(status),
+    last_error = COALESCE(sqlc.narg(last_error), last_error),
+    updated_at = NOW()
+WHERE provider_message_id = sqlc.arg(provider_message_id)
+    AND status = ANY(sqlc.arg(from_statuses)::text [])
+RETURNING *;
+
+-- name: MarkEmailOpenedByProviderID :execrows
+UPDATE email_messages
+SET opened_at = sqlc.arg(opened_at),
+    updated_at = NOW()
+WHERE provider_message_id = sqlc.arg(provider_message_id)
+    AND opened_at IS NULL;
+
+-- name: DeleteUserEmailMessages :exec
+DELETE FROM email_messages
+WHERE user_id = $1;
+
+-- name: DeleteOldEmailMessages :execrows
+DELETE FROM email_messages
+WHERE created_at < sqlc.arg(before);
+
+-- name: IsEmailSuppressed :one
+SELECT EXISTS (
+        SELECT 1
+        FROM email_suppressions
+        WHERE email = LOWER($1)
+    );
+
+-- name: UpsertEmailSuppression :one
+INSERT INTO email_suppressions (email, reason, detail)
+VALUES (LOWER($1), $2, $3) ON CONFLICT (email) DO
+UPDATE
+SET reason = EXCLUDED.reason,
+    detail = EXCLUDED.detail
+RETURNING *;
+
+-- name: ListEmailSuppressions :many
+SELECT *
+FROM email_suppressions
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: DeleteEmailSuppression :execrows
+DELETE FROM email_suppressions
+WHERE email = LOWER($1);
@@ -0,0 +1,154 @@
+-- name: CreateEmailCampaign :one
+INSERT INTO email_campaigns (
+        name,
+        template_key,
+        locale,
+        data,
+        audience,
+        created_by
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetEmailCampaign :one
+SELECT *
+FROM email_campaigns
+WHERE id = $1;
+
+-- name: ListEmailCampaigns :many
+SELECT *
+FROM email_campaigns
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: StartEmailCampaign :one
+UPDATE email_campaigns
+SET status = 'running',
+    run = run + 1,
+    started_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+    AND status = 'draft'
+RETURNING *;
+
+-- name: AddEmailCampaignRecipients :execrows
+INSERT INTO email_campaign_recipients (campaign_id, position, user_id)
+SELECT sqlc.arg(campaign_id),
+    row_number() OVER (
+        ORDER BY u.created_at,
+            u.id
+    ),
+    u.id
+FROM users u
+WHERE u.deleted_at IS NULL
+    AND (
+        sqlc.narg(email_domain)::text IS NULL
+        OR lower(split_part(u.email, '@', 2)) = lower(sqlc.narg(email_domain))
+    )
+    AND (
+        sqlc.narg(query)::text IS NULL
+        OR u.email ILIKE '%' || sqlc.narg(query) || '%'
+        OR u.name ILIKE '%' || sqlc.narg(query) || '%'
+    )
+    AND (
+        sqlc.narg(created_after)::timestamptz IS NULL
+        OR u.created_at >= sqlc.narg(created_after)
+    )
+    AND (
+        sqlc.narg(created_before)::timestamptz IS NULL
+        OR u.created_at < sqlc.narg(created_before)
+    )
+    AND (
+        sqlc.narg(role)::text IS NULL
+        OR EXISTS (
+            SELECT 1
+            FROM user_roles r
+            WHERE r.user_id = u.id
+                AND r.role = sqlc.narg(role)
+        )
+    );
+
+-- name: PauseEmailCampaign :one
+UPDATE email_campaigns
+SET status = 'paused',
+    updated_at = NOW()
+WHERE id = $1
+    AND status = 'running'
+RETURNING *;
+
+-- name: ResumeEmailCampaign :one
+UPDATE email_campaigns
+SET status = 'running',
+    run = run + 1,
+    updated_at = NOW()
+WHERE id = $1
+    AND status = 'paused'
+RETURNING *;
+
+-- name: ListPendingEmailCampaignRecipients :many
+SELECT r.position,
+    r.user_id,
+    u.email,
+    u.name
+FROM email_campaign_recipients r
+    LEFT JOIN users u ON u.id = r.user_id
+    AND u.deleted_at IS NULL
+WHERE r.campaign_id = $1
+    AND r.status = 'pending'
+ORDER BY r.position
+LIMIT sqlc.arg(row_limit);
+
+-- name: FinishEmailCampaignRecipient :exec
+UPDATE email_campaign_recipients
+SET status = $3,
+    error = $4,
+    finished_at = NOW()
+WHERE campaign_id = $1
+    AND position = $2
+    AND status = 'pending';
+
+-- name: RefreshEmailCampaign :one
+UPDATE email_campaigns c
+SET total = r.total,
+    queued = r.queued,
+    skipped = r.skipped,
+    failed = r.failed,
+    status = CASE
+        WHEN r.pending = 0 THEN 'completed'
+        ELSE c.status
+    END,
+    finished_at = CASE
+        WHEN r.pending = 0 THEN COALESCE(c.finished_at, NOW())
+    END,
+    updated_at = NOW()
+FROM (
+        SELECT count(*) AS total,
+            count(*) FILTER (WHERE status = 'queued') AS queued,
+            count(*) FILTER (WHERE status = 'skipped') AS skipped,
+            count(*) FILTER (WHERE status = 'failed') AS failed,
+            count(*) FILTER (WHERE status = 'pending') AS pending
+        FROM email_campaign_recipients
+        WHERE campaign_id = $1
+    ) r
+WHERE c.id = $1
+RETURNING c.*;
+
+-- name: ListEmailCampaignRecipients :many
+SELECT *
+FROM email_campaign_recipients
+WHERE campaign_id = $1
+    AND position > sqlc.arg(after)
+ORDER BY position
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetEmailCampaignDelivery :one
+SELECT count(*) FILTER (WHERE status = 'queued') AS queued,
+    count(*) FILTER (WHERE status = 'sent') AS sent,
+    count(*) FILTER (WHERE status = 'delivered') AS delivered,
+    count(*) FILTER (WHERE status = 'bounced') AS bounced,
+    count(*) FILTER (WHERE status = 'complained') AS complained,
+    count(*) FILTER (WHERE status = 'suppressed') AS suppressed,
+    count(*) FILTER (WHERE status = 'failed') AS failed,
+    count(opened_at) AS opened
+FROM email_messages
+WHERE campaign_id = $1;
@@ -0,0 +1,76 @@
+-- name: CreateEmailChange :one
+INSERT INTO email_changes (
+        user_id,
+        old_email,
+        new_email,
+        old_token_hash,
+        new_token_hash,
+        expires_at
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id) DO UPDATE
+SET old_email = EXCLUDED.old_email,
+    new_email = EXCLUDED.new_email,
+    old_token_hash = EXCLUDED.old_token_hash,
+    new_token_hash = EXCLUDED.new_token_hash,
+    old_confirmed_at = NULL,
+    new_confirmed_at = NULL,
+    expires_at = EXCLUDED.expires_at,
+    created_at = NOW()
+RETURNING *;
+
+-- name: GetEmailChange :one
+SELECT *
+FROM email_changes
+WHERE user_id = $1
+    AND expires_at > NOW();
+
+-- name: ConfirmEmailChange :one
+UPDATE email_changes
+SET old_confirmed_at = CASE
+        WHEN old_token_hash = sqlc.arg(token_hash) THEN COALESCE(old_confirmed_at, NOW())
+        ELSE old_confirmed_at
+    END,
+    new_confirmed_at = CASE
+        WHEN new_token_hash = sqlc.arg(token_hash) THEN COALESCE(new_confirmed_at, NOW())
+        ELSE new_confirmed_at
+    END
+WHERE (
+        old_token_hash = sqlc.arg(token_hash)
+        OR new_token_hash = sqlc.arg(token_hash)
+    )
+    AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteEmailChange :execrows
+DELETE FROM email_changes
+WHERE user_id = $1;
+
+-- name: SetUserPendingEmail :execrows
+UPDATE users
+SET pending_email = $2,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NULL;
+
+-- name: ChangeUserEmail :execrows
+UPDATE users
+SET email = sqlc.arg(new_email),
+    pending_email = NULL,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+    AND email = sqlc.arg(old_email)
+    AND deleted_at IS NULL;
+
+-- name: PruneEmailChanges :execrows
+WITH expired AS (
+    DELETE FROM email_changes
+    WHERE expires_at <= NOW()
+    RETURNING user_id
+)
+UPDATE users
+SET pending_email = NULL
+WHERE id IN (
+        SELECT user_id
+        FROM expired
+    );
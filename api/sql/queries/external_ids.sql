@@ -0,0 +1,37 @@
+-- name: FindExternalID :one
+SELECT e.user_id
+FROM user_external_ids e
+    JOIN users u ON u.id = e.user_id
+WHERE e.system = $1
+    AND e.external_id = $2
+    AND u.deleted_at IS NULL;
+
+-- name: ImportExternalIDs :many
+INSERT INTO user_external_ids (system, external_id, user_id)
+SELECT sqlc.arg(system),
+    t.external_id,
+    t.user_id
+FROM unnest(sqlc.arg(external_ids)::text[], sqlc.arg(user_ids)::uuid[]) AS t(external_id, user_id)
+    JOIN users u ON u.id = t.user_id
+WHERE u.deleted_at IS NULL
+ON CONFLICT (system, external_id) DO NOTHING
+RETURNING external_id;
+
+-- name: ListExternalIDsByValue :many
+SELECT *
+FROM user_external_ids
+WHERE system = sqlc.arg(system)
+    AND external_id = ANY(sqlc.arg(external_ids)::text[]);
+
+-- name: ListUserExternalIDs :many
+SELECT *
+FROM user_external_ids
+WHERE user_id = $1
+ORDER BY system,
+    external_id;
+
+-- name: DeleteExternalID :one
+DELETE FROM user_external_ids
+WHERE system = $1
+    AND external_id = $2
+RETURNING *;
@@ -0,0 +1,58 @@
+-- name: GetUserHandle :one
+SELECT id,
+    handle,
+    handle_changed_at
+FROM users
+WHERE id = $1
+    AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: FindHandle :one
+SELECT u.id AS user_id,
+    u.handle AS current_handle,
+    NULL::timestamptz AS released_at
+FROM users u
+WHERE u.handle = sqlc.arg(handle)
+UNION ALL
+SELECT h.user_id,
+    u.handle,
+    h.released_at
+FROM user_handle_history h
+    JOIN users u ON u.id = h.user_id
+WHERE h.handle = sqlc.arg(handle)
+LIMIT 1;
+
+-- name: SetUserHandle :one
+UPDATE users
+SET handle = $2,
+    handle_changed_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NULL
+RETURNING id,
+    email,
+    name,
+    created_at,
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email;
+
+-- name: RecordPreviousHandle :exec
+INSERT INTO user_handle_history (handle, user_id)
+VALUES ($1, $2)
+ON CONFLICT (handle) DO UPDATE
+SET user_id = EXCLUDED.user_id,
+    released_at = NOW();
+
+-- name: ReleaseHandle :exec
+DELETE FROM user_handle_history
+WHERE handle = $1;
+
+-- name: ListPreviousHandles :many
+SELECT *
+FROM user_handle_history
+WHERE user_id = $1
+ORDER BY released_at DESC;
@@ -0,0 +1,96 @@
+-- name: CreateCalendarInvite :one
+INSERT INTO calendar_invites (
+        summary,
+        description,
+        location,
+        starts_at,
+        ends_at,
+        timezone,
+        recurrence,
+        attendees,
+        created_by
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id,
+    summary,
+    description,
+    location,
+    starts_at,
+    ends_at,
+    timezone,
+    recurrence,
+    attendees,
+    sequence,
+    status,
+    created_by,
+    created_at,
+    updated_at;
+
+-- name: GetCalendarInvite :one
+SELECT id,
+    summary,
+    description,
+    location,
+    starts_at,
+    ends_at,
+    timezone,
+    recurrence,
+    attendees,
+    sequence,
+    status,
+    created_by,
+    created_at,
+    updated_at
+FROM calendar_invites
+WHERE id = $1;
+
+-- name: UpdateCalendarInvite :one
+UPDATE calendar_invites
+SET summary = $2,
+    description = $3,
+    location = $4,
+    starts_at = $5,
+    ends_at = $6,
+    timezone = $7,
+    recurrence = $8,
+    attendees = $9,
+    sequence = sequence + 1,
+    updated_at = NOW()
+WHERE id = $1
+    AND status = 'confirmed'
+RETURNING id,
+    summary,
+    description,
+    location,
+    starts_at,
+    ends_at,
+    timezone,
+    recurrence,
+    attendees,
+    sequence,
+    status,
+    created_by,
+    created_at,
+    updated_at;
+
+-- name: CancelCalendarInvite :one
+UPDATE calendar_invites
+SET status = 'cancelled',
+    sequence = sequence + 1,
+    updated_at = NOW()
+WHERE id = $1
+    AND status = 'confirmed'
+RETURNING id,
+    summary,
+    description,
+    location,
+    starts_at,
+    ends_at,
+    timezone,
+    recurrence,
+    attendees,
+    sequence,
+    status,
+    created_by,
+    created_at,
+    updated_at;
@@ -0,0 +1,192 @@
+-- name: EnqueueJob :one
+INSERT INTO jobs (
+        kind,
+        payload,
+        max_attempts,
+        trace_context,
+        request_id,
+        priority,
+        run_at
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: EnqueueUniqueJob :one
+WITH claimed AS (
+    INSERT INTO job_unique_keys (unique_key, job_id, expires_at)
+    VALUES (
+            sqlc.arg(unique_key),
+            sqlc.arg(id),
+            sqlc.arg(expires_at)
+        ) ON CONFLICT (unique_key) DO
+    UPDATE
+    SET job_id = EXCLUDED.job_id,
+        expires_at = EXCLUDED.expires_at
+    WHERE job_unique_keys.expires_at <= NOW()
+    RETURNING job_id
+)
+INSERT INTO jobs (
+        id,
+        kind,
+        payload,
+        max_attempts,
+        trace_context,
+        request_id,
+        priority,
+        run_at
+    )
+SELECT claimed.job_id,
+    sqlc.arg(kind)::text,
+    sqlc.arg(payload)::jsonb,
+    sqlc.arg(max_attempts)::integer,
+    sqlc.arg(trace_context)::jsonb,
+    sqlc.narg(request_id)::text,
+    sqlc.arg(priority)::smallint,
+    sqlc.arg(run_at)::timestamptz
+FROM claimed
+RETURNING *;
+
+-- name: GetJobByUniqueKey :one
+SELECT j.*
+FROM jobs j
+    JOIN job_unique_keys k ON k.job_id = j.id
+WHERE k.unique_key = $1;
+
+-- name: GetJob :one
+SELECT *
+FROM jobs
+WHERE id = $1;
+
+-- name: ListJobs :many
+SELECT *
+FROM jobs
+WHERE (
+        sqlc.narg(status)::text IS NULL
+        OR status = sqlc.narg(status)
+    )
+    AND (
+        sqlc.narg(kind)::text IS NULL
+        OR kind = sqlc.narg(kind)
+    )
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ClaimDueJobs :many
+WITH due AS (
+    SELECT id,
+        run_at
+    FROM jobs
+    WHERE status IN ('queued', 'running')
+        AND run_at <= NOW()
+        AND priority = ANY(sqlc.arg(priorities)::smallint [])
+    ORDER BY priority,
+        run_at
+    LIMIT sqlc.arg(row_limit) FOR
+    UPDATE SKIP LOCKED
+)
+UPDATE jobs j
+SET status = 'running',
+    attempts = j.attempts + 1,
+    run_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::float8),
+    updated_at = NOW()
+FROM due
+WHERE j.id = due.id
+RETURNING j.id,
+    j.kind,
+    j.payload,
+    j.attempts,
+    j.max_attempts,
+    j.trace_context,
+    j.request_id,
+    j.priority,
+    due.run_at AS due_at;
+
+-- name: CompleteJob :exec
+UPDATE jobs
+SET status = 'succeeded',
+    last_error = NULL,
+    updated_at = NOW(),
+    finished_at = NOW()
+WHERE id = $1;
+
+-- name: RetryJobLater :exec
+UPDATE jobs
+SET status = 'queued',
+    last_error = $2,
+    run_at = $3,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: FailJob :exec
+UPDATE jobs
+SET status = 'failed',
+    last_error = $2,
+    updated_at = NOW(),
+    finished_at = NOW()
+WHERE id = $1;
+
+-- name: RetryJob :one
+UPDATE jobs
+SET status = 'queued',
+    attempts = 0,
+    run_at = NOW(),
+    updated_at = NOW(),
+    finished_at = NULL
+WHERE id = $1
+    AND status IN ('failed', 'cancelled')
+RETURNING *;
+
+-- name: CancelJob :one
+UPDATE jobs
+SET status = 'cancelled',
+    updated_at = NOW(),
+    finished_at = NOW()
+WHERE id = $1
+    AND status = 'queued'
+RETURNING *;
+
+-- name: SetJobPriority :one
+UPDATE jobs
+SET priority = $2,
+    updated_at = NOW()
+WHERE id = $1
+    AND status = 'queued'
+RETURNING *;
+
+-- name: CountQueuedJobs :many
+SELECT priority,
+    run_at <= NOW() AS ready,
+    COUNT(*) AS jobs
+FROM jobs
+WHERE status = 'queued'
+GROUP BY priority,
+    ready;
+
+-- name: DeleteFinishedJobs :execrows
+DELETE FROM jobs
+WHERE finished_at < $1;
+
+-- name: DeleteExpiredJobUniqueKeys :execrows
+DELETE FROM job_unique_keys
+WHERE expires_at < NOW();
+
+-- name: HasJobEffect :one
+SELECT EXISTS (
+        SELECT 1
+        FROM job_effects
+        WHERE job_id = $1
+            AND effect = $2
+    );
+
+-- name: RecordJobEffect :exec
+INSERT INTO job_effects (job_id, effect)
+VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: ClaimRecurringJob :one
+INSERT INTO recurring_jobs (kind, next_run_at)
+VALUES ($1, $2) ON CONFLICT (kind) DO
+UPDATE
+SET next_run_at = EXCLUDED.next_run_at,
+    last_enqueued_at = NOW()
+WHERE recurring_jobs.next_run_at <= NOW()
+RETURNING kind;
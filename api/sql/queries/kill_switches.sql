@@ -0,0 +1,23 @@
+-- name: UpsertKillSwitch :one
+INSERT INTO kill_switches (route, reason, created_by)
+VALUES ($1, $2, $3) ON CONFLICT (route) DO
+UPDATE
+SET reason = EXCLUDED.reason,
+    created_by = EXCLUDED.created_by,
+    created_at = NOW()
+RETURNING route,
+    reason,
+    created_by,
+    created_at;
+
+-- name: ListKillSwitches :many
+SELECT route,
+    reason,
+    created_by,
+    created_at
+FROM kill_switches
+ORDER BY route;
+
+-- name: DeleteKillSwitch :execrows
+DELETE FROM kill_switches
+WHERE route = $1;
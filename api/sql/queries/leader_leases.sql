@@ -0,0 +1,42 @@
+-- name: AcquireLeaderLease :one
+INSERT INTO leader_leases (
+        name,
+        holder,
+        expires_at
+    )
+VALUES (
+        sqlc.arg(name),
+        sqlc.arg(holder),
+        NOW() + make_interval(secs => sqlc.arg(ttl_seconds)::float8)
+    ) ON CONFLICT (name) DO
+UPDATE
+SET holder = EXCLUDED.holder,
+    acquired_at = CASE
+        WHEN leader_leases.holder = EXCLUDED.holder
+        AND leader_leases.expires_at > NOW() THEN leader_leases.acquired_at
+        ELSE NOW()
+    END,
+    renewed_at = NOW(),
+    expires_at = EXCLUDED.expires_at
+WHERE leader_leases.holder = EXCLUDED.holder
+    OR leader_leases.expires_at <= NOW()
+RETURNING name,
+    holder,
+    acquired_at,
+    renewed_at,
+    expires_at;
+
+-- name: GetLeaderLease :one
+SELECT name,
+    holder,
+    acquired_at,
+    renewed_at,
+    expires_at
+FROM leader_leases
+WHERE name = $1;
+
+-- name: ReleaseLeaderLease :exec
+UPDATE leader_leases
+SET expires_at = NOW()
+WHERE name = $1
+    AND holder = $2;
@@ -0,0 +1,102 @@
+-- name: GetActiveUserIDByEmail :one
+SELECT id
+FROM users
+WHERE email = $1
+    AND deleted_at IS NULL;
+
+-- name: CountRecentMagicLinks :one
+SELECT COUNT(*) FILTER (
+        WHERE email = sqlc.arg(email)
+    ) AS from_email,
+    COUNT(*) FILTER (
+        WHERE ip_address = sqlc.arg(ip_address)
+    ) AS from_ip,
+    COUNT(*) FILTER (
+        WHERE email = sqlc.arg(email)
+            AND created_at > sqlc.arg(resent_after)
+    ) AS resent
+FROM magic_links
+WHERE created_at > sqlc.arg(since)
+    AND (
+        email = sqlc.arg(email)
+        OR ip_address = sqlc.arg(ip_address)
+    );
+
+-- name: CreateMagicLink :one
+INSERT INTO magic_links (
+        user_id,
+        email,
+        token_hash,
+        device_key,
+        device,
+        ip_address,
+        country,
+        expires_at
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetMagicLink :one
+SELECT *
+FROM magic_links
+WHERE token_hash = $1;
+
+-- name: UseMagicLink :one
+UPDATE magic_links
+SET used_at = NOW()
+WHERE id = $1
+    AND used_at IS NULL
+    AND expires_at > NOW()
+RETURNING *;
+
+-- name: PruneMagicLinks :execrows
+DELETE FROM magic_links
+WHERE created_at < sqlc.arg(before);
+
+-- name: CreateAuthSession :one
+INSERT INTO auth_sessions (
+        user_id,
+        magic_link_id,
+        token_hash,
+        device,
+        ip_address,
+        country,
+        method,
+        expires_at
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetActiveAuthSession :one
+SELECT s.id,
+    s.user_id,
+    u.email,
+    s.expires_at,
+    ARRAY(
+        SELECT r.role
+        FROM user_roles r
+        WHERE r.user_id = u.id
+        ORDER BY r.role
+    )::text[] AS roles
+FROM auth_sessions s
+    JOIN users u ON u.id = s.user_id
+WHERE s.token_hash = $1
+    AND s.revoked_at IS NULL
+    AND s.expires_at > NOW()
+    AND u.deleted_at IS NULL;
+
+-- name: TouchAuthSession :exec
+UPDATE auth_sessions
+SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: RevokeAuthSession :execrows
+UPDATE auth_sessions
+SET revoked_at = NOW()
+WHERE token_hash = $1
+    AND revoked_at IS NULL;
+
+-- name: PruneAuthSessions :execrows
+DELETE FROM auth_sessions
+WHERE expires_at < sqlc.arg(before)
+    OR revoked_at < sqlc.arg(before);
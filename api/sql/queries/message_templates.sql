@@ -0,0 +1,118 @@
+-- name: CreateMessageTemplate :one
+INSERT INTO message_templates (key, channel, locale, description)
+VALUES ($1, $2, $3, $4)
+RETURNING id,
+    key,
+    channel,
+    locale,
+    description,
+    active_version,
+    created_at,
+    updated_at;
+
+-- name: GetMessageTemplate :one
+SELECT id,
+    key,
+    channel,
+    locale,
+    description,
+    active_version,
+    created_at,
+    updated_at
+FROM message_templates
+WHERE id = $1
+    AND deleted_at IS NULL;
+
+-- name: ListMessageTemplates :many
+SELECT id,
+    key,
+    channel,
+    locale,
+    description,
+    active_version,
+    created_at,
+    updated_at
+FROM message_templates
+WHERE deleted_at IS NULL
+ORDER BY key,
+    channel,
+    locale;
+
+-- name: ListMessageTemplatesByKey :many
+SELECT id,
+    key,
+    channel,
+    locale,
+    description,
+    active_version,
+    created_at,
+    updated_at
+FROM message_templates
+WHERE key = $1
+    AND channel = $2
+    AND active_version > 0
+    AND deleted_at IS NULL;
+
+-- name: SetMessageTemplateActiveVersion :one
+UPDATE message_templates
+SET active_version = $2,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NULL
+RETURNING id,
+    key,
+    channel,
+    locale,
+    description,
+    active_version,
+    created_at,
+    updated_at;
+
+-- name: TrashMessageTemplate :execrows
+UPDATE message_templates
+SET deleted_at = NOW(),
+    deleted_by = $2
+WHERE id = $1
+    AND deleted_at IS NULL;
+
+-- name: CreateMessageTemplateVersion :one
+INSERT INTO message_template_versions (template_id, version, subject, body, variables, created_by)
+SELECT sqlc.arg(template_id)::UUID,
+    COALESCE(MAX(version), 0) + 1,
+    sqlc.narg(subject)::TEXT,
+    sqlc.arg(body)::TEXT,
+    sqlc.arg(variables)::TEXT[],
+    sqlc.arg(created_by)::VARCHAR
+FROM message_template_versions
+WHERE template_id = sqlc.arg(template_id)
+RETURNING template_id,
+    version,
+    subject,
+    body,
+    variables,
+    created_by,
+    created_at;
+
+-- name: GetMessageTemplateVersion :one
+SELECT template_id,
+    version,
+    subject,
+    body,
+    variables,
+    created_by,
+    created_at
+FROM message_template_versions
+WHERE template_id = $1
+    AND version = $2;
+
+-- name: ListMessageTemplateVersions :many
+SELECT template_id,
+    version,
+    subject,
+    body,
+    variables,
+    created_by,
+    created_at
+FROM message_template_versions
+WHERE template_id = $1
+ORDER BY version DESC;
@@ -0,0 +1,33 @@
+-- name: CreateModerationFlag :one
+INSERT INTO moderation_flags (resource_type, resource_id, field, content, reasons, actor)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetModerationFlag :one
+SELECT *
+FROM moderation_flags
+WHERE id = $1;
+
+-- name: ListModerationFlags :many
+SELECT *
+FROM moderation_flags
+WHERE status = sqlc.arg(status)
+ORDER BY CASE WHEN sqlc.arg(newest_first)::boolean THEN created_at END DESC,
+    created_at,
+    id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountModerationFlags :one
+SELECT COUNT(*)
+FROM moderation_flags
+WHERE status = $1;
+
+-- name: ResolveModerationFlag :one
+UPDATE moderation_flags
+SET status = $2,
+    reviewed_by = $3,
+    review_note = $4,
+    reviewed_at = NOW()
+WHERE id = $1
+    AND status = 'pending'
+RETURNING *;
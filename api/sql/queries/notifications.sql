@@ -0,0 +1,156 @@
+-- name: UpsertPushSubscription :one
+INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (endpoint) DO UPDATE
+SET user_id = EXCLUDED.user_id,
+    p256dh = EXCLUDED.p256dh,
+    auth = EXCLUDED.auth,
+    user_agent = EXCLUDED.user_agent
+RETURNING id,
+    user_id,
+    endpoint,
+    p256dh,
+    auth,
+    user_agent,
+    created_at,
+    last_used_at;
+
+-- name: ListPushSubscriptions :many
+SELECT id,
+    user_id,
+    endpoint,
+    p256dh,
+    auth,
+    user_agent,
+    created_at,
+    last_used_at
+FROM push_subscriptions
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeletePushSubscription :execrows
+DELETE FROM push_subscriptions
+WHERE id = $1
+    AND user_id = $2;
+
+-- name: DeletePushSubscriptionByEndpoint :exec
+DELETE FROM push_subscriptions
+WHERE endpoint = $1;
+
+-- name: TouchPushSubscription :exec
+UPDATE push_subscriptions
+SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: ListNotificationPreferences :many
+SELECT user_id,
+    channel,
+    enabled,
+    updated_at
+FROM notification_preferences
+WHERE user_id = $1
+ORDER BY channel;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, channel, enabled)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, channel) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    updated_at = NOW();
+
+-- name: DeleteUserPushSubscriptions :exec
+DELETE FROM push_subscriptions
+WHERE user_id = $1;
+
+-- name: ListNotificationPreferencesForUsers :many
+SELECT user_id,
+    channel,
+    enabled,
+    updated_at
+FROM notification_preferences
+WHERE user_id = ANY(sqlc.arg(user_ids)::uuid[])
+ORDER BY user_id, channel;
+
+-- name: CreateNotification :one
+INSERT INTO notifications (user_id, title, body, url)
+VALUES ($1, $2, $3, $4)
+RETURNING id,
+    user_id,
+    title,
+    body,
+    url,
+    created_at;
+
+-- name: ListNotifications :many
+SELECT id,
+    user_id,
+    title,
+    body,
+    url,
+    created_at
+FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+    AND (
+        NOT sqlc.arg(unread_only)::boolean
+        OR created_at > COALESCE(
+            (
+                SELECT MAX(read_through)
+                FROM notification_read_cursors
+                WHERE user_id = sqlc.arg(user_id)
+            ),
+            '-infinity'
+        )
+    )
+ORDER BY created_at DESC,
+    id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountNotifications :one
+SELECT COUNT(*)
+FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+    AND (
+        NOT sqlc.arg(unread_only)::boolean
+        OR created_at > COALESCE(
+            (
+                SELECT MAX(read_through)
+                FROM notification_read_cursors
+                WHERE user_id = sqlc.arg(user_id)
+            ),
+            '-infinity'
+        )
+    );
+
+-- name: ListNotificationsSince :many
+SELECT id,
+    user_id,
+    title,
+    body,
+    url,
+    created_at
+FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+    AND created_at > sqlc.arg(after)
+ORDER BY created_at,
+    id
+LIMIT sqlc.arg(row_limit);
+
+-- name: AdvanceNotificationReadCursor :one
+INSERT INTO notification_read_cursors (user_id, device_id, read_through)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, device_id) DO UPDATE
+SET read_through = GREATEST(notification_read_cursors.read_through, EXCLUDED.read_through),
+    updated_at = NOW()
+RETURNING user_id,
+    device_id,
+    read_through,
+    updated_at;
+
+-- name: ListNotificationReadCursors :many
+SELECT user_id,
+    device_id,
+    read_through,
+    updated_at
+FROM notification_read_cursors
+WHERE user_id = $1
+ORDER BY updated_at DESC;
@@ -0,0 +1,101 @@
+-- name: CreateOperation :one
+INSERT INTO operations (kind, owner)
+VALUES ($1, $2)
+RETURNING id,
+    kind,
+    owner,
+    status,
+    result,
+    error,
+    created_at,
+    updated_at,
+    payload,
+    undo_until;
+
+-- name: StageOperation :one
+INSERT INTO operations (kind, owner, status, payload, undo_until)
+VALUES ($1, $2, 'staged', $3, NOW() + make_interval(secs => sqlc.arg(undo_seconds)::float8))
+RETURNING id,
+    kind,
+    owner,
+    status,
+    result,
+    error,
+    created_at,
+    updated_at,
+    payload,
+    undo_until;
+
+-- name: GetOperation :one
+SELECT id,
+    kind,
+    owner,
+    status,
+    result,
+    error,
+    created_at,
+    updated_at,
+    payload,
+    undo_until
+FROM operations
+WHERE id = $1;
+
+-- name: CompleteOperation :one
+UPDATE operations
+SET status = $2,
+    result = $3,
+    error = $4,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id,
+    kind,
+    owner,
+    status,
+    result,
+    error,
+    created_at,
+    updated_at,
+    payload,
+    undo_until;
+
+-- name: UndoOperation :one
+UPDATE operations
+SET status = 'undone',
+    updated_at = NOW()
+WHERE id = $1
+    AND status = 'staged'
+    AND undo_until > NOW()
+RETURNING id,
+    kind,
+    owner,
+    status,
+    result,
+    error,
+    created_at,
+    updated_at,
+    payload,
+    undo_until;
+
+-- name: ClaimDueStagedOperations :many
+UPDATE operations
+SET status = 'pending',
+    updated_at = NOW()
+WHERE id IN (
+        SELECT s.id
+        FROM operations s
+        WHERE s.status = 'staged'
+            AND s.undo_until <= NOW()
+        ORDER BY s.undo_until
+        LIMIT sqlc.arg(row_limit)
+        FOR UPDATE SKIP LOCKED
+    )
+RETURNING id,
+    kind,
+    owner,
+    status,
+    result,
+    error,
+    created_at,
+    updated_at,
+    payload,
+    undo_until;
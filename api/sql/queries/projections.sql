@@ -0,0 +1,211 @@
+-- name: ClaimProjectionCheckpoint :one
+INSERT INTO projection_checkpoints (name)
+VALUES ($1) ON CONFLICT (name) DO
+UPDATE
+SET name = EXCLUDED.name
+RETURNING position,
+    txid;
+
+-- name: SaveProjectionCheckpoint :exec
+UPDATE projection_checkpoints
+SET position = $2,
+    txid = $3,
+    updated_at = NOW()
+WHERE name = $1;
+
+-- name: ListProjectionCheckpoints :many
+SELECT c.name,
+    c.position,
+    c.updated_at,
+    (
+        SELECT COUNT(*)
+        FROM user_changes u
+        WHERE (u.txid, u.id) > (c.txid, c.position)
+    )::bigint AS lag
+FROM projection_checkpoints c
+ORDER BY c.name;
+
+-- name: UpsertUserSearchFromChange :exec
+INSERT INTO user_search (
+        user_id,
+        email,
+        name,
+        bio,
+        phone,
+        phone_verified,
+        shadow_banned,
+        created_at,
+        updated_at
+    )
+SELECT (d->>'id')::uuid,
+    d->>'email',
+    d->>'name',
+    COALESCE(d->>'bio', ''),
+    d->>'phone',
+    d->>'phone_verified_at' IS NOT NULL,
+    d->>'shadow_banned_at' IS NOT NULL,
+    (d->>'created_at')::timestamptz,
+    (d->>'updated_at')::timestamptz
+FROM (
+        SELECT sqlc.arg(data)::jsonb AS d
+    ) c
+WHERE d->>'deleted_at' IS NULL ON CONFLICT (user_id) DO
+UPDATE
+SET email = EXCLUDED.email,
+    name = EXCLUDED.name,
+    bio = EXCLUDED.bio,
+    phone = EXCLUDED.phone,
+    phone_verified = EXCLUDED.phone_verified,
+    shadow_banned = EXCLUDED.shadow_banned,
+    updated_at = EXCLUDED.updated_at;
+
+-- name: DeleteUserSearch :exec
+DELETE FROM user_search
+WHERE user_id = $1;
+
+-- name: ClearUserSearch :exec
+DELETE FROM user_search;
+
+-- name: RebuildUserSearch :execrows
+INSERT INTO user_search (
+        user_id,
+        email,
+        name,
+        bio,
+        phone,
+        phone_verified,
+        shadow_banned,
+        created_at,
+        updated_at
+    )
+SELECT id,
+    email,
+    name,
+    bio,
+    phone,
+    phone_verified_at IS NOT NULL,
+    shadow_banned_at IS NOT NULL,
+    created_at,
+    updated_at
+FROM users
+WHERE deleted_at IS NULL;
+
+-- name: SearchUsers :many
+SELECT user_id,
+    email,
+    name,
+    bio,
+    phone,
+    phone_verified,
+    created_at,
+    updated_at
+FROM user_search
+WHERE document @@ plainto_tsquery('simple', sqlc.arg(query))
+    AND (
+        NOT shadow_banned
+        OR email = sqlc.arg(viewer)
+    )
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: GetProjectionLag :one
+SELECT c.updated_at,
+    (
+        SELECT COUNT(*)
+        FROM user_changes u
+        WHERE (u.txid, u.id) > (c.txid, c.position)
+    )::bigint AS lag
+FROM projection_checkpoints c
+WHERE c.name = $1;
+
+-- name: UpsertUserSummaryFromChange :exec
+INSERT INTO user_summaries (
+        user_id,
+        email,
+        name,
+        handle,
+        bio,
+        phone,
+        phone_verified,
+        pending_email,
+        shadow_banned,
+        created_at,
+        updated_at
+    )
+SELECT (d->>'id')::uuid,
+    d->>'email',
+    d->>'name',
+    d->>'handle',
+    COALESCE(d->>'bio', ''),
+    d->>'phone',
+    d->>'phone_verified_at' IS NOT NULL,
+    d->>'pending_email',
+    d->>'shadow_banned_at' IS NOT NULL,
+    (d->>'created_at')::timestamptz,
+    (d->>'updated_at')::timestamptz
+FROM (
+        SELECT sqlc.arg(data)::jsonb AS d
+    ) c
+WHERE d->>'deleted_at' IS NULL ON CONFLICT (user_id) DO
+UPDATE
+SET email = EXCLUDED.email,
+    name = EXCLUDED.name,
+    handle = EXCLUDED.handle,
+    bio = EXCLUDED.bio,
+    phone = EXCLUDED.phone,
+    phone_verified = EXCLUDED.phone_verified,
+    pending_email = EXCLUDED.pending_email,
+    shadow_banned = EXCLUDED.shadow_banned,
+    updated_at = EXCLUDED.updated_at;
+
+-- name: DeleteUserSummary :exec
+DELETE FROM user_summaries
+WHERE user_id = $1;
+
+-- name: ClearUserSummaries :exec
+DELETE FROM user_summaries;
+
+-- name: RebuildUserSummaries :execrows
+INSERT INTO user_summaries (
+        user_id,
+        email,
+        name,
+        handle,
+        bio,
+        phone,
+        phone_verified,
+        pending_email,
+        shadow_banned,
+        created_at,
+        updated_at
+    )
+SELECT id,
+    email,
+    name,
+    handle,
+    bio,
+    phone,
+    phone_verified_at IS NOT NULL,
+    pending_email,
+    shadow_banned_at IS NOT NULL,
+    created_at,
+    updated_at
+FROM users
+WHERE deleted_at IS NULL;
+
+-- name: ListUserSummaries :many
+SELECT user_id,
+    email,
+    name,
+    handle,
+    bio,
+    phone,
+    phone_verified,
+    pending_email,
+    created_at,
+    updated_at
+FROM user_summaries
+WHERE NOT shadow_banned
+    OR email = sqlc.arg(viewer)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
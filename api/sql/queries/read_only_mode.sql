@@ -0,0 +1,21 @@
+-- name: GetReadOnlyMode :one
+SELECT id,
+    reason,
+    enabled_by,
+    enabled_at
+FROM read_only_mode;
+
+-- name: SetReadOnlyMode :one
+INSERT INTO read_only_mode (reason, enabled_by)
+VALUES ($1, $2) ON CONFLICT (id) DO
+UPDATE
+SET reason = EXCLUDED.reason,
+    enabled_by = EXCLUDED.enabled_by,
+    enabled_at = NOW()
+RETURNING id,
+    reason,
+    enabled_by,
+    enabled_at;
+
+-- name: ClearReadOnlyMode :execrows
+DELETE FROM read_only_mode;
@@ -0,0 +1,41 @@
+-- name: UpsertReplicaHeartbeat :exec
+INSERT INTO replica_heartbeats (
+        id,
+        version,
+        ready,
+        started_at,
+        expires_at
+    )
+VALUES (
+        sqlc.arg(id),
+        sqlc.arg(version),
+        sqlc.arg(ready),
+        sqlc.arg(started_at),
+        NOW() + make_interval(secs => sqlc.arg(ttl_seconds)::float8)
+    ) ON CONFLICT (id) DO
+UPDATE
+SET version = EXCLUDED.version,
+    ready = EXCLUDED.ready,
+    started_at = EXCLUDED.started_at,
+    heartbeat_at = NOW(),
+    expires_at = EXCLUDED.expires_at;
+
+-- name: ListReplicaHeartbeats :many
+SELECT id,
+    version,
+    ready,
+    started_at,
+    heartbeat_at,
+    expires_at,
+    expires_at > NOW() AS alive
+FROM replica_heartbeats
+ORDER BY started_at,
+    id;
+
+-- name: DeleteReplicaHeartbeat :exec
+DELETE FROM replica_heartbeats
+WHERE id = $1;
+
+-- name: DeleteExpiredReplicaHeartbeats :exec
+DELETE FROM replica_heartbeats
+WHERE expires_at < NOW() - make_interval(secs => sqlc.arg(retention_seconds)::float8);
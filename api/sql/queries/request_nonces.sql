@@ -0,0 +1,7 @@
+-- name: UseRequestNonce :execrows
+INSERT INTO request_nonces (client_id, nonce, expires_at)
+VALUES ($1, $2, $3) ON CONFLICT (client_id, nonce) DO NOTHING;
+
+-- name: DeleteExpiredRequestNonces :execrows
+DELETE FROM request_nonces
+WHERE expires_at < sqlc.arg(before);
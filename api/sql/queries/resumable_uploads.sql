@@ -0,0 +1,35 @@
+-- name: CreateResumableUpload :one
+INSERT INTO resumable_uploads (id, owner, filename, content_type, upload_length, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetResumableUpload :one
+SELECT *
+FROM resumable_uploads
+WHERE id = $1;
+
+-- name: AppendResumableUploadChunk :one
+UPDATE resumable_uploads
+SET upload_offset = upload_offset + sqlc.arg(size),
+    chunk_keys = array_append(chunk_keys, sqlc.arg(chunk_key)::text),
+    expires_at = sqlc.arg(expires_at),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+    AND upload_offset = sqlc.arg(expected_offset)
+RETURNING *;
+
+-- name: DeleteResumableUpload :exec
+DELETE FROM resumable_uploads
+WHERE id = $1;
+
+-- name: ListExpiredResumableUploads :many
+SELECT *
+FROM resumable_uploads
+WHERE expires_at < NOW()
+ORDER BY expires_at
+LIMIT $1;
+
+-- name: DeleteExpiredResumableUpload :execrows
+DELETE FROM resumable_uploads
+WHERE id = $1
+    AND expires_at < NOW();
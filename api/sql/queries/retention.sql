@@ -0,0 +1,100 @@
+-- name: CreateRetentionPolicy :one
+INSERT INTO retention_policies (name, target, action, retention_days, enabled)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id,
+    name,
+    target,
+    action,
+    retention_days,
+    enabled,
+    last_run_at,
+    created_at,
+    updated_at;
+
+-- name: GetRetentionPolicy :one
+SELECT id,
+    name,
+    target,
+    action,
+    retention_days,
+    enabled,
+    last_run_at,
+    created_at,
+    updated_at
+FROM retention_policies
+WHERE id = $1
+    AND deleted_at IS NULL;
+
+-- name: ListRetentionPolicies :many
+SELECT id,
+    name,
+    target,
+    action,
+    retention_days,
+    enabled,
+    last_run_at,
+    created_at,
+    updated_at
+FROM retention_policies
+WHERE deleted_at IS NULL
+ORDER BY name;
+
+-- name: ListEnabledRetentionPolicies :many
+SELECT id,
+    name,
+    target,
+    action,
+    retention_days,
+    enabled,
+    last_run_at,
+    created_at,
+    updated_at
+FROM retention_policies
+WHERE enabled = TRUE
+    AND deleted_at IS NULL
+ORDER BY name;
+
+-- name: TrashRetentionPolicy :execrows
+UPDATE retention_policies
+SET deleted_at = NOW(),
+    deleted_by = $2
+WHERE id = $1
+    AND deleted_at IS NULL;
+
+-- name: MarkRetentionPolicyRun :exec
+UPDATE retention_policies
+SET last_run_at = NOW()
+WHERE id = $1;
+
+-- name: CreateRetentionRun :one
+INSERT INTO retention_runs (
+        policy_id,
+        dry_run,
+        cutoff,
+        affected_rows,
+        error,
+        started_at
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id,
+    policy_id,
+    dry_run,
+    cutoff,
+    affected_rows,
+    error,
+    started_at,
+    finished_at;
+
+-- name: ListRetentionRuns :many
+SELECT id,
+    policy_id,
+    dry_run,
+    cutoff,
+    affected_rows,
+    error,
+    started_at,
+    finished_at
+FROM retention_runs
+WHERE policy_id = $1
+ORDER BY started_at DESC
+LIMIT $2;
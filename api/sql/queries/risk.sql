@@ -0,0 +1,68 @@
+-- name: GetUserRiskSignalsByEmail :one
+SELECT u.id,
+    u.created_at,
+    u.phone_verified_at,
+    u.shadow_banned_at,
+    (
+        SELECT COUNT(*)
+        FROM moderation_flags f
+        WHERE f.resource_type = 'user'
+            AND f.resource_id = u.id::text
+            AND f.status IN ('pending', 'removed')
+    ) AS moderation_flags
+FROM users u
+WHERE u.email = $1
+    AND u.deleted_at IS NULL;
+
+-- name: GetUserRiskSignals :one
+SELECT u.id,
+    u.created_at,
+    u.phone_verified_at,
+    u.shadow_banned_at,
+    (
+        SELECT COUNT(*)
+        FROM moderation_flags f
+        WHERE f.resource_type = 'user'
+            AND f.resource_id = u.id::text
+            AND f.status IN ('pending', 'removed')
+    ) AS moderation_flags
+FROM users u
+WHERE u.id = $1
+    AND u.deleted_at IS NULL;
+
+-- name: SetUserShadowBan :one
+UPDATE users
+SET shadow_banned_at = COALESCE(shadow_banned_at, NOW()),
+    shadow_ban_reason = $2
+WHERE id = $1
+    AND deleted_at IS NULL
+RETURNING id,
+    email,
+    name,
+    shadow_banned_at,
+    shadow_ban_reason;
+
+-- name: ClearUserShadowBan :execrows
+UPDATE users
+SET shadow_banned_at = NULL,
+    shadow_ban_reason = NULL
+WHERE id = $1
+    AND shadow_banned_at IS NOT NULL;
+
+-- name: ListShadowBannedUsers :many
+SELECT id,
+    email,
+    name,
+    shadow_banned_at,
+    shadow_ban_reason
+FROM users
+WHERE shadow_banned_at IS NOT NULL
+    AND deleted_at IS NULL
+ORDER BY shadow_banned_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountShadowBannedUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE shadow_banned_at IS NOT NULL
+    AND deleted_at IS NULL;
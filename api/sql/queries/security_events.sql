@@ -0,0 +1,113 @@
+-- name: UpsertLoginFingerprint :one
+INSERT INTO login_fingerprints (user_email, ip_range, device, country)
+VALUES ($1, $2, $3, $4) ON CONFLICT (user_email, ip_range, device, country) DO
+UPDATE
+SET last_seen_at = NOW()
+RETURNING (xmax = 0) AS inserted;
+
+-- name: CountLoginFingerprintMatches :one
+SELECT COUNT(*) AS known,
+    COUNT(*) FILTER (
+        WHERE ip_range = $2
+    ) AS same_network,
+    COUNT(*) FILTER (
+        WHERE device = $3
+    ) AS same_device,
+    COUNT(*) FILTER (
+        WHERE country = $4
+    ) AS same_country
+FROM login_fingerprints
+WHERE user_email = $1
+    AND NOT (
+        ip_range = $2
+        AND device = $3
+        AND country = $4
+    );
+
+-- name: PruneLoginFingerprints :execrows
+DELETE FROM login_fingerprints
+WHERE last_seen_at < sqlc.arg(before);
+
+-- name: CreateSecurityEvent :one
+INSERT INTO security_events (
+        user_email,
+        kind,
+        reasons,
+        session_key,
+        device,
+        ip_address,
+        country,
+        revoke_token_hash
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id,
+    user_email,
+    kind,
+    reasons,
+    session_key,
+    device,
+    ip_address,
+    country,
+    revoke_token_hash,
+    created_at,
+    revoked_at;
+
+-- name: ListSecurityEvents :many
+SELECT id,
+    user_email,
+    kind,
+    reasons,
+    session_key,
+    device,
+    ip_address,
+    country,
+    revoke_token_hash,
+    created_at,
+    revoked_at
+FROM security_events
+WHERE user_email = $1
+ORDER BY created_at DESC
+LIMIT 100;
+
+-- name: UseRevokeToken :one
+WITH event AS (
+    UPDATE security_events
+    SET revoked_at = NOW()
+    WHERE revoke_token_hash = $1
+        AND revoked_at IS NULL
+        AND created_at > sqlc.arg(issued_after)
+    RETURNING id,
+        user_email,
+        kind,
+        reasons,
+        session_key,
+        device,
+        ip_address,
+        country,
+        revoke_token_hash,
+        created_at,
+        revoked_at
+),
+session AS (
+    UPDATE user_sessions s
+    SET revoked_at = COALESCE(s.revoked_at, NOW())
+    FROM event e
+    WHERE s.user_email = e.user_email
+        AND s.session_key = e.session_key
+)
+SELECT id,
+    user_email,
+    kind,
+    reasons,
+    session_key,
+    device,
+    ip_address,
+    country,
+    revoke_token_hash,
+    created_at,
+    revoked_at
+FROM event;
+
+-- name: PruneSecurityEvents :execrows
+DELETE FROM security_events
+WHERE created_at < sqlc.arg(before);
@@ -0,0 +1,165 @@
+-- name: CreateServiceAccount :one
+INSERT INTO service_accounts (name, description, scopes, owner_id, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id,
+    name,
+    description,
+    scopes,
+    owner_id,
+    created_by,
+    created_at,
+    disabled_at;
+
+-- name: ListServiceAccounts :many
+SELECT id,
+    name,
+    description,
+    scopes,
+    owner_id,
+    created_by,
+    created_at,
+    disabled_at
+FROM service_accounts
+ORDER BY name;
+
+-- name: GetServiceAccount :one
+SELECT id,
+    name,
+    description,
+    scopes,
+    owner_id,
+    created_by,
+    created_at,
+    disabled_at
+FROM service_accounts
+WHERE id = $1;
+
+-- name: SetServiceAccountScopes :one
+UPDATE service_accounts
+SET scopes = $2
+WHERE id = $1
+    AND disabled_at IS NULL
+RETURNING id,
+    name,
+    description,
+    scopes,
+    owner_id,
+    created_by,
+    created_at,
+    disabled_at;
+
+-- name: DisableServiceAccount :one
+WITH revoked AS (
+    UPDATE service_account_credentials
+    SET revoked_at = NOW()
+    WHERE account_id = $1
+        AND revoked_at IS NULL
+)
+UPDATE service_accounts
+SET disabled_at = COALESCE(disabled_at, NOW())
+WHERE id = $1
+RETURNING id,
+    name,
+    description,
+    scopes,
+    owner_id,
+    created_by,
+    created_at,
+    disabled_at;
+
+-- name: IssueServiceAccountCredential :one
+WITH replaced AS (
+    UPDATE service_account_credentials
+    SET expires_at = LEAST(expires_at, sqlc.arg(replaced_until)),
+        expiry_warned_at = COALESCE(expiry_warned_at, NOW())
+    WHERE account_id = sqlc.arg(account_id)
+        AND revoked_at IS NULL
+)
+INSERT INTO service_account_credentials (account_id, prefix, secret_hash, created_by, expires_at)
+SELECT id,
+    sqlc.arg(prefix),
+    sqlc.arg(secret_hash),
+    sqlc.arg(created_by),
+    sqlc.arg(expires_at)
+FROM service_accounts
+WHERE id = sqlc.arg(account_id)
+    AND disabled_at IS NULL
+RETURNING id,
+    account_id,
+    prefix,
+    secret_hash,
+    created_by,
+    created_at,
+    expires_at,
+    last_used_at,
+    revoked_at,
+    expiry_warned_at;
+
+-- name: ListServiceAccountCredentials :many
+SELECT id,
+    account_id,
+    prefix,
+    secret_hash,
+    created_by,
+    created_at,
+    expires_at,
+    last_used_at,
+    revoked_at,
+    expiry_warned_at
+FROM service_account_credentials
+WHERE account_id = ANY(sqlc.arg(account_ids)::uuid[])
+ORDER BY created_at DESC;
+
+-- name: RevokeServiceAccountCredential :one
+UPDATE service_account_credentials
+SET revoked_at = COALESCE(revoked_at, NOW())
+WHERE id = $1
+    AND account_id = $2
+RETURNING id,
+    account_id,
+    prefix,
+    secret_hash,
+    created_by,
+    created_at,
+    expires_at,
+    last_used_at,
+    revoked_at,
+    expiry_warned_at;
+
+-- name: GetActiveServiceAccountCredential :one
+SELECT c.id,
+    c.account_id,
+    c.expires_at,
+    a.name,
+    a.scopes
+FROM service_account_credentials c
+    JOIN service_accounts a ON a.id = c.account_id
+WHERE c.secret_hash = $1
+    AND c.revoked_at IS NULL
+    AND c.expires_at > NOW()
+    AND a.disabled_at IS NULL;
+
+-- name: TouchServiceAccountCredential :exec
+UPDATE service_account_credentials
+SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: ListExpiringServiceAccountCredentials :many
+SELECT c.id,
+    c.prefix,
+    c.expires_at,
+    a.id AS account_id,
+    a.name,
+    a.owner_id
+FROM service_account_credentials c
+    JOIN service_accounts a ON a.id = c.account_id
+WHERE c.revoked_at IS NULL
+    AND c.expiry_warned_at IS NULL
+    AND c.expires_at < $1
+    AND a.disabled_at IS NULL
+ORDER BY c.expires_at;
+
+-- name: MarkServiceAccountCredentialWarned :exec
+UPDATE service_account_credentials
+SET expiry_warned_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,54 @@
+-- name: CreateShortLink :one
+INSERT INTO short_links (code, target_url, created_by, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id,
+    code,
+    target_url,
+    clicks,
+    created_by,
+    expires_at,
+    last_clicked_at,
+    created_at;
+
+-- name: GetShortLink :one
+SELECT id,
+    code,
+    target_url,
+    clicks,
+    created_by,
+    expires_at,
+    last_clicked_at,
+    created_at
+FROM short_links
+WHERE code = $1;
+
+-- name: FollowShortLink :one
+UPDATE short_links
+SET clicks = clicks + 1,
+    last_clicked_at = NOW()
+WHERE code = $1
+    AND (
+        expires_at IS NULL
+        OR expires_at > NOW()
+    )
+RETURNING id,
+    code,
+    target_url,
+    clicks,
+    created_by,
+    expires_at,
+    last_clicked_at,
+    created_at;
+
+-- name: RecordShortLinkClick :exec
+INSERT INTO short_link_clicks (link_id, referer_host, device_class, country)
+VALUES ($1, $2, $3, $4);
+
+-- name: CountShortLinkClicksByDay :many
+SELECT (clicked_at AT TIME ZONE 'UTC')::date AS day,
+    COUNT(*) AS clicks
+FROM short_link_clicks
+WHERE link_id = $1
+    AND clicked_at >= $2
+GROUP BY day
+ORDER BY day;
@@ -0,0 +1,50 @@
+-- name: CreateTenantDomain :one
+INSERT INTO tenant_domains (domain, tenant_id, verification_token, cors_origins, cookie_domain, cookie_same_site, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetTenantDomain :one
+SELECT *
+FROM tenant_domains
+WHERE domain = $1;
+
+-- name: ListTenantDomains :many
+SELECT *
+FROM tenant_domains
+WHERE sqlc.narg(tenant_id)::text IS NULL OR tenant_id = sqlc.narg(tenant_id)
+ORDER BY domain;
+
+-- name: ListVerifiedTenantDomains :many
+SELECT *
+FROM tenant_domains
+WHERE verified_at IS NOT NULL;
+
+-- name: ListUnverifiedTenantDomains :many
+SELECT *
+FROM tenant_domains
+WHERE verified_at IS NULL
+    AND created_at > sqlc.arg(created_after)
+ORDER BY last_checked_at NULLS FIRST
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpdateTenantDomainSettings :one
+UPDATE tenant_domains
+SET cors_origins = sqlc.arg(cors_origins),
+    cookie_domain = sqlc.narg(cookie_domain),
+    cookie_same_site = sqlc.narg(cookie_same_site),
+    updated_at = NOW()
+WHERE domain = sqlc.arg(domain)
+RETURNING *;
+
+-- name: RecordTenantDomainCheck :one
+UPDATE tenant_domains
+SET verified_at = CASE WHEN sqlc.arg(verified)::boolean THEN COALESCE(verified_at, NOW()) ELSE verified_at END,
+    last_checked_at = NOW(),
+    check_error = sqlc.narg(check_error),
+    updated_at = NOW()
+WHERE domain = sqlc.arg(domain)
+RETURNING *;
+
+-- name: DeleteTenantDomain :execrows
+DELETE FROM tenant_domains
+WHERE domain = $1;
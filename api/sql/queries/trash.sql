@@ -0,0 +1,62 @@
+-- name: ListTrash :many
+SELECT resource_type,
+    id,
+    name,
+    deleted_at,
+    deleted_by
+FROM (
+        SELECT 'message_template'::text AS resource_type,
+            id,
+            (key || ' (' || channel || ', ' || locale || ')')::text AS name,
+            deleted_at,
+            COALESCE(deleted_by, '')::text AS deleted_by
+        FROM message_templates
+        WHERE deleted_at IS NOT NULL
+        UNION ALL
+        SELECT 'retention_policy'::text AS resource_type,
+            id,
+            name::text AS name,
+            deleted_at,
+            COALESCE(deleted_by, '')::text AS deleted_by
+        FROM retention_policies
+        WHERE deleted_at IS NOT NULL
+    ) AS trash
+WHERE sqlc.narg(resource_type)::text IS NULL
+    OR resource_type = sqlc.narg(resource_type)
+ORDER BY deleted_at DESC,
+    id
+LIMIT sqlc.arg(row_limit);
+
+-- name: RestoreMessageTemplate :execrows
+UPDATE message_templates
+SET deleted_at = NULL,
+    deleted_by = NULL,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NOT NULL;
+
+-- name: PurgeMessageTemplate :execrows
+DELETE FROM message_templates
+WHERE id = $1
+    AND deleted_at IS NOT NULL;
+
+-- name: PurgeTrashedMessageTemplates :execrows
+DELETE FROM message_templates
+WHERE deleted_at < sqlc.arg(cutoff);
+
+-- name: RestoreRetentionPolicy :execrows
+UPDATE retention_policies
+SET deleted_at = NULL,
+    deleted_by = NULL,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NOT NULL;
+
+-- name: PurgeRetentionPolicy :execrows
+DELETE FROM retention_policies
+WHERE id = $1
+    AND deleted_at IS NOT NULL;
+
+-- name: PurgeTrashedRetentionPolicies :execrows
+DELETE FROM retention_policies
+WHERE deleted_at < sqlc.arg(cutoff);
@@ -0,0 +1,158 @@
+-- name: CreateUpload :one
+INSERT INTO uploads (id, owner, filename, content_type, size, sha256, object_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id,
+    owner,
+    filename,
+    content_type,
+    size,
+    sha256,
+    object_key,
+    status,
+    scan_engine,
+    scan_signature,
+    scan_error,
+    scanned_at,
+    created_at,
+    updated_at,
+    sniffed_type,
+    width,
+    height,
+    duration_ms,
+    metadata_extracted_at;
+
+-- name: GetUpload :one
+SELECT id,
+    owner,
+    filename,
+    content_type,
+    size,
+    sha256,
+    object_key,
+    status,
+    scan_engine,
+    scan_signature,
+    scan_error,
+    scanned_at,
+    created_at,
+    updated_at,
+    sniffed_type,
+    width,
+    height,
+    duration_ms,
+    metadata_extracted_at
+FROM uploads
+WHERE id = $1;
+
+-- name: ClaimPendingUploads :many
+UPDATE uploads
+SET status = 'scanning',
+    updated_at = NOW()
+WHERE id IN (
+        SELECT id
+        FROM uploads
+        WHERE status = 'pending'
+            OR (
+                status = 'scanning'
+                AND updated_at < sqlc.arg(stale_before)
+            )
+        ORDER BY created_at
+        LIMIT sqlc.arg(row_limit) FOR UPDATE SKIP LOCKED
+    )
+RETURNING id,
+    owner,
+    filename,
+    content_type,
+    size,
+    sha256,
+    object_key,
+    status,
+    scan_engine,
+    scan_signature,
+    scan_error,
+    scanned_at,
+    created_at,
+    updated_at,
+    sniffed_type,
+    width,
+    height,
+    duration_ms,
+    metadata_extracted_at;
+
+-- name: CompleteUploadScan :one
+UPDATE uploads
+SET status = $2,
+    object_key = $3,
+    scan_engine = $4,
+    scan_signature = $5,
+    scan_error = $6,
+    scanned_at = CASE
+        WHEN $2 = 'pending' THEN scanned_at
+        ELSE NOW()
+    END,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id,
+    owner,
+    filename,
+    content_type,
+    size,
+    sha256,
+    object_key,
+    status,
+    scan_engine,
+    scan_signature,
+    scan_error,
+    scanned_at,
+    created_at,
+    updated_at,
+    sniffed_type,
+    width,
+    height,
+    duration_ms,
+    metadata_extracted_at;
+
+-- name: FindUploadObjectBySHA256 :one
+SELECT object_key
+FROM uploads
+WHERE sha256 = sqlc.arg(sha256)
+    AND status = 'clean'
+    AND id <> sqlc.arg(exclude_id)
+ORDER BY created_at
+LIMIT 1;
+
+-- name: ListUploadsWithoutMetadata :many
+SELECT id,
+    owner,
+    filename,
+    content_type,
+    size,
+    sha256,
+    object_key,
+    status,
+    scan_engine,
+    scan_signature,
+    scan_error,
+    scanned_at,
+    created_at,
+    updated_at,
+    sniffed_type,
+    width,
+    height,
+    duration_ms,
+    metadata_extracted_at
+FROM uploads
+WHERE status = 'clean'
+    AND metadata_extracted_at IS NULL
+ORDER BY created_at
+LIMIT $1;
+
+-- name: SetUploadMetadata :exec
+UPDATE uploads
+SET sniffed_type = $2,
+    width = $3,
+    height = $4,
+    duration_ms = $5,
+    metadata_extracted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,21 @@
+-- name: ListUserChangesSince :many
+SELECT id,
+    user_id,
+    operation,
+    old_data,
+    new_data,
+    changed_at,
+    txid
+FROM user_changes
+WHERE (txid, id) > (sqlc.arg(since_txid)::bigint, sqlc.arg(since_id)::bigint)
+    AND txid < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+ORDER BY txid,
+    id
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetLatestUserChangeID :one
+SELECT COALESCE(MAX(id), 0)::bigint AS latest_id
+FROM user_changes;
+
+-- name: GetUserChangeLogEnd :one
+SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint AS txid;
@@ -0,0 +1,106 @@
+-- name: UpsertUserEmbeddingFromChange :exec
+INSERT INTO user_embeddings (user_id, content_hash)
+SELECT (d->>'id')::uuid,
+    md5(COALESCE(d->>'name', '') || E'\n' || COALESCE(d->>'bio', ''))
+FROM (
+        SELECT sqlc.arg(data)::jsonb AS d
+    ) c ON CONFLICT (user_id) DO
+UPDATE
+SET content_hash = EXCLUDED.content_hash,
+    model = CASE
+        WHEN user_embeddings.content_hash = EXCLUDED.content_hash THEN user_embeddings.model
+    END,
+    embedding = CASE
+        WHEN user_embeddings.content_hash = EXCLUDED.content_hash THEN user_embeddings.embedding
+    END,
+    embedded_at = CASE
+        WHEN user_embeddings.content_hash = EXCLUDED.content_hash THEN user_embeddings.embedded_at
+    END,
+    updated_at = NOW();
+
+-- name: DeleteUserEmbedding :exec
+DELETE FROM user_embeddings
+WHERE user_id = $1;
+
+-- name: DeleteStaleUserEmbeddings :execrows
+DELETE FROM user_embeddings e
+WHERE NOT EXISTS (
+        SELECT 1
+        FROM users u
+        WHERE u.id = e.user_id
+            AND u.deleted_at IS NULL
+    );
+
+-- name: RebuildUserEmbeddings :execrows
+INSERT INTO user_embeddings (user_id, content_hash)
+SELECT id,
+    md5(name || E'\n' || bio)
+FROM users
+WHERE deleted_at IS NULL ON CONFLICT (user_id) DO
+UPDATE
+SET content_hash = EXCLUDED.content_hash,
+    model = CASE
+        WHEN user_embeddings.content_hash = EXCLUDED.content_hash THEN user_embeddings.model
+    END,
+    embedding = CASE
+        WHEN user_embeddings.content_hash = EXCLUDED.content_hash THEN user_embeddings.embedding
+    END,
+    embedded_at = CASE
+        WHEN user_embeddings.content_hash = EXCLUDED.content_hash THEN user_embeddings.embedded_at
+    END,
+    updated_at = NOW();
+
+-- name: ListPendingUserEmbeddings :many
+SELECT e.user_id,
+    e.content_hash,
+    u.name,
+    u.bio
+FROM user_embeddings e
+    JOIN users u ON u.id = e.user_id
+WHERE e.model IS DISTINCT FROM sqlc.arg(model)
+    OR e.embedding IS NULL
+ORDER BY e.updated_at
+LIMIT sqlc.arg(row_limit);
+
+-- name: SaveUserEmbedding :execrows
+UPDATE user_embeddings
+SET model = sqlc.arg(model),
+    embedding = sqlc.arg(embedding)::vector,
+    embedded_at = NOW()
+WHERE user_id = sqlc.arg(user_id)
+    AND content_hash = sqlc.arg(content_hash);
+
+-- name: ResetUserEmbeddings :execrows
+UPDATE user_embeddings
+SET model = NULL,
+    embedding = NULL,
+    embedded_at = NULL;
+
+-- name: CountUserEmbeddings :one
+SELECT COUNT(*) AS total,
+    COUNT(*) FILTER (
+        WHERE model IS DISTINCT FROM sqlc.arg(model)
+            OR embedding IS NULL
+    ) AS pending
+FROM user_embeddings;
+
+-- name: SearchUserEmbeddings :many
+SELECT u.id,
+    u.email,
+    u.name,
+    u.bio,
+    u.phone,
+    u.phone_verified_at,
+    u.created_at,
+    u.updated_at,
+    (1 - (e.embedding <=> sqlc.arg(embedding)::vector))::float8 AS score
+FROM user_embeddings e
+    JOIN users u ON u.id = e.user_id
+WHERE e.model = sqlc.arg(model)
+    AND u.deleted_at IS NULL
+    AND (
+        u.shadow_banned_at IS NULL
+        OR u.email = sqlc.arg(viewer)
+    )
+ORDER BY e.embedding <=> sqlc.arg(embedding)::vector
+LIMIT sqlc.arg(row_limit);
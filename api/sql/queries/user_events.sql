@@ -0,0 +1,50 @@
+-- name: GetUserSnapshot :one
+SELECT user_id,
+    version,
+    state,
+    taken_at
+FROM user_snapshots
+WHERE user_id = $1;
+
+-- name: ListUserEventsAfter :many
+SELECT user_id,
+    version,
+    type,
+    data,
+    recorded_at
+FROM user_events
+WHERE user_id = sqlc.arg(user_id)
+    AND version > sqlc.arg(after_version)
+ORDER BY version;
+
+-- name: AppendUserEvent :exec
+INSERT INTO user_events (user_id, version, type, data)
+VALUES ($1, $2, $3, $4);
+
+-- name: SaveUserSnapshot :exec
+INSERT INTO user_snapshots (user_id, version, state)
+VALUES ($1, $2, $3) ON CONFLICT (user_id) DO
+UPDATE
+SET version = EXCLUDED.version,
+    state = EXCLUDED.state,
+    taken_at = NOW();
+
+-- name: ProjectUser :one
+UPDATE users
+SET name = sqlc.arg(name),
+    bio = sqlc.arg(bio),
+    phone = sqlc.arg(phone),
+    phone_verified_at = sqlc.arg(phone_verified_at),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+    AND deleted_at IS NULL
+RETURNING id,
+    email,
+    name,
+    created_at,
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email;
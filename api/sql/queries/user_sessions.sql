@@ -0,0 +1,89 @@
+-- name: TouchUserSession :one
+INSERT INTO user_sessions (
+        user_email,
+        session_key,
+        user_agent,
+        browser,
+        browser_version,
+        os,
+        device_class,
+        ip_address,
+        country
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (user_email, session_key) DO
+UPDATE
+SET user_agent = EXCLUDED.user_agent,
+    browser = EXCLUDED.browser,
+    browser_version = EXCLUDED.browser_version,
+    os = EXCLUDED.os,
+    device_class = EXCLUDED.device_class,
+    ip_address = EXCLUDED.ip_address,
+    country = EXCLUDED.country,
+    last_seen_at = NOW()
+RETURNING revoked_at IS NOT NULL AS revoked;
+
+-- name: ListUserSessions :many
+SELECT *
+FROM user_sessions
+WHERE user_email = $1
+ORDER BY last_seen_at DESC
+LIMIT 100;
+
+-- name: CountSessionsByDevice :many
+SELECT d.dimension::text AS dimension,
+    d.value::text AS value,
+    COUNT(*)::bigint AS sessions,
+    COUNT(DISTINCT s.user_email)::bigint AS users
+FROM user_sessions s
+    CROSS JOIN LATERAL (
+        VALUES ('browser', s.browser),
+            ('os', s.os),
+            ('device_class', s.device_class)
+    ) AS d(dimension, value)
+WHERE s.last_seen_at >= sqlc.arg(since)
+GROUP BY d.dimension,
+    d.value
+ORDER BY d.dimension,
+    sessions DESC;
+
+-- name: CountActiveSessions :one
+SELECT COUNT(*)::bigint AS sessions
+FROM user_sessions
+WHERE last_seen_at >= sqlc.arg(since)
+    AND revoked_at IS NULL;
+
+-- name: DeleteUserSessions :exec
+WITH target AS (
+    SELECT email
+    FROM users
+    WHERE id = $1
+),
+fingerprints AS (
+    DELETE FROM login_fingerprints
+    WHERE user_email = (
+            SELECT email
+            FROM target
+        )
+),
+events AS (
+    DELETE FROM security_events
+    WHERE user_email = (
+            SELECT email
+            FROM target
+        )
+)
+DELETE FROM user_sessions
+WHERE user_email = (
+        SELECT email
+        FROM target
+    );
+
+-- name: PruneUserSessions :execrows
+DELETE FROM user_sessions
+WHERE last_seen_at < sqlc.arg(before);
+
+-- name: ListUserSessionsForEmails :many
+SELECT *
+FROM user_sessions
+WHERE user_email = ANY(sqlc.arg(user_emails)::text[])
+ORDER BY user_email, last_seen_at DESC;
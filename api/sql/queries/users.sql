@@ -3,18 +3,219 @@ SELECT id,
     email,
     name,
     created_at,
-    updated_at
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email,
+    shadow_banned_at
 FROM users
 WHERE id = $1
     AND deleted_at IS NULL;
 
--- name: ListUsers :many
+-- name: CountInactiveUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE updated_at < sqlc.arg(cutoff)
+    AND deleted_at IS NULL
+    AND email NOT LIKE 'anonymized+%';
+
+-- name: AnonymizeInactiveUsers :execrows
+WITH inactive AS (
+    SELECT id
+    FROM users
+    WHERE updated_at < sqlc.arg(cutoff)
+        AND deleted_at IS NULL
+        AND email NOT LIKE 'anonymized+%'
+),
+dropped_events AS (
+    DELETE FROM user_events
+    WHERE user_id IN (
+            SELECT id
+            FROM inactive
+        )
+),
+dropped_snapshots AS (
+    DELETE FROM user_snapshots
+    WHERE user_id IN (
+            SELECT id
+            FROM inactive
+        )
+),
+dropped_handles AS (
+    DELETE FROM user_handle_history
+    WHERE user_id IN (
+            SELECT id
+            FROM inactive
+        )
+),
+dropped_email_changes AS (
+    DELETE FROM email_changes
+    WHERE user_id IN (
+            SELECT id
+            FROM inactive
+        )
+)
+UPDATE users
+SET email = 'anonymized+' || id::text || '@invalid',
+    name = 'Anonymized User',
+    handle = NULL,
+    pending_email = NULL,
+    updated_at = NOW()
+WHERE updated_at < sqlc.arg(cutoff)
+    AND deleted_at IS NULL
+    AND email NOT LIKE 'anonymized+%';
+
+-- name: CountPurgeableUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE deleted_at < sqlc.arg(cutoff);
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deleted_at < sqlc.arg(cutoff);
+
+-- name: ListActiveUsersSnapshot :many
 SELECT id,
     email,
     name,
     created_at,
-    updated_at
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email
 FROM users
 WHERE deleted_at IS NULL
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
\ No newline at end of file
+    AND (
+        shadow_banned_at IS NULL
+        OR email = sqlc.arg(viewer)
+    )
+    AND id > sqlc.arg(after)
+ORDER BY id
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpdateUserPhone :one
+UPDATE users
+SET phone = $2,
+    phone_verified_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NULL
+RETURNING id,
+    email,
+    name,
+    created_at,
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email;
+
+-- name: MarkUserPhoneVerified :execrows
+UPDATE users
+SET phone_verified_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+    AND phone = $2
+    AND deleted_at IS NULL;
+
+-- name: LockUserForUpdate :one
+SELECT id,
+    email,
+    name,
+    bio
+FROM users
+WHERE id = $1
+    AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET name = $2,
+    bio = $3,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NULL
+RETURNING id,
+    email,
+    name,
+    created_at,
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email;
+
+-- name: CreateUser :one
+INSERT INTO users (id, email, name, bio)
+VALUES ($1, $2, $3, $4)
+RETURNING id,
+    email,
+    name,
+    created_at,
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email;
+
+-- name: UpdateUser :one
+UPDATE users
+SET email = $2,
+    name = $3,
+    bio = $4,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NULL
+RETURNING id,
+    email,
+    name,
+    created_at,
+    updated_at,
+    phone,
+    phone_verified_at,
+    bio,
+    handle,
+    pending_email;
+
+-- name: EnsureProbeUser :one
+INSERT INTO users (email, name)
+VALUES ($1, $2)
+ON CONFLICT (email) DO UPDATE
+SET deleted_at = NULL
+RETURNING id;
+
+-- name: DeactivateUser :execrows
+UPDATE users
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NULL;
+
+-- name: ReactivateUser :execrows
+UPDATE users
+SET deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+    AND deleted_at IS NOT NULL;
+
+-- name: DeleteDeactivatedUser :execrows
+DELETE FROM users
+WHERE id = $1
+    AND deleted_at IS NOT NULL;
+
+-- name: FindEmailOwner :one
+SELECT id,
+    deleted_at IS NOT NULL AS deactivated,
+    email = $1 AS current
+FROM users
+WHERE email = $1
+    OR pending_email = $1
+ORDER BY email = $1 DESC
+LIMIT 1;
@@ -0,0 +1,75 @@
+-- name: GetWebauthnUser :one
+SELECT id,
+    email,
+    name
+FROM users
+WHERE email = $1
+    AND deleted_at IS NULL;
+
+-- name: CreateWebauthnChallenge :exec
+INSERT INTO webauthn_challenges (challenge, kind, user_id, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: ConsumeWebauthnChallenge :one
+DELETE FROM webauthn_challenges
+WHERE challenge = $1
+    AND kind = $2
+    AND expires_at > NOW()
+RETURNING *;
+
+-- name: PruneWebauthnChallenges :execrows
+DELETE FROM webauthn_challenges
+WHERE expires_at < sqlc.arg(before);
+
+-- name: CreateWebauthnCredential :one
+INSERT INTO webauthn_credentials (
+        user_id,
+        credential_id,
+        public_key,
+        algorithm,
+        sign_count,
+        aaguid,
+        transports,
+        backup_eligible,
+        backed_up,
+        name
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: ListWebauthnCredentials :many
+SELECT *
+FROM webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: GetWebauthnCredentialForSignIn :one
+SELECT c.id,
+    c.user_id,
+    u.email,
+    c.public_key,
+    c.sign_count
+FROM webauthn_credentials c
+    JOIN users u ON u.id = c.user_id
+WHERE c.credential_id = $1
+    AND u.deleted_at IS NULL;
+
+-- name: UseWebauthnCredential :execrows
+UPDATE webauthn_credentials
+SET sign_count = sqlc.arg(sign_count),
+    backed_up = sqlc.arg(backed_up),
+    last_used_at = NOW()
+WHERE id = sqlc.arg(id)
+    AND sign_count = sqlc.arg(previous_sign_count);
+
+-- name: RenameWebauthnCredential :one
+UPDATE webauthn_credentials
+SET name = $3
+WHERE id = $1
+    AND user_id = $2
+RETURNING *;
+
+-- name: DeleteWebauthnCredential :execrows
+DELETE FROM webauthn_credentials
+WHERE id = $1
+    AND user_id = $2;
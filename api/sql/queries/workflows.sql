@@ -0,0 +1,122 @@
+-- name: CreateWorkflow :one
+INSERT INTO workflows (kind, data)
+VALUES ($1, $2)
+RETURNING id,
+    kind,
+    status,
+    step,
+    compensating,
+    data,
+    attempts,
+    last_error,
+    next_run_at,
+    created_at,
+    updated_at;
+
+-- name: GetWorkflow :one
+SELECT id,
+    kind,
+    status,
+    step,
+    compensating,
+    data,
+    attempts,
+    last_error,
+    next_run_at,
+    created_at,
+    updated_at
+FROM workflows
+WHERE id = $1;
+
+-- name: ListWorkflows :many
+SELECT id,
+    kind,
+    status,
+    step,
+    compensating,
+    data,
+    attempts,
+    last_error,
+    next_run_at,
+    created_at,
+    updated_at
+FROM workflows
+WHERE sqlc.narg(status)::text IS NULL
+    OR status = sqlc.narg(status)
+ORDER BY updated_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ClaimDueWorkflows :many
+UPDATE workflows
+SET next_run_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::float8)
+WHERE id IN (
+        SELECT id
+        FROM workflows
+        WHERE status IN ('running', 'compensating')
+            AND next_run_at <= NOW()
+        ORDER BY next_run_at
+        LIMIT sqlc.arg(row_limit) FOR
+        UPDATE SKIP LOCKED
+    )
+RETURNING id,
+    kind,
+    status,
+    step,
+    compensating,
+    data,
+    attempts,
+    last_error,
+    next_run_at,
+    created_at,
+    updated_at;
+
+-- name: SaveWorkflow :exec
+UPDATE workflows
+SET status = $2,
+    step = $3,
+    compensating = $4,
+    data = $5,
+    attempts = $6,
+    last_error = $7,
+    next_run_at = $8,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: RetryWorkflow :one
+UPDATE workflows
+SET status = CASE
+        WHEN compensating THEN 'compensating'
+        ELSE 'running'
+    END,
+    attempts = 0,
+    next_run_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+    AND status = 'failed'
+RETURNING id,
+    kind,
+    status,
+    step,
+    compensating,
+    data,
+    attempts,
+    last_error,
+    next_run_at,
+    created_at,
+    updated_at;
+
+-- name: RecordWorkflowStep :exec
+INSERT INTO workflow_steps (workflow_id, step, name, action, error)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ListWorkflowSteps :many
+SELECT id,
+    workflow_id,
+    step,
+    name,
+    action,
+    error,
+    created_at
+FROM workflow_steps
+WHERE workflow_id = $1
+ORDER BY id;
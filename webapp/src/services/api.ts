@@ -6,6 +6,27 @@ interface ApiResponse<T> {
   error?: string;
 }
 
+// errorMessage reads problem details, or the older {"error": ...} shape
+// still sent by some endpoints
+function errorMessage(data: Record<string, unknown>, status: number): string {
+  const message = data.detail ?? data.error ?? data.title;
+  return typeof message === 'string'
+    ? message
+    : `Request failed with status ${status}`;
+}
+
+// Mutations carry a fresh nonce so the server can reject replayed requests
+// when AUTH_REPLAY_PROTECTION is on
+function replayHeaders(method: string): Record<string, string> {
+  if (method === 'GET') {
+    return {};
+  }
+  return {
+    'X-Request-Nonce': crypto.randomUUID(),
+    'X-Request-Timestamp': String(Math.floor(Date.now() / 1000)),
+  };
+}
+
 class ApiClient {
   private baseURL: string;
   private headers: Record<string, string>;
@@ -17,6 +38,20 @@ class ApiClient {
     };
   }
 
+  // Sends the OIDC access token on every request; pass null on sign-out
+  setAccessToken(token: string | null) {
+    if (token) {
+      this.headers.Authorization = `Bearer ${token}`;
+    } else {
+      delete this.headers.Authorization;
+    }
+  }
+
+  authHeaders(): Record<string, string> {
+    const { Authorization } = this.headers;
+    return Authorization ? { Authorization } : {};
+  }
+
   private async request<T>(
     method: string,
     path: string,
@@ -41,6 +76,7 @@ class ApiClient {
         method,
         headers: {
           ...this.headers,
+          ...replayHeaders(method),
           ...options?.headers,
         },
         body: options?.body ? JSON.stringify(options.body) : undefined,
@@ -51,7 +87,7 @@ class ApiClient {
       if (!response.ok) {
         return {
           data: null as T,
-          error: data.error || `Request failed with status ${response.status}`,
+          error: errorMessage(data, response.status),
         };
       }
 
@@ -77,6 +113,10 @@ class ApiClient {
     return this.request<T>('PUT', path, { body });
   }
 
+  patch<T>(path: string, body?: unknown) {
+    return this.request<T>('PATCH', path, { body });
+  }
+
   delete<T>(path: string) {
     return this.request<T>('DELETE', path);
   }
@@ -89,24 +129,545 @@ export interface User {
   id: string;
   email: string;
   name: string;
+  handle?: string;
+  // Address the email changes to once both addresses confirm
+  pending_email?: string;
+  bio: string;
+  phone?: string;
+  phone_verified: boolean;
   created_at: string;
   updated_at: string;
+  // Related resources requested with ?expand=
+  expanded?: Record<string, unknown>;
+}
+
+export interface UpdateProfileRequest {
+  name?: string;
+  bio?: string;
+}
+
+export interface HandleAvailability {
+  handle: string;
+  available: boolean;
+  reason?: 'invalid' | 'reserved' | 'taken' | 'held';
+}
+
+// Whether a value could be taken; it may still be in use
+export interface AcceptableValue {
+  value: string;
+  acceptable: boolean;
+  reason?: 'invalid' | 'reserved';
+}
+
+export interface AvailabilityCheck {
+  email?: AcceptableValue;
+  handle?: AcceptableValue;
+}
+
+export interface HandleInfo {
+  handle: string | null;
+  changed_at?: string;
+  // Omitted when the handle can be changed now
+  changeable_at?: string;
+  previous: { handle: string; released_at: string }[];
+}
+
+export interface EmailChange {
+  new_email: string;
+  old_confirmed: boolean;
+  new_confirmed: boolean;
+  completed: boolean;
+  expires_at: string;
 }
 
 export interface UsersListResponse {
   users: User[];
   limit: number;
   offset: number;
+  // Lists are read from a projection; lag counts changes not yet shown
+  meta: {
+    source: 'user_summaries' | 'user_search';
+    lag: number;
+    as_of?: string;
+  };
+}
+
+export interface SemanticSearchResponse {
+  results: { user: User; score: number }[];
+  limit: number;
+}
+
+export interface Session {
+  id: string;
+  browser: string;
+  browser_version: string;
+  os: string;
+  device_class: 'desktop' | 'mobile' | 'tablet' | 'bot' | 'unknown';
+  user_agent: string;
+  ip_address?: string;
+  country?: string;
+  current: boolean;
+  first_seen_at: string;
+  last_seen_at: string;
+  revoked_at?: string;
+}
+
+export interface SecurityEvent {
+  id: string;
+  kind: 'login' | 'anomalous_login' | 'session_revoked';
+  reasons: ('new_network' | 'new_device' | 'new_country')[];
+  device: string;
+  ip_address?: string;
+  country?: string;
+  created_at: string;
+  revoked_at?: string;
+}
+
+export interface MagicLinkSession {
+  token: string;
+  token_type: 'Bearer';
+  email: string;
+  expires_at: string;
+}
+
+export interface Passkey {
+  id: string;
+  name: string;
+  transports: string[];
+  backup_eligible: boolean;
+  synced: boolean;
+  created_at: string;
+  last_used_at?: string;
+}
+
+export interface Identity {
+  id: string;
+  provider: string;
+  subject: string;
+  email: string;
+  created_at: string;
+}
+
+// WebAuthn options in their JSON form, for
+// PublicKeyCredential.parseCreationOptionsFromJSON and
+// parseRequestOptionsFromJSON
+export interface PasskeyOptions {
+  publicKey: Record<string, unknown>;
+}
+
+// Offline sync types
+export interface EntityChanges<T> {
+  upserts: T[];
+  deletes: string[];
+}
+
+export interface SyncResponse {
+  users: EntityChanges<User>;
+  sync_token: string;
+  has_more: boolean;
+  full: boolean;
+}
+
+// Operation receipt for asynchronous mutations
+export interface Operation {
+  id: string;
+  kind: string;
+  status: 'staged' | 'undone' | 'pending' | 'succeeded' | 'failed';
+  result?: unknown;
+  error?: string;
+  // Set while a staged operation can still be undone
+  undo_until?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+// Notification types
+export type NotificationChannel = 'email' | 'sms' | 'push' | 'in_app';
+
+export interface PushSubscriptionRecord {
+  id: string;
+  endpoint: string;
+  user_agent?: string;
+  created_at: string;
+  last_used_at: string | null;
+}
+
+export interface NotificationPreferences {
+  channels: Partial<Record<NotificationChannel, boolean>>;
+}
+
+// Upload types
+export interface Upload {
+  id: string;
+  filename: string;
+  content_type: string;
+  size: number;
+  sha256: string;
+  status: 'pending' | 'scanning' | 'clean' | 'infected';
+  scan?: {
+    engine?: string;
+    signature?: string;
+    error?: string;
+    scanned_at: string | null;
+  };
+  metadata?: {
+    sniffed_type: string;
+    width?: number;
+    height?: number;
+    duration_ms?: number;
+    extracted_at: string;
+  };
+  created_at: string;
+  updated_at: string;
+}
+
+// Assistant types
+export interface AssistMessage {
+  role: 'user' | 'assistant';
+  content: string;
+}
+
+export interface AssistDone {
+  usage: { input_tokens: number; output_tokens: number };
+  tokens_remaining: number;
+}
+
+// Streams the assistant's reply, passing each piece of text to onDelta. The
+// endpoint is a POST, so the event stream is read with fetch, not EventSource.
+async function streamAssist(
+  messages: AssistMessage[],
+  onDelta: (text: string) => void,
+  options?: { headers?: Record<string, string>; signal?: AbortSignal }
+): Promise<ApiResponse<AssistDone>> {
+  try {
+    const response = await fetch(`${API_BASE_URL}/api/v1/assist`, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        ...apiClient.authHeaders(),
+        ...options?.headers,
+      },
+      body: JSON.stringify({ messages }),
+      signal: options?.signal,
+    });
+    if (!response.ok || !response.body) {
+      const data = await response.json().catch(() => ({}));
+      return {
+        data: null as unknown as AssistDone,
+        error: errorMessage(data, response.status),
+      };
+    }
+
+    const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+    let buffer = '';
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) break;
+      buffer += value;
+
+      let end: number;
+      while ((end = buffer.indexOf('\n\n')) >= 0) {
+        const block = buffer.slice(0, end);
+        buffer = buffer.slice(end + 2);
+
+        let event = 'message';
+        let data = '';
+        for (const line of block.split('\n')) {
+          if (line.startsWith('event:')) event = line.slice(6).trim();
+          else if (line.startsWith('data:')) data += line.slice(5).trim();
+        }
+        if (!data) continue;
+
+        const payload = JSON.parse(data);
+        if (event === 'delta') onDelta(payload.text);
+        else if (event === 'done') return { data: payload as AssistDone };
+        else if (event === 'error') {
+          return { data: null as unknown as AssistDone, error: payload.error };
+        }
+      }
+    }
+    return {
+      data: null as unknown as AssistDone,
+      error: 'The assistant stream ended unexpectedly',
+    };
+  } catch (error) {
+    return {
+      data: null as unknown as AssistDone,
+      error:
+        error instanceof Error ? error.message : 'An unknown error occurred',
+    };
+  }
+}
+
+// Sends a file over the tus protocol in chunks, resuming from the server's
+// offset after a failed chunk. The finished upload has the session's ID.
+async function uploadResumable(
+  file: File,
+  options?: {
+    chunkSize?: number;
+    onProgress?: (sent: number, total: number) => void;
+    signal?: AbortSignal;
+  }
+): Promise<ApiResponse<Upload>> {
+  const chunkSize = options?.chunkSize ?? 5 << 20;
+  const tusHeaders = (method: string) => ({
+    'Tus-Resumable': '1.0.0',
+    ...apiClient.authHeaders(),
+    ...replayHeaders(method),
+  });
+  const encode = (value: string) =>
+    btoa(String.fromCharCode(...new TextEncoder().encode(value)));
+  const failed = async (response: Response) => {
+    const data = await response.json().catch(() => ({}));
+    return {
+      data: null as unknown as Upload,
+      error: errorMessage(data, response.status),
+    };
+  };
+
+  try {
+    const created = await fetch(`${API_BASE_URL}/api/v1/resumable-uploads`, {
+      method: 'POST',
+      headers: {
+        ...tusHeaders('POST'),
+        'Upload-Length': String(file.size),
+        'Upload-Metadata': [
+          `filename ${encode(file.name)}`,
+          `filetype ${encode(file.type || 'application/octet-stream')}`,
+        ].join(','),
+      },
+      signal: options?.signal,
+    });
+    const location = created.headers.get('Location');
+    if (!created.ok || !location) return failed(created);
+    const url = `${API_BASE_URL}${location}`;
+
+    let offset = 0;
+    let retries = 0;
+    while (offset < file.size) {
+      const response = await fetch(url, {
+        method: 'PATCH',
+        headers: {
+          ...tusHeaders('PATCH'),
+          'Content-Type': 'application/offset+octet-stream',
+          'Upload-Offset': String(offset),
+        },
+        body: file.slice(offset, offset + chunkSize),
+        signal: options?.signal,
+      }).catch((error) => {
+        if (options?.signal?.aborted) throw error;
+        return null;
+      });
+      if (response?.ok) {
+        offset = Number(response.headers.get('Upload-Offset'));
+        retries = 0;
+        options?.onProgress?.(offset, file.size);
+        continue;
+      }
+      if (response && response.status !== 409 && response.status < 500) {
+        return failed(response);
+      }
+      if (++retries > 3) {
+        return response
+          ? failed(response)
+          : {
+              data: null as unknown as Upload,
+              error: 'The upload was interrupted',
+            };
+      }
+      const head = await fetch(url, {
+        method: 'HEAD',
+        headers: tusHeaders('HEAD'),
+        signal: options?.signal,
+      });
+      if (!head.ok) return failed(head);
+      offset = Number(head.headers.get('Upload-Offset'));
+    }
+
+    const id = location.split('/').pop();
+    return apiClient.get<Upload>(`/api/v1/uploads/${id}`);
+  } catch (error) {
+    return {
+      data: null as unknown as Upload,
+      error:
+        error instanceof Error ? error.message : 'An unknown error occurred',
+    };
+  }
 }
 
 // API functions
 export const api = {
   health: () => apiClient.get<{ status: string }>('/health'),
 
+  auth: {
+    requestMagicLink: (email: string) =>
+      apiClient.post<{ message: string }>('/auth/magic-link', { email }),
+
+    // Call setAccessToken with the returned token. Opened on another device
+    // than it was requested from, this fails with 409 until retried with
+    // confirmDevice.
+    signInWithMagicLink: (token: string, confirmDevice = false) =>
+      apiClient.post<MagicLinkSession>('/auth/magic-link/callback', {
+        token,
+        confirm_device: confirmDevice,
+      }),
+
+    signOut: () => apiClient.delete<void>('/api/v1/auth/session'),
+
+    // Token from either link of an email change; needs no sign-in
+    confirmEmailChange: (token: string) =>
+      apiClient.post<EmailChange>('/auth/email-change/confirm', { token }),
+
+    // Post the credential navigator.credentials.create returns, as its
+    // toJSON() serializes it
+    passkeyRegistrationOptions: () =>
+      apiClient.post<PasskeyOptions>('/auth/webauthn/register/options', {}),
+
+    registerPasskey: (credential: unknown, name?: string) =>
+      apiClient.post<Passkey>('/auth/webauthn/register', { credential, name }),
+
+    // Without an email the browser offers any passkey for the site
+    passkeyLoginOptions: (email?: string) =>
+      apiClient.post<PasskeyOptions>('/auth/webauthn/login/options', { email }),
+
+    // Call setAccessToken with the returned token
+    signInWithPasskey: (credential: unknown) =>
+      apiClient.post<MagicLinkSession>('/auth/webauthn/login', { credential }),
+
+    listPasskeys: () =>
+      apiClient.get<{ passkeys: Passkey[] }>('/api/v1/me/passkeys'),
+
+    renamePasskey: (id: string, name: string) =>
+      apiClient.patch<Passkey>(`/api/v1/me/passkeys/${id}`, { name }),
+
+    deletePasskey: (id: string) =>
+      apiClient.delete<void>(`/api/v1/me/passkeys/${id}`),
+
+    listIdentities: () =>
+      apiClient.get<{ identities: Identity[] }>('/api/v1/me/identities'),
+
+    // token is an identity provider token for the identity to link
+    linkIdentity: (token: string) =>
+      apiClient.post<Identity>('/api/v1/me/identities', { token }),
+
+    unlinkIdentity: (id: string) =>
+      apiClient.delete<void>(`/api/v1/me/identities/${id}`),
+  },
+
   users: {
     list: (params?: { limit?: number; offset?: number }) =>
       apiClient.get<UsersListResponse>('/api/v1/users', params),
 
     getById: (id: string) => apiClient.get<User>(`/api/v1/users/${id}`),
+
+    updateProfile: (id: string, profile: UpdateProfileRequest) =>
+      apiClient.patch<User>(`/api/v1/users/${id}/profile`, profile),
+
+    // Previous handles redirect to the user's current one
+    getByHandle: (handle: string) =>
+      apiClient.get<User>(
+        `/api/v1/users/by-handle/${encodeURIComponent(handle)}`
+      ),
+
+    checkHandle: (handle: string) =>
+      apiClient.get<HandleAvailability>('/api/v1/users/handle-availability', {
+        handle,
+      }),
+
+    // Public; never reveals whether a value is in use
+    checkAvailability: (check: { email?: string; handle?: string }) =>
+      apiClient.post<AvailabilityCheck>('/auth/availability', check),
+
+    getHandle: (id: string) =>
+      apiClient.get<HandleInfo>(`/api/v1/users/${id}/handle`),
+
+    setHandle: (id: string, handle: string) =>
+      apiClient.put<User>(`/api/v1/users/${id}/handle`, { handle }),
+
+    requestEmailChange: (id: string, email: string) =>
+      apiClient.post<EmailChange>(`/api/v1/users/${id}/email-change`, {
+        email,
+      }),
+
+    getEmailChange: (id: string) =>
+      apiClient.get<EmailChange>(`/api/v1/users/${id}/email-change`),
+
+    cancelEmailChange: (id: string) =>
+      apiClient.delete<void>(`/api/v1/users/${id}/email-change`),
+
+    semanticSearch: (q: string, params?: { limit?: number }) =>
+      apiClient.get<SemanticSearchResponse>('/api/v1/search/semantic', {
+        q,
+        ...params,
+      }),
+  },
+
+  notifications: {
+    vapidPublicKey: () =>
+      apiClient.get<{ enabled: boolean; public_key: string }>(
+        '/api/v1/push/vapid-public-key'
+      ),
+
+    listSubscriptions: (userId: string) =>
+      apiClient.get<{ subscriptions: PushSubscriptionRecord[] }>(
+        `/api/v1/users/${userId}/push-subscriptions`
+      ),
+
+    subscribe: (userId: string, subscription: PushSubscriptionJSON) =>
+      apiClient.post<PushSubscriptionRecord>(
+        `/api/v1/users/${userId}/push-subscriptions`,
+        subscription
+      ),
+
+    unsubscribe: (userId: string, subscriptionId: string) =>
+      apiClient.delete<void>(
+        `/api/v1/users/${userId}/push-subscriptions/${subscriptionId}`
+      ),
+
+    getPreferences: (userId: string) =>
+      apiClient.get<NotificationPreferences>(
+        `/api/v1/users/${userId}/notification-preferences`
+      ),
+
+    updatePreferences: (userId: string, prefs: NotificationPreferences) =>
+      apiClient.put<NotificationPreferences>(
+        `/api/v1/users/${userId}/notification-preferences`,
+        prefs
+      ),
   },
+
+  uploads: {
+    getById: (id: string) => apiClient.get<Upload>(`/api/v1/uploads/${id}`),
+
+    contentUrl: (id: string) => `${API_BASE_URL}/api/v1/uploads/${id}/content`,
+
+    uploadResumable,
+  },
+
+  operations: {
+    getById: (id: string) =>
+      apiClient.get<Operation>(`/api/v1/operations/${id}`),
+
+    undo: (id: string) =>
+      apiClient.post<Operation>(`/api/v1/operations/${id}/undo`),
+
+    eventsUrl: () => `${API_BASE_URL}/api/v1/operations/events`,
+  },
+
+  assistant: {
+    ask: streamAssist,
+  },
+
+  sessions: {
+    listMine: () =>
+      apiClient.get<{ sessions: Session[] }>('/api/v1/sessions'),
+
+    securityEvents: () =>
+      apiClient.get<{ security_events: SecurityEvent[] }>(
+        '/api/v1/me/security-events'
+      ),
+  },
+
+  sync: (token?: string) =>
+    apiClient.get<SyncResponse>('/api/v1/sync', token ? { token } : undefined),
 };
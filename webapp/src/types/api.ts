@@ -12,3 +12,16 @@ export interface ApiError {
 export interface ErrorResponse {
   error: string;
 }
+
+// RFC 9457 problem details, sent as application/problem+json
+export interface Problem {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string;
+  code: string;
+  request_id?: string;
+  trace_id?: string;
+  errors?: { field: string; message: string }[];
+}